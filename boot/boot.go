@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"strings"
@@ -13,16 +14,32 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/nuohe369/crab/common"
+	"github.com/nuohe369/crab/common/audit"
+	"github.com/nuohe369/crab/common/authz"
 	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/ctxutil"
 	bizErrors "github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/export"
+	"github.com/nuohe369/crab/common/importer"
 	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/oauth"
 	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/common/service"
 	"github.com/nuohe369/crab/pkg"
 	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/crypto"
+	"github.com/nuohe369/crab/pkg/discovery"
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/graphql"
+	"github.com/nuohe369/crab/pkg/health"
+	"github.com/nuohe369/crab/pkg/jobs"
 	"github.com/nuohe369/crab/pkg/json"
 	"github.com/nuohe369/crab/pkg/logger"
 	"github.com/nuohe369/crab/pkg/metrics"
+	"github.com/nuohe369/crab/pkg/mq"
 	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/server"
 )
 
 // Module defines the interface that all modules must implement.
@@ -35,8 +52,9 @@ type Module interface {
 }
 
 var (
-	modules []Module
-	app     *fiber.App
+	modules       []Module
+	app           *fiber.App
+	activeService *config.Service // service being run by RunService, nil otherwise
 )
 
 // Register registers a module to the application.
@@ -44,7 +62,7 @@ func Register(m Module) {
 	modules = append(modules, m)
 }
 
-// App returns the Fiber application instance.
+// App returns the Fiber application instance; with RunServices, the app of the first service.
 func App() *fiber.App {
 	return app
 }
@@ -73,21 +91,43 @@ func initBase() {
 	if secretKey != "" {
 		config.SetDecryptKey(secretKey)
 	}
-	config.MustLoad("config.toml")
+	config.MustLoad(configFiles...)
+	initInfra()
+}
 
+// initInfra initializes the logger, pkg infrastructure and common layer from the loaded configuration.
+func initInfra() {
 	// Initialize logger configuration | 初始化日志器配置
 	logger.SetConfig(config.GetLogger())
 
+	// Keys of encrypted model columns | 加密模型列的密钥
+	if field := config.GetFieldEncryption(); len(field.Keys) > 0 {
+		if err := crypto.InitFieldKeys(field); err != nil {
+			log.Fatalf("Field encryption initialization failed: %v", err)
+		}
+	}
+
 	// Build pkg.Config from common/config
 	snowflakeCfg := config.GetSnowflake()
 	pkgCfg := pkg.Config{
 		SnowflakeMachineID: snowflakeCfg.MachineID,
 		Databases:          config.GetDatabases(),
 		Redis:              config.GetRedisInstances(),
+		DBCache:            config.GetDBCache(),
 		MQ:                 config.GetMQ(),
+		Jobs:               config.GetJobs(),
+		CDC:                config.GetCDC(),
 		JWT:                config.GetJWT(),
 		Metrics:            config.GetMetrics(),
+		Breaker:            config.GetBreaker(),
+		Bulkhead:           config.GetBulkhead(),
+		HTTPClient:         config.GetHTTPClient(),
+		Discovery:          config.GetDiscovery(),
+		Email:              config.GetEmail(),
+		SMS:                config.GetSMS(),
 		Storage:            config.GetStorage(),
+		Search:             config.GetSearch(),
+		Time:               config.GetTime(),
 		Trace:              config.GetTrace(),
 	}
 
@@ -101,9 +141,13 @@ func migrateModels(targetModules []Module) {
 		return
 	}
 
-	// Collect all models from modules (deduplicated)
+	// Collect all models from common layer and modules (deduplicated)
 	seen := make(map[string]bool)
 	var models []any
+	for _, md := range common.Models() {
+		seen[fmt.Sprintf("%T", md)] = true
+		models = append(models, md)
+	}
 	for _, m := range targetModules {
 		for _, md := range m.Models() {
 			key := fmt.Sprintf("%T", md)
@@ -170,12 +214,31 @@ func migrateModels(targetModules []Module) {
 		if err := db.Engine().Sync2(mds...); err != nil {
 			log.Fatalf("Database migration failed: %v", err)
 		}
+		// Sync creates geography fields as text, convert them | Sync 将 geography 字段创建为 text，需要转换
+		for _, md := range mds {
+			if gm, ok := md.(model.GeoModel); ok {
+				if err := model.EnsureGeoColumns(context.Background(), gm); err != nil {
+					log.Fatalf("Database migration failed: %v", err)
+				}
+			}
+		}
 		totalMigrated += len(mds)
 	}
 
 	log.Printf("Migrated %d models across %d databases", totalMigrated, len(dbGroups))
 }
 
+// autoMigrateEnabled reports whether any database has auto_migrate enabled
+// autoMigrateEnabled 判断是否有数据库启用了 auto_migrate
+func autoMigrateEnabled() bool {
+	for _, dbCfg := range config.GetDatabases() {
+		if dbCfg.AutoMigrate {
+			return true
+		}
+	}
+	return false
+}
+
 // initAfterMigrate performs initialization after database migration.
 func initAfterMigrate() {
 	log.Println("Initialization completed")
@@ -188,78 +251,112 @@ func Run(addr string) {
 
 // RunModules starts the specified modules. If moduleNames is nil or empty, all modules are started.
 func RunModules(moduleNames []string, addr string) {
-	app = fiber.New(fiber.Config{
-		DisableStartupMessage: true, // Disable Fiber's default startup message | 禁用 Fiber 的默认启动消息
-		JSONEncoder:           json.Marshal,
-		JSONDecoder:           json.Unmarshal,
-		ErrorHandler:          customErrorHandler, // Custom error handler | 自定义错误处理器
-		
-		// High concurrency configuration | 高并发配置
-		Prefork:               false,                // Multi-process mode (enable in production) | 多进程模式（生产环境可开启）
-		ReadBufferSize:        8192,                 // Read buffer size | 读缓冲区大小
-		WriteBufferSize:       8192,                 // Write buffer size | 写缓冲区大小
-		ReadTimeout:           10 * time.Second,     // Read timeout | 读超时
-		WriteTimeout:          10 * time.Second,     // Write timeout | 写超时
-		IdleTimeout:           120 * time.Second,    // Idle timeout | 空闲超时
-		BodyLimit:             4 * 1024 * 1024,      // 4MB body limit | 4MB 请求体限制
-		Concurrency:           256 * 1024,           // Max concurrent connections | 最大并发连接数
-		DisableKeepalive:      false,                // Keep-alive enabled | 启用长连接
-		ReduceMemoryUsage:     false,                // High performance mode | 高性能模式
-	})
+	initBase()
 
+	runListeners([]*listener{{service: activeService, addr: addr, modules: selectModules(moduleNames)}})
+}
+
+// RunServices starts several services defined in the configuration file in one process.
+// Each service gets its own Fiber app on its own address; infrastructure is shared.
+// RunServices 在一个进程中启动配置文件中定义的多个服务
+// 每个服务在自己的地址上拥有独立的 Fiber 应用，基础设施共享。
+func RunServices(serviceNames []string) {
 	initBase()
 
-	// Register global middleware
-	middleware.Setup(app)
+	listeners, err := planServices(serviceNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Starting services: %s", strings.Join(serviceNames, ", "))
 
-	// Register metrics middleware and routes
-	if metrics.Enabled() {
-		app.Use(metrics.Middleware())
-		app.Get(metrics.Path(), metrics.Handler())
+	runListeners(listeners)
+}
+
+// planServices builds one listener per service, rejecting shared addresses and modules
+// planServices 为每个服务构建一个监听，拒绝共用地址和模块
+func planServices(serviceNames []string) ([]*listener, error) {
+	if config.GetServer().Prefork {
+		return nil, errors.New("prefork is not supported when running several services in one process")
 	}
 
-	// Determine which modules to start
-	var targetModules []Module
-	if len(moduleNames) == 0 {
-		targetModules = modules
-	} else {
-		nameSet := make(map[string]bool)
-		for _, name := range moduleNames {
-			nameSet[name] = true
+	var listeners []*listener
+	owner := make(map[string]string) // module -> service
+	addrs := make(map[string]string) // addr -> service
+	for _, name := range serviceNames {
+		svc := config.GetService(name)
+		if svc == nil {
+			return nil, fmt.Errorf("service %s is not defined in configuration file", name)
 		}
-		for _, m := range modules {
-			if nameSet[m.Name()] {
-				targetModules = append(targetModules, m)
+		if other, ok := addrs[svc.Addr]; ok {
+			return nil, fmt.Errorf("services %s and %s both listen on %s", other, name, svc.Addr)
+		}
+		addrs[svc.Addr] = name
+
+		l := &listener{service: svc, addr: svc.Addr, modules: selectModules(svc.Modules)}
+		// A module is initialized and started once, so it can only serve one service
+		// 模块只初始化和启动一次，因此只能属于一个服务
+		for _, m := range l.modules {
+			if other, ok := owner[m.Name()]; ok {
+				return nil, fmt.Errorf("module %s is in both services %s and %s", m.Name(), other, name)
 			}
+			owner[m.Name()] = name
 		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("no services to start")
 	}
+	return listeners, nil
+}
+
+// listener is one Fiber app serving a group of modules on its own address
+// listener 是在独立地址上为一组模块提供服务的 Fiber 应用
+type listener struct {
+	service *config.Service // nil when started by module list | 按模块列表启动时为 nil
+	addr    string
+	app     *fiber.App
+	modules []Module
+	ln      net.Listener // Bound by bindListeners, nil in prefork mode | 由 bindListeners 绑定，prefork 模式下为 nil
+	tls     *server.TLS  // nil when [server.tls] is disabled | 未启用 [server.tls] 时为 nil
+}
 
-	if len(targetModules) == 0 {
-		log.Fatal("No modules found to start")
+// name returns the service name used for logs and service discovery
+// name 返回用于日志和服务发现的服务名
+func (l *listener) name() string {
+	if l.service != nil {
+		return l.service.Name
 	}
+	return config.GetApp().Name
+}
 
+// runListeners migrates, initializes and starts the modules of every listener, then serves them (blocking).
+// runListeners 为每个监听迁移、初始化并启动模块，然后开始服务（阻塞）
+func runListeners(listeners []*listener) {
 	// Validate module dependencies and filter out modules with missing dependencies
 	// 验证模块依赖并过滤掉缺少依赖的模块
 	strictMode := config.IsStrictDependencyCheck()
-	targetModules = ValidateAndFilterModules(targetModules, strictMode)
-
-	if len(targetModules) == 0 {
-		log.Fatal("❌ No modules available to start after dependency validation")
-	}
-
-	// Migrate models declared by modules
-	databases := config.GetDatabases()
-	// Check if any database has auto_migrate enabled
-	autoMigrate := false
-	for _, dbCfg := range databases {
-		if dbCfg.AutoMigrate {
-			autoMigrate = true
-			break
+	var targetModules []Module
+	for _, l := range listeners {
+		if len(l.modules) == 0 {
+			log.Fatalf("No modules found to start for %s", l.name())
+		}
+		for _, m := range l.modules {
+			setModuleStatus(m.Name(), ModuleSkipped)
+		}
+		l.modules = ValidateAndFilterModules(l.modules, strictMode)
+		if len(l.modules) == 0 {
+			log.Fatalf("❌ No modules available to start for %s after dependency validation", l.name())
 		}
+		targetModules = append(targetModules, l.modules...)
 	}
 
-	if autoMigrate {
+	// Migrate models declared by modules
+	if autoMigrateEnabled() {
 		migrateModels(targetModules)
+		// Development databases get the baseline data right away | 开发环境数据库立即获得基础数据
+		if config.IsDev() {
+			runSeeds(targetModules, nil, false)
+		}
 	} else {
 		log.Println("Database auto migration is disabled")
 	}
@@ -267,14 +364,40 @@ func RunModules(moduleNames []string, addr string) {
 	// Post-migration initialization
 	initAfterMigrate()
 
-	// Initialize modules
-	for _, m := range targetModules {
-		group := app.Group("/" + m.Name())
-		ctx := NewModuleContext(group, nil)
-		if err := m.Init(ctx); err != nil {
-			log.Fatalf("Module %s initialization failed: %v", m.Name(), err)
+	// Create one app per listener, framework endpoints and modules register on the current app
+	// 每个监听创建一个应用，框架接口和模块注册到当前应用
+	for _, l := range listeners {
+		l.app = newApp()
+		app = l.app
+
+		// Register global middleware and framework endpoints | 注册全局中间件和框架接口
+		toggles := l.service.Toggles()
+		setupApp(toggles)
+
+		// Initialize modules
+		for _, m := range l.modules {
+			group := app.Group("/" + m.Name())
+			ctx := NewModuleContext(group, nil)
+			ctx.Toggles = toggles
+			if err := m.Init(ctx); err != nil {
+				log.Fatalf("Module %s initialization failed: %v", m.Name(), err)
+			}
+			log.Printf("Module %s initialized", m.Name())
+		}
+	}
+	// Build the GraphQL schema from the fragments registered by modules | 使用模块注册的片段构建 GraphQL schema
+	if graphql.GetConfig().Path != "" {
+		if err := graphql.Build(); err != nil {
+			log.Fatalf("GraphQL schema build failed: %v", err)
 		}
-		log.Printf("Module %s initialized", m.Name())
+	}
+	// App() returns the first listener's app | App() 返回第一个监听的应用
+	app = listeners[0].app
+
+	// Bind every address before starting modules or registering with service discovery
+	// 在启动模块或注册服务发现之前绑定所有地址
+	if err := bindListeners(listeners); err != nil {
+		log.Fatalf("Server failed to start: %v", err)
 	}
 
 	// Start modules
@@ -282,22 +405,167 @@ func RunModules(moduleNames []string, addr string) {
 		if err := m.Start(); err != nil {
 			log.Fatalf("Module %s start failed: %v", m.Name(), err)
 		}
+		setModuleStatus(m.Name(), ModuleRunning)
 		log.Printf("Module %s started", m.Name())
 	}
 
 	// Start cron scheduler
 	cron.Start()
 
-	// Print startup information | 打印启动信息
-	printStartupInfo(addr, targetModules)
+	// Start email dispatcher (when [email.queue] is enabled) | 启动邮件调度器（启用 [email.queue] 时）
+	email.StartDispatcher()
+
+	// Start background job workers (when [mq] is configured) | 启动后台任务工作协程（配置 [mq] 时）
+	jobs.Start()
+
+	// Start audit consumer (when [audit] async is enabled with mq) | 启动审计消费者（启用 [audit] async 且配置 mq 时）
+	audit.Start()
+
+	// Start consumers registered with mq.RegisterConsumer | 启动通过 mq.RegisterConsumer 注册的消费者
+	mq.StartConsumers()
+
+	// Publish recurring mq schedules | 发布周期性 mq 计划
+	mq.StartScheduler()
+
+	for _, l := range listeners {
+		// Print startup information | 打印启动信息
+		printStartupInfo(l)
+
+		// Register with service discovery once listening | 开始监听后注册到服务发现
+		setupDiscovery(l)
+	}
+
+	// Reload logger configuration on SIGHUP | 收到 SIGHUP 时重载日志器配置
+	setupLogReload()
 
 	// Setup graceful shutdown | 设置优雅关闭
-	setupGracefulShutdown(targetModules)
+	setupGracefulShutdown(listeners, targetModules)
+
+	// Start HTTP servers, HTTPS when [server.tls] is enabled (blocking) | 启动 HTTP 服务器，启用 [server.tls] 时为 HTTPS（阻塞）
+	for _, l := range listeners[1:] {
+		go func(l *listener) {
+			if err := listen(l, false); err != nil {
+				discovery.Close() // Deregister every service before exiting | 退出前注销所有服务
+				log.Fatalf("Server %s failed: %v", l.name(), err)
+			}
+		}(l)
+	}
+	if err := listen(listeners[0], true); err != nil {
+		discovery.Close()
+		log.Fatalf("Server failed: %v", err)
+	}
+}
 
-	// Start HTTP server (blocking)
-	if err := app.Listen(addr); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+// newApp creates the Fiber application with the framework settings.
+// newApp 使用框架设置创建 Fiber 应用
+func newApp() *fiber.App {
+	// [server] overrides the tuning defaults below | [server] 覆盖以下调优默认值
+	return fiber.New(config.GetServer().Fiber(fiber.Config{
+		DisableStartupMessage: true, // Disable Fiber's default startup message | 禁用 Fiber 的默认启动消息
+		JSONEncoder:           json.Marshal,
+		JSONDecoder:           json.Unmarshal,
+		ErrorHandler:          customErrorHandler, // Custom error handler | 自定义错误处理器
+
+		// High concurrency configuration | 高并发配置
+		Prefork:           false,                   // Multi-process mode ([server] prefork) | 多进程模式（[server] prefork）
+		ReadBufferSize:    8192,                    // Read buffer size | 读缓冲区大小
+		WriteBufferSize:   8192,                    // Write buffer size | 写缓冲区大小
+		ReadTimeout:       10 * time.Second,        // Read timeout | 读超时
+		WriteTimeout:      10 * time.Second,        // Write timeout | 写超时
+		IdleTimeout:       120 * time.Second,       // Idle timeout | 空闲超时
+		BodyLimit:         server.DefaultBodyLimit, // 4MB body limit | 4MB 请求体限制
+		Concurrency:       256 * 1024,              // Max concurrent connections | 最大并发连接数
+		DisableKeepalive:  false,                   // Keep-alive enabled | 启用长连接
+		ReduceMemoryUsage: false,                   // High performance mode | 高性能模式
+	}))
+}
+
+// setupApp registers global middleware and the framework endpoints enabled in config.
+// setupApp 注册全局中间件和配置中启用的框架接口
+func setupApp(toggles middleware.Toggles) {
+	// Register global middleware
+	middleware.Setup(app, toggles)
+
+	// Register metrics middleware and routes
+	if metrics.Enabled() {
+		app.Use(metrics.Middleware())
+		app.Get(metrics.Path(), metrics.Handler())
+	}
+
+	// Register runtime log level endpoint (when [logger] admin_path is set) | 注册运行时日志级别接口（设置 [logger] admin_path 时）
+	setupLogAdmin()
+
+	// Register pprof and runtime debug endpoints (when [debug] enabled or env = dev) | 注册 pprof 和运行时调试接口（启用 [debug] 或 env = dev 时）
+	setupDebug()
+
+	// Register audit log query endpoints (when [audit] admin_path is set) | 注册审计日志查询接口（设置 [audit] admin_path 时）
+	if auditCfg := config.GetAudit(); auditCfg.AdminPath != "" {
+		audit.RegisterRoutes(app.Group(auditCfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(auditCfg.Permission)))
+	}
+
+	// Register maintenance toggle endpoints (when [maintenance] admin_path is set) | 注册维护模式开关接口（设置 [maintenance] admin_path 时）
+	setupMaintenanceAdmin()
+
+	// Register feature flag CRUD endpoints (when [featureflag] admin_path is set) | 注册功能开关管理接口（设置 [featureflag] admin_path 时）
+	setupFeatureFlagAdmin()
+
+	// Register quota usage and limit endpoints (when [quota] admin_path is set) | 注册配额用量和限额管理接口（设置 [quota] admin_path 时）
+	setupQuotaAdmin()
+
+	// Register background job dashboard (when [jobs] admin_path is set) | 注册后台任务面板（设置 [jobs] admin_path 时）
+	setupJobsAdmin()
+
+	// Register webhook subscription and redelivery endpoints (when [webhook] admin_path is set) | 注册 Webhook 订阅和重新投递接口（设置 [webhook] admin_path 时）
+	setupWebhookAdmin()
+
+	// Register payment notification endpoint (when [payment] notify_path is set) | 注册支付通知接口（设置 [payment] notify_path 时）
+	setupPaymentNotify()
+
+	// Register topic management endpoints (when [mq] admin_path is set) | 注册主题管理接口（设置 [mq] admin_path 时）
+	setupMQAdmin()
+
+	// Register export endpoints (when [export] path is set) | 注册导出接口（设置 [export] path 时）
+	if exportCfg := config.GetExport(); exportCfg.Path != "" {
+		export.RegisterRoutes(app.Group(exportCfg.Path, middleware.RequireAuth()))
+	}
+
+	// Register import endpoints (when [import] path is set) | 注册导入接口（设置 [import] path 时）
+	if importCfg := config.GetImport(); importCfg.Path != "" {
+		importer.RegisterRoutes(app.Group(importCfg.Path, middleware.RequireAuth()))
 	}
+
+	// Register social login endpoints (when [oauth] path is set) | 注册社交登录接口（设置 [oauth] path 时）
+	if oauthCfg := config.GetOAuth(); oauthCfg.Path != "" {
+		oauth.RegisterRoutes(app.Group(oauthCfg.Path))
+	}
+
+	// Register the GraphQL endpoint (when [graphql] path is set), the schema is built after module Init
+	// 注册 GraphQL 接口（设置 [graphql] path 时），schema 在模块 Init 之后构建
+	if gqlCfg := graphql.GetConfig(); gqlCfg.Path != "" {
+		auth := middleware.RequireAuth(gqlCfg.Plats...)
+		app.Get(gqlCfg.Path, auth, graphql.Handler())
+		app.Post(gqlCfg.Path, auth, graphql.Handler())
+	}
+}
+
+// selectModules returns the named modules, or all modules if moduleNames is empty.
+// selectModules 返回指定的模块，moduleNames 为空时返回所有模块
+func selectModules(moduleNames []string) []Module {
+	var targetModules []Module
+	if len(moduleNames) == 0 {
+		targetModules = modules
+	} else {
+		nameSet := make(map[string]bool)
+		for _, name := range moduleNames {
+			nameSet[name] = true
+		}
+		for _, m := range modules {
+			if nameSet[m.Name()] {
+				targetModules = append(targetModules, m)
+			}
+		}
+	}
+	return targetModules
 }
 
 // RunService starts a service by name as defined in the configuration file.
@@ -306,7 +574,7 @@ func RunService(serviceName string, addrOverride string) {
 	if secretKey != "" {
 		config.SetDecryptKey(secretKey)
 	}
-	config.MustLoad("config.toml")
+	config.MustLoad(configFiles...)
 
 	svc := config.GetService(serviceName)
 	if svc == nil {
@@ -319,6 +587,7 @@ func RunService(serviceName string, addrOverride string) {
 	}
 
 	log.Printf("Starting service: %s", serviceName)
+	activeService = svc
 
 	RunModules(svc.Modules, addr)
 }
@@ -333,16 +602,28 @@ func getModuleNames(mods []Module) []string {
 
 // printStartupInfo prints server startup information using our logger
 // printStartupInfo 使用我们的日志器打印服务器启动信息
-func printStartupInfo(addr string, modules []Module) {
+func printStartupInfo(l *listener) {
 	serverLog := logger.NewSystem("server")
 
 	appCfg := config.GetApp()
 
 	serverLog.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	serverLog.Info("🚀 %s v%s", appCfg.Name, appCfg.Version)
-	serverLog.Info("📍 Address: %s", addr)
-	serverLog.Info("📦 Modules: %s", strings.Join(getModuleNames(modules), ", "))
-	serverLog.Info("🔧 Handlers: %d", app.HandlersCount())
+	if l.service != nil {
+		serverLog.Info("🧩 Service: %s", l.service.Name)
+	}
+	serverLog.Info("📍 Address: %s", l.addr)
+	if h2 := config.GetServer().HTTP2Addr; h2 != "" {
+		serverLog.Info("⚡ HTTP/2: %s", h2)
+	}
+	if tlsCfg := config.GetServer().TLS; tlsCfg.Enabled {
+		serverLog.Info("🔒 HTTPS: enabled")
+		if tlsCfg.RedirectAddr != "" {
+			serverLog.Info("↪️  HTTP redirect: %s", tlsCfg.RedirectAddr)
+		}
+	}
+	serverLog.Info("📦 Modules: %s", strings.Join(getModuleNames(l.modules), ", "))
+	serverLog.Info("🔧 Handlers: %d", l.app.HandlersCount())
 	serverLog.Info("🆔 PID: %d", os.Getpid())
 	serverLog.Info("🌍 Environment: %s", appCfg.Env)
 
@@ -366,42 +647,70 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		// Get HTTP status code from business error code | 从业务错误码获取 HTTP 状态码
 		statusCode := getHTTPStatusCode(bizErr.Code)
 		c.Status(statusCode)
-		
-		return c.JSON(response.Response{
-			Code: bizErr.Code,
-			Msg:  bizErr.Msg,
-		})
+
+		// Log details server-side, clients only get the safe message | 详情仅写入服务端日志，客户端只获得安全消息
+		if statusCode >= fiber.StatusInternalServerError || bizErr.Err != nil {
+			logBizError(c, err, bizErr, statusCode)
+		}
+
+		return response.Write(c, bizErr.Code, bizErr.Localize(response.Lang(c)), nil)
 	}
 
 	// Check if it's a Fiber error | 检查是否为 Fiber 错误
 	var fiberErr *fiber.Error
 	if errors.As(err, &fiberErr) {
 		c.Status(fiberErr.Code)
-		
+
 		// Map common Fiber errors to business error codes | 映射常见 Fiber 错误到业务错误码
 		code := mapFiberErrorCode(fiberErr.Code)
-		return c.JSON(response.Response{
-			Code: code,
-			Msg:  fiberErr.Message,
-		})
+		return response.Write(c, code, fiberErr.Message, nil)
 	}
 
 	// Unknown error, return 500 | 未知错误，返回 500
 	c.Status(fiber.StatusInternalServerError)
-	
+
 	// Log the error for debugging | 记录错误用于调试
 	serverLog := logger.NewSystem("server")
 	serverLog.Error("Unhandled error: %v", err)
-	
-	return c.JSON(response.Response{
-		Code: response.CodeServerError,
-		Msg:  response.CodeServerError.Msg(),
-	})
+
+	return response.Write(c, response.CodeServerError, response.CodeServerError.MsgLang(response.Lang(c)), nil)
+}
+
+// logBizError logs the error chain with its fields and stack
+// logBizError 记录错误链及其字段和调用栈
+func logBizError(c *fiber.Ctx, err error, bizErr *bizErrors.BizError, status int) {
+	fields := logger.Fields{
+		"code":   bizErr.Code,
+		"status": status,
+		"method": c.Method(),
+		"path":   c.Path(),
+		"chain":  bizErrors.Chain(err),
+	}
+	if id := ctxutil.RequestID(c.UserContext()); id != "" {
+		fields["request_id"] = id
+	}
+	if extra := bizErr.Fields(); len(extra) > 0 {
+		fields["fields"] = extra
+	}
+	if stack := bizErr.Stack(); stack != "" {
+		fields["stack"] = stack
+	}
+
+	level := logger.WARN
+	if status >= fiber.StatusInternalServerError {
+		level = logger.ERROR
+	}
+	logger.NewSystem("server").LogFields(c.UserContext(), level, bizErr.Msg, fields)
 }
 
 // getHTTPStatusCode maps business error codes to HTTP status codes
 // getHTTPStatusCode 将业务错误码映射到 HTTP 状态码
 func getHTTPStatusCode(code response.Code) int {
+	// Codes registered with an explicit status | 注册时指定了状态码的错误码
+	if status, ok := response.HTTPStatus(code); ok {
+		return status
+	}
+
 	switch {
 	// Success | 成功
 	case code == response.CodeSuccess:
@@ -414,29 +723,60 @@ func getHTTPStatusCode(code response.Code) int {
 		return fiber.StatusUnauthorized
 
 	// Authorization errors (403) | 授权错误 (403)
-	case code == response.CodeForbid:
+	case code == response.CodeForbid,
+		code == response.CodeOrgPermissionDenied,
+		code == response.CodeOrgNotMember,
+		code == response.CodeOrgDisabled,
+		code == response.CodeOrgCannotRemoveOwner,
+		code == response.CodeOrgOwnerCannotLeave,
+		code == response.CodeOrgCannotInviteOwner,
+		code == response.CodeOAuthDenied,
+		code == response.CodeOAuthNotLinked:
 		return fiber.StatusForbidden
 
 	// Not found errors (404) | 未找到错误 (404)
 	case code == response.CodeNotFound,
-		code == response.CodeUserNotFound:
+		code == response.CodeUserNotFound,
+		code == response.CodeOrgNotFound,
+		code == response.CodeOrgTargetNotMember,
+		code == response.CodeOrgUserNotFound,
+		code == response.CodeOAuthProviderUnknown:
 		return fiber.StatusNotFound
 
 	// Parameter errors (400) | 参数错误 (400)
 	case code == response.CodeParamError,
 		code == response.CodeParamMissing,
-		code == response.CodeParamInvalid:
+		code == response.CodeParamInvalid,
+		code == response.CodeOrgNotSelected,
+		code == response.CodeOrgInviteInvalid,
+		code == response.CodeOAuthStateInvalid:
 		return fiber.StatusBadRequest
 
 	// Conflict errors (409) | 冲突错误 (409)
 	case code == response.CodeDuplicate,
-		code == response.CodeUserExists:
+		code == response.CodeUserExists,
+		code == response.CodeOrgCodeExists,
+		code == response.CodeOrgMemberExists,
+		code == response.CodeOrgAlreadyMember,
+		code == response.CodeOrgMemberFull,
+		code == response.CodeOrgMemberLimit,
+		code == response.CodeOAuthLinkedOther:
 		return fiber.StatusConflict
 
-	// Rate limiting (429) | 限流 (429)
-	case code == response.CodeTooManyRequests:
+	// Used or expired invitations (410) | 已使用或已过期的邀请 (410)
+	case code == response.CodeOrgInviteExpired,
+		code == response.CodeOrgInviteUsed:
+		return fiber.StatusGone
+
+	// Rate limiting and quotas (429) | 限流和配额 (429)
+	case code == response.CodeTooManyRequests,
+		code == response.CodeQuotaExceeded:
 		return fiber.StatusTooManyRequests
 
+	// Maintenance (503) | 维护中 (503)
+	case code == response.CodeServiceUnavailable:
+		return fiber.StatusServiceUnavailable
+
 	// Server errors (500) | 服务器错误 (500)
 	case code == response.CodeServerError,
 		code == response.CodeDBError,
@@ -447,6 +787,10 @@ func getHTTPStatusCode(code response.Code) int {
 	case code >= 4000 && code < 5000:
 		return fiber.StatusUnprocessableEntity
 
+	// Module codes registered without a status (422) | 未指定状态码的模块错误码 (422)
+	case code >= response.ModuleCodeMin:
+		return fiber.StatusUnprocessableEntity
+
 	// Default to 500 for unknown errors | 未知错误默认返回 500
 	default:
 		return fiber.StatusInternalServerError
@@ -457,7 +801,7 @@ func getHTTPStatusCode(code response.Code) int {
 // mapFiberErrorCode 将 Fiber HTTP 状态码映射到业务错误码
 func mapFiberErrorCode(statusCode int) response.Code {
 	switch statusCode {
-	case fiber.StatusBadRequest:
+	case fiber.StatusBadRequest, fiber.StatusRequestEntityTooLarge:
 		return response.CodeParamError
 	case fiber.StatusUnauthorized:
 		return response.CodeUnauth
@@ -471,14 +815,34 @@ func mapFiberErrorCode(statusCode int) response.Code {
 		return response.CodeTooManyRequests
 	case fiber.StatusInternalServerError:
 		return response.CodeServerError
+	case fiber.StatusServiceUnavailable:
+		return response.CodeServiceUnavailable
 	default:
 		return response.CodeError
 	}
 }
 
+// setupDiscovery mounts the health routes checked by the registry and registers the
+// listener's service once the server is listening; it does nothing without [discovery]
+// setupDiscovery 挂载注册中心检查的健康路由，并在服务器开始监听后注册该监听的服务；未配置 [discovery] 时不做任何事
+func setupDiscovery(l *listener) {
+	if discovery.Get() == nil {
+		return
+	}
+	health.RegisterFiberRoutes(l.app, "/health")
+
+	name := l.name()
+	l.app.Hooks().OnListen(func(fiber.ListenData) error {
+		if err := discovery.RegisterService(context.Background(), name, l.addr); err != nil {
+			logger.NewSystem("server").Error("Service discovery registration failed: %v", err)
+		}
+		return nil
+	})
+}
+
 // setupGracefulShutdown sets up graceful shutdown for the application
 // setupGracefulShutdown 为应用程序设置优雅关闭
-func setupGracefulShutdown(targetModules []Module) {
+func setupGracefulShutdown(listeners []*listener, targetModules []Module) {
 	// Create channel to listen for interrupt signals | 创建通道监听中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
@@ -491,28 +855,64 @@ func setupGracefulShutdown(targetModules []Module) {
 		serverLog.Info("Starting graceful shutdown...")
 
 		// Create shutdown context with timeout | 创建带超时的关闭上下文
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		timeout := config.GetServer().ShutdownTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
-		// Shutdown HTTP server | 关闭 HTTP 服务器
+		// Deregister first so callers stop picking this instance | 先注销，使调用方不再选择本实例
+		discovery.Close()
+
+		// End SSE streams, they would otherwise hold the HTTP shutdown until the timeout | 结束 SSE 流，否则它们会使 HTTP 关闭等待到超时
+		service.CloseSSE()
+
+		// Let in-flight requests finish before the servers cancel their contexts, new ones get 503
+		// 在服务器取消请求 context 之前等待进行中的请求完成，新请求返回 503
+		serverLog.Info("Draining %d in-flight requests...", middleware.ActiveRequests())
+		if err := middleware.Drain(ctx); err != nil {
+			serverLog.Warn("Drain timed out with %d requests in flight", middleware.ActiveRequests())
+		}
+
+		// Shutdown HTTP servers | 关闭 HTTP 服务器
 		serverLog.Info("Shutting down HTTP server...")
-		if err := app.ShutdownWithContext(ctx); err != nil {
-			serverLog.Error("HTTP server shutdown error: %v", err)
-		} else {
-			serverLog.Info("HTTP server stopped")
+		for _, l := range listeners {
+			if err := l.app.ShutdownWithContext(ctx); err != nil {
+				serverLog.Error("HTTP server %s shutdown error: %v", l.addr, err)
+			} else {
+				serverLog.Info("HTTP server %s stopped", l.addr)
+			}
 		}
+		shutdownExtra(ctx)
 
 		// Stop cron scheduler | 停止定时任务调度器
 		serverLog.Info("Stopping cron scheduler...")
 		cron.Stop()
 		serverLog.Info("Cron scheduler stopped")
 
+		// Stop email dispatcher | 停止邮件调度器
+		email.StopDispatcher()
+
+		// Stop publishing mq schedules | 停止发布 mq 计划
+		mq.StopScheduler()
+
+		// Stop registered consumers, waiting for in-flight messages | 停止已注册的消费者，等待正在处理的消息
+		mq.StopConsumers(ctx)
+
+		// Stop background job workers, waiting for in-flight jobs | 停止后台任务工作协程，等待正在处理的任务
+		jobs.Stop()
+
+		// Stop audit consumer | 停止审计消费者
+		audit.Stop()
+
 		// Stop modules | 停止模块
 		serverLog.Info("Stopping modules...")
 		for _, m := range targetModules {
 			if err := m.Stop(); err != nil {
 				serverLog.Error("Module %s stop error: %v", m.Name(), err)
 			} else {
+				setModuleStatus(m.Name(), ModuleStopped)
 				serverLog.Info("Module %s stopped", m.Name())
 			}
 		}
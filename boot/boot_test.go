@@ -0,0 +1,141 @@
+package boot
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "boot-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// loadConfig installs data as the global configuration
+func loadConfig(t *testing.T, data string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := config.Load(path); err != nil {
+		t.Fatalf("config.Load failed: %v", err)
+	}
+}
+
+// stubModule is a module that does nothing
+type stubModule string
+
+func (m stubModule) Name() string                  { return string(m) }
+func (m stubModule) Models() []any                 { return nil }
+func (m stubModule) Init(ctx *ModuleContext) error { return nil }
+func (m stubModule) Start() error                  { return nil }
+func (m stubModule) Stop() error                   { return nil }
+
+// useModules replaces the registered modules for the test
+func useModules(t *testing.T, names ...string) {
+	t.Helper()
+	prev := modules
+	t.Cleanup(func() { modules = prev })
+	modules = nil
+	for _, name := range names {
+		Register(stubModule(name))
+	}
+}
+
+func TestPlanServices(t *testing.T) {
+	useModules(t, "user", "order", "admin")
+	loadConfig(t, `
+[[services]]
+name = "api"
+addr = ":8080"
+modules = ["user", "order"]
+
+[[services]]
+name = "backoffice"
+addr = ":8081"
+modules = ["admin"]
+
+[[services]]
+name = "orders"
+addr = ":8082"
+modules = ["order"]
+
+[[services]]
+name = "shadow"
+addr = ":8080"
+modules = ["admin"]
+`)
+
+	listeners, err := planServices([]string{"api", "backoffice"})
+	if err != nil {
+		t.Fatalf("planServices failed: %v", err)
+	}
+	if len(listeners) != 2 || len(listeners[0].modules) != 2 || listeners[1].addr != ":8081" {
+		t.Errorf("Unexpected listeners: %+v", listeners)
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		want     string
+	}{
+		{"module in two services", []string{"api", "orders"}, "module order is in both services api and orders"},
+		{"shared address", []string{"api", "shadow"}, "services api and shadow both listen on :8080"},
+		{"unknown service", []string{"api", "missing"}, "service missing is not defined in configuration file"},
+		{"no services", nil, "no services to start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := planServices(tt.services); err == nil || err.Error() != tt.want {
+				t.Errorf("Expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPlanServicesRejectsPrefork(t *testing.T) {
+	loadConfig(t, "[server]\nprefork = true\n")
+	if _, err := planServices([]string{"api"}); err == nil {
+		t.Error("Expected prefork to be rejected")
+	}
+}
+
+func TestBindListeners(t *testing.T) {
+	loadConfig(t, "")
+
+	busy, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer busy.Close()
+
+	first := &listener{addr: "127.0.0.1:0", app: newApp()}
+	second := &listener{addr: busy.Addr().String(), app: newApp()}
+	if err := bindListeners([]*listener{first, second}); err == nil {
+		t.Fatal("Expected binding a busy address to fail")
+	}
+	if first.ln != nil {
+		t.Error("Expected sockets bound before the failure to be closed")
+	}
+
+	ok := &listener{addr: "127.0.0.1:0", app: newApp()}
+	if err := bindListeners([]*listener{ok}); err != nil {
+		t.Fatalf("bindListeners failed: %v", err)
+	}
+	defer ok.ln.Close()
+	if ok.ln == nil || ok.tls != nil {
+		t.Errorf("Expected a plain bound socket, got %v, %v", ok.ln, ok.tls)
+	}
+}
@@ -0,0 +1,217 @@
+package boot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/storage"
+)
+
+// Check outcomes | 检查结果
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+	checkSkip = "SKIP"
+)
+
+// checkRow is one line of the `config check` report
+// checkRow 是 `config check` 报告中的一行
+type checkRow struct {
+	name, status, detail string
+}
+
+// runConfigCheck validates the configuration file and probes the configured backends.
+// runConfigCheck 校验配置文件并探测已配置的后端
+func runConfigCheck() {
+	if secretKey != "" {
+		config.SetDecryptKey(secretKey)
+	}
+	paths := configFiles
+	if checkFile != "" {
+		paths = []string{checkFile}
+	}
+	name := strings.Join(paths, ", ")
+	if name == "" {
+		name = "config.toml"
+	}
+	cfg, report, err := config.Check(paths...)
+	if err != nil {
+		fmt.Printf("Failed to read %s: %v\n", name, err)
+		os.Exit(1)
+	}
+
+	var rows []checkRow
+	add := func(name, status, detail string) {
+		rows = append(rows, checkRow{name, status, detail})
+	}
+
+	// Schema: keys that match no field are usually typos | 结构：不匹配任何字段的键通常是拼写错误
+	for _, key := range report.Unknown {
+		add(key, checkFail, "unknown key")
+	}
+	if len(report.Unknown) == 0 {
+		add("schema", checkPass, "no unknown keys")
+	}
+
+	// Values rejected by validate tags | 未通过 validate 标签的值
+	for _, key := range sortedKeys(report.Invalid) {
+		add(key, checkFail, "invalid value: "+report.Invalid[key])
+	}
+
+	// Encrypted values | 加密值
+	encrypted := len(report.Encrypted) > 0
+	switch {
+	case !encrypted:
+	case secretKey == "":
+		add("encryption", checkWarn, fmt.Sprintf("%d ENC() values not verified, pass -k to decrypt", len(report.Encrypted)))
+	case len(report.Undecryptable) > 0:
+		keys := make([]string, 0, len(report.Undecryptable))
+		for key := range report.Undecryptable {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			add(key, checkFail, "cannot decrypt: "+report.Undecryptable[key])
+		}
+	default:
+		add("encryption", checkPass, fmt.Sprintf("%d ENC() values decrypted", len(report.Encrypted)))
+	}
+
+	// Secret references | 密钥引用
+	for _, key := range sortedKeys(report.Unresolved) {
+		add(key, checkFail, "cannot resolve secret: "+report.Unresolved[key])
+	}
+	if n := len(report.Secrets); n > 0 && len(report.Unresolved) == 0 {
+		add("secrets", checkPass, fmt.Sprintf("%d secret references resolved", n))
+	}
+
+	// Required sections | 必需的配置段
+	if cfg.App.Name == "" {
+		add("app", checkFail, "[app] name is missing")
+	} else {
+		add("app", checkPass, cfg.App.Name+" ("+cfg.App.Env+")")
+	}
+	depStatus := checkWarn
+	if cfg.App.StrictDependencyCheck || cfg.App.Env == "prod" {
+		depStatus = checkFail
+	}
+	for _, m := range modules {
+		for _, db := range CheckModuleDependencies(m).Databases {
+			if _, ok := cfg.Database[db]; !ok {
+				add("database."+db, depStatus, "required by module "+m.Name()+" but not configured")
+			}
+		}
+	}
+	for _, svc := range cfg.Services {
+		for _, name := range svc.Modules {
+			if GetModule(name) == nil {
+				add("services."+svc.Name, checkFail, "module "+name+" is not registered")
+			}
+		}
+	}
+
+	// Connectivity | 连通性
+	switch {
+	case checkOffline:
+	case encrypted && (secretKey == "" || len(report.Undecryptable) > 0):
+		add("connectivity", checkSkip, "encrypted values could not be decrypted")
+	default:
+		rows = append(rows, probeBackends(cfg)...)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	failed := 0
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.name, r.status, r.detail)
+		if r.status == checkFail {
+			failed++
+		}
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Printf("\n%s: %d check(s) failed\n", name, failed)
+		os.Exit(1)
+	}
+	fmt.Printf("\n%s: all checks passed\n", name)
+}
+
+// probeBackends connects to every configured database, Redis, MQ and storage backend.
+// probeBackends 连接每个已配置的数据库、Redis、MQ 和存储后端
+func probeBackends(cfg *config.Config) []checkRow {
+	var rows []checkRow
+	probe := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		if err := fn(ctx); err != nil {
+			rows = append(rows, checkRow{name, checkFail, err.Error()})
+			return
+		}
+		rows = append(rows, checkRow{name, checkPass, fmt.Sprintf("connected in %s", time.Since(start).Round(time.Millisecond))})
+	}
+
+	for _, name := range sortedKeys(cfg.Database) {
+		dbCfg := cfg.Database[name]
+		probe("database."+name, func(ctx context.Context) error {
+			client, err := pgsql.New(dbCfg)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.Engine().PingContext(ctx)
+		})
+	}
+	for _, name := range sortedKeys(cfg.Redis) {
+		redisCfg := cfg.Redis[name]
+		probe("redis."+name, func(context.Context) error {
+			client, err := redis.New(redisCfg)
+			if err != nil {
+				return err
+			}
+			return client.Close()
+		})
+	}
+	if cfg.MQ.Driver != "" {
+		probe("mq", func(context.Context) error {
+			client, err := mq.New(cfg.MQ)
+			if err != nil {
+				return err
+			}
+			return client.Close()
+		})
+	}
+	if cfg.Storage.Driver != "" {
+		probe("storage", func(ctx context.Context) error {
+			client, err := storage.New(cfg.Storage)
+			if err != nil {
+				return err
+			}
+			_, err = client.Exists(ctx, "crab-config-check")
+			return err
+		})
+	}
+	return rows
+}
+
+// sortedKeys returns the keys of a config map in order
+// sortedKeys 按顺序返回配置 map 的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,13 +1,19 @@
 package boot
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/middleware"
 )
 
 // ModuleContext provides context for module initialization.
 type ModuleContext struct {
-	Router fiber.Router // route group for the module
-	Config any          // optional module-specific configuration
+	Router  fiber.Router       // route group for the module
+	Config  any                // optional module-specific configuration
+	Toggles middleware.Toggles // middleware toggles of the running service
+	DryRun  bool               // set by `crab routes`: register routes only, skip connections and goroutines
 }
 
 // NewModuleContext creates a new module context.
@@ -17,3 +23,74 @@ func NewModuleContext(router fiber.Router, config any) *ModuleContext {
 		Config: config,
 	}
 }
+
+// Use attaches middleware to the module's route group.
+// Use 为模块路由组挂载中间件
+func (ctx *ModuleContext) Use(handlers ...fiber.Handler) *ModuleContext {
+	for _, h := range handlers {
+		ctx.Router.Use(h)
+	}
+	return ctx
+}
+
+// UseNamed attaches middleware unless the running service disables it by name.
+// UseNamed 挂载中间件，除非当前服务按名称禁用了它
+func (ctx *ModuleContext) UseNamed(name string, handler fiber.Handler) *ModuleContext {
+	if ctx.Toggles.Enabled(name, true) {
+		ctx.Router.Use(handler)
+	}
+	return ctx
+}
+
+// RequireAuth requires a valid JWT for every route of the module, optionally restricted to platforms.
+// RequireAuth 要求模块所有路由携带有效 JWT，可选限制平台
+func (ctx *ModuleContext) RequireAuth(plats ...string) *ModuleContext {
+	return ctx.Use(middleware.RequireAuth(plats...))
+}
+
+// RequirePermission requires RBAC permissions for every route of the module.
+// RequirePermission 要求模块所有路由具备 RBAC 权限
+func (ctx *ModuleContext) RequirePermission(perms ...string) *ModuleContext {
+	return ctx.Use(authz.RequirePermission(perms...))
+}
+
+// RateLimit limits requests per IP to max within window (default 1 minute).
+// Services can turn it off with disable_middleware = ["ratelimit"].
+// RateLimit 按 IP 限制窗口内（默认 1 分钟）的最大请求数，服务可通过 disable_middleware = ["ratelimit"] 关闭
+func (ctx *ModuleContext) RateLimit(max int, window ...time.Duration) *ModuleContext {
+	w := time.Minute
+	if len(window) > 0 {
+		w = window[0]
+	}
+	return ctx.UseNamed("ratelimit", middleware.RateLimitByIP(max, w))
+}
+
+// Breaker runs every route of the module through a circuit breaker shared per method unless name is given.
+// Services can turn it off with disable_middleware = ["breaker"].
+// Breaker 让模块所有路由经过熔断器，未指定名称时按请求方法共享，服务可通过 disable_middleware = ["breaker"] 关闭
+func (ctx *ModuleContext) Breaker(name ...string) *ModuleContext {
+	return ctx.UseNamed("breaker", middleware.Breaker(name...))
+}
+
+// Compress compresses the module's large responses with brotli or gzip, see middleware.Compress.
+// Services can turn it off with disable_middleware = ["compress"].
+// Compress 使用 brotli 或 gzip 压缩模块的大响应，见 middleware.Compress，服务可通过 disable_middleware = ["compress"] 关闭
+func (ctx *ModuleContext) Compress(config ...middleware.CompressConfig) *ModuleContext {
+	return ctx.UseNamed("compress", middleware.Compress(config...))
+}
+
+// ETag adds ETags to the module's GET responses and answers If-None-Match with 304, see middleware.ETag.
+// Services can turn it off with disable_middleware = ["etag"].
+// ETag 为模块的 GET 响应添加 ETag 并对 If-None-Match 返回 304，见 middleware.ETag，服务可通过 disable_middleware = ["etag"] 关闭
+func (ctx *ModuleContext) ETag(config ...middleware.ETagConfig) *ModuleContext {
+	return ctx.UseNamed("etag", middleware.ETag(config...))
+}
+
+// Quota counts each request of the module against the plan of the caller's organization or user,
+// by default daily and monthly API calls; call it after RequireAuth. See middleware.Quota.
+// Services can turn it off with disable_middleware = ["quota"].
+// Quota 将模块的每个请求计入调用方组织或用户的套餐，默认为每日和每月 API 调用；应在 RequireAuth 之后调用，见 middleware.Quota，
+// 服务可通过 disable_middleware = ["quota"] 关闭
+func (ctx *ModuleContext) Quota(resources ...string) *ModuleContext {
+	return ctx.UseNamed("quota", middleware.Quota(resources...))
+}
@@ -0,0 +1,166 @@
+package boot
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// HeaderDebugToken is the request header carrying the debug access token
+// HeaderDebugToken 是携带调试访问令牌的请求头
+const HeaderDebugToken = "X-Debug-Token"
+
+// gcStats is the body of GET {path}/gc
+// gcStats 是 GET {path}/gc 的响应体
+type gcStats struct {
+	NumGC        uint32    `json:"num_gc"`
+	LastGC       time.Time `json:"last_gc"`
+	PauseTotal   string    `json:"pause_total"`
+	RecentPauses []string  `json:"recent_pauses"` // Most recent first | 最近的在前
+	HeapAlloc    uint64    `json:"heap_alloc"`
+	HeapInuse    uint64    `json:"heap_inuse"`
+	HeapObjects  uint64    `json:"heap_objects"`
+	NextGC       uint64    `json:"next_gc"`
+	Sys          uint64    `json:"sys"`
+	Goroutines   int       `json:"goroutines"`
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+	GCPercent    uint64    `json:"gc_percent"`   // GOGC | GOGC
+	MemoryLimit  int64     `json:"memory_limit"` // GOMEMLIMIT in bytes | GOMEMLIMIT 字节数
+}
+
+// buildInfo is the body of GET {path}/build
+// buildInfo 是 GET {path}/build 的响应体
+type buildInfo struct {
+	Version   string            `json:"version"`
+	GitCommit string            `json:"git_commit"`
+	BuildTime string            `json:"build_time"`
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"`
+	Deps      map[string]string `json:"deps,omitempty"`
+}
+
+// setupDebug mounts pprof, expvar, GC stats and build info when [debug] enabled = true or env = dev
+// setupDebug 在 [debug] enabled = true 或 env = dev 时挂载 pprof、expvar、GC 统计和构建信息
+func setupDebug() {
+	cfg := config.GetDebug()
+	if !cfg.Enabled && !config.IsDev() {
+		return
+	}
+	serverLog := logger.NewSystem("server")
+	if cfg.Token == "" && !config.IsDev() {
+		serverLog.Warn("Debug endpoints not mounted: [debug] token is required outside dev")
+		return
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/debug"
+	}
+
+	group := app.Group(cfg.Path, debugAuth(cfg.Token))
+
+	// pprof.Index only resolves profiles under /debug/pprof/, so named profiles are routed explicitly
+	// pprof.Index 仅解析 /debug/pprof/ 下的 profile，因此具名 profile 单独路由
+	group.Get("/pprof/", adaptor.HTTPHandlerFunc(pprof.Index))
+	group.Get("/pprof/cmdline", adaptor.HTTPHandlerFunc(pprof.Cmdline))
+	group.Get("/pprof/profile", adaptor.HTTPHandlerFunc(pprof.Profile))
+	group.All("/pprof/symbol", adaptor.HTTPHandlerFunc(pprof.Symbol))
+	group.Get("/pprof/trace", adaptor.HTTPHandlerFunc(pprof.Trace))
+	group.Get("/pprof/:name", func(c *fiber.Ctx) error {
+		return adaptor.HTTPHandler(pprof.Handler(c.Params("name")))(c)
+	})
+
+	group.Get("/vars", adaptor.HTTPHandler(expvar.Handler()))
+	group.Get("/gc", func(c *fiber.Ctx) error {
+		return response.OK(c, readGCStats())
+	})
+	group.Get("/build", func(c *fiber.Ctx) error {
+		return response.OK(c, readBuildInfo())
+	})
+
+	serverLog.Info("Debug endpoints mounted at %s (token required: %v)", cfg.Path, cfg.Token != "")
+}
+
+// debugAuth checks the X-Debug-Token header; an empty token (dev only) allows every request
+// The token is not accepted as a query parameter, where it would end up in access logs and browser history.
+// debugAuth 校验 X-Debug-Token 请求头；令牌为空（仅 dev）时放行所有请求
+// 不接受通过查询参数传递令牌，以免其出现在访问日志和浏览器历史中。
+func debugAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Next()
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Get(HeaderDebugToken)), []byte(token)) != 1 {
+			return errors.ErrUnauthorized("invalid debug token")
+		}
+		return c.Next()
+	}
+}
+
+// readGCStats collects garbage collector and heap statistics
+// readGCStats 收集垃圾回收和堆统计信息
+func readGCStats() gcStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	pauses := make([]string, 0, 10)
+	for i := 0; i < len(gc.Pause) && i < 10; i++ {
+		pauses = append(pauses, gc.Pause[i].String())
+	}
+
+	gogc := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(gogc)
+
+	return gcStats{
+		NumGC:        mem.NumGC,
+		LastGC:       gc.LastGC,
+		PauseTotal:   gc.PauseTotal.String(),
+		RecentPauses: pauses,
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		NextGC:       mem.NextGC,
+		Sys:          mem.Sys,
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		GCPercent:    gogc[0].Value.Uint64(),
+		MemoryLimit:  debug.SetMemoryLimit(-1),
+	}
+}
+
+// readBuildInfo returns the ldflags version information and the module build info
+// readBuildInfo 返回 ldflags 版本信息和模块构建信息
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Path = bi.Path
+	info.Settings = make(map[string]string, len(bi.Settings))
+	for _, s := range bi.Settings {
+		info.Settings[s.Key] = s.Value
+	}
+	info.Deps = make(map[string]string, len(bi.Deps))
+	for _, d := range bi.Deps {
+		info.Deps[d.Path] = d.Version
+	}
+	return info
+}
@@ -0,0 +1,59 @@
+package boot
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// debugStatus mounts the debug endpoints for cfg and requests /debug/build with the given token
+func debugStatus(t *testing.T, cfg, header, query string) int {
+	t.Helper()
+	loadConfig(t, cfg)
+	prev := app
+	t.Cleanup(func() { app = prev })
+	app = newApp()
+	setupDebug()
+
+	req := httptest.NewRequest("GET", "/debug/build"+query, nil)
+	if header != "" {
+		req.Header.Set(HeaderDebugToken, header)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestSetupDebug(t *testing.T) {
+	const (
+		dev           = "[app]\nenv = \"dev\"\n"
+		prodNoToken   = "[app]\nenv = \"prod\"\n[debug]\nenabled = true\n"
+		prodWithToken = "[app]\nenv = \"prod\"\n[debug]\nenabled = true\ntoken = \"s3cret\"\n"
+		prodDisabled  = "[app]\nenv = \"prod\"\n[debug]\ntoken = \"s3cret\"\n"
+	)
+	tests := []struct {
+		name   string
+		cfg    string
+		header string
+		query  string
+		want   int
+	}{
+		{"dev without token is open", dev, "", "", fiber.StatusOK},
+		{"prod without token is not mounted", prodNoToken, "", "", fiber.StatusNotFound},
+		{"prod disabled is not mounted", prodDisabled, "s3cret", "", fiber.StatusNotFound},
+		{"prod with header token", prodWithToken, "s3cret", "", fiber.StatusOK},
+		{"prod with wrong token", prodWithToken, "guess", "", fiber.StatusUnauthorized},
+		{"prod without token", prodWithToken, "", "", fiber.StatusUnauthorized},
+		{"prod with query token", prodWithToken, "", "?token=s3cret", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := debugStatus(t, tt.cfg, tt.header, tt.query); got != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package boot
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/featureflag"
+)
+
+// flagRequest is the body of PUT {admin_path}/:key
+// flagRequest 是 PUT {admin_path}/:key 的请求体
+type flagRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Rollout     *int     `json:"rollout"` // 0-100, omitted means 100 (a plain boolean flag) | 0-100，省略时为 100（普通布尔开关）
+	Users       []string `json:"users"`
+	Orgs        []string `json:"orgs"`
+}
+
+// setupFeatureFlagAdmin mounts the feature flag CRUD endpoints when [featureflag] admin_path is set
+// setupFeatureFlagAdmin 在设置 [featureflag] admin_path 时挂载功能开关管理接口
+func setupFeatureFlagAdmin() {
+	cfg := featureflag.GetConfig()
+	if cfg.AdminPath == "" {
+		return
+	}
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("", func(c *fiber.Ctx) error {
+		flags, err := featureflag.Get().All(c.UserContext())
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, flags)
+	})
+	group.Get("/:key", func(c *fiber.Ctx) error {
+		flag, err := featureflag.Get().Get(c.UserContext(), c.Params("key"))
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		if flag == nil {
+			return errors.ErrNotFound()
+		}
+		return response.OK(c, flag)
+	})
+	group.Put("/:key", saveFeatureFlag)
+	group.Delete("/:key", func(c *fiber.Ctx) error {
+		if err := featureflag.Get().Delete(c.UserContext(), c.Params("key")); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, nil)
+	})
+}
+
+// saveFeatureFlag creates or replaces one flag
+// saveFeatureFlag 创建或替换一个开关
+func saveFeatureFlag(c *fiber.Ctx) error {
+	var req flagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	flag := &featureflag.Flag{
+		Key:         c.Params("key"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Rollout:     100,
+		Users:       req.Users,
+		Orgs:        req.Orgs,
+	}
+	if req.Rollout != nil {
+		flag.Rollout = *req.Rollout
+	}
+	if err := flag.Validate(); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	if err := featureflag.Get().Save(c.UserContext(), flag); err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, flag)
+}
@@ -0,0 +1,92 @@
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/clientgen"
+)
+
+// ClientEndpoints returns the module routes of app for client generation; framework endpoints,
+// HEAD routes and WebSocket upgrades are left out
+// ClientEndpoints 返回 app 中用于生成客户端的模块路由；不包括框架接口、HEAD 路由和 WebSocket 升级
+func ClientEndpoints(app *fiber.App) []clientgen.Endpoint {
+	moduleNames := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		moduleNames[m.Name()] = true
+	}
+
+	var endpoints []clientgen.Endpoint
+	seen := make(map[string]bool)
+	for _, r := range app.GetRoutes() {
+		if r.Method == "USE" || r.Method == fiber.MethodHead || len(r.Handlers) == 0 {
+			continue
+		}
+		module, _, _ := strings.Cut(strings.TrimPrefix(r.Path, "/"), "/")
+		handler := clientgen.FuncName(r.Handlers[len(r.Handlers)-1])
+		if !moduleNames[module] || strings.HasPrefix(handler, "github.com/gofiber/websocket/") {
+			continue
+		}
+		if key := r.Method + " " + r.Path; !seen[key] {
+			seen[key] = true
+			endpoints = append(endpoints, clientgen.NewEndpoint(module, r.Method, r.Path, handler))
+		}
+	}
+	return endpoints
+}
+
+// runGenClient writes the Go and TypeScript clients of the module routes
+// runGenClient 写出模块路由的 Go 和 TypeScript 客户端
+func runGenClient() {
+	endpoints := ClientEndpoints(dryRunApp())
+	if len(endpoints) == 0 {
+		fmt.Println("No module routes found")
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(genOut, 0o755); err != nil {
+		fmt.Printf("Failed to create %s: %v\n", genOut, err)
+		os.Exit(1)
+	}
+	for _, lang := range splitList(genLang) {
+		var (
+			name string
+			src  []byte
+			err  error
+		)
+		switch lang {
+		case "go":
+			pkg := genPackage
+			if pkg == "" {
+				pkg = filepath.Base(filepath.Clean(genOut))
+			}
+			name = "client.go"
+			src, err = clientgen.Go(pkg, endpoints)
+		case "ts", "typescript":
+			name = "client.ts"
+			src = clientgen.TypeScript(endpoints)
+		default:
+			fmt.Printf("Unknown language %q, use go or ts\n", lang)
+			os.Exit(1)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(genOut, name), src, 0o644)
+		}
+		if err != nil {
+			fmt.Printf("Failed to generate %s client: %v\n", lang, err)
+			os.Exit(1)
+		}
+		fmt.Printf("  %s\n", filepath.Join(genOut, name))
+	}
+
+	var typed int
+	for _, e := range endpoints {
+		if e.Described {
+			typed++
+		}
+	}
+	fmt.Printf("\n%d endpoints, %d typed with clientgen.Describe\n", len(endpoints), typed)
+}
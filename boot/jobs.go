@@ -0,0 +1,50 @@
+package boot
+
+import (
+	stderrors "errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/jobs"
+)
+
+// setupJobsAdmin mounts the background job dashboard when [jobs] admin_path is set
+// setupJobsAdmin 在设置 [jobs] admin_path 时挂载后台任务面板
+func setupJobsAdmin() {
+	runner := jobs.Get()
+	if runner == nil || runner.Config().AdminPath == "" {
+		return
+	}
+	cfg := runner.Config()
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("", func(c *fiber.Ctx) error {
+		return response.OK(c, runner.Stats(c.UserContext()))
+	})
+	group.Get("/:queue/failed", func(c *fiber.Ctx) error {
+		failed, err := runner.Failed(c.UserContext(), c.Params("queue"))
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, failed)
+	})
+	group.Post("/:queue/failed/:id/retry", func(c *fiber.Ctx) error {
+		err := runner.Retry(c.UserContext(), c.Params("queue"), c.Params("id"))
+		if stderrors.Is(err, jobs.ErrJobNotFound) {
+			return errors.ErrNotFound()
+		}
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, nil)
+	})
+	group.Delete("/:queue/failed/:id", func(c *fiber.Ctx) error {
+		if err := runner.DeleteFailed(c.UserContext(), c.Params("queue"), c.Params("id")); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, nil)
+	})
+}
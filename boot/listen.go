@@ -0,0 +1,109 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/server"
+)
+
+// extraServers are the net/http listeners started next to the Fiber apps: the HTTPS redirect and the HTTP/2 listener
+// extraServers 是与 Fiber 应用一起启动的 net/http 监听：HTTPS 重定向和 HTTP/2 监听
+var extraServers []*http.Server
+
+// bindListeners opens the socket of every listener, TLS when [server.tls] is enabled.
+// All addresses are bound before any listener serves or registers with service discovery,
+// so one bad address fails startup before anything is announced. On error the sockets
+// already opened are closed. Plain prefork is left to Fiber, which binds in every child.
+// bindListeners 打开每个监听的套接字，启用 [server.tls] 时为 TLS。
+// 在任何监听开始服务或注册服务发现之前绑定所有地址，因此一个错误的地址会在对外公布前使启动失败。
+// 出错时关闭已打开的套接字。不带 TLS 的 prefork 交由 Fiber 在每个子进程中绑定。
+func bindListeners(listeners []*listener) error {
+	serverCfg := config.GetServer()
+	t, err := server.NewTLS(serverCfg.TLS)
+	if err != nil {
+		return err
+	}
+	for _, l := range listeners {
+		l.tls = t
+		if t == nil && serverCfg.Prefork {
+			continue
+		}
+		var ln net.Listener
+		if t != nil {
+			ln, err = t.Listen(l.addr)
+		} else {
+			ln, err = net.Listen(l.app.Config().Network, l.addr)
+		}
+		if err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("listen on %s: %w", l.addr, err)
+		}
+		l.ln = ln
+	}
+	return nil
+}
+
+// closeListeners closes the sockets opened by bindListeners
+// closeListeners 关闭 bindListeners 打开的套接字
+func closeListeners(listeners []*listener) {
+	for _, l := range listeners {
+		if l.ln != nil {
+			l.ln.Close()
+			l.ln = nil
+		}
+	}
+}
+
+// listen serves l on its bound socket (blocking), HTTPS when [server.tls] is enabled.
+// The redirect and HTTP/2 listeners are started for the primary listener only.
+// listen 在已绑定的套接字上为 l 提供服务（阻塞），启用 [server.tls] 时为 HTTPS
+// 重定向和 HTTP/2 监听仅为主监听启动。
+func listen(l *listener, primary bool) error {
+	serverCfg := config.GetServer()
+
+	// Prefork children share the parent's extra listeners | prefork 子进程共用父进程的额外监听
+	if primary && !fiber.IsChild() {
+		if l.tls != nil {
+			if srv := l.tls.RedirectServer(l.addr); srv != nil {
+				startExtra("HTTPS redirect", srv)
+			}
+		}
+		if serverCfg.HTTP2Addr != "" {
+			// The adaptor buffers responses, so SSE and WebSocket stay on the main listener
+			// 适配器会缓冲响应，因此 SSE 和 WebSocket 仍使用主监听
+			startExtra("HTTP/2", server.HTTP2Server(serverCfg.HTTP2Addr, adaptor.FiberApp(l.app), l.tls))
+		}
+	}
+
+	if l.ln == nil {
+		return l.app.Listen(l.addr)
+	}
+	return l.app.Listener(l.ln)
+}
+
+// startExtra runs srv in the background and keeps it for shutdown
+// startExtra 在后台运行 srv 并记录以便关闭
+func startExtra(name string, srv *http.Server) {
+	extraServers = append(extraServers, srv)
+	go func() {
+		if err := server.ListenAndServe(srv); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.NewSystem("server").Error("%s listener on %s failed: %v", name, srv.Addr, err)
+		}
+	}()
+}
+
+// shutdownExtra stops the extra listeners
+// shutdownExtra 停止额外的监听
+func shutdownExtra(ctx context.Context) {
+	for _, srv := range extraServers {
+		srv.Shutdown(ctx)
+	}
+}
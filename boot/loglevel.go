@@ -0,0 +1,101 @@
+package boot
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// LogAdminScope is the API key scope required by the log level endpoint
+// LogAdminScope 是日志级别接口要求的 API 密钥权限范围
+const LogAdminScope = "admin:logger"
+
+// setLevelRequest is the body of PUT {admin_path}
+// setLevelRequest 是 PUT {admin_path} 的请求体
+type setLevelRequest struct {
+	Name    string `json:"name"`    // Logger name, empty for the minimum level | 日志器名称，为空时修改最低级别
+	Level   string `json:"level"`   // debug, info, warn, error
+	TTL     string `json:"ttl"`     // Restore after this duration, e.g. "10m" | 在该时长后恢复，例如 "10m"
+	Persist bool   `json:"persist"` // Write [logger.levels] back to the config file | 将 [logger.levels] 写回配置文件
+}
+
+// setupLogAdmin mounts the runtime log level endpoint when [logger] admin_path is set
+// setupLogAdmin 在设置 [logger] admin_path 时挂载运行时日志级别接口
+func setupLogAdmin() {
+	path := config.GetLogger().AdminPath
+	if path == "" {
+		return
+	}
+
+	group := app.Group(path, middleware.APIKey(LogAdminScope))
+	group.Get("", func(c *fiber.Ctx) error {
+		return response.OK(c, logger.Levels())
+	})
+	group.Put("", setLogLevel)
+	group.Delete("/:name", func(c *fiber.Ctx) error {
+		logger.ResetLevel(c.Params("name"))
+		return response.OK(c, logger.Levels())
+	})
+}
+
+// setLogLevel changes one level, optionally temporary or persisted
+// setLogLevel 修改一个级别，可临时生效或持久化
+func setLogLevel(c *fiber.Ctx) error {
+	var req setLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			return errors.ErrParamInvalid("invalid ttl")
+		}
+	}
+	if req.Persist && (ttl > 0 || req.Name == "") {
+		return errors.ErrParamInvalid("only permanent per-logger levels can be persisted")
+	}
+
+	logger.SetLevelFor(req.Name, level, ttl)
+	snap := logger.Levels()
+	logger.NewSystem("server").Info("Log level of %q set to %s (ttl %s, persist %v)", req.Name, level, ttl, req.Persist)
+
+	if req.Persist {
+		if err := config.SaveLoggerLevels(snap.Permanent()); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+	}
+	return response.OK(c, snap)
+}
+
+// setupLogReload re-applies the [logger] section of the config file on SIGHUP
+// setupLogReload 在收到 SIGHUP 时重新应用配置文件中的 [logger] 段
+func setupLogReload() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			serverLog := logger.NewSystem("server")
+			cfg, err := config.ReloadLogger()
+			if err != nil {
+				serverLog.Error("Logger reload failed: %v", err)
+				continue
+			}
+			logger.SetConfig(cfg)
+			serverLog.Info("Logger configuration reloaded, level %s", logger.Levels().Level)
+		}
+	}()
+}
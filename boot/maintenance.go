@@ -0,0 +1,47 @@
+package boot
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// maintenanceRequest is the body of PUT {admin_path}
+// maintenanceRequest 是 PUT {admin_path} 的请求体
+type maintenanceRequest struct {
+	Message string `json:"message"`
+}
+
+// setupMaintenanceAdmin mounts the maintenance toggle endpoints when [maintenance] admin_path is set
+// setupMaintenanceAdmin 在设置 [maintenance] admin_path 时挂载维护模式开关接口
+func setupMaintenanceAdmin() {
+	cfg := middleware.GetMaintenanceConfig()
+	if cfg.AdminPath == "" {
+		return
+	}
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("", func(c *fiber.Ctx) error {
+		return response.OK(c, middleware.GetMaintenance(c.UserContext()))
+	})
+	group.Put("", func(c *fiber.Ctx) error {
+		var req maintenanceRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return errors.ErrParamInvalid(err.Error())
+			}
+		}
+		if err := middleware.SetMaintenance(c.UserContext(), true, req.Message); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, middleware.GetMaintenance(c.UserContext()))
+	})
+	group.Delete("", func(c *fiber.Ctx) error {
+		if err := middleware.SetMaintenance(c.UserContext(), false, ""); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, middleware.GetMaintenance(c.UserContext()))
+	})
+}
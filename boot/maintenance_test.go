@@ -0,0 +1,85 @@
+package boot
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// grants is a resolver granting fixed permissions per user
+type grants map[int64][]string
+
+func (g grants) Permissions(_ context.Context, userID int64) ([]string, error) {
+	return g[userID], nil
+}
+
+func TestMaintenanceAdmin(t *testing.T) {
+	loadConfig(t, "")
+	if err := jwt.Init(jwt.Config{Secret: "test-secret", Expire: "1h"}); err != nil {
+		t.Fatalf("jwt.Init failed: %v", err)
+	}
+	authz.SetResolver(grants{1: {"maintenance:write"}})
+	middleware.InitMaintenance(middleware.MaintenanceConfig{AdminPath: "/admin/maintenance"})
+	t.Cleanup(func() { middleware.InitMaintenance(middleware.MaintenanceConfig{}) })
+
+	prev := app
+	t.Cleanup(func() { app = prev })
+	app = newApp()
+	app.Use(middleware.Maintenance())
+	setupMaintenanceAdmin()
+	app.Get("/orders", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	admin, _ := jwt.Get().Generate(1, "admin")
+	viewer, _ := jwt.Get().Generate(2, "admin")
+	call := func(method, path, token, body string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	if status, _ := call("PUT", "/admin/maintenance", "", ""); status != fiber.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", status)
+	}
+	if status, _ := call("PUT", "/admin/maintenance", viewer, ""); status != fiber.StatusForbidden {
+		t.Errorf("Expected 403 without permission, got %d", status)
+	}
+
+	status, body := call("PUT", "/admin/maintenance", admin, `{"message":"upgrading"}`)
+	if status != fiber.StatusOK || !strings.Contains(body, `"enabled":true`) || !strings.Contains(body, "upgrading") {
+		t.Fatalf("Expected maintenance on, got %d %s", status, body)
+	}
+	if status, _ := call("GET", "/orders", "", ""); status != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected 503 during maintenance, got %d", status)
+	}
+	// The admin path stays reachable to turn maintenance off | 管理路径保持可访问以便关闭维护模式
+	if status, body := call("GET", "/admin/maintenance", admin, ""); status != fiber.StatusOK || !strings.Contains(body, `"enabled":true`) {
+		t.Errorf("Expected state from admin path, got %d %s", status, body)
+	}
+
+	if status, _ := call("PUT", "/admin/maintenance", admin, `{bad`); status != fiber.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed body, got %d", status)
+	}
+
+	if status, body := call("DELETE", "/admin/maintenance", admin, ""); status != fiber.StatusOK || !strings.Contains(body, `"enabled":false`) {
+		t.Errorf("Expected maintenance off, got %d %s", status, body)
+	}
+	if status, _ := call("GET", "/orders", "", ""); status != fiber.StatusOK {
+		t.Errorf("Expected 200 after maintenance, got %d", status)
+	}
+}
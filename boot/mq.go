@@ -0,0 +1,66 @@
+package boot
+
+import (
+	stderrors "errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/mq"
+)
+
+// setupMQAdmin mounts the topic management endpoints when [mq] admin_path is set
+// setupMQAdmin 在设置 [mq] admin_path 时挂载主题管理接口
+func setupMQAdmin() {
+	cfg := mq.GetConfig()
+	if !mq.Enabled() || cfg.AdminPath == "" {
+		return
+	}
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("", func(c *fiber.Ctx) error {
+		topics, err := mq.Topics(c.UserContext())
+		if err != nil {
+			return mqAdminError(err)
+		}
+		infos := make([]*mq.TopicInfo, 0, len(topics))
+		for _, topic := range topics {
+			info, err := mq.Inspect(c.UserContext(), topic)
+			if err != nil {
+				return mqAdminError(err)
+			}
+			infos = append(infos, info)
+		}
+		return response.OK(c, infos)
+	})
+	group.Get("/:topic", func(c *fiber.Ctx) error {
+		info, err := mq.Inspect(c.UserContext(), c.Params("topic"))
+		if err != nil {
+			return mqAdminError(err)
+		}
+		return response.OK(c, info)
+	})
+	group.Delete("/:topic/messages", func(c *fiber.Ctx) error {
+		if err := mq.Purge(c.UserContext(), c.Params("topic")); err != nil {
+			return mqAdminError(err)
+		}
+		return response.OK(c, nil)
+	})
+	group.Delete("/:topic/groups/:group", func(c *fiber.Ctx) error {
+		if err := mq.DeleteGroup(c.UserContext(), c.Params("topic"), c.Params("group")); err != nil {
+			return mqAdminError(err)
+		}
+		return response.OK(c, nil)
+	})
+}
+
+// mqAdminError converts an admin operation error to a business error
+// mqAdminError 将管理操作错误转换为业务错误
+func mqAdminError(err error) error {
+	if stderrors.Is(err, mq.ErrNotSupported) {
+		return errors.New(response.CodeBizError, "operation not supported by the mq driver")
+	}
+	return errors.Wrap(response.CodeServerError, err)
+}
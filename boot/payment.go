@@ -0,0 +1,37 @@
+package boot
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/pkg/payment"
+)
+
+// setupPaymentNotify mounts POST {notify_path}/:provider when payment is configured and [payment] notify_path is set
+// The route carries no auth and is exempt from CSRF, providers are verified by the signature of each notification.
+// setupPaymentNotify 在已配置支付且设置 [payment] notify_path 时挂载 POST {notify_path}/:provider
+// 该路由不做认证且免于 CSRF 校验，通过每条通知的签名校验服务商。
+func setupPaymentNotify() {
+	m := payment.Get()
+	if m == nil || m.Config().NotifyPath == "" {
+		return
+	}
+
+	path := strings.TrimRight(m.Config().NotifyPath, "/")
+	middleware.ExemptCSRF(path)
+	app.Post(path+"/:provider", func(c *fiber.Ctx) error {
+		header := http.Header{}
+		for k, values := range c.GetReqHeaders() {
+			for _, value := range values {
+				header.Add(k, value)
+			}
+		}
+		reply := m.HandleNotify(c.UserContext(), c.Params("provider"), header, c.Body())
+		if reply.ContentType != "" {
+			c.Set(fiber.HeaderContentType, reply.ContentType)
+		}
+		return c.Status(reply.Status).SendString(reply.Body)
+	})
+}
@@ -0,0 +1,119 @@
+package boot
+
+import (
+	stderrors "errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/quota"
+)
+
+// usageRequest is the body of PUT {admin_path}/:subject/usage/:resource
+// usageRequest 是 PUT {admin_path}/:subject/usage/:resource 的请求体
+type usageRequest struct {
+	Used int64 `json:"used"`
+}
+
+// setupQuotaAdmin mounts the quota usage and limit endpoints when [quota] admin_path is set
+// Subjects are written user:<id> or org:<id>.
+// setupQuotaAdmin 在设置 [quota] admin_path 时挂载配额用量和限额管理接口
+// 主体写作 user:<id> 或 org:<id>。
+func setupQuotaAdmin() {
+	cfg := quota.GetConfig()
+	if cfg.AdminPath == "" {
+		return
+	}
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("/plans", func(c *fiber.Ctx) error {
+		return response.OK(c, fiber.Map{"default_plan": cfg.DefaultPlan, "plans": quota.Get().Plans()})
+	})
+	group.Get("/:subject", func(c *fiber.Ctx) error {
+		subject, err := quotaSubjectParam(c)
+		if err != nil {
+			return err
+		}
+		settings, err := quota.Get().Settings(c.UserContext(), subject)
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		usage, err := quota.Get().Report(c.UserContext(), subject)
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, fiber.Map{"subject": subject, "settings": settings, "usage": usage})
+	})
+	group.Put("/:subject", func(c *fiber.Ctx) error {
+		subject, err := quotaSubjectParam(c)
+		if err != nil {
+			return err
+		}
+		var settings quota.Settings
+		if err := c.BodyParser(&settings); err != nil {
+			return errors.ErrParamInvalid(err.Error())
+		}
+		if err := quota.Get().SaveSettings(c.UserContext(), subject, settings); err != nil {
+			if stderrors.Is(err, quota.ErrUnknownPlan) {
+				return errors.ErrParamInvalid(err.Error())
+			}
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, settings)
+	})
+	group.Put("/:subject/usage/:resource", func(c *fiber.Ctx) error {
+		subject, err := quotaSubjectParam(c)
+		if err != nil {
+			return err
+		}
+		var req usageRequest
+		if err := c.BodyParser(&req); err != nil {
+			return errors.ErrParamInvalid(err.Error())
+		}
+		if req.Used < 0 {
+			return errors.ErrParamInvalid("used must not be negative")
+		}
+		return setQuotaUsage(c, subject, req.Used)
+	})
+	group.Delete("/:subject/usage/:resource", func(c *fiber.Ctx) error {
+		subject, err := quotaSubjectParam(c)
+		if err != nil {
+			return err
+		}
+		return setQuotaUsage(c, subject, 0)
+	})
+}
+
+// setQuotaUsage overwrites the usage of the :resource parameter and returns it
+// setQuotaUsage 覆盖 :resource 参数对应资源的用量并返回
+func setQuotaUsage(c *fiber.Ctx, subject string, used int64) error {
+	resource := c.Params("resource")
+	if err := quota.Get().SetUsage(c.UserContext(), subject, resource, used); err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	usage, err := quota.Get().Usage(c.UserContext(), subject, resource)
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, usage)
+}
+
+// quotaSubjectParam validates the :subject parameter | quotaSubjectParam 校验 :subject 参数
+func quotaSubjectParam(c *fiber.Ctx) (string, error) {
+	kind, id, _ := strings.Cut(c.Params("subject"), ":")
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return "", errors.ErrParamInvalid("subject must be user:<id> or org:<id>")
+	}
+	switch kind {
+	case "user":
+		return quota.User(n), nil
+	case "org":
+		return quota.Org(n), nil
+	}
+	return "", errors.ErrParamInvalid("subject must be user:<id> or org:<id>")
+}
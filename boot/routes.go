@@ -0,0 +1,181 @@
+package boot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/config"
+)
+
+// RouteInfo describes one route of the app
+// RouteInfo 描述应用的一个路由
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Module     string   `json:"module,omitempty"` // Empty for framework endpoints | 框架接口为空
+	Middleware []string `json:"middleware"`       // app.Use / group middleware matching the path, in order | 匹配该路径的 app.Use / 分组中间件，按顺序
+	Handlers   []string `json:"handlers"`         // Route handlers, the last one ends the chain | 路由处理器，最后一个结束调用链
+}
+
+// funcSuffix matches closure and method value suffixes such as .func1.2 and -fm
+var funcSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*|-fm)+$`)
+
+// RouteTable lists the routes of app sorted by path and method; HEAD routes Fiber adds for GET are omitted
+// RouteTable 列出 app 的路由，按路径和方法排序；省略 Fiber 为 GET 添加的 HEAD 路由
+func RouteTable(app *fiber.App) []RouteInfo {
+	// Middleware is copied into every method stack, keep the first copy | 中间件会复制到每个方法栈中，保留第一份
+	type use struct {
+		prefix string
+		names  []string
+	}
+	var uses []use
+	seen := make(map[string]bool)
+	var routes []fiber.Route
+	for _, r := range app.GetRoutes() {
+		if r.Method == "USE" {
+			names := handlerNames(r.Handlers)
+			key := r.Path + " " + strings.Join(names, ",")
+			if !seen[key] {
+				seen[key] = true
+				uses = append(uses, use{prefix: strings.TrimRight(r.Path, "/"), names: names})
+			}
+			continue
+		}
+		if r.Method != fiber.MethodHead {
+			routes = append(routes, r)
+		}
+	}
+
+	moduleNames := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		moduleNames[m.Name()] = true
+	}
+
+	table := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		info := RouteInfo{Method: r.Method, Path: r.Path, Middleware: []string{}, Handlers: handlerNames(r.Handlers)}
+		if first, _, _ := strings.Cut(strings.TrimPrefix(r.Path, "/"), "/"); moduleNames[first] {
+			info.Module = first
+		}
+		for _, u := range uses {
+			if u.prefix == "" || r.Path == u.prefix || strings.HasPrefix(r.Path, u.prefix+"/") {
+				info.Middleware = append(info.Middleware, u.names...)
+			}
+		}
+		table = append(table, info)
+	}
+	sort.SliceStable(table, func(i, j int) bool {
+		if table[i].Path != table[j].Path {
+			return table[i].Path < table[j].Path
+		}
+		return table[i].Method < table[j].Method
+	})
+	return table
+}
+
+// handlerNames returns short function names such as middleware.RequireAuth
+// handlerNames 返回 middleware.RequireAuth 这样的简短函数名
+func handlerNames(handlers []fiber.Handler) []string {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+		name = funcSuffix.ReplaceAllString(name, "")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// runRoutes registers the framework endpoints and module routes without starting anything, then prints them.
+// runRoutes 在不启动任何组件的情况下注册框架接口和模块路由，然后打印
+func runRoutes() {
+	table := RouteTable(dryRunApp())
+	switch routesFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(table)
+	case "markdown", "md":
+		fmt.Println("| Method | Path | Module | Middleware | Handlers |")
+		fmt.Println("|--------|------|--------|------------|----------|")
+		for _, r := range table {
+			fmt.Printf("| %s | `%s` | %s | %s | %s |\n", r.Method, r.Path, r.Module,
+				strings.Join(r.Middleware, ", "), strings.Join(r.Handlers, ", "))
+		}
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "METHOD\tPATH\tMODULE\tMIDDLEWARE\tHANDLERS")
+		for _, r := range table {
+			module := r.Module
+			if module == "" {
+				module = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, module,
+				strings.Join(r.Middleware, ","), strings.Join(r.Handlers, " > "))
+		}
+		w.Flush()
+		fmt.Printf("\n%d routes\n", len(table))
+	}
+}
+
+// splitList parses a comma-separated list
+// splitList 解析逗号分隔的列表
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	names := strings.Split(list, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+// dryRunApp registers the framework endpoints and the routes of the selected modules without starting anything
+// dryRunApp 在不启动任何组件的情况下注册框架接口和所选模块的路由
+func dryRunApp() *fiber.App {
+	if routesInit {
+		// Connect infrastructure so endpoints that need it (metrics, jobs dashboard) are included
+		// 连接基础设施，使依赖它的接口（metrics、任务面板）也被列出
+		initBase()
+	} else {
+		if secretKey != "" {
+			config.SetDecryptKey(secretKey)
+		}
+		config.MustLoad(configFiles...)
+	}
+
+	names := splitList(moduleList)
+	if serviceName != "" {
+		svc := config.GetService(serviceName)
+		if svc == nil {
+			fmt.Printf("Service %s is not defined in configuration file\n", serviceName)
+			os.Exit(1)
+		}
+		activeService = svc
+		names = svc.Modules
+	}
+
+	app = newApp()
+	toggles := activeService.Toggles()
+	setupApp(toggles)
+	for _, m := range selectModules(names) {
+		ctx := NewModuleContext(app.Group("/"+m.Name()), nil)
+		ctx.Toggles = toggles
+		ctx.DryRun = true
+		if err := m.Init(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Module %s initialization failed: %v\n", m.Name(), err)
+		}
+	}
+	return app
+}
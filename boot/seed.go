@@ -0,0 +1,44 @@
+package boot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/pkg/seed"
+)
+
+// runSeed applies the seeds of the selected modules, migrating first when auto_migrate is on
+// runSeed 应用所选模块的种子，启用 auto_migrate 时先执行迁移
+func runSeed() {
+	initBase()
+
+	targetModules := selectModules(splitList(moduleList))
+	if autoMigrateEnabled() {
+		migrateModels(targetModules)
+	}
+	if err := runSeeds(targetModules, splitList(seedNames), seedForce); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+// runSeeds applies the seeds of the current environment owned by targetModules or shared
+// runSeeds 应用当前环境中属于 targetModules 或共享的种子
+func runSeeds(targetModules []Module, names []string, force bool) error {
+	env := config.GetApp().Env
+	moduleNames := make([]string, len(targetModules))
+	for i, m := range targetModules {
+		moduleNames[i] = m.Name()
+	}
+
+	res, err := seed.Run(context.Background(), seed.Options{Env: env, Modules: moduleNames, Names: names, Force: force})
+	if err != nil {
+		log.Printf("⚠ Seeding failed: %v", err)
+		return err
+	}
+	log.Printf("Seeded %s: %d applied, %d already applied", env, len(res.Applied), len(res.Skipped))
+	return nil
+}
@@ -0,0 +1,63 @@
+package boot
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Module states reported by ModuleInfos
+// ModuleInfos 报告的模块状态
+const (
+	ModuleRegistered = "registered" // Registered but not part of the running service | 已注册但不属于当前服务
+	ModuleSkipped    = "skipped"    // Selected but missing database dependencies | 已选中但缺少数据库依赖
+	ModuleRunning    = "running"    // Initialized and started | 已初始化并启动
+	ModuleStopped    = "stopped"    // Stopped during shutdown | 关闭时已停止
+)
+
+// ModuleInfo describes a registered module
+// ModuleInfo 描述一个已注册的模块
+type ModuleInfo struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Databases []string `json:"databases"` // Required databases | 需要的数据库
+	Models    []string `json:"models"`    // Model types | 模型类型
+}
+
+var (
+	statusMu     sync.RWMutex
+	moduleStatus = make(map[string]string)
+)
+
+// setModuleStatus records the state of the named module
+// setModuleStatus 记录指定模块的状态
+func setModuleStatus(name, status string) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	moduleStatus[name] = status
+}
+
+// ModuleInfos returns every registered module with its state and dependencies
+// ModuleInfos 返回所有已注册模块及其状态和依赖
+func ModuleInfos() []ModuleInfo {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+
+	infos := make([]ModuleInfo, 0, len(modules))
+	for _, m := range modules {
+		dep := CheckModuleDependencies(m)
+		info := ModuleInfo{
+			Name:      m.Name(),
+			Status:    moduleStatus[m.Name()],
+			Databases: dep.Databases,
+			Models:    make([]string, len(dep.Models)),
+		}
+		if info.Status == "" {
+			info.Status = ModuleRegistered
+		}
+		for i, model := range dep.Models {
+			info.Models[i] = fmt.Sprintf("%T", model)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,387 @@
+package boot
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg"
+	"github.com/nuohe369/crab/pkg/graphql"
+	"github.com/nuohe369/crab/pkg/jwt"
+	"github.com/nuohe369/crab/pkg/pgsql"
+)
+
+// TestDSNEnv names the environment variable with a PostgreSQL data source for NewTestApp
+// TestDSNEnv 为 NewTestApp 使用的 PostgreSQL 数据源环境变量名
+const TestDSNEnv = "CRAB_TEST_DSN"
+
+// testDBSeq keeps the in-memory databases of test apps apart | testDBSeq 区分各测试应用的内存数据库
+var testDBSeq atomic.Int64
+
+// TestApp is the Fiber app of the given modules backed by miniredis and a test database
+// TestApp 为由 miniredis 和测试数据库支撑的指定模块的 Fiber 应用
+type TestApp struct {
+	App   *fiber.App
+	Redis *miniredis.Miniredis
+	DB    *pgsql.Client // nil when no database is available | 无可用数据库时为 nil
+
+	t      testing.TB
+	header http.Header
+	url    string
+}
+
+// NewTestApp starts modules the way the server does and tears everything down when the test ends.
+// The database is CRAB_TEST_DSN (PostgreSQL) when set, otherwise an in-memory SQLite database when
+// the test binary links a "sqlite3" or "sqlite" driver; without either, tests of modules that declare
+// models are skipped. The framework keeps global state, so test apps must not run in parallel.
+// NewTestApp 按服务器的方式启动模块，并在测试结束时全部清理。
+// 设置了 CRAB_TEST_DSN（PostgreSQL）时使用该数据库，否则在测试程序链接了 "sqlite3" 或 "sqlite" 驱动时使用内存 SQLite 数据库；
+// 两者都没有时，声明了模型的模块的测试会被跳过。框架持有全局状态，测试应用不能并行运行。
+//
+// Usage | 用法:
+//
+//	import _ "modernc.org/sqlite" // Optional in-memory database | 可选的内存数据库
+//
+//	func TestCreateArticle(t *testing.T) {
+//	    a := boot.NewTestApp(t, blog.New())
+//	    var got model.Article
+//	    a.AuthAs(1, "admin").Post("/blog/article", map[string]any{"title": "Hello"}).AssertOK().Decode(&got)
+//	}
+func NewTestApp(t testing.TB, mods ...Module) *TestApp {
+	t.Helper()
+	return NewTestAppConfig(t, "", mods...)
+}
+
+// NewTestAppConfig is NewTestApp with TOML merged over the test configuration, e.g. module sections
+// NewTestAppConfig 为合并 TOML 到测试配置之上的 NewTestApp，例如模块配置段
+func NewTestAppConfig(t testing.TB, data string, mods ...Module) *TestApp {
+	t.Helper()
+	db, hasDB := testDatabase()
+	if !hasDB {
+		for _, m := range mods {
+			if len(m.Models()) > 0 {
+				t.Skipf("boot: module %s declares models, set %s or link a sqlite driver", m.Name(), TestDSNEnv)
+			}
+		}
+	}
+
+	a := &TestApp{Redis: miniredis.RunT(t), t: t, header: http.Header{}}
+	dir := t.TempDir()
+	base := fmt.Sprintf(`
+[app]
+name = "crab-test"
+env = "test"
+
+[logger]
+dir = %q
+
+%s
+auto_migrate = true
+
+[redis.default]
+addr = %q
+
+[jwt]
+secret = "crab-test-secret"
+expire = "1h"
+`, filepath.Join(dir, "logs"), db, a.Redis.Addr())
+	paths := []string{writeTestFile(t, dir, "config.toml", base)}
+	if data != "" {
+		paths = append(paths, writeTestFile(t, dir, "override.toml", data))
+	}
+	if err := config.Load(paths...); err != nil {
+		t.Fatalf("boot: load test configuration: %v", err)
+	}
+	initInfra()
+	t.Cleanup(pkg.Close)
+	if hasDB {
+		a.DB = pgsql.Get()
+	}
+
+	prevModules, prevApp := modules, app
+	t.Cleanup(func() { modules, app = prevModules, prevApp })
+	modules = mods
+	if hasDB {
+		migrateModels(mods)
+	}
+
+	app = newApp()
+	a.App = app
+	setupApp(middleware.Toggles{})
+	for _, m := range mods {
+		if err := m.Init(NewModuleContext(app.Group("/"+m.Name()), nil)); err != nil {
+			t.Fatalf("boot: module %s initialization failed: %v", m.Name(), err)
+		}
+	}
+	if graphql.GetConfig().Path != "" {
+		if err := graphql.Build(); err != nil {
+			t.Fatalf("boot: GraphQL schema build failed: %v", err)
+		}
+	}
+	for i, m := range mods {
+		if err := m.Start(); err != nil {
+			t.Fatalf("boot: module %s start failed: %v", m.Name(), err)
+		}
+		setModuleStatus(m.Name(), ModuleRunning)
+		t.Cleanup(func() {
+			if err := mods[i].Stop(); err != nil {
+				t.Errorf("boot: module %s stop failed: %v", mods[i].Name(), err)
+			}
+		})
+	}
+	return a
+}
+
+// testDatabase returns the [database.default] section of a test app and whether it is usable;
+// an unusable one points at an unreachable server, engines connect lazily
+// testDatabase 返回测试应用的 [database.default] 配置段及其是否可用；不可用时指向无法访问的服务器，引擎延迟连接
+func testDatabase() (section string, ok bool) {
+	if dsn := os.Getenv(TestDSNEnv); dsn != "" {
+		return fmt.Sprintf("[database.default]\nsource = %q", dsn), true
+	}
+	for _, driver := range []string{"sqlite3", "sqlite"} {
+		if slices.Contains(sql.Drivers(), driver) {
+			source := fmt.Sprintf("file:crabtest%d?mode=memory&cache=shared", testDBSeq.Add(1))
+			return fmt.Sprintf("[database.default]\ndriver = %q\nsource = %q", driver, source), true
+		}
+	}
+	return "[database.default]\nsource = \"postgres://crab@127.0.0.1:1/crab?sslmode=disable\"", false
+}
+
+// writeTestFile writes a configuration file into dir | writeTestFile 将配置文件写入 dir
+func writeTestFile(t testing.TB, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("boot: write %s: %v", name, err)
+	}
+	return path
+}
+
+// Fixtures inserts beans into their databases, see model.GetDB
+// Fixtures 将 beans 插入各自的数据库，见 model.GetDB
+func (a *TestApp) Fixtures(beans ...any) {
+	a.t.Helper()
+	if a.DB == nil {
+		a.t.Fatalf("boot: fixtures need a database, set %s or link a sqlite driver", TestDSNEnv)
+	}
+	for _, bean := range beans {
+		if _, err := model.GetDB(bean).Insert(bean); err != nil {
+			a.t.Fatalf("boot: insert fixture %T: %v", bean, err)
+		}
+	}
+}
+
+// WithHeader returns a copy of the app that sends the header with every request
+// WithHeader 返回每个请求都携带该请求头的应用副本
+func (a *TestApp) WithHeader(key, value string) *TestApp {
+	c := *a
+	c.header = a.header.Clone()
+	c.header.Set(key, value)
+	return &c
+}
+
+// AuthAs returns a copy of the app that sends a JWT of the user
+// AuthAs 返回携带该用户 JWT 的应用副本
+func (a *TestApp) AuthAs(id int64, plat string) *TestApp {
+	a.t.Helper()
+	token, err := jwt.Get().Generate(id, plat)
+	if err != nil {
+		a.t.Fatalf("boot: generate token: %v", err)
+	}
+	return a.WithHeader(fiber.HeaderAuthorization, "Bearer "+token)
+}
+
+// Get sends a GET request | Get 发送 GET 请求
+func (a *TestApp) Get(path string) *TestResponse {
+	a.t.Helper()
+	return a.Request(fiber.MethodGet, path, nil)
+}
+
+// Post sends body as JSON | Post 以 JSON 发送 body
+func (a *TestApp) Post(path string, body any) *TestResponse {
+	a.t.Helper()
+	return a.Request(fiber.MethodPost, path, body)
+}
+
+// Put sends body as JSON | Put 以 JSON 发送 body
+func (a *TestApp) Put(path string, body any) *TestResponse {
+	a.t.Helper()
+	return a.Request(fiber.MethodPut, path, body)
+}
+
+// Delete sends a DELETE request | Delete 发送 DELETE 请求
+func (a *TestApp) Delete(path string) *TestResponse {
+	a.t.Helper()
+	return a.Request(fiber.MethodDelete, path, nil)
+}
+
+// Request sends body as JSON, or as is when it is a string or []byte; nil sends no body
+// Request 以 JSON 发送 body，为 string 或 []byte 时原样发送；nil 时不发送请求体
+func (a *TestApp) Request(method, path string, body any) *TestResponse {
+	a.t.Helper()
+	var (
+		r           io.Reader
+		contentType string
+	)
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatalf("boot: marshal request body: %v", err)
+		}
+		r, contentType = bytes.NewReader(data), fiber.MIMEApplicationJSON
+	}
+	req, err := http.NewRequest(method, path, r)
+	if err != nil {
+		a.t.Fatalf("boot: build request: %v", err)
+	}
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	return a.Do(req)
+}
+
+// Do sends req through the app and reads the response
+// Do 通过应用发送 req 并读取响应
+func (a *TestApp) Do(req *http.Request) *TestResponse {
+	a.t.Helper()
+	for k, v := range a.header {
+		if req.Header.Get(k) == "" {
+			req.Header[k] = v
+		}
+	}
+	resp, err := a.App.Test(req, -1)
+	if err != nil {
+		a.t.Fatalf("boot: %s %s: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Fatalf("boot: read response of %s %s: %v", req.Method, req.URL, err)
+	}
+
+	r := &TestResponse{Status: resp.StatusCode, Header: resp.Header, Body: body, Code: -1, t: a.t, req: req.Method + " " + req.URL.String()}
+	var env struct {
+		Code *response.Code  `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &env) == nil && env.Code != nil {
+		r.Code, r.Msg, r.Data = *env.Code, env.Msg, env.Data
+	}
+	return r
+}
+
+// URL serves the app on a loopback port and returns its base URL, for clients that need a socket
+// such as generated API clients and WebSockets
+// URL 在回环端口上提供应用服务并返回基础 URL，供需要套接字的客户端使用，例如生成的 API 客户端和 WebSocket
+func (a *TestApp) URL() string {
+	a.t.Helper()
+	if a.url != "" {
+		return a.url
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		a.t.Fatalf("boot: listen: %v", err)
+	}
+	go a.App.Listener(ln)
+	a.t.Cleanup(func() { a.App.Shutdown() })
+	a.url = "http://" + ln.Addr().String()
+	return a.url
+}
+
+// TestResponse is a response of a TestApp, the envelope already decoded
+// TestResponse 为 TestApp 的响应，响应信封已解码
+type TestResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Code   response.Code   // Envelope code, -1 when the body is not an envelope | 信封响应码，响应体不是信封时为 -1
+	Msg    string          // Envelope message | 信封消息
+	Data   json.RawMessage // Envelope data | 信封数据
+
+	t   testing.TB
+	req string
+}
+
+// AssertStatus fails the test unless the HTTP status is status
+// AssertStatus 在 HTTP 状态码不是 status 时使测试失败
+func (r *TestResponse) AssertStatus(status int) *TestResponse {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("%s: status %d, want %d: %s", r.req, r.Status, status, r.Body)
+	}
+	return r
+}
+
+// AssertCode fails the test unless the envelope code is code
+// AssertCode 在信封响应码不是 code 时使测试失败
+func (r *TestResponse) AssertCode(code response.Code) *TestResponse {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("%s: code %d, want %d: %s", r.req, r.Code, code, r.Body)
+	}
+	return r
+}
+
+// AssertOK fails the test unless the request succeeded with status 200 and code 0
+// AssertOK 在请求未以状态码 200 和响应码 0 成功时使测试失败
+func (r *TestResponse) AssertOK() *TestResponse {
+	r.t.Helper()
+	return r.AssertStatus(fiber.StatusOK).AssertCode(response.CodeSuccess)
+}
+
+// AssertData fails the test unless the envelope data equals want as JSON
+// AssertData 在信封数据与 want 的 JSON 不相等时使测试失败
+func (r *TestResponse) AssertData(want any) *TestResponse {
+	r.t.Helper()
+	data, err := json.Marshal(want)
+	if err != nil {
+		r.t.Fatalf("boot: marshal expected data: %v", err)
+	}
+	var got, exp any
+	if len(r.Data) > 0 {
+		if err := json.Unmarshal(r.Data, &got); err != nil {
+			r.t.Fatalf("%s: decode data: %v", r.req, err)
+		}
+	}
+	if err := json.Unmarshal(data, &exp); err != nil {
+		r.t.Fatalf("boot: decode expected data: %v", err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		r.t.Fatalf("%s: data %s, want %s", r.req, r.Data, data)
+	}
+	return r
+}
+
+// Decode unmarshals the envelope data into v | Decode 将信封数据解码到 v
+func (r *TestResponse) Decode(v any) *TestResponse {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		r.t.Fatalf("%s: decode data into %T: %v: %s", r.req, v, err, r.Data)
+	}
+	return r
+}
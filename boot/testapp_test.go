@@ -0,0 +1,47 @@
+package boot
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// echoModule serves a public and an authenticated route
+type echoModule struct{ stubModule }
+
+func (m echoModule) Init(ctx *ModuleContext) error {
+	ctx.Router.Post("/echo", func(c *fiber.Ctx) error {
+		var body map[string]any
+		if err := c.BodyParser(&body); err != nil {
+			return response.FailCode(c, response.CodeParamError)
+		}
+		return response.OK(c, body)
+	})
+	ctx.Router.Get("/me", middleware.RequireAuth(), func(c *fiber.Ctx) error {
+		return response.OK(c, fiber.Map{"id": middleware.GetClaims(c).ID})
+	})
+	return nil
+}
+
+func TestNewTestApp(t *testing.T) {
+	a := NewTestApp(t, echoModule{"echo"})
+
+	a.Post("/echo/echo", map[string]any{"name": "crab"}).AssertOK().AssertData(map[string]any{"name": "crab"})
+	a.Post("/echo/echo", "{").AssertCode(response.CodeParamError)
+	a.Get("/echo/me").AssertStatus(fiber.StatusUnauthorized)
+
+	var me struct {
+		ID int64 `json:"id"`
+	}
+	a.AuthAs(42, "app").Get("/echo/me").AssertOK().Decode(&me)
+	if me.ID != 42 {
+		t.Errorf("id = %d, want 42", me.ID)
+	}
+	a.Get("/echo/missing").AssertStatus(fiber.StatusNotFound)
+
+	if err := a.Redis.Set("k", "v"); err != nil || a.DB != nil {
+		t.Errorf("Redis.Set = %v, DB = %v", err, a.DB)
+	}
+}
@@ -0,0 +1,140 @@
+package boot
+
+import (
+	stderrors "errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/webhook"
+)
+
+// subscriptionRequest is the body of POST and PUT {admin_path}/subscriptions
+// subscriptionRequest 是 POST 和 PUT {admin_path}/subscriptions 的请求体
+type subscriptionRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"` // Generated on create when empty, kept on update when empty | 创建时为空则自动生成，更新时为空则保持不变
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // Omitted means true | 省略时为 true
+}
+
+// setupWebhookAdmin mounts the subscription and redelivery endpoints when [webhook] admin_path is set
+// setupWebhookAdmin 在设置 [webhook] admin_path 时挂载订阅和重新投递接口
+func setupWebhookAdmin() {
+	cfg := webhook.Get().Config()
+	if cfg.AdminPath == "" {
+		return
+	}
+
+	group := app.Group(cfg.AdminPath, middleware.RequireAuth(), authz.RequirePermission(cfg.Permission))
+	group.Get("/subscriptions", func(c *fiber.Ctx) error {
+		subs, err := webhook.Get().Store().Subscriptions(c.UserContext())
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, subs)
+	})
+	group.Post("/subscriptions", saveWebhookSubscription)
+	group.Put("/subscriptions/:id", saveWebhookSubscription)
+	group.Delete("/subscriptions/:id", func(c *fiber.Ctx) error {
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return errors.ErrParamInvalid("invalid subscription id")
+		}
+		if err := webhook.Get().Store().DeleteSubscription(c.UserContext(), id); err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, nil)
+	})
+	group.Get("/subscriptions/:id/deliveries", func(c *fiber.Ctx) error {
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return errors.ErrParamInvalid("invalid subscription id")
+		}
+		list, err := webhook.Get().Store().Deliveries(c.UserContext(), id, c.QueryInt("limit", 50))
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, list)
+	})
+	group.Get("/deliveries/:id", func(c *fiber.Ctx) error {
+		store := webhook.Get().Store()
+		delivery, err := store.GetDelivery(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		if delivery == nil {
+			return errors.ErrNotFound()
+		}
+		attempts, err := store.Attempts(c.UserContext(), delivery.ID)
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, fiber.Map{"delivery": delivery, "attempts": attempts})
+	})
+	group.Post("/deliveries/:id/redeliver", func(c *fiber.Ctx) error {
+		err := webhook.Get().Redeliver(c.UserContext(), c.Params("id"))
+		if stderrors.Is(err, webhook.ErrNotFound) {
+			return errors.ErrNotFound()
+		}
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, nil)
+	})
+}
+
+// saveWebhookSubscription creates a subscription, or replaces the one of :id
+// saveWebhookSubscription 创建订阅，或替换 :id 对应的订阅
+func saveWebhookSubscription(c *fiber.Ctx) error {
+	var req subscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	sub := &webhook.Subscription{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+	}
+
+	dispatcher := webhook.Get()
+	if c.Params("id") == "" {
+		err := dispatcher.Subscribe(c.UserContext(), sub)
+		if stderrors.Is(err, webhook.ErrEndpoint) || stderrors.Is(err, webhook.ErrNoEvents) {
+			return errors.ErrParamInvalid(err.Error())
+		}
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, sub)
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrParamInvalid("invalid subscription id")
+	}
+	existing, err := dispatcher.Store().GetSubscription(c.UserContext(), id)
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	if existing == nil {
+		return errors.ErrNotFound()
+	}
+	if err := sub.Validate(); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	sub.ID, sub.CreatedAt = existing.ID, existing.CreatedAt
+	if sub.Secret == "" {
+		sub.Secret = existing.Secret
+	}
+	if err := dispatcher.Store().SaveSubscription(c.UserContext(), sub); err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, sub)
+}
@@ -0,0 +1,262 @@
+// Package apikey provides API key management for machine-to-machine integrations
+// apikey 包提供用于机器对机器集成的 API 密钥管理
+//
+// Keys look like "ck_<prefix>_<secret>". Only the SHA-256 hash is stored, the
+// plain key is returned once at creation time.
+// 密钥格式为 "ck_<prefix>_<secret>"。仅存储 SHA-256 哈希，明文密钥只在创建时返回一次。
+//
+// Usage | 用法:
+//
+//	plain, key, err := apikey.Create(ctx, apikey.CreateOptions{Name: "erp", Scopes: []string{"order:read"}})
+//
+//	router.Use(middleware.APIKey("order:read"))
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/snowflake"
+	"github.com/nuohe369/crab/pkg/util"
+)
+
+var log = logger.NewSystem("apikey")
+
+var (
+	ErrInvalidKey = errors.New("apikey: invalid key") // Key is malformed, unknown or revoked | 密钥格式错误、不存在或已吊销
+	ErrExpiredKey = errors.New("apikey: key expired") // Key is past its expiry | 密钥已过期
+)
+
+// keyPrefix is the fixed prefix of every plain key
+// keyPrefix 是所有明文密钥的固定前缀
+const keyPrefix = "ck_"
+
+// Tier represents a rate tier applied to keys
+// Tier 表示应用于密钥的限流等级
+type Tier struct {
+	Max    int           `toml:"max"`    // Max requests per window | 每个窗口的最大请求数
+	Window time.Duration `toml:"window"` // Window duration | 窗口时长
+}
+
+// Config represents API key configuration
+// Config 表示 API 密钥配置
+type Config struct {
+	Header        string          `toml:"header"`         // Header carrying the key (default "X-API-Key") | 携带密钥的请求头（默认 "X-API-Key"）
+	CacheTTL      time.Duration   `toml:"cache_ttl"`      // Key lookup cache TTL (default 1m) | 密钥查询缓存 TTL（默认 1 分钟）
+	TouchInterval time.Duration   `toml:"touch_interval"` // Minimum interval between last-used updates (default 1m) | 最后使用时间更新最小间隔（默认 1 分钟）
+	DefaultTier   string          `toml:"default_tier"`   // Tier for keys without one | 未指定等级的密钥使用的等级
+	Tiers         map[string]Tier `toml:"tiers"`          // Rate tiers by name | 按名称定义的限流等级
+}
+
+var (
+	cfg = Config{
+		Header:        "X-API-Key",
+		CacheTTL:      time.Minute,
+		TouchInterval: time.Minute,
+	}
+	touched sync.Map // map[int64]time.Time, last persisted usage | 最后一次持久化的使用时间
+)
+
+// Init initializes API key configuration
+// Init 初始化 API 密钥配置
+func Init(c Config) {
+	if c.Header == "" {
+		c.Header = "X-API-Key"
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = time.Minute
+	}
+	if c.TouchInterval <= 0 {
+		c.TouchInterval = time.Minute
+	}
+	cfg = c
+	log.Info("API key initialized: header=%s, tiers=%d", cfg.Header, len(cfg.Tiers))
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// TierOf returns the rate tier of a key, false if the key is unlimited
+// TierOf 返回密钥的限流等级，不限流时返回 false
+func TierOf(k *APIKey) (Tier, bool) {
+	name := k.Tier
+	if name == "" {
+		name = cfg.DefaultTier
+	}
+	t, ok := cfg.Tiers[name]
+	if !ok || t.Max <= 0 {
+		return Tier{}, false
+	}
+	if t.Window <= 0 {
+		t.Window = time.Minute
+	}
+	return t, true
+}
+
+// CreateOptions represents options for creating a key
+// CreateOptions 表示创建密钥的选项
+type CreateOptions struct {
+	Name      string     // Display name | 显示名称
+	OwnerID   int64      // Owning user, 0 for system keys | 所属用户，系统密钥为 0
+	Scopes    []string   // Granted scopes | 授予的权限范围
+	Tier      string     // Rate tier name | 限流等级名称
+	ExpiresAt *time.Time // Optional expiry | 可选过期时间
+}
+
+// Create creates a key and returns the plain key (shown only once)
+// Create 创建密钥并返回明文密钥（仅显示一次）
+func Create(ctx context.Context, opts CreateOptions) (string, *APIKey, error) {
+	db, err := model.GetDBSafe(&APIKey{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefix := strings.ToLower(util.RandomString(8))
+	plain := keyPrefix + prefix + "_" + util.RandomString(32)
+
+	k := &APIKey{
+		Name:      opts.Name,
+		Prefix:    prefix,
+		KeyHash:   util.SHA256(plain),
+		OwnerID:   snowflake.SnowflakeID(opts.OwnerID),
+		Scopes:    opts.Scopes,
+		Tier:      opts.Tier,
+		Status:    StatusActive,
+		ExpiresAt: opts.ExpiresAt,
+	}
+	if _, err := db.Context(ctx).Insert(k); err != nil {
+		return "", nil, err
+	}
+	return plain, k, nil
+}
+
+// Validate resolves a plain key to its record
+// Validate 将明文密钥解析为对应记录
+func Validate(ctx context.Context, plain string) (*APIKey, error) {
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	hash := util.SHA256(plain)
+
+	var k APIKey
+	err := getOrLoad(ctx, cacheKey(hash), &k, func() (any, error) {
+		db, err := model.GetDBSafe(&APIKey{})
+		if err != nil {
+			return nil, err
+		}
+		var row APIKey
+		has, err := db.Context(ctx).Where("key_hash = ?", hash).Get(&row)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			// Cache misses too, so unknown keys cannot hammer the database | 同样缓存未命中，避免未知密钥压垮数据库
+			return APIKey{}, nil
+		}
+		return row, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if k.ID.IsZero() || k.Status != StatusActive {
+		return nil, ErrInvalidKey
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, ErrExpiredKey
+	}
+	return &k, nil
+}
+
+// Touch records last-used metadata, throttled by TouchInterval and written asynchronously
+// Touch 记录最后使用信息，受 TouchInterval 限制并异步写入
+func Touch(k *APIKey, ip string) {
+	now := time.Now()
+	id := k.ID.Int64()
+	if last, ok := touched.Load(id); ok && now.Sub(last.(time.Time)) < cfg.TouchInterval {
+		return
+	}
+	touched.Store(id, now)
+
+	go saveUsage(id, now, ip)
+}
+
+// saveUsage persists last-used metadata, replaced in tests
+// saveUsage 持久化最后使用信息，测试中可替换
+var saveUsage = func(id int64, at time.Time, ip string) {
+	db, err := model.GetDBSafe(&APIKey{})
+	if err != nil {
+		return
+	}
+	row := &APIKey{LastUsedAt: &at, LastUsedIP: ip}
+	if _, err := db.ID(id).Cols("last_used_at", "last_used_ip").Update(row); err != nil {
+		log.Warn("Failed to record API key usage: %v", err)
+	}
+}
+
+// Revoke revokes a key
+// Revoke 吊销密钥
+func Revoke(ctx context.Context, id int64) error {
+	db, err := model.GetDBSafe(&APIKey{})
+	if err != nil {
+		return err
+	}
+
+	var k APIKey
+	has, err := db.Context(ctx).ID(id).Get(&k)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return ErrInvalidKey
+	}
+
+	if _, err := db.Context(ctx).ID(id).Cols("status").Update(&APIKey{Status: StatusRevoked}); err != nil {
+		return err
+	}
+	if c := cache.Get(); c != nil {
+		return c.Del(ctx, cacheKey(k.KeyHash))
+	}
+	return nil
+}
+
+// List returns the keys owned by a user
+// List 返回用户拥有的密钥
+func List(ctx context.Context, ownerID int64) ([]APIKey, error) {
+	db, err := model.GetDBSafe(&APIKey{})
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	err = db.Context(ctx).Where("owner_id = ?", ownerID).Desc("created_at").Find(&keys)
+	return keys, err
+}
+
+// getOrLoad uses pkg/cache when available, otherwise calls the loader directly
+// getOrLoad 在 pkg/cache 可用时使用缓存，否则直接调用加载函数
+func getOrLoad(ctx context.Context, key string, dest *APIKey, loader func() (any, error)) error {
+	if c := cache.Get(); c != nil {
+		return c.GetOrSet(ctx, key, dest, cfg.CacheTTL, loader)
+	}
+	val, err := loader()
+	if err != nil {
+		return err
+	}
+	*dest = val.(APIKey)
+	return nil
+}
+
+// cacheKey returns the cache key for a key hash
+// cacheKey 返回密钥哈希的缓存键
+func cacheKey(hash string) string {
+	return "apikey:" + hash
+}
@@ -0,0 +1,151 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/util"
+)
+
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "apikey-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useCache installs a miniredis-backed default cache, no database is configured
+func useCache(t *testing.T) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New failed: %v", err)
+	}
+	cache.Init(rdb, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	t.Cleanup(func() {
+		cache.Get().Close()
+		rdb.Close()
+	})
+}
+
+// seed caches rec as the record of plain
+func seed(t *testing.T, plain string, rec APIKey) {
+	t.Helper()
+	if err := cache.SetValue(context.Background(), cacheKey(util.SHA256(plain)), rec, time.Minute); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	useCache(t)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+
+	seed(t, "ck_active_secret", APIKey{ID: 1, Name: "erp", Status: StatusActive, Scopes: []string{"order:read"}})
+	seed(t, "ck_revoked_secret", APIKey{ID: 2, Status: StatusRevoked})
+	seed(t, "ck_expired_secret", APIKey{ID: 3, Status: StatusActive, ExpiresAt: &past})
+	// Unknown keys are cached as an empty record | 未知密钥以空记录缓存
+	seed(t, "ck_unknown_secret", APIKey{})
+
+	k, err := Validate(ctx, "ck_active_secret")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if k.Name != "erp" || len(k.Scopes) != 1 {
+		t.Errorf("Unexpected key: %+v", k)
+	}
+
+	tests := []struct {
+		plain string
+		want  error
+	}{
+		{"no_prefix", ErrInvalidKey},
+		{"ck_revoked_secret", ErrInvalidKey},
+		{"ck_expired_secret", ErrExpiredKey},
+		{"ck_unknown_secret", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if _, err := Validate(ctx, tt.plain); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.plain, err, tt.want)
+		}
+	}
+
+	// A key in neither cache nor database reaches the loader | 缓存和数据库中均不存在的密钥会调用加载函数
+	if _, err := Validate(ctx, "ck_uncached_secret"); err == nil || errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected database error for uncached key, got %v", err)
+	}
+}
+
+func TestTouchThrottled(t *testing.T) {
+	prevCfg, prevSave := cfg, saveUsage
+	t.Cleanup(func() { cfg, saveUsage = prevCfg, prevSave })
+
+	var saved atomic.Int32
+	done := make(chan string, 4)
+	saveUsage = func(id int64, at time.Time, ip string) {
+		saved.Add(1)
+		done <- ip
+	}
+	Init(Config{TouchInterval: time.Hour})
+
+	k := &APIKey{ID: 42}
+	Touch(k, "10.0.0.1")
+	Touch(k, "10.0.0.2")
+
+	select {
+	case ip := <-done:
+		if ip != "10.0.0.1" {
+			t.Errorf("Expected first IP recorded, got %s", ip)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Usage was not saved")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := saved.Load(); n != 1 {
+		t.Errorf("Expected 1 usage write within the interval, got %d", n)
+	}
+
+	// Past the interval the next use is written again | 超过间隔后再次写入
+	touched.Store(int64(42), time.Now().Add(-2*time.Hour))
+	Touch(k, "10.0.0.3")
+	if ip := <-done; ip != "10.0.0.3" {
+		t.Errorf("Expected 10.0.0.3 recorded, got %s", ip)
+	}
+}
+
+func TestTierOf(t *testing.T) {
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+	Init(Config{
+		DefaultTier: "free",
+		Tiers: map[string]Tier{
+			"free": {Max: 10},
+			"pro":  {Max: 1000, Window: time.Second},
+			"none": {Max: 0},
+		},
+	})
+
+	if tier, ok := TierOf(&APIKey{}); !ok || tier.Max != 10 || tier.Window != time.Minute {
+		t.Errorf("Expected default tier with 1m window, got %+v, %v", tier, ok)
+	}
+	if tier, ok := TierOf(&APIKey{Tier: "pro"}); !ok || tier.Max != 1000 {
+		t.Errorf("Expected pro tier, got %+v, %v", tier, ok)
+	}
+	if _, ok := TierOf(&APIKey{Tier: "none"}); ok {
+		t.Error("Expected tier without max to be unlimited")
+	}
+}
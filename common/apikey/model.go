@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Key status constants | 密钥状态常量
+const (
+	StatusRevoked = 0 // Revoked | 已吊销
+	StatusActive  = 1 // Active | 有效
+)
+
+// APIKey represents an API key record
+// APIKey 表示 API 密钥记录
+type APIKey struct {
+	ID         snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	Name       string                `json:"name" xorm:"varchar(100) notnull 'name'"`                  // Display name | 显示名称
+	Prefix     string                `json:"prefix" xorm:"varchar(16) notnull index 'prefix'"`         // Public prefix for identification | 用于识别的公开前缀
+	KeyHash    string                `json:"-" xorm:"varchar(64) notnull unique 'key_hash'"`           // SHA-256 of the plain key | 明文密钥的 SHA-256
+	OwnerID    snowflake.SnowflakeID `json:"owner_id" xorm:"index 'owner_id' bigint"`                  // Owning user, 0 for system keys | 所属用户，系统密钥为 0
+	Scopes     []string              `json:"scopes" xorm:"json 'scopes'"`                              // Granted scopes | 授予的权限范围
+	Tier       string                `json:"tier" xorm:"varchar(32) 'tier'"`                           // Rate tier | 限流等级
+	Status     int                   `json:"status" xorm:"default(1) 'status'"`                        // Status: 1=active, 0=revoked | 状态: 1=有效, 0=已吊销
+	ExpiresAt  *time.Time            `json:"expires_at,omitempty" xorm:"'expires_at'"`                 // Expiry, nil means never | 过期时间，nil 表示永不过期
+	LastUsedAt *time.Time            `json:"last_used_at,omitempty" xorm:"'last_used_at'"`             // Last used time | 最后使用时间
+	LastUsedIP string                `json:"last_used_ip,omitempty" xorm:"varchar(64) 'last_used_ip'"` // Last used IP | 最后使用 IP
+	CreatedAt  time.Time             `json:"created_at" xorm:"created 'created_at'"`                   // Creation time | 创建时间
+	UpdatedAt  time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`                   // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (k *APIKey) TableName() string {
+	return "api_key"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (k *APIKey) BeforeInsert() {
+	if k.ID.IsZero() {
+		k.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{new(APIKey)}
+}
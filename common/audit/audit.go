@@ -0,0 +1,175 @@
+// Package audit records sensitive operations for compliance
+// Each entry keeps who did what to which target, the field diff, IP and request ID.
+// Entries are persisted asynchronously through mq when available.
+// audit 包为合规记录敏感操作
+// 每条记录保存操作人、操作、目标、字段差异、IP 和请求 ID。
+// mq 可用时通过 mq 异步持久化。
+//
+// Usage | 用法:
+//
+//	router.Use(audit.Middleware()) // records every successful mutation | 记录每个成功的变更请求
+//
+//	before := *user
+//	user.Status = 0
+//	audit.Record(ctx, "user.disable", "user:42", audit.Diff(before, *user))
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+var log = logger.NewSystem("audit")
+
+// Config represents audit configuration
+// Config 表示审计配置
+type Config struct {
+	Async      bool   `toml:"async"`      // Persist through mq when enabled | 启用 mq 时通过 mq 持久化
+	Topic      string `toml:"topic"`      // MQ topic (default "audit:log") | MQ 主题（默认 "audit:log"）
+	Group      string `toml:"group"`      // MQ consumer group (default "audit") | MQ 消费者组（默认 "audit"）
+	AdminPath  string `toml:"admin_path"` // Query endpoints, empty disables | 查询接口路径，为空时禁用
+	Permission string `toml:"permission"` // Permission required by the query endpoints (default "audit:read") | 查询接口要求的权限（默认 "audit:read"）
+}
+
+var (
+	cfg = Config{Topic: "audit:log", Group: "audit", Permission: "audit:read"}
+
+	mu     sync.Mutex
+	store  Store = dbStore{}
+	cancel context.CancelFunc
+	done   chan struct{}
+)
+
+// Init initializes audit configuration
+// Init 初始化审计配置
+func Init(c Config) {
+	if c.Topic == "" {
+		c.Topic = "audit:log"
+	}
+	if c.Group == "" {
+		c.Group = "audit"
+	}
+	if c.Permission == "" {
+		c.Permission = "audit:read"
+	}
+	cfg = c
+	log.Info("Audit initialized: async=%v, topic=%s", cfg.Async && mq.Enabled(), cfg.Topic)
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// SetStore replaces the store (e.g. to ship entries to another system)
+// SetStore 替换存储（例如将记录发送到其他系统）
+func SetStore(s Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	store = s
+}
+
+func getStore() Store {
+	mu.Lock()
+	defer mu.Unlock()
+	return store
+}
+
+// Record records an operation by the user of ctx on target
+// Record 记录 ctx 中的用户对目标执行的操作
+func Record(ctx context.Context, action, target string, changes Changes) error {
+	entry := &AuditLog{
+		ID:        snowflake.SnowflakeID(snowflake.Generate()),
+		Plat:      ctxutil.Plat(ctx),
+		Action:    action,
+		Target:    target,
+		Changes:   changes,
+		RequestID: ctxutil.RequestID(ctx),
+		CreatedAt: time.Now(),
+	}
+	if uid, ok := ctxutil.UserID(ctx); ok {
+		entry.UserID = snowflake.SnowflakeID(uid)
+	}
+	if m := metaFrom(ctx); m != nil {
+		m.recorded = true
+		entry.IP, entry.UserAgent, entry.Method, entry.Path = m.ip, m.userAgent, m.method, m.path
+	}
+	return write(ctxutil.Detach(ctx), entry)
+}
+
+// write publishes the entry to mq in async mode, otherwise saves it directly
+// write 在异步模式下将记录发布到 mq，否则直接保存
+func write(ctx context.Context, entry *AuditLog) error {
+	if cfg.Async && mq.Enabled() {
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err = mq.Publish(ctx, cfg.Topic, payload); err == nil {
+			return nil
+		}
+		log.WarnCtx(ctx, "Publish audit entry failed, saving directly: %v", err)
+	}
+	if err := getStore().Save(ctx, entry); err != nil {
+		return fmt.Errorf("audit: save %s: %w", entry.Action, err)
+	}
+	return nil
+}
+
+// Start consumes queued entries in the background when async mode is on
+// Start 在异步模式下于后台消费排队的记录
+func Start() {
+	if !cfg.Async || !mq.Enabled() {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel != nil {
+		return
+	}
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		if err := mq.Consume(ctx, cfg.Topic, cfg.Group, handle); err != nil && ctx.Err() == nil {
+			log.Error("Audit consumer stopped: %v", err)
+		}
+	}(done)
+	log.Info("Audit consumer started, topic: %s", cfg.Topic)
+}
+
+// Stop stops the background consumer
+// Stop 停止后台消费者
+func Stop() {
+	mu.Lock()
+	c, d := cancel, done
+	cancel = nil
+	mu.Unlock()
+	if c == nil {
+		return
+	}
+	c()
+	<-d
+}
+
+// handle saves one queued entry; returning nil acknowledges it
+// handle 保存一条排队的记录；返回 nil 表示确认
+func handle(ctx context.Context, m *mq.Message) error {
+	var entry AuditLog
+	if err := json.Unmarshal(m.Payload, &entry); err != nil {
+		log.Error("Dropping malformed audit entry %s: %v", m.ID, err)
+		return nil
+	}
+	// Leave unacknowledged on failure so the queue redelivers it | 失败时不确认，由队列重新投递
+	return getStore().Save(ctx, &entry)
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nuohe369/crab/common/ctxutil"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	entries []AuditLog
+	err     error
+}
+
+func (s *memStore) Save(_ context.Context, e *AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, *e)
+	return nil
+}
+
+func (s *memStore) Query(context.Context, Filter) ([]AuditLog, int64, error) {
+	return s.entries, int64(len(s.entries)), nil
+}
+
+func (s *memStore) Get(context.Context, int64) (*AuditLog, error) { return nil, nil }
+
+func useStore(t *testing.T) *memStore {
+	t.Helper()
+	s := &memStore{}
+	prev := getStore()
+	SetStore(s)
+	t.Cleanup(func() { SetStore(prev) })
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	type user struct {
+		Name     string `json:"name"`
+		Status   int    `json:"status"`
+		Password string `json:"-"`
+	}
+	changes := Diff(user{Name: "a", Status: 1, Password: "x"}, user{Name: "a", Status: 0, Password: "y"})
+	if len(changes) != 1 {
+		t.Fatalf("changes = %v, want only status", changes)
+	}
+	if c := changes["status"]; c.Before != float64(1) || c.After != float64(0) {
+		t.Errorf("status change = %+v", c)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	s := useStore(t)
+	ctx := ctxutil.WithUser(ctxutil.WithRequestID(context.Background(), "req-1"), 42, "admin")
+	m := &meta{ip: "10.0.0.1", method: "POST", path: "/admin/users/7/disable"}
+	ctx = context.WithValue(ctx, metaKey{}, m)
+
+	if err := Record(ctx, "user.disable", "user:7", Changes{"status": {Before: 1, After: 0}}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if len(s.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(s.entries))
+	}
+	e := s.entries[0]
+	if e.UserID.Int64() != 42 || e.Plat != "admin" || e.RequestID != "req-1" || e.IP != "10.0.0.1" || e.Target != "user:7" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e.ID.IsZero() || e.CreatedAt.IsZero() {
+		t.Error("ID and CreatedAt must be set at record time")
+	}
+	if !m.recorded {
+		t.Error("meta not marked as recorded")
+	}
+}
+
+func TestRecord_StoreError(t *testing.T) {
+	s := useStore(t)
+	s.err = errors.New("db down")
+	if err := Record(context.Background(), "user.delete", "user:1", nil); err == nil {
+		t.Error("expected store error")
+	}
+}
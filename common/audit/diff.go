@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Diff returns the fields that differ between two values of the same struct
+// Fields are compared by their JSON form, so fields tagged json:"-" (e.g. password hashes) never appear.
+// Diff 返回同一结构体两个值之间不同的字段
+// 字段按 JSON 形式比较，因此标记为 json:"-" 的字段（例如密码哈希）不会出现。
+func Diff(before, after any) Changes {
+	b, a := toMap(before), toMap(after)
+	changes := Changes{}
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = Change{Before: bv, After: a[k]}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{After: av}
+		}
+	}
+	return changes
+}
+
+// toMap converts a value to its JSON object form, nil for non-objects
+// toMap 将值转换为 JSON 对象形式，非对象返回 nil
+func toMap(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if json.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// listReq is the query of GET {admin_path}; from and to are RFC 3339 times
+// listReq 是 GET {admin_path} 的查询参数；from 和 to 为 RFC 3339 时间
+type listReq struct {
+	Filter
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// RegisterRoutes mounts the query endpoints on router; protect the router with auth and permission middleware
+// RegisterRoutes 在 router 上挂载查询接口；请使用认证和权限中间件保护该路由
+func RegisterRoutes(router fiber.Router) {
+	router.Get("", list)
+	router.Get("/:id", get)
+}
+
+func list(c *fiber.Ctx) error {
+	var req listReq
+	if err := c.QueryParser(&req); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	f := req.Filter
+	var err error
+	if req.From != "" {
+		if f.From, err = time.Parse(time.RFC3339, req.From); err != nil {
+			return errors.ErrParamInvalid("invalid from")
+		}
+	}
+	if req.To != "" {
+		if f.To, err = time.Parse(time.RFC3339, req.To); err != nil {
+			return errors.ErrParamInvalid("invalid to")
+		}
+	}
+
+	entries, total, err := getStore().Query(c.UserContext(), f)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	return response.Page(c, entries, total, f.GetPage(), f.GetSize())
+}
+
+func get(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrParamInvalid("invalid id")
+	}
+	entry, err := getStore().Get(c.UserContext(), id)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if entry == nil {
+		return errors.ErrNotFound()
+	}
+	return response.OK(c, entry)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// meta holds request details for entries recorded during a request
+// meta 保存请求期间记录所需的请求信息
+type meta struct {
+	ip        string
+	userAgent string
+	method    string
+	path      string
+	recorded  bool // Set by Record so the middleware does not record twice | 由 Record 设置，避免中间件重复记录
+}
+
+type metaKey struct{}
+
+func metaFrom(ctx context.Context) *meta {
+	m, _ := ctx.Value(metaKey{}).(*meta)
+	return m
+}
+
+// Middleware adds request details to entries and records every successful mutation
+// POST, PUT, PATCH and DELETE requests that did not call Record are recorded as
+// action "<METHOD> <route>" on target "<path>". Place it after RequestContext and auth.
+// Middleware 为记录补充请求信息，并记录每个成功的变更请求
+// 未调用 Record 的 POST、PUT、PATCH 和 DELETE 请求会以操作 "<METHOD> <route>"、目标 "<path>" 记录。
+// 请放在 RequestContext 和认证中间件之后。
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		m := &meta{
+			ip:        strings.Clone(c.IP()),
+			userAgent: strings.Clone(c.Get(fiber.HeaderUserAgent)),
+			method:    strings.Clone(c.Method()),
+			path:      strings.Clone(c.Path()),
+		}
+		c.SetUserContext(context.WithValue(c.UserContext(), metaKey{}, m))
+
+		err := c.Next()
+		if err != nil || m.recorded || !isMutation(m.method) || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			return err
+		}
+		if rerr := Record(c.UserContext(), m.method+" "+c.Route().Path, m.path, nil); rerr != nil {
+			log.ErrorCtx(c.UserContext(), "Record %s %s failed: %v", m.method, m.path, rerr)
+		}
+		return nil
+	}
+}
+
+// isMutation reports whether the method changes state
+// isMutation 判断方法是否会修改状态
+func isMutation(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
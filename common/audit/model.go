@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Change is the before and after value of one field
+// Change 是一个字段修改前后的值
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Changes maps field names to their changes
+// Changes 将字段名映射到其变更
+type Changes map[string]Change
+
+// AuditLog represents one audited operation
+// AuditLog 表示一次被审计的操作
+type AuditLog struct {
+	ID        snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	UserID    snowflake.SnowflakeID `json:"user_id" xorm:"index 'user_id' bigint"`                      // Operator, 0 for system | 操作人，系统操作为 0
+	Plat      string                `json:"plat" xorm:"varchar(20) 'plat'"`                             // Operator platform | 操作人平台
+	Action    string                `json:"action" xorm:"varchar(100) notnull index 'action'"`          // e.g. "user.disable" | 例如 "user.disable"
+	Target    string                `json:"target" xorm:"varchar(200) index 'target'"`                  // e.g. "user:42" | 例如 "user:42"
+	Changes   Changes               `json:"changes,omitempty" xorm:"json 'changes'"`                    // Field diff | 字段差异
+	IP        string                `json:"ip" xorm:"varchar(64) 'ip'"`                                 // Client IP | 客户端 IP
+	UserAgent string                `json:"user_agent,omitempty" xorm:"varchar(255) 'user_agent'"`      // Client user agent | 客户端 UA
+	Method    string                `json:"method,omitempty" xorm:"varchar(10) 'method'"`               // HTTP method | HTTP 方法
+	Path      string                `json:"path,omitempty" xorm:"varchar(255) 'path'"`                  // Request path | 请求路径
+	RequestID string                `json:"request_id,omitempty" xorm:"varchar(64) index 'request_id'"` // Request ID | 请求 ID
+	CreatedAt time.Time             `json:"created_at" xorm:"index 'created_at'"`                       // Operation time | 操作时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (a *AuditLog) TableName() string {
+	return "audit_log"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (a *AuditLog) BeforeInsert() {
+	if a.ID.IsZero() {
+		a.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{new(AuditLog)}
+}
@@ -0,0 +1,282 @@
+// Package authz provides role-based access control (RBAC)
+// authz 包提供基于角色的访问控制（RBAC）
+//
+// Permissions use "resource:action" codes. A granted "*" matches everything,
+// and "article:*" matches every action on articles.
+// 权限使用 "resource:action" 编码。授予 "*" 匹配所有权限，"article:*" 匹配文章的所有操作。
+//
+// Usage | 用法:
+//
+//	router.Post("/articles", authz.RequirePermission("article:write"), h.Create)
+//
+//	ok, err := authz.HasPermission(ctx, userID, "article:delete")
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+var log = logger.NewSystem("authz")
+
+// Config represents authorization configuration
+// Config 表示授权配置
+type Config struct {
+	CacheTTL time.Duration `toml:"cache_ttl"` // Permission cache TTL (default 5m) | 权限缓存 TTL（默认 5 分钟）
+}
+
+// Resolver resolves the permissions granted to a user
+// Resolver 解析用户被授予的权限
+type Resolver interface {
+	Permissions(ctx context.Context, userID int64) ([]string, error)
+}
+
+var (
+	defaultResolver Resolver = &DBResolver{ttl: 5 * time.Minute}
+)
+
+// Init initializes the default resolver
+// Init 初始化默认解析器
+func Init(cfg Config) {
+	ttl := cfg.CacheTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	defaultResolver = &DBResolver{ttl: ttl}
+	log.Info("Authz initialized: cache_ttl=%v", ttl)
+}
+
+// SetResolver replaces the default resolver (e.g. to load permissions from another service)
+// SetResolver 替换默认解析器（例如从其他服务加载权限）
+func SetResolver(r Resolver) {
+	defaultResolver = r
+}
+
+// Permissions returns all permissions granted to a user
+// Permissions 返回用户被授予的所有权限
+func Permissions(ctx context.Context, userID int64) ([]string, error) {
+	return defaultResolver.Permissions(ctx, userID)
+}
+
+// HasPermission checks if a user holds all the given permissions
+// HasPermission 检查用户是否拥有所有给定权限
+func HasPermission(ctx context.Context, userID int64, perms ...string) (bool, error) {
+	granted, err := defaultResolver.Permissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range perms {
+		if !Match(granted, p) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasAnyPermission checks if a user holds at least one of the given permissions
+// HasAnyPermission 检查用户是否至少拥有一个给定权限
+func HasAnyPermission(ctx context.Context, userID int64, perms ...string) (bool, error) {
+	granted, err := defaultResolver.Permissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range perms {
+		if Match(granted, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Match checks if the required permission is covered by the granted set
+// Match 检查所需权限是否被已授予的权限集合覆盖
+func Match(granted []string, required string) bool {
+	for _, g := range granted {
+		if g == "*" || g == required {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(g, ":*"); ok && strings.HasPrefix(required, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ============ DB resolver | 数据库解析器 ============
+
+// DBResolver loads permissions from the authz tables, cached via pkg/cache
+// User roles and role permissions are cached separately, so changing a role
+// only invalidates that role.
+// DBResolver 从 authz 表加载权限，通过 pkg/cache 缓存
+// 用户角色与角色权限分开缓存，修改角色只需失效该角色。
+type DBResolver struct {
+	ttl time.Duration
+}
+
+// Permissions returns all permissions granted to a user through their roles
+// Permissions 返回用户通过角色获得的所有权限
+func (r *DBResolver) Permissions(ctx context.Context, userID int64) ([]string, error) {
+	roleIDs, err := r.userRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var perms []string
+	for _, roleID := range roleIDs {
+		codes, err := r.rolePermissions(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range codes {
+			if !seen[code] {
+				seen[code] = true
+				perms = append(perms, code)
+			}
+		}
+	}
+	return perms, nil
+}
+
+// userRoles returns the role IDs assigned to a user
+// userRoles 返回分配给用户的角色 ID
+func (r *DBResolver) userRoles(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+	err := getOrLoad(ctx, userRolesKey(userID), &ids, r.ttl, func() (any, error) {
+		db, err := model.GetDBSafe(&UserRole{})
+		if err != nil {
+			return nil, err
+		}
+		var rows []int64
+		err = db.Context(ctx).Table(&UserRole{}).Where("user_id = ?", userID).Cols("role_id").Find(&rows)
+		return rows, err
+	})
+	return ids, err
+}
+
+// rolePermissions returns the permission codes bound to a role
+// rolePermissions 返回绑定到角色的权限编码
+func (r *DBResolver) rolePermissions(ctx context.Context, roleID int64) ([]string, error) {
+	var codes []string
+	err := getOrLoad(ctx, rolePermsKey(roleID), &codes, r.ttl, func() (any, error) {
+		db, err := model.GetDBSafe(&Permission{})
+		if err != nil {
+			return nil, err
+		}
+		var rows []string
+		err = db.Context(ctx).Table("authz_permission").Alias("p").
+			Join("INNER", []string{"authz_role_permission", "rp"}, "rp.permission_id = p.id").
+			Where("rp.role_id = ?", roleID).
+			Select("p.code").
+			Find(&rows)
+		return rows, err
+	})
+	return codes, err
+}
+
+// getOrLoad uses pkg/cache when available, otherwise calls the loader directly
+// getOrLoad 在 pkg/cache 可用时使用缓存，否则直接调用加载函数
+func getOrLoad[T any](ctx context.Context, key string, dest *T, ttl time.Duration, loader func() (any, error)) error {
+	if c := cache.Get(); c != nil {
+		return c.GetOrSet(ctx, key, dest, ttl, loader)
+	}
+	val, err := loader()
+	if err != nil {
+		return err
+	}
+	*dest = val.(T)
+	return nil
+}
+
+// userRolesKey returns the cache key for a user's roles
+// userRolesKey 返回用户角色的缓存键
+func userRolesKey(userID int64) string {
+	return fmt.Sprintf("authz:user_roles:%d", userID)
+}
+
+// rolePermsKey returns the cache key for a role's permissions
+// rolePermsKey 返回角色权限的缓存键
+func rolePermsKey(roleID int64) string {
+	return fmt.Sprintf("authz:role_perms:%d", roleID)
+}
+
+// ============ Management | 管理 ============
+
+// AssignRole assigns a role to a user
+// AssignRole 为用户分配角色
+func AssignRole(ctx context.Context, userID, roleID int64) error {
+	db, err := model.GetDBSafe(&UserRole{})
+	if err != nil {
+		return err
+	}
+	ur := &UserRole{UserID: snowflake.SnowflakeID(userID), RoleID: snowflake.SnowflakeID(roleID)}
+	if _, err := db.Context(ctx).Insert(ur); err != nil {
+		return err
+	}
+	return InvalidateUser(ctx, userID)
+}
+
+// RevokeRole removes a role from a user
+// RevokeRole 移除用户的角色
+func RevokeRole(ctx context.Context, userID, roleID int64) error {
+	db, err := model.GetDBSafe(&UserRole{})
+	if err != nil {
+		return err
+	}
+	if _, err := db.Context(ctx).Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&UserRole{}); err != nil {
+		return err
+	}
+	return InvalidateUser(ctx, userID)
+}
+
+// GrantPermission binds a permission to a role
+// GrantPermission 将权限绑定到角色
+func GrantPermission(ctx context.Context, roleID, permissionID int64) error {
+	db, err := model.GetDBSafe(&RolePermission{})
+	if err != nil {
+		return err
+	}
+	rp := &RolePermission{RoleID: snowflake.SnowflakeID(roleID), PermissionID: snowflake.SnowflakeID(permissionID)}
+	if _, err := db.Context(ctx).Insert(rp); err != nil {
+		return err
+	}
+	return InvalidateRole(ctx, roleID)
+}
+
+// RevokePermission unbinds a permission from a role
+// RevokePermission 解除角色的权限绑定
+func RevokePermission(ctx context.Context, roleID, permissionID int64) error {
+	db, err := model.GetDBSafe(&RolePermission{})
+	if err != nil {
+		return err
+	}
+	if _, err := db.Context(ctx).Where("role_id = ? AND permission_id = ?", roleID, permissionID).Delete(&RolePermission{}); err != nil {
+		return err
+	}
+	return InvalidateRole(ctx, roleID)
+}
+
+// InvalidateUser drops the cached roles of a user
+// InvalidateUser 清除用户角色缓存
+func InvalidateUser(ctx context.Context, userID int64) error {
+	if c := cache.Get(); c != nil {
+		return c.Del(ctx, userRolesKey(userID))
+	}
+	return nil
+}
+
+// InvalidateRole drops the cached permissions of a role
+// InvalidateRole 清除角色权限缓存
+func InvalidateRole(ctx context.Context, roleID int64) error {
+	if c := cache.Get(); c != nil {
+		return c.Del(ctx, rolePermsKey(roleID))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package authz
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "authz-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{"article:write"}, "article:write", true},
+		{[]string{"article:read"}, "article:write", false},
+		{[]string{"article:*"}, "article:delete", true},
+		{[]string{"article:*"}, "articles:delete", false},
+		{[]string{"*"}, "user:ban", true},
+		{nil, "article:read", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.granted, tt.required); got != tt.want {
+			t.Errorf("Match(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+// useCache installs a miniredis-backed default cache for the test
+func useCache(t *testing.T) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New failed: %v", err)
+	}
+	cache.Init(rdb, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	t.Cleanup(func() {
+		cache.Get().Close()
+		rdb.Close()
+	})
+}
+
+func TestDBResolverCache(t *testing.T) {
+	useCache(t)
+	ctx := context.Background()
+	r := &DBResolver{ttl: time.Minute}
+
+	// Seed the cache, no database is configured so any load fails | 预置缓存，未配置数据库，任何加载都会失败
+	cache.SetValue(ctx, userRolesKey(1), []int64{10, 20}, time.Minute)
+	cache.SetValue(ctx, rolePermsKey(10), []string{"article:*"}, time.Minute)
+	cache.SetValue(ctx, rolePermsKey(20), []string{"user:read", "article:*"}, time.Minute)
+
+	perms, err := r.Permissions(ctx, 1)
+	if err != nil {
+		t.Fatalf("Permissions failed: %v", err)
+	}
+	slices.Sort(perms)
+	if !slices.Equal(perms, []string{"article:*", "user:read"}) {
+		t.Errorf("Expected deduplicated permissions, got %v", perms)
+	}
+
+	// Invalidating a role drops only that role | 失效角色只清除该角色
+	if err := InvalidateRole(ctx, 20); err != nil {
+		t.Fatalf("InvalidateRole failed: %v", err)
+	}
+	if _, err := r.Permissions(ctx, 1); err == nil {
+		t.Error("Expected reload of the invalidated role to hit the database")
+	}
+	cache.SetValue(ctx, rolePermsKey(20), []string{"user:write"}, time.Minute)
+	perms, _ = r.Permissions(ctx, 1)
+	if !Match(perms, "user:write") || Match(perms, "user:read") {
+		t.Errorf("Expected updated role permissions, got %v", perms)
+	}
+
+	if err := InvalidateUser(ctx, 1); err != nil {
+		t.Fatalf("InvalidateUser failed: %v", err)
+	}
+	if _, err := r.Permissions(ctx, 1); err == nil {
+		t.Error("Expected reload of the invalidated user to hit the database")
+	}
+}
@@ -0,0 +1,45 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+)
+
+// RequirePermission returns a middleware that requires all given permissions
+// Requires authentication middleware to set c.Locals("user_id") first
+// RequirePermission 返回要求拥有所有给定权限的中间件
+// 需要先由认证中间件设置 c.Locals("user_id")
+func RequirePermission(perms ...string) fiber.Handler {
+	return require(HasPermission, perms)
+}
+
+// RequireAnyPermission returns a middleware that requires at least one of the given permissions
+// RequireAnyPermission 返回要求至少拥有一个给定权限的中间件
+func RequireAnyPermission(perms ...string) fiber.Handler {
+	return require(HasAnyPermission, perms)
+}
+
+// require builds a permission-checking middleware
+// require 构建权限检查中间件
+func require(check func(ctx context.Context, userID int64, perms ...string) (bool, error), perms []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Fail closed when no authentication middleware ran | 未经过认证中间件时拒绝访问
+		userID, ok := c.Locals(ctxutil.LocalsUserID).(int64)
+		if !ok || userID == 0 {
+			return errors.ErrUnauthorized()
+		}
+
+		allowed, err := check(c.UserContext(), userID, perms...)
+		if err != nil {
+			log.Error("Failed to resolve permissions for user %d: %v", userID, err)
+			return errors.ErrServerError()
+		}
+		if !allowed {
+			return errors.ErrForbidden()
+		}
+		return c.Next()
+	}
+}
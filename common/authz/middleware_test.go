@@ -0,0 +1,94 @@
+package authz
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// stubResolver grants fixed permissions per user
+type stubResolver map[int64][]string
+
+func (r stubResolver) Permissions(_ context.Context, userID int64) ([]string, error) {
+	if userID < 0 {
+		return nil, stderrors.New("resolver down")
+	}
+	return r[userID], nil
+}
+
+// useResolver swaps the default resolver for the test
+func useResolver(t *testing.T, r Resolver) {
+	t.Helper()
+	prev := defaultResolver
+	SetResolver(r)
+	t.Cleanup(func() { SetResolver(prev) })
+}
+
+// newTestApp mounts handler behind a fake authentication step that sets user_id to user
+func newTestApp(user any, handler fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{
+		// Same code to status mapping as the boot error handler | 与 boot 错误处理器的状态码映射一致
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var bizErr *errors.BizError
+			if !stderrors.As(err, &bizErr) {
+				return fiber.DefaultErrorHandler(c, err)
+			}
+			switch bizErr.Code {
+			case response.CodeUnauth:
+				return c.SendStatus(fiber.StatusUnauthorized)
+			case response.CodeForbid:
+				return c.SendStatus(fiber.StatusForbidden)
+			default:
+				return c.SendStatus(fiber.StatusInternalServerError)
+			}
+		},
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		if user != nil {
+			c.Locals("user_id", user)
+		}
+		return c.Next()
+	}, handler, func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestRequirePermission(t *testing.T) {
+	useResolver(t, stubResolver{
+		1: {"article:*"},
+		2: {"article:read"},
+	})
+
+	tests := []struct {
+		name    string
+		user    any
+		handler fiber.Handler
+		want    int
+	}{
+		{"unauthenticated", nil, RequirePermission("article:write"), fiber.StatusUnauthorized},
+		{"wrong user_id type", "1", RequirePermission("article:write"), fiber.StatusUnauthorized},
+		{"zero user_id", int64(0), RequirePermission("article:write"), fiber.StatusUnauthorized},
+		{"wildcard grant", int64(1), RequirePermission("article:write", "article:delete"), fiber.StatusOK},
+		{"missing one of all", int64(2), RequirePermission("article:read", "article:write"), fiber.StatusForbidden},
+		{"any of", int64(2), RequireAnyPermission("article:write", "article:read"), fiber.StatusOK},
+		{"none of any", int64(2), RequireAnyPermission("user:ban"), fiber.StatusForbidden},
+		{"resolver error", int64(-1), RequirePermission("article:read"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newTestApp(tt.user, tt.handler).Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package authz
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Role represents a named set of permissions
+// Role 表示一组权限的命名集合
+type Role struct {
+	ID          snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	Code        string                `json:"code" xorm:"varchar(64) notnull unique 'code'"` // Role code, e.g. "editor" | 角色编码，如 "editor"
+	Name        string                `json:"name" xorm:"varchar(100) notnull 'name'"`       // Display name | 显示名称
+	Description string                `json:"description" xorm:"varchar(255) 'description'"` // Description | 描述
+	CreatedAt   time.Time             `json:"created_at" xorm:"created 'created_at'"`        // Creation time | 创建时间
+	UpdatedAt   time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`        // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (r *Role) TableName() string {
+	return "authz_role"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (r *Role) BeforeInsert() {
+	if r.ID.IsZero() {
+		r.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Permission represents a single permission in "resource:action" form
+// Permission 表示 "resource:action" 形式的单个权限
+type Permission struct {
+	ID          snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	Code        string                `json:"code" xorm:"varchar(128) notnull unique 'code'"` // Permission code, e.g. "article:write" | 权限编码，如 "article:write"
+	Name        string                `json:"name" xorm:"varchar(100) notnull 'name'"`        // Display name | 显示名称
+	Description string                `json:"description" xorm:"varchar(255) 'description'"`  // Description | 描述
+	CreatedAt   time.Time             `json:"created_at" xorm:"created 'created_at'"`         // Creation time | 创建时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (p *Permission) TableName() string {
+	return "authz_permission"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (p *Permission) BeforeInsert() {
+	if p.ID.IsZero() {
+		p.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// RolePermission binds a permission to a role
+// RolePermission 将权限绑定到角色
+type RolePermission struct {
+	ID           snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	RoleID       snowflake.SnowflakeID `json:"role_id" xorm:"notnull unique(role_perm) 'role_id' bigint"`             // Role ID | 角色 ID
+	PermissionID snowflake.SnowflakeID `json:"permission_id" xorm:"notnull unique(role_perm) 'permission_id' bigint"` // Permission ID | 权限 ID
+	CreatedAt    time.Time             `json:"created_at" xorm:"created 'created_at'"`                                // Creation time | 创建时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (rp *RolePermission) TableName() string {
+	return "authz_role_permission"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (rp *RolePermission) BeforeInsert() {
+	if rp.ID.IsZero() {
+		rp.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// UserRole assigns a role to a user
+// UserRole 为用户分配角色
+type UserRole struct {
+	ID        snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	UserID    snowflake.SnowflakeID `json:"user_id" xorm:"notnull unique(user_role) 'user_id' bigint"`       // User ID | 用户 ID
+	RoleID    snowflake.SnowflakeID `json:"role_id" xorm:"notnull unique(user_role) index 'role_id' bigint"` // Role ID | 角色 ID
+	CreatedAt time.Time             `json:"created_at" xorm:"created 'created_at'"`                          // Creation time | 创建时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (ur *UserRole) TableName() string {
+	return "authz_user_role"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (ur *UserRole) BeforeInsert() {
+	if ur.ID.IsZero() {
+		ur.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{
+		new(Role),
+		new(Permission),
+		new(RolePermission),
+		new(UserRole),
+	}
+}
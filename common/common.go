@@ -3,9 +3,43 @@
 package common
 
 import (
+	"context"
+	"strconv"
+
+	"github.com/nuohe369/crab/common/apikey"
+	"github.com/nuohe369/crab/common/audit"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/export"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/importer"
 	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/oauth"
+	"github.com/nuohe369/crab/common/org"
 	"github.com/nuohe369/crab/common/service"
+	"github.com/nuohe369/crab/common/session"
+	"github.com/nuohe369/crab/common/tenant"
+	"github.com/nuohe369/crab/pkg/captcha"
+	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/featureflag"
+	"github.com/nuohe369/crab/pkg/graphql"
 	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/notify"
+	"github.com/nuohe369/crab/pkg/otp"
+	"github.com/nuohe369/crab/pkg/payment"
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/quota"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/server"
+	"github.com/nuohe369/crab/pkg/sms"
+	"github.com/nuohe369/crab/pkg/transaction"
+	"github.com/nuohe369/crab/pkg/webhook"
+	"github.com/nuohe369/crab/pkg/ws"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 var log = logger.NewSystem("common")
@@ -15,9 +49,259 @@ var log = logger.NewSystem("common")
 func Init() {
 	log.Info("Initializing common business layer...")
 
+	// Capture error stacks in development | 开发环境中捕获错误调用栈
+	errors.SetStackCapture(config.IsDev())
+
+	// Load locale files for response and validation messages | 加载响应和校验消息的语言文件
+	if err := i18n.Init(config.GetI18n()); err != nil {
+		log.Warn("Failed to load i18n locale files: %v", err)
+	}
+
 	// Initialize rate limiter with Redis if available | 如果 Redis 可用，则初始化限流器
-	middleware.InitRateLimiter()
+	middleware.InitRateLimiter(config.GetRateLimit())
+
+	// Access log settings used by middleware.Setup | middleware.Setup 使用的访问日志配置
+	middleware.InitAccessLog(config.GetAccessLog())
+
+	// Response compression and ETags used by middleware.Setup | middleware.Setup 使用的响应压缩和 ETag 配置
+	middleware.InitCompress(config.GetCompress())
+	middleware.InitETag(config.GetETag())
+
+	// CORS, CSRF and security headers used by middleware.Setup | middleware.Setup 使用的 CORS、CSRF 和安全响应头配置
+	middleware.InitSecurity(config.GetSecurity(), config.GetApp().Env)
+
+	// Per-path body limits used by middleware.Setup | middleware.Setup 使用的按路径请求体限制
+	serverCfg := config.GetServer()
+	if serverCfg.BodyLimit <= 0 {
+		serverCfg.BodyLimit = server.DefaultBodyLimit
+	}
+	middleware.InitBodyLimits(serverCfg.BodyLimit, serverCfg.BodyLimits)
+	middleware.InitRequestTimeout(serverCfg.RequestTimeout)
+
+	// Maintenance mode used by middleware.Setup | middleware.Setup 使用的维护模式配置
+	middleware.InitMaintenance(config.GetMaintenance())
+
+	// Tenant resolution and tenant-scoped sessions | 租户解析和租户限定会话
+	tenant.Init(config.GetTenant())
+
+	// Initialize session manager (requires Redis) | 初始化会话管理器（需要 Redis）
+	session.Init(config.GetSession())
+
+	// Initialize RBAC permission resolver | 初始化 RBAC 权限解析器
+	authz.Init(config.GetAuthz())
+
+	// Initialize API key authentication | 初始化 API 密钥认证
+	apikey.Init(config.GetAPIKey())
+
+	// Initialize audit log | 初始化审计日志
+	audit.Init(config.GetAudit())
+
+	// Persist dead sagas in the default database | 在默认数据库中持久化死信 saga
+	if db := pgsql.Get(); db != nil {
+		transaction.SetDeadLetterStore(transaction.NewDBDeadLetterStore(db.Engine()))
+	}
+
+	// Keep mq schedules in the default database, shared by all instances | 在默认数据库中保存 mq 计划，供所有实例共享
+	if db := pgsql.Get(); db != nil {
+		mq.SetScheduleStore(mq.NewDBScheduleStore(db.Engine()))
+	}
 
 	// Initialize WebSocket service | 初始化 WebSocket 服务
 	service.InitWS()
+
+	// Forward configured mq topics to WebSocket clients | 将已配置的 mq 主题转发给 WebSocket 客户端
+	if err := service.InitBridge(config.GetWSBridge()); err != nil {
+		log.Warn("Invalid ws bridge routes skipped: %v", err)
+	}
+
+	// Initialize Server-Sent Events service | 初始化 Server-Sent Events 服务
+	service.InitSSE()
+
+	// Notification channels and preferences | 通知渠道和偏好
+	initNotify()
+
+	// Feature flags evaluated for the request user | 针对请求用户评估的功能开关
+	initFeatureFlag()
+
+	// Plan limits of users and organizations, counted in Redis when available | 用户和组织的套餐限额，Redis 可用时在 Redis 中计数
+	initQuota()
+
+	// Captchas and one-time codes protecting login and registration | 保护登录和注册的验证码和一次性验证码
+	initVerification()
+
+	// Social login providers, login states kept in Redis when available | 社交登录方式，Redis 可用时登录状态保存在 Redis 中
+	initOAuth()
+
+	// Webhook subscriptions and deliveries in the default database, delivered on jobs | Webhook 订阅和投递保存在默认数据库中，在 jobs 上投递
+	initWebhook()
+
+	// Payment providers, orders in the default database and reconciled on cron | 支付服务商，订单保存在默认数据库中并由定时任务对账
+	initPayment()
+
+	// GraphQL endpoint, modules register schema fragments in Init | GraphQL 接口，模块在 Init 中注册 schema 片段
+	graphql.Init(config.GetGraphQL())
+
+	// Background CSV/XLSX exports, finished exports are pushed over WebSocket | 后台 CSV/XLSX 导出，完成后通过 WebSocket 推送
+	export.Init(config.GetExport())
+	export.SetNotifier(func(ctx context.Context, userID int64, msgType string, payload any) error {
+		return service.PublishToUser(ctx, userID, ws.NewMessage(userID, msgType, payload))
+	})
+
+	// Bulk CSV/XLSX imports, large files run on jobs | 批量 CSV/XLSX 导入，大文件在 jobs 上运行
+	importer.Init(config.GetImport())
+	importer.SetNotifier(func(ctx context.Context, userID int64, msgType string, payload any) error {
+		return service.PublishToUser(ctx, userID, ws.NewMessage(userID, msgType, payload))
+	})
+}
+
+// initNotify registers the configured notification channels and the DB preference store
+// initNotify 注册已配置的通知渠道和数据库偏好存储
+func initNotify() {
+	notify.Init(config.GetNotify())
+	notify.Register(notify.NewWSChannel(func(ctx context.Context, userID int64, msgType string, payload any) error {
+		return service.PublishToUser(ctx, userID, ws.NewMessage(userID, msgType, payload))
+	}))
+	if client := email.Get(); client != nil {
+		notify.Register(notify.NewEmailChannel(client))
+	}
+	if client := sms.Get(); client != nil {
+		notify.Register(notify.NewSMSChannel(client))
+	}
+	if db := pgsql.Get(); db != nil {
+		notify.SetPreferenceStore(notify.NewDBPreferenceStore(db.Engine()))
+	}
+}
+
+// initFeatureFlag selects the feature flag store and evaluates flags for the user of the context
+// initFeatureFlag 选择功能开关存储，并针对 context 中的用户评估开关
+func initFeatureFlag() {
+	cfg := config.GetFeatureFlag()
+	var store featureflag.Store
+	switch cfg.Store {
+	case featureflag.StoreDB:
+		if db := pgsql.Get(); db != nil {
+			store = featureflag.NewDBStore(db.Engine())
+		}
+	case "", featureflag.StoreRedis:
+		if client := redis.Get(); client != nil {
+			store = featureflag.NewRedisStore(client)
+		}
+	default:
+		log.Warn("Unknown feature flag store %q, flags kept in memory", cfg.Store)
+	}
+	if store == nil {
+		store = featureflag.NewMemoryStore()
+	}
+	featureflag.Init(cfg, store)
+	featureflag.SetSubjectFunc(func(ctx context.Context) featureflag.Subject {
+		var s featureflag.Subject
+		if uid, ok := ctxutil.UserID(ctx); ok {
+			s.UserID = strconv.FormatInt(uid, 10)
+		}
+		return s
+	})
+}
+
+// initQuota keeps quota counters in Redis when available so all instances share them
+// initQuota 在 Redis 可用时将配额计数保存在 Redis 中，使所有实例共享
+func initQuota() {
+	cfg := config.GetQuota()
+	var store quota.Store
+	if client := redis.Get(); client != nil {
+		if rdb, ok := client.GetRaw().(goredis.UniversalClient); ok {
+			store = quota.NewRedisStore(rdb, cfg.KeyPrefix)
+		}
+	}
+	if store == nil {
+		store = quota.NewMemoryStore()
+	}
+	quota.Init(cfg, store)
+}
+
+// initVerification initializes captchas and one-time codes, kept in Redis when available
+// initVerification 初始化图片验证码和一次性验证码，Redis 可用时保存在 Redis 中
+func initVerification() {
+	var captchaStore captcha.Store
+	var otpStore otp.Store
+	if client := redis.Get(); client != nil {
+		if raw, ok := client.GetRaw().(goredis.UniversalClient); ok {
+			captchaStore = captcha.NewRedisStore(raw, "")
+			otpStore = otp.NewRedisStore(raw, "")
+		}
+	}
+	captcha.Init(config.GetCaptcha(), captchaStore)
+	otp.Init(config.GetOTP(), otpStore)
+	if email.Get() != nil {
+		otp.Get().Register(otp.ChannelEmail, otp.EmailSender{})
+	}
+	if sms.Get() != nil {
+		otp.Get().Register(otp.ChannelSMS, otp.SMSSender{Template: "verify_code"})
+	}
+}
+
+// initOAuth creates the configured social login providers
+// initOAuth 创建已配置的社交登录方式
+func initOAuth() {
+	var store oauth.StateStore
+	if client := redis.Get(); client != nil {
+		if raw, ok := client.GetRaw().(goredis.UniversalClient); ok {
+			store = oauth.NewRedisStateStore(raw, "")
+		}
+	}
+	if err := oauth.Init(config.GetOAuth(), store); err != nil {
+		log.Warn("Invalid social login providers skipped: %v", err)
+	}
+}
+
+// initWebhook keeps webhook data in the default database, or in memory without one,
+// and shares the inbound replay cache through Redis when available
+// initWebhook 将 Webhook 数据保存在默认数据库中，没有数据库时保存在内存中，
+// Redis 可用时通过 Redis 共享接收 Webhook 的防重放缓存
+func initWebhook() {
+	var store webhook.Store
+	if db := pgsql.Get(); db != nil {
+		store = webhook.NewDBStore(db.Engine())
+	}
+	webhook.Init(config.GetWebhook(), store)
+	if client := redis.Get(); client != nil {
+		webhook.SetReplayStore(webhook.NewRedisReplayStore(client))
+	}
+}
+
+// initPayment keeps orders in the default database, or in memory without one, and registers the
+// reconciliation job when [payment] reconcile is set
+// initPayment 将订单保存在默认数据库中，没有数据库时保存在内存中，并在设置 [payment] reconcile 时注册对账任务
+func initPayment() {
+	cfg := config.GetPayment()
+	var store payment.Store
+	if db := pgsql.Get(); db != nil {
+		store = payment.NewDBStore(db.Engine())
+	}
+	if err := payment.Init(cfg, store); err != nil {
+		log.Warn("Payment disabled: %v", err)
+		return
+	}
+	if m := payment.Get(); m != nil && cfg.Reconcile != "" && cron.Get() != nil {
+		if err := cron.Register(m.ReconcileJob()); err != nil {
+			log.Warn("Invalid payment reconcile spec %q: %v", cfg.Reconcile, err)
+		}
+	}
+}
+
+// Models returns the models owned by the common layer, migrated together with module models
+// Models 返回通用层拥有的模型，与模块模型一起迁移
+func Models() []any {
+	var models []any
+	models = append(models, authz.Models()...)
+	models = append(models, apikey.Models()...)
+	models = append(models, audit.Models()...)
+	models = append(models, &transaction.DeadSaga{})
+	models = append(models, &mq.Schedule{})
+	models = append(models, notify.Models()...)
+	models = append(models, featureflag.Models()...)
+	models = append(models, org.Models()...)
+	models = append(models, oauth.Models()...)
+	models = append(models, webhook.Models()...)
+	models = append(models, payment.Models()...)
+	return models
 }
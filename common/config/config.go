@@ -3,32 +3,103 @@
 package config
 
 import (
+	"log"
+
+	"github.com/nuohe369/crab/common/apikey"
+	"github.com/nuohe369/crab/common/audit"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/export"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/importer"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/oauth"
+	"github.com/nuohe369/crab/common/session"
+	"github.com/nuohe369/crab/common/tenant"
+	"github.com/nuohe369/crab/pkg/breaker"
+	"github.com/nuohe369/crab/pkg/bulkhead"
+	"github.com/nuohe369/crab/pkg/captcha"
+	"github.com/nuohe369/crab/pkg/cdc"
 	"github.com/nuohe369/crab/pkg/config"
+	"github.com/nuohe369/crab/pkg/crypto"
+	"github.com/nuohe369/crab/pkg/dbcache"
+	"github.com/nuohe369/crab/pkg/discovery"
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/featureflag"
+	"github.com/nuohe369/crab/pkg/graphql"
+	"github.com/nuohe369/crab/pkg/httpclient"
+	"github.com/nuohe369/crab/pkg/jobs"
 	"github.com/nuohe369/crab/pkg/jwt"
 	"github.com/nuohe369/crab/pkg/logger"
 	"github.com/nuohe369/crab/pkg/metrics"
 	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/notify"
+	"github.com/nuohe369/crab/pkg/otp"
+	"github.com/nuohe369/crab/pkg/payment"
 	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/quota"
 	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/search"
+	"github.com/nuohe369/crab/pkg/server"
+	"github.com/nuohe369/crab/pkg/sms"
 	"github.com/nuohe369/crab/pkg/storage"
+	"github.com/nuohe369/crab/pkg/timex"
 	"github.com/nuohe369/crab/pkg/trace"
+	"github.com/nuohe369/crab/pkg/webhook"
+	"github.com/nuohe369/crab/pkg/wsbridge"
 )
 
 // Config represents the application configuration
 // Config 表示应用程序配置
 type Config struct {
-	App       App                     `toml:"app"`
-	Server    Server                  `toml:"server"`
-	Logger    logger.Config           `toml:"logger"`
-	Snowflake Snowflake               `toml:"snowflake"`
-	Database  map[string]pgsql.Config `toml:"database"`
-	Redis     map[string]redis.Config `toml:"redis"`
-	MQ        mq.Config               `toml:"mq"`
-	JWT       jwt.Config              `toml:"jwt"`
-	Trace     trace.Config            `toml:"trace"`
-	Metrics   metrics.Config          `toml:"metrics"`
-	Storage   storage.Config          `toml:"storage"`
-	Services  []Service               `toml:"services"`
+	App         App                          `toml:"app"`
+	Server      Server                       `toml:"server"`
+	Logger      logger.Config                `toml:"logger"`
+	Snowflake   Snowflake                    `toml:"snowflake"`
+	Database    map[string]pgsql.Config      `toml:"database"`
+	Redis       map[string]redis.Config      `toml:"redis"`
+	DBCache     dbcache.Config               `toml:"dbcache"`
+	MQ          mq.Config                    `toml:"mq"`
+	Jobs        jobs.Config                  `toml:"jobs"`
+	CDC         cdc.Config                   `toml:"cdc"`
+	JWT         jwt.Config                   `toml:"jwt"`
+	FieldCrypto crypto.FieldConfig           `toml:"field_encryption"`
+	Session     session.Config               `toml:"session"`
+	Authz       authz.Config                 `toml:"authz"`
+	APIKey      apikey.Config                `toml:"apikey"`
+	Audit       audit.Config                 `toml:"audit"`
+	Export      export.Config                `toml:"export"`
+	Import      importer.Config              `toml:"import"`
+	WSBridge    wsbridge.Config              `toml:"ws_bridge"`
+	RateLimit   middleware.RateLimitSettings `toml:"ratelimit"`
+	AccessLog   middleware.AccessLogConfig   `toml:"access_log"`
+	Compress    middleware.CompressConfig    `toml:"compress"`
+	ETag        middleware.ETagConfig        `toml:"etag"`
+	Security    middleware.SecurityConfig    `toml:"security"`
+	Maintenance middleware.MaintenanceConfig `toml:"maintenance"`
+	Tenant      tenant.Config                `toml:"tenant"`
+	I18n        i18n.Config                  `toml:"i18n"`
+	Trace       trace.Config                 `toml:"trace"`
+	Metrics     metrics.Config               `toml:"metrics"`
+	Debug       Debug                        `toml:"debug"`
+	Breaker     map[string]breaker.Config    `toml:"breaker"`
+	Bulkhead    map[string]bulkhead.Config   `toml:"bulkhead"`
+	HTTPClient  httpclient.Config            `toml:"httpclient"`
+	Discovery   discovery.Config             `toml:"discovery"`
+	Email       email.Config                 `toml:"email"`
+	SMS         sms.Config                   `toml:"sms"`
+	Search      search.Config                `toml:"search"`
+	Time        timex.Config                 `toml:"time"`
+	Notify      notify.Config                `toml:"notify"`
+	FeatureFlag featureflag.Config           `toml:"featureflag"`
+	Quota       quota.Config                 `toml:"quota"`
+	Captcha     captcha.Config               `toml:"captcha"`
+	OTP         otp.Config                   `toml:"otp"`
+	OAuth       oauth.Config                 `toml:"oauth"`
+	Webhook     webhook.Config               `toml:"webhook"`
+	Payment     payment.Config               `toml:"payment"`
+	GraphQL     graphql.Config               `toml:"graphql"`
+	Storage     storage.Config               `toml:"storage"`
+	Services    []Service                    `toml:"services"`
 }
 
 // App represents application configuration
@@ -43,7 +114,7 @@ type App struct {
 // Snowflake represents Snowflake ID generator configuration
 // Snowflake 表示雪花 ID 生成器配置
 type Snowflake struct {
-	MachineID int64 `toml:"machine_id"` // Machine ID (0-1023), default 1 | 机器 ID (0-1023)，默认 1
+	MachineID int64 `toml:"machine_id" default:"1" validate:"min=0,max=1023"` // Machine ID (0-1023), default 1 | 机器 ID (0-1023)，默认 1
 }
 
 // IsDev returns true if the environment is development
@@ -78,32 +149,135 @@ func IsStrictDependencyCheck() bool {
 
 // Server represents server configuration
 // Server 表示服务器配置
-type Server struct {
-	Addr string `toml:"addr"` // Listen address | 监听地址
+type Server = server.Config
+
+// Debug represents the runtime debug endpoints configuration (pprof, expvar, GC stats, build info)
+// The endpoints are mounted when enabled or in dev; outside dev a token is required.
+// Debug 表示运行时调试接口配置（pprof、expvar、GC 统计、构建信息）
+// 启用或处于 dev 环境时挂载；非 dev 环境必须设置令牌。
+type Debug struct {
+	Enabled bool   `toml:"enabled"` // Mount the endpoints (always mounted in dev) | 挂载接口（dev 环境始终挂载）
+	Path    string `toml:"path"`    // Group path, default /debug | 路由组路径，默认 /debug
+	Token   string `toml:"token"`   // Access token sent in the X-Debug-Token header | 访问令牌，通过 X-Debug-Token 请求头传递
 }
 
 // Service defines a service configuration
 // Service 定义服务配置
 type Service struct {
-	Name    string   `toml:"name"`    // Service name | 服务名称
-	Addr    string   `toml:"addr"`    // Listen address | 监听地址
-	Modules []string `toml:"modules"` // Included modules | 包含的模块
+	Name              string   `toml:"name"`               // Service name | 服务名称
+	Addr              string   `toml:"addr"`               // Listen address | 监听地址
+	Modules           []string `toml:"modules"`            // Included modules | 包含的模块
+	EnableMiddleware  []string `toml:"enable_middleware"`  // Named middleware to force-enable | 强制启用的具名中间件
+	DisableMiddleware []string `toml:"disable_middleware"` // Named middleware to disable | 禁用的具名中间件
+}
+
+// Toggles returns the middleware toggles of the service
+// Toggles 返回服务的中间件开关
+func (s *Service) Toggles() middleware.Toggles {
+	if s == nil {
+		return middleware.Toggles{}
+	}
+	return middleware.Toggles{Enable: s.EnableMiddleware, Disable: s.DisableMiddleware}
+}
+
+var (
+	cfg      *Config
+	cfgPaths []string // Files the configuration was loaded from, in override order | 配置的加载文件，按覆盖顺序
+)
+
+// files returns the layers to load: a single path expands to its environment and local overrides
+// (see config.Layers), several paths are used as given.
+// files 返回要加载的层：单个路径展开为其环境和本地覆盖文件（见 config.Layers），多个路径按原样使用
+func files(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"config.toml"}
+	}
+	if len(paths) > 1 {
+		return paths, nil
+	}
+	return config.Layers(paths[0])
+}
+
+// Load loads configuration from the given files, later files overriding earlier ones
+// With one path, config.{env}.toml and config.local.toml next to it are layered on top when present.
+// Load 从给定文件加载配置，后面的文件覆盖前面的
+// 只有一个路径时，会在其上叠加同目录下存在的 config.{env}.toml 和 config.local.toml。
+func Load(paths ...string) error {
+	layers, err := files(paths)
+	if err != nil {
+		return err
+	}
+	cfg, cfgPaths = &Config{}, layers
+	if err := config.LoadFiles(layers, cfg); err != nil {
+		return err
+	}
+	return cfg.Security.Validate()
+}
+
+// MustLoad loads configuration like Load, exits on failure
+// MustLoad 像 Load 一样加载配置，失败时退出
+func MustLoad(paths ...string) {
+	if err := Load(paths...); err != nil {
+		log.Fatalf("Configuration loading failed: %v", err)
+	}
+}
+
+// Check decodes the configuration files without installing them and reports unknown keys and ENC() problems
+// The files are layered as in Load.
+// Check 解码配置文件但不设为全局配置，并报告未知键和 ENC() 问题
+// 文件按 Load 的方式叠加。
+func Check(paths ...string) (*Config, *config.CheckReport, error) {
+	layers, err := files(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &Config{}
+	report, err := config.CheckFiles(layers, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, report, nil
+}
+
+// Path returns the base file the configuration was loaded from
+// Path 返回配置的基础加载文件
+func Path() string {
+	if len(cfgPaths) == 0 {
+		return ""
+	}
+	return cfgPaths[0]
+}
+
+// Paths returns all files the configuration was loaded from, in override order
+// Paths 返回配置的所有加载文件，按覆盖顺序
+func Paths() []string {
+	return cfgPaths
 }
 
-var cfg *Config
+// ReloadLogger re-reads the [logger] section from the configuration file
+// Other sections keep their loaded values; they only change on restart.
+// ReloadLogger 从配置文件重新读取 [logger] 段
+// 其他段保持已加载的值，仅在重启后变更。
+func ReloadLogger() (logger.Config, error) {
+	fresh := &Config{}
+	if err := config.LoadFiles(cfgPaths, fresh); err != nil {
+		return logger.Config{}, err
+	}
+	return fresh.Logger, nil
+}
 
-// Load loads configuration from the specified path
-// Load 从指定路径加载配置
-func Load(path string) error {
-	cfg = &Config{}
-	return config.Load(path, cfg)
+// SaveLoggerLevels writes the per-logger levels back to [logger.levels] in the configuration file
+// The last layer that sets [logger.levels] is written, the base file when none does.
+// SaveLoggerLevels 将按日志器的级别写回配置文件的 [logger.levels]
+// 写入最后一个设置了 [logger.levels] 的层，都未设置时写入基础文件。
+func SaveLoggerLevels(levels map[string]string) error {
+	return config.WriteTable(config.DefiningFile(cfgPaths, "logger.levels"), "logger.levels", levels)
 }
 
-// MustLoad loads configuration from the specified path, panics on failure
-// MustLoad 从指定路径加载配置，失败时 panic
-func MustLoad(path string) {
-	cfg = &Config{}
-	config.MustLoad(path, cfg)
+// Redacted returns the effective configuration with passwords, secrets, tokens and keys hidden
+// Redacted 返回隐藏密码、密钥和令牌后的生效配置
+func Redacted() (map[string]any, error) {
+	return config.Redact(cfg)
 }
 
 // SetDecryptKey sets the decryption key for encrypted configuration values
@@ -174,6 +348,90 @@ func GetJWT() jwt.Config {
 	return cfg.JWT
 }
 
+// GetFieldEncryption returns the field encryption configuration
+// GetFieldEncryption 返回字段加密配置
+func GetFieldEncryption() crypto.FieldConfig {
+	return cfg.FieldCrypto
+}
+
+// GetSession returns the session configuration
+// GetSession 返回会话配置
+func GetSession() session.Config {
+	return cfg.Session
+}
+
+// GetAuthz returns the authorization configuration
+// GetAuthz 返回授权配置
+func GetAuthz() authz.Config {
+	return cfg.Authz
+}
+
+// GetAPIKey returns the API key configuration
+// GetAPIKey 返回 API 密钥配置
+func GetAPIKey() apikey.Config {
+	return cfg.APIKey
+}
+
+// GetDebug returns the debug endpoints configuration
+// GetDebug 返回调试接口配置
+func GetDebug() Debug {
+	return cfg.Debug
+}
+
+// GetAudit returns the audit configuration
+// GetAudit 返回审计配置
+func GetAudit() audit.Config {
+	return cfg.Audit
+}
+
+// GetRateLimit returns the rate limit configuration
+// GetRateLimit 返回限流配置
+func GetRateLimit() middleware.RateLimitSettings {
+	return cfg.RateLimit
+}
+
+// GetAccessLog returns the access log configuration
+// GetAccessLog 返回访问日志配置
+func GetAccessLog() middleware.AccessLogConfig {
+	return cfg.AccessLog
+}
+
+// GetCompress returns the response compression configuration
+// GetCompress 返回响应压缩配置
+func GetCompress() middleware.CompressConfig {
+	return cfg.Compress
+}
+
+// GetETag returns the ETag configuration
+// GetETag 返回 ETag 配置
+func GetETag() middleware.ETagConfig {
+	return cfg.ETag
+}
+
+// GetMaintenance returns the maintenance mode configuration
+// GetMaintenance 返回维护模式配置
+func GetMaintenance() middleware.MaintenanceConfig {
+	return cfg.Maintenance
+}
+
+// GetTenant returns the multi-tenancy configuration
+// GetTenant 返回多租户配置
+func GetTenant() tenant.Config {
+	return cfg.Tenant
+}
+
+// GetSecurity returns the security configuration
+// GetSecurity 返回安全配置
+func GetSecurity() middleware.SecurityConfig {
+	return cfg.Security
+}
+
+// GetI18n returns the i18n configuration
+// GetI18n 返回国际化配置
+func GetI18n() i18n.Config {
+	return cfg.I18n
+}
+
 // GetTrace returns the tracing configuration
 // GetTrace 返回追踪配置
 func GetTrace() trace.Config {
@@ -192,6 +450,12 @@ func GetMQ() mq.Config {
 	return cfg.MQ
 }
 
+// GetJobs returns the background job configuration
+// GetJobs 返回后台任务配置
+func GetJobs() jobs.Config {
+	return cfg.Jobs
+}
+
 // GetService returns a service configuration by name
 // GetService 根据名称返回服务配置
 func GetService(name string) *Service {
@@ -209,6 +473,138 @@ func GetMetrics() metrics.Config {
 	return cfg.Metrics
 }
 
+// GetBreaker returns the circuit breaker configurations keyed by breaker name
+// GetBreaker 返回以熔断器名称为键的熔断器配置
+func GetBreaker() map[string]breaker.Config {
+	return cfg.Breaker
+}
+
+// GetBulkhead returns the bulkhead configurations keyed by dependency name
+// GetBulkhead 返回以依赖名称为键的舱壁配置
+func GetBulkhead() map[string]bulkhead.Config {
+	return cfg.Bulkhead
+}
+
+// GetHTTPClient returns the outbound HTTP client configuration
+// GetHTTPClient 返回出站 HTTP 客户端配置
+func GetHTTPClient() httpclient.Config {
+	return cfg.HTTPClient
+}
+
+// GetDiscovery returns the service discovery configuration
+// GetDiscovery 返回服务发现配置
+func GetDiscovery() discovery.Config {
+	return cfg.Discovery
+}
+
+// GetEmail returns the email configuration
+// GetEmail 返回邮件配置
+func GetEmail() email.Config {
+	return cfg.Email
+}
+
+// GetSMS returns the SMS configuration
+// GetSMS 返回短信配置
+func GetSMS() sms.Config {
+	return cfg.SMS
+}
+
+// GetCDC returns the entity change event configuration
+// GetCDC 返回实体变更事件配置
+func GetCDC() cdc.Config {
+	return cfg.CDC
+}
+
+// GetDBCache returns the query result cache configuration
+// GetDBCache 返回查询结果缓存配置
+func GetDBCache() dbcache.Config {
+	return cfg.DBCache
+}
+
+// GetSearch returns the full-text search configuration
+// GetSearch 返回全文搜索配置
+func GetSearch() search.Config {
+	return cfg.Search
+}
+
+// GetTime returns the time zone and business calendar configuration
+// GetTime 返回时区和工作日历配置
+func GetTime() timex.Config {
+	return cfg.Time
+}
+
+// GetNotify returns the notification configuration
+// GetNotify 返回通知配置
+func GetNotify() notify.Config {
+	return cfg.Notify
+}
+
+// GetFeatureFlag returns the feature flag configuration
+// GetFeatureFlag 返回功能开关配置
+func GetFeatureFlag() featureflag.Config {
+	return cfg.FeatureFlag
+}
+
+// GetQuota returns the quota configuration
+// GetQuota 返回配额配置
+func GetQuota() quota.Config {
+	return cfg.Quota
+}
+
+// GetCaptcha returns the captcha configuration
+// GetCaptcha 返回验证码配置
+func GetCaptcha() captcha.Config {
+	return cfg.Captcha
+}
+
+// GetOTP returns the one-time code configuration
+// GetOTP 返回一次性验证码配置
+func GetOTP() otp.Config {
+	return cfg.OTP
+}
+
+// GetOAuth returns the social login configuration
+// GetOAuth 返回社交登录配置
+func GetOAuth() oauth.Config {
+	return cfg.OAuth
+}
+
+// GetWebhook returns the webhook configuration
+// GetWebhook 返回 Webhook 配置
+func GetWebhook() webhook.Config {
+	return cfg.Webhook
+}
+
+// GetPayment returns the payment configuration
+// GetPayment 返回支付配置
+func GetPayment() payment.Config {
+	return cfg.Payment
+}
+
+// GetGraphQL returns the GraphQL configuration
+// GetGraphQL 返回 GraphQL 配置
+func GetGraphQL() graphql.Config {
+	return cfg.GraphQL
+}
+
+// GetExport returns the export configuration
+// GetExport 返回导出配置
+func GetExport() export.Config {
+	return cfg.Export
+}
+
+// GetImport returns the import configuration
+// GetImport 返回导入配置
+func GetImport() importer.Config {
+	return cfg.Import
+}
+
+// GetWSBridge returns the mq to WebSocket bridge configuration
+// GetWSBridge 返回 mq 到 WebSocket 的桥接配置
+func GetWSBridge() wsbridge.Config {
+	return cfg.WSBridge
+}
+
 // GetStorage returns the storage configuration
 // GetStorage 返回存储配置
 func GetStorage() storage.Config {
@@ -0,0 +1,141 @@
+// Package ctxutil provides typed accessors for request-scoped values in context.Context
+// ctxutil 包提供 context.Context 中请求级数据的类型化访问器
+//
+// middleware.RequestContext stores the request ID and the authenticated user
+// in both Fiber Locals and c.UserContext(), so service, logging and DB code
+// can correlate a request end to end without depending on Fiber.
+// middleware.RequestContext 将请求 ID 和已认证用户同时存入 Fiber Locals 与 c.UserContext()，
+// 使服务、日志和数据库代码无需依赖 Fiber 即可端到端关联请求。
+//
+// Usage | 用法:
+//
+//	ctx := c.UserContext()
+//	if uid, ok := ctxutil.UserID(ctx); ok { ... }
+//	log.WithContext(ctx).Info("order created") // adds request_id and user_id | 自动附加 request_id 和 user_id
+package ctxutil
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// Every log entry carrying a request context gets request_id and user_id
+// 每条携带请求 context 的日志都会带上 request_id 和 user_id
+func init() {
+	logger.RegisterContextExtractor(func(ctx context.Context) logger.Fields {
+		fields := logger.Fields{}
+		if id := RequestID(ctx); id != "" {
+			fields["request_id"] = id
+		}
+		if uid, ok := UserID(ctx); ok {
+			fields["user_id"] = uid
+		}
+		if tid := TenantID(ctx); tid != "" {
+			fields["tenant_id"] = tid
+		}
+		return fields
+	})
+}
+
+// Fiber Locals keys set by middleware.RequestContext
+// middleware.RequestContext 设置的 Fiber Locals 键
+const (
+	LocalsRequestID = "request_id" // string
+	LocalsUserID    = "user_id"    // int64
+	LocalsPlat      = "plat"       // string
+	LocalsClaims    = "claims"     // *jwt.Claims
+	LocalsLang      = "lang"       // string
+	LocalsTenantID  = "tenant_id"  // string, set by tenant.Middleware | 由 tenant.Middleware 设置
+)
+
+// HeaderRequestID is the request ID header
+// HeaderRequestID 是请求 ID 请求头
+const HeaderRequestID = "X-Request-ID"
+
+// ctxKey is the private context key type
+// ctxKey 是私有的 context 键类型
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+	platKey
+	langKey
+	tenantKey
+)
+
+// WithRequestID returns a context carrying the request ID
+// WithRequestID 返回携带请求 ID 的 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID, empty if absent
+// RequestID 返回请求 ID，不存在时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUser returns a context carrying the authenticated user
+// WithUser 返回携带已认证用户的 context
+func WithUser(ctx context.Context, userID int64, plat string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	return context.WithValue(ctx, platKey, plat)
+}
+
+// UserID returns the authenticated user ID
+// UserID 返回已认证用户 ID
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey).(int64)
+	return id, ok
+}
+
+// MustUserID returns the authenticated user ID, 0 if absent
+// MustUserID 返回已认证用户 ID，不存在时返回 0
+func MustUserID(ctx context.Context) int64 {
+	id, _ := UserID(ctx)
+	return id
+}
+
+// Plat returns the platform of the authenticated user (admin/frontend)
+// Plat 返回已认证用户的平台（admin/frontend）
+func Plat(ctx context.Context) string {
+	plat, _ := ctx.Value(platKey).(string)
+	return plat
+}
+
+// WithLang returns a context carrying the negotiated language
+// WithLang 返回携带协商语言的 context
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey, lang)
+}
+
+// Lang returns the negotiated language, empty if absent
+// Lang 返回协商的语言，不存在时返回空字符串
+func Lang(ctx context.Context) string {
+	lang, _ := ctx.Value(langKey).(string)
+	return lang
+}
+
+// WithTenant returns a context carrying the tenant ID
+// WithTenant 返回携带租户 ID 的 context
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// TenantID returns the tenant ID, empty if absent
+// TenantID 返回租户 ID，不存在时返回空字符串
+func TenantID(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey).(string)
+	return id
+}
+
+// Detach returns a context that keeps request values but is not canceled with the request
+// Use it for work that outlives the request, e.g. async audit writes.
+// Detach 返回保留请求数据但不随请求取消的 context
+// 用于生命周期超过请求的任务，例如异步审计写入。
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
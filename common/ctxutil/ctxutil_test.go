@@ -0,0 +1,59 @@
+package ctxutil
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	if id := RequestID(ctx); id != "" {
+		t.Errorf("Expected empty request ID, got %q", id)
+	}
+
+	ctx = WithRequestID(ctx, "req-1")
+	if id := RequestID(ctx); id != "req-1" {
+		t.Errorf("Expected req-1, got %q", id)
+	}
+}
+
+func TestUser(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := UserID(ctx); ok {
+		t.Error("Expected no user in empty context")
+	}
+
+	ctx = WithUser(ctx, 42, "admin")
+	id, ok := UserID(ctx)
+	if !ok || id != 42 {
+		t.Errorf("Expected user 42, got %d (%v)", id, ok)
+	}
+	if plat := Plat(ctx); plat != "admin" {
+		t.Errorf("Expected plat admin, got %q", plat)
+	}
+}
+
+func TestTenant(t *testing.T) {
+	ctx := context.Background()
+	if id := TenantID(ctx); id != "" {
+		t.Errorf("Expected empty tenant ID, got %q", id)
+	}
+
+	ctx = WithTenant(ctx, "acme")
+	if id := TenantID(ctx); id != "acme" {
+		t.Errorf("Expected acme, got %q", id)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithCancel(WithRequestID(context.Background(), "req-2"))
+	detached := Detach(parent)
+	cancel()
+
+	if detached.Err() != nil {
+		t.Error("Detached context should not be canceled")
+	}
+	if RequestID(detached) != "req-2" {
+		t.Error("Detached context should keep request values")
+	}
+}
@@ -0,0 +1,132 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// captureStack enables stack capture in constructors, see SetStackCapture
+// captureStack 控制构造函数是否捕获堆栈，参见 SetStackCapture
+var captureStack atomic.Bool
+
+// SetStackCapture enables or disables stack capture for new errors
+// Capturing only records program counters; frames are resolved when Stack is called.
+// common.Init enables it in the dev environment.
+// SetStackCapture 启用或禁用新错误的堆栈捕获
+// 捕获时仅记录程序计数器，调用 Stack 时才解析栈帧。common.Init 在 dev 环境中启用它。
+func SetStackCapture(enabled bool) {
+	captureStack.Store(enabled)
+}
+
+// withStack records the caller stack when capture is enabled
+// withStack 在启用捕获时记录调用栈
+func withStack(e *BizError) *BizError {
+	if captureStack.Load() {
+		pcs := make([]uintptr, 32)
+		n := runtime.Callers(3, pcs) // skip Callers, withStack and the constructor | 跳过 Callers、withStack 和构造函数
+		e.stack = pcs[:n]
+	}
+	return e
+}
+
+// WithField attaches a structured field that is logged but never returned to clients
+// It returns e for chaining.
+// WithField 附加一个结构化字段，只写入日志，不会返回给客户端
+// 返回 e 以便链式调用。
+//
+// Usage | 用法:
+//
+//	return errors.Wrap(response.CodeDBError, err).WithField("order_id", id)
+func (e *BizError) WithField(key string, value any) *BizError {
+	if e.fields == nil {
+		e.fields = make(map[string]any)
+	}
+	e.fields[key] = value
+	return e
+}
+
+// WithFields attaches several structured fields, see WithField
+// WithFields 附加多个结构化字段，参见 WithField
+func (e *BizError) WithFields(fields map[string]any) *BizError {
+	if e.fields == nil {
+		e.fields = make(map[string]any, len(fields))
+	}
+	maps.Copy(e.fields, fields)
+	return e
+}
+
+// Fields returns the structured fields of e and of every BizError it wraps
+// Outer errors win on key conflicts.
+// Fields 返回 e 及其包装的所有 BizError 的结构化字段
+// 键冲突时外层错误优先。
+func (e *BizError) Fields() map[string]any {
+	var chain []*BizError
+	var err error = e
+	for err != nil {
+		if biz, ok := err.(*BizError); ok && len(biz.fields) > 0 {
+			chain = append(chain, biz)
+		}
+		err = errors.Unwrap(err)
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]any)
+	for i := len(chain) - 1; i >= 0; i-- {
+		maps.Copy(fields, chain[i].fields)
+	}
+	return fields
+}
+
+// Stack returns the formatted call stack, empty when capture was disabled
+// Leading frames inside this package (the constructors) are omitted.
+// Stack 返回格式化的调用栈，未启用捕获时为空
+// 省略开头位于本包内的栈帧（构造函数）。
+func (e *BizError) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	caller := false
+	for {
+		frame, more := frames.Next()
+		if !caller && !isConstructorFrame(frame) {
+			caller = true
+		}
+		if caller {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// isConstructorFrame reports whether frame belongs to this package's non-test code
+// isConstructorFrame 判断栈帧是否属于本包的非测试代码
+func isConstructorFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "github.com/nuohe369/crab/common/errors.") &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// Chain returns a description of every error in err's Unwrap chain, outermost first
+// Chain 返回 err 的 Unwrap 链中每个错误的描述，最外层在前
+func Chain(err error) []string {
+	var chain []string
+	for err != nil {
+		if biz, ok := err.(*BizError); ok {
+			chain = append(chain, fmt.Sprintf("[%d] %s", biz.Code, biz.Msg))
+		} else {
+			chain = append(chain, fmt.Sprintf("%T: %v", err, err))
+		}
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 
+	"github.com/nuohe369/crab/common/i18n"
 	"github.com/nuohe369/crab/common/response"
 )
 
@@ -11,7 +12,12 @@ import (
 type BizError struct {
 	Code response.Code
 	Msg  string
-	Err  error // Underlying error (optional) | 底层错误（可选）
+	Key  string // i18n catalog key, empty keeps Msg as is | i18n 目录键，为空时原样使用 Msg
+	Args []any  // Arguments for the translated message | 翻译消息的参数
+	Err  error  // Underlying error (optional) | 底层错误（可选）
+
+	fields map[string]any // Structured context for logs, never sent to clients | 日志用的结构化上下文，不会返回给客户端
+	stack  []uintptr      // Call stack when stack capture is enabled | 启用堆栈捕获时的调用栈
 }
 
 // Error implements error interface
@@ -29,22 +35,59 @@ func (e *BizError) Unwrap() error {
 	return e.Err
 }
 
+// Localize returns the message in lang, falling back to Msg when Key is empty
+// Localize 返回 lang 语言的消息，Key 为空时回退到 Msg
+func (e *BizError) Localize(lang string) string {
+	if e.Key == "" {
+		return e.Msg
+	}
+	if _, ok := i18n.Lookup(lang, e.Key); !ok {
+		if _, ok := i18n.Lookup(i18n.Default(), e.Key); !ok {
+			return e.Msg
+		}
+	}
+	return i18n.T(lang, e.Key, e.Args...)
+}
+
 // New creates a new business error
 // New 创建一个新的业务错误
 func New(code response.Code, msg string) *BizError {
-	return &BizError{
+	return withStack(&BizError{
 		Code: code,
 		Msg:  msg,
-	}
+	})
 }
 
 // Newf creates a new business error with formatted message
 // Newf 创建一个带格式化消息的业务错误
 func Newf(code response.Code, format string, args ...any) *BizError {
-	return &BizError{
+	return withStack(&BizError{
 		Code: code,
 		Msg:  fmt.Sprintf(format, args...),
-	}
+	})
+}
+
+// Localized creates a business error whose message is translated per request
+// key is an i18n catalog key, args are applied to the translated message.
+// Localized 创建按请求语言翻译消息的业务错误
+// key 为 i18n 目录键，args 用于格式化翻译后的消息。
+func Localized(code response.Code, key string, args ...any) *BizError {
+	return withStack(&BizError{
+		Code: code,
+		Msg:  i18n.T(i18n.Default(), key, args...),
+		Key:  key,
+		Args: args,
+	})
+}
+
+// FromCode creates a business error carrying the translatable default message of code
+// FromCode 创建携带错误码默认可翻译消息的业务错误
+func FromCode(code response.Code) *BizError {
+	return withStack(&BizError{
+		Code: code,
+		Msg:  code.Msg(),
+		Key:  code.Key(),
+	})
 }
 
 // Wrap wraps an existing error with error code
@@ -53,11 +96,12 @@ func Wrap(code response.Code, err error) *BizError {
 	if err == nil {
 		return nil
 	}
-	return &BizError{
+	return withStack(&BizError{
 		Code: code,
 		Msg:  code.Msg(),
+		Key:  code.Key(),
 		Err:  err,
-	}
+	})
 }
 
 // Wrapf wraps an existing error with error code and custom message
@@ -66,11 +110,11 @@ func Wrapf(code response.Code, err error, format string, args ...any) *BizError
 	if err == nil {
 		return nil
 	}
-	return &BizError{
+	return withStack(&BizError{
 		Code: code,
 		Msg:  fmt.Sprintf(format, args...),
 		Err:  err,
-	}
+	})
 }
 
 // Common error constructors for frequently used errors
@@ -82,7 +126,7 @@ func ErrUnauthorized(msg ...string) *BizError {
 	if len(msg) > 0 {
 		return New(response.CodeUnauth, msg[0])
 	}
-	return New(response.CodeUnauth, response.CodeUnauth.Msg())
+	return FromCode(response.CodeUnauth)
 }
 
 // ErrForbidden creates a forbidden error
@@ -91,7 +135,7 @@ func ErrForbidden(msg ...string) *BizError {
 	if len(msg) > 0 {
 		return New(response.CodeForbid, msg[0])
 	}
-	return New(response.CodeForbid, response.CodeForbid.Msg())
+	return FromCode(response.CodeForbid)
 }
 
 // ErrParamInvalid creates a parameter invalid error
@@ -100,7 +144,7 @@ func ErrParamInvalid(msg ...string) *BizError {
 	if len(msg) > 0 {
 		return New(response.CodeParamInvalid, msg[0])
 	}
-	return New(response.CodeParamInvalid, response.CodeParamInvalid.Msg())
+	return FromCode(response.CodeParamInvalid)
 }
 
 // ErrNotFound creates a not found error
@@ -109,13 +153,13 @@ func ErrNotFound(msg ...string) *BizError {
 	if len(msg) > 0 {
 		return New(response.CodeNotFound, msg[0])
 	}
-	return New(response.CodeNotFound, response.CodeNotFound.Msg())
+	return FromCode(response.CodeNotFound)
 }
 
 // ErrUserNotFound creates a user not found error
 // ErrUserNotFound 创建一个用户未找到错误
 func ErrUserNotFound() *BizError {
-	return New(response.CodeUserNotFound, response.CodeUserNotFound.Msg())
+	return FromCode(response.CodeUserNotFound)
 }
 
 // ErrServerError creates a server error
@@ -124,7 +168,7 @@ func ErrServerError(msg ...string) *BizError {
 	if len(msg) > 0 {
 		return New(response.CodeServerError, msg[0])
 	}
-	return New(response.CodeServerError, response.CodeServerError.Msg())
+	return FromCode(response.CodeServerError)
 }
 
 // ErrDBError creates a database error
@@ -181,3 +181,55 @@ func TestGetCode(t *testing.T) {
 		t.Errorf("Expected default code %d, got %d", response.CodeError, code)
 	}
 }
+
+func TestLocalized(t *testing.T) {
+	err := ErrRequired("username")
+	if err.Code != response.CodeParamMissing {
+		t.Errorf("Expected code %d, got %d", response.CodeParamMissing, err.Code)
+	}
+	if msg := err.Localize("en"); msg != "username is required" {
+		t.Errorf("Expected English message, got '%s'", msg)
+	}
+	if msg := err.Localize("zh-CN"); msg != "username 不能为空" {
+		t.Errorf("Expected Chinese message, got '%s'", msg)
+	}
+
+	if msg := ErrForbidden().Localize("zh"); msg != "禁止访问" {
+		t.Errorf("Expected translated code message, got '%s'", msg)
+	}
+	if msg := ErrForbidden("custom").Localize("zh"); msg != "custom" {
+		t.Errorf("Expected custom message kept, got '%s'", msg)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	inner := New(response.CodeDBError, "insert failed").WithField("table", "orders").WithField("order_id", 1)
+	outer := Wrap(response.CodeServerError, inner).WithField("order_id", 2)
+
+	fields := outer.Fields()
+	if fields["table"] != "orders" || fields["order_id"] != 2 {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+	if strings.Contains(outer.Error(), "orders") {
+		t.Errorf("Fields must not leak into the message: %s", outer.Error())
+	}
+
+	chain := Chain(outer)
+	if len(chain) != 2 || chain[1] != "[5002] insert failed" {
+		t.Errorf("Unexpected chain: %v", chain)
+	}
+}
+
+func TestStackCapture(t *testing.T) {
+	if New(response.CodeError, "no stack").Stack() != "" {
+		t.Error("Expected no stack when capture is disabled")
+	}
+
+	SetStackCapture(true)
+	defer SetStackCapture(false)
+
+	stack := New(response.CodeError, "with stack").Stack()
+	if !strings.Contains(stack, "TestStackCapture") {
+		t.Errorf("Expected caller in stack, got:\n%s", stack)
+	}
+}
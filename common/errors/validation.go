@@ -0,0 +1,71 @@
+package errors
+
+import (
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// Built-in validation messages, field names are translated via "field.<name>"
+// 内置校验消息，字段名通过 "field.<name>" 翻译
+func init() {
+	i18n.Register("en", map[string]string{
+		"validation.body":       "Failed to parse request body",
+		"validation.required":   "%s is required",
+		"validation.invalid":    "%s is invalid",
+		"validation.min_length": "%s must be at least %d characters",
+		"validation.max_length": "%s must be at most %d characters",
+		"validation.range":      "%s must be between %v and %v",
+		"validation.one_of":     "%s must be one of %v",
+	})
+	i18n.Register("zh", map[string]string{
+		"validation.body":       "请求体解析失败",
+		"validation.required":   "%s 不能为空",
+		"validation.invalid":    "%s 格式不正确",
+		"validation.min_length": "%s 长度不能少于 %d 个字符",
+		"validation.max_length": "%s 长度不能超过 %d 个字符",
+		"validation.range":      "%s 必须在 %v 到 %v 之间",
+		"validation.one_of":     "%s 必须是 %v 之一",
+	})
+}
+
+// ErrBodyInvalid creates an error for a request body that cannot be parsed
+// ErrBodyInvalid 创建请求体无法解析的错误
+func ErrBodyInvalid() *BizError {
+	return Localized(response.CodeParamInvalid, "validation.body")
+}
+
+// ErrRequired creates an error for a missing field
+// ErrRequired 创建字段缺失的错误
+func ErrRequired(field string) *BizError {
+	return Localized(response.CodeParamMissing, "validation.required", i18n.Field(field))
+}
+
+// ErrInvalidField creates an error for a malformed field
+// ErrInvalidField 创建字段格式不正确的错误
+func ErrInvalidField(field string) *BizError {
+	return Localized(response.CodeParamInvalid, "validation.invalid", i18n.Field(field))
+}
+
+// ErrMinLength creates an error for a field shorter than min characters
+// ErrMinLength 创建字段长度不足的错误
+func ErrMinLength(field string, min int) *BizError {
+	return Localized(response.CodeParamInvalid, "validation.min_length", i18n.Field(field), min)
+}
+
+// ErrMaxLength creates an error for a field longer than max characters
+// ErrMaxLength 创建字段长度超限的错误
+func ErrMaxLength(field string, max int) *BizError {
+	return Localized(response.CodeParamInvalid, "validation.max_length", i18n.Field(field), max)
+}
+
+// ErrRange creates an error for a value outside [min, max]
+// ErrRange 创建取值超出 [min, max] 范围的错误
+func ErrRange(field string, min, max any) *BizError {
+	return Localized(response.CodeParamInvalid, "validation.range", i18n.Field(field), min, max)
+}
+
+// ErrOneOf creates an error for a value outside the allowed set
+// ErrOneOf 创建取值不在允许集合中的错误
+func ErrOneOf(field string, allowed any) *BizError {
+	return Localized(response.CodeParamInvalid, "validation.one_of", i18n.Field(field), allowed)
+}
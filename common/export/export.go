@@ -0,0 +1,371 @@
+// Package export generates CSV/XLSX exports in the background
+// A handler starts a task; a jobs worker streams the exporter's rows into a
+// temporary file, uploads it to pkg/storage and records the download URL.
+// Progress is kept in Redis and the user can be notified over WebSocket.
+// export 包在后台生成 CSV/XLSX 导出文件
+// 处理器创建任务；jobs 工作协程将导出器的行流式写入临时文件，上传到 pkg/storage 并记录下载 URL。
+// 进度保存在 Redis 中，并可通过 WebSocket 通知用户。
+//
+// Usage | 用法:
+//
+//	export.Register("orders", export.Exporter{
+//		Permission: "order:export",
+//		Header:     []string{"ID", "Amount", "Created"},
+//		Count: func(ctx context.Context, params json.RawMessage) (int64, error) {
+//			return db.Context(ctx).Count(&Order{})
+//		},
+//		Rows: func(ctx context.Context, params json.RawMessage, emit func(row []any) error) error {
+//			return db.Context(ctx).Iterate(&Order{}, func(_ int, bean any) error {
+//				o := bean.(*Order)
+//				return emit([]any{o.ID, o.Amount, o.CreatedAt})
+//			})
+//		},
+//	})
+//
+//	// POST {path}/orders {"format": "xlsx", "notify": true} -> task, GET {path}/tasks/:id -> progress and URL
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/jobs"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/sheet"
+	"github.com/nuohe369/crab/pkg/storage"
+)
+
+var log = logger.NewSystem("export")
+
+// Task states | 任务状态
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// WebSocket message types sent when Notify is set | 设置 Notify 时发送的 WebSocket 消息类型
+const (
+	MessageDone   = "export.done"
+	MessageFailed = "export.failed"
+)
+
+// jobType is the jobs type running an export
+// jobType 是运行导出的任务类型
+const jobType = "export.run"
+
+// progressEvery is how many rows are written between progress updates
+// progressEvery 是两次进度更新之间写入的行数
+const progressEvery = 1000
+
+// ErrUnknownExporter is returned by Start for an unregistered exporter name
+// ErrUnknownExporter 在导出器名称未注册时由 Start 返回
+var ErrUnknownExporter = errors.New("export: unknown exporter")
+
+// Config represents export configuration
+// Config 表示导出配置
+type Config struct {
+	Path    string        `toml:"path"`     // User endpoints (JWT), empty disables | 用户接口路径（JWT），为空时禁用
+	Dir     string        `toml:"dir"`      // Storage key prefix (default "exports") | 存储键前缀（默认 "exports"）
+	Queue   string        `toml:"queue"`    // Jobs queue (default "default") | 任务队列（默认 "default"）
+	TaskTTL time.Duration `toml:"task_ttl"` // How long task status is kept (default 24h) | 任务状态保留时长（默认 24 小时）
+}
+
+// Exporter produces the rows of one export
+// Exporter 生成一种导出的行数据
+type Exporter struct {
+	Permission string   // Required permission, empty allows any authenticated user | 所需权限，为空时允许任何已认证用户
+	Header     []string // First row | 首行
+	// Count returns the number of rows for a percentage, optional | Count 返回行数用于计算百分比，可选
+	Count func(ctx context.Context, params json.RawMessage) (int64, error)
+	// Rows emits every row; ctx carries the requesting user | Rows 输出每一行；ctx 携带请求用户
+	Rows func(ctx context.Context, params json.RawMessage, emit func(row []any) error) error
+}
+
+// Task is one export run
+// Task 表示一次导出
+type Task struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Format    string          `json:"format"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	UserID    int64           `json:"user_id,string"`
+	Plat      string          `json:"plat,omitempty"`
+	Notify    bool            `json:"notify"`
+	Status    string          `json:"status"`
+	Rows      int64           `json:"rows"`            // Rows written so far | 已写入行数
+	Total     int64           `json:"total"`           // Total rows, 0 if unknown | 总行数，未知时为 0
+	Progress  int             `json:"progress"`        // 0-100 | 0-100
+	URL       string          `json:"url,omitempty"`   // Download URL when done | 完成后的下载 URL
+	Error     string          `json:"error,omitempty"` // Failure reason | 失败原因
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// NotifyFunc pushes a message to a user
+// NotifyFunc 向用户推送消息
+type NotifyFunc func(ctx context.Context, userID int64, msgType string, payload any) error
+
+var (
+	cfg = Config{Dir: "exports", Queue: jobs.DefaultQueue, TaskTTL: 24 * time.Hour}
+
+	mu        sync.RWMutex
+	exporters           = make(map[string]Exporter)
+	store     TaskStore = NewMemoryTaskStore()
+	files     storage.Storage
+	notify    NotifyFunc
+)
+
+// Init initializes export configuration and registers the worker with jobs
+// Init 初始化导出配置并向 jobs 注册工作处理器
+func Init(c Config) {
+	if c.Dir == "" {
+		c.Dir = "exports"
+	}
+	if c.Queue == "" {
+		c.Queue = jobs.DefaultQueue
+	}
+	if c.TaskTTL <= 0 {
+		c.TaskTTL = 24 * time.Hour
+	}
+	cfg = c
+
+	mu.Lock()
+	if client := redis.Get(); client != nil {
+		store = NewRedisTaskStore(client)
+	}
+	files = storage.Get()
+	mu.Unlock()
+
+	jobs.Register(jobType, jobs.Handle(func(ctx context.Context, id string) error {
+		return run(ctx, id)
+	}))
+	log.Info("Export initialized: storage=%v, async=%v", files != nil, jobs.Get() != nil)
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// SetNotifier sets how users are told that an export finished
+// SetNotifier 设置导出完成时通知用户的方式
+func SetNotifier(fn NotifyFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	notify = fn
+}
+
+// Register adds or replaces an exporter
+// Register 添加或替换导出器
+func Register(name string, e Exporter) {
+	if e.Rows == nil {
+		panic("export: exporter " + name + " has no Rows")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	exporters[name] = e
+}
+
+// lookup returns a registered exporter
+// lookup 返回已注册的导出器
+func lookup(name string) (Exporter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := exporters[name]
+	return e, ok
+}
+
+func getStore() TaskStore {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Start creates a task for the user of ctx and queues it
+// Without a jobs runner (no mq) the export runs in a goroutine of this instance.
+// Start 为 ctx 中的用户创建任务并加入队列
+// 没有 jobs 运行器（未配置 mq）时，导出在本实例的协程中运行。
+func Start(ctx context.Context, name, format string, params json.RawMessage, notifyUser bool) (*Task, error) {
+	if _, ok := lookup(name); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, name)
+	}
+	if !sheet.Supported(format) {
+		return nil, fmt.Errorf("export: unsupported format %q", format)
+	}
+
+	now := time.Now()
+	t := &Task{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Format:    format,
+		Params:    params,
+		UserID:    ctxutil.MustUserID(ctx),
+		Plat:      ctxutil.Plat(ctx),
+		Notify:    notifyUser,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := getStore().Save(ctx, t, cfg.TaskTTL); err != nil {
+		return nil, fmt.Errorf("export: save task: %w", err)
+	}
+
+	// One attempt only: a failed export is reported to the user instead of retried | 仅执行一次：失败的导出直接报告给用户而不是重试
+	_, err := jobs.Enqueue(ctx, jobType, t.ID, jobs.Queue(cfg.Queue), jobs.MaxRetries(-1))
+	if errors.Is(err, jobs.ErrNotInitialized) {
+		go func(ctx context.Context) {
+			if err := run(ctx, t.ID); err != nil {
+				log.ErrorCtx(ctx, "Export %s failed: %v", t.ID, err)
+			}
+		}(ctxutil.Detach(ctx))
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Get returns a task, nil if missing or expired
+// Get 返回任务，不存在或已过期时返回 nil
+func Get(ctx context.Context, id string) (*Task, error) {
+	return getStore().Load(ctx, id)
+}
+
+// run generates the file of one task
+// run 生成一个任务的文件
+func run(ctx context.Context, id string) error {
+	t, err := getStore().Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return jobs.Permanent(fmt.Errorf("export: task %s not found", id))
+	}
+
+	e, ok := lookup(t.Name)
+	if !ok {
+		return finish(ctx, t, fmt.Errorf("%w: %s", ErrUnknownExporter, t.Name))
+	}
+	ctx = ctxutil.WithUser(ctx, t.UserID, t.Plat)
+
+	t.Status = StatusRunning
+	if e.Count != nil {
+		if t.Total, err = e.Count(ctx, t.Params); err != nil {
+			return finish(ctx, t, fmt.Errorf("count rows: %w", err))
+		}
+	}
+	save(ctx, t)
+
+	return finish(ctx, t, generate(ctx, t, e))
+}
+
+// generate writes the rows to a temporary file and uploads it
+// generate 将行写入临时文件并上传
+func generate(ctx context.Context, t *Task, e Exporter) error {
+	mu.RLock()
+	dst := files
+	mu.RUnlock()
+	if dst == nil {
+		return errors.New("storage is not configured")
+	}
+
+	tmp, err := os.CreateTemp("", "export-*."+t.Format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := sheet.NewWriter(t.Format, tmp)
+	if err != nil {
+		return err
+	}
+	if len(e.Header) > 0 {
+		header := make([]any, len(e.Header))
+		for i, h := range e.Header {
+			header[i] = h
+		}
+		if err := w.WriteRow(header); err != nil {
+			return err
+		}
+	}
+	err = e.Rows(ctx, t.Params, func(row []any) error {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+		t.Rows++
+		if t.Rows%progressEvery == 0 {
+			save(ctx, t)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s/%s/%s.%s", cfg.Dir, t.CreatedAt.Format("20060102"), t.ID, t.Format)
+	if err := dst.Put(ctx, key, tmp, size, sheet.ContentType(t.Format)); err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	t.URL = dst.URL(key)
+	return nil
+}
+
+// finish records the outcome and notifies the user; the task error is not returned so jobs acknowledges it
+// finish 记录结果并通知用户；任务错误不会返回，使 jobs 确认该任务
+func finish(ctx context.Context, t *Task, err error) error {
+	msgType := MessageDone
+	if err != nil {
+		t.Status, t.Error = StatusFailed, err.Error()
+		msgType = MessageFailed
+		log.WarnCtx(ctx, "Export %s (%s) failed: %v", t.ID, t.Name, err)
+	} else {
+		t.Status, t.Progress = StatusDone, 100
+	}
+	save(ctx, t)
+
+	mu.RLock()
+	fn := notify
+	mu.RUnlock()
+	if t.Notify && fn != nil && t.UserID != 0 {
+		if nerr := fn(ctx, t.UserID, msgType, t); nerr != nil {
+			log.WarnCtx(ctx, "Notify export %s failed: %v", t.ID, nerr)
+		}
+	}
+	return nil
+}
+
+// save updates the progress and stores the task; failures are only logged
+// save 更新进度并保存任务；失败只记录日志
+func save(ctx context.Context, t *Task) {
+	if t.Status == StatusRunning && t.Total > 0 {
+		t.Progress = int(min(t.Rows*100/t.Total, 99))
+	}
+	t.UpdatedAt = time.Now()
+	if err := getStore().Save(ctx, t, cfg.TaskTTL); err != nil {
+		log.WarnCtx(ctx, "Save export task %s failed: %v", t.ID, err)
+	}
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/storage"
+)
+
+type memStorage struct {
+	storage.Storage
+	files map[string][]byte
+}
+
+func (m *memStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	m.files[key] = data
+	return err
+}
+
+func (m *memStorage) URL(key string) string { return "/files/" + key }
+
+type pushLog struct {
+	mu    sync.Mutex
+	types []string
+}
+
+func (p *pushLog) last() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.types) == 0 {
+		return ""
+	}
+	return p.types[len(p.types)-1]
+}
+
+// setup installs in-memory storage and task store and records pushed messages
+func setup(t *testing.T) (*memStorage, *pushLog) {
+	t.Helper()
+	fs := &memStorage{files: map[string][]byte{}}
+	pushed := &pushLog{}
+	mu.Lock()
+	files, store = fs, NewMemoryTaskStore()
+	exporters = map[string]Exporter{}
+	notify = func(_ context.Context, userID int64, msgType string, _ any) error {
+		pushed.mu.Lock()
+		defer pushed.mu.Unlock()
+		pushed.types = append(pushed.types, msgType)
+		return nil
+	}
+	mu.Unlock()
+	return fs, pushed
+}
+
+// wait polls a task until it leaves the queued and running states
+func wait(t *testing.T, id string) *Task {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		task, _ := Get(context.Background(), id)
+		if task != nil && (task.Status == StatusDone || task.Status == StatusFailed) {
+			return task
+		}
+	}
+	t.Fatalf("task %s did not finish", id)
+	return nil
+}
+
+func TestExportCSV(t *testing.T) {
+	fs, pushed := setup(t)
+	var sawUser int64
+	Register("users", Exporter{
+		Header: []string{"id", "name"},
+		Count:  func(context.Context, json.RawMessage) (int64, error) { return 2, nil },
+		Rows: func(ctx context.Context, params json.RawMessage, emit func([]any) error) error {
+			sawUser = ctxutil.MustUserID(ctx)
+			if string(params) != `{"active":true}` {
+				t.Errorf("params = %s", params)
+			}
+			emit([]any{1, "Alice"})
+			return emit([]any{2, "Bob"})
+		},
+	})
+
+	ctx := ctxutil.WithUser(context.Background(), 42, "admin")
+	task, err := Start(ctx, "users", "csv", json.RawMessage(`{"active":true}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a jobs runner the export runs in a goroutine of this instance
+	got := wait(t, task.ID)
+	if got.Status != StatusDone || got.Rows != 2 || got.Progress != 100 || !strings.HasSuffix(got.URL, task.ID+".csv") {
+		t.Fatalf("task = %+v", got)
+	}
+	if sawUser != 42 {
+		t.Errorf("exporter ran as user %d, want 42", sawUser)
+	}
+	data := fs.files[strings.TrimPrefix(got.URL, "/files/")]
+	if !bytes.Contains(data, []byte("id,name\n1,Alice\n2,Bob\n")) {
+		t.Errorf("file = %q", data)
+	}
+	if pushed.last() != MessageDone {
+		t.Errorf("last push = %q, want %s", pushed.last(), MessageDone)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	_, pushed := setup(t)
+	Register("broken", Exporter{
+		Rows: func(context.Context, json.RawMessage, func([]any) error) error {
+			return errors.New("query timeout")
+		},
+	})
+
+	ctx := ctxutil.WithUser(context.Background(), 7, "")
+	task, err := Start(ctx, "broken", "xlsx", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := wait(t, task.ID)
+	if got.Status != StatusFailed || got.Error != "query timeout" {
+		t.Errorf("task = %+v", got)
+	}
+	if pushed.last() != MessageFailed {
+		t.Errorf("last push = %q, want %s", pushed.last(), MessageFailed)
+	}
+
+	// Failures are recorded on the task, not returned to jobs for a retry
+	if err := run(ctx, task.ID); err != nil {
+		t.Errorf("run returned %v", err)
+	}
+
+	if _, err := Start(ctx, "missing", "csv", nil, false); !errors.Is(err, ErrUnknownExporter) {
+		t.Errorf("unknown exporter err = %v", err)
+	}
+	if _, err := Start(ctx, "broken", "pdf", nil, false); err == nil {
+		t.Error("unsupported format should be rejected")
+	}
+}
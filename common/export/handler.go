@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	stderrors "errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// startReq is the body of POST {path}/:name
+// startReq 是 POST {path}/:name 的请求体
+type startReq struct {
+	Format string          `json:"format"` // csv or xlsx (default xlsx) | csv 或 xlsx（默认 xlsx）
+	Params json.RawMessage `json:"params"` // Passed to the exporter as is | 原样传给导出器
+	Notify bool            `json:"notify"` // Push export.done / export.failed over WebSocket | 通过 WebSocket 推送 export.done / export.failed
+}
+
+// RegisterRoutes mounts the export endpoints on router; protect the router with auth middleware
+// RegisterRoutes 在 router 上挂载导出接口；请使用认证中间件保护该路由
+func RegisterRoutes(router fiber.Router) {
+	router.Get("/tasks/:id", getTask)
+	router.Post("/:name", start)
+}
+
+func start(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID, ok := ctxutil.UserID(ctx)
+	if !ok {
+		return errors.ErrUnauthorized()
+	}
+	e, ok := lookup(c.Params("name"))
+	if !ok {
+		return errors.ErrNotFound()
+	}
+	if e.Permission != "" {
+		allowed, err := authz.HasPermission(ctx, userID, e.Permission)
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		if !allowed {
+			return errors.ErrForbidden()
+		}
+	}
+
+	var req startReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	if req.Format == "" {
+		req.Format = "xlsx"
+	}
+	task, err := Start(ctx, c.Params("name"), req.Format, req.Params, req.Notify)
+	if stderrors.Is(err, ErrUnknownExporter) {
+		return errors.ErrNotFound()
+	}
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, task)
+}
+
+// getTask returns a task of the current user; other users' tasks look missing
+// getTask 返回当前用户的任务；其他用户的任务视为不存在
+func getTask(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	task, err := Get(ctx, c.Params("id"))
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	if task == nil || task.UserID != ctxutil.MustUserID(ctx) {
+		return errors.ErrNotFound()
+	}
+	return response.OK(c, task)
+}
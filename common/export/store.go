@@ -0,0 +1,82 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+// TaskStore persists export tasks
+// TaskStore 持久化导出任务
+type TaskStore interface {
+	Save(ctx context.Context, t *Task, ttl time.Duration) error
+	Load(ctx context.Context, id string) (*Task, error) // nil if missing or expired | 不存在或已过期时返回 nil
+}
+
+// redisTaskStore keeps tasks as JSON under export:task:{id}
+// redisTaskStore 以 JSON 形式将任务保存在 export:task:{id} 下
+type redisTaskStore struct {
+	client *redis.Client
+}
+
+// NewRedisTaskStore creates a Redis-backed task store
+// NewRedisTaskStore 创建基于 Redis 的任务存储
+func NewRedisTaskStore(client *redis.Client) TaskStore {
+	return &redisTaskStore{client: client}
+}
+
+func (s *redisTaskStore) Save(ctx context.Context, t *Task, ttl time.Duration) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "export:task:"+t.ID, data, ttl)
+}
+
+func (s *redisTaskStore) Load(ctx context.Context, id string) (*Task, error) {
+	data, err := s.client.Get(ctx, "export:task:"+id)
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Task
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// memoryTaskStore keeps tasks in process, used when Redis is unavailable
+// memoryTaskStore 在进程内保存任务，Redis 不可用时使用
+type memoryTaskStore struct {
+	mu    sync.RWMutex
+	tasks map[string]Task
+}
+
+// NewMemoryTaskStore creates an in-process task store (entries do not expire)
+// NewMemoryTaskStore 创建进程内任务存储（条目不会过期）
+func NewMemoryTaskStore() TaskStore {
+	return &memoryTaskStore{tasks: make(map[string]Task)}
+}
+
+func (s *memoryTaskStore) Save(_ context.Context, t *Task, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[t.ID] = *t
+	return nil
+}
+
+func (s *memoryTaskStore) Load(_ context.Context, id string) (*Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
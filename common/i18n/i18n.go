@@ -0,0 +1,231 @@
+// Package i18n provides message catalogs for response codes and validation errors
+// i18n 包为响应码和校验错误提供多语言消息目录
+//
+// Packages register built-in messages with Register, locale files in
+// [i18n].dir override them. Files are TOML named after the language, nested
+// tables are flattened into dotted keys:
+// 各包通过 Register 注册内置消息，[i18n].dir 中的语言文件可覆盖它们。
+// 文件为以语言命名的 TOML，嵌套表会展开为点分隔的键：
+//
+//	# locales/zh.toml
+//	[code]
+//	1001 = "未认证"
+//	[validation]
+//	required = "%s 不能为空"
+//
+// Usage | 用法:
+//
+//	lang := i18n.Match(c.Get("Accept-Language"))
+//	msg := i18n.T(lang, "validation.required", "username")
+package i18n
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config represents i18n configuration
+// Config 表示国际化配置
+type Config struct {
+	Default string `toml:"default"` // Default language when Accept-Language matches nothing (default "en") | Accept-Language 无匹配时的默认语言（默认 "en"）
+	Dir     string `toml:"dir"`     // Directory of <lang>.toml locale files, optional | <lang>.toml 语言文件目录，可选
+}
+
+var (
+	mu          sync.RWMutex
+	catalogs    = map[string]map[string]string{}
+	defaultLang = "en"
+)
+
+// Init sets the default language and loads locale files
+// Init 设置默认语言并加载语言文件
+func Init(cfg Config) error {
+	if cfg.Default != "" {
+		mu.Lock()
+		defaultLang = normalize(cfg.Default)
+		mu.Unlock()
+	}
+	if cfg.Dir == "" {
+		return nil
+	}
+	return LoadDir(cfg.Dir)
+}
+
+// LoadDir loads every <lang>.toml file in dir, overriding registered messages
+// LoadDir 加载目录中所有 <lang>.toml 文件，覆盖已注册的消息
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		var raw map[string]any
+		if _, err := toml.DecodeFile(file, &raw); err != nil {
+			return fmt.Errorf("i18n: load %s: %w", file, err)
+		}
+		messages := make(map[string]string)
+		flatten("", raw, messages)
+		Register(strings.TrimSuffix(filepath.Base(file), ".toml"), messages)
+	}
+	return nil
+}
+
+// Register merges messages into the catalog of a language
+// Register 将消息合并到某语言的目录中
+func Register(lang string, messages map[string]string) {
+	lang = normalize(lang)
+	mu.Lock()
+	defer mu.Unlock()
+	catalog, ok := catalogs[lang]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		catalogs[lang] = catalog
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+}
+
+// Default returns the default language
+// Default 返回默认语言
+func Default() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLang
+}
+
+// Languages returns the languages that have a catalog
+// Languages 返回拥有消息目录的语言
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Lookup returns the message for key in lang, falling back to the base language (zh-tw -> zh)
+// Lookup 返回 lang 中 key 对应的消息，找不到时回退到基础语言（zh-tw -> zh）
+func Lookup(lang, key string) (string, bool) {
+	lang = normalize(lang)
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg, true
+	}
+	if base, _, found := strings.Cut(lang, "-"); found {
+		if msg, ok := catalogs[base][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Field is a message argument naming a request field
+// T translates it through the "field.<name>" key, keeping the name when absent.
+// Field 是表示请求字段名的消息参数
+// T 通过 "field.<name>" 键翻译它，不存在时保留原名。
+type Field string
+
+// T translates key into lang, falling back to the default language and then to the key itself
+// Args are applied with fmt.Sprintf when present.
+// T 将 key 翻译为 lang，依次回退到默认语言和 key 本身
+// 传入 args 时使用 fmt.Sprintf 格式化。
+func T(lang, key string, args ...any) string {
+	msg, ok := Lookup(lang, key)
+	if !ok {
+		if msg, ok = Lookup(Default(), key); !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg
+		if field, ok := arg.(Field); ok {
+			values[i] = string(field)
+			if name, ok := Lookup(lang, "field."+string(field)); ok {
+				values[i] = name
+			}
+		}
+	}
+	return fmt.Sprintf(msg, values...)
+}
+
+// Match picks the best registered language for an Accept-Language header
+// Returns the default language when nothing matches.
+// Match 为 Accept-Language 请求头选择最合适的已注册语言
+// 无匹配时返回默认语言。
+func Match(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{normalize(tag), q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, c := range candidates {
+		if _, ok := catalogs[c.lang]; ok {
+			return c.lang
+		}
+		if base, _, found := strings.Cut(c.lang, "-"); found {
+			if _, ok := catalogs[base]; ok {
+				return base
+			}
+		}
+	}
+	return defaultLang
+}
+
+// normalize lowercases a language tag and uses "-" as separator (zh_CN -> zh-cn)
+// normalize 将语言标签转为小写并使用 "-" 分隔（zh_CN -> zh-cn）
+func normalize(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// flatten turns nested TOML tables into dotted keys
+// flatten 将嵌套的 TOML 表展开为点分隔的键
+func flatten(prefix string, raw map[string]any, out map[string]string) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flatten(key, val, out)
+		case string:
+			out[key] = val
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
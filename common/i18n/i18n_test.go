@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	Register("en", map[string]string{"hello": "Hello"})
+	Register("zh", map[string]string{"hello": "你好"})
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"zh-CN,zh;q=0.9,en;q=0.8", "zh"},
+		{"en-US,en;q=0.9", "en"},
+		{"fr;q=0.9,zh;q=0.5", "zh"},
+		{"en;q=0.3,zh;q=0.7", "zh"},
+		{"fr", "en"},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	Register("en", map[string]string{"greet": "Hello %s"})
+	Register("zh", map[string]string{"greet": "你好 %s"})
+
+	if got := T("zh-TW", "greet", "crab"); got != "你好 crab" {
+		t.Errorf("T(zh-TW) = %q", got)
+	}
+	if got := T("fr", "greet", "crab"); got != "Hello crab" {
+		t.Errorf("T(fr) fallback = %q", got)
+	}
+	if got := T("en", "missing.key"); got != "missing.key" {
+		t.Errorf("T(missing) = %q", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	data := "[code]\n1001 = \"未认证\"\n\n[validation]\nrequired = \"%s 不能为空\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "zh.toml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if got, _ := Lookup("zh", "code.1001"); got != "未认证" {
+		t.Errorf("code.1001 = %q", got)
+	}
+	if got := T("zh", "validation.required", "username"); got != "username 不能为空" {
+		t.Errorf("validation.required = %q", got)
+	}
+}
+
+func TestTField(t *testing.T) {
+	Register("en", map[string]string{"validation.required": "%s is required"})
+	Register("zh", map[string]string{"validation.required": "%s 不能为空", "field.username": "用户名"})
+
+	args := []any{Field("username")}
+	if got := T("zh", "validation.required", args...); got != "用户名 不能为空" {
+		t.Errorf("T(zh) = %q", got)
+	}
+	if got := T("en", "validation.required", args...); got != "username is required" {
+		t.Errorf("T(en) = %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package importer
+
+import (
+	stderrors "errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/authz"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/sheet"
+)
+
+// RegisterRoutes mounts the import endpoints on router; protect the router with auth middleware
+// RegisterRoutes 在 router 上挂载导入接口；请使用认证中间件保护该路由
+func RegisterRoutes(router fiber.Router) {
+	router.Get("/tasks/:id", getTask)
+	router.Post("/:name", upload)
+}
+
+// upload imports the multipart "file" field; files above async_size, or with async=true, run in the background
+// Form fields: async, notify (push import.done / import.failed over WebSocket).
+// upload 导入 multipart 的 "file" 字段；超过 async_size 或 async=true 的文件在后台运行
+// 表单字段：async、notify（通过 WebSocket 推送 import.done / import.failed）。
+func upload(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID, ok := ctxutil.UserID(ctx)
+	if !ok {
+		return errors.ErrUnauthorized()
+	}
+	name := c.Params("name")
+	s, ok := lookup(name)
+	if !ok {
+		return errors.ErrNotFound()
+	}
+	if s.Permission != "" {
+		allowed, err := authz.HasPermission(ctx, userID, s.Permission)
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		if !allowed {
+			return errors.ErrForbidden()
+		}
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return errors.ErrParamInvalid("file is required")
+	}
+	format := sheet.FormatOf(fh.Filename)
+	if format == "" {
+		return errors.ErrParamInvalid("only csv and xlsx files are supported")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	defer f.Close()
+
+	if c.FormValue("async") == "true" || fh.Size > int64(cfg.AsyncSize) {
+		task, err := Start(ctx, name, format, f, fh.Size, c.FormValue("notify") == "true")
+		if err != nil {
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OK(c, task)
+	}
+
+	report, err := Import(ctx, name, format, f, fh.Size)
+	if stderrors.Is(err, ErrInvalidFile) {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, report)
+}
+
+// getTask returns a task of the current user; other users' tasks look missing
+// getTask 返回当前用户的任务；其他用户的任务视为不存在
+func getTask(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	task, err := Get(ctx, c.Params("id"))
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	if task == nil || task.UserID != ctxutil.MustUserID(ctx) {
+		return errors.ErrNotFound()
+	}
+	return response.OK(c, task)
+}
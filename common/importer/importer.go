@@ -0,0 +1,423 @@
+// Package importer loads CSV/XLSX files into the database
+// Every row is checked against the columns of a registered schema, valid rows are
+// inserted in batches, each batch in its own transaction, and rejected rows are
+// collected into a per-row error report. Small files are imported within the
+// request; large ones are uploaded to pkg/storage and imported by a jobs worker.
+// importer 包将 CSV/XLSX 文件导入数据库
+// 每一行都按已注册模式的列进行校验，有效行分批插入，每批一个事务，
+// 被拒绝的行汇总为逐行的错误报告。小文件在请求内导入；
+// 大文件上传到 pkg/storage 后由 jobs 工作协程导入。
+//
+// Usage | 用法:
+//
+//	importer.Register("users", importer.Schema{
+//		Permission: "user:import",
+//		Columns: []importer.Column{
+//			{Name: "email", Required: true, Validate: validateEmail},
+//			{Name: "name", Required: true},
+//			{Name: "age"},
+//		},
+//		Parse: func(ctx context.Context, row importer.Row) (any, error) {
+//			age, err := strconv.Atoi(row.Get("age"))
+//			if err != nil && row.Get("age") != "" {
+//				return nil, &importer.RowError{Column: "age", Message: "not a number"}
+//			}
+//			return &User{Email: row.Get("email"), Name: row.Get("name"), Age: age}, nil
+//		},
+//	})
+//
+//	// POST {path}/users (multipart "file") -> report, or a task for large files; GET {path}/tasks/:id polls it
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/pkg/jobs"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/sheet"
+	"github.com/nuohe369/crab/pkg/storage"
+	"github.com/nuohe369/crab/pkg/transaction"
+	"xorm.io/xorm"
+)
+
+var log = logger.NewSystem("importer")
+
+// ErrUnknownSchema is returned for an unregistered schema name
+// ErrUnknownSchema 在模式名称未注册时返回
+var ErrUnknownSchema = errors.New("importer: unknown schema")
+
+// ErrInvalidFile is returned when the file cannot be imported at all (bad header, too many rows, unreadable)
+// ErrInvalidFile 在文件完全无法导入时返回（表头错误、行数过多、无法读取）
+var ErrInvalidFile = errors.New("importer: invalid file")
+
+// Config represents import configuration
+// Config 表示导入配置
+type Config struct {
+	Path      string        `toml:"path"`       // User endpoints (JWT), empty disables | 用户接口路径（JWT），为空时禁用
+	Dir       string        `toml:"dir"`        // Storage key prefix of async uploads (default "imports") | 异步上传文件的存储键前缀（默认 "imports"）
+	Queue     string        `toml:"queue"`      // Jobs queue (default "default") | 任务队列（默认 "default"）
+	TaskTTL   time.Duration `toml:"task_ttl"`   // How long task status is kept (default 24h) | 任务状态保留时长（默认 24 小时）
+	AsyncSize int           `toml:"async_size"` // Files larger than this many bytes import in the background (default 1MB) | 超过该字节数的文件在后台导入（默认 1MB）
+	BatchSize int           `toml:"batch_size"` // Rows per transaction (default 500) | 每个事务的行数（默认 500）
+	MaxRows   int           `toml:"max_rows"`   // Rows per file (default 100000) | 每个文件的行数上限（默认 100000）
+	MaxErrors int           `toml:"max_errors"` // Row errors kept in the report (default 1000) | 报告中保留的行错误数（默认 1000）
+}
+
+// withDefaults fills zero fields with defaults | withDefaults 用默认值填充零值字段
+func (c Config) withDefaults() Config {
+	if c.Dir == "" {
+		c.Dir = "imports"
+	}
+	if c.Queue == "" {
+		c.Queue = jobs.DefaultQueue
+	}
+	if c.TaskTTL <= 0 {
+		c.TaskTTL = 24 * time.Hour
+	}
+	if c.AsyncSize <= 0 {
+		c.AsyncSize = 1 << 20
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
+	}
+	if c.MaxRows <= 0 {
+		c.MaxRows = 100000
+	}
+	if c.MaxErrors <= 0 {
+		c.MaxErrors = 1000
+	}
+	return c
+}
+
+// Column describes one column of the file, matched to the header case-insensitively
+// Column 描述文件中的一列，与表头按不区分大小写的方式匹配
+type Column struct {
+	Name     string                   // Header text | 表头文本
+	Required bool                     // The header must exist and the value must not be empty | 表头必须存在且值不能为空
+	Validate func(value string) error // Checks a non-empty value, optional | 校验非空值，可选
+}
+
+// Schema describes how the rows of one import are validated and stored
+// Schema 描述一种导入的行如何校验和存储
+type Schema struct {
+	Permission string   // Required permission, empty allows any authenticated user | 所需权限，为空时允许任何已认证用户
+	Columns    []Column // Expected columns, others are ignored | 期望的列，其他列会被忽略
+	DB         string   // pgsql instance name, empty uses the default | pgsql 实例名称，为空时使用默认实例
+	// Parse converts a validated row into a bean; return a *RowError to point at a column
+	// Parse 将已校验的行转换为 bean；返回 *RowError 可指向具体列
+	Parse func(ctx context.Context, row Row) (any, error)
+	// Insert stores one batch within the transaction, optional: defaults to session.Insert(beans...)
+	// Insert 在事务内存储一批数据，可选：默认为 session.Insert(beans...)
+	Insert func(ctx context.Context, session *xorm.Session, beans []any) error
+}
+
+// Row is one data row of the file
+// Row 表示文件中的一行数据
+type Row struct {
+	Line   int               // Line (CSV) or row number (XLSX) in the file | 在文件中的行号
+	Values map[string]string // Trimmed values keyed by Column.Name | 以 Column.Name 为键的去空格值
+}
+
+// Get returns the value of a column
+// Get 返回某列的值
+func (r Row) Get(column string) string {
+	return r.Values[column]
+}
+
+// RowError is one problem found in a row
+// RowError 表示某行中发现的一个问题
+type RowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %s: %s", e.Line, e.Column, e.Message)
+}
+
+// Report summarizes an import
+// Report 汇总一次导入的结果
+type Report struct {
+	Total     int        `json:"total"`               // Data rows read, blank rows excluded | 读取的数据行数，不含空行
+	Inserted  int        `json:"inserted"`            // Rows stored | 已存储的行数
+	Failed    int        `json:"failed"`              // Rows rejected | 被拒绝的行数
+	Errors    []RowError `json:"errors"`              // Why rows were rejected | 行被拒绝的原因
+	Truncated bool       `json:"truncated,omitempty"` // More errors than max_errors | 错误数超过 max_errors
+}
+
+// fail records a rejected row
+// fail 记录一个被拒绝的行
+func (r *Report) fail(errs ...RowError) {
+	r.Failed++
+	for _, e := range errs {
+		if len(r.Errors) >= cfg.MaxErrors {
+			r.Truncated = true
+			return
+		}
+		r.Errors = append(r.Errors, e)
+	}
+}
+
+var (
+	cfg = Config{}.withDefaults()
+
+	mu      sync.RWMutex
+	schemas           = make(map[string]Schema)
+	store   TaskStore = NewMemoryTaskStore()
+	files   storage.Storage
+	notify  NotifyFunc
+)
+
+// Init initializes import configuration and registers the worker with jobs
+// Init 初始化导入配置并向 jobs 注册工作处理器
+func Init(c Config) {
+	cfg = c.withDefaults()
+
+	mu.Lock()
+	if client := redis.Get(); client != nil {
+		store = NewRedisTaskStore(client)
+	}
+	files = storage.Get()
+	mu.Unlock()
+
+	jobs.Register(jobType, jobs.Handle(func(ctx context.Context, id string) error {
+		return run(ctx, id)
+	}))
+	log.Info("Importer initialized: storage=%v, async=%v", files != nil, jobs.Get() != nil)
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// Register adds or replaces a schema
+// Register 添加或替换模式
+func Register(name string, s Schema) {
+	if s.Parse == nil {
+		panic("importer: schema " + name + " has no Parse")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	schemas[name] = s
+}
+
+// lookup returns a registered schema
+// lookup 返回已注册的模式
+func lookup(name string) (Schema, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := schemas[name]
+	return s, ok
+}
+
+// Import reads, validates and stores a whole file within the call
+// Import 在调用内读取、校验并存储整个文件
+func Import(ctx context.Context, name, format string, r io.ReaderAt, size int64) (*Report, error) {
+	s, ok := lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, name)
+	}
+	rd, err := sheet.NewReader(format, r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return process(ctx, s, rd, nil)
+}
+
+// insertBatch stores beans in one transaction; replaced in tests
+// insertBatch 在一个事务中存储 beans；测试中会被替换
+var insertBatch = func(ctx context.Context, s Schema, beans []any) error {
+	db, err := model.GetDBSafe(nil, s.DB)
+	if err != nil {
+		return err
+	}
+	return transaction.WithTransaction(db, func(session *xorm.Session) error {
+		session.Context(ctx)
+		if s.Insert != nil {
+			return s.Insert(ctx, session, beans)
+		}
+		_, err := session.Insert(beans...)
+		return err
+	})
+}
+
+// pending is a parsed row waiting for its batch
+// pending 是等待所在批次的已解析行
+type pending struct {
+	line int
+	bean any
+}
+
+// process imports the rows of r; progress, if set, is called after every batch
+// process 导入 r 中的行；progress 非空时在每批之后调用
+func process(ctx context.Context, s Schema, r sheet.Reader, progress func(*Report)) (*Report, error) {
+	rep := &Report{Errors: []RowError{}}
+	header, err := r.Read()
+	if err == io.EOF {
+		return rep, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return rep, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	index, err := mapHeader(s.Columns, header)
+	if err != nil {
+		return rep, err
+	}
+
+	batch := make([]pending, 0, cfg.BatchSize)
+	for {
+		cells, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rep, fmt.Errorf("%w: after line %d: %v", ErrInvalidFile, r.Line(), err)
+		}
+		if blank(cells) {
+			continue
+		}
+		if rep.Total >= cfg.MaxRows {
+			return rep, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, cfg.MaxRows)
+		}
+		rep.Total++
+
+		row := Row{Line: r.Line(), Values: make(map[string]string, len(s.Columns))}
+		for i, c := range s.Columns {
+			if j := index[i]; j >= 0 && j < len(cells) {
+				row.Values[c.Name] = strings.TrimSpace(cells[j])
+			}
+		}
+		if errs := validate(s.Columns, row); len(errs) > 0 {
+			rep.fail(errs...)
+			continue
+		}
+		bean, err := s.Parse(ctx, row)
+		if err != nil {
+			rep.fail(rowError(row.Line, err))
+			continue
+		}
+
+		batch = append(batch, pending{line: row.Line, bean: bean})
+		if len(batch) >= cfg.BatchSize {
+			flush(ctx, s, batch, rep)
+			batch = batch[:0]
+			if progress != nil {
+				progress(rep)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return rep, err
+		}
+	}
+	flush(ctx, s, batch, rep)
+	return rep, nil
+}
+
+// flush inserts a batch; when the transaction fails the rows are retried one by one to find the bad ones
+// flush 插入一批数据；事务失败时逐行重试以找出错误行
+func flush(ctx context.Context, s Schema, batch []pending, rep *Report) {
+	if len(batch) == 0 {
+		return
+	}
+	beans := make([]any, len(batch))
+	for i, p := range batch {
+		beans[i] = p.bean
+	}
+	if err := insertBatch(ctx, s, beans); err == nil {
+		rep.Inserted += len(batch)
+		return
+	}
+	for _, p := range batch {
+		if err := insertBatch(ctx, s, []any{p.bean}); err != nil {
+			rep.fail(rowError(p.line, err))
+			continue
+		}
+		rep.Inserted++
+	}
+}
+
+// mapHeader returns the file column of every schema column, -1 if absent
+// mapHeader 返回每个模式列在文件中的列号，不存在时为 -1
+func mapHeader(columns []Column, header []string) ([]int, error) {
+	pos := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, ok := pos[h]; !ok {
+			pos[h] = i
+		}
+	}
+	index := make([]int, len(columns))
+	var missing []string
+	for i, c := range columns {
+		j, ok := pos[strings.ToLower(c.Name)]
+		if !ok {
+			j = -1
+			if c.Required {
+				missing = append(missing, c.Name)
+			}
+		}
+		index[i] = j
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing columns %s", ErrInvalidFile, strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+// validate checks the values of a row against the columns
+// validate 按列校验一行的值
+func validate(columns []Column, row Row) []RowError {
+	var errs []RowError
+	for _, c := range columns {
+		v := row.Values[c.Name]
+		if v == "" {
+			if c.Required {
+				errs = append(errs, RowError{Line: row.Line, Column: c.Name, Message: "required"})
+			}
+			continue
+		}
+		if c.Validate != nil {
+			if err := c.Validate(v); err != nil {
+				errs = append(errs, RowError{Line: row.Line, Column: c.Name, Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
+
+// rowError converts an error of Parse or Insert into a RowError
+// rowError 将 Parse 或 Insert 的错误转换为 RowError
+func rowError(line int, err error) RowError {
+	var re *RowError
+	if errors.As(err, &re) {
+		e := *re
+		e.Line = line
+		return e
+	}
+	return RowError{Line: line, Message: err.Error()}
+}
+
+// blank reports whether every cell is empty
+// blank 判断是否所有单元格都为空
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/storage"
+)
+
+type user struct {
+	Email string
+	Age   int
+}
+
+type memStorage struct {
+	storage.Storage
+	files map[string][]byte
+}
+
+func (m *memStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	m.files[key] = data
+	return err
+}
+
+func (m *memStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.files[key])), nil
+}
+
+// fakeDB records inserted beans and rejects users whose email is taken
+type fakeDB struct {
+	mu       sync.Mutex
+	batches  int
+	inserted []string
+}
+
+func (f *fakeDB) insert(_ context.Context, _ Schema, beans []any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches++
+	for _, b := range beans {
+		if b.(*user).Email == "taken@x.io" {
+			return errors.New("duplicate key")
+		}
+	}
+	for _, b := range beans {
+		f.inserted = append(f.inserted, b.(*user).Email)
+	}
+	return nil
+}
+
+// setup installs in-memory storage, task store and a fake database
+func setup(t *testing.T) *fakeDB {
+	t.Helper()
+	db := &fakeDB{}
+	mu.Lock()
+	files, store = &memStorage{files: map[string][]byte{}}, NewMemoryTaskStore()
+	schemas = map[string]Schema{}
+	mu.Unlock()
+	prev, prevCfg := insertBatch, cfg
+	insertBatch = db.insert
+	cfg = Config{BatchSize: 2}.withDefaults()
+	t.Cleanup(func() { insertBatch, cfg = prev, prevCfg })
+
+	Register("users", Schema{
+		Columns: []Column{
+			{Name: "email", Required: true, Validate: func(v string) error {
+				if !strings.Contains(v, "@") {
+					return errors.New("invalid email")
+				}
+				return nil
+			}},
+			{Name: "age"},
+		},
+		Parse: func(_ context.Context, row Row) (any, error) {
+			age, err := strconv.Atoi(row.Get("age"))
+			if err != nil && row.Get("age") != "" {
+				return nil, &RowError{Column: "age", Message: "not a number"}
+			}
+			return &user{Email: row.Get("email"), Age: age}, nil
+		},
+	})
+	return db
+}
+
+func TestImportReport(t *testing.T) {
+	db := setup(t)
+	file := "Age,EMAIL,extra\n" +
+		"30,a@x.io,\n" +
+		"31,taken@x.io,\n" +
+		",,\n" +
+		"old,b@x.io,\n" +
+		"40,nope,\n" +
+		"41,,\n" +
+		"42,c@x.io,\n"
+	rep, err := Import(context.Background(), "users", "csv", strings.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Total != 6 || rep.Inserted != 2 || rep.Failed != 4 {
+		t.Fatalf("report = %+v", rep)
+	}
+	want := []RowError{
+		{Line: 3, Message: "duplicate key"},
+		{Line: 5, Column: "age", Message: "not a number"},
+		{Line: 6, Column: "email", Message: "invalid email"},
+		{Line: 7, Column: "email", Message: "required"},
+	}
+	for i, e := range want {
+		if i >= len(rep.Errors) || rep.Errors[i] != e {
+			t.Fatalf("errors = %+v, want %+v", rep.Errors, want)
+		}
+	}
+	// The failed batch is retried row by row so a@x.io is still stored
+	if strings.Join(db.inserted, ",") != "a@x.io,c@x.io" {
+		t.Errorf("inserted = %v", db.inserted)
+	}
+}
+
+func TestImportInvalidFile(t *testing.T) {
+	setup(t)
+	for name, file := range map[string]string{
+		"missing column": "name,age\nBob,3\n",
+		"empty":          "",
+	} {
+		_, err := Import(context.Background(), "users", "csv", strings.NewReader(file), int64(len(file)))
+		if !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("%s: err = %v, want ErrInvalidFile", name, err)
+		}
+	}
+	if _, err := Import(context.Background(), "orders", "csv", strings.NewReader(""), 0); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("unknown schema err = %v", err)
+	}
+}
+
+func TestImportMaxErrors(t *testing.T) {
+	setup(t)
+	cfg.MaxErrors = 2
+	file := "email\nx\ny\nz\n"
+	rep, err := Import(context.Background(), "users", "csv", strings.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Failed != 3 || len(rep.Errors) != 2 || !rep.Truncated {
+		t.Errorf("report = %+v", rep)
+	}
+}
+
+func TestImportAsync(t *testing.T) {
+	db := setup(t)
+	var pushed []string
+	var pushMu sync.Mutex
+	SetNotifier(func(_ context.Context, userID int64, msgType string, _ any) error {
+		pushMu.Lock()
+		defer pushMu.Unlock()
+		pushed = append(pushed, msgType)
+		return nil
+	})
+
+	ctx := ctxutil.WithUser(context.Background(), 42, "admin")
+	file := "email\na@x.io\nb@x.io\nc@x.io\n"
+	task, err := Start(ctx, "users", "csv", strings.NewReader(file), int64(len(file)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(task.File, "imports/") || !strings.HasSuffix(task.File, task.ID+".csv") {
+		t.Errorf("file = %s", task.File)
+	}
+
+	// Without a jobs runner the import runs in a goroutine of this instance
+	var got *Task
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if got, _ = Get(ctx, task.ID); got != nil && got.Status == StatusDone {
+			break
+		}
+	}
+	if got == nil || got.Status != StatusDone || got.Report == nil || got.Report.Inserted != 3 {
+		t.Fatalf("task = %+v", got)
+	}
+	db.mu.Lock()
+	if db.batches != 2 {
+		t.Errorf("batches = %d, want 2", db.batches)
+	}
+	db.mu.Unlock()
+	pushMu.Lock()
+	if len(pushed) != 1 || pushed[0] != MessageDone {
+		t.Errorf("pushed = %v", pushed)
+	}
+	pushMu.Unlock()
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/jobs"
+	"github.com/nuohe369/crab/pkg/sheet"
+)
+
+// Task states | 任务状态
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// WebSocket message types sent when Notify is set | 设置 Notify 时发送的 WebSocket 消息类型
+const (
+	MessageDone   = "import.done"
+	MessageFailed = "import.failed"
+)
+
+// jobType is the jobs type running an import
+// jobType 是运行导入的任务类型
+const jobType = "import.run"
+
+// Task is one background import
+// Task 表示一次后台导入
+type Task struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Format    string    `json:"format"`
+	File      string    `json:"file"` // Storage key of the upload | 上传文件的存储键
+	UserID    int64     `json:"user_id,string"`
+	Plat      string    `json:"plat,omitempty"`
+	Notify    bool      `json:"notify"`
+	Status    string    `json:"status"`
+	Report    *Report   `json:"report,omitempty"` // Progress while running, result when finished | 运行中为进度，结束后为结果
+	Error     string    `json:"error,omitempty"`  // Why the whole file failed | 整个文件失败的原因
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotifyFunc pushes a message to a user
+// NotifyFunc 向用户推送消息
+type NotifyFunc func(ctx context.Context, userID int64, msgType string, payload any) error
+
+// SetNotifier sets how users are told that an import finished
+// SetNotifier 设置导入完成时通知用户的方式
+func SetNotifier(fn NotifyFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	notify = fn
+}
+
+func getStore() TaskStore {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Start uploads the file to storage and queues its import for the user of ctx
+// Without a jobs runner (no mq) the import runs in a goroutine of this instance.
+// Start 将文件上传到存储，并为 ctx 中的用户将导入加入队列
+// 没有 jobs 运行器（未配置 mq）时，导入在本实例的协程中运行。
+func Start(ctx context.Context, name, format string, r io.Reader, size int64, notifyUser bool) (*Task, error) {
+	if _, ok := lookup(name); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, name)
+	}
+	if !sheet.Supported(format) {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidFile, format)
+	}
+	mu.RLock()
+	dst := files
+	mu.RUnlock()
+	if dst == nil {
+		return nil, errors.New("importer: storage is not configured")
+	}
+
+	now := time.Now()
+	t := &Task{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Format:    format,
+		UserID:    ctxutil.MustUserID(ctx),
+		Plat:      ctxutil.Plat(ctx),
+		Notify:    notifyUser,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	t.File = fmt.Sprintf("%s/%s/%s.%s", cfg.Dir, now.Format("20060102"), t.ID, format)
+	if err := dst.Put(ctx, t.File, r, size, sheet.ContentType(format)); err != nil {
+		return nil, fmt.Errorf("importer: upload: %w", err)
+	}
+	if err := getStore().Save(ctx, t, cfg.TaskTTL); err != nil {
+		return nil, fmt.Errorf("importer: save task: %w", err)
+	}
+
+	// One attempt only: rerunning a half-imported file would insert its rows twice | 仅执行一次：重新运行已部分导入的文件会重复插入行
+	_, err := jobs.Enqueue(ctx, jobType, t.ID, jobs.Queue(cfg.Queue), jobs.MaxRetries(-1))
+	if errors.Is(err, jobs.ErrNotInitialized) {
+		go func(ctx context.Context) {
+			if err := run(ctx, t.ID); err != nil {
+				log.ErrorCtx(ctx, "Import %s failed: %v", t.ID, err)
+			}
+		}(ctxutil.Detach(ctx))
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Get returns a task, nil if missing or expired
+// Get 返回任务，不存在或已过期时返回 nil
+func Get(ctx context.Context, id string) (*Task, error) {
+	return getStore().Load(ctx, id)
+}
+
+// run imports the uploaded file of one task
+// run 导入一个任务上传的文件
+func run(ctx context.Context, id string) error {
+	t, err := getStore().Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return jobs.Permanent(fmt.Errorf("importer: task %s not found", id))
+	}
+
+	s, ok := lookup(t.Name)
+	if !ok {
+		return finish(ctx, t, fmt.Errorf("%w: %s", ErrUnknownSchema, t.Name))
+	}
+	ctx = ctxutil.WithUser(ctx, t.UserID, t.Plat)
+
+	t.Status = StatusRunning
+	save(ctx, t)
+
+	rep, err := importFile(ctx, t, s)
+	t.Report = rep
+	return finish(ctx, t, err)
+}
+
+// importFile downloads the upload to a temporary file and imports it
+// importFile 将上传文件下载到临时文件并导入
+func importFile(ctx context.Context, t *Task, s Schema) (*Report, error) {
+	mu.RLock()
+	src := files
+	mu.RUnlock()
+	if src == nil {
+		return nil, errors.New("storage is not configured")
+	}
+
+	body, err := src.Get(ctx, t.File)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "import-*."+t.Format)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, body)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	r, err := sheet.NewReader(t.Format, tmp, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return process(ctx, s, r, func(rep *Report) {
+		// Counts only, the errors are stored once at the end | 仅保存计数，错误在结束时保存一次
+		t.Report = &Report{Total: rep.Total, Inserted: rep.Inserted, Failed: rep.Failed}
+		save(ctx, t)
+	})
+}
+
+// finish records the outcome and notifies the user; the task error is not returned so jobs acknowledges it
+// finish 记录结果并通知用户；任务错误不会返回，使 jobs 确认该任务
+func finish(ctx context.Context, t *Task, err error) error {
+	msgType := MessageDone
+	if err != nil {
+		t.Status, t.Error = StatusFailed, err.Error()
+		msgType = MessageFailed
+		log.WarnCtx(ctx, "Import %s (%s) failed: %v", t.ID, t.Name, err)
+	} else {
+		t.Status = StatusDone
+	}
+	save(ctx, t)
+
+	mu.RLock()
+	fn := notify
+	mu.RUnlock()
+	if t.Notify && fn != nil && t.UserID != 0 {
+		if nerr := fn(ctx, t.UserID, msgType, t); nerr != nil {
+			log.WarnCtx(ctx, "Notify import %s failed: %v", t.ID, nerr)
+		}
+	}
+	return nil
+}
+
+// save stores the task; failures are only logged
+// save 保存任务；失败只记录日志
+func save(ctx context.Context, t *Task) {
+	t.UpdatedAt = time.Now()
+	if err := getStore().Save(ctx, t, cfg.TaskTTL); err != nil {
+		log.WarnCtx(ctx, "Save import task %s failed: %v", t.ID, err)
+	}
+}
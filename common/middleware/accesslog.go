@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/json"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/mask"
+)
+
+// AccessLogConfig defines access log configuration
+// AccessLogConfig 定义访问日志配置
+type AccessLogConfig struct {
+	Enabled             bool                    `toml:"enabled"`               // Register in Setup | 在 Setup 中注册
+	SampleRate          float64                 `toml:"sample_rate"`           // Fraction of successful fast requests to log, 0 means 1 | 记录的成功快速请求比例，0 表示 1
+	SlowThreshold       time.Duration           `toml:"slow_threshold"`        // Requests slower than this are always logged as WARN | 慢于此阈值的请求总是以 WARN 记录
+	CaptureRequestBody  bool                    `toml:"capture_request_body"`  // Log request bodies | 记录请求体
+	CaptureResponseBody bool                    `toml:"capture_response_body"` // Log response bodies | 记录响应体
+	MaxBodySize         int                     `toml:"max_body_size"`         // Captured body size limit in bytes (default 2048) | 记录的请求体大小上限（默认 2048 字节）
+	RedactFields        []string                `toml:"redact_fields"`         // JSON fields replaced by "***" (case-insensitive) | 替换为 "***" 的 JSON 字段（不区分大小写）
+	MaskFields          map[string]string       `toml:"mask_fields"`           // JSON fields partially masked, field to mask kind | 部分脱敏的 JSON 字段，字段到脱敏类型
+	Skip                func(c *fiber.Ctx) bool `toml:"-"`                     // Skip logging for matching requests | 跳过匹配请求的日志
+}
+
+// defaultRedactFields are always redacted from captured bodies
+// defaultRedactFields 在记录的请求体中总是被脱敏
+var defaultRedactFields = []string{"password", "token", "access_token", "refresh_token", "secret", "authorization", "api_key"}
+
+// defaultMaskFields are always masked in captured bodies, see pkg/mask for the kinds
+// defaultMaskFields 在记录的请求体中总是被部分脱敏，脱敏类型见 pkg/mask
+var defaultMaskFields = map[string]string{
+	"phone": "phone", "mobile": "phone",
+	"email":   "email",
+	"id_card": "idcard", "idcard": "idcard", "id_number": "idcard",
+	"bank_card": "bankcard",
+}
+
+// accessLogConfig holds the configuration used by Setup | accessLogConfig 保存 Setup 使用的配置
+var accessLogConfig AccessLogConfig
+
+// InitAccessLog stores the access log configuration used by Setup
+// InitAccessLog 保存 Setup 使用的访问日志配置
+func InitAccessLog(cfg AccessLogConfig) {
+	accessLogConfig = cfg
+}
+
+// AccessLog returns a structured access log middleware
+// Each request is written as one JSON line to logs/access. Errors (status >= 500)
+// and slow requests are always logged; other requests are sampled.
+// AccessLog 返回结构化访问日志中间件
+// 每个请求以一行 JSON 写入 logs/access。错误（状态码 >= 500）和慢请求总是记录，其他请求按比例采样。
+func AccessLog(config ...AccessLogConfig) fiber.Handler {
+	cfg := AccessLogConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 2048
+	}
+	redact := make([]string, 0, len(defaultRedactFields)+len(cfg.RedactFields))
+	for _, f := range append(slices.Clone(defaultRedactFields), cfg.RedactFields...) {
+		redact = append(redact, strings.ToLower(f))
+	}
+	masked := maps.Clone(defaultMaskFields)
+	for f, kind := range cfg.MaskFields {
+		masked[strings.ToLower(f)] = kind
+	}
+
+	log := logger.NewWithName("access")
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+
+		// Let the error handler set the final status, like SmartLogger | 与 SmartLogger 一样，先让错误处理器设置最终状态码
+		if err != nil {
+			if h := c.App().Config().ErrorHandler; h != nil {
+				_ = h(c, err)
+			}
+		}
+
+		latency := time.Since(start)
+		status := c.Response().StatusCode()
+		slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+
+		if status < 500 && !slow && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+			return nil
+		}
+
+		fields := logger.Fields{
+			"method":     c.Method(),
+			"path":       c.Path(),
+			"status":     status,
+			"latency_ms": float64(latency.Microseconds()) / 1000,
+			"ip":         c.IP(),
+			"bytes_in":   len(c.Request().Body()),
+			"bytes_out":  len(c.Response().Body()),
+			"user_agent": c.Get(fiber.HeaderUserAgent),
+		}
+		if id := GetRequestID(c); id != "" {
+			fields["request_id"] = id
+		}
+		if userID, ok := ctxutil.UserID(c.UserContext()); ok {
+			fields["user_id"] = userID
+		} else if userID, ok := c.Locals(ctxutil.LocalsUserID).(int64); ok {
+			fields["user_id"] = userID
+		}
+		if slow {
+			fields["slow"] = true
+		}
+		if cfg.CaptureRequestBody {
+			fields["request_body"] = captureBody(c.Request().Body(), cfg.MaxBodySize, redact, masked)
+		}
+		if cfg.CaptureResponseBody {
+			fields["response_body"] = captureBody(c.Response().Body(), cfg.MaxBodySize, redact, masked)
+		}
+
+		level := logger.INFO
+		switch {
+		case status >= 500:
+			level = logger.ERROR
+		case status >= 400 || slow:
+			level = logger.WARN
+		}
+		log.LogFields(c.UserContext(), level, "access", fields)
+
+		// Error already handled above | 错误已在上面处理
+		return nil
+	}
+}
+
+// captureBody returns a redacted, masked, size-limited representation of a body
+// captureBody 返回脱敏且限制大小的请求体表示
+func captureBody(body []byte, limit int, redact []string, masked map[string]string) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		v = mask.Keys(redactValue(v, redact), masked)
+		if data, err := json.Marshal(v); err == nil && len(data) <= limit {
+			return v
+		} else if err == nil {
+			return string(data[:limit]) + "...(truncated)"
+		}
+	}
+
+	if len(body) > limit {
+		return string(body[:limit]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// redactValue replaces sensitive fields in decoded JSON recursively
+// redactValue 递归替换已解码 JSON 中的敏感字段
+func redactValue(v any, redact []string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if slices.Contains(redact, strings.ToLower(k)) {
+				val[k] = "***"
+			} else {
+				val[k] = redactValue(child, redact)
+			}
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = redactValue(child, redact)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/apikey"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/ratelimit"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/util"
+)
+
+// seedAPIKey caches rec as the record of plain in a miniredis-backed default cache
+func seedAPIKey(t *testing.T, plain string, rec apikey.APIKey) {
+	t.Helper()
+	if cache.Get() == nil {
+		mr := miniredis.RunT(t)
+		rdb, err := redis.New(redis.Config{Addr: mr.Addr()})
+		if err != nil {
+			t.Fatalf("redis.New failed: %v", err)
+		}
+		cache.Init(rdb, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	}
+	if err := cache.SetValue(context.Background(), "apikey:"+util.SHA256(plain), rec, time.Minute); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	prevCfg := apikey.GetConfig()
+	t.Cleanup(func() { apikey.Init(prevCfg); SetLimiter(ratelimit.NewMemory()) })
+	apikey.Init(apikey.Config{Tiers: map[string]apikey.Tier{"basic": {Max: 2, Window: time.Minute}}})
+	SetLimiter(ratelimit.NewMemory())
+
+	seedAPIKey(t, "ck_reader_secret", apikey.APIKey{ID: 1, OwnerID: 7, Status: apikey.StatusActive, Scopes: []string{"order:read"}})
+	seedAPIKey(t, "ck_tiered_secret", apikey.APIKey{ID: 2, Status: apikey.StatusActive, Scopes: []string{"*"}, Tier: "basic"})
+	seedAPIKey(t, "ck_revoked_secret", apikey.APIKey{ID: 3, Status: apikey.StatusRevoked})
+
+	app := newTestApp()
+	app.Get("/orders", APIKey("order:read"), func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(int64)
+		return c.JSON(fiber.Map{"user_id": userID, "key": GetAPIKey(c).ID.Int64()})
+	})
+	app.Post("/orders", APIKey("order:write"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	get := func(method, key, authorization string) int {
+		req := httptest.NewRequest(method, "/orders", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, _ := do(t, app, req)
+		return resp.StatusCode
+	}
+
+	if code := get("GET", "", ""); code != fiber.StatusUnauthorized {
+		t.Errorf("Missing key: expected 401, got %d", code)
+	}
+	if code := get("GET", "ck_revoked_secret", ""); code != fiber.StatusUnauthorized {
+		t.Errorf("Revoked key: expected 401, got %d", code)
+	}
+	if code := get("GET", "", "ApiKey ck_reader_secret"); code != fiber.StatusOK {
+		t.Errorf("Authorization header: expected 200, got %d", code)
+	}
+	if code := get("POST", "ck_reader_secret", ""); code != fiber.StatusForbidden {
+		t.Errorf("Missing scope: expected 403, got %d", code)
+	}
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-API-Key", "ck_reader_secret")
+	if _, body := do(t, app, req); body != `{"key":1,"user_id":7}` {
+		t.Errorf("Expected owner set as user, got %s", body)
+	}
+
+	// The basic tier allows two requests per minute | basic 等级每分钟允许两次请求
+	for i := range 2 {
+		if code := get("GET", "ck_tiered_secret", ""); code != fiber.StatusOK {
+			t.Fatalf("Request %d within tier: expected 200, got %d", i+1, code)
+		}
+	}
+	req = httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-API-Key", "ck_tiered_secret")
+	resp, _ := do(t, app, req)
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("Over tier: expected 429, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" || resp.Header.Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("Expected rate limit headers, got %v", resp.Header)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// RequireAuth returns a middleware that requires a valid JWT Bearer token
+// If plats are given, the token's platform must be one of them.
+// RequireAuth 返回要求有效 JWT Bearer 令牌的中间件
+// 如果指定了平台，令牌的平台必须属于其中之一。
+func RequireAuth(plats ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := GetClaims(c)
+		if claims == nil {
+			// RequestContext may not be registered or may have skipped an expired token | RequestContext 可能未注册或跳过了过期令牌
+			mgr := jwt.Get()
+			if mgr == nil {
+				return errors.ErrServerError("JWT not initialized")
+			}
+			token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" {
+				return errors.ErrUnauthorized()
+			}
+			parsed, err := mgr.Parse(token)
+			if err != nil {
+				if stderrors.Is(err, jwt.ErrExpiredToken) {
+					return errors.New(response.CodeTokenExpired, response.CodeTokenExpired.Msg())
+				}
+				return errors.New(response.CodeTokenInvalid, response.CodeTokenInvalid.Msg())
+			}
+			claims = parsed
+			c.Locals(ctxutil.LocalsClaims, claims)
+			c.Locals(ctxutil.LocalsUserID, claims.ID)
+			c.Locals(ctxutil.LocalsPlat, claims.Plat)
+			c.SetUserContext(ctxutil.WithUser(c.UserContext(), claims.ID, claims.Plat))
+		}
+
+		if len(plats) > 0 && !slices.Contains(plats, claims.Plat) {
+			return errors.ErrForbidden()
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/metrics"
+)
+
+// bodyLimitRule is a body limit for a path prefix | bodyLimitRule 是某个路径前缀的请求体限制
+type bodyLimitRule struct {
+	prefix string
+	max    int
+}
+
+var (
+	bodyLimitDefault int
+	bodyLimitRules   []bodyLimitRule // Longest prefix first | 最长前缀优先
+)
+
+// InitBodyLimits stores the per-prefix body limits used by Setup; def applies to other paths
+// The server accepts bodies up to the largest limit, this middleware enforces the smaller ones.
+// InitBodyLimits 保存 Setup 使用的按路径前缀请求体限制，def 用于其他路径
+// 服务器接受不超过最大限制的请求体，由该中间件执行更小的限制。
+func InitBodyLimits(def int, limits map[string]int) {
+	rules := make([]bodyLimitRule, 0, len(limits))
+	for prefix, max := range limits {
+		rules = append(rules, bodyLimitRule{prefix: strings.TrimRight(prefix, "/"), max: max})
+	}
+	sort.Slice(rules, func(i, j int) bool { return len(rules[i].prefix) > len(rules[j].prefix) })
+	bodyLimitDefault, bodyLimitRules = def, rules
+}
+
+// BodyLimit rejects requests whose body is larger than max bytes with 413
+// It can only lower the server limit ([server] body_limit); raise that for larger uploads.
+// BodyLimit 以 413 拒绝请求体大于 max 字节的请求
+// 只能降低服务器限制（[server] body_limit），更大的上传需调高该配置。
+//
+// Usage | 用法:
+//
+//	router.Post("/comments", middleware.BodyLimit(64<<10), handler.CreateComment)
+func BodyLimit(max int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if metrics.RequestSize(c) > max {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return c.Next()
+	}
+}
+
+// bodyLimits enforces the limits stored by InitBodyLimits, nil when none are configured
+// bodyLimits 执行 InitBodyLimits 保存的限制，未配置时返回 nil
+func bodyLimits() fiber.Handler {
+	if len(bodyLimitRules) == 0 {
+		return nil
+	}
+	def, rules := bodyLimitDefault, bodyLimitRules
+	return func(c *fiber.Ctx) error {
+		max := def
+		path := c.Path()
+		for _, r := range rules {
+			if path == r.prefix || strings.HasPrefix(path, r.prefix+"/") {
+				max = r.max
+				break
+			}
+		}
+		if max > 0 && metrics.RequestSize(c) > max {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return c.Next()
+	}
+}
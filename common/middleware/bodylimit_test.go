@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// useBodyLimits installs per-prefix limits for the test
+func useBodyLimits(t *testing.T, def int, limits map[string]int) {
+	t.Helper()
+	prevDef, prevRules := bodyLimitDefault, bodyLimitRules
+	InitBodyLimits(def, limits)
+	t.Cleanup(func() { bodyLimitDefault, bodyLimitRules = prevDef, prevRules })
+}
+
+func postStatus(t *testing.T, app *fiber.App, path string, size int) int {
+	t.Helper()
+	resp, _ := do(t, app, httptest.NewRequest("POST", path, strings.NewReader(strings.Repeat("x", size))))
+	return resp.StatusCode
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := newTestApp()
+	app.Post("/comments", BodyLimit(16), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	if got := postStatus(t, app, "/comments", 16); got != fiber.StatusOK {
+		t.Errorf("Expected 200 at the limit, got %d", got)
+	}
+	if got := postStatus(t, app, "/comments", 17); got != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 above the limit, got %d", got)
+	}
+}
+
+func TestBodyLimitsByPrefix(t *testing.T) {
+	useBodyLimits(t, 16, map[string]int{
+		"/upload/":       64,
+		"/upload/avatar": 32,
+	})
+
+	app := newTestApp()
+	app.Use(bodyLimits())
+	app.Post("/*", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/comments", 16, fiber.StatusOK},
+		{"/comments", 17, fiber.StatusRequestEntityTooLarge},
+		{"/upload", 64, fiber.StatusOK},
+		{"/upload/file", 64, fiber.StatusOK},
+		{"/upload/file", 65, fiber.StatusRequestEntityTooLarge},
+		// Longest prefix wins | 最长前缀优先
+		{"/upload/avatar", 33, fiber.StatusRequestEntityTooLarge},
+		{"/upload/avatar/big", 32, fiber.StatusOK},
+		// Prefixes match whole path segments | 前缀按完整路径段匹配
+		{"/uploads", 17, fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if got := postStatus(t, app, tt.path, tt.size); got != tt.want {
+			t.Errorf("POST %s with %d bytes: expected %d, got %d", tt.path, tt.size, tt.want, got)
+		}
+	}
+}
+
+func TestBodyLimitsUnconfigured(t *testing.T) {
+	useBodyLimits(t, 16, nil)
+	if bodyLimits() != nil {
+		t.Error("Expected no middleware without per-prefix limits")
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/breaker"
+)
+
+// errHandlerFailed marks a request as a breaker failure | errHandlerFailed 将请求标记为熔断失败
+var errHandlerFailed = fiber.NewError(fiber.StatusInternalServerError)
+
+// BreakerConfig defines circuit breaker middleware configuration
+// BreakerConfig 定义熔断中间件配置
+type BreakerConfig struct {
+	Name      func(c *fiber.Ctx) string          // Breaker name, defaults to "route:" + method + matched path | 熔断器名称，默认为 "route:" + 方法 + 匹配路径
+	IsFailure func(c *fiber.Ctx, err error) bool // Failure check, defaults to defaultBreakerFailure | 失败判定，默认为 defaultBreakerFailure
+	Manager   *breaker.Manager                   // Breaker manager, defaults to breaker.GetManager() | 熔断器管理器，默认为 breaker.GetManager()
+}
+
+// Breaker returns a middleware that runs the handler through a circuit breaker
+// Without a name the breaker is keyed by method and matched path: per route when passed
+// as a route handler, per group prefix when mounted with Use. While the breaker is open,
+// requests are rejected with CodeTooManyRequests and HTTP 503 without reaching the handler.
+// Breaker 返回在熔断器保护下执行处理器的中间件
+// 未指定名称时按方法和匹配路径区分熔断器：作为路由处理器传入时每个路由一个，通过 Use 挂载时每个分组前缀一个。
+// 熔断期间请求直接以 CodeTooManyRequests 和 HTTP 503 拒绝，不会到达处理器。
+//
+// Usage | 用法:
+//
+//	g.Get("/rates", middleware.Breaker(), GetRates)
+func Breaker(name ...string) fiber.Handler {
+	cfg := BreakerConfig{}
+	if len(name) > 0 && name[0] != "" {
+		n := name[0]
+		cfg.Name = func(*fiber.Ctx) string { return n }
+	}
+	return BreakerWithConfig(cfg)
+}
+
+// BreakerWithConfig returns a circuit breaker middleware with custom configuration
+// BreakerWithConfig 返回带自定义配置的熔断中间件
+func BreakerWithConfig(cfg BreakerConfig) fiber.Handler {
+	if cfg.Name == nil {
+		cfg.Name = func(c *fiber.Ctx) string {
+			return "route:" + c.Method() + " " + c.Route().Path
+		}
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = defaultBreakerFailure
+	}
+
+	return func(c *fiber.Ctx) error {
+		m := cfg.Manager
+		if m == nil {
+			m = breaker.GetManager()
+		}
+		cb := m.Get(cfg.Name(c))
+
+		var handlerErr error
+		err := cb.Execute(func() error {
+			handlerErr = c.Next()
+			if cfg.IsFailure(c, handlerErr) {
+				return errHandlerFailed
+			}
+			return nil
+		})
+		if breaker.IsRejected(err) {
+			return breakerRejected(c, cb)
+		}
+		return handlerErr
+	}
+}
+
+// defaultBreakerFailure counts server-side failures only
+// Business errors without a cause are client outcomes and do not trip the breaker.
+// defaultBreakerFailure 只统计服务端失败
+// 不带底层原因的业务错误属于客户端结果，不会触发熔断。
+func defaultBreakerFailure(c *fiber.Ctx, err error) bool {
+	if err == nil {
+		return c.Response().StatusCode() >= fiber.StatusInternalServerError
+	}
+	if fe, ok := err.(*fiber.Error); ok {
+		return fe.Code >= fiber.StatusInternalServerError
+	}
+	if bizErr, ok := err.(*errors.BizError); ok {
+		return bizErr.Err != nil
+	}
+	return true
+}
+
+// breakerRejected writes a 503 response telling the client when to retry
+// breakerRejected 写入 503 响应并告知客户端何时重试
+func breakerRejected(c *fiber.Ctx, cb *breaker.CircuitBreaker) error {
+	retryAfter := int64(math.Ceil(cb.Config().Timeout.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+	c.Status(fiber.StatusServiceUnavailable)
+	return response.Write(c, response.CodeTooManyRequests, response.CodeTooManyRequests.MsgLang(response.Lang(c)), nil)
+}
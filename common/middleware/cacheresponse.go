@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/cache"
+)
+
+// responseCachePrefix namespaces cached responses in pkg/cache | responseCachePrefix 为 pkg/cache 中的缓存响应添加命名空间
+const responseCachePrefix = "response:"
+
+// cachedResponse is a stored GET response, or only the Vary list and generation when the response varies
+// Variants live under keys built from the generation, so replacing the list orphans the old variants.
+// cachedResponse 是保存的 GET 响应，响应存在 Vary 时仅保存 Vary 列表和代号
+// 变体保存在由代号构建的键下，替换列表后旧变体随之失效。
+type cachedResponse struct {
+	Vary        []string `json:"vary,omitempty"`
+	Generation  string   `json:"generation,omitempty"`
+	ContentType string   `json:"content_type,omitempty"`
+	Encoding    string   `json:"encoding,omitempty"`
+	ETag        string   `json:"etag,omitempty"`
+	Body        []byte   `json:"body,omitempty"`
+	Expires     int64    `json:"expires"` // Unix milliseconds, the local level may keep entries longer | Unix 毫秒，本地缓存可能保留更久
+}
+
+// fresh reports whether the entry is still within its TTL | fresh 判断条目是否仍在 TTL 内
+func (r *cachedResponse) fresh(now time.Time) bool {
+	return now.UnixMilli() < r.Expires
+}
+
+// CacheResponse returns a middleware caching successful GET responses in the default pkg/cache for ttl
+// keyFn picks the key of a request, "" bypasses the cache; by default it is the request URL, and
+// requests carrying Authorization bypass the cache. Responses with a Vary header are stored per
+// value of the listed request headers; responses setting cookies, Vary: * or Cache-Control
+// private/no-store are not stored. Without pkg/cache the middleware does nothing.
+// CacheResponse 返回将成功的 GET 响应缓存在默认 pkg/cache 中 ttl 时长的中间件
+// keyFn 决定请求的键，返回 "" 表示跳过缓存；默认使用请求 URL，且携带 Authorization 的请求跳过缓存。
+// 带 Vary 响应头的响应按所列请求头的取值分别保存；设置 Cookie、Vary: * 或 Cache-Control 为 private/no-store 的响应不保存。
+// 未初始化 pkg/cache 时中间件不做任何处理。
+//
+// Usage | 用法:
+//
+//	router.Get("/categories", middleware.CacheResponse(5*time.Minute), h.ListCategories)
+//	// after an update | 更新之后
+//	middleware.PurgeResponse(ctx, "/api/testapi/categories")
+func CacheResponse(ttl time.Duration, keyFn ...func(c *fiber.Ctx) string) fiber.Handler {
+	key := defaultResponseKey
+	if len(keyFn) > 0 && keyFn[0] != nil {
+		key = keyFn[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		store := cache.Get()
+		if store == nil || c.Method() != fiber.MethodGet {
+			return c.Next()
+		}
+		k := key(c)
+		if k == "" {
+			return c.Next()
+		}
+		k = responseCachePrefix + k
+		ctx := c.UserContext()
+
+		meta, hit := lookupResponse(ctx, store, c, k)
+		if hit != nil {
+			if meta != nil {
+				c.Vary(meta.Vary...)
+			}
+			c.Set("X-Cache", "HIT")
+			return hit.write(c)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		c.Set("X-Cache", "MISS")
+		entry, vary, ok := responseEntry(c)
+		if !ok {
+			return nil
+		}
+		// Storing fails only on encoding errors, the response is sent either way
+		// 仅在编码出错时保存失败，响应照常发送
+		entry.Expires = time.Now().Add(ttl).UnixMilli()
+		if len(vary) == 0 {
+			_ = store.SetValue(ctx, k, entry, ttl)
+			return nil
+		}
+		if meta == nil || !slices.Equal(meta.Vary, vary) {
+			meta = &cachedResponse{Vary: vary, Generation: strconv.FormatInt(time.Now().UnixNano(), 36), Expires: entry.Expires}
+			_ = store.SetValue(ctx, k, meta, ttl)
+		}
+		_ = store.SetValue(ctx, variantKey(c, k, meta), entry, ttl)
+		return nil
+	}
+}
+
+// PurgeResponse drops the responses CacheResponse stored under keys, all Vary variants included
+// Other instances drop their local copies when the pkg/cache local TTL runs out.
+// PurgeResponse 删除 CacheResponse 以 keys 保存的响应，包括所有 Vary 变体
+// 其他实例的本地副本在 pkg/cache 本地 TTL 到期后删除。
+func PurgeResponse(ctx context.Context, keys ...string) error {
+	store := cache.Get()
+	if store == nil || len(keys) == 0 {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = responseCachePrefix + k
+	}
+	return store.Del(ctx, full...)
+}
+
+// defaultResponseKey keys a request by its URL, skipping authenticated requests
+// defaultResponseKey 以请求 URL 作为键，跳过已认证的请求
+func defaultResponseKey(c *fiber.Ctx) string {
+	if c.Get(fiber.HeaderAuthorization) != "" {
+		return ""
+	}
+	return c.OriginalURL()
+}
+
+// lookupResponse returns the fresh response stored for the request, and the Vary entry when the key has one
+// lookupResponse 返回为请求保存的有效响应，以及键对应的 Vary 条目（如果存在）
+func lookupResponse(ctx context.Context, store *cache.Cache, c *fiber.Ctx, key string) (meta, hit *cachedResponse) {
+	now := time.Now()
+	var entry cachedResponse
+	if store.GetValue(ctx, key, &entry) != nil || !entry.fresh(now) {
+		return nil, nil
+	}
+	if len(entry.Vary) == 0 {
+		return nil, &entry
+	}
+	var variant cachedResponse
+	if store.GetValue(ctx, variantKey(c, key, &entry), &variant) != nil || !variant.fresh(now) {
+		return &entry, nil
+	}
+	return &entry, &variant
+}
+
+// variantKey returns the key of the variant matching the request headers listed in meta
+// variantKey 返回与 meta 所列请求头匹配的变体键
+func variantKey(c *fiber.Ctx, key string, meta *cachedResponse) string {
+	h := xxhash.New()
+	for _, name := range meta.Vary {
+		h.WriteString(c.Get(name))
+		h.WriteString("\x00")
+	}
+	return key + "|" + meta.Generation + "|" + strconv.FormatUint(h.Sum64(), 36)
+}
+
+// responseEntry captures the response for storing with its normalized Vary list, false when it must not be cached
+// responseEntry 捕获待保存的响应及规范化的 Vary 列表，不可缓存时返回 false
+func responseEntry(c *fiber.Ctx) (*cachedResponse, []string, bool) {
+	resp := c.Response()
+	if resp.StatusCode() != fiber.StatusOK || resp.IsBodyStream() || len(resp.Header.Peek(fiber.HeaderSetCookie)) > 0 {
+		return nil, nil, false
+	}
+	cacheControl := strings.ToLower(c.GetRespHeader(fiber.HeaderCacheControl))
+	if strings.Contains(cacheControl, "private") || strings.Contains(cacheControl, "no-store") {
+		return nil, nil, false
+	}
+
+	var vary []string
+	for _, name := range strings.Split(c.GetRespHeader(fiber.HeaderVary), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "*" {
+			return nil, nil, false
+		}
+		if name != "" && !slices.Contains(vary, name) {
+			vary = append(vary, name)
+		}
+	}
+	slices.Sort(vary)
+
+	return &cachedResponse{
+		ContentType: string(resp.Header.ContentType()),
+		Encoding:    string(resp.Header.ContentEncoding()),
+		ETag:        c.GetRespHeader(fiber.HeaderETag),
+		Body:        append([]byte(nil), resp.Body()...),
+	}, vary, true
+}
+
+// write sends the stored response | write 发送保存的响应
+func (r *cachedResponse) write(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, r.ContentType)
+	if r.Encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, r.Encoding)
+	}
+	if r.ETag != "" {
+		c.Set(fiber.HeaderETag, r.ETag)
+	}
+	return c.Status(fiber.StatusOK).Send(r.Body)
+}
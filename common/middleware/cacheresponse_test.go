@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+// useCache points the default cache at a fresh miniredis server for the test
+func useCache(t *testing.T) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New failed: %v", err)
+	}
+	cache.Init(rdb, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	t.Cleanup(cache.Get().Close)
+}
+
+// newCachedApp serves cached routes counting handler calls; /localized varies by Accept-Language
+func newCachedApp(ttl time.Duration) (*fiber.App, *int) {
+	calls := 0
+	app := fiber.New()
+	app.Get("/categories", CacheResponse(ttl), func(c *fiber.Ctx) error {
+		calls++
+		return c.JSON(fiber.Map{"calls": calls})
+	})
+	app.Get("/localized", CacheResponse(ttl), func(c *fiber.Ctx) error {
+		calls++
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.SendString(c.Get(fiber.HeaderAcceptLanguage) + " " + strconv.Itoa(calls))
+	})
+	app.Get("/private", CacheResponse(ttl), func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "private")
+		return c.SendString(strconv.Itoa(calls))
+	})
+	return app, &calls
+}
+
+func getCached(t *testing.T, app *fiber.App, path string, headers map[string]string) (string, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, body := do(t, app, req)
+	return body, resp.Header.Get("X-Cache")
+}
+
+func TestCacheResponse(t *testing.T) {
+	useCache(t)
+	app, calls := newCachedApp(time.Minute)
+
+	first, state := getCached(t, app, "/categories", nil)
+	if state != "MISS" {
+		t.Errorf("X-Cache = %q, want MISS", state)
+	}
+	second, state := getCached(t, app, "/categories", nil)
+	if state != "HIT" || second != first || *calls != 1 {
+		t.Errorf("second request: X-Cache = %q, body %q, calls %d; want HIT, %q, 1", state, second, *calls, first)
+	}
+
+	// Another URL and authenticated requests bypass the entry | 其他 URL 和已认证请求不使用该条目
+	getCached(t, app, "/categories?page=2", nil)
+	if _, state := getCached(t, app, "/categories", map[string]string{fiber.HeaderAuthorization: "Bearer x"}); state != "" || *calls != 3 {
+		t.Errorf("authenticated request: X-Cache = %q, calls %d; want bypass, 3", state, *calls)
+	}
+
+	if err := PurgeResponse(context.Background(), "/categories"); err != nil {
+		t.Fatalf("PurgeResponse failed: %v", err)
+	}
+	if _, state := getCached(t, app, "/categories", nil); state != "MISS" {
+		t.Errorf("after purge: X-Cache = %q, want MISS", state)
+	}
+}
+
+func TestCacheResponseVary(t *testing.T) {
+	useCache(t)
+	app, calls := newCachedApp(time.Minute)
+	en := map[string]string{fiber.HeaderAcceptLanguage: "en"}
+	zh := map[string]string{fiber.HeaderAcceptLanguage: "zh"}
+
+	getCached(t, app, "/localized", en)
+	getCached(t, app, "/localized", zh)
+	if body, state := getCached(t, app, "/localized", en); state != "HIT" || body != "en 1" {
+		t.Errorf("en: body %q, X-Cache %q; want en 1, HIT", body, state)
+	}
+	if body, state := getCached(t, app, "/localized", zh); state != "HIT" || body != "zh 2" {
+		t.Errorf("zh: body %q, X-Cache %q; want zh 2, HIT", body, state)
+	}
+
+	// Purging the key drops every variant | 删除键会作废所有变体
+	PurgeResponse(context.Background(), "/localized")
+	getCached(t, app, "/localized", en)
+	if body, state := getCached(t, app, "/localized", zh); state != "MISS" || body != "zh 4" {
+		t.Errorf("zh after purge: body %q, X-Cache %q; want zh 4, MISS", body, state)
+	}
+	if *calls != 4 {
+		t.Errorf("calls = %d, want 4", *calls)
+	}
+}
+
+func TestCacheResponseNotStored(t *testing.T) {
+	useCache(t)
+	app, calls := newCachedApp(time.Minute)
+
+	getCached(t, app, "/private", nil)
+	if _, state := getCached(t, app, "/private", nil); state != "MISS" || *calls != 2 {
+		t.Errorf("private: X-Cache = %q, calls %d; want MISS, 2", state, *calls)
+	}
+
+	// Expired entries are reloaded although the local level still holds them
+	// 过期条目即使仍在本地缓存中也会重新加载
+	app, calls = newCachedApp(time.Millisecond)
+	getCached(t, app, "/categories", nil)
+	time.Sleep(5 * time.Millisecond)
+	if _, state := getCached(t, app, "/categories", nil); state != "MISS" || *calls != 2 {
+		t.Errorf("expired: X-Cache = %q, calls %d; want MISS, 2", state, *calls)
+	}
+}
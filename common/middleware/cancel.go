@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var requestTimeout time.Duration
+
+// InitRequestTimeout sets the deadline applied by Cancellation, 0 for none
+// InitRequestTimeout 设置 Cancellation 使用的时限，0 表示不限制
+func InitRequestTimeout(timeout time.Duration) {
+	requestTimeout = timeout
+}
+
+// Cancellation makes c.UserContext() cancelable, so queries and outbound calls given it stop
+// once the request is over: when the handler returns, after [server] request_timeout and on
+// server shutdown. fasthttp does not report client disconnects while a handler runs, the
+// timeout bounds the work left running after one. Streamed responses (SSE) write after the
+// handler returns and keep their context; pass ctxutil.Detach(ctx) to work that outlives the request.
+// Cancellation 使 c.UserContext() 可取消，传入它的查询和外部调用会在请求结束时停止：
+// 处理器返回时、超过 [server] request_timeout 后以及服务器关闭时。fasthttp 不会在处理器运行期间通知客户端断开，
+// 该时限限制了断开后仍在运行的工作。流式响应（SSE）在处理器返回后写出，保留其 context；
+// 生命周期超过请求的工作请传入 ctxutil.Detach(ctx)。
+func Cancellation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancelCause(c.UserContext())
+		stop := context.AfterFunc(c.Context(), func() { cancel(context.Canceled) }) // Server shutdown | 服务器关闭
+		var timer *time.Timer
+		if requestTimeout > 0 {
+			timer = time.AfterFunc(requestTimeout, func() { cancel(context.DeadlineExceeded) })
+		}
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		stop()
+		if timer != nil {
+			timer.Stop()
+		}
+		if !c.Response().IsBodyStream() {
+			cancel(context.Canceled)
+		}
+		return err
+	}
+}
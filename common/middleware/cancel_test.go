@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCancellation(t *testing.T) {
+	prev := requestTimeout
+	InitRequestTimeout(20 * time.Millisecond)
+	t.Cleanup(func() { requestTimeout = prev })
+
+	var done, slow context.Context
+	app := newTestApp()
+	app.Use(Cancellation())
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		done = c.UserContext()
+		if done.Err() != nil {
+			t.Error("The context should be live while the handler runs")
+		}
+		return c.SendString("ok")
+	})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		slow = c.UserContext()
+		select {
+		case <-slow.Done():
+		case <-time.After(time.Second):
+			t.Error("request_timeout should cancel the context")
+		}
+		return c.SendString("late")
+	})
+
+	do(t, app, httptest.NewRequest("GET", "/fast", nil))
+	if done.Err() == nil {
+		t.Error("The context should be canceled once the handler returns")
+	}
+	do(t, app, httptest.NewRequest("GET", "/slow", nil))
+	if !errors.Is(context.Cause(slow), context.DeadlineExceeded) {
+		t.Errorf("Cause = %v, want deadline exceeded", context.Cause(slow))
+	}
+}
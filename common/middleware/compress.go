@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// CompressConfig represents the [compress] configuration section
+// CompressConfig 表示 [compress] 配置段
+type CompressConfig struct {
+	Enabled      bool                    `toml:"enabled"`       // Register in Setup | 在 Setup 中注册
+	Level        string                  `toml:"level"`         // "speed", "default" or "best" | "speed"、"default" 或 "best"
+	MinSize      int                     `toml:"min_size"`      // Smaller bodies are sent as is (default 1024) | 更小的响应体原样发送（默认 1024）
+	ContentTypes []string                `toml:"content_types"` // Content-Type prefixes to compress (default JSON, text, JavaScript, XML, SVG) | 需要压缩的 Content-Type 前缀（默认 JSON、文本、JavaScript、XML、SVG）
+	Skip         func(c *fiber.Ctx) bool `toml:"-"`             // Skip compression for matching requests | 跳过匹配请求的压缩
+}
+
+// defaultCompressTypes are compressed when ContentTypes is empty
+// defaultCompressTypes 在 ContentTypes 为空时被压缩
+var defaultCompressTypes = []string{
+	"application/json", "application/problem+json", "application/javascript",
+	"application/xml", "text/", "image/svg+xml",
+}
+
+// compressConfig holds the configuration used by Setup | compressConfig 保存 Setup 使用的配置
+var compressConfig CompressConfig
+
+// InitCompress stores the compression configuration used by Setup
+// InitCompress 保存 Setup 使用的压缩配置
+func InitCompress(cfg CompressConfig) {
+	compressConfig = cfg
+}
+
+// Compress returns a middleware compressing responses with brotli or gzip, as the client accepts
+// Only complete bodies of at least MinSize bytes with a listed content type are compressed;
+// streamed bodies and responses already carrying a Content-Encoding pass through. A strong
+// ETag becomes weak, since the compressed bytes differ from the ones it was computed on.
+// Compress 返回按客户端支持使用 brotli 或 gzip 压缩响应的中间件
+// 仅压缩不小于 MinSize 字节且 Content-Type 在列表中的完整响应体；流式响应体和已带 Content-Encoding 的响应原样通过。
+// 强 ETag 会变为弱 ETag，因为压缩后的字节与计算 ETag 时的不同。
+//
+// Usage | 用法:
+//
+//	router.Get("/articles", middleware.Compress(), h.ListArticles)
+func Compress(config ...CompressConfig) fiber.Handler {
+	cfg := CompressConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = 1024
+	}
+	types := cfg.ContentTypes
+	if len(types) == 0 {
+		types = defaultCompressTypes
+	}
+	brLevel, gzipLevel := compressLevels(cfg.Level)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		status := resp.StatusCode()
+		if status < fiber.StatusOK || status == fiber.StatusNoContent || status == fiber.StatusPartialContent || status == fiber.StatusNotModified {
+			return nil
+		}
+		if resp.IsBodyStream() || len(resp.Header.ContentEncoding()) > 0 {
+			return nil
+		}
+		body := resp.Body()
+		if len(body) < cfg.MinSize || !hasContentType(string(resp.Header.ContentType()), types) {
+			return nil
+		}
+
+		// Caches must key on Accept-Encoding even when this client gets identity
+		// 即使本客户端获得未压缩内容，缓存也必须按 Accept-Encoding 区分
+		c.Vary(fiber.HeaderAcceptEncoding)
+
+		var out []byte
+		encoding := acceptedEncoding(c.Get(fiber.HeaderAcceptEncoding))
+		switch encoding {
+		case "br":
+			out = fasthttp.AppendBrotliBytesLevel(nil, body, brLevel)
+		case "gzip":
+			out = fasthttp.AppendGzipBytesLevel(nil, body, gzipLevel)
+		default:
+			return nil
+		}
+		if len(out) >= len(body) {
+			return nil
+		}
+
+		resp.SetBodyRaw(out)
+		resp.Header.SetContentEncoding(encoding)
+		if etag := c.GetRespHeader(fiber.HeaderETag); strings.HasPrefix(etag, `"`) {
+			c.Set(fiber.HeaderETag, "W/"+etag)
+		}
+		return nil
+	}
+}
+
+// compressLevels maps a level name to brotli and gzip levels | compressLevels 将级别名称映射为 brotli 和 gzip 级别
+func compressLevels(level string) (br, gzip int) {
+	switch level {
+	case "speed":
+		return fasthttp.CompressBrotliBestSpeed, fasthttp.CompressBestSpeed
+	case "best":
+		return fasthttp.CompressBrotliBestCompression, fasthttp.CompressBestCompression
+	default:
+		return fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressDefaultCompression
+	}
+}
+
+// hasContentType reports whether contentType starts with one of the prefixes
+// hasContentType 判断 contentType 是否以其中一个前缀开头
+func hasContentType(contentType string, prefixes []string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, p := range prefixes {
+		if strings.HasPrefix(contentType, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedEncoding picks br over gzip from an Accept-Encoding header, "" when the client takes neither
+// acceptedEncoding 从 Accept-Encoding 请求头中优先选择 br，其次 gzip，两者都不接受时返回 ""
+func acceptedEncoding(header string) string {
+	var br, gzip bool
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue // q=0 refuses the coding | q=0 表示拒绝该编码
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "br":
+			br = true
+		case "gzip":
+			gzip = true
+		case "*":
+			br, gzip = true, true
+		}
+	}
+	switch {
+	case br:
+		return "br"
+	case gzip:
+		return "gzip"
+	}
+	return ""
+}
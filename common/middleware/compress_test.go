@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newCompressApp serves a JSON list of n bytes and a small one behind Compress and ETag
+func newCompressApp(cfg CompressConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(Compress(cfg), ETag())
+	app.Get("/list", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.SendString(`{"items":"` + strings.Repeat("crab", 1024) + `"}`)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+	app.Get("/image", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(bytes.Repeat([]byte{0}, 4096))
+	})
+	return app
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	app := newCompressApp(CompressConfig{})
+	want := `{"items":"` + strings.Repeat("crab", 1024) + `"}`
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"brotli preferred", "/list", "gzip, deflate, br", "br"},
+		{"gzip", "/list", "gzip", "gzip"},
+		{"brotli refused", "/list", "br;q=0, gzip", "gzip"},
+		{"wildcard", "/list", "*", "br"},
+		{"identity only", "/list", "identity", ""},
+		{"no header", "/list", "", ""},
+		{"below min size", "/small", "br", ""},
+		{"content type not listed", "/image", "br", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set(fiber.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+			resp, body := do(t, app, req)
+			if got := resp.Header.Get(fiber.HeaderContentEncoding); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.path != "/list" {
+				return
+			}
+			if got := resp.Header.Get(fiber.HeaderVary); got != fiber.HeaderAcceptEncoding {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+
+			var r io.Reader = strings.NewReader(body)
+			switch tt.wantEncoding {
+			case "br":
+				r = brotli.NewReader(r)
+			case "gzip":
+				zr, err := gzip.NewReader(r)
+				if err != nil {
+					t.Fatalf("gzip.NewReader failed: %v", err)
+				}
+				r = zr
+			}
+			plain, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Decompress failed: %v", err)
+			}
+			if string(plain) != want {
+				t.Errorf("Decompressed body differs from the original (%d bytes, want %d)", len(plain), len(want))
+			}
+		})
+	}
+}
+
+func TestCompressConfig(t *testing.T) {
+	app := newCompressApp(CompressConfig{
+		MinSize:      8,
+		ContentTypes: []string{"image/"},
+		Skip:         func(c *fiber.Ctx) bool { return c.Query("raw") != "" },
+	})
+
+	for path, want := range map[string]string{"/image": "gzip", "/small": "", "/image?raw=1": ""} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+		resp, _ := do(t, app, req)
+		if got := resp.Header.Get(fiber.HeaderContentEncoding); got != want {
+			t.Errorf("%s: Content-Encoding = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCompressWeakensStrongETag(t *testing.T) {
+	app := fiber.New()
+	app.Use(Compress())
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, `"v1"`)
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlain)
+		return c.SendString(strings.Repeat("crab", 1024))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, _ := do(t, app, req)
+	if got := resp.Header.Get(fiber.HeaderETag); got != `W/"v1"` {
+		t.Errorf("ETag = %q, want W/\"v1\"", got)
+	}
+}
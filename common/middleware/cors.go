@@ -7,12 +7,15 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
-// Cors returns a CORS middleware handler
-// Cors 返回 CORS 中间件处理器
+// Cors returns a permissive CORS middleware handler
+// Setup uses Security, which reads CORS settings from [security.cors].
+// Cors 返回宽松的 CORS 中间件处理器
+// Setup 使用 Security，它从 [security.cors] 读取 CORS 配置。
 func Cors() fiber.Handler {
 	return cors.New(cors.Config{
-		AllowOrigins: "*",                                        // Allowed origins | 允许的源
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",              // Allowed methods | 允许的方法
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization", // Allowed headers | 允许的请求头
+		AllowOrigins:  "*",                                                     // Allowed origins | 允许的源
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",                           // Allowed methods | 允许的方法
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,X-Request-ID", // Allowed headers | 允许的请求头
+		ExposeHeaders: "X-Request-ID",                                          // Headers exposed to browsers | 暴露给浏览器的响应头
 	})
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// drainPoll is how often Drain checks the in-flight count | drainPoll 为 Drain 检查进行中请求数的间隔
+const drainPoll = 10 * time.Millisecond
+
+var (
+	activeRequests atomic.Int64
+	draining       atomic.Bool
+)
+
+// InFlight counts the requests being served so shutdown can wait for them, and answers 503 with
+// Connection: close once Drain has started, so clients and load balancers move to other instances.
+// Streamed responses (SSE) count until their handler returns, not until the stream ends.
+// InFlight 统计正在处理的请求以便关闭时等待它们，Drain 开始后以 503 和 Connection: close 响应，
+// 使客户端和负载均衡器转向其他实例。流式响应（SSE）只计算到处理器返回，而不是流结束。
+func InFlight() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if draining.Load() {
+			c.Context().SetConnectionClose()
+			c.Status(fiber.StatusServiceUnavailable)
+			return response.Write(c, response.CodeServiceUnavailable, response.CodeServiceUnavailable.MsgLang(response.Lang(c)), nil)
+		}
+		activeRequests.Add(1)
+		defer activeRequests.Add(-1)
+		return c.Next()
+	}
+}
+
+// ActiveRequests returns the number of requests being served
+// ActiveRequests 返回正在处理的请求数
+func ActiveRequests() int64 {
+	return activeRequests.Load()
+}
+
+// Drain rejects new requests and waits until the active ones finish, returning ctx's error
+// if they are still running when it ends. Call it before shutting the servers down.
+// Drain 拒绝新请求并等待正在处理的请求完成，ctx 结束时仍有请求在运行则返回 ctx 的错误。
+// 应在关闭服务器之前调用。
+func Drain(ctx context.Context) error {
+	draining.Store(true)
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+	for activeRequests.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDrainWaitsForInFlight(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+
+	started, release := make(chan struct{}), make(chan struct{})
+	app := fiber.New()
+	app.Use(InFlight())
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendString("done")
+	})
+	app.Get("/fast", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	slow := make(chan int, 1)
+	go func() {
+		resp, _ := do(t, app, httptest.NewRequest("GET", "/slow", nil))
+		slow <- resp.StatusCode
+	}()
+	<-started
+	if n := ActiveRequests(); n != 1 {
+		t.Fatalf("ActiveRequests = %d, want 1", n)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- Drain(context.Background()) }()
+
+	// New requests are turned away while the slow one runs | 慢请求运行期间拒绝新请求
+	deadline := time.Now().Add(time.Second)
+	for !draining.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	resp, _ := do(t, app, httptest.NewRequest("GET", "/fast", nil))
+	if resp.StatusCode != fiber.StatusServiceUnavailable || !resp.Close {
+		t.Errorf("status = %d, close = %v; want 503 with Connection: close", resp.StatusCode, resp.Close)
+	}
+	select {
+	case <-drained:
+		t.Fatal("Expected Drain to wait for the slow request")
+	default:
+	}
+
+	close(release)
+	if err := <-drained; err != nil {
+		t.Errorf("Drain = %v, want nil", err)
+	}
+	if status := <-slow; status != fiber.StatusOK {
+		t.Errorf("slow request status = %d, want 200", status)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+
+	activeRequests.Add(1)
+	defer activeRequests.Add(-1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Drain = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETagConfig represents the [etag] configuration section
+// ETagConfig 表示 [etag] 配置段
+type ETagConfig struct {
+	Enabled bool                    `toml:"enabled"` // Register in Setup | 在 Setup 中注册
+	Skip    func(c *fiber.Ctx) bool `toml:"-"`       // Skip matching requests | 跳过匹配的请求
+}
+
+// etagConfig holds the configuration used by Setup | etagConfig 保存 Setup 使用的配置
+var etagConfig ETagConfig
+
+// InitETag stores the ETag configuration used by Setup
+// InitETag 保存 Setup 使用的 ETag 配置
+func InitETag(cfg ETagConfig) {
+	etagConfig = cfg
+}
+
+// ETag returns a middleware tagging 200 responses to GET and HEAD with a weak ETag and
+// answering 304 Not Modified when If-None-Match carries it
+// An ETag set by the handler is kept; otherwise it is a hash of the body, weak so that it
+// survives compression. Streamed bodies get no ETag.
+// ETag 返回为 GET 和 HEAD 的 200 响应添加弱 ETag 的中间件，If-None-Match 携带该值时返回 304 Not Modified
+// 处理器设置的 ETag 会被保留，否则使用响应体的哈希值，弱 ETag 在压缩后仍然有效。流式响应体不生成 ETag。
+//
+// Usage | 用法:
+//
+//	router.Get("/categories", middleware.ETag(), h.ListCategories)
+func ETag(config ...ETagConfig) fiber.Handler {
+	cfg := ETagConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		if method := c.Method(); method != fiber.MethodGet && method != fiber.MethodHead {
+			return c.Next()
+		}
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		if resp.StatusCode() != fiber.StatusOK {
+			return nil
+		}
+		etag := c.GetRespHeader(fiber.HeaderETag)
+		if etag == "" {
+			if resp.IsBodyStream() {
+				return nil
+			}
+			etag = bodyETag(resp.Body())
+			c.Set(fiber.HeaderETag, etag)
+		}
+
+		if etagMatch(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			c.Context().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// bodyETag returns the weak ETag of a body | bodyETag 返回响应体的弱 ETag
+func bodyETag(body []byte) string {
+	b := make([]byte, 0, 40)
+	b = append(b, `W/"`...)
+	b = strconv.AppendUint(b, uint64(len(body)), 16)
+	b = append(b, '-')
+	b = strconv.AppendUint(b, xxhash.Sum64(body), 16)
+	b = append(b, '"')
+	return string(b)
+}
+
+// etagMatch reports whether an If-None-Match header matches etag, comparing weakly as RFC 9110 requires
+// etagMatch 判断 If-None-Match 请求头是否匹配 etag，按 RFC 9110 的要求使用弱比较
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
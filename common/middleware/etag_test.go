@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestETagConditionalGet(t *testing.T) {
+	app := newCompressApp(CompressConfig{})
+
+	resp, body := do(t, app, httptest.NewRequest("GET", "/list", nil))
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("ETag = %q, want a weak ETag", etag)
+	}
+
+	// The tag does not depend on the encoding the client gets | ETag 与客户端获得的编码无关
+	req := httptest.NewRequest("GET", "/list", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "br")
+	if resp, _ := do(t, app, req); resp.Header.Get(fiber.HeaderETag) != etag {
+		t.Errorf("ETag with br = %q, want %q", resp.Header.Get(fiber.HeaderETag), etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"match", etag, fiber.StatusNotModified},
+		{"strong form", strings.TrimPrefix(etag, "W/"), fiber.StatusNotModified},
+		{"in a list", `"other", ` + etag, fiber.StatusNotModified},
+		{"wildcard", "*", fiber.StatusNotModified},
+		{"stale", `W/"0-0"`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/list", nil)
+			req.Header.Set(fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+			req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+			resp, got := do(t, app, req)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == fiber.StatusNotModified && (got != "" || resp.Header.Get(fiber.HeaderETag) != etag) {
+				t.Errorf("304 body = %q, ETag = %q, want empty body and %q", got, resp.Header.Get(fiber.HeaderETag), etag)
+			}
+		})
+	}
+
+	// A changed body gets another tag | 响应体变化后得到不同的 ETag
+	if bodyETag([]byte(body+" ")) == etag {
+		t.Error("Expected a different ETag for a different body")
+	}
+}
+
+func TestETagSkipsNonGet(t *testing.T) {
+	app := fiber.New()
+	app.Use(ETag())
+	app.Post("/items", func(c *fiber.Ctx) error { return c.SendString("created") })
+	app.Get("/missing", func(c *fiber.Ctx) error { return c.Status(fiber.StatusNotFound).SendString("missing") })
+	app.Get("/tagged", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, `"v2"`)
+		return c.SendString("tagged")
+	})
+
+	if resp, _ := do(t, app, httptest.NewRequest("POST", "/items", nil)); resp.Header.Get(fiber.HeaderETag) != "" {
+		t.Error("Expected no ETag on POST")
+	}
+	if resp, _ := do(t, app, httptest.NewRequest("GET", "/missing", nil)); resp.Header.Get(fiber.HeaderETag) != "" {
+		t.Error("Expected no ETag on a 404")
+	}
+
+	// The handler's own ETag is kept and compared | 保留并比较处理器自己的 ETag
+	req := httptest.NewRequest("GET", "/tagged", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, `"v2"`)
+	if resp, _ := do(t, app, req); resp.StatusCode != fiber.StatusNotModified || resp.Header.Get(fiber.HeaderETag) != `"v2"` {
+		t.Errorf("status = %d, ETag = %q, want 304 with \"v2\"", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+}
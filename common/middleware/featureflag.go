@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/pkg/featureflag"
+)
+
+// RequireFlag hides a route unless the feature flag is on for the request user
+// Routes behind an off flag answer 404, as if they did not exist.
+// RequireFlag 除非功能开关对请求用户开启，否则隐藏路由
+// 开关关闭时路由返回 404，如同不存在。
+//
+// Usage | 用法:
+//
+//	router.Post("/checkout/v2", middleware.RequireAuth(), middleware.RequireFlag("new_checkout"), handler.CheckoutV2)
+func RequireFlag(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !featureflag.Enabled(c.UserContext(), key) {
+			return errors.ErrNotFound()
+		}
+		return c.Next()
+	}
+}
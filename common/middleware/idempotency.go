@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/json"
+	"github.com/nuohe369/crab/pkg/logger"
+	pkgredis "github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/util"
+	"github.com/redis/go-redis/v9"
+)
+
+var idempotencyLog = logger.NewSystem("idempotency")
+
+// idempotencyUnlockScript deletes the lock only while it still holds the caller's token,
+// so a request outliving LockTimeout cannot release a lock taken over by a retry.
+// idempotencyUnlockScript 仅在锁仍持有调用方令牌时删除，避免超过 LockTimeout 的请求释放被重试接管的锁。
+var idempotencyUnlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+return redis.call('DEL', KEYS[1])`)
+
+// IdempotencyConfig defines idempotency configuration
+// IdempotencyConfig 定义幂等配置
+type IdempotencyConfig struct {
+	Header      string        // Header carrying the key (default "Idempotency-Key") | 携带幂等键的请求头（默认 "Idempotency-Key"）
+	TTL         time.Duration // How long the first response is replayed (default 24h) | 首次响应的重放时长（默认 24 小时）
+	LockTimeout time.Duration // Max time a request may hold the key while processing (default 30s) | 请求处理期间持有幂等键的最长时间（默认 30 秒）
+	Methods     []string      // Methods the middleware applies to (default POST, PUT, PATCH, DELETE) | 适用的请求方法（默认 POST、PUT、PATCH、DELETE）
+	Required    bool          // Reject requests without a key | 拒绝没有幂等键的请求
+	KeyPrefix   string        // Redis key prefix (default "idempotency:") | Redis 键前缀（默认 "idempotency:"）
+}
+
+// idempotentResponse is the stored first response
+// idempotentResponse 是保存的首次响应
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"` // SHA-256 of the request body | 请求体的 SHA-256
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency returns a middleware that replays the first response for a repeated Idempotency-Key
+// Only successful responses (2xx with a success envelope code) are stored, so failed requests
+// can be retried with the same key.
+// Idempotency 返回对重复 Idempotency-Key 重放首次响应的中间件
+// 仅保存成功响应（2xx 且响应码为成功），失败的请求可以使用相同的键重试。
+//
+// Usage | 用法:
+//
+//	router.Post("/orders", middleware.Idempotency(), h.CreateOrder)
+func Idempotency(config ...IdempotencyConfig) fiber.Handler {
+	cfg := IdempotencyConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = "Idempotency-Key"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = 30 * time.Second
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete}
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "idempotency:"
+	}
+
+	return func(c *fiber.Ctx) error {
+		if !slices.Contains(cfg.Methods, c.Method()) {
+			return c.Next()
+		}
+
+		idemKey := c.Get(cfg.Header)
+		if idemKey == "" {
+			if cfg.Required {
+				return errors.New(response.CodeParamMissing, cfg.Header+" header required")
+			}
+			return c.Next()
+		}
+		if len(idemKey) > 255 {
+			return errors.New(response.CodeParamInvalid, cfg.Header+" too long")
+		}
+
+		rdb := pkgredis.Get()
+		if rdb == nil {
+			// Without Redis there is nowhere to store responses | 没有 Redis 无法保存响应
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		key := cfg.KeyPrefix + idempotencyScope(c) + ":" + idemKey
+		lockKey := key + ":lock"
+		fingerprint := util.SHA256(string(c.Body()))
+
+		// Replay a stored response | 重放已保存的响应
+		if replayed, err := replayIdempotent(c, rdb, key, fingerprint, cfg.Header); replayed || err != nil {
+			return err
+		}
+
+		// Claim the key so concurrent retries don't execute twice | 抢占幂等键，避免并发重试重复执行
+		token := uuid.NewString()
+		ok, err := rdb.SetNX(ctx, lockKey, token, cfg.LockTimeout)
+		if err != nil {
+			return errors.Wrap(response.CodeRedisError, err)
+		}
+		if !ok {
+			return errors.New(response.CodeDuplicate, "A request with this "+cfg.Header+" is already in progress")
+		}
+		defer releaseIdempotencyLock(ctx, rdb, lockKey, token)
+
+		// The first request may have stored its response and released the lock after our lookup
+		// 首个请求可能在上面的查询之后保存了响应并释放了锁
+		if replayed, err := replayIdempotent(c, rdb, key, fingerprint, cfg.Header); replayed || err != nil {
+			return err
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// Business failures are sent with HTTP 200, check the envelope code too | 业务失败以 HTTP 200 返回，同时检查响应码
+		status := c.Response().StatusCode()
+		if status < 200 || status >= 300 {
+			return nil
+		}
+		if code, ok := response.WrittenCode(c); ok && code != response.CodeSuccess {
+			return nil
+		}
+
+		data, err := json.MarshalString(idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		})
+		if err == nil {
+			if err := rdb.Set(ctx, key, data, cfg.TTL); err != nil {
+				idempotencyLog.Error("Failed to store response: %v", err)
+			}
+		}
+		return nil
+	}
+}
+
+// replayIdempotent sends the response stored under key, reporting whether one was found
+// replayIdempotent 发送 key 下保存的响应，并返回是否找到
+func replayIdempotent(c *fiber.Ctx, rdb *pkgredis.Client, key, fingerprint, header string) (bool, error) {
+	val, err := rdb.Get(c.UserContext(), key)
+	if err != nil {
+		if pkgredis.IsNil(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(response.CodeRedisError, err)
+	}
+
+	var stored idempotentResponse
+	if err := json.UnmarshalString(val, &stored); err != nil {
+		return false, nil
+	}
+	if stored.Fingerprint != fingerprint {
+		return true, errors.New(response.CodeParamInvalid, header+" reused with a different request body")
+	}
+	c.Set("Idempotent-Replayed", "true")
+	c.Set(fiber.HeaderContentType, stored.ContentType)
+	return true, c.Status(stored.Status).Send(stored.Body)
+}
+
+// releaseIdempotencyLock deletes lockKey if it still holds token
+// releaseIdempotencyLock 在 lockKey 仍持有 token 时将其删除
+func releaseIdempotencyLock(ctx context.Context, rdb *pkgredis.Client, lockKey, token string) {
+	raw, ok := rdb.GetRaw().(redis.Scripter)
+	if !ok {
+		idempotencyLog.Error("Failed to release lock %s: unsupported Redis client", lockKey)
+		return
+	}
+	if err := idempotencyUnlockScript.Run(ctx, raw, []string{lockKey}, token).Err(); err != nil {
+		idempotencyLog.Error("Failed to release lock %s: %v", lockKey, err)
+	}
+}
+
+// idempotencyScope scopes keys to the caller and route, so keys from different users never collide
+// idempotencyScope 将幂等键限定在调用者和路由范围内，避免不同用户的键冲突
+func idempotencyScope(c *fiber.Ctx) string {
+	caller := "ip:" + c.IP()
+	if userID, ok := ctxutil.UserID(c.UserContext()); ok {
+		caller = fmt.Sprintf("user:%d", userID)
+	}
+	return caller + ":" + c.Method() + ":" + strings.TrimSuffix(c.Path(), "/")
+}
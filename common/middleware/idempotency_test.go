@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/response"
+)
+
+func idempotentPost(path, key, body string) *http.Request {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	return req
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	useRedis(t)
+
+	var calls atomic.Int32
+	app := newTestApp()
+	app.Post("/orders", Idempotency(), func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"order": n})
+	})
+
+	resp, first := do(t, app, idempotentPost("/orders", "k1", `{"sku":1}`))
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("Expected 201, got %d", resp.StatusCode)
+	}
+
+	resp, second := do(t, app, idempotentPost("/orders", "k1", `{"sku":1}`))
+	if resp.StatusCode != fiber.StatusCreated || second != first {
+		t.Errorf("Expected replayed 201 %s, got %d %s", first, resp.StatusCode, second)
+	}
+	if resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected Idempotent-Replayed header")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", n)
+	}
+
+	// Same key with a different body is rejected | 相同的键但请求体不同时拒绝
+	resp, _ = do(t, app, idempotentPost("/orders", "k1", `{"sku":2}`))
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected 400 for a reused key, got %d", resp.StatusCode)
+	}
+
+	// Requests without a key pass through | 没有幂等键的请求直接通过
+	do(t, app, httptest.NewRequest("POST", "/orders", nil))
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected keyless request to run the handler, ran %d times", n)
+	}
+}
+
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	useRedis(t)
+
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	app := newTestApp()
+	app.Post("/pay", Idempotency(), func(c *fiber.Ctx) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return response.OK(c, "paid")
+	})
+
+	var wg sync.WaitGroup
+	var firstStatus int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := app.Test(idempotentPost("/pay", "k2", "{}"), -1)
+		if err == nil {
+			firstStatus = resp.StatusCode
+		}
+	}()
+
+	<-started
+	resp, _ := do(t, app, idempotentPost("/pay", "k2", "{}"))
+	if resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("Expected 409 while the first request runs, got %d", resp.StatusCode)
+	}
+	close(release)
+	wg.Wait()
+
+	if firstStatus != fiber.StatusOK {
+		t.Errorf("Expected first request to succeed, got %d", firstStatus)
+	}
+	resp, _ = do(t, app, idempotentPost("/pay", "k2", "{}"))
+	if resp.Header.Get("Idempotent-Replayed") != "true" || calls.Load() != 1 {
+		t.Errorf("Expected replay after completion, handler ran %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyFailedRequest(t *testing.T) {
+	useRedis(t)
+
+	var calls atomic.Int32
+	app := newTestApp()
+	app.Post("/transfer", Idempotency(), func(c *fiber.Ctx) error {
+		switch calls.Add(1) {
+		case 1:
+			// Business failure sent with HTTP 200 | 以 HTTP 200 返回的业务失败
+			return response.FailCode(c, response.CodeBizError)
+		case 2:
+			return c.Status(fiber.StatusInternalServerError).SendString("boom")
+		default:
+			return response.OK(c, "done")
+		}
+	})
+
+	for i := range 3 {
+		do(t, app, idempotentPost("/transfer", "k3", "{}"))
+		if n := calls.Load(); n != int32(i+1) {
+			t.Fatalf("Attempt %d: expected failed responses not to be replayed, handler ran %d times", i+1, n)
+		}
+	}
+
+	resp, _ := do(t, app, idempotentPost("/transfer", "k3", "{}"))
+	if resp.Header.Get("Idempotent-Replayed") != "true" || calls.Load() != 3 {
+		t.Errorf("Expected the successful response to be replayed, handler ran %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyLockTakenOver(t *testing.T) {
+	mr := useRedis(t)
+
+	app := newTestApp()
+	app.Post("/pay", Idempotency(), func(c *fiber.Ctx) error {
+		// The lock expired and a retry claimed it while this request ran | 本请求运行期间锁已过期并被重试请求占有
+		for _, key := range mr.Keys() {
+			if strings.HasSuffix(key, ":lock") {
+				mr.Set(key, "retry-token")
+			}
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString("boom")
+	})
+
+	do(t, app, idempotentPost("/pay", "k4", "{}"))
+	var locks []string
+	for _, key := range mr.Keys() {
+		if strings.HasSuffix(key, ":lock") {
+			locks = append(locks, key)
+		}
+	}
+	if len(locks) != 1 {
+		t.Fatalf("Expected the retry's lock to survive, got %v", locks)
+	}
+	if val, _ := mr.Get(locks[0]); val != "retry-token" {
+		t.Errorf("Expected lock to keep the retry token, got %q", val)
+	}
+}
+
+func TestIdempotencyScopedByUser(t *testing.T) {
+	useRedis(t)
+
+	var calls atomic.Int32
+	app := newTestApp()
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		if id, err := strconv.ParseInt(c.Get("X-User"), 10, 64); err == nil {
+			c.SetUserContext(ctxutil.WithUser(c.UserContext(), id, "web"))
+		}
+		return c.Next()
+	}, Idempotency(), func(c *fiber.Ctx) error {
+		return response.OK(c, calls.Add(1))
+	})
+
+	post := func(user string) *http.Response {
+		req := idempotentPost("/orders", "k5", "{}")
+		req.Header.Set("X-User", user)
+		resp, _ := do(t, app, req)
+		return resp
+	}
+	post("1")
+	if resp := post("2"); resp.Header.Get("Idempotent-Replayed") == "true" {
+		t.Error("Expected another user's key not to replay")
+	}
+	if resp := post("1"); resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected the same user's key to replay")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected handler to run twice, ran %d times", n)
+	}
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/json"
+	pkgredis "github.com/nuohe369/crab/pkg/redis"
+)
+
+// MaintenanceConfig represents the [maintenance] configuration section
+// MaintenanceConfig 表示 [maintenance] 配置段
+type MaintenanceConfig struct {
+	Enabled    bool          `toml:"enabled"`     // Force maintenance on for this process | 强制本进程处于维护模式
+	Allow      []string      `toml:"allow"`       // Path prefixes still served, /health and admin_path are always allowed | 维护期间仍可访问的路径前缀，/health 和 admin_path 总是允许
+	AllowIPs   []string      `toml:"allow_ips"`   // Client IPs or CIDRs that bypass maintenance | 绕过维护模式的客户端 IP 或 CIDR
+	Message    string        `toml:"message"`     // Default message of the 503 response | 503 响应的默认提示信息
+	RetryAfter time.Duration `toml:"retry_after"` // Retry-After header (default 5m) | Retry-After 响应头（默认 5 分钟）
+	AdminPath  string        `toml:"admin_path"`  // GET/PUT/DELETE toggle endpoint (JWT + permission), empty disables | 维护开关接口（JWT + 权限），为空表示禁用
+	Permission string        `toml:"permission"`  // Permission required by admin_path (default maintenance:write) | admin_path 需要的权限（默认 maintenance:write）
+	CacheTTL   time.Duration `toml:"cache_ttl"`   // How long the shared Redis state is cached (default 2s) | 共享 Redis 状态的缓存时长（默认 2 秒）
+	KeyPrefix  string        `toml:"key_prefix"`  // Redis key prefix (default "maintenance:") | Redis 键前缀（默认 "maintenance:"）
+}
+
+// key returns the Redis key holding the shared maintenance state | key 返回保存共享维护状态的 Redis 键
+func (c MaintenanceConfig) key() string {
+	return c.KeyPrefix + "state"
+}
+
+// MaintenanceState is the maintenance state shared through Redis
+// MaintenanceState 是通过 Redis 共享的维护状态
+type MaintenanceState struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since,omitempty"`
+}
+
+// cachedMaintenance is a state read from Redis and when it was read | cachedMaintenance 是从 Redis 读取的状态及读取时间
+type cachedMaintenance struct {
+	state MaintenanceState
+	at    time.Time
+}
+
+var (
+	maintenanceConfig  MaintenanceConfig
+	maintenanceNets    []*net.IPNet
+	maintenanceLocal   MaintenanceState // State used without Redis | 无 Redis 时使用的状态
+	maintenanceMu      sync.Mutex
+	maintenanceCache   atomic.Pointer[cachedMaintenance]
+	maintenanceRefresh sync.Mutex
+)
+
+// InitMaintenance stores the maintenance configuration used by Setup
+// InitMaintenance 保存 Setup 使用的维护模式配置
+func InitMaintenance(cfg MaintenanceConfig) {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 5 * time.Minute
+	}
+	if cfg.Permission == "" {
+		cfg.Permission = "maintenance:write"
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 2 * time.Second
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "maintenance:"
+	}
+
+	nets := make([]*net.IPNet, 0, len(cfg.AllowIPs))
+	for _, s := range cfg.AllowIPs {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		if _, n, err := net.ParseCIDR(s); err == nil {
+			nets = append(nets, n)
+		}
+	}
+
+	maintenanceMu.Lock()
+	maintenanceConfig, maintenanceNets = cfg, nets
+	maintenanceLocal = MaintenanceState{}
+	maintenanceMu.Unlock()
+	maintenanceCache.Store(nil)
+}
+
+// GetMaintenanceConfig returns the maintenance configuration
+// GetMaintenanceConfig 返回维护模式配置
+func GetMaintenanceConfig() MaintenanceConfig {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+	return maintenanceConfig
+}
+
+// SetMaintenance turns maintenance mode on or off for every instance sharing Redis,
+// or for this process only without Redis
+// SetMaintenance 为共享 Redis 的所有实例开启或关闭维护模式，无 Redis 时仅作用于本进程
+func SetMaintenance(ctx context.Context, enabled bool, message string) error {
+	state := MaintenanceState{}
+	if enabled {
+		state = MaintenanceState{Enabled: true, Message: message, Since: time.Now()}
+	}
+
+	if rdb := pkgredis.Get(); rdb != nil {
+		key := GetMaintenanceConfig().key()
+		if !enabled {
+			if err := rdb.Del(ctx, key); err != nil {
+				return err
+			}
+		} else {
+			data, err := json.Marshal(state)
+			if err != nil {
+				return err
+			}
+			if err := rdb.Set(ctx, key, string(data), 0); err != nil {
+				return err
+			}
+		}
+		maintenanceCache.Store(&cachedMaintenance{state: state, at: time.Now()})
+		return nil
+	}
+
+	maintenanceMu.Lock()
+	maintenanceLocal = state
+	maintenanceMu.Unlock()
+	return nil
+}
+
+// GetMaintenance returns the effective maintenance state
+// GetMaintenance 返回生效的维护状态
+func GetMaintenance(ctx context.Context) MaintenanceState {
+	maintenanceMu.Lock()
+	cfg, local := maintenanceConfig, maintenanceLocal
+	maintenanceMu.Unlock()
+
+	if cfg.Enabled {
+		return MaintenanceState{Enabled: true, Message: cfg.Message}
+	}
+	rdb := pkgredis.Get()
+	if rdb == nil {
+		return local
+	}
+
+	if cached := maintenanceCache.Load(); cached != nil && time.Since(cached.at) < cfg.CacheTTL {
+		return cached.state
+	}
+	// One request refreshes, the others keep the stale state | 由一个请求刷新，其他请求沿用旧状态
+	if !maintenanceRefresh.TryLock() {
+		if cached := maintenanceCache.Load(); cached != nil {
+			return cached.state
+		}
+		maintenanceRefresh.Lock()
+	}
+	defer maintenanceRefresh.Unlock()
+
+	state := MaintenanceState{}
+	data, err := rdb.Get(ctx, cfg.key())
+	if err == nil && data != "" {
+		_ = json.Unmarshal([]byte(data), &state)
+	} else if err != nil && !pkgredis.IsNil(err) {
+		// Keep the last known state while Redis is unreachable | Redis 不可用时保持最后已知状态
+		if cached := maintenanceCache.Load(); cached != nil {
+			return cached.state
+		}
+	}
+	maintenanceCache.Store(&cachedMaintenance{state: state, at: time.Now()})
+	return state
+}
+
+// Maintenance returns a middleware answering 503 for every route outside the allowlist while maintenance is on
+// Maintenance 返回在维护模式下对白名单之外的所有路由返回 503 的中间件
+func Maintenance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := GetMaintenance(c.UserContext())
+		if !state.Enabled || maintenanceAllowed(c) {
+			return c.Next()
+		}
+
+		cfg := GetMaintenanceConfig()
+		msg := state.Message
+		if msg == "" {
+			msg = cfg.Message
+		}
+		if msg == "" {
+			msg = response.CodeServiceUnavailable.MsgLang(response.Lang(c))
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(cfg.RetryAfter.Seconds())))
+		c.Status(fiber.StatusServiceUnavailable)
+		return response.Write(c, response.CodeServiceUnavailable, msg, nil)
+	}
+}
+
+// maintenanceAllowed reports whether the request bypasses maintenance
+// maintenanceAllowed 判断请求是否绕过维护模式
+func maintenanceAllowed(c *fiber.Ctx) bool {
+	maintenanceMu.Lock()
+	cfg, nets := maintenanceConfig, maintenanceNets
+	maintenanceMu.Unlock()
+
+	path := c.Path()
+	allowed := append([]string{"/health", cfg.AdminPath}, cfg.Allow...)
+	for _, p := range allowed {
+		p = strings.TrimRight(p, "/")
+		if p != "" && (path == p || strings.HasPrefix(path, p+"/")) {
+			return true
+		}
+	}
+	if len(nets) > 0 {
+		if ip := net.ParseIP(c.IP()); ip != nil {
+			for _, n := range nets {
+				if n.Contains(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
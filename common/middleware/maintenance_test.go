@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// useMaintenance installs cfg and resets the maintenance state for the test
+func useMaintenance(t *testing.T, cfg MaintenanceConfig) {
+	t.Helper()
+	InitMaintenance(cfg)
+	t.Cleanup(func() { InitMaintenance(MaintenanceConfig{}) })
+}
+
+// newMaintenanceApp serves every path behind Maintenance, trusting X-Forwarded-For for the client IP
+func newMaintenanceApp() *fiber.App {
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(Maintenance())
+	app.Use(func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func maintenanceStatus(t *testing.T, app *fiber.App, path, ip string) int {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	if ip != "" {
+		req.Header.Set(fiber.HeaderXForwardedFor, ip)
+	}
+	resp, _ := do(t, app, req)
+	return resp.StatusCode
+}
+
+func TestMaintenanceAllowlist(t *testing.T) {
+	useMaintenance(t, MaintenanceConfig{
+		Enabled:   true,
+		Allow:     []string{"/api/status/"},
+		AllowIPs:  []string{"10.1.0.0/16", "192.168.1.7"},
+		AdminPath: "/admin/maintenance",
+	})
+	app := newMaintenanceApp()
+
+	tests := []struct {
+		name string
+		path string
+		ip   string
+		want int
+	}{
+		{"blocked route", "/api/orders", "", fiber.StatusServiceUnavailable},
+		{"health", "/health", "", fiber.StatusOK},
+		{"health subpath", "/health/ready", "", fiber.StatusOK},
+		{"admin path", "/admin/maintenance", "", fiber.StatusOK},
+		{"allow prefix", "/api/status/db", "", fiber.StatusOK},
+		{"allow prefix exact", "/api/status", "", fiber.StatusOK},
+		{"not a path segment", "/api/statusx", "", fiber.StatusServiceUnavailable},
+		{"CIDR bypass", "/api/orders", "10.1.2.3", fiber.StatusOK},
+		{"single IP bypass", "/api/orders", "192.168.1.7", fiber.StatusOK},
+		{"IP outside allowlist", "/api/orders", "10.2.0.1", fiber.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintenanceStatus(t, app, tt.path, tt.ip); got != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMaintenanceResponse(t *testing.T) {
+	useMaintenance(t, MaintenanceConfig{Enabled: true, Message: "Back soon", RetryAfter: 90 * time.Second})
+
+	resp, body := do(t, newMaintenanceApp(), httptest.NewRequest("GET", "/", nil))
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "90" {
+		t.Errorf("Expected Retry-After 90, got %q", got)
+	}
+	if want := `"msg":"Back soon"`; !strings.Contains(body, want) {
+		t.Errorf("Expected %s in body, got %s", want, body)
+	}
+}
+
+func TestMaintenanceLocalState(t *testing.T) {
+	useMaintenance(t, MaintenanceConfig{})
+	ctx := context.Background()
+	app := newMaintenanceApp()
+
+	if got := maintenanceStatus(t, app, "/", ""); got != fiber.StatusOK {
+		t.Fatalf("Expected 200 before maintenance, got %d", got)
+	}
+	if err := SetMaintenance(ctx, true, "upgrading"); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+	if state := GetMaintenance(ctx); !state.Enabled || state.Message != "upgrading" {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+	if got := maintenanceStatus(t, app, "/", ""); got != fiber.StatusServiceUnavailable {
+		t.Errorf("Expected 503 during maintenance, got %d", got)
+	}
+	SetMaintenance(ctx, false, "")
+	if got := maintenanceStatus(t, app, "/", ""); got != fiber.StatusOK {
+		t.Errorf("Expected 200 after maintenance, got %d", got)
+	}
+}
+
+func TestMaintenanceSharedState(t *testing.T) {
+	mr := useRedis(t)
+	useMaintenance(t, MaintenanceConfig{CacheTTL: time.Minute, KeyPrefix: "shop:maintenance:"})
+	ctx := context.Background()
+
+	if err := SetMaintenance(ctx, true, "db migration"); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+	if !mr.Exists("shop:maintenance:state") {
+		t.Fatalf("Expected state under the configured prefix, keys: %v", mr.Keys())
+	}
+
+	// Another instance turns maintenance off; the cached state holds until the TTL passes
+	// 另一个实例关闭维护模式；缓存的状态在 TTL 过期前保持不变
+	mr.Del("shop:maintenance:state")
+	if !GetMaintenance(ctx).Enabled {
+		t.Error("Expected cached state within the TTL")
+	}
+	expireMaintenanceCache()
+	if GetMaintenance(ctx).Enabled {
+		t.Error("Expected state reloaded from Redis after the TTL")
+	}
+
+	// Another instance turns it on | 另一个实例开启维护模式
+	mr.Set("shop:maintenance:state", `{"enabled":true,"message":"from peer"}`)
+	expireMaintenanceCache()
+	if state := GetMaintenance(ctx); !state.Enabled || state.Message != "from peer" {
+		t.Errorf("Expected peer state, got %+v", state)
+	}
+
+	// The last known state is kept while Redis is unreachable | Redis 不可用时保持最后已知状态
+	mr.Close()
+	expireMaintenanceCache()
+	down, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if !GetMaintenance(down).Enabled {
+		t.Error("Expected last known state while Redis is down")
+	}
+}
+
+func TestMaintenanceDefaultKey(t *testing.T) {
+	mr := useRedis(t)
+	useMaintenance(t, MaintenanceConfig{})
+
+	if err := SetMaintenance(context.Background(), true, ""); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+	if !mr.Exists("maintenance:state") {
+		t.Errorf("Expected default key maintenance:state, keys: %v", mr.Keys())
+	}
+}
+
+// expireMaintenanceCache ages the cached state past any TTL
+func expireMaintenanceCache() {
+	if cached := maintenanceCache.Load(); cached != nil {
+		maintenanceCache.Store(&cachedMaintenance{state: cached.state, at: time.Time{}})
+	}
+}
@@ -2,13 +2,57 @@ package middleware
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/tenant"
 )
 
 // Setup registers global middleware (excluding Logger, which is registered by each module)
+// Optional toggles enable or disable named middleware for the running service.
 // Setup 注册全局中间件（不包括 Logger，Logger 由各模块自行注册）
-func Setup(app *fiber.App) {
+// 可选的 toggles 为当前运行的服务启用或禁用具名中间件。
+func Setup(app *fiber.App, toggles ...Toggles) {
+	var t Toggles
+	if len(toggles) > 0 {
+		t = toggles[0]
+	}
+
+	if t.Enabled("drain", true) {
+		app.Use(InFlight()) // In-flight count waited for on shutdown, 503 while draining | 关闭时等待的进行中请求计数，排空期间返回 503
+	}
 	app.Use(Recovery())
-	app.Use(Cors())
-	app.Use(Trace())
-	app.Use(SmartLogger()) // Smart request logger with auto module detection | 智能请求日志，自动检测模块
+	if t.Enabled("cancellation", true) {
+		app.Use(Cancellation()) // c.UserContext() canceled when the request ends, see [server] request_timeout | 请求结束时取消 c.UserContext()，见 [server] request_timeout
+	}
+	if t.Enabled("security", true) {
+		app.Use(Security()) // CORS, security headers and CSRF from [security] | 来自 [security] 的 CORS、安全响应头和 CSRF
+	}
+	if t.Enabled("trace", true) {
+		app.Use(Trace())
+	}
+	if t.Enabled("request_context", true) {
+		app.Use(RequestContext()) // Request ID and JWT claims in Locals and UserContext | 请求 ID 与 JWT 声明写入 Locals 和 UserContext
+	}
+	if t.Enabled("tenant", tenant.Enabled()) {
+		app.Use(tenant.Middleware()) // Tenant from JWT, header or subdomain, see [tenant] | 从 JWT、请求头或子域名解析租户，见 [tenant]
+	}
+	if h := bodyLimits(); h != nil && t.Enabled("body_limit", true) {
+		app.Use(h) // Per-path body limits from [server] body_limits | 来自 [server] body_limits 的按路径请求体限制
+	}
+	if t.Enabled("maintenance", true) {
+		app.Use(Maintenance()) // 503 outside the allowlist while maintenance is on | 维护模式下白名单之外返回 503
+	}
+	if h := globalRateLimit(); h != nil && t.Enabled("ratelimit", true) {
+		app.Use(h) // Global rate limit rules from config.toml | 来自 config.toml 的全局限流规则
+	}
+	if t.Enabled("compress", compressConfig.Enabled) {
+		app.Use(Compress(compressConfig)) // brotli/gzip for large bodies from [compress] | 来自 [compress] 的大响应体 brotli/gzip 压缩
+	}
+	if t.Enabled("etag", etagConfig.Enabled) {
+		app.Use(ETag(etagConfig)) // ETag and 304 for GET from [etag] | 来自 [etag] 的 GET ETag 与 304
+	}
+	if t.Enabled("access_log", accessLogConfig.Enabled) {
+		app.Use(AccessLog(accessLogConfig)) // Structured JSON access log | 结构化 JSON 访问日志
+	}
+	if t.Enabled("logger", true) {
+		app.Use(SmartLogger()) // Smart request logger with auto module detection | 智能请求日志，自动检测模块
+	}
 }
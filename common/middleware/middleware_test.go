@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/logger"
+	pkgredis "github.com/nuohe369/crab/pkg/redis"
+)
+
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "middleware-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testStatus maps the business codes used by this package the way the boot error handler does
+var testStatus = map[response.Code]int{
+	response.CodeUnauth:             fiber.StatusUnauthorized,
+	response.CodeTokenInvalid:       fiber.StatusUnauthorized,
+	response.CodeForbid:             fiber.StatusForbidden,
+	response.CodeParamError:         fiber.StatusBadRequest,
+	response.CodeParamMissing:       fiber.StatusBadRequest,
+	response.CodeParamInvalid:       fiber.StatusBadRequest,
+	response.CodeDuplicate:          fiber.StatusConflict,
+	response.CodeTooManyRequests:    fiber.StatusTooManyRequests,
+	response.CodeQuotaExceeded:      fiber.StatusTooManyRequests,
+	response.CodeServiceUnavailable: fiber.StatusServiceUnavailable,
+}
+
+// newTestApp returns an app whose error handler writes business errors as envelopes
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var bizErr *errors.BizError
+			if !stderrors.As(err, &bizErr) {
+				return fiber.DefaultErrorHandler(c, err)
+			}
+			status, ok := testStatus[bizErr.Code]
+			if !ok {
+				status = fiber.StatusInternalServerError
+			}
+			c.Status(status)
+			return response.Write(c, bizErr.Code, bizErr.Msg, nil)
+		},
+	})
+}
+
+// do sends req to app and returns the response with its body
+func do(t *testing.T, app *fiber.App, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+// useRedis points the default Redis client at a miniredis server for the test
+func useRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	if err := pkgredis.Init(pkgredis.Config{Addr: mr.Addr()}); err != nil {
+		t.Fatalf("redis.Init failed: %v", err)
+	}
+	t.Cleanup(pkgredis.Close)
+	return mr
+}
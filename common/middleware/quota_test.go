@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/org"
+	"github.com/nuohe369/crab/pkg/quota"
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+func TestQuota(t *testing.T) {
+	quota.Init(quota.Config{Plans: map[string]map[string]int64{
+		"free": {quota.APICallsDaily: 5, quota.APICallsMonthly: 2},
+	}}, quota.NewMemoryStore())
+	t.Cleanup(func() { quota.Init(quota.Config{}, quota.NewMemoryStore()) })
+
+	app := newTestApp()
+	app.Use(func(c *fiber.Ctx) error {
+		switch c.Get("X-Test-As") {
+		case "user":
+			c.Locals("user_id", int64(1))
+		case "org":
+			c.Locals("user_id", int64(1))
+			c.Locals(org.LocalsMember, &org.Member{OrgID: snowflake.SnowflakeID(9)})
+		}
+		return c.Next()
+	})
+	app.Get("/", Quota(), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	get := func(as string) *http.Response {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Test-As", as)
+		resp, _ := do(t, app, req)
+		return resp
+	}
+
+	for i := range 2 {
+		if resp := get("user"); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, resp.StatusCode)
+		}
+	}
+	resp := get("user")
+	if resp.StatusCode != fiber.StatusTooManyRequests || resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Errorf("over the monthly limit: status %d, Retry-After %q; want 429 with Retry-After", resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+	// The daily unit taken before the monthly check failed is given back | 月度检查失败前消耗的每日单位已归还
+	if u, _ := quota.Get().Usage(context.Background(), quota.User(1), quota.APICallsDaily); u.Used != 2 {
+		t.Errorf("daily usage = %d, want 2", u.Used)
+	}
+
+	// Organizations have their own counters and anonymous requests pass
+	// 组织有独立的计数，匿名请求直接放行
+	if resp := get("org"); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("org request: status %d, want 200", resp.StatusCode)
+	}
+	if resp := get(""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("anonymous request: status %d, want 200", resp.StatusCode)
+	}
+}
@@ -2,16 +2,22 @@ package middleware
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
+	"github.com/nuohe369/crab/common/errors"
 	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/logger"
 	"github.com/nuohe369/crab/pkg/ratelimit"
 	pkgredis "github.com/nuohe369/crab/pkg/redis"
+	"github.com/redis/go-redis/v9"
 )
 
+var rateLimitLog = logger.NewSystem("ratelimit")
+
 // defaultLimiter is the default rate limiter (memory-based) | defaultLimiter 默认限流器（基于内存）
 var defaultLimiter ratelimit.Limiter
 
@@ -19,10 +25,34 @@ func init() {
 	defaultLimiter = ratelimit.NewMemory()
 }
 
+// RateLimitRule defines a rate limit rule configured in config.toml
+// RateLimitRule 定义在 config.toml 中配置的限流规则
+type RateLimitRule struct {
+	Name   string        `toml:"name"`   // Rule name, used by RateLimitByRule | 规则名称，供 RateLimitByRule 使用
+	By     string        `toml:"by"`     // Key source: ip, user, route (default ip) | 限流键来源：ip、user、route（默认 ip）
+	Path   string        `toml:"path"`   // Path prefix the rule applies to, empty means all | 规则适用的路径前缀，为空表示全部
+	Max    int           `toml:"max"`    // Maximum requests within the window | 窗口内最大请求数
+	Window time.Duration `toml:"window"` // Window duration | 窗口时长
+	Global bool          `toml:"global"` // Apply to every request in Setup | 在 Setup 中应用于所有请求
+}
+
+// RateLimitSettings represents rate limit configuration
+// RateLimitSettings 表示限流配置
+type RateLimitSettings struct {
+	Rules []RateLimitRule `toml:"rules"` // Configured rules | 已配置的规则
+}
+
+// rateLimitSettings holds the configured rules | rateLimitSettings 保存已配置的规则
+var rateLimitSettings RateLimitSettings
+
 // InitRateLimiter initializes the rate limiter with Redis if available
 // InitRateLimiter 如果 Redis 可用，则使用 Redis 初始化限流器
 // Call this after Redis is initialized | 在 Redis 初始化后调用
-func InitRateLimiter() {
+func InitRateLimiter(settings ...RateLimitSettings) {
+	if len(settings) > 0 {
+		rateLimitSettings = settings[0]
+	}
+
 	redisClient := pkgredis.Get()
 	if redisClient != nil {
 		// Use Redis-based rate limiter for distributed scenarios
@@ -134,10 +164,118 @@ func RateLimitWithConfig(cfg RateLimitConfig) fiber.Handler {
 		c.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
 
 		if !allowed {
-			c.Set("Retry-After", strconv.FormatInt(int64(time.Until(resetAt).Seconds()), 10))
-			return response.FailMsg(c, response.CodeTooManyRequests, "Too many requests, please try again later")
+			return tooManyRequests(c, resetAt)
 		}
 
 		return c.Next()
 	}
 }
+
+// RateLimitByRule returns a rate limiting middleware for a rule configured in config.toml
+// Mount per-user rules after the authentication middleware so c.Locals("user_id") is set.
+// RateLimitByRule 返回 config.toml 中配置的规则对应的限流中间件
+// 按用户限流的规则应挂载在认证中间件之后，以便 c.Locals("user_id") 已设置。
+func RateLimitByRule(name string) fiber.Handler {
+	for _, rule := range rateLimitSettings.Rules {
+		if rule.Name == name {
+			check := ruleHandler(rule)
+			return func(c *fiber.Ctx) error {
+				if err := check(c); err != nil {
+					return err
+				}
+				return c.Next()
+			}
+		}
+	}
+	rateLimitLog.Warn("Rate limit rule %q not configured, skipping", name)
+	return func(c *fiber.Ctx) error {
+		return c.Next()
+	}
+}
+
+// globalRateLimit returns a middleware applying all global rules, nil if none
+// globalRateLimit 返回应用所有全局规则的中间件，没有时返回 nil
+func globalRateLimit() fiber.Handler {
+	var handlers []fiber.Handler
+	for _, rule := range rateLimitSettings.Rules {
+		if rule.Global {
+			handlers = append(handlers, ruleHandler(rule))
+		}
+	}
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	return func(c *fiber.Ctx) error {
+		for _, h := range handlers {
+			// Each rule handler only checks and never calls Next | 每个规则处理器只做检查，不调用 Next
+			if err := h(c); err != nil {
+				return err
+			}
+		}
+		return c.Next()
+	}
+}
+
+// ruleHandler builds a checking handler for a configured rule, it never calls c.Next()
+// ruleHandler 为已配置的规则构建检查处理器，不会调用 c.Next()
+func ruleHandler(rule RateLimitRule) fiber.Handler {
+	if rule.Max <= 0 {
+		rule.Max = 100
+	}
+	if rule.Window <= 0 {
+		rule.Window = time.Minute
+	}
+	keyGen := ruleKeyGenerator(rule.By)
+
+	return func(c *fiber.Ctx) error {
+		if rule.Path != "" && !strings.HasPrefix(c.Path(), rule.Path) {
+			return nil
+		}
+
+		key := "rule:" + rule.Name + ":" + keyGen(c)
+		allowed, remaining, resetAt := defaultLimiter.Allow(c.Context(), key, rule.Max, rule.Window)
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(rule.Max))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+		if !allowed {
+			return tooManyRequests(c, resetAt)
+		}
+		return nil
+	}
+}
+
+// ruleKeyGenerator returns the key generator for a rule's "by" setting
+// ruleKeyGenerator 返回规则 "by" 设置对应的键生成函数
+func ruleKeyGenerator(by string) func(c *fiber.Ctx) string {
+	switch by {
+	case "user":
+		return func(c *fiber.Ctx) string {
+			if userID, ok := c.Locals("user_id").(int64); ok {
+				return fmt.Sprintf("user:%d", userID)
+			}
+			return "ip:" + c.IP() // Fallback to IP for unauthenticated users | 未认证用户回退到 IP
+		}
+	case "route":
+		return func(c *fiber.Ctx) string {
+			return "route:" + c.IP() + ":" + c.Method() + ":" + c.Path()
+		}
+	default:
+		return func(c *fiber.Ctx) string {
+			return "ip:" + c.IP()
+		}
+	}
+}
+
+// tooManyRequests sets Retry-After and returns a CodeTooManyRequests error (HTTP 429)
+// tooManyRequests 设置 Retry-After 并返回 CodeTooManyRequests 错误（HTTP 429）
+func tooManyRequests(c *fiber.Ctx, resetAt time.Time) error {
+	retryAfter := int64(math.Ceil(time.Until(resetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+	return errors.New(response.CodeTooManyRequests, "Too many requests, please try again later")
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// useRules installs rate limit rules and a fresh memory limiter for the test
+func useRules(t *testing.T, rules ...RateLimitRule) {
+	t.Helper()
+	prev := rateLimitSettings
+	rateLimitSettings = RateLimitSettings{Rules: rules}
+	SetLimiter(ratelimit.NewMemory())
+	t.Cleanup(func() {
+		rateLimitSettings = prev
+		SetLimiter(ratelimit.NewMemory())
+	})
+}
+
+func statusOf(t *testing.T, app *fiber.App, path string) int {
+	t.Helper()
+	resp, _ := do(t, app, httptest.NewRequest("GET", path, nil))
+	return resp.StatusCode
+}
+
+func TestRateLimitByRule(t *testing.T) {
+	useRules(t, RateLimitRule{Name: "login", Max: 2, Window: time.Minute})
+
+	app := newTestApp()
+	ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+	app.Get("/login", RateLimitByRule("login"), ok)
+	app.Get("/open", RateLimitByRule("missing"), ok)
+
+	for i := range 2 {
+		if code := statusOf(t, app, "/login"); code != fiber.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, code)
+		}
+	}
+	if code := statusOf(t, app, "/login"); code != fiber.StatusTooManyRequests {
+		t.Errorf("Expected 429 over the limit, got %d", code)
+	}
+
+	// An unknown rule never limits | 未知规则不限流
+	for range 5 {
+		if code := statusOf(t, app, "/open"); code != fiber.StatusOK {
+			t.Fatalf("Unknown rule: expected 200, got %d", code)
+		}
+	}
+}
+
+func TestGlobalRateLimit(t *testing.T) {
+	useRules(t)
+	if globalRateLimit() != nil {
+		t.Fatal("Expected no global handler without global rules")
+	}
+
+	useRules(t,
+		RateLimitRule{Name: "api", Path: "/api", Max: 3, Window: time.Minute, Global: true},
+		RateLimitRule{Name: "upload", Path: "/api/upload", Max: 1, Window: time.Minute, Global: true},
+		RateLimitRule{Name: "named", Max: 1, Window: time.Minute},
+	)
+
+	app := newTestApp()
+	app.Use(globalRateLimit())
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if code := statusOf(t, app, "/api/upload"); code != fiber.StatusOK {
+		t.Fatalf("Expected first upload allowed, got %d", code)
+	}
+	// Every global rule applies, the stricter one wins | 所有全局规则都生效，更严格的规则优先
+	if code := statusOf(t, app, "/api/upload"); code != fiber.StatusTooManyRequests {
+		t.Errorf("Expected second upload limited, got %d", code)
+	}
+	// Both uploads counted against the api rule, one request is left | 两次上传都计入 api 规则，只剩一次请求
+	if code := statusOf(t, app, "/api/users"); code != fiber.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := statusOf(t, app, "/api/users"); code != fiber.StatusTooManyRequests {
+		t.Errorf("Expected api rule limit shared across paths, got %d", code)
+	}
+
+	// Paths outside every rule and non-global rules are not limited | 规则外的路径和非全局规则不限流
+	for range 5 {
+		if code := statusOf(t, app, "/health"); code != fiber.StatusOK {
+			t.Fatalf("Expected /health unlimited, got %d", code)
+		}
+	}
+}
+
+func TestRetryAfterRoundsUp(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	useRules(t, RateLimitRule{Name: "slow", Max: 1, Window: 1500 * time.Millisecond})
+	SetLimiter(ratelimit.NewRedisWithClient(rdb, "ratelimit:"))
+
+	app := newTestApp()
+	app.Get("/slow", RateLimitByRule("slow"), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if code := statusOf(t, app, "/slow"); code != fiber.StatusOK {
+		t.Fatalf("Expected first request allowed, got %d", code)
+	}
+	resp, _ := do(t, app, httptest.NewRequest("GET", "/slow", nil))
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", resp.StatusCode)
+	}
+	// The oldest request frees its slot within 1.5s, reported as 2 whole seconds | 最早的请求在 1.5 秒内释放名额，报告为 2 整秒
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected no remaining requests, got %q", got)
+	}
+}
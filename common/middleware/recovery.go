@@ -1,14 +1,55 @@
 package middleware
 
 import (
+	"fmt"
+	"runtime/debug"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/metrics"
+	"github.com/nuohe369/crab/pkg/trace"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// panicLog is the logger for recovered panics | panicLog 是已恢复 panic 的日志器
+var panicLog = logger.NewSystem("panic")
+
 // Recovery returns a panic recovery middleware
+// A panic is converted into a CodeServerError BizError so it goes through the
+// unified error handler. The stack trace is logged, recorded on the active
+// trace span, and counted in the http_panics_total metric.
 // Recovery 返回 panic 恢复中间件
+// panic 会被转换为 CodeServerError 业务错误，交由统一错误处理器处理。
+// 堆栈会被记录到日志和当前追踪 span，并计入 http_panics_total 指标。
 func Recovery() fiber.Handler {
-	return recover.New(recover.Config{
-		EnableStackTrace: true, // Enable stack trace logging | 启用堆栈跟踪日志
-	})
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			stack := debug.Stack()
+			ctx := c.UserContext()
+
+			panicLog.ErrorCtx(ctx, "panic recovered: %s %s [request_id=%s]: %v\n%s",
+				c.Method(), c.Path(), GetRequestID(c), panicErr, stack)
+
+			trace.RecordError(ctx, panicErr, attribute.String("exception.stacktrace", string(stack)))
+
+			if counter := metrics.Counter("http_panics_total", "Total number of recovered handler panics", "method", "route"); counter != nil {
+				counter.WithLabelValues(c.Method(), c.Route().Path).Inc()
+			}
+
+			err = errors.Wrap(response.CodeServerError, panicErr)
+		}()
+
+		return c.Next()
+	}
 }
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// systemLog flushes the panic logger and returns the system log written so far
+func systemLog(t *testing.T) string {
+	t.Helper()
+	if err := panicLog.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(logger.GetConfig().Dir, "system", "*.log"))
+	var sb strings.Builder
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		sb.Write(data)
+	}
+	return sb.String()
+}
+
+func TestRecovery(t *testing.T) {
+	app := newTestApp()
+	app.Use(Recovery())
+	app.Get("/boom", func(c *fiber.Ctx) error {
+		panic("recovery-test-value")
+	})
+
+	resp, body := do(t, app, httptest.NewRequest("GET", "/boom", nil))
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") ||
+		!strings.Contains(body, `"code":`) || !strings.Contains(body, `"msg":`) {
+		t.Errorf("Expected the standard error envelope, got %s", body)
+	}
+	if strings.Contains(body, "recovery-test-value") {
+		t.Errorf("Panic value leaked to the client: %s", body)
+	}
+
+	log := systemLog(t)
+	if !strings.Contains(log, "panic recovered: GET /boom") || !strings.Contains(log, "recovery-test-value") {
+		t.Errorf("Expected the panic value to be logged, got %q", log)
+	}
+	if !strings.Contains(log, "goroutine ") || !strings.Contains(log, "recovery_test.go") {
+		t.Errorf("Expected the stack to be logged, got %q", log)
+	}
+}
+
+func TestRecoveryPassesErrors(t *testing.T) {
+	app := newTestApp()
+	app.Use(Recovery())
+	app.Get("/teapot", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	})
+
+	if resp, _ := do(t, app, httptest.NewRequest("GET", "/teapot", nil)); resp.StatusCode != fiber.StatusTeapot {
+		t.Errorf("Expected handler errors to pass through, got %d", resp.StatusCode)
+	}
+}
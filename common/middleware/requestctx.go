@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// RequestContext returns a middleware that populates request-scoped values
+// It assigns a request ID (reusing a valid incoming X-Request-ID), negotiates the
+// language from Accept-Language and, when a valid Bearer token is present,
+// extracts the JWT claims. Values are stored in
+// both Fiber Locals and c.UserContext() so ctxutil getters work everywhere.
+// An invalid or missing token is not rejected here; use an auth middleware for that.
+// RequestContext 返回填充请求级数据的中间件
+// 它分配请求 ID（复用合法的 X-Request-ID），根据 Accept-Language 协商语言，并在存在有效 Bearer 令牌时提取 JWT 声明。
+// 数据同时存入 Fiber Locals 和 c.UserContext()，使 ctxutil 的获取函数处处可用。
+// 此处不会拒绝无效或缺失的令牌，请使用认证中间件。
+func RequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(ctxutil.HeaderRequestID)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(ctxutil.HeaderRequestID, requestID)
+		c.Locals(ctxutil.LocalsRequestID, requestID)
+
+		lang := i18n.Match(c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals(ctxutil.LocalsLang, lang)
+
+		ctx := ctxutil.WithRequestID(c.UserContext(), requestID)
+		ctx = ctxutil.WithLang(ctx, lang)
+
+		if claims := parseBearer(c); claims != nil {
+			c.Locals(ctxutil.LocalsClaims, claims)
+			c.Locals(ctxutil.LocalsUserID, claims.ID)
+			c.Locals(ctxutil.LocalsPlat, claims.Plat)
+			ctx = ctxutil.WithUser(ctx, claims.ID, claims.Plat)
+		}
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the request ID set by RequestContext
+// GetRequestID 返回由 RequestContext 设置的请求 ID
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(ctxutil.LocalsRequestID).(string)
+	return id
+}
+
+// GetClaims returns the JWT claims extracted by RequestContext, nil if unauthenticated
+// GetClaims 返回由 RequestContext 提取的 JWT 声明，未认证时返回 nil
+func GetClaims(c *fiber.Ctx) *jwt.Claims {
+	claims, _ := c.Locals(ctxutil.LocalsClaims).(*jwt.Claims)
+	return claims
+}
+
+// parseBearer parses the Bearer token if JWT is initialized
+// parseBearer 在 JWT 已初始化时解析 Bearer 令牌
+func parseBearer(c *fiber.Ctx) *jwt.Claims {
+	mgr := jwt.Get()
+	if mgr == nil {
+		return nil
+	}
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	claims, err := mgr.Parse(token)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
+// validRequestID accepts client IDs that are short and log-safe
+// validRequestID 接受简短且可安全写入日志的客户端 ID
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
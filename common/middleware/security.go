@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+	"github.com/nuohe369/crab/common/apikey"
+	"github.com/nuohe369/crab/common/errors"
+)
+
+// SecurityConfig represents the [security] configuration section
+// SecurityConfig 表示 [security] 配置段
+type SecurityConfig struct {
+	CORS    CORSConfig            `toml:"cors"`    // Cross-origin resource sharing | 跨域资源共享
+	CSRF    CSRFConfig            `toml:"csrf"`    // CSRF protection for cookie-based flows | 基于 Cookie 流程的 CSRF 防护
+	Headers SecurityHeadersConfig `toml:"headers"` // Standard security headers | 标准安全响应头
+}
+
+// CORSConfig represents CORS configuration
+// CORSConfig 表示 CORS 配置
+type CORSConfig struct {
+	AllowOrigins     []string            `toml:"allow_origins"`     // Allowed origins, "*" allows any (default "*") | 允许的源，"*" 表示任意（默认 "*"）
+	EnvOrigins       map[string][]string `toml:"env_origins"`       // Origins per app.env, overrides AllowOrigins | 按 app.env 配置的源，覆盖 AllowOrigins
+	AllowMethods     []string            `toml:"allow_methods"`     // Allowed methods | 允许的方法
+	AllowHeaders     []string            `toml:"allow_headers"`     // Allowed request headers | 允许的请求头
+	ExposeHeaders    []string            `toml:"expose_headers"`    // Headers exposed to browsers | 暴露给浏览器的响应头
+	AllowCredentials bool                `toml:"allow_credentials"` // Allow cookies, requires explicit origins without "*" | 允许携带 Cookie，需要不含 "*" 的显式源
+	MaxAge           int                 `toml:"max_age"`           // Preflight cache seconds | 预检缓存秒数
+}
+
+// CSRFConfig represents CSRF configuration
+// CSRFConfig 表示 CSRF 配置
+type CSRFConfig struct {
+	Enabled      bool          `toml:"enabled"`       // Enable CSRF protection | 启用 CSRF 防护
+	CookieName   string        `toml:"cookie_name"`   // Token cookie name (default "csrf_") | 令牌 Cookie 名称（默认 "csrf_"）
+	HeaderName   string        `toml:"header_name"`   // Token header name (default "X-CSRF-Token") | 令牌请求头名称（默认 "X-CSRF-Token"）
+	CookieSecure bool          `toml:"cookie_secure"` // Secure flag on the cookie | Cookie 的 Secure 标志
+	SameSite     string        `toml:"same_site"`     // Lax, Strict or None (default Lax) | Lax、Strict 或 None（默认 Lax）
+	Expiration   time.Duration `toml:"expiration"`    // Token lifetime (default 1h) | 令牌有效期（默认 1 小时）
+}
+
+// SecurityHeadersConfig represents security header configuration
+// SecurityHeadersConfig 表示安全响应头配置
+type SecurityHeadersConfig struct {
+	HSTSMaxAge            int    `toml:"hsts_max_age"`            // Strict-Transport-Security max-age, 0 disables | HSTS max-age，0 表示禁用
+	HSTSIncludeSubdomains bool   `toml:"hsts_include_subdomains"` // Add includeSubDomains | 添加 includeSubDomains
+	FrameOptions          string `toml:"frame_options"`           // X-Frame-Options (default SAMEORIGIN) | X-Frame-Options（默认 SAMEORIGIN）
+	ContentSecurityPolicy string `toml:"content_security_policy"` // Content-Security-Policy, empty disables | 内容安全策略，为空表示禁用
+	ReferrerPolicy        string `toml:"referrer_policy"`         // Referrer-Policy (default strict-origin-when-cross-origin) | Referrer-Policy（默认 strict-origin-when-cross-origin）
+}
+
+// Validate reports configuration combinations that are unsafe to serve
+// Validate 报告不安全的配置组合
+func (c SecurityConfig) Validate() error {
+	return c.CORS.Validate()
+}
+
+// Validate rejects allow_credentials together with an origin list that allows any origin,
+// as browsers would then send cookies from every site.
+// Validate 拒绝 allow_credentials 与允许任意源的源列表同时使用，否则浏览器会从任意站点携带 Cookie。
+func (c CORSConfig) Validate() error {
+	if !c.AllowCredentials {
+		return nil
+	}
+	if len(c.AllowOrigins) == 0 || slices.Contains(c.AllowOrigins, "*") {
+		return stderrors.New(`security.cors: allow_credentials requires explicit allow_origins without "*"`)
+	}
+	for env, origins := range c.EnvOrigins {
+		if len(origins) == 0 || slices.Contains(origins, "*") {
+			return stderrors.New(`security.cors: allow_credentials requires explicit env_origins.` + env + ` without "*"`)
+		}
+	}
+	return nil
+}
+
+// securityConfig holds the configuration used by Setup | securityConfig 保存 Setup 使用的配置
+var (
+	securityConfig SecurityConfig
+	securityEnv    string
+
+	csrfMu     sync.RWMutex
+	csrfExempt []string // Path prefixes registered by ExemptCSRF | 通过 ExemptCSRF 注册的路径前缀
+)
+
+// InitSecurity stores the security configuration and app environment used by Setup
+// InitSecurity 保存 Setup 使用的安全配置和应用环境
+func InitSecurity(cfg SecurityConfig, env string) {
+	securityConfig = cfg
+	securityEnv = env
+}
+
+// Security returns a middleware applying CORS, security headers and CSRF protection
+// Without arguments it uses the configuration passed to InitSecurity.
+// Security 返回应用 CORS、安全响应头和 CSRF 防护的中间件
+// 不传参数时使用 InitSecurity 传入的配置。
+func Security(config ...SecurityConfig) fiber.Handler {
+	cfg := securityConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	cors := newCORS(cfg.CORS, securityEnv)
+	headers := newSecurityHeaders(cfg.Headers)
+
+	next := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.CSRF.Enabled {
+		next = newCSRF(cfg.CSRF)
+	}
+
+	return func(c *fiber.Ctx) error {
+		headers(c)
+		if done := cors(c); done {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		return next(c)
+	}
+}
+
+// newCORS builds the CORS handler, it returns true when a preflight request was answered
+// newCORS 构建 CORS 处理函数，处理完预检请求时返回 true
+func newCORS(cfg CORSConfig, env string) func(c *fiber.Ctx) bool {
+	origins := cfg.AllowOrigins
+	if envOrigins, ok := cfg.EnvOrigins[env]; ok {
+		origins = envOrigins
+	}
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", "X-CSRF-Token"}
+	}
+	if len(cfg.ExposeHeaders) == 0 {
+		cfg.ExposeHeaders = []string{"X-Request-ID"}
+	}
+	anyOrigin := slices.Contains(origins, "*")
+	if anyOrigin {
+		// Never pair "*" with credentials, see Validate | "*" 永不与凭证同时使用，见 Validate
+		cfg.AllowCredentials = false
+	}
+	methods := strings.Join(cfg.AllowMethods, ",")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ",")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ",")
+
+	return func(c *fiber.Ctx) bool {
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin == "" {
+			return false
+		}
+
+		c.Vary(fiber.HeaderOrigin)
+		switch {
+		case anyOrigin:
+			c.Set(fiber.HeaderAccessControlAllowOrigin, "*")
+		case slices.Contains(origins, origin):
+			c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+		default:
+			return false
+		}
+		if cfg.AllowCredentials {
+			c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+		}
+
+		if c.Method() != fiber.MethodOptions || c.Get(fiber.HeaderAccessControlRequestMethod) == "" {
+			c.Set(fiber.HeaderAccessControlExposeHeaders, exposeHeaders)
+			return false
+		}
+
+		// Preflight | 预检请求
+		c.Set(fiber.HeaderAccessControlAllowMethods, methods)
+		c.Set(fiber.HeaderAccessControlAllowHeaders, allowHeaders)
+		if cfg.MaxAge > 0 {
+			c.Set(fiber.HeaderAccessControlMaxAge, strconv.Itoa(cfg.MaxAge))
+		}
+		return true
+	}
+}
+
+// newSecurityHeaders builds the security header setter
+// newSecurityHeaders 构建安全响应头设置函数
+func newSecurityHeaders(cfg SecurityHeadersConfig) func(c *fiber.Ctx) {
+	if cfg.FrameOptions == "" {
+		cfg.FrameOptions = "SAMEORIGIN"
+	}
+	if cfg.ReferrerPolicy == "" {
+		cfg.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(c *fiber.Ctx) {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderXFrameOptions, cfg.FrameOptions)
+		c.Set(fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy)
+		if hsts != "" && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		if cfg.ContentSecurityPolicy != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy)
+		}
+	}
+}
+
+// newCSRF builds the CSRF middleware using the double-submit cookie pattern
+// Requests carrying a valid Bearer token or API key are exempt, as a cross-site page cannot attach them,
+// as are paths registered with ExemptCSRF.
+// newCSRF 使用双重提交 Cookie 模式构建 CSRF 中间件
+// 携带有效 Bearer 令牌或 API 密钥的请求不受限制，因为跨站页面无法附带它们；通过 ExemptCSRF 注册的路径同样不受限制。
+func newCSRF(cfg CSRFConfig) fiber.Handler {
+	if cfg.CookieName == "" {
+		cfg.CookieName = "csrf_"
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = "X-CSRF-Token"
+	}
+	if cfg.SameSite == "" {
+		cfg.SameSite = "Lax"
+	}
+	if cfg.Expiration <= 0 {
+		cfg.Expiration = time.Hour
+	}
+
+	return csrf.New(csrf.Config{
+		KeyLookup:      "header:" + cfg.HeaderName,
+		CookieName:     cfg.CookieName,
+		CookieSecure:   cfg.CookieSecure,
+		CookieSameSite: cfg.SameSite,
+		Expiration:     cfg.Expiration,
+		Next: func(c *fiber.Ctx) bool {
+			return csrfExempted(c.Path()) || parseBearer(c) != nil || validAPIKey(c)
+		},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return errors.ErrForbidden("Invalid CSRF token")
+		},
+	})
+}
+
+// validAPIKey reports whether the request carries an API key that passes validation
+// validAPIKey 判断请求是否携带通过校验的 API 密钥
+func validAPIKey(c *fiber.Ctx) bool {
+	plain := extractAPIKey(c)
+	if plain == "" {
+		return false
+	}
+	_, err := apikey.Validate(c.UserContext(), plain)
+	return err == nil
+}
+
+// ExemptCSRF exempts requests under the path prefix from CSRF checks
+// Only use it for routes authenticated by other means, such as signed provider callbacks.
+// ExemptCSRF 使该路径前缀下的请求免于 CSRF 校验
+// 仅用于通过其他方式认证的路由，例如带签名的服务商回调。
+func ExemptCSRF(prefix string) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return
+	}
+	csrfMu.Lock()
+	defer csrfMu.Unlock()
+	if !slices.Contains(csrfExempt, prefix) {
+		csrfExempt = append(csrfExempt, prefix)
+	}
+}
+
+// csrfExempted reports whether path is under a prefix registered with ExemptCSRF
+// csrfExempted 判断路径是否位于通过 ExemptCSRF 注册的前缀下
+func csrfExempted(path string) bool {
+	csrfMu.RLock()
+	defer csrfMu.RUnlock()
+	for _, p := range csrfExempt {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
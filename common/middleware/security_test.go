@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/apikey"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	app := newTestApp()
+	app.Use(Security(SecurityConfig{CORS: CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}}))
+	app.Post("/orders", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	preflight := func(origin string) *http.Response {
+		req := httptest.NewRequest("OPTIONS", "/orders", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		resp, _ := do(t, app, req)
+		return resp
+	}
+
+	resp := preflight("https://app.example.com")
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("Allowed preflight: expected 204, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allowed preflight: expected echoed origin, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allowed preflight: expected credentials, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Methods"); !strings.Contains(got, "POST") {
+		t.Errorf("Allowed preflight: expected methods, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Allowed preflight: expected max-age 600, got %q", got)
+	}
+
+	resp = preflight("https://evil.example.com")
+	if resp.StatusCode == fiber.StatusNoContent {
+		t.Errorf("Unknown origin: preflight must not be answered")
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Unknown origin: expected no allow-origin, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Unknown origin: expected no credentials, got %q", got)
+	}
+
+	req := httptest.NewRequest("POST", "/orders", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	simple, body := do(t, app, req)
+	if body != "ok" || simple.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		simple.Header.Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("Simple request: unexpected response %q %v", body, simple.Header)
+	}
+}
+
+func TestCORSWildcardDropsCredentials(t *testing.T) {
+	app := newTestApp()
+	app.Use(Security(SecurityConfig{CORS: CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, _ := do(t, app, req)
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected wildcard allow-origin, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials with wildcard, got %q", got)
+	}
+}
+
+func TestCORSConfigValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  CORSConfig
+		ok   bool
+	}{
+		{"wildcard without credentials", CORSConfig{AllowOrigins: []string{"*"}}, true},
+		{"explicit with credentials", CORSConfig{AllowOrigins: []string{"https://a.example.com"}, AllowCredentials: true}, true},
+		{"wildcard with credentials", CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}, false},
+		{"default origins with credentials", CORSConfig{AllowCredentials: true}, false},
+		{"env wildcard with credentials", CORSConfig{
+			AllowOrigins:     []string{"https://a.example.com"},
+			EnvOrigins:       map[string][]string{"dev": {"*"}},
+			AllowCredentials: true,
+		}, false},
+	}
+	for _, tc := range cases {
+		if err := tc.cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tc.name, tc.ok, err)
+		}
+	}
+}
+
+func TestCSRFExemptions(t *testing.T) {
+	prevCfg := apikey.GetConfig()
+	t.Cleanup(func() { apikey.Init(prevCfg) })
+	if err := jwt.Init(jwt.Config{Secret: "csrf-test-secret", Expire: "1h"}); err != nil {
+		t.Fatalf("jwt.Init failed: %v", err)
+	}
+	apikey.Init(apikey.Config{})
+	seedAPIKey(t, "ck_csrf_secret", apikey.APIKey{ID: 1, Status: apikey.StatusActive, Scopes: []string{"*"}})
+	token, err := jwt.Get().Generate(7, "web")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	app := newTestApp()
+	app.Use(Security(SecurityConfig{CSRF: CSRFConfig{Enabled: true}}))
+	app.Get("/form", func(c *fiber.Ctx) error { return c.SendString("form") })
+	app.Post("/orders", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	post := func(headers map[string]string) int {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		req.Header.Set("Cookie", "crab_sid=session")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, _ := do(t, app, req)
+		return resp.StatusCode
+	}
+
+	if code := post(nil); code != fiber.StatusForbidden {
+		t.Errorf("Session cookie without token: expected 403, got %d", code)
+	}
+	if code := post(map[string]string{"Authorization": "Bearer forged"}); code != fiber.StatusForbidden {
+		t.Errorf("Invalid bearer: expected 403, got %d", code)
+	}
+	if code := post(map[string]string{"X-API-Key": "ck_unknown"}); code != fiber.StatusForbidden {
+		t.Errorf("Invalid API key: expected 403, got %d", code)
+	}
+	if code := post(map[string]string{"Authorization": "Bearer " + token}); code != fiber.StatusOK {
+		t.Errorf("Valid bearer: expected 200, got %d", code)
+	}
+	if code := post(map[string]string{"X-API-Key": "ck_csrf_secret"}); code != fiber.StatusOK {
+		t.Errorf("Valid API key: expected 200, got %d", code)
+	}
+
+	// Double submit: the token issued on GET must accompany the POST | 双重提交：GET 下发的令牌必须随 POST 提交
+	resp, _ := do(t, app, httptest.NewRequest("GET", "/form", nil))
+	var csrfToken string
+	for _, c := range resp.Cookies() {
+		if c.Name == "csrf_" {
+			csrfToken = c.Value
+		}
+	}
+	if csrfToken == "" {
+		t.Fatal("Expected csrf_ cookie on GET")
+	}
+	req := httptest.NewRequest("POST", "/orders", nil)
+	req.Header.Set("Cookie", "crab_sid=session; csrf_="+csrfToken)
+	req.Header.Set("X-CSRF-Token", csrfToken)
+	if resp, _ := do(t, app, req); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Matching token: expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestCSRFExemptPath(t *testing.T) {
+	t.Cleanup(func() { csrfExempt = nil })
+	ExemptCSRF("/payment/notify/")
+
+	app := newTestApp()
+	app.Use(Security(SecurityConfig{CSRF: CSRFConfig{Enabled: true}}))
+	app.Post("/payment/notify/:provider", func(c *fiber.Ctx) error { return c.SendString("success") })
+	app.Post("/payment/notifyx", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Post("/orders", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	post := func(path string) int {
+		resp, _ := do(t, app, httptest.NewRequest("POST", path, nil))
+		return resp.StatusCode
+	}
+	if code := post("/payment/notify/alipay"); code != fiber.StatusOK {
+		t.Errorf("Exempt path: expected 200, got %d", code)
+	}
+	if code := post("/payment/notifyx"); code != fiber.StatusForbidden {
+		t.Errorf("Sibling path: expected 403, got %d", code)
+	}
+	if code := post("/orders"); code != fiber.StatusForbidden {
+		t.Errorf("Cookieless request elsewhere: expected 403, got %d", code)
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import "slices"
+
+// Toggles enables or disables named middleware for a service
+// Names: access_log, body_limit, cancellation, compress, drain, etag, maintenance, ratelimit, request_context, security, tenant, trace, logger, plus any
+// name a module passes to ModuleContext.UseNamed.
+// Toggles 为服务启用或禁用具名中间件
+// 名称：access_log、body_limit、cancellation、compress、drain、etag、maintenance、ratelimit、request_context、security、tenant、trace、logger，以及模块传给 ModuleContext.UseNamed 的任意名称。
+type Toggles struct {
+	Enable  []string // Force-enable middleware | 强制启用的中间件
+	Disable []string // Disable middleware | 禁用的中间件
+}
+
+// Enabled reports whether the named middleware is on, given its default
+// Disable wins over Enable.
+// Enabled 根据默认值判断具名中间件是否启用，Disable 优先于 Enable。
+func (t Toggles) Enabled(name string, def bool) bool {
+	if slices.Contains(t.Disable, name) {
+		return false
+	}
+	if slices.Contains(t.Enable, name) {
+		return true
+	}
+	return def
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"log"
 
@@ -47,6 +48,18 @@ func GetDB(model any, name ...string) *xorm.Engine {
 	return db
 }
 
+// DB returns a session of the model's database bound to ctx, see GetDB for the database choice.
+// Handlers pass c.UserContext() so queries stop when the request is canceled or times out.
+// DB 返回绑定 ctx 的模型数据库会话，数据库选择规则同 GetDB。
+// 处理器传入 c.UserContext()，使查询在请求取消或超时后停止。
+//
+// Usage | 用法:
+//
+//	has, err := model.DB(c.UserContext(), &user).ID(id).Get(&user)
+func DB(ctx context.Context, model any, name ...string) *xorm.Session {
+	return GetDB(model, name...).Context(ctx)
+}
+
 // GetDBSafe gets the database engine for a model (safe version, returns error instead of panic)
 // GetDBSafe 获取模型对应的数据库引擎（安全版本，返回错误而不是 panic）
 // Priority: parameter specified > Model's DBName() > default database
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/nuohe369/crab/common/request"
+	"xorm.io/xorm"
+)
+
+// Keyset describes the ordering of a keyset (cursor) paginated query
+// Rows are ordered by SortColumn and then by IDColumn, which breaks ties, so
+// the pair must be unique. Index (SortColumn, IDColumn) for large tables.
+// Keyset 描述键集（游标）分页查询的排序方式
+// 行先按 SortColumn 再按 IDColumn 排序，IDColumn 用于打破并列，因此二者组合必须唯一。
+// 大表请为 (SortColumn, IDColumn) 建立索引。
+type Keyset struct {
+	SortColumn string // Sort column, e.g. "created_at"; empty orders by IDColumn only | 排序列，例如 "created_at"；为空时仅按 IDColumn 排序
+	IDColumn   string // Snowflake ID column (default "id") | 雪花 ID 列（默认 "id"）
+	Asc        bool   // Ascending order, default is descending (newest first) | 升序，默认降序（最新在前）
+}
+
+// Apply adds the keyset condition, ordering and a limit of size+1 to the session
+// The extra row tells whether there is a next page, see CursorSlice.
+// Apply 为会话添加键集条件、排序以及 size+1 的限制
+// 多查询的一行用于判断是否还有下一页，参见 CursorSlice。
+func (k Keyset) Apply(s *xorm.Session, cur *request.Cursor, size int) *xorm.Session {
+	id := k.IDColumn
+	if id == "" {
+		id = "id"
+	}
+	op := "<"
+	if k.Asc {
+		op = ">"
+	}
+
+	if cur != nil {
+		if k.SortColumn != "" && cur.Sort != nil {
+			s = s.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", k.SortColumn, op, k.SortColumn, id, op),
+				cur.Sort, cur.Sort, cur.ID)
+		} else {
+			s = s.Where(id+" "+op+" ?", cur.ID)
+		}
+	}
+
+	cols := []string{id}
+	if k.SortColumn != "" {
+		cols = []string{k.SortColumn, id}
+	}
+	if k.Asc {
+		s = s.Asc(cols...)
+	} else {
+		s = s.Desc(cols...)
+	}
+	return s.Limit(size + 1)
+}
+
+// CursorSlice trims the extra row fetched by Keyset.Apply and builds the next cursor
+// cursorOf returns the cursor position of a row (its ID and sort key value).
+// CursorSlice 去掉 Keyset.Apply 多查询的一行并构建下一页游标
+// cursorOf 返回一行数据的游标位置（ID 和排序键值）。
+func CursorSlice[T any](list []T, size int, cursorOf func(*T) request.Cursor) (page []T, next string, hasMore bool) {
+	if len(list) <= size {
+		return list, "", false
+	}
+	page = list[:size]
+	return page, cursorOf(&page[size-1]).Encode(), true
+}
+
+// FindCursor runs a keyset paginated query
+// s carries the filters, e.g. model.GetDB(&user).Where("status = ?", 1).
+// Returns request.ErrInvalidCursor for a malformed cursor.
+// FindCursor 执行键集分页查询
+// s 携带筛选条件，例如 model.GetDB(&user).Where("status = ?", 1)。
+// 游标格式错误时返回 request.ErrInvalidCursor。
+//
+// Usage | 用法:
+//
+//	list, next, hasMore, err := model.FindCursor(model.GetDB(&user).Where("status = ?", 1),
+//	    model.Keyset{SortColumn: "created_at"}, &req,
+//	    func(u *model.ExampleUser) request.Cursor { return request.Cursor{ID: u.ID.Int64(), Sort: u.CreatedAt} })
+func FindCursor[T any](s *xorm.Session, k Keyset, req *request.CursorReq, cursorOf func(*T) request.Cursor) ([]T, string, bool, error) {
+	cur, err := req.Decode()
+	if err != nil {
+		return nil, "", false, err
+	}
+	size := req.GetSize()
+
+	var list []T
+	if err := k.Apply(s, cur, size).Find(&list); err != nil {
+		return nil, "", false, err
+	}
+	page, next, hasMore := CursorSlice(list, size, cursorOf)
+	return page, next, hasMore, nil
+}
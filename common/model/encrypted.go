@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/crypto"
+)
+
+// ReencryptColumns rewrites crypto.EncryptedString columns of a model's table under the current field key
+// Rows are read batch at a time in primary key order (default 500); values encrypted with an older key,
+// or still in plaintext, are re-encrypted. Each row is updated only if the column is unchanged since it
+// was read, so the rotation can run while the application writes. It returns the number of updated rows.
+// ReencryptColumns 使用当前字段密钥重写模型表中的 crypto.EncryptedString 列
+// 按主键顺序每次读取 batch 行（默认 500），使用旧密钥加密或仍为明文的值会被重新加密。
+// 仅当列值自读取后未被修改时才更新该行，因此轮换可以在应用写入期间运行。返回更新的行数。
+//
+// Example | 示例:
+//
+//	// After adding key k2 and setting it current | 添加密钥 k2 并设为当前密钥后
+//	n, err := model.ReencryptColumns(ctx, &Customer{}, 1000, "phone", "id_number")
+func ReencryptColumns(ctx context.Context, bean any, batch int, columns ...string) (int64, error) {
+	db, err := GetDBSafe(bean)
+	if err != nil {
+		return 0, err
+	}
+	table, err := db.TableInfo(bean)
+	if err != nil {
+		return 0, err
+	}
+	if len(table.PrimaryKeys) != 1 {
+		return 0, fmt.Errorf("reencrypt %s: a single-column primary key is required", table.Name)
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	if batch <= 0 {
+		batch = 500
+	}
+
+	pk := table.PrimaryKeys[0]
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = db.Quote(c)
+	}
+	selectSQL := fmt.Sprintf("SELECT %s, %s FROM %s", db.Quote(pk), strings.Join(quoted, ", "), db.Quote(table.Name))
+
+	var updated int64
+	var last string
+	for first := true; ; first = false {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+		query, args := selectSQL, []any{}
+		if !first {
+			query += " WHERE " + db.Quote(pk) + " > ?"
+			args = append(args, last)
+		}
+		query += fmt.Sprintf(" ORDER BY %s LIMIT %d", db.Quote(pk), batch)
+		rows, err := db.Context(ctx).QueryString(append([]any{query}, args...)...)
+		if err != nil {
+			return updated, err
+		}
+
+		for _, row := range rows {
+			last = row[pk]
+			var sets, conds []string
+			var setArgs, condArgs []any
+			for i, c := range columns {
+				value, changed, err := crypto.ReencryptField(row[c])
+				if err != nil {
+					return updated, fmt.Errorf("reencrypt %s.%s (%s=%s): %w", table.Name, c, pk, last, err)
+				}
+				if changed {
+					sets = append(sets, quoted[i]+" = ?")
+					setArgs = append(setArgs, value)
+					conds = append(conds, quoted[i]+" = ?")
+					condArgs = append(condArgs, row[c])
+				}
+			}
+			if len(sets) == 0 {
+				continue
+			}
+			update := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ? AND %s", db.Quote(table.Name),
+				strings.Join(sets, ", "), db.Quote(pk), strings.Join(conds, " AND "))
+			args := append(append(append([]any{update}, setArgs...), last), condArgs...)
+			res, err := db.Context(ctx).Exec(args...)
+			if err != nil {
+				return updated, err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				updated += n
+			}
+		}
+		if len(rows) < batch {
+			return updated, nil
+		}
+	}
+}
@@ -3,11 +3,28 @@
 package model
 
 import (
+	"context"
 	"time"
 
+	"github.com/nuohe369/crab/pkg/cdc"
+	"github.com/nuohe369/crab/pkg/search"
 	"github.com/nuohe369/crab/pkg/snowflake"
 )
 
+// ExampleArticleIndex is the search index of articles
+// ExampleArticleIndex 文章的搜索索引
+const ExampleArticleIndex = "example_article"
+
+func init() {
+	search.Register(search.Index{
+		Name:       ExampleArticleIndex,
+		Fields:     []search.Field{{Name: "title", Weight: "A"}, {Name: "content", Weight: "B"}},
+		Filterable: []string{"user_id", "category_id", "status"},
+		Sortable:   []string{"created_at", "view_count"},
+		Where:      "deleted_at IS NULL",
+	})
+}
+
 // ExampleArticle represents the example article model for demonstration
 // ExampleArticle 示例文章模型，用于演示
 type ExampleArticle struct {
@@ -20,6 +37,9 @@ type ExampleArticle struct {
 	Status     int                   `json:"status" xorm:"default(1) 'status'"`                     // Status: 1=published, 0=draft, 2=offline | 状态: 1=已发布, 0=草稿, 2=下架
 	CreatedAt  time.Time             `json:"created_at" xorm:"created 'created_at'"`                // Creation time | 创建时间
 	UpdatedAt  time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`                // Update time | 更新时间
+
+	Versioned  `xorm:"extends"` // Optimistic lock, see UpdateWithVersion | 乐观锁，见 UpdateWithVersion
+	SoftDelete `xorm:"extends"` // Soft delete, see Restore and Purge | 软删除，见 Restore 和 Purge
 }
 
 // TableName returns the table name
@@ -36,6 +56,79 @@ func (a *ExampleArticle) BeforeInsert() {
 	}
 }
 
+// BeforeUpdate captures the article for the change event | BeforeUpdate 为变更事件捕获文章
+func (a *ExampleArticle) BeforeUpdate() { cdc.Before(a) }
+
+// BeforeDelete captures the article for the change event | BeforeDelete 为变更事件捕获文章
+func (a *ExampleArticle) BeforeDelete() { cdc.Before(a) }
+
+// AfterInsert indexes the new article and publishes its creation | AfterInsert 索引新文章并发布创建事件
+func (a *ExampleArticle) AfterInsert() {
+	search.Sync(a)
+	cdc.Created(a)
+}
+
+// AfterUpdate reindexes the article and publishes the change, set ID on the bean
+// AfterUpdate 重新索引文章并发布变更，需在 bean 上设置 ID
+func (a *ExampleArticle) AfterUpdate() {
+	search.Sync(a)
+	cdc.Updated(a)
+}
+
+// AfterDelete removes the article from the index and publishes the deletion, set ID on the bean
+// AfterDelete 从索引中移除文章并发布删除事件，需在 bean 上设置 ID
+func (a *ExampleArticle) AfterDelete() {
+	search.Sync(a)
+	cdc.Deleted(a)
+}
+
+// EntityName implements cdc.Entity | EntityName 实现 cdc.Entity
+func (a *ExampleArticle) EntityName() string { return "article" }
+
+// EntityID implements cdc.Entity | EntityID 实现 cdc.Entity
+func (a *ExampleArticle) EntityID() string { return a.SearchID() }
+
+// EntitySnapshot reloads the article for change events, false once deleted
+// EntitySnapshot 为变更事件重新加载文章，删除后返回 false
+func (a *ExampleArticle) EntitySnapshot(ctx context.Context) (any, bool, error) {
+	row := &ExampleArticle{}
+	has, err := GetDB(row).Context(ctx).ID(a.ID).Get(row)
+	if err != nil || !has {
+		return nil, false, err
+	}
+	return row, true, nil
+}
+
+// SearchIndex implements search.Indexable | SearchIndex 实现 search.Indexable
+func (a *ExampleArticle) SearchIndex() string { return ExampleArticleIndex }
+
+// SearchID implements search.Indexable | SearchID 实现 search.Indexable
+func (a *ExampleArticle) SearchID() string {
+	if a.ID.IsZero() {
+		return ""
+	}
+	return a.ID.String()
+}
+
+// SearchDocument reloads the article for the index, false once deleted
+// SearchDocument 重新加载文章用于索引，删除后返回 false
+func (a *ExampleArticle) SearchDocument(ctx context.Context) (map[string]any, bool, error) {
+	row := &ExampleArticle{}
+	has, err := GetDB(row).Context(ctx).ID(a.ID).Get(row)
+	if err != nil || !has {
+		return nil, false, err
+	}
+	return map[string]any{
+		"title":       row.Title,
+		"content":     row.Content,
+		"user_id":     row.UserID,
+		"category_id": row.CategoryID,
+		"status":      row.Status,
+		"view_count":  row.ViewCount,
+		"created_at":  row.CreatedAt.Unix(), // Numbers sort in every engine | 数字在所有引擎中都可排序
+	}, true, nil
+}
+
 // Article status constants | 文章状态常量
 const (
 	ExampleArticleStatusDraft     = 0 // Draft | 草稿
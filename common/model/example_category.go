@@ -23,6 +23,12 @@ func (c *ExampleCategory) TableName() string {
 	return "example_category"
 }
 
+// CacheTTL keeps category lists in the query cache longer, they rarely change
+// CacheTTL 让分类列表在查询缓存中保留更久，分类很少变更
+func (c *ExampleCategory) CacheTTL() time.Duration {
+	return 10 * time.Minute
+}
+
 // BeforeInsert generates snowflake ID before insertion
 // BeforeInsert 插入前生成雪花 ID
 func (c *ExampleCategory) BeforeInsert() {
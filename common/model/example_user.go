@@ -3,8 +3,8 @@ package model
 import (
 	"time"
 
+	"github.com/nuohe369/crab/pkg/crypto"
 	"github.com/nuohe369/crab/pkg/snowflake"
-	"github.com/nuohe369/crab/pkg/util"
 )
 
 // ExampleUser represents the example user model for demonstration
@@ -33,10 +33,10 @@ func (u *ExampleUser) BeforeInsert() {
 	}
 }
 
-// SetPassword sets the password (encrypted)
-// SetPassword 设置密码（加密）
+// SetPassword sets the password (hashed with crypto.HashPassword)
+// SetPassword 设置密码（使用 crypto.HashPassword 哈希）
 func (u *ExampleUser) SetPassword(password string) error {
-	hashed, err := util.HashPassword(password)
+	hashed, err := crypto.HashPassword(password)
 	if err != nil {
 		return err
 	}
@@ -45,9 +45,22 @@ func (u *ExampleUser) SetPassword(password string) error {
 }
 
 // CheckPassword validates the password
+// A correct password stored with outdated parameters is rehashed into Password and rehashed is true:
+// update the password column to keep the upgrade.
 // CheckPassword 校验密码
-func (u *ExampleUser) CheckPassword(password string) bool {
-	return util.CheckPassword(password, u.Password)
+// 正确但使用旧参数存储的密码会被重新哈希到 Password，并且 rehashed 为 true：更新 password 列以保存升级。
+func (u *ExampleUser) CheckPassword(password string) (ok, rehashed bool) {
+	ok, rehash, err := crypto.VerifyPassword(password, u.Password)
+	if !ok || err != nil {
+		return false, false
+	}
+	if rehash {
+		if hashed, err := crypto.HashPassword(password); err == nil {
+			u.Password = hashed
+			return true, true
+		}
+	}
+	return true, false
 }
 
 // IsEnabled checks if the user is enabled
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/pkg/geo"
+	"xorm.io/xorm"
+)
+
+// GeoModel is a model with PostGIS columns, converted by the migration after Sync
+// GeoModel 表示带有 PostGIS 列的模型，迁移时在 Sync 之后转换这些列
+//
+// Example | 示例:
+//
+//	func (s *Store) GeoColumns() map[string]string {
+//		return map[string]string{"location": geo.TypePoint, "delivery_zone": geo.TypePolygon}
+//	}
+type GeoModel interface {
+	GeoColumns() map[string]string // Column -> geo.TypePoint / geo.TypePolygon | 列名 -> geo.TypePoint / geo.TypePolygon
+}
+
+// EnsureGeoColumns makes the columns of a GeoModel geography columns with GIST indexes
+// EnsureGeoColumns 将 GeoModel 的列设为带 GIST 索引的 geography 列
+func EnsureGeoColumns(ctx context.Context, bean GeoModel) error {
+	db, err := GetDBSafe(bean)
+	if err != nil {
+		return err
+	}
+	table, err := db.TableInfo(bean)
+	if err != nil {
+		return err
+	}
+	for column, typ := range bean.GeoColumns() {
+		if err := geo.EnsureColumn(ctx, db, table.Name, column, typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindNearby loads the rows within radius meters of p, nearest first; limit <= 0 loads them all
+// Conditions already on the session (tenant, status) still apply.
+// FindNearby 加载距 p 不超过 radius 米的行，最近的在前；limit <= 0 时加载全部
+// 会话上已有的条件（租户、状态）仍然生效。
+//
+// Usage | 用法:
+//
+//	stores, err := model.FindNearby[Store](db.NewSession().Where("status = ?", 1), "location", p, 3000, 20)
+func FindNearby[T any](s *xorm.Session, column string, p geo.Point, radius float64, limit int) ([]T, error) {
+	s = geo.NearestFirst(geo.Within(s, column, p, radius), column, p)
+	if limit > 0 {
+		s = s.Limit(limit)
+	}
+	var rows []T
+	if err := s.Find(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
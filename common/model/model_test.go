@@ -0,0 +1,27 @@
+package model
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nuohe369/crab/pkg/pgsql"
+)
+
+var testDBSeq atomic.Int64
+
+// useTestDB points the default database at a fresh in-memory SQLite database with the tables of beans
+func useTestDB(t *testing.T, beans ...any) *pgsql.Client {
+	t.Helper()
+	source := fmt.Sprintf("file:modeltest%d?mode=memory&cache=shared", testDBSeq.Add(1))
+	if err := pgsql.Init(pgsql.Config{Driver: "sqlite3", Source: source}); err != nil {
+		t.Fatalf("pgsql.Init failed: %v", err)
+	}
+	t.Cleanup(pgsql.Close)
+	db := pgsql.Get()
+	if err := db.Sync(beans...); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/pkg/cron"
+	"xorm.io/xorm"
+)
+
+// SoftDelete is a mixin marking rows deleted instead of removing them, embed it with `xorm:"extends"`
+// It uses the xorm "deleted" tag: Delete sets deleted_at, and Get, Find, Count, Exist and Update skip
+// rows with deleted_at set. Use Unscoped to include them, Restore to undelete a row and Purge or
+// PurgeJob to remove old rows for good.
+// SoftDelete 是将行标记为已删除而非真正删除的混入类型，使用 `xorm:"extends"` 嵌入
+// 基于 xorm 的 "deleted" 标签：Delete 设置 deleted_at，Get、Find、Count、Exist 和 Update 会跳过
+// 已设置 deleted_at 的行。使用 Unscoped 包含这些行，使用 Restore 恢复行，使用 Purge 或 PurgeJob 永久删除旧行。
+//
+// Example | 示例:
+//
+//	type Article struct {
+//	    ID    snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+//	    Title string                `json:"title" xorm:"'title'"`
+//	    model.SoftDelete `xorm:"extends"`
+//	}
+type SoftDelete struct {
+	DeletedAt time.Time `json:"-" xorm:"deleted index 'deleted_at'"` // Deletion time, NULL while live | 删除时间，未删除时为 NULL
+}
+
+// IsDeleted reports whether the row is soft-deleted | IsDeleted 判断行是否已被软删除
+func (s *SoftDelete) IsDeleted() bool {
+	return !s.DeletedAt.IsZero()
+}
+
+// Unscoped returns a session of the model's database that includes soft-deleted rows
+// Unscoped 返回模型所在数据库的会话，查询结果包含已软删除的行
+//
+// Usage | 用法:
+//
+//	has, err := model.Unscoped(&article).ID(id).Get(&article)
+func Unscoped(bean any, name ...string) *xorm.Session {
+	return GetDB(bean, name...).Unscoped()
+}
+
+// Restore undeletes the soft-deleted row id of bean's table, returning ErrNotFound when there is no such row
+// Restore 恢复 bean 所在表中已软删除的 id 行，不存在该行时返回 ErrNotFound
+//
+// Usage | 用法:
+//
+//	err := model.Restore(ctx, &model.ExampleArticle{}, id)
+func Restore(ctx context.Context, bean any, id any) error {
+	db, err := GetDBSafe(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	table, err := db.TableInfo(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	col := table.DeletedColumn()
+	if col == nil {
+		return errors.ErrServerError(fmt.Sprintf("restore %s: no deleted column", table.Name))
+	}
+
+	affected, err := db.Context(ctx).Unscoped().NoVersionCheck().ID(id).
+		Where(db.Quote(col.Name)+" IS NOT NULL").
+		SetExpr(col.Name, "NULL").
+		Update(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if affected == 0 {
+		return errors.ErrNotFound()
+	}
+	return nil
+}
+
+// Purge permanently deletes rows of bean's table soft-deleted more than retention ago
+// Rows are deleted batch at a time (default 500) so large tables do not hold long locks; each batch
+// selects the primary keys first, as MySQL rejects LIMIT in an IN subquery. It returns the number of
+// deleted rows.
+// Purge 永久删除 bean 所在表中软删除时间早于 retention 之前的行
+// 每次删除 batch 行（默认 500），避免大表长时间持有锁；每批先查询主键再删除，因为 MySQL 不支持
+// IN 子查询中的 LIMIT。返回删除的行数。
+func Purge(ctx context.Context, bean any, retention time.Duration, batch int) (int64, error) {
+	db, err := GetDBSafe(bean)
+	if err != nil {
+		return 0, err
+	}
+	table, err := db.TableInfo(bean)
+	if err != nil {
+		return 0, err
+	}
+	col := table.DeletedColumn()
+	if col == nil {
+		return 0, fmt.Errorf("purge %s: no deleted column", table.Name)
+	}
+	if len(table.PrimaryKeys) != 1 {
+		return 0, fmt.Errorf("purge %s: a single-column primary key is required", table.Name)
+	}
+	if batch <= 0 {
+		batch = 500
+	}
+
+	// xorm writes the zero time for live rows in batch inserts, the lower bound keeps them
+	// xorm 批量插入时会为未删除的行写入零值时间，下限用于保留这些行
+	pkName := table.PrimaryKeys[0]
+	pk, name, deleted := db.Quote(pkName), db.Quote(table.Name), db.Quote(col.Name)
+	selectQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s < ? AND %s > ? LIMIT %d", pk, name, deleted, deleted, batch)
+	cutoff := time.Now().Add(-retention)
+
+	var purged int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		rows, err := db.Context(ctx).QueryInterface(selectQuery, cutoff, time.Unix(0, 0))
+		if err != nil {
+			return purged, err
+		}
+		if len(rows) == 0 {
+			return purged, nil
+		}
+
+		args := make([]any, 1, len(rows)+1)
+		for _, row := range rows {
+			args = append(args, row[pkName])
+		}
+		args[0] = fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", name, pk, strings.TrimSuffix(strings.Repeat("?,", len(rows)), ","))
+		res, err := db.Context(ctx).Exec(args...)
+		if err != nil {
+			return purged, err
+		}
+		n, _ := res.RowsAffected()
+		purged += n
+		if len(rows) < batch {
+			return purged, nil
+		}
+	}
+}
+
+// PurgeJob returns a cron job purging rows of the given models soft-deleted more than retention ago
+// PurgeJob 返回一个定时任务，永久删除给定模型中软删除时间早于 retention 之前的行
+//
+// Usage | 用法:
+//
+//	cron.Register(model.PurgeJob("purge_articles", "0 30 3 * * *", 30*24*time.Hour, new(model.ExampleArticle)))
+func PurgeJob(name, spec string, retention time.Duration, beans ...any) cron.Job {
+	return cron.Job{
+		Name:    name,
+		Spec:    spec,
+		Timeout: 30 * time.Minute,
+		Func: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			defer cancel()
+			for _, bean := range beans {
+				n, err := Purge(ctx, bean, retention, 0)
+				if err != nil {
+					log.Printf("model: purge %T failed after %d rows: %v", bean, n, err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("model: purged %d soft-deleted rows of %T", n, bean)
+				}
+			}
+		},
+	}
+}
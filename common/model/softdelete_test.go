@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+type softDoc struct {
+	ID         int64  `xorm:"pk 'id'"`
+	Title      string `xorm:"'title'"`
+	SoftDelete `xorm:"extends"`
+}
+
+func (d *softDoc) TableName() string { return "soft_doc" }
+
+func TestRestore(t *testing.T) {
+	db := useTestDB(t, new(softDoc))
+	ctx := context.Background()
+
+	if _, err := db.Engine().Insert(&softDoc{ID: 1, Title: "a"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := db.Engine().ID(1).Delete(new(softDoc)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if has, _ := db.Engine().ID(1).Get(new(softDoc)); has {
+		t.Fatal("Expected the soft-deleted row to be hidden")
+	}
+	row := &softDoc{}
+	if has, _ := Unscoped(row).ID(1).Get(row); !has || !row.IsDeleted() {
+		t.Fatalf("Expected Unscoped to return the deleted row, got %v %+v", has, row)
+	}
+
+	if err := Restore(ctx, new(softDoc), 1); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	row = &softDoc{}
+	if has, _ := db.Engine().ID(1).Get(row); !has || row.IsDeleted() || row.Title != "a" {
+		t.Errorf("Expected the restored row, got %v %+v", has, row)
+	}
+
+	// A live or missing row cannot be restored | 未删除或不存在的行无法恢复
+	for _, id := range []int64{1, 2} {
+		var be *errors.BizError
+		if err := Restore(ctx, new(softDoc), id); !stderrors.As(err, &be) || be.Code != response.CodeNotFound {
+			t.Errorf("Restore(%d): expected not found, got %v", id, err)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db := useTestDB(t, new(softDoc))
+	ctx := context.Background()
+
+	// Rows 1-5 deleted two days ago, 6 deleted just now, 7 live | 1-5 行两天前删除，6 刚删除，7 未删除
+	for id := int64(1); id <= 7; id++ {
+		if _, err := db.Engine().Insert(&softDoc{ID: id}); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if id == 7 {
+			continue
+		}
+		if _, err := db.Engine().ID(id).Delete(new(softDoc)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if id <= 5 {
+			old := &softDoc{SoftDelete: SoftDelete{DeletedAt: time.Now().Add(-48 * time.Hour)}}
+			if _, err := db.Engine().Unscoped().ID(id).Cols("deleted_at").Update(old); err != nil {
+				t.Fatalf("Backdate failed: %v", err)
+			}
+		}
+	}
+
+	n, err := Purge(ctx, new(softDoc), 24*time.Hour, 2)
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 purged rows over three batches, got %d", n)
+	}
+
+	var left []softDoc
+	if err := db.Engine().Unscoped().Asc("id").Find(&left); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(left) != 2 || left[0].ID != 6 || left[1].ID != 7 {
+		t.Errorf("Expected rows 6 and 7 to remain, got %+v", left)
+	}
+
+	if n, err := Purge(ctx, new(softDoc), 24*time.Hour, 2); n != 0 || err != nil {
+		t.Errorf("Second purge = %d, %v", n, err)
+	}
+}
+
+func TestPurgeJob(t *testing.T) {
+	db := useTestDB(t, new(softDoc))
+
+	// Row 1 deleted two hours ago, row 2 just now | 第 1 行两小时前删除，第 2 行刚删除
+	for id := int64(1); id <= 2; id++ {
+		if _, err := db.Engine().Insert(&softDoc{ID: id}); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if _, err := db.Engine().ID(id).Delete(new(softDoc)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	}
+	old := &softDoc{SoftDelete: SoftDelete{DeletedAt: time.Now().Add(-2 * time.Hour)}}
+	if _, err := db.Engine().Unscoped().ID(1).Cols("deleted_at").Update(old); err != nil {
+		t.Fatalf("Backdate failed: %v", err)
+	}
+
+	job := PurgeJob("purge_soft_docs", "0 30 3 * * *", time.Hour, new(softDoc))
+	if job.Name != "purge_soft_docs" || job.Spec != "0 30 3 * * *" {
+		t.Errorf("Unexpected job %+v", job)
+	}
+	job.Func()
+
+	var left []softDoc
+	if err := db.Engine().Unscoped().Find(&left); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(left) != 1 || left[0].ID != 2 {
+		t.Errorf("Expected only the row deleted within retention to remain, got %+v", left)
+	}
+}
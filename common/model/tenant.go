@@ -0,0 +1,34 @@
+package model
+
+// TenantScoped is a mixin adding the tenant_id column, embed it with `xorm:"extends"`
+// Sessions from tenant.DB filter such models by the request tenant and fill the column on insert.
+// TenantScoped 是添加 tenant_id 列的混入类型，使用 `xorm:"extends"` 嵌入
+// tenant.DB 返回的会话会按请求租户过滤此类模型，并在插入时填充该列。
+//
+// Example | 示例:
+//
+//	type Invoice struct {
+//	    ID     snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+//	    Amount int64                 `json:"amount" xorm:"'amount'"`
+//	    model.TenantScoped `xorm:"extends"`
+//	}
+type TenantScoped struct {
+	TenantID string `json:"-" xorm:"varchar(64) notnull index 'tenant_id'"` // Owning tenant | 所属租户
+}
+
+// GetTenantID returns the tenant ID | GetTenantID 返回租户 ID
+func (t *TenantScoped) GetTenantID() string {
+	return t.TenantID
+}
+
+// SetTenantID sets the tenant ID | SetTenantID 设置租户 ID
+func (t *TenantScoped) SetTenantID(id string) {
+	t.TenantID = id
+}
+
+// TenantModel is a model owned by a tenant, usually through TenantScoped
+// TenantModel 是归属于租户的模型，通常通过 TenantScoped 实现
+type TenantModel interface {
+	GetTenantID() string
+	SetTenantID(id string)
+}
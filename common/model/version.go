@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/response"
+)
+
+func init() {
+	i18n.Register("en", map[string]string{
+		"model.version_conflict": "The record was modified by someone else, reload it and try again",
+	})
+	i18n.Register("zh", map[string]string{
+		"model.version_conflict": "记录已被他人修改，请刷新后重试",
+	})
+}
+
+// ErrVersionConflict matches, with errors.Is, the error of an update made on a stale version
+// ErrVersionConflict 可通过 errors.Is 匹配基于过期版本进行更新所返回的错误
+var ErrVersionConflict = stderrors.New("version conflict")
+
+// Versioned is a mixin adding an optimistic lock column, embed it with `xorm:"extends"`
+// It uses the xorm "version" tag: Insert sets the version to 1, and every struct Update adds
+// WHERE version = ? with the bean's version and increments it. Send the version read by the client
+// back with the edit and call UpdateWithVersion, which turns a rejected update into a 409.
+// Updates not based on a version the client read must use NoVersionCheck.
+// Versioned 是添加乐观锁列的混入类型，使用 `xorm:"extends"` 嵌入
+// 基于 xorm 的 "version" 标签：Insert 将版本设为 1，每次结构体 Update 都会以 bean 的版本添加
+// WHERE version = ? 并递增版本。将客户端读取到的版本随修改一起提交并调用 UpdateWithVersion，
+// 被拒绝的更新会转换为 409。并非基于客户端读取版本的更新需使用 NoVersionCheck。
+//
+// Example | 示例:
+//
+//	type Article struct {
+//	    ID    snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+//	    Title string                `json:"title" xorm:"'title'"`
+//	    model.Versioned `xorm:"extends"`
+//	}
+type Versioned struct {
+	Version int `json:"version" xorm:"version notnull default(1) 'version'"` // Incremented by every update | 每次更新递增
+}
+
+// GetVersion returns the version | GetVersion 返回版本号
+func (v *Versioned) GetVersion() int {
+	return v.Version
+}
+
+// SetVersion sets the version | SetVersion 设置版本号
+func (v *Versioned) SetVersion(version int) {
+	v.Version = version
+}
+
+// VersionedModel is a model with an optimistic lock column, usually through Versioned
+// VersionedModel 是带乐观锁列的模型，通常通过 Versioned 实现
+type VersionedModel interface {
+	GetVersion() int
+	SetVersion(version int)
+}
+
+// ConflictError describes an update rejected by the optimistic lock
+// ConflictError 描述被乐观锁拒绝的更新
+type ConflictError struct {
+	Table   string // Table name | 表名
+	ID      any    // Primary key | 主键
+	Version int    // Version the update was based on | 更新所基于的版本
+}
+
+// Error implements the error interface
+// Error 实现 error 接口
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %v: version %d is stale", e.Table, e.ID, e.Version)
+}
+
+// Is makes errors.Is(err, ErrVersionConflict) match
+// Is 使 errors.Is(err, ErrVersionConflict) 能够匹配
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// UpdateWithVersion updates the row id of bean when its version still equals bean's version
+// xorm's version check does the update; this helper only tells the two reasons for an update that
+// matched no row apart. It updates the given columns (all non-zero fields when none) and, on success,
+// bean's version. A stale version returns a CodeDuplicate business error (HTTP 409) wrapping a
+// *ConflictError; a missing row returns ErrNotFound.
+// UpdateWithVersion 在行的版本仍等于 bean 的版本时更新 id 对应的行
+// 更新由 xorm 的版本检查完成，本函数仅区分更新未匹配任何行的两种原因。更新指定的列（未指定时为所有
+// 非零字段），成功后同步更新 bean 的版本。版本过期时返回包装 *ConflictError 的 CodeDuplicate
+// 业务错误（HTTP 409）；行不存在时返回 ErrNotFound。
+//
+// Example | 示例:
+//
+//	article.Title = req.Title
+//	article.Version = req.Version // Version the client edited | 客户端编辑所基于的版本
+//	if err := model.UpdateWithVersion(c.UserContext(), article, article.ID, "title"); err != nil {
+//	    return err
+//	}
+func UpdateWithVersion(ctx context.Context, bean VersionedModel, id any, cols ...string) error {
+	db, err := GetDBSafe(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	version := bean.GetVersion()
+
+	s := db.Context(ctx).ID(id)
+	if len(cols) > 0 {
+		s = s.Cols(cols...)
+	}
+	affected, err := s.Update(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	// xorm increments the field even when no row matched | 即使未匹配任何行，xorm 也会递增该字段
+	bean.SetVersion(version)
+
+	exists, err := db.Context(ctx).ID(id).NoAutoCondition().Exist(bean)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if !exists {
+		return errors.ErrNotFound()
+	}
+	be := errors.Localized(response.CodeDuplicate, "model.version_conflict")
+	be.Err = &ConflictError{Table: db.TableName(bean), ID: id, Version: version}
+	return be
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+type versionedDoc struct {
+	ID        int64  `xorm:"pk 'id'"`
+	Title     string `xorm:"'title'"`
+	Versioned `xorm:"extends"`
+}
+
+func (d *versionedDoc) TableName() string { return "versioned_doc" }
+
+func TestUpdateWithVersion(t *testing.T) {
+	db := useTestDB(t, new(versionedDoc))
+	ctx := context.Background()
+
+	doc := &versionedDoc{ID: 1, Title: "draft"}
+	if _, err := db.Engine().Insert(doc); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if doc.Version != 1 {
+		t.Fatalf("Expected version 1 after insert, got %d", doc.Version)
+	}
+
+	// Two clients edit the version they both read | 两个客户端编辑各自读取到的同一版本
+	first := &versionedDoc{ID: 1, Title: "first", Versioned: Versioned{Version: 1}}
+	second := &versionedDoc{ID: 1, Title: "second", Versioned: Versioned{Version: 1}}
+
+	if err := UpdateWithVersion(ctx, first, first.ID, "title"); err != nil {
+		t.Fatalf("First update failed: %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("Expected bean version 2, got %d", first.Version)
+	}
+
+	err := UpdateWithVersion(ctx, second, second.ID, "title")
+	if !stderrors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+	var be *errors.BizError
+	if !stderrors.As(err, &be) || be.Code != response.CodeDuplicate {
+		t.Errorf("Expected CodeDuplicate business error, got %v", err)
+	}
+	var conflict *ConflictError
+	if !stderrors.As(err, &conflict) || conflict.Version != 1 || conflict.Table != "versioned_doc" {
+		t.Errorf("Expected conflict on version 1 of versioned_doc, got %+v", conflict)
+	}
+	if second.Version != 1 {
+		t.Errorf("Expected rejected bean to keep version 1, got %d", second.Version)
+	}
+
+	stored := &versionedDoc{}
+	if has, err := db.Engine().ID(1).Get(stored); err != nil || !has {
+		t.Fatalf("Get failed: %v, %v", has, err)
+	}
+	if stored.Title != "first" || stored.Version != 2 {
+		t.Errorf("Expected first edit at version 2, got %q at %d", stored.Title, stored.Version)
+	}
+
+	// Retrying on the fresh version succeeds | 基于最新版本重试成功
+	second.Version = stored.Version
+	if err := UpdateWithVersion(ctx, second, second.ID, "title"); err != nil || second.Version != 3 {
+		t.Errorf("Retry = %v, version %d", err, second.Version)
+	}
+}
+
+func TestUpdateWithVersionNotFound(t *testing.T) {
+	useTestDB(t, new(versionedDoc))
+
+	err := UpdateWithVersion(context.Background(), &versionedDoc{ID: 9, Title: "x", Versioned: Versioned{Version: 1}}, 9, "title")
+	var be *errors.BizError
+	if !stderrors.As(err, &be) || be.Code != response.CodeNotFound || stderrors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package oauth
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// github implements GitHub login | github 实现 GitHub 登录
+type github struct {
+	name   string
+	config ProviderConfig
+	apiURL string // REST API base, user endpoints are below it | REST API 基地址，用户接口位于其下
+}
+
+func newGitHub(name string, pc ProviderConfig) *github {
+	if pc.AuthURL == "" {
+		pc.AuthURL = "https://github.com/login/oauth/authorize"
+	}
+	if pc.TokenURL == "" {
+		pc.TokenURL = "https://github.com/login/oauth/access_token"
+	}
+	if pc.UserInfoURL == "" {
+		pc.UserInfoURL = "https://api.github.com/user"
+	}
+	if len(pc.Scopes) == 0 {
+		pc.Scopes = []string{"read:user", "user:email"}
+	}
+	return &github{name: name, config: pc}
+}
+
+func (p *github) Name() string {
+	return p.name
+}
+
+func (p *github) AuthCodeURL(_ context.Context, state, challenge string) (string, error) {
+	return authURL(p.config.AuthURL, url.Values{
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}), nil
+}
+
+func (p *github) Exchange(ctx context.Context, code, verifier string) (*Profile, error) {
+	tok, err := exchangeCode(ctx, p.config.TokenURL, p.config, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, p.config.UserInfoURL, tok.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	profile := &Profile{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+		Avatar:   user.AvatarURL,
+	}
+	if profile.Name == "" {
+		profile.Name = user.Login
+	}
+
+	// The public email may be unverified, use the verified primary one | 公开邮箱可能未验证，使用已验证的主邮箱
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.config.UserInfoURL+"/emails", tok.AccessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				profile.Email, profile.EmailVerified = e.Email, true
+				break
+			}
+		}
+	}
+	if profile.Email == "" {
+		profile.Email = user.Email
+	}
+	return profile, nil
+}
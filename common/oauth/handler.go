@@ -0,0 +1,148 @@
+package oauth
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// RegisterRoutes registers the login and account linking endpoints
+// RegisterRoutes 注册登录和账号绑定接口
+//
+//	GET    /providers            configured providers | 已配置的登录方式
+//	GET    /:provider/login      redirect to the provider, ?redirect= receives the result | 跳转到登录方式，?redirect= 接收结果
+//	GET    /:provider/callback   provider callback, issues a token | 登录方式回调，签发令牌
+//	GET    /identities           linked identities (JWT) | 已绑定的身份（JWT）
+//	POST   /:provider/link       authorization URL to link the provider (JWT) | 绑定登录方式的授权地址（JWT）
+//	DELETE /:provider/link       unlink the provider (JWT) | 解除登录方式绑定（JWT）
+func RegisterRoutes(router fiber.Router) {
+	router.Get("/providers", listProviders)
+	router.Get("/:provider/login", login)
+	router.Get("/:provider/callback", callback)
+
+	auth := router.Group("", middleware.RequireAuth(GetConfig().Plat))
+	auth.Get("/identities", listIdentities)
+	auth.Post("/:provider/link", link)
+	auth.Delete("/:provider/link", unlink)
+}
+
+// listProviders returns the configured providers
+// GET /oauth/providers
+func listProviders(c *fiber.Ctx) error {
+	return response.OK(c, Providers())
+}
+
+// login redirects to the provider authorization page
+// GET /oauth/:provider/login?redirect=
+func login(c *fiber.Ctx) error {
+	u, err := Begin(c.UserContext(), c.Params("provider"), c.Query("redirect"), ctxutil.TenantID(c.UserContext()), 0)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(u, fiber.StatusFound)
+}
+
+// link returns the authorization URL linking the provider to the current user
+// The front end navigates to it, as the redirect cannot carry the Authorization header.
+// link 返回将登录方式绑定到当前用户的授权地址
+// 前端需自行跳转，因为重定向无法携带 Authorization 请求头。
+// POST /oauth/:provider/link?redirect=
+func link(c *fiber.Ctx) error {
+	uid, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+	u, err := Begin(c.UserContext(), c.Params("provider"), c.Query("redirect"), ctxutil.TenantID(c.UserContext()), uid)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, fiber.Map{"url": u})
+}
+
+// unlink removes the provider identity of the current user
+// DELETE /oauth/:provider/link
+func unlink(c *fiber.Ctx) error {
+	uid, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+	if err := Unlink(c.UserContext(), uid, c.Params("provider")); err != nil {
+		return err
+	}
+	return response.OK(c, nil)
+}
+
+// listIdentities returns the identities of the current user
+// GET /oauth/identities
+func listIdentities(c *fiber.Ctx) error {
+	uid, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+	list, err := Identities(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, list)
+}
+
+// callback completes a flow
+// Flows started with a redirect get the result in its fragment (token, expires_in, user_id, linked or error),
+// so the token never reaches server logs; other flows get a JSON response.
+// callback 完成流程
+// 带跳转地址的流程通过地址片段返回结果（token、expires_in、user_id、linked 或 error），令牌不会进入服务端日志；其他流程返回 JSON。
+// GET /oauth/:provider/callback?code=&state=
+func callback(c *fiber.Ctx) error {
+	provider := c.Params("provider")
+	res, err := Complete(c.UserContext(), provider, c.Query("state"), c.Query("code"))
+	result := fiber.Map{}
+	if err == nil {
+		if res.Linked {
+			result["linked"] = provider
+		} else {
+			var token string
+			var expiresIn int64
+			if token, expiresIn, err = issueToken(res); err == nil {
+				result["token"], result["expires_in"], result["user_id"] = token, expiresIn, strconv.FormatInt(res.UserID, 10)
+			}
+		}
+	}
+	if res == nil || res.Redirect == "" {
+		if err != nil {
+			return err
+		}
+		return response.OK(c, result)
+	}
+
+	values := url.Values{}
+	if err != nil {
+		values.Set("error", strconv.Itoa(int(errors.GetCode(err))))
+	} else {
+		for k, v := range result {
+			values.Set(k, fmt.Sprint(v))
+		}
+	}
+	return c.Redirect(withFragment(res.Redirect, values), fiber.StatusFound)
+}
+
+// issueToken signs a token for the user of a login flow
+// issueToken 为登录流程的用户签发令牌
+func issueToken(res *Result) (string, int64, error) {
+	mgr := jwt.Get()
+	if mgr == nil {
+		return "", 0, errors.ErrServerError("JWT not initialized")
+	}
+	token, err := mgr.GenerateForTenant(res.UserID, GetConfig().Plat, res.Tenant)
+	if err != nil {
+		return "", 0, errors.Wrap(response.CodeServerError, err)
+	}
+	return token, int64(mgr.Expire().Seconds()), nil
+}
+
+// withFragment sets the fragment of a URL | withFragment 设置 URL 的片段
+func withFragment(raw string, values url.Values) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	return u.String() + "#" + values.Encode()
+}
@@ -0,0 +1,44 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Identity links a provider account to a user
+// Identity 将登录方式账号关联到用户
+type Identity struct {
+	ID        snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	UserID    snowflake.SnowflakeID `json:"user_id" xorm:"notnull index 'user_id' bigint"`                           // User ID | 用户 ID
+	Provider  string                `json:"provider" xorm:"varchar(32) notnull unique(provider_subject) 'provider'"` // Provider name | 登录方式名称
+	Subject   string                `json:"subject" xorm:"varchar(128) notnull unique(provider_subject) 'subject'"`  // User ID at the provider | 用户在登录方式处的 ID
+	Email     string                `json:"email" xorm:"varchar(255) 'email'"`                                       // Email at the provider | 登录方式处的邮箱
+	Name      string                `json:"name" xorm:"varchar(100) 'name'"`                                         // Display name at the provider | 登录方式处的显示名称
+	Avatar    string                `json:"avatar" xorm:"varchar(500) 'avatar'"`                                     // Avatar URL at the provider | 登录方式处的头像地址
+	LastLogin *time.Time            `json:"last_login_at,omitempty" xorm:"'last_login_at'"`                          // Last login through this identity | 最后一次通过该身份登录的时间
+	CreatedAt time.Time             `json:"created_at" xorm:"created 'created_at'"`                                  // Link time | 绑定时间
+	UpdatedAt time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`                                  // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (i *Identity) TableName() string {
+	return "oauth_identity"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (i *Identity) BeforeInsert() {
+	if i.ID.IsZero() {
+		i.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{
+		new(Identity),
+	}
+}
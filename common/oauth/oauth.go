@@ -0,0 +1,394 @@
+// Package oauth provides social login with GitHub, Google, WeChat and generic OpenID Connect providers
+// Each login is protected by a single-use state and a PKCE verifier kept in Redis (or memory).
+// Provider accounts are linked to users through the oauth_identity table, and a successful callback
+// issues a crab JWT for the linked user.
+// oauth 包提供 GitHub、Google、微信和通用 OpenID Connect 社交登录
+// 每次登录使用保存在 Redis（或内存）中的一次性 state 和 PKCE 校验码保护。
+// 登录方式账号通过 oauth_identity 表关联到用户，回调成功后为关联用户签发 crab JWT。
+//
+// Without a resolver only linked identities can log in. A module owning the user table sets one to
+// find or create the user of a new identity and to reject disabled users.
+// 未设置解析器时只有已绑定的身份可以登录。拥有用户表的模块可设置解析器，为新身份查找或创建用户并拒绝已禁用用户。
+//
+// Usage | 用法:
+//
+//	[oauth]
+//	path = "/api/oauth"
+//	redirects = ["https://app.example.com/"]
+//	[oauth.providers.github]
+//	client_id = "..."
+//	client_secret = "..."
+//	redirect_url = "https://api.example.com/api/oauth/github/callback"
+//
+//	oauth.SetResolver(func(ctx context.Context, p *oauth.Profile, userID int64) (int64, error) {
+//	    ...
+//	})
+package oauth
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/json"
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Config represents the social login configuration
+// Config 表示社交登录配置
+type Config struct {
+	Path      string                    `toml:"path"`      // Route prefix, empty disables the endpoints | 路由前缀，为空时禁用接口
+	Plat      string                    `toml:"plat"`      // JWT platform of issued tokens, default "user" | 签发令牌的 JWT 平台，默认 "user"
+	StateTTL  time.Duration             `toml:"state_ttl"` // Time allowed to complete a login, default 10m | 完成登录的时限，默认 10m
+	Redirects []string                  `toml:"redirects"` // Allowed front-end redirect URL prefixes | 允许的前端跳转地址前缀
+	Providers map[string]ProviderConfig `toml:"providers"` // Providers by name | 按名称配置的登录方式
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Plat == "" {
+		c.Plat = "user"
+	}
+	if c.StateTTL <= 0 {
+		c.StateTTL = 10 * time.Minute
+	}
+	return c
+}
+
+// Resolver returns the user to log in for a profile
+// userID is the linked user, 0 for an identity seen for the first time. Return an error to reject the login.
+// Resolver 返回资料对应的登录用户
+// userID 为已绑定的用户，首次出现的身份为 0。返回错误以拒绝登录。
+type Resolver func(ctx context.Context, p *Profile, userID int64) (int64, error)
+
+// Result is the outcome of a completed flow
+// Result 是完成流程的结果
+type Result struct {
+	Profile  *Profile // Provider profile | 登录方式资料
+	UserID   int64    // Logged in or linking user | 登录或绑定的用户
+	Linked   bool     // The flow linked an identity instead of logging in | 该流程为绑定身份而非登录
+	Redirect string   // Front-end URL of the flow | 流程的前端跳转地址
+	Tenant   string   // Tenant of the flow | 流程所属租户
+}
+
+var (
+	mu        sync.RWMutex
+	config    = Config{}.withDefaults()
+	store     = NewMemoryStateStore()
+	providers = make(map[string]Provider)
+	resolver  Resolver
+)
+
+// Init creates the configured providers, a nil store keeps states in memory
+// Invalid providers are skipped and reported in the returned error.
+// Init 创建已配置的登录方式，store 为 nil 时状态保存在内存中
+// 无效的登录方式会被跳过，并在返回的错误中报告。
+func Init(cfg Config, s StateStore) error {
+	if s == nil {
+		s = NewMemoryStateStore()
+	}
+	built := make(map[string]Provider, len(cfg.Providers))
+	var errs []error
+	for name, pc := range cfg.Providers {
+		p, err := newProvider(name, pc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		built[name] = p
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	config = cfg.withDefaults()
+	store = s
+	providers = built
+	return stderrors.Join(errs...)
+}
+
+// GetConfig returns the effective configuration
+// GetConfig 返回生效的配置
+func GetConfig() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return config
+}
+
+// Register adds or replaces a provider, for providers not covered by the built-in types
+// Register 添加或替换登录方式，用于内置类型未覆盖的登录方式
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Providers returns the names of the registered providers
+// Providers 返回已注册登录方式的名称
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetResolver sets the resolver of logins
+// SetResolver 设置登录解析器
+func SetResolver(r Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	resolver = r
+}
+
+// lookup returns a provider, CodeOAuthProviderUnknown when it is not registered
+// lookup 返回登录方式，未注册时返回 CodeOAuthProviderUnknown
+func lookup(name string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, errors.FromCode(response.CodeOAuthProviderUnknown)
+	}
+	return p, nil
+}
+
+// AllowedRedirect checks that redirect starts with a configured prefix, an empty redirect is allowed
+// AllowedRedirect 检查 redirect 是否以配置的前缀开头，空地址允许
+func AllowedRedirect(redirect string) bool {
+	if redirect == "" {
+		return true
+	}
+	for _, prefix := range GetConfig().Redirects {
+		if prefix != "" && strings.HasPrefix(redirect, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Begin starts a flow and returns the provider authorization URL
+// linkUser links the identity to that user instead of logging in, 0 for login.
+// Begin 开始流程并返回登录方式的授权地址
+// linkUser 表示将身份绑定到该用户而非登录，登录时为 0。
+func Begin(ctx context.Context, provider, redirect, tenant string, linkUser int64) (string, error) {
+	p, err := lookup(provider)
+	if err != nil {
+		return "", err
+	}
+	if !AllowedRedirect(redirect) {
+		return "", errors.ErrInvalidField("redirect")
+	}
+
+	st := flowState{Provider: provider, Verifier: randomToken(32), Redirect: redirect, Tenant: tenant, LinkUser: linkUser}
+	state := randomToken(24)
+	u, err := p.AuthCodeURL(ctx, state, pkceChallenge(st.Verifier))
+	if err != nil {
+		return "", errors.Wrap(response.CodeOAuthFailed, err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return "", errors.Wrap(response.CodeServerError, err)
+	}
+	mu.RLock()
+	s, ttl := store, config.StateTTL
+	mu.RUnlock()
+	if err := s.Set(ctx, state, string(data), ttl); err != nil {
+		return "", errors.Wrap(response.CodeRedisError, err)
+	}
+	return u, nil
+}
+
+// takeState consumes a state of provider, CodeOAuthStateInvalid when it is unknown, used or expired
+// takeState 消费 provider 的状态，状态不存在、已使用或已过期时返回 CodeOAuthStateInvalid
+func takeState(ctx context.Context, provider, state string) (*flowState, error) {
+	mu.RLock()
+	s := store
+	mu.RUnlock()
+	data, err := s.Take(ctx, state)
+	if err != nil {
+		if stderrors.Is(err, errStateNotFound) {
+			return nil, errors.FromCode(response.CodeOAuthStateInvalid)
+		}
+		return nil, errors.Wrap(response.CodeRedisError, err)
+	}
+	var st flowState
+	if err := json.Unmarshal([]byte(data), &st); err != nil || st.Provider != provider {
+		return nil, errors.FromCode(response.CodeOAuthStateInvalid)
+	}
+	return &st, nil
+}
+
+// Complete finishes a flow with the code of the callback
+// A login flow returns the user resolved for the identity, a link flow links the identity to its user.
+// The returned result carries the flow redirect even when err is not nil, once the state was valid.
+// Complete 使用回调中的授权码完成流程
+// 登录流程返回身份解析出的用户，绑定流程将身份绑定到发起用户。
+// 状态有效时，即使 err 不为 nil，返回的结果也携带流程的跳转地址。
+func Complete(ctx context.Context, provider, state, code string) (*Result, error) {
+	p, err := lookup(provider)
+	if err != nil {
+		return nil, err
+	}
+	st, err := takeState(ctx, provider, state)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Redirect: st.Redirect, Tenant: st.Tenant}
+	if code == "" {
+		return res, errors.FromCode(response.CodeOAuthDenied)
+	}
+
+	profile, err := p.Exchange(ctx, code, st.Verifier)
+	if err != nil {
+		return res, errors.Wrap(response.CodeOAuthFailed, err)
+	}
+	res.Profile = profile
+
+	if st.LinkUser != 0 {
+		res.UserID, res.Linked = st.LinkUser, true
+		return res, Link(ctx, st.LinkUser, profile)
+	}
+	res.UserID, err = resolveUser(ctx, profile)
+	return res, err
+}
+
+// resolveUser resolves the user of a profile and records the identity
+// resolveUser 解析资料对应的用户并记录身份
+func resolveUser(ctx context.Context, p *Profile) (int64, error) {
+	db, err := model.GetDBSafe(&Identity{})
+	if err != nil {
+		return 0, errors.ErrDBError(err)
+	}
+	ident, err := findIdentity(ctx, p.Provider, p.Subject)
+	if err != nil {
+		return 0, err
+	}
+	var userID int64
+	if ident != nil {
+		userID = ident.UserID.Int64()
+	}
+
+	mu.RLock()
+	r := resolver
+	mu.RUnlock()
+	if r != nil {
+		if userID, err = r(ctx, p, userID); err != nil {
+			return 0, err
+		}
+	}
+	if userID == 0 {
+		return 0, errors.FromCode(response.CodeOAuthNotLinked)
+	}
+
+	now := time.Now()
+	if ident == nil {
+		ident = newIdentity(userID, p)
+		ident.LastLogin = &now
+		if _, err := db.Context(ctx).Insert(ident); err != nil {
+			return 0, errors.ErrDBError(err)
+		}
+		return userID, nil
+	}
+	ident.Email, ident.Name, ident.Avatar, ident.LastLogin = p.Email, p.Name, p.Avatar, &now
+	if _, err := db.Context(ctx).ID(ident.ID).Cols("email", "name", "avatar", "last_login_at").Update(ident); err != nil {
+		return 0, errors.ErrDBError(err)
+	}
+	return userID, nil
+}
+
+// Link links a provider identity to userID, CodeOAuthLinkedOther when it belongs to another user
+// Link 将登录方式身份绑定到 userID，已属于其他用户时返回 CodeOAuthLinkedOther
+func Link(ctx context.Context, userID int64, p *Profile) error {
+	ident, err := findIdentity(ctx, p.Provider, p.Subject)
+	if err != nil {
+		return err
+	}
+	if ident != nil {
+		if ident.UserID.Int64() != userID {
+			return errors.FromCode(response.CodeOAuthLinkedOther)
+		}
+		return nil
+	}
+	db, err := model.GetDBSafe(&Identity{})
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(ctx).Insert(newIdentity(userID, p)); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return nil
+}
+
+// Unlink removes the identity of provider from userID
+// Unlink 解除 userID 在 provider 上的身份绑定
+func Unlink(ctx context.Context, userID int64, provider string) error {
+	db, err := model.GetDBSafe(&Identity{})
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	n, err := db.Context(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&Identity{})
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if n == 0 {
+		return errors.FromCode(response.CodeNotFound)
+	}
+	return nil
+}
+
+// Identities returns the identities linked to userID
+// Identities 返回绑定到 userID 的身份
+func Identities(ctx context.Context, userID int64) ([]Identity, error) {
+	db, err := model.GetDBSafe(&Identity{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	var list []Identity
+	if err := db.Context(ctx).Where("user_id = ?", userID).Asc("provider").Find(&list); err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return list, nil
+}
+
+// findIdentity returns the identity of a provider subject, nil when there is none
+// findIdentity 返回登录方式主体对应的身份，不存在时返回 nil
+func findIdentity(ctx context.Context, provider, subject string) (*Identity, error) {
+	if subject == "" {
+		return nil, errors.Wrap(response.CodeOAuthFailed, fmt.Errorf("oauth: %s returned no subject", provider))
+	}
+	db, err := model.GetDBSafe(&Identity{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	ident := &Identity{}
+	has, err := db.Context(ctx).Where("provider = ? AND subject = ?", provider, subject).Get(ident)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	if !has {
+		return nil, nil
+	}
+	return ident, nil
+}
+
+// newIdentity builds the identity of a profile | newIdentity 根据资料构建身份
+func newIdentity(userID int64, p *Profile) *Identity {
+	return &Identity{
+		UserID:   snowflake.SnowflakeID(userID),
+		Provider: p.Provider,
+		Subject:  p.Subject,
+		Email:    p.Email,
+		Name:     p.Name,
+		Avatar:   p.Avatar,
+	}
+}
@@ -0,0 +1,224 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/redis/go-redis/v9"
+)
+
+// newServer serves JSON bodies by path and records the token request form
+// newServer 按路径返回 JSON 响应并记录令牌请求的表单
+func newServer(t *testing.T, routes map[string]string, form *url.Values) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if form != nil && r.Method == http.MethodPost {
+			_ = r.ParseForm()
+			*form = r.PostForm
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(strings.ReplaceAll(body, "{{base}}", "http://"+r.Host)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHub(t *testing.T) {
+	var form url.Values
+	srv := newServer(t, map[string]string{
+		"/token":       `{"access_token":"gho_1","token_type":"bearer"}`,
+		"/user":        `{"id":42,"login":"octocat","email":"public@example.com","avatar_url":"https://a/1.png"}`,
+		"/user/emails": `[{"email":"other@example.com","primary":false,"verified":true},{"email":"octo@example.com","primary":true,"verified":true}]`,
+	}, &form)
+	p, err := newProvider("github", ProviderConfig{ClientID: "id", ClientSecret: "secret", RedirectURL: "https://api/cb",
+		AuthURL: srv.URL + "/authorize", TokenURL: srv.URL + "/token", UserInfoURL: srv.URL + "/user"})
+	if err != nil {
+		t.Fatalf("newProvider failed: %v", err)
+	}
+
+	u, _ := p.AuthCodeURL(context.Background(), "st", "ch")
+	q, _ := url.Parse(u)
+	if q.Query().Get("state") != "st" || q.Query().Get("code_challenge") != "ch" || q.Query().Get("scope") != "read:user user:email" {
+		t.Errorf("Unexpected auth URL %s", u)
+	}
+
+	profile, err := p.Exchange(context.Background(), "code1", "verifier1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if form.Get("code") != "code1" || form.Get("code_verifier") != "verifier1" || form.Get("client_secret") != "secret" {
+		t.Errorf("Unexpected token request %v", form)
+	}
+	want := Profile{Provider: "github", Subject: "42", Email: "octo@example.com", EmailVerified: true, Name: "octocat", Avatar: "https://a/1.png"}
+	if *profile != want {
+		t.Errorf("Expected %+v, got %+v", want, *profile)
+	}
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	srv := newServer(t, map[string]string{
+		"/.well-known/openid-configuration": `{"authorization_endpoint":"{{base}}/auth","token_endpoint":"{{base}}/token","userinfo_endpoint":"{{base}}/userinfo"}`,
+		"/token":                            `{"access_token":"at","id_token":"x.y.z"}`,
+		"/userinfo":                         `{"sub":"u-1","email":"a@example.com","email_verified":"true","name":"Alice"}`,
+	}, nil)
+	p, err := newProvider("sso", ProviderConfig{Type: TypeOIDC, Issuer: srv.URL + "/", ClientID: "id"})
+	if err != nil {
+		t.Fatalf("newProvider failed: %v", err)
+	}
+
+	u, err := p.AuthCodeURL(context.Background(), "st", "ch")
+	if err != nil || !strings.HasPrefix(u, srv.URL+"/auth?") || !strings.Contains(u, "scope=openid+email+profile") {
+		t.Fatalf("Unexpected auth URL %s %v", u, err)
+	}
+	profile, err := p.Exchange(context.Background(), "code", "verifier")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if profile.Subject != "u-1" || !profile.EmailVerified || profile.Name != "Alice" {
+		t.Errorf("Unexpected profile %+v", profile)
+	}
+}
+
+func TestWeChat(t *testing.T) {
+	srv := newServer(t, map[string]string{
+		"/token":    `{"access_token":"at","openid":"o-1","unionid":"un-1"}`,
+		"/userinfo": `{"openid":"o-1","nickname":"小明","headimgurl":"https://wx/1.png"}`,
+	}, nil)
+	p, _ := newProvider("wechat", ProviderConfig{ClientID: "wx1", TokenURL: srv.URL + "/token", UserInfoURL: srv.URL + "/userinfo"})
+
+	u, _ := p.AuthCodeURL(context.Background(), "st", "ch")
+	if !strings.Contains(u, "appid=wx1") || !strings.HasSuffix(u, "#wechat_redirect") || strings.Contains(u, "code_challenge") {
+		t.Errorf("Unexpected auth URL %s", u)
+	}
+	profile, err := p.Exchange(context.Background(), "code", "")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if profile.Subject != "un-1" || profile.Name != "小明" || profile.Email != "" {
+		t.Errorf("Unexpected profile %+v", profile)
+	}
+
+	failing := newServer(t, map[string]string{"/token": `{"errcode":40029,"errmsg":"invalid code"}`}, nil)
+	p, _ = newProvider("wechat", ProviderConfig{ClientID: "wx1", TokenURL: failing.URL + "/token"})
+	if _, err := p.Exchange(context.Background(), "bad", ""); err == nil || !strings.Contains(err.Error(), "40029") {
+		t.Errorf("Expected WeChat error, got %v", err)
+	}
+}
+
+func TestNewProviderErrors(t *testing.T) {
+	tests := map[string]ProviderConfig{
+		"no client id": {Type: TypeGitHub},
+		"unknown type": {Type: "myspace", ClientID: "id"},
+		"oidc":         {Type: TypeOIDC, ClientID: "id"},
+	}
+	for name, pc := range tests {
+		if _, err := newProvider(name, pc); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// stubProvider returns a fixed profile | stubProvider 返回固定资料
+type stubProvider struct{ verifier string }
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) AuthCodeURL(_ context.Context, state, challenge string) (string, error) {
+	return "https://idp/auth?" + url.Values{"state": {state}, "challenge": {challenge}}.Encode(), nil
+}
+
+func (p *stubProvider) Exchange(_ context.Context, _, verifier string) (*Profile, error) {
+	p.verifier = verifier
+	return &Profile{Provider: "stub", Subject: "1"}, nil
+}
+
+func TestFlowState(t *testing.T) {
+	if err := Init(Config{Redirects: []string{"https://app.example.com/"}}, nil); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	stub := &stubProvider{}
+	Register(stub)
+	ctx := context.Background()
+
+	if _, err := Begin(ctx, "none", "", "", 0); errors.GetCode(err) != response.CodeOAuthProviderUnknown {
+		t.Errorf("Expected unknown provider, got %v", err)
+	}
+	if _, err := Begin(ctx, "stub", "https://evil.example.com/", "", 0); err == nil {
+		t.Error("Redirect outside the allowed prefixes should be rejected")
+	}
+
+	raw, err := Begin(ctx, "stub", "https://app.example.com/login", "t1", 0)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	state := u.Query().Get("state")
+
+	if _, err := takeState(ctx, "other", state); errors.GetCode(err) != response.CodeOAuthStateInvalid {
+		t.Errorf("State of another provider should be rejected, got %v", err)
+	}
+	raw, _ = Begin(ctx, "stub", "https://app.example.com/login", "t1", 0)
+	u, _ = url.Parse(raw)
+	state = u.Query().Get("state")
+
+	// A denied authorization has no code, the state is still consumed | 拒绝授权时没有授权码，状态仍被消费
+	res, err := Complete(ctx, "stub", state, "")
+	if errors.GetCode(err) != response.CodeOAuthDenied || res.Redirect != "https://app.example.com/login" || res.Tenant != "t1" {
+		t.Errorf("Expected denied with the flow redirect, got %+v %v", res, err)
+	}
+	if _, err := Complete(ctx, "stub", state, "code"); errors.GetCode(err) != response.CodeOAuthStateInvalid {
+		t.Errorf("State should be single-use, got %v", err)
+	}
+
+	// The verifier of the state matches the challenge sent to the provider | 状态中的校验码与发给登录方式的挑战值匹配
+	raw, _ = Begin(ctx, "stub", "", "", 0)
+	u, _ = url.Parse(raw)
+	_, _ = Complete(ctx, "stub", u.Query().Get("state"), "code")
+	if stub.verifier == "" || pkceChallenge(stub.verifier) != u.Query().Get("challenge") {
+		t.Errorf("Verifier %q does not match challenge %s", stub.verifier, u.Query().Get("challenge"))
+	}
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// RFC 7636 appendix B | RFC 7636 附录 B
+	if got := pkceChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("Unexpected challenge %s", got)
+	}
+}
+
+func TestRedisStateStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisStateStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "s1", "v", time.Minute)
+	if ttl := mr.TTL("oauth:state:s1"); ttl <= 0 {
+		t.Errorf("Expected TTL, got %v", ttl)
+	}
+	if v, err := store.Take(ctx, "s1"); v != "v" || err != nil {
+		t.Errorf("Expected v, got %q %v", v, err)
+	}
+	if _, err := store.Take(ctx, "s1"); err != errStateNotFound {
+		t.Errorf("Expected errStateNotFound, got %v", err)
+	}
+}
+
+func TestWithFragment(t *testing.T) {
+	got := withFragment("https://app.example.com/cb?x=1#old", url.Values{"token": {"abc"}})
+	if got != "https://app.example.com/cb?x=1#token=abc" {
+		t.Errorf("Unexpected URL %s", got)
+	}
+}
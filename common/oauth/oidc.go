@@ -0,0 +1,116 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// oidc implements OpenID Connect login, used for Google and generic issuers
+// Endpoints come from the issuer's discovery document, fetched on first use.
+// oidc 实现 OpenID Connect 登录，用于 Google 和通用签发者
+// 端点来自签发者的发现文档，首次使用时获取。
+type oidc struct {
+	name   string
+	config ProviderConfig
+
+	mu         sync.Mutex
+	discovered bool
+}
+
+func newOIDC(name string, pc ProviderConfig) *oidc {
+	if len(pc.Scopes) == 0 {
+		pc.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &oidc{name: name, config: pc}
+}
+
+func (p *oidc) Name() string {
+	return p.name
+}
+
+// endpoints returns the configuration with discovered endpoints filled in
+// A failed discovery is retried on the next call.
+// endpoints 返回填充了发现端点的配置
+// 发现失败时在下次调用时重试。
+func (p *oidc) endpoints(ctx context.Context) (ProviderConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered || p.config.Issuer == "" {
+		return p.config, nil
+	}
+	var doc struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	u := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, u, "", &doc); err != nil {
+		return p.config, fmt.Errorf("oauth: discover %s: %w", p.config.Issuer, err)
+	}
+	// Configured endpoints win over discovered ones | 配置的端点优先于发现的端点
+	if p.config.AuthURL == "" {
+		p.config.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.config.TokenURL == "" {
+		p.config.TokenURL = doc.TokenEndpoint
+	}
+	if p.config.UserInfoURL == "" {
+		p.config.UserInfoURL = doc.UserInfoEndpoint
+	}
+	p.discovered = true
+	return p.config, nil
+}
+
+func (p *oidc) AuthCodeURL(ctx context.Context, state, challenge string) (string, error) {
+	pc, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	return authURL(pc.AuthURL, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {pc.ClientID},
+		"redirect_uri":          {pc.RedirectURL},
+		"scope":                 {strings.Join(pc.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}), nil
+}
+
+// Exchange reads the profile from the userinfo endpoint, which is served over TLS by the issuer
+// so the ID token signature does not need to be checked.
+// Exchange 从 userinfo 端点读取资料，该端点由签发者通过 TLS 提供，因此无需校验 ID 令牌签名。
+func (p *oidc) Exchange(ctx context.Context, code, verifier string) (*Profile, error) {
+	pc, err := p.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := exchangeCode(ctx, pc.TokenURL, pc, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"` // Some issuers send a string | 部分签发者返回字符串
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := getJSON(ctx, pc.UserInfoURL, tok.AccessToken, &info); err != nil {
+		return nil, err
+	}
+	if info.Sub == "" {
+		return nil, fmt.Errorf("oauth: %s userinfo: missing sub", p.name)
+	}
+	return &Profile{
+		Provider:      p.name,
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified == true || info.EmailVerified == "true",
+		Name:          info.Name,
+		Avatar:        info.Picture,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/httpclient"
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// Provider types | 登录方式类型
+const (
+	TypeGitHub = "github"
+	TypeGoogle = "google"
+	TypeWeChat = "wechat"
+	TypeOIDC   = "oidc"
+)
+
+// ProviderConfig represents the configuration of a login provider
+// ProviderConfig 表示登录方式的配置
+type ProviderConfig struct {
+	Type         string   `toml:"type"`          // github, google, wechat or oidc, defaults to the provider name | github、google、wechat 或 oidc，默认为登录方式名称
+	ClientID     string   `toml:"client_id"`     // Client ID (AppID for WeChat) | 客户端 ID（微信为 AppID）
+	ClientSecret string   `toml:"client_secret"` // Client secret (AppSecret for WeChat) | 客户端密钥（微信为 AppSecret）
+	RedirectURL  string   `toml:"redirect_url"`  // Callback URL registered at the provider | 在登录方式处登记的回调地址
+	Scopes       []string `toml:"scopes"`        // Scopes, empty uses the provider defaults | 授权范围，为空时使用默认值
+	Issuer       string   `toml:"issuer"`        // OIDC issuer, its discovery document supplies the endpoints | OIDC 签发者，端点从其发现文档获取
+	AuthURL      string   `toml:"auth_url"`      // Overrides the authorization endpoint | 覆盖授权端点
+	TokenURL     string   `toml:"token_url"`     // Overrides the token endpoint | 覆盖令牌端点
+	UserInfoURL  string   `toml:"userinfo_url"`  // Overrides the user info endpoint | 覆盖用户信息端点
+}
+
+// Profile is the identity returned by a provider
+// Profile 是登录方式返回的身份信息
+type Profile struct {
+	Provider      string `json:"provider"`       // Provider name | 登录方式名称
+	Subject       string `json:"subject"`        // Stable user ID at the provider | 用户在登录方式处的稳定 ID
+	Email         string `json:"email"`          // Email, may be empty | 邮箱，可能为空
+	EmailVerified bool   `json:"email_verified"` // Whether the provider verified Email | 登录方式是否已验证邮箱
+	Name          string `json:"name"`           // Display name | 显示名称
+	Avatar        string `json:"avatar"`         // Avatar URL | 头像地址
+}
+
+// Provider authenticates users with an authorization code flow
+// Provider 使用授权码流程认证用户
+type Provider interface {
+	// Name returns the configured provider name | Name 返回配置的登录方式名称
+	Name() string
+	// AuthCodeURL returns the authorization URL, challenge is the S256 PKCE challenge
+	// AuthCodeURL 返回授权地址，challenge 为 S256 PKCE 挑战值
+	AuthCodeURL(ctx context.Context, state, challenge string) (string, error)
+	// Exchange redeems the code and returns the profile of the user
+	// Exchange 兑换授权码并返回用户资料
+	Exchange(ctx context.Context, code, verifier string) (*Profile, error)
+}
+
+// newProvider creates the provider of a configuration | newProvider 根据配置创建登录方式
+func newProvider(name string, pc ProviderConfig) (Provider, error) {
+	if pc.ClientID == "" {
+		return nil, fmt.Errorf("oauth: provider %s: client_id is required", name)
+	}
+	typ := pc.Type
+	if typ == "" {
+		typ = name
+	}
+	switch typ {
+	case TypeGitHub:
+		return newGitHub(name, pc), nil
+	case TypeGoogle:
+		if pc.Issuer == "" {
+			pc.Issuer = "https://accounts.google.com"
+		}
+		return newOIDC(name, pc), nil
+	case TypeOIDC:
+		if pc.Issuer == "" && (pc.AuthURL == "" || pc.TokenURL == "" || pc.UserInfoURL == "") {
+			return nil, fmt.Errorf("oauth: provider %s: issuer or endpoints are required", name)
+		}
+		return newOIDC(name, pc), nil
+	case TypeWeChat:
+		return newWeChat(name, pc), nil
+	}
+	return nil, fmt.Errorf("oauth: provider %s: unknown type %q", name, typ)
+}
+
+// tokenResponse is the token endpoint response | tokenResponse 是令牌端点的响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode redeems an authorization code at a standard OAuth2 token endpoint
+// exchangeCode 在标准 OAuth2 令牌端点兑换授权码
+func exchangeCode(ctx context.Context, tokenURL string, pc ProviderConfig, code, verifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {pc.RedirectURL},
+		"client_id":     {pc.ClientID},
+		"client_secret": {pc.ClientSecret},
+	}
+	if verifier != "" {
+		form.Set("code_verifier", verifier)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var tok tokenResponse
+	if err := doJSON(req, &tok); err != nil {
+		return nil, err
+	}
+	if tok.Error != "" {
+		return nil, fmt.Errorf("oauth: token: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("oauth: token: no access token")
+	}
+	return &tok, nil
+}
+
+// getJSON requests url with a bearer token and decodes the JSON response
+// getJSON 携带 bearer 令牌请求 url 并解码 JSON 响应
+func getJSON(ctx context.Context, url, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, v)
+}
+
+// doJSON sends req and decodes a 2xx JSON response | doJSON 发送 req 并解码 2xx JSON 响应
+func doJSON(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpclient.Get().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("oauth: %s %s: status %d", req.Method, req.URL.Host, resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// authURL appends query parameters to an endpoint | authURL 为端点追加查询参数
+func authURL(endpoint string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + params.Encode()
+}
@@ -0,0 +1,122 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// errStateNotFound is returned for unknown, used or expired states | errStateNotFound 表示状态不存在、已使用或已过期
+var errStateNotFound = errors.New("oauth: state not found")
+
+// flowState is kept between the login redirect and the callback
+// flowState 在登录跳转和回调之间保存
+type flowState struct {
+	Provider string `json:"provider"`            // Provider name | 登录方式名称
+	Verifier string `json:"verifier"`            // PKCE code verifier | PKCE 校验码
+	Redirect string `json:"redirect,omitempty"`  // Front-end URL receiving the result | 接收结果的前端地址
+	Tenant   string `json:"tenant,omitempty"`    // Tenant of the login request | 登录请求所属租户
+	LinkUser int64  `json:"link_user,omitempty"` // User linking the identity, 0 for login | 绑定身份的用户，登录时为 0
+}
+
+// StateStore keeps flow states, each state can be taken once
+// StateStore 保存流程状态，每个状态只能取出一次
+type StateStore interface {
+	// Set stores value for ttl | Set 保存 value，有效期为 ttl
+	Set(ctx context.Context, state, value string, ttl time.Duration) error
+	// Take returns and deletes the value | Take 返回并删除 value
+	Take(ctx context.Context, state string) (string, error)
+}
+
+// memoryStateStore keeps states in process memory, for single instance deployments and tests
+// memoryStateStore 将状态保存在进程内存中，适用于单实例部署和测试
+type memoryStateStore struct {
+	mu     sync.Mutex
+	values map[string]memoryState
+}
+
+type memoryState struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewMemoryStateStore creates an in-memory state store
+// NewMemoryStateStore 创建内存状态存储
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{values: make(map[string]memoryState)}
+}
+
+func (s *memoryStateStore) Set(_ context.Context, state, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// Expired states are dropped on write, no cleanup goroutine is needed | 写入时清理过期状态，无需清理协程
+	for k, v := range s.values {
+		if now.After(v.expiresAt) {
+			delete(s.values, k)
+		}
+	}
+	s.values[state] = memoryState{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryStateStore) Take(_ context.Context, state string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[state]
+	if !ok {
+		return "", errStateNotFound
+	}
+	delete(s.values, state)
+	if time.Now().After(v.expiresAt) {
+		return "", errStateNotFound
+	}
+	return v.value, nil
+}
+
+// redisStateStore keeps states in Redis so the callback can reach any instance
+// redisStateStore 将状态保存在 Redis 中，回调可以到达任意实例
+type redisStateStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStateStore creates a Redis state store, prefix defaults to "oauth:state:"
+// NewRedisStateStore 创建 Redis 状态存储，prefix 默认为 "oauth:state:"
+func NewRedisStateStore(client redis.UniversalClient, prefix string) StateStore {
+	if prefix == "" {
+		prefix = "oauth:state:"
+	}
+	return &redisStateStore{rdb: client, prefix: prefix}
+}
+
+func (s *redisStateStore) Set(ctx context.Context, state, value string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.prefix+state, value, ttl).Err()
+}
+
+func (s *redisStateStore) Take(ctx context.Context, state string) (string, error) {
+	v, err := s.rdb.GetDel(ctx, s.prefix+state).Result()
+	if err == redis.Nil {
+		return "", errStateNotFound
+	}
+	return v, err
+}
+
+// randomToken returns n random bytes encoded as base64url | randomToken 返回 base64url 编码的 n 个随机字节
+func randomToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// pkceChallenge returns the S256 challenge of a verifier | pkceChallenge 返回校验码的 S256 挑战值
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
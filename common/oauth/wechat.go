@@ -0,0 +1,118 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// wechat implements WeChat website login (QR code), which does not support PKCE
+// The subject is the UnionID when the app is bound to an open platform account, otherwise the OpenID.
+// wechat 实现微信网站应用登录（扫码），不支持 PKCE
+// 应用绑定开放平台账号时主体为 UnionID，否则为 OpenID。
+type wechat struct {
+	name   string
+	config ProviderConfig
+}
+
+func newWeChat(name string, pc ProviderConfig) *wechat {
+	if pc.AuthURL == "" {
+		pc.AuthURL = "https://open.weixin.qq.com/connect/qrconnect"
+	}
+	if pc.TokenURL == "" {
+		pc.TokenURL = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	}
+	if pc.UserInfoURL == "" {
+		pc.UserInfoURL = "https://api.weixin.qq.com/sns/userinfo"
+	}
+	if len(pc.Scopes) == 0 {
+		pc.Scopes = []string{"snsapi_login"}
+	}
+	return &wechat{name: name, config: pc}
+}
+
+func (p *wechat) Name() string {
+	return p.name
+}
+
+func (p *wechat) AuthCodeURL(_ context.Context, state, _ string) (string, error) {
+	return authURL(p.config.AuthURL, url.Values{
+		"appid":         {p.config.ClientID},
+		"redirect_uri":  {p.config.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(p.config.Scopes, ",")},
+		"state":         {state},
+	}) + "#wechat_redirect", nil
+}
+
+// wechatError is embedded in WeChat responses | wechatError 嵌入在微信响应中
+type wechatError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e wechatError) err() error {
+	if e.ErrCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("oauth: wechat: %d %s", e.ErrCode, e.ErrMsg)
+}
+
+func (p *wechat) Exchange(ctx context.Context, code, _ string) (*Profile, error) {
+	var tok struct {
+		wechatError
+		AccessToken string `json:"access_token"`
+		OpenID      string `json:"openid"`
+		UnionID     string `json:"unionid"`
+	}
+	err := p.get(ctx, p.config.TokenURL, url.Values{
+		"appid":      {p.config.ClientID},
+		"secret":     {p.config.ClientSecret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	}, &tok)
+	if err != nil {
+		return nil, err
+	}
+	if err := tok.err(); err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		wechatError
+		OpenID     string `json:"openid"`
+		UnionID    string `json:"unionid"`
+		Nickname   string `json:"nickname"`
+		HeadImgURL string `json:"headimgurl"`
+	}
+	err = p.get(ctx, p.config.UserInfoURL, url.Values{
+		"access_token": {tok.AccessToken},
+		"openid":       {tok.OpenID},
+	}, &info)
+	if err != nil {
+		return nil, err
+	}
+	if err := info.err(); err != nil {
+		return nil, err
+	}
+
+	subject := info.UnionID
+	if subject == "" {
+		subject = tok.UnionID
+	}
+	if subject == "" {
+		subject = tok.OpenID
+	}
+	return &Profile{Provider: p.name, Subject: subject, Name: info.Nickname, Avatar: info.HeadImgURL}, nil
+}
+
+// get calls a WeChat API, which takes its parameters in the query | get 调用微信接口，参数通过查询字符串传递
+func (p *wechat) get(ctx context.Context, endpoint string, params url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL(endpoint, params), nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(req, v)
+}
@@ -0,0 +1,174 @@
+package org
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/snowflake"
+	"github.com/nuohe369/crab/pkg/transaction"
+	"github.com/nuohe369/crab/pkg/util"
+	"xorm.io/xorm"
+)
+
+// DefaultInviteTTL is how long an invitation is valid when CreateInvite gets no ttl
+// DefaultInviteTTL 是 CreateInvite 未指定 ttl 时邀请的有效期
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+// CreateInvite creates a single-use invitation to join orgID with role, valid for ttl
+// Checking that the inviter may invite is left to the caller, e.g. Require(RoleAdmin).
+// CreateInvite 创建加入 orgID 的一次性邀请，授予 role 角色，有效期为 ttl
+// 邀请人是否有权邀请由调用方检查，例如使用 Require(RoleAdmin)。
+func CreateInvite(ctx context.Context, orgID, inviterID int64, role string, ttl time.Duration) (*Invite, error) {
+	if role == "" {
+		role = RoleMember
+	}
+	if role == RoleOwner {
+		return nil, errors.FromCode(response.CodeOrgCannotInviteOwner)
+	}
+	if rank(role) == 0 {
+		return nil, errors.ErrParamInvalid()
+	}
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+
+	o, err := Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !o.IsActive() {
+		return nil, errors.FromCode(response.CodeOrgDisabled)
+	}
+
+	db, err := model.GetDBSafe(&Invite{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	inv := &Invite{
+		OrgID:     o.ID,
+		Code:      util.RandomString(24),
+		Role:      role,
+		InviterID: snowflake.SnowflakeID(inviterID),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if _, err := db.Context(ctx).Insert(inv); err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return inv, nil
+}
+
+// AcceptInvite adds userID to the organization of the invitation code and returns the membership
+// Joins of one organization are serialized on its row, so the member limit holds under concurrency.
+// AcceptInvite 将 userID 加入邀请码对应的组织并返回成员关系
+// 同一组织的加入操作在组织行上串行执行，因此并发时成员上限依然有效。
+func AcceptInvite(ctx context.Context, code string, userID int64) (*Member, error) {
+	db, err := model.GetDBSafe(&Invite{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+
+	var member *Member
+	err = transaction.WithTransaction(db, func(s *xorm.Session) error {
+		inv := &Invite{}
+		has, err := s.Context(ctx).Where("code = ?", code).Get(inv)
+		if err != nil {
+			return errors.ErrDBError(err)
+		}
+		if !has {
+			return errors.FromCode(response.CodeOrgInviteInvalid)
+		}
+		if !inv.UsedBy.IsZero() {
+			return errors.FromCode(response.CodeOrgInviteUsed)
+		}
+		if time.Now().After(inv.ExpiresAt) {
+			return errors.FromCode(response.CodeOrgInviteExpired)
+		}
+
+		o := &Organization{}
+		has, err = s.Context(ctx).ID(inv.OrgID).ForUpdate().Get(o)
+		if err != nil {
+			return errors.ErrDBError(err)
+		}
+		if !has {
+			return errors.FromCode(response.CodeOrgNotFound)
+		}
+		if !o.IsActive() {
+			return errors.FromCode(response.CodeOrgDisabled)
+		}
+
+		exists, err := s.Context(ctx).Where("org_id = ? AND user_id = ?", o.ID, userID).Exist(&Member{})
+		if err != nil {
+			return errors.ErrDBError(err)
+		}
+		if exists {
+			return errors.FromCode(response.CodeOrgAlreadyMember)
+		}
+		if o.MemberLimit > 0 {
+			n, err := s.Context(ctx).Where("org_id = ?", o.ID).Count(&Member{})
+			if err != nil {
+				return errors.ErrDBError(err)
+			}
+			if n >= int64(o.MemberLimit) {
+				return errors.FromCode(response.CodeOrgMemberLimit)
+			}
+		}
+
+		// Claim the invitation, a concurrent acceptance leaves nothing to update
+		// 占用邀请，并发接受时不会再有可更新的行
+		now := time.Now()
+		affected, err := s.Context(ctx).ID(inv.ID).Where("used_by = 0").Cols("used_by", "used_at").
+			Update(&Invite{UsedBy: snowflake.SnowflakeID(userID), UsedAt: &now})
+		if err != nil {
+			return errors.ErrDBError(err)
+		}
+		if affected == 0 {
+			return errors.FromCode(response.CodeOrgInviteUsed)
+		}
+
+		member = &Member{OrgID: o.ID, UserID: snowflake.SnowflakeID(userID), Role: inv.Role}
+		if _, err := s.Context(ctx).Insert(member); err != nil {
+			return errors.ErrDBError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// Invites returns the unused, unexpired invitations of an organization
+// Invites 返回组织中未使用且未过期的邀请
+func Invites(ctx context.Context, orgID int64) ([]Invite, error) {
+	db, err := model.GetDBSafe(&Invite{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	var invites []Invite
+	err = db.Context(ctx).Where("org_id = ? AND used_by = 0 AND expires_at > ?", orgID, time.Now()).
+		Desc("created_at").Find(&invites)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return invites, nil
+}
+
+// RevokeInvite deletes an unused invitation of orgID
+// RevokeInvite 删除 orgID 中未使用的邀请
+func RevokeInvite(ctx context.Context, orgID, inviteID int64) error {
+	db, err := model.GetDBSafe(&Invite{})
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	affected, err := db.Context(ctx).ID(inviteID).Where("org_id = ? AND used_by = 0", orgID).Delete(&Invite{})
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if affected == 0 {
+		return errors.FromCode(response.CodeOrgInviteInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package org
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// Member roles, ordered owner > admin > member
+// 成员角色，权限顺序为 owner > admin > member
+const (
+	RoleOwner  = "owner"  // Created the organization, cannot leave or be removed | 组织创建者，不能退出或被移除
+	RoleAdmin  = "admin"  // Manages members and invitations | 管理成员和邀请
+	RoleMember = "member" // Regular member | 普通成员
+)
+
+// Organization status | 组织状态
+const (
+	StatusDisabled = 0 // Disabled | 已禁用
+	StatusActive   = 1 // Active | 正常
+)
+
+// rank orders the roles, unknown roles rank lowest
+// rank 为角色排序，未知角色最低
+func rank(role string) int {
+	switch role {
+	case RoleOwner:
+		return 3
+	case RoleAdmin:
+		return 2
+	case RoleMember:
+		return 1
+	}
+	return 0
+}
+
+// Organization represents an organization
+// Organization 表示一个组织
+type Organization struct {
+	ID          snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	Code        string                `json:"code" xorm:"varchar(64) notnull unique 'code'"`   // Unique code, e.g. "acme" | 唯一编码，如 "acme"
+	Name        string                `json:"name" xorm:"varchar(100) notnull 'name'"`         // Display name | 显示名称
+	OwnerID     snowflake.SnowflakeID `json:"owner_id" xorm:"notnull index 'owner_id' bigint"` // Owner user ID | 所有者用户 ID
+	MemberLimit int                   `json:"member_limit" xorm:"default(0) 'member_limit'"`   // Maximum members, 0 means unlimited | 最大成员数，0 表示不限
+	Status      int                   `json:"status" xorm:"default(1) 'status'"`               // Status: 1=active, 0=disabled | 状态: 1=正常, 0=禁用
+	CreatedAt   time.Time             `json:"created_at" xorm:"created 'created_at'"`          // Creation time | 创建时间
+	UpdatedAt   time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`          // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (o *Organization) TableName() string {
+	return "org_organization"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (o *Organization) BeforeInsert() {
+	if o.ID.IsZero() {
+		o.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// IsActive checks if the organization is active
+// IsActive 检查组织是否正常
+func (o *Organization) IsActive() bool {
+	return o.Status == StatusActive
+}
+
+// Member binds a user to an organization with a role
+// Member 以角色将用户绑定到组织
+type Member struct {
+	ID        snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	OrgID     snowflake.SnowflakeID `json:"org_id" xorm:"notnull unique(org_user) 'org_id' bigint"`         // Organization ID | 组织 ID
+	UserID    snowflake.SnowflakeID `json:"user_id" xorm:"notnull unique(org_user) index 'user_id' bigint"` // User ID | 用户 ID
+	Role      string                `json:"role" xorm:"varchar(16) notnull 'role'"`                         // owner, admin or member | owner、admin 或 member
+	CreatedAt time.Time             `json:"created_at" xorm:"created 'created_at'"`                         // Join time | 加入时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (m *Member) TableName() string {
+	return "org_member"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (m *Member) BeforeInsert() {
+	if m.ID.IsZero() {
+		m.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// HasRole checks if the member's role is at least role
+// HasRole 检查成员角色是否不低于 role
+func (m *Member) HasRole(role string) bool {
+	return rank(m.Role) >= rank(role)
+}
+
+// Invite is a single-use invitation to join an organization
+// Invite 是加入组织的一次性邀请
+type Invite struct {
+	ID        snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	OrgID     snowflake.SnowflakeID `json:"org_id" xorm:"notnull index 'org_id' bigint"`        // Organization ID | 组织 ID
+	Code      string                `json:"code" xorm:"varchar(32) notnull unique 'code'"`      // Invitation code | 邀请码
+	Role      string                `json:"role" xorm:"varchar(16) notnull 'role'"`             // Role granted on acceptance | 接受后授予的角色
+	InviterID snowflake.SnowflakeID `json:"inviter_id" xorm:"notnull 'inviter_id' bigint"`      // Inviting user ID | 邀请人用户 ID
+	ExpiresAt time.Time             `json:"expires_at" xorm:"notnull 'expires_at'"`             // Expiration time | 过期时间
+	UsedBy    snowflake.SnowflakeID `json:"used_by" xorm:"notnull default(0) 'used_by' bigint"` // Accepting user ID, 0 while unused | 接受邀请的用户 ID，未使用时为 0
+	UsedAt    *time.Time            `json:"used_at,omitempty" xorm:"'used_at'"`                 // Acceptance time | 接受时间
+	CreatedAt time.Time             `json:"created_at" xorm:"created 'created_at'"`             // Creation time | 创建时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (i *Invite) TableName() string {
+	return "org_invite"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (i *Invite) BeforeInsert() {
+	if i.ID.IsZero() {
+		i.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{
+		new(Organization),
+		new(Member),
+		new(Invite),
+	}
+}
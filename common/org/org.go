@@ -0,0 +1,179 @@
+// Package org provides organizations, memberships and invitations shared by modules
+// org 包提供供各模块复用的组织、成员和邀请功能
+//
+// Errors are business errors with the response.CodeOrg* codes, so handlers return them as is.
+// 错误均为带 response.CodeOrg* 错误码的业务错误，处理器可直接返回。
+//
+// Usage | 用法:
+//
+//	g := router.Group("/org", org.Require(org.RoleMember))
+//	g.Post("/invites", org.Require(org.RoleAdmin), func(c *fiber.Ctx) error {
+//	    m := org.CurrentMember(c)
+//	    inv, err := org.CreateInvite(c.UserContext(), m.OrgID.Int64(), m.UserID.Int64(), org.RoleMember, 0)
+//	    ...
+//	})
+package org
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/snowflake"
+	"github.com/nuohe369/crab/pkg/transaction"
+	"xorm.io/xorm"
+)
+
+// Create creates an organization owned by ownerID, who becomes its first member
+// Create 创建由 ownerID 拥有的组织，所有者成为第一个成员
+func Create(ctx context.Context, ownerID int64, code, name string) (*Organization, error) {
+	db, err := model.GetDBSafe(&Organization{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	exists, err := db.Context(ctx).Where("code = ?", code).Exist(&Organization{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	if exists {
+		return nil, errors.FromCode(response.CodeOrgCodeExists)
+	}
+
+	o := &Organization{Code: code, Name: name, OwnerID: snowflake.SnowflakeID(ownerID), Status: StatusActive}
+	err = transaction.WithTransaction(db, func(s *xorm.Session) error {
+		if _, err := s.Context(ctx).Insert(o); err != nil {
+			return err
+		}
+		_, err := s.Context(ctx).Insert(&Member{OrgID: o.ID, UserID: o.OwnerID, Role: RoleOwner})
+		return err
+	})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return o, nil
+}
+
+// Get returns an organization, CodeOrgNotFound when it does not exist
+// Get 返回组织，不存在时返回 CodeOrgNotFound
+func Get(ctx context.Context, orgID int64) (*Organization, error) {
+	db, err := model.GetDBSafe(&Organization{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	o := &Organization{}
+	has, err := db.Context(ctx).ID(orgID).Get(o)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	if !has {
+		return nil, errors.FromCode(response.CodeOrgNotFound)
+	}
+	return o, nil
+}
+
+// GetMember returns the membership of userID in orgID, CodeOrgNotMember when there is none
+// GetMember 返回 userID 在 orgID 中的成员关系，不存在时返回 CodeOrgNotMember
+func GetMember(ctx context.Context, orgID, userID int64) (*Member, error) {
+	m, err := findMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.FromCode(response.CodeOrgNotMember)
+	}
+	return m, nil
+}
+
+// Members returns the members of an organization, oldest first
+// Members 返回组织成员，按加入时间升序
+func Members(ctx context.Context, orgID int64) ([]Member, error) {
+	db, err := model.GetDBSafe(&Member{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	var members []Member
+	if err := db.Context(ctx).Where("org_id = ?", orgID).Asc("created_at", "id").Find(&members); err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return members, nil
+}
+
+// Organizations returns the organizations userID belongs to
+// Organizations 返回 userID 所属的组织
+func Organizations(ctx context.Context, userID int64) ([]Organization, error) {
+	db, err := model.GetDBSafe(&Organization{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	var orgs []Organization
+	err = db.Context(ctx).Table("org_organization").Alias("o").
+		Join("INNER", []string{"org_member", "m"}, "m.org_id = o.id").
+		Where("m.user_id = ?", userID).
+		Select("o.*").
+		Asc("o.created_at").
+		Find(&orgs)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	return orgs, nil
+}
+
+// RemoveMember removes userID from orgID, the owner cannot be removed
+// RemoveMember 将 userID 移出 orgID，所有者不能被移除
+func RemoveMember(ctx context.Context, orgID, userID int64) error {
+	m, err := findMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return errors.FromCode(response.CodeOrgTargetNotMember)
+	}
+	if m.Role == RoleOwner {
+		return errors.FromCode(response.CodeOrgCannotRemoveOwner)
+	}
+	return deleteMember(ctx, m)
+}
+
+// Leave removes userID from orgID at their own request, the owner cannot leave
+// Leave 应 userID 本人请求将其移出 orgID，所有者不能退出
+func Leave(ctx context.Context, orgID, userID int64) error {
+	m, err := GetMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m.Role == RoleOwner {
+		return errors.FromCode(response.CodeOrgOwnerCannotLeave)
+	}
+	return deleteMember(ctx, m)
+}
+
+// findMember returns the membership of userID in orgID, nil when there is none
+// findMember 返回 userID 在 orgID 中的成员关系，不存在时返回 nil
+func findMember(ctx context.Context, orgID, userID int64) (*Member, error) {
+	db, err := model.GetDBSafe(&Member{})
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	m := &Member{}
+	has, err := db.Context(ctx).Where("org_id = ? AND user_id = ?", orgID, userID).Get(m)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	if !has {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// deleteMember deletes a membership row | deleteMember 删除成员关系记录
+func deleteMember(ctx context.Context, m *Member) error {
+	db, err := model.GetDBSafe(m)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(ctx).ID(m.ID).Delete(&Member{}); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return nil
+}
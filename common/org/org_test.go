@@ -0,0 +1,80 @@
+package org
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleOwner, RoleAdmin, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleMember, RoleAdmin, false},
+		{RoleMember, RoleMember, true},
+		{"guest", RoleMember, false},
+	}
+	for _, tt := range tests {
+		if got := (&Member{Role: tt.role}).HasRole(tt.required); got != tt.want {
+			t.Errorf("%s has %s: expected %v, got %v", tt.role, tt.required, tt.want, got)
+		}
+	}
+}
+
+// newApp mounts Require(role) behind a stub authentication that optionally presets a membership,
+// errors are answered with their business code
+func newApp(userID int64, preset *Member, role string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(fiber.StatusBadRequest).SendString(strconv.Itoa(int(errors.GetCode(err))))
+	}})
+	app.Use(func(c *fiber.Ctx) error {
+		if userID != 0 {
+			c.Locals(ctxutil.LocalsUserID, userID)
+		}
+		if preset != nil {
+			c.Locals(LocalsMember, preset)
+		}
+		return c.Next()
+	})
+	app.Get("/orgs/:org_id/x", Require(role), func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/x", Require(role), func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, path string) string {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestRequire(t *testing.T) {
+	if got := get(t, newApp(0, nil, RoleMember), "/x"); got != "1001" {
+		t.Errorf("Expected unauthenticated, got %s", got)
+	}
+	if got := get(t, newApp(1, nil, RoleMember), "/x"); got != "4113" {
+		t.Errorf("Expected organization not selected, got %s", got)
+	}
+	if got := get(t, newApp(1, nil, RoleMember), "/orgs/abc/x"); got != "4113" {
+		t.Errorf("Expected a malformed ID to select nothing, got %s", got)
+	}
+
+	admin := &Member{OrgID: 7, UserID: 1, Role: RoleAdmin}
+	if got := get(t, newApp(1, admin, RoleAdmin), "/orgs/7/x"); got != "ok" {
+		t.Errorf("Expected the preset membership to pass, got %s", got)
+	}
+	if got := get(t, newApp(1, admin, RoleOwner), "/orgs/7/x"); got != "4102" {
+		t.Errorf("Expected permission denied, got %s", got)
+	}
+}
@@ -58,12 +58,13 @@ type UpdateArticleReq struct {
 	Title      string `json:"title"`       // Article title | 文章标题
 	Content    string `json:"content"`     // Article content | 文章内容
 	Status     *int   `json:"status"`      // Status | 状态
+	Version    *int   `json:"version"`     // Version being edited, enables the conflict check | 编辑所基于的版本，启用冲突检查
 }
 
 // ListArticleReq represents the list articles request
 // ListArticleReq 文章列表请求
 type ListArticleReq struct {
-	PageReq           // Pagination | 分页
+	SearchReq         // Pagination and keyword search | 分页和关键词搜索
 	UserID     string `json:"user_id" query:"user_id"`         // User ID filter | 用户ID筛选
 	CategoryID string `json:"category_id" query:"category_id"` // Category ID filter | 分类ID筛选
 	Status     *int   `json:"status" query:"status"`           // Status filter | 状态筛选
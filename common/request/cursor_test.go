@@ -0,0 +1,48 @@
+package request
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	tests := []Cursor{
+		{ID: 42},
+		{ID: 42, Sort: now},
+		{ID: 42, Sort: int64(7)},
+		{ID: 42, Sort: "name|with|pipes"},
+	}
+	for _, tt := range tests {
+		got, err := DecodeCursor(tt.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor(%v): %v", tt, err)
+		}
+		if got.ID != tt.ID {
+			t.Errorf("ID = %d, want %d", got.ID, tt.ID)
+		}
+		if ts, ok := tt.Sort.(time.Time); ok {
+			if gt, _ := got.Sort.(time.Time); !gt.Equal(ts) {
+				t.Errorf("Sort = %v, want %v", got.Sort, ts)
+			}
+		} else if got.Sort != tt.Sort {
+			t.Errorf("Sort = %v, want %v", got.Sort, tt.Sort)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, s := range []string{"!!!", EncodeCursor(1, nil) + "x", "YWJj"} {
+		if _, err := DecodeCursor(s); err == nil {
+			t.Errorf("DecodeCursor(%q) expected error", s)
+		}
+	}
+
+	req := CursorReq{}
+	if cur, err := req.Decode(); cur != nil || err != nil {
+		t.Errorf("empty cursor = %v, %v", cur, err)
+	}
+	if req.GetSize() != 10 {
+		t.Errorf("default size = %d", req.GetSize())
+	}
+}
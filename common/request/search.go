@@ -0,0 +1,32 @@
+package request
+
+import (
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/search"
+)
+
+// SearchReq represents the parameters of a list endpoint with full-text search
+// SearchReq 支持全文搜索的列表接口参数
+type SearchReq struct {
+	PageReq        // Pagination | 分页
+	Keyword string `json:"keyword" query:"keyword"` // Search text, searched through pkg/search when set | 搜索文本，设置时通过 pkg/search 搜索
+	Sort    string `json:"sort" query:"sort"`       // Comma separated "field:desc", rank order if empty | 逗号分隔的 "field:desc"，为空时按相关度排序
+}
+
+// Query returns the search query of the request with filters
+// Query 返回带有 filters 的请求搜索查询
+func (r *SearchReq) Query(filters map[string]any) *search.Query {
+	q := &search.Query{
+		Text:    strings.TrimSpace(r.Keyword),
+		Filters: filters,
+		Page:    r.GetPage(),
+		Size:    r.GetSize(),
+	}
+	for _, s := range strings.Split(r.Sort, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			q.Sort = append(q.Sort, s)
+		}
+	}
+	return q
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSearchReqQuery(t *testing.T) {
+	var req ListArticleReq
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error { return c.QueryParser(&req) })
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?page=2&size=5&keyword=%20go%20&sort=created_at:desc,%20view_count&status=1", nil)); err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+
+	q := req.Query(map[string]any{"status": *req.Status})
+	if q.Text != "go" || q.Page != 2 || q.Size != 5 || q.Filters["status"] != 1 {
+		t.Errorf("Unexpected query %+v", q)
+	}
+	if want := []string{"created_at:desc", "view_count"}; !reflect.DeepEqual(q.Sort, want) {
+		t.Errorf("Sort = %v, want %v", q.Sort, want)
+	}
+}
@@ -0,0 +1,125 @@
+package response
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/pkg/json"
+	"github.com/nuohe369/crab/pkg/trace"
+)
+
+// Envelope builds the JSON body written for a code, message and data.
+// Install a custom one with SetEnvelope to rename fields or add extra ones.
+type Envelope func(c *fiber.Ctx, code Code, msg string, data any) any
+
+// envelope is the active envelope, nil means the default Response structure.
+var envelope Envelope
+
+// SetEnvelope overrides the JSON structure of every enveloped response,
+// including errors rendered by the error handler. Call it once at startup,
+// before the server starts. Passing nil restores the default Response.
+//
+// Usage:
+//
+//	response.SetEnvelope(response.NewEnvelope(response.EnvelopeConfig{
+//		CodeField: "status", MsgField: "message", TraceID: true, ServerTime: true,
+//	}))
+func SetEnvelope(fn Envelope) {
+	envelope = fn
+}
+
+// localsCode is the Fiber Locals key holding the code of the written envelope.
+const localsCode = "response_code"
+
+// responsePool reuses the default envelopes, which only live while being encoded.
+var responsePool = sync.Pool{New: func() any { return new(Response) }}
+
+// Write renders code, msg and data through the active envelope.
+// The HTTP status is left untouched, set it with c.Status before calling.
+func Write(c *fiber.Ctx, code Code, msg string, data any) error {
+	c.Locals(localsCode, code)
+	if envelope != nil {
+		return writeJSON(c, envelope(c, code, msg, data))
+	}
+	r := responsePool.Get().(*Response)
+	r.Code, r.Msg, r.Data = code, msg, data
+	err := writeJSON(c, r)
+	*r = Response{} // Don't keep data alive in the pool
+	responsePool.Put(r)
+	return err
+}
+
+// writeJSON encodes v with pkg/json, the encoder boot installs into Fiber, into pooled
+// scratch space and copies it into the response body, saving the per-request encode buffer.
+func writeJSON(c *fiber.Ctx, v any) error {
+	buf := json.GetBuffer()
+	defer json.PutBuffer(buf)
+
+	var err error
+	if *buf, err = json.MarshalAppend(*buf, v); err != nil {
+		return err
+	}
+	c.Response().SetBody(*buf)
+	c.Response().Header.SetContentType(fiber.MIMEApplicationJSON)
+	return nil
+}
+
+// WrittenCode returns the code of the envelope written for the request, false if
+// the response was not written through Write (e.g. a file or raw body).
+// Business failures are usually sent with HTTP 200, so middleware that inspects
+// the outcome of a request should check the code as well as the status.
+func WrittenCode(c *fiber.Ctx) (Code, bool) {
+	code, ok := c.Locals(localsCode).(Code)
+	return code, ok
+}
+
+// EnvelopeConfig configures the envelope built by NewEnvelope.
+type EnvelopeConfig struct {
+	CodeField  string // Name of the code field (default "code")
+	MsgField   string // Name of the message field (default "msg")
+	DataField  string // Name of the data field (default "data")
+	TraceID    bool   // Add "trace_id" when the request is traced
+	RequestID  bool   // Add "request_id" set by middleware.RequestContext
+	ServerTime bool   // Add "server_time" as Unix milliseconds
+}
+
+// NewEnvelope returns an Envelope with configurable field names and extra fields.
+// Data is omitted when nil, like the default Response.
+func NewEnvelope(cfg EnvelopeConfig) Envelope {
+	if cfg.CodeField == "" {
+		cfg.CodeField = "code"
+	}
+	if cfg.MsgField == "" {
+		cfg.MsgField = "msg"
+	}
+	if cfg.DataField == "" {
+		cfg.DataField = "data"
+	}
+
+	return func(c *fiber.Ctx, code Code, msg string, data any) any {
+		body := fiber.Map{
+			cfg.CodeField: code,
+			cfg.MsgField:  msg,
+		}
+		if data != nil {
+			body[cfg.DataField] = data
+		}
+		ctx := c.UserContext()
+		if cfg.TraceID {
+			if id := trace.TraceID(ctx); id != "" {
+				body["trace_id"] = id
+			}
+		}
+		if cfg.RequestID {
+			if id := ctxutil.RequestID(ctx); id != "" {
+				body["request_id"] = id
+			}
+		}
+		if cfg.ServerTime {
+			body["server_time"] = time.Now().UnixMilli()
+		}
+		return body
+	}
+}
@@ -0,0 +1,77 @@
+package response
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/i18n"
+)
+
+// Built-in English and Chinese messages, override or extend them with locale files.
+func init() {
+	en := make(map[string]string, len(codeMsg)+1)
+	for code, msg := range codeMsg {
+		en[code.Key()] = msg
+	}
+	en["code.unknown"] = "Unknown error"
+	i18n.Register("en", en)
+
+	i18n.Register("zh", map[string]string{
+		CodeSuccess.Key():              "成功",
+		CodeError.Key():                "错误",
+		CodeUnauth.Key():               "未认证",
+		CodeTokenExpired.Key():         "令牌已过期",
+		CodeTokenInvalid.Key():         "令牌无效",
+		CodeForbid.Key():               "禁止访问",
+		CodeParamError.Key():           "参数错误",
+		CodeParamMissing.Key():         "缺少参数",
+		CodeParamInvalid.Key():         "参数无效",
+		CodeNotFound.Key():             "资源不存在",
+		CodeDuplicate.Key():            "资源重复",
+		CodeUserNotFound.Key():         "用户不存在",
+		CodePasswordWrong.Key():        "密码错误",
+		CodeUserDisabled.Key():         "用户已禁用",
+		CodeUserExists.Key():           "用户已存在",
+		CodeBizError.Key():             "业务错误",
+		CodeAuthError.Key():            "认证错误",
+		CodeServerError.Key():          "服务器错误",
+		CodeDBError.Key():              "数据库错误",
+		CodeRedisError.Key():           "Redis 错误",
+		CodeTooManyRequests.Key():      "请求过于频繁",
+		CodeServiceUnavailable.Key():   "服务维护中，请稍后再试",
+		CodeOrgCodeExists.Key():        "组织编码已存在",
+		CodeOrgNotFound.Key():          "组织不存在",
+		CodeOrgPermissionDenied.Key():  "无权限",
+		CodeOrgMemberFull.Key():        "组织成员已满",
+		CodeOrgMemberExists.Key():      "用户已是成员",
+		CodeOrgInviteExpired.Key():     "邀请已过期",
+		CodeOrgInviteUsed.Key():        "邀请已被使用",
+		CodeOrgInviteInvalid.Key():     "邀请码无效",
+		CodeOrgCannotRemoveOwner.Key(): "不能移除所有者",
+		CodeOrgOwnerCannotLeave.Key():  "所有者不能退出",
+		CodeOrgTargetNotMember.Key():   "目标用户不是成员",
+		CodeOrgCannotInviteOwner.Key(): "不能邀请为所有者",
+		CodeOrgDisabled.Key():          "组织已禁用",
+		CodeOrgNotSelected.Key():       "未选择组织",
+		CodeOrgAlreadyMember.Key():     "用户已是成员",
+		CodeOrgMemberLimit.Key():       "成员数量已达上限",
+		CodeOrgNotMember.Key():         "不是组织成员",
+		CodeOrgUserNotFound.Key():      "用户不存在",
+		CodeOAuthProviderUnknown.Key(): "未知的登录方式",
+		CodeOAuthStateInvalid.Key():    "登录请求无效或已过期，请重试",
+		CodeOAuthFailed.Key():          "第三方登录失败",
+		CodeOAuthDenied.Key():          "用户拒绝授权",
+		CodeOAuthNotLinked.Key():       "账号未绑定，请先登录后绑定",
+		CodeOAuthLinkedOther.Key():     "该账号已绑定其他用户",
+		CodeQuotaExceeded.Key():        "已超出配额，请升级套餐或稍后再试",
+		"code.unknown":                 "未知错误",
+	})
+}
+
+// Lang returns the language negotiated for the request.
+// It uses the value set by middleware.RequestContext and falls back to Accept-Language.
+func Lang(c *fiber.Ctx) string {
+	if lang, ok := c.Locals(ctxutil.LocalsLang).(string); ok && lang != "" {
+		return lang
+	}
+	return i18n.Match(c.Get(fiber.HeaderAcceptLanguage))
+}
@@ -0,0 +1,137 @@
+package response
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Codes below ModuleCodeMin are reserved for common. Modules reserve their own
+// range with ReserveCodes and register codes in it, so they never edit this package.
+//
+// Usage:
+//
+//	var codes = response.ReserveCodes("order", 10000, 10999)
+//
+//	var (
+//		CodeOrderNotFound = codes.Register(10001, "Order not found", fiber.StatusNotFound)
+//		CodeOrderPaid     = codes.Register(10002, "Order already paid", fiber.StatusConflict)
+//	)
+const ModuleCodeMin Code = 10000
+
+// CodeInfo describes a registered code.
+type CodeInfo struct {
+	Code       Code
+	Msg        string
+	HTTPStatus int    // 0 means the error handler's default mapping
+	Namespace  string // Owning namespace, "common" for built-in codes
+}
+
+// CodeSpace is a reserved range of codes owned by a namespace.
+type CodeSpace struct {
+	Namespace string
+	Min, Max  Code
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Code]CodeInfo{}
+	spaces     []CodeSpace
+)
+
+// ReserveCodes reserves [min, max] for namespace.
+// It panics when the range is below ModuleCodeMin or overlaps another namespace,
+// so collisions surface at startup rather than in responses.
+func ReserveCodes(namespace string, min, max Code) *CodeSpace {
+	if min < ModuleCodeMin || max < min {
+		panic(fmt.Sprintf("response: invalid code range %d-%d for %q, module codes start at %d", min, max, namespace, ModuleCodeMin))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, s := range spaces {
+		if min <= s.Max && s.Min <= max {
+			panic(fmt.Sprintf("response: code range %d-%d for %q overlaps %q (%d-%d)", min, max, namespace, s.Namespace, s.Min, s.Max))
+		}
+	}
+	space := CodeSpace{Namespace: namespace, Min: min, Max: max}
+	spaces = append(spaces, space)
+	return &space
+}
+
+// Register registers a code in the namespace's range and returns it.
+// It panics when the code is outside the range or already registered.
+func (s *CodeSpace) Register(code Code, msg string, httpStatus int) Code {
+	if code < s.Min || code > s.Max {
+		panic(fmt.Sprintf("response: code %d is outside the range %d-%d of %q", code, s.Min, s.Max, s.Namespace))
+	}
+	register(CodeInfo{Code: code, Msg: msg, HTTPStatus: httpStatus, Namespace: s.Namespace})
+	return code
+}
+
+// RegisterCode registers a code outside any namespace and returns it.
+// Prefer ReserveCodes in modules. It panics when the code is already
+// registered, built in, or inside a reserved range.
+func RegisterCode(code Code, msg string, httpStatus int) Code {
+	registryMu.RLock()
+	for _, s := range spaces {
+		if code >= s.Min && code <= s.Max {
+			registryMu.RUnlock()
+			panic(fmt.Sprintf("response: code %d belongs to namespace %q, register it through its CodeSpace", code, s.Namespace))
+		}
+	}
+	registryMu.RUnlock()
+
+	register(CodeInfo{Code: code, Msg: msg, HTTPStatus: httpStatus})
+	return code
+}
+
+// register adds info to the registry, panicking on duplicates.
+func register(info CodeInfo) {
+	if _, ok := codeMsg[info.Code]; ok {
+		panic(fmt.Sprintf("response: code %d is a built-in code", info.Code))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if existing, ok := registry[info.Code]; ok {
+		panic(fmt.Sprintf("response: duplicate code %d (%q), already registered by %q as %q",
+			info.Code, info.Msg, existing.Namespace, existing.Msg))
+	}
+	registry[info.Code] = info
+}
+
+// Lookup returns the registered information of a code, including built-in codes.
+func Lookup(code Code) (CodeInfo, bool) {
+	if msg, ok := codeMsg[code]; ok {
+		return CodeInfo{Code: code, Msg: msg, Namespace: "common"}, true
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, ok := registry[code]
+	return info, ok
+}
+
+// HTTPStatus returns the HTTP status registered for a code.
+// ok is false for built-in codes and codes registered without a status.
+func HTTPStatus(code Code) (status int, ok bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, found := registry[code]
+	return info.HTTPStatus, found && info.HTTPStatus != 0
+}
+
+// Codes returns all known codes sorted by value, e.g. for generating API docs.
+func Codes() []CodeInfo {
+	registryMu.RLock()
+	list := make([]CodeInfo, 0, len(codeMsg)+len(registry))
+	for _, info := range registry {
+		list = append(list, info)
+	}
+	registryMu.RUnlock()
+	for code, msg := range codeMsg {
+		list = append(list, CodeInfo{Code: code, Msg: msg, Namespace: "common"})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
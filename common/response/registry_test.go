@@ -0,0 +1,47 @@
+package response
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: expected panic", name)
+		}
+	}()
+	fn()
+}
+
+func TestReserveCodes(t *testing.T) {
+	codes := ReserveCodes("order", 10000, 10999)
+	code := codes.Register(10001, "Order not found", fiber.StatusNotFound)
+
+	if code.Msg() != "Order not found" {
+		t.Errorf("Msg() = %s", code.Msg())
+	}
+	if status, ok := HTTPStatus(code); !ok || status != fiber.StatusNotFound {
+		t.Errorf("HTTPStatus() = %d, %v", status, ok)
+	}
+	if info, ok := Lookup(code); !ok || info.Namespace != "order" {
+		t.Errorf("Lookup() = %+v, %v", info, ok)
+	}
+
+	expectPanic(t, "duplicate", func() { codes.Register(10001, "Again", 0) })
+	expectPanic(t, "out of range", func() { codes.Register(11000, "Outside", 0) })
+	expectPanic(t, "overlap", func() { ReserveCodes("payment", 10500, 11500) })
+	expectPanic(t, "common range", func() { ReserveCodes("bad", 4000, 4999) })
+	expectPanic(t, "namespaced code", func() { RegisterCode(10002, "Sneaky", 0) })
+}
+
+func TestRegisterCode(t *testing.T) {
+	code := RegisterCode(90001, "Custom", 0)
+	if _, ok := HTTPStatus(code); ok {
+		t.Errorf("HTTPStatus() should not be set")
+	}
+	expectPanic(t, "built-in", func() { RegisterCode(CodeNotFound, "Again", 0) })
+	expectPanic(t, "duplicate", func() { RegisterCode(90001, "Again", 0) })
+}
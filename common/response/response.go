@@ -1,6 +1,12 @@
 package response
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/pkg/mask"
+)
 
 // Code represents a response code.
 type Code int
@@ -42,10 +48,7 @@ const (
 	CodeAuthError     Code = 4005 // authentication error
 )
 
-// Organization related codes (4100-4199)
-// Reserved for future organization module implementation
-// Uncomment when organization module is added
-/*
+// Organization related codes (4100-4199), used by common/org
 const (
 	CodeOrgCodeExists        Code = 4100 // organization code already exists
 	CodeOrgNotFound          Code = 4101 // organization not found
@@ -66,71 +69,107 @@ const (
 	CodeOrgNotMember         Code = 4116 // not an organization member
 	CodeOrgUserNotFound      Code = 4117 // user not found
 )
-*/
+
+// OAuth related codes (4200-4299), used by common/oauth
+const (
+	CodeOAuthProviderUnknown Code = 4200 // unknown login provider
+	CodeOAuthStateInvalid    Code = 4201 // login state invalid or expired
+	CodeOAuthFailed          Code = 4202 // provider exchange failed
+	CodeOAuthDenied          Code = 4203 // authorization denied by the user
+	CodeOAuthNotLinked       Code = 4204 // identity not linked to an account
+	CodeOAuthLinkedOther     Code = 4205 // identity linked to another account
+)
+
+// Quota related codes (4300-4399), used by pkg/quota
+const (
+	CodeQuotaExceeded Code = 4300 // quota exceeded
+)
 
 // System related codes (5000-5999)
 const (
-	CodeServerError     Code = 5001 // server error
-	CodeDBError         Code = 5002 // database error
-	CodeRedisError      Code = 5003 // Redis error
-	CodeTooManyRequests Code = 5004 // too many requests
+	CodeServerError        Code = 5001 // server error
+	CodeDBError            Code = 5002 // database error
+	CodeRedisError         Code = 5003 // Redis error
+	CodeTooManyRequests    Code = 5004 // too many requests
+	CodeServiceUnavailable Code = 5005 // service unavailable (maintenance)
 )
 
 // Error code message mapping
 var codeMsg = map[Code]string{
-	CodeSuccess:         "success",
-	CodeError:           "error",
-	CodeUnauth:          "Unauthenticated",
-	CodeTokenExpired:    "Token expired",
-	CodeTokenInvalid:    "Invalid token",
-	CodeForbid:          "Forbidden",
-	CodeParamError:      "Parameter error",
-	CodeParamMissing:    "Parameter missing",
-	CodeParamInvalid:    "Invalid parameter",
-	CodeNotFound:        "Resource not found",
-	CodeDuplicate:       "Resource duplicate",
-	CodeUserNotFound:    "User not found",
-	CodePasswordWrong:   "Wrong password",
-	CodeUserDisabled:    "User disabled",
-	CodeUserExists:      "User already exists",
-	CodeBizError:        "Business error",
-	CodeAuthError:       "Authentication error",
-	CodeServerError:     "Server error",
-	CodeDBError:         "Database error",
-	CodeRedisError:      "Redis error",
-	CodeTooManyRequests: "Too many requests",
-	// Organization related codes are commented out in const section
-	// Uncomment the mappings below when organization module is implemented
-	/*
-		CodeOrgCodeExists:        "Organization code already exists",
-		CodeOrgNotFound:          "Organization not found",
-		CodeOrgPermissionDenied:  "Permission denied",
-		CodeOrgMemberFull:        "Organization member limit reached",
-		CodeOrgMemberExists:      "User is already a member",
-		CodeOrgInviteExpired:     "Invitation expired",
-		CodeOrgInviteUsed:        "Invitation already used",
-		CodeOrgInviteInvalid:     "Invalid invitation code",
-		CodeOrgCannotRemoveOwner: "Cannot remove owner",
-		CodeOrgOwnerCannotLeave:  "Owner cannot leave",
-		CodeOrgTargetNotMember:   "Target user is not a member",
-		CodeOrgCannotInviteOwner: "Cannot invite as owner",
-		CodeOrgDisabled:          "Organization disabled",
-		CodeOrgNotSelected:       "Organization not selected",
-		CodeOrgAlreadyMember:     "User is already a member",
-		CodeOrgMemberLimit:       "Member limit reached",
-		CodeOrgNotMember:         "Not an organization member",
-		CodeOrgUserNotFound:      "User not found",
-	*/
-}
-
-// Msg returns the message for the error code.
+	CodeSuccess:              "success",
+	CodeError:                "error",
+	CodeUnauth:               "Unauthenticated",
+	CodeTokenExpired:         "Token expired",
+	CodeTokenInvalid:         "Invalid token",
+	CodeForbid:               "Forbidden",
+	CodeParamError:           "Parameter error",
+	CodeParamMissing:         "Parameter missing",
+	CodeParamInvalid:         "Invalid parameter",
+	CodeNotFound:             "Resource not found",
+	CodeDuplicate:            "Resource duplicate",
+	CodeUserNotFound:         "User not found",
+	CodePasswordWrong:        "Wrong password",
+	CodeUserDisabled:         "User disabled",
+	CodeUserExists:           "User already exists",
+	CodeBizError:             "Business error",
+	CodeAuthError:            "Authentication error",
+	CodeServerError:          "Server error",
+	CodeDBError:              "Database error",
+	CodeRedisError:           "Redis error",
+	CodeTooManyRequests:      "Too many requests",
+	CodeServiceUnavailable:   "Service under maintenance, please try again later",
+	CodeOrgCodeExists:        "Organization code already exists",
+	CodeOrgNotFound:          "Organization not found",
+	CodeOrgPermissionDenied:  "Permission denied",
+	CodeOrgMemberFull:        "Organization member limit reached",
+	CodeOrgMemberExists:      "User is already a member",
+	CodeOrgInviteExpired:     "Invitation expired",
+	CodeOrgInviteUsed:        "Invitation already used",
+	CodeOrgInviteInvalid:     "Invalid invitation code",
+	CodeOrgCannotRemoveOwner: "Cannot remove owner",
+	CodeOrgOwnerCannotLeave:  "Owner cannot leave",
+	CodeOrgTargetNotMember:   "Target user is not a member",
+	CodeOrgCannotInviteOwner: "Cannot invite as owner",
+	CodeOrgDisabled:          "Organization disabled",
+	CodeOrgNotSelected:       "Organization not selected",
+	CodeOrgAlreadyMember:     "User is already a member",
+	CodeOrgMemberLimit:       "Member limit reached",
+	CodeOrgNotMember:         "Not an organization member",
+	CodeOrgUserNotFound:      "User not found",
+	CodeOAuthProviderUnknown: "Unknown login provider",
+	CodeOAuthStateInvalid:    "Login request invalid or expired, please try again",
+	CodeOAuthFailed:          "Third-party login failed",
+	CodeOAuthDenied:          "Authorization denied",
+	CodeOAuthNotLinked:       "Account not linked, please log in and link it first",
+	CodeOAuthLinkedOther:     "Account already linked to another user",
+	CodeQuotaExceeded:        "Quota exceeded, please upgrade your plan or try again later",
+}
+
+// Msg returns the message for the error code in the default language.
 func (c Code) Msg() string {
-	if msg, ok := codeMsg[c]; ok {
+	return c.MsgLang(i18n.Default())
+}
+
+// MsgLang returns the message for the error code in lang.
+// Catalog entries ("code.<n>") win over the built-in English messages.
+func (c Code) MsgLang(lang string) string {
+	if msg, ok := i18n.Lookup(lang, c.Key()); ok {
+		return msg
+	}
+	if info, ok := Lookup(c); ok {
+		return info.Msg
+	}
+	if msg, ok := i18n.Lookup(lang, "code.unknown"); ok {
 		return msg
 	}
 	return "Unknown error"
 }
 
+// Key returns the i18n catalog key of the code, e.g. "code.1001".
+func (c Code) Key() string {
+	return "code." + strconv.Itoa(int(c))
+}
+
 // Response represents a unified response structure.
 type Response struct {
 	Code Code   `json:"code"`
@@ -146,37 +185,37 @@ type PageData struct {
 	Size  int   `json:"size"`
 }
 
+// CursorPage represents cursor-paginated data.
+type CursorPage struct {
+	List       any    `json:"list"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+	HasMore    bool   `json:"has_more"`
+}
+
 // OK returns a successful response.
 func OK(c *fiber.Ctx, data any) error {
-	return c.JSON(Response{
-		Code: CodeSuccess,
-		Msg:  CodeSuccess.Msg(),
-		Data: data,
-	})
+	return Write(c, CodeSuccess, CodeSuccess.MsgLang(Lang(c)), data)
+}
+
+// OKMasked returns a successful response with the `mask:"..."` tagged fields of data masked (see pkg/mask),
+// for callers not allowed to see the full personal data.
+func OKMasked(c *fiber.Ctx, data any) error {
+	return OK(c, mask.Struct(data))
 }
 
 // Fail returns a failure response with a custom message.
 func Fail(c *fiber.Ctx, msg string) error {
-	return c.JSON(Response{
-		Code: CodeError,
-		Msg:  msg,
-	})
+	return Write(c, CodeError, msg, nil)
 }
 
 // FailCode returns a failure response using the default message for the error code.
 func FailCode(c *fiber.Ctx, code Code) error {
-	return c.JSON(Response{
-		Code: code,
-		Msg:  code.Msg(),
-	})
+	return Write(c, code, code.MsgLang(Lang(c)), nil)
 }
 
 // FailCodeMsg returns a failure response with an error code and custom message.
 func FailCodeMsg(c *fiber.Ctx, code Code, msg string) error {
-	return c.JSON(Response{
-		Code: code,
-		Msg:  msg,
-	})
+	return Write(c, code, msg, nil)
 }
 
 // Page returns a paginated response.
@@ -189,6 +228,15 @@ func Page(c *fiber.Ctx, list any, total int64, page, size int) error {
 	})
 }
 
+// Cursor returns a cursor-paginated response.
+func Cursor(c *fiber.Ctx, list any, nextCursor string, hasMore bool) error {
+	return OK(c, CursorPage{
+		List:       list,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	})
+}
+
 // FailMsg is an alias for FailCodeMsg.
 func FailMsg(c *fiber.Ctx, code Code, msg string) error {
 	return FailCodeMsg(c, code, msg)
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 )
 
 func TestCodeMsg(t *testing.T) {
@@ -62,6 +65,31 @@ func TestOK(t *testing.T) {
 	}
 }
 
+func TestOKMasked(t *testing.T) {
+	type userVO struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone" mask:"phone"`
+	}
+	user := &userVO{Name: "alice", Phone: "13800138000"}
+
+	app := fiber.New()
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return OKMasked(c, user)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/test", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"phone":"138****8000"`) || !strings.Contains(string(body), `"name":"alice"`) {
+		t.Errorf("Expected masked phone, got %s", body)
+	}
+	if user.Phone != "13800138000" {
+		t.Errorf("Expected data to be unchanged, got %s", user.Phone)
+	}
+}
+
 func TestFail(t *testing.T) {
 	app := fiber.New()
 	app.Get("/test", func(c *fiber.Ctx) error {
@@ -174,3 +202,114 @@ func TestPage(t *testing.T) {
 		t.Errorf("Expected size 10, got %d", result.Data.Size)
 	}
 }
+
+func TestSetEnvelope(t *testing.T) {
+	SetEnvelope(NewEnvelope(EnvelopeConfig{CodeField: "status", MsgField: "message", ServerTime: true}))
+	defer SetEnvelope(nil)
+
+	app := fiber.New()
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return OK(c, "payload")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/test", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var result map[string]any
+	json.Unmarshal(body, &result)
+
+	if result["status"] != float64(CodeSuccess) || result["message"] != "success" || result["data"] != "payload" {
+		t.Errorf("Unexpected envelope: %s", body)
+	}
+	if _, ok := result["server_time"]; !ok {
+		t.Errorf("Expected server_time in %s", body)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	app := fiber.New()
+	app.Get("/events", func(c *fiber.Ctx) error {
+		return SSE(c, func(w *SSEWriter) error {
+			return w.Send(Event{ID: "1", Event: "greeting", Data: "hello\nworld"})
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %s", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	expected := "id: 1\nevent: greeting\ndata: hello\ndata: world\n\n"
+	if string(body) != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	app := fiber.New()
+	app.Get("/events", func(c *fiber.Ctx) error {
+		return SSE(c, func(w *SSEWriter) error {
+			stop := w.Heartbeat(10 * time.Millisecond)
+			time.Sleep(35 * time.Millisecond)
+			stop()
+			return w.Send(Event{Data: "done"})
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(string(body), ": ping\n\n") || !strings.HasSuffix(string(body), "data: done\n\n") {
+		t.Errorf("Unexpected stream %q", body)
+	}
+}
+
+func TestWriteReusesEnvelope(t *testing.T) {
+	app := fiber.New()
+	app.Get("/data", func(c *fiber.Ctx) error { return OK(c, fiber.Map{"id": 1}) })
+	app.Get("/empty", func(c *fiber.Ctx) error { return FailCode(c, CodeNotFound) })
+
+	for _, path := range []string{"/data", "/empty", "/data", "/empty"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != fiber.MIMEApplicationJSON {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		// A pooled envelope must not carry data into the next response
+		if got := strings.Contains(string(body), `"data"`); got != (path == "/data") {
+			t.Errorf("%s: unexpected body %s", path, body)
+		}
+	}
+}
+
+type benchUser struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Nickname string `json:"nickname"`
+}
+
+func BenchmarkOK(b *testing.B) {
+	app := fiber.New()
+	user := &benchUser{ID: 1, Name: "crab", Email: "crab@example.com", Nickname: "Crab"}
+	fctx := &fasthttp.RequestCtx{}
+	b.ReportAllocs()
+	for b.Loop() {
+		c := app.AcquireCtx(fctx)
+		OK(c, user)
+		app.ReleaseCtx(c)
+		fctx.Response.Reset()
+	}
+}
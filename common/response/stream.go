@@ -0,0 +1,180 @@
+package response
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// The helpers below write raw bodies and bypass the JSON envelope.
+// Errors returned before anything is written still go through the error handler.
+
+// Stream sends the content of r with the given content type.
+// r is closed after sending when it implements io.Closer.
+func Stream(c *fiber.Ctx, contentType string, r io.Reader) error {
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(r)
+}
+
+// File sends a file from disk. When filename is given the file is sent as an
+// attachment, otherwise it is displayed inline.
+func File(c *fiber.Ctx, path string, filename ...string) error {
+	if len(filename) > 0 && filename[0] != "" {
+		return c.Download(path, filename[0])
+	}
+	return c.SendFile(path)
+}
+
+// Attachment sends the content of r as a downloadable file named filename.
+func Attachment(c *fiber.Ctx, filename, contentType string, r io.Reader) error {
+	c.Attachment(filename)
+	return Stream(c, contentType, r)
+}
+
+// Event represents a Server-Sent Event.
+type Event struct {
+	ID    string // Event ID, sent as "id:"
+	Event string // Event type, sent as "event:"
+	Data  any    // string and []byte are sent as is, other values as JSON
+	Retry int    // Reconnection delay in milliseconds, 0 omits it
+}
+
+// SSEWriter writes Server-Sent Events to the client. It is safe for
+// concurrent use, so Heartbeat can run next to Send.
+type SSEWriter struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	conn    net.Conn
+	timeout time.Duration // Server write timeout, renewed on every flush
+}
+
+// flush sends buffered data. The server write timeout covers the whole
+// response, so it is extended on each flush to keep long streams open.
+func (s *SSEWriter) flush() error {
+	if s.conn != nil && s.timeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	return s.w.Flush()
+}
+
+// Send writes an event and flushes it. An error means the client has gone away.
+func (s *SSEWriter) Send(e Event) error {
+	var data string
+	switch v := e.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	var sb strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&sb, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&sb, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&sb, "retry: %d\n", e.Retry)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&sb, "data: %s\n", line)
+	}
+	sb.WriteString("\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.WriteString(sb.String()); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Comment writes an SSE comment, useful as a keep-alive ping.
+func (s *SSEWriter) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.WriteString(": " + text + "\n\n"); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Heartbeat sends a ping comment every interval until stop is called or a
+// write fails, so idle streams are not closed by proxies. Call stop before
+// returning from the SSE callback.
+func (s *SSEWriter) Heartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if s.Comment("ping") != nil {
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// SSE starts a Server-Sent Events stream and calls fn to produce events.
+// fn runs after the handler returns, so it must not use c; copy anything it
+// needs (user ID, params, c.UserContext()) beforehand. Return from fn to end
+// the stream; a Send error means the client disconnected.
+//
+// Usage:
+//
+//	userID := c.Locals("user_id").(int64)
+//	return response.SSE(c, func(w *response.SSEWriter) error {
+//		defer w.Heartbeat(15 * time.Second)()
+//		for msg := range subscribe(userID) {
+//			if err := w.Send(response.Event{Event: "message", Data: msg}); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	})
+func SSE(c *fiber.Ctx, fn func(w *SSEWriter) error) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	conn := c.Context().Conn()
+	timeout := c.App().Config().WriteTimeout
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		sw := &SSEWriter{w: w, conn: conn, timeout: timeout}
+		// Send the headers right away so the client sees the stream open
+		if sw.flush() != nil {
+			return
+		}
+		_ = fn(sw)
+	})
+	return nil
+}
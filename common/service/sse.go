@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/sse"
+)
+
+var sseLog = logger.NewSystem("sse")
+
+// ============================================================
+// Server-Sent Events Service Wrapper | Server-Sent Events 服务包装器
+//
+// The SSE counterpart of the WebSocket hubs, for dashboards that only receive events.
+// 与 WebSocket Hub 对应的 SSE 版本，用于只接收事件的看板等场景。
+//
+// Usage | 用法:
+//
+//	service.PublishSSEToUser(ctx, 123, sse.NewEvent(0, "order.paid", payload))
+//	service.PublishSSEToAdmin(ctx, 0, sse.NewBroadcast("stats", payload))
+//
+// ============================================================
+
+// Redis channel names | Redis 频道名称
+const (
+	sseChannelUser  = "sse:user"  // User-side channel | 用户端频道
+	sseChannelAdmin = "sse:admin" // Admin-side channel | 管理端频道
+)
+
+var (
+	// userSSE is the user-side SSE hub | userSSE 用户端 SSE Hub
+	userSSE *sse.Hub
+
+	// adminSSE is the admin-side SSE hub | adminSSE 管理端 SSE Hub
+	adminSSE *sse.Hub
+)
+
+// InitSSE initializes the SSE hubs, after InitWS whose context cancels the Redis subscriptions
+// InitSSE 初始化 SSE Hub，需在 InitWS 之后调用，其上下文用于取消 Redis 订阅
+func InitSSE() {
+	userSSE = sse.NewHub(sse.WithName("user"))
+	adminSSE = sse.NewHub(sse.WithName("admin"))
+
+	if rdb := redis.Get(); rdb != nil {
+		if client, ok := rdb.GetRaw().(sse.RedisClient); ok {
+			userSSE.EnableCluster(ctx, client, sseChannelUser)
+			adminSSE.EnableCluster(ctx, client, sseChannelAdmin)
+			sseLog.Info("Cluster mode enabled (redis pub/sub)")
+			return
+		}
+		sseLog.Warn("Redis client does not support Pub/Sub")
+	}
+}
+
+// CloseSSE ends all open streams, clients reconnect to another instance
+// CloseSSE 结束所有打开的流，客户端会重连到其他实例
+func CloseSSE() {
+	if userSSE != nil {
+		userSSE.Close()
+	}
+	if adminSSE != nil {
+		adminSSE.Close()
+	}
+}
+
+// GetUserSSEHub returns the user-side SSE hub
+// GetUserSSEHub 返回用户端 SSE Hub
+func GetUserSSEHub() *sse.Hub {
+	return userSSE
+}
+
+// GetAdminSSEHub returns the admin-side SSE hub
+// GetAdminSSEHub 返回管理端 SSE Hub
+func GetAdminSSEHub() *sse.Hub {
+	return adminSSE
+}
+
+// PublishSSEToUser sends an event to the streams of a user (0 means all users), on every node in cluster mode
+// PublishSSEToUser 向用户的流发送事件（0 表示所有用户），集群模式下发送到所有节点
+func PublishSSEToUser(ctx context.Context, userID int64, e *sse.Event) error {
+	if userSSE == nil {
+		return nil
+	}
+	return userSSE.PublishToUser(ctx, userID, e)
+}
+
+// PublishSSEToAdmin sends an event to the streams of an admin (0 means all admins)
+// PublishSSEToAdmin 向管理员的流发送事件（0 表示所有管理员）
+func PublishSSEToAdmin(ctx context.Context, adminID int64, e *sse.Event) error {
+	if adminSSE == nil {
+		return nil
+	}
+	return adminSSE.PublishToUser(ctx, adminID, e)
+}
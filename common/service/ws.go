@@ -8,6 +8,7 @@ import (
 	"github.com/nuohe369/crab/pkg/logger"
 	"github.com/nuohe369/crab/pkg/redis"
 	"github.com/nuohe369/crab/pkg/ws"
+	"github.com/nuohe369/crab/pkg/wsbridge"
 )
 
 var wsLog = logger.NewSystem("ws")
@@ -33,6 +34,12 @@ var wsLog = logger.NewSystem("ws")
 //	service.PublishToUser(ctx, 123, &ws.Message{Type: "notify", Payload: xxx})
 //	service.PublishToAdmin(ctx, 0, &ws.Message{Type: "broadcast", Payload: xxx})
 //
+//	// Handle client messages by type (module Init) | 按类型处理客户端消息（模块 Init 中）
+//	service.HandleUserMessage("chat.send", handler)
+//
+//	// Forward mq topics to clients (module Init) | 将 mq 主题转发给客户端（模块 Init 中）
+//	service.BridgeToUser("order:status", wsbridge.JSON("order.status", "user_id"))
+//
 // ============================================================
 
 // Redis channel names | Redis 频道名称
@@ -48,6 +55,10 @@ var (
 	// adminHub is the admin-side Hub | adminHub 管理端 Hub
 	adminHub *ws.Hub
 
+	// userPoll and adminPoll are the long-poll transports of the hubs | userPoll 和 adminPoll 是 Hub 的长轮询传输
+	userPoll  *ws.LongPoll
+	adminPoll *ws.LongPoll
+
 	// ctx is the global context for canceling subscriptions | ctx 用于取消订阅的全局上下文
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -63,13 +74,21 @@ func InitWS() {
 	ctx, cancel = context.WithCancel(context.Background())
 
 	// Create Hubs | 创建 Hub
-	userHub = ws.NewHub()
-	adminHub = ws.NewHub()
+	userHub = ws.NewHub(ws.WithName("user"))
+	adminHub = ws.NewHub(ws.WithName("admin"))
 
 	// Start Hub event loops | 启动 Hub 事件循环
 	go userHub.Run()
 	go adminHub.Run()
 
+	// Long-poll fallback for networks blocking WebSocket | 为拦截 WebSocket 的网络提供长轮询回退
+	userPoll = ws.NewLongPoll(userHub)
+	adminPoll = ws.NewLongPoll(adminHub)
+
+	// Application-level heartbeat, other types are registered by modules | 应用层心跳，其他类型由模块注册
+	userHub.HandleFunc("ping", handlePing)
+	adminHub.HandleFunc("ping", handlePing)
+
 	// Enable cluster mode if Redis is available | 如果 Redis 可用，启用集群模式
 	if rdb := redis.Get(); rdb != nil {
 		rawClient := rdb.GetRaw()
@@ -102,13 +121,19 @@ func CloseWS() {
 // ============================================================
 
 // GetUserHub returns the user-side Hub
-// Used by module/ws_user to register connections
+// Used by module/ws to register connections
 // GetUserHub 返回用户端 Hub
-// 由 module/ws_user 使用以注册连接
+// 由 module/ws 使用以注册连接
 func GetUserHub() *ws.Hub {
 	return userHub
 }
 
+// GetUserLongPoll returns the long-poll transport of the user-side Hub
+// GetUserLongPoll 返回用户端 Hub 的长轮询传输
+func GetUserLongPoll() *ws.LongPoll {
+	return userPoll
+}
+
 // PublishToUser sends a message to a user
 // This is the main interface called by other modules
 // In cluster mode, messages are broadcast to all nodes via Redis
@@ -166,13 +191,19 @@ func GetUserOnlineCount() int {
 // ============================================================
 
 // GetAdminHub returns the admin-side Hub
-// Used by module/ws_admin to register connections
+// Used by module/ws to register connections
 // GetAdminHub 返回管理端 Hub
-// 由 module/ws_admin 使用以注册连接
+// 由 module/ws 使用以注册连接
 func GetAdminHub() *ws.Hub {
 	return adminHub
 }
 
+// GetAdminLongPoll returns the long-poll transport of the admin-side Hub
+// GetAdminLongPoll 返回管理端 Hub 的长轮询传输
+func GetAdminLongPoll() *ws.LongPoll {
+	return adminPoll
+}
+
 // PublishToAdmin sends a message to an admin
 // PublishToAdmin 向管理员发送消息
 //
@@ -204,3 +235,64 @@ func GetAdminOnlineCount() int {
 	}
 	return adminHub.UserCount()
 }
+
+// ============================================================
+// Message handlers | 消息处理器
+// ============================================================
+
+// HandleUserMessage registers the handler of a user-side message type, replacing any previous one
+// Call it in a module's Init; use ws.Handle(service.GetUserHub(), ...) for a typed payload.
+// HandleUserMessage 注册用户端某种消息类型的处理器，覆盖已有的处理器
+// 在模块的 Init 中调用；类型化载荷请使用 ws.Handle(service.GetUserHub(), ...)。
+//
+// Example | 示例:
+//
+//	service.HandleUserMessage("chat.send", func(client *ws.Client, msg *ws.Message) error {
+//	    return service.PublishToUser(context.Background(), 0, &ws.Message{Type: "chat.message", Payload: msg.Payload})
+//	})
+func HandleUserMessage(msgType string, h ws.HandlerFunc) {
+	if userHub != nil {
+		userHub.HandleFunc(msgType, h)
+	}
+}
+
+// HandleAdminMessage registers the handler of an admin-side message type, replacing any previous one
+// HandleAdminMessage 注册管理端某种消息类型的处理器，覆盖已有的处理器
+func HandleAdminMessage(msgType string, h ws.HandlerFunc) {
+	if adminHub != nil {
+		adminHub.HandleFunc(msgType, h)
+	}
+}
+
+// handlePing answers application-level heartbeats from clients that cannot send ping frames (browsers)
+// handlePing 回复无法发送 ping 帧的客户端（浏览器）的应用层心跳
+func handlePing(client *ws.Client, _ *ws.Message) error {
+	client.Send(&ws.Message{Type: "pong"})
+	return nil
+}
+
+// ============================================================
+// MQ bridge | MQ 桥接
+// ============================================================
+
+// InitBridge registers the configured mq routes on the "user" and "admin" hubs, call it after InitWS
+// InitBridge 在 "user" 和 "admin" Hub 上注册已配置的 mq 路由，需在 InitWS 之后调用
+func InitBridge(cfg wsbridge.Config) error {
+	return wsbridge.Init(cfg, map[string]*ws.Hub{"user": userHub, "admin": adminHub})
+}
+
+// BridgeToUser forwards an mq topic to user-side clients through fn
+// BridgeToUser 通过 fn 将 mq 主题转发给用户端客户端
+func BridgeToUser(topic string, fn wsbridge.Mapper, opts ...wsbridge.Option) {
+	if userHub != nil {
+		wsbridge.Register(userHub, topic, fn, opts...)
+	}
+}
+
+// BridgeToAdmin forwards an mq topic to admin-side clients through fn
+// BridgeToAdmin 通过 fn 将 mq 主题转发给管理端客户端
+func BridgeToAdmin(topic string, fn wsbridge.Mapper, opts ...wsbridge.Option) {
+	if adminHub != nil {
+		wsbridge.Register(adminHub, topic, fn, opts...)
+	}
+}
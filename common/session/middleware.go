@@ -0,0 +1,179 @@
+package session
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+)
+
+// localsKey is the fiber.Ctx locals key for the current session
+// localsKey 是当前会话在 fiber.Ctx locals 中的键
+const localsKey = "session"
+
+// Middleware returns a middleware that loads the session and slides its expiry
+// On success it sets c.Locals("session") and c.Locals("user_id")
+// Middleware 返回加载会话并滑动续期的中间件
+// 成功时设置 c.Locals("session") 和 c.Locals("user_id")
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		m := defaultManager
+		if m == nil {
+			return errors.ErrServerError("session not initialized")
+		}
+
+		s, err := m.Load(c.Context(), m.idFromCtx(c))
+		if err != nil {
+			if stderrors.Is(err, ErrNotFound) {
+				return errors.ErrUnauthorized()
+			}
+			return errors.Wrap(response.CodeRedisError, err)
+		}
+
+		if err := m.Touch(c.Context(), s); err != nil {
+			log.Warn("Failed to touch session: %v", err)
+		}
+
+		c.Locals(localsKey, s)
+		c.Locals(ctxutil.LocalsUserID, s.UserID)
+		c.SetUserContext(ctxutil.WithUser(c.UserContext(), s.UserID, ""))
+		return c.Next()
+	}
+}
+
+// FromCtx returns the session loaded by Middleware, nil if absent
+// FromCtx 返回由 Middleware 加载的会话，不存在时返回 nil
+func FromCtx(c *fiber.Ctx) *Session {
+	s, _ := c.Locals(localsKey).(*Session)
+	return s
+}
+
+// DeviceFromCtx builds device metadata from the request
+// DeviceFromCtx 从请求构建设备元数据
+func DeviceFromCtx(c *fiber.Ctx) Device {
+	return Device{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
+// SetCookie writes the session cookie
+// SetCookie 写入会话 Cookie
+func SetCookie(c *fiber.Ctx, s *Session) {
+	if defaultManager == nil {
+		return
+	}
+	cfg := defaultManager.config
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.CookieName,
+		Value:    s.ID,
+		Path:     "/",
+		Expires:  s.ExpiresAt,
+		Secure:   cfg.CookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// ClearCookie removes the session cookie
+// ClearCookie 清除会话 Cookie
+func ClearCookie(c *fiber.Ctx) {
+	if defaultManager == nil {
+		return
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     defaultManager.config.CookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+	})
+}
+
+// idFromCtx reads the session ID from header, falling back to cookie
+// idFromCtx 从请求头读取会话 ID，回退到 Cookie
+func (m *Manager) idFromCtx(c *fiber.Ctx) string {
+	if id := c.Get(m.config.HeaderName); id != "" {
+		return id
+	}
+	return c.Cookies(m.config.CookieName)
+}
+
+// ============ Handlers | 处理器 ============
+
+// sessionView is the public view of a session
+// sessionView 是会话的对外视图
+type sessionView struct {
+	ID         string    `json:"id"`
+	Device     Device    `json:"device"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ListHandler lists the current user's sessions ("my devices")
+// Must be mounted behind Middleware
+// ListHandler 列出当前用户的会话（"我的设备"）
+// 必须挂载在 Middleware 之后
+func ListHandler(c *fiber.Ctx) error {
+	cur := FromCtx(c)
+	if cur == nil {
+		return errors.ErrUnauthorized()
+	}
+
+	sessions, err := List(c.Context(), cur.UserID)
+	if err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+
+	list := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, sessionView{
+			ID:         s.ID,
+			Device:     s.Device,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == cur.ID,
+		})
+	}
+	return response.OK(c, list)
+}
+
+// LogoutHandler destroys the current session
+// Must be mounted behind Middleware
+// LogoutHandler 销毁当前会话
+// 必须挂载在 Middleware 之后
+func LogoutHandler(c *fiber.Ctx) error {
+	cur := FromCtx(c)
+	if cur == nil {
+		return errors.ErrUnauthorized()
+	}
+
+	if err := defaultManager.Destroy(c.Context(), cur); err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+	ClearCookie(c)
+	return response.OK(c, nil)
+}
+
+// LogoutAllHandler logs out all other devices, keeping the current session
+// Must be mounted behind Middleware
+// LogoutAllHandler 退出所有其他设备，保留当前会话
+// 必须挂载在 Middleware 之后
+func LogoutAllHandler(c *fiber.Ctx) error {
+	cur := FromCtx(c)
+	if cur == nil {
+		return errors.ErrUnauthorized()
+	}
+
+	n, err := DestroyAll(c.Context(), cur.UserID, cur.ID)
+	if err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+	return response.OK(c, fiber.Map{"count": n})
+}
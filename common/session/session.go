@@ -0,0 +1,408 @@
+// Package session provides Redis-backed server-side sessions
+// session 包提供基于 Redis 的服务端会话
+//
+// Sessions complement JWT for admin consoles: they can be listed per user,
+// revoked individually, and logged out across all devices at once.
+// 会话用于补充 JWT 的管理后台场景：可按用户列出、单独吊销，以及一键退出所有设备。
+//
+// Usage | 用法:
+//
+//	// Login | 登录
+//	sess, err := session.Create(c.Context(), userID, session.DeviceFromCtx(c))
+//	session.SetCookie(c, sess)
+//
+//	// Protect routes | 保护路由
+//	router.Use(session.Middleware())
+//
+//	// In handlers | 在处理器中
+//	sess := session.FromCtx(c)
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/json"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+var log = logger.NewSystem("session")
+
+// ErrNotFound indicates the session does not exist or has expired
+// ErrNotFound 表示会话不存在或已过期
+var ErrNotFound = errors.New("session: not found")
+
+// ErrNotInitialized indicates the session manager is not initialized
+// ErrNotInitialized 表示会话管理器未初始化
+var ErrNotInitialized = errors.New("session: not initialized")
+
+// RedisClient defines the Redis client interface
+// RedisClient 定义 Redis 客户端接口
+type RedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	SAdd(ctx context.Context, key string, members ...any) error
+	SRem(ctx context.Context, key string, members ...any) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// Config represents session configuration
+// Config 表示会话配置
+type Config struct {
+	TTL           time.Duration `toml:"ttl"`            // Idle timeout, sliding on every request (default 2h) | 空闲超时，每次请求滑动续期（默认 2 小时）
+	MaxLifetime   time.Duration `toml:"max_lifetime"`   // Absolute lifetime regardless of activity, 0 means unlimited | 绝对有效期，0 表示不限制
+	TouchInterval time.Duration `toml:"touch_interval"` // Minimum interval between expiry refreshes (default 1m) | 续期最小间隔（默认 1 分钟）
+	CookieName    string        `toml:"cookie_name"`    // Cookie name (default "crab_sid") | Cookie 名称（默认 "crab_sid"）
+	HeaderName    string        `toml:"header_name"`    // Header name (default "X-Session-ID") | 请求头名称（默认 "X-Session-ID"）
+	CookieSecure  bool          `toml:"cookie_secure"`  // Set Secure flag on cookie | 设置 Cookie 的 Secure 标志
+	KeyPrefix     string        `toml:"key_prefix"`     // Redis key prefix (default "session:") | Redis 键前缀（默认 "session:"）
+}
+
+// DefaultConfig returns default configuration
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		TTL:           2 * time.Hour,
+		TouchInterval: time.Minute,
+		CookieName:    "crab_sid",
+		HeaderName:    "X-Session-ID",
+		KeyPrefix:     "session:",
+	}
+}
+
+// withDefaults fills zero values with defaults
+// withDefaults 用默认值填充零值
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.TTL <= 0 {
+		c.TTL = d.TTL
+	}
+	if c.TouchInterval <= 0 {
+		c.TouchInterval = d.TouchInterval
+	}
+	if c.CookieName == "" {
+		c.CookieName = d.CookieName
+	}
+	if c.HeaderName == "" {
+		c.HeaderName = d.HeaderName
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = d.KeyPrefix
+	}
+	return c
+}
+
+// Device represents the client device that created the session
+// Device 表示创建会话的客户端设备
+type Device struct {
+	UserAgent string `json:"user_agent"` // User-Agent header | User-Agent 请求头
+	IP        string `json:"ip"`         // Client IP | 客户端 IP
+	Name      string `json:"name"`       // Optional device name | 可选设备名称
+}
+
+// Session represents a server-side session
+// Session 表示服务端会话
+type Session struct {
+	ID         string            `json:"id"`             // Session ID | 会话 ID
+	UserID     int64             `json:"user_id,string"` // User ID | 用户 ID
+	Device     Device            `json:"device"`         // Device metadata | 设备元数据
+	Data       map[string]string `json:"data,omitempty"` // Custom data | 自定义数据
+	CreatedAt  time.Time         `json:"created_at"`     // Creation time | 创建时间
+	LastSeenAt time.Time         `json:"last_seen_at"`   // Last activity time | 最后活跃时间
+	ExpiresAt  time.Time         `json:"expires_at"`     // Current expiry (slides with activity) | 当前过期时间（随活跃滑动）
+}
+
+// Manager manages sessions
+// Manager 管理会话
+type Manager struct {
+	redis  RedisClient
+	config Config
+}
+
+var defaultManager *Manager
+
+// Init initializes the default manager with the default Redis client
+// Init 使用默认 Redis 客户端初始化默认管理器
+func Init(cfg Config) {
+	rdb := redis.Get()
+	if rdb == nil {
+		log.Warn("Redis not initialized, session manager disabled")
+		return
+	}
+	defaultManager = New(rdb, cfg)
+	log.Info("Session manager initialized: ttl=%v", defaultManager.config.TTL)
+}
+
+// Get returns the default manager
+// Get 返回默认管理器
+func Get() *Manager {
+	return defaultManager
+}
+
+// New creates a session manager
+// New 创建会话管理器
+func New(rdb RedisClient, cfg Config) *Manager {
+	return &Manager{
+		redis:  rdb,
+		config: cfg.withDefaults(),
+	}
+}
+
+// Config returns the manager configuration
+// Config 返回管理器配置
+func (m *Manager) Config() Config {
+	return m.config
+}
+
+// sessionKey returns the Redis key for a session
+// sessionKey 返回会话的 Redis 键
+func (m *Manager) sessionKey(id string) string {
+	return m.config.KeyPrefix + id
+}
+
+// userKey returns the Redis key for a user's session index
+// userKey 返回用户会话索引的 Redis 键
+func (m *Manager) userKey(userID int64) string {
+	return m.config.KeyPrefix + "user:" + strconv.FormatInt(userID, 10)
+}
+
+// Create creates a new session for the user
+// Create 为用户创建新会话
+func (m *Manager) Create(ctx context.Context, userID int64, device Device) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:         id,
+		UserID:     userID,
+		Device:     device,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  m.expiry(now, now),
+	}
+
+	if err := m.save(ctx, s); err != nil {
+		return nil, err
+	}
+	if err := m.redis.SAdd(ctx, m.userKey(userID), id); err != nil {
+		return nil, err
+	}
+	// Index lives as long as the longest possible session | 索引的生命周期与最长会话一致
+	m.redis.Expire(ctx, m.userKey(userID), m.indexTTL())
+
+	log.Debug("Created session for user %d", userID)
+	return s, nil
+}
+
+// Load loads a session by ID
+// Load 根据 ID 加载会话
+func (m *Manager) Load(ctx context.Context, id string) (*Session, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+
+	val, err := m.redis.Get(ctx, m.sessionKey(id))
+	if err != nil {
+		if redis.IsNil(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var s Session
+	if err := json.UnmarshalString(val, &s); err != nil {
+		return nil, err
+	}
+	if time.Now().After(s.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+// Touch slides the session expiry, skipped if refreshed within TouchInterval
+// Touch 滑动续期会话，如果在 TouchInterval 内已续期则跳过
+func (m *Manager) Touch(ctx context.Context, s *Session) error {
+	now := time.Now()
+	if now.Sub(s.LastSeenAt) < m.config.TouchInterval {
+		return nil
+	}
+
+	s.LastSeenAt = now
+	s.ExpiresAt = m.expiry(s.CreatedAt, now)
+	if !s.ExpiresAt.After(now) {
+		return m.Destroy(ctx, s)
+	}
+	if err := m.save(ctx, s); err != nil {
+		return err
+	}
+	// Keep the index alive with the session so DestroyAll still finds it | 索引随会话续期，确保 DestroyAll 仍能找到它
+	return m.redis.Expire(ctx, m.userKey(s.UserID), m.indexTTL())
+}
+
+// Save persists session data changes without sliding the expiry
+// Save 保存会话数据变更，不滑动过期时间
+func (m *Manager) Save(ctx context.Context, s *Session) error {
+	return m.save(ctx, s)
+}
+
+// Destroy deletes a session
+// Destroy 删除会话
+func (m *Manager) Destroy(ctx context.Context, s *Session) error {
+	if err := m.redis.Del(ctx, m.sessionKey(s.ID)); err != nil {
+		return err
+	}
+	return m.redis.SRem(ctx, m.userKey(s.UserID), s.ID)
+}
+
+// List returns all active sessions of a user
+// List 返回用户的所有活跃会话
+func (m *Manager) List(ctx context.Context, userID int64) ([]*Session, error) {
+	ids, err := m.redis.SMembers(ctx, m.userKey(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	var stale []any
+	for _, id := range ids {
+		s, err := m.Load(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				stale = append(stale, id)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	// Lazily clean up expired entries from the index | 惰性清理索引中已过期的条目
+	if len(stale) > 0 {
+		m.redis.SRem(ctx, m.userKey(userID), stale...)
+	}
+	return sessions, nil
+}
+
+// DestroyAll logs the user out of all devices, except the session IDs given
+// DestroyAll 退出用户的所有设备，except 中的会话 ID 除外
+func (m *Manager) DestroyAll(ctx context.Context, userID int64, except ...string) (int, error) {
+	ids, err := m.redis.SMembers(ctx, m.userKey(userID))
+	if err != nil {
+		return 0, err
+	}
+
+	keep := make(map[string]bool, len(except))
+	for _, id := range except {
+		keep[id] = true
+	}
+
+	var keys []string
+	var members []any
+	for _, id := range ids {
+		if keep[id] {
+			continue
+		}
+		keys = append(keys, m.sessionKey(id))
+		members = append(members, id)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	if err := m.redis.Del(ctx, keys...); err != nil {
+		return 0, err
+	}
+	if err := m.redis.SRem(ctx, m.userKey(userID), members...); err != nil {
+		return 0, err
+	}
+
+	log.Info("Destroyed %d sessions for user %d", len(keys), userID)
+	return len(keys), nil
+}
+
+// save writes the session with TTL matching its expiry
+// save 写入会话，TTL 与其过期时间一致
+func (m *Manager) save(ctx context.Context, s *Session) error {
+	data, err := json.MarshalString(s)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(s.ExpiresAt)
+	if ttl <= 0 {
+		return ErrNotFound
+	}
+	return m.redis.Set(ctx, m.sessionKey(s.ID), data, ttl)
+}
+
+// expiry computes the sliding expiry capped by MaxLifetime
+// expiry 计算受 MaxLifetime 限制的滑动过期时间
+func (m *Manager) expiry(createdAt, now time.Time) time.Time {
+	exp := now.Add(m.config.TTL)
+	if m.config.MaxLifetime > 0 {
+		if limit := createdAt.Add(m.config.MaxLifetime); exp.After(limit) {
+			exp = limit
+		}
+	}
+	return exp
+}
+
+// indexTTL returns the TTL of the per-user index, refreshed on create and touch
+// It outlives every session of the user: none expires later than now plus TTL or MaxLifetime.
+// indexTTL 返回用户索引的 TTL，在创建和续期时刷新
+// 它比用户的所有会话都长：任何会话的过期时间都不晚于当前时间加 TTL 或 MaxLifetime。
+func (m *Manager) indexTTL() time.Duration {
+	ttl := 30 * 24 * time.Hour
+	if m.config.MaxLifetime > 0 {
+		ttl = m.config.MaxLifetime
+	}
+	return max(ttl, m.config.TTL)
+}
+
+// newID generates a 256-bit random session ID
+// newID 生成 256 位随机会话 ID
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ============ Convenience methods (using default manager) | 便捷方法（使用默认管理器） ============
+
+// Create creates a session using the default manager
+// Create 使用默认管理器创建会话
+func Create(ctx context.Context, userID int64, device Device) (*Session, error) {
+	if defaultManager == nil {
+		return nil, ErrNotInitialized
+	}
+	return defaultManager.Create(ctx, userID, device)
+}
+
+// List lists a user's sessions using the default manager
+// List 使用默认管理器列出用户会话
+func List(ctx context.Context, userID int64) ([]*Session, error) {
+	if defaultManager == nil {
+		return nil, ErrNotInitialized
+	}
+	return defaultManager.List(ctx, userID)
+}
+
+// DestroyAll logs a user out of all devices using the default manager
+// DestroyAll 使用默认管理器退出用户的所有设备
+func DestroyAll(ctx context.Context, userID int64, except ...string) (int, error) {
+	if defaultManager == nil {
+		return 0, ErrNotInitialized
+	}
+	return defaultManager.DestroyAll(ctx, userID, except...)
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+func newTestManager(t *testing.T, cfg Config) (*Manager, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New failed: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb, cfg), mr
+}
+
+func TestCreateAndLoad(t *testing.T) {
+	m, mr := newTestManager(t, Config{TTL: time.Hour})
+	ctx := context.Background()
+
+	s, err := m.Create(ctx, 42, Device{IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	loaded, err := m.Load(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.UserID != 42 || loaded.Device.IP != "10.0.0.1" {
+		t.Errorf("Unexpected session: %+v", loaded)
+	}
+	if ttl := mr.TTL(m.sessionKey(s.ID)); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Expected session TTL within 1h, got %v", ttl)
+	}
+	if ok, _ := mr.SIsMember(m.userKey(42), s.ID); !ok {
+		t.Error("Expected session in user index")
+	}
+}
+
+func TestTouchRefreshesIndex(t *testing.T) {
+	m, mr := newTestManager(t, Config{TTL: 40 * 24 * time.Hour, TouchInterval: time.Second})
+	ctx := context.Background()
+
+	s, err := m.Create(ctx, 1, Device{})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// A sliding session outlives the index TTL set on create | 滑动会话比创建时设置的索引 TTL 活得更久
+	mr.FastForward(35 * 24 * time.Hour)
+	s.LastSeenAt = s.LastSeenAt.Add(-time.Minute)
+	if err := m.Touch(ctx, s); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if idx, sess := mr.TTL(m.userKey(1)), mr.TTL(m.sessionKey(s.ID)); idx < sess {
+		t.Errorf("Index TTL %v shorter than session TTL %v", idx, sess)
+	}
+
+	n, err := m.DestroyAll(ctx, 1)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 destroyed session, got %d, %v", n, err)
+	}
+}
+
+func TestTouchWithinInterval(t *testing.T) {
+	m, mr := newTestManager(t, Config{TTL: time.Hour, TouchInterval: time.Minute})
+	ctx := context.Background()
+
+	s, _ := m.Create(ctx, 1, Device{})
+	mr.FastForward(30 * time.Minute)
+	if err := m.Touch(ctx, s); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if ttl := mr.TTL(m.sessionKey(s.ID)); ttl > 30*time.Minute {
+		t.Errorf("Expected touch within interval to be skipped, TTL %v", ttl)
+	}
+}
+
+func TestDestroyAll(t *testing.T) {
+	m, _ := newTestManager(t, Config{})
+	ctx := context.Background()
+
+	keep, _ := m.Create(ctx, 7, Device{Name: "laptop"})
+	m.Create(ctx, 7, Device{Name: "phone"})
+	m.Create(ctx, 7, Device{Name: "tablet"})
+	other, _ := m.Create(ctx, 8, Device{})
+
+	n, err := m.DestroyAll(ctx, 7, keep.ID)
+	if err != nil {
+		t.Fatalf("DestroyAll failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 destroyed sessions, got %d", n)
+	}
+
+	sessions, _ := m.List(ctx, 7)
+	if len(sessions) != 1 || sessions[0].ID != keep.ID {
+		t.Errorf("Expected only the kept session, got %v", sessions)
+	}
+	if _, err := m.Load(ctx, other.ID); err != nil {
+		t.Errorf("Other user's session should survive: %v", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	m, mr := newTestManager(t, Config{TTL: time.Hour})
+	ctx := context.Background()
+
+	s, _ := m.Create(ctx, 3, Device{})
+	mr.FastForward(2 * time.Hour)
+
+	if _, err := m.Load(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after expiry, got %v", err)
+	}
+
+	// List drops the expired entry from the index | List 从索引中删除已过期的条目
+	sessions, err := m.List(ctx, 3)
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("Expected no sessions, got %v, %v", sessions, err)
+	}
+	if ok, _ := mr.SIsMember(m.userKey(3), s.ID); ok {
+		t.Error("Expected expired session removed from index")
+	}
+}
+
+func TestMaxLifetime(t *testing.T) {
+	m, _ := newTestManager(t, Config{TTL: time.Hour, MaxLifetime: 90 * time.Minute, TouchInterval: time.Second})
+	ctx := context.Background()
+
+	s, _ := m.Create(ctx, 5, Device{})
+	s.CreatedAt = s.CreatedAt.Add(-2 * time.Hour)
+	s.LastSeenAt = s.LastSeenAt.Add(-time.Minute)
+
+	// Past the absolute lifetime, touch destroys the session | 超过绝对有效期后，续期会销毁会话
+	if err := m.Touch(ctx, s); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if _, err := m.Load(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
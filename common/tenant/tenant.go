@@ -0,0 +1,229 @@
+// Package tenant resolves the tenant of each request and scopes database access to it
+// tenant 包解析每个请求所属的租户，并将数据库访问限定在该租户内
+//
+// Middleware reads the tenant from the JWT "tenant" claim, a header or the subdomain and stores
+// it in c.UserContext(). DB then returns sessions limited to that tenant, by a tenant_id column
+// (model.TenantScoped), by a schema per tenant or by a database per tenant.
+// Middleware 从 JWT 的 "tenant" 声明、请求头或子域名中读取租户，并存入 c.UserContext()。
+// DB 随后返回限定在该租户内的会话，可按 tenant_id 列（model.TenantScoped）、每租户一个 schema
+// 或每租户一个数据库进行隔离。
+//
+// Usage | 用法:
+//
+//	s, err := tenant.DB(c.UserContext(), &Invoice{})
+//	if err != nil {
+//	    return err
+//	}
+//	err = s.Find(&invoices) // WHERE tenant_id = ? | 自动添加 WHERE tenant_id = ?
+package tenant
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/jwt"
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+var log = logger.NewSystem("tenant")
+
+func init() {
+	i18n.Register("en", map[string]string{
+		"tenant.required": "Tenant is required",
+		"tenant.invalid":  "Tenant is invalid",
+	})
+	i18n.Register("zh", map[string]string{
+		"tenant.required": "缺少租户",
+		"tenant.invalid":  "租户无效",
+	})
+}
+
+// Tenant sources | 租户来源
+const (
+	SourceJWT       = "jwt"       // "tenant" claim of the Bearer token | Bearer 令牌的 "tenant" 声明
+	SourceHeader    = "header"    // Config.Header request header | Config.Header 请求头
+	SourceSubdomain = "subdomain" // acme.{Config.Domain} | acme.{Config.Domain}
+)
+
+// Isolation modes | 隔离模式
+const (
+	ModeColumn   = "column"   // Shared tables filtered by tenant_id | 共享表，按 tenant_id 过滤
+	ModeSchema   = "schema"   // One schema per tenant: {SchemaPrefix}{id} | 每租户一个 schema：{SchemaPrefix}{id}
+	ModeDatabase = "database" // One pgsql named client per tenant: {DatabasePrefix}{id} | 每租户一个 pgsql 命名客户端：{DatabasePrefix}{id}
+)
+
+// Config represents the [tenant] configuration section
+// Config 表示 [tenant] 配置段
+type Config struct {
+	Enabled        bool     `toml:"enabled"`         // Mount the middleware | 挂载中间件
+	Sources        []string `toml:"sources"`         // Resolution order (default ["jwt", "header"]) | 解析顺序（默认 ["jwt", "header"]）
+	Header         string   `toml:"header"`          // Tenant header (default X-Tenant-ID) | 租户请求头（默认 X-Tenant-ID）
+	Domain         string   `toml:"domain"`          // Base domain of the subdomain source, e.g. "example.com" | 子域名来源的基础域名，例如 "example.com"
+	Required       bool     `toml:"required"`        // Reject requests without a tenant | 拒绝没有租户的请求
+	Exclude        []string `toml:"exclude"`         // Path prefixes not requiring a tenant, /health always | 不要求租户的路径前缀，/health 始终不要求
+	PlatformAdmins []string `toml:"platform_admins"` // Token platforms that may act on any tenant, e.g. ["admin"] | 可操作任意租户的令牌平台，例如 ["admin"]
+	Mode           string   `toml:"mode"`            // column (default), schema or database | column（默认）、schema 或 database
+	SchemaPrefix   string   `toml:"schema_prefix"`   // Schema name prefix (default "tenant_") | schema 名称前缀（默认 "tenant_"）
+	DatabasePrefix string   `toml:"database_prefix"` // pgsql client name prefix (default "tenant_") | pgsql 客户端名称前缀（默认 "tenant_"）
+}
+
+var cfg = Config{
+	Sources:        []string{SourceJWT, SourceHeader},
+	Header:         "X-Tenant-ID",
+	Mode:           ModeColumn,
+	SchemaPrefix:   "tenant_",
+	DatabasePrefix: "tenant_",
+}
+
+// Init stores the tenancy configuration used by Middleware and DB
+// Init 保存 Middleware 和 DB 使用的多租户配置
+func Init(c Config) {
+	if len(c.Sources) == 0 {
+		c.Sources = []string{SourceJWT, SourceHeader}
+	}
+	if c.Header == "" {
+		c.Header = "X-Tenant-ID"
+	}
+	switch c.Mode {
+	case "":
+		c.Mode = ModeColumn
+	case ModeColumn, ModeSchema, ModeDatabase:
+	default:
+		log.Warn("Unknown tenant mode %q, using %s", c.Mode, ModeColumn)
+		c.Mode = ModeColumn
+	}
+	if c.SchemaPrefix == "" {
+		c.SchemaPrefix = "tenant_"
+	}
+	if c.DatabasePrefix == "" {
+		c.DatabasePrefix = "tenant_"
+	}
+	cfg = c
+	if c.Enabled {
+		log.Info("Tenancy initialized: mode=%s sources=%v", c.Mode, c.Sources)
+	}
+}
+
+// Enabled reports whether the tenant middleware is enabled
+// Enabled 判断租户中间件是否启用
+func Enabled() bool {
+	return cfg.Enabled
+}
+
+// GetConfig returns the tenancy configuration | GetConfig 返回多租户配置
+func GetConfig() Config {
+	return cfg
+}
+
+// Middleware returns a middleware resolving the request tenant
+// Mount it after RequestContext, which parses the JWT. A token bound to a tenant is only accepted
+// for that tenant, so a header or subdomain cannot switch an authenticated user to another tenant.
+// A token without a tenant may only select one when its platform is listed in platform_admins;
+// on excluded paths it proceeds without a tenant instead.
+// Middleware 返回解析请求租户的中间件
+// 需挂载在解析 JWT 的 RequestContext 之后。绑定租户的令牌只能用于该租户，
+// 因此无法通过请求头或子域名将已认证用户切换到其他租户。
+// 不带租户的令牌仅在其平台列于 platform_admins 时可以选择租户；在排除的路径上则不带租户继续处理。
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, _ := c.Locals(ctxutil.LocalsClaims).(*jwt.Claims)
+		id := resolve(c, claims)
+		if id == "" {
+			if cfg.Required && !excluded(c.Path()) {
+				return errors.Localized(response.CodeParamMissing, "tenant.required")
+			}
+			return c.Next()
+		}
+		if !ValidID(id) {
+			return errors.Localized(response.CodeParamInvalid, "tenant.invalid")
+		}
+		if claims != nil && claims.Tenant != id {
+			switch {
+			case claims.Tenant != "":
+				return errors.ErrForbidden()
+			case slices.Contains(cfg.PlatformAdmins, claims.Plat):
+				// Platform admins act on the requested tenant | 平台管理员操作所请求的租户
+			case excluded(c.Path()):
+				// Tenant-agnostic route, the token grants no tenant | 与租户无关的路由，令牌不授予租户
+				return c.Next()
+			default:
+				return errors.ErrForbidden()
+			}
+		}
+
+		c.Locals(ctxutil.LocalsTenantID, id)
+		c.SetUserContext(ctxutil.WithTenant(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// resolve returns the tenant of the first source that has one
+// resolve 返回第一个提供租户的来源中的租户
+func resolve(c *fiber.Ctx, claims *jwt.Claims) string {
+	for _, source := range cfg.Sources {
+		var id string
+		switch source {
+		case SourceJWT:
+			if claims != nil {
+				id = claims.Tenant
+			}
+		case SourceHeader:
+			id = strings.TrimSpace(c.Get(cfg.Header))
+		case SourceSubdomain:
+			id = subdomain(c.Hostname(), cfg.Domain)
+		}
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// subdomain returns the single label in front of domain, "www" is ignored
+// subdomain 返回 domain 前的单级标签，忽略 "www"
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+	if !ok || label == "" || label == "www" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// excluded reports whether path does not require a tenant
+// excluded 判断路径是否不要求租户
+func excluded(path string) bool {
+	if path == "/health" || strings.HasPrefix(path, "/health/") {
+		return true
+	}
+	return slices.ContainsFunc(cfg.Exclude, func(prefix string) bool {
+		return strings.HasPrefix(path, prefix)
+	})
+}
+
+// ValidID reports whether id is a usable tenant ID: 1-64 lowercase letters, digits or '_'
+// Tenant IDs end up unquoted in schema names and in client names, so nothing else is accepted.
+// ValidID 判断 id 是否为可用的租户 ID：1-64 个小写字母、数字或 '_'
+// 租户 ID 会以不加引号的形式用于 schema 名称以及客户端名称，因此不接受其他字符。
+func ValidID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
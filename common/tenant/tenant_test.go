@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	bizerrors "github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// newApp serves the resolved tenant, claims come from the X-Claims-Tenant and X-Claims-Plat test
+// headers and errors are answered with their business code
+func newApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(fiber.StatusBadRequest).SendString(strconv.Itoa(int(bizerrors.GetCode(err))))
+	}})
+	app.Use(func(c *fiber.Ctx) error {
+		if tenant, ok := c.GetReqHeaders()["X-Claims-Tenant"]; ok {
+			c.Locals(ctxutil.LocalsClaims, &jwt.Claims{ID: 1, Tenant: tenant[0], Plat: c.Get("X-Claims-Plat")})
+		}
+		return c.Next()
+	})
+	app.Use(Middleware())
+	app.Use(func(c *fiber.Ctx) error { return c.SendString("tenant=" + ctxutil.TenantID(c.UserContext())) })
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, host, path string, headers map[string]string) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	req.Host = host
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	Init(Config{Enabled: true, Sources: []string{SourceJWT, SourceHeader, SourceSubdomain}, Domain: "example.com", Required: true, Exclude: []string{"/public"}, PlatformAdmins: []string{"admin"}})
+	t.Cleanup(func() { Init(Config{}) })
+	app := newApp()
+
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{"header", "api.local", "/x", map[string]string{"X-Tenant-ID": "acme"}, "tenant=acme"},
+		{"subdomain", "globex.example.com:8080", "/x", nil, "tenant=globex"},
+		{"jwt first", "globex.example.com", "/x", map[string]string{"X-Claims-Tenant": "acme"}, "tenant=acme"},
+		{"www ignored", "www.example.com", "/x", nil, "2002"},
+		{"required", "api.local", "/x", nil, "2002"},
+		{"excluded", "api.local", "/public/info", nil, "tenant="},
+		{"health", "api.local", "/health", nil, "tenant="},
+		{"invalid", "api.local", "/x", map[string]string{"X-Tenant-ID": "a.b"}, "2003"},
+		{"uppercase", "api.local", "/x", map[string]string{"X-Tenant-ID": "Acme"}, "2003"},
+		{"dash", "api.local", "/x", map[string]string{"X-Tenant-ID": "acme-corp"}, "2003"},
+		{"tenantless token", "api.local", "/x", map[string]string{"X-Claims-Tenant": "", "X-Tenant-ID": "acme"}, "1004"},
+		{"tenantless token by subdomain", "acme.example.com", "/x", map[string]string{"X-Claims-Tenant": ""}, "1004"},
+		{"tenantless token on excluded path", "api.local", "/public/info", map[string]string{"X-Claims-Tenant": "", "X-Tenant-ID": "acme"}, "tenant="},
+		{"platform admin", "api.local", "/x", map[string]string{"X-Claims-Tenant": "", "X-Claims-Plat": "admin", "X-Tenant-ID": "acme"}, "tenant=acme"},
+		{"tenant token of admin platform", "api.local", "/x", map[string]string{"X-Claims-Tenant": "globex", "X-Claims-Plat": "admin", "X-Tenant-ID": "acme"}, "tenant=globex"},
+		{"header behind jwt", "api.local", "/x", map[string]string{"X-Claims-Tenant": "acme", "X-Tenant-ID": "globex"}, "tenant=acme"},
+	}
+	for _, tt := range tests {
+		if got := get(t, app, tt.host, tt.path, tt.headers); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	// With the header first, a token of another tenant is rejected | 请求头优先时，其他租户的令牌被拒绝
+	Init(Config{Enabled: true, Sources: []string{SourceHeader, SourceJWT}})
+	got := get(t, app, "api.local", "/x", map[string]string{"X-Claims-Tenant": "acme", "X-Tenant-ID": "globex"})
+	if got != "1004" {
+		t.Errorf("Expected a token of another tenant to be forbidden, got %q", got)
+	}
+}
+
+func TestDBWithoutTenant(t *testing.T) {
+	if _, err := DB(context.Background(), &struct{}{}); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant, got %v", err)
+	}
+
+	Init(Config{Mode: ModeDatabase})
+	t.Cleanup(func() { Init(Config{}) })
+	if _, err := Engine(ctxutil.WithTenant(context.Background(), "nobody"), &struct{}{}); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("Expected ErrUnknownTenant, got %v", err)
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"github.com/nuohe369/crab/boot"
-	_ "github.com/nuohe369/crab/module/testapi" // auto-register module
-	_ "github.com/nuohe369/crab/module/ws"      // auto-register module
+	_ "github.com/nuohe369/crab/module/admin"      // auto-register module
+	_ "github.com/nuohe369/crab/module/testapi"    // auto-register module
+	_ "github.com/nuohe369/crab/module/usercenter" // auto-register module
+	_ "github.com/nuohe369/crab/module/ws"         // auto-register module
 )
 
 func main() {
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/boot"
+	"github.com/nuohe369/crab/common/config"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/breaker"
+	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/health"
+)
+
+// Setup 注册所有路由
+func Setup(router fiber.Router) {
+	router.Get("/modules", Modules)
+	router.Get("/routes", Routes)
+	router.Get("/config", Config)
+	router.Get("/breakers", Breakers)
+	router.Get("/cron", CronJobs)
+	router.Get("/health", Health)
+}
+
+// route is one entry of GET /admin/routes
+type route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Name   string `json:"name,omitempty"`
+}
+
+// breakerStats is one entry of GET /admin/breakers
+type breakerStats struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Requests uint32 `json:"requests"`
+	Failures uint32 `json:"failures"`
+	Success  uint32 `json:"success"`
+}
+
+// Modules lists registered modules with their state and database dependencies
+func Modules(c *fiber.Ctx) error {
+	return response.OK(c, boot.ModuleInfos())
+}
+
+// Routes lists all routes of the running app, HEAD routes Fiber adds for GET are omitted
+func Routes(c *fiber.Ctx) error {
+	var routes []route
+	for _, r := range c.App().GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		routes = append(routes, route{Method: r.Method, Path: r.Path, Name: r.Name})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return response.OK(c, routes)
+}
+
+// Config returns the effective configuration with secrets redacted
+func Config(c *fiber.Ctx) error {
+	cfg, err := config.Redacted()
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, cfg)
+}
+
+// Breakers returns circuit breaker state and counts of the current interval
+func Breakers(c *fiber.Ctx) error {
+	stats := breaker.AllStats()
+	list := make([]breakerStats, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, breakerStats{
+			Name:     s.Name,
+			State:    s.State.String(),
+			Requests: s.Requests,
+			Failures: s.Failures,
+			Success:  s.Success,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return response.OK(c, list)
+}
+
+// CronJobs lists registered cron jobs with their next and previous run
+func CronJobs(c *fiber.Ctx) error {
+	return response.OK(c, cron.Jobs())
+}
+
+// Health runs every registered health checker and returns the details
+func Health(c *fiber.Ctx) error {
+	return response.OK(c, health.Check(c.UserContext()))
+}
@@ -0,0 +1,46 @@
+// Package admin provides runtime introspection endpoints for operators
+// It is `crab list` / `crab deps` over HTTP: registered modules and their state, routes,
+// the effective (redacted) configuration, circuit breakers, cron jobs and health details.
+// Every route requires an API key with the admin:read scope.
+// admin 包为运维人员提供运行时自省接口
+// 相当于 HTTP 版的 `crab list` / `crab deps`：已注册模块及其状态、路由、
+// 生效配置（已脱敏）、熔断器、定时任务和健康详情。所有路由都要求具有 admin:read 权限范围的 API 密钥。
+package admin
+
+import (
+	"github.com/nuohe369/crab/boot"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/module/admin/internal/handler"
+)
+
+// Scope is the API key scope required by the admin endpoints
+// Scope 是管理接口要求的 API 密钥权限范围
+const Scope = "admin:read"
+
+func init() {
+	boot.Register(&Module{})
+}
+
+type Module struct{}
+
+func (m *Module) Name() string {
+	return "admin"
+}
+
+func (m *Module) Models() []any {
+	return nil
+}
+
+func (m *Module) Init(ctx *boot.ModuleContext) error {
+	ctx.Use(middleware.APIKey(Scope))
+	handler.Setup(ctx.Router)
+	return nil
+}
+
+func (m *Module) Start() error {
+	return nil
+}
+
+func (m *Module) Stop() error {
+	return nil
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	stderrors "errors"
+
 	"github.com/nuohe369/crab/common/errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/nuohe369/crab/common/model"
 	"github.com/nuohe369/crab/common/request"
 	"github.com/nuohe369/crab/common/response"
 	"github.com/nuohe369/crab/module/testapi/internal/vo"
+	"github.com/nuohe369/crab/pkg/clientgen"
+	"github.com/nuohe369/crab/pkg/search"
 	"github.com/nuohe369/crab/pkg/snowflake"
 	"github.com/nuohe369/crab/pkg/util"
 )
@@ -19,7 +23,16 @@ func SetupArticle(router fiber.Router) {
 	g.Get("/:id", GetArticle)
 	g.Put("/", UpdateArticle)
 	g.Delete("/:id", DeleteArticle)
+	g.Post("/:id/restore", RestoreArticle)
 	g.Get("/", ListArticle)
+
+	// Types of the generated API clients (crab gen client) | 生成的 API 客户端的类型（crab gen client）
+	clientgen.Describe(CreateArticle, request.CreateArticleReq{}, fiber.Map{})
+	clientgen.Describe(GetArticle, nil, vo.ArticleVO{})
+	clientgen.Describe(UpdateArticle, request.UpdateArticleReq{}, fiber.Map{})
+	clientgen.Describe(DeleteArticle, nil, nil)
+	clientgen.Describe(RestoreArticle, nil, nil)
+	clientgen.Describe(ListArticle, request.ListArticleReq{}, response.PageData{})
 }
 
 // CreateArticle creates an article
@@ -46,7 +59,7 @@ func CreateArticle(c *fiber.Ctx) error {
 		Status:     req.Status,
 	}
 
-	_, err := model.GetDB(article).Insert(article)
+	_, err := model.DB(c.UserContext(), article).Insert(article)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -66,7 +79,7 @@ func GetArticle(c *fiber.Ctx) error {
 	}
 
 	article := &model.ExampleArticle{}
-	has, err := model.GetDB(article).ID(id).Get(article)
+	has, err := model.DB(c.UserContext(), article).ID(id).Get(article)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -91,7 +104,8 @@ func UpdateArticle(c *fiber.Ctx) error {
 		return errors.New(response.CodeParamMissing, "id required")
 	}
 
-	article := &model.ExampleArticle{}
+	// ID on the bean lets the update hook reindex the article | bean 上的 ID 供更新钩子重新索引文章
+	article := &model.ExampleArticle{ID: snowflake.SnowflakeID(id)}
 	cols := []string{}
 
 	if req.CategoryID != "" {
@@ -118,7 +132,17 @@ func UpdateArticle(c *fiber.Ctx) error {
 		return errors.New(response.CodeParamMissing, "nothing to update")
 	}
 
-	_, err := model.GetDB(article).ID(id).Cols(cols...).Update(article)
+	// Concurrent edits are rejected with 409 when the client sends the version it read
+	// 客户端提交读取到的版本时，并发修改会以 409 拒绝
+	if req.Version != nil {
+		article.Version = *req.Version
+		if err := model.UpdateWithVersion(c.UserContext(), article, id, cols...); err != nil {
+			return err
+		}
+		return response.OK(c, fiber.Map{"version": article.Version})
+	}
+
+	_, err := model.DB(c.UserContext(), article).ID(id).Cols(cols...).NoVersionCheck().Update(article)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -135,8 +159,8 @@ func DeleteArticle(c *fiber.Ctx) error {
 		return errors.ErrParamInvalid("参数解析失败")
 	}
 
-	article := &model.ExampleArticle{}
-	_, err := model.GetDB(article).ID(id).Delete(article)
+	article := &model.ExampleArticle{ID: snowflake.SnowflakeID(id)}
+	_, err := model.DB(c.UserContext(), article).ID(id).Delete(article)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -144,9 +168,26 @@ func DeleteArticle(c *fiber.Ctx) error {
 	return response.OK(c, nil)
 }
 
+// RestoreArticle restores a deleted article
+// RestoreArticle 恢复已删除的文章
+// POST /testapi/article/:id/restore
+func RestoreArticle(c *fiber.Ctx) error {
+	id := util.MustStringToInt64(c.Params("id"))
+	if id == 0 {
+		return errors.ErrParamInvalid("参数解析失败")
+	}
+
+	if err := model.Restore(c.UserContext(), &model.ExampleArticle{}, id); err != nil {
+		return err
+	}
+	search.Sync(&model.ExampleArticle{ID: snowflake.SnowflakeID(id)})
+
+	return response.OK(c, nil)
+}
+
 // ListArticle lists articles
 // ListArticle 文章列表
-// GET /testapi/article?page=1&size=10&user_id=xxx&category_id=xxx&status=1
+// GET /testapi/article?page=1&size=10&user_id=xxx&category_id=xxx&status=1&keyword=xxx&sort=created_at:desc
 func ListArticle(c *fiber.Ctx) error {
 	var req request.ListArticleReq
 	if err := c.QueryParser(&req); err != nil {
@@ -154,24 +195,42 @@ func ListArticle(c *fiber.Ctx) error {
 	}
 
 	article := &model.ExampleArticle{}
-	session := model.GetDB(article).NewSession()
+	session := model.GetDB(article).NewSession().Context(c.UserContext())
 	defer session.Close()
 
-	// Build query conditions | 构建查询条件
+	// Build query conditions, also used as search filters | 构建查询条件，同时作为搜索过滤条件
+	filters := map[string]any{}
 	if req.UserID != "" {
 		userID := snowflake.SnowflakeID(util.MustStringToInt64(req.UserID))
 		if userID.Valid() {
 			session.Where("user_id = ?", userID)
+			filters["user_id"] = userID
 		}
 	}
 	if req.CategoryID != "" {
 		categoryID := snowflake.SnowflakeID(util.MustStringToInt64(req.CategoryID))
 		if categoryID.Valid() {
 			session.Where("category_id = ?", categoryID)
+			filters["category_id"] = categoryID
 		}
 	}
 	if req.Status != nil {
 		session.Where("status = ?", *req.Status)
+		filters["status"] = *req.Status
+	}
+
+	// Keyword: ranked full-text search | 关键词：按相关度排序的全文搜索
+	if req.Keyword != "" {
+		list, total, err := search.Find[model.ExampleArticle](c.UserContext(), session, req.Query(filters))
+		switch {
+		case stderrors.Is(err, search.ErrInvalidQuery):
+			return errors.ErrParamInvalid(err.Error())
+		case stderrors.Is(err, search.ErrNotConfigured):
+			return errors.ErrParamInvalid("搜索未配置")
+		case err != nil:
+			return errors.Wrap(response.CodeServerError, err)
+		}
+		return response.OKList(c, vo.ToArticleVOList(list), total, req.GetPage(), req.GetSize())
 	}
 
 	var list []model.ExampleArticle
@@ -7,6 +7,7 @@ import (
 	"github.com/nuohe369/crab/common/request"
 	"github.com/nuohe369/crab/common/response"
 	"github.com/nuohe369/crab/module/testapi/internal/vo"
+	"github.com/nuohe369/crab/pkg/dbcache"
 	"github.com/nuohe369/crab/pkg/util"
 )
 
@@ -39,7 +40,7 @@ func CreateCategory(c *fiber.Ctx) error {
 		Sort: req.Sort,
 	}
 
-	_, err := model.GetDB(category).Insert(category)
+	_, err := model.DB(c.UserContext(), category).Insert(category)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -59,7 +60,7 @@ func GetCategory(c *fiber.Ctx) error {
 	}
 
 	category := &model.ExampleCategory{}
-	has, err := model.GetDB(category).ID(id).Get(category)
+	has, err := model.DB(c.UserContext(), category).ID(id).Get(category)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -104,7 +105,7 @@ func UpdateCategory(c *fiber.Ctx) error {
 		return errors.New(response.CodeParamMissing, "nothing to update")
 	}
 
-	_, err := model.GetDB(category).ID(id).Cols(cols...).Update(category)
+	_, err := model.DB(c.UserContext(), category).ID(id).Cols(cols...).Update(category)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -122,7 +123,7 @@ func DeleteCategory(c *fiber.Ctx) error {
 	}
 
 	category := &model.ExampleCategory{}
-	_, err := model.GetDB(category).ID(id).Delete(category)
+	_, err := model.DB(c.UserContext(), category).ID(id).Delete(category)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -142,16 +143,12 @@ func ListCategory(c *fiber.Ctx) error {
 	category := &model.ExampleCategory{}
 	var list []model.ExampleCategory
 
-	total, err := model.GetDB(category).Count(category)
-	if err != nil {
-		return errors.ErrDBError(err)
-	}
-
-	err = model.GetDB(category).
+	// Served from the query cache until the category table is written | 在分类表被写入前由查询缓存返回
+	total, err := dbcache.New(c.UserContext(), model.GetDB(category)).
 		Limit(req.GetSize(), req.GetOffset()).
 		Asc("sort").
 		Desc("created_at").
-		Find(&list)
+		FindAndCount(&list)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/nuohe369/crab/common/response"
 	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/timex"
 )
 
 // Store recently consumed messages (for test verification)
@@ -40,40 +41,28 @@ func SetupMQ(router fiber.Router) {
 	mqGroup.Get("/consumed", MQConsumed)
 	mqGroup.Get("/status", MQStatus)
 
-	// Start consumer
-	go startConsumer()
+	// Started by boot after the modules, stopped on shutdown
+	mq.RegisterConsumer("testapi:demo", "testapi-consumer", consume)
 }
 
-// startConsumer starts test consumer
-func startConsumer() {
-	ctx := context.Background()
-	topic := "testapi:demo"
-	group := "testapi-consumer"
-
-	log.Printf("testapi: starting MQ consumer, topic=%s, group=%s", topic, group)
-
-	err := mq.Consume(ctx, topic, group, func(ctx context.Context, msg *mq.Message) error {
-		log.Printf("testapi: received message id=%s, payload=%s", msg.ID, string(msg.Payload))
-
-		// Save to memory
-		consumedMu.Lock()
-		consumedMessages = append(consumedMessages, ConsumedMessage{
-			ID:        msg.ID,
-			Payload:   string(msg.Payload),
-			ConsumeAt: time.Now(),
-		})
-		// Remove oldest if exceeds limit
-		if len(consumedMessages) > maxMessages {
-			consumedMessages = consumedMessages[1:]
-		}
-		consumedMu.Unlock()
-
-		return nil
-	})
+// consume handles messages of the test consumer
+func consume(ctx context.Context, msg *mq.Message) error {
+	log.Printf("testapi: received message id=%s, payload=%s", msg.ID, string(msg.Payload))
 
-	if err != nil {
-		log.Printf("testapi: MQ consumer exited: %v", err)
+	// Save to memory
+	consumedMu.Lock()
+	consumedMessages = append(consumedMessages, ConsumedMessage{
+		ID:        msg.ID,
+		Payload:   string(msg.Payload),
+		ConsumeAt: time.Now(),
+	})
+	// Remove oldest if exceeds limit
+	if len(consumedMessages) > maxMessages {
+		consumedMessages = consumedMessages[1:]
 	}
+	consumedMu.Unlock()
+
+	return nil
 }
 
 // MQPublish publishes test message
@@ -84,7 +73,7 @@ func MQPublish(c *fiber.Ctx) error {
 
 	payload, _ := json.Marshal(map[string]any{
 		"content": content,
-		"time":    time.Now().Format("2006-01-02 15:04:05"),
+		"time":    timex.Format(time.Now()),
 	})
 
 	err := mq.Publish(context.Background(), "testapi:demo", payload)
@@ -109,7 +98,7 @@ func MQPublishDelay(c *fiber.Ctx) error {
 
 	payload, _ := json.Marshal(map[string]any{
 		"content":    content,
-		"publish_at": time.Now().Format("2006-01-02 15:04:05"),
+		"publish_at": timex.Format(time.Now()),
 		"delay_sec":  delaySec,
 	})
 
@@ -125,7 +114,7 @@ func MQPublishDelay(c *fiber.Ctx) error {
 		"topic":      "testapi:demo",
 		"content":    content,
 		"delay_sec":  delaySec,
-		"execute_at": executeAt.Format("2006-01-02 15:04:05"),
+		"execute_at": timex.Format(executeAt),
 	})
 }
 
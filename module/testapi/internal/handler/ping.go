@@ -6,6 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/nuohe369/crab/common/middleware"
 	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/timex"
 )
 
 // SetupPing 注册 Ping 和限流测试路由
@@ -27,7 +28,7 @@ func SetupPing(router fiber.Router) {
 func Ping(c *fiber.Ctx) error {
 	return response.OK(c, fiber.Map{
 		"message": "pong",
-		"time":    time.Now().Format("2006-01-02 15:04:05"),
+		"time":    timex.Format(time.Now()),
 	})
 }
 
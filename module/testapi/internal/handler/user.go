@@ -7,6 +7,7 @@ import (
 	"github.com/nuohe369/crab/common/request"
 	"github.com/nuohe369/crab/common/response"
 	"github.com/nuohe369/crab/module/testapi/internal/vo"
+	"github.com/nuohe369/crab/pkg/crypto"
 	"github.com/nuohe369/crab/pkg/util"
 )
 
@@ -15,6 +16,7 @@ import (
 func SetupUser(router fiber.Router) {
 	g := router.Group("/user")
 	g.Post("/", CreateUser)
+	g.Get("/cursor", ListUserCursor) // before /:id | 需在 /:id 之前注册
 	g.Get("/:id", GetUser)
 	g.Put("/", UpdateUser)
 	g.Delete("/:id", DeleteUser)
@@ -30,11 +32,19 @@ func SetupUser(router fiber.Router) {
 func CreateUser(c *fiber.Ctx) error {
 	var req request.CreateUserReq
 	if err := c.BodyParser(&req); err != nil {
-		return errors.ErrParamInvalid("参数解析失败")
+		return errors.ErrBodyInvalid()
 	}
 
-	if req.Username == "" || req.Nickname == "" || req.Password == "" {
-		return errors.New(response.CodeParamMissing, "username, nickname, password required")
+	switch {
+	case req.Username == "":
+		return errors.ErrRequired("username")
+	case req.Nickname == "":
+		return errors.ErrRequired("nickname")
+	case req.Password == "":
+		return errors.ErrRequired("password")
+	}
+	if err := crypto.CheckPasswordPolicy(req.Password, req.Username, req.Nickname); err != nil {
+		return errors.ErrParamInvalid(err.Error())
 	}
 
 	user := &model.ExampleUser{
@@ -45,7 +55,7 @@ func CreateUser(c *fiber.Ctx) error {
 		return errors.Wrap(response.CodeServerError, err)
 	}
 
-	_, err := model.GetDB(user).Insert(user)
+	_, err := model.DB(c.UserContext(), user).Insert(user)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -65,7 +75,7 @@ func GetUser(c *fiber.Ctx) error {
 	}
 
 	user := &model.ExampleUser{}
-	has, err := model.GetDB(user).ID(id).Get(user)
+	has, err := model.DB(c.UserContext(), user).ID(id).Get(user)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -106,7 +116,7 @@ func UpdateUser(c *fiber.Ctx) error {
 		return errors.New(response.CodeParamMissing, "nothing to update")
 	}
 
-	_, err := model.GetDB(user).ID(id).Cols(cols...).Update(user)
+	_, err := model.DB(c.UserContext(), user).ID(id).Cols(cols...).Update(user)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -124,7 +134,7 @@ func DeleteUser(c *fiber.Ctx) error {
 	}
 
 	user := &model.ExampleUser{}
-	_, err := model.GetDB(user).ID(id).Delete(user)
+	_, err := model.DB(c.UserContext(), user).ID(id).Delete(user)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
@@ -144,12 +154,12 @@ func ListUser(c *fiber.Ctx) error {
 	user := &model.ExampleUser{}
 	var list []model.ExampleUser
 
-	total, err := model.GetDB(user).Count(user)
+	total, err := model.DB(c.UserContext(), user).Count(user)
 	if err != nil {
 		return errors.ErrDBError(err)
 	}
 
-	err = model.GetDB(user).
+	err = model.DB(c.UserContext(), user).
 		Limit(req.GetSize(), req.GetOffset()).
 		Desc("created_at").
 		Find(&list)
@@ -159,3 +169,28 @@ func ListUser(c *fiber.Ctx) error {
 
 	return response.OKList(c, vo.ToUserVOList(list), total, req.GetPage(), req.GetSize())
 }
+
+// ListUserCursor lists users with cursor pagination
+// ListUserCursor 用户列表（游标分页）
+// GET /testapi/user/cursor?cursor=&size=10
+func ListUserCursor(c *fiber.Ctx) error {
+	var req request.CursorReq
+	if err := c.QueryParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+
+	user := &model.ExampleUser{}
+	list, next, hasMore, err := model.FindCursor(model.DB(c.UserContext(), user).Table(user),
+		model.Keyset{SortColumn: "created_at"}, &req,
+		func(u *model.ExampleUser) request.Cursor {
+			return request.Cursor{ID: u.ID.Int64(), Sort: u.CreatedAt}
+		})
+	if err == request.ErrInvalidCursor {
+		return errors.ErrInvalidField("cursor")
+	}
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+
+	return response.Cursor(c, vo.ToUserVOList(list), next, hasMore)
+}
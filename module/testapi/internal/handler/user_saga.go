@@ -49,7 +49,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 
 				// Query articles to be deleted (for compensation)
 				// 查询要删除的文章（用于补偿）
-				err := model.GetDB(article).
+				err := model.DB(ctx, article).
 					Where("user_id = ?", id).
 					Find(&deletedArticles)
 				if err != nil {
@@ -62,7 +62,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 				}
 
 				// Delete articles | 删除文章
-				_, err = model.GetDB(article).
+				_, err = model.DB(ctx, article).
 					Where("user_id = ?", id).
 					Delete(&model.ExampleArticle{})
 				return err
@@ -73,7 +73,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 				if len(deletedArticles) > 0 {
 					article := &model.ExampleArticle{}
 					for _, a := range deletedArticles {
-						_, err := model.GetDB(article).Insert(&a)
+						_, err := model.DB(ctx, article).Insert(&a)
 						if err != nil {
 							return err
 						}
@@ -91,7 +91,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 
 				// Query user info (for compensation)
 				// 查询用户信息（用于补偿）
-				has, err := model.GetDB(user).ID(id).Get(user)
+				has, err := model.DB(ctx, user).ID(id).Get(user)
 				if err != nil {
 					return err
 				}
@@ -106,7 +106,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 				}
 
 				// Delete user | 删除用户
-				_, err = model.GetDB(user).ID(id).Delete(user)
+				_, err = model.DB(ctx, user).ID(id).Delete(user)
 				return err
 			},
 			Compensate: func(ctx context.Context) error {
@@ -114,7 +114,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 				// 补偿：恢复删除的用户
 				if deletedUser != nil {
 					user := &model.ExampleUser{}
-					_, err := model.GetDB(user).Insert(deletedUser)
+					_, err := model.DB(ctx, user).Insert(deletedUser)
 					return err
 				}
 				return nil
@@ -133,7 +133,7 @@ func DeleteUserWithSaga(c *fiber.Ctx) error {
 		})
 
 	// Execute Saga | 执行 Saga
-	if err := saga.Execute(c.Context()); err != nil {
+	if err := saga.Execute(c.UserContext()); err != nil {
 		return errors.Wrap(response.CodeServerError, err)
 	}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/nuohe369/crab/common/response"
 	"github.com/nuohe369/crab/common/service"
+	"github.com/nuohe369/crab/pkg/timex"
 	"github.com/nuohe369/crab/pkg/ws"
 )
 
@@ -36,7 +37,7 @@ func PushToUser(c *fiber.Ctx) error {
 		Type: "push_msg",
 		Payload: map[string]any{
 			"content": content,
-			"time":    time.Now().Format("2006-01-02 15:04:05"),
+			"time":    timex.Format(time.Now()),
 		},
 	})
 	if err != nil {
@@ -60,7 +61,7 @@ func Broadcast(c *fiber.Ctx) error {
 		Type: "broadcast_msg",
 		Payload: map[string]any{
 			"content": content,
-			"time":    time.Now().Format("2006-01-02 15:04:05"),
+			"time":    timex.Format(time.Now()),
 		},
 	})
 	if err != nil {
@@ -1,11 +1,13 @@
 package vo
 
+import "github.com/nuohe369/crab/pkg/timex"
+
 // CategoryVO represents the category view object
 // CategoryVO 分类视图对象
 type CategoryVO struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Sort      int    `json:"sort"`
-	Status    int    `json:"status"`
-	CreatedAt string `json:"created_at"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Sort      int            `json:"sort"`
+	Status    int            `json:"status"`
+	CreatedAt timex.JSONTime `json:"created_at"`
 }
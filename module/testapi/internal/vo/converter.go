@@ -1,6 +1,9 @@
 package vo
 
-import "github.com/nuohe369/crab/common/model"
+import (
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/pkg/timex"
+)
 
 // ToUserVO converts model.ExampleUser to UserVO
 // ToUserVO 将 model.ExampleUser 转换为 UserVO
@@ -10,7 +13,7 @@ func ToUserVO(u *model.ExampleUser) UserVO {
 		Username:  u.Username,
 		Nickname:  u.Nickname,
 		Status:    u.Status,
-		CreatedAt: u.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedAt: timex.JSON(u.CreatedAt),
 	}
 }
 
@@ -35,7 +38,8 @@ func ToArticleVO(a *model.ExampleArticle) ArticleVO {
 		Content:    a.Content,
 		ViewCount:  a.ViewCount,
 		Status:     a.Status,
-		CreatedAt:  a.CreatedAt.Format("2006-01-02 15:04:05"),
+		Version:    a.Version,
+		CreatedAt:  timex.JSON(a.CreatedAt),
 	}
 }
 
@@ -57,7 +61,7 @@ func ToCategoryVO(c *model.ExampleCategory) CategoryVO {
 		Name:      c.Name,
 		Sort:      c.Sort,
 		Status:    c.Status,
-		CreatedAt: c.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedAt: timex.JSON(c.CreatedAt),
 	}
 }
 
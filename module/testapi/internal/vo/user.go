@@ -1,11 +1,13 @@
 package vo
 
+import "github.com/nuohe369/crab/pkg/timex"
+
 // UserVO represents the user view object
 // UserVO 用户视图对象
 type UserVO struct {
-	ID        string `json:"id"`
-	Username  string `json:"username"`
-	Nickname  string `json:"nickname"`
-	Status    int    `json:"status"`
-	CreatedAt string `json:"created_at"`
+	ID        string         `json:"id"`
+	Username  string         `json:"username"`
+	Nickname  string         `json:"nickname"`
+	Status    int            `json:"status"`
+	CreatedAt timex.JSONTime `json:"created_at"`
 }
@@ -1,13 +1,43 @@
 package testapi
 
 import (
+	"context"
+	"time"
+
 	"github.com/nuohe369/crab/boot"
 	"github.com/nuohe369/crab/common/model"
 	"github.com/nuohe369/crab/module/testapi/internal/handler"
+	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/seed"
+	"xorm.io/xorm"
 )
 
 func init() {
 	boot.Register(&Module{})
+	seed.Register(seed.Seed{
+		Name:   "testapi_example_categories",
+		Module: "testapi",
+		Envs:   []string{"dev"},
+		Run:    seedCategories,
+	})
+}
+
+// seedCategories adds the example categories that are missing
+// seedCategories 添加缺失的示例分类
+func seedCategories(ctx context.Context, s *xorm.Session) error {
+	for i, name := range []string{"News", "Tech", "Life"} {
+		has, err := s.Where("name = ?", name).Exist(new(model.ExampleCategory))
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := s.Insert(&model.ExampleCategory{Name: name, Sort: i + 1, Status: 1}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type Module struct{}
@@ -26,7 +56,11 @@ func (m *Module) Models() []any {
 
 func (m *Module) Init(ctx *boot.ModuleContext) error {
 	handler.Setup(ctx.Router)
-	return nil
+	if ctx.DryRun || cron.Get() == nil {
+		return nil
+	}
+	// Deleted articles are kept 30 days | 已删除的文章保留 30 天
+	return cron.Register(model.PurgeJob("testapi_purge_articles", "0 30 3 * * *", 30*24*time.Hour, new(model.ExampleArticle)))
 }
 
 func (m *Module) Start() error {
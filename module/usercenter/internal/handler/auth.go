@@ -0,0 +1,221 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	ucmodel "github.com/nuohe369/crab/module/usercenter/internal/model"
+	"github.com/nuohe369/crab/module/usercenter/internal/vo"
+	"github.com/nuohe369/crab/pkg/crypto"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+// RegisterReq represents the registration request
+// RegisterReq 注册请求
+type RegisterReq struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Nickname string `json:"nickname"` // Defaults to username | 默认为用户名
+	Password string `json:"password"`
+}
+
+// LoginReq represents the login request
+// LoginReq 登录请求
+type LoginReq struct {
+	Account  string `json:"account"` // Username or email | 用户名或邮箱
+	Password string `json:"password"`
+}
+
+// Register creates an account and logs it in
+// A taken username and a taken email get the same error. Registration still tells that one of them is
+// taken, which is accepted: it is rate limited per IP, and hiding it would need email verification first.
+// Register 创建账号并登录
+// 用户名和邮箱已被占用时返回相同错误。注册仍会暴露其中之一已被占用，这是可接受的：
+// 注册按 IP 限流，而隐藏这一点需要先验证邮箱。
+// POST /usercenter/register
+func Register(c *fiber.Ctx) error {
+	var req RegisterReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.Nickname = strings.TrimSpace(req.Nickname); req.Nickname == "" {
+		req.Nickname = req.Username
+	}
+
+	switch {
+	case req.Username == "":
+		return errors.ErrRequired("username")
+	case req.Email == "":
+		return errors.ErrRequired("email")
+	case req.Password == "":
+		return errors.ErrRequired("password")
+	}
+	if !validEmail(req.Email) {
+		return errors.ErrInvalidField("email")
+	}
+	if err := crypto.CheckPasswordPolicy(req.Password, req.Username, req.Email, req.Nickname); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+
+	u, err := findUser(c, "username = ? OR email = ?", req.Username, req.Email)
+	if err != nil {
+		return err
+	}
+	if u != nil {
+		return errors.Localized(response.CodeUserExists, "usercenter.account_exists")
+	}
+
+	u = &ucmodel.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Nickname: req.Nickname,
+		Status:   ucmodel.StatusActive,
+	}
+	if err := u.SetPassword(req.Password); err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	db, err := model.GetDBSafe(u)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(c.UserContext()).Insert(u); err != nil {
+		return errors.ErrDBError(err)
+	}
+
+	token, err := issueToken(c, u)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, fiber.Map{
+		"user":  vo.ToUserVO(u),
+		"token": token,
+	})
+}
+
+// Login verifies the credentials and issues a token
+// Unknown accounts and wrong passwords get the same error, and unknown accounts are checked against
+// a dummy hash so the response time does not tell them apart either.
+// Login 校验凭据并签发令牌
+// 账号不存在和密码错误返回相同错误，且账号不存在时也校验一个占位哈希，使响应时间同样无法区分二者。
+// POST /usercenter/login
+func Login(c *fiber.Ctx) error {
+	var req LoginReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	req.Account = strings.TrimSpace(req.Account)
+	switch {
+	case req.Account == "":
+		return errors.ErrRequired("account")
+	case req.Password == "":
+		return errors.ErrRequired("password")
+	}
+
+	u, err := findUser(c, "username = ? OR email = ?", req.Account, strings.ToLower(req.Account))
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		crypto.VerifyPassword(req.Password, dummyHash())
+		return errors.Localized(response.CodePasswordWrong, "usercenter.invalid_credentials")
+	}
+	ok, rehashed := u.CheckPassword(req.Password)
+	if !ok {
+		return errors.Localized(response.CodePasswordWrong, "usercenter.invalid_credentials")
+	}
+	if !u.IsActive() {
+		return errors.FromCode(response.CodeUserDisabled)
+	}
+
+	now := time.Now()
+	u.LastLoginAt = &now
+	cols := []string{"last_login_at"}
+	if rehashed {
+		cols = append(cols, "password")
+	}
+	db, err := model.GetDBSafe(u)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(c.UserContext()).ID(u.ID).Cols(cols...).Update(u); err != nil {
+		return errors.ErrDBError(err)
+	}
+
+	token, err := issueToken(c, u)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, token)
+}
+
+// Refresh exchanges a valid or recently expired token for a new one
+// Tokens of disabled users, or issued before the last password change, are rejected.
+// Refresh 用有效或刚过期的令牌换取新令牌
+// 已禁用用户的令牌，或早于最近一次修改密码签发的令牌会被拒绝。
+// POST /usercenter/token/refresh
+func Refresh(c *fiber.Ctx) error {
+	mgr := jwt.Get()
+	if mgr == nil {
+		return errors.ErrServerError("JWT not initialized")
+	}
+	raw, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || raw == "" {
+		return errors.ErrUnauthorized()
+	}
+	claims, err := mgr.Parse(raw)
+	if err != nil {
+		if !stderrors.Is(err, jwt.ErrExpiredToken) {
+			return errors.FromCode(response.CodeTokenInvalid)
+		}
+		if claims.ExpiresAt == nil || time.Since(claims.ExpiresAt.Time) > cfg.RefreshWindow {
+			return errors.Localized(response.CodeTokenExpired, "usercenter.refresh_expired")
+		}
+	}
+	if claims.Plat != cfg.Plat {
+		return errors.FromCode(response.CodeTokenInvalid)
+	}
+
+	u, err := findUser(c, "id = ?", claims.ID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return errors.ErrUnauthorized()
+	}
+	if !u.IsActive() {
+		return errors.FromCode(response.CodeUserDisabled)
+	}
+	// IssuedAt has second precision | IssuedAt 精度为秒
+	if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(u.PasswordChangedAt.Truncate(time.Second)) {
+		return errors.Localized(response.CodeTokenExpired, "usercenter.refresh_expired")
+	}
+
+	token, err := mgr.GenerateForTenant(claims.ID, claims.Plat, claims.Tenant)
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	return response.OK(c, vo.TokenVO{Token: token, ExpiresIn: int64(mgr.Expire().Seconds())})
+}
+
+// dummyHash is verified for unknown accounts so logins take as long as for known ones
+// dummyHash 在账号不存在时参与校验，使登录耗时与账号存在时相同
+var dummyHash = sync.OnceValue(func() string {
+	hashed, _ := crypto.HashPassword("usercenter-dummy-password")
+	return hashed
+})
+
+// validEmail checks that s is a bare email address
+// validEmail 检查 s 是否为不带名称的邮箱地址
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
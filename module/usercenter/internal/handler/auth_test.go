@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nuohe369/crab/boot"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/module/usercenter"
+	"github.com/nuohe369/crab/module/usercenter/internal/vo"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+const password = "Crab-Passw0rd!"
+
+func register(a *boot.TestApp, username, email string) *boot.TestResponse {
+	return a.Post("/usercenter/register", map[string]any{"username": username, "email": email, "password": password})
+}
+
+func TestRegister(t *testing.T) {
+	a := boot.NewTestApp(t, &usercenter.Module{})
+
+	var got struct {
+		User  vo.UserVO  `json:"user"`
+		Token vo.TokenVO `json:"token"`
+	}
+	register(a, "alice", " Alice@Example.com ").AssertOK().Decode(&got)
+	if got.User.Username != "alice" || got.User.Email != "alice@example.com" || got.User.Nickname != "alice" {
+		t.Errorf("user = %+v", got.User)
+	}
+	claims, err := jwt.Get().Parse(got.Token.Token)
+	if err != nil || claims.Plat != "user" || got.Token.ExpiresIn != 3600 {
+		t.Errorf("token = %+v, claims = %+v, %v", got.Token, claims, err)
+	}
+
+	// A taken username and a taken email are not told apart | 用户名和邮箱被占用时不加区分
+	byName := register(a, "alice", "other@example.com").AssertCode(response.CodeUserExists)
+	byEmail := register(a, "bob", "alice@example.com").AssertCode(response.CodeUserExists)
+	if byName.Msg != byEmail.Msg {
+		t.Errorf("messages differ: %q, %q", byName.Msg, byEmail.Msg)
+	}
+
+	register(a, "", "carol@example.com").AssertCode(response.CodeParamMissing)
+	register(a, "carol", "not-an-email").AssertCode(response.CodeParamInvalid)
+	a.Post("/usercenter/register", map[string]any{"username": "carol", "email": "carol@example.com", "password": "short"}).
+		AssertCode(response.CodeParamInvalid)
+}
+
+func TestLogin(t *testing.T) {
+	a := boot.NewTestApp(t, &usercenter.Module{})
+	register(a, "alice", "alice@example.com").AssertOK()
+
+	login := func(account, password string) *boot.TestResponse {
+		return a.Post("/usercenter/login", map[string]any{"account": account, "password": password})
+	}
+
+	for _, account := range []string{"alice", "ALICE@example.com"} {
+		var token vo.TokenVO
+		login(account, password).AssertOK().Decode(&token)
+		if _, err := jwt.Get().Parse(token.Token); err != nil {
+			t.Errorf("%s: token invalid: %v", account, err)
+		}
+	}
+
+	// Unknown accounts and wrong passwords get the same error | 账号不存在和密码错误返回相同错误
+	wrong := login("alice", "Wrong-Passw0rd!").AssertCode(response.CodePasswordWrong)
+	unknown := login("nobody", password).AssertCode(response.CodePasswordWrong)
+	if wrong.Msg != unknown.Msg {
+		t.Errorf("messages differ: %q, %q", wrong.Msg, unknown.Msg)
+	}
+	login("", password).AssertCode(response.CodeParamMissing)
+}
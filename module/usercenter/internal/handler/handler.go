@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/i18n"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/oauth"
+	"github.com/nuohe369/crab/common/response"
+	ucmodel "github.com/nuohe369/crab/module/usercenter/internal/model"
+	"github.com/nuohe369/crab/module/usercenter/internal/vo"
+	"github.com/nuohe369/crab/pkg/captcha"
+	"github.com/nuohe369/crab/pkg/jwt"
+)
+
+func init() {
+	i18n.Register("en", map[string]string{
+		"usercenter.invalid_credentials": "Invalid account or password",
+		"usercenter.account_exists":      "Username or email already registered",
+		"usercenter.reset_invalid":       "Reset link is invalid or expired",
+		"usercenter.refresh_expired":     "Token can no longer be refreshed, please log in again",
+	})
+	i18n.Register("zh", map[string]string{
+		"usercenter.invalid_credentials": "账号或密码错误",
+		"usercenter.account_exists":      "用户名或邮箱已被注册",
+		"usercenter.reset_invalid":       "重置链接无效或已过期",
+		"usercenter.refresh_expired":     "令牌已无法刷新，请重新登录",
+	})
+}
+
+// Config represents the user center configuration
+// Config 表示用户中心配置
+type Config struct {
+	Plat          string        // JWT platform of issued tokens, default "user" | 签发令牌的 JWT 平台，默认 "user"
+	LoginMax      int           // Login, register and reset attempts per IP within LoginWindow, default 10 | 每个 IP 在 LoginWindow 内的登录、注册和重置次数，默认 10
+	LoginWindow   time.Duration // Rate limit window, default 1m | 限流窗口，默认 1m
+	RefreshWindow time.Duration // How long after expiry a token can be refreshed, default 7 days | 令牌过期后仍可刷新的时长，默认 7 天
+	ResetURL      string        // Password reset page, the token is appended as ?token= | 密码重置页面，令牌以 ?token= 追加
+	ResetTTL      time.Duration // Reset link lifetime, default 30m | 重置链接有效期，默认 30m
+	Captcha       bool          // Require an image captcha to log in and register | 登录和注册需要图片验证码
+	OAuth         bool          // Log in and register with the providers of common/oauth | 使用 common/oauth 的登录方式登录和注册
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Plat == "" {
+		c.Plat = "user"
+	}
+	if c.LoginMax <= 0 {
+		c.LoginMax = 10
+	}
+	if c.LoginWindow <= 0 {
+		c.LoginWindow = time.Minute
+	}
+	if c.RefreshWindow <= 0 {
+		c.RefreshWindow = 7 * 24 * time.Hour
+	}
+	if c.ResetTTL <= 0 {
+		c.ResetTTL = 30 * time.Minute
+	}
+	return c
+}
+
+// cfg is the active configuration | cfg 是当前生效的配置
+var cfg = Config{}.withDefaults()
+
+// Setup 注册所有路由
+func Setup(router fiber.Router, c Config) {
+	cfg = c.withDefaults()
+
+	guard := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.Captcha {
+		router.Get("/captcha", captcha.Handler())
+		guard = captcha.Require()
+	}
+	if cfg.OAuth {
+		oauth.SetResolver(resolveOAuth)
+	}
+	router.Post("/register", limit("register"), guard, Register)
+	router.Post("/login", limit("login"), guard, Login)
+	router.Post("/token/refresh", Refresh)
+	router.Post("/password/forgot", limit("forgot"), ForgotPassword)
+	router.Post("/password/reset", limit("reset"), ResetPassword)
+
+	auth := router.Group("", middleware.RequireAuth(cfg.Plat))
+	auth.Get("/profile", GetProfile)
+	auth.Put("/profile", UpdateProfile)
+	auth.Put("/password", ChangePassword)
+}
+
+// limit returns the per-IP rate limit of an unauthenticated endpoint
+// limit 返回未认证接口的按 IP 限流中间件
+func limit(name string) fiber.Handler {
+	return middleware.RateLimitWithConfig(middleware.RateLimitConfig{
+		Max:    cfg.LoginMax,
+		Window: cfg.LoginWindow,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "usercenter:" + name + ":" + c.IP()
+		},
+	})
+}
+
+// issueToken signs a token for u, scoped to the tenant of the request
+// issueToken 为 u 签发令牌，并限定在请求所属租户内
+func issueToken(c *fiber.Ctx, u *ucmodel.User) (vo.TokenVO, error) {
+	mgr := jwt.Get()
+	if mgr == nil {
+		return vo.TokenVO{}, errors.ErrServerError("JWT not initialized")
+	}
+	token, err := mgr.GenerateForTenant(u.ID.Int64(), cfg.Plat, ctxutil.TenantID(c.UserContext()))
+	if err != nil {
+		return vo.TokenVO{}, errors.Wrap(response.CodeServerError, err)
+	}
+	return vo.TokenVO{Token: token, ExpiresIn: int64(mgr.Expire().Seconds())}, nil
+}
+
+// currentUser loads the authenticated user
+// currentUser 加载当前认证用户
+func currentUser(c *fiber.Ctx) (*ucmodel.User, error) {
+	id, ok := c.Locals(ctxutil.LocalsUserID).(int64)
+	if !ok || id == 0 {
+		return nil, errors.ErrUnauthorized()
+	}
+	u, err := findUser(c, "id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.ErrUserNotFound()
+	}
+	if !u.IsActive() {
+		return nil, errors.FromCode(response.CodeUserDisabled)
+	}
+	return u, nil
+}
+
+// findUser returns the user matching the condition, nil when there is none
+// findUser 返回满足条件的用户，不存在时返回 nil
+func findUser(c *fiber.Ctx, query string, args ...any) (*ucmodel.User, error) {
+	u := &ucmodel.User{}
+	db, err := model.GetDBSafe(u)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	has, err := db.Context(c.UserContext()).Where(query, args...).Get(u)
+	if err != nil {
+		return nil, errors.ErrDBError(err)
+	}
+	if !has {
+		return nil, nil
+	}
+	return u, nil
+}
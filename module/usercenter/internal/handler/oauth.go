@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/oauth"
+	"github.com/nuohe369/crab/common/response"
+	ucmodel "github.com/nuohe369/crab/module/usercenter/internal/model"
+	"github.com/nuohe369/crab/pkg/util"
+)
+
+// resolveOAuth resolves the user center account of a social login
+// A new identity with a verified email logs in to the account of that email, or registers one.
+// Identities without a verified email (e.g. WeChat) must be linked from a logged in account first.
+// resolveOAuth 解析社交登录对应的用户中心账号
+// 带已验证邮箱的新身份登录该邮箱的账号，不存在时注册新账号。
+// 没有已验证邮箱的身份（如微信）需先在已登录账号中绑定。
+func resolveOAuth(ctx context.Context, p *oauth.Profile, userID int64) (int64, error) {
+	db, err := model.GetDBSafe(&ucmodel.User{})
+	if err != nil {
+		return 0, errors.ErrDBError(err)
+	}
+	u := &ucmodel.User{}
+	var has bool
+	switch {
+	case userID != 0:
+		has, err = db.Context(ctx).ID(userID).Get(u)
+	case p.Email != "" && p.EmailVerified:
+		has, err = db.Context(ctx).Where("email = ?", strings.ToLower(p.Email)).Get(u)
+	default:
+		return 0, errors.FromCode(response.CodeOAuthNotLinked)
+	}
+	if err != nil {
+		return 0, errors.ErrDBError(err)
+	}
+
+	now := time.Now()
+	if !has {
+		if userID != 0 {
+			return 0, errors.ErrUserNotFound()
+		}
+		u = &ucmodel.User{
+			Username:    p.Provider + "_" + strings.ToLower(util.RandomString(10)),
+			Email:       strings.ToLower(p.Email),
+			Nickname:    nickname(p),
+			Avatar:      p.Avatar,
+			Status:      ucmodel.StatusActive,
+			LastLoginAt: &now,
+		}
+		// No password can be guessed until the user sets one with a reset link | 用户通过重置链接设置密码前无法猜中密码
+		if err := u.SetPassword(util.RandomString(32)); err != nil {
+			return 0, errors.Wrap(response.CodeServerError, err)
+		}
+		if _, err := db.Context(ctx).Insert(u); err != nil {
+			return 0, errors.ErrDBError(err)
+		}
+		return u.ID.Int64(), nil
+	}
+
+	if !u.IsActive() {
+		return 0, errors.FromCode(response.CodeUserDisabled)
+	}
+	u.LastLoginAt = &now
+	if _, err := db.Context(ctx).ID(u.ID).Cols("last_login_at").Update(u); err != nil {
+		return 0, errors.ErrDBError(err)
+	}
+	return u.ID.Int64(), nil
+}
+
+// nickname returns the nickname of a new account, limited to the column size
+// nickname 返回新账号的昵称，长度不超过列宽
+func nickname(p *oauth.Profile) string {
+	name := []rune(strings.TrimSpace(p.Name))
+	if len(name) == 0 {
+		return p.Provider + " user"
+	}
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	return string(name)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/response"
+	"github.com/nuohe369/crab/pkg/crypto"
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/logger"
+	pkgredis "github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/util"
+	"github.com/redis/go-redis/v9"
+)
+
+var log = logger.NewSystem("usercenter")
+
+// resetKeyPrefix prefixes the Redis keys of reset tokens, which are stored hashed
+// resetKeyPrefix 是重置令牌 Redis 键的前缀，令牌以哈希形式存储
+const resetKeyPrefix = "usercenter:reset:"
+
+// ForgotPasswordReq represents the password reset request
+// ForgotPasswordReq 找回密码请求
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordReq represents the password reset confirmation
+// ResetPasswordReq 重置密码请求
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPassword emails a single-use reset link to the account of the address
+// It succeeds whether or not the address is registered, so accounts cannot be enumerated.
+// ForgotPassword 向邮箱对应的账号发送一次性重置链接
+// 无论邮箱是否注册都返回成功，避免账号被枚举。
+// POST /usercenter/password/forgot
+func ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.Email == "" {
+		return errors.ErrRequired("email")
+	}
+	if cfg.ResetURL == "" || email.Get() == nil {
+		return errors.ErrServerError("password reset by email not configured")
+	}
+	rdb := pkgredis.Get()
+	if rdb == nil {
+		return errors.New(response.CodeRedisError, "Redis not initialized")
+	}
+
+	u, err := findUser(c, "email = ?", req.Email)
+	if err != nil {
+		return err
+	}
+	if u == nil || !u.IsActive() {
+		return response.OK(c, nil)
+	}
+
+	ctx := c.UserContext()
+	token := util.RandomString(32)
+	if err := rdb.Set(ctx, resetKeyPrefix+util.SHA256(token), u.ID.Int64(), cfg.ResetTTL); err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+
+	link, err := url.Parse(cfg.ResetURL)
+	if err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	data := email.PasswordResetData{ResetURL: link.String(), ExpireMinutes: int(cfg.ResetTTL.Minutes())}
+	if err := sendMail(ctx, u.Email, "password_reset", data); err != nil {
+		// The response must not reveal that the address exists | 响应不能暴露邮箱已注册
+		log.Error("Send password reset email to user %d failed: %v", u.ID.Int64(), err)
+	}
+	return response.OK(c, nil)
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword
+// ResetPassword 使用 ForgotPassword 发出的令牌设置新密码
+// POST /usercenter/password/reset
+func ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	switch {
+	case req.Token == "":
+		return errors.ErrRequired("token")
+	case req.Password == "":
+		return errors.ErrRequired("password")
+	}
+	rdb := pkgredis.Get()
+	if rdb == nil {
+		return errors.New(response.CodeRedisError, "Redis not initialized")
+	}
+
+	ctx := c.UserContext()
+	key := resetKeyPrefix + util.SHA256(req.Token)
+	val, err := rdb.Get(ctx, key)
+	if pkgredis.IsNil(err) {
+		return errors.Localized(response.CodeParamInvalid, "usercenter.reset_invalid")
+	}
+	if err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+	id, _ := strconv.ParseInt(val, 10, 64)
+
+	u, err := findUser(c, "id = ?", id)
+	if err != nil {
+		return err
+	}
+	if u == nil || !u.IsActive() {
+		return errors.Localized(response.CodeParamInvalid, "usercenter.reset_invalid")
+	}
+	// A rejected password keeps the link usable | 密码被拒绝时链接仍可使用
+	if err := crypto.CheckPasswordPolicy(req.Password, u.Username, u.Email, u.Nickname); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+
+	// Claim the token atomically so a link works once | 原子地占用令牌，链接只能使用一次
+	claimed, err := claimResetToken(ctx, rdb, key)
+	if err != nil {
+		return errors.Wrap(response.CodeRedisError, err)
+	}
+	if !claimed {
+		return errors.Localized(response.CodeParamInvalid, "usercenter.reset_invalid")
+	}
+	if err := updatePassword(c, u, req.Password); err != nil {
+		return err
+	}
+	return response.OK(c, nil)
+}
+
+// claimResetToken deletes a reset token, false when another request deleted it first
+// claimResetToken 删除重置令牌，已被其他请求先删除时返回 false
+func claimResetToken(ctx context.Context, rdb *pkgredis.Client, key string) (bool, error) {
+	raw, ok := rdb.GetRaw().(redis.Cmdable)
+	if !ok {
+		return false, errors.ErrServerError("unsupported Redis client")
+	}
+	n, err := raw.Del(ctx, key).Result()
+	return n == 1, err
+}
+
+// sendMail renders a template and sends it through the queue when enabled, directly otherwise
+// sendMail 渲染模板，启用队列时通过队列发送，否则直接发送
+func sendMail(ctx context.Context, to, template string, data any) error {
+	subject, body, err := email.DefaultRegistry().RenderMessage(template, data)
+	if err != nil {
+		return err
+	}
+	msg := &email.Message{To: []string{to}, Subject: subject, Body: body, IsHTML: true}
+	if email.GetDispatcher() != nil {
+		_, err = email.Enqueue(ctx, msg)
+		return err
+	}
+	return email.Get().SendContext(ctx, msg)
+}
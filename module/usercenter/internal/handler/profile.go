@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/common/errors"
+	"github.com/nuohe369/crab/common/model"
+	"github.com/nuohe369/crab/common/response"
+	ucmodel "github.com/nuohe369/crab/module/usercenter/internal/model"
+	"github.com/nuohe369/crab/module/usercenter/internal/vo"
+	"github.com/nuohe369/crab/pkg/crypto"
+)
+
+// UpdateProfileReq represents the profile update request, nil fields are left unchanged
+// UpdateProfileReq 资料更新请求，nil 字段保持不变
+type UpdateProfileReq struct {
+	Nickname *string `json:"nickname"`
+	Avatar   *string `json:"avatar"`
+}
+
+// ChangePasswordReq represents the password change request
+// ChangePasswordReq 修改密码请求
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// GetProfile returns the profile of the current user
+// GetProfile 获取当前用户资料
+// GET /usercenter/profile
+func GetProfile(c *fiber.Ctx) error {
+	u, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, vo.ToUserVO(u))
+}
+
+// UpdateProfile updates the profile of the current user
+// UpdateProfile 更新当前用户资料
+// PUT /usercenter/profile
+func UpdateProfile(c *fiber.Ctx) error {
+	var req UpdateProfileReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	u, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	cols := []string{}
+	if req.Nickname != nil {
+		nickname := strings.TrimSpace(*req.Nickname)
+		if nickname == "" {
+			return errors.ErrRequired("nickname")
+		}
+		u.Nickname = nickname
+		cols = append(cols, "nickname")
+	}
+	if req.Avatar != nil {
+		u.Avatar = strings.TrimSpace(*req.Avatar)
+		cols = append(cols, "avatar")
+	}
+	if len(cols) == 0 {
+		return errors.New(response.CodeParamMissing, "nothing to update")
+	}
+
+	db, err := model.GetDBSafe(u)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(c.UserContext()).ID(u.ID).Cols(cols...).Update(u); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return response.OK(c, vo.ToUserVO(u))
+}
+
+// ChangePassword changes the password of the current user and issues a new token
+// Tokens issued before the change can no longer be refreshed.
+// ChangePassword 修改当前用户密码并签发新令牌
+// 修改前签发的令牌将无法再刷新。
+// PUT /usercenter/password
+func ChangePassword(c *fiber.Ctx) error {
+	var req ChangePasswordReq
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrBodyInvalid()
+	}
+	switch {
+	case req.OldPassword == "":
+		return errors.ErrRequired("old_password")
+	case req.NewPassword == "":
+		return errors.ErrRequired("new_password")
+	}
+
+	u, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	if ok, _ := u.CheckPassword(req.OldPassword); !ok {
+		return errors.FromCode(response.CodePasswordWrong)
+	}
+	if err := updatePassword(c, u, req.NewPassword); err != nil {
+		return err
+	}
+
+	token, err := issueToken(c, u)
+	if err != nil {
+		return err
+	}
+	return response.OK(c, token)
+}
+
+// updatePassword checks the policy and stores the new password of u
+// updatePassword 校验密码策略并保存 u 的新密码
+func updatePassword(c *fiber.Ctx, u *ucmodel.User, password string) error {
+	if err := crypto.CheckPasswordPolicy(password, u.Username, u.Email, u.Nickname); err != nil {
+		return errors.ErrParamInvalid(err.Error())
+	}
+	if err := u.SetPassword(password); err != nil {
+		return errors.Wrap(response.CodeServerError, err)
+	}
+	db, err := model.GetDBSafe(u)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if _, err := db.Context(c.UserContext()).ID(u.ID).Cols("password", "password_changed_at").Update(u); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return nil
+}
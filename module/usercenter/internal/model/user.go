@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/nuohe369/crab/pkg/crypto"
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// User status | 用户状态
+const (
+	StatusDisabled = 0 // Disabled | 已禁用
+	StatusActive   = 1 // Active | 正常
+)
+
+// User represents an account of the user center
+// User 表示用户中心的账号
+type User struct {
+	ID                snowflake.SnowflakeID `json:"id" xorm:"pk 'id' bigint"`
+	Username          string                `json:"username" xorm:"varchar(50) notnull unique 'username'"` // Username (unique) | 用户名（唯一）
+	Email             string                `json:"email" xorm:"varchar(255) notnull unique 'email'"`      // Email (unique, lowercase) | 邮箱（唯一，小写）
+	Nickname          string                `json:"nickname" xorm:"varchar(50) notnull 'nickname'"`        // Nickname | 昵称
+	Avatar            string                `json:"avatar" xorm:"varchar(255) 'avatar'"`                   // Avatar URL | 头像地址
+	Password          string                `json:"-" xorm:"varchar(255) notnull 'password'"`              // Password hash | 密码哈希
+	Status            int                   `json:"status" xorm:"default(1) 'status'"`                     // Status: 1=active, 0=disabled | 状态: 1=正常, 0=禁用
+	PasswordChangedAt time.Time             `json:"-" xorm:"'password_changed_at'"`                        // Tokens issued earlier cannot be refreshed | 早于此时间签发的令牌不能刷新
+	LastLoginAt       *time.Time            `json:"last_login_at,omitempty" xorm:"'last_login_at'"`        // Last login time | 最后登录时间
+	CreatedAt         time.Time             `json:"created_at" xorm:"created 'created_at'"`                // Creation time | 创建时间
+	UpdatedAt         time.Time             `json:"updated_at" xorm:"updated 'updated_at'"`                // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (u *User) TableName() string {
+	return "uc_user"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (u *User) BeforeInsert() {
+	if u.ID.IsZero() {
+		u.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+
+// SetPassword hashes password into Password and records the change time
+// SetPassword 将密码哈希到 Password 并记录修改时间
+func (u *User) SetPassword(password string) error {
+	hashed, err := crypto.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	u.Password = hashed
+	u.PasswordChangedAt = time.Now()
+	return nil
+}
+
+// CheckPassword validates the password
+// A correct password stored with outdated parameters is rehashed into Password and rehashed is true:
+// update the password column to keep the upgrade.
+// CheckPassword 校验密码
+// 正确但使用旧参数存储的密码会被重新哈希到 Password，并且 rehashed 为 true：更新 password 列以保存升级。
+func (u *User) CheckPassword(password string) (ok, rehashed bool) {
+	ok, rehash, err := crypto.VerifyPassword(password, u.Password)
+	if !ok || err != nil {
+		return false, false
+	}
+	if rehash {
+		if hashed, err := crypto.HashPassword(password); err == nil {
+			u.Password = hashed
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// IsActive checks if the user is active
+// IsActive 检查用户是否正常
+func (u *User) IsActive() bool {
+	return u.Status == StatusActive
+}
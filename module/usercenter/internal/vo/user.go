@@ -0,0 +1,39 @@
+package vo
+
+import (
+	"github.com/nuohe369/crab/module/usercenter/internal/model"
+	"github.com/nuohe369/crab/pkg/timex"
+)
+
+// UserVO represents the profile view object
+// UserVO 用户资料视图对象
+type UserVO struct {
+	ID          string          `json:"id"`
+	Username    string          `json:"username"`
+	Email       string          `json:"email"`
+	Nickname    string          `json:"nickname"`
+	Avatar      string          `json:"avatar"`
+	LastLoginAt *timex.JSONTime `json:"last_login_at,omitempty"`
+	CreatedAt   timex.JSONTime  `json:"created_at"`
+}
+
+// TokenVO represents an issued access token
+// TokenVO 签发的访问令牌
+type TokenVO struct {
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"` // Seconds | 秒
+}
+
+// ToUserVO converts model.User to UserVO
+// ToUserVO 将 model.User 转换为 UserVO
+func ToUserVO(u *model.User) UserVO {
+	return UserVO{
+		ID:          u.ID.String(),
+		Username:    u.Username,
+		Email:       u.Email,
+		Nickname:    u.Nickname,
+		Avatar:      u.Avatar,
+		LastLoginAt: timex.JSONPtr(u.LastLoginAt),
+		CreatedAt:   timex.JSON(u.CreatedAt),
+	}
+}
@@ -0,0 +1,66 @@
+// Package usercenter provides account registration, login, token refresh, profile and password endpoints
+// Tokens are signed by pkg/jwt for the configured platform, passwords are hashed with pkg/crypto and
+// reset links are emailed with the password_reset template of pkg/email. Login, registration and
+// password reset are rate limited per IP, and Config.Captcha adds an image captcha to login and registration.
+// Config.OAuth logs in social login users of common/oauth, registering an account for a new verified email.
+// usercenter 包提供账号注册、登录、令牌刷新、资料和密码接口
+// 令牌由 pkg/jwt 按配置的平台签发，密码使用 pkg/crypto 哈希，重置链接使用 pkg/email 的 password_reset 模板发送。
+// 登录、注册和密码重置按 IP 限流，Config.Captcha 为登录和注册增加图片验证码。
+// Config.OAuth 使 common/oauth 的社交登录用户登录本模块账号，新的已验证邮箱会注册账号。
+//
+// Usage | 用法:
+//
+//	import _ "github.com/nuohe369/crab/module/usercenter"
+//
+//	func init() {
+//	    usercenter.Configure(usercenter.Config{ResetURL: "https://example.com/reset-password"})
+//	}
+package usercenter
+
+import (
+	"github.com/nuohe369/crab/boot"
+	"github.com/nuohe369/crab/module/usercenter/internal/handler"
+	"github.com/nuohe369/crab/module/usercenter/internal/model"
+)
+
+// Config represents the user center configuration
+// Config 表示用户中心配置
+type Config = handler.Config
+
+// config is applied when the module is initialized | config 在模块初始化时生效
+var config Config
+
+// Configure sets the user center configuration, call it before the service starts
+// Configure 设置用户中心配置，需在服务启动前调用
+func Configure(cfg Config) {
+	config = cfg
+}
+
+func init() {
+	boot.Register(&Module{})
+}
+
+type Module struct{}
+
+func (m *Module) Name() string {
+	return "usercenter"
+}
+
+func (m *Module) Models() []any {
+	return []any{
+		new(model.User),
+	}
+}
+
+func (m *Module) Init(ctx *boot.ModuleContext) error {
+	handler.Setup(ctx.Router, config)
+	return nil
+}
+
+func (m *Module) Start() error {
+	return nil
+}
+
+func (m *Module) Stop() error {
+	return nil
+}
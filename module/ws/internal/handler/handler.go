@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+
+	"github.com/nuohe369/crab/common/ctxutil"
+	"github.com/nuohe369/crab/common/middleware"
+	"github.com/nuohe369/crab/common/service"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/sse"
+	"github.com/nuohe369/crab/pkg/ws"
+)
+
+var log = logger.NewWithName("ws")
+
+// Setup 注册用户端和管理端的 WebSocket 升级路由
+func Setup(router fiber.Router) {
+	router.Get("/user", upgrade, middleware.RequireAuth("frontend"), websocket.New(serve(service.GetUserHub, "user")))
+	router.Get("/admin", upgrade, middleware.RequireAuth("admin"), websocket.New(serve(service.GetAdminHub, "admin")))
+
+	// Server-Sent Events 流，EventSource 同样无法设置请求头，复用 ?token=
+	router.Get("/sse/user", queryToken, middleware.RequireAuth("frontend"), stream(service.GetUserSSEHub))
+	router.Get("/sse/admin", queryToken, middleware.RequireAuth("admin"), stream(service.GetAdminSSEHub))
+
+	// 长轮询回退，供拦截 WebSocket 的代理环境使用，消息语义与 WebSocket 相同
+	router.All("/poll/user", queryToken, middleware.RequireAuth("frontend"), poll(service.GetUserLongPoll, "user"))
+	router.All("/poll/admin", queryToken, middleware.RequireAuth("admin"), poll(service.GetAdminLongPoll, "admin"))
+}
+
+// upgrade 拒绝非 WebSocket 请求，并把 ?token= 转为 Authorization 头（浏览器无法为 WebSocket 设置请求头）
+func upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+	return queryToken(c)
+}
+
+// queryToken 把 ?token= 转为 Authorization 头
+func queryToken(c *fiber.Ctx) error {
+	if token := c.Query("token"); token != "" && c.Get(fiber.HeaderAuthorization) == "" {
+		c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	return c.Next()
+}
+
+// stream 将请求作为 hub 中当前用户的 SSE 流
+func stream(hub func() *sse.Hub) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		h := hub()
+		if h == nil {
+			return fiber.ErrServiceUnavailable
+		}
+		return h.Handler(func(c *fiber.Ctx) int64 {
+			userID, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+			return userID
+		})(c)
+	}
+}
+
+// poll 将请求交给长轮询传输，新会话同样收到 connected 消息
+func poll(transport func() *ws.LongPoll, name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p := transport()
+		if p == nil {
+			return fiber.ErrServiceUnavailable
+		}
+		return p.Handler(func(c *fiber.Ctx) int64 {
+			userID, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+			return userID
+		}, func(client *ws.Client) {
+			connected(client, name)
+		})(c)
+	}
+}
+
+// serve 将连接注册到 hub，读写循环负责 ping/pong 心跳，消息按 Type 分发到 service 注册的处理器
+func serve(hub func() *ws.Hub, name string) func(*websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		h := hub()
+		if h == nil {
+			log.Error("%s hub not initialized", name)
+			conn.Close()
+			return
+		}
+		userID, _ := conn.Locals(ctxutil.LocalsUserID).(int64)
+
+		client := ws.NewClient(h, userID, conn)
+		h.Register(client)
+		defer h.Unregister(client)
+
+		connected(client, name)
+
+		go client.WritePump()
+		client.ReadPump()
+	}
+}
+
+// connected 向新连接发送 connected 消息
+func connected(client *ws.Client, name string) {
+	client.Send(&ws.Message{
+		Type: "connected",
+		Payload: map[string]any{
+			"user_id": client.UserID,
+			"hub":     name,
+		},
+	})
+}
@@ -1,13 +1,30 @@
-// Package ws WebSocket example module
+// Package ws WebSocket module
 //
-// Demonstrates various usages of pkg/ws, accessible via different paths after startup:
+// Authenticated endpoints backed by the hubs of common/service:
+//
+//   - /ws/user        - Frontend users (JWT plat "frontend"), service.GetUserHub
+//   - /ws/admin       - Admins (JWT plat "admin"), service.GetAdminHub
+//   - /ws/sse/user    - Server-Sent Events for frontend users, service.GetUserSSEHub
+//   - /ws/sse/admin   - Server-Sent Events for admins, service.GetAdminSSEHub
+//   - /ws/poll/user   - Long-polling fallback on the user hub, service.GetUserLongPoll
+//   - /ws/poll/admin  - Long-polling fallback on the admin hub, service.GetAdminLongPoll
+//
+// The JWT is read from the Authorization header or the token query parameter.
+// Client messages are routed by Type to handlers registered with
+// service.HandleUserMessage / service.HandleAdminMessage, or ws.Handle on the hub
+// for a typed payload; unknown types get an "error" reply and {"type": "ping"}
+// is answered with {"type": "pong"}.
+//
+// Examples of pkg/ws usage:
 //
 //   - /ws/basic       - Basic usage
 //   - /ws/multiuser   - Multi-user targeted messaging
 //   - /ws/callback    - Callback handling
 //   - /ws/cluster     - Redis cluster mode
 //
-// Test: websocat ws://localhost:3000/ws/basic
+// Test: websocat "ws://localhost:3000/ws/user?token=<jwt>"
+// SSE:  curl -N "http://localhost:3000/ws/sse/user?token=<jwt>"
+// Poll: curl -X POST "http://localhost:3000/ws/poll/user?token=<jwt>", then GET with &sid=<sid>
 package ws
 
 import (
@@ -17,6 +34,7 @@ import (
 	"github.com/nuohe369/crab/module/ws/example_03_callback"
 	"github.com/nuohe369/crab/module/ws/example_04_cluster"
 	"github.com/nuohe369/crab/module/ws/example_05_service"
+	"github.com/nuohe369/crab/module/ws/internal/handler"
 )
 
 func init() {
@@ -30,6 +48,8 @@ func (m *Module) Name() string { return "ws" }
 func (m *Module) Models() []any { return nil }
 
 func (m *Module) Init(ctx *boot.ModuleContext) error {
+	handler.Setup(ctx.Router)
+
 	example_01_basic.Setup(ctx.Router)
 	example_02_multiuser.Setup(ctx.Router)
 	example_03_callback.Setup(ctx.Router)
@@ -1,6 +1,7 @@
 package breaker
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -32,6 +33,30 @@ func DefaultConfig() Config {
 	}
 }
 
+// DefaultName is the configuration key holding settings shared by all breakers
+// DefaultName 是保存所有熔断器共享配置的配置键
+const DefaultName = "default"
+
+// withDefaults fills zero fields of c from base | withDefaults 用 base 填充 c 中的零值字段
+func (c Config) withDefaults(base Config) Config {
+	if c.MaxRequests == 0 {
+		c.MaxRequests = base.MaxRequests
+	}
+	if c.Interval == 0 {
+		c.Interval = base.Interval
+	}
+	if c.Timeout == 0 {
+		c.Timeout = base.Timeout
+	}
+	if c.FailureRatio == 0 {
+		c.FailureRatio = base.FailureRatio
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = base.MinRequests
+	}
+	return c
+}
+
 // State represents circuit breaker state
 // State 表示熔断器状态
 type State = gobreaker.State
@@ -45,8 +70,9 @@ const (
 // CircuitBreaker wraps gobreaker circuit breaker
 // CircuitBreaker 封装 gobreaker 熔断器
 type CircuitBreaker struct {
-	cb   *gobreaker.CircuitBreaker[any]
-	name string
+	cb     *gobreaker.CircuitBreaker[any]
+	name   string
+	config Config
 }
 
 // New creates a new circuit breaker
@@ -72,8 +98,9 @@ func New(name string, config Config) *CircuitBreaker {
 	}
 
 	return &CircuitBreaker{
-		cb:   gobreaker.NewCircuitBreaker[any](settings),
-		name: name,
+		cb:     gobreaker.NewCircuitBreaker[any](settings),
+		name:   name,
+		config: config,
 	}
 }
 
@@ -104,6 +131,12 @@ func (c *CircuitBreaker) Name() string {
 	return c.name
 }
 
+// Config returns the configuration the breaker was created with
+// Config 返回创建熔断器时使用的配置
+func (c *CircuitBreaker) Config() Config {
+	return c.config
+}
+
 // Counts returns statistics
 // Counts 返回统计信息
 func (c *CircuitBreaker) Counts() gobreaker.Counts {
@@ -113,9 +146,10 @@ func (c *CircuitBreaker) Counts() gobreaker.Counts {
 // Manager manages multiple circuit breakers
 // Manager 管理多个熔断器
 type Manager struct {
-	breakers map[string]*CircuitBreaker
-	config   Config
-	mu       sync.RWMutex
+	breakers  map[string]*CircuitBreaker
+	config    Config
+	overrides map[string]Config
+	mu        sync.RWMutex
 }
 
 var (
@@ -128,14 +162,47 @@ var (
 func Init(config Config) {
 	managerOnce.Do(func() {
 		defaultManager = &Manager{
-			breakers: make(map[string]*CircuitBreaker),
-			config:   config,
+			breakers:  make(map[string]*CircuitBreaker),
+			config:    config,
+			overrides: make(map[string]Config),
 		}
 		log.Info("Circuit breaker manager initialized (gobreaker): maxRequests=%d, timeout=%v, failureRatio=%.2f",
 			config.MaxRequests, config.Timeout, config.FailureRatio)
 	})
 }
 
+// Setup initializes the manager from the [breaker] config sections
+// configs[DefaultName] applies to all breakers, every other entry overrides the named
+// breaker; fields left empty fall back to the shared settings.
+// Setup 根据 [breaker] 配置段初始化管理器
+// configs[DefaultName] 作用于所有熔断器，其他条目覆盖同名熔断器；未填写的字段回退到共享配置。
+//
+// Config example | 配置示例:
+//
+//	[breaker.default]
+//	timeout = "30s"
+//
+//	[breaker."http:api.partner.com"]
+//	timeout = "2m"
+//	failure_ratio = 0.3
+//	min_requests = 20
+func Setup(configs map[string]Config) {
+	base := configs[DefaultName].withDefaults(DefaultConfig())
+	Init(base)
+	defaultManager.SetConfig(base) // In case GetManager already created it | 以防 GetManager 已创建管理器
+	for name, cfg := range configs {
+		if name != DefaultName {
+			defaultManager.Configure(name, cfg)
+		}
+	}
+}
+
+// SetConfig overrides the configuration of the named breaker on the default manager, see Manager.Configure
+// SetConfig 覆盖默认管理器中指定熔断器的配置，参见 Manager.Configure
+func SetConfig(name string, config Config) {
+	GetManager().Configure(name, config)
+}
+
 // GetManager returns the manager instance
 // GetManager 返回管理器实例
 func GetManager() *Manager {
@@ -169,12 +236,40 @@ func (m *Manager) Get(name string) *CircuitBreaker {
 		return cb
 	}
 
-	cb := New(name, m.config)
+	cb := New(name, m.configFor(name))
 	m.breakers[name] = cb
 	log.Debug("Created circuit breaker: %s", name)
 	return cb
 }
 
+// configFor returns the effective configuration of a breaker, m.mu must be held
+// configFor 返回熔断器的生效配置，调用时须持有 m.mu
+func (m *Manager) configFor(name string) Config {
+	if cfg, ok := m.overrides[name]; ok {
+		return cfg.withDefaults(m.config)
+	}
+	return m.config
+}
+
+// Configure overrides the configuration of the named breaker
+// Empty fields fall back to the manager configuration. An existing breaker is rebuilt,
+// which resets its state and counts, so a flaky dependency can be retuned at runtime.
+// Configure 覆盖指定熔断器的配置
+// 空字段回退到管理器配置。已存在的熔断器会被重建，其状态和计数随之重置，因此可在运行时调整故障依赖的阈值。
+func (m *Manager) Configure(name string, config Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.overrides == nil {
+		m.overrides = make(map[string]Config)
+	}
+	m.overrides[name] = config
+	if _, ok := m.breakers[name]; ok {
+		m.breakers[name] = New(name, m.configFor(name))
+		log.Info("Circuit breaker [%s] rebuilt with new configuration", name)
+	}
+}
+
 // GetAll returns all circuit breakers
 // GetAll 返回所有熔断器
 func (m *Manager) GetAll() map[string]*CircuitBreaker {
@@ -218,6 +313,17 @@ func (m *Manager) GetAllStats() []Stats {
 	return stats
 }
 
+// AllStats returns statistics of the default manager, nil before Init
+// Unlike GetManager it never creates the manager, so collectors can call it safely.
+// AllStats 返回默认管理器的统计信息，Init 之前返回 nil
+// 与 GetManager 不同，它不会创建管理器，因此指标采集器可以安全调用。
+func AllStats() []Stats {
+	if defaultManager == nil {
+		return nil
+	}
+	return defaultManager.GetAllStats()
+}
+
 // SetConfig updates configuration (only affects newly created circuit breakers)
 // SetConfig 更新配置（仅影响新创建的熔断器）
 func (m *Manager) SetConfig(config Config) {
@@ -229,11 +335,17 @@ func (m *Manager) SetConfig(config Config) {
 // IsOpen checks if the error is circuit breaker open error
 // IsOpen 检查错误是否为熔断器开启错误
 func IsOpen(err error) bool {
-	return err == gobreaker.ErrOpenState
+	return errors.Is(err, gobreaker.ErrOpenState)
 }
 
 // IsTooManyRequests checks if the error is too many requests in half-open state
 // IsTooManyRequests 检查错误是否为半开状态请求过多
 func IsTooManyRequests(err error) bool {
-	return err == gobreaker.ErrTooManyRequests
+	return errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// IsRejected checks if the breaker rejected the call without running it (open or half-open limit)
+// IsRejected 检查调用是否被熔断器拒绝而未执行（开启状态或半开状态请求过多）
+func IsRejected(err error) bool {
+	return IsOpen(err) || IsTooManyRequests(err)
 }
@@ -151,3 +151,48 @@ func TestManager_GetAllStats(t *testing.T) {
 		t.Errorf("Expected at least 2 stats, got %d", len(stats))
 	}
 }
+
+func TestManager_Configure(t *testing.T) {
+	m := &Manager{breakers: make(map[string]*CircuitBreaker), config: DefaultConfig()}
+
+	// 未创建时覆盖配置，创建时生效，空字段回退到共享配置
+	m.Configure("partner", Config{Timeout: time.Minute, MinRequests: 20})
+	cb := m.Get("partner")
+	cfg := cb.Config()
+	if cfg.Timeout != time.Minute || cfg.MinRequests != 20 {
+		t.Errorf("Expected override to apply, got %+v", cfg)
+	}
+	if cfg.FailureRatio != 0.6 || cfg.MaxRequests != 3 {
+		t.Errorf("Expected empty fields to fall back to defaults, got %+v", cfg)
+	}
+	if m.Get("internal").Config().Timeout != 30*time.Second {
+		t.Error("Expected other breakers to keep the shared config")
+	}
+
+	// 已存在的熔断器会被重建
+	m.Configure("partner", Config{FailureRatio: 0.3})
+	rebuilt := m.Get("partner")
+	if rebuilt == cb {
+		t.Error("Expected breaker to be rebuilt")
+	}
+	if rebuilt.Config().FailureRatio != 0.3 {
+		t.Errorf("Expected failure ratio 0.3, got %v", rebuilt.Config().FailureRatio)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defaultManager = nil
+	managerOnce = sync.Once{}
+
+	Setup(map[string]Config{
+		DefaultName: {Timeout: 10 * time.Second},
+		"partner":   {MinRequests: 50},
+	})
+
+	if cfg := GetBreaker("other").Config(); cfg.Timeout != 10*time.Second || cfg.MinRequests != 5 {
+		t.Errorf("Expected default section merged with built-in defaults, got %+v", cfg)
+	}
+	if cfg := GetBreaker("partner").Config(); cfg.Timeout != 10*time.Second || cfg.MinRequests != 50 {
+		t.Errorf("Expected partner override on top of default section, got %+v", cfg)
+	}
+}
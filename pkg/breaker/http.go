@@ -0,0 +1,85 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// errServerError marks a 5xx response as a breaker failure | errServerError 将 5xx 响应标记为熔断失败
+var errServerError = errors.New("breaker: server error response")
+
+// Transport is an http.RoundTripper that routes outbound calls through a breaker per host
+// Rejected calls return an error for which IsOpen or IsTooManyRequests is true,
+// also when wrapped by http.Client in a *url.Error.
+// Transport 是按目标主机经过熔断器发出请求的 http.RoundTripper
+// 被拒绝的调用返回的错误满足 IsOpen 或 IsTooManyRequests，被 http.Client 包装为 *url.Error 时同样适用。
+type Transport struct {
+	Base      http.RoundTripper                         // Underlying transport (default http.DefaultTransport) | 底层传输（默认 http.DefaultTransport）
+	Manager   *Manager                                  // Breaker manager (default GetManager()) | 熔断器管理器（默认 GetManager()）
+	Name      func(req *http.Request) string            // Breaker name (default "http:" + host), e.g. host + path for per-route breakers | 熔断器名称（默认 "http:" + 主机），例如按路由熔断时使用主机 + 路径
+	IsFailure func(resp *http.Response, err error) bool // Failure check (default transport error or 5xx) | 失败判定（默认传输错误或 5xx）
+}
+
+// RoundTrip implements http.RoundTripper
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	m := t.Manager
+	if m == nil {
+		m = GetManager()
+	}
+	name := "http:" + req.URL.Host
+	if t.Name != nil {
+		name = t.Name(req)
+	}
+	isFailure := t.IsFailure
+	if isFailure == nil {
+		isFailure = defaultHTTPFailure
+	}
+
+	var resp *http.Response
+	var rtErr error
+	err := m.Get(name).Execute(func() error {
+		resp, rtErr = base.RoundTrip(req)
+		if !isFailure(resp, rtErr) {
+			return nil
+		}
+		if rtErr != nil {
+			return rtErr
+		}
+		return errServerError
+	})
+	if IsRejected(err) {
+		return nil, fmt.Errorf("breaker [%s]: %w", name, err)
+	}
+	// 5xx responses are returned to the caller as is | 5xx 响应原样返回给调用方
+	return resp, rtErr
+}
+
+// defaultHTTPFailure treats transport errors and 5xx responses as failures
+// defaultHTTPFailure 将传输错误和 5xx 响应视为失败
+func defaultHTTPFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// NewHTTPClient creates an http.Client whose calls go through a breaker per host
+// NewHTTPClient 创建按目标主机经过熔断器发出请求的 http.Client
+//
+// Usage | 用法:
+//
+//	client := breaker.NewHTTPClient(5 * time.Second)
+//	resp, err := client.Get("https://api.example.com/v1/rates")
+//	if breaker.IsRejected(err) {
+//	    // Fall back without waiting for the failing host | 直接降级，无需等待故障主机
+//	}
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &Transport{},
+	}
+}
@@ -0,0 +1,80 @@
+package breaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// TestMain keeps the system log of the breaker on stdout instead of the package directory
+func TestMain(m *testing.M) {
+	logger.SetConfig(logger.Config{Enabled: false})
+	os.Exit(m.Run())
+}
+
+func TestTransport_OpensOnServerErrors(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	m := &Manager{breakers: make(map[string]*CircuitBreaker), config: Config{
+		MaxRequests:  1,
+		Timeout:      time.Minute,
+		FailureRatio: 0.5,
+		MinRequests:  3,
+	}}
+	client := &http.Client{Transport: &Transport{Manager: m}}
+
+	// 5xx 响应原样返回，同时计为失败
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Expected 502 response, got error %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected status 502, got %d", resp.StatusCode)
+		}
+	}
+
+	// 熔断后请求不再发出
+	_, err := client.Get(srv.URL)
+	if !IsOpen(err) || !IsRejected(err) {
+		t.Errorf("Expected open breaker error, got %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("Expected 3 requests to reach the server, got %d", hits.Load())
+	}
+}
+
+func TestTransport_SuccessKeepsClosed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	m := &Manager{breakers: make(map[string]*CircuitBreaker), config: DefaultConfig()}
+	client := &http.Client{Transport: &Transport{Manager: m}}
+
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	for _, s := range m.GetAllStats() {
+		if s.State != StateClosed || s.Failures != 0 {
+			t.Errorf("Expected closed breaker without failures, got %+v", s)
+		}
+	}
+}
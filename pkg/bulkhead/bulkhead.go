@@ -0,0 +1,326 @@
+// Package bulkhead limits concurrent calls per dependency
+// A bulkhead caps how many calls to one dependency run at once and how many may wait,
+// so a slow dependency cannot consume every worker. It complements pkg/breaker, which
+// stops calling a dependency that keeps failing.
+// bulkhead 包按依赖限制并发调用
+// 舱壁限制对同一依赖同时执行的调用数及可等待的调用数，使慢依赖无法占满所有工作协程。
+// 它与 pkg/breaker 互补，后者在依赖持续失败时停止调用。
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+var log = logger.NewSystem("bulkhead")
+
+var (
+	// ErrFull is returned when all slots are busy and the wait queue is full
+	// ErrFull 在所有名额被占用且等待队列已满时返回
+	ErrFull = errors.New("bulkhead: full")
+	// ErrTimeout is returned when a call waited longer than QueueTimeout for a slot
+	// ErrTimeout 在调用等待名额超过 QueueTimeout 时返回
+	ErrTimeout = errors.New("bulkhead: queue timeout")
+)
+
+// DefaultName is the configuration key holding settings shared by all bulkheads
+// DefaultName 是保存所有舱壁共享配置的配置键
+const DefaultName = "default"
+
+// Config represents bulkhead configuration
+// Config 表示舱壁配置
+type Config struct {
+	MaxConcurrent int           `toml:"max_concurrent"` // Max calls running at once (default 20) | 最大同时执行调用数（默认 20）
+	MaxQueue      int           `toml:"max_queue"`      // Max calls waiting for a slot, -1 rejects immediately when busy (default 50) | 最大等待调用数，-1 表示繁忙时立即拒绝（默认 50）
+	QueueTimeout  time.Duration `toml:"queue_timeout"`  // Max time to wait for a slot (default 1s) | 等待名额的最长时间（默认 1 秒）
+}
+
+// DefaultConfig returns default configuration
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		MaxConcurrent: 20,
+		MaxQueue:      50,
+		QueueTimeout:  time.Second,
+	}
+}
+
+// withDefaults fills zero fields of c from base | withDefaults 用 base 填充 c 中的零值字段
+func (c Config) withDefaults(base Config) Config {
+	if c.MaxConcurrent <= 0 {
+		c.MaxConcurrent = base.MaxConcurrent
+	}
+	if c.MaxQueue == 0 {
+		c.MaxQueue = base.MaxQueue
+	}
+	if c.QueueTimeout == 0 {
+		c.QueueTimeout = base.QueueTimeout
+	}
+	return c
+}
+
+// Bulkhead limits concurrent calls to one dependency
+// Bulkhead 限制对单个依赖的并发调用
+type Bulkhead struct {
+	name     string
+	config   Config
+	slots    chan struct{}
+	queued   atomic.Int64
+	rejected atomic.Uint64
+}
+
+// New creates a new bulkhead
+// New 创建新的舱壁
+func New(name string, config Config) *Bulkhead {
+	config = config.withDefaults(DefaultConfig())
+	if config.MaxQueue < 0 {
+		config.MaxQueue = 0
+	}
+	return &Bulkhead{
+		name:   name,
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// Execute runs fn once a slot is free
+// It waits in the queue up to QueueTimeout and returns an error wrapping ErrFull,
+// ErrTimeout or the context error without running fn when no slot was obtained.
+// Execute 在有空闲名额时执行 fn
+// 最多在队列中等待 QueueTimeout，未获得名额时不执行 fn，并返回包装了 ErrFull、ErrTimeout 或 context 错误的错误。
+func (b *Bulkhead) Execute(ctx context.Context, fn func() error) error {
+	if err := b.acquire(ctx); err != nil {
+		return fmt.Errorf("bulkhead [%s]: %w", b.name, err)
+	}
+	defer func() { <-b.slots }()
+	return fn()
+}
+
+// acquire takes a slot, waiting in the queue if needed | acquire 获取名额，必要时排队等待
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if b.queued.Add(1) > int64(b.config.MaxQueue) {
+		b.queued.Add(-1)
+		b.rejected.Add(1)
+		return ErrFull
+	}
+	defer b.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if b.config.QueueTimeout > 0 {
+		timer := time.NewTimer(b.config.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		b.rejected.Add(1)
+		return ErrTimeout
+	case <-ctx.Done():
+		b.rejected.Add(1)
+		return ctx.Err()
+	}
+}
+
+// Name returns the name
+// Name 返回名称
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+// Config returns the configuration the bulkhead was created with
+// Config 返回创建舱壁时使用的配置
+func (b *Bulkhead) Config() Config {
+	return b.config
+}
+
+// Stats represents bulkhead statistics
+// Stats 表示舱壁统计信息
+type Stats struct {
+	Name     string
+	Active   int    // Calls running | 正在执行的调用数
+	Queued   int    // Calls waiting for a slot | 等待名额的调用数
+	Rejected uint64 // Calls rejected since creation | 创建以来被拒绝的调用数
+	Limit    int    // MaxConcurrent
+}
+
+// Stats returns current statistics
+// Stats 返回当前统计信息
+func (b *Bulkhead) Stats() Stats {
+	return Stats{
+		Name:     b.name,
+		Active:   len(b.slots),
+		Queued:   int(b.queued.Load()),
+		Rejected: b.rejected.Load(),
+		Limit:    b.config.MaxConcurrent,
+	}
+}
+
+// IsRejected checks if the error means the call was not run for lack of a slot
+// IsRejected 检查错误是否表示因无名额而未执行调用
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrFull) || errors.Is(err, ErrTimeout)
+}
+
+// Manager manages bulkheads by name
+// Manager 按名称管理舱壁
+type Manager struct {
+	bulkheads map[string]*Bulkhead
+	config    Config
+	overrides map[string]Config
+	mu        sync.RWMutex
+}
+
+// NewManager creates a manager whose bulkheads use config unless overridden
+// NewManager 创建管理器，其舱壁默认使用 config
+func NewManager(config Config) *Manager {
+	return &Manager{
+		bulkheads: make(map[string]*Bulkhead),
+		config:    config.withDefaults(DefaultConfig()),
+		overrides: make(map[string]Config),
+	}
+}
+
+var (
+	defaultManager atomic.Pointer[Manager]
+	managerOnce    sync.Once
+)
+
+// Setup configures the default manager from the [bulkhead] config sections
+// configs[DefaultName] applies to all bulkheads, every other entry overrides the named one.
+// It also applies when GetManager already created the manager with default settings.
+// Setup 根据 [bulkhead] 配置段配置默认管理器
+// configs[DefaultName] 作用于所有舱壁，其他条目覆盖同名舱壁。
+// GetManager 已使用默认配置创建管理器时同样生效。
+func Setup(configs map[string]Config) {
+	m := GetManager()
+	base := configs[DefaultName].withDefaults(DefaultConfig())
+	m.SetConfig(base)
+	for name, cfg := range configs {
+		if name != DefaultName {
+			m.Configure(name, cfg)
+		}
+	}
+	log.Info("Bulkhead manager configured: maxConcurrent=%d, maxQueue=%d, queueTimeout=%v",
+		base.MaxConcurrent, base.MaxQueue, base.QueueTimeout)
+}
+
+// GetManager returns the default manager, creating it with default settings on first use
+// GetManager 返回默认管理器，首次使用时以默认配置创建
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		defaultManager.Store(NewManager(DefaultConfig()))
+	})
+	return defaultManager.Load()
+}
+
+// Get gets or creates a bulkhead
+// Get 获取或创建舱壁
+func (m *Manager) Get(name string) *Bulkhead {
+	m.mu.RLock()
+	if b, ok := m.bulkheads[name]; ok {
+		m.mu.RUnlock()
+		return b
+	}
+	m.mu.RUnlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, ok := m.bulkheads[name]; ok {
+		return b
+	}
+	cfg := m.config
+	if o, ok := m.overrides[name]; ok {
+		cfg = o.withDefaults(m.config)
+	}
+	b := New(name, cfg)
+	m.bulkheads[name] = b
+	log.Debug("Created bulkhead: %s", name)
+	return b
+}
+
+// Configure overrides the configuration of the named bulkhead
+// An existing bulkhead is replaced; calls already running on it finish undisturbed.
+// Configure 覆盖指定舱壁的配置
+// 已存在的舱壁会被替换，其上正在执行的调用不受影响。
+func (m *Manager) Configure(name string, config Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.overrides[name] = config
+	if _, ok := m.bulkheads[name]; ok {
+		m.bulkheads[name] = New(name, config.withDefaults(m.config))
+		log.Info("Bulkhead [%s] rebuilt with new configuration", name)
+	}
+}
+
+// SetConfig replaces the shared configuration
+// Existing bulkheads are rebuilt; calls already running on them finish undisturbed.
+// SetConfig 替换共享配置
+// 已存在的舱壁会被重建，其上正在执行的调用不受影响。
+func (m *Manager) SetConfig(config Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config = config.withDefaults(DefaultConfig())
+	for name := range m.bulkheads {
+		cfg := m.config
+		if o, ok := m.overrides[name]; ok {
+			cfg = o.withDefaults(m.config)
+		}
+		m.bulkheads[name] = New(name, cfg)
+	}
+}
+
+// GetAllStats returns statistics for all bulkheads
+// GetAllStats 返回所有舱壁的统计信息
+func (m *Manager) GetAllStats() []Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make([]Stats, 0, len(m.bulkheads))
+	for _, b := range m.bulkheads {
+		stats = append(stats, b.Stats())
+	}
+	return stats
+}
+
+// Execute runs fn in the named bulkhead of the default manager
+// Execute 在默认管理器的指定舱壁中执行 fn
+//
+// Usage | 用法:
+//
+//	err := bulkhead.Execute(ctx, "payment-api", func() error {
+//	    return callPaymentAPI(ctx)
+//	})
+//	if bulkhead.IsRejected(err) {
+//	    return errors.New(response.CodeTooManyRequests, "Payment service busy")
+//	}
+func Execute(ctx context.Context, name string, fn func() error) error {
+	return GetManager().Get(name).Execute(ctx, fn)
+}
+
+// AllStats returns statistics of the default manager, nil before it is first used
+// AllStats 返回默认管理器的统计信息，首次使用之前返回 nil
+func AllStats() []Stats {
+	m := defaultManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.GetAllStats()
+}
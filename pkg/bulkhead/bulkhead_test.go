@@ -0,0 +1,215 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bulkhead-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// resetDefault clears the default manager for the test
+func resetDefault(t *testing.T) {
+	t.Helper()
+	defaultManager.Store(nil)
+	managerOnce = sync.Once{}
+	t.Cleanup(func() {
+		defaultManager.Store(nil)
+		managerOnce = sync.Once{}
+	})
+}
+
+func TestBulkhead_LimitsConcurrency(t *testing.T) {
+	b := New("test-limit", Config{MaxConcurrent: 2, MaxQueue: 10, QueueTimeout: time.Second})
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.Execute(context.Background(), func() error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestBulkhead_QueueFull(t *testing.T) {
+	b := New("test-full", Config{MaxConcurrent: 1, MaxQueue: -1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go b.Execute(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	err := b.Execute(context.Background(), func() error { return nil })
+	if !errors.Is(err, ErrFull) || !IsRejected(err) {
+		t.Errorf("Expected ErrFull, got %v", err)
+	}
+	close(release)
+
+	if b.Stats().Rejected != 1 {
+		t.Errorf("Expected 1 rejection, got %d", b.Stats().Rejected)
+	}
+}
+
+func TestBulkhead_QueueTimeout(t *testing.T) {
+	b := New("test-timeout", Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 20 * time.Millisecond})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go b.Execute(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	ran := false
+	err := b.Execute(context.Background(), func() error { ran = true; return nil })
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if ran {
+		t.Error("Expected fn not to run")
+	}
+	if q := b.Stats().Queued; q != 0 {
+		t.Errorf("Expected empty queue after timeout, got %d", q)
+	}
+}
+
+func TestBulkhead_ContextCancel(t *testing.T) {
+	b := New("test-ctx", Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go b.Execute(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := b.Execute(ctx, func() error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+}
+
+func TestBulkhead_ReturnsFnError(t *testing.T) {
+	b := New("test-err", DefaultConfig())
+	want := errors.New("boom")
+
+	if err := b.Execute(context.Background(), func() error { return want }); err != want {
+		t.Errorf("Expected fn error, got %v", err)
+	}
+	if b.Stats().Active != 0 {
+		t.Error("Expected slot to be released")
+	}
+}
+
+func TestManager_Configure(t *testing.T) {
+	m := NewManager(Config{MaxConcurrent: 5})
+	m.Configure("slow-api", Config{MaxConcurrent: 2})
+
+	if cfg := m.Get("slow-api").Config(); cfg.MaxConcurrent != 2 || cfg.MaxQueue != 50 {
+		t.Errorf("Expected override with defaults, got %+v", cfg)
+	}
+	if cfg := m.Get("other").Config(); cfg.MaxConcurrent != 5 {
+		t.Errorf("Expected shared config, got %+v", cfg)
+	}
+	if len(m.GetAllStats()) != 2 {
+		t.Errorf("Expected 2 stats, got %d", len(m.GetAllStats()))
+	}
+}
+
+func TestSetup_AfterGetManager(t *testing.T) {
+	resetDefault(t)
+	if AllStats() != nil {
+		t.Error("Expected no stats before first use")
+	}
+
+	// A bulkhead used before Setup picks up the configuration | Setup 之前使用的舱壁会应用配置
+	early := GetManager().Get("payment-api")
+	if early.Config().MaxConcurrent != 20 {
+		t.Fatalf("Expected default config, got %+v", early.Config())
+	}
+
+	Setup(map[string]Config{
+		DefaultName:   {MaxConcurrent: 8},
+		"payment-api": {MaxConcurrent: 3},
+	})
+	if cfg := GetManager().Get("payment-api").Config(); cfg.MaxConcurrent != 3 {
+		t.Errorf("Expected override after Setup, got %+v", cfg)
+	}
+	if cfg := GetManager().Get("search-api").Config(); cfg.MaxConcurrent != 8 || cfg.MaxQueue != 50 {
+		t.Errorf("Expected shared config after Setup, got %+v", cfg)
+	}
+	if len(AllStats()) != 2 {
+		t.Errorf("Expected 2 stats, got %d", len(AllStats()))
+	}
+}
+
+func TestDefaultManager_Concurrent(t *testing.T) {
+	resetDefault(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Execute(context.Background(), "shared", func() error { return nil })
+		}()
+		go func() {
+			defer wg.Done()
+			AllStats()
+		}()
+	}
+	Setup(map[string]Config{DefaultName: {MaxConcurrent: 4}})
+	wg.Wait()
+
+	if cfg := GetManager().Get("shared").Config(); cfg.MaxConcurrent != 4 {
+		t.Errorf("Expected configured bulkhead, got %+v", cfg)
+	}
+}
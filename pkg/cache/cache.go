@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 // ErrNotFound indicates cache key not found
@@ -26,6 +30,7 @@ type Config struct {
 	LocalTTL    time.Duration // local cache TTL, default 1 minute | 本地缓存 TTL，默认 1 分钟
 	LocalSize   int           // maximum local cache entries, default 10000 | 最大本地缓存条目数，默认 10000
 	EnableLocal bool          // enable local cache, default true | 启用本地缓存，默认 true
+	Clock       clock.Clock   // time source of local TTLs, default real time | 本地 TTL 的时间来源，默认为真实时间
 }
 
 // Cache implements a two-level cache system
@@ -34,6 +39,22 @@ type Cache struct {
 	redis  RedisClient
 	local  *localCache
 	config Config
+
+	versions sync.Map // namespace versions without Redis | 无 Redis 时的命名空间版本
+
+	localHits atomic.Uint64
+	redisHits atomic.Uint64
+	misses    atomic.Uint64
+}
+
+// Stats represents cache statistics
+// Stats 表示缓存统计信息
+type Stats struct {
+	LocalHits uint64 // Lookups served by the local cache | 本地缓存命中次数
+	RedisHits uint64 // Lookups served by Redis | Redis 命中次数
+	Misses    uint64 // Lookups found in neither level | 两级均未命中次数
+	Evictions uint64 // Expired local entries removed | 移除的过期本地条目数
+	LocalSize int    // Current local entry count | 当前本地条目数
 }
 
 var defaultCache *Cache
@@ -90,7 +111,7 @@ func Del(ctx context.Context, keys ...string) error {
 func New(redis RedisClient, cfg Config) *Cache {
 	return &Cache{
 		redis:  redis,
-		local:  newLocalCache(cfg.LocalSize, cfg.LocalTTL),
+		local:  newLocalCache(cfg.LocalSize, cfg.LocalTTL, cfg.Clock),
 		config: cfg,
 	}
 }
@@ -98,9 +119,14 @@ func New(redis RedisClient, cfg Config) *Cache {
 // GetValue retrieves a cached value
 // GetValue 获取缓存值
 func (c *Cache) GetValue(ctx context.Context, key string, dest any) error {
+	ctx, span := startSpan(ctx, "cache get", key)
+	defer span.end()
+
 	// 1. Check local cache first | 首先检查本地缓存
 	if c.config.EnableLocal {
 		if data, ok := c.local.get(key); ok {
+			c.localHits.Add(1)
+			span.hit("local")
 			return json.Unmarshal(data, dest)
 		}
 	}
@@ -109,6 +135,8 @@ func (c *Cache) GetValue(ctx context.Context, key string, dest any) error {
 	if c.redis != nil {
 		val, err := c.redis.Get(ctx, key)
 		if err == nil {
+			c.redisHits.Add(1)
+			span.hit("redis")
 			// Backfill local cache | 回填本地缓存
 			if c.config.EnableLocal {
 				c.local.set(key, []byte(val))
@@ -121,6 +149,7 @@ func (c *Cache) GetValue(ctx context.Context, key string, dest any) error {
 		}
 	}
 
+	c.misses.Add(1)
 	return ErrNotFound
 }
 
@@ -158,7 +187,10 @@ func (c *Cache) GetOrSet(ctx context.Context, key string, dest any, ttl time.Dur
 	}
 
 	// Load data | 加载数据
+	_, span := startSpan(ctx, "cache load", key)
 	val, err := loader()
+	span.fail(err)
+	span.end()
 	if err != nil {
 		return err
 	}
@@ -193,6 +225,18 @@ func (c *Cache) Del(ctx context.Context, keys ...string) error {
 	return nil
 }
 
+// Stats returns hit, miss and eviction counts since creation
+// Stats 返回创建以来的命中、未命中和淘汰次数
+func (c *Cache) Stats() Stats {
+	return Stats{
+		LocalHits: c.localHits.Load(),
+		RedisHits: c.redisHits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.local.evictions.Load(),
+		LocalSize: c.local.Len(),
+	}
+}
+
 // Close closes the cache and stops cleanup goroutines
 // Close 关闭缓存并停止清理协程
 func (c *Cache) Close() {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 func TestLocalCache(t *testing.T) {
-	cache := newLocalCache(100, time.Minute)
+	cache := newLocalCache(100, time.Minute, nil)
 
 	// Test set and get
 	cache.set("key1", []byte("value1"))
@@ -35,7 +37,8 @@ func TestLocalCache(t *testing.T) {
 }
 
 func TestLocalCacheExpiration(t *testing.T) {
-	cache := newLocalCache(100, 50*time.Millisecond)
+	fake := clock.NewFake(time.Now())
+	cache := newLocalCache(100, 50*time.Millisecond, fake)
 
 	cache.set("key1", []byte("value1"))
 
@@ -45,8 +48,13 @@ func TestLocalCacheExpiration(t *testing.T) {
 		t.Error("Key should exist immediately after set")
 	}
 
+	fake.Advance(50 * time.Millisecond)
+	if _, ok := cache.get("key1"); !ok {
+		t.Error("Key should exist until its TTL has passed")
+	}
+
 	// Wait for expiration
-	time.Sleep(100 * time.Millisecond)
+	fake.Advance(time.Millisecond)
 
 	// Should be expired
 	_, ok = cache.get("key1")
@@ -56,7 +64,7 @@ func TestLocalCacheExpiration(t *testing.T) {
 }
 
 func TestLocalCacheLen(t *testing.T) {
-	cache := newLocalCache(100, time.Minute)
+	cache := newLocalCache(100, time.Minute, nil)
 
 	if cache.Len() != 0 {
 		t.Error("New cache should be empty")
@@ -77,7 +85,8 @@ func TestLocalCacheLen(t *testing.T) {
 }
 
 func TestLocalCacheEviction(t *testing.T) {
-	cache := newLocalCache(3, 10*time.Millisecond) // Short TTL for eviction
+	fake := clock.NewFake(time.Now())
+	cache := newLocalCache(3, 10*time.Millisecond, fake) // Short TTL for eviction
 
 	cache.set("key1", []byte("value1"))
 	cache.set("key2", []byte("value2"))
@@ -88,14 +97,36 @@ func TestLocalCacheEviction(t *testing.T) {
 	}
 
 	// Wait for items to expire
-	time.Sleep(20 * time.Millisecond)
+	fake.Advance(20 * time.Millisecond)
 
 	// Adding 4th item should trigger eviction of expired items
 	cache.set("key4", []byte("value4"))
 
 	// After eviction, only key4 should remain (others expired)
-	if cache.Len() > 3 {
-		t.Errorf("Cache should not exceed maxSize after eviction, got %d", cache.Len())
+	if cache.Len() != 1 {
+		t.Errorf("Expected only key4 after eviction, got %d items", cache.Len())
+	}
+}
+
+func TestLocalCacheCleanup(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	cache := newLocalCache(100, time.Second, fake)
+	defer cache.Close()
+
+	cache.set("key1", []byte("value1"))
+	fake.BlockUntil(1) // Cleanup ticker | 清理 ticker
+	fake.Advance(time.Minute)
+
+	// The cleanup goroutine removes the expired entry | 清理协程移除过期条目
+	deadline := time.Now().Add(time.Second)
+	for cache.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected cleanup to remove the expired entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := cache.evictions.Load(); n != 1 {
+		t.Errorf("evictions = %d, want 1", n)
 	}
 }
 
@@ -232,3 +263,48 @@ func TestCacheDel(t *testing.T) {
 		t.Errorf("Expected ErrNotFound, got %v", err)
 	}
 }
+
+func TestCacheStats(t *testing.T) {
+	redis := newMockRedis()
+	cache := New(redis, Config{
+		LocalTTL:    time.Minute,
+		LocalSize:   100,
+		EnableLocal: true,
+	})
+
+	ctx := context.Background()
+	redis.Set(ctx, "remote", `"value"`, time.Hour)
+	cache.SetValue(ctx, "local", "value", time.Hour)
+
+	var result string
+	cache.GetValue(ctx, "local", &result)
+	cache.GetValue(ctx, "remote", &result)
+	cache.GetValue(ctx, "missing", &result)
+
+	stats := cache.Stats()
+	if stats.LocalHits != 1 || stats.RedisHits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 local hit, 1 redis hit, 1 miss", stats)
+	}
+	if stats.LocalSize != 2 {
+		t.Errorf("LocalSize = %d, want 2", stats.LocalSize)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []*Cache{New(newMockRedis(), Config{}), New(nil, Config{})} {
+		if v, err := c.Version(ctx, "users"); err != nil || v != "0" {
+			t.Fatalf("Version before Bump = %q, %v", v, err)
+		}
+		c.Bump(ctx, "users", "orders")
+		v1, _ := c.Version(ctx, "users")
+		c.Bump(ctx, "users")
+		v2, _ := c.Version(ctx, "users")
+		if v1 == "0" || v1 == v2 {
+			t.Errorf("Bump should change the version: %q then %q", v1, v2)
+		}
+		if v, _ := c.Version(ctx, "orders"); v != v1 {
+			t.Errorf("orders = %q, want %q", v, v1)
+		}
+	}
+}
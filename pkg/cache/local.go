@@ -2,7 +2,10 @@ package cache
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 // localCache is the local memory cache
@@ -11,7 +14,10 @@ type localCache struct {
 	mu      sync.RWMutex
 	maxSize int
 	ttl     time.Duration
+	clock   clock.Clock
 	stopCh  chan struct{}
+
+	evictions atomic.Uint64
 }
 
 type cacheItem struct {
@@ -19,15 +25,19 @@ type cacheItem struct {
 	expireAt time.Time
 }
 
-func newLocalCache(maxSize int, ttl time.Duration) *localCache {
+func newLocalCache(maxSize int, ttl time.Duration, clk clock.Clock) *localCache {
+	if clk == nil {
+		clk = clock.Real()
+	}
 	c := &localCache{
 		data:    make(map[string]*cacheItem),
 		maxSize: maxSize,
 		ttl:     ttl,
+		clock:   clk,
 		stopCh:  make(chan struct{}),
 	}
 	// Start cleanup goroutine
-	go c.cleanup()
+	go c.cleanup(clk.NewTicker(time.Minute))
 	return c
 }
 
@@ -41,7 +51,7 @@ func (c *localCache) get(key string) ([]byte, bool) {
 	}
 
 	// Check if expired
-	if time.Now().After(item.expireAt) {
+	if c.clock.Now().After(item.expireAt) {
 		return nil, false
 	}
 
@@ -59,7 +69,7 @@ func (c *localCache) set(key string, value []byte) {
 
 	c.data[key] = &cacheItem{
 		value:    value,
-		expireAt: time.Now().Add(c.ttl),
+		expireAt: c.clock.Now().Add(c.ttl),
 	}
 }
 
@@ -71,17 +81,17 @@ func (c *localCache) del(key string) {
 
 // evict removes expired data
 func (c *localCache) evict() {
-	now := time.Now()
+	now := c.clock.Now()
 	for key, item := range c.data {
 		if now.After(item.expireAt) {
 			delete(c.data, key)
+			c.evictions.Add(1)
 		}
 	}
 }
 
 // cleanup periodically cleans expired data
-func (c *localCache) cleanup() {
-	ticker := time.NewTicker(time.Minute)
+func (c *localCache) cleanup(ticker *clock.Ticker) {
 	defer ticker.Stop()
 
 	for {
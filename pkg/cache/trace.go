@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cacheSpan is a span around a cache operation, nil when ctx is not traced
+// cacheSpan 是缓存操作的 span，ctx 未被追踪时为 nil
+type cacheSpan struct {
+	span trace.Span
+}
+
+// startSpan starts a child span of the span in ctx; a miss is assumed until hit is called
+// startSpan 创建 ctx 中 span 的子 span；在调用 hit 之前视为未命中
+func startSpan(ctx context.Context, name, key string) (context.Context, *cacheSpan) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, nil
+	}
+	ctx, span := parent.TracerProvider().Tracer("cache").Start(ctx, name,
+		trace.WithAttributes(
+			attribute.String("cache.key", key),
+			attribute.Bool("cache.hit", false),
+		))
+	return ctx, &cacheSpan{span: span}
+}
+
+// hit marks the lookup as served by layer ("local" or "redis")
+// hit 标记查询由 layer（"local" 或 "redis"）命中
+func (s *cacheSpan) hit(layer string) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attribute.Bool("cache.hit", true), attribute.String("cache.layer", layer))
+}
+
+// fail records err if not nil
+// fail 在 err 不为 nil 时记录错误
+func (s *cacheSpan) fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// end ends the span
+// end 结束 span
+func (s *cacheSpan) end() {
+	if s != nil {
+		s.span.End()
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// versionPrefix namespaces version keys in Redis | versionPrefix 为 Redis 中的版本键添加命名空间
+const versionPrefix = "cache:version:"
+
+var versionSeq atomic.Uint64
+
+// Version returns the current version of a namespace, "0" until it is bumped.
+// Versions skip the local level so all instances agree; put them in keys to drop
+// a whole namespace at once with Bump. Redis errors are returned so callers skip the cache
+// rather than read entries of an outdated version.
+// Version 返回命名空间的当前版本，Bump 之前为 "0"。
+// 版本不经过本地缓存以保证各实例一致；将其放入键中即可通过 Bump 一次性作废整个命名空间。
+// Redis 错误会被返回，以便调用方跳过缓存，而不是读取过期版本的条目。
+func (c *Cache) Version(ctx context.Context, name string) (string, error) {
+	if c.redis == nil {
+		if v, ok := c.versions.Load(name); ok {
+			return v.(string), nil
+		}
+		return "0", nil
+	}
+	v, err := c.redis.Get(ctx, versionPrefix+name)
+	if isNotFound(err) {
+		return "0", nil
+	}
+	return v, err
+}
+
+// Bump gives namespaces a new version, invalidating the keys built with the old one
+// Bump 为命名空间生成新版本，使用旧版本构建的键随之失效
+func (c *Cache) Bump(ctx context.Context, names ...string) {
+	v := strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(versionSeq.Add(1), 36)
+	for _, name := range names {
+		if c.redis == nil {
+			c.versions.Store(name, v)
+			continue
+		}
+		if err := c.redis.Set(ctx, versionPrefix+name, v, 0); err != nil {
+			log.Printf("cache: redis set version error: %v", err)
+		}
+	}
+}
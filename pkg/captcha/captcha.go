@@ -0,0 +1,139 @@
+// Package captcha provides image captchas with answers kept in a Store
+// Answers are single-use: Verify consumes the captcha whether or not the answer matches,
+// so a captcha cannot be brute-forced.
+// Package captcha 提供图片验证码，答案保存在 Store 中
+// 答案只能使用一次：无论是否匹配，Verify 都会消费验证码，因此无法被暴力破解。
+//
+// Usage | 用法:
+//
+//	captcha.Init(captcha.Config{}, captcha.NewRedisStore(rdb, ""))
+//	router.Get("/captcha", captcha.Handler())
+//	router.Post("/login", captcha.Require(), Login)
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound indicates the captcha does not exist, has expired or was already used
+// ErrNotFound 表示验证码不存在、已过期或已使用
+var ErrNotFound = errors.New("captcha: not found")
+
+// Config represents captcha configuration
+// Config 表示验证码配置
+type Config struct {
+	Width  int           `toml:"width"`  // Image width in pixels, default 120 | 图片宽度（像素），默认 120
+	Height int           `toml:"height"` // Image height in pixels, default 40 | 图片高度（像素），默认 40
+	Length int           `toml:"length"` // Number of digits, default 4 | 数字位数，默认 4
+	TTL    time.Duration `toml:"ttl"`    // Answer lifetime, default 5m | 答案有效期，默认 5m
+	Noise  int           `toml:"noise"`  // Noise lines drawn over the digits, default 4 | 覆盖在数字上的干扰线数量，默认 4
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Width <= 0 {
+		c.Width = 120
+	}
+	if c.Height <= 0 {
+		c.Height = 40
+	}
+	if c.Length <= 0 {
+		c.Length = 4
+	}
+	if c.TTL <= 0 {
+		c.TTL = 5 * time.Minute
+	}
+	if c.Noise <= 0 {
+		c.Noise = 4
+	}
+	return c
+}
+
+// Store keeps captcha answers
+// Store 保存验证码答案
+type Store interface {
+	// Set stores the answer of id for ttl | Set 保存 id 的答案，有效期为 ttl
+	Set(ctx context.Context, id, answer string, ttl time.Duration) error
+	// Take returns and deletes the answer of id, ErrNotFound when missing | Take 返回并删除 id 的答案，不存在时返回 ErrNotFound
+	Take(ctx context.Context, id string) (string, error)
+}
+
+// Captcha generates and verifies captchas
+// Captcha 生成并校验验证码
+type Captcha struct {
+	config Config
+	store  Store
+}
+
+// New creates a captcha generator, a nil store keeps answers in memory
+// New 创建验证码生成器，store 为 nil 时答案保存在内存中
+func New(cfg Config, store Store) *Captcha {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Captcha{config: cfg.withDefaults(), store: store}
+}
+
+// Generate creates a captcha and returns its ID and PNG image
+// Generate 创建验证码并返回其 ID 和 PNG 图片
+func (c *Captcha) Generate(ctx context.Context) (id string, png []byte, err error) {
+	answer := randomDigits(c.config.Length)
+	png, err = render(answer, c.config)
+	if err != nil {
+		return "", nil, err
+	}
+	id = uuid.NewString()
+	if err := c.store.Set(ctx, id, answer, c.config.TTL); err != nil {
+		return "", nil, err
+	}
+	return id, png, nil
+}
+
+// Verify checks the answer of a captcha and consumes it
+// Verify 校验验证码答案并将其消费
+func (c *Captcha) Verify(ctx context.Context, id, answer string) (bool, error) {
+	if id == "" || answer == "" {
+		return false, nil
+	}
+	expected, err := c.store.Take(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.TrimSpace(answer)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(answer)) == 1, nil
+}
+
+var defaultCaptcha = New(Config{}, nil) // Default captcha, answers in memory until Init | 默认验证码，Init 前答案保存在内存中
+
+// Init initializes the default captcha
+// Init 初始化默认验证码
+func Init(cfg Config, store Store) {
+	defaultCaptcha = New(cfg, store)
+}
+
+// Get returns the default captcha
+// Get 返回默认验证码
+func Get() *Captcha {
+	return defaultCaptcha
+}
+
+// Generate creates a captcha with the default captcha
+// Generate 使用默认验证码创建验证码
+func Generate(ctx context.Context) (string, []byte, error) {
+	return defaultCaptcha.Generate(ctx)
+}
+
+// Verify checks and consumes a captcha with the default captcha
+// Verify 使用默认验证码校验并消费验证码
+func Verify(ctx context.Context, id, answer string) (bool, error) {
+	return defaultCaptcha.Verify(ctx, id, answer)
+}
@@ -0,0 +1,124 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGenerateAndVerify(t *testing.T) {
+	store := NewMemoryStore()
+	c := New(Config{Length: 5}, store)
+	ctx := context.Background()
+
+	id, img, err := c.Generate(ctx)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("Image is not a PNG: %v", err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 120 || b.Dy() != 40 {
+		t.Errorf("Expected 120x40 image, got %v", b)
+	}
+
+	// Peek at the answer and put it back | 读取答案后放回
+	answer, err := store.Take(ctx, id)
+	if err != nil || len(answer) != 5 {
+		t.Fatalf("Expected a 5 digit answer, got %q %v", answer, err)
+	}
+	_ = store.Set(ctx, id, answer, time.Minute)
+
+	if ok, _ := c.Verify(ctx, id, answer); !ok {
+		t.Error("Correct answer should verify")
+	}
+	if ok, _ := c.Verify(ctx, id, answer); ok {
+		t.Error("Captcha should be single-use")
+	}
+}
+
+func TestWrongAnswerConsumes(t *testing.T) {
+	store := NewMemoryStore()
+	c := New(Config{}, store)
+	ctx := context.Background()
+	_ = store.Set(ctx, "id", "1234", time.Minute)
+
+	if ok, _ := c.Verify(ctx, "id", "0000"); ok {
+		t.Error("Wrong answer should not verify")
+	}
+	if ok, _ := c.Verify(ctx, "id", "1234"); ok {
+		t.Error("A failed attempt should consume the captcha")
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	_ = store.Set(ctx, "id", "1234", -time.Second)
+	if _, err := store.Take(ctx, "id"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for expired answer, got %v", err)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "id", "1234", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !mr.Exists("captcha:id") {
+		t.Error("Expected key with default prefix")
+	}
+	if answer, err := store.Take(ctx, "id"); err != nil || answer != "1234" {
+		t.Errorf("Expected 1234, got %q %v", answer, err)
+	}
+	if _, err := store.Take(ctx, "id"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after Take, got %v", err)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	store := NewMemoryStore()
+	c := New(Config{}, store)
+	_ = store.Set(context.Background(), "a", "1234", time.Minute)
+	_ = store.Set(context.Background(), "b", "4321", time.Minute)
+
+	app := fiber.New()
+	app.Post("/login", c.Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	tests := []struct {
+		name   string
+		id     string
+		answer string
+		status int
+	}{
+		{"correct", "a", "1234", fiber.StatusOK},
+		{"reused", "a", "1234", fiber.StatusBadRequest},
+		{"wrong", "b", "0000", fiber.StatusBadRequest},
+		{"missing", "", "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodPost, "/login", nil)
+		req.Header.Set(HeaderID, tt.id)
+		req.Header.Set(HeaderAnswer, tt.answer)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package captcha
+
+import (
+	"encoding/base64"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request headers carrying the captcha, checked before the body fields captcha_id and captcha
+// 携带验证码的请求头，优先于请求体字段 captcha_id 和 captcha 检查
+const (
+	HeaderID     = "X-Captcha-ID"
+	HeaderAnswer = "X-Captcha-Answer"
+)
+
+// Image is the response of Handler
+// Image 是 Handler 的响应
+type Image struct {
+	ID    string `json:"id"`    // Captcha ID to send back with the answer | 随答案一起提交的验证码 ID
+	Image string `json:"image"` // PNG data URL | PNG 数据 URL
+}
+
+// answer holds the captcha fields of a request body | answer 保存请求体中的验证码字段
+type answer struct {
+	ID     string `json:"captcha_id" form:"captcha_id"`
+	Answer string `json:"captcha" form:"captcha"`
+}
+
+// Handler returns a handler that creates a captcha and responds with its ID and image
+// Handler 返回创建验证码并响应其 ID 和图片的处理器
+func (c *Captcha) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, png, err := c.Generate(ctx.UserContext())
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderCacheControl, "no-store")
+		return ctx.JSON(Image{ID: id, Image: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)})
+	}
+}
+
+// Require returns a middleware that rejects requests without a correct captcha answer
+// The answer comes from the X-Captcha-ID / X-Captcha-Answer headers or the captcha_id / captcha body fields.
+// Require 返回拒绝未携带正确验证码答案的请求的中间件
+// 答案来自 X-Captcha-ID / X-Captcha-Answer 请求头或请求体字段 captcha_id / captcha。
+func (c *Captcha) Require() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		a := answer{ID: ctx.Get(HeaderID), Answer: ctx.Get(HeaderAnswer)}
+		if a.ID == "" || a.Answer == "" {
+			// The body is parsed again by the handler, Fiber keeps it | 处理器会再次解析请求体，Fiber 会保留它
+			_ = ctx.BodyParser(&a)
+		}
+		ok, err := c.Verify(ctx.UserContext(), a.ID, a.Answer)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid captcha")
+		}
+		return ctx.Next()
+	}
+}
+
+// Handler returns the image handler of the default captcha
+// Handler 返回默认验证码的图片处理器
+func Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return defaultCaptcha.Handler()(c)
+	}
+}
+
+// Require returns the verification middleware of the default captcha
+// Require 返回默认验证码的校验中间件
+func Require() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return defaultCaptcha.Require()(c)
+	}
+}
@@ -0,0 +1,138 @@
+package captcha
+
+import (
+	"bytes"
+	"crypto/rand"
+	"image"
+	"image/color"
+	"image/png"
+	"math/big"
+)
+
+// glyphs is a 5x7 bitmap font for the digits 0-9
+// glyphs 是数字 0-9 的 5x7 点阵字体
+var glyphs = [10][7]string{
+	{".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	{"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	{".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	{"####.", "....#", "....#", ".###.", "....#", "....#", "####."},
+	{"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	{"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	{"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	{"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	{".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	{".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+}
+
+// randInt returns a uniform random int in [0, n) | randInt 返回 [0, n) 内均匀分布的随机整数
+func randInt(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	v, _ := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	return int(v.Int64())
+}
+
+// randomDigits returns n random decimal digits | randomDigits 返回 n 位随机数字
+func randomDigits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + randInt(10))
+	}
+	return string(b)
+}
+
+// randomColor returns a dark color readable on the light background | randomColor 返回在浅色背景上可读的深色
+func randomColor() color.RGBA {
+	return color.RGBA{R: uint8(randInt(120)), G: uint8(randInt(120)), B: uint8(randInt(120)), A: 255}
+}
+
+// render draws the digits with jitter and noise lines and encodes the image as PNG
+// render 绘制带有抖动和干扰线的数字并编码为 PNG
+func render(digits string, cfg Config) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	bg := color.RGBA{R: uint8(225 + randInt(30)), G: uint8(225 + randInt(30)), B: uint8(225 + randInt(30)), A: 255}
+	for y := 0; y < cfg.Height; y++ {
+		for x := 0; x < cfg.Width; x++ {
+			img.SetRGBA(x, y, bg)
+		}
+	}
+
+	// Each digit gets an equal cell, the glyph is scaled to fit it | 每个数字占据等宽单元格，字形按单元格缩放
+	cell := cfg.Width / len(digits)
+	scale := max(min(cell/7, cfg.Height/10), 1)
+	for i, d := range digits {
+		glyph := glyphs[d-'0']
+		c := randomColor()
+		x0 := i*cell + randInt(max(cell-5*scale, 1))
+		y0 := randInt(max(cfg.Height-7*scale, 1))
+		for row, line := range glyph {
+			// Shear rows a little so glyphs are not pixel-identical | 行错位使字形不完全相同
+			shift := (row - 3) * randInt(2)
+			for col, px := range line {
+				if px != '#' {
+					continue
+				}
+				fillRect(img, x0+col*scale+shift, y0+row*scale, scale, scale, c)
+			}
+		}
+	}
+
+	for i := 0; i < cfg.Noise; i++ {
+		drawLine(img, randInt(cfg.Width), randInt(cfg.Height), randInt(cfg.Width), randInt(cfg.Height), randomColor())
+	}
+	for i := 0; i < cfg.Width*cfg.Height/20; i++ {
+		img.SetRGBA(randInt(cfg.Width), randInt(cfg.Height), randomColor())
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fillRect fills a w x h rectangle at (x, y), clipped to the image | fillRect 在 (x, y) 填充 w x h 矩形，超出图片部分被裁剪
+func fillRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// drawLine draws a line with Bresenham's algorithm | drawLine 使用 Bresenham 算法画线
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package captcha
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryStore keeps answers in process memory, for single instance deployments and tests
+// memoryStore 将答案保存在进程内存中，适用于单实例部署和测试
+type memoryStore struct {
+	mu      sync.Mutex
+	answers map[string]memoryAnswer
+}
+
+type memoryAnswer struct {
+	answer    string
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() Store {
+	return &memoryStore{answers: make(map[string]memoryAnswer)}
+}
+
+func (s *memoryStore) Set(_ context.Context, id, answer string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// Expired answers are dropped on write, no cleanup goroutine is needed | 写入时清理过期答案，无需清理协程
+	for k, a := range s.answers {
+		if now.After(a.expiresAt) {
+			delete(s.answers, k)
+		}
+	}
+	s.answers[id] = memoryAnswer{answer: answer, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Take(_ context.Context, id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.answers[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	delete(s.answers, id)
+	if time.Now().After(a.expiresAt) {
+		return "", ErrNotFound
+	}
+	return a.answer, nil
+}
+
+// redisStore keeps answers in Redis so any instance can verify them
+// redisStore 将答案保存在 Redis 中，任意实例都可以校验
+type redisStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis store, prefix defaults to "captcha:"
+// NewRedisStore 创建 Redis 存储，prefix 默认为 "captcha:"
+func NewRedisStore(client redis.UniversalClient, prefix string) Store {
+	if prefix == "" {
+		prefix = "captcha:"
+	}
+	return &redisStore{rdb: client, prefix: prefix}
+}
+
+func (s *redisStore) Set(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.prefix+id, answer, ttl).Err()
+}
+
+func (s *redisStore) Take(ctx context.Context, id string) (string, error) {
+	answer, err := s.rdb.GetDel(ctx, s.prefix+id).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return answer, err
+}
@@ -0,0 +1,302 @@
+// Package cdc publishes entity lifecycle events to mq from xorm hooks, a light change data capture.
+// Package cdc 通过 xorm 钩子向 mq 发布实体生命周期事件，即轻量的变更数据捕获。
+//
+// Each event carries JSON snapshots of the row before and after the write, so downstream
+// services and audit consumers react to changes without database triggers or Debezium.
+// Events of an entity go to the topic "<prefix>:<entity>", typed "<entity>.created",
+// "<entity>.updated" or "<entity>.deleted". Snapshots use the json form of the model, fields
+// tagged json:"-" (e.g. password hashes) never leave the service.
+// 每个事件携带写入前后行的 JSON 快照，下游服务和审计消费者无需数据库触发器或 Debezium 即可响应变更。
+// 实体的事件发送到主题 "<prefix>:<entity>"，类型为 "<entity>.created"、"<entity>.updated" 或 "<entity>.deleted"。
+// 快照使用模型的 json 形式，标记 json:"-" 的字段（例如密码哈希）不会离开服务。
+//
+// Hooks run after the commit when the write is in a transaction (xorm defers them), and the
+// event is published in the background. Like search.Sync, updates and deletes need the ID on the bean.
+// 写入位于事务中时钩子在提交后运行（xorm 会延后执行），事件在后台发布。与 search.Sync 相同，更新和删除需要在 bean 上设置 ID。
+//
+// Example | 示例:
+//
+//	func (a *Article) BeforeUpdate() { cdc.Before(a) }
+//	func (a *Article) BeforeDelete() { cdc.Before(a) }
+//	func (a *Article) AfterInsert()  { cdc.Created(a) }
+//	func (a *Article) AfterUpdate()  { cdc.Updated(a) }
+//	func (a *Article) AfterDelete()  { cdc.Deleted(a) }
+//
+//	cdc.Consume(ctx, "article", "notifier", func(ctx context.Context, e *cdc.Event) error { ... })
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/pkg/mq"
+)
+
+// Lifecycle actions | 生命周期动作
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Config represents change event configuration
+// Config 表示变更事件配置
+type Config struct {
+	Enabled bool                   `toml:"enabled"` // Publish events, hooks do nothing otherwise | 发布事件，否则钩子不做任何操作
+	Prefix  string                 `toml:"prefix"`  // Topic prefix, topics are "<prefix>:<entity>", default "cdc" | 主题前缀，主题为 "<prefix>:<entity>"，默认 "cdc"
+	Timeout time.Duration          `toml:"timeout"` // Snapshot loading and publishing timeout, default 5s | 快照加载和发布的超时，默认 5s
+	Models  map[string]ModelConfig `toml:"models"`  // Per entity settings | 按实体的设置
+}
+
+// ModelConfig represents the settings of one entity
+// ModelConfig 表示单个实体的设置
+type ModelConfig struct {
+	Disable bool     `toml:"disable"` // Publish nothing for the entity | 不发布该实体的事件
+	Topic   string   `toml:"topic"`   // Topic override | 覆盖主题
+	Actions []string `toml:"actions"` // created, updated and/or deleted, empty for all | created、updated 和/或 deleted，为空表示全部
+	Wait    bool     `toml:"wait"`    // Wait for the broker ack (mq.PublishAndWait) | 等待 broker 确认（mq.PublishAndWait）
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Prefix == "" {
+		c.Prefix = "cdc"
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	return c
+}
+
+// Entity is a model publishing lifecycle events
+// Entity 表示发布生命周期事件的模型
+type Entity interface {
+	EntityName() string // Entity name, e.g. "article" | 实体名称，例如 "article"
+	EntityID() string   // Row ID, empty skips the event | 行 ID，为空时跳过事件
+	// EntitySnapshot reloads the row, false once it is gone (including soft deletes)
+	// EntitySnapshot 重新加载行，不存在（包括软删除）时返回 false
+	EntitySnapshot(ctx context.Context) (any, bool, error)
+}
+
+// Event is a lifecycle event of an entity
+// Event 表示实体的生命周期事件
+type Event struct {
+	ID      string          `json:"id"`                // Unique event ID, for idempotent consumers | 唯一事件 ID，用于幂等消费
+	Type    string          `json:"type"`              // "<entity>.<action>"
+	Entity  string          `json:"entity"`            // Entity name | 实体名称
+	Action  string          `json:"action"`            // created, updated or deleted | created、updated 或 deleted
+	Key     string          `json:"key"`               // Row ID | 行 ID
+	Before  json.RawMessage `json:"before,omitempty"`  // Row before the write, absent on create | 写入前的行，创建时不存在
+	After   json.RawMessage `json:"after,omitempty"`   // Row after the write, absent on delete | 写入后的行，删除时不存在
+	Changed []string        `json:"changed,omitempty"` // Fields differing between Before and After | Before 与 After 之间不同的字段
+	Time    time.Time       `json:"time"`              // Time of the write | 写入时间
+}
+
+// publisher sends an event payload, replaced in tests | publisher 发送事件负载，测试中可替换
+type publisher func(ctx context.Context, topic string, payload []byte, wait bool) error
+
+var (
+	config = Config{}.withDefaults()
+
+	publish publisher = func(ctx context.Context, topic string, payload []byte, wait bool) error {
+		if wait {
+			return mq.PublishAndWait(ctx, topic, payload)
+		}
+		return mq.Publish(ctx, topic, payload)
+	}
+
+	// Before snapshots waiting for the After hook of the same bean | 等待同一 bean 的 After 钩子的写入前快照
+	pendingMu sync.Mutex
+	pending   = make(map[any]snapshot)
+	lastSweep time.Time
+)
+
+// snapshot is a row captured before a write | snapshot 为写入前捕获的行
+type snapshot struct {
+	data json.RawMessage
+	at   time.Time
+}
+
+// pendingTTL drops Before snapshots whose write failed and never reached an After hook
+// pendingTTL 清除写入失败、未到达 After 钩子的写入前快照
+const pendingTTL = time.Minute
+
+// Init applies the configuration | Init 应用配置
+func Init(cfg Config) {
+	config = cfg.withDefaults()
+}
+
+// Topic returns the topic of an entity's events | Topic 返回实体事件的主题
+func Topic(entity string) string {
+	return config.topic(entity)
+}
+
+// topic returns the topic of an entity's events | topic 返回实体事件的主题
+func (c Config) topic(entity string) string {
+	if m := c.Models[entity]; m.Topic != "" {
+		return m.Topic
+	}
+	return c.Prefix + ":" + entity
+}
+
+// wants reports whether action events of entity are published | wants 报告是否发布实体的 action 事件
+func wants(entity, action string) bool {
+	if !config.Enabled {
+		return false
+	}
+	m := config.Models[entity]
+	return !m.Disable && (len(m.Actions) == 0 || slices.Contains(m.Actions, action))
+}
+
+// Before captures the row about to change, call it from BeforeUpdate and BeforeDelete
+// It reads the database synchronously, and only when update or delete events are published.
+// Before 捕获即将变更的行，在 BeforeUpdate 和 BeforeDelete 中调用
+// 它会同步读取数据库，且仅在发布更新或删除事件时读取。
+func Before(e Entity) {
+	name := e.EntityName()
+	if (!wants(name, ActionUpdated) && !wants(name, ActionDeleted)) || e.EntityID() == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	data, err := load(ctx, e)
+	if err != nil {
+		log.Printf("cdc: snapshot %s %s failed: %v", name, e.EntityID(), err)
+		return
+	}
+
+	now := time.Now()
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if now.Sub(lastSweep) > pendingTTL {
+		for k, s := range pending {
+			if now.Sub(s.at) > pendingTTL {
+				delete(pending, k)
+			}
+		}
+		lastSweep = now
+	}
+	pending[e] = snapshot{data: data, at: now}
+}
+
+// Created publishes a created event, call it from AfterInsert | Created 发布创建事件，在 AfterInsert 中调用
+func Created(e Entity) { emit(e, ActionCreated) }
+
+// Updated publishes an updated event, call it from AfterUpdate | Updated 发布更新事件，在 AfterUpdate 中调用
+func Updated(e Entity) { emit(e, ActionUpdated) }
+
+// Deleted publishes a deleted event, call it from AfterDelete | Deleted 发布删除事件，在 AfterDelete 中调用
+func Deleted(e Entity) { emit(e, ActionDeleted) }
+
+// emit builds and publishes the event in the background | emit 在后台构建并发布事件
+func emit(e Entity, action string) {
+	pendingMu.Lock()
+	before := pending[e]
+	delete(pending, e)
+	pendingMu.Unlock()
+
+	name, key := e.EntityName(), e.EntityID()
+	if !wants(name, action) || key == "" {
+		return
+	}
+	cfg, pub, at := config, publish, time.Now()
+	// Hooks run inside the write, which must not wait for the broker | 钩子在写入过程中运行，不能等待 broker
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		defer cancel()
+		if err := send(ctx, cfg, pub, e, key, action, before.data, at); err != nil {
+			log.Printf("cdc: publish %s.%s %s failed: %v", name, action, key, err)
+		}
+	}()
+}
+
+// send loads the after snapshot and publishes the event, skipping updates that changed nothing
+// send 加载写入后的快照并发布事件，跳过没有任何变更的更新
+func send(ctx context.Context, cfg Config, pub publisher, e Entity, key, action string, before json.RawMessage, at time.Time) error {
+	name := e.EntityName()
+	ev := &Event{
+		ID:     uuid.NewString(),
+		Type:   name + "." + action,
+		Entity: name,
+		Action: action,
+		Key:    key,
+		Before: before,
+		Time:   at,
+	}
+	if action != ActionDeleted {
+		after, err := load(ctx, e)
+		if err != nil {
+			return err
+		}
+		ev.After = after
+	}
+	if action == ActionUpdated {
+		if before != nil && ev.After != nil {
+			ev.Changed = changed(before, ev.After)
+			if len(ev.Changed) == 0 {
+				return nil
+			}
+		}
+		if ev.After == nil {
+			// Soft deletes are updates that hide the row | 软删除是隐藏行的更新
+			ev.Action, ev.Type = ActionDeleted, name+"."+ActionDeleted
+		}
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return pub(ctx, cfg.topic(name), payload, cfg.Models[name].Wait)
+}
+
+// load returns the JSON snapshot of the row, nil when it is gone | load 返回行的 JSON 快照，不存在时返回 nil
+func load(ctx context.Context, e Entity) (json.RawMessage, error) {
+	row, ok, err := e.EntitySnapshot(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return json.Marshal(row)
+}
+
+// changed returns the top-level fields that differ between two JSON objects, sorted
+// changed 返回两个 JSON 对象之间不同的顶层字段，已排序
+func changed(before, after json.RawMessage) []string {
+	var b, a map[string]any
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil {
+		return nil
+	}
+	var fields []string
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(bv, av) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Consume handles the events of an entity in a consumer group
+// Consume 在消费组中处理实体的事件
+func Consume(ctx context.Context, entity, group string, handler func(ctx context.Context, e *Event) error) error {
+	return mq.Consume(ctx, Topic(entity), group, func(ctx context.Context, msg *mq.Message) error {
+		var e Event
+		if err := json.Unmarshal(msg.Payload, &e); err != nil {
+			return fmt.Errorf("cdc: decode event: %w", err)
+		}
+		return handler(ctx, &e)
+	})
+}
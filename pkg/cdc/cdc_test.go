@@ -0,0 +1,123 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// row is an entity whose stored state the test controls | row 为由测试控制存储状态的实体
+type row struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Secret string `json:"-"`
+
+	stored atomic.Pointer[row] // nil once deleted | 删除后为 nil
+}
+
+func (r *row) EntityName() string { return "article" }
+func (r *row) EntityID() string   { return r.ID }
+func (r *row) EntitySnapshot(context.Context) (any, bool, error) {
+	if s := r.stored.Load(); s != nil {
+		return s, true, nil
+	}
+	return nil, false, nil
+}
+
+type sent struct {
+	topic string
+	event Event
+	wait  bool
+}
+
+// capture enables events with cfg and returns the published ones | capture 使用 cfg 启用事件并返回已发布的事件
+func capture(t *testing.T, cfg Config) chan sent {
+	t.Helper()
+	prevConfig, prevPublish := config, publish
+	ch := make(chan sent, 10)
+	Init(cfg)
+	publish = func(_ context.Context, topic string, payload []byte, wait bool) error {
+		var e Event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			t.Error(err)
+		}
+		ch <- sent{topic, e, wait}
+		return nil
+	}
+	t.Cleanup(func() { config, publish = prevConfig, prevPublish })
+	return ch
+}
+
+func next(t *testing.T, ch chan sent) sent {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(time.Second):
+		t.Fatal("No event published")
+		return sent{}
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	ch := capture(t, Config{Enabled: true, Models: map[string]ModelConfig{"article": {Wait: true}}})
+	r := &row{ID: "1", Title: "draft", Secret: "x"}
+	r.stored.Store(&row{ID: "1", Title: "draft", Secret: "x"})
+
+	Created(r)
+	s := next(t, ch)
+	if s.topic != "cdc:article" || s.event.Type != "article.created" || s.event.Before != nil || !s.wait {
+		t.Errorf("created = %+v", s)
+	}
+	if string(s.event.After) != `{"id":"1","title":"draft"}` {
+		t.Errorf("After = %s, json:\"-\" fields must not be published", s.event.After)
+	}
+
+	Before(r)
+	r.stored.Store(&row{ID: "1", Title: "final"})
+	Updated(r)
+	s = next(t, ch)
+	if s.event.Type != "article.updated" || !reflect.DeepEqual(s.event.Changed, []string{"title"}) {
+		t.Errorf("updated = %+v", s.event)
+	}
+
+	Before(r)
+	Updated(r) // Nothing changed | 无变更
+	Before(r)
+	r.stored.Store(nil)
+	Deleted(r)
+	s = next(t, ch)
+	if s.event.Type != "article.deleted" || string(s.event.Before) != `{"id":"1","title":"final"}` || s.event.After != nil {
+		t.Errorf("deleted = %+v, the no-op update should be skipped", s.event)
+	}
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if len(pending) != 0 {
+		t.Errorf("Snapshots should be released, %d left", len(pending))
+	}
+}
+
+func TestModelConfig(t *testing.T) {
+	ch := capture(t, Config{Enabled: true, Prefix: "app", Models: map[string]ModelConfig{
+		"article": {Actions: []string{ActionDeleted}, Topic: "articles"},
+		"user":    {Disable: true},
+	}})
+	if Topic("article") != "articles" || Topic("order") != "app:order" {
+		t.Errorf("Topic = %s, %s", Topic("article"), Topic("order"))
+	}
+	if !wants("order", ActionCreated) || wants("article", ActionCreated) || !wants("article", ActionDeleted) || wants("user", ActionDeleted) {
+		t.Error("wants does not follow the model settings")
+	}
+
+	r := &row{ID: "1"}
+	r.stored.Store(&row{ID: "1"})
+	Created(r)
+	Before(r)
+	Deleted(r)
+	if s := next(t, ch); s.topic != "articles" || s.event.Action != ActionDeleted {
+		t.Errorf("Only the delete should be published, got %+v", s)
+	}
+}
@@ -0,0 +1,230 @@
+// Package clientgen generates typed Go and TypeScript clients for the HTTP API
+// Routes come from the app, their request and response types from Describe calls next to
+// the handlers. Routes without a description get untyped methods.
+// Package clientgen 为 HTTP API 生成类型化的 Go 和 TypeScript 客户端
+// 路由来自应用，请求和响应类型来自处理器旁的 Describe 调用。未描述的路由生成无类型的方法。
+//
+// Usage | 用法:
+//
+//	g.Post("/", CreateArticle)
+//	clientgen.Describe(CreateArticle, request.CreateArticleReq{}, vo.ArticleVO{})
+//
+//	crab gen client -o web/src/api --lang ts
+package clientgen
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Description holds the types of a handler | Description 保存处理器的类型
+type Description struct {
+	Request  reflect.Type // Body, or query for GET/HEAD/DELETE; nil when there is none | 请求体，GET/HEAD/DELETE 时为查询参数；没有时为 nil
+	Response reflect.Type // The data of response.OK; nil when there is none | response.OK 的 data；没有时为 nil
+}
+
+var (
+	mu           sync.RWMutex
+	descriptions = make(map[string]Description)
+)
+
+// Describe records the request and response types of handler, nil for none
+// The values only provide their types, zero values are fine.
+// Describe 记录处理器的请求和响应类型，nil 表示没有
+// 这些值仅用于提供类型，传零值即可。
+func Describe(handler, req, resp any) {
+	d := Description{Request: reflect.TypeOf(req), Response: reflect.TypeOf(resp)}
+	mu.Lock()
+	defer mu.Unlock()
+	descriptions[FuncName(handler)] = d
+}
+
+// Lookup returns the description of a handler by FuncName
+// Lookup 按 FuncName 返回处理器的描述
+func Lookup(name string) (Description, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d, ok := descriptions[name]
+	return d, ok
+}
+
+// FuncName returns the full name of a function, e.g. github.com/x/handler.CreateArticle
+// FuncName 返回函数的完整名称，例如 github.com/x/handler.CreateArticle
+func FuncName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
+
+// Endpoint is a route of the generated client | Endpoint 表示生成的客户端中的一个路由
+type Endpoint struct {
+	Module    string      // Module owning the route | 路由所属模块
+	Method    string      // HTTP method | HTTP 方法
+	Path      string      // Fiber path, e.g. /testapi/article/:id | Fiber 路径，例如 /testapi/article/:id
+	Handler   string      // FuncName of the final handler | 最终处理器的 FuncName
+	Described bool        // Has a Describe call | 有 Describe 调用
+	Desc      Description // Types when Described | Described 时的类型
+	name      string      // Exported method name, set by prepare | 导出的方法名，由 prepare 设置
+	segments  []pathSegment
+}
+
+// NewEndpoint builds the endpoint of a route, looking up its description
+// NewEndpoint 构建路由的端点，并查找其描述
+func NewEndpoint(module, method, path, handler string) Endpoint {
+	e := Endpoint{Module: module, Method: method, Path: path, Handler: handler}
+	e.Desc, e.Described = Lookup(handler)
+	return e
+}
+
+// queryRequest reports whether the request is sent as query parameters
+// queryRequest 报告请求是否以查询参数发送
+func (e *Endpoint) queryRequest() bool {
+	return e.Method == "GET" || e.Method == "HEAD" || e.Method == "DELETE"
+}
+
+// pathSegment is a literal part of a path or a parameter | pathSegment 为路径中的字面部分或参数
+type pathSegment struct {
+	literal string
+	param   string // Argument name, empty for literals | 参数名，字面部分为空
+	raw     bool   // Wildcard, sent unescaped | 通配符，不转义发送
+}
+
+// paramPattern matches :name, :name? and the wildcards * and + | paramPattern 匹配 :name、:name? 以及通配符 * 和 +
+var paramPattern = regexp.MustCompile(`:(\w+)\??|[*+]\d*`)
+
+// parsePath splits a Fiber path into literals and parameters | parsePath 将 Fiber 路径拆分为字面部分和参数
+func parsePath(path string) []pathSegment {
+	var segs []pathSegment
+	last := 0
+	for _, m := range paramPattern.FindAllStringSubmatchIndex(path, -1) {
+		if m[0] > last {
+			segs = append(segs, pathSegment{literal: path[last:m[0]]})
+		}
+		if m[2] >= 0 {
+			segs = append(segs, pathSegment{param: lowerCamel(path[m[2]:m[3]])})
+		} else {
+			segs = append(segs, pathSegment{param: "wildcard" + strings.TrimLeft(path[m[0]+1:m[1]], "*+"), raw: true})
+		}
+		last = m[1]
+	}
+	if last < len(path) {
+		segs = append(segs, pathSegment{literal: path[last:]})
+	}
+	return segs
+}
+
+// closureSuffix matches the names of closures and method values | closureSuffix 匹配闭包和方法值的名称
+var closureSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*|-fm)$`)
+
+// prepare sorts endpoints and gives each a unique method name: the handler name, else the
+// method and path, prefixed with the module when two modules share it
+// prepare 对端点排序并为每个端点分配唯一的方法名：优先使用处理器名称，否则使用方法和路径，
+// 两个模块重名时加上模块前缀
+func prepare(endpoints []Endpoint) []Endpoint {
+	out := append([]Endpoint(nil), endpoints...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+
+	count := make(map[string]int)
+	for i := range out {
+		e := &out[i]
+		e.segments = parsePath(e.Path)
+		e.name = handlerName(e.Handler)
+		if e.name == "" {
+			e.name = routeName(e.Method, e.Module, e.segments)
+		}
+		count[e.name]++
+	}
+	used := make(map[string]bool)
+	for i := range out {
+		e := &out[i]
+		if count[e.name] > 1 {
+			e.name = upperCamel(e.Module) + e.name
+		}
+		base := e.name
+		for n := 2; used[e.name]; n++ {
+			e.name = fmt.Sprintf("%s%d", base, n)
+		}
+		used[e.name] = true
+	}
+	return out
+}
+
+// handlerName returns the exported name of a named handler, "" for closures
+// handlerName 返回具名处理器的导出名称，闭包返回 ""
+func handlerName(fn string) string {
+	if fn == "" || closureSuffix.MatchString(fn) {
+		return ""
+	}
+	name := fn[strings.LastIndex(fn, ".")+1:]
+	return upperCamel(name)
+}
+
+// routeName derives a name such as GetArticleByID from a route | routeName 从路由派生 GetArticleByID 这样的名称
+func routeName(method, module string, segs []pathSegment) string {
+	var b strings.Builder
+	b.WriteString(upperCamel(strings.ToLower(method)))
+	for _, s := range segs {
+		if s.param != "" {
+			b.WriteString("By" + upperCamel(s.param))
+			continue
+		}
+		for _, part := range strings.Split(s.literal, "/") {
+			if part != "" && part != module {
+				b.WriteString(upperCamel(part))
+			}
+		}
+	}
+	return b.String()
+}
+
+// words splits snake, kebab and camel case names | words 拆分 snake、kebab 和 camel 命名
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// upperCamel converts user_id to UserID | upperCamel 将 user_id 转换为 UserID
+func upperCamel(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		if up := strings.ToUpper(w); initialisms[up] {
+			b.WriteString(up)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// lowerCamel converts user_id to userID | lowerCamel 将 user_id 转换为 userID
+func lowerCamel(s string) string {
+	ws := words(s)
+	if len(ws) == 0 {
+		return "arg"
+	}
+	name := strings.ToLower(ws[0]) + upperCamel(strings.Join(ws[1:], "_"))
+	if goKeywords[name] {
+		name += "_"
+	}
+	return name
+}
+
+// initialisms are kept upper case in Go names | initialisms 在 Go 名称中保持大写
+var initialisms = map[string]bool{"ID": true, "URL": true, "API": true, "HTTP": true, "IP": true, "UUID": true, "JSON": true}
+
+// goKeywords cannot be used as argument names | goKeywords 不能用作参数名
+var goKeywords = map[string]bool{"type": true, "func": true, "map": true, "range": true, "default": true, "case": true, "package": true, "select": true, "go": true, "var": true}
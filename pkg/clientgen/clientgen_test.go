@@ -0,0 +1,110 @@
+package clientgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+type pageReq struct {
+	Page int `json:"page" query:"page"`
+	Size int `json:"size" query:"size"`
+}
+
+type listReq struct {
+	pageReq
+	Status *int   `json:"status" query:"status"`
+	secret string // Unexported, not part of the API | 未导出，不属于 API
+}
+
+type article struct {
+	ID       snowflake.SnowflakeID `json:"id"`
+	Title    string                `json:"title"`
+	Tags     []string              `json:"tags,omitempty"`
+	Created  time.Time             `json:"created"`
+	Password string                `json:"-"`
+	Author   *author               `json:"author"`
+}
+
+type author struct {
+	Name string `json:"name"`
+}
+
+func getArticle()  {}
+func listArticle() {}
+func remove()      {}
+
+func endpoints() []Endpoint {
+	Describe(getArticle, nil, article{})
+	Describe(listArticle, listReq{}, []article{})
+	Describe(remove, nil, nil)
+	return []Endpoint{
+		NewEndpoint("blog", "GET", "/blog/article/:id", FuncName(getArticle)),
+		NewEndpoint("blog", "GET", "/blog/article", FuncName(listArticle)),
+		NewEndpoint("blog", "DELETE", "/blog/article/:id", FuncName(remove)),
+		NewEndpoint("blog", "POST", "/blog/files/*", FuncName(func() {})),
+		NewEndpoint("shop", "DELETE", "/shop/item/:item_id", FuncName(remove)),
+	}
+}
+
+func TestGo(t *testing.T) {
+	src, err := Go("api", endpoints())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package api",
+		"func (c *Client) GetArticle(ctx context.Context, id string) (*Article, error)",
+		"func (c *Client) ListArticle(ctx context.Context, req *ListReq) ([]Article, error)",
+		`encodeQuery(req)`,
+		"func (c *Client) BlogRemove(ctx context.Context, id string) error",
+		"func (c *Client) ShopRemove(ctx context.Context, itemID string) error",
+		`func (c *Client) PostFilesByWildcard(ctx context.Context, wildcard string, body any) (json.RawMessage, error)`,
+		"ID      string    `json:\"id\"`",
+		"Created time.Time `json:\"created\"`",
+		"Author  *Author   `json:\"author\"`",
+		"Tags    []string  `json:\"tags,omitempty\"`",
+		"Page   int  `json:\"page\" query:\"page\"`",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("Go client misses %q", want)
+		}
+	}
+	for _, unwanted := range []string{"Password", "secret", "pageReq"} {
+		if strings.Contains(string(src), unwanted) {
+			t.Errorf("Go client should not contain %q", unwanted)
+		}
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	src := string(TypeScript(endpoints()))
+	for _, want := range []string{
+		"getArticle(id: string | number): Promise<Article>",
+		"`/blog/article/${encodeURIComponent(String(id))}`",
+		"listArticle(req: Partial<ListReq>): Promise<Array<Article>>",
+		`this.request("GET", ` + "`/blog/article`" + `, req, undefined)`,
+		"blogRemove(id: string | number): Promise<void>",
+		"shopRemove(itemID: string | number): Promise<void>",
+		"postFilesByWildcard(wildcard: string, body?: unknown): Promise<unknown>",
+		"  id: string;\n  title: string;\n  tags?: Array<string>;\n  created: string;\n  author: Author | null;\n",
+		"status: number | null;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("TypeScript client misses %q", want)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for in, want := range map[string]string{"user_id": "userID", "type": "type_", "order-item": "orderItem"} {
+		if got := lowerCamel(in); got != want {
+			t.Errorf("lowerCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := routeName("GET", "blog", parsePath("/blog/article/:id/comments")); got != "GetArticleByIDComments" {
+		t.Errorf("routeName = %q", got)
+	}
+}
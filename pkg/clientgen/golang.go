@@ -0,0 +1,291 @@
+package clientgen
+
+import (
+	"fmt"
+	"go/format"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Go returns the source of a Go client package, standard library only
+// Go 返回 Go 客户端包的源码，仅依赖标准库
+func Go(pkg string, endpoints []Endpoint) ([]byte, error) {
+	g := &goGen{types: newNamed()}
+	var methods strings.Builder
+	for _, e := range prepare(endpoints) {
+		g.method(&methods, &e)
+	}
+	// Declaring a type can register the types of its fields | 声明类型时可能注册其字段的类型
+	var decls strings.Builder
+	for i := 0; i < len(g.types.order); i++ {
+		g.decl(&decls, g.types.order[i])
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by crab gen client. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "// Package %s calls the HTTP API with typed requests and responses.\n", pkg)
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", pkg)
+	for _, imp := range []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "reflect", "strings"} {
+		fmt.Fprintf(&b, "\t%q\n", imp)
+	}
+	if g.time {
+		b.WriteString("\t\"time\"\n")
+	}
+	b.WriteString(")\n")
+	b.WriteString(goRuntime)
+	b.WriteString(decls.String())
+	b.WriteString(methods.String())
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("clientgen: format go client: %w", err)
+	}
+	return src, nil
+}
+
+// goGen writes Go declarations | goGen 写出 Go 声明
+type goGen struct {
+	types *named
+	time  bool // time.Time is used | 使用了 time.Time
+}
+
+// typ returns the Go type expression of t | typ 返回 t 的 Go 类型表达式
+func (g *goGen) typ(t reflect.Type) string {
+	switch t {
+	case timeType:
+		g.time = true
+		return "time.Time"
+	case rawMessageType:
+		return "json.RawMessage"
+	}
+	switch wireOf(t) {
+	case wireString:
+		return "string"
+	case wireNumber:
+		return "json.Number"
+	case wireAny:
+		return "json.RawMessage"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Kind().String()
+	case reflect.Pointer:
+		return "*" + g.typ(t.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "[]byte"
+		}
+		return "[]" + g.typ(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + g.typ(t.Elem())
+	case reflect.Map:
+		return "map[" + g.typ(t.Key()) + "]" + g.typ(t.Elem())
+	case reflect.Struct:
+		if t.Name() == "" {
+			var b strings.Builder
+			b.WriteString("struct {\n")
+			g.fields(&b, t)
+			b.WriteString("}")
+			return b.String()
+		}
+		name, _ := g.types.name(t)
+		return name
+	}
+	return "any"
+}
+
+// fields writes the fields of struct type t | fields 写出结构体类型 t 的字段
+func (g *goGen) fields(b *strings.Builder, t reflect.Type) {
+	for _, f := range fields(t) {
+		tag := f.JSON
+		if f.Optional {
+			tag += ",omitempty"
+		}
+		tag = fmt.Sprintf("json:%q", tag)
+		if f.Query != "" {
+			tag += fmt.Sprintf(" query:%q", f.Query)
+		}
+		fmt.Fprintf(b, "\t%s %s `%s`\n", f.Name, g.typ(f.Type), tag)
+	}
+}
+
+// decl writes the declaration of named struct type t | decl 写出具名结构体类型 t 的声明
+func (g *goGen) decl(b *strings.Builder, t reflect.Type) {
+	name, _ := g.types.name(t)
+	fmt.Fprintf(b, "\n// %s mirrors %s.%s\ntype %s struct {\n", name, t.PkgPath(), t.Name(), name)
+	g.fields(b, t)
+	b.WriteString("}\n")
+}
+
+// method writes the client method of an endpoint | method 写出端点的客户端方法
+func (g *goGen) method(b *strings.Builder, e *Endpoint) {
+	args := []string{"ctx context.Context"}
+	var path []string
+	for _, s := range e.segments {
+		switch {
+		case s.param == "":
+			path = append(path, strconv.Quote(s.literal))
+		case s.raw:
+			args = append(args, s.param+" string")
+			path = append(path, s.param)
+		default:
+			args = append(args, s.param+" string")
+			path = append(path, "url.PathEscape("+s.param+")")
+		}
+	}
+
+	query, body := "nil", "nil"
+	switch {
+	case !e.Described && e.queryRequest():
+		args = append(args, "query url.Values")
+		query = "query"
+	case !e.Described:
+		args = append(args, "body any")
+		body = "body"
+	case e.Desc.Request != nil:
+		typ := g.typ(e.Desc.Request)
+		if e.Desc.Request.Kind() == reflect.Struct {
+			typ = "*" + typ
+		}
+		args = append(args, "req "+typ)
+		if e.queryRequest() {
+			query = "encodeQuery(req)"
+		} else {
+			body = "req"
+		}
+	}
+	call := fmt.Sprintf("c.do(ctx, %q, %s, %s, %s, ", e.Method, strings.Join(path, "+"), query, body)
+
+	fmt.Fprintf(b, "\n// %s calls %s %s\n", e.name, e.Method, e.Path)
+	resp := e.Desc.Response
+	switch {
+	case e.Described && resp == nil:
+		fmt.Fprintf(b, "func (c *Client) %s(%s) error {\n\treturn %snil)\n}\n", e.name, strings.Join(args, ", "), call)
+	case !e.Described:
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (json.RawMessage, error) {\n\tvar out json.RawMessage\n\terr := %s&out)\n\treturn out, err\n}\n",
+			e.name, strings.Join(args, ", "), call)
+	case resp.Kind() == reflect.Struct && wireOf(resp) == wireNative:
+		typ := g.typ(resp)
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (*%s, error) {\n\tout := new(%s)\n\tif err := %sout); err != nil {\n\t\treturn nil, err\n\t}\n\treturn out, nil\n}\n",
+			e.name, strings.Join(args, ", "), typ, typ, call)
+	default:
+		typ := g.typ(resp)
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n\tvar out %s\n\terr := %s&out)\n\treturn out, err\n}\n",
+			e.name, strings.Join(args, ", "), typ, typ, call)
+	}
+}
+
+// goRuntime is the transport of the Go client | goRuntime 为 Go 客户端的传输层
+const goRuntime = `
+// Client calls the API; Header is sent with every request, e.g. Authorization
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Header  http.Header
+}
+
+// New returns a client of the API at baseURL
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: http.DefaultClient, Header: http.Header{}}
+}
+
+// Error is a failed call: an HTTP error status or a non-zero response code
+type Error struct {
+	Status int    // HTTP status
+	Code   int    // Response code, -1 when the body is not a response envelope
+	Msg    string // Response message
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("api: status %d, code %d: %s", e.Status, e.Code, e.Msg)
+}
+
+// do sends a request and decodes the data of the response envelope into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var env struct {
+		Code int             ` + "`json:\"code\"`" + `
+		Msg  string          ` + "`json:\"msg\"`" + `
+		Data json.RawMessage ` + "`json:\"data\"`" + `
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return &Error{Status: resp.StatusCode, Code: -1, Msg: http.StatusText(resp.StatusCode)}
+	}
+	if resp.StatusCode >= 300 || env.Code != 0 {
+		return &Error{Status: resp.StatusCode, Code: env.Code, Msg: env.Msg}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// encodeQuery encodes the non-zero fields of a request struct as query parameters
+func encodeQuery(v any) url.Values {
+	q := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return q
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return q
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		f, fv := rv.Type().Field(i), rv.Field(i)
+		name := f.Tag.Get("query")
+		if name == "" {
+			name, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+		if name == "" || name == "-" || fv.IsZero() {
+			continue
+		}
+		for fv.Kind() == reflect.Pointer {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice {
+			for j := 0; j < fv.Len(); j++ {
+				q.Add(name, fmt.Sprint(fv.Index(j).Interface()))
+			}
+			continue
+		}
+		q.Add(name, fmt.Sprint(fv.Interface()))
+	}
+	return q
+}
+`
@@ -0,0 +1,143 @@
+package clientgen
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is a JSON field of a struct, embedded structs flattened
+// field 为结构体的 JSON 字段，嵌入的结构体已展开
+type field struct {
+	Name     string       // Go field name | Go 字段名
+	JSON     string       // JSON name | JSON 名称
+	Query    string       // query tag, "" when absent | query 标签，不存在时为 ""
+	Optional bool         // omitempty | omitempty
+	Type     reflect.Type // Field type | 字段类型
+}
+
+// fields lists the JSON fields of struct type t the way encoding/json sees them
+// fields 按 encoding/json 的规则列出结构体类型 t 的 JSON 字段
+func fields(t reflect.Type) []field {
+	var out []field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				out = append(out, fields(ft)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		query, _, _ := strings.Cut(f.Tag.Get("query"), ",")
+		out = append(out, field{
+			Name:     f.Name,
+			JSON:     name,
+			Query:    query,
+			Optional: strings.Contains(opts, "omitempty"),
+			Type:     ft,
+		})
+	}
+	return out
+}
+
+// wire is how a type with custom marshaling appears in JSON | wire 表示自定义序列化的类型在 JSON 中的形式
+type wire int
+
+const (
+	wireNative wire = iota // No custom marshaling | 无自定义序列化
+	wireString             // A string, e.g. snowflake IDs and times | 字符串，例如雪花 ID 和时间
+	wireNumber             // A number | 数字
+	wireAny                // Unknown | 未知
+)
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+)
+
+// wireOf returns the JSON form of t, probing custom marshalers with the zero value
+// wireOf 返回 t 的 JSON 形式，使用零值探测自定义序列化
+func wireOf(t reflect.Type) (w wire) {
+	ptr := reflect.PointerTo(t)
+	switch {
+	case t.Implements(marshalerType) || ptr.Implements(marshalerType):
+	case t.Implements(textMarshalerType) || ptr.Implements(textMarshalerType):
+		return wireString
+	default:
+		return wireNative
+	}
+
+	defer func() {
+		if recover() != nil {
+			w = wireAny
+		}
+	}()
+	data, err := json.Marshal(reflect.New(t).Interface())
+	if err != nil || len(data) == 0 {
+		return wireAny
+	}
+	switch c := data[0]; {
+	case c == '"', string(data) == "null": // Zero times are often null | 零值时间通常为 null
+		return wireString
+	case c == '-' || (c >= '0' && c <= '9'):
+		return wireNumber
+	}
+	return wireAny
+}
+
+// named collects the struct types to declare, in first use order, with unique names
+// named 按首次使用顺序收集需要声明的结构体类型，并分配唯一名称
+type named struct {
+	order []reflect.Type
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+}
+
+func newNamed() *named {
+	return &named{names: make(map[reflect.Type]string), taken: make(map[string]reflect.Type)}
+}
+
+// name returns the declared name of struct type t, registering it on first use
+// name 返回结构体类型 t 的声明名称，首次使用时注册
+func (n *named) name(t reflect.Type) (string, bool) {
+	if s, ok := n.names[t]; ok {
+		return s, false
+	}
+	base := upperCamel(t.Name())
+	if i := strings.IndexByte(t.Name(), '['); i >= 0 { // Generic instance | 泛型实例
+		base = upperCamel(t.Name()[:i])
+	}
+	s := base
+	if n.taken[s] != nil {
+		pkg := t.PkgPath()
+		prefixed := upperCamel(pkg[strings.LastIndex(pkg, "/")+1:]) + base
+		s = prefixed
+		for i := 2; n.taken[s] != nil; i++ {
+			s = prefixed + strconv.Itoa(i)
+		}
+	}
+	n.names[t] = s
+	n.taken[s] = t
+	n.order = append(n.order, t)
+	return s, true
+}
@@ -0,0 +1,211 @@
+package clientgen
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// TypeScript returns the source of a TypeScript client module using fetch
+// TypeScript 返回使用 fetch 的 TypeScript 客户端模块源码
+func TypeScript(endpoints []Endpoint) []byte {
+	g := &tsGen{types: newNamed()}
+	var methods strings.Builder
+	for _, e := range prepare(endpoints) {
+		g.method(&methods, &e)
+	}
+	var decls strings.Builder
+	for i := 0; i < len(g.types.order); i++ {
+		g.decl(&decls, g.types.order[i])
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by crab gen client. DO NOT EDIT.\n")
+	b.WriteString(decls.String())
+	b.WriteString(tsRuntime)
+	b.WriteString(methods.String())
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// tsGen writes TypeScript declarations | tsGen 写出 TypeScript 声明
+type tsGen struct {
+	types *named
+}
+
+// typ returns the TypeScript type expression of t | typ 返回 t 的 TypeScript 类型表达式
+func (g *tsGen) typ(t reflect.Type) string {
+	if t == rawMessageType {
+		return "unknown"
+	}
+	switch wireOf(t) {
+	case wireString:
+		return "string"
+	case wireNumber:
+		return "number"
+	case wireAny:
+		return "unknown"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Pointer:
+		return g.typ(t.Elem()) + " | null"
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+		return "Array<" + g.typ(t.Elem()) + ">"
+	case reflect.Map:
+		return "Record<string, " + g.typ(t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() == "" {
+			var b strings.Builder
+			b.WriteString("{\n")
+			g.fields(&b, t, "    ")
+			b.WriteString("  }")
+			return b.String()
+		}
+		name, _ := g.types.name(t)
+		return name
+	}
+	return "unknown"
+}
+
+// tsIdent matches property names that need no quotes | tsIdent 匹配无需引号的属性名
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// fields writes the properties of struct type t | fields 写出结构体类型 t 的属性
+func (g *tsGen) fields(b *strings.Builder, t reflect.Type, indent string) {
+	for _, f := range fields(t) {
+		name := f.JSON
+		if !tsIdent.MatchString(name) {
+			name = fmt.Sprintf("%q", name)
+		}
+		if f.Optional {
+			name += "?"
+		}
+		fmt.Fprintf(b, "%s%s: %s;\n", indent, name, g.typ(f.Type))
+	}
+}
+
+// decl writes the interface of named struct type t | decl 写出具名结构体类型 t 的接口
+func (g *tsGen) decl(b *strings.Builder, t reflect.Type) {
+	name, _ := g.types.name(t)
+	fmt.Fprintf(b, "\n/** Mirrors %s.%s */\nexport interface %s {\n", t.PkgPath(), t.Name(), name)
+	g.fields(b, t, "  ")
+	b.WriteString("}\n")
+}
+
+// method writes the client method of an endpoint | method 写出端点的客户端方法
+func (g *tsGen) method(b *strings.Builder, e *Endpoint) {
+	var args []string
+	var path strings.Builder
+	for _, s := range e.segments {
+		switch {
+		case s.param == "":
+			path.WriteString(s.literal)
+		case s.raw:
+			args = append(args, s.param+": string")
+			path.WriteString("${" + s.param + "}")
+		default:
+			args = append(args, s.param+": string | number")
+			path.WriteString("${encodeURIComponent(String(" + s.param + "))}")
+		}
+	}
+
+	query, body := "undefined", "undefined"
+	switch {
+	case !e.Described && e.queryRequest():
+		args = append(args, "query?: Record<string, unknown>")
+		query = "query"
+	case !e.Described:
+		args = append(args, "body?: unknown")
+		body = "body"
+	case e.Desc.Request != nil:
+		typ := g.typ(e.Desc.Request)
+		if e.Desc.Request.Kind() == reflect.Struct {
+			typ = "Partial<" + typ + ">" // Go zero values are omitted | Go 零值字段可省略
+		}
+		args = append(args, "req: "+typ)
+		if e.queryRequest() {
+			query = "req"
+		} else {
+			body = "req"
+		}
+	}
+	resp := "unknown"
+	if e.Described {
+		resp = "void"
+		if e.Desc.Response != nil {
+			resp = g.typ(e.Desc.Response)
+		}
+	}
+
+	name := strings.ToLower(e.name[:1]) + e.name[1:]
+	fmt.Fprintf(b, "\n  /** %s %s */\n  %s(%s): Promise<%s> {\n    return this.request(%q, `%s`, %s, %s);\n  }\n",
+		e.Method, e.Path, name, strings.Join(args, ", "), resp, e.Method, path.String(), query, body)
+}
+
+// tsRuntime is the transport of the TypeScript client, the class body is closed by TypeScript
+// tsRuntime 为 TypeScript 客户端的传输层，类体由 TypeScript 闭合
+const tsRuntime = `
+/** A failed call: an HTTP error status or a non-zero response code */
+export class APIError extends Error {
+  constructor(
+    public readonly status: number,
+    public readonly code: number,
+    message: string,
+  ) {
+    super(message);
+    this.name = "APIError";
+  }
+}
+
+export interface ClientOptions {
+  baseURL: string;
+  /** Sent with every request, e.g. Authorization; a function is called per request */
+  headers?: Record<string, string> | (() => Record<string, string>);
+  fetch?: typeof fetch;
+}
+
+export class Client {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async request<T>(method: string, path: string, query?: object, body?: unknown): Promise<T> {
+    let url = this.options.baseURL.replace(/\/+$/, "") + path;
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value === undefined || value === null || value === "") continue;
+      for (const item of Array.isArray(value) ? value : [value]) params.append(key, String(item));
+    }
+    const search = params.toString();
+    if (search) url += "?" + search;
+
+    const extra = typeof this.options.headers === "function" ? this.options.headers() : this.options.headers;
+    const headers: Record<string, string> = { Accept: "application/json", ...extra };
+    if (body !== undefined) headers["Content-Type"] = "application/json";
+    const res = await (this.options.fetch ?? fetch)(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    let env: { code: number; msg: string; data?: T };
+    try {
+      env = await res.json();
+    } catch {
+      throw new APIError(res.status, -1, res.statusText);
+    }
+    if (!res.ok || env.code !== 0) throw new APIError(res.status, env.code, env.msg);
+    return env.data as T;
+  }
+`
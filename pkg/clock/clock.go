@@ -0,0 +1,104 @@
+// Package clock abstracts the current time and timers so time-dependent code can run on a fake clock in tests
+// Package clock 抽象当前时间和定时器，使依赖时间的代码可以在测试中使用模拟时钟
+//
+// Components take a Clock and default to Real(); tests pass a Fake and move it with Advance
+// instead of sleeping.
+// 组件接收一个 Clock，默认为 Real()；测试传入 Fake 并通过 Advance 推进时间，而不是睡眠等待。
+//
+// Usage | 用法:
+//
+//	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+//	node.SetClock(fake)
+//	fake.Advance(time.Second)
+package clock
+
+import "time"
+
+// Clock tells the time and creates timers
+// Clock 提供当前时间并创建定时器
+type Clock interface {
+	// Now returns the current time | Now 返回当前时间
+	Now() time.Time
+
+	// Since returns the time elapsed since t | Since 返回自 t 起经过的时间
+	Since(t time.Time) time.Duration
+
+	// After sends the time on the returned channel once d has passed
+	// After 在经过 d 后向返回的通道发送时间
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep blocks until d has passed | Sleep 阻塞直到经过 d
+	Sleep(d time.Duration)
+
+	// NewTimer creates a timer firing once after d | NewTimer 创建在 d 后触发一次的定时器
+	NewTimer(d time.Duration) *Timer
+
+	// NewTicker creates a ticker firing every d, d must be positive
+	// NewTicker 创建每隔 d 触发的定时器，d 必须为正数
+	NewTicker(d time.Duration) *Ticker
+}
+
+// Timer is a single event, like time.Timer
+// Timer 表示单次事件，与 time.Timer 相同
+type Timer struct {
+	C <-chan time.Time
+
+	stop  func() bool
+	reset func(time.Duration) bool
+}
+
+// Stop prevents the timer from firing, false if it already fired or was stopped
+// Stop 阻止定时器触发，已触发或已停止时返回 false
+func (t *Timer) Stop() bool {
+	return t.stop()
+}
+
+// Reset changes the timer to fire after d, false if it had fired or been stopped
+// Reset 将定时器改为在 d 后触发，已触发或已停止时返回 false
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.reset(d)
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker
+// Ticks are dropped while the receiver falls behind.
+// Ticker 按间隔发送时间，与 time.Ticker 相同
+// 接收方处理不及时时会丢弃时间。
+type Ticker struct {
+	C <-chan time.Time
+
+	stop  func()
+	reset func(time.Duration)
+}
+
+// Stop turns off the ticker | Stop 关闭 ticker
+func (t *Ticker) Stop() {
+	t.stop()
+}
+
+// Reset changes the interval of the ticker | Reset 修改 ticker 的间隔
+func (t *Ticker) Reset(d time.Duration) {
+	t.reset(d)
+}
+
+// realClock is the system clock | realClock 为系统时钟
+type realClock struct{}
+
+// Real returns the system clock | Real 返回系统时钟
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) *Timer {
+	t := time.NewTimer(d)
+	return &Timer{C: t.C, stop: t.Stop, reset: t.Reset}
+}
+
+func (realClock) NewTicker(d time.Duration) *Ticker {
+	t := time.NewTicker(d)
+	return &Ticker{C: t.C, stop: t.Stop, reset: t.Reset}
+}
@@ -0,0 +1,130 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// received reports whether ch holds a value | received 判断 ch 中是否有值
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeNow(t *testing.T) {
+	f := NewFake(start)
+	f.Advance(time.Minute)
+	if got := f.Now(); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("Now = %v, want %v", got, start.Add(time.Minute))
+	}
+	if got := f.Since(start); got != time.Minute {
+		t.Errorf("Since = %v, want 1m", got)
+	}
+	f.Advance(-2 * time.Minute)
+	if got := f.Since(start); got != -time.Minute {
+		t.Errorf("Since after moving back = %v, want -1m", got)
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	if _, ok := received(timer.C); ok {
+		t.Fatal("Expected timer not to fire before its deadline")
+	}
+	f.Advance(time.Millisecond)
+	if at, ok := received(timer.C); !ok || !at.Equal(start.Add(time.Second)) {
+		t.Fatalf("Expected timer to fire at its deadline, got %v, %v", at, ok)
+	}
+	if timer.Stop() {
+		t.Error("Expected Stop to report a fired timer")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Expected Reset to report a fired timer")
+	}
+	if !timer.Stop() {
+		t.Error("Expected Stop to report a pending timer")
+	}
+	f.Advance(time.Hour)
+	if _, ok := received(timer.C); ok {
+		t.Error("Expected a stopped timer not to fire")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d, want 0", f.Waiters())
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		f.Advance(10 * time.Second)
+		at, ok := received(ticker.C)
+		if want := start.Add(time.Duration(i) * 10 * time.Second); !ok || !at.Equal(want) {
+			t.Fatalf("tick %d = %v, %v, want %v", i, at, ok, want)
+		}
+	}
+
+	// Ticks are dropped while nobody receives | 无人接收时丢弃时间
+	f.Advance(time.Minute)
+	if at, ok := received(ticker.C); !ok || !at.Equal(start.Add(40*time.Second)) {
+		t.Errorf("Expected the first missed tick only, got %v, %v", at, ok)
+	}
+	if _, ok := received(ticker.C); ok {
+		t.Error("Expected the other missed ticks to be dropped")
+	}
+}
+
+func TestFakeFiresInOrder(t *testing.T) {
+	f := NewFake(start)
+	late := f.NewTimer(2 * time.Second)
+	early := f.NewTimer(time.Second)
+
+	f.Advance(time.Hour)
+	a, _ := received(early.C)
+	b, _ := received(late.C)
+	if !a.Equal(start.Add(time.Second)) || !b.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Expected each timer to fire at its own deadline, got %v and %v", a, b)
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Sleep to return after Advance")
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	c := Real()
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatal("Expected real timer to fire")
+	}
+	if c.Since(c.Now()) < 0 {
+		t.Error("Expected real time to move forward")
+	}
+}
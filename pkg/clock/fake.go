@@ -0,0 +1,189 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to, for tests
+// Timers and tickers fire during Advance and Set, in deadline order, with the clock set to
+// their deadline. Moving the clock back fires nothing, like a wall clock jumping back.
+// Fake 是仅在被推进时才移动的时钟，用于测试
+// 定时器和 ticker 在 Advance 和 Set 期间按到期顺序触发，触发时时钟位于其到期时间。
+// 时钟回拨时不会触发任何定时器，与墙上时钟回拨一致。
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending timer or ticker | waiter 为等待中的定时器或 ticker
+type waiter struct {
+	at     time.Time
+	period time.Duration // 0 for timers | 定时器为 0
+	ch     chan time.Time
+}
+
+// NewFake creates a fake clock set to start
+// NewFake 创建设置为 start 的模拟时钟
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time | Now 返回模拟时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t | Since 返回自 t 起经过的模拟时间
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After sends the time once the clock has advanced by d | After 在时钟推进 d 后发送时间
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C
+}
+
+// Sleep blocks until the clock has advanced by d | Sleep 阻塞直到时钟推进 d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTimer creates a timer firing once the clock has advanced by d
+// NewTimer 创建在时钟推进 d 后触发的定时器
+func (f *Fake) NewTimer(d time.Duration) *Timer {
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.schedule(w, d)
+	return &Timer{
+		C:    w.ch,
+		stop: func() bool { return f.remove(w) },
+		reset: func(d time.Duration) bool {
+			active := f.remove(w)
+			f.schedule(w, d)
+			return active
+		},
+	}
+}
+
+// NewTicker creates a ticker firing every time the clock advances by d
+// NewTicker 创建时钟每推进 d 就触发一次的 ticker
+func (f *Fake) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &waiter{period: d, ch: make(chan time.Time, 1)}
+	f.schedule(w, d)
+	return &Ticker{
+		C:    w.ch,
+		stop: func() { f.remove(w) },
+		reset: func(d time.Duration) {
+			if d <= 0 {
+				panic("clock: non-positive interval for Ticker.Reset")
+			}
+			f.remove(w)
+			w.period = d
+			f.schedule(w, d)
+		},
+	}
+}
+
+// Advance moves the clock forward by d, firing the timers that come due; a negative d moves it back
+// Advance 将时钟向前推进 d 并触发到期的定时器；d 为负数时时钟回拨
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing the timers that come due
+// Set 将时钟设置为 t 并触发到期的定时器
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) > 0 && !f.waiters[0].at.After(t) {
+		w := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		if w.at.After(f.now) {
+			f.now = w.at
+		}
+		select {
+		case w.ch <- f.now:
+		default: // Receiver behind, drop the tick | 接收方落后，丢弃本次时间
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			f.insert(w)
+		}
+	}
+	f.now = t
+}
+
+// BlockUntil waits until n timers or tickers are pending, so a test can advance the clock
+// only after the code under test started waiting on it
+// BlockUntil 等待直到有 n 个定时器或 ticker 处于等待状态，使测试在被测代码开始等待之后再推进时钟
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// Waiters returns the number of pending timers and tickers
+// Waiters 返回等待中的定时器和 ticker 数量
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// schedule arms w to fire after d, immediately when d is not positive
+// schedule 设置 w 在 d 后触发，d 不为正数时立即触发
+func (f *Fake) schedule(w *waiter, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d <= 0 && w.period == 0 {
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+		return
+	}
+	w.at = f.now.Add(d)
+	f.insert(w)
+	f.cond.Broadcast()
+}
+
+// insert adds w in deadline order, after waiters with the same deadline; the caller holds f.mu
+// insert 按到期顺序加入 w，排在到期时间相同的 waiter 之后；调用方需持有 f.mu
+func (f *Fake) insert(w *waiter) {
+	i, _ := slices.BinarySearchFunc(f.waiters, w.at, func(e *waiter, at time.Time) int {
+		if e.at.After(at) {
+			return 1
+		}
+		return -1
+	})
+	f.waiters = slices.Insert(f.waiters, i, w)
+}
+
+// remove disarms w and drops an undelivered time, reporting whether it was pending
+// remove 取消 w 并丢弃未接收的时间，返回其是否处于等待状态
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	select {
+	case <-w.ch:
+	default:
+	}
+	i := slices.Index(f.waiters, w)
+	if i < 0 {
+		return false
+	}
+	f.waiters = slices.Delete(f.waiters, i, i+1)
+	return true
+}
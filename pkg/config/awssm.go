@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// AWSSecretsResolver reads secrets from AWS Secrets Manager, for AWSSM(name) or AWSSM(name#key) values
+// With #key the secret string is parsed as JSON and the field key is returned.
+// Empty fields fall back to AWS_REGION (or AWS_DEFAULT_REGION), AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+// AWSSecretsResolver 从 AWS Secrets Manager 读取密钥，用于 AWSSM(name) 或 AWSSM(name#key) 值
+// 带 #key 时将密钥字符串按 JSON 解析并返回 key 字段。
+// 字段为空时使用 AWS_REGION（或 AWS_DEFAULT_REGION）、AWS_ACCESS_KEY_ID、
+// AWS_SECRET_ACCESS_KEY 和 AWS_SESSION_TOKEN。
+type AWSSecretsResolver struct {
+	Region          string       // e.g. us-east-1 | 如 us-east-1
+	AccessKeyID     string       // Access key | 访问密钥
+	SecretAccessKey string       // Secret key | 私有密钥
+	SessionToken    string       // Temporary credentials token | 临时凭据令牌
+	Endpoint        string       // Override, e.g. a VPC endpoint or LocalStack | 覆盖地址，如 VPC 终端节点或 LocalStack
+	Client          *http.Client // Default 10s timeout | 默认 10s 超时
+}
+
+// NewAWSSecretsResolver creates a resolver configured from the environment
+// NewAWSSecretsResolver 创建从环境变量读取配置的解析器
+func NewAWSSecretsResolver() *AWSSecretsResolver {
+	return &AWSSecretsResolver{}
+}
+
+// Resolve fetches the current version of the secret name
+// Resolve 获取密钥 name 的当前版本
+func (a *AWSSecretsResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	name, key, _ := strings.Cut(ref, "#")
+	region := firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	accessKey := firstNonEmpty(a.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := firstNonEmpty(a.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	token := firstNonEmpty(a.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))
+	if region == "" || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("awssm: region and credentials are required (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY)")
+	}
+	endpoint := firstNonEmpty(a.Endpoint, "https://secretsmanager."+region+".amazonaws.com")
+
+	body, _ := json.Marshal(map[string]string{"SecretId": name})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, body, region, "secretsmanager", accessKey, secretKey, time.Now().UTC())
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: secretTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("awssm: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("awssm: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		return "", fmt.Errorf("awssm: %s: %d %s %s", name, resp.StatusCode, e.Type, e.Message)
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("awssm: invalid response: %w", err)
+	}
+	if key == "" {
+		return out.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(out.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("awssm: %s is not a JSON secret: %w", name, err)
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("awssm: %s has no field %s", name, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req
+// signV4 为 req 添加 AWS Signature Version 4 的 Authorization 头
+func signV4(req *http.Request, body []byte, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	// Canonical headers, sorted by lowercase name | 按小写名称排序的规范头
+	names := []string{"content-type", "host", "x-amz-date"}
+	if req.Header.Get("X-Amz-Security-Token") != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	names = append(names, "x-amz-target")
+	var canonical strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonical.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	request := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()),
+		canonical.String(), signed, sha256Hex(body),
+	}, "\n")
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(request))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+// canonicalQuery encodes query parameters sorted by key, spaces as %20
+// canonicalQuery 按键排序编码查询参数，空格编码为 %20
+func canonicalQuery(q url.Values) string {
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// firstNonEmpty returns the first non-empty string
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
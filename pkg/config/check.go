@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nuohe369/crab/pkg/crypto"
+)
+
+// CheckReport lists the problems Check found in a configuration file
+// CheckReport 列出 Check 在配置文件中发现的问题
+type CheckReport struct {
+	Unknown       []string          // Keys that match no field, usually typos | 不匹配任何字段的键，通常是拼写错误
+	Encrypted     []string          // Keys holding ENC() values | 包含 ENC() 值的键
+	Undecryptable map[string]string // ENC() values the key set by SetDecryptKey cannot decrypt | SetDecryptKey 设置的密钥无法解密的 ENC() 值
+	Invalid       map[string]string // Failed validate rule by key | 按键记录未通过的 validate 规则
+	Secrets       []string          // Keys holding registered secret references | 包含已注册密钥引用的键
+	Unresolved    map[string]string // Secret references that failed to resolve | 解析失败的密钥引用
+}
+
+// Check decodes path into target like Load, but reports unknown keys, undecryptable and invalid values instead of failing
+// Without a decryption key, ENC() values are listed but left encrypted in target.
+// Secret references are resolved to verify access, but left unresolved in target.
+// Check 像 Load 一样将 path 解码到 target，但报告未知键、无法解密和不合法的值而不是直接失败
+// 未设置解密密钥时，ENC() 值会被列出，但在 target 中保持加密状态。
+// 密钥引用会被解析以验证访问权限，但在 target 中保持未解析状态。
+func Check(path string, target any) (*CheckReport, error) {
+	return CheckFiles([]string{path}, target)
+}
+
+// CheckFiles is Check for files layered as in LoadFiles, the report covers the merged result
+// CheckFiles 是按 LoadFiles 方式叠加文件的 Check，报告针对合并结果
+func CheckFiles(paths []string, target any) (*CheckReport, error) {
+	data, err := mergeFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	md, err := toml.Decode(data, target)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CheckReport{
+		Undecryptable: make(map[string]string),
+		Invalid:       make(map[string]string),
+		Unresolved:    make(map[string]string),
+	}
+	for _, key := range md.Undecoded() {
+		report.Unknown = append(report.Unknown, key.String())
+	}
+
+	raw := make(map[string]any)
+	if _, err := toml.Decode(data, &raw); err != nil {
+		return nil, err
+	}
+	walkStrings("", raw, func(key, value string) {
+		if _, ok, err := resolveSecret(value); ok || err != nil {
+			report.Secrets = append(report.Secrets, key)
+			if err != nil {
+				report.Unresolved[key] = err.Error()
+			}
+			return
+		}
+		if !crypto.IsEncrypted(value) {
+			return
+		}
+		report.Encrypted = append(report.Encrypted, key)
+		if decryptKey == "" {
+			return
+		}
+		if _, err := crypto.Decrypt(value, decryptKey); err != nil {
+			report.Undecryptable[key] = err.Error()
+		}
+	})
+	sort.Strings(report.Encrypted)
+	sort.Strings(report.Secrets)
+
+	if decryptKey != "" && len(report.Undecryptable) == 0 {
+		if err := decryptFields(reflect.ValueOf(target), decryptKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyTags(md, target); err != nil {
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var fe *FieldError
+			if errors.As(e, &fe) {
+				report.Invalid[fe.Path] = fe.Rule
+			}
+		}
+	}
+	return report, nil
+}
+
+// walkStrings calls fn for every string value of a decoded TOML document with its dotted key
+// walkStrings 对解码后 TOML 文档中的每个字符串值及其点分键调用 fn
+func walkStrings(prefix string, v any, fn func(key, value string)) {
+	switch x := v.(type) {
+	case string:
+		fn(prefix, x)
+	case map[string]any:
+		for k, item := range x {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			walkStrings(key, item, fn)
+		}
+	case []map[string]any:
+		for i, item := range x {
+			walkStrings(fmt.Sprintf("%s[%d]", prefix, i), item, fn)
+		}
+	case []any:
+		for i, item := range x {
+			walkStrings(fmt.Sprintf("%s[%d]", prefix, i), item, fn)
+		}
+	}
+}
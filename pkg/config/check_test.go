@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nuohe369/crab/pkg/crypto"
+)
+
+type checkDB struct {
+	Host     string `toml:"host"`
+	Password string `toml:"password"`
+}
+
+type checkConfig struct {
+	App struct {
+		Name string `toml:"name"`
+	} `toml:"app"`
+	Database map[string]checkDB `toml:"database"`
+}
+
+func TestCheck(t *testing.T) {
+	secret, err := crypto.Encrypt("s3cret", "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[app]
+name = "crab"
+nmae = "typo"
+
+[database.default]
+host = "localhost"
+password = "`+secret+`"
+port = 5432
+`), 0644)
+	defer SetDecryptKey("")
+
+	// Without a key the value is listed but stays encrypted
+	SetDecryptKey("")
+	var cfg checkConfig
+	report, err := Check(path, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(report.Unknown, ",") != "app.nmae,database.default.port" {
+		t.Errorf("unknown = %v", report.Unknown)
+	}
+	if strings.Join(report.Encrypted, ",") != "database.default.password" || len(report.Undecryptable) != 0 {
+		t.Errorf("encrypted = %v, undecryptable = %v", report.Encrypted, report.Undecryptable)
+	}
+	if cfg.Database["default"].Password != secret {
+		t.Errorf("password = %q, want it encrypted", cfg.Database["default"].Password)
+	}
+
+	// The right key decrypts values inside maps too
+	SetDecryptKey("k1")
+	cfg = checkConfig{}
+	if report, err = Check(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Undecryptable) != 0 || cfg.Database["default"].Password != "s3cret" {
+		t.Errorf("undecryptable = %v, password = %q", report.Undecryptable, cfg.Database["default"].Password)
+	}
+
+	SetDecryptKey("wrong")
+	if report, err = Check(path, &checkConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := report.Undecryptable["database.default.password"]; !ok {
+		t.Errorf("undecryptable = %v", report.Undecryptable)
+	}
+}
@@ -29,19 +29,27 @@ func SetDecryptKey(key string) {
 }
 
 // Load loads a TOML configuration file into the target structure.
+// Keys missing from the file take their `default:"..."` tag, then `validate:"..."` tags are checked.
 // Load 将 TOML 配置文件加载到目标结构中
+// 文件中缺失的键使用 `default:"..."` 标签的值，然后检查 `validate:"..."` 标签。
 func Load(path string, target any) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	return load(string(data), target)
+}
 
+// load decodes TOML text into target, then decrypts, resolves secrets, applies defaults and validates.
+// load 将 TOML 文本解码到 target，然后解密、解析密钥、应用默认值并校验
+func load(data string, target any) error {
 	// Check if configuration contains encrypted values but no key was provided | 检查配置是否包含加密值但未提供密钥
-	if decryptKey == "" && strings.Contains(string(data), "ENC(") {
+	if decryptKey == "" && strings.Contains(data, "ENC(") {
 		return ErrEncryptedNoKey
 	}
 
-	if err := toml.Unmarshal(data, target); err != nil {
+	md, err := toml.Decode(data, target)
+	if err != nil {
 		return err
 	}
 	// Decrypt encrypted fields | 解密加密字段
@@ -50,7 +58,12 @@ func Load(path string, target any) error {
 			return err
 		}
 	}
-	return nil
+	// Resolve VAULT(...), AWSSM(...) and other registered secret references | 解析 VAULT(...)、AWSSM(...) 等已注册的密钥引用
+	if err := resolveSecrets(reflect.ValueOf(target)); err != nil {
+		return err
+	}
+	// Fill defaults and validate, see applyTags | 填充默认值并校验，见 applyTags
+	return applyTags(md, target)
 }
 
 // MustLoad loads configuration, exits on failure.
@@ -61,42 +74,70 @@ func MustLoad(path string, target any) {
 	}
 }
 
-// decryptFields recursively decrypts encrypted fields in a struct.
-// decryptFields 递归解密结构体中的加密字段
+// decryptFields recursively decrypts encrypted fields in a struct, including map and slice elements.
+// decryptFields 递归解密结构体中的加密字段，包括 map 和切片元素
 func decryptFields(v reflect.Value, key string) error {
+	return mapStrings(v, func(s string) (string, error) {
+		if !crypto.IsEncrypted(s) {
+			return s, nil
+		}
+		decrypted, err := crypto.Decrypt(s, key)
+		if err != nil {
+			return "", errors.New("decryption failed: " + err.Error())
+		}
+		return decrypted, nil
+	})
+}
+
+// mapStrings replaces every string of a struct, including map and slice elements, with fn's result.
+// mapStrings 将结构体中的每个字符串（包括 map 和切片元素）替换为 fn 的结果
+func mapStrings(v reflect.Value, fn func(string) (string, error)) error {
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 	if v.Kind() != reflect.Struct {
 		return nil
 	}
+	return mapValue(v, fn)
+}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		if !field.CanSet() {
-			continue
+// mapValue applies fn to the strings of a settable value in place.
+// mapValue 原地对可设置值中的字符串应用 fn
+func mapValue(v reflect.Value, fn func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := fn(v.String())
+		if err != nil {
+			return err
 		}
-
-		switch field.Kind() {
-		case reflect.String:
-			s := field.String()
-			if crypto.IsEncrypted(s) {
-				decrypted, err := crypto.Decrypt(s, key)
-				if err != nil {
-					return errors.New("decryption failed: " + err.Error())
+		v.SetString(s)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				if err := mapValue(field, fn); err != nil {
+					return err
 				}
-				field.SetString(decrypted)
 			}
-		case reflect.Struct:
-			if err := decryptFields(field, key); err != nil {
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return mapValue(v.Elem(), fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := mapValue(v.Index(i), fn); err != nil {
 				return err
 			}
-		case reflect.Ptr:
-			if !field.IsNil() {
-				if err := decryptFields(field, key); err != nil {
-					return err
-				}
+		}
+	case reflect.Map:
+		// Map elements are not addressable, map a copy and store it back | map 元素不可寻址，处理副本后写回
+		for _, k := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			if err := mapValue(elem, fn); err != nil {
+				return err
 			}
+			v.SetMapIndex(k, elem)
 		}
 	}
 	return nil
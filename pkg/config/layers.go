@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LoadFiles loads several TOML files into target, each file overriding the keys of the previous ones
+// Tables are merged key by key, any other value, arrays included, is replaced as a whole.
+// Decryption, secret resolution, defaults and validation then run once on the merged result, as in Load.
+// LoadFiles 将多个 TOML 文件加载到 target，后面的文件覆盖前面文件中的键
+// 表按键逐个合并，其他值（包括数组）整体替换。随后在合并结果上执行一次解密、密钥解析、默认值和校验，与 Load 相同。
+//
+// Example | 示例:
+//
+//	paths, _ := config.Layers("config.toml") // config.toml, config.prod.toml, config.local.toml
+//	err := config.LoadFiles(paths, &cfg)
+func LoadFiles(paths []string, target any) error {
+	data, err := mergeFiles(paths)
+	if err != nil {
+		return err
+	}
+	return load(data, target)
+}
+
+// Layers returns path followed by its existing environment and local override files
+// For config.toml these are config.{env}.toml, env being app.env of config.local.toml or else of config.toml,
+// then config.local.toml, which holds machine-specific values and is usually not committed.
+// Layers 返回 path 及其已存在的环境覆盖文件和本地覆盖文件
+// 对于 config.toml，依次为 config.{env}.toml（env 取 config.local.toml 的 app.env，否则取 config.toml 的）
+// 和 config.local.toml（存放本机特有的值，通常不提交）。
+func Layers(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	local := stem + ".local" + ext
+
+	env, err := appEnv(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(local); err == nil {
+		if localEnv, err := appEnv(local); err != nil {
+			return nil, err
+		} else if localEnv != "" {
+			env = localEnv
+		}
+	}
+
+	layers := []string{path}
+	if env != "" && env != "local" {
+		if envFile := stem + "." + env + ext; fileExists(envFile) {
+			layers = append(layers, envFile)
+		}
+	}
+	if fileExists(local) {
+		layers = append(layers, local)
+	}
+	return layers, nil
+}
+
+// appEnv reads app.env from a TOML file
+// appEnv 从 TOML 文件读取 app.env
+func appEnv(path string) (string, error) {
+	var head struct {
+		App struct {
+			Env string `toml:"env"`
+		} `toml:"app"`
+	}
+	if _, err := toml.DecodeFile(path, &head); err != nil {
+		return "", err
+	}
+	return head.App.Env, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// mergeFiles returns the TOML text of the merged files, a single file is returned as is
+// mergeFiles 返回合并后文件的 TOML 文本，单个文件原样返回
+func mergeFiles(paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", fmt.Errorf("config: no configuration file")
+	}
+	if len(paths) == 1 {
+		data, err := os.ReadFile(paths[0])
+		return string(data), err
+	}
+
+	merged := make(map[string]any)
+	for _, path := range paths {
+		layer := make(map[string]any)
+		if _, err := toml.DecodeFile(path, &layer); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		mergeTables(merged, layer)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(merged); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// mergeTables copies src into dst, merging nested tables
+// mergeTables 将 src 复制到 dst，嵌套的表逐键合并
+func mergeTables(dst, src map[string]any) {
+	for k, v := range src {
+		if table, ok := v.(map[string]any); ok {
+			if existing, ok := dst[k].(map[string]any); ok {
+				mergeTables(existing, table)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// DefiningFile returns the last of paths that sets the dotted key, so writing it there takes effect, else the first path
+// DefiningFile 返回 paths 中最后一个设置了点分键 key 的文件（写入该文件才会生效），否则返回第一个路径
+func DefiningFile(paths []string, key string) string {
+	parts := strings.Split(key, ".")
+	for i := len(paths) - 1; i > 0; i-- {
+		var v any
+		md, err := toml.DecodeFile(paths[i], &v)
+		if err == nil && md.IsDefined(parts...) {
+			return paths[i]
+		}
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	return paths[0]
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type layerConfig struct {
+	App struct {
+		Name string `toml:"name"`
+		Env  string `toml:"env"`
+	} `toml:"app"`
+	DB struct {
+		Host  string   `toml:"host"`
+		Port  int      `toml:"port" default:"5432"`
+		Hosts []string `toml:"hosts"`
+	} `toml:"db"`
+	Levels map[string]string `toml:"levels"`
+}
+
+// writeFiles creates files in a temporary directory and returns it
+// writeFiles 在临时目录中创建文件并返回该目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLayers(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.toml":      "[app]\nenv = \"dev\"\n",
+		"config.dev.toml":  "",
+		"config.prod.toml": "",
+	})
+	base := filepath.Join(dir, "config.toml")
+
+	layers, err := Layers(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{base, filepath.Join(dir, "config.dev.toml")}; !reflect.DeepEqual(layers, want) {
+		t.Errorf("Expected %v, got %v", want, layers)
+	}
+
+	// app.env of the local file selects the environment | 本地文件的 app.env 决定环境
+	os.WriteFile(filepath.Join(dir, "config.local.toml"), []byte("[app]\nenv = \"prod\"\n"), 0644)
+	layers, _ = Layers(base)
+	want := []string{base, filepath.Join(dir, "config.prod.toml"), filepath.Join(dir, "config.local.toml")}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("Expected %v, got %v", want, layers)
+	}
+
+	if _, err := Layers(filepath.Join(dir, "missing.toml")); err == nil {
+		t.Error("Expected error for a missing base file")
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.toml": `
+[app]
+name = "crab"
+env = "prod"
+
+[db]
+host = "localhost"
+hosts = ["a", "b"]
+
+[levels]
+http = "info"
+sql = "warn"
+`,
+		"config.prod.toml": `
+[db]
+host = "db.prod"
+hosts = ["c"]
+
+[levels]
+sql = "error"
+`,
+	})
+	paths := []string{filepath.Join(dir, "config.toml"), filepath.Join(dir, "config.prod.toml")}
+
+	cfg := &layerConfig{}
+	if err := LoadFiles(paths, cfg); err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+	if cfg.App.Name != "crab" || cfg.DB.Host != "db.prod" {
+		t.Errorf("Expected merged values, got %+v", cfg)
+	}
+	// Arrays are replaced, tables merged | 数组整体替换，表逐键合并
+	if !reflect.DeepEqual(cfg.DB.Hosts, []string{"c"}) {
+		t.Errorf("Expected hosts to be replaced, got %v", cfg.DB.Hosts)
+	}
+	if cfg.Levels["http"] != "info" || cfg.Levels["sql"] != "error" {
+		t.Errorf("Expected levels to be merged, got %v", cfg.Levels)
+	}
+	// Defaults still apply to keys no layer sets | 所有层都未设置的键仍使用默认值
+	if cfg.DB.Port != 5432 {
+		t.Errorf("Expected default port, got %d", cfg.DB.Port)
+	}
+
+	if got := DefiningFile(paths, "levels"); got != paths[1] {
+		t.Errorf("Expected levels to be defined by %s, got %s", paths[1], got)
+	}
+	if got := DefiningFile(paths, "app.name"); got != paths[0] {
+		t.Errorf("Expected app.name to fall back to %s, got %s", paths[0], got)
+	}
+
+	typo := filepath.Join(writeFiles(t, map[string]string{"x.toml": "[db]\nhots = 1\n"}), "x.toml")
+	report, err := CheckFiles(append(paths, typo), &layerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Unknown, []string{"db.hots"}) {
+		t.Errorf("Expected db.hots to be unknown, got %v", report.Unknown)
+	}
+}
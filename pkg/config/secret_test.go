@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type secretConfig struct {
+	DB struct {
+		User     string            `toml:"user"`
+		Password string            `toml:"password"`
+		Options  map[string]string `toml:"options"`
+	} `toml:"db"`
+}
+
+func loadSecrets(t *testing.T, data string) (*secretConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &secretConfig{}
+	return cfg, Load(path, cfg)
+}
+
+// useResolver registers a resolver for the test and clears the cache around it
+// useResolver 为测试注册解析器，并在前后清空缓存
+func useResolver(t *testing.T, scheme string, r SecretResolver) {
+	t.Helper()
+	RegisterSecretResolver(scheme, r)
+	SetSecretCacheTTL(5 * time.Minute)
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		delete(secretResolver, scheme)
+		secretsMu.Unlock()
+		SetSecretCacheTTL(5 * time.Minute)
+	})
+}
+
+func TestLoadSecrets(t *testing.T) {
+	var calls atomic.Int32
+	useResolver(t, "TEST", SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+		calls.Add(1)
+		if ref == "missing" {
+			return "", errors.New("not found")
+		}
+		return "v:" + ref, nil
+	}))
+
+	data := `
+[db]
+user = "app"
+password = "TEST(db/password)"
+options = { sslkey = "TEST(db/key)", mode = "OTHER(x)" }
+`
+	cfg, err := loadSecrets(t, data)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.DB.Password != "v:db/password" || cfg.DB.Options["sslkey"] != "v:db/key" {
+		t.Errorf("Expected resolved secrets, got %+v", cfg.DB)
+	}
+	// Unregistered schemes and plain values are untouched | 未注册的方案和普通值保持不变
+	if cfg.DB.User != "app" || cfg.DB.Options["mode"] != "OTHER(x)" {
+		t.Errorf("Expected plain values to be kept, got %+v", cfg.DB)
+	}
+
+	// A second load is served from the cache | 第二次加载从缓存读取
+	if _, err := loadSecrets(t, data); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected 2 resolutions with caching, got %d", n)
+	}
+
+	if _, err := loadSecrets(t, "[db]\npassword = \"TEST(missing)\"\n"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected resolution error, got %v", err)
+	}
+}
+
+func TestVaultResolver(t *testing.T) {
+	var renewed atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			w.Write([]byte(`{"data":{"data":{"password":"kv2"},"metadata":{"version":3}}}`))
+		case "/v1/database/creds/app":
+			w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":true,"data":{"password":"dyn"}}`))
+		case "/v1/sys/leases/renew":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.Method == http.MethodPut && body["lease_id"] == "database/creds/app/1" {
+				renewed.Add(1)
+			}
+			w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := &VaultResolver{Addr: srv.URL, Token: "root", RenewLeases: true}
+	defer v.Stop()
+	ctx := context.Background()
+
+	if s, err := v.Resolve(ctx, "secret/data/app#password"); err != nil || s != "kv2" {
+		t.Errorf("Expected kv2, got %q %v", s, err)
+	}
+	// A single field needs no #key | 单个字段无需 #key
+	if s, err := v.Resolve(ctx, "database/creds/app"); err != nil || s != "dyn" {
+		t.Errorf("Expected dyn, got %q %v", s, err)
+	}
+	if _, err := v.Resolve(ctx, "secret/data/app#user"); err == nil {
+		t.Error("Expected error for a missing field")
+	}
+	if _, err := v.Resolve(ctx, "secret/data/none#x"); err == nil {
+		t.Error("Expected error for a missing path")
+	}
+	if _, err := (&VaultResolver{Addr: srv.URL, Token: "bad"}).Resolve(ctx, "secret/data/app#password"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for renewed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if renewed.Load() == 0 {
+		t.Error("Expected the dynamic secret lease to be renewed")
+	}
+}
+
+func TestAWSSecretsResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(auth, "/eu-west-1/secretsmanager/aws4_request") ||
+			!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.SecretId {
+		case "plain":
+			w.Write([]byte(`{"SecretString":"hunter2"}`))
+		case "json":
+			w.Write([]byte(`{"SecretString":"{\"password\":\"p\",\"port\":5432}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	a := &AWSSecretsResolver{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "tok", Endpoint: srv.URL}
+	ctx := context.Background()
+	for ref, want := range map[string]string{"plain": "hunter2", "json#password": "p", "json#port": "5432"} {
+		if s, err := a.Resolve(ctx, ref); err != nil || s != want {
+			t.Errorf("%s: expected %q, got %q %v", ref, want, s, err)
+		}
+	}
+	if _, err := a.Resolve(ctx, "none"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if _, err := a.Resolve(ctx, "plain#password"); err == nil {
+		t.Error("Expected error for #key on a non-JSON secret")
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// WriteTable replaces the key/value pairs of a string table in a TOML file, keeping comments and other sections
+// The table is appended when missing. A running Watcher picks the change up like any other edit.
+// WriteTable 替换 TOML 文件中字符串表的键值对，保留注释和其他段落
+// 表不存在时追加到文件末尾。运行中的 Watcher 会像其他编辑一样感知该变更。
+func WriteTable(path, table string, values map[string]string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(replaceTable(string(data), table, values)), info.Mode().Perm())
+}
+
+// replaceTable rewrites the table body in TOML text
+// replaceTable 改写 TOML 文本中的表内容
+func replaceTable(text, table string, values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		key := k
+		if !bareKey.MatchString(k) {
+			key = strconv.Quote(k)
+		}
+		pairs = append(pairs, key+" = "+strconv.Quote(values[k]))
+	}
+
+	lines := strings.Split(text, "\n")
+	start := -1
+	for i, line := range lines {
+		if tableHeader(line) == table {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		text = strings.TrimRight(text, "\n")
+		return text + "\n\n[" + table + "]\n" + strings.Join(pairs, "\n") + "\n"
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if tableHeader(lines[i]) != "" {
+			end = i
+			break
+		}
+	}
+
+	// Keep comments and blank lines, drop the old pairs | 保留注释和空行，删除旧的键值对
+	out := make([]string, 0, len(lines)+len(pairs))
+	out = append(out, lines[:start+1]...)
+	out = append(out, pairs...)
+	for _, line := range lines[start+1 : end] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			out = append(out, line)
+		}
+	}
+	out = append(out, lines[end:]...)
+	return strings.Join(out, "\n")
+}
+
+// tableHeader returns the name of a [table] or [[array]] header line, or "" for other lines
+// tableHeader 返回 [table] 或 [[array]] 表头行的名称，其他行返回 ""
+func tableHeader(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(line, "[]"))
+}
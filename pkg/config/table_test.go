@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`[logger]
+level = "info"
+
+[logger.levels]
+# Per-logger overrides
+sql = "warn"
+
+[redis.default]
+addr = "localhost:6379"
+`), 0600)
+
+	values := map[string]string{"sql": "debug", "system:server": "error"}
+	if err := WriteTable(path, "logger.levels", values); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+
+	var got struct {
+		Logger struct {
+			Level  string            `toml:"level"`
+			Levels map[string]string `toml:"levels"`
+		} `toml:"logger"`
+		Redis map[string]struct {
+			Addr string `toml:"addr"`
+		} `toml:"redis"`
+	}
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("rewritten file does not parse: %v", err)
+	}
+	if got.Logger.Level != "info" || got.Redis["default"].Addr != "localhost:6379" {
+		t.Errorf("other sections changed: %+v", got)
+	}
+	if len(got.Logger.Levels) != 2 || got.Logger.Levels["sql"] != "debug" || got.Logger.Levels["system:server"] != "error" {
+		t.Errorf("levels = %v", got.Logger.Levels)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestWriteTable_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[logger]\nlevel = \"info\"\n"), 0644)
+
+	if err := WriteTable(path, "logger.levels", map[string]string{"sql": "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "[logger]\nlevel = \"info\"\n\n[logger.levels]\nsql = \"debug\"\n"
+	if string(data) != want {
+		t.Errorf("file =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FieldError is a configuration value rejected by a validate tag
+// FieldError 是被 validate 标签拒绝的配置值
+type FieldError struct {
+	Path string // Dotted TOML key, e.g. database.default.host | 点分 TOML 键，如 database.default.host
+	Rule string // Failed rule, e.g. required or min=1 | 未通过的规则，如 required 或 min=1
+}
+
+// Error implements the error interface
+// Error 实现 error 接口
+func (e *FieldError) Error() string {
+	return "config: " + e.Path + ": " + e.Rule
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyTags sets `default:"..."` values of keys missing from the file, then checks `validate:"..."` rules
+// Supported rules, comma separated: required (non-zero), min=N and max=N (number, or length of a
+// string, slice or map) and oneof=a b c, which accepts an empty value so optional fields can use it.
+// Every failure is returned, joined, as a *FieldError.
+// applyTags 为文件中缺失的键设置 `default:"..."` 值，然后检查 `validate:"..."` 规则
+// 支持的规则以逗号分隔：required（非零值）、min=N 和 max=N（数值，或字符串、切片、map 的长度）
+// 以及 oneof=a b c（接受空值，便于可选字段使用）。所有失败都以 *FieldError 的形式合并返回。
+//
+// Example | 示例:
+//
+//	type Pool struct {
+//	    Size    int           `toml:"size" default:"10" validate:"min=1"`
+//	    Timeout time.Duration `toml:"timeout" default:"5s"`
+//	    DSN     string        `toml:"dsn" validate:"required"`
+//	}
+func applyTags(md toml.MetaData, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var errs []error
+	walkTags(md, v.Elem(), nil, &errs)
+	return errors.Join(errs...)
+}
+
+// walkTags processes the tags of a struct value and its nested structs, maps and slices
+// walkTags 处理结构体值及其嵌套结构体、map 和切片的标签
+func walkTags(md toml.MetaData, v reflect.Value, path []string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkTags(md, v.Elem(), path, errs)
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+			if name == "-" {
+				continue
+			}
+			field := v.Field(i)
+			// Untagged embedded structs share the parent's keys | 无标签的嵌入结构体与父级共享键
+			if f.Anonymous && name == "" {
+				walkTags(md, field, path, errs)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			key := append(append([]string(nil), path...), name)
+
+			if def, ok := f.Tag.Lookup("default"); ok && !md.IsDefined(key...) && field.IsZero() {
+				if err := setDefault(field, def); err != nil {
+					*errs = append(*errs, &FieldError{Path: strings.Join(key, "."), Rule: "invalid default " + strconv.Quote(def) + ": " + err.Error()})
+				}
+			}
+			walkTags(md, field, key, errs)
+			if rules := f.Tag.Get("validate"); rules != "" {
+				for _, rule := range strings.Split(rules, ",") {
+					if !checkRule(field, strings.TrimSpace(rule)) {
+						*errs = append(*errs, &FieldError{Path: strings.Join(key, "."), Rule: strings.TrimSpace(rule)})
+					}
+				}
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		// Map elements are not addressable, process a copy and store it back | map 元素不可寻址，处理副本后写回
+		for _, k := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			walkTags(md, elem, append(append([]string(nil), path...), k.String()), errs)
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		// Array tables have no per-element keys in the metadata | 表数组的元素在元数据中没有独立的键
+		for i := 0; i < v.Len(); i++ {
+			p := append([]string(nil), path...)
+			p[len(p)-1] += "[" + strconv.Itoa(i) + "]"
+			walkTags(toml.MetaData{}, v.Index(i), p, errs)
+		}
+	}
+}
+
+// setDefault parses def into a settable field
+// setDefault 将 def 解析到可设置的字段
+func setDefault(v reflect.Value, def string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(def, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		parts := strings.Split(def, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		v.Set(reflect.ValueOf(parts).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// checkRule reports whether v satisfies one validate rule, unknown rules fail
+// checkRule 判断 v 是否满足一条 validate 规则，未知规则视为失败
+func checkRule(v reflect.Value, rule string) bool {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		return !v.IsZero()
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := measure(v)
+		if !ok {
+			return false
+		}
+		if name == "min" {
+			return n >= limit
+		}
+		return n <= limit
+	case "oneof":
+		if v.IsZero() {
+			return true
+		}
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if s == allowed {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// measure returns the number compared by min and max: the value of numbers, the length of others
+// measure 返回 min 和 max 比较的数值：数字取其值，其他类型取长度
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tagPool struct {
+	Size    int           `toml:"size" default:"10" validate:"min=1"`
+	Timeout time.Duration `toml:"timeout" default:"5s"`
+	DSN     string        `toml:"dsn" validate:"required"`
+}
+
+type tagConfig struct {
+	App struct {
+		Env     string   `toml:"env" default:"dev" validate:"oneof=dev prod"`
+		Debug   bool     `toml:"debug" default:"true"`
+		Origins []string `toml:"origins" default:"a, b"`
+	} `toml:"app"`
+	Pools map[string]tagPool `toml:"pools"`
+	Jobs  []tagPool          `toml:"jobs"`
+}
+
+func loadTags(t *testing.T, data string) (*tagConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &tagConfig{}
+	return cfg, Load(path, cfg)
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := loadTags(t, `
+[app]
+debug = false
+
+[pools.main]
+dsn = "postgres://main"
+
+[pools.replica]
+dsn = "postgres://replica"
+size = 3
+
+[[jobs]]
+dsn = "redis://"
+`)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.App.Env != "dev" || len(cfg.App.Origins) != 2 || cfg.App.Origins[1] != "b" {
+		t.Errorf("Unexpected app defaults: %+v", cfg.App)
+	}
+	// An explicit false is kept | 显式的 false 会被保留
+	if cfg.App.Debug {
+		t.Error("Expected explicit debug = false to be kept")
+	}
+	if p := cfg.Pools["main"]; p.Size != 10 || p.Timeout != 5*time.Second {
+		t.Errorf("Unexpected main pool: %+v", p)
+	}
+	if p := cfg.Pools["replica"]; p.Size != 3 {
+		t.Errorf("Expected replica size 3, got %d", p.Size)
+	}
+	if cfg.Jobs[0].Size != 10 {
+		t.Errorf("Expected array table default, got %d", cfg.Jobs[0].Size)
+	}
+}
+
+func TestLoadValidate(t *testing.T) {
+	_, err := loadTags(t, `
+[app]
+env = "staging"
+
+[pools.main]
+size = 0
+
+[[jobs]]
+dsn = "redis://"
+size = -1
+`)
+	want := map[string]string{
+		"app.env":         "oneof=dev prod",
+		"pools.main.dsn":  "required",
+		"pools.main.size": "min=1",
+		"jobs[0].size":    "min=1",
+	}
+	got := map[string]string{}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			got[fe.Path] = fe.Rule
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("errors = %v, want %v", got, want)
+	}
+	for path, rule := range want {
+		if got[path] != rule {
+			t.Errorf("%s: rule = %q, want %q", path, got[path], rule)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultResolver reads secrets through the Vault HTTP API, for VAULT(path#key) values
+// The path is the API path without /v1, e.g. secret/data/app#db_password for KV v2 or
+// database/creds/app#password for a dynamic secret; #key may be omitted when the secret has one field.
+// Empty fields fall back to VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+// VaultResolver 通过 Vault HTTP API 读取密钥，用于 VAULT(path#key) 值
+// path 为不含 /v1 的 API 路径，如 KV v2 的 secret/data/app#db_password 或动态密钥的
+// database/creds/app#password；密钥只有一个字段时可以省略 #key。
+// 字段为空时使用 VAULT_ADDR、VAULT_TOKEN 和 VAULT_NAMESPACE。
+type VaultResolver struct {
+	Addr        string       // Vault address, e.g. https://vault:8200 | Vault 地址
+	Token       string       // Client token | 客户端令牌
+	Namespace   string       // Enterprise namespace | 企业版命名空间
+	Client      *http.Client // Default 10s timeout | 默认 10s 超时
+	RenewLeases bool         // Renew leases of dynamic secrets in the background | 在后台续期动态密钥的租约
+
+	mu     sync.Mutex
+	leases map[string]context.CancelFunc // Renewal loops by lease ID | 按租约 ID 的续期循环
+}
+
+// NewVaultResolver creates a resolver configured from the environment
+// NewVaultResolver 创建从环境变量读取配置的解析器
+func NewVaultResolver() *VaultResolver {
+	return &VaultResolver{}
+}
+
+// vaultResponse is the envelope of Vault secret and lease responses
+// vaultResponse 是 Vault 密钥和租约响应的外层结构
+type vaultResponse struct {
+	LeaseID       string         `json:"lease_id"`
+	LeaseDuration int            `json:"lease_duration"`
+	Renewable     bool           `json:"renewable"`
+	Data          map[string]any `json:"data"`
+	Errors        []string       `json:"errors"`
+}
+
+// Resolve reads path and returns the field key of the secret
+// Resolve 读取 path 并返回密钥的 key 字段
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, _ := strings.Cut(ref, "#")
+	var resp vaultResponse
+	if err := v.do(ctx, http.MethodGet, strings.TrimPrefix(path, "/"), nil, &resp); err != nil {
+		return "", err
+	}
+
+	data := resp.Data
+	// KV v2 nests the fields under data.data | KV v2 将字段嵌套在 data.data 下
+	if inner, ok := data["data"].(map[string]any); ok && data["metadata"] != nil {
+		data = inner
+	}
+	if key == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("vault: %s has %d fields, add #key", path, len(data))
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no field %s", path, key)
+	}
+
+	if v.RenewLeases && resp.Renewable && resp.LeaseID != "" && resp.LeaseDuration > 0 {
+		v.renew(resp.LeaseID, time.Duration(resp.LeaseDuration)*time.Second)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// Stop ends the lease renewals, leases then expire at the end of their duration
+// Stop 结束租约续期，租约在到期后失效
+func (v *VaultResolver) Stop() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for id, cancel := range v.leases {
+		cancel()
+		delete(v.leases, id)
+	}
+}
+
+// renew keeps a lease alive, renewing it after two thirds of its duration
+// renew 保持租约有效，在租期过去三分之二时续期
+func (v *VaultResolver) renew(leaseID string, ttl time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.leases[leaseID]; ok {
+		return
+	}
+	if v.leases == nil {
+		v.leases = make(map[string]context.CancelFunc)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	v.leases[leaseID] = cancel
+
+	go func() {
+		defer func() {
+			v.mu.Lock()
+			delete(v.leases, leaseID)
+			v.mu.Unlock()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(ttl * 2 / 3):
+			}
+			body := map[string]any{"lease_id": leaseID, "increment": int(ttl.Seconds())}
+			var resp vaultResponse
+			if err := v.do(ctx, http.MethodPut, "sys/leases/renew", body, &resp); err != nil {
+				log.Printf("config: vault lease %s renewal failed: %v", leaseID, err)
+				return
+			}
+			if resp.LeaseDuration <= 0 {
+				return
+			}
+			ttl = time.Duration(resp.LeaseDuration) * time.Second
+		}
+	}()
+}
+
+// do sends one API request and decodes the response into out
+// do 发送一次 API 请求并将响应解码到 out
+func (v *VaultResolver) do(ctx context.Context, method, path string, body any, out *vaultResponse) error {
+	addr, token := v.Addr, v.Token
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if addr == "" || token == "" {
+		return fmt.Errorf("vault: address and token are required (VAULT_ADDR, VAULT_TOKEN)")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(addr, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := v.Namespace; ns != "" || os.Getenv("VAULT_NAMESPACE") != "" {
+		if ns == "" {
+			ns = os.Getenv("VAULT_NAMESPACE")
+		}
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: secretTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("vault: invalid response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault: %s %s: %d %s", method, path, resp.StatusCode, strings.Join(out.Errors, "; "))
+	}
+	return nil
+}
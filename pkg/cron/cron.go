@@ -3,12 +3,18 @@ package cron
 import (
 	"context"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/nuohe369/crab/pkg/clock"
 	"github.com/robfig/cron/v3"
 )
 
+// parser accepts specs with a leading seconds field and descriptors such as @every 1m
+// parser 接受带秒字段的表达式以及 @every 1m 等描述符
+var parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
 // RedisClient defines the Redis client interface
 // RedisClient 定义 Redis 客户端接口
 type RedisClient interface {
@@ -28,10 +34,45 @@ type Job struct {
 // Scheduler manages cron jobs
 // Scheduler 管理定时任务
 type Scheduler struct {
-	cron  *cron.Cron
-	redis RedisClient
-	jobs  map[string]cron.EntryID
-	mu    sync.RWMutex
+	redis   RedisClient
+	clock   clock.Clock
+	entries map[string]*entry
+	mu      sync.RWMutex
+
+	wake    chan struct{} // Wakes the loop when the entries change | 任务变化时唤醒循环
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+	jobs    sync.WaitGroup // Running jobs | 运行中的任务
+}
+
+// entry is a registered job with its schedule | entry 为已注册的任务及其调度
+type entry struct {
+	job      Job
+	schedule cron.Schedule
+	next     time.Time
+	prev     time.Time
+}
+
+// Option configures a Scheduler
+// Option 配置 Scheduler
+type Option func(*Scheduler)
+
+// WithClock sets the clock the scheduler fires on, for tests
+// WithClock 设置调度器触发所依据的时钟，用于测试
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// JobInfo describes a registered job
+// JobInfo 描述一个已注册的任务
+type JobInfo struct {
+	Name string    `json:"name"`
+	Spec string    `json:"spec"`
+	Next time.Time `json:"next"` // Zero before Start | Start 之前为零值
+	Prev time.Time `json:"prev"` // Zero until the first run | 首次运行前为零值
 }
 
 var defaultScheduler *Scheduler
@@ -50,40 +91,48 @@ func Get() *Scheduler {
 
 // New creates a new scheduler
 // New 创建新的调度器
-func New(redis RedisClient) *Scheduler {
-	return &Scheduler{
-		cron: cron.New(cron.WithSeconds(), cron.WithChain(
-			cron.Recover(cron.DefaultLogger),
-		)),
-		redis: redis,
-		jobs:  make(map[string]cron.EntryID),
+func New(redis RedisClient, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		redis:   redis,
+		clock:   clock.Real(),
+		entries: make(map[string]*entry),
+		wake:    make(chan struct{}, 1),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Register registers a job with the scheduler
-// Register 向调度器注册任务
+// Register registers a job with the scheduler, replacing the job with the same name
+// Register 向调度器注册任务，替换同名任务
 func (s *Scheduler) Register(job Job) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Remove existing job with the same name | 移除同名的现有任务
-	if id, ok := s.jobs[job.Name]; ok {
-		s.cron.Remove(id)
-	}
-
-	// Wrap job function | 包装任务函数
-	fn := s.wrapJob(job)
-
-	id, err := s.cron.AddFunc(job.Spec, fn)
+	schedule, err := parser.Parse(job.Spec)
 	if err != nil {
 		return err
 	}
 
-	s.jobs[job.Name] = id
+	s.mu.Lock()
+	e := &entry{job: job, schedule: schedule}
+	if s.running {
+		e.next = schedule.Next(s.clock.Now())
+	}
+	s.entries[job.Name] = e
+	s.mu.Unlock()
+
+	s.notify()
 	log.Printf("cron: registered job [%s] spec=%s", job.Name, job.Spec)
 	return nil
 }
 
+// notify wakes the loop to recompute the next run | notify 唤醒循环以重新计算下次运行时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
 // wrapJob wraps a job to handle distributed locking
 // wrapJob 包装任务以处理分布式锁
 func (s *Scheduler) wrapJob(job Job) func() {
@@ -110,7 +159,7 @@ func (s *Scheduler) wrapJob(job Job) func() {
 
 // executeJob executes a job.
 func (s *Scheduler) executeJob(job Job) {
-	start := time.Now()
+	start := s.clock.Now()
 	log.Printf("cron: job [%s] started", job.Name)
 
 	defer func() {
@@ -120,34 +169,130 @@ func (s *Scheduler) executeJob(job Job) {
 	}()
 
 	job.Func()
-	log.Printf("cron: job [%s] completed in %v", job.Name, time.Since(start))
+	log.Printf("cron: job [%s] completed in %v", job.Name, s.clock.Since(start))
 }
 
 // Remove removes a job from the scheduler
 // Remove 从调度器中移除任务
 func (s *Scheduler) Remove(name string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	_, ok := s.entries[name]
+	delete(s.entries, name)
+	s.mu.Unlock()
 
-	if id, ok := s.jobs[name]; ok {
-		s.cron.Remove(id)
-		delete(s.jobs, name)
+	if ok {
+		s.notify()
 		log.Printf("cron: removed job [%s]", name)
 	}
 }
 
-// Start starts the scheduler
-// Start 启动调度器
+// Jobs returns the registered jobs sorted by name
+// Jobs 返回按名称排序的已注册任务
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]JobInfo, 0, len(s.entries))
+	for name, e := range s.entries {
+		jobs = append(jobs, JobInfo{Name: name, Spec: e.job.Spec, Next: e.next, Prev: e.prev})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// Start starts the scheduler, a running scheduler is left as is
+// Start 启动调度器，已运行的调度器保持不变
 func (s *Scheduler) Start() {
-	s.cron.Start()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	now := s.clock.Now()
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+	}
+	s.running = true
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.run(s.stop, s.done)
 	log.Println("cron: scheduler started (distributed mode)")
 }
 
-// Stop stops the scheduler
-// Stop 停止调度器
+// run fires the due jobs and sleeps on the clock until the next one
+// run 触发到期的任务，并在时钟上休眠到下一个任务
+func (s *Scheduler) run(stop, done chan struct{}) {
+	defer close(done)
+	for {
+		s.mu.Lock()
+		now := s.clock.Now()
+		var next time.Time
+		for _, e := range s.entries {
+			if e.next.IsZero() {
+				continue // Spec never matches | 表达式永远不会匹配
+			}
+			if !e.next.After(now) {
+				e.prev = e.next
+				e.next = e.schedule.Next(now)
+				s.fire(e.job)
+			}
+			if !e.next.IsZero() && (next.IsZero() || e.next.Before(next)) {
+				next = e.next
+			}
+		}
+		s.mu.Unlock()
+
+		// Without jobs only a change or Stop wakes the loop | 没有任务时仅由变化或 Stop 唤醒循环
+		var timer *clock.Timer
+		var fired <-chan time.Time
+		if !next.IsZero() {
+			timer = s.clock.NewTimer(next.Sub(now))
+			fired = timer.C
+		}
+		select {
+		case <-fired:
+		case <-s.wake:
+		case <-stop:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// fire runs a job in its own goroutine | fire 在独立的协程中运行任务
+func (s *Scheduler) fire(job Job) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("cron: job [%s] panic: %v", job.Name, r)
+			}
+		}()
+		s.wrapJob(job)()
+	}()
+}
+
+// Stop stops the scheduler and waits for running jobs
+// Stop 停止调度器并等待运行中的任务
 func (s *Scheduler) Stop() {
-	ctx := s.cron.Stop()
-	<-ctx.Done()
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+	s.jobs.Wait()
 	log.Println("cron: scheduler stopped")
 }
 
@@ -165,6 +310,15 @@ func Remove(name string) {
 	defaultScheduler.Remove(name)
 }
 
+// Jobs returns the jobs of the default scheduler, nil before Init
+// Jobs 返回默认调度器的任务，Init 之前返回 nil
+func Jobs() []JobInfo {
+	if defaultScheduler == nil {
+		return nil
+	}
+	return defaultScheduler.Jobs()
+}
+
 // Start starts the default scheduler
 // Start 启动默认调度器
 func Start() {
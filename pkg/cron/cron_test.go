@@ -0,0 +1,141 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
+)
+
+// memRedis is an in-memory RedisClient | memRedis 为内存中的 RedisClient
+type memRedis struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newMemRedis() *memRedis {
+	return &memRedis{keys: make(map[string]bool)}
+}
+
+func (r *memRedis) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys[key] {
+		return false, nil
+	}
+	r.keys[key] = true
+	return true, nil
+}
+
+func (r *memRedis) Del(ctx context.Context, keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		delete(r.keys, k)
+	}
+	return nil
+}
+
+var start = time.Date(2025, 1, 1, 0, 0, 30, 0, time.UTC)
+
+// newTestScheduler returns a started scheduler on a fake clock with one job reporting its runs
+// newTestScheduler 返回在模拟时钟上启动的调度器，其中一个任务会报告每次运行
+func newTestScheduler(t *testing.T, redis RedisClient) (*Scheduler, *clock.Fake, chan time.Time) {
+	t.Helper()
+	fake := clock.NewFake(start)
+	s := New(redis, WithClock(fake))
+	runs := make(chan time.Time, 10)
+	err := s.Register(Job{Name: "report", Spec: "0 * * * * *", Func: func() { runs <- fake.Now() }})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+	fake.BlockUntil(1)
+	return s, fake, runs
+}
+
+// waitIdle waits until the loop has no timer armed | waitIdle 等待循环不再设置定时器
+func waitIdle(t *testing.T, fake *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for fake.Waiters() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the scheduler to disarm its timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsOnClock(t *testing.T) {
+	s, fake, runs := newTestScheduler(t, newMemRedis())
+
+	if jobs := s.Jobs(); len(jobs) != 1 || !jobs[0].Next.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("Jobs = %+v, want the next run at the full minute", jobs)
+	}
+
+	fake.Advance(29 * time.Second)
+	select {
+	case <-runs:
+		t.Fatal("Expected no run before the scheduled time")
+	default:
+	}
+
+	for i := 1; i <= 2; i++ {
+		fake.Advance(time.Second)
+		at := <-runs
+		if want := start.Add(30*time.Second + time.Duration(i-1)*time.Minute); !at.Equal(want) {
+			t.Errorf("run %d at %v, want %v", i, at, want)
+		}
+		fake.BlockUntil(1)
+		fake.Advance(59 * time.Second)
+	}
+
+	jobs := s.Jobs()
+	if !jobs[0].Prev.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Prev = %v, want %v", jobs[0].Prev, start.Add(90*time.Second))
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	s, fake, runs := newTestScheduler(t, newMemRedis())
+
+	s.Remove("report")
+	waitIdle(t, fake)
+	fake.Advance(time.Hour)
+	select {
+	case <-runs:
+		t.Error("Expected a removed job not to run")
+	default:
+	}
+	if len(s.Jobs()) != 0 {
+		t.Errorf("Jobs = %+v, want none", s.Jobs())
+	}
+}
+
+func TestSchedulerSkipsWhileLocked(t *testing.T) {
+	redis := newMemRedis()
+	redis.keys["cron:lock:report"] = true // Another instance runs it | 另一实例正在运行
+	s, fake, runs := newTestScheduler(t, redis)
+
+	fake.Advance(30 * time.Second)
+	fake.BlockUntil(1)
+	s.Stop() // Waits for the fired job | 等待已触发的任务
+	select {
+	case <-runs:
+		t.Error("Expected the job to be skipped while another instance holds the lock")
+	default:
+	}
+}
+
+func TestRegisterInvalidSpec(t *testing.T) {
+	s := New(newMemRedis())
+	if err := s.Register(Job{Name: "bad", Spec: "not a spec", Func: func() {}}); err == nil {
+		t.Error("Expected an error for an invalid spec")
+	}
+	if len(s.Jobs()) != 0 {
+		t.Error("Expected the invalid job not to be registered")
+	}
+}
@@ -1,5 +1,5 @@
-// Package crypto provides encryption and decryption utilities using AES-GCM
-// Package crypto 提供使用 AES-GCM 的加密和解密工具
+// Package crypto provides AES-GCM encryption of configuration values and password hashing utilities
+// Package crypto 提供配置值的 AES-GCM 加密和密码哈希工具
 package crypto
 
 import (
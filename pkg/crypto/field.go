@@ -0,0 +1,181 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// fieldPrefix marks an encrypted column value: aesgcm:<key id>:<base64 nonce+ciphertext>
+// fieldPrefix 标记加密的列值：aesgcm:<密钥 ID>:<base64 nonce+密文>
+const fieldPrefix = "aesgcm:"
+
+var (
+	// ErrNoFieldKey indicates EncryptedString was written before InitFieldKeys
+	// ErrNoFieldKey 表示在 InitFieldKeys 之前写入了 EncryptedString
+	ErrNoFieldKey = errors.New("crypto: no field encryption key configured")
+	// ErrUnknownFieldKey indicates a column value encrypted with a key ID that is not configured
+	// ErrUnknownFieldKey 表示列值使用了未配置的密钥 ID 加密
+	ErrUnknownFieldKey = errors.New("crypto: unknown field encryption key")
+)
+
+// FieldConfig configures the keys of EncryptedString columns
+// Keep retired keys listed until ReencryptColumns has rewritten their rows.
+// FieldConfig 配置 EncryptedString 列的密钥
+// 已停用的密钥需保留，直到 ReencryptColumns 重写了使用它们的行。
+type FieldConfig struct {
+	Current string            `toml:"current"` // Key ID used to encrypt | 用于加密的密钥 ID
+	Keys    map[string]string `toml:"keys"`    // Secrets by key ID, usually ENC() or VAULT() values | 按密钥 ID 的密钥，通常为 ENC() 或 VAULT() 值
+}
+
+var (
+	fieldMu      sync.RWMutex
+	fieldAEADs   map[string]cipher.AEAD
+	fieldCurrent string
+)
+
+// InitFieldKeys installs the keys of EncryptedString columns
+// InitFieldKeys 安装 EncryptedString 列的密钥
+func InitFieldKeys(cfg FieldConfig) error {
+	if _, ok := cfg.Keys[cfg.Current]; !ok {
+		return fmt.Errorf("crypto: current field key %q is not in keys", cfg.Current)
+	}
+	aeads := make(map[string]cipher.AEAD, len(cfg.Keys))
+	for id, secret := range cfg.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return fmt.Errorf("crypto: invalid field key id %q", id)
+		}
+		if secret == "" {
+			return fmt.Errorf("crypto: field key %q is empty", id)
+		}
+		block, err := aes.NewCipher(deriveKey(secret))
+		if err != nil {
+			return err
+		}
+		if aeads[id], err = cipher.NewGCM(block); err != nil {
+			return err
+		}
+	}
+	fieldMu.Lock()
+	defer fieldMu.Unlock()
+	fieldAEADs, fieldCurrent = aeads, cfg.Current
+	return nil
+}
+
+// EncryptedString is a string column stored encrypted with AES-GCM under the current field key
+// The stored value carries the key ID, so rows written before a key rotation keep decrypting.
+// Values without the aesgcm: prefix are read as plaintext, letting ReencryptColumns encrypt existing columns.
+// The empty string is stored as is. Encrypted columns cannot be searched or indexed by value.
+// EncryptedString 是使用当前字段密钥以 AES-GCM 加密存储的字符串列
+// 存储值带有密钥 ID，密钥轮换前写入的行仍可解密。
+// 没有 aesgcm: 前缀的值按明文读取，便于 ReencryptColumns 加密已有的列。
+// 空字符串原样存储。加密列不能按值搜索或建立索引。
+//
+// Example | 示例:
+//
+//	type Customer struct {
+//	    ID    int64                  `xorm:"pk 'id'"`
+//	    Phone crypto.EncryptedString `xorm:"text 'phone'"`
+//	}
+type EncryptedString string
+
+// ToDB encrypts the value (called by XORM when writing to database)
+// ToDB 加密该值（XORM 写入数据库时调用）
+func (s EncryptedString) ToDB() ([]byte, error) {
+	if s == "" {
+		return []byte{}, nil
+	}
+	v, err := encryptField(string(s))
+	return []byte(v), err
+}
+
+// FromDB decrypts the stored value (called by XORM when reading from database)
+// FromDB 解密存储的值（XORM 从数据库读取时调用）
+func (s *EncryptedString) FromDB(data []byte) error {
+	v, err := decryptField(string(data))
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(v)
+	return nil
+}
+
+// String returns the plaintext
+// String 返回明文
+func (s EncryptedString) String() string {
+	return string(s)
+}
+
+// ReencryptField rewrites a stored column value under the current key
+// changed is false when the value is empty or already uses the current key.
+// ReencryptField 使用当前密钥重写存储的列值
+// 值为空或已使用当前密钥时 changed 为 false。
+func ReencryptField(stored string) (value string, changed bool, err error) {
+	if stored == "" {
+		return stored, false, nil
+	}
+	fieldMu.RLock()
+	current := fieldCurrent
+	fieldMu.RUnlock()
+	if strings.HasPrefix(stored, fieldPrefix+current+":") {
+		return stored, false, nil
+	}
+	plain, err := decryptField(stored)
+	if err != nil {
+		return "", false, err
+	}
+	if value, err = encryptField(plain); err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// encryptField encrypts plaintext under the current key
+// encryptField 使用当前密钥加密明文
+func encryptField(plaintext string) (string, error) {
+	fieldMu.RLock()
+	aead, id := fieldAEADs[fieldCurrent], fieldCurrent
+	fieldMu.RUnlock()
+	if aead == nil {
+		return "", ErrNoFieldKey
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(id))
+	return fieldPrefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptField decrypts a stored value with the key named by its prefix, plaintext passes through
+// decryptField 使用前缀指定的密钥解密存储值，明文原样返回
+func decryptField(stored string) (string, error) {
+	rest, ok := strings.CutPrefix(stored, fieldPrefix)
+	if !ok {
+		return stored, nil
+	}
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("crypto: malformed encrypted field")
+	}
+	fieldMu.RLock()
+	aead := fieldAEADs[id]
+	fieldMu.RUnlock()
+	if aead == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownFieldKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("crypto: malformed encrypted field")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("crypto: decrypt field with key %s: %w", id, err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedString(t *testing.T) {
+	if err := InitFieldKeys(FieldConfig{Current: "k1", Keys: map[string]string{"k1": "secret-1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := EncryptedString("13800138000").ToDB()
+	if err != nil {
+		t.Fatalf("ToDB failed: %v", err)
+	}
+	if !strings.HasPrefix(string(stored), "aesgcm:k1:") || strings.Contains(string(stored), "13800138000") {
+		t.Errorf("Unexpected stored value: %s", stored)
+	}
+	var s EncryptedString
+	if err := s.FromDB(stored); err != nil || s != "13800138000" {
+		t.Errorf("Expected round trip, got %q %v", s, err)
+	}
+
+	// Empty and plaintext values | 空值和明文值
+	if b, _ := EncryptedString("").ToDB(); len(b) != 0 {
+		t.Errorf("Expected empty value to be stored as is, got %q", b)
+	}
+	if err := s.FromDB([]byte("legacy")); err != nil || s != "legacy" {
+		t.Errorf("Expected plaintext to pass through, got %q %v", s, err)
+	}
+
+	// Rotation: old rows keep decrypting and are rewritten under the new key | 轮换：旧行仍可解密并用新密钥重写
+	if err := InitFieldKeys(FieldConfig{Current: "k2", Keys: map[string]string{"k1": "secret-1", "k2": "secret-2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FromDB(stored); err != nil || s != "13800138000" {
+		t.Errorf("Expected old key to decrypt, got %q %v", s, err)
+	}
+	rotated, changed, err := ReencryptField(string(stored))
+	if err != nil || !changed || !strings.HasPrefix(rotated, "aesgcm:k2:") {
+		t.Fatalf("Expected value under k2, got %q %v %v", rotated, changed, err)
+	}
+	if _, changed, _ := ReencryptField(rotated); changed {
+		t.Error("Expected a current value to be left unchanged")
+	}
+	if v, changed, _ := ReencryptField("legacy"); !changed || !strings.HasPrefix(v, "aesgcm:k2:") {
+		t.Errorf("Expected plaintext to be encrypted, got %q", v)
+	}
+
+	// Retiring k1 makes its rows unreadable | 移除 k1 后其加密的行无法读取
+	if err := InitFieldKeys(FieldConfig{Current: "k2", Keys: map[string]string{"k2": "secret-2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FromDB(stored); !errors.Is(err, ErrUnknownFieldKey) {
+		t.Errorf("Expected ErrUnknownFieldKey, got %v", err)
+	}
+	// The key ID is authenticated | 密钥 ID 参与认证
+	tampered := strings.Replace(rotated, "aesgcm:k2:", "aesgcm:k3:", 1)
+	InitFieldKeys(FieldConfig{Current: "k2", Keys: map[string]string{"k2": "secret-2", "k3": "secret-2"}})
+	if err := s.FromDB([]byte(tampered)); err == nil {
+		t.Error("Expected a relabelled value to fail")
+	}
+
+	for _, bad := range []FieldConfig{
+		{Current: "k9", Keys: map[string]string{"k1": "x"}},
+		{Current: "a:b", Keys: map[string]string{"a:b": "x"}},
+		{Current: "k1", Keys: map[string]string{"k1": ""}},
+	} {
+		if err := InitFieldKeys(bad); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms | 密码哈希算法
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// ErrInvalidHash indicates a stored password hash in no supported format
+// ErrInvalidHash 表示存储的密码哈希不是受支持的格式
+var ErrInvalidHash = errors.New("crypto: invalid password hash")
+
+// PasswordParams configures new password hashes
+// Existing hashes keep verifying after a change; VerifyPassword reports them as needing a rehash.
+// PasswordParams 配置新密码哈希的参数
+// 修改后已有哈希仍可校验，VerifyPassword 会报告它们需要重新哈希。
+type PasswordParams struct {
+	Algorithm   string // argon2id (default) or bcrypt | argon2id（默认）或 bcrypt
+	Memory      uint32 // Argon2id memory in KiB, default 64 MiB | Argon2id 内存（KiB），默认 64 MiB
+	Iterations  uint32 // Argon2id passes, default 3 | Argon2id 迭代次数，默认 3
+	Parallelism uint8  // Argon2id lanes, default 2 | Argon2id 并行度，默认 2
+	SaltLength  uint32 // Argon2id salt bytes, default 16 | Argon2id 盐长度（字节），默认 16
+	KeyLength   uint32 // Argon2id hash bytes, default 32 | Argon2id 哈希长度（字节），默认 32
+	BcryptCost  int    // Bcrypt cost, default 10 | Bcrypt 代价，默认 10
+}
+
+// DefaultPasswordParams returns the parameters used unless SetPasswordParams is called
+// DefaultPasswordParams 返回未调用 SetPasswordParams 时使用的参数
+func DefaultPasswordParams() PasswordParams {
+	return PasswordParams{
+		Algorithm:   Argon2id,
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		BcryptCost:  bcrypt.DefaultCost,
+	}
+}
+
+var (
+	paramsMu       sync.RWMutex
+	passwordParams = DefaultPasswordParams()
+)
+
+// SetPasswordParams sets the parameters of new hashes, zero fields take the defaults
+// SetPasswordParams 设置新哈希的参数，零值字段使用默认值
+func SetPasswordParams(p PasswordParams) {
+	def := DefaultPasswordParams()
+	if p.Algorithm == "" {
+		p.Algorithm = def.Algorithm
+	}
+	if p.Memory == 0 {
+		p.Memory = def.Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = def.Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = def.Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = def.SaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = def.KeyLength
+	}
+	if p.BcryptCost == 0 {
+		p.BcryptCost = def.BcryptCost
+	}
+	paramsMu.Lock()
+	passwordParams = p
+	paramsMu.Unlock()
+}
+
+func currentParams() PasswordParams {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	return passwordParams
+}
+
+// HashPassword hashes password with the current parameters
+// Argon2id hashes use the PHC string format $argon2id$v=19$m=65536,t=3,p=2$salt$hash,
+// bcrypt hashes the standard $2a$cost$... format, so the algorithm and parameters travel with the hash.
+// HashPassword 使用当前参数哈希密码
+// Argon2id 哈希使用 PHC 字符串格式 $argon2id$v=19$m=65536,t=3,p=2$salt$hash，
+// bcrypt 哈希使用标准的 $2a$cost$... 格式，算法和参数随哈希一起保存。
+func HashPassword(password string) (string, error) {
+	p := currentParams()
+	switch p.Algorithm {
+	case Bcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		return string(hash), err
+	case Argon2id:
+		salt := make([]byte, p.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	return "", fmt.Errorf("crypto: unknown password algorithm %q", p.Algorithm)
+}
+
+// VerifyPassword checks password against a stored hash of any supported format
+// rehash reports that the hash was made with other parameters than the current ones:
+// on a successful login, store HashPassword(password) to upgrade it transparently.
+// VerifyPassword 使用任一受支持格式的存储哈希校验密码
+// rehash 表示该哈希使用的参数与当前参数不同：登录成功时保存 HashPassword(password) 即可透明升级。
+//
+// Example | 示例:
+//
+//	ok, rehash, err := crypto.VerifyPassword(input, user.Password)
+//	if ok && rehash {
+//	    user.Password, _ = crypto.HashPassword(input)
+//	    db.ID(user.ID).Cols("password").Update(user)
+//	}
+func VerifyPassword(password, hash string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		h, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false, err
+		}
+		key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+		if subtle.ConstantTimeCompare(key, h.key) != 1 {
+			return false, false, nil
+		}
+		return true, NeedsRehash(hash), nil
+	case strings.HasPrefix(hash, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, ErrInvalidHash
+		}
+		return true, NeedsRehash(hash), nil
+	}
+	return false, false, ErrInvalidHash
+}
+
+// NeedsRehash reports whether hash was made with other parameters than the current ones
+// NeedsRehash 判断哈希使用的参数是否与当前参数不同
+func NeedsRehash(hash string) bool {
+	p := currentParams()
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		h, err := parseArgon2id(hash)
+		return err != nil || p.Algorithm != Argon2id || h.memory != p.Memory || h.iterations != p.Iterations ||
+			h.parallelism != p.Parallelism || uint32(len(h.key)) != p.KeyLength || uint32(len(h.salt)) != p.SaltLength
+	case strings.HasPrefix(hash, "$2"):
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || p.Algorithm != Bcrypt || cost != p.BcryptCost
+	}
+	return true
+}
+
+// argon2Hash is a decoded argon2id PHC string
+// argon2Hash 是解码后的 argon2id PHC 字符串
+type argon2Hash struct {
+	memory, iterations uint32
+	parallelism        uint8
+	salt, key          []byte
+}
+
+func parseArgon2id(hash string) (*argon2Hash, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrInvalidHash
+	}
+	h := &argon2Hash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil ||
+		h.iterations == 0 || h.parallelism == 0 {
+		return nil, ErrInvalidHash
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return h, nil
+}
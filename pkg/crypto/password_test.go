@@ -0,0 +1,111 @@
+package crypto
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useParams sets cheap hashing parameters for the test and restores the defaults after it
+// useParams 为测试设置低开销的哈希参数，并在结束后恢复默认值
+func useParams(t *testing.T, p PasswordParams) {
+	t.Helper()
+	SetPasswordParams(p)
+	t.Cleanup(func() { SetPasswordParams(DefaultPasswordParams()) })
+}
+
+func TestHashPassword(t *testing.T) {
+	useParams(t, PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1})
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+	if other, _ := HashPassword("correct horse"); other == hash {
+		t.Error("Expected a random salt per hash")
+	}
+
+	if ok, rehash, err := VerifyPassword("correct horse", hash); !ok || rehash || err != nil {
+		t.Errorf("Expected valid current hash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+	if ok, _, err := VerifyPassword("wrong", hash); ok || err != nil {
+		t.Errorf("Expected mismatch, got ok=%v err=%v", ok, err)
+	}
+	for _, bad := range []string{"", "plain", "$argon2id$v=19$m=1,t=0,p=1$c2FsdA$a2V5", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		if _, _, err := VerifyPassword("x", bad); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%q: expected ErrInvalidHash, got %v", bad, err)
+		}
+	}
+}
+
+func TestRehash(t *testing.T) {
+	// Hashes made before a parameter change | 参数变更前生成的哈希
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	useParams(t, PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1})
+	weak, _ := HashPassword("secret")
+
+	useParams(t, PasswordParams{Memory: 2048, Iterations: 1, Parallelism: 1})
+	for name, hash := range map[string]string{"bcrypt": string(legacy), "argon2id": weak} {
+		ok, rehash, err := VerifyPassword("secret", hash)
+		if !ok || !rehash || err != nil {
+			t.Errorf("%s: expected valid outdated hash, got ok=%v rehash=%v err=%v", name, ok, rehash, err)
+		}
+	}
+
+	// Switching to bcrypt marks argon2id hashes as outdated | 切换到 bcrypt 后 argon2id 哈希视为过期
+	useParams(t, PasswordParams{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost})
+	hash, err := HashPassword("secret")
+	if err != nil || !strings.HasPrefix(hash, "$2a$04$") {
+		t.Fatalf("Expected bcrypt hash, got %s %v", hash, err)
+	}
+	if NeedsRehash(hash) || !NeedsRehash(weak) {
+		t.Error("Expected only the argon2id hash to need a rehash")
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	p := PasswordPolicy{
+		MinLength:    10,
+		MaxLength:    20,
+		RequireUpper: true,
+		RequireDigit: true,
+		MinClasses:   3,
+		Blocklist:    []string{"Password123"},
+	}
+
+	tests := []struct {
+		password string
+		related  []string
+		want     []string
+	}{
+		{"Tr0ub4dor&3", nil, nil},
+		{"short", nil, []string{"min_length=10", "upper", "digit", "min_classes=3"}},
+		{strings.Repeat("Aa1", 10), nil, []string{"max_length=20"}},
+		{"password123", nil, []string{"upper", "min_classes=3", "common"}},
+		{"Alice-2024-pw", []string{"alice"}, []string{"contains_personal_info"}},
+		{"Ab1-xyzwvut", []string{"ab"}, nil}, // Related values under 3 characters are ignored | 少于 3 个字符的相关值被忽略
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.password, tt.related...)
+		var got []string
+		var pe *PolicyError
+		if errors.As(err, &pe) {
+			got = pe.Rules
+		} else if err != nil {
+			t.Fatalf("%q: unexpected error %v", tt.password, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.password, tt.want, got)
+		}
+	}
+
+	if err := CheckPasswordPolicy("12345678"); err == nil {
+		t.Error("Expected the default policy to reject a common password")
+	}
+}
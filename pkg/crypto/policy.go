@@ -0,0 +1,144 @@
+package crypto
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy describes the passwords users may choose
+// PasswordPolicy 描述用户可选择的密码
+type PasswordPolicy struct {
+	MinLength     int      // Minimum characters, default 8 | 最少字符数，默认 8
+	MaxLength     int      // Maximum characters, default 128 (bounds hashing cost) | 最多字符数，默认 128（限制哈希开销）
+	RequireUpper  bool     // At least one uppercase letter | 至少一个大写字母
+	RequireLower  bool     // At least one lowercase letter | 至少一个小写字母
+	RequireDigit  bool     // At least one digit | 至少一个数字
+	RequireSymbol bool     // At least one symbol or punctuation | 至少一个符号或标点
+	MinClasses    int      // Minimum of the four character classes present | 至少包含的字符类别数（共四类）
+	Blocklist     []string // Rejected passwords, compared case-insensitively | 禁止使用的密码，不区分大小写
+}
+
+// DefaultPasswordPolicy returns the policy used unless SetPasswordPolicy is called
+// DefaultPasswordPolicy 返回未调用 SetPasswordPolicy 时使用的策略
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 128,
+		Blocklist: []string{"password", "12345678", "123456789", "1234567890", "qwerty123", "11111111", "iloveyou", "admin123"},
+	}
+}
+
+// PolicyError lists the rules a password breaks, e.g. min_length=8, upper or contains_personal_info
+// PolicyError 列出密码违反的规则，如 min_length=8、upper 或 contains_personal_info
+type PolicyError struct {
+	Rules []string
+}
+
+// Error implements the error interface
+// Error 实现 error 接口
+func (e *PolicyError) Error() string {
+	return "crypto: password does not meet policy: " + strings.Join(e.Rules, ", ")
+}
+
+var (
+	policyMu       sync.RWMutex
+	passwordPolicy = DefaultPasswordPolicy()
+)
+
+// SetPasswordPolicy replaces the policy checked by CheckPasswordPolicy
+// SetPasswordPolicy 替换 CheckPasswordPolicy 使用的策略
+func SetPasswordPolicy(p PasswordPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	passwordPolicy = p
+}
+
+// CheckPasswordPolicy checks password against the current policy, see PasswordPolicy.Check
+// CheckPasswordPolicy 使用当前策略检查密码，见 PasswordPolicy.Check
+func CheckPasswordPolicy(password string, related ...string) error {
+	policyMu.RLock()
+	p := passwordPolicy
+	policyMu.RUnlock()
+	return p.Check(password, related...)
+}
+
+// Check returns a *PolicyError listing every broken rule, or nil
+// related are values the password must not contain, such as the username or email (case-insensitive, 3+ characters).
+// Check 返回列出所有违反规则的 *PolicyError，或 nil
+// related 是密码不能包含的值，如用户名或邮箱（不区分大小写，长度至少 3）。
+//
+// Example | 示例:
+//
+//	if err := crypto.CheckPasswordPolicy(req.Password, req.Username, req.Email); err != nil {
+//	    return errors.ErrParamInvalid(err.Error())
+//	}
+func (p PasswordPolicy) Check(password string, related ...string) error {
+	var rules []string
+	n := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && n < p.MinLength {
+		rules = append(rules, "min_length="+strconv.Itoa(p.MinLength))
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		rules = append(rules, "max_length="+strconv.Itoa(p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	for _, c := range []struct {
+		required, present bool
+		rule              string
+	}{
+		{p.RequireUpper, upper, "upper"},
+		{p.RequireLower, lower, "lower"},
+		{p.RequireDigit, digit, "digit"},
+		{p.RequireSymbol, symbol, "symbol"},
+	} {
+		if c.required && !c.present {
+			rules = append(rules, c.rule)
+		}
+	}
+	if p.MinClasses > 0 {
+		classes := 0
+		for _, present := range []bool{upper, lower, digit, symbol} {
+			if present {
+				classes++
+			}
+		}
+		if classes < p.MinClasses {
+			rules = append(rules, "min_classes="+strconv.Itoa(p.MinClasses))
+		}
+	}
+
+	lowered := strings.ToLower(password)
+	for _, blocked := range p.Blocklist {
+		if lowered == strings.ToLower(blocked) {
+			rules = append(rules, "common")
+			break
+		}
+	}
+	for _, value := range related {
+		if v := strings.ToLower(strings.TrimSpace(value)); utf8.RuneCountInString(v) >= 3 && strings.Contains(lowered, v) {
+			rules = append(rules, "contains_personal_info")
+			break
+		}
+	}
+
+	if len(rules) > 0 {
+		return &PolicyError{Rules: rules}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// encPattern matches ENC() values embedded in a document
+// encPattern 匹配文档中嵌入的 ENC() 值
+var encPattern = regexp.MustCompile(`ENC\([A-Za-z0-9+/]*=*\)`)
+
+// Rekey re-encrypts every ENC() value in data from oldKey to newKey, leaving everything else untouched
+// It returns the number of values rewritten. Nothing is returned if any value fails to decrypt.
+// Rekey 将 data 中的每个 ENC() 值从 oldKey 重新加密为 newKey，其余内容保持不变
+// 返回重写的值数量。任一值解密失败时不返回任何内容。
+func Rekey(data []byte, oldKey, newKey string) ([]byte, int, error) {
+	var firstErr error
+	count := 0
+	out := encPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		if firstErr != nil {
+			return match
+		}
+		plaintext, err := Decrypt(string(match), oldKey)
+		if err != nil {
+			firstErr = fmt.Errorf("decrypt %s: %w", match, err)
+			return match
+		}
+		encrypted, err := Encrypt(plaintext, newKey)
+		if err != nil {
+			firstErr = err
+			return match
+		}
+		count++
+		return []byte(encrypted)
+	})
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+	return out, count, nil
+}
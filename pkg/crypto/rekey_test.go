@@ -0,0 +1,48 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRekey(t *testing.T) {
+	password, _ := Encrypt("secret", "old")
+	token, _ := Encrypt("token-123", "old")
+	doc := "[database.default]\npassword = \"" + password + "\"\nhost = \"localhost\"\n\n[redis.default]\npassword = '" + token + "' # cache\n"
+
+	out, n, err := Rekey([]byte(doc), "old", "new")
+	if err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("rewrote %d values, want 2", n)
+	}
+	if strings.Contains(string(out), password) || strings.Contains(string(out), token) {
+		t.Error("old ciphertext left in output")
+	}
+	if !strings.Contains(string(out), "host = \"localhost\"") || !strings.Contains(string(out), "# cache") {
+		t.Errorf("surrounding content changed:\n%s", out)
+	}
+
+	values := encPattern.FindAllString(string(out), -1)
+	want := []string{"secret", "token-123"}
+	for i, v := range values {
+		got, err := Decrypt(v, "new")
+		if err != nil || got != want[i] {
+			t.Errorf("value %d: got %q, %v; want %q", i, got, err, want[i])
+		}
+	}
+}
+
+func TestRekeyWrongKey(t *testing.T) {
+	password, _ := Encrypt("secret", "old")
+	doc := "password = \"" + password + "\"\n"
+
+	out, n, err := Rekey([]byte(doc), "wrong", "new")
+	if err == nil {
+		t.Fatal("expected error with wrong old key")
+	}
+	if out != nil || n != 0 {
+		t.Errorf("got output %q, %d values on error", out, n)
+	}
+}
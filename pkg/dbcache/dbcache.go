@@ -0,0 +1,135 @@
+// Package dbcache caches xorm Get/Find results in pkg/cache and drops them when their tables change.
+// Package dbcache 将 xorm 的 Get/Find 结果缓存到 pkg/cache，并在表变更时使其失效。
+//
+// Cache keys hold the query (table, conditions, order, limit and arguments) and the version of
+// every table read. Hook bumps the version of a table after each successful INSERT, UPDATE,
+// DELETE or TRUNCATE, so older entries are never read again and simply expire. The version is
+// bumped a second time after Delay, dropping rows cached by a reader that ran between the write
+// and the commit of its transaction.
+// 缓存键包含查询（表、条件、排序、分页及参数）和所读各表的版本。Hook 在每次 INSERT、UPDATE、
+// DELETE 或 TRUNCATE 成功后提升表的版本，旧条目不会再被读取，只会自然过期。Delay 之后会再次提升版本，
+// 以清除在写入与事务提交之间被其他读取方缓存的数据（延时双删）。
+//
+// Rows are encoded with gob: exported fields are kept whatever their json tags, unexported
+// ones are lost. Models holding secrets should opt out with a zero CacheTTL.
+// 行数据使用 gob 编码：导出字段无论 json 标签如何都会保留，未导出字段会丢失。包含敏感数据的模型应通过返回 0 的 CacheTTL 退出缓存。
+//
+// Usage | 用法:
+//
+//	var list []model.Category
+//	total, err := dbcache.New(ctx, db).Where("status = ?", 1).Asc("sort").Limit(size, offset).FindAndCount(&list)
+//
+//	// Per-model TTL, zero or negative disables caching | 按模型设置 TTL，零或负数表示不缓存
+//	func (c *Category) CacheTTL() time.Duration { return 10 * time.Minute }
+package dbcache
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/cache"
+	"xorm.io/xorm/contexts"
+)
+
+// keyPrefix namespaces result keys and table versions | keyPrefix 为结果键和表版本添加命名空间
+const keyPrefix = "dbcache:"
+
+// Config represents query cache configuration
+// Config 表示查询缓存配置
+type Config struct {
+	Disable bool          `toml:"disable"` // Bypass the cache, queries always hit the database | 绕过缓存，查询总是访问数据库
+	TTL     time.Duration `toml:"ttl"`     // Default result lifetime, default 1m | 默认结果有效期，默认 1m
+	Delay   time.Duration `toml:"delay"`   // Second invalidation after a write, default 1s, negative disables | 写入后第二次失效的延迟，默认 1s，负数表示禁用
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = time.Minute
+	}
+	if c.Delay == 0 {
+		c.Delay = time.Second
+	}
+	return c
+}
+
+// Cacheable lets a model choose how long its results are cached
+// Cacheable 允许模型选择其结果的缓存时长
+type Cacheable interface {
+	// CacheTTL returns the result lifetime, zero or negative disables caching | CacheTTL 返回结果有效期，零或负数表示不缓存
+	CacheTTL() time.Duration
+}
+
+var (
+	store  *cache.Cache
+	config = Config{}.withDefaults()
+)
+
+// Init enables the query cache on c; until then queries always hit the database
+// Init 在 c 上启用查询缓存；在此之前查询总是访问数据库
+func Init(c *cache.Cache, cfg Config) {
+	store = c
+	config = cfg.withDefaults()
+}
+
+// enabled reports whether results are cached | enabled 报告是否缓存结果
+func enabled() bool {
+	return store != nil && !config.Disable
+}
+
+// Invalidate drops the cached results reading tables, for writes made outside hooked engines
+// Invalidate 清除读取了这些表的缓存结果，用于未安装钩子的引擎上的写入
+func Invalidate(ctx context.Context, tables ...string) {
+	if store == nil || len(tables) == 0 {
+		return
+	}
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = keyPrefix + strings.ToLower(t)
+	}
+	store.Bump(ctx, names...)
+}
+
+// Hook invalidates tables after successful writes, add it to every engine read through the cache
+// Hook 在写入成功后使表失效，需添加到所有经由缓存读取的引擎
+type Hook struct{}
+
+// BeforeProcess implements contexts.Hook | BeforeProcess 实现 contexts.Hook
+func (Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess bumps the table written by the statement, now and again after Delay
+// AfterProcess 提升语句所写表的版本，立即一次，Delay 之后再一次
+func (Hook) AfterProcess(c *contexts.ContextHook) error {
+	if c.Err != nil || store == nil {
+		return nil
+	}
+	table := writtenTable(c.SQL)
+	if table == "" {
+		return nil
+	}
+	Invalidate(context.WithoutCancel(c.Ctx), table)
+	if config.Delay > 0 {
+		time.AfterFunc(config.Delay, func() {
+			Invalidate(context.Background(), table)
+		})
+	}
+	return nil
+}
+
+// writeStmt matches the target table of a write statement, skipping its schema
+// writeStmt 匹配写语句的目标表，跳过其 schema
+var writeStmt = regexp.MustCompile(`(?is)^\s*(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM|TRUNCATE(?:\s+TABLE)?)\s+(?:ONLY\s+)?(?:(?:"[^"]+"|[\w$]+)\.)?("[^"]+"|[\w$]+)`)
+
+// writtenTable returns the table written by query, "" for other statements
+// writtenTable 返回 query 所写的表，其他语句返回 ""
+func writtenTable(query string) string {
+	m := writeStmt.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(m[1], `"`))
+}
@@ -0,0 +1,127 @@
+package dbcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/nuohe369/crab/pkg/cache"
+	"xorm.io/xorm"
+	"xorm.io/xorm/contexts"
+)
+
+type article struct {
+	ID    int64
+	Title string
+}
+
+func (a *article) TableName() string { return "article" }
+
+type secret struct {
+	ID    int64
+	Token string
+}
+
+func (s *secret) CacheTTL() time.Duration { return 0 }
+
+// setup enables the cache in memory and returns an engine that cannot connect
+// setup 在内存中启用缓存，并返回一个无法连接的引擎
+func setup(t *testing.T) *xorm.Engine {
+	t.Helper()
+	db, err := xorm.NewEngine("postgres", "postgres://nobody@127.0.0.1:1/none?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cache.New(nil, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	prevStore, prevConfig := store, config
+	Init(c, Config{Delay: -1})
+	t.Cleanup(func() {
+		store, config = prevStore, prevConfig
+		c.Close()
+		db.Close()
+	})
+	return db
+}
+
+func TestWrittenTable(t *testing.T) {
+	for query, want := range map[string]string{
+		`INSERT INTO "article" ("id","title") VALUES ($1,$2)`: "article",
+		`UPDATE "public"."Article" SET "title" = $1`:          "article",
+		"delete from article_tag where article_id = ?":        "article_tag",
+		"TRUNCATE TABLE ONLY audit_log":                       "audit_log",
+		`SELECT "id" FROM "article"`:                          "",
+		"BEGIN TRANSACTION":                                   "",
+	} {
+		if got := writtenTable(query); got != want {
+			t.Errorf("writtenTable(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	db := setup(t)
+	ctx := context.Background()
+	key := func(s *Session) string {
+		k, _ := s.cacheKey("find", &[]article{})
+		return k
+	}
+
+	first := key(New(ctx, db).Where("title = ?", "go").Limit(10))
+	if first == "" || first != key(New(ctx, db).Where("title = ?", "go").Limit(10)) {
+		t.Fatalf("Identical queries should share a key, got %q", first)
+	}
+	if first == key(New(ctx, db).Where("title = ?", "rust").Limit(10)) {
+		t.Error("Arguments should be part of the key")
+	}
+	if key(New(ctx, db).Where("title = ?", "go").TTL(0)) != "" {
+		t.Error("TTL(0) should disable caching")
+	}
+	if k, _ := New(ctx, db).cacheKey("find", &[]*secret{}); k != "" {
+		t.Error("A zero CacheTTL should disable caching for the model")
+	}
+	if k, _ := New(ctx, db).SQL("SELECT 1").cacheKey("find", &[]int{}); k != "" {
+		t.Error("Queries without known tables cannot be invalidated and should not be cached")
+	}
+
+	joined := key(New(ctx, db).Depends("tag"))
+	_ = Hook{}.AfterProcess(&contexts.ContextHook{Ctx: ctx, SQL: `UPDATE "article" SET "title" = $1`})
+	if key(New(ctx, db).Where("title = ?", "go").Limit(10)) == first {
+		t.Error("Writing the table should change the key")
+	}
+	Invalidate(ctx, "tag")
+	if key(New(ctx, db).Depends("tag")) == joined {
+		t.Error("Writing a dependency should change the key")
+	}
+}
+
+func TestServedFromCache(t *testing.T) {
+	db := setup(t)
+	ctx := context.Background()
+	want := []article{{ID: 1, Title: "go"}, {ID: 2, Title: "rust"}}
+
+	// Prime the cache through a query that never reaches the database | 通过不访问数据库的查询预热缓存
+	runs := 0
+	fake := func(*xorm.Session) (entry, error) {
+		runs++
+		return entry{Total: 5}, nil
+	}
+	primed := append([]article(nil), want...)
+	if err := New(ctx, db).Asc("id").load("find_count", &primed, new(entry), fake, &primed); err != nil {
+		t.Fatal(err)
+	}
+
+	got := []article{{ID: 9}}
+	total, err := New(ctx, db).Asc("id").FindAndCount(&got)
+	if err != nil {
+		t.Fatalf("Should be served without the database: %v", err)
+	}
+	if total != 5 || len(got) != 2 || got[1] != want[1] {
+		t.Errorf("FindAndCount = %d %+v", total, got)
+	}
+
+	_ = Hook{}.AfterProcess(&contexts.ContextHook{Ctx: ctx, SQL: `DELETE FROM "article" WHERE "id" = $1`})
+	if err := New(ctx, db).Asc("id").load("find_count", &got, new(entry), fake, &got); err != nil || runs != 2 {
+		t.Errorf("A write should send the next query to the database, runs = %d, err = %v", runs, err)
+	}
+}
@@ -0,0 +1,276 @@
+package dbcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Session builds a cached query; builder calls are recorded and replayed on a cache miss
+// Session 构建带缓存的查询；构建方法会被记录，并在缓存未命中时重放
+type Session struct {
+	ctx    context.Context
+	db     *xorm.Engine
+	steps  []func(*xorm.Session) *xorm.Session
+	key    strings.Builder
+	table  string
+	tables []string
+	ttl    time.Duration
+}
+
+// entry is a cached result | entry 为一条缓存结果
+type entry struct {
+	Found bool   `json:"f,omitempty"`
+	Total int64  `json:"t,omitempty"`
+	Rows  []byte `json:"r,omitempty"`
+}
+
+// New starts a cached query on db | New 在 db 上开始一个带缓存的查询
+func New(ctx context.Context, db *xorm.Engine) *Session {
+	return &Session{ctx: ctx, db: db}
+}
+
+// record appends a builder call to the query and its key | record 将构建方法追加到查询及其键中
+func (s *Session) record(step func(*xorm.Session) *xorm.Session, name string, args ...any) *Session {
+	s.steps = append(s.steps, step)
+	s.key.WriteString(name)
+	for _, a := range args {
+		fmt.Fprintf(&s.key, "|%T:%v", a, a)
+	}
+	s.key.WriteByte(';')
+	return s
+}
+
+// Table sets the table of the query | Table 设置查询的表
+func (s *Session) Table(name string) *Session {
+	s.table = name
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Table(name) }, "table", name)
+}
+
+// Where adds a condition, query is SQL with ? placeholders | Where 添加条件，query 为带 ? 占位符的 SQL
+func (s *Session) Where(query string, args ...any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Where(query, args...) }, "where", append([]any{query}, args...)...)
+}
+
+// And adds a condition joined with AND | And 添加以 AND 连接的条件
+func (s *Session) And(query string, args ...any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.And(query, args...) }, "and", append([]any{query}, args...)...)
+}
+
+// Or adds a condition joined with OR | Or 添加以 OR 连接的条件
+func (s *Session) Or(query string, args ...any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Or(query, args...) }, "or", append([]any{query}, args...)...)
+}
+
+// In adds a column IN (args) condition | In 添加 column IN (args) 条件
+func (s *Session) In(column string, args ...any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.In(column, args...) }, "in", append([]any{column}, args...)...)
+}
+
+// ID adds a primary key condition | ID 添加主键条件
+func (s *Session) ID(id any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.ID(id) }, "id", id)
+}
+
+// Cols limits the selected columns | Cols 限制查询的列
+func (s *Session) Cols(cols ...string) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Cols(cols...) }, "cols", strings.Join(cols, ","))
+}
+
+// OrderBy sets a raw ORDER BY clause | OrderBy 设置原始 ORDER BY 子句
+func (s *Session) OrderBy(order string) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.OrderBy(order) }, "order", order)
+}
+
+// Asc orders by columns ascending | Asc 按列升序排序
+func (s *Session) Asc(cols ...string) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Asc(cols...) }, "asc", strings.Join(cols, ","))
+}
+
+// Desc orders by columns descending | Desc 按列降序排序
+func (s *Session) Desc(cols ...string) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Desc(cols...) }, "desc", strings.Join(cols, ","))
+}
+
+// Limit sets the page size and optional offset | Limit 设置分页大小和可选的偏移量
+func (s *Session) Limit(limit int, start ...int) *Session {
+	offset := 0
+	if len(start) > 0 {
+		offset = start[0]
+	}
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.Limit(limit, offset) }, "limit", limit, offset)
+}
+
+// SQL runs a raw query; name its tables with Depends so it can be invalidated
+// SQL 执行原始查询；需通过 Depends 声明其读取的表以便失效
+func (s *Session) SQL(query string, args ...any) *Session {
+	return s.record(func(x *xorm.Session) *xorm.Session { return x.SQL(query, args...) }, "sql", append([]any{query}, args...)...)
+}
+
+// Depends adds tables read by joins or raw SQL, their writes invalidate the result too
+// Depends 添加通过连接或原始 SQL 读取的表，这些表的写入同样会使结果失效
+func (s *Session) Depends(tables ...string) *Session {
+	s.tables = append(s.tables, tables...)
+	return s
+}
+
+// TTL overrides the lifetime of this result, zero or negative disables caching
+// TTL 覆盖本次结果的有效期，零或负数表示不缓存
+func (s *Session) TTL(ttl time.Duration) *Session {
+	if ttl <= 0 {
+		ttl = -1
+	}
+	s.ttl = ttl
+	return s
+}
+
+// Get loads one row into bean | Get 将一行加载到 bean
+func (s *Session) Get(bean any) (bool, error) {
+	var e entry
+	err := s.load("get", bean, &e, func(x *xorm.Session) (entry, error) {
+		found, err := x.Get(bean)
+		return entry{Found: found}, err
+	}, bean)
+	return e.Found, err
+}
+
+// Find loads rows into the slice rowsSlicePtr points to | Find 将多行加载到 rowsSlicePtr 指向的切片
+func (s *Session) Find(rowsSlicePtr any) error {
+	return s.load("find", rowsSlicePtr, new(entry), func(x *xorm.Session) (entry, error) {
+		return entry{}, x.Find(rowsSlicePtr)
+	}, rowsSlicePtr)
+}
+
+// FindAndCount loads rows and counts all matching rows regardless of Limit
+// FindAndCount 加载多行并统计所有匹配行数（不受 Limit 影响）
+func (s *Session) FindAndCount(rowsSlicePtr any) (int64, error) {
+	var e entry
+	err := s.load("find_count", rowsSlicePtr, &e, func(x *xorm.Session) (entry, error) {
+		total, err := x.FindAndCount(rowsSlicePtr)
+		return entry{Total: total}, err
+	}, rowsSlicePtr)
+	return e.Total, err
+}
+
+// Count counts the matching rows of bean's table | Count 统计 bean 所在表的匹配行数
+func (s *Session) Count(bean any) (int64, error) {
+	var e entry
+	err := s.load("count", bean, &e, func(x *xorm.Session) (entry, error) {
+		total, err := x.Count(bean)
+		return entry{Total: total}, err
+	}, nil)
+	return e.Total, err
+}
+
+// load serves a result from the cache or runs the query and caches it, rows is nil when
+// the result has no rows to encode
+// load 从缓存返回结果，或执行查询并缓存，结果无需编码行数据时 rows 为 nil
+func (s *Session) load(op string, bean any, e *entry, query func(*xorm.Session) (entry, error), rows any) error {
+	key, ttl := s.cacheKey(op, bean)
+	if key != "" && store.GetValue(s.ctx, key, e) == nil {
+		if rows == nil || len(e.Rows) == 0 {
+			return nil
+		}
+		// Decode into a fresh value, gob leaves zero fields untouched | 解码到新值中，gob 不会覆盖零值字段
+		v := reflect.ValueOf(rows).Elem()
+		fresh := reflect.New(v.Type())
+		if err := gob.NewDecoder(bytes.NewReader(e.Rows)).Decode(fresh.Interface()); err == nil {
+			v.Set(fresh.Elem())
+			return nil
+		}
+	}
+
+	x := s.db.NewSession().Context(s.ctx)
+	defer x.Close()
+	for _, step := range s.steps {
+		x = step(x)
+	}
+	res, err := query(x)
+	if err != nil {
+		return err
+	}
+	*e = res
+	if key == "" {
+		return nil
+	}
+	if rows != nil && (op != "get" || res.Found) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(rows); err != nil {
+			return nil // Not encodable, served uncached | 无法编码，不经缓存返回
+		}
+		e.Rows = buf.Bytes()
+	}
+	_ = store.SetValue(s.ctx, key, e, ttl)
+	return nil
+}
+
+// cacheKey returns the key and lifetime of the result, "" when it must not be cached
+// cacheKey 返回结果的键和有效期，不应缓存时返回 ""
+func (s *Session) cacheKey(op string, bean any) (string, time.Duration) {
+	if !enabled() || s.ttl < 0 {
+		return "", 0
+	}
+	model := modelOf(bean)
+	ttl := s.ttl
+	if ttl == 0 {
+		ttl = config.TTL
+		if c, ok := model.(Cacheable); ok {
+			ttl = c.CacheTTL()
+		}
+	}
+	if ttl <= 0 {
+		return "", 0
+	}
+
+	tables := s.tables
+	if s.table != "" {
+		tables = append([]string{s.table}, tables...)
+	} else if model != nil {
+		tables = append([]string{s.db.TableName(model)}, tables...)
+	}
+	if len(tables) == 0 {
+		return "", 0 // Nothing would invalidate it | 无法使其失效
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%T|%s", s.db.Dialect().URI().DBName, op, bean, s.key.String())
+	if op == "get" || op == "count" {
+		fmt.Fprintf(h, "|%+v", reflect.Indirect(reflect.ValueOf(bean)).Interface()) // Non-zero fields are conditions | 非零字段为查询条件
+	}
+	for _, t := range tables {
+		v, err := store.Version(s.ctx, keyPrefix+strings.ToLower(t))
+		if err != nil {
+			return "", 0
+		}
+		fmt.Fprintf(h, "|%s=%s", strings.ToLower(t), v)
+	}
+	return keyPrefix + strings.ToLower(tables[0]) + ":" + hex.EncodeToString(h.Sum(nil)), ttl
+}
+
+// modelOf returns a pointer to the model of a bean or slice pointer, nil for other values
+// modelOf 返回 bean 或切片指针对应模型的指针，其他值返回 nil
+func modelOf(bean any) any {
+	t := reflect.TypeOf(bean)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return nil
+	}
+	t = t.Elem()
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return reflect.New(t).Interface()
+}
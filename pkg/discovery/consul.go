@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// consulRegistry uses the Consul agent HTTP API; Consul checks HealthPath itself
+// consulRegistry 使用 Consul agent HTTP API，由 Consul 自行检查 HealthPath
+type consulRegistry struct {
+	cfg    Config
+	client *http.Client
+}
+
+type consulCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	Timeout                        string `json:"Timeout"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+type consulService struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name,omitempty"`
+	Service string            `json:"Service,omitempty"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   *consulCheck      `json:"Check,omitempty"`
+}
+
+type consulEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service consulService `json:"Service"`
+}
+
+func (r *consulRegistry) Register(ctx context.Context, inst Instance) error {
+	svc := consulService{
+		ID:      inst.ID,
+		Name:    inst.Name,
+		Address: inst.Host,
+		Port:    inst.Port,
+		Meta:    inst.Meta,
+		Check: &consulCheck{
+			HTTP:                           "http://" + inst.Addr() + r.cfg.HealthPath,
+			Interval:                       r.cfg.TTL.String(),
+			Timeout:                        (r.cfg.TTL / 2).String(),
+			DeregisterCriticalServiceAfter: (r.cfg.TTL * 6).String(),
+		},
+	}
+	return r.do(ctx, http.MethodPut, "/v1/agent/service/register", svc, nil)
+}
+
+func (r *consulRegistry) Deregister(ctx context.Context, inst Instance) error {
+	return r.do(ctx, http.MethodPut, "/v1/agent/service/deregister/"+url.PathEscape(inst.ID), nil, nil)
+}
+
+func (r *consulRegistry) Instances(ctx context.Context, name string) ([]Instance, error) {
+	var entries []consulEntry
+	if err := r.do(ctx, http.MethodGet, "/v1/health/service/"+url.PathEscape(name)+"?passing=true", nil, &entries); err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		instances = append(instances, Instance{
+			ID:   e.Service.ID,
+			Name: e.Service.Service,
+			Host: host,
+			Port: e.Service.Port,
+			Meta: e.Service.Meta,
+		})
+	}
+	return instances, nil
+}
+
+// do sends a request to the agent, encoding in and decoding the response into out
+// do 向代理发送请求，编码 in 并将响应解码到 out
+func (r *consulRegistry) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(r.cfg.Endpoint, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if r.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", r.cfg.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discovery: consul %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discovery: consul %s %s: status %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package discovery registers running services and resolves sibling services by name
+// Consul and etcd are reached through their HTTP APIs, so no client library is needed.
+// discovery 包注册运行中的服务，并按名称解析同级服务
+// 通过 HTTP API 访问 Consul 和 etcd，无需额外的客户端库。
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/logger"
+)
+
+var log = logger.NewSystem("discovery")
+
+// ErrNoInstances is returned when a service has no healthy instance
+// ErrNoInstances 在服务没有健康实例时返回
+var ErrNoInstances = errors.New("discovery: no instances")
+
+// Config represents service discovery configuration
+// Config 表示服务发现配置
+type Config struct {
+	Driver     string        `toml:"driver"`      // consul or etcd, empty disables discovery | consul 或 etcd，为空时禁用
+	Endpoint   string        `toml:"endpoint"`    // Agent URL, e.g. http://127.0.0.1:8500 or http://127.0.0.1:2379 | 代理地址
+	Token      string        `toml:"token"`       // Consul ACL token | Consul ACL 令牌
+	Address    string        `toml:"address"`     // Advertised host, default the first non-loopback IPv4 | 对外公布的主机，默认第一个非回环 IPv4
+	TTL        time.Duration `toml:"ttl"`         // Health check interval (Consul) or lease TTL (etcd), default 10s | 健康检查间隔（Consul）或租约 TTL（etcd），默认 10 秒
+	HealthPath string        `toml:"health_path"` // Health endpoint checked by Consul, default /health/ready | Consul 检查的健康端点，默认 /health/ready
+	Prefix     string        `toml:"prefix"`      // etcd key prefix, default /crab/services/ | etcd 键前缀，默认 /crab/services/
+	CacheTTL   time.Duration `toml:"cache_ttl"`   // How long resolved instances are cached, default 5s | 解析结果缓存时间，默认 5 秒
+}
+
+// Enabled returns whether discovery is configured
+// Enabled 返回是否配置了服务发现
+func (c Config) Enabled() bool {
+	return c.Driver != ""
+}
+
+// withDefaults fills empty fields | withDefaults 填充空字段
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = 10 * time.Second
+	}
+	if c.HealthPath == "" {
+		c.HealthPath = "/health/ready"
+	}
+	if c.Prefix == "" {
+		c.Prefix = "/crab/services/"
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = 5 * time.Second
+	}
+	return c
+}
+
+// Instance is one running copy of a service
+// Instance 是服务的一个运行实例
+type Instance struct {
+	ID   string            `json:"id"`
+	Name string            `json:"name"`
+	Host string            `json:"host"`
+	Port int               `json:"port"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Addr returns host:port
+// Addr 返回 host:port
+func (i Instance) Addr() string {
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// Registry stores service instances
+// Registry 存储服务实例
+type Registry interface {
+	// Register adds the instance and keeps it alive until Deregister | Register 添加实例并保持其存活直到 Deregister
+	Register(ctx context.Context, inst Instance) error
+	// Deregister removes the instance | Deregister 移除实例
+	Deregister(ctx context.Context, inst Instance) error
+	// Instances returns the healthy instances of a service | Instances 返回服务的健康实例
+	Instances(ctx context.Context, name string) ([]Instance, error)
+}
+
+// New creates the registry for cfg.Driver
+// New 根据 cfg.Driver 创建注册中心
+func New(cfg Config) (Registry, error) {
+	cfg = cfg.withDefaults()
+	client := &http.Client{Timeout: 5 * time.Second}
+	switch cfg.Driver {
+	case "consul":
+		return &consulRegistry{cfg: cfg, client: client}, nil
+	case "etcd":
+		return &etcdRegistry{cfg: cfg, client: client, leases: make(map[string]*etcdLease)}, nil
+	default:
+		return nil, fmt.Errorf("discovery: unsupported driver %q", cfg.Driver)
+	}
+}
+
+var (
+	defaultConfig   Config
+	defaultRegistry Registry
+	defaultResolver *Resolver
+	registered      []Instance
+	mu              sync.Mutex
+)
+
+// Init initializes the default registry and resolver
+// Init 初始化默认注册中心和解析器
+func Init(cfg Config) error {
+	reg, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultConfig = cfg.withDefaults()
+	defaultRegistry = reg
+	defaultResolver = NewResolver(reg, defaultConfig.CacheTTL)
+	log.Info("Service discovery initialized: driver=%s, endpoint=%s", cfg.Driver, cfg.Endpoint)
+	return nil
+}
+
+// Get returns the default registry, nil if discovery is not configured
+// Get 返回默认注册中心，未配置服务发现时返回 nil
+func Get() Registry {
+	mu.Lock()
+	defer mu.Unlock()
+	return defaultRegistry
+}
+
+// Default returns the default resolver, nil if discovery is not configured
+// Default 返回默认解析器，未配置服务发现时返回 nil
+func Default() *Resolver {
+	mu.Lock()
+	defer mu.Unlock()
+	return defaultResolver
+}
+
+// RegisterService registers the running service listening on listenAddr
+// The host comes from Config.Address, falling back to the first non-loopback IPv4.
+// It does nothing when discovery is not configured.
+// RegisterService 注册监听在 listenAddr 上的当前服务
+// 主机取自 Config.Address，未配置时使用第一个非回环 IPv4。未配置服务发现时不做任何事。
+func RegisterService(ctx context.Context, name, listenAddr string) error {
+	reg := Get()
+	if reg == nil {
+		return nil
+	}
+
+	host, portStr, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return fmt.Errorf("discovery: invalid listen address %q: %w", listenAddr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("discovery: invalid port in %q: %w", listenAddr, err)
+	}
+	if defaultConfig.Address != "" {
+		host = defaultConfig.Address
+	} else if host == "" || host == "0.0.0.0" || host == "::" {
+		host = localIP()
+	}
+
+	inst := Instance{
+		ID:   fmt.Sprintf("%s-%s-%d", name, host, port),
+		Name: name,
+		Host: host,
+		Port: port,
+	}
+	if err := reg.Register(ctx, inst); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	registered = append(registered, inst)
+	mu.Unlock()
+	log.Info("Registered service %s at %s", name, inst.Addr())
+	return nil
+}
+
+// Close deregisters every service registered by RegisterService
+// Call it before shutting the server down so callers stop picking this instance.
+// Close 注销所有通过 RegisterService 注册的服务
+// 应在关闭服务器前调用，使调用方不再选择本实例。
+func Close() {
+	mu.Lock()
+	reg, insts := defaultRegistry, registered
+	registered = nil
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, inst := range insts {
+		if err := reg.Deregister(ctx, inst); err != nil {
+			log.Warn("Failed to deregister service %s: %v", inst.ID, err)
+		} else {
+			log.Info("Deregistered service %s", inst.ID)
+		}
+	}
+}
+
+// localIP returns the first non-loopback IPv4 address | localIP 返回第一个非回环 IPv4 地址
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				return ipNet.IP.String()
+			}
+		}
+	}
+	return "127.0.0.1"
+}
@@ -0,0 +1,224 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRegistry struct {
+	mu        sync.Mutex
+	instances map[string][]Instance
+	calls     int
+	err       error
+}
+
+func (f *fakeRegistry) Register(ctx context.Context, inst Instance) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.instances[inst.Name] = append(f.instances[inst.Name], inst)
+	return nil
+}
+
+func (f *fakeRegistry) Deregister(ctx context.Context, inst Instance) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.instances, inst.Name)
+	return nil
+}
+
+func (f *fakeRegistry) Instances(ctx context.Context, name string) ([]Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.instances[name], nil
+}
+
+func TestResolver_RoundRobin(t *testing.T) {
+	reg := &fakeRegistry{instances: map[string][]Instance{
+		"order": {{Host: "10.0.0.1", Port: 8080}, {Host: "10.0.0.2", Port: 8080}},
+	}}
+	r := NewResolver(reg, time.Minute)
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		addr, err := r.Resolve(context.Background(), "order")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		seen[addr]++
+	}
+	if seen["10.0.0.1:8080"] != 2 || seen["10.0.0.2:8080"] != 2 {
+		t.Errorf("Expected even round-robin, got %v", seen)
+	}
+	if reg.calls != 1 {
+		t.Errorf("Expected cached lookups, got %d registry calls", reg.calls)
+	}
+
+	if _, err := r.Resolve(context.Background(), "missing"); !errors.Is(err, ErrNoInstances) {
+		t.Errorf("Expected ErrNoInstances, got %v", err)
+	}
+}
+
+func TestResolver_KeepsCacheOnRefreshError(t *testing.T) {
+	reg := &fakeRegistry{instances: map[string][]Instance{"order": {{Host: "10.0.0.1", Port: 80}}}}
+	r := NewResolver(reg, time.Millisecond)
+
+	if _, err := r.Resolve(context.Background(), "order"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	reg.err = errors.New("registry down")
+
+	addr, err := r.Resolve(context.Background(), "order")
+	if err != nil || addr != "10.0.0.1:80" {
+		t.Errorf("Expected cached instance, got %q, %v", addr, err)
+	}
+}
+
+func TestTransport_RewritesServiceURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+	host, portStr, _ := strings.Cut(strings.TrimPrefix(srv.URL, "http://"), ":")
+	port, _ := strconv.Atoi(portStr)
+
+	reg := &fakeRegistry{instances: map[string][]Instance{}}
+	reg.Register(context.Background(), Instance{Name: "order", Host: host, Port: port})
+	mu.Lock()
+	defaultResolver = NewResolver(reg, time.Minute)
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		defaultResolver = nil
+		mu.Unlock()
+	}()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	resp, err := client.Get("service://order/v1/orders")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	if string(buf[:n]) != "/v1/orders" {
+		t.Errorf("Expected path /v1/orders, got %q", buf[:n])
+	}
+}
+
+func TestConsulRegistry(t *testing.T) {
+	var registered consulService
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/agent/service/register":
+			json.NewDecoder(r.Body).Decode(&registered)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/health/service/order":
+			if r.URL.Query().Get("passing") != "true" {
+				t.Error("Expected passing=true")
+			}
+			json.NewEncoder(w).Encode([]consulEntry{{Service: registered}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	reg, err := New(Config{Driver: "consul", Endpoint: srv.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := Instance{ID: "order-1", Name: "order", Host: "10.0.0.1", Port: 8080}
+	if err := reg.Register(context.Background(), inst); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if registered.Check == nil || registered.Check.HTTP != "http://10.0.0.1:8080/health/ready" {
+		t.Errorf("Expected HTTP health check, got %+v", registered.Check)
+	}
+
+	registered.Service = registered.Name
+	list, err := reg.Instances(context.Background(), "order")
+	if err != nil || len(list) != 1 || list[0].Addr() != "10.0.0.1:8080" {
+		t.Errorf("Unexpected instances %+v, %v", list, err)
+	}
+}
+
+func TestEtcdRegistry(t *testing.T) {
+	var mu sync.Mutex
+	kv := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v3/lease/grant":
+			w.Write([]byte(`{"ID":"42","TTL":"10"}`))
+		case "/v3/kv/put":
+			kv[body["key"]] = body["value"]
+			w.Write([]byte(`{}`))
+		case "/v3/kv/range":
+			start, _ := base64.StdEncoding.DecodeString(body["key"])
+			end, _ := base64.StdEncoding.DecodeString(body["range_end"])
+			var kvs []map[string]string
+			for k, v := range kv {
+				key, _ := base64.StdEncoding.DecodeString(k)
+				if string(key) >= string(start) && string(key) < string(end) {
+					kvs = append(kvs, map[string]string{"key": k, "value": v})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"kvs": kvs})
+		case "/v3/lease/revoke":
+			if body["ID"] != "42" {
+				t.Errorf("Expected lease 42 to be revoked, got %q", body["ID"])
+			}
+			clear(kv)
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{"result":{"ID":"42","TTL":"10"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	reg, err := New(Config{Driver: "etcd", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := Instance{ID: "order-1", Name: "order", Host: "10.0.0.1", Port: 8080}
+	if err := reg.Register(context.Background(), inst); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	reg.Register(context.Background(), Instance{ID: "orders-1", Name: "orders", Host: "10.0.0.9", Port: 1})
+
+	list, err := reg.Instances(context.Background(), "order")
+	if err != nil || len(list) != 1 || list[0].ID != "order-1" {
+		t.Errorf("Expected only order-1 under the order prefix, got %+v, %v", list, err)
+	}
+
+	if err := reg.Deregister(context.Background(), inst); err != nil {
+		t.Fatalf("Deregister failed: %v", err)
+	}
+	reg.Deregister(context.Background(), Instance{ID: "orders-1", Name: "orders"})
+}
+
+func TestPrefixEnd(t *testing.T) {
+	if got := prefixEnd("/crab/services/order/"); got != "/crab/services/order0" {
+		t.Errorf("Unexpected prefix end %q", got)
+	}
+}
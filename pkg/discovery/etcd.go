@@ -0,0 +1,208 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// etcdRegistry uses the etcd v3 JSON gateway
+// Each instance is a key under Prefix/<name>/ attached to a lease that is kept alive
+// every TTL/3; if the process dies the lease expires and the key disappears.
+// etcdRegistry 使用 etcd v3 JSON 网关
+// 每个实例是 Prefix/<name>/ 下的一个键，绑定的租约每隔 TTL/3 续期；进程退出后租约过期，键随之消失。
+type etcdRegistry struct {
+	cfg    Config
+	client *http.Client
+	mu     sync.Mutex
+	leases map[string]*etcdLease
+}
+
+// etcdLease is the keep-alive loop of one registered instance | etcdLease 是单个注册实例的续期循环
+type etcdLease struct {
+	id     string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (r *etcdRegistry) key(inst Instance) string {
+	return r.cfg.Prefix + inst.Name + "/" + inst.ID
+}
+
+func (r *etcdRegistry) Register(ctx context.Context, inst Instance) error {
+	leaseID, err := r.put(ctx, inst)
+	if err != nil {
+		return err
+	}
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	lease := &etcdLease{id: leaseID, cancel: cancel, done: make(chan struct{})}
+	r.mu.Lock()
+	if old := r.leases[inst.ID]; old != nil {
+		old.cancel()
+	}
+	r.leases[inst.ID] = lease
+	r.mu.Unlock()
+
+	go r.keepAlive(loopCtx, inst, lease)
+	return nil
+}
+
+// put grants a lease and writes the instance key with it | put 申请租约并写入实例键
+func (r *etcdRegistry) put(ctx context.Context, inst Instance) (string, error) {
+	var grant struct {
+		ID string `json:"ID"`
+	}
+	ttl := int64(r.cfg.TTL / time.Second)
+	if err := r.do(ctx, "/v3/lease/grant", map[string]any{"TTL": max(ttl, 1)}, &grant); err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(inst)
+	if err != nil {
+		return "", err
+	}
+	err = r.do(ctx, "/v3/kv/put", map[string]any{
+		"key":   b64(r.key(inst)),
+		"value": base64.StdEncoding.EncodeToString(value),
+		"lease": grant.ID,
+	}, nil)
+	return grant.ID, err
+}
+
+// keepAlive renews the lease, re-registering when it was lost
+// keepAlive 续期租约，租约丢失时重新注册
+func (r *etcdRegistry) keepAlive(ctx context.Context, inst Instance, lease *etcdLease) {
+	defer close(lease.done)
+	ticker := time.NewTicker(max(r.cfg.TTL/3, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var resp struct {
+			Result struct {
+				TTL string `json:"TTL"`
+			} `json:"result"`
+		}
+		err := r.do(ctx, "/v3/lease/keepalive", map[string]any{"ID": lease.id}, &resp)
+		if err == nil && resp.Result.TTL != "" && resp.Result.TTL != "0" {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warn("Failed to renew lease of %s: %v", inst.ID, err)
+			continue
+		}
+
+		// Lease expired, e.g. after a network partition | 租约已过期，例如网络分区之后
+		id, err := r.put(ctx, inst)
+		if err != nil {
+			log.Warn("Failed to re-register %s: %v", inst.ID, err)
+			continue
+		}
+		lease.id = id
+		log.Info("Re-registered %s after lease expiry", inst.ID)
+	}
+}
+
+func (r *etcdRegistry) Deregister(ctx context.Context, inst Instance) error {
+	r.mu.Lock()
+	lease := r.leases[inst.ID]
+	delete(r.leases, inst.ID)
+	r.mu.Unlock()
+	if lease == nil {
+		return nil
+	}
+
+	lease.cancel()
+	<-lease.done
+	return r.do(ctx, "/v3/lease/revoke", map[string]any{"ID": lease.id}, nil)
+}
+
+func (r *etcdRegistry) Instances(ctx context.Context, name string) ([]Instance, error) {
+	prefix := r.cfg.Prefix + name + "/"
+	var resp struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := r.do(ctx, "/v3/kv/range", map[string]any{
+		"key":       b64(prefix),
+		"range_end": b64(prefixEnd(prefix)),
+	}, &resp); err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		data, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			continue
+		}
+		var inst Instance
+		if json.Unmarshal(data, &inst) == nil {
+			instances = append(instances, inst)
+		}
+	}
+	return instances, nil
+}
+
+// do posts a JSON request to the gateway and decodes the response into out
+// do 向网关发送 JSON 请求并将响应解码到 out
+func (r *etcdRegistry) do(ctx context.Context, path string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(r.cfg.Endpoint, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discovery: etcd %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discovery: etcd %s: status %d: %s", path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// prefixEnd returns the range end covering every key with the prefix
+// prefixEnd 返回覆盖该前缀所有键的范围终点
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return "\x00"
+}
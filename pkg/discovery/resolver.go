@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Scheme marks URLs resolved through discovery, e.g. service://order-api/v1/orders
+// Requests are sent over plain HTTP to a healthy instance of the named service.
+// Scheme 标记需通过服务发现解析的 URL，例如 service://order-api/v1/orders
+// 请求会通过 HTTP 发送到该服务的某个健康实例。
+const Scheme = "service"
+
+// Resolver picks instances of a service round-robin, caching registry lookups
+// Resolver 以轮询方式选择服务实例，并缓存注册中心的查询结果
+type Resolver struct {
+	registry Registry
+	ttl      time.Duration
+	mu       sync.Mutex
+	cache    map[string]*resolved
+}
+
+// resolved is a cached lookup | resolved 是缓存的查询结果
+type resolved struct {
+	instances []Instance
+	expires   time.Time
+	next      atomic.Uint64
+}
+
+// NewResolver creates a resolver caching lookups for ttl
+// NewResolver 创建缓存查询结果 ttl 时长的解析器
+func NewResolver(registry Registry, ttl time.Duration) *Resolver {
+	return &Resolver{registry: registry, ttl: ttl, cache: make(map[string]*resolved)}
+}
+
+// Pick returns the next healthy instance of the service
+// Pick 返回服务的下一个健康实例
+func (r *Resolver) Pick(ctx context.Context, name string) (Instance, error) {
+	entry, err := r.lookup(ctx, name)
+	if err != nil {
+		return Instance{}, err
+	}
+	n := entry.next.Add(1) - 1
+	return entry.instances[n%uint64(len(entry.instances))], nil
+}
+
+// Resolve returns host:port of the next healthy instance of the service
+// Resolve 返回服务下一个健康实例的 host:port
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	inst, err := r.Pick(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return inst.Addr(), nil
+}
+
+// lookup returns cached instances, refreshing them after ttl
+// A failed refresh keeps serving the previous instances.
+// lookup 返回缓存的实例，超过 ttl 后刷新。刷新失败时继续使用之前的实例。
+func (r *Resolver) lookup(ctx context.Context, name string) (*resolved, error) {
+	r.mu.Lock()
+	entry := r.cache[name]
+	r.mu.Unlock()
+	if entry != nil && time.Now().Before(entry.expires) {
+		return entry, nil
+	}
+
+	instances, err := r.registry.Instances(ctx, name)
+	if err == nil && len(instances) == 0 {
+		err = fmt.Errorf("%w for service %q", ErrNoInstances, name)
+	}
+	if err != nil {
+		if entry != nil {
+			log.Warn("Failed to refresh instances of %s, using cached: %v", name, err)
+			return entry, nil
+		}
+		return nil, err
+	}
+
+	fresh := &resolved{instances: instances, expires: time.Now().Add(r.ttl)}
+	if entry != nil {
+		fresh.next.Store(entry.next.Load())
+	}
+	r.mu.Lock()
+	r.cache[name] = fresh
+	r.mu.Unlock()
+	return fresh, nil
+}
+
+// Transport returns an http.RoundTripper that sends service:// URLs to an instance
+// of the named service through next; other URLs pass through unchanged. It uses the
+// default resolver at request time, so it can be installed before Init.
+// Transport 返回将 service:// URL 发送到对应服务实例的 http.RoundTripper，其他 URL 原样传递。
+// 它在请求时使用默认解析器，因此可以在 Init 之前安装。
+//
+// Usage | 用法:
+//
+//	resp, err := httpclient.Get().Get("service://order-api/v1/orders/1")
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+// RoundTrip 实现 http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != Scheme {
+		return t.next.RoundTrip(req)
+	}
+	r := Default()
+	if r == nil {
+		return nil, fmt.Errorf("discovery: cannot resolve %s, discovery is not configured", req.URL.Host)
+	}
+	addr, err := r.Resolve(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = "http"
+	out.URL.Host = addr
+	out.Host = addr
+	return t.next.RoundTrip(out)
+}
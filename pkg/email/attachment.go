@@ -0,0 +1,166 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment represents a file attached to or embedded in an email
+// Attachment 表示邮件附件或内嵌文件
+type Attachment struct {
+	Filename    string // File name shown to the recipient | 收件人看到的文件名
+	ContentType string // MIME type, detected from Filename when empty | MIME 类型，为空时按文件名识别
+	Data        []byte // File content | 文件内容
+	ContentID   string // Content-ID for inline images, referenced as cid:<id> | 内嵌图片的 Content-ID，以 cid:<id> 引用
+}
+
+// inline reports whether the attachment is embedded in the body
+// inline 返回附件是否内嵌在正文中
+func (a Attachment) inline() bool {
+	return a.ContentID != ""
+}
+
+// contentType returns the attachment MIME type
+// contentType 返回附件 MIME 类型
+func (a Attachment) contentType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Filename)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Attach adds an attachment
+// Attach 添加附件
+func (m *Message) Attach(filename string, data []byte) *Message {
+	m.Attachments = append(m.Attachments, Attachment{Filename: filename, Data: data})
+	return m
+}
+
+// AttachFile adds a file from disk as an attachment
+// AttachFile 将磁盘文件添加为附件
+func (m *Message) AttachFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+	m.Attach(filepath.Base(path), data)
+	return nil
+}
+
+// Embed adds an inline image referenced from the HTML body as <img src="cid:{cid}">
+// Embed 添加内嵌图片，在 HTML 正文中以 <img src="cid:{cid}"> 引用
+func (m *Message) Embed(cid, filename string, data []byte) *Message {
+	m.Attachments = append(m.Attachments, Attachment{Filename: filename, Data: data, ContentID: cid})
+	return m
+}
+
+// writeMultipart writes the Content-Type header and multipart body of msg
+// Inline images are grouped with the body in multipart/related; other attachments go in multipart/mixed.
+// writeMultipart 写入 msg 的 Content-Type 头和多部分正文
+// 内嵌图片与正文组成 multipart/related，其他附件放在 multipart/mixed 中。
+func writeMultipart(w *strings.Builder, msg *Message) {
+	var inline, attached []Attachment
+	for _, a := range msg.Attachments {
+		if a.inline() {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	// Body, wrapped with inline images when present | 正文，存在内嵌图片时一起包装
+	var body bytes.Buffer
+	bodyType := textType(msg)
+	if len(inline) > 0 {
+		related := multipart.NewWriter(&body)
+		writeTextPart(related, msg)
+		for _, a := range inline {
+			writeAttachmentPart(related, a)
+		}
+		related.Close()
+		bodyType = "multipart/related; boundary=" + related.Boundary()
+	}
+
+	if len(attached) == 0 {
+		w.WriteString("Content-Type: " + bodyType + "\r\n\r\n")
+		w.Write(body.Bytes())
+		return
+	}
+
+	var out bytes.Buffer
+	mixed := multipart.NewWriter(&out)
+	if len(inline) > 0 {
+		part, _ := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {bodyType}})
+		part.Write(body.Bytes())
+	} else {
+		writeTextPart(mixed, msg)
+	}
+	for _, a := range attached {
+		writeAttachmentPart(mixed, a)
+	}
+	mixed.Close()
+
+	w.WriteString("Content-Type: multipart/mixed; boundary=" + mixed.Boundary() + "\r\n\r\n")
+	w.Write(out.Bytes())
+}
+
+// textType returns the Content-Type of the message body
+// textType 返回邮件正文的 Content-Type
+func textType(msg *Message) string {
+	if msg.IsHTML {
+		return "text/html; charset=UTF-8"
+	}
+	return "text/plain; charset=UTF-8"
+}
+
+// writeTextPart writes the message body as a base64 part
+// writeTextPart 以 base64 部分写入邮件正文
+func writeTextPart(mw *multipart.Writer, msg *Message) {
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {textType(msg)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	writeBase64(part, []byte(msg.Body))
+}
+
+// writeAttachmentPart writes an attachment or inline image part
+// writeAttachmentPart 写入附件或内嵌图片部分
+func writeAttachmentPart(mw *multipart.Writer, a Attachment) {
+	disposition := "attachment"
+	if a.inline() {
+		disposition = "inline"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(a.contentType(), map[string]string{"name": a.Filename})},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.inline() {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	part, _ := mw.CreatePart(header)
+	writeBase64(part, a.Data)
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines (RFC 2045)
+// writeBase64 以每行 76 个字符写入 base64 编码数据（RFC 2045）
+func writeBase64(w io.Writer, data []byte) {
+	const lineLen = 76
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > lineLen {
+		io.WriteString(w, encoded[:lineLen]+"\r\n")
+		encoded = encoded[lineLen:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
@@ -0,0 +1,364 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// QueueConfig represents async sending configuration
+// QueueConfig 表示异步发送配置
+type QueueConfig struct {
+	Enabled       bool          `toml:"enabled"`         // Send through the MQ dispatcher | 通过 MQ 调度器发送
+	Topic         string        `toml:"topic"`           // MQ topic, default email:send | MQ 主题，默认 email:send
+	Group         string        `toml:"group"`           // Consumer group, default email | 消费者组，默认 email
+	MaxRetries    int           `toml:"max_retries"`     // Retries for transient failures, default 5, -1 disables | 暂时性失败的重试次数，默认 5，-1 表示不重试
+	RetryDelay    time.Duration `toml:"retry_delay"`     // First retry delay, doubled per attempt, default 30s | 首次重试延迟，每次翻倍，默认 30s
+	MaxRetryDelay time.Duration `toml:"max_retry_delay"` // Retry delay cap, default 30m | 重试延迟上限，默认 30m
+	StatusTTL     time.Duration `toml:"status_ttl"`      // How long send status is kept, default 7 days | 发送状态保留时长，默认 7 天
+}
+
+// withDefaults fills zero fields with defaults | withDefaults 用默认值填充零值字段
+func (c QueueConfig) withDefaults() QueueConfig {
+	if c.Topic == "" {
+		c.Topic = "email:send"
+	}
+	if c.Group == "" {
+		c.Group = "email"
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 5
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = 30 * time.Second
+	}
+	if c.MaxRetryDelay <= 0 {
+		c.MaxRetryDelay = 30 * time.Minute
+	}
+	if c.StatusTTL <= 0 {
+		c.StatusTTL = 7 * 24 * time.Hour
+	}
+	return c
+}
+
+// Send states | 发送状态
+const (
+	StatusQueued   = "queued"
+	StatusRetrying = "retrying"
+	StatusSent     = "sent"
+	StatusFailed   = "failed"
+)
+
+// ErrStatusNotFound is returned when no status is recorded for an ID
+// ErrStatusNotFound 在 ID 没有发送状态记录时返回
+var ErrStatusNotFound = errors.New("email: status not found")
+
+// ErrNoDispatcher is returned by Enqueue when [email.queue] is not enabled
+// ErrNoDispatcher 在未启用 [email.queue] 时由 Enqueue 返回
+var ErrNoDispatcher = errors.New("email: dispatcher not initialized")
+
+// Status records the delivery state of a queued message
+// Status 记录排队邮件的发送状态
+type Status struct {
+	ID        string    `json:"id"`
+	State     string    `json:"state"`
+	To        []string  `json:"to"`
+	Subject   string    `json:"subject"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StatusStore persists send status
+// StatusStore 持久化发送状态
+type StatusStore interface {
+	Save(ctx context.Context, st *Status, ttl time.Duration) error
+	Load(ctx context.Context, id string) (*Status, error)
+}
+
+// redisStatusStore keeps status as JSON under email:status:{id}
+// redisStatusStore 以 JSON 形式将状态保存在 email:status:{id} 下
+type redisStatusStore struct {
+	client *redis.Client
+}
+
+// NewRedisStatusStore creates a Redis-backed status store
+// NewRedisStatusStore 创建基于 Redis 的状态存储
+func NewRedisStatusStore(client *redis.Client) StatusStore {
+	return &redisStatusStore{client: client}
+}
+
+func (s *redisStatusStore) Save(ctx context.Context, st *Status, ttl time.Duration) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "email:status:"+st.ID, data, ttl)
+}
+
+func (s *redisStatusStore) Load(ctx context.Context, id string) (*Status, error) {
+	data, err := s.client.Get(ctx, "email:status:"+id)
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrStatusNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st Status
+	if err := json.Unmarshal([]byte(data), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// memoryStatusStore keeps status in process, used when Redis is unavailable
+// memoryStatusStore 在进程内保存状态，Redis 不可用时使用
+type memoryStatusStore struct {
+	mu     sync.RWMutex
+	status map[string]Status
+}
+
+// NewMemoryStatusStore creates an in-process status store (entries do not expire)
+// NewMemoryStatusStore 创建进程内状态存储（条目不会过期）
+func NewMemoryStatusStore() StatusStore {
+	return &memoryStatusStore{status: make(map[string]Status)}
+}
+
+func (s *memoryStatusStore) Save(_ context.Context, st *Status, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[st.ID] = *st
+	return nil
+}
+
+func (s *memoryStatusStore) Load(_ context.Context, id string) (*Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.status[id]
+	if !ok {
+		return nil, ErrStatusNotFound
+	}
+	return &st, nil
+}
+
+// job is the queued payload | job 是队列中的消息体
+type job struct {
+	ID      string   `json:"id"`
+	Attempt int      `json:"attempt"`
+	Message *Message `json:"message"`
+}
+
+// Dispatcher sends email asynchronously through the message queue
+// Transient failures are re-published with exponential backoff up to MaxRetries.
+// Dispatcher 通过消息队列异步发送邮件
+// 暂时性失败会以指数退避重新发布，最多重试 MaxRetries 次。
+type Dispatcher struct {
+	sender Sender
+	queue  mq.MQ
+	store  StatusStore
+	cfg    QueueConfig
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcher creates a dispatcher
+// NewDispatcher 创建调度器
+func NewDispatcher(sender Sender, queue mq.MQ, store StatusStore, cfg QueueConfig) *Dispatcher {
+	if store == nil {
+		store = NewMemoryStatusStore()
+	}
+	return &Dispatcher{sender: sender, queue: queue, store: store, cfg: cfg.withDefaults()}
+}
+
+// Enqueue queues a message and returns its ID for status lookups
+// Enqueue 将邮件加入队列并返回用于查询状态的 ID
+func (d *Dispatcher) Enqueue(ctx context.Context, msg *Message) (string, error) {
+	if len(msg.To) == 0 {
+		return "", fmt.Errorf("recipient cannot be empty")
+	}
+	j := job{ID: uuid.NewString(), Message: msg}
+	payload, err := json.Marshal(j)
+	if err != nil {
+		return "", err
+	}
+	d.saveStatus(ctx, &j, StatusQueued, nil)
+	if err := d.queue.Publish(ctx, d.cfg.Topic, payload); err != nil {
+		return "", fmt.Errorf("email: enqueue failed: %w", err)
+	}
+	return j.ID, nil
+}
+
+// Status returns the recorded send status of a queued message
+// Status 返回排队邮件记录的发送状态
+func (d *Dispatcher) Status(ctx context.Context, id string) (*Status, error) {
+	return d.store.Load(ctx, id)
+}
+
+// Run consumes the queue until ctx is cancelled
+// Run 消费队列直到 ctx 被取消
+func (d *Dispatcher) Run(ctx context.Context) error {
+	return d.queue.Consume(ctx, d.cfg.Topic, d.cfg.Group, d.handle)
+}
+
+// Start runs the consumer in the background
+// Start 在后台运行消费者
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		if err := d.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("email: dispatcher stopped: %v", err)
+		}
+	}()
+	log.Printf("email: dispatcher started, topic: %s", d.cfg.Topic)
+}
+
+// Stop stops the background consumer and waits for the in-flight message
+// Stop 停止后台消费者并等待正在处理的邮件
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel = nil
+	d.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	log.Println("email: dispatcher stopped")
+}
+
+// handle sends one queued message; returning nil acknowledges it
+// handle 发送一封排队邮件；返回 nil 表示确认
+func (d *Dispatcher) handle(ctx context.Context, m *mq.Message) error {
+	var j job
+	if err := json.Unmarshal(m.Payload, &j); err != nil || j.Message == nil {
+		log.Printf("email: dropping malformed job %s: %v", m.ID, err)
+		return nil
+	}
+
+	j.Attempt++
+	err := d.sender.SendContext(ctx, j.Message)
+	if err == nil {
+		d.saveStatus(ctx, &j, StatusSent, nil)
+		return nil
+	}
+
+	if !IsTemporary(err) || j.Attempt > d.cfg.MaxRetries {
+		log.Printf("email: job %s failed after %d attempt(s): %v", j.ID, j.Attempt, err)
+		d.saveStatus(ctx, &j, StatusFailed, err)
+		return nil
+	}
+
+	payload, _ := json.Marshal(j)
+	if perr := d.queue.PublishDelay(ctx, d.cfg.Topic, payload, d.backoff(j.Attempt)); perr != nil {
+		// Leave unacknowledged so the queue redelivers it | 不确认，由队列重新投递
+		return perr
+	}
+	d.saveStatus(ctx, &j, StatusRetrying, err)
+	return nil
+}
+
+// backoff returns the delay before retry attempt+1
+// backoff 返回第 attempt+1 次尝试前的延迟
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.RetryDelay
+	for i := 1; i < attempt && delay < d.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxRetryDelay)
+}
+
+// saveStatus records the job state; failures are only logged
+// saveStatus 记录任务状态；失败只记录日志
+func (d *Dispatcher) saveStatus(ctx context.Context, j *job, state string, err error) {
+	st := &Status{
+		ID:        j.ID,
+		State:     state,
+		To:        j.Message.To,
+		Subject:   j.Message.Subject,
+		Attempts:  j.Attempt,
+		UpdatedAt: time.Now(),
+	}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	if serr := d.store.Save(ctx, st, d.cfg.StatusTTL); serr != nil {
+		log.Printf("email: failed to save status of %s: %v", j.ID, serr)
+	}
+}
+
+var defaultDispatcher *Dispatcher // Default dispatcher | 默认调度器
+
+// initDispatcher creates the default dispatcher on the default MQ and Redis
+// initDispatcher 基于默认 MQ 和 Redis 创建默认调度器
+func initDispatcher(sender Sender, cfg QueueConfig) error {
+	queue := mq.Get()
+	if queue == nil {
+		return errors.New("email: queue enabled but mq is not initialized")
+	}
+	var store StatusStore
+	if client := redis.Get(); client != nil {
+		store = NewRedisStatusStore(client)
+	}
+	defaultDispatcher = NewDispatcher(sender, queue, store, cfg)
+	return nil
+}
+
+// GetDispatcher returns the default dispatcher, nil when the queue is disabled
+// GetDispatcher 返回默认调度器，未启用队列时为 nil
+func GetDispatcher() *Dispatcher {
+	return defaultDispatcher
+}
+
+// Enqueue queues a message on the default dispatcher
+// Enqueue 将邮件加入默认调度器的队列
+func Enqueue(ctx context.Context, msg *Message) (string, error) {
+	if defaultDispatcher == nil {
+		return "", ErrNoDispatcher
+	}
+	return defaultDispatcher.Enqueue(ctx, msg)
+}
+
+// GetStatus returns the send status of a message queued on the default dispatcher
+// GetStatus 返回默认调度器中排队邮件的发送状态
+func GetStatus(ctx context.Context, id string) (*Status, error) {
+	if defaultDispatcher == nil {
+		return nil, ErrNoDispatcher
+	}
+	return defaultDispatcher.Status(ctx, id)
+}
+
+// StartDispatcher starts the default dispatcher if configured
+// StartDispatcher 启动已配置的默认调度器
+func StartDispatcher() {
+	if defaultDispatcher != nil {
+		defaultDispatcher.Start()
+	}
+}
+
+// StopDispatcher stops the default dispatcher if running
+// StopDispatcher 停止正在运行的默认调度器
+func StopDispatcher() {
+	if defaultDispatcher != nil {
+		defaultDispatcher.Stop()
+	}
+}
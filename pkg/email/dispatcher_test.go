@@ -0,0 +1,259 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/mq"
+)
+
+// fakeMQ records published payloads and lets tests deliver them by hand
+type fakeMQ struct {
+	mu        sync.Mutex
+	published [][]byte
+	delays    []time.Duration
+}
+
+func (q *fakeMQ) Publish(_ context.Context, _ string, payload []byte) error {
+	return q.PublishDelay(context.Background(), "", payload, 0)
+}
+
+func (q *fakeMQ) PublishDelay(_ context.Context, _ string, payload []byte, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.published = append(q.published, payload)
+	q.delays = append(q.delays, delay)
+	return nil
+}
+
+func (q *fakeMQ) Consume(ctx context.Context, _, _ string, _ mq.Handler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (q *fakeMQ) Ack(context.Context, string, string, string) error { return nil }
+func (q *fakeMQ) Close() error                                      { return nil }
+func (q *fakeMQ) GetRaw() any                                       { return nil }
+
+// pop removes and returns the oldest published payload
+func (q *fakeMQ) pop(t *testing.T) *mq.Message {
+	t.Helper()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.published) == 0 {
+		t.Fatal("nothing published")
+	}
+	payload := q.published[0]
+	q.published = q.published[1:]
+	return &mq.Message{ID: "1", Payload: payload}
+}
+
+type fakeSender struct {
+	errs  []error
+	calls int
+}
+
+func (s *fakeSender) SendContext(context.Context, *Message) error {
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func TestDispatcher_RetryThenSent(t *testing.T) {
+	queue := &fakeMQ{}
+	sender := &fakeSender{errs: []error{&textproto.Error{Code: 421, Msg: "try later"}}}
+	d := NewDispatcher(sender, queue, nil, QueueConfig{RetryDelay: time.Second})
+	ctx := context.Background()
+
+	id, err := d.Enqueue(ctx, &Message{To: []string{"a@example.com"}, Subject: "Hi", Body: "x"})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if st, _ := d.Status(ctx, id); st.State != StatusQueued {
+		t.Errorf("state = %s, want queued", st.State)
+	}
+
+	// First attempt fails transiently and is re-published with a delay
+	if err := d.handle(ctx, queue.pop(t)); err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	st, _ := d.Status(ctx, id)
+	if st.State != StatusRetrying || st.Attempts != 1 || st.Error == "" {
+		t.Errorf("unexpected status after failure: %+v", st)
+	}
+	if queue.delays[len(queue.delays)-1] != time.Second {
+		t.Errorf("retry delay = %v, want 1s", queue.delays[len(queue.delays)-1])
+	}
+
+	// Second attempt succeeds
+	if err := d.handle(ctx, queue.pop(t)); err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	st, _ = d.Status(ctx, id)
+	if st.State != StatusSent || st.Attempts != 2 {
+		t.Errorf("unexpected status after success: %+v", st)
+	}
+}
+
+func TestDispatcher_PermanentFailure(t *testing.T) {
+	queue := &fakeMQ{}
+	sender := &fakeSender{errs: []error{&textproto.Error{Code: 550, Msg: "no such user"}}}
+	d := NewDispatcher(sender, queue, nil, QueueConfig{})
+	ctx := context.Background()
+
+	id, _ := d.Enqueue(ctx, &Message{To: []string{"a@example.com"}})
+	d.handle(ctx, queue.pop(t))
+
+	st, _ := d.Status(ctx, id)
+	if st.State != StatusFailed {
+		t.Errorf("state = %s, want failed", st.State)
+	}
+	if len(queue.published) != 0 {
+		t.Error("permanent failure should not be retried")
+	}
+}
+
+func TestDispatcher_MaxRetries(t *testing.T) {
+	queue := &fakeMQ{}
+	temp := &temporaryError{errors.New("unavailable")}
+	sender := &fakeSender{errs: []error{temp, temp, temp}}
+	d := NewDispatcher(sender, queue, nil, QueueConfig{MaxRetries: 2})
+	ctx := context.Background()
+
+	id, _ := d.Enqueue(ctx, &Message{To: []string{"a@example.com"}})
+	for i := 0; i < 3; i++ {
+		d.handle(ctx, queue.pop(t))
+	}
+
+	st, _ := d.Status(ctx, id)
+	if st.State != StatusFailed || st.Attempts != 3 {
+		t.Errorf("unexpected status: %+v", st)
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(&fakeSender{}, &fakeMQ{}, nil, QueueConfig{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestSendGridTransport(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	client := New(Config{Driver: DriverSendGrid, APIKey: "key", Endpoint: srv.URL, From: "from@example.com"})
+	msg := &Message{To: []string{"a@example.com"}, Subject: "Hi", Body: "<p>x</p>", IsHTML: true}
+	msg.Attach("a.txt", []byte("hello"))
+	if err := client.Send(msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if payload["subject"] != "Hi" {
+		t.Errorf("subject = %v", payload["subject"])
+	}
+	content := payload["content"].([]any)[0].(map[string]any)
+	if content["type"] != "text/html" {
+		t.Errorf("content type = %v", content["type"])
+	}
+	if len(payload["attachments"].([]any)) != 1 {
+		t.Error("missing attachment")
+	}
+}
+
+func TestMailgunTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mg.example.com/messages.mime" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "api" || pass != "key" {
+			t.Errorf("unexpected auth %s:%s", user, pass)
+		}
+		if to := r.FormValue("to"); to != "a@example.com,b@example.com" {
+			t.Errorf("to = %q", to)
+		}
+		file, _, err := r.FormFile("message")
+		if err != nil {
+			t.Fatalf("missing message: %v", err)
+		}
+		raw, _ := io.ReadAll(file)
+		if !strings.Contains(string(raw), "Subject: Hi") || strings.Contains(string(raw), "b@example.com") {
+			t.Errorf("unexpected MIME message: %s", raw)
+		}
+	}))
+	defer srv.Close()
+
+	client := New(Config{Driver: DriverMailgun, APIKey: "key", Domain: "mg.example.com", Endpoint: srv.URL, From: "from@example.com"})
+	msg := &Message{To: []string{"a@example.com"}, Bcc: []string{"b@example.com"}, Subject: "Hi", Body: "x"}
+	if err := client.Send(msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+}
+
+func TestSESTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/email/outbound-emails" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "AKID/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") {
+			t.Errorf("unexpected Authorization %q", auth)
+		}
+		var body struct {
+			Content struct{ Raw struct{ Data []byte } }
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !strings.Contains(string(body.Content.Raw.Data), "Subject: Hi") {
+			t.Errorf("unexpected raw message: %s", body.Content.Raw.Data)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := New(Config{Driver: DriverSES, Region: "eu-west-1", AccessKey: "AKID", SecretKey: "secret", Endpoint: srv.URL, From: "from@example.com"})
+	err := client.Send(&Message{To: []string{"a@example.com"}, Subject: "Hi", Body: "x"})
+	if err == nil || !IsTemporary(err) {
+		t.Errorf("expected temporary error for 503, got %v", err)
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{&textproto.Error{Code: 451}, true},
+		{&textproto.Error{Code: 554}, false},
+		{&temporaryError{errors.New("429")}, true},
+		{context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		if got := IsTemporary(tt.err); got != tt.want {
+			t.Errorf("IsTemporary(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
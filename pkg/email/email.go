@@ -3,29 +3,65 @@
 package email
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"log"
+	"mime"
+	"net/http"
 	"net/smtp"
 	"strings"
 	"time"
 )
 
+// Supported delivery drivers | 支持的发送驱动
+const (
+	DriverSMTP     = "smtp"
+	DriverSES      = "ses"
+	DriverSendGrid = "sendgrid"
+	DriverMailgun  = "mailgun"
+)
+
 // Config represents email configuration
 // Config 表示邮件配置
 type Config struct {
-	Host     string // SMTP server address | SMTP 服务器地址
-	Port     int    // SMTP port | SMTP 端口
-	Username string // Username | 用户名
-	Password string // Password/authorization code | 密码/授权码
-	From     string // Sender address | 发件人地址
-	FromName string // Sender name | 发件人名称
-	UseTLS   bool   // Whether to use TLS | 是否使用 TLS
+	Driver   string `toml:"driver"`    // smtp (default), ses, sendgrid or mailgun | smtp（默认）、ses、sendgrid 或 mailgun
+	Host     string `toml:"host"`      // SMTP server address | SMTP 服务器地址
+	Port     int    `toml:"port"`      // SMTP port | SMTP 端口
+	Username string `toml:"username"`  // Username | 用户名
+	Password string `toml:"password"`  // Password/authorization code | 密码/授权码
+	From     string `toml:"from"`      // Sender address | 发件人地址
+	FromName string `toml:"from_name"` // Sender name | 发件人名称
+	UseTLS   bool   `toml:"use_tls"`   // Whether to use TLS | 是否使用 TLS
+
+	APIKey    string        `toml:"api_key"`    // SendGrid / Mailgun API key | SendGrid / Mailgun API 密钥
+	Domain    string        `toml:"domain"`     // Mailgun sending domain | Mailgun 发信域名
+	Region    string        `toml:"region"`     // SES region, default us-east-1 | SES 区域，默认 us-east-1
+	AccessKey string        `toml:"access_key"` // SES access key ID | SES 访问密钥 ID
+	SecretKey string        `toml:"secret_key"` // SES secret access key | SES 私有访问密钥
+	Endpoint  string        `toml:"endpoint"`   // API base URL override, e.g. https://api.eu.mailgun.net | API 基础地址覆盖
+	Timeout   time.Duration `toml:"timeout"`    // API request timeout, default 10s | API 请求超时，默认 10s
+
+	Queue QueueConfig `toml:"queue"` // Async sending queue | 异步发送队列
 }
 
 // Client represents an email client
 // Client 表示邮件客户端
 type Client struct {
-	config Config // Email configuration | 邮件配置
+	config    Config    // Email configuration | 邮件配置
+	transport transport // Delivery driver, nil means SMTP | 发送驱动，nil 表示 SMTP
+}
+
+// Sender is implemented by Client and used by the dispatcher
+// Sender 由 Client 实现，供调度器使用
+type Sender interface {
+	SendContext(ctx context.Context, msg *Message) error
+}
+
+// transport delivers a message through an API provider
+// transport 通过 API 服务商发送邮件
+type transport interface {
+	send(ctx context.Context, c *Client, msg *Message) error
 }
 
 // Message represents an email message
@@ -37,20 +73,87 @@ type Message struct {
 	Subject string   // Subject | 主题
 	Body    string   // Body (HTML or plain text) | 正文（HTML 或纯文本）
 	IsHTML  bool     // Whether HTML format | 是否 HTML 格式
+
+	Attachments []Attachment // Attachments and inline images | 附件和内嵌图片
 }
 
-// New creates an email client
-// New 创建邮件客户端
+// New creates an email client for the configured driver
+// New 根据配置的驱动创建邮件客户端
 func New(cfg Config) *Client {
-	return &Client{config: cfg}
+	c := &Client{config: cfg}
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+	if httpClient.Timeout <= 0 {
+		httpClient.Timeout = 10 * time.Second
+	}
+	switch cfg.Driver {
+	case DriverSES:
+		c.transport = &sesTransport{http: httpClient}
+	case DriverSendGrid:
+		c.transport = &sendGridTransport{http: httpClient}
+	case DriverMailgun:
+		c.transport = &mailgunTransport{http: httpClient}
+	}
+	return c
+}
+
+var defaultClient *Client // Default email client | 默认邮件客户端
+
+// Init initializes the default client and, when [email.queue] is enabled, the dispatcher
+// Init 初始化默认客户端，启用 [email.queue] 时同时初始化调度器
+func Init(cfg Config) error {
+	switch cfg.Driver {
+	case "", DriverSMTP, DriverSES, DriverSendGrid, DriverMailgun:
+	default:
+		return fmt.Errorf("email: unsupported driver: %s", cfg.Driver)
+	}
+	defaultClient = New(cfg)
+	if cfg.Queue.Enabled {
+		if err := initDispatcher(defaultClient, cfg.Queue); err != nil {
+			return err
+		}
+	}
+	log.Printf("email: initialized, driver: %s", cfg.driver())
+	return nil
+}
+
+// Get returns the default email client
+// Get 返回默认邮件客户端
+func Get() *Client {
+	return defaultClient
+}
+
+// Enabled reports whether email sending is configured
+// Enabled 返回是否配置了邮件发送
+func (cfg Config) Enabled() bool {
+	return cfg.Host != "" || cfg.driver() != DriverSMTP
+}
+
+// driver returns the configured driver name | driver 返回配置的驱动名称
+func (cfg Config) driver() string {
+	if cfg.Driver == "" {
+		return DriverSMTP
+	}
+	return cfg.Driver
 }
 
 // Send sends an email
 // Send 发送邮件
 func (c *Client) Send(msg *Message) error {
+	return c.SendContext(context.Background(), msg)
+}
+
+// SendContext sends an email, honoring ctx for API drivers
+// SendContext 发送邮件，API 驱动会遵循 ctx
+func (c *Client) SendContext(ctx context.Context, msg *Message) error {
 	if len(msg.To) == 0 {
 		return fmt.Errorf("recipient cannot be empty")
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.transport != nil {
+		return c.transport.send(ctx, c, msg)
+	}
 
 	// Build email content | 构建邮件内容
 	content := c.buildMessage(msg)
@@ -87,6 +190,26 @@ func (c *Client) SendHTML(to, subject, htmlBody string) error {
 	})
 }
 
+// SendTemplate renders a template from the default registry and sends it as HTML
+// The subject comes from the template's {{define "subject"}} block.
+// SendTemplate 使用默认注册表渲染模板并以 HTML 发送，主题取自模板的 {{define "subject"}} 块
+func (c *Client) SendTemplate(to []string, name string, data any, attachments ...Attachment) error {
+	subject, body, err := defaultRegistry.RenderMessage(name, data)
+	if err != nil {
+		return err
+	}
+	if subject == "" {
+		return fmt.Errorf("email template %s has no subject block", name)
+	}
+	return c.Send(&Message{
+		To:          to,
+		Subject:     subject,
+		Body:        body,
+		IsHTML:      true,
+		Attachments: attachments,
+	})
+}
+
 // buildMessage builds email content
 // buildMessage 构建邮件内容
 func (c *Client) buildMessage(msg *Message) []byte {
@@ -94,7 +217,7 @@ func (c *Client) buildMessage(msg *Message) []byte {
 
 	// From | 发件人
 	if c.config.FromName != "" {
-		builder.WriteString(fmt.Sprintf("From: %s <%s>\r\n", c.config.FromName, c.config.From))
+		builder.WriteString(fmt.Sprintf("From: %s <%s>\r\n", mime.BEncoding.Encode("UTF-8", c.config.FromName), c.config.From))
 	} else {
 		builder.WriteString(fmt.Sprintf("From: %s\r\n", c.config.From))
 	}
@@ -108,13 +231,18 @@ func (c *Client) buildMessage(msg *Message) []byte {
 	}
 
 	// Subject | 主题
-	builder.WriteString(fmt.Sprintf("Subject: %s\r\n", msg.Subject))
+	builder.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject)))
 
 	// Date | 日期
 	builder.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
 
 	// MIME | MIME 类型
 	builder.WriteString("MIME-Version: 1.0\r\n")
+	if len(msg.Attachments) > 0 {
+		// Multipart body with attachments | 带附件的多部分正文
+		writeMultipart(&builder, msg)
+		return []byte(builder.String())
+	}
 	if msg.IsHTML {
 		builder.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
 	} else {
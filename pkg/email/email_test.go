@@ -1,6 +1,12 @@
 package email
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
 	"strings"
 	"testing"
 )
@@ -134,3 +140,120 @@ func TestSendEmptyRecipients(t *testing.T) {
 		t.Errorf("Expected 'recipient cannot be empty' error, got: %v", err)
 	}
 }
+
+func TestBuildMessageWithAttachments(t *testing.T) {
+	client := New(Config{From: "sender@example.com"})
+
+	msg := &Message{
+		To:      []string{"recipient@example.com"},
+		Subject: "报告",
+		Body:    `<p>Hi</p><img src="cid:logo">`,
+		IsHTML:  true,
+	}
+	msg.Embed("logo", "logo.png", []byte("png-bytes"))
+	msg.Attach("report.csv", []byte("a,b\n1,2\n"))
+
+	m, err := mail.ReadMessage(bytes.NewReader(client.buildMessage(msg)))
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject")); subject != "报告" {
+		t.Errorf("Subject = %q", subject)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %s, want multipart/mixed", mediaType)
+	}
+	mixed := multipart.NewReader(m.Body, params["boundary"])
+
+	// First part: related body with the inline image
+	part, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart failed: %v", err)
+	}
+	mediaType, params, _ = mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if mediaType != "multipart/related" {
+		t.Fatalf("first part = %s, want multipart/related", mediaType)
+	}
+	related := multipart.NewReader(part, params["boundary"])
+	body, _ := related.NextPart()
+	if got := readBase64(t, body); got != msg.Body {
+		t.Errorf("body = %q", got)
+	}
+	img, _ := related.NextPart()
+	if img.Header.Get("Content-ID") != "<logo>" || img.Header.Get("Content-Type") != `image/png; name=logo.png` {
+		t.Errorf("unexpected inline image headers: %v", img.Header)
+	}
+	if got := readBase64(t, img); got != "png-bytes" {
+		t.Errorf("inline image = %q", got)
+	}
+
+	// Second part: attachment
+	att, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart failed: %v", err)
+	}
+	if att.FileName() != "report.csv" {
+		t.Errorf("attachment filename = %q", att.FileName())
+	}
+	if got := readBase64(t, att); got != "a,b\n1,2\n" {
+		t.Errorf("attachment = %q", got)
+	}
+}
+
+func readBase64(t *testing.T, r io.Reader) string {
+	t.Helper()
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	return string(data)
+}
+
+func TestRegistryLayout(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("orphan", "x", "missing"); err == nil {
+		t.Error("expected error for unregistered layout")
+	}
+
+	if err := r.RegisterLayout("base", `<html><body>{{template "content" .}}</body></html>`); err != nil {
+		t.Fatalf("RegisterLayout failed: %v", err)
+	}
+	if err := r.Register("order", `{{define "subject"}}Order {{.ID}}{{end}}<p>Order {{.ID}} for {{.Name}}</p>`, "base"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	subject, body, err := r.RenderMessage("order", map[string]any{"ID": 42, "Name": "<b>Bob</b>"})
+	if err != nil {
+		t.Fatalf("RenderMessage failed: %v", err)
+	}
+	if subject != "Order 42" {
+		t.Errorf("subject = %q", subject)
+	}
+	want := `<html><body><p>Order 42 for &lt;b&gt;Bob&lt;/b&gt;</p></body></html>`
+	if body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	if _, err := r.Render("unknown", nil); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestRenderPredefined(t *testing.T) {
+	subject, body, err := DefaultRegistry().RenderMessage("welcome", WelcomeData{SiteName: "Crab", Nickname: "Bob"})
+	if err != nil {
+		t.Fatalf("RenderMessage failed: %v", err)
+	}
+	if subject != "欢迎加入 Crab" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Bob") {
+		t.Error("body missing nickname")
+	}
+
+	if out, err := RenderTemplate("unknown", nil); out != "" || err != nil {
+		t.Errorf("RenderTemplate(unknown) = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,232 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// temporaryError marks a failure worth retrying | temporaryError 标记值得重试的失败
+type temporaryError struct {
+	err error
+}
+
+func (e *temporaryError) Error() string { return e.err.Error() }
+func (e *temporaryError) Unwrap() error { return e.err }
+
+// IsTemporary reports whether a send failure is transient and may succeed on retry:
+// network errors, SMTP 4xx replies and provider 429/5xx responses
+// IsTemporary 判断发送失败是否为暂时性错误、重试后可能成功：网络错误、SMTP 4xx 响应和服务商 429/5xx 响应
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	var tempErr *temporaryError
+	if errors.As(err, &tempErr) {
+		return true
+	}
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// doAPI sends an API request and classifies the response
+// doAPI 发送 API 请求并对响应进行分类
+func doAPI(client *http.Client, req *http.Request, provider string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return &temporaryError{fmt.Errorf("%s request failed: %w", provider, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &temporaryError{err}
+	}
+	return err
+}
+
+// apiBase returns the configured endpoint or the provider default
+// apiBase 返回配置的地址或服务商默认地址
+func (c *Client) apiBase(def string) string {
+	if c.config.Endpoint != "" {
+		return strings.TrimSuffix(c.config.Endpoint, "/")
+	}
+	return def
+}
+
+// ============ SendGrid ============
+
+// sendGridTransport sends through the SendGrid v3 mail API
+// sendGridTransport 通过 SendGrid v3 邮件 API 发送
+type sendGridTransport struct {
+	http *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+func sendGridAddresses(list []string) []sendGridAddress {
+	out := make([]sendGridAddress, 0, len(list))
+	for _, addr := range list {
+		out = append(out, sendGridAddress{Email: addr})
+	}
+	return out
+}
+
+func (t *sendGridTransport) send(ctx context.Context, c *Client, msg *Message) error {
+	personalization := map[string]any{"to": sendGridAddresses(msg.To)}
+	if len(msg.Cc) > 0 {
+		personalization["cc"] = sendGridAddresses(msg.Cc)
+	}
+	if len(msg.Bcc) > 0 {
+		personalization["bcc"] = sendGridAddresses(msg.Bcc)
+	}
+
+	payload := map[string]any{
+		"personalizations": []any{personalization},
+		"from":             sendGridAddress{Email: c.config.From, Name: c.config.FromName},
+		"subject":          msg.Subject,
+		"content":          []map[string]string{{"type": strings.SplitN(textType(msg), ";", 2)[0], "value": msg.Body}},
+	}
+	if len(msg.Attachments) > 0 {
+		attachments := make([]sendGridAttachment, 0, len(msg.Attachments))
+		for _, a := range msg.Attachments {
+			disposition := "attachment"
+			if a.inline() {
+				disposition = "inline"
+			}
+			attachments = append(attachments, sendGridAttachment{
+				Content:     base64.StdEncoding.EncodeToString(a.Data),
+				Type:        a.contentType(),
+				Filename:    a.Filename,
+				Disposition: disposition,
+				ContentID:   a.ContentID,
+			})
+		}
+		payload["attachments"] = attachments
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiBase("https://api.sendgrid.com")+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	return doAPI(t.http, req, "sendgrid")
+}
+
+// ============ Mailgun ============
+
+// mailgunTransport sends the MIME message through the Mailgun messages.mime API
+// mailgunTransport 通过 Mailgun messages.mime API 发送 MIME 邮件
+type mailgunTransport struct {
+	http *http.Client
+}
+
+func (t *mailgunTransport) send(ctx context.Context, c *Client, msg *Message) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	recipients := append(append(append([]string{}, msg.To...), msg.Cc...), msg.Bcc...)
+	form.WriteField("to", strings.Join(recipients, ","))
+	part, err := form.CreateFormFile("message", "message.mime")
+	if err != nil {
+		return err
+	}
+	part.Write(c.buildMessage(msg))
+	form.Close()
+
+	url := fmt.Sprintf("%s/v3/%s/messages.mime", c.apiBase("https://api.mailgun.net"), c.config.Domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", c.config.APIKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return doAPI(t.http, req, "mailgun")
+}
+
+// ============ Amazon SES ============
+
+// sesTransport sends the MIME message through the SES v2 SendEmail API (SigV4 signed)
+// sesTransport 通过 SES v2 SendEmail API 发送 MIME 邮件（SigV4 签名）
+type sesTransport struct {
+	http *http.Client
+}
+
+func (t *sesTransport) send(ctx context.Context, c *Client, msg *Message) error {
+	region := c.config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	destination := map[string][]string{"ToAddresses": msg.To}
+	if len(msg.Cc) > 0 {
+		destination["CcAddresses"] = msg.Cc
+	}
+	if len(msg.Bcc) > 0 {
+		destination["BccAddresses"] = msg.Bcc
+	}
+	body, err := json.Marshal(map[string]any{
+		"FromEmailAddress": c.config.From,
+		"Destination":      destination,
+		"Content":          map[string]any{"Raw": map[string][]byte{"Data": c.buildMessage(msg)}},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := c.apiBase(fmt.Sprintf("https://email.%s.amazonaws.com", region)) + "/v2/email/outbound-emails"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	hash := sha256.Sum256(body)
+	creds := aws.Credentials{AccessKeyID: c.config.AccessKey, SecretAccessKey: c.config.SecretKey}
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "ses", region, time.Now()); err != nil {
+		return fmt.Errorf("ses signing failed: %w", err)
+	}
+	return doAPI(t.http, req, "ses")
+}
@@ -2,13 +2,194 @@ package email
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
 )
 
+// Template blocks recognised by the registry
+// 注册表识别的模板块
+const (
+	ContentBlock = "content" // Body rendered inside a layout via {{template "content" .}} | 在布局中通过 {{template "content" .}} 渲染的正文
+	SubjectBlock = "subject" // Optional subject defined with {{define "subject"}} | 通过 {{define "subject"}} 定义的可选主题
+)
+
+// Registry holds named html/template email templates and layouts
+// Registry 保存命名的 html/template 邮件模板和布局
+type Registry struct {
+	mu        sync.RWMutex
+	funcs     template.FuncMap
+	layouts   map[string]*template.Template
+	templates map[string]*template.Template
+}
+
+// NewRegistry creates an empty template registry
+// NewRegistry 创建空的模板注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		funcs:     template.FuncMap{},
+		layouts:   make(map[string]*template.Template),
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// Funcs adds template functions; call before registering templates that use them
+// Funcs 添加模板函数；需在注册使用它们的模板之前调用
+func (r *Registry) Funcs(funcs template.FuncMap) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, v := range funcs {
+		r.funcs[k] = v
+	}
+	return r
+}
+
+// RegisterLayout registers a layout that renders templates with {{template "content" .}}
+// RegisterLayout 注册布局，布局通过 {{template "content" .}} 渲染模板
+func (r *Registry) RegisterLayout(name, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tpl, err := template.New(name).Funcs(r.funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse email layout %s: %w", name, err)
+	}
+	r.layouts[name] = tpl
+	return nil
+}
+
+// Register registers a template, optionally rendered inside a previously registered layout
+// Register 注册模板，可选择在已注册的布局中渲染
+func (r *Registry) Register(name, text string, layout ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		tpl *template.Template
+		err error
+	)
+	if len(layout) > 0 && layout[0] != "" {
+		base, ok := r.layouts[layout[0]]
+		if !ok {
+			return fmt.Errorf("email layout %s not registered", layout[0])
+		}
+		if tpl, err = base.Clone(); err == nil {
+			_, err = tpl.New(ContentBlock).Parse(text)
+		}
+	} else {
+		tpl, err = template.New(name).Funcs(r.funcs).Parse(text)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse email template %s: %w", name, err)
+	}
+	r.templates[name] = tpl
+	return nil
+}
+
+// ParseFS registers every file matching pattern, named after its base name without extension
+// ParseFS 注册所有匹配 pattern 的文件，以去掉扩展名的文件名命名
+func (r *Registry) ParseFS(fsys fs.FS, pattern string, layout ...string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		base := path.Base(file)
+		if err := r.Register(strings.TrimSuffix(base, path.Ext(base)), string(data), layout...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Has reports whether a template is registered
+// Has 返回模板是否已注册
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.templates[name]
+	return ok
+}
+
+// Render renders a template (inside its layout) to HTML
+// Render 将模板（在其布局中）渲染为 HTML
+func (r *Registry) Render(name string, data any) (string, error) {
+	_, body, err := r.RenderMessage(name, data)
+	return body, err
+}
+
+// RenderMessage renders a template's subject block and HTML body
+// RenderMessage 渲染模板的主题块和 HTML 正文
+func (r *Registry) RenderMessage(name string, data any) (subject, body string, err error) {
+	r.mu.RLock()
+	tpl, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", "", fmt.Errorf("email template %s not registered", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render email template %s: %w", name, err)
+	}
+	body = buf.String()
+
+	if sub := tpl.Lookup(SubjectBlock); sub != nil {
+		buf.Reset()
+		if err := sub.Execute(&buf, data); err != nil {
+			return "", "", fmt.Errorf("failed to render email subject %s: %w", name, err)
+		}
+		subject = strings.TrimSpace(buf.String())
+	}
+	return subject, body, nil
+}
+
+// defaultRegistry holds the predefined templates | defaultRegistry 保存预定义模板
+var defaultRegistry = NewRegistry()
+
+func init() {
+	for name, text := range templates {
+		if err := defaultRegistry.Register(name, text); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// DefaultRegistry returns the package registry used by Render and Client.SendTemplate
+// DefaultRegistry 返回 Render 和 Client.SendTemplate 使用的包级注册表
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisterLayout registers a layout in the default registry
+// RegisterLayout 在默认注册表中注册布局
+func RegisterLayout(name, text string) error {
+	return defaultRegistry.RegisterLayout(name, text)
+}
+
+// Register registers a template in the default registry
+// Register 在默认注册表中注册模板
+func Register(name, text string, layout ...string) error {
+	return defaultRegistry.Register(name, text, layout...)
+}
+
+// Render renders a template from the default registry
+// Render 使用默认注册表渲染模板
+func Render(name string, data any) (string, error) {
+	return defaultRegistry.Render(name, data)
+}
+
 // Predefined templates
 var templates = map[string]string{
 	// Verification code template
-	"verify_code": `
+	"verify_code": `{{define "subject"}}验证码{{end}}
 <!DOCTYPE html>
 <html>
 <head><meta charset="UTF-8"></head>
@@ -22,7 +203,7 @@ var templates = map[string]string{
 </html>`,
 
 	// Password reset template
-	"password_reset": `
+	"password_reset": `{{define "subject"}}密码重置{{end}}
 <!DOCTYPE html>
 <html>
 <head><meta charset="UTF-8"></head>
@@ -36,7 +217,7 @@ var templates = map[string]string{
 </html>`,
 
 	// Welcome email template
-	"welcome": `
+	"welcome": `{{define "subject"}}欢迎加入 {{.SiteName}}{{end}}
 <!DOCTYPE html>
 <html>
 <head><meta charset="UTF-8"></head>
@@ -48,24 +229,15 @@ var templates = map[string]string{
 </html>`,
 }
 
-// RenderTemplate renders a template
+// RenderTemplate renders a template, returning an empty string for unknown names
+// RenderTemplate 渲染模板，未知名称返回空字符串
+//
+// Deprecated: use Render, which reports unknown templates as errors.
 func RenderTemplate(name string, data any) (string, error) {
-	tplStr, ok := templates[name]
-	if !ok {
+	if !defaultRegistry.Has(name) {
 		return "", nil
 	}
-
-	tpl, err := template.New(name).Parse(tplStr)
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	if err := tpl.Execute(&buf, data); err != nil {
-		return "", err
-	}
-
-	return buf.String(), nil
+	return defaultRegistry.Render(name, data)
 }
 
 // VerifyCodeData verification code template data
@@ -0,0 +1,320 @@
+// Package featureflag evaluates boolean and percentage-rollout feature flags
+// A flag is on for a subject when it is enabled and the subject is targeted by
+// user or org, or falls inside the rollout percentage. Flags are loaded from a
+// Store (Redis or database) and cached for cache_ttl, so changes made on other
+// nodes take effect within that window.
+// Package featureflag 评估布尔和按百分比灰度的功能开关
+// 开关启用时，若主体被按用户或组织定向，或落在灰度百分比内，则对该主体开启。
+// 开关从 Store（Redis 或数据库）加载并缓存 cache_ttl，其他节点的修改在该时间内生效。
+//
+// Usage | 用法:
+//
+//	if featureflag.Enabled(ctx, "new_checkout") {
+//		return newCheckout(ctx, order)
+//	}
+//
+//	featureflag.Get().Save(ctx, &featureflag.Flag{Key: "new_checkout", Enabled: true, Rollout: 10})
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store backends | 存储后端
+const (
+	StoreRedis = "redis"
+	StoreDB    = "db"
+)
+
+// Config represents feature flag configuration
+// Config 表示功能开关配置
+type Config struct {
+	Store      string        `toml:"store"`      // "redis" (default) or "db" | "redis"（默认）或 "db"
+	CacheTTL   time.Duration `toml:"cache_ttl"`  // How long flags are cached (default 30s) | 开关缓存时长（默认 30 秒）
+	AdminPath  string        `toml:"admin_path"` // CRUD endpoints, empty disables | 管理接口路径，为空时禁用
+	Permission string        `toml:"permission"` // Permission required by the CRUD endpoints (default "featureflag:write") | 管理接口要求的权限（默认 "featureflag:write"）
+}
+
+// Flag is one feature flag
+// Flag 表示一个功能开关
+type Flag struct {
+	Key         string    `json:"key" xorm:"pk varchar(100) 'key'"`              // Unique key, e.g. "new_checkout" | 唯一键，例如 "new_checkout"
+	Description string    `json:"description" xorm:"varchar(255) 'description'"` // Description | 描述
+	Enabled     bool      `json:"enabled" xorm:"notnull 'enabled'"`              // Master switch, false turns the flag off for everyone | 总开关，false 时对所有人关闭
+	Rollout     int       `json:"rollout" xorm:"notnull default 0 'rollout'"`    // Percentage of subjects that get the flag (0-100) | 开启的主体百分比（0-100）
+	Users       []string  `json:"users" xorm:"json 'users'"`                     // User IDs that always get the flag | 始终开启的用户 ID
+	Orgs        []string  `json:"orgs" xorm:"json 'orgs'"`                       // Org IDs that always get the flag | 始终开启的组织 ID
+	UpdatedAt   time.Time `json:"updated_at" xorm:"updated 'updated_at'"`        // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (f *Flag) TableName() string {
+	return "feature_flag"
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{new(Flag)}
+}
+
+// Validate checks the key and rollout range
+// Validate 检查键和灰度范围
+func (f *Flag) Validate() error {
+	if f.Key == "" {
+		return errors.New("featureflag: key is required")
+	}
+	if f.Rollout < 0 || f.Rollout > 100 {
+		return fmt.Errorf("featureflag: rollout %d out of range 0-100", f.Rollout)
+	}
+	return nil
+}
+
+// Subject is who a flag is evaluated for; empty fields are not targeted
+// Subject 表示评估开关的主体；空字段不参与定向
+type Subject struct {
+	UserID string
+	OrgID  string
+}
+
+// EnabledFor reports whether the flag is on for s
+// Percentage rollout hashes the key with the user (or org) ID, so a subject keeps
+// its answer as the percentage grows; subjects without an ID only get 100%.
+// EnabledFor 判断开关对 s 是否开启
+// 百分比灰度对键和用户（或组织）ID 取哈希，因此百分比增大时主体的结果保持不变；没有 ID 的主体仅在 100% 时开启。
+func (f *Flag) EnabledFor(s Subject) bool {
+	if f == nil || !f.Enabled {
+		return false
+	}
+	if s.UserID != "" && slices.Contains(f.Users, s.UserID) {
+		return true
+	}
+	if s.OrgID != "" && slices.Contains(f.Orgs, s.OrgID) {
+		return true
+	}
+	if f.Rollout >= 100 {
+		return true
+	}
+	if f.Rollout <= 0 {
+		return false
+	}
+	id := s.UserID
+	if id == "" {
+		id = s.OrgID
+	}
+	if id == "" {
+		return false
+	}
+	return bucket(f.Key, id) < uint32(f.Rollout)
+}
+
+// bucket maps a subject to 0-99 for one flag
+// bucket 将主体映射到某个开关的 0-99 区间
+func bucket(key, id string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{':'})
+	h.Write([]byte(id))
+	return h.Sum32() % 100
+}
+
+// SubjectFunc extracts the subject from a context
+// SubjectFunc 从 context 中提取主体
+type SubjectFunc func(ctx context.Context) Subject
+
+// Evaluator caches the flags of a store and evaluates them
+// Evaluator 缓存存储中的开关并进行评估
+type Evaluator struct {
+	store Store
+	ttl   time.Duration
+
+	mu       sync.RWMutex
+	flags    map[string]*Flag
+	loadedAt time.Time
+	subject  SubjectFunc
+}
+
+// NewEvaluator creates an evaluator; ttl <= 0 uses 30s
+// NewEvaluator 创建评估器；ttl <= 0 时使用 30 秒
+func NewEvaluator(store Store, ttl time.Duration) *Evaluator {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &Evaluator{store: store, ttl: ttl}
+}
+
+// SetSubjectFunc sets how Enabled finds the subject of a context
+// SetSubjectFunc 设置 Enabled 如何获取 context 的主体
+func (e *Evaluator) SetSubjectFunc(fn SubjectFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subject = fn
+}
+
+// Enabled reports whether key is on for the subject of ctx; unknown flags are off
+// Enabled 判断 key 对 ctx 的主体是否开启；未知开关视为关闭
+func (e *Evaluator) Enabled(ctx context.Context, key string) bool {
+	e.mu.RLock()
+	fn := e.subject
+	e.mu.RUnlock()
+	var s Subject
+	if fn != nil {
+		s = fn(ctx)
+	}
+	return e.EnabledFor(ctx, key, s)
+}
+
+// EnabledFor reports whether key is on for s; unknown flags are off
+// EnabledFor 判断 key 对 s 是否开启；未知开关视为关闭
+func (e *Evaluator) EnabledFor(ctx context.Context, key string, s Subject) bool {
+	flags, err := e.load(ctx)
+	if err != nil {
+		log.Printf("featureflag: load flags error: %v", err)
+	}
+	return flags[key].EnabledFor(s)
+}
+
+// load returns the cached flags, reloading them after ttl
+// A failed reload keeps serving the previous flags.
+// load 返回缓存的开关，超过 ttl 后重新加载
+// 重新加载失败时继续使用之前的开关。
+func (e *Evaluator) load(ctx context.Context) (map[string]*Flag, error) {
+	e.mu.RLock()
+	flags, fresh := e.flags, e.flags != nil && time.Since(e.loadedAt) < e.ttl
+	e.mu.RUnlock()
+	if fresh {
+		return flags, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.flags != nil && time.Since(e.loadedAt) < e.ttl {
+		return e.flags, nil
+	}
+	list, err := e.store.All(ctx)
+	if err != nil {
+		// Retry after another ttl instead of on every call | 在下一个 ttl 后重试，而不是每次调用都重试
+		e.loadedAt = time.Now()
+		return e.flags, err
+	}
+	e.flags = make(map[string]*Flag, len(list))
+	for i := range list {
+		e.flags[list[i].Key] = &list[i]
+	}
+	e.loadedAt = time.Now()
+	return e.flags, nil
+}
+
+// Invalidate drops the cached flags so the next evaluation reloads them
+// Invalidate 丢弃缓存的开关，下次评估时重新加载
+func (e *Evaluator) Invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flags = nil
+}
+
+// Store returns the underlying store
+// Store 返回底层存储
+func (e *Evaluator) Store() Store {
+	return e.store
+}
+
+// All returns every flag from the store, sorted by key
+// All 返回存储中的所有开关，按键排序
+func (e *Evaluator) All(ctx context.Context) ([]Flag, error) {
+	return e.store.All(ctx)
+}
+
+// Get returns one flag from the store, nil if missing
+// Get 返回存储中的一个开关，不存在时返回 nil
+func (e *Evaluator) Get(ctx context.Context, key string) (*Flag, error) {
+	return e.store.Get(ctx, key)
+}
+
+// Save validates and stores a flag, then invalidates the cache
+// Save 校验并保存开关，然后使缓存失效
+func (e *Evaluator) Save(ctx context.Context, f *Flag) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	f.UpdatedAt = time.Now()
+	if err := e.store.Save(ctx, f); err != nil {
+		return err
+	}
+	e.Invalidate()
+	return nil
+}
+
+// Delete removes a flag, then invalidates the cache
+// Delete 删除开关，然后使缓存失效
+func (e *Evaluator) Delete(ctx context.Context, key string) error {
+	if err := e.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	e.Invalidate()
+	return nil
+}
+
+var (
+	cfg              = Config{Store: StoreRedis, CacheTTL: 30 * time.Second, Permission: "featureflag:write"}
+	defaultEvaluator = NewEvaluator(NewMemoryStore(), 0) // Default evaluator | 默认评估器
+)
+
+// Init replaces the default evaluator with one reading store
+// Init 使用读取 store 的评估器替换默认评估器
+func Init(c Config, store Store) {
+	if c.Store == "" {
+		c.Store = StoreRedis
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = 30 * time.Second
+	}
+	if c.Permission == "" {
+		c.Permission = "featureflag:write"
+	}
+	cfg = c
+
+	e := NewEvaluator(store, c.CacheTTL)
+	defaultEvaluator.mu.RLock()
+	e.subject = defaultEvaluator.subject
+	defaultEvaluator.mu.RUnlock()
+	defaultEvaluator = e
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// Get returns the default evaluator
+// Get 返回默认评估器
+func Get() *Evaluator {
+	return defaultEvaluator
+}
+
+// SetSubjectFunc sets how the default evaluator finds the subject of a context
+// SetSubjectFunc 设置默认评估器如何获取 context 的主体
+func SetSubjectFunc(fn SubjectFunc) {
+	defaultEvaluator.SetSubjectFunc(fn)
+}
+
+// Enabled reports whether key is on for the subject of ctx
+// Enabled 判断 key 对 ctx 的主体是否开启
+func Enabled(ctx context.Context, key string) bool {
+	return defaultEvaluator.Enabled(ctx, key)
+}
+
+// EnabledFor reports whether key is on for s
+// EnabledFor 判断 key 对 s 是否开启
+func EnabledFor(ctx context.Context, key string, s Subject) bool {
+	return defaultEvaluator.EnabledFor(ctx, key, s)
+}
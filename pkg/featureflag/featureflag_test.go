@@ -0,0 +1,177 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEnabledFor_Targeting(t *testing.T) {
+	f := &Flag{Key: "new_checkout", Enabled: true, Users: []string{"42"}, Orgs: []string{"7"}}
+
+	if !f.EnabledFor(Subject{UserID: "42"}) {
+		t.Error("targeted user should get the flag")
+	}
+	if !f.EnabledFor(Subject{UserID: "1", OrgID: "7"}) {
+		t.Error("member of a targeted org should get the flag")
+	}
+	if f.EnabledFor(Subject{UserID: "1"}) {
+		t.Error("untargeted user should not get a 0% flag")
+	}
+
+	f.Enabled = false
+	if f.EnabledFor(Subject{UserID: "42"}) {
+		t.Error("disabled flag should be off even for targeted users")
+	}
+	if (*Flag)(nil).EnabledFor(Subject{UserID: "42"}) {
+		t.Error("missing flag should be off")
+	}
+}
+
+func TestEnabledFor_Rollout(t *testing.T) {
+	f := &Flag{Key: "new_checkout", Enabled: true, Rollout: 30}
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		if f.EnabledFor(Subject{UserID: fmt.Sprint(i)}) {
+			on++
+		}
+	}
+	if on < 2700 || on > 3300 {
+		t.Errorf("30%% rollout enabled %d of 10000 subjects", on)
+	}
+
+	// Raising the percentage keeps everyone who already had the flag
+	wider := &Flag{Key: "new_checkout", Enabled: true, Rollout: 60}
+	for i := 0; i < 1000; i++ {
+		s := Subject{UserID: fmt.Sprint(i)}
+		if f.EnabledFor(s) && !wider.EnabledFor(s) {
+			t.Fatalf("user %d lost the flag when rollout grew", i)
+		}
+	}
+
+	if f.EnabledFor(Subject{}) {
+		t.Error("anonymous subject should only get a 100% flag")
+	}
+	f.Rollout = 100
+	if !f.EnabledFor(Subject{}) {
+		t.Error("100% flag should be on for everyone")
+	}
+}
+
+type countingStore struct {
+	Store
+	loads int
+	err   error
+}
+
+func (s *countingStore) All(ctx context.Context) ([]Flag, error) {
+	s.loads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Store.All(ctx)
+}
+
+func TestEvaluator_CacheAndInvalidate(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Store: NewMemoryStore()}
+	e := NewEvaluator(store, time.Hour)
+	e.SetSubjectFunc(func(context.Context) Subject { return Subject{UserID: "42"} })
+
+	if err := e.Save(ctx, &Flag{Key: "beta", Enabled: true, Users: []string{"42"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Enabled(ctx, "beta") || e.Enabled(ctx, "unknown") {
+		t.Fatal("unexpected evaluation")
+	}
+	if store.loads != 1 {
+		t.Errorf("loads = %d, want 1 (cached)", store.loads)
+	}
+
+	// Writes through the evaluator are visible immediately
+	if err := e.Delete(ctx, "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if e.Enabled(ctx, "beta") {
+		t.Error("deleted flag should be off")
+	}
+	if store.loads != 2 {
+		t.Errorf("loads = %d, want 2 after invalidation", store.loads)
+	}
+
+	if err := e.Save(ctx, &Flag{Key: "bad", Rollout: 101}); err == nil {
+		t.Error("rollout above 100 should be rejected")
+	}
+}
+
+func TestEvaluator_LoadErrorKeepsFlags(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Store: NewMemoryStore()}
+	store.Store.Save(ctx, &Flag{Key: "beta", Enabled: true, Rollout: 100})
+	e := NewEvaluator(store, time.Millisecond)
+
+	if !e.EnabledFor(ctx, "beta", Subject{}) {
+		t.Fatal("beta should be on")
+	}
+	time.Sleep(5 * time.Millisecond)
+	store.err = errors.New("redis down")
+	if !e.EnabledFor(ctx, "beta", Subject{}) {
+		t.Error("previous flags should be served when reload fails")
+	}
+}
+
+type fakeRedis struct {
+	hashes map[string]map[string]string
+}
+
+func (r *fakeRedis) HGet(_ context.Context, key, field string) (string, error) {
+	v, ok := r.hashes[key][field]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return v, nil
+}
+
+func (r *fakeRedis) HGetAll(_ context.Context, key string) (map[string]string, error) {
+	return r.hashes[key], nil
+}
+
+func (r *fakeRedis) HSet(_ context.Context, key, field string, value any) error {
+	if r.hashes[key] == nil {
+		r.hashes[key] = map[string]string{}
+	}
+	r.hashes[key][field] = value.(string)
+	return nil
+}
+
+func (r *fakeRedis) HDel(_ context.Context, key string, fields ...string) error {
+	for _, f := range fields {
+		delete(r.hashes[key], f)
+	}
+	return nil
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewRedisStore(&fakeRedis{hashes: map[string]map[string]string{}})
+
+	s.Save(ctx, &Flag{Key: "b", Enabled: true, Rollout: 50, Orgs: []string{"7"}})
+	s.Save(ctx, &Flag{Key: "a"})
+
+	all, err := s.All(ctx)
+	if err != nil || len(all) != 2 || all[0].Key != "a" || all[1].Key != "b" {
+		t.Fatalf("All() = %v, %v", all, err)
+	}
+	f, err := s.Get(ctx, "b")
+	if err != nil || f == nil || f.Rollout != 50 || f.Orgs[0] != "7" {
+		t.Fatalf("Get(b) = %+v, %v", f, err)
+	}
+
+	s.Delete(ctx, "b")
+	if f, err := s.Get(ctx, "b"); f != nil || err != nil {
+		t.Errorf("Get(deleted) = %+v, %v, want nil, nil", f, err)
+	}
+}
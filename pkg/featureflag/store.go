@@ -0,0 +1,194 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"xorm.io/xorm"
+)
+
+// RedisKey is the hash holding the flags of the Redis store, one JSON field per flag
+// RedisKey 是 Redis 存储保存开关的哈希，每个开关一个 JSON 字段
+const RedisKey = "featureflag:flags"
+
+// Store persists feature flags
+// Store 持久化功能开关
+type Store interface {
+	All(ctx context.Context) ([]Flag, error)            // Sorted by key | 按键排序
+	Get(ctx context.Context, key string) (*Flag, error) // nil if missing | 不存在时返回 nil
+	Save(ctx context.Context, f *Flag) error            // Create or replace | 创建或替换
+	Delete(ctx context.Context, key string) error       // Missing keys are ignored | 忽略不存在的键
+}
+
+// memoryStore keeps flags in process
+// memoryStore 在进程内保存开关
+type memoryStore struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// NewMemoryStore creates an in-process store
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() Store {
+	return &memoryStore{flags: make(map[string]Flag)}
+}
+
+func (s *memoryStore) All(_ context.Context) ([]Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		out = append(out, f)
+	}
+	sortFlags(out)
+	return out, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (*Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.flags[key]
+	if !ok {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, f *Flag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[f.Key] = *f
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.flags, key)
+	return nil
+}
+
+// RedisClient defines the Redis client interface used by the Redis store
+// RedisClient 定义 Redis 存储使用的 Redis 客户端接口
+type RedisClient interface {
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key, field string, value any) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+// redisStore keeps flags in the RedisKey hash
+// redisStore 将开关保存在 RedisKey 哈希中
+type redisStore struct {
+	client RedisClient
+}
+
+// NewRedisStore creates a store backed by Redis
+// NewRedisStore 创建基于 Redis 的存储
+func NewRedisStore(client RedisClient) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) All(ctx context.Context) ([]Flag, error) {
+	fields, err := s.client.HGetAll(ctx, RedisKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Flag, 0, len(fields))
+	for key, raw := range fields {
+		var f Flag
+		if err := json.Unmarshal([]byte(raw), &f); err != nil {
+			return nil, fmt.Errorf("featureflag: decode %s: %w", key, err)
+		}
+		out = append(out, f)
+	}
+	sortFlags(out)
+	return out, nil
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (*Flag, error) {
+	raw, err := s.client.HGet(ctx, RedisKey, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var f Flag
+	if err := json.Unmarshal([]byte(raw), &f); err != nil {
+		return nil, fmt.Errorf("featureflag: decode %s: %w", key, err)
+	}
+	return &f, nil
+}
+
+func (s *redisStore) Save(ctx context.Context, f *Flag) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, RedisKey, f.Key, string(data))
+}
+
+func (s *redisStore) Delete(ctx context.Context, key string) error {
+	return s.client.HDel(ctx, RedisKey, key)
+}
+
+// dbStore keeps flags in the feature_flag table
+// dbStore 将开关保存在 feature_flag 表中
+type dbStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBStore creates a store backed by the database
+// NewDBStore 创建基于数据库的存储
+func NewDBStore(engine *xorm.Engine) Store {
+	return &dbStore{engine: engine}
+}
+
+func (s *dbStore) All(ctx context.Context) ([]Flag, error) {
+	var flags []Flag
+	err := s.engine.Context(ctx).Asc("key").Find(&flags)
+	return flags, err
+}
+
+func (s *dbStore) Get(ctx context.Context, key string) (*Flag, error) {
+	var f Flag
+	has, err := s.engine.Context(ctx).Where("key = ?", key).Get(&f)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (s *dbStore) Save(ctx context.Context, f *Flag) error {
+	has, err := s.engine.Context(ctx).Exist(&Flag{Key: f.Key})
+	if err != nil {
+		return err
+	}
+	if has {
+		_, err = s.engine.Context(ctx).ID(f.Key).AllCols().Update(f)
+		return err
+	}
+	_, err = s.engine.Context(ctx).Insert(f)
+	return err
+}
+
+func (s *dbStore) Delete(ctx context.Context, key string) error {
+	_, err := s.engine.Context(ctx).ID(key).Delete(&Flag{})
+	return err
+}
+
+// isNotFound checks if error is redis.Nil without importing go-redis
+// isNotFound 在不导入 go-redis 的情况下检查错误是否为 redis.Nil
+func isNotFound(err error) bool {
+	return err != nil && err.Error() == "redis: nil"
+}
+
+// sortFlags orders flags by key
+// sortFlags 按键对开关排序
+func sortFlags(flags []Flag) {
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+}
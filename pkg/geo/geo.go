@@ -0,0 +1,288 @@
+// Package geo provides points, bounding boxes, polygons and geohashes for location features,
+// mapped to PostGIS geography columns (WGS 84, SRID 4326).
+// Package geo 提供用于位置功能的点、边界框、多边形和 geohash，映射到 PostGIS geography 列（WGS 84，SRID 4326）。
+//
+// Coordinates are in degrees with longitude first, like PostGIS; distances are in meters.
+// 坐标单位为度，经度在前（与 PostGIS 一致）；距离单位为米。
+package geo
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SRID is the spatial reference of all values, WGS 84 | SRID 为所有值的空间参考，WGS 84
+const SRID = 4326
+
+// EarthRadius is the mean earth radius in meters | EarthRadius 为地球平均半径（米）
+const EarthRadius = 6371008.8
+
+// ErrInvalidGeometry is returned when a database value is not a supported geometry
+// ErrInvalidGeometry 表示数据库值不是支持的几何类型
+var ErrInvalidGeometry = errors.New("geo: invalid geometry")
+
+// Point is a WGS 84 location, stored in a geography(Point,4326) column.
+// Point 表示一个 WGS 84 位置，存储在 geography(Point,4326) 列中
+//
+// Example | 示例:
+//
+//	type Store struct {
+//		ID       int64      `xorm:"pk autoincr"`
+//		Location *geo.Point `xorm:"location"`
+//	}
+type Point struct {
+	Lng float64 `json:"lng"` // Longitude, -180 to 180 | 经度，-180 到 180
+	Lat float64 `json:"lat"` // Latitude, -90 to 90 | 纬度，-90 到 90
+}
+
+// Valid reports whether the coordinates are in range | Valid 判断坐标是否在有效范围内
+func (p Point) Valid() bool {
+	return p.Lng >= -180 && p.Lng <= 180 && p.Lat >= -90 && p.Lat <= 90
+}
+
+// String returns the point as WKT, e.g. POINT(116.397 39.909) | String 以 WKT 形式返回该点，例如 POINT(116.397 39.909)
+func (p Point) String() string {
+	return "POINT(" + coords(p) + ")"
+}
+
+// DistanceTo returns the great-circle distance to q in meters | DistanceTo 返回到 q 的大圆距离（米）
+func (p Point) DistanceTo(q Point) float64 {
+	return Distance(p, q)
+}
+
+// ToDB converts the point to EWKT (called by XORM when writing to database)
+// ToDB 将该点转换为 EWKT（XORM 写入数据库时调用）
+func (p Point) ToDB() (driver.Value, error) {
+	if !p.Valid() {
+		return nil, fmt.Errorf("%w: %s out of range", ErrInvalidGeometry, p)
+	}
+	return fmt.Sprintf("SRID=%d;%s", SRID, p), nil
+}
+
+// FromDB parses the hex EWKB returned by PostGIS, or WKT (called by XORM when reading from database)
+// FromDB 解析 PostGIS 返回的十六进制 EWKB 或 WKT（XORM 从数据库读取时调用）
+func (p *Point) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*p = Point{}
+		return nil
+	}
+	g, err := parseGeometry(string(b))
+	if err != nil {
+		return err
+	}
+	if g.kind != wkbPoint {
+		return fmt.Errorf("%w: expected a point", ErrInvalidGeometry)
+	}
+	*p = g.rings[0][0]
+	return nil
+}
+
+// Distance returns the great-circle (haversine) distance between a and b in meters.
+// It differs from the spheroid distance of PostGIS by less than 0.5%.
+// Distance 返回 a 和 b 之间的大圆（haversine）距离（米）
+// 与 PostGIS 的椭球距离相差不到 0.5%。
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// coords formats the coordinates of p as "lng lat" | coords 将 p 的坐标格式化为 "lng lat"
+func coords(p Point) string {
+	return strconv.FormatFloat(p.Lng, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
+
+// WKB geometry types | WKB 几何类型
+const (
+	wkbPoint   = 1
+	wkbPolygon = 3
+
+	ewkbSRID = 0x20000000 // EWKB flag telling an SRID follows the type | EWKB 标志，表示类型后跟 SRID
+)
+
+// geometry is a parsed point or polygon; a point is a single ring of one point
+// geometry 表示解析后的点或多边形；点为只包含一个点的单个环
+type geometry struct {
+	kind  uint32
+	rings [][]Point
+}
+
+// parseGeometry parses hex (E)WKB, as PostGIS returns geography values, or (E)WKT
+// parseGeometry 解析十六进制 (E)WKB（PostGIS 返回 geography 值的格式）或 (E)WKT
+func parseGeometry(s string) (*geometry, error) {
+	s = strings.TrimSpace(s)
+	if data, err := hex.DecodeString(s); err == nil {
+		return parseWKB(data)
+	}
+	return parseWKT(s)
+}
+
+// parseWKB parses a point or polygon in (E)WKB | parseWKB 解析 (E)WKB 格式的点或多边形
+func parseWKB(data []byte) (*geometry, error) {
+	r := &wkbReader{data: data}
+	if order := r.bytes(1); order == nil || order[0] > 1 {
+		return nil, ErrInvalidGeometry
+	} else if order[0] == 1 {
+		r.order = binary.LittleEndian
+	} else {
+		r.order = binary.BigEndian
+	}
+
+	typ := r.uint32()
+	if typ&ewkbSRID != 0 {
+		if srid := r.uint32(); srid != SRID && r.err == nil {
+			return nil, fmt.Errorf("%w: SRID %d, want %d", ErrInvalidGeometry, srid, SRID)
+		}
+	}
+	g := &geometry{kind: typ &^ ewkbSRID}
+	switch g.kind {
+	case wkbPoint:
+		g.rings = [][]Point{{r.point()}}
+	case wkbPolygon:
+		g.rings = make([][]Point, r.count())
+		for i := range g.rings {
+			ring := make([]Point, r.count())
+			for j := range ring {
+				ring[j] = r.point()
+			}
+			g.rings[i] = ring
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported WKB type %d", ErrInvalidGeometry, typ)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return g, nil
+}
+
+// wkbReader reads WKB values, keeping the first error | wkbReader 读取 WKB 值并保留第一个错误
+type wkbReader struct {
+	data  []byte
+	order binary.ByteOrder
+	err   error
+}
+
+func (r *wkbReader) bytes(n int) []byte {
+	if r.err != nil || len(r.data) < n {
+		r.err = ErrInvalidGeometry
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *wkbReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return r.order.Uint32(b)
+	}
+	return 0
+}
+
+// count reads a ring or point count, bounded by the remaining data | count 读取环或点的数量，受剩余数据长度限制
+func (r *wkbReader) count() int {
+	n := int(r.uint32())
+	if n > len(r.data)/16 {
+		r.err = ErrInvalidGeometry
+		return 0
+	}
+	return n
+}
+
+func (r *wkbReader) point() Point {
+	b := r.bytes(16)
+	if b == nil {
+		return Point{}
+	}
+	return Point{
+		Lng: math.Float64frombits(r.order.Uint64(b[:8])),
+		Lat: math.Float64frombits(r.order.Uint64(b[8:])),
+	}
+}
+
+// parseWKT parses POINT(lng lat) or POLYGON((lng lat, ...), ...), with an optional SRID=4326; prefix
+// parseWKT 解析 POINT(lng lat) 或 POLYGON((lng lat, ...), ...)，可带 SRID=4326; 前缀
+func parseWKT(s string) (*geometry, error) {
+	if prefix, rest, ok := strings.Cut(s, ";"); ok {
+		if prefix != "SRID="+strconv.Itoa(SRID) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGeometry, prefix)
+		}
+		s = rest
+	}
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.HasPrefix(upper, "POINT"):
+		body, ok := unwrap(strings.TrimSpace(s[len("POINT"):]))
+		if !ok {
+			return nil, ErrInvalidGeometry
+		}
+		points, err := parsePoints(body)
+		if err != nil || len(points) != 1 {
+			return nil, ErrInvalidGeometry
+		}
+		return &geometry{kind: wkbPoint, rings: [][]Point{points}}, nil
+	case strings.HasPrefix(upper, "POLYGON"):
+		body, ok := unwrap(strings.TrimSpace(s[len("POLYGON"):]))
+		if !ok {
+			return nil, ErrInvalidGeometry
+		}
+		g := &geometry{kind: wkbPolygon}
+		for body = strings.TrimSpace(body); body != ""; {
+			end := strings.IndexByte(body, ')')
+			if body[0] != '(' || end < 0 {
+				return nil, ErrInvalidGeometry
+			}
+			points, err := parsePoints(body[1:end])
+			if err != nil {
+				return nil, err
+			}
+			g.rings = append(g.rings, points)
+			body = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(body[end+1:]), ","))
+		}
+		if len(g.rings) == 0 {
+			return nil, ErrInvalidGeometry
+		}
+		return g, nil
+	}
+	return nil, ErrInvalidGeometry
+}
+
+// unwrap strips the outer parentheses of s | unwrap 去掉 s 最外层的括号
+func unwrap(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// parsePoints parses "lng lat, lng lat, ..." | parsePoints 解析 "lng lat, lng lat, ..."
+func parsePoints(s string) ([]Point, error) {
+	parts := strings.Split(s, ",")
+	points := make([]Point, len(parts))
+	for i, part := range parts {
+		xy := strings.Fields(part)
+		if len(xy) != 2 {
+			return nil, ErrInvalidGeometry
+		}
+		lng, err1 := strconv.ParseFloat(xy[0], 64)
+		lat, err2 := strconv.ParseFloat(xy[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, ErrInvalidGeometry
+		}
+		points[i] = Point{Lng: lng, Lat: lat}
+	}
+	return points, nil
+}
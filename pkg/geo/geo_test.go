@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Tiananmen and the Oriental Pearl Tower | 天安门和东方明珠
+var (
+	beijing  = Point{Lng: 116.397389, Lat: 39.908722}
+	shanghai = Point{Lng: 121.499718, Lat: 31.239703}
+)
+
+func TestDistance(t *testing.T) {
+	// About 1067 km apart | 相距约 1067 公里
+	if d := Distance(beijing, shanghai); math.Abs(d-1067e3) > 5e3 {
+		t.Errorf("Distance = %.0f m", d)
+	}
+	if d := beijing.DistanceTo(beijing); d != 0 {
+		t.Errorf("Distance to itself = %f", d)
+	}
+}
+
+func TestPointDB(t *testing.T) {
+	v, err := beijing.ToDB()
+	if err != nil || v != "SRID=4326;POINT(116.397389 39.908722)" {
+		t.Errorf("ToDB = %v, %v", v, err)
+	}
+	if _, err := (Point{Lng: 200}).ToDB(); !errors.Is(err, ErrInvalidGeometry) {
+		t.Errorf("Out of range point should be rejected, got %v", err)
+	}
+
+	// SELECT 'SRID=4326;POINT(1 2)'::geography | PostGIS 返回的十六进制 EWKB
+	var p Point
+	if err := p.FromDB([]byte("0101000020E6100000000000000000F03F0000000000000040")); err != nil || p != (Point{Lng: 1, Lat: 2}) {
+		t.Errorf("FromDB(EWKB) = %+v, %v", p, err)
+	}
+	if err := p.FromDB([]byte("SRID=4326;POINT(116.397389 39.908722)")); err != nil || p != beijing {
+		t.Errorf("FromDB(EWKT) = %+v, %v", p, err)
+	}
+	if err := p.FromDB(nil); err != nil || p != (Point{}) {
+		t.Errorf("FromDB(nil) = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"0101000020E6100000000000000000F03F", "SRID=3857;POINT(1 2)", "POINT(1)", "LINESTRING(1 2, 3 4)"} {
+		if err := p.FromDB([]byte(bad)); !errors.Is(err, ErrInvalidGeometry) {
+			t.Errorf("FromDB(%q) error = %v", bad, err)
+		}
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	zone := Polygon{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}},
+	}
+	for _, c := range []struct {
+		p    Point
+		want bool
+	}{{Point{2, 2}, true}, {Point{5, 5}, false}, {Point{11, 5}, false}, {Point{8, 9}, true}} {
+		if got := zone.Contains(c.p); got != c.want {
+			t.Errorf("Contains(%v) = %v", c.p, got)
+		}
+	}
+	if b := zone.Bounds(); b != (BBox{0, 0, 10, 10}) {
+		t.Errorf("Bounds = %+v", b)
+	}
+
+	v, err := zone.ToDB()
+	want := "SRID=4326;POLYGON((0 0, 10 0, 10 10, 0 10, 0 0), (4 4, 6 4, 6 6, 4 6, 4 4))"
+	if err != nil || v != want {
+		t.Fatalf("ToDB = %v, %v", v, err)
+	}
+	var back Polygon
+	if err := back.FromDB([]byte(want)); err != nil || len(back) != 2 || len(back[1]) != 5 || back[1][2] != (Point{6, 6}) {
+		t.Errorf("FromDB(EWKT) = %v, %v", back, err)
+	}
+	// SELECT 'SRID=4326;POLYGON((0 0, 1 0, 0 1, 0 0))'::geography
+	ewkb := "0103000020E61000000100000004000000" +
+		"00000000000000000000000000000000" + "000000000000F03F0000000000000000" +
+		"0000000000000000000000000000F03F" + "00000000000000000000000000000000"
+	if err := back.FromDB([]byte(ewkb)); err != nil || len(back) != 1 || back[0][1] != (Point{1, 0}) {
+		t.Errorf("FromDB(EWKB) = %v, %v", back, err)
+	}
+	if _, err := (Polygon{{{0, 0}, {1, 1}}}).ToDB(); !errors.Is(err, ErrInvalidGeometry) {
+		t.Errorf("Rings with 2 points should be rejected, got %v", err)
+	}
+}
+
+func TestAround(t *testing.T) {
+	b := Around(beijing, 1000)
+	if !b.Contains(beijing) || b.Center() != beijing {
+		t.Errorf("Box %+v should be centered on the point", b)
+	}
+	// The box edges are 1 km away | 边界距离中心 1 公里
+	for _, edge := range []Point{{b.MaxLng, beijing.Lat}, {beijing.Lng, b.MinLat}} {
+		if d := Distance(beijing, edge); math.Abs(d-1000) > 1 {
+			t.Errorf("Edge %v is %.1f m away", edge, d)
+		}
+	}
+	if pole := Around(Point{0, 90}, 1000); pole.MinLng != -180 || pole.MaxLng != 180 {
+		t.Errorf("Box around the pole should span all longitudes, got %+v", pole)
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	// Reference value from geohash.org | 来自 geohash.org 的参考值
+	if h := Encode(Point{Lng: -5.6, Lat: 42.6}, 5); h != "ezs42" {
+		t.Errorf("Encode = %s, want ezs42", h)
+	}
+	h := Encode(beijing, 8)
+	cell, err := Decode(h)
+	if err != nil || !cell.Contains(beijing) {
+		t.Errorf("Decode(%s) = %+v, %v", h, cell, err)
+	}
+	if !strings.HasPrefix(h, Encode(beijing, 5)) {
+		t.Errorf("Shorter hashes should be prefixes of %s", h)
+	}
+	if _, err := Decode("abc"); err == nil {
+		t.Error("Geohashes with a or other letters outside the alphabet should be rejected")
+	}
+
+	neighbors, err := Neighbors("ezs42")
+	want := []string{"ezs48", "ezs49", "ezs43", "ezs41", "ezs40", "ezefp", "ezefr", "ezefx"}
+	if err != nil || strings.Join(neighbors, ",") != strings.Join(want, ",") {
+		t.Errorf("Neighbors = %v, want %v", neighbors, want)
+	}
+	if north, _ := Neighbors(Encode(Point{0, 89.99}, 3)); len(north) != 5 {
+		t.Errorf("Cells at the pole should have 5 neighbors, got %v", north)
+	}
+}
+
+func TestColumnSchema(t *testing.T) {
+	stmts := columnSchema("store", "location", TypePoint)
+	if stmts[1] != `ALTER TABLE "store" ADD COLUMN IF NOT EXISTS "location" geography(Point,4326)` {
+		t.Errorf("Unexpected add column %s", stmts[1])
+	}
+	if !strings.Contains(stmts[2], `ALTER TABLE "store" ALTER COLUMN "location" TYPE geography(Point,4326) USING NULLIF("location"::text, '')::geography(Point,4326)`) {
+		t.Errorf("Unexpected conversion %s", stmts[2])
+	}
+	if stmts[3] != `CREATE INDEX IF NOT EXISTS "idx_store_location" ON "store" USING GIST ("location")` {
+		t.Errorf("Unexpected index %s", stmts[3])
+	}
+	if sql := DistanceSQL("location", Point{1.5, -2}); sql != "ST_Distance(location, ST_SetSRID(ST_MakePoint(1.5, -2), 4326)::geography)" {
+		t.Errorf("DistanceSQL = %s", sql)
+	}
+}
@@ -0,0 +1,106 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// base32 is the geohash alphabet | base32 为 geohash 字母表
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxPrecision is the longest supported geohash, about 4 cm | MaxPrecision 为支持的最长 geohash，精度约 4 厘米
+const MaxPrecision = 12
+
+// Encode returns the geohash of p with precision characters (1 to 12).
+// Nearby points share a prefix, so geohashes group locations in caches and counters.
+// Encode 返回 p 的 geohash，长度为 precision 个字符（1 到 12）
+// 相邻的点具有相同的前缀，因此 geohash 可用于在缓存和计数中对位置分组。
+//
+// Precision | 精度: 5 ≈ 4.9 km, 6 ≈ 1.2 km, 7 ≈ 153 m, 8 ≈ 38 m
+func Encode(p Point, precision int) string {
+	precision = min(max(precision, 1), MaxPrecision)
+	lng, lat := [2]float64{-180, 180}, [2]float64{-90, 90}
+	var sb strings.Builder
+	sb.Grow(precision)
+	even, bit, ch := true, 0, 0
+	for sb.Len() < precision {
+		// Bits alternate between longitude and latitude | 位交替表示经度和纬度
+		r, v := &lat, p.Lat
+		if even {
+			r, v = &lng, p.Lng
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// Decode returns the cell of a geohash | Decode 返回 geohash 对应的单元格
+func Decode(hash string) (BBox, error) {
+	if hash == "" || len(hash) > MaxPrecision {
+		return BBox{}, fmt.Errorf("geo: invalid geohash %q", hash)
+	}
+	lng, lat := [2]float64{-180, 180}, [2]float64{-90, 90}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		ch := strings.IndexByte(base32, hash[i])
+		if ch < 0 {
+			return BBox{}, fmt.Errorf("geo: invalid geohash %q", hash)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			r := &lat
+			if even {
+				r = &lng
+			}
+			mid := (r[0] + r[1]) / 2
+			if ch>>bit&1 == 1 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return BBox{MinLng: lng[0], MinLat: lat[0], MaxLng: lng[1], MaxLat: lat[1]}, nil
+}
+
+// Neighbors returns the 8 cells around hash, clockwise from north; cells beyond a pole are left out.
+// A radius search at a given precision covers hash and its neighbors.
+// Neighbors 返回 hash 周围的 8 个单元格，从北开始顺时针排列；超出极点的单元格会被省略。
+// 在给定精度下，半径搜索覆盖 hash 及其相邻单元格即可。
+func Neighbors(hash string) ([]string, error) {
+	cell, err := Decode(hash)
+	if err != nil {
+		return nil, err
+	}
+	c := cell.Center()
+	w, h := cell.MaxLng-cell.MinLng, cell.MaxLat-cell.MinLat
+	offsets := [8][2]float64{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+	out := make([]string, 0, len(offsets))
+	for _, o := range offsets {
+		lat := c.Lat + o[1]*h
+		if lat > 90 || lat < -90 {
+			continue
+		}
+		// Longitude wraps around the antimeridian | 经度跨越反子午线时回绕
+		lng := c.Lng + o[0]*w
+		if lng > 180 {
+			lng -= 360
+		} else if lng < -180 {
+			lng += 360
+		}
+		out = append(out, Encode(Point{Lng: lng, Lat: lat}, len(hash)))
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+// Column types created by EnsureColumn | EnsureColumn 创建的列类型
+const (
+	TypePoint   = "geography(Point,4326)"
+	TypePolygon = "geography(Polygon,4326)"
+)
+
+// point is the SQL of a bound point as geography | point 为绑定参数点转为 geography 的 SQL
+const point = "ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography"
+
+// Within keeps the rows whose column lies within radius meters of p, using the GIST index
+// Within 仅保留 column 距 p 不超过 radius 米的行，会使用 GIST 索引
+//
+// Example | 示例:
+//
+//	var stores []Store
+//	err := geo.NearestFirst(geo.Within(db.NewSession(), "location", p, 3000), "location", p).
+//		Limit(20).Find(&stores)
+func Within(s *xorm.Session, column string, p Point, radius float64) *xorm.Session {
+	return s.And(fmt.Sprintf("ST_DWithin(%s, %s, ?)", column, point), p.Lng, p.Lat, radius)
+}
+
+// InBox keeps the rows whose column intersects b, e.g. the stores in the visible map area
+// InBox 仅保留 column 与 b 相交的行，例如地图可见区域内的门店
+func InBox(s *xorm.Session, column string, b BBox) *xorm.Session {
+	return s.And(column+" && ST_MakeEnvelope(?, ?, ?, ?, 4326)::geography", b.MinLng, b.MinLat, b.MaxLng, b.MaxLat)
+}
+
+// Covers keeps the rows whose polygon column covers p, e.g. the delivery zones serving an address
+// Covers 仅保留多边形列 column 覆盖 p 的行，例如覆盖某地址的配送范围
+func Covers(s *xorm.Session, column string, p Point) *xorm.Session {
+	return s.And(fmt.Sprintf("ST_Covers(%s, %s)", column, point), p.Lng, p.Lat)
+}
+
+// NearestFirst orders the rows by distance from p, using the GIST index for KNN
+// NearestFirst 按到 p 的距离排序，使用 GIST 索引进行 KNN 查询
+func NearestFirst(s *xorm.Session, column string, p Point) *xorm.Session {
+	return s.OrderBy(fmt.Sprintf("%s <-> %s", column, point), p.Lng, p.Lat)
+}
+
+// DistanceSQL returns the expression of the distance in meters from column to p, for Select.
+// Coordinates are formatted into the SQL since Select takes no arguments.
+// DistanceSQL 返回 column 到 p 的距离（米）表达式，用于 Select
+// 由于 Select 不接受参数，坐标会被格式化到 SQL 中。
+//
+// Example | 示例:
+//
+//	type storeDistance struct {
+//		Store    `xorm:"extends"`
+//		Distance float64 `xorm:"distance"`
+//	}
+//	s := db.Table("store").Select("store.*, " + geo.DistanceSQL("location", p) + " AS distance")
+func DistanceSQL(column string, p Point) string {
+	return fmt.Sprintf("ST_Distance(%s, ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography)", column,
+		strconv.FormatFloat(p.Lng, 'f', -1, 64), strconv.FormatFloat(p.Lat, 'f', -1, 64))
+}
+
+// EnsureColumn makes column of table a geography column of typ with a GIST index, enabling PostGIS.
+// XORM cannot declare geography columns, so Sync creates the field as text; the column is
+// converted in place, and created when missing. It is safe to run on every start.
+// EnsureColumn 将 table 的 column 设为 typ 类型的 geography 列并建立 GIST 索引，同时启用 PostGIS
+// XORM 无法声明 geography 列，因此 Sync 会将该字段创建为 text；该列会被原地转换，不存在时会被创建。
+// 每次启动时运行都是安全的。
+func EnsureColumn(ctx context.Context, db *xorm.Engine, table, column, typ string) error {
+	for _, stmt := range columnSchema(table, column, typ) {
+		if _, err := db.Context(ctx).Exec(stmt); err != nil {
+			return fmt.Errorf("geo: ensure %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+// columnSchema returns the statements of EnsureColumn | columnSchema 返回 EnsureColumn 执行的语句
+func columnSchema(table, column, typ string) []string {
+	t, c := quoteIdent(table), quoteIdent(column)
+	return []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis",
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", t, c, typ),
+		// Only text columns are converted, an existing geography is left as is | 仅转换 text 列，已有的 geography 列保持不变
+		fmt.Sprintf(`DO $$ BEGIN
+IF (SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = %s AND column_name = %s) <> 'USER-DEFINED' THEN
+ALTER TABLE %s ALTER COLUMN %s TYPE %s USING NULLIF(%s::text, '')::%s;
+END IF;
+END $$`, quoteLiteral(table), quoteLiteral(column), t, c, typ, c, typ),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIST (%s)", quoteIdent("idx_"+table+"_"+column), t, c),
+	}
+}
+
+// quoteIdent quotes a PostgreSQL identifier | quoteIdent 为 PostgreSQL 标识符加引号
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a PostgreSQL string literal | quoteLiteral 为 PostgreSQL 字符串字面量加引号
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,153 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// BBox is a bounding box in degrees, e.g. the visible area of a map
+// BBox 表示以度为单位的边界框，例如地图的可见区域
+type BBox struct {
+	MinLng float64 `json:"min_lng"`
+	MinLat float64 `json:"min_lat"`
+	MaxLng float64 `json:"max_lng"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// Around returns the box containing the circle of radius meters around p, a cheap prefilter of radius searches
+// Around 返回包含以 p 为圆心、radius 米为半径的圆的边界框，用作半径搜索的低成本预过滤
+func Around(p Point, radius float64) BBox {
+	dLat := degrees(radius / EarthRadius)
+	// Longitude degrees shrink towards the poles | 经度的度数向两极收缩
+	dLng := 180.0
+	if c := math.Cos(radians(p.Lat)); c > 1e-9 {
+		dLng = math.Min(180, dLat/c)
+	}
+	return BBox{
+		MinLng: math.Max(-180, p.Lng-dLng),
+		MinLat: math.Max(-90, p.Lat-dLat),
+		MaxLng: math.Min(180, p.Lng+dLng),
+		MaxLat: math.Min(90, p.Lat+dLat),
+	}
+}
+
+// Contains reports whether p lies in the box, edges included | Contains 判断 p 是否在框内（含边界）
+func (b BBox) Contains(p Point) bool {
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng && p.Lat >= b.MinLat && p.Lat <= b.MaxLat
+}
+
+// Expand returns the box grown to contain p | Expand 返回扩大到包含 p 的边界框
+func (b BBox) Expand(p Point) BBox {
+	return BBox{
+		MinLng: math.Min(b.MinLng, p.Lng),
+		MinLat: math.Min(b.MinLat, p.Lat),
+		MaxLng: math.Max(b.MaxLng, p.Lng),
+		MaxLat: math.Max(b.MaxLat, p.Lat),
+	}
+}
+
+// Center returns the center of the box | Center 返回边界框的中心点
+func (b BBox) Center() Point {
+	return Point{Lng: (b.MinLng + b.MaxLng) / 2, Lat: (b.MinLat + b.MaxLat) / 2}
+}
+
+// Polygon is an area such as a delivery zone, stored in a geography(Polygon,4326) column.
+// The first ring is the outer boundary, the others are holes; rings are closed on write.
+// Polygon 表示一个区域，例如配送范围，存储在 geography(Polygon,4326) 列中
+// 第一个环为外边界，其余为洞；写入时会自动闭合各环。
+type Polygon [][]Point
+
+// Contains reports whether p lies inside the outer ring and outside the holes
+// Contains 判断 p 是否在外环内且不在洞内
+func (pg Polygon) Contains(p Point) bool {
+	if len(pg) == 0 || !inRing(pg[0], p) {
+		return false
+	}
+	for _, hole := range pg[1:] {
+		if inRing(hole, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds returns the bounding box of the outer ring | Bounds 返回外环的边界框
+func (pg Polygon) Bounds() BBox {
+	if len(pg) == 0 || len(pg[0]) == 0 {
+		return BBox{}
+	}
+	first := pg[0][0]
+	b := BBox{MinLng: first.Lng, MinLat: first.Lat, MaxLng: first.Lng, MaxLat: first.Lat}
+	for _, p := range pg[0][1:] {
+		b = b.Expand(p)
+	}
+	return b
+}
+
+// String returns the polygon as WKT with closed rings | String 以 WKT 形式返回多边形，各环已闭合
+func (pg Polygon) String() string {
+	rings := make([]string, len(pg))
+	for i, ring := range pg {
+		points := make([]string, 0, len(ring)+1)
+		for _, p := range ring {
+			points = append(points, coords(p))
+		}
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			points = append(points, coords(ring[0]))
+		}
+		rings[i] = "(" + strings.Join(points, ", ") + ")"
+	}
+	return "POLYGON(" + strings.Join(rings, ", ") + ")"
+}
+
+// ToDB converts the polygon to EWKT (called by XORM when writing to database)
+// ToDB 将多边形转换为 EWKT（XORM 写入数据库时调用）
+func (pg Polygon) ToDB() (driver.Value, error) {
+	if len(pg) == 0 {
+		return nil, nil
+	}
+	for _, ring := range pg {
+		if len(ring) < 3 {
+			return nil, fmt.Errorf("%w: a ring needs at least 3 points", ErrInvalidGeometry)
+		}
+		for _, p := range ring {
+			if !p.Valid() {
+				return nil, fmt.Errorf("%w: %s out of range", ErrInvalidGeometry, p)
+			}
+		}
+	}
+	return fmt.Sprintf("SRID=%d;%s", SRID, pg), nil
+}
+
+// FromDB parses the hex EWKB returned by PostGIS, or WKT (called by XORM when reading from database)
+// FromDB 解析 PostGIS 返回的十六进制 EWKB 或 WKT（XORM 从数据库读取时调用）
+func (pg *Polygon) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*pg = nil
+		return nil
+	}
+	g, err := parseGeometry(string(b))
+	if err != nil {
+		return err
+	}
+	if g.kind != wkbPolygon {
+		return fmt.Errorf("%w: expected a polygon", ErrInvalidGeometry)
+	}
+	*pg = g.rings
+	return nil
+}
+
+// inRing reports whether p lies inside ring by ray casting | inRing 使用射线法判断 p 是否在环内
+func inRing(ring []Point, p Point) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			in = !in
+		}
+	}
+	return in
+}
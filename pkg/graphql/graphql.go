@@ -0,0 +1,198 @@
+// Package graphql serves a GraphQL endpoint assembled from schema fragments contributed by modules
+// The package does not implement GraphQL itself: an Engine adapter (graph-gophers/graphql-go,
+// gqlgen, ...) set with SetEngine builds the merged schema and resolvers into an Executor.
+// The package provides the registry, the HTTP handler, request tracing and dataloaders.
+// Package graphql 提供由模块贡献的 schema 片段组装而成的 GraphQL 接口
+// 本包不实现 GraphQL 本身：通过 SetEngine 设置的引擎适配器（graph-gophers/graphql-go、gqlgen 等）
+// 将合并后的 schema 和解析器构建为 Executor。本包提供注册表、HTTP 处理器、请求追踪和数据加载器。
+//
+// Usage | 用法:
+//
+//	graphql.Register(graphql.Fragment{
+//		Name:     "usercenter",
+//		Schema:   `extend type Query { me: User }`,
+//		Resolver: &resolver{},
+//		Context:  withLoaders, // Per-request dataloaders | 每个请求的数据加载器
+//	})
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNoEngine = errors.New("graphql: no engine set")    // Fragments registered without SetEngine | 注册了片段但未调用 SetEngine
+	ErrNoSchema = errors.New("graphql: schema not built") // Build not called or nothing registered | 未调用 Build 或没有注册内容
+)
+
+// Config represents GraphQL configuration
+// Config 表示 GraphQL 配置
+type Config struct {
+	Path  string   `toml:"path"`  // Endpoint path, e.g. "/graphql", empty disables | 接口路径，例如 "/graphql"，为空时禁用
+	Plats []string `toml:"plats"` // JWT platforms allowed, empty allows any | 允许的 JWT 平台，为空时允许任意平台
+}
+
+// Fragment is the part of the schema contributed by one module
+// Fragment 是一个模块贡献的 schema 部分
+type Fragment struct {
+	Name     string                                    // Module name | 模块名称
+	Schema   string                                    // SDL, usually "extend type Query { ... }" | SDL，通常为 "extend type Query { ... }"
+	Resolver any                                       // Resolver understood by the engine | 引擎可识别的解析器
+	Context  func(ctx context.Context) context.Context // Optional per-request setup, e.g. dataloaders | 可选的每请求初始化，例如数据加载器
+}
+
+// Request is a GraphQL request
+// Request 表示 GraphQL 请求
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Error is a GraphQL error
+// Error 表示 GraphQL 错误
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Response is a GraphQL response
+// Response 表示 GraphQL 响应
+type Response struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []Error         `json:"errors,omitempty"`
+}
+
+// Executor runs requests against a built schema
+// Executor 针对已构建的 schema 执行请求
+type Executor interface {
+	Execute(ctx context.Context, req *Request) *Response
+}
+
+// ExecutorFunc adapts a function to Executor
+// ExecutorFunc 将函数适配为 Executor
+type ExecutorFunc func(ctx context.Context, req *Request) *Response
+
+// Execute calls f | Execute 调用 f
+func (f ExecutorFunc) Execute(ctx context.Context, req *Request) *Response {
+	return f(ctx, req)
+}
+
+// Engine builds the merged schema and the resolvers of every fragment into an Executor
+// Engine 将合并后的 schema 和各片段的解析器构建为 Executor
+type Engine interface {
+	Build(schema string, resolvers []any) (Executor, error)
+}
+
+var (
+	mu        sync.RWMutex
+	config    Config
+	engine    Engine
+	fragments []Fragment
+	executor  Executor
+)
+
+// Init sets the configuration
+// Init 设置配置
+func Init(cfg Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	config = cfg
+}
+
+// GetConfig returns the configuration
+// GetConfig 返回配置
+func GetConfig() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return config
+}
+
+// SetEngine sets the engine used by Build
+// SetEngine 设置 Build 使用的引擎
+func SetEngine(e Engine) {
+	mu.Lock()
+	defer mu.Unlock()
+	engine = e
+}
+
+// Register adds a fragment, called from module Init
+// Register 添加片段，在模块 Init 中调用
+func Register(f Fragment) {
+	mu.Lock()
+	defer mu.Unlock()
+	fragments = append(fragments, f)
+}
+
+// Schema returns the fragments joined in registration order
+// Schema 返回按注册顺序拼接的片段
+func Schema() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	parts := make([]string, 0, len(fragments))
+	for _, f := range fragments {
+		parts = append(parts, strings.TrimSpace(f.Schema))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Build builds the registered fragments, boot calls it once modules are initialized when [graphql] path is set
+// Nothing is built when no fragment is registered.
+// Build 构建已注册的片段，设置 [graphql] path 时 boot 在模块初始化后调用
+// 没有注册片段时不构建。
+func Build() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(fragments) == 0 {
+		return nil
+	}
+	if engine == nil {
+		return ErrNoEngine
+	}
+	parts := make([]string, 0, len(fragments))
+	resolvers := make([]any, 0, len(fragments))
+	for _, f := range fragments {
+		parts = append(parts, strings.TrimSpace(f.Schema))
+		resolvers = append(resolvers, f.Resolver)
+	}
+	exec, err := engine.Build(strings.Join(parts, "\n\n"), resolvers)
+	if err != nil {
+		return fmt.Errorf("graphql: build schema: %w", err)
+	}
+	executor = exec
+	return nil
+}
+
+// Execute runs a request with the built schema, after the Context hooks of every fragment
+// Execute 在执行各片段的 Context 钩子后，使用已构建的 schema 执行请求
+func Execute(ctx context.Context, req *Request) *Response {
+	mu.RLock()
+	exec := executor
+	hooks := make([]func(context.Context) context.Context, 0, len(fragments))
+	for _, f := range fragments {
+		if f.Context != nil {
+			hooks = append(hooks, f.Context)
+		}
+	}
+	mu.RUnlock()
+
+	if exec == nil {
+		return ErrorResponse(ErrNoSchema)
+	}
+	for _, hook := range hooks {
+		ctx = hook(ctx)
+	}
+	return exec.Execute(ctx, req)
+}
+
+// ErrorResponse returns a response carrying err
+// ErrorResponse 返回携带 err 的响应
+func ErrorResponse(err error) *Response {
+	return &Response{Errors: []Error{{Message: err.Error()}}}
+}
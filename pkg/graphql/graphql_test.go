@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/cache"
+)
+
+type ctxKey struct{}
+
+// stubEngine echoes the request and the value set by the fragment Context hook
+// stubEngine 回显请求以及片段 Context 钩子设置的值
+type stubEngine struct {
+	schema    string
+	resolvers []any
+}
+
+func (e *stubEngine) Build(schema string, resolvers []any) (Executor, error) {
+	e.schema, e.resolvers = schema, resolvers
+	return ExecutorFunc(func(ctx context.Context, req *Request) *Response {
+		data, _ := json.Marshal(map[string]any{"query": req.Query, "vars": req.Variables, "hook": ctx.Value(ctxKey{})})
+		return &Response{Data: data}
+	}), nil
+}
+
+// reset clears the package state | reset 清空包状态
+func reset(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		fragments, engine, executor = nil, nil, nil
+	})
+}
+
+func TestBuild(t *testing.T) {
+	reset(t)
+	if err := Build(); err != nil {
+		t.Fatalf("Build without fragments should be a no-op, got %v", err)
+	}
+	if resp := Execute(context.Background(), &Request{Query: "{a}"}); resp.Errors[0].Message != ErrNoSchema.Error() {
+		t.Errorf("Expected ErrNoSchema, got %+v", resp)
+	}
+
+	Register(Fragment{Name: "a", Schema: "  type Query { a: Int }\n", Resolver: "ra"})
+	if err := Build(); err != ErrNoEngine {
+		t.Errorf("Expected ErrNoEngine, got %v", err)
+	}
+
+	engine := &stubEngine{}
+	SetEngine(engine)
+	Register(Fragment{Name: "b", Schema: "extend type Query { b: Int }", Resolver: "rb",
+		Context: func(ctx context.Context) context.Context { return context.WithValue(ctx, ctxKey{}, "b") }})
+	if err := Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if engine.schema != "type Query { a: Int }\n\nextend type Query { b: Int }" || len(engine.resolvers) != 2 {
+		t.Errorf("Unexpected schema %q resolvers %v", engine.schema, engine.resolvers)
+	}
+	if engine.schema != Schema() {
+		t.Errorf("Schema() differs from the built schema")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reset(t)
+	SetEngine(&stubEngine{})
+	Register(Fragment{Schema: "type Query { a: Int }",
+		Context: func(ctx context.Context) context.Context { return context.WithValue(ctx, ctxKey{}, "hooked") }})
+	if err := Build(); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	app := fiber.New()
+	app.All("/graphql", Handler())
+	do := func(method, target, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		var out map[string]any
+		_ = json.Unmarshal(raw, &out)
+		return resp.StatusCode, out
+	}
+
+	status, out := do(fiber.MethodPost, "/graphql", `{"query":"{a}","variables":{"x":1}}`)
+	data, _ := out["data"].(map[string]any)
+	if status != fiber.StatusOK || data["query"] != "{a}" || data["hook"] != "hooked" {
+		t.Errorf("Unexpected POST response %d %v", status, out)
+	}
+
+	q := url.Values{"query": {"{a}"}, "variables": {`{"x":2}`}}
+	status, out = do(fiber.MethodGet, "/graphql?"+q.Encode(), "")
+	data, _ = out["data"].(map[string]any)
+	if vars, _ := data["vars"].(map[string]any); status != fiber.StatusOK || vars["x"] != float64(2) {
+		t.Errorf("Unexpected GET response %d %v", status, out)
+	}
+
+	for _, body := range []string{`not json`, `{"query":""}`} {
+		if status, out := do(fiber.MethodPost, "/graphql", body); status != fiber.StatusBadRequest || out["errors"] == nil {
+			t.Errorf("%s: expected 400 with errors, got %d %v", body, status, out)
+		}
+	}
+}
+
+func TestLoaderBatches(t *testing.T) {
+	var calls atomic.Int32
+	var mu sync.Mutex
+	var seen [][]int
+	l := NewLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+		calls.Add(1)
+		mu.Lock()
+		seen = append(seen, slices.Sorted(slices.Values(keys)))
+		mu.Unlock()
+		out := make(map[int]string)
+		for _, k := range keys {
+			if k != 3 {
+				out[k] = strings.Repeat("x", k)
+			}
+		}
+		return out, nil
+	}, LoaderConfig{Wait: 10 * time.Millisecond})
+
+	values, errs := l.LoadMany(context.Background(), []int{1, 2, 2, 3})
+	if values[0] != "x" || values[1] != "xx" || values[2] != "xx" || errs[3] != ErrNotFound {
+		t.Errorf("Unexpected values %v errors %v", values, errs)
+	}
+	if calls.Load() != 1 || !slices.Equal(seen[0], []int{1, 2, 3}) {
+		t.Errorf("Expected one batch of distinct keys, got %v", seen)
+	}
+
+	// Memoized within the loader | 在加载器内缓存
+	if v, _ := l.Load(context.Background(), 2); v != "xx" || calls.Load() != 1 {
+		t.Errorf("Expected memoized value, got %q after %d calls", v, calls.Load())
+	}
+	l.Prime(9, "primed")
+	if v, _ := l.Load(context.Background(), 9); v != "primed" || calls.Load() != 1 {
+		t.Errorf("Expected primed value, got %q", v)
+	}
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	var sizes []int
+	var mu sync.Mutex
+	l := NewLoader(func(_ context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		sizes = append(sizes, len(keys))
+		mu.Unlock()
+		out := make(map[int]int)
+		for _, k := range keys {
+			out[k] = k
+		}
+		return out, nil
+	}, LoaderConfig{Wait: 50 * time.Millisecond, MaxBatch: 2})
+
+	_, errs := l.LoadMany(context.Background(), []int{1, 2, 3, 4, 5})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+	slices.Sort(sizes)
+	if !slices.Equal(sizes, []int{1, 2, 2}) {
+		t.Errorf("Expected batches of at most 2, got %v", sizes)
+	}
+}
+
+func TestLoaderCache(t *testing.T) {
+	shared := cache.New(nil, cache.Config{LocalTTL: time.Minute, LocalSize: 100, EnableLocal: true})
+	var calls atomic.Int32
+	newLoader := func() *Loader[int, string] {
+		return NewLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+			calls.Add(1)
+			return map[int]string{keys[0]: "v"}, nil
+		}, LoaderConfig{Cache: shared, TTL: time.Minute, Prefix: "gql:test:"})
+	}
+
+	// A second request is served by pkg/cache | 第二个请求由 pkg/cache 提供
+	for range 2 {
+		if v, err := newLoader().Load(context.Background(), 1); v != "v" || err != nil {
+			t.Fatalf("Load failed: %q %v", v, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected one fetch, got %d", calls.Load())
+	}
+}
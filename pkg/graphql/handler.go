@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Handler serves GraphQL over HTTP, POST with a JSON body or GET with query parameters
+// Responses follow the GraphQL format rather than the response envelope.
+// Handler 通过 HTTP 提供 GraphQL 服务，支持 JSON 请求体的 POST 或查询参数的 GET
+// 响应使用 GraphQL 格式而非统一响应结构。
+//
+// Usage | 用法:
+//
+//	app.All("/graphql", middleware.RequireAuth(), graphql.Handler())
+func Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req, err := parseRequest(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse(err))
+		}
+		return c.JSON(execute(c.UserContext(), req))
+	}
+}
+
+// parseRequest reads the request from the body or the query string
+// parseRequest 从请求体或查询字符串读取请求
+func parseRequest(c *fiber.Ctx) (*Request, error) {
+	req := &Request{}
+	switch c.Method() {
+	case fiber.MethodGet:
+		req.Query, req.OperationName = c.Query("query"), c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return nil, errors.New("variables must be a JSON object")
+			}
+		}
+	case fiber.MethodPost:
+		if err := json.Unmarshal(c.Body(), req); err != nil {
+			return nil, errors.New("body must be a JSON GraphQL request")
+		}
+	default:
+		return nil, errors.New("only GET and POST are supported")
+	}
+	if req.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	return req, nil
+}
+
+// execute runs req inside a span named after the operation
+// execute 在以操作名命名的 span 中执行 req
+func execute(ctx context.Context, req *Request) *Response {
+	name := "graphql"
+	if req.OperationName != "" {
+		name += " " + req.OperationName
+	}
+	ctx, span := trace.Start(ctx, name)
+	defer span.End()
+	span.SetAttributes(attribute.String("graphql.operation.name", req.OperationName))
+
+	resp := Execute(ctx, req)
+	if len(resp.Errors) > 0 {
+		span.SetAttributes(attribute.Int("graphql.errors", len(resp.Errors)))
+		span.SetStatus(codes.Error, resp.Errors[0].Message)
+	}
+	return resp
+}
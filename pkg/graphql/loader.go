@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/trace"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrNotFound is returned by Load for a key missing from the batch result
+// ErrNotFound 表示批量结果中不存在该键
+var ErrNotFound = errors.New("graphql: not found")
+
+// BatchFunc loads many keys at once, missing keys are left out of the map
+// BatchFunc 一次加载多个键，不存在的键不放入结果
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// LoaderConfig configures a Loader
+// LoaderConfig 配置 Loader
+type LoaderConfig struct {
+	Wait     time.Duration // Time collecting keys before a batch runs, default 2ms | 批量执行前收集键的时间，默认 2 毫秒
+	MaxBatch int           // Keys per batch, default 100 | 每批的键数，默认 100
+	Cache    *cache.Cache  // Shares values across requests, nil keeps them per request | 跨请求共享值，为 nil 时仅在请求内缓存
+	TTL      time.Duration // TTL of cached values | 缓存值的 TTL
+	Prefix   string        // Cache key prefix, e.g. "gql:user:" | 缓存键前缀，例如 "gql:user:"
+}
+
+// Loader batches and memoizes the lookups of one request, create one per request in Fragment.Context
+// Loader 对一个请求的查询进行批量合并和缓存，在 Fragment.Context 中为每个请求创建
+//
+// Usage | 用法:
+//
+//	users := graphql.NewLoader(func(ctx context.Context, ids []int64) (map[int64]*User, error) {
+//		return userDAO.FindByIDs(ctx, ids)
+//	}, graphql.LoaderConfig{Cache: cache.Get(), TTL: time.Minute, Prefix: "gql:user:"})
+//	user, err := users.Load(ctx, 42)
+type Loader[K comparable, V any] struct {
+	fetch  BatchFunc[K, V]
+	config LoaderConfig
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending *batch[K, V]
+}
+
+// result is the outcome of one key | result 是一个键的结果
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// batch collects keys until it runs | batch 在执行前收集键
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+// NewLoader creates a loader around fetch
+// NewLoader 基于 fetch 创建加载器
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], cfg LoaderConfig) *Loader[K, V] {
+	if cfg.Wait <= 0 {
+		cfg.Wait = 2 * time.Millisecond
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 100
+	}
+	return &Loader[K, V]{fetch: fetch, config: cfg, results: make(map[K]*result[V])}
+}
+
+// Load returns the value of key, ErrNotFound when the batch did not return it
+// Load 返回 key 的值，批量结果中不存在时返回 ErrNotFound
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		if l.pending == nil {
+			l.pending = &batch[K, V]{}
+			// The batch outlives a cancelled first caller, it still serves the others | 批次在首个调用方取消后仍为其他调用方服务
+			bctx := context.WithoutCancel(ctx)
+			b := l.pending
+			time.AfterFunc(l.config.Wait, func() { l.dispatch(bctx, b) })
+		}
+		l.pending.keys = append(l.pending.keys, key)
+		l.pending.results = append(l.pending.results, r)
+		if len(l.pending.keys) >= l.config.MaxBatch {
+			b := l.pending
+			l.pending = nil
+			go l.run(context.WithoutCancel(ctx), b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany loads keys in one batch, the values and errors follow the order of keys
+// LoadMany 在一个批次中加载多个键，值和错误与 keys 的顺序一致
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, []error) {
+	values, errs := make([]V, len(keys)), make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}()
+	}
+	wg.Wait()
+	return values, errs
+}
+
+// Prime stores a value already known, e.g. returned by a list query
+// Prime 保存已知的值，例如列表查询返回的值
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.results[key]; !ok {
+		r := &result[V]{done: make(chan struct{}), value: value}
+		close(r.done)
+		l.results[key] = r
+	}
+}
+
+// dispatch runs b if it is still pending | dispatch 在 b 仍待执行时执行它
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+	l.run(ctx, b)
+}
+
+// run resolves a batch from the cache, then fetch | run 先从缓存再通过 fetch 解析一个批次
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	ctx, span := trace.Start(ctx, "graphql.loader")
+	defer span.End()
+
+	missing := make([]K, 0, len(b.keys))
+	waiting := make([]*result[V], 0, len(b.keys))
+	for i, key := range b.keys {
+		r := b.results[i]
+		if l.config.Cache != nil && l.config.Cache.GetValue(ctx, l.cacheKey(key), &r.value) == nil {
+			close(r.done)
+			continue
+		}
+		missing = append(missing, key)
+		waiting = append(waiting, r)
+	}
+	span.SetAttributes(attribute.Int("graphql.loader.keys", len(b.keys)), attribute.Int("graphql.loader.fetched", len(missing)))
+	if len(missing) == 0 {
+		return
+	}
+
+	values, err := l.fetch(ctx, missing)
+	if err != nil {
+		trace.RecordError(ctx, err)
+	}
+	for i, key := range missing {
+		r := waiting[i]
+		switch v, ok := values[key]; {
+		case err != nil:
+			r.err = err
+		case !ok:
+			r.err = ErrNotFound
+		default:
+			r.value = v
+			if l.config.Cache != nil {
+				_ = l.config.Cache.SetValue(ctx, l.cacheKey(key), v, l.config.TTL)
+			}
+		}
+		close(r.done)
+	}
+}
+
+// cacheKey returns the cache key of key | cacheKey 返回 key 的缓存键
+func (l *Loader[K, V]) cacheKey(key K) string {
+	return l.config.Prefix + fmt.Sprint(key)
+}
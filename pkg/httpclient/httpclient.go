@@ -0,0 +1,147 @@
+// Package httpclient provides the outbound HTTP client used by modules
+// Clients share sane timeouts and connection pooling, retry idempotent requests with
+// backoff, propagate trace headers, record metrics and go through pkg/breaker per host.
+// httpclient 包提供模块使用的出站 HTTP 客户端
+// 客户端统一超时与连接池配置，对幂等请求进行退避重试，传播追踪头，记录指标，并按主机经过 pkg/breaker。
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/breaker"
+	"github.com/nuohe369/crab/pkg/discovery"
+)
+
+// Config represents HTTP client configuration
+// Config 表示 HTTP 客户端配置
+type Config struct {
+	Timeout             time.Duration `toml:"timeout"`                 // Whole request timeout including retries (default 30s) | 整个请求超时，包含重试（默认 30 秒）
+	DialTimeout         time.Duration `toml:"dial_timeout"`            // Connect timeout (default 5s) | 连接超时（默认 5 秒）
+	TLSHandshakeTimeout time.Duration `toml:"tls_handshake_timeout"`   // TLS handshake timeout (default 5s) | TLS 握手超时（默认 5 秒）
+	MaxIdleConns        int           `toml:"max_idle_conns"`          // Idle connections kept in total (default 100) | 保留的空闲连接总数（默认 100）
+	MaxIdleConnsPerHost int           `toml:"max_idle_conns_per_host"` // Idle connections kept per host (default 10) | 每个主机保留的空闲连接数（默认 10）
+	MaxConnsPerHost     int           `toml:"max_conns_per_host"`      // Connections per host, 0 means unlimited | 每个主机的连接数，0 表示不限制
+	IdleConnTimeout     time.Duration `toml:"idle_conn_timeout"`       // Idle connection lifetime (default 90s) | 空闲连接存活时间（默认 90 秒）
+	RetryMax            int           `toml:"retry_max"`               // Retries for idempotent requests, -1 disables (default 2) | 幂等请求的重试次数，-1 表示禁用（默认 2）
+	RetryWaitMin        time.Duration `toml:"retry_wait_min"`          // First backoff (default 100ms) | 首次退避时间（默认 100 毫秒）
+	RetryWaitMax        time.Duration `toml:"retry_wait_max"`          // Backoff cap, also caps Retry-After (default 2s) | 退避上限，同时限制 Retry-After（默认 2 秒）
+	DisableBreaker      bool          `toml:"disable_breaker"`         // Do not route calls through pkg/breaker | 不经过 pkg/breaker
+}
+
+// DefaultConfig returns default configuration
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		Timeout:             30 * time.Second,
+		DialTimeout:         5 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		RetryMax:            2,
+		RetryWaitMin:        100 * time.Millisecond,
+		RetryWaitMax:        2 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields of c from base | withDefaults 用 base 填充 c 中的零值字段
+func (c Config) withDefaults(base Config) Config {
+	if c.Timeout == 0 {
+		c.Timeout = base.Timeout
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = base.DialTimeout
+	}
+	if c.TLSHandshakeTimeout == 0 {
+		c.TLSHandshakeTimeout = base.TLSHandshakeTimeout
+	}
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = base.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = base.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout == 0 {
+		c.IdleConnTimeout = base.IdleConnTimeout
+	}
+	if c.RetryMax == 0 {
+		c.RetryMax = base.RetryMax
+	}
+	if c.RetryWaitMin == 0 {
+		c.RetryWaitMin = base.RetryWaitMin
+	}
+	if c.RetryWaitMax == 0 {
+		c.RetryWaitMax = base.RetryWaitMax
+	}
+	return c
+}
+
+// New creates a client; empty fields fall back to DefaultConfig
+// Each request is traced, retried when idempotent, resolved when it uses a service:// URL
+// (see pkg/discovery), measured and sent through the breaker of its host, in that order.
+// Retries resolve again, so they may reach another instance.
+// New 创建客户端，空字段回退到 DefaultConfig
+// 每个请求依次经过追踪、幂等重试、service:// URL 解析（参见 pkg/discovery）、指标记录以及目标主机的熔断器。
+// 重试时会重新解析，因此可能发往其他实例。
+func New(cfg Config) *http.Client {
+	cfg = cfg.withDefaults(DefaultConfig())
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	var rt http.RoundTripper = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if !cfg.DisableBreaker {
+		rt = &breaker.Transport{Base: rt}
+	}
+	rt = &metricsTransport{next: rt}
+	rt = discovery.Transport(rt)
+	if cfg.RetryMax > 0 {
+		rt = &retryTransport{next: rt, max: cfg.RetryMax, waitMin: cfg.RetryWaitMin, waitMax: cfg.RetryWaitMax}
+	}
+	rt = &traceTransport{next: rt}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: rt,
+	}
+}
+
+var (
+	defaultConfig Config
+	defaultClient *http.Client
+	clientOnce    sync.Once
+)
+
+// Init sets the configuration of the default client
+// Init 设置默认客户端的配置
+func Init(cfg Config) {
+	defaultConfig = cfg
+}
+
+// Get returns the default client, built from the Init configuration on first use
+// Get 返回默认客户端，首次使用时根据 Init 的配置创建
+//
+// Usage | 用法:
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/v1/rates", nil)
+//	resp, err := httpclient.Get().Do(req)
+func Get() *http.Client {
+	clientOnce.Do(func() {
+		defaultClient = New(defaultConfig)
+	})
+	return defaultClient
+}
@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/breaker"
+)
+
+// retryTransport retries idempotent requests on transport errors and retryable statuses
+// retryTransport 在传输错误和可重试状态码时重试幂等请求
+type retryTransport struct {
+	next    http.RoundTripper
+	max     int
+	waitMin time.Duration
+	waitMax time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+// RoundTrip 实现 http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !idempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if attempt >= t.max || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // Reuse the connection | 复用连接
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// idempotent reports whether a request is safe to send again
+// Non-idempotent methods qualify when they carry an Idempotency-Key header.
+// idempotent 判断请求是否可以安全地重复发送
+// 非幂等方法携带 Idempotency-Key 请求头时同样视为可重试。
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// retryable reports whether the outcome of an attempt is worth retrying
+// Breaker rejections and context errors are final.
+// retryable 判断一次尝试的结果是否值得重试
+// 熔断拒绝和 context 错误不会重试。
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !breaker.IsRejected(err) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt: Retry-After when present,
+// otherwise exponential backoff with jitter, capped at waitMax
+// backoff 返回下次尝试前的等待时间：优先使用 Retry-After，否则为带抖动的指数退避，上限为 waitMax
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			return min(time.Duration(s)*time.Second, t.waitMax)
+		}
+	}
+	d := min(t.waitMin<<attempt, t.waitMax)
+	if d <= 0 {
+		return t.waitMax
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryClient(max int) *http.Client {
+	return &http.Client{Transport: &retryTransport{
+		next:    http.DefaultTransport,
+		max:     max,
+		waitMin: time.Millisecond,
+		waitMax: 10 * time.Millisecond,
+	}}
+}
+
+func TestRetry_IdempotentRequest(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	resp, err := newRetryClient(2).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after retries, got %d", resp.StatusCode)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", hits.Load())
+	}
+}
+
+func TestRetry_GivesUpAfterMax(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	resp, err := newRetryClient(2).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || hits.Load() != 3 {
+		t.Errorf("Expected last 502 after 3 attempts, got %d after %d", resp.StatusCode, hits.Load())
+	}
+}
+
+func TestRetry_PostOnlyWithIdempotencyKey(t *testing.T) {
+	var hits atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client := newRetryClient(1)
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("order"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 {
+		t.Errorf("Expected POST not to be retried, got %d attempts", hits.Load())
+	}
+
+	hits.Store(0)
+	bodies = nil
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("order"))
+	req.Header.Set("Idempotency-Key", "k1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 2 {
+		t.Errorf("Expected POST with Idempotency-Key to be retried, got %d attempts", hits.Load())
+	}
+	for _, b := range bodies {
+		if b != "order" {
+			t.Errorf("Expected body to be resent, got %q", b)
+		}
+	}
+}
+
+func TestRetry_NotOnClientError(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	resp, err := newRetryClient(3).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 {
+		t.Errorf("Expected no retry on 404, got %d attempts", hits.Load())
+	}
+}
+
+func TestBackoff_RetryAfter(t *testing.T) {
+	rt := &retryTransport{waitMin: 100 * time.Millisecond, waitMax: 2 * time.Second}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"1"}}}
+	if d := rt.backoff(0, resp); d != time.Second {
+		t.Errorf("Expected Retry-After of 1s, got %v", d)
+	}
+	resp.Header.Set("Retry-After", "120")
+	if d := rt.backoff(0, resp); d != 2*time.Second {
+		t.Errorf("Expected Retry-After capped at 2s, got %v", d)
+	}
+	for attempt := 0; attempt < 70; attempt++ {
+		if d := rt.backoff(attempt, nil); d <= 0 || d > 2*time.Second {
+			t.Errorf("Backoff out of range at attempt %d: %v", attempt, d)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceTransport starts a client span and injects trace headers into the request
+// traceTransport 创建客户端 span 并向请求注入追踪头
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+// RoundTrip 实现 http.RoundTripper
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer("httpclient").Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.full", req.URL.Redacted()),
+		))
+	defer span.End()
+
+	r := req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// metricsTransport records the duration of every attempt
+// metricsTransport 记录每次尝试的耗时
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+// RoundTrip 实现 http.RoundTripper
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	if h := metrics.Histogram("http_client_request_duration_seconds", "Outbound HTTP request duration in seconds",
+		nil, "host", "method", "status"); h != nil {
+		h.WithLabelValues(req.URL.Host, req.Method, status).Observe(time.Since(start).Seconds())
+	}
+	return resp, err
+}
@@ -0,0 +1,138 @@
+package id
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+func TestULID_RoundTrip(t *testing.T) {
+	u := NewULID()
+	s := u.String()
+	if len(s) != 26 {
+		t.Fatalf("ULID length = %d, want 26", len(s))
+	}
+
+	parsed, err := ParseULID(s)
+	if err != nil {
+		t.Fatalf("ParseULID failed: %v", err)
+	}
+	if parsed != u {
+		t.Errorf("round trip mismatch: %s != %s", parsed, u)
+	}
+
+	if d := time.Since(u.Time()); d < 0 || d > time.Second {
+		t.Errorf("unexpected ULID time %v", u.Time())
+	}
+}
+
+func TestULID_Monotonic(t *testing.T) {
+	prev := NewULID().String()
+	for i := 0; i < 10000; i++ {
+		next := NewULID().String()
+		if next <= prev {
+			t.Fatalf("ULID not increasing: %s <= %s", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestParseULID_Invalid(t *testing.T) {
+	for _, s := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if _, err := ParseULID(s); err == nil {
+			t.Errorf("ParseULID(%q) expected error", s)
+		}
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	u := NewUUIDv7()
+	parsed, err := ParseUUID(u.String())
+	if err != nil {
+		t.Fatalf("ParseUUID failed: %v", err)
+	}
+	if parsed != u {
+		t.Errorf("round trip mismatch: %s != %s", parsed, u)
+	}
+	if d := time.Since(u.Time()); d < 0 || d > time.Second {
+		t.Errorf("unexpected UUID time %v", u.Time())
+	}
+}
+
+func TestBase62(t *testing.T) {
+	for _, n := range []int64{0, 1, 61, 62, 123456789, math.MaxInt64} {
+		s := EncodeBase62(n)
+		got, err := DecodeBase62(s)
+		if err != nil {
+			t.Fatalf("DecodeBase62(%q) failed: %v", s, err)
+		}
+		if got != n {
+			t.Errorf("DecodeBase62(EncodeBase62(%d)) = %d", n, got)
+		}
+	}
+
+	for _, s := range []string{"", "abc-", "zzzzzzzzzzz"} {
+		if _, err := DecodeBase62(s); err == nil {
+			t.Errorf("DecodeBase62(%q) expected error", s)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	snowflake.Init(1)
+
+	type payload struct {
+		ULID  ULID    `json:"ulid"`
+		UUID  UUID    `json:"uuid"`
+		Short ShortID `json:"short"`
+	}
+	in := payload{ULID: NewULID(), UUID: NewUUIDv7(), Short: NewShortID()}
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Unmarshal raw failed: %v", err)
+	}
+	if raw["short"] != in.Short.String() {
+		t.Errorf("short = %v, want %s", raw["short"], in.Short)
+	}
+
+	var out payload
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out != in {
+		t.Errorf("JSON round trip mismatch: %+v != %+v", out, in)
+	}
+}
+
+func TestDB(t *testing.T) {
+	u := NewULID()
+	v, _ := u.ToDB()
+	var u2 ULID
+	if err := u2.FromDB([]byte(v.(string))); err != nil || u2 != u {
+		t.Errorf("ULID DB round trip failed: %v", err)
+	}
+
+	id := NewUUIDv7()
+	v, _ = id.ToDB()
+	var id2 UUID
+	if err := id2.FromDB([]byte(v.(string))); err != nil || id2 != id {
+		t.Errorf("UUID DB round trip failed: %v", err)
+	}
+
+	var s ShortID
+	if err := s.FromDB([]byte("123456789")); err != nil || s != 123456789 {
+		t.Errorf("ShortID FromDB = %d, %v", s, err)
+	}
+	if v, _ := s.ToDB(); v != int64(123456789) {
+		t.Errorf("ShortID ToDB = %v", v)
+	}
+}
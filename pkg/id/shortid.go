@@ -0,0 +1,157 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/nuohe369/crab/pkg/snowflake"
+)
+
+// base62 is the alphabet used for short codes | base62 是短码使用的字母表
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidShortID is returned when decoding a malformed base62 code
+// ErrInvalidShortID 在解码格式错误的 base62 短码时返回
+var ErrInvalidShortID = errors.New("invalid short ID")
+
+// EncodeBase62 encodes a non-negative int64 as a base62 string
+// EncodeBase62 将非负 int64 编码为 base62 字符串
+func EncodeBase62(n int64) string {
+	if n <= 0 {
+		return "0"
+	}
+	var buf [11]byte // 62^11 > 2^63
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62[n%62]
+		n /= 62
+	}
+	return string(buf[i:])
+}
+
+// DecodeBase62 decodes a base62 string produced by EncodeBase62
+// DecodeBase62 解码由 EncodeBase62 生成的 base62 字符串
+func DecodeBase62(s string) (int64, error) {
+	if s == "" || len(s) > 11 {
+		return 0, ErrInvalidShortID
+	}
+	var n int64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		var v int64
+		switch {
+		case c >= '0' && c <= '9':
+			v = int64(c - '0')
+		case c >= 'A' && c <= 'Z':
+			v = int64(c-'A') + 10
+		case c >= 'a' && c <= 'z':
+			v = int64(c-'a') + 36
+		default:
+			return 0, ErrInvalidShortID
+		}
+		if n > (math.MaxInt64-v)/62 {
+			return 0, ErrInvalidShortID
+		}
+		n = n*62 + v
+	}
+	return n, nil
+}
+
+// ShortID is a snowflake ID presented as a base62 code (share links, order numbers)
+// It is stored as bigint like snowflake.SnowflakeID, but serialized to JSON as the short code.
+// ShortID 是以 base62 短码呈现的雪花 ID（分享链接、订单号）
+// 与 snowflake.SnowflakeID 一样以 bigint 存储，但 JSON 序列化为短码。
+type ShortID int64
+
+// NewShortID generates a ShortID from the default snowflake node
+// NewShortID 使用默认雪花节点生成 ShortID
+func NewShortID() ShortID {
+	return ShortID(snowflake.Generate())
+}
+
+// ParseShortID parses a base62 short code
+// ParseShortID 解析 base62 短码
+func ParseShortID(s string) (ShortID, error) {
+	n, err := DecodeBase62(s)
+	if err != nil {
+		return 0, err
+	}
+	return ShortID(n), nil
+}
+
+// String returns the base62 short code
+// String 返回 base62 短码
+func (s ShortID) String() string {
+	return EncodeBase62(int64(s))
+}
+
+// Int64 returns the int64 value of ShortID
+// Int64 返回 ShortID 的 int64 值
+func (s ShortID) Int64() int64 {
+	return int64(s)
+}
+
+// FromDB converts database value to ShortID (called by XORM when reading from database)
+// FromDB 将数据库值转换为 ShortID（XORM 从数据库读取时调用）
+func (s *ShortID) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*s = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return errors.New("failed to parse short ID: " + err.Error())
+	}
+	*s = ShortID(n)
+	return nil
+}
+
+// ToDB converts ShortID to database value (called by XORM when writing to database)
+// ToDB 将 ShortID 转换为数据库值（XORM 写入数据库时调用）
+func (s ShortID) ToDB() (driver.Value, error) {
+	return int64(s), nil
+}
+
+// MarshalJSON implements json.Marshaler interface to serialize as the short code
+// MarshalJSON 实现 json.Marshaler 接口，序列化为短码
+func (s ShortID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface to deserialize from short code or number
+// UnmarshalJSON 实现 json.Unmarshaler 接口，从短码或数字反序列化
+func (s *ShortID) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		n, err := DecodeBase62(str)
+		if err != nil {
+			return err
+		}
+		*s = ShortID(n)
+		return nil
+	}
+
+	var num int64
+	if err := json.Unmarshal(data, &num); err == nil {
+		*s = ShortID(num)
+		return nil
+	}
+
+	return errors.New("short ID must be a string or number")
+}
+
+// IsZero checks if the ShortID is zero
+// IsZero 检查 ShortID 是否为零
+func (s ShortID) IsZero() bool {
+	return s == 0
+}
+
+// Valid checks if the ShortID is valid (non-zero)
+// Valid 检查 ShortID 是否有效（非零）
+func (s ShortID) Valid() bool {
+	return s != 0
+}
@@ -0,0 +1,196 @@
+// Package id provides ULID, UUIDv7 and base62 short-ID generation
+// IDs convert to and from the database and JSON as strings, like snowflake.SnowflakeID.
+// Package id 提供 ULID、UUIDv7 和 base62 短 ID 生成
+// ID 与数据库和 JSON 之间以字符串形式转换，与 snowflake.SnowflakeID 一致。
+package id
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+// crockford 是 ULID 使用的 Crockford base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLen is the length of a ULID in text form | ulidLen 是 ULID 文本形式的长度
+const ulidLen = 26
+
+// ErrInvalidULID is returned when parsing a malformed ULID
+// ErrInvalidULID 在解析格式错误的 ULID 时返回
+var ErrInvalidULID = errors.New("invalid ULID")
+
+// ULID is a 128-bit lexicographically sortable identifier (48-bit ms timestamp + 80-bit randomness)
+// ULID 是 128 位可按字典序排序的标识符（48 位毫秒时间戳 + 80 位随机数）
+type ULID [16]byte
+
+var (
+	ulidMu      sync.Mutex
+	ulidLastMs  uint64
+	ulidLastRnd [10]byte
+)
+
+// NewULID generates a ULID; IDs generated in the same millisecond are strictly increasing
+// NewULID 生成 ULID；同一毫秒内生成的 ID 严格递增
+func NewULID() ULID {
+	ms := uint64(time.Now().UnixMilli())
+
+	ulidMu.Lock()
+	defer ulidMu.Unlock()
+
+	if ms <= ulidLastMs {
+		// Same millisecond (or clock behind): increment the random part | 同一毫秒（或时钟回拨）：递增随机部分
+		ms = ulidLastMs
+		if !incr(ulidLastRnd[:]) {
+			ms++
+			_, _ = rand.Read(ulidLastRnd[:])
+		}
+	} else {
+		_, _ = rand.Read(ulidLastRnd[:])
+	}
+	ulidLastMs = ms
+
+	var u ULID
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+	copy(u[6:], ulidLastRnd[:])
+	return u
+}
+
+// incr adds one to b as a big-endian number, reporting false on overflow
+// incr 将 b 作为大端数加一，溢出时返回 false
+func incr(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseULID parses a 26-character ULID string (case-insensitive)
+// ParseULID 解析 26 个字符的 ULID 字符串（不区分大小写）
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != ulidLen {
+		return u, ErrInvalidULID
+	}
+	// The first character carries only 3 bits | 第一个字符只携带 3 位
+	if v := decodeCrockford(s[0]); v < 0 || v > 7 {
+		return u, ErrInvalidULID
+	}
+
+	var hi, lo uint64 // 128 bits as two halves | 128 位拆为两半
+	for i := 0; i < ulidLen; i++ {
+		v := decodeCrockford(s[i])
+		if v < 0 {
+			return u, ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+// decodeCrockford returns the value of c, or -1 if it is not a Crockford character
+// decodeCrockford 返回 c 对应的值，不是 Crockford 字符时返回 -1
+func decodeCrockford(c byte) int {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// String returns the 26-character Crockford base32 form
+// String 返回 26 个字符的 Crockford base32 形式
+func (u ULID) String() string {
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+
+	var buf [ulidLen]byte
+	for i := ulidLen - 1; i >= 0; i-- {
+		buf[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(buf[:])
+}
+
+// Time returns the timestamp embedded in the ULID
+// Time 返回 ULID 中嵌入的时间戳
+func (u ULID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(binary.BigEndian.Uint32(u[2:6]))
+	return time.UnixMilli(int64(ms))
+}
+
+// FromDB converts database value to ULID (called by XORM when reading from database)
+// FromDB 将数据库值转换为 ULID（XORM 从数据库读取时调用）
+func (u *ULID) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*u = ULID{}
+		return nil
+	}
+	v, err := ParseULID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// ToDB converts ULID to database value (called by XORM when writing to database)
+// ToDB 将 ULID 转换为数据库值（XORM 写入数据库时调用）
+func (u ULID) ToDB() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// MarshalJSON implements json.Marshaler interface to serialize as string
+// MarshalJSON 实现 json.Marshaler 接口，序列化为字符串
+func (u ULID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface to deserialize from string
+// UnmarshalJSON 实现 json.Unmarshaler 接口，从字符串反序列化
+func (u *ULID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("ULID must be a string")
+	}
+	if s == "" {
+		*u = ULID{}
+		return nil
+	}
+	v, err := ParseULID(s)
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// IsZero checks if the ULID is zero
+// IsZero 检查 ULID 是否为零
+func (u ULID) IsZero() bool {
+	return u == ULID{}
+}
+
+// Valid checks if the ULID is valid (non-zero)
+// Valid 检查 ULID 是否有效（非零）
+func (u ULID) Valid() bool {
+	return !u.IsZero()
+}
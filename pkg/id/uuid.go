@@ -0,0 +1,104 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UUID is a time-ordered UUIDv7, stored and serialized in its canonical string form
+// UUID 是按时间排序的 UUIDv7，以标准字符串形式存储和序列化
+type UUID uuid.UUID
+
+// NewUUIDv7 generates a UUIDv7
+// NewUUIDv7 生成 UUIDv7
+func NewUUIDv7() UUID {
+	return UUID(uuid.Must(uuid.NewV7()))
+}
+
+// ParseUUID parses a UUID string of any version
+// ParseUUID 解析任意版本的 UUID 字符串
+func ParseUUID(s string) (UUID, error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return UUID{}, err
+	}
+	return UUID(u), nil
+}
+
+// String returns the canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form
+// String 返回标准 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx 形式
+func (u UUID) String() string {
+	return uuid.UUID(u).String()
+}
+
+// Time returns the timestamp embedded in a UUIDv7, zero for other versions
+// Time 返回 UUIDv7 中嵌入的时间戳，其他版本返回零值
+func (u UUID) Time() time.Time {
+	if uuid.UUID(u).Version() != 7 {
+		return time.Time{}
+	}
+	sec, nsec := uuid.UUID(u).Time().UnixTime()
+	return time.Unix(sec, nsec)
+}
+
+// FromDB converts database value to UUID (called by XORM when reading from database)
+// FromDB 将数据库值转换为 UUID（XORM 从数据库读取时调用）
+func (u *UUID) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*u = UUID{}
+		return nil
+	}
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return errors.New("failed to parse UUID: " + err.Error())
+	}
+	*u = v
+	return nil
+}
+
+// ToDB converts UUID to database value (called by XORM when writing to database)
+// ToDB 将 UUID 转换为数据库值（XORM 写入数据库时调用）
+func (u UUID) ToDB() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// MarshalJSON implements json.Marshaler interface to serialize as string
+// MarshalJSON 实现 json.Marshaler 接口，序列化为字符串
+func (u UUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface to deserialize from string
+// UnmarshalJSON 实现 json.Unmarshaler 接口，从字符串反序列化
+func (u *UUID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("UUID must be a string")
+	}
+	if s == "" {
+		*u = UUID{}
+		return nil
+	}
+	v, err := ParseUUID(s)
+	if err != nil {
+		return errors.New("invalid UUID string: " + err.Error())
+	}
+	*u = v
+	return nil
+}
+
+// IsZero checks if the UUID is zero
+// IsZero 检查 UUID 是否为零
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// Valid checks if the UUID is valid (non-zero)
+// Valid 检查 UUID 是否有效（非零）
+func (u UUID) Valid() bool {
+	return !u.IsZero()
+}
@@ -0,0 +1,229 @@
+// Package jobs runs ad-hoc background jobs on top of mq
+// Handlers enqueue typed jobs onto named queues; each queue is consumed by its own
+// pool of workers. Failed jobs are retried with exponential backoff, then kept
+// in a failed list that can be inspected and retried. Unique jobs hold a Redis
+// lock from enqueue until they finish, so duplicates are rejected meanwhile.
+// Package jobs 基于 mq 运行临时后台任务
+// 处理器将带类型的任务加入具名队列；每个队列由各自的工作协程池消费。
+// 失败的任务按指数退避重试，之后保存在可查看和重试的失败列表中。
+// 唯一任务从入队到完成期间持有 Redis 锁，期间的重复任务会被拒绝。
+//
+// Usage | 用法:
+//
+//	type WelcomePayload struct {
+//		UserID int64 `json:"user_id"`
+//	}
+//
+//	jobs.Register("user.welcome", jobs.Handle(func(ctx context.Context, p WelcomePayload) error {
+//		return sendWelcome(ctx, p.UserID)
+//	}))
+//
+//	id, err := jobs.Enqueue(ctx, "user.welcome", WelcomePayload{UserID: 42},
+//		jobs.Queue("mail"), jobs.Delay(time.Minute), jobs.Unique("welcome:42", time.Hour))
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultQueue is used when a job names no queue
+// DefaultQueue 在任务未指定队列时使用
+const DefaultQueue = "default"
+
+// ErrDuplicate is returned by Enqueue when a job with the same unique key is still pending
+// ErrDuplicate 在相同唯一键的任务仍未完成时由 Enqueue 返回
+var ErrDuplicate = errors.New("jobs: duplicate unique job")
+
+// ErrNotInitialized is returned when the default runner is not initialized (mq not configured)
+// ErrNotInitialized 在默认运行器未初始化（未配置 mq）时返回
+var ErrNotInitialized = errors.New("jobs: not initialized")
+
+// ErrJobNotFound is returned by Retry when the failed job does not exist
+// ErrJobNotFound 在失败任务不存在时由 Retry 返回
+var ErrJobNotFound = errors.New("jobs: failed job not found")
+
+// Config represents background job configuration
+// Config 表示后台任务配置
+type Config struct {
+	Queues        map[string]int `toml:"queues"`          // Worker count per queue, default {default = 1} | 每个队列的工作协程数，默认 {default = 1}
+	MaxRetries    int            `toml:"max_retries"`     // Retries before a job is marked failed, default 3, -1 disables | 标记失败前的重试次数，默认 3，-1 表示不重试
+	RetryDelay    time.Duration  `toml:"retry_delay"`     // First retry delay, doubled per attempt, default 10s | 首次重试延迟，每次翻倍，默认 10 秒
+	MaxRetryDelay time.Duration  `toml:"max_retry_delay"` // Retry delay cap, default 10m | 重试延迟上限，默认 10 分钟
+	Timeout       time.Duration  `toml:"timeout"`         // Per-attempt timeout, default 5m | 每次执行的超时，默认 5 分钟
+	AdminPath     string         `toml:"admin_path"`      // Dashboard endpoints, empty disables | 面板接口路径，为空时禁用
+	Permission    string         `toml:"permission"`      // Permission required by the dashboard (default "jobs:admin") | 面板要求的权限（默认 "jobs:admin"）
+}
+
+// withDefaults fills zero fields with defaults | withDefaults 用默认值填充零值字段
+func (c Config) withDefaults() Config {
+	if len(c.Queues) == 0 {
+		c.Queues = map[string]int{DefaultQueue: 1}
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = 10 * time.Second
+	}
+	if c.MaxRetryDelay <= 0 {
+		c.MaxRetryDelay = 10 * time.Minute
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Minute
+	}
+	if c.Permission == "" {
+		c.Permission = "jobs:admin"
+	}
+	return c
+}
+
+// Job is one queued unit of work
+// Job 表示一个排队的工作单元
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`                 // Registered handler name | 已注册的处理器名称
+	Queue      string          `json:"queue"`                // Queue name | 队列名称
+	Payload    json.RawMessage `json:"payload"`              // JSON payload | JSON 负载
+	Attempt    int             `json:"attempt"`              // Attempts made so far | 已执行次数
+	MaxRetries int             `json:"max_retries"`          // -1 disables retries | -1 表示不重试
+	UniqueKey  string          `json:"unique_key,omitempty"` // Unique lock key | 唯一锁键
+	EnqueuedAt time.Time       `json:"enqueued_at"`          // First enqueue time | 首次入队时间
+	Error      string          `json:"error,omitempty"`      // Last error | 最近一次错误
+	FailedAt   *time.Time      `json:"failed_at,omitempty"`  // When the job was marked failed | 标记为失败的时间
+}
+
+// Bind decodes the payload into v
+// Bind 将负载解码到 v
+func (j *Job) Bind(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler processes one job; returning an error retries it
+// Handler 处理一个任务；返回错误时重试
+type Handler func(ctx context.Context, job *Job) error
+
+// Handle adapts a function taking a typed payload into a Handler
+// Malformed payloads fail permanently.
+// Handle 将接收带类型负载的函数适配为 Handler
+// 负载格式错误时直接失败，不再重试。
+func Handle[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Bind(&payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// permanentError marks an error that must not be retried
+// permanentError 标记不应重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is marked failed without further retries
+// Permanent 包装 err，使任务直接标记为失败而不再重试
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped by Permanent
+// IsPermanent 判断 err 是否由 Permanent 包装
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Option configures an enqueued job
+// Option 配置入队的任务
+type Option func(*enqueueOptions)
+
+type enqueueOptions struct {
+	queue      string
+	delay      time.Duration
+	maxRetries *int
+	uniqueKey  string
+	uniqueTTL  time.Duration
+}
+
+// Queue puts the job on a named queue instead of DefaultQueue
+// Queue 将任务放入指定队列而不是 DefaultQueue
+func Queue(name string) Option {
+	return func(o *enqueueOptions) { o.queue = name }
+}
+
+// Delay runs the job no earlier than d from now
+// Delay 使任务在 d 之后才执行
+func Delay(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.delay = d }
+}
+
+// At runs the job no earlier than t
+// At 使任务在 t 之后才执行
+func At(t time.Time) Option {
+	return func(o *enqueueOptions) { o.delay = time.Until(t) }
+}
+
+// MaxRetries overrides [jobs] max_retries for this job, -1 disables retries
+// MaxRetries 为该任务覆盖 [jobs] max_retries，-1 表示不重试
+func MaxRetries(n int) Option {
+	return func(o *enqueueOptions) { o.maxRetries = &n }
+}
+
+// Unique rejects the job with ErrDuplicate while another job with key is pending
+// The lock is released when the job succeeds or fails for good, or after ttl (default 24h).
+// Unique 在另一个相同 key 的任务未完成时以 ErrDuplicate 拒绝该任务
+// 锁在任务成功或最终失败时释放，或在 ttl（默认 24 小时）后过期。
+func Unique(key string, ttl time.Duration) Option {
+	return func(o *enqueueOptions) {
+		o.uniqueKey = key
+		o.uniqueTTL = ttl
+	}
+}
+
+// newJob builds a job from the enqueue arguments
+// newJob 根据入队参数构建任务
+func newJob(typ string, payload any, cfg Config, o enqueueOptions) (*Job, error) {
+	if typ == "" {
+		return nil, errors.New("jobs: type is required")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: encode payload of %s: %w", typ, err)
+	}
+	j := &Job{
+		ID:         uuid.NewString(),
+		Type:       typ,
+		Queue:      o.queue,
+		Payload:    data,
+		MaxRetries: cfg.MaxRetries,
+		UniqueKey:  o.uniqueKey,
+		EnqueuedAt: time.Now(),
+	}
+	if j.Queue == "" {
+		j.Queue = DefaultQueue
+	}
+	if o.maxRetries != nil {
+		j.MaxRetries = *o.maxRetries
+	}
+	return j, nil
+}
+
+// topic returns the mq topic of a queue
+// topic 返回队列对应的 mq 主题
+func topic(queue string) string {
+	return "jobs:" + queue
+}
@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/mq"
+)
+
+type published struct {
+	topic   string
+	payload []byte
+	delay   time.Duration
+}
+
+type fakeMQ struct {
+	mu  sync.Mutex
+	out []published
+}
+
+func (q *fakeMQ) Publish(_ context.Context, topic string, payload []byte) error {
+	return q.PublishDelay(context.Background(), topic, payload, 0)
+}
+
+func (q *fakeMQ) PublishDelay(_ context.Context, topic string, payload []byte, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.out = append(q.out, published{topic, payload, delay})
+	return nil
+}
+
+func (q *fakeMQ) Consume(ctx context.Context, _, _ string, _ mq.Handler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (q *fakeMQ) Ack(context.Context, string, string, string) error { return nil }
+func (q *fakeMQ) Close() error                                      { return nil }
+func (q *fakeMQ) GetRaw() any                                       { return nil }
+
+// next pops the oldest published message
+func (q *fakeMQ) next(t *testing.T) published {
+	t.Helper()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.out) == 0 {
+		t.Fatal("nothing published")
+	}
+	p := q.out[0]
+	q.out = q.out[1:]
+	return p
+}
+
+type payload struct {
+	N int `json:"n"`
+}
+
+func TestEnqueueAndHandle(t *testing.T) {
+	ctx := context.Background()
+	q := &fakeMQ{}
+	r := New(q, nil, Config{})
+
+	var got int
+	r.Register("count", Handle(func(_ context.Context, p payload) error {
+		got = p.N
+		return nil
+	}))
+
+	if _, err := r.Enqueue(ctx, "count", payload{N: 7}, Queue("critical"), Delay(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	p := q.next(t)
+	if p.topic != "jobs:critical" || p.delay != time.Minute {
+		t.Fatalf("published to %s with delay %s", p.topic, p.delay)
+	}
+	if err := r.handle(ctx, &mq.Message{Payload: p.payload}); err != nil {
+		t.Fatal(err)
+	}
+	if got != 7 {
+		t.Errorf("handler got %d, want 7", got)
+	}
+	if c := r.countersOf("critical"); c.processed.Load() != 1 {
+		t.Errorf("processed = %d, want 1", c.processed.Load())
+	}
+}
+
+func TestRetryThenFail(t *testing.T) {
+	ctx := context.Background()
+	q := &fakeMQ{}
+	r := New(q, nil, Config{MaxRetries: 2, RetryDelay: time.Second})
+	r.Register("flaky", func(context.Context, *Job) error { return errors.New("boom") })
+
+	id, _ := r.Enqueue(ctx, "flaky", nil)
+	delays := []time.Duration{time.Second, 2 * time.Second}
+	for _, want := range delays {
+		if err := r.handle(ctx, &mq.Message{Payload: q.next(t).payload}); err != nil {
+			t.Fatal(err)
+		}
+		if p := q.out[0]; p.delay != want {
+			t.Errorf("retry delay = %s, want %s", p.delay, want)
+		}
+	}
+	r.handle(ctx, &mq.Message{Payload: q.next(t).payload})
+	if len(q.out) != 0 {
+		t.Fatal("job should not be retried after max_retries")
+	}
+
+	failed, _ := r.Failed(ctx, DefaultQueue)
+	if len(failed) != 1 || failed[0].ID != id || failed[0].Attempt != 3 || failed[0].Error != "boom" {
+		t.Fatalf("failed = %+v", failed)
+	}
+
+	// Retrying from the dashboard re-enqueues with a fresh attempt count
+	if err := r.Retry(ctx, DefaultQueue, id); err != nil {
+		t.Fatal(err)
+	}
+	if failed, _ = r.Failed(ctx, DefaultQueue); len(failed) != 0 {
+		t.Error("retried job should leave the failed list")
+	}
+	if err := r.Retry(ctx, DefaultQueue, id); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("second retry err = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestPermanentAndPanic(t *testing.T) {
+	ctx := context.Background()
+	q := &fakeMQ{}
+	r := New(q, nil, Config{})
+	r.Register("bad", func(context.Context, *Job) error { return Permanent(errors.New("invalid")) })
+	r.Register("panics", func(context.Context, *Job) error { panic("oops") })
+
+	r.Enqueue(ctx, "bad", nil)
+	r.handle(ctx, &mq.Message{Payload: q.next(t).payload})
+	if len(q.out) != 0 {
+		t.Error("permanent error should not be retried")
+	}
+
+	r.Enqueue(ctx, "panics", nil, MaxRetries(-1))
+	if err := r.handle(ctx, &mq.Message{Payload: q.next(t).payload}); err != nil {
+		t.Fatal(err)
+	}
+	failed, _ := r.Failed(ctx, DefaultQueue)
+	if len(failed) != 2 {
+		t.Fatalf("failed = %d, want 2", len(failed))
+	}
+}
+
+func TestUnique(t *testing.T) {
+	ctx := context.Background()
+	q := &fakeMQ{}
+	r := New(q, nil, Config{})
+	r.Register("sync", func(context.Context, *Job) error { return nil })
+
+	if _, err := r.Enqueue(ctx, "sync", nil, Unique("sync:1", time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Enqueue(ctx, "sync", nil, Unique("sync:1", time.Hour)); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("duplicate err = %v, want ErrDuplicate", err)
+	}
+
+	// Finishing the job releases the lock
+	r.handle(ctx, &mq.Message{Payload: q.next(t).payload})
+	if _, err := r.Enqueue(ctx, "sync", nil, Unique("sync:1", time.Hour)); err != nil {
+		t.Errorf("enqueue after completion: %v", err)
+	}
+}
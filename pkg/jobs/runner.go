@@ -0,0 +1,403 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/redis"
+)
+
+// group is the mq consumer group shared by all workers of a queue
+// group 是同一队列所有工作协程共享的 mq 消费者组
+const group = "jobs"
+
+// QueueStats describes one queue on the dashboard
+// Processed, Retried and Failed count this instance only.
+// QueueStats 描述面板上的一个队列
+// Processed、Retried 和 Failed 仅统计本实例。
+type QueueStats struct {
+	Queue     string `json:"queue"`
+	Workers   int    `json:"workers"`
+	Pending   int64  `json:"pending"` // Jobs waiting or in flight, -1 if unknown | 等待或处理中的任务数，未知时为 -1
+	Processed uint64 `json:"processed"`
+	Retried   uint64 `json:"retried"`
+	Failed    uint64 `json:"failed"`
+	FailedNow int    `json:"failed_now"` // Jobs currently in the failed list | 当前失败列表中的任务数
+}
+
+type counters struct {
+	processed atomic.Uint64
+	retried   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// Runner enqueues jobs and runs the worker pools
+// Runner 负责任务入队并运行工作协程池
+type Runner struct {
+	queue mq.MQ
+	store Store
+	cfg   Config
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	counters map[string]*counters
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// New creates a runner; a nil store keeps locks and failed jobs in memory
+// New 创建运行器；store 为 nil 时在内存中保存锁和失败任务
+func New(queue mq.MQ, store Store, cfg Config) *Runner {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	cfg = cfg.withDefaults()
+	r := &Runner{
+		queue:    queue,
+		store:    store,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		counters: make(map[string]*counters),
+	}
+	for name := range cfg.Queues {
+		r.counters[name] = &counters{}
+	}
+	return r
+}
+
+// Config returns the configuration with defaults applied
+// Config 返回已应用默认值的配置
+func (r *Runner) Config() Config {
+	return r.cfg
+}
+
+// Register sets the handler of a job type
+// Register 设置任务类型的处理器
+func (r *Runner) Register(typ string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[typ] = h
+}
+
+// Enqueue queues a job and returns its ID
+// Enqueue 将任务加入队列并返回其 ID
+func (r *Runner) Enqueue(ctx context.Context, typ string, payload any, opts ...Option) (string, error) {
+	var o enqueueOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	j, err := newJob(typ, payload, r.cfg, o)
+	if err != nil {
+		return "", err
+	}
+
+	if j.UniqueKey != "" {
+		ttl := o.uniqueTTL
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+		ok, err := r.store.Lock(ctx, j.UniqueKey, ttl)
+		if err != nil {
+			return "", fmt.Errorf("jobs: lock %s: %w", j.UniqueKey, err)
+		}
+		if !ok {
+			return "", ErrDuplicate
+		}
+	}
+
+	if err := r.publish(ctx, j, o.delay); err != nil {
+		if j.UniqueKey != "" {
+			r.store.Unlock(ctx, j.UniqueKey)
+		}
+		return "", fmt.Errorf("jobs: enqueue %s: %w", typ, err)
+	}
+	return j.ID, nil
+}
+
+// publish sends the job to its queue, delayed when delay > 0
+// publish 将任务发送到其队列，delay > 0 时延迟发送
+func (r *Runner) publish(ctx context.Context, j *Job, delay time.Duration) error {
+	payload, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	if delay > 0 {
+		return r.queue.PublishDelay(ctx, topic(j.Queue), payload, delay)
+	}
+	return r.queue.Publish(ctx, topic(j.Queue), payload)
+}
+
+// Start runs the configured number of workers for every queue
+// Start 为每个队列运行配置数量的工作协程
+func (r *Runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	for name, workers := range r.cfg.Queues {
+		for i := 0; i < max(workers, 1); i++ {
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				if err := r.queue.Consume(ctx, topic(name), group, r.handle); err != nil && ctx.Err() == nil {
+					log.Printf("jobs: worker of queue %s stopped: %v", name, err)
+				}
+			}()
+		}
+		log.Printf("jobs: queue %s started with %d worker(s)", name, max(workers, 1))
+	}
+}
+
+// Stop stops the workers and waits for in-flight jobs
+// Stop 停止工作协程并等待正在处理的任务
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	r.wg.Wait()
+	log.Println("jobs: workers stopped")
+}
+
+// handle runs one job; returning nil acknowledges the message
+// handle 运行一个任务；返回 nil 表示确认消息
+func (r *Runner) handle(ctx context.Context, m *mq.Message) error {
+	var j Job
+	if err := json.Unmarshal(m.Payload, &j); err != nil {
+		log.Printf("jobs: dropping malformed job %s: %v", m.ID, err)
+		return nil
+	}
+
+	j.Attempt++
+	err := r.run(ctx, &j)
+	c := r.countersOf(j.Queue)
+	if err == nil {
+		c.processed.Add(1)
+		r.release(ctx, &j)
+		return nil
+	}
+
+	j.Error = err.Error()
+	if IsPermanent(err) || j.MaxRetries < 0 || j.Attempt > j.MaxRetries {
+		c.failed.Add(1)
+		now := time.Now()
+		j.FailedAt = &now
+		log.Printf("jobs: job %s (%s) failed after %d attempt(s): %v", j.ID, j.Type, j.Attempt, err)
+		if serr := r.store.SaveFailed(ctx, &j); serr != nil {
+			log.Printf("jobs: failed to save failed job %s: %v", j.ID, serr)
+		}
+		r.release(ctx, &j)
+		return nil
+	}
+
+	if perr := r.publish(ctx, &j, r.backoff(j.Attempt)); perr != nil {
+		// Leave unacknowledged so the queue redelivers it | 不确认，由队列重新投递
+		return perr
+	}
+	c.retried.Add(1)
+	return nil
+}
+
+// run calls the handler with the per-attempt timeout, turning panics into errors
+// run 以单次执行超时调用处理器，并将 panic 转换为错误
+func (r *Runner) run(ctx context.Context, j *Job) (err error) {
+	r.mu.RLock()
+	h, ok := r.handlers[j.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler registered for %s", j.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(ctx, j)
+}
+
+// release drops the unique lock of a finished job
+// release 释放已完成任务的唯一锁
+func (r *Runner) release(ctx context.Context, j *Job) {
+	if j.UniqueKey == "" {
+		return
+	}
+	if err := r.store.Unlock(ctx, j.UniqueKey); err != nil {
+		log.Printf("jobs: failed to unlock %s: %v", j.UniqueKey, err)
+	}
+}
+
+// backoff returns the delay before retry attempt+1
+// backoff 返回第 attempt+1 次尝试前的延迟
+func (r *Runner) backoff(attempt int) time.Duration {
+	delay := r.cfg.RetryDelay
+	for i := 1; i < attempt && delay < r.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.MaxRetryDelay)
+}
+
+// countersOf returns the counters of a queue, creating them for unconfigured queues
+// countersOf 返回队列的计数器，未配置的队列会自动创建
+func (r *Runner) countersOf(queue string) *counters {
+	r.mu.RLock()
+	c, ok := r.counters[queue]
+	r.mu.RUnlock()
+	if ok {
+		return c
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok = r.counters[queue]; !ok {
+		c = &counters{}
+		r.counters[queue] = c
+	}
+	return c
+}
+
+// Stats returns the state of every configured queue, sorted by name
+// Stats 返回每个已配置队列的状态，按名称排序
+func (r *Runner) Stats(ctx context.Context) []QueueStats {
+	out := make([]QueueStats, 0, len(r.cfg.Queues))
+	for name, workers := range r.cfg.Queues {
+		c := r.countersOf(name)
+		st := QueueStats{
+			Queue:     name,
+			Workers:   max(workers, 1),
+			Pending:   -1,
+			Processed: c.processed.Load(),
+			Retried:   c.retried.Load(),
+			Failed:    c.failed.Load(),
+		}
+		if lagger, ok := r.queue.(mq.Lagger); ok {
+			if n, err := lagger.Lag(ctx, topic(name), group); err == nil {
+				st.Pending = n
+			}
+		}
+		if failed, err := r.store.Failed(ctx, name); err == nil {
+			st.FailedNow = len(failed)
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Queue < out[k].Queue })
+	return out
+}
+
+// Failed returns the failed jobs of a queue, most recent first
+// Failed 返回队列中失败的任务，最近的在前
+func (r *Runner) Failed(ctx context.Context, queue string) ([]Job, error) {
+	return r.store.Failed(ctx, queue)
+}
+
+// Retry re-enqueues a failed job with a fresh attempt count
+// Retry 以重置的执行次数重新入队一个失败的任务
+func (r *Runner) Retry(ctx context.Context, queue, id string) error {
+	j, err := r.store.GetFailed(ctx, queue, id)
+	if err != nil {
+		return err
+	}
+	if j == nil {
+		return ErrJobNotFound
+	}
+	j.Attempt, j.Error, j.FailedAt = 0, "", nil
+	if err := r.publish(ctx, j, 0); err != nil {
+		return err
+	}
+	return r.store.DeleteFailed(ctx, queue, id)
+}
+
+// DeleteFailed removes a failed job
+// DeleteFailed 删除一个失败的任务
+func (r *Runner) DeleteFailed(ctx context.Context, queue, id string) error {
+	return r.store.DeleteFailed(ctx, queue, id)
+}
+
+var (
+	defaultRunner *Runner // Default runner | 默认运行器
+
+	registryMu sync.Mutex
+	registry   = make(map[string]Handler) // Handlers registered before Init | Init 之前注册的处理器
+)
+
+// Init creates the default runner on the default MQ and Redis
+// Init 基于默认 MQ 和 Redis 创建默认运行器
+func Init(cfg Config) error {
+	queue := mq.Get()
+	if queue == nil {
+		return errors.New("jobs: mq is not initialized")
+	}
+	var store Store
+	if client := redis.Get(); client != nil {
+		store = NewRedisStore(client)
+	}
+	r := New(queue, store, cfg)
+
+	registryMu.Lock()
+	for typ, h := range registry {
+		r.Register(typ, h)
+	}
+	defaultRunner = r
+	registryMu.Unlock()
+	return nil
+}
+
+// Get returns the default runner, nil when mq is not configured
+// Get 返回默认运行器，未配置 mq 时为 nil
+func Get() *Runner {
+	return defaultRunner
+}
+
+// Register sets the handler of a job type on the default runner
+// Handlers may be registered before Init.
+// Register 在默认运行器上设置任务类型的处理器
+// 可以在 Init 之前注册处理器。
+func Register(typ string, h Handler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[typ] = h
+	if defaultRunner != nil {
+		defaultRunner.Register(typ, h)
+	}
+}
+
+// Enqueue queues a job on the default runner
+// Enqueue 将任务加入默认运行器的队列
+func Enqueue(ctx context.Context, typ string, payload any, opts ...Option) (string, error) {
+	if defaultRunner == nil {
+		return "", ErrNotInitialized
+	}
+	return defaultRunner.Enqueue(ctx, typ, payload, opts...)
+}
+
+// Start starts the workers of the default runner if configured
+// Start 启动已配置的默认运行器的工作协程
+func Start() {
+	if defaultRunner != nil {
+		defaultRunner.Start()
+	}
+}
+
+// Stop stops the workers of the default runner if running
+// Stop 停止正在运行的默认运行器的工作协程
+func Stop() {
+	if defaultRunner != nil {
+		defaultRunner.Stop()
+	}
+}
@@ -0,0 +1,183 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store holds unique locks and failed jobs
+// Store 保存唯一锁和失败的任务
+type Store interface {
+	Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) // false if already held | 已被持有时返回 false
+	Unlock(ctx context.Context, key string) error
+	SaveFailed(ctx context.Context, job *Job) error
+	Failed(ctx context.Context, queue string) ([]Job, error)       // Most recent first | 最近的在前
+	GetFailed(ctx context.Context, queue, id string) (*Job, error) // nil if missing | 不存在时返回 nil
+	DeleteFailed(ctx context.Context, queue, id string) error
+}
+
+// RedisClient defines the Redis client interface used by the Redis store
+// RedisClient 定义 Redis 存储使用的 Redis 客户端接口
+type RedisClient interface {
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key, field string, value any) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+// redisStore keeps locks under jobs:unique:{key} and failed jobs in the jobs:failed:{queue} hash
+// redisStore 将锁保存在 jobs:unique:{key} 下，失败任务保存在 jobs:failed:{queue} 哈希中
+type redisStore struct {
+	client RedisClient
+}
+
+// NewRedisStore creates a store backed by Redis
+// NewRedisStore 创建基于 Redis 的存储
+func NewRedisStore(client RedisClient) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, "jobs:unique:"+key, 1, ttl)
+}
+
+func (s *redisStore) Unlock(ctx context.Context, key string) error {
+	return s.client.Del(ctx, "jobs:unique:"+key)
+}
+
+func (s *redisStore) SaveFailed(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, "jobs:failed:"+job.Queue, job.ID, string(data))
+}
+
+func (s *redisStore) Failed(ctx context.Context, queue string) ([]Job, error) {
+	fields, err := s.client.HGetAll(ctx, "jobs:failed:"+queue)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Job, 0, len(fields))
+	for id, raw := range fields {
+		var j Job
+		if err := json.Unmarshal([]byte(raw), &j); err != nil {
+			return nil, fmt.Errorf("jobs: decode failed job %s: %w", id, err)
+		}
+		out = append(out, j)
+	}
+	sortFailed(out)
+	return out, nil
+}
+
+func (s *redisStore) GetFailed(ctx context.Context, queue, id string) (*Job, error) {
+	raw, err := s.client.HGet(ctx, "jobs:failed:"+queue, id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var j Job
+	if err := json.Unmarshal([]byte(raw), &j); err != nil {
+		return nil, fmt.Errorf("jobs: decode failed job %s: %w", id, err)
+	}
+	return &j, nil
+}
+
+func (s *redisStore) DeleteFailed(ctx context.Context, queue, id string) error {
+	return s.client.HDel(ctx, "jobs:failed:"+queue, id)
+}
+
+// memoryStore keeps locks and failed jobs in process, used when Redis is unavailable
+// memoryStore 在进程内保存锁和失败任务，Redis 不可用时使用
+type memoryStore struct {
+	mu     sync.Mutex
+	locks  map[string]time.Time
+	failed map[string]map[string]Job
+}
+
+// NewMemoryStore creates an in-process store
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() Store {
+	return &memoryStore{locks: make(map[string]time.Time), failed: make(map[string]map[string]Job)}
+}
+
+func (s *memoryStore) Lock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exp, ok := s.locks[key]; ok && time.Now().Before(exp) {
+		return false, nil
+	}
+	s.locks[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *memoryStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locks, key)
+	return nil
+}
+
+func (s *memoryStore) SaveFailed(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed[job.Queue] == nil {
+		s.failed[job.Queue] = make(map[string]Job)
+	}
+	s.failed[job.Queue][job.ID] = *job
+	return nil
+}
+
+func (s *memoryStore) Failed(_ context.Context, queue string) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0, len(s.failed[queue]))
+	for _, j := range s.failed[queue] {
+		out = append(out, j)
+	}
+	sortFailed(out)
+	return out, nil
+}
+
+func (s *memoryStore) GetFailed(_ context.Context, queue, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.failed[queue][id]
+	if !ok {
+		return nil, nil
+	}
+	return &j, nil
+}
+
+func (s *memoryStore) DeleteFailed(_ context.Context, queue, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failed[queue], id)
+	return nil
+}
+
+// sortFailed orders failed jobs most recent first
+// sortFailed 将失败任务按时间倒序排列
+func sortFailed(jobs []Job) {
+	sort.Slice(jobs, func(i, k int) bool {
+		a, b := jobs[i].FailedAt, jobs[k].FailedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+}
+
+// isNotFound checks if the error indicates a missing field
+// isNotFound 检查错误是否表示字段不存在
+func isNotFound(err error) bool {
+	return err != nil && err.Error() == "redis: nil"
+}
@@ -2,50 +2,39 @@
 // It wraps github.com/bytedance/sonic for better performance.
 // Package json 提供统一的 JSON 编码/解码接口
 // 它封装了 github.com/bytedance/sonic 以获得更好的性能
+//
+// The backend is chosen at build time, so calls compile down to the selected library:
+// 后端在编译时选择，调用直接编译到所选的库:
+//
+//	go build ./...               # bytedance/sonic (default | 默认)
+//	go build -tags gojson ./...  # goccy/go-json
+//	go build -tags jsonstd ./... # encoding/json
+//
+// sonic leaves HTML characters unescaped while the other two escape them like encoding/json.
+// sonic 不转义 HTML 字符，另外两个后端与 encoding/json 一样会转义。
+//
+// Compare them on a service payload with the benchmarks of this package:
+// 使用本包的基准测试在服务的负载上比较它们:
+//
+//	go test -tags gojson -run '^$' -bench . ./pkg/json
 package json
 
-import (
-	"io"
+import "io"
 
-	"github.com/bytedance/sonic"
-)
-
-var (
-	// Marshal returns the JSON encoding of v.
-	// Marshal 返回 v 的 JSON 编码
-	Marshal = sonic.Marshal
-
-	// MarshalIndent is like Marshal but with indentation.
-	// MarshalIndent 类似 Marshal 但带缩进
-	MarshalIndent = sonic.MarshalIndent
-
-	// Unmarshal parses the JSON-encoded data and stores the result in v.
-	// Unmarshal 解析 JSON 编码的数据并将结果存储在 v 中
-	Unmarshal = sonic.Unmarshal
-
-	// MarshalString returns the JSON encoding of v as a string.
-	// MarshalString 返回 v 的 JSON 编码字符串
-	MarshalString = sonic.MarshalString
-
-	// UnmarshalString parses the JSON-encoded string and stores the result in v.
-	// UnmarshalString 解析 JSON 编码的字符串并将结果存储在 v 中
-	UnmarshalString = sonic.UnmarshalString
-)
-
-// NewDecoder returns a new decoder that reads from r.
-// NewDecoder 返回从 r 读取的新解码器
-func NewDecoder(r io.Reader) sonic.Decoder {
-	return sonic.ConfigDefault.NewDecoder(r)
-}
-
-// NewEncoder returns a new encoder that writes to w.
-// NewEncoder 返回写入 w 的新编码器
-func NewEncoder(w io.Writer) sonic.Encoder {
-	return sonic.ConfigDefault.NewEncoder(w)
+// Encoder writes JSON values to an output stream, whatever the backend
+// Encoder 将 JSON 值写入输出流，与后端无关
+type Encoder interface {
+	Encode(v any) error
+	SetEscapeHTML(on bool)
+	SetIndent(prefix, indent string)
 }
 
-// Valid reports whether data is a valid JSON encoding.
-// Valid 报告 data 是否为有效的 JSON 编码
-func Valid(data []byte) bool {
-	return sonic.Valid(data)
+// Decoder reads JSON values from an input stream, whatever the backend
+// Decoder 从输入流读取 JSON 值，与后端无关
+type Decoder interface {
+	Decode(v any) error
+	More() bool
+	Buffered() io.Reader
+	UseNumber()
+	DisallowUnknownFields()
 }
@@ -0,0 +1,70 @@
+//go:build gojson && !jsonstd
+
+package json
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/goccy/go-json"
+)
+
+// Backend names the JSON library compiled in | Backend 为编译进来的 JSON 库名称
+const Backend = "go-json"
+
+var (
+	// Marshal returns the JSON encoding of v.
+	// Marshal 返回 v 的 JSON 编码
+	Marshal = json.Marshal
+
+	// MarshalIndent is like Marshal but with indentation.
+	// MarshalIndent 类似 Marshal 但带缩进
+	MarshalIndent = json.MarshalIndent
+
+	// Unmarshal parses the JSON-encoded data and stores the result in v.
+	// Unmarshal 解析 JSON 编码的数据并将结果存储在 v 中
+	Unmarshal = json.Unmarshal
+)
+
+// MarshalString returns the JSON encoding of v as a string.
+// MarshalString 返回 v 的 JSON 编码字符串
+func MarshalString(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// UnmarshalString parses the JSON-encoded string and stores the result in v.
+// UnmarshalString 解析 JSON 编码的字符串并将结果存储在 v 中
+func UnmarshalString(data string, v any) error {
+	return json.Unmarshal([]byte(data), v)
+}
+
+// MarshalAppend appends the JSON encoding of v to dst, encoding like Marshal
+// MarshalAppend 将 v 的 JSON 编码追加到 dst，编码方式与 Marshal 相同
+func MarshalAppend(dst []byte, v any) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return dst, err
+	}
+	// Encode terminates the value with a newline, Marshal does not | Encode 以换行结尾，Marshal 不会
+	out := buf.Bytes()
+	return out[:len(out)-1], nil
+}
+
+// NewDecoder returns a new decoder that reads from r.
+// NewDecoder 返回从 r 读取的新解码器
+func NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// NewEncoder returns a new encoder that writes to w.
+// NewEncoder 返回写入 w 的新编码器
+func NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// Valid reports whether data is a valid JSON encoding.
+// Valid 报告 data 是否为有效的 JSON 编码
+func Valid(data []byte) bool {
+	return json.Valid(data)
+}
@@ -0,0 +1,197 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+)
+
+type sample struct {
+	ID   int64    `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// payload is a typical API response body | payload 为典型的 API 响应体
+type payload struct {
+	Code int      `json:"code"`
+	Msg  string   `json:"msg"`
+	Data []sample `json:"data"`
+}
+
+func newPayload() *payload {
+	p := &payload{Msg: "success"}
+	for i := range 20 {
+		p.Data = append(p.Data, sample{ID: int64(i), Name: "crab " + strings.Repeat("x", i), Tags: []string{"a", "b"}})
+	}
+	return p
+}
+
+// TestBackendMatchesStd checks that the compiled-in backend encodes like encoding/json,
+// apart from HTML escaping which sonic leaves off
+// TestBackendMatchesStd 检查编译进来的后端与 encoding/json 编码一致，sonic 不转义 HTML 的差异除外
+func TestBackendMatchesStd(t *testing.T) {
+	p := newPayload()
+	want, err := stdjson.Marshal(p)
+	if err != nil {
+		t.Fatalf("encoding/json Marshal failed: %v", err)
+	}
+	got, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s Marshal = %s, want %s", Backend, got, want)
+	}
+
+	s, err := MarshalString(p)
+	if err != nil || s != string(want) {
+		t.Errorf("MarshalString = %s, %v, want %s", s, err, want)
+	}
+
+	var back payload
+	if err := UnmarshalString(s, &back); err != nil {
+		t.Fatalf("UnmarshalString failed: %v", err)
+	}
+	if len(back.Data) != len(p.Data) || back.Data[19].Name != p.Data[19].Name {
+		t.Errorf("UnmarshalString = %+v, want %+v", back, p)
+	}
+	if !Valid(want) || Valid(want[:len(want)-1]) {
+		t.Error("Valid disagrees with encoding/json")
+	}
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for i := range 2 {
+		if err := enc.Encode(sample{ID: int64(i), Name: "<crab>"}); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if !strings.Contains(buf.String(), "<crab>") {
+		t.Errorf("Expected HTML escaping to be off, got %s", buf.String())
+	}
+
+	dec := NewDecoder(&buf)
+	dec.DisallowUnknownFields()
+	var n int
+	for dec.More() {
+		var s sample
+		if err := dec.Decode(&s); err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if s.ID != int64(n) {
+			t.Errorf("Decode ID = %d, want %d", s.ID, n)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Decoded %d values, want 2", n)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"id":1,"unknown":true}`))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sample{}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestMarshalAppend(t *testing.T) {
+	v := sample{ID: 1, Name: "crab", Tags: []string{"a", "b"}}
+	want, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	got, err := MarshalAppend([]byte("prefix:"), v)
+	if err != nil {
+		t.Fatalf("MarshalAppend failed: %v", err)
+	}
+	if !bytes.Equal(got, append([]byte("prefix:"), want...)) {
+		t.Errorf("MarshalAppend = %s, want prefix:%s", got, want)
+	}
+
+	if _, err := MarshalAppend(nil, make(chan int)); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+}
+
+func TestBufferPool(t *testing.T) {
+	b := GetBuffer()
+	*b = append(*b, "stale"...)
+	PutBuffer(b)
+	if b := GetBuffer(); len(*b) != 0 {
+		t.Errorf("Expected an empty buffer, got %q", *b)
+	}
+
+	// Oversized buffers are dropped | 超大缓冲区会被丢弃
+	large := make([]byte, 0, maxPooledBuffer+1)
+	PutBuffer(&large)
+}
+
+// The payload benchmarks run the compiled-in backend next to encoding/json, build with
+// -tags gojson or -tags jsonstd to measure the other backends
+// 负载基准测试将编译进来的后端与 encoding/json 对比，使用 -tags gojson 或 -tags jsonstd 测量其他后端
+
+func BenchmarkPayloadMarshal(b *testing.B) {
+	p := newPayload()
+	b.Run("std", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			stdjson.Marshal(p)
+		}
+	})
+	b.Run(Backend, func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			Marshal(p)
+		}
+	})
+	b.Run(Backend+"/pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			buf := GetBuffer()
+			*buf, _ = MarshalAppend(*buf, p)
+			PutBuffer(buf)
+		}
+	})
+}
+
+func BenchmarkPayloadUnmarshal(b *testing.B) {
+	data, _ := stdjson.Marshal(newPayload())
+	b.Run("std", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var p payload
+			stdjson.Unmarshal(data, &p)
+		}
+	})
+	b.Run(Backend, func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var p payload
+			Unmarshal(data, &p)
+		}
+	})
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	v := sample{ID: 1, Name: "crab", Tags: []string{"a", "b"}}
+	b.ReportAllocs()
+	for b.Loop() {
+		Marshal(v)
+	}
+}
+
+func BenchmarkMarshalAppendPooled(b *testing.B) {
+	v := &sample{ID: 1, Name: "crab", Tags: []string{"a", "b"}}
+	b.ReportAllocs()
+	for b.Loop() {
+		buf := GetBuffer()
+		*buf, _ = MarshalAppend(*buf, v)
+		PutBuffer(buf)
+	}
+}
@@ -0,0 +1,33 @@
+package json
+
+import "sync"
+
+// maxPooledBuffer bounds the capacity of buffers returned to the pool, so one large
+// document does not keep its memory alive
+// maxPooledBuffer 限制放回池中的缓冲区容量，避免一次大文档长期占用内存
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// GetBuffer returns empty scratch space for encoding, give it back with PutBuffer
+// once the bytes are no longer referenced
+// GetBuffer 返回用于编码的空暂存空间，字节不再被引用后使用 PutBuffer 归还
+func GetBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// PutBuffer returns scratch space obtained from GetBuffer to the pool
+// PutBuffer 将 GetBuffer 获取的暂存空间归还到池中
+func PutBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
+}
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// JWK represents a single JSON Web Key (public part only)
+// JWK 表示单个 JSON Web Key（仅公钥部分）
+type JWK struct {
+	Kty string `json:"kty"`           // Key type: RSA or EC | 密钥类型：RSA 或 EC
+	Kid string `json:"kid,omitempty"` // Key ID | 密钥 ID
+	Alg string `json:"alg,omitempty"` // Algorithm | 算法
+	Use string `json:"use,omitempty"` // Public key use, always "sig" | 公钥用途，固定为 "sig"
+	N   string `json:"n,omitempty"`   // RSA modulus | RSA 模数
+	E   string `json:"e,omitempty"`   // RSA exponent | RSA 指数
+	Crv string `json:"crv,omitempty"` // EC curve | EC 曲线
+	X   string `json:"x,omitempty"`   // EC x coordinate | EC x 坐标
+	Y   string `json:"y,omitempty"`   // EC y coordinate | EC y 坐标
+}
+
+// JWKSet represents a JSON Web Key Set
+// JWKSet 表示 JSON Web Key 集合
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwksFetchTimeout is the HTTP timeout for fetching a remote JWKS
+// jwksFetchTimeout 获取远程 JWKS 的 HTTP 超时
+const jwksFetchTimeout = 10 * time.Second
+
+// toJWK converts a public key to JWK, returns false for symmetric keys
+// toJWK 将公钥转换为 JWK，对称密钥返回 false
+func toJWK(k *Key) (JWK, bool) {
+	enc := base64.RawURLEncoding
+
+	switch pub := k.verify.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Alg: k.Algorithm,
+			Use: "sig",
+			N:   enc.EncodeToString(pub.N.Bytes()),
+			E:   enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Kid: k.ID,
+			Alg: k.Algorithm,
+			Use: "sig",
+			Crv: pub.Curve.Params().Name,
+			X:   enc.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:   enc.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// fromJWK converts a JWK to a verification-only key
+// fromJWK 将 JWK 转换为仅用于验证的密钥
+func fromJWK(j JWK) (*Key, error) {
+	dec := base64.RawURLEncoding
+
+	switch j.Kty {
+	case "RSA":
+		n, err := dec.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid jwk modulus: %w", err)
+		}
+		e, err := dec.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid jwk exponent: %w", err)
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return NewRSAKey(j.Kid, nil, pub), nil
+
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedAlg, j.Crv)
+		}
+		x, err := dec.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid jwk x: %w", err)
+		}
+		y, err := dec.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid jwk y: %w", err)
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		return NewECDSAKey(j.Kid, nil, pub), nil
+
+	default:
+		return nil, fmt.Errorf("%w: kty %s", ErrUnsupportedAlg, j.Kty)
+	}
+}
+
+// fetchJWKS downloads and parses a remote JWKS
+// fetchJWKS 下载并解析远程 JWKS
+func fetchJWKS(ctx context.Context, url string) ([]*Key, error) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwt: fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwt: decode jwks: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		key, err := fromJWK(j)
+		if err != nil {
+			// Skip keys we cannot use instead of failing the whole set | 跳过无法使用的密钥，而不是整体失败
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
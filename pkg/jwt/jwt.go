@@ -3,8 +3,11 @@
 package jwt
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,19 +18,27 @@ var (
 	ErrExpiredToken = errors.New("token expired") // Expired token error | 令牌过期错误
 )
 
-// Claims represents JWT payload, stores only ID and Platform
-// Claims 表示 JWT 载荷，仅存储 ID 和平台
+// Claims represents JWT payload, stores only ID, Platform and Tenant
+// Claims 表示 JWT 载荷，仅存储 ID、平台和租户
 type Claims struct {
-	ID   int64  `json:"id"`   // User ID | 用户 ID
-	Plat string `json:"plat"` // Platform: admin/frontend | 平台：admin/frontend
+	ID     int64  `json:"id"`               // User ID | 用户 ID
+	Plat   string `json:"plat"`             // Platform: admin/frontend | 平台：admin/frontend
+	Tenant string `json:"tenant,omitempty"` // Tenant ID, empty for single-tenant apps | 租户 ID，单租户应用为空
 	jwt.RegisteredClaims
 }
 
 // Config represents JWT configuration
 // Config 表示 JWT 配置
 type Config struct {
-	Secret string `toml:"secret"` // Secret key | 密钥
-	Expire string `toml:"expire"` // Expiration duration e.g. "24h" | 过期时间，例如 "24h"
+	Secret        string      `toml:"secret"`         // Secret key (HS256) | 密钥（HS256）
+	Expire        string      `toml:"expire"`         // Expiration duration e.g. "24h" | 过期时间，例如 "24h"
+	Algorithm     string      `toml:"algorithm"`      // HS256 (default), RS256, ES256 | 签名算法，默认 HS256
+	KeyID         string      `toml:"key_id"`         // kid header of the signing key | 签名密钥的 kid 头
+	PrivateKey    string      `toml:"private_key"`    // PEM private key file (RS256/ES256) | PEM 私钥文件（RS256/ES256）
+	Keys          []KeyConfig `toml:"keys"`           // Previous keys that still validate | 仍可验证的旧密钥
+	JWKSURL       string      `toml:"jwks_url"`       // Remote JWKS for verification-only services | 仅验证服务使用的远程 JWKS
+	GraceWindow   string      `toml:"grace_window"`   // How long a rotated-out key still validates, default = expire | 轮换后旧密钥的有效期，默认等于 expire
+	RefreshWindow string      `toml:"refresh_window"` // How long after expiry Refresh still accepts a token, default 0 | 过期后 Refresh 仍接受令牌的时长，默认 0
 }
 
 // GetExpire parses expiration duration
@@ -40,19 +51,60 @@ func (c Config) GetExpire() time.Duration {
 	return d
 }
 
+// GetGraceWindow parses the rotation grace window, defaults to token lifetime
+// GetGraceWindow 解析轮换宽限期，默认等于令牌有效期
+func (c Config) GetGraceWindow() time.Duration {
+	d, _ := time.ParseDuration(c.GraceWindow)
+	if d <= 0 {
+		d = c.GetExpire()
+	}
+	return d
+}
+
+// GetRefreshWindow parses the refresh window, expired tokens are not refreshed by default
+// GetRefreshWindow 解析刷新窗口，默认不刷新已过期的令牌
+func (c Config) GetRefreshWindow() time.Duration {
+	d, _ := time.ParseDuration(c.RefreshWindow)
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// Enabled checks if any key material is configured
+// Enabled 检查是否配置了任何密钥
+func (c Config) Enabled() bool {
+	return c.Secret != "" || c.PrivateKey != "" || c.JWKSURL != ""
+}
+
+// jwksRefreshInterval limits how often an unknown kid triggers a JWKS refetch
+// jwksRefreshInterval 限制未知 kid 触发 JWKS 重新获取的频率
+const jwksRefreshInterval = time.Minute
+
 // Manager manages JWT operations
 // Manager 管理 JWT 操作
 type Manager struct {
-	secret []byte        // Secret key | 密钥
-	expire time.Duration // Expiration duration | 过期时间
+	mu          sync.RWMutex
+	signing     *Key            // Active signing key (nil for verification-only) | 当前签名密钥（仅验证时为 nil）
+	keys        map[string]*Key // Verification keys by kid | 按 kid 索引的验证密钥
+	expire      time.Duration   // Expiration duration | 过期时间
+	grace       time.Duration   // Rotation grace window | 轮换宽限期
+	refresh     time.Duration   // How long after expiry a token can be refreshed | 过期后令牌仍可刷新的时长
+	jwksURL     string          // Remote JWKS URL | 远程 JWKS 地址
+	jwksFetched time.Time       // Last JWKS fetch time | 上次获取 JWKS 的时间
 }
 
 var defaultMgr *Manager // Default manager instance | 默认管理器实例
 
 // Init initializes the default manager
 // Init 初始化默认管理器
-func Init(cfg Config) {
-	defaultMgr = New(cfg)
+func Init(cfg Config) error {
+	mgr, err := NewManager(cfg)
+	if err != nil {
+		return err
+	}
+	defaultMgr = mgr
+	return nil
 }
 
 // Get returns the default manager
@@ -64,28 +116,96 @@ func Get() *Manager {
 // MustInit initializes and panics on error
 // MustInit 初始化，失败时 panic
 func MustInit(cfg Config) {
-	if cfg.Secret == "" {
+	if !cfg.Enabled() {
 		log.Fatal("jwt secret cannot be empty")
 	}
-	Init(cfg)
+	if err := Init(cfg); err != nil {
+		log.Fatalf("jwt initialization failed: %v", err)
+	}
 }
 
-// New creates a JWT manager
-// New 创建 JWT 管理器
+// New creates an HS256 JWT manager from the configured secret
+// New 使用配置的密钥创建 HS256 JWT 管理器
+//
+// Use NewManager for asymmetric keys, rotation or JWKS, which can fail on key loading.
+// 非对称密钥、密钥轮换或 JWKS 请使用 NewManager，它会返回密钥加载错误。
 func New(cfg Config) *Manager {
+	m := newManager(cfg)
+	m.signing = NewHMACKey(cfg.KeyID, []byte(cfg.Secret))
+	m.keys[cfg.KeyID] = m.signing
+	return m
+}
+
+// NewManager creates a JWT manager with full key configuration
+// NewManager 使用完整的密钥配置创建 JWT 管理器
+func NewManager(cfg Config) (*Manager, error) {
+	m := newManager(cfg)
+
+	signing, err := loadSigningKey(cfg)
+	switch {
+	case err == nil:
+		m.signing = signing
+		m.keys[signing.ID] = signing
+	case errors.Is(err, ErrNoSigningKey) && cfg.JWKSURL != "":
+		// Verification-only service | 仅验证服务
+	default:
+		return nil, err
+	}
+
+	for _, kc := range cfg.Keys {
+		key, err := loadVerificationKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := m.keys[key.ID]; exists {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", key.ID)
+		}
+		m.keys[key.ID] = key
+	}
+
+	if m.jwksURL != "" {
+		if err := m.RefreshJWKS(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// newManager creates an empty manager
+// newManager 创建空的管理器
+func newManager(cfg Config) *Manager {
 	return &Manager{
-		secret: []byte(cfg.Secret),
-		expire: cfg.GetExpire(),
+		keys:    make(map[string]*Key),
+		expire:  cfg.GetExpire(),
+		grace:   cfg.GetGraceWindow(),
+		refresh: cfg.GetRefreshWindow(),
+		jwksURL: cfg.JWKSURL,
 	}
 }
 
 // Generate generates a JWT token
 // Generate 生成 JWT 令牌
 func (m *Manager) Generate(id int64, plat string) (string, error) {
+	return m.GenerateForTenant(id, plat, "")
+}
+
+// GenerateForTenant generates a JWT token bound to a tenant
+// GenerateForTenant 生成绑定到租户的 JWT 令牌
+func (m *Manager) GenerateForTenant(id int64, plat, tenant string) (string, error) {
+	m.mu.RLock()
+	key := m.signing
+	m.mu.RUnlock()
+
+	if key == nil || !key.CanSign() {
+		return "", ErrNoSigningKey
+	}
+
 	now := time.Now()
 	claims := Claims{
-		ID:   id,
-		Plat: plat,
+		ID:     id,
+		Plat:   plat,
+		Tenant: tenant,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.expire)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -93,37 +213,197 @@ func (m *Manager) Generate(id int64, plat string) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	token := jwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.sign)
 }
 
 // Parse parses and validates a JWT token
+// An expired token with a valid signature returns its claims along with ErrExpiredToken.
 // Parse 解析并验证 JWT 令牌
+// 签名有效但已过期的令牌会同时返回其载荷和 ErrExpiredToken。
 func (m *Manager) Parse(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (any, error) {
-		return m.secret, nil
-	})
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, m.keyFunc)
 
 	if err != nil {
+		// The signature is verified before the expiry | 签名在过期时间之前校验
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrExpiredToken
+			return claims, ErrExpiredToken
 		}
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if token.Valid {
 		return claims, nil
 	}
 
 	return nil, ErrInvalidToken
 }
 
-// Refresh refreshes an expired or valid token
-// Refresh 刷新过期或有效的令牌
+// keyFunc resolves the verification key from the kid header
+// keyFunc 根据 kid 头解析验证密钥
+func (m *Manager) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	key := m.lookup(kid)
+	if key == nil && kid != "" && m.jwksURL != "" && m.jwksStale() {
+		// Unknown kid, the issuer may have rotated | 未知 kid，签发方可能已轮换密钥
+		if err := m.RefreshJWKS(context.Background()); err == nil {
+			key = m.lookup(kid)
+		}
+	}
+	if key == nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Reject algorithm confusion (e.g. HS256 token verified with an RSA public key)
+	// 拒绝算法混淆（例如用 RSA 公钥验证 HS256 令牌）
+	if t.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	if key.expired(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+	return key.verify, nil
+}
+
+// lookup finds a verification key by kid, tokens without kid use the signing key
+// lookup 根据 kid 查找验证密钥，无 kid 的令牌使用签名密钥
+func (m *Manager) lookup(kid string) *Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if key, ok := m.keys[kid]; ok {
+		return key
+	}
+	if kid == "" {
+		return m.signing
+	}
+	return nil
+}
+
+// jwksStale checks if the remote JWKS may be refetched
+// jwksStale 检查是否可以重新获取远程 JWKS
+func (m *Manager) jwksStale() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.jwksFetched) >= jwksRefreshInterval
+}
+
+// RefreshJWKS reloads verification keys from the configured JWKS URL
+// RefreshJWKS 从配置的 JWKS 地址重新加载验证密钥
+func (m *Manager) RefreshJWKS(ctx context.Context) error {
+	if m.jwksURL == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	m.jwksFetched = time.Now()
+	m.mu.Unlock()
+
+	keys, err := fetchJWKS(ctx, m.jwksURL)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		// Local keys take precedence over remote ones | 本地密钥优先于远程密钥
+		if _, exists := m.keys[key.ID]; !exists {
+			m.keys[key.ID] = key
+		}
+	}
+	return nil
+}
+
+// Rotate makes key the active signing key
+// The previous signing key keeps validating tokens for the grace window
+// Rotate 将 key 设为当前签名密钥
+// 之前的签名密钥在宽限期内仍可验证令牌
+func (m *Manager) Rotate(key *Key) error {
+	if key == nil || !key.CanSign() {
+		return ErrNoSigningKey
+	}
+	if key.ID == "" {
+		return errors.New("jwt: rotated key must have a key id")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.signing != nil && m.signing.ID != key.ID {
+		// Retire a copy, keyFunc reads expiresAt of looked-up keys without the lock
+		// 退役其副本，keyFunc 在不持锁的情况下读取已查到密钥的 expiresAt
+		retired := *m.signing
+		retired.expiresAt = time.Now().Add(m.grace)
+		m.keys[retired.ID] = &retired
+	}
+	m.signing = key
+	m.keys[key.ID] = key
+
+	// Drop keys whose grace window has passed | 删除已超过宽限期的密钥
+	now := time.Now()
+	for id, k := range m.keys {
+		if k.expired(now) {
+			delete(m.keys, id)
+		}
+	}
+	return nil
+}
+
+// SigningKeyID returns the kid of the active signing key
+// SigningKeyID 返回当前签名密钥的 kid
+func (m *Manager) SigningKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.signing == nil {
+		return ""
+	}
+	return m.signing.ID
+}
+
+// Expire returns the lifetime of generated tokens
+// Expire 返回生成令牌的有效期
+func (m *Manager) Expire() time.Duration {
+	return m.expire
+}
+
+// JWKS returns the public keys as a JWK set, symmetric keys are never exposed
+// Serve it at /.well-known/jwks.json so other services can validate tokens
+// JWKS 以 JWK 集合返回公钥，对称密钥永不暴露
+// 可在 /.well-known/jwks.json 暴露，供其他服务验证令牌
+func (m *Manager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	set := JWKSet{Keys: make([]JWK, 0, len(m.keys))}
+	for _, k := range m.keys {
+		if k.expired(now) {
+			continue
+		}
+		if j, ok := toJWK(k); ok {
+			set.Keys = append(set.Keys, j)
+		}
+	}
+	return set
+}
+
+// Refresh refreshes a valid token, or one that expired within the refresh window
+// Refresh 刷新有效的令牌，或在刷新窗口内过期的令牌
 func (m *Manager) Refresh(tokenStr string) (string, error) {
 	claims, err := m.Parse(tokenStr)
-	if err != nil && !errors.Is(err, ErrExpiredToken) {
-		return "", err
+	if err != nil {
+		if !errors.Is(err, ErrExpiredToken) {
+			return "", err
+		}
+		if claims.ExpiresAt == nil || time.Since(claims.ExpiresAt.Time) > m.refresh {
+			return "", err
+		}
 	}
-	return m.Generate(claims.ID, claims.Plat)
+	return m.GenerateForTenant(claims.ID, claims.Plat, claims.Tenant)
 }
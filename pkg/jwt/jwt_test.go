@@ -50,8 +50,9 @@ func TestJWTGenerateAndParse(t *testing.T) {
 
 func TestJWTExpired(t *testing.T) {
 	mgr := New(Config{
-		Secret: "test-secret",
-		Expire: "1ms", // Very short expiration
+		Secret:        "test-secret",
+		Expire:        "1ms", // Very short expiration
+		RefreshWindow: "1h",
 	})
 
 	token, err := mgr.Generate(1, "test")
@@ -62,10 +63,37 @@ func TestJWTExpired(t *testing.T) {
 	// Wait for token to expire
 	time.Sleep(10 * time.Millisecond)
 
-	_, err = mgr.Parse(token)
+	claims, err := mgr.Parse(token)
 	if err != ErrExpiredToken {
 		t.Errorf("Expected ErrExpiredToken, got %v", err)
 	}
+	if claims == nil || claims.ID != 1 {
+		t.Errorf("Expired token should return its claims, got %+v", claims)
+	}
+
+	// A token expired within the refresh window can still be refreshed | 在刷新窗口内过期的令牌仍可刷新
+	if _, err := mgr.Refresh(token); err != nil {
+		t.Errorf("Refresh of expired token failed: %v", err)
+	}
+
+	// Without a refresh window expired tokens are rejected | 未配置刷新窗口时拒绝过期令牌
+	strict := New(Config{Secret: "test-secret", Expire: "1ms"})
+	if _, err := strict.Refresh(token); err != ErrExpiredToken {
+		t.Errorf("Expected ErrExpiredToken without refresh window, got %v", err)
+	}
+}
+
+func TestJWTRefreshOldToken(t *testing.T) {
+	// A negative lifetime issues tokens that expired two hours ago | 负的有效期签发两小时前已过期的令牌
+	old, err := New(Config{Secret: "test-secret", Expire: "-2h"}).Generate(1, "test")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	mgr := New(Config{Secret: "test-secret", Expire: "1h", RefreshWindow: "1h"})
+	if token, err := mgr.Refresh(old); err != ErrExpiredToken || token != "" {
+		t.Errorf("Expected ErrExpiredToken for a token past the refresh window, got %q %v", token, err)
+	}
 }
 
 func TestJWTInvalidToken(t *testing.T) {
@@ -131,6 +159,12 @@ func TestJWTRefresh(t *testing.T) {
 	if claims.ID != 123 || claims.Plat != "admin" {
 		t.Error("Refreshed token should preserve claims")
 	}
+
+	tenantToken, _ := mgr.GenerateForTenant(123, "admin", "acme")
+	refreshed, _ := mgr.Refresh(tenantToken)
+	if claims, err := mgr.Parse(refreshed); err != nil || claims.Tenant != "acme" {
+		t.Errorf("Refreshed token should preserve the tenant, got %+v %v", claims, err)
+	}
 }
 
 func TestConfigGetExpire(t *testing.T) {
@@ -0,0 +1,205 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256" // HMAC with SHA-256 (shared secret) | HMAC SHA-256（共享密钥）
+	AlgRS256 = "RS256" // RSA PKCS#1 v1.5 with SHA-256 | RSA PKCS#1 v1.5 SHA-256
+	AlgES256 = "ES256" // ECDSA P-256 with SHA-256 | ECDSA P-256 SHA-256
+)
+
+var (
+	ErrUnsupportedAlg = errors.New("jwt: unsupported signing algorithm") // Unsupported algorithm error | 不支持的算法错误
+	ErrNoSigningKey   = errors.New("jwt: no signing key configured")     // No signing key error | 未配置签名密钥错误
+)
+
+// KeyConfig represents a verification key kept during rotation
+// KeyConfig 表示轮换期间保留的验证密钥
+type KeyConfig struct {
+	ID        string `toml:"id"`         // Key ID (kid header) | 密钥 ID（kid 头）
+	Algorithm string `toml:"algorithm"`  // HS256, RS256, ES256 | 签名算法
+	Secret    string `toml:"secret"`     // Shared secret (HS256) | 共享密钥（HS256）
+	PublicKey string `toml:"public_key"` // PEM public key file (RS256/ES256) | PEM 公钥文件（RS256/ES256）
+	ExpiresAt string `toml:"expires_at"` // RFC3339 time after which the key is rejected, empty means never | 密钥失效时间（RFC3339），为空表示永不失效
+}
+
+// Key is a signing or verification key
+// Key 表示签名或验证密钥
+type Key struct {
+	ID        string    // Key ID (kid header) | 密钥 ID（kid 头）
+	Algorithm string    // Signing algorithm | 签名算法
+	sign      any       // Signing key: []byte, *rsa.PrivateKey, *ecdsa.PrivateKey | 签名密钥
+	verify    any       // Verification key: []byte, *rsa.PublicKey, *ecdsa.PublicKey | 验证密钥
+	expiresAt time.Time // Zero means never expires | 零值表示永不失效
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+// NewHMACKey 使用共享密钥创建 HS256 密钥
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: AlgHS256, sign: secret, verify: secret}
+}
+
+// NewRSAKey creates an RS256 key, priv may be nil for verification-only keys
+// NewRSAKey 创建 RS256 密钥，仅验证时 priv 可为 nil
+func NewRSAKey(id string, priv *rsa.PrivateKey, pub *rsa.PublicKey) *Key {
+	if pub == nil && priv != nil {
+		pub = &priv.PublicKey
+	}
+	k := &Key{ID: id, Algorithm: AlgRS256, verify: pub}
+	if priv != nil {
+		k.sign = priv
+	}
+	return k
+}
+
+// NewECDSAKey creates an ES256 key, priv may be nil for verification-only keys
+// NewECDSAKey 创建 ES256 密钥，仅验证时 priv 可为 nil
+func NewECDSAKey(id string, priv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) *Key {
+	if pub == nil && priv != nil {
+		pub = &priv.PublicKey
+	}
+	k := &Key{ID: id, Algorithm: AlgES256, verify: pub}
+	if priv != nil {
+		k.sign = priv
+	}
+	return k
+}
+
+// CanSign checks if the key holds private material
+// CanSign 检查密钥是否包含私钥
+func (k *Key) CanSign() bool {
+	return k.sign != nil
+}
+
+// ExpiresAt returns the time after which the key is rejected (zero means never)
+// ExpiresAt 返回密钥失效时间（零值表示永不失效）
+func (k *Key) ExpiresAt() time.Time {
+	return k.expiresAt
+}
+
+// expired checks if the key is past its grace window
+// expired 检查密钥是否已超过宽限期
+func (k *Key) expired(now time.Time) bool {
+	return !k.expiresAt.IsZero() && now.After(k.expiresAt)
+}
+
+// method returns the golang-jwt signing method for the key
+// method 返回密钥对应的 golang-jwt 签名方法
+func (k *Key) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgES256:
+		return jwt.SigningMethodES256
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// loadSigningKey builds the active signing key from configuration
+// loadSigningKey 根据配置构建当前签名密钥
+func loadSigningKey(cfg Config) (*Key, error) {
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = AlgHS256
+	}
+
+	switch alg {
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return nil, ErrNoSigningKey
+		}
+		return NewHMACKey(cfg.KeyID, []byte(cfg.Secret)), nil
+
+	case AlgRS256:
+		if cfg.PrivateKey == "" {
+			return nil, ErrNoSigningKey
+		}
+		data, err := os.ReadFile(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: read private key: %w", err)
+		}
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: parse RSA private key: %w", err)
+		}
+		return NewRSAKey(cfg.KeyID, priv, nil), nil
+
+	case AlgES256:
+		if cfg.PrivateKey == "" {
+			return nil, ErrNoSigningKey
+		}
+		data, err := os.ReadFile(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: read private key: %w", err)
+		}
+		priv, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: parse EC private key: %w", err)
+		}
+		return NewECDSAKey(cfg.KeyID, priv, nil), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+}
+
+// loadVerificationKey builds a verification-only key from configuration
+// loadVerificationKey 根据配置构建仅用于验证的密钥
+func loadVerificationKey(kc KeyConfig) (*Key, error) {
+	var key *Key
+
+	switch kc.Algorithm {
+	case AlgHS256, "":
+		if kc.Secret == "" {
+			return nil, fmt.Errorf("jwt: key %q has no secret", kc.ID)
+		}
+		key = NewHMACKey(kc.ID, []byte(kc.Secret))
+
+	case AlgRS256:
+		data, err := os.ReadFile(kc.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: read public key %q: %w", kc.ID, err)
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: parse RSA public key %q: %w", kc.ID, err)
+		}
+		key = NewRSAKey(kc.ID, nil, pub)
+
+	case AlgES256:
+		data, err := os.ReadFile(kc.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: read public key %q: %w", kc.ID, err)
+		}
+		pub, err := jwt.ParseECPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: parse EC public key %q: %w", kc.ID, err)
+		}
+		key = NewECDSAKey(kc.ID, nil, pub)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, kc.Algorithm)
+	}
+
+	if kc.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, kc.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q has invalid expires_at: %w", kc.ID, err)
+		}
+		key.expiresAt = t
+	}
+
+	return key, nil
+}
@@ -0,0 +1,199 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRS256FromKeyFile(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	path := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(priv))
+
+	mgr, err := NewManager(Config{Algorithm: AlgRS256, KeyID: "rsa-1", PrivateKey: path, Expire: "1h"})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	token, err := mgr.Generate(42, "admin")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	claims, err := mgr.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if claims.ID != 42 || claims.Plat != "admin" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestES256FromKeyFile(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+	path := writePEM(t, "ec.pem", "EC PRIVATE KEY", der)
+
+	mgr, err := NewManager(Config{Algorithm: AlgES256, KeyID: "ec-1", PrivateKey: path, Expire: "1h"})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	token, err := mgr.Generate(7, "frontend")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, err := mgr.Parse(token); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	if _, err := NewManager(Config{Algorithm: "none", Secret: "x"}); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+}
+
+func TestRotateGraceWindow(t *testing.T) {
+	mgr, err := NewManager(Config{Secret: "old-secret", KeyID: "v1", Expire: "1h", GraceWindow: "50ms"})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	oldToken, _ := mgr.Generate(1, "admin")
+
+	if err := mgr.Rotate(NewHMACKey("v2", []byte("new-secret"))); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if mgr.SigningKeyID() != "v2" {
+		t.Errorf("Expected signing key v2, got %s", mgr.SigningKeyID())
+	}
+
+	// Old token still valid within grace window
+	if _, err := mgr.Parse(oldToken); err != nil {
+		t.Errorf("Old token should validate during grace window: %v", err)
+	}
+
+	newToken, _ := mgr.Generate(1, "admin")
+	if _, err := mgr.Parse(newToken); err != nil {
+		t.Errorf("New token should validate: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := mgr.Parse(oldToken); err != ErrInvalidToken {
+		t.Errorf("Old token should be rejected after grace window, got %v", err)
+	}
+}
+
+func TestRotateConcurrentParse(t *testing.T) {
+	mgr, err := NewManager(Config{Secret: "old-secret", KeyID: "v1", Expire: "1h"})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	token, _ := mgr.Generate(1, "admin")
+
+	// Run with -race: parsing must not race with retiring the signing key
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			mgr.Parse(token)
+		}
+	}()
+	for i := range 20 {
+		if err := mgr.Rotate(NewHMACKey(fmt.Sprintf("v%d", i+2), []byte("new-secret"))); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+	<-done
+}
+
+func TestRejectAlgorithmConfusion(t *testing.T) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	rsaMgr := newManager(Config{Expire: "1h"})
+	rsaMgr.signing = NewRSAKey("k1", priv, nil)
+	rsaMgr.keys["k1"] = rsaMgr.signing
+
+	// HS256 token with the same kid must not verify against the RSA key
+	hsMgr := New(Config{Secret: "attacker", KeyID: "k1", Expire: "1h"})
+	token, _ := hsMgr.Generate(1, "admin")
+
+	if _, err := rsaMgr.Parse(token); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestJWKSVerification(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	issuer := newManager(Config{Expire: "1h"})
+	issuer.signing = NewECDSAKey("ec-remote", priv, nil)
+	issuer.keys["ec-remote"] = issuer.signing
+
+	set := issuer.JWKS()
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "ec-remote" {
+		t.Fatalf("unexpected JWKS: %+v", set)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+
+	verifier, err := NewManager(Config{JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	token, _ := issuer.Generate(99, "api")
+	claims, err := verifier.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse via JWKS failed: %v", err)
+	}
+	if claims.ID != 99 {
+		t.Errorf("Expected ID 99, got %d", claims.ID)
+	}
+
+	if _, err := verifier.Generate(1, "api"); err != ErrNoSigningKey {
+		t.Errorf("Verification-only manager should not sign, got %v", err)
+	}
+}
+
+func TestJWKSHidesSymmetricKeys(t *testing.T) {
+	mgr := New(Config{Secret: "secret", KeyID: "hs", Expire: "1h"})
+	if n := len(mgr.JWKS().Keys); n != 0 {
+		t.Errorf("Expected no public keys, got %d", n)
+	}
+}
@@ -0,0 +1,111 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/pkg/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// lease is a token-owned hold on a Redis key shared by RWMutex and Semaphore
+// Each instance owns one token, so concurrent holders use separate instances.
+// lease 是 RWMutex 和 Semaphore 共用的、由令牌持有的 Redis 键占用
+// 每个实例拥有一个令牌，因此并发持有者需使用各自的实例。
+type lease struct {
+	client   redis.UniversalClient
+	name     string
+	key      string // Redis key, the name unless the type hash-tags it | Redis 键，除非类型为其加哈希标签，否则即名称
+	token    string
+	config   Config
+	held     bool
+	watchdog *watchdog
+}
+
+// newLease creates a lease with a fresh token | newLease 创建带有新令牌的占用
+func newLease(client redis.UniversalClient, name string, cfg Config, opts []Option) lease {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return lease{client: client, name: name, key: name, token: uuid.NewString(), config: cfg}
+}
+
+// expiryMs returns the expiry in milliseconds for scripts | expiryMs 返回供脚本使用的毫秒过期时间
+func (l *lease) expiryMs() int64 {
+	if l.config.Expiry <= 0 {
+		return DefaultConfig().Expiry.Milliseconds()
+	}
+	return l.config.Expiry.Milliseconds()
+}
+
+// clock returns the time source of the lease | clock 返回占用的时间来源
+func (l *lease) clock() clock.Clock {
+	if l.config.Clock != nil {
+		return l.config.Clock
+	}
+	return clock.Real()
+}
+
+// acquire calls try up to Tries times, waiting RetryDelay between attempts
+// Returns ErrFailed when every attempt failed.
+// acquire 最多调用 try Tries 次，每次间隔 RetryDelay
+// 全部失败时返回 ErrFailed。
+func (l *lease) acquire(ctx context.Context, try, extend func(context.Context) (bool, error)) error {
+	tries := max(l.config.Tries, 1)
+	for i := 0; i < tries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-l.clock().After(l.config.RetryDelay):
+			}
+		}
+		ok, err := l.tryAcquire(ctx, try, extend)
+		if err != nil || ok {
+			return err
+		}
+	}
+	log.Debug("Failed to acquire lock [%s]", l.name)
+	return ErrFailed
+}
+
+// tryAcquire makes a single attempt and starts the watchdog on success
+// tryAcquire 尝试一次，成功时启动看门狗
+func (l *lease) tryAcquire(ctx context.Context, try, extend func(context.Context) (bool, error)) (bool, error) {
+	ok, err := try(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	l.held = true
+	if l.config.AutoRenew {
+		l.watchdog = startWatchdog(ctx, l.clock(), l.name, l.config.Expiry, extend)
+	}
+	log.Debug("Acquired lock [%s]", l.name)
+	return true, nil
+}
+
+// release stops the watchdog and runs the release script
+// release 停止看门狗并执行释放脚本
+func (l *lease) release(ctx context.Context, script *redis.Script, args ...any) (bool, error) {
+	if !l.held {
+		return false, nil
+	}
+	if l.watchdog != nil {
+		l.watchdog.stop()
+		l.watchdog = nil
+	}
+	n, err := script.Run(ctx, l.client, []string{l.key}, append([]any{l.token}, args...)...).Int()
+	if err != nil {
+		return false, err
+	}
+	l.held = false
+	log.Debug("Released lock [%s]", l.name)
+	return n == 1, nil
+}
+
+// run executes a script against the lease key and reports whether it returned 1
+// run 对占用的键执行脚本，并返回结果是否为 1
+func (l *lease) run(ctx context.Context, script *redis.Script, args ...any) (bool, error) {
+	n, err := script.Run(ctx, l.client, []string{l.key}, append([]any{l.token}, args...)...).Int()
+	return n == 1, err
+}
@@ -2,17 +2,22 @@ package lock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/go-redsync/redsync/v4"
-	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/nuohe369/crab/pkg/clock"
 	"github.com/nuohe369/crab/pkg/logger"
+	pkgredis "github.com/nuohe369/crab/pkg/redis"
 	"github.com/redis/go-redis/v9"
 )
 
 var log = logger.NewSystem("lock")
 
+// ErrFailed is returned when a lock could not be acquired within Tries attempts
+// ErrFailed 表示在 Tries 次尝试内未能获取锁
+var ErrFailed = errors.New("lock: failed to acquire lock")
+
 // Config represents distributed lock configuration
 // Config 表示分布式锁配置
 type Config struct {
@@ -20,7 +25,9 @@ type Config struct {
 	Tries         int           `toml:"tries"`          // Max retry attempts (default 32) | 最大重试次数（默认 32）
 	RetryDelay    time.Duration `toml:"retry_delay"`    // Retry interval (default 100ms) | 重试间隔（默认 100 毫秒）
 	DriftFactor   float64       `toml:"drift_factor"`   // Clock drift factor (default 0.01) | 时钟漂移因子（默认 0.01）
-	TimeoutFactor float64       `toml:"timeout_factor"` // Timeout factor (default 0.05) | 超时因子（默认 0.05）
+	TimeoutFactor float64       `toml:"timeout_factor"` // Per-attempt timeout as a factor of expiry (default 0.05) | 单次尝试超时占过期时间的比例（默认 0.05）
+	AutoRenew     bool          `toml:"auto_renew"`     // Renew the lock while it is held (default false) | 持有期间自动续期（默认 false）
+	Clock         clock.Clock   `toml:"-"`              // Time source of expiries and retries (default real time) | 过期与重试的时间来源（默认真实时间）
 }
 
 // DefaultConfig returns default configuration
@@ -38,8 +45,9 @@ func DefaultConfig() Config {
 // Locker is the distributed lock manager
 // Locker 是分布式锁管理器
 type Locker struct {
-	rs     *redsync.Redsync
+	client redis.UniversalClient
 	config Config
+	local  *pkgredis.Client // In-process server of NewLocal | NewLocal 的进程内服务器
 }
 
 var defaultLocker *Locker
@@ -47,133 +55,182 @@ var defaultLocker *Locker
 // Init initializes distributed lock
 // Init 初始化分布式锁
 func Init(client redis.UniversalClient, cfg Config) {
-	pool := goredis.NewPool(client)
 	defaultLocker = &Locker{
-		rs:     redsync.New(pool),
+		client: client,
 		config: cfg,
 	}
 	log.Info("Distributed lock initialized: expiry=%v, tries=%d", cfg.Expiry, cfg.Tries)
 }
 
+// NewLocal creates a Locker on an in-process Redis server, for single-instance deployments,
+// tests and local development; locks only exclude holders in the same process
+// NewLocal 创建基于进程内 Redis 服务器的 Locker，用于单实例部署、测试和本地开发；锁仅对同一进程内的持有者互斥
+func NewLocal(cfg Config) (*Locker, error) {
+	local, err := pkgredis.New(pkgredis.Config{Driver: "memory"})
+	if err != nil {
+		return nil, err
+	}
+	return &Locker{client: local.GetRaw().(redis.UniversalClient), config: cfg, local: local}, nil
+}
+
+// InitLocal initializes the default Locker with NewLocal
+// InitLocal 使用 NewLocal 初始化默认 Locker
+func InitLocal(cfg Config) error {
+	l, err := NewLocal(cfg)
+	if err != nil {
+		return err
+	}
+	defaultLocker = l
+	log.Info("Local lock initialized: expiry=%v, tries=%d", cfg.Expiry, cfg.Tries)
+	return nil
+}
+
+// Close stops the server of a NewLocal Locker, a Locker created by Init keeps its client open
+// Close 停止 NewLocal 创建的 Locker 的服务器，Init 创建的 Locker 保持其客户端打开
+func (l *Locker) Close() error {
+	if l.local == nil {
+		return nil
+	}
+	return l.local.Close()
+}
+
 // Get returns the default Locker
 // Get 返回默认 Locker
 func Get() *Locker {
 	return defaultLocker
 }
 
+// The mutex key holds the owner token. Acquiring sets it and increments the fencing
+// counter in one script, so tokens follow the order in which the lock was granted.
+// 互斥锁键保存持有者令牌。获取时在同一脚本中设置该键并递增 fencing 计数器，
+// 因此令牌顺序与锁的授予顺序一致。
+var (
+	mutexLockScript = redis.NewScript(`
+if not redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then return 0 end
+return redis.call('INCR', KEYS[2])`)
+
+	mutexExtendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+return redis.call('PEXPIRE', KEYS[1], ARGV[2])`)
+
+	mutexUnlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+return redis.call('DEL', KEYS[1])`)
+)
+
 // Mutex represents a distributed mutex
 // Mutex 表示分布式互斥锁
 type Mutex struct {
-	mu     *redsync.Mutex
-	name   string
-	locked bool
+	lease
+	fence int64
+	until time.Time
+}
+
+// FenceSuffix is appended to the mutex key to form its fencing counter key
+// A mutex named name is stored under "{name}" and its counter under "{name}:fence"; the hash tag
+// keeps both keys in one Redis Cluster slot so the lock script can touch them together.
+// The counter never expires so tokens keep increasing across lock expiries.
+// FenceSuffix 附加在互斥锁键后构成其 fencing 计数器键
+// 名为 name 的互斥锁存储在 "{name}"，计数器存储在 "{name}:fence"；哈希标签使两个键位于同一 Redis Cluster 槽，
+// 锁脚本才能同时操作它们。计数器永不过期，因此令牌在锁过期后仍保持递增。
+const FenceSuffix = ":fence"
+
+// MutexKey returns the Redis key of the mutex named name, its fencing counter is MutexKey(name)+FenceSuffix
+// MutexKey 返回名为 name 的互斥锁的 Redis 键，其 fencing 计数器为 MutexKey(name)+FenceSuffix
+func MutexKey(name string) string {
+	return "{" + name + "}"
 }
 
 // NewMutex creates a mutex
 // NewMutex 创建互斥锁
 func (l *Locker) NewMutex(name string, opts ...Option) *Mutex {
-	options := l.config
-	for _, opt := range opts {
-		opt(&options)
-	}
-
-	rsOpts := []redsync.Option{
-		redsync.WithExpiry(options.Expiry),
-		redsync.WithTries(options.Tries),
-		redsync.WithRetryDelay(options.RetryDelay),
-		redsync.WithDriftFactor(options.DriftFactor),
-		redsync.WithTimeoutFactor(options.TimeoutFactor),
-		redsync.WithGenValueFunc(func() (string, error) {
-			return fmt.Sprintf("%d", time.Now().UnixNano()), nil
-		}),
-	}
-
-	mu := l.rs.NewMutex(name, rsOpts...)
-
-	return &Mutex{
-		mu:   mu,
-		name: name,
-	}
+	m := &Mutex{lease: newLease(l.client, name, l.config, opts)}
+	m.key = MutexKey(name)
+	return m
 }
 
 // Lock acquires the lock
 // Lock 获取锁
 func (m *Mutex) Lock() error {
-	if err := m.mu.Lock(); err != nil {
-		log.Debug("Failed to acquire lock [%s]: %v", m.name, err)
-		return err
-	}
-	m.locked = true
-	log.Debug("Acquired lock [%s]", m.name)
-	return nil
+	return m.LockContext(context.Background())
 }
 
 // LockContext acquires the lock with context
 // LockContext 使用 context 获取锁
 func (m *Mutex) LockContext(ctx context.Context) error {
-	if err := m.mu.LockContext(ctx); err != nil {
-		log.Debug("Failed to acquire lock [%s]: %v", m.name, err)
-		return err
-	}
-	m.locked = true
-	log.Debug("Acquired lock [%s]", m.name)
-	return nil
+	return m.acquire(ctx, m.tryLock, m.extend)
 }
 
 // TryLock tries to acquire the lock (non-blocking)
 // TryLock 尝试获取锁（非阻塞）
 func (m *Mutex) TryLock() (bool, error) {
-	err := m.mu.TryLock()
-	if err != nil {
-		if err == redsync.ErrFailed {
-			return false, nil // Lock is held by another | 锁被其他持有
-		}
-		return false, err
-	}
-	m.locked = true
-	log.Debug("Acquired lock [%s]", m.name)
-	return true, nil
+	return m.TryLockContext(context.Background())
 }
 
 // TryLockContext tries to acquire the lock with context
 // TryLockContext 使用 context 尝试获取锁
 func (m *Mutex) TryLockContext(ctx context.Context) (bool, error) {
-	err := m.mu.TryLockContext(ctx)
-	if err != nil {
-		if err == redsync.ErrFailed {
-			return false, nil
-		}
+	return m.tryAcquire(ctx, m.tryLock, m.extend)
+}
+
+// tryLock sets the lock key and draws the fencing token in a single script
+// tryLock 在同一脚本中设置锁键并获取 fencing 令牌
+func (m *Mutex) tryLock(ctx context.Context) (bool, error) {
+	start := m.clock().Now()
+	if d := m.attemptTimeout(); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	keys := []string{m.key, m.key + FenceSuffix}
+	token, err := mutexLockScript.Run(ctx, m.client, keys, m.token, m.expiryMs()).Int64()
+	if err != nil || token == 0 {
 		return false, err
 	}
-	m.locked = true
-	log.Debug("Acquired lock [%s]", m.name)
+	m.fence = token
+	m.until = m.validUntil(start)
 	return true, nil
 }
 
+// extend resets the expiry while this instance still owns the key
+// extend 在本实例仍持有该键时重置过期时间
+func (m *Mutex) extend(ctx context.Context) (bool, error) {
+	start := m.clock().Now()
+	ok, err := m.run(ctx, mutexExtendScript, m.expiryMs())
+	if ok && err == nil {
+		m.until = m.validUntil(start)
+	}
+	return ok, err
+}
+
+// attemptTimeout bounds a single Redis round trip, 0 for none
+// attemptTimeout 限制单次 Redis 往返的时长，0 表示不限制
+func (m *Mutex) attemptTimeout() time.Duration {
+	return time.Duration(float64(m.expiryMs()) * m.config.TimeoutFactor * float64(time.Millisecond))
+}
+
+// validUntil returns when a hold taken at start expires, minus the clock drift allowance
+// validUntil 返回在 start 时获取的持有的过期时间，已扣除时钟漂移余量
+func (m *Mutex) validUntil(start time.Time) time.Time {
+	expiry := time.Duration(m.expiryMs()) * time.Millisecond
+	drift := time.Duration(float64(expiry)*m.config.DriftFactor) + 2*time.Millisecond
+	return start.Add(expiry - drift)
+}
+
 // Unlock releases the lock
 // Unlock 释放锁
 func (m *Mutex) Unlock() (bool, error) {
-	if !m.locked {
-		return false, nil
-	}
-	ok, err := m.mu.Unlock()
-	if ok {
-		m.locked = false
-		log.Debug("Released lock [%s]", m.name)
-	}
-	return ok, err
+	return m.UnlockContext(context.Background())
 }
 
 // UnlockContext releases the lock with context
+// It reports false when the lock had already expired or been taken by another holder.
 // UnlockContext 使用 context 释放锁
+// 锁已过期或已被其他持有者获取时返回 false。
 func (m *Mutex) UnlockContext(ctx context.Context) (bool, error) {
-	if !m.locked {
-		return false, nil
-	}
-	ok, err := m.mu.UnlockContext(ctx)
-	if ok {
-		m.locked = false
-		log.Debug("Released lock [%s]", m.name)
+	ok, err := m.release(ctx, mutexUnlockScript)
+	if !m.held {
+		m.fence = 0
 	}
 	return ok, err
 }
@@ -181,13 +238,16 @@ func (m *Mutex) UnlockContext(ctx context.Context) (bool, error) {
 // Extend extends the lock expiry time
 // Extend 延长锁过期时间
 func (m *Mutex) Extend() (bool, error) {
-	return m.mu.Extend()
+	return m.ExtendContext(context.Background())
 }
 
 // ExtendContext extends the lock expiry time with context
 // ExtendContext 使用 context 延长锁过期时间
 func (m *Mutex) ExtendContext(ctx context.Context) (bool, error) {
-	return m.mu.ExtendContext(ctx)
+	if !m.held {
+		return false, nil
+	}
+	return m.extend(ctx)
 }
 
 // Name returns the lock name
@@ -199,13 +259,31 @@ func (m *Mutex) Name() string {
 // IsLocked checks if the lock is held
 // IsLocked 检查锁是否被持有
 func (m *Mutex) IsLocked() bool {
-	return m.locked
+	return m.held
+}
+
+// Token returns the fencing token of the current hold, 0 when the lock is not held
+// Tokens increase with every acquisition of the same name. Pass the token along with
+// writes and have the downstream system reject tokens lower than the last one it saw,
+// so a holder whose lock expired (e.g. after a long GC pause) cannot overwrite newer data.
+// Token 返回当前持有的 fencing 令牌，未持有锁时返回 0
+// 同名锁每次获取时令牌递增。写入时携带令牌，并让下游系统拒绝小于其已见最大值的令牌，
+// 这样锁已过期的持有者（例如长时间 GC 停顿后）无法覆盖更新的数据。
+//
+// Usage | 用法:
+//
+//	db.Exec("UPDATE doc SET body = ?, fence = ? WHERE id = ? AND fence < ?", body, mu.Token(), id, mu.Token())
+func (m *Mutex) Token() int64 {
+	if !m.held {
+		return 0
+	}
+	return m.fence
 }
 
 // Until returns the lock expiry time
 // Until 返回锁过期时间
 func (m *Mutex) Until() time.Time {
-	return m.mu.Until()
+	return m.until
 }
 
 // Option is a configuration option
@@ -236,6 +314,27 @@ func WithRetryDelay(d time.Duration) Option {
 	}
 }
 
+// WithAutoRenew keeps extending the lock every expiry/3 while it is held
+// Renewal stops on Unlock or when the context passed to LockContext/TryLockContext is done.
+// Use it for work that may outlast the expiry; the expiry then only bounds how long
+// a crashed holder blocks others.
+// WithAutoRenew 在持有锁期间每隔 expiry/3 自动续期
+// 续期在 Unlock 或传给 LockContext/TryLockContext 的 context 结束时停止。
+// 适用于可能超过过期时间的任务，此时过期时间仅限制崩溃的持有者阻塞他人的时长。
+func WithAutoRenew() Option {
+	return func(c *Config) {
+		c.AutoRenew = true
+	}
+}
+
+// WithClock sets the time source of expiries, retries and renewals, for tests
+// WithClock 设置过期、重试和续期的时间来源，用于测试
+func WithClock(c clock.Clock) Option {
+	return func(cfg *Config) {
+		cfg.Clock = c
+	}
+}
+
 // NewMutex creates a mutex using default Locker
 // NewMutex 使用默认 Locker 创建互斥锁
 // Returns nil if lock is not initialized
@@ -1,13 +1,40 @@
 package lock
 
 import (
+	"context"
+	"os"
 	"testing"
 	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/clock"
+	"github.com/nuohe369/crab/pkg/logger"
+	"github.com/nuohe369/crab/pkg/testing/containers"
+	"github.com/redis/go-redis/v9"
 )
 
-// 注意：这些测试需要真实的 Redis 实例
-// miniredis 对 redsync 使用的 Lua 脚本支持有限
-// 运行测试前请确保 Redis 在 localhost:6379 运行
+// TestMain writes the system log to a temporary directory instead of the package directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lock-logs")
+	if err != nil {
+		panic(err)
+	}
+	logger.SetConfig(logger.Config{Enabled: true, Dir: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestLocker returns a Locker backed by miniredis
+func newTestLocker(t *testing.T) (*Locker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	cfg := DefaultConfig()
+	cfg.Tries = 1
+	return &Locker{client: client, config: cfg}, mr
+}
 
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
@@ -63,23 +90,198 @@ func TestNewMutex_NotInitialized(t *testing.T) {
 	}
 }
 
-// 以下测试需要真实 Redis，默认跳过
-// 运行方式: go test -v -run TestIntegration -tags=integration
+func TestMutexToken_NotHeld(t *testing.T) {
+	m := &Mutex{fence: 7}
+	if m.Token() != 0 {
+		t.Errorf("Expected token 0 when lock not held, got %d", m.Token())
+	}
+
+	m.held = true
+	if m.Token() != 7 {
+		t.Errorf("Expected token 7, got %d", m.Token())
+	}
+}
+
+func TestMutex_LockUnlock(t *testing.T) {
+	l, mr := newTestLocker(t)
+
+	a := l.NewMutex("order:1")
+	if err := a.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if !a.IsLocked() || a.Token() != 1 {
+		t.Errorf("Expected held lock with token 1, got %v, %d", a.IsLocked(), a.Token())
+	}
+	if ttl := mr.TTL(MutexKey("order:1")); ttl <= 0 || ttl > 8*time.Second {
+		t.Errorf("Expected lock TTL within 8s, got %v", ttl)
+	}
+	if !a.Until().After(time.Now()) {
+		t.Error("Expected Until in the future")
+	}
 
-/*
+	b := l.NewMutex("order:1")
+	if ok, err := b.TryLock(); ok || err != nil {
+		t.Errorf("Expected TryLock to fail while held, got %v, %v", ok, err)
+	}
+	if err := b.Lock(); err != ErrFailed {
+		t.Errorf("Expected ErrFailed, got %v", err)
+	}
+	// A failed attempt must not consume a token | 获取失败不应消耗令牌
+	if v, _ := mr.Get("{order:1}" + FenceSuffix); v != "1" {
+		t.Errorf("Expected fence counter 1, got %s", v)
+	}
+
+	if ok, err := a.Unlock(); !ok || err != nil {
+		t.Fatalf("Unlock = %v, %v", ok, err)
+	}
+	if a.IsLocked() || a.Token() != 0 {
+		t.Error("Expected lock released")
+	}
+	if ok, err := b.TryLock(); !ok || err != nil || b.Token() != 2 {
+		t.Errorf("Expected TryLock with token 2, got %v, %v, %d", ok, err, b.Token())
+	}
+}
+
+func TestNewLocal(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tries = 1
+	l, err := NewLocal(cfg)
+	if err != nil {
+		t.Fatalf("NewLocal failed: %v", err)
+	}
+	defer l.Close()
+
+	a, b := l.NewMutex("order:1", WithExpiry(200*time.Millisecond)), l.NewMutex("order:1")
+	if err := a.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if ok, _ := b.TryLock(); ok {
+		t.Error("Expected TryLock to fail while held")
+	}
+	// The lock expires with the wall clock | 锁按实际时间过期
+	time.Sleep(400 * time.Millisecond)
+	if ok, err := b.TryLock(); !ok || err != nil || b.Token() != 2 {
+		t.Errorf("Expected TryLock with token 2 after expiry, got %v, %v, %d", ok, err, b.Token())
+	}
+
+	s := l.NewSemaphore("export", 1)
+	if ok, err := s.TryAcquire(context.Background()); !ok || err != nil {
+		t.Errorf("TryAcquire = %v, %v", ok, err)
+	}
+}
+
+func TestMutex_RetriesOnClock(t *testing.T) {
+	l, _ := newTestLocker(t)
+	fake := clock.NewFake(time.Now())
+	a := l.NewMutex("order:1", WithClock(fake))
+	b := l.NewMutex("order:1", WithClock(fake), WithTries(3))
+	if err := a.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if !a.Until().Equal(fake.Now().Add(8*time.Second - 82*time.Millisecond)) {
+		t.Errorf("Until = %v, want the expiry minus drift on the fake clock", a.Until())
+	}
+
+	done := make(chan error)
+	go func() { done <- b.Lock() }()
+
+	// The first attempt fails, then b waits RetryDelay on the clock | 首次尝试失败后 b 在时钟上等待 RetryDelay
+	fake.BlockUntil(1)
+	a.Unlock()
+	fake.Advance(DefaultConfig().RetryDelay)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the retry to acquire the lock, got %v", err)
+	}
+}
+
+func TestMutex_ExpiredHolder(t *testing.T) {
+	l, mr := newTestLocker(t)
+
+	a := l.NewMutex("job", WithExpiry(time.Second))
+	if err := a.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	mr.FastForward(2 * time.Second)
+
+	b := l.NewMutex("job", WithExpiry(time.Second))
+	if err := b.Lock(); err != nil {
+		t.Fatalf("Lock after expiry failed: %v", err)
+	}
+	if b.Token() <= a.Token() {
+		t.Errorf("Expected new token %d above stale token %d", b.Token(), a.Token())
+	}
+
+	// The stale holder can neither extend nor release the new hold | 过期的持有者既不能续期也不能释放新的持有
+	if ok, _ := a.Extend(); ok {
+		t.Error("Expected stale holder Extend to fail")
+	}
+	if ok, _ := a.Unlock(); ok {
+		t.Error("Expected stale holder Unlock to fail")
+	}
+	if !mr.Exists(MutexKey("job")) {
+		t.Fatal("Expected the new hold to survive")
+	}
+
+	mr.FastForward(500 * time.Millisecond)
+	if ok, err := b.Extend(); !ok || err != nil {
+		t.Errorf("Extend = %v, %v", ok, err)
+	}
+	if ttl := mr.TTL(MutexKey("job")); ttl != time.Second {
+		t.Errorf("Expected TTL reset to 1s, got %v", ttl)
+	}
+}
+
+func TestRWMutexAndSemaphore_NotInitialized(t *testing.T) {
+	defaultLocker = nil
+
+	if NewRWMutex("test") != nil {
+		t.Error("Expected nil RWMutex when locker not initialized")
+	}
+	if NewSemaphore("test", 3) != nil {
+		t.Error("Expected nil Semaphore when locker not initialized")
+	}
+	if err := WithSemaphore(context.Background(), "test", 3, func() error { return nil }); err == nil {
+		t.Error("Expected error when locker not initialized")
+	}
+}
+
+func TestNewSemaphore_Options(t *testing.T) {
+	l := &Locker{config: DefaultConfig()}
+
+	sem := l.NewSemaphore("test", 0, WithExpiry(time.Minute), WithAutoRenew())
+	if sem.Limit() != 1 {
+		t.Errorf("Expected limit to be clamped to 1, got %d", sem.Limit())
+	}
+	if sem.expiryMs() != time.Minute.Milliseconds() {
+		t.Errorf("Expected expiry 1m, got %dms", sem.expiryMs())
+	}
+	if !sem.config.AutoRenew {
+		t.Error("Expected AutoRenew to be set")
+	}
+	if sem.IsHeld() {
+		t.Error("Expected new semaphore not to be held")
+	}
+
+	other := l.NewSemaphore("test", 3)
+	if other.token == sem.token {
+		t.Error("Expected each instance to have its own token")
+	}
+}
+
+// TestIntegration_LockUnlock runs the lock scripts against a real Redis server
+// TestIntegration_LockUnlock 在真实 Redis 服务器上运行锁脚本
 func TestIntegration_LockUnlock(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
 	}
 
 	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
+		Addr: containers.Redis(t).Addr,
 	})
 	defer client.Close()
 
-	Init(client, DefaultConfig())
-
-	mu := NewMutex("test:integration:1")
+	l := &Locker{client: client, config: DefaultConfig()}
+	mu := l.NewMutex("test:integration:1")
 
 	err := mu.Lock()
 	if err != nil {
@@ -90,6 +292,10 @@ func TestIntegration_LockUnlock(t *testing.T) {
 		t.Error("Expected IsLocked to be true")
 	}
 
+	if ok, err := l.NewMutex("test:integration:1").TryLock(); err != nil || ok {
+		t.Errorf("Expected a second holder to be refused, got %v, %v", ok, err)
+	}
+
 	ok, err := mu.Unlock()
 	if err != nil {
 		t.Fatalf("Failed to release lock: %v", err)
@@ -98,4 +304,3 @@ func TestIntegration_LockUnlock(t *testing.T) {
 		t.Error("Expected unlock to succeed")
 	}
 }
-*/
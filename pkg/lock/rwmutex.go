@@ -0,0 +1,173 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The read-write lock is a Redis hash: readers are fields named by their token,
+// the writer is the __writer field. The key expiry is only ever extended,
+// so it covers the longest-lived reader.
+// 读写锁是一个 Redis 哈希：读者是以令牌命名的字段，写者是 __writer 字段。
+// 键的过期时间只会延长，因此覆盖存活最久的读者。
+var (
+	rLockScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], '__writer') == 1 then return 0 end
+redis.call('HSET', KEYS[1], ARGV[1], 1)
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then redis.call('PEXPIRE', KEYS[1], ARGV[2]) end
+return 1`)
+
+	rExtendScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then return 0 end
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then redis.call('PEXPIRE', KEYS[1], ARGV[2]) end
+return 1`)
+
+	rUnlockScript = redis.NewScript(`
+return redis.call('HDEL', KEYS[1], ARGV[1])`)
+
+	wLockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then return 0 end
+redis.call('HSET', KEYS[1], '__writer', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1`)
+
+	wExtendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], '__writer') ~= ARGV[1] then return 0 end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1`)
+
+	wUnlockScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], '__writer') ~= ARGV[1] then return 0 end
+return redis.call('DEL', KEYS[1])`)
+)
+
+// RWMutex is a distributed read-write lock: many readers or a single writer
+// An instance is one holder; concurrent readers each create their own instance.
+// Writers are not given priority, so a steady stream of readers can delay them.
+// RWMutex 是分布式读写锁：允许多个读者或单个写者
+// 一个实例代表一个持有者，并发读者需各自创建实例。写者没有优先权，持续的读者可能延迟写者。
+type RWMutex struct {
+	lease
+	writing bool
+}
+
+// NewRWMutex creates a read-write lock
+// NewRWMutex 创建读写锁
+func (l *Locker) NewRWMutex(name string, opts ...Option) *RWMutex {
+	return &RWMutex{lease: newLease(l.client, name, l.config, opts)}
+}
+
+// RLock acquires the lock for reading
+// RLock 获取读锁
+func (m *RWMutex) RLock(ctx context.Context) error {
+	m.writing = false
+	return m.acquire(ctx, m.tryRLock, m.extend)
+}
+
+// TryRLock tries to acquire the lock for reading (non-blocking)
+// TryRLock 尝试获取读锁（非阻塞）
+func (m *RWMutex) TryRLock(ctx context.Context) (bool, error) {
+	m.writing = false
+	return m.tryAcquire(ctx, m.tryRLock, m.extend)
+}
+
+// Lock acquires the lock for writing
+// Lock 获取写锁
+func (m *RWMutex) Lock(ctx context.Context) error {
+	m.writing = true
+	return m.acquire(ctx, m.tryLock, m.extend)
+}
+
+// TryLock tries to acquire the lock for writing (non-blocking)
+// TryLock 尝试获取写锁（非阻塞）
+func (m *RWMutex) TryLock(ctx context.Context) (bool, error) {
+	m.writing = true
+	return m.tryAcquire(ctx, m.tryLock, m.extend)
+}
+
+// Unlock releases the lock, whichever mode it was acquired in
+// Unlock 释放锁，无论以何种模式获取
+func (m *RWMutex) Unlock(ctx context.Context) (bool, error) {
+	if m.writing {
+		return m.release(ctx, wUnlockScript)
+	}
+	return m.release(ctx, rUnlockScript)
+}
+
+// Extend extends the lock expiry time
+// Extend 延长锁过期时间
+func (m *RWMutex) Extend(ctx context.Context) (bool, error) {
+	if !m.held {
+		return false, nil
+	}
+	return m.extend(ctx)
+}
+
+// Name returns the lock name
+// Name 返回锁名称
+func (m *RWMutex) Name() string {
+	return m.name
+}
+
+// IsLocked checks if the lock is held
+// IsLocked 检查锁是否被持有
+func (m *RWMutex) IsLocked() bool {
+	return m.held
+}
+
+func (m *RWMutex) tryRLock(ctx context.Context) (bool, error) {
+	return m.run(ctx, rLockScript, m.expiryMs())
+}
+
+func (m *RWMutex) tryLock(ctx context.Context) (bool, error) {
+	return m.run(ctx, wLockScript, m.expiryMs())
+}
+
+func (m *RWMutex) extend(ctx context.Context) (bool, error) {
+	if m.writing {
+		return m.run(ctx, wExtendScript, m.expiryMs())
+	}
+	return m.run(ctx, rExtendScript, m.expiryMs())
+}
+
+// NewRWMutex creates a read-write lock using default Locker
+// NewRWMutex 使用默认 Locker 创建读写锁
+// Returns nil if lock is not initialized
+// 如果锁未初始化则返回 nil
+func NewRWMutex(name string, opts ...Option) *RWMutex {
+	if defaultLocker == nil {
+		log.Error("lock: not initialized, call lock.Init() first")
+		return nil
+	}
+	return defaultLocker.NewRWMutex(name, opts...)
+}
+
+// WithReadLock executes function while holding the read lock
+// WithReadLock 在持有读锁时执行函数
+func WithReadLock(ctx context.Context, name string, fn func() error, opts ...Option) error {
+	mu := NewRWMutex(name, opts...)
+	if mu == nil {
+		return fmt.Errorf("lock: not initialized, call lock.Init() first")
+	}
+	if err := mu.RLock(ctx); err != nil {
+		return err
+	}
+	defer mu.Unlock(context.WithoutCancel(ctx))
+	return fn()
+}
+
+// WithWriteLock executes function while holding the write lock
+// WithWriteLock 在持有写锁时执行函数
+func WithWriteLock(ctx context.Context, name string, fn func() error, opts ...Option) error {
+	mu := NewRWMutex(name, opts...)
+	if mu == nil {
+		return fmt.Errorf("lock: not initialized, call lock.Init() first")
+	}
+	if err := mu.Lock(ctx); err != nil {
+		return err
+	}
+	defer mu.Unlock(context.WithoutCancel(ctx))
+	return fn()
+}
@@ -0,0 +1,160 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The semaphore is a Redis sorted set of holder tokens scored by their deadline
+// in Redis server time, so expired holders are dropped before counting.
+// 信号量是一个 Redis 有序集合，成员为持有者令牌，分数为基于 Redis 服务器时间的截止时间，
+// 因此计数前会先剔除已过期的持有者。
+var (
+	semAcquireScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then return 0 end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then redis.call('PEXPIRE', KEYS[1], ARGV[2]) end
+return 1`)
+
+	semExtendScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local deadline = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not deadline or tonumber(deadline) <= now then return 0 end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then redis.call('PEXPIRE', KEYS[1], ARGV[2]) end
+return 1`)
+
+	semReleaseScript = redis.NewScript(`
+return redis.call('ZREM', KEYS[1], ARGV[1])`)
+
+	semCountScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+return redis.call('ZCOUNT', KEYS[1], '(' .. now, '+inf')`)
+)
+
+// Semaphore is a distributed counting semaphore limiting concurrent holders cluster-wide
+// An instance is one holder; each worker creates its own instance.
+// Semaphore 是分布式计数信号量，在集群范围内限制并发持有者数量
+// 一个实例代表一个持有者，每个工作者需各自创建实例。
+type Semaphore struct {
+	lease
+	limit int
+}
+
+// NewSemaphore creates a semaphore allowing at most limit holders
+// NewSemaphore 创建最多允许 limit 个持有者的信号量
+func (l *Locker) NewSemaphore(name string, limit int, opts ...Option) *Semaphore {
+	return &Semaphore{lease: newLease(l.client, name, l.config, opts), limit: max(limit, 1)}
+}
+
+// Acquire takes a slot, retrying while all slots are in use
+// Acquire 获取一个名额，名额用尽时重试
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.acquire(ctx, s.try, s.extend)
+}
+
+// TryAcquire tries to take a slot (non-blocking)
+// TryAcquire 尝试获取一个名额（非阻塞）
+func (s *Semaphore) TryAcquire(ctx context.Context) (bool, error) {
+	return s.tryAcquire(ctx, s.try, s.extend)
+}
+
+// Release gives the slot back
+// Release 归还名额
+func (s *Semaphore) Release(ctx context.Context) (bool, error) {
+	return s.release(ctx, semReleaseScript)
+}
+
+// Extend extends the slot expiry time
+// Extend 延长名额过期时间
+func (s *Semaphore) Extend(ctx context.Context) (bool, error) {
+	if !s.held {
+		return false, nil
+	}
+	return s.extend(ctx)
+}
+
+// Count returns the number of slots currently held cluster-wide
+// Count 返回集群范围内当前被占用的名额数
+func (s *Semaphore) Count(ctx context.Context) (int, error) {
+	return semCountScript.Run(ctx, s.client, []string{s.name}).Int()
+}
+
+// Name returns the semaphore name
+// Name 返回信号量名称
+func (s *Semaphore) Name() string {
+	return s.name
+}
+
+// Limit returns the maximum number of holders
+// Limit 返回最大持有者数量
+func (s *Semaphore) Limit() int {
+	return s.limit
+}
+
+// IsHeld checks if this instance holds a slot
+// IsHeld 检查本实例是否持有名额
+func (s *Semaphore) IsHeld() bool {
+	return s.held
+}
+
+func (s *Semaphore) try(ctx context.Context) (bool, error) {
+	return s.run(ctx, semAcquireScript, s.expiryMs(), s.limit)
+}
+
+func (s *Semaphore) extend(ctx context.Context) (bool, error) {
+	return s.run(ctx, semExtendScript, s.expiryMs())
+}
+
+// NewSemaphore creates a semaphore using default Locker
+// NewSemaphore 使用默认 Locker 创建信号量
+// Returns nil if lock is not initialized
+// 如果锁未初始化则返回 nil
+func NewSemaphore(name string, limit int, opts ...Option) *Semaphore {
+	if defaultLocker == nil {
+		log.Error("lock: not initialized, call lock.Init() first")
+		return nil
+	}
+	return defaultLocker.NewSemaphore(name, limit, opts...)
+}
+
+// WithSemaphore executes function while holding a semaphore slot
+// WithSemaphore 在持有信号量名额时执行函数
+//
+// Usage | 用法:
+//
+//	// Only 3 export workers at a time | 同一时间最多 3 个导出任务
+//	err := lock.WithSemaphore(ctx, "export:workers", 3, runExport, lock.WithAutoRenew())
+func WithSemaphore(ctx context.Context, name string, limit int, fn func() error, opts ...Option) error {
+	sem := NewSemaphore(name, limit, opts...)
+	if sem == nil {
+		return fmt.Errorf("lock: not initialized, call lock.Init() first")
+	}
+	if err := sem.Acquire(ctx); err != nil {
+		return err
+	}
+	defer sem.Release(context.WithoutCancel(ctx))
+	return fn()
+}
+
+// TryWithSemaphore tries to execute function while holding a semaphore slot (non-blocking)
+// TryWithSemaphore 尝试在持有信号量名额时执行函数（非阻塞）
+func TryWithSemaphore(ctx context.Context, name string, limit int, fn func() error, opts ...Option) (bool, error) {
+	sem := NewSemaphore(name, limit, opts...)
+	if sem == nil {
+		return false, fmt.Errorf("lock: not initialized, call lock.Init() first")
+	}
+	ok, err := sem.TryAcquire(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer sem.Release(context.WithoutCancel(ctx))
+	return true, fn()
+}
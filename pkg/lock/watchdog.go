@@ -0,0 +1,72 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
+)
+
+// watchdog keeps extending a held lock in the background, see WithAutoRenew
+// watchdog 在后台持续延长已持有的锁，参见 WithAutoRenew
+type watchdog struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startWatchdog calls extend every expiry/3 until stop is called or ctx is done
+// It gives up once no renewal succeeded for a whole expiry, as the lock is lost by then.
+// startWatchdog 每隔 expiry/3 调用一次 extend，直到调用 stop 或 ctx 结束
+// 若整个过期时间内都未续期成功则放弃，因为此时锁已经丢失。
+func startWatchdog(ctx context.Context, clk clock.Clock, name string, expiry time.Duration, extend func(context.Context) (bool, error)) *watchdog {
+	if expiry <= 0 {
+		expiry = DefaultConfig().Expiry
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watchdog{cancel: cancel, done: make(chan struct{})}
+	go w.run(ctx, clk.NewTicker(expiry/3), name, expiry, clk.Now(), extend)
+	return w
+}
+
+// run is the renewal loop | run 是续期循环
+func (w *watchdog) run(ctx context.Context, tick *clock.Ticker, name string, expiry time.Duration, renewed time.Time, extend func(context.Context) (bool, error)) {
+	defer close(w.done)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Lock watchdog [%s] panic: %v", name, r)
+		}
+	}()
+
+	defer tick.Stop()
+
+	for {
+		var at time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case at = <-tick.C:
+		}
+
+		ok, err := extend(ctx)
+		if ok && err == nil {
+			renewed = at
+			log.Debug("Renewed lock [%s]", name)
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if at.Sub(renewed) >= expiry {
+			log.Warn("Lost lock [%s], stopping renewal: %v", name, err)
+			return
+		}
+		log.Warn("Failed to renew lock [%s], will retry: %v", name, err)
+	}
+}
+
+// stop ends the renewal loop and waits for it to exit
+// stop 结束续期循环并等待其退出
+func (w *watchdog) stop() {
+	w.cancel()
+	<-w.done
+}
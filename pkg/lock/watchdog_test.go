@@ -0,0 +1,106 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
+)
+
+// newFakeClock returns a clock the watchdog tests move by hand | newFakeClock 返回看门狗测试手动推进的时钟
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Unix(0, 0))
+}
+
+func TestWithAutoRenew_Option(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAutoRenew()(&cfg)
+
+	if !cfg.AutoRenew {
+		t.Error("Expected AutoRenew to be true")
+	}
+}
+
+func TestWatchdog_RenewsUntilStopped(t *testing.T) {
+	fake := newFakeClock()
+	calls := make(chan struct{}, 10)
+	w := startWatchdog(context.Background(), fake, "test", 30*time.Second, func(context.Context) (bool, error) {
+		calls <- struct{}{}
+		return true, nil
+	})
+
+	for range 3 {
+		fake.Advance(10 * time.Second)
+		<-calls
+	}
+	w.stop()
+
+	fake.Advance(10 * time.Second)
+	select {
+	case <-calls:
+		t.Error("Expected no renewals after stop")
+	default:
+	}
+	if fake.Waiters() != 0 {
+		t.Error("Expected the ticker to be stopped")
+	}
+}
+
+func TestWatchdog_StopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := startWatchdog(ctx, newFakeClock(), "test", 30*time.Second, func(context.Context) (bool, error) {
+		return true, nil
+	})
+	cancel()
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected watchdog to stop after context cancel")
+	}
+	w.stop() // Safe after exit | 退出后调用也安全
+}
+
+func TestWatchdog_GivesUpWhenLost(t *testing.T) {
+	fake := newFakeClock()
+	calls := make(chan struct{}, 10)
+	w := startWatchdog(context.Background(), fake, "test", 30*time.Second, func(context.Context) (bool, error) {
+		calls <- struct{}{}
+		return false, errors.New("taken")
+	})
+
+	// Failures within the expiry are retried | 过期时间内的失败会重试
+	for range 2 {
+		fake.Advance(10 * time.Second)
+		<-calls
+	}
+	select {
+	case <-w.done:
+		t.Fatal("Expected watchdog to keep retrying within the expiry")
+	default:
+	}
+
+	fake.Advance(10 * time.Second)
+	<-calls
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected watchdog to give up after expiry without renewal")
+	}
+}
+
+func TestWatchdog_RecoversPanic(t *testing.T) {
+	fake := newFakeClock()
+	w := startWatchdog(context.Background(), fake, "test", 30*time.Second, func(context.Context) (bool, error) {
+		panic("boom")
+	})
+	fake.Advance(10 * time.Second)
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected watchdog to exit after panic")
+	}
+}
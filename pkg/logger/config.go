@@ -1,15 +1,39 @@
 package logger
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Config represents logger configuration
 // Config 表示日志器配置
 type Config struct {
-	BufferSize    int           `toml:"buffer_size"`    // Buffer size in bytes, default 64KB | 缓冲区大小（字节），默认 64KB
-	FlushInterval time.Duration `toml:"flush_interval"` // Flush interval, default 3s | 刷新间隔，默认 3 秒
-	Enabled       bool          `toml:"enabled"`        // Enable file logging, default true | 启用文件日志，默认 true
+	BufferSize    int               `toml:"buffer_size"`    // Buffer size in bytes, default 64KB | 缓冲区大小（字节），默认 64KB
+	FlushInterval time.Duration     `toml:"flush_interval"` // Flush interval, default 3s | 刷新间隔，默认 3 秒
+	Enabled       bool              `toml:"enabled"`        // Enable file logging, default true | 启用文件日志，默认 true
+	Output        string            `toml:"output"`         // stdout, file or both; empty follows Enabled | stdout、file 或 both；为空时由 Enabled 决定
+	Format        string            `toml:"format"`         // text (default) or json | text（默认）或 json
+	Dir           string            `toml:"dir"`            // Log directory, default logs | 日志目录，默认 logs
+	MaxSize       int               `toml:"max_size"`       // Max file size in MB before rotating, 0 rotates daily only | 单文件最大 MB，超出后轮转，0 表示仅按天轮转
+	MaxAge        int               `toml:"max_age"`        // Days to keep log files, 0 keeps forever | 日志文件保留天数，0 表示永久保留
+	Level         string            `toml:"level"`          // Minimum level, default debug | 最低级别，默认 debug
+	Levels        map[string]string `toml:"levels"`         // Level overrides by logger name, e.g. sql = "warn" | 按日志器名称覆盖级别，例如 sql = "warn"
+	AdminPath     string            `toml:"admin_path"`     // Runtime level endpoint, empty disables | 运行时级别接口路径，为空时禁用
 }
 
+// Output sinks | 输出目标
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+// Output formats | 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 // DefaultConfig returns default logger configuration
 // DefaultConfig 返回默认日志器配置
 func DefaultConfig() Config {
@@ -17,13 +41,24 @@ func DefaultConfig() Config {
 		BufferSize:    64 * 1024,      // 64KB
 		FlushInterval: 3 * time.Second, // 3 seconds
 		Enabled:       true,
+		Output:        OutputBoth,
+		Format:        FormatText,
+		Dir:           logDir,
 	}
 }
 
-var globalConfig = DefaultConfig()
+// globalConfig holds the current Config, replaced at runtime on reload
+// globalConfig 保存当前配置，重载时在运行时替换
+var globalConfig atomic.Value
+
+func init() {
+	globalConfig.Store(DefaultConfig())
+}
 
-// SetConfig sets global logger configuration
-// SetConfig 设置全局日志器配置
+// SetConfig sets global logger configuration; it may be called again at runtime to reload
+// Runtime level changes are replaced by the levels of cfg.
+// SetConfig 设置全局日志器配置；可在运行时再次调用以重载
+// 运行时修改的级别会被 cfg 中的级别替换。
 func SetConfig(cfg Config) {
 	// Apply defaults for zero values | 为零值应用默认值
 	if cfg.BufferSize <= 0 {
@@ -32,11 +67,36 @@ func SetConfig(cfg Config) {
 	if cfg.FlushInterval <= 0 {
 		cfg.FlushInterval = 3 * time.Second
 	}
-	globalConfig = cfg
+	if cfg.Output == "" {
+		cfg.Output = OutputStdout
+		if cfg.Enabled {
+			cfg.Output = OutputBoth
+		}
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatText
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = logDir
+	}
+	globalConfig.Store(cfg)
+	levels.load(cfg)
+}
+
+// toStdout reports whether entries are printed to stdout
+// toStdout 判断是否输出到标准输出
+func (c Config) toStdout() bool {
+	return c.Output != OutputFile
+}
+
+// toFile reports whether entries are written to files
+// toFile 判断是否写入文件
+func (c Config) toFile() bool {
+	return c.Output == OutputFile || c.Output == OutputBoth
 }
 
 // GetConfig returns current global logger configuration
 // GetConfig 返回当前全局日志器配置
 func GetConfig() Config {
-	return globalConfig
+	return globalConfig.Load().(Config)
 }
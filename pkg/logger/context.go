@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextExtractor returns the fields a context carries, e.g. request_id and user_id
+// ContextExtractor 返回 context 携带的字段，例如 request_id 和 user_id
+type ContextExtractor func(ctx context.Context) Fields
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor adds fields taken from the context to every entry logged with one
+// pkg/logger cannot see business context keys, so upper layers register them (see common/ctxutil).
+// RegisterContextExtractor 为每条携带 context 的日志添加从 context 中提取的字段
+// pkg/logger 无法访问业务层的 context 键，因此由上层注册（参见 common/ctxutil）。
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fn)
+}
+
+// WithContext returns a copy of the logger bound to ctx
+// Every entry includes trace_id, span_id and the registered context fields such as request_id and user_id.
+// WithContext 返回绑定 ctx 的日志器副本
+// 每条日志都会包含 trace_id、span_id 以及已注册的 context 字段，例如 request_id 和 user_id。
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	bound := *l
+	bound.ctx = ctx
+	return &bound
+}
+
+var (
+	appOnce   sync.Once
+	appLogger *Logger
+)
+
+// WithContext returns the "app" logger bound to ctx
+// WithContext 返回绑定 ctx 的 "app" 日志器
+func WithContext(ctx context.Context) *Logger {
+	appOnce.Do(func() { appLogger = NewWithName("app") })
+	return appLogger.WithContext(ctx)
+}
+
+// contextFields collects span_id and the registered fields of ctx; trace_id is handled by emit
+// contextFields 收集 ctx 的 span_id 和已注册字段；trace_id 由 emit 处理
+func contextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	var fields Fields
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		fields = Fields{"span_id": sc.SpanID().String()}
+	}
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	for _, fn := range extractors {
+		for k, v := range fn(ctx) {
+			if fields == nil {
+				fields = Fields{}
+			}
+			fields[k] = v
+		}
+	}
+	return fields
+}
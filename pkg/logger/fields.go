@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stdout is the console sink, replaced in tests
+// stdout 是控制台输出目标，测试中会被替换
+var stdout io.Writer = os.Stdout
+
+// Fields represents structured log fields
+// Fields 表示结构化日志字段
+type Fields map[string]any
+
+// LogFields writes a structured entry: key=value pairs on console, one JSON object per line in the file
+// LogFields 写入结构化日志：控制台输出 key=value 形式，文件中每行一个 JSON 对象
+func (l *Logger) LogFields(ctx context.Context, level Level, msg string, fields Fields) {
+	if !l.Enabled(level) {
+		return
+	}
+	if ctx == nil {
+		ctx = l.ctx
+	}
+	l.emit(ctx, level, msg, fields, true)
+}
+
+// emit writes an entry to the configured sinks, adding the context fields of ctx
+// Text format prints colored lines on console and plain lines in the file (JSON for structured entries);
+// JSON format prints one JSON object per line everywhere.
+// emit 将日志写入配置的输出目标，并附加 ctx 中的字段
+// text 格式在控制台输出彩色行、文件中输出纯文本行（结构化日志为 JSON）；
+// json 格式在所有目标中每行输出一个 JSON 对象。
+func (l *Logger) emit(ctx context.Context, level Level, msg string, fields Fields, structured bool) {
+	cfg := GetConfig()
+	now := time.Now()
+	traceID := getTraceID(ctx)
+
+	// Explicit fields win over context fields | 显式字段优先于 context 字段
+	if extra := contextFields(ctx); len(extra) > 0 {
+		for k, v := range fields {
+			extra[k] = v
+		}
+		fields = extra
+	}
+
+	if cfg.Format == FormatJSON {
+		line := jsonLine(now, l.module, level, msg, traceID, fields)
+		if line == "" {
+			return
+		}
+		if cfg.toStdout() {
+			io.WriteString(stdout, line)
+		}
+		if cfg.toFile() {
+			l.writer.Write(line)
+		}
+		return
+	}
+
+	pairs := keyValues(fields)
+	if cfg.toStdout() {
+		// Console colored output | 控制台彩色输出
+		traceStr := ""
+		if traceID != "" {
+			traceStr = fmt.Sprintf(" %s[%s]%s", "\033[90m", traceID[:16], "\033[0m")
+		}
+		fmt.Fprintf(stdout, "%s[%s]%s %s[%s]%s %s%s%s%s %s%s\n",
+			"\033[90m", now.Format("2006-01-02 15:04:05"), "\033[0m",
+			l.color, l.module, "\033[0m",
+			levelColors[level], levelNames[level], "\033[0m",
+			traceStr,
+			msg,
+			pairs,
+		)
+	}
+
+	if !cfg.toFile() {
+		return
+	}
+	if structured {
+		// Structured entries stay JSON in the file | 结构化日志在文件中保持 JSON
+		if line := jsonLine(now, l.module, level, msg, traceID, fields); line != "" {
+			l.writer.Write(line)
+		}
+		return
+	}
+
+	// File output (no color) | 文件输出（无颜色）
+	traceFileStr := ""
+	if traceID != "" {
+		traceFileStr = fmt.Sprintf(" [%s]", traceID[:16])
+	}
+	l.writer.Write(fmt.Sprintf("[%s] [%s] [%s]%s %s%s\n",
+		now.Format("2006-01-02 15:04:05"),
+		l.module,
+		levelNames[level],
+		traceFileStr,
+		msg,
+		pairs,
+	))
+}
+
+// keyValues formats fields as " k=v" pairs sorted by key
+// keyValues 将字段格式化为按键排序的 " k=v" 对
+func keyValues(fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, fields[k])
+	}
+	return sb.String()
+}
+
+// jsonLine encodes an entry as one JSON object followed by a newline
+// jsonLine 将日志编码为一个 JSON 对象并以换行结尾
+func jsonLine(now time.Time, module string, level Level, msg, traceID string, fields Fields) string {
+	entry := make(map[string]any, len(fields)+5)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = now.Format(time.RFC3339Nano)
+	entry["level"] = levelNames[level]
+	entry["module"] = module
+	entry["msg"] = msg
+	if traceID != "" {
+		entry["trace_id"] = traceID
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
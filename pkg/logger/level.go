@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// String returns the level name
+// String 返回级别名称
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN"
+// ParseLevel 解析级别名称，例如 "debug" 或 "WARN"
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "error":
+		return ERROR, nil
+	}
+	return DEBUG, fmt.Errorf("logger: unknown level %q", s)
+}
+
+// levelState holds the minimum level and per-logger overrides
+// levelState 保存最低级别和按日志器的覆盖
+type levelState struct {
+	mu        sync.RWMutex
+	min       Level
+	overrides map[string]Level
+	timers    map[string]*restore // Pending restores of SetLevelFor | SetLevelFor 的待执行恢复
+}
+
+// restore is a scheduled revert of a temporary level
+// restore 是临时级别的计划恢复
+type restore struct {
+	timer *time.Timer
+	at    time.Time
+}
+
+var levels = &levelState{min: DEBUG, overrides: map[string]Level{}}
+
+// load replaces the levels from config, ignoring invalid names
+// load 从配置替换级别，忽略无效名称
+func (s *levelState) load(cfg Config) {
+	min := DEBUG
+	if cfg.Level != "" {
+		if lv, err := ParseLevel(cfg.Level); err == nil {
+			min = lv
+		}
+	}
+	overrides := make(map[string]Level, len(cfg.Levels))
+	for name, value := range cfg.Levels {
+		if lv, err := ParseLevel(value); err == nil {
+			overrides[name] = lv
+		}
+	}
+
+	s.mu.Lock()
+	for name := range s.timers {
+		s.stopTimer(name)
+	}
+	s.min, s.overrides = min, overrides
+	s.mu.Unlock()
+}
+
+// levelFor returns the effective level of a logger
+// An exact name wins, then the prefix before ":" (so "system" covers "system:server").
+// levelFor 返回日志器的生效级别
+// 精确名称优先，其次是 ":" 之前的前缀（"system" 覆盖 "system:server"）。
+func (s *levelState) levelFor(module string) Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if lv, ok := s.overrides[module]; ok {
+		return lv
+	}
+	if i := strings.IndexByte(module, ':'); i > 0 {
+		if lv, ok := s.overrides[module[:i]]; ok {
+			return lv
+		}
+	}
+	return s.min
+}
+
+// Enabled reports whether the logger writes entries at the given level
+// Enabled 判断日志器是否输出指定级别的日志
+func (l *Logger) Enabled(level Level) bool {
+	return level >= levels.levelFor(l.module)
+}
+
+// SetLevel changes the level of a logger name at runtime; an empty name changes the minimum level
+// SetLevel 在运行时修改日志器名称的级别；名称为空时修改最低级别
+func SetLevel(name string, level Level) {
+	levels.set(name, level, 0)
+}
+
+// SetLevelFor changes a level like SetLevel and restores the previous value after d
+// SetLevelFor 像 SetLevel 一样修改级别，并在 d 之后恢复原值
+func SetLevelFor(name string, level Level, d time.Duration) {
+	levels.set(name, level, d)
+}
+
+// ResetLevel removes the runtime override of a logger name
+// ResetLevel 移除日志器名称的运行时覆盖
+func ResetLevel(name string) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.stopTimer(name)
+	delete(levels.overrides, name)
+}
+
+// LevelSnapshot is the current minimum level and per-logger overrides
+// LevelSnapshot 是当前的最低级别和按日志器的覆盖
+type LevelSnapshot struct {
+	Level   string               `json:"level"`
+	Levels  map[string]string    `json:"levels"`
+	Expires map[string]time.Time `json:"expires,omitempty"` // Restore time of temporary levels, "" for the minimum level | 临时级别的恢复时间，"" 表示最低级别
+}
+
+// Permanent returns the overrides that are not temporary
+// Permanent 返回非临时的覆盖
+func (s LevelSnapshot) Permanent() map[string]string {
+	out := make(map[string]string, len(s.Levels))
+	for name, level := range s.Levels {
+		if _, ok := s.Expires[name]; !ok {
+			out[name] = level
+		}
+	}
+	return out
+}
+
+// Levels returns the current levels
+// Levels 返回当前级别
+func Levels() LevelSnapshot {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	snap := LevelSnapshot{Level: levels.min.String(), Levels: make(map[string]string, len(levels.overrides))}
+	for name, lv := range levels.overrides {
+		snap.Levels[name] = lv.String()
+	}
+	if len(levels.timers) > 0 {
+		snap.Expires = make(map[string]time.Time, len(levels.timers))
+		for name, r := range levels.timers {
+			snap.Expires[name] = r.at
+		}
+	}
+	return snap
+}
+
+// set applies a level, scheduling a restore when d > 0
+// set 设置级别，d > 0 时安排恢复
+func (s *levelState) set(name string, level Level, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTimer(name)
+
+	prev, hadPrev := s.min, true
+	if name != "" {
+		prev, hadPrev = s.overrides[name]
+		s.overrides[name] = level
+	} else {
+		s.min = level
+	}
+	if d <= 0 {
+		return
+	}
+
+	r := &restore{at: time.Now().Add(d)}
+	r.timer = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.timers[name] != r {
+			return // Replaced by a later change | 已被后续修改替换
+		}
+		delete(s.timers, name)
+		switch {
+		case name == "":
+			s.min = prev
+		case hadPrev:
+			s.overrides[name] = prev
+		default:
+			delete(s.overrides, name)
+		}
+	})
+	if s.timers == nil {
+		s.timers = make(map[string]*restore)
+	}
+	s.timers[name] = r
+}
+
+// stopTimer cancels a pending restore; the caller holds s.mu
+// stopTimer 取消待执行的恢复；调用方需持有 s.mu
+func (s *levelState) stopTimer(name string) {
+	if r, ok := s.timers[name]; ok {
+		r.timer.Stop()
+		delete(s.timers, name)
+	}
+}
@@ -3,7 +3,6 @@ package logger
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"go.opentelemetry.io/otel/trace"
 )
@@ -39,6 +38,7 @@ type Logger struct {
 	module string
 	color  string
 	writer *Writer
+	ctx    context.Context // Bound by WithContext | 由 WithContext 绑定
 }
 
 // NewWithName creates logger with specified module name
@@ -47,7 +47,7 @@ func NewWithName(name string) *Logger {
 	return &Logger{
 		module: name,
 		color:  getModuleColor(name),
-		writer: NewWriter(name),
+		writer: sharedWriter(name),
 	}
 }
 
@@ -65,7 +65,7 @@ func NewSystem(name string) *System {
 		Logger: &Logger{
 			module: fullName,
 			color:  "\033[93m", // Bright yellow, fixed color for system level | 亮黄色，系统级固定颜色
-			writer: NewWriter("system"),
+			writer: sharedWriter("system"),
 		},
 	}
 }
@@ -84,39 +84,17 @@ func getTraceID(ctx context.Context) string {
 }
 
 func (l *Logger) log(ctx context.Context, level Level, msg string, args ...any) {
-	now := time.Now()
+	if !l.Enabled(level) {
+		return
+	}
+	if ctx == nil {
+		ctx = l.ctx
+	}
 	text := msg
 	if len(args) > 0 {
 		text = fmt.Sprintf(msg, args...)
 	}
-
-	traceID := getTraceID(ctx)
-	traceStr := ""
-	traceFileStr := ""
-	if traceID != "" {
-		traceStr = fmt.Sprintf(" %s[%s]%s", "\033[90m", traceID[:16], "\033[0m")
-		traceFileStr = fmt.Sprintf(" [%s]", traceID[:16])
-	}
-
-	// Console colored output | 控制台彩色输出
-	console := fmt.Sprintf("%s[%s]%s %s[%s]%s %s%s%s%s %s\n",
-		"\033[90m", now.Format("2006-01-02 15:04:05"), "\033[0m",
-		l.color, l.module, "\033[0m",
-		levelColors[level], levelNames[level], "\033[0m",
-		traceStr,
-		text,
-	)
-	fmt.Print(console)
-
-	// File output (no color) | 文件输出（无颜色）
-	file := fmt.Sprintf("[%s] [%s] [%s]%s %s\n",
-		now.Format("2006-01-02 15:04:05"),
-		l.module,
-		levelNames[level],
-		traceFileStr,
-		text,
-	)
-	l.writer.Write(file)
+	l.emit(ctx, level, text, nil, false)
 }
 
 // Log methods with ctx | 带 ctx 的日志方法
@@ -153,6 +131,12 @@ func (l *Logger) Error(msg string, args ...any) {
 	l.log(nil, ERROR, msg, args...)
 }
 
+// Flush writes buffered entries of the logger's file to disk
+// Flush 将日志器文件的缓冲日志写入磁盘
+func (l *Logger) Flush() error {
+	return l.writer.Flush()
+}
+
 // Close closes logger
 // Close 关闭日志器
 func (l *Logger) Close() error {
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func useConfig(t *testing.T, cfg Config) *bytes.Buffer {
+	t.Helper()
+	prev, prevOut := GetConfig(), stdout
+	var buf bytes.Buffer
+	stdout = &buf
+	SetConfig(cfg)
+	t.Cleanup(func() {
+		stdout = prevOut
+		SetConfig(prev)
+	})
+	return &buf
+}
+
+func TestLevels(t *testing.T) {
+	buf := useConfig(t, Config{Output: OutputStdout, Level: "info", Levels: map[string]string{
+		"sql":    "warn",
+		"system": "error",
+		"worker": "debug",
+	}})
+
+	NewWithName("api").Debug("hidden")
+	NewWithName("api").Info("api info")
+	NewWithName("sql").Info("hidden")
+	NewWithName("sql").Warn("sql warn")
+	NewWithName("worker").Debug("worker debug")
+	NewSystem("server").Warn("hidden")
+	NewSystem("server").Error("system error")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("filtered entry printed:\n%s", out)
+	}
+	for _, want := range []string{"api info", "sql warn", "worker debug", "system error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if lv, err := ParseLevel("Warning"); err != nil || lv != WARN {
+		t.Errorf("ParseLevel(Warning) = %v, %v", lv, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	buf := useConfig(t, Config{Output: OutputStdout, Format: FormatJSON})
+
+	l := NewWithName("json")
+	l.Info("hello %s", "world")
+	l.LogFields(nil, WARN, "structured", Fields{"user_id": 7})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), buf)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid JSON %q: %v", lines[0], err)
+	}
+	if entry["msg"] != "hello world" || entry["level"] != "INFO" || entry["module"] != "json" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry["user_id"] != float64(7) {
+		t.Errorf("unexpected structured entry %q: %v", lines[1], err)
+	}
+}
+
+func TestWriter_SizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	useConfig(t, Config{Output: OutputFile, Dir: dir, MaxSize: 1})
+
+	w := NewWriter("rotate")
+	defer w.Close()
+	chunk := strings.Repeat("x", 400<<10) + "\n"
+	for i := 0; i < 5; i++ {
+		w.Write(chunk)
+	}
+	w.Flush()
+
+	date := time.Now().Format("2006-01-02")
+	for _, name := range []string{date + ".log", date + ".1.log"} {
+		info, err := os.Stat(filepath.Join(dir, "rotate", name))
+		if err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+		if info.Size() > 1<<20 {
+			t.Errorf("%s exceeds max_size: %d bytes", name, info.Size())
+		}
+	}
+}
+
+func TestWriter_StdoutOnly(t *testing.T) {
+	dir := t.TempDir()
+	useConfig(t, Config{Output: OutputStdout, Dir: dir})
+
+	w := NewWriter("none")
+	defer w.Close()
+	w.Write("dropped\n")
+	if _, err := os.Stat(filepath.Join(dir, "none")); !os.IsNotExist(err) {
+		t.Errorf("stdout output created log directory: %v", err)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "2000-01-01.log")
+	fresh := filepath.Join(dir, "today.log")
+	os.WriteFile(old, []byte("old"), 0644)
+	os.WriteFile(fresh, []byte("new"), 0644)
+	past := time.Now().AddDate(0, 0, -10)
+	os.Chtimes(old, past, past)
+
+	cleanup(dir, 7)
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expired file not removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("fresh file removed")
+	}
+}
+
+func TestSetLevel_Runtime(t *testing.T) {
+	useConfig(t, Config{Output: OutputStdout, Level: "info", Levels: map[string]string{"sql": "warn"}})
+	sql := NewWithName("sql")
+
+	SetLevelFor("sql", DEBUG, 20*time.Millisecond)
+	if !sql.Enabled(DEBUG) {
+		t.Fatal("sql debug not enabled after SetLevelFor")
+	}
+	if snap := Levels(); snap.Levels["sql"] != "DEBUG" || snap.Expires["sql"].IsZero() || len(snap.Permanent()) != 0 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if sql.Enabled(INFO) || !sql.Enabled(WARN) {
+		t.Error("sql level not restored to WARN")
+	}
+
+	SetLevel("", ERROR)
+	if NewWithName("api").Enabled(WARN) {
+		t.Error("minimum level not raised")
+	}
+	ResetLevel("sql")
+	if sql.Enabled(WARN) {
+		t.Error("sql should follow the minimum level after ResetLevel")
+	}
+}
+
+type testCtxKey struct{}
+
+func TestWithContext(t *testing.T) {
+	buf := useConfig(t, Config{Output: OutputStdout, Format: FormatJSON})
+	RegisterContextExtractor(func(ctx context.Context) Fields {
+		if id, ok := ctx.Value(testCtxKey{}).(string); ok {
+			return Fields{"request_id": id}
+		}
+		return nil
+	})
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+	ctx := trace.ContextWithSpanContext(context.WithValue(context.Background(), testCtxKey{}, "req-1"), sc)
+
+	l := NewWithName("ctx").WithContext(ctx)
+	l.Info("bound")
+	l.LogFields(nil, INFO, "explicit", Fields{"request_id": "override"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var entry map[string]any
+	json.Unmarshal([]byte(lines[0]), &entry)
+	if entry["request_id"] != "req-1" || entry["span_id"] != sc.SpanID().String() || entry["trace_id"] != sc.TraceID().String() {
+		t.Errorf("context fields missing: %v", entry)
+	}
+	json.Unmarshal([]byte(lines[1]), &entry)
+	if entry["request_id"] != "override" {
+		t.Errorf("explicit field should win: %v", entry)
+	}
+}
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,24 +14,48 @@ const (
 	logDir = "logs"
 )
 
-// Writer writes log files with daily rotation support
+// Writer writes log files with daily and size based rotation
+// Files are named <dir>/<module>/<date>.log, then <date>.1.log, <date>.2.log once max_size is reached.
+// Writer 写入按天和按大小轮转的日志文件
+// 文件名为 <dir>/<module>/<date>.log，达到 max_size 后依次为 <date>.1.log、<date>.2.log。
 type Writer struct {
-	module   string
-	file     *os.File
-	buffer   *bufio.Writer
-	date     string
-	mu       sync.Mutex
-	stopChan chan struct{} // Signal to stop periodic flush | 停止定期刷新的信号
-	wg       sync.WaitGroup
+	module    string
+	file      *os.File
+	buffer    *bufio.Writer
+	date      string
+	index     int   // Size rotation index of the current file | 当前文件的大小轮转序号
+	size      int64 // Bytes in the current file | 当前文件字节数
+	mu        sync.Mutex
+	stopChan  chan struct{} // Signal to stop periodic flush | 停止定期刷新的信号
+	wg        sync.WaitGroup
+	closeOnce sync.Once
 }
 
-// NewWriter creates writer
+var (
+	writersMu sync.Mutex
+	writers   = map[string]*Writer{}
+)
+
+// sharedWriter returns the writer of a module, shared by all loggers writing to it
+// sharedWriter 返回模块的写入器，写入同一模块的日志器共享该写入器
+func sharedWriter(module string) *Writer {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if w, ok := writers[module]; ok {
+		return w
+	}
+	w := NewWriter(module)
+	writers[module] = w
+	return w
+}
+
+// NewWriter creates writer; the file is opened on first write
+// NewWriter 创建写入器；文件在首次写入时打开
 func NewWriter(module string) *Writer {
 	w := &Writer{
 		module:   module,
 		stopChan: make(chan struct{}),
 	}
-	w.rotate()
 	
 	// Start background flusher | 启动后台刷新器
 	w.wg.Add(1)
@@ -60,7 +86,8 @@ func (w *Writer) periodicFlush() {
 // Write writes log message
 func (w *Writer) Write(msg string) {
 	// Check if file logging is disabled | 检查是否禁用文件日志
-	if !GetConfig().Enabled {
+	cfg := GetConfig()
+	if !cfg.toFile() {
 		return
 	}
 	
@@ -70,13 +97,21 @@ func (w *Writer) Write(msg string) {
 	// Check date change and auto-rotate
 	today := time.Now().Format("2006-01-02")
 	if today != w.date {
+		w.date, w.index = today, 0
+		w.rotate()
+		if cfg.MaxAge > 0 {
+			go cleanup(filepath.Join(cfg.Dir, w.module), cfg.MaxAge)
+		}
+	} else if limit := int64(cfg.MaxSize) << 20; limit > 0 && w.size > 0 && w.size+int64(len(msg)) > limit {
+		// Size exceeded, continue in the next file | 超出大小，切换到下一个文件
+		w.index++
 		w.rotate()
 	}
 
 	if w.buffer != nil {
 		w.buffer.WriteString(msg)
+		w.size += int64(len(msg))
 		// Auto flush if buffer is getting full (>75%) | 缓冲区超过 75% 时自动刷新
-		cfg := GetConfig()
 		if w.buffer.Buffered() > cfg.BufferSize*3/4 {
 			w.buffer.Flush()
 		}
@@ -94,36 +129,79 @@ func (w *Writer) Flush() error {
 	return nil
 }
 
-// rotate rotates log file
+// rotate opens the file of w.date and w.index, skipping files already over max_size
+// rotate 打开 w.date 和 w.index 对应的文件，跳过已超过 max_size 的文件
 func (w *Writer) rotate() {
 	// Flush and close old file | 刷新并关闭旧文件
 	if w.buffer != nil {
 		w.buffer.Flush()
+		w.buffer = nil
 	}
 	if w.file != nil {
 		w.file.Close()
+		w.file = nil
 	}
 
-	w.date = time.Now().Format("2006-01-02")
-	dir := filepath.Join(logDir, w.module)
+	cfg := GetConfig()
+	dir := filepath.Join(cfg.Dir, w.module)
 	os.MkdirAll(dir, 0755)
 
-	path := filepath.Join(dir, w.date+".log")
+	limit := int64(cfg.MaxSize) << 20
+	path := w.path(dir)
+	for limit > 0 {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() < limit {
+			break
+		}
+		w.index++
+		path = w.path(dir)
+	}
+
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return
 	}
 	w.file = f
+	w.size = 0
+	if info, err := f.Stat(); err == nil {
+		w.size = info.Size()
+	}
 	
 	// Use configured buffer size | 使用配置的缓冲区大小
-	cfg := GetConfig()
 	w.buffer = bufio.NewWriterSize(f, cfg.BufferSize)
 }
 
+// path returns the file path of the current date and index
+// path 返回当前日期和序号对应的文件路径
+func (w *Writer) path(dir string) string {
+	if w.index == 0 {
+		return filepath.Join(dir, w.date+".log")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%d.log", w.date, w.index))
+}
+
+// cleanup removes log files in dir last modified more than maxAge days ago
+// cleanup 删除 dir 中最后修改时间超过 maxAge 天的日志文件
+func cleanup(dir string, maxAge int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -maxAge)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".log") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
 // Close closes file
 func (w *Writer) Close() error {
 	// Stop periodic flush goroutine | 停止定期刷新的 goroutine
-	close(w.stopChan)
+	w.closeOnce.Do(func() { close(w.stopChan) })
 	w.wg.Wait()
 	
 	w.mu.Lock()
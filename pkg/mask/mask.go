@@ -0,0 +1,298 @@
+// Package mask hides personal data in values written to logs and API responses
+// Package mask 隐藏写入日志和 API 响应的值中的个人数据
+package mask
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Func masks one value | Func 对单个值进行脱敏
+type Func func(s string) string
+
+var (
+	mu    sync.RWMutex
+	kinds = map[string]Func{
+		"phone":    Phone,
+		"email":    Email,
+		"idcard":   IDCard,
+		"name":     Name,
+		"bankcard": BankCard,
+		"full":     Full,
+	}
+	typeCache sync.Map // reflect.Type -> bool, whether the type holds mask tags | 类型是否包含 mask 标签
+)
+
+// Register adds or replaces a masking kind usable in `mask:"kind"` tags
+// Register 添加或替换可用于 `mask:"kind"` 标签的脱敏类型
+func Register(kind string, fn Func) {
+	mu.Lock()
+	defer mu.Unlock()
+	kinds[kind] = fn
+}
+
+// Value masks s with the named kind, unknown kinds mask fully
+// Value 使用指定类型对 s 脱敏，未知类型完全脱敏
+func Value(kind, s string) string {
+	mu.RLock()
+	fn, ok := kinds[kind]
+	mu.RUnlock()
+	if !ok {
+		return Full(s)
+	}
+	return fn(s)
+}
+
+// Phone keeps the first 3 and last 4 digits: 138****8000
+// Phone 保留前 3 位和后 4 位：138****8000
+func Phone(s string) string {
+	return keep(s, 3, 4)
+}
+
+// Email keeps the first character of the local part and the domain: a***@example.com
+// Email 保留本地部分的首字符和域名：a***@example.com
+func Email(s string) string {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok {
+		return keep(s, 1, 0)
+	}
+	return keep(local, 1, 0) + "@" + domain
+}
+
+// IDCard keeps the first 3 and last 4 characters: 110***********1234
+// IDCard 保留前 3 位和后 4 位：110***********1234
+func IDCard(s string) string {
+	return keep(s, 3, 4)
+}
+
+// Name keeps the first character: 张**, J***
+// Name 保留首字符：张**、J***
+func Name(s string) string {
+	return keep(s, 1, 0)
+}
+
+// BankCard keeps the last 4 digits: ************1234
+// BankCard 保留后 4 位：************1234
+func BankCard(s string) string {
+	return keep(s, 0, 4)
+}
+
+// Full hides the whole value | Full 隐藏整个值
+func Full(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+// keep shows head and tail runes and masks the rest, values too short for both keep only their first rune
+// keep 显示开头和结尾的字符并隐藏其余部分，长度不足的值只保留首字符
+func keep(s string, head, tail int) string {
+	runes := []rune(s)
+	n := len(runes)
+	if n == 0 {
+		return ""
+	}
+	if n <= head+tail {
+		if n == 1 {
+			return "*"
+		}
+		head, tail = 1, 0
+	}
+	return string(runes[:head]) + strings.Repeat("*", n-head-tail) + string(runes[n-tail:])
+}
+
+// Struct returns a copy of v with the string fields tagged `mask:"kind"` masked, v is not modified
+// Structs are followed through pointers, slices, arrays, maps and interfaces. Values whose type holds
+// no mask tag are returned as is without copying; values nested deeper than 32 levels are dropped.
+// Struct 返回 v 的副本，其中带 `mask:"kind"` 标签的字符串字段已脱敏，v 不会被修改
+// 会沿指针、切片、数组、map 和接口深入结构体。类型中不含 mask 标签的值原样返回，不做复制；嵌套超过 32 层的值会被丢弃。
+//
+// Example | 示例:
+//
+//	type UserVO struct {
+//	    Name  string `json:"name" mask:"name"`
+//	    Phone string `json:"phone" mask:"phone"`
+//	}
+//	log.Printf("%+v", mask.Struct(vo))
+func Struct[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+	if !needsMask(rv.Type()) {
+		return v
+	}
+	out := reflect.New(rv.Type()).Elem()
+	copyMasked(out, rv, 0)
+	return out.Interface().(T)
+}
+
+// maxDepth bounds the copy of self-referencing values, deeper values are left zero rather than unmasked
+// maxDepth 限制自引用值的复制深度，更深的值保持零值而不是未脱敏
+const maxDepth = 32
+
+// copyMasked copies src into the settable dst, masking tagged fields
+// copyMasked 将 src 复制到可设置的 dst，并对带标签的字段脱敏
+func copyMasked(dst, src reflect.Value, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	if !needsMask(src.Type()) {
+		dst.Set(src)
+		return
+	}
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		copyMasked(p.Elem(), src.Elem(), depth+1)
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		c := reflect.New(elem.Type()).Elem()
+		copyMasked(c, elem, depth+1)
+		dst.Set(c)
+	case reflect.Struct:
+		// Unexported fields are copied by the assignment | 未导出字段通过整体赋值复制
+		dst.Set(src)
+		t := src.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if kind := f.Tag.Get("mask"); kind != "" && kind != "-" {
+				maskField(dst.Field(i), src.Field(i), kind)
+				continue
+			}
+			copyMasked(dst.Field(i), src.Field(i), depth+1)
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			copyMasked(s.Index(i), src.Index(i), depth+1)
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyMasked(dst.Index(i), src.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			c := reflect.New(src.Type().Elem()).Elem()
+			copyMasked(c, iter.Value(), depth+1)
+			m.SetMapIndex(iter.Key(), c)
+		}
+		dst.Set(m)
+	default:
+		dst.Set(src)
+	}
+}
+
+// maskField masks a tagged string, *string or []string field
+// maskField 对带标签的 string、*string 或 []string 字段脱敏
+func maskField(dst, src reflect.Value, kind string) {
+	switch {
+	case src.Kind() == reflect.String:
+		dst.SetString(Value(kind, src.String()))
+	case src.Kind() == reflect.Ptr && src.Type().Elem().Kind() == reflect.String:
+		if src.IsNil() {
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		p.Elem().SetString(Value(kind, src.Elem().String()))
+		dst.Set(p)
+	case src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.String:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			s.Index(i).SetString(Value(kind, src.Index(i).String()))
+		}
+		dst.Set(s)
+	default:
+		dst.Set(src)
+	}
+}
+
+// needsMask reports whether values of t can hold a mask tag, interfaces are checked at run time
+// needsMask 判断 t 类型的值是否可能包含 mask 标签，接口在运行时检查
+func needsMask(t reflect.Type) bool {
+	if cached, ok := typeCache.Load(t); ok {
+		return cached.(bool)
+	}
+	result := scanType(t, map[reflect.Type]bool{})
+	typeCache.Store(t, result)
+	return result
+}
+
+// scanType looks for mask tags in t, visiting skips types already on the path
+// scanType 在 t 中查找 mask 标签，visiting 用于跳过路径上已访问的类型
+func scanType(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return scanType(t.Elem(), visiting)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if kind := f.Tag.Get("mask"); (kind != "" && kind != "-") || scanType(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Keys masks decoded JSON in place: string values of object keys found in fields, which maps lowercase keys to kinds
+// Object keys are matched case-insensitively.
+// Keys 原地对解码后的 JSON 脱敏：对象中出现在 fields（小写键到脱敏类型的映射）中的键的字符串值
+// 对象键的匹配不区分大小写。
+//
+// Example | 示例:
+//
+//	var body any
+//	json.Unmarshal(data, &body)
+//	mask.Keys(body, map[string]string{"phone": "phone", "email": "email"})
+func Keys(v any, fields map[string]string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if kind, ok := fields[strings.ToLower(k)]; ok {
+				if s, ok := child.(string); ok {
+					val[k] = Value(kind, s)
+					continue
+				}
+			}
+			val[k] = Keys(child, fields)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = Keys(child, fields)
+		}
+	}
+	return v
+}
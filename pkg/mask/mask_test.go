@@ -0,0 +1,115 @@
+package mask
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	tests := []struct {
+		fn   Func
+		in   string
+		want string
+	}{
+		{Phone, "13800138000", "138****8000"},
+		{Phone, "12345", "1****"},
+		{Email, "alice@example.com", "a****@example.com"},
+		{Email, "a@example.com", "*@example.com"},
+		{IDCard, "110101199001011234", "110***********1234"},
+		{Name, "张三", "张*"},
+		{Name, "张", "*"},
+		{BankCard, "6222021234567890", "************7890"},
+		{Full, "anything", "***"},
+		{Phone, "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+
+	Register("last2", func(s string) string { return keep(s, 0, 2) })
+	if got := Value("last2", "abcdef"); got != "****ef" {
+		t.Errorf("Expected registered kind, got %q", got)
+	}
+	if got := Value("unknown", "abc"); got != "***" {
+		t.Errorf("Expected unknown kind to mask fully, got %q", got)
+	}
+}
+
+type contact struct {
+	Email string `json:"email" mask:"email"`
+}
+
+type user struct {
+	Name     string              `json:"name" mask:"name"`
+	Phone    *string             `json:"phone" mask:"phone"`
+	Aliases  []string            `json:"aliases" mask:"full"`
+	ID       int64               `json:"id"`
+	Contacts []contact           `json:"contacts"`
+	ByKind   map[string]*contact `json:"by_kind"`
+	Extra    any                 `json:"extra"`
+	Next     *user               `json:"next"`
+	note     string
+}
+
+func TestStruct(t *testing.T) {
+	phone := "13800138000"
+	u := &user{
+		Name:     "张三",
+		Phone:    &phone,
+		Aliases:  []string{"zs"},
+		ID:       7,
+		Contacts: []contact{{Email: "alice@example.com"}},
+		ByKind:   map[string]*contact{"work": {Email: "bob@example.com"}},
+		Extra:    contact{Email: "carol@example.com"},
+		Next:     &user{Name: "李四"},
+		note:     "kept",
+	}
+
+	m := Struct(u)
+	if m == u || m.Name != "张*" || *m.Phone != "138****8000" || m.Aliases[0] != "***" || m.ID != 7 || m.note != "kept" {
+		t.Errorf("Unexpected masked user: %+v", m)
+	}
+	if m.Contacts[0].Email != "a****@example.com" || m.ByKind["work"].Email != "b**@example.com" {
+		t.Errorf("Expected nested structs to be masked, got %+v %+v", m.Contacts, m.ByKind["work"])
+	}
+	if extra, ok := m.Extra.(contact); !ok || extra.Email != "c****@example.com" {
+		t.Errorf("Expected interface value to be masked, got %+v", m.Extra)
+	}
+	if m.Next.Name != "李*" {
+		t.Errorf("Expected self-referencing field to be masked, got %q", m.Next.Name)
+	}
+
+	// The original is untouched | 原值不变
+	if u.Name != "张三" || phone != "13800138000" || u.Contacts[0].Email != "alice@example.com" || u.ByKind["work"].Email != "bob@example.com" {
+		t.Errorf("Expected original to be unchanged, got %+v", u)
+	}
+
+	// Untagged types pass through | 无标签的类型直接返回
+	plain := []int{1, 2}
+	if got := Struct(plain); &got[0] != &plain[0] {
+		t.Error("Expected an untagged value to be returned without copying")
+	}
+	var data any = []user{{Name: "王五"}}
+	if got := Struct(data).([]user); got[0].Name != "王*" {
+		t.Errorf("Expected value behind any to be masked, got %+v", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	body := map[string]any{
+		"Phone": "13800138000",
+		"items": []any{map[string]any{"email": "alice@example.com", "qty": 1.0}},
+		"email": 3.0,
+	}
+	Keys(body, map[string]string{"phone": "phone", "email": "email"})
+	want := map[string]any{
+		"Phone": "138****8000",
+		"items": []any{map[string]any{"email": "a****@example.com", "qty": 1.0}},
+		"email": 3.0,
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("Expected %v, got %v", want, body)
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/nuohe369/crab/pkg/breaker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// breakerCollector exports circuit breaker state at scrape time
+// breakerCollector 在采集时导出熔断器状态
+type breakerCollector struct {
+	state    *prometheus.Desc
+	requests *prometheus.Desc
+	failures *prometheus.Desc
+}
+
+func newBreakerCollector() *breakerCollector {
+	labels := []string{"name"}
+	return &breakerCollector{
+		state: prometheus.NewDesc("circuit_breaker_state",
+			"Circuit breaker state (0 closed, 1 half-open, 2 open)", labels, nil),
+		requests: prometheus.NewDesc("circuit_breaker_requests",
+			"Requests in the current breaker interval", labels, nil),
+		failures: prometheus.NewDesc("circuit_breaker_failures",
+			"Failures in the current breaker interval", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.requests
+	ch <- c.failures
+}
+
+// Collect implements prometheus.Collector
+func (c *breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range breaker.AllStats() {
+		ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, float64(s.State), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.requests, prometheus.GaugeValue, float64(s.Requests), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(s.Failures), s.Name)
+	}
+}
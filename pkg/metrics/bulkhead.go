@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"github.com/nuohe369/crab/pkg/bulkhead"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// bulkheadCollector exports bulkhead usage at scrape time
+// bulkheadCollector 在采集时导出舱壁使用情况
+type bulkheadCollector struct {
+	active   *prometheus.Desc
+	queued   *prometheus.Desc
+	limit    *prometheus.Desc
+	rejected *prometheus.Desc
+}
+
+func newBulkheadCollector() *bulkheadCollector {
+	labels := []string{"name"}
+	return &bulkheadCollector{
+		active:   prometheus.NewDesc("bulkhead_active", "Calls currently running in the bulkhead", labels, nil),
+		queued:   prometheus.NewDesc("bulkhead_queued", "Calls waiting for a bulkhead slot", labels, nil),
+		limit:    prometheus.NewDesc("bulkhead_limit", "Maximum concurrent calls of the bulkhead", labels, nil),
+		rejected: prometheus.NewDesc("bulkhead_rejected_total", "Calls rejected by the bulkhead", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *bulkheadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.queued
+	ch <- c.limit
+	ch <- c.rejected
+}
+
+// Collect implements prometheus.Collector
+func (c *bulkheadCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range bulkhead.AllStats() {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(s.Active), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(s.Queued), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(s.Limit), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(s.Rejected), s.Name)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCollector exports default cache hit, miss and eviction counts at scrape time
+// cacheCollector 在采集时导出默认缓存的命中、未命中和淘汰次数
+type cacheCollector struct {
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	size      *prometheus.Desc
+}
+
+func newCacheCollector() *cacheCollector {
+	return &cacheCollector{
+		hits:      prometheus.NewDesc("cache_hits_total", "Cache lookups served, by level", []string{"level"}, nil),
+		misses:    prometheus.NewDesc("cache_misses_total", "Cache lookups found in neither level", nil, nil),
+		evictions: prometheus.NewDesc("cache_evictions_total", "Expired local cache entries removed", nil, nil),
+		size:      prometheus.NewDesc("cache_local_entries", "Entries in the local cache", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.size
+}
+
+// Collect implements prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	cc := cache.Get()
+	if cc == nil {
+		return
+	}
+	s := cc.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.LocalHits), "local")
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.RedisHits), "redis")
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.LocalSize))
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"sync"
 
+	"github.com/nuohe369/crab/pkg/snowflake"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 )
@@ -60,6 +61,25 @@ func Init(cfg Config) {
 	registry.MustRegister(httpRequestsTotal)
 	registry.MustRegister(httpRequestDuration)
 	registry.MustRegister(httpRequestsInFlight)
+	registry.MustRegister(httpRequestSize)
+	registry.MustRegister(httpResponseSize)
+
+	// Register circuit breaker state and bulkhead usage | 注册熔断器状态和舱壁使用情况
+	registry.MustRegister(newBreakerCollector())
+	registry.MustRegister(newBulkheadCollector())
+
+	// Register infrastructure pools, cache, WebSocket and SSE hubs | 注册基础设施连接池、缓存、WebSocket 和 SSE Hub
+	registry.MustRegister(newPgsqlCollector())
+	registry.MustRegister(newRedisCollector())
+	registry.MustRegister(newCacheCollector())
+	registry.MustRegister(newWSCollector())
+	registry.MustRegister(newSSECollector())
+
+	// Register snowflake clock skew events | 注册雪花 ID 时钟回拨事件
+	registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "snowflake_clock_skew_total",
+		Help: "Backward clock jumps detected by the snowflake ID generator",
+	}, func() float64 { return float64(snowflake.SkewEvents()) }))
 
 	log.Printf("metrics: enabled, path: %s", path)
 }
@@ -102,6 +122,24 @@ var (
 		[]string{"method", "path"},
 	)
 
+	httpRequestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "HTTP request body size in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7), // 100B to 100MB
+		},
+		[]string{"method", "route"},
+	)
+
+	httpResponseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size in bytes, streamed responses excluded",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7), // 100B to 100MB
+		},
+		[]string{"method", "route"},
+	)
+
 	httpRequestsInFlight = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "http_requests_in_flight",
@@ -120,6 +158,18 @@ func RecordHTTPRequest(method, path, status string, duration float64) {
 	httpRequestDuration.WithLabelValues(method, path).Observe(duration)
 }
 
+// RecordHTTPSize records request and response body sizes by matched route; a negative size is skipped
+// RecordHTTPSize 按匹配的路由记录请求和响应体大小；负数大小会被跳过
+func RecordHTTPSize(method, route string, requestBytes, responseBytes int) {
+	if !enabled {
+		return
+	}
+	httpRequestSize.WithLabelValues(method, route).Observe(float64(requestBytes))
+	if responseBytes >= 0 {
+		httpResponseSize.WithLabelValues(method, route).Observe(float64(responseBytes))
+	}
+}
+
 // IncHTTPInFlight increments the in-flight request count
 // IncHTTPInFlight 增加正在处理的请求计数
 func IncHTTPInFlight() {
@@ -32,11 +32,32 @@ func Middleware() fiber.Handler {
 
 		// 记录指标
 		RecordHTTPRequest(c.Method(), c.Path(), status, duration)
+		RecordHTTPSize(c.Method(), c.Route().Path, RequestSize(c), responseSize(c))
 
 		return err
 	}
 }
 
+// RequestSize 返回请求体大小,存在 Content-Length 时使用该值
+func RequestSize(c *fiber.Ctx) int {
+	if n := c.Request().Header.ContentLength(); n > 0 {
+		return n
+	}
+	return len(c.Request().Body())
+}
+
+// responseSize 返回响应体大小,流式响应未知时返回 -1(读取流会破坏 SSE)
+func responseSize(c *fiber.Ctx) int {
+	resp := c.Response()
+	if n := resp.Header.ContentLength(); n >= 0 {
+		return n
+	}
+	if resp.IsBodyStream() {
+		return -1
+	}
+	return len(resp.Body())
+}
+
 // Handler 返回 Prometheus 指标暴露的 HTTP handler
 func Handler() fiber.Handler {
 	if !enabled || registry == nil {
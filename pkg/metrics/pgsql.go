@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pgsqlCollector exports database connection pool stats at scrape time
+// pgsqlCollector 在采集时导出数据库连接池统计
+type pgsqlCollector struct {
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	closed       *prometheus.Desc
+}
+
+func newPgsqlCollector() *pgsqlCollector {
+	labels := []string{"name"}
+	return &pgsqlCollector{
+		maxOpen:      prometheus.NewDesc("db_pool_max_open", "Maximum open connections of the pool", labels, nil),
+		open:         prometheus.NewDesc("db_pool_open", "Open connections, in use and idle", labels, nil),
+		inUse:        prometheus.NewDesc("db_pool_in_use", "Connections currently in use", labels, nil),
+		idle:         prometheus.NewDesc("db_pool_idle", "Idle connections", labels, nil),
+		waitCount:    prometheus.NewDesc("db_pool_wait_total", "Times a caller waited for a connection", labels, nil),
+		waitDuration: prometheus.NewDesc("db_pool_wait_seconds_total", "Total time spent waiting for a connection", labels, nil),
+		closed: prometheus.NewDesc("db_pool_closed_total",
+			"Connections closed due to max idle, idle time or lifetime limits", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *pgsqlCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.closed
+}
+
+// Collect implements prometheus.Collector
+func (c *pgsqlCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range pgsql.AllStats() {
+		closed := s.MaxIdleClosed + s.MaxIdleTimeClosed + s.MaxLifetimeClosed
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds(), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(closed), s.Name)
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/nuohe369/crab/pkg/ws"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// wsCollector exports WebSocket hub connection and message counts at scrape time
+// wsCollector 在采集时导出 WebSocket Hub 连接数和消息数
+type wsCollector struct {
+	clients  *prometheus.Desc
+	users    *prometheus.Desc
+	dropped  *prometheus.Desc
+	messages *prometheus.Desc
+	unknown  *prometheus.Desc
+}
+
+func newWSCollector() *wsCollector {
+	labels := []string{"hub"}
+	return &wsCollector{
+		clients:  prometheus.NewDesc("ws_clients", "Current WebSocket connections", labels, nil),
+		users:    prometheus.NewDesc("ws_users", "Current online WebSocket users", labels, nil),
+		dropped:  prometheus.NewDesc("ws_dropped_messages_total", "Messages dropped on full send buffers", labels, nil),
+		messages: prometheus.NewDesc("ws_messages_total", "Client messages handled by type and result (ok, error)", []string{"hub", "type", "result"}, nil),
+		unknown:  prometheus.NewDesc("ws_unknown_messages_total", "Client messages of unregistered types", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *wsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.clients
+	ch <- c.users
+	ch <- c.dropped
+	ch <- c.messages
+	ch <- c.unknown
+}
+
+// Collect implements prometheus.Collector
+func (c *wsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range ws.AllStats() {
+		ch <- prometheus.MustNewConstMetric(c.clients, prometheus.GaugeValue, float64(s.Clients), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(s.Users), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped), s.Name)
+		ch <- prometheus.MustNewConstMetric(c.unknown, prometheus.CounterValue, float64(s.Unknown), s.Name)
+		for msgType, m := range s.Messages {
+			ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, float64(m.Handled), s.Name, msgType, "ok")
+			ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, float64(m.Failed), s.Name, msgType, "error")
+		}
+	}
+}
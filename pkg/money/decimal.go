@@ -0,0 +1,348 @@
+// Package money provides an exact decimal type and currency amounts, so prices are never float64.
+// Package money 提供精确的十进制类型和货币金额，使价格不再使用 float64。
+//
+// Rounding is half to even (banker's rounding) everywhere, which keeps sums of rounded
+// amounts unbiased.
+// 所有舍入均为四舍六入五成双（银行家舍入），使舍入后金额的求和没有偏差。
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxScale is the largest number of decimal places | MaxScale 为最大小数位数
+const MaxScale = 18
+
+var (
+	// ErrOverflow is the panic value when a result does not fit, about 9.2e18 units of the scale
+	// ErrOverflow 为结果超出范围时的 panic 值，范围约为 9.2e18 个最小单位
+	ErrOverflow = errors.New("money: decimal overflow")
+	// ErrDivisionByZero is the panic value of a division by zero | ErrDivisionByZero 为除以零时的 panic 值
+	ErrDivisionByZero = errors.New("money: division by zero")
+)
+
+// Decimal is an exact decimal number: a coefficient and a number of decimal places.
+// The zero value is 0. Like integers, arithmetic panics on overflow and division by zero.
+// Decimal 表示精确的十进制数：系数和小数位数
+// 零值为 0。与整数一样，运算溢出或除以零时会 panic。
+//
+// 12.50 and 12.5 are equal but keep their scale when printed, use Equal or Cmp rather than ==.
+// 12.50 与 12.5 相等，但打印时保留各自的小数位数，请使用 Equal 或 Cmp 而不是 ==。
+//
+// Example | 示例:
+//
+//	type Product struct {
+//		Price money.Decimal `json:"price" xorm:"decimal(20,4) notnull default 0"`
+//	}
+//	total := p.Price.Mul(money.NewFromInt(qty)).Round(2)
+type Decimal struct {
+	coef  int64
+	scale int32
+}
+
+// Zero is the decimal 0 | Zero 为十进制数 0
+var Zero = Decimal{}
+
+// New returns coef × 10^-scale, e.g. New(1250, 2) is 12.50 | New 返回 coef × 10^-scale，例如 New(1250, 2) 为 12.50
+func New(coef int64, scale int32) Decimal {
+	if scale < 0 || scale > MaxScale {
+		panic(fmt.Sprintf("money: scale %d out of range [0, %d]", scale, MaxScale))
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// NewFromInt returns the integer v | NewFromInt 返回整数 v
+func NewFromInt(v int64) Decimal {
+	return Decimal{coef: v}
+}
+
+// NewFromFloat returns the shortest decimal printing as f, rounded to MaxScale.
+// Use it at boundaries only, e.g. for legacy float input.
+// NewFromFloat 返回打印结果与 f 相同的最短十进制数，舍入到 MaxScale
+// 仅在边界处使用，例如兼容旧的浮点输入。
+func NewFromFloat(f float64) (Decimal, error) {
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Parse parses a decimal such as "12.50", "-3" or "+0.1"; extra decimal places round to MaxScale
+// Parse 解析十进制数，例如 "12.50"、"-3" 或 "+0.1"；超出的小数位数舍入到 MaxScale
+func Parse(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	digits := strings.TrimLeft(str, "+-")
+	if len(str)-len(digits) > 1 {
+		return Zero, fmt.Errorf("money: invalid decimal %q", s)
+	}
+	intPart, frac, _ := strings.Cut(digits, ".")
+	if intPart == "" && frac == "" || !isDigits(intPart) || !isDigits(frac) {
+		return Zero, fmt.Errorf("money: invalid decimal %q", s)
+	}
+
+	v, ok := new(big.Int).SetString(intPart+frac, 10)
+	if !ok {
+		return Zero, fmt.Errorf("money: invalid decimal %q", s)
+	}
+	if strings.HasPrefix(str, "-") {
+		v.Neg(v)
+	}
+	scale := int32(len(frac))
+	if scale > MaxScale {
+		v, scale = rescale(v, scale, MaxScale), MaxScale
+	}
+	if !v.IsInt64() {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	return Decimal{coef: v.Int64(), scale: scale}, nil
+}
+
+// MustParse is like Parse but panics on error, for constants | MustParse 与 Parse 相同但出错时 panic，用于常量
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Scale returns the number of decimal places | Scale 返回小数位数
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Coefficient returns the unscaled value, e.g. 1250 for 12.50 | Coefficient 返回未缩放的值，例如 12.50 返回 1250
+func (d Decimal) Coefficient() int64 { return d.coef }
+
+// Sign returns -1, 0 or 1 | Sign 返回 -1、0 或 1
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef < 0:
+		return -1
+	case d.coef > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether d is 0 | IsZero 判断 d 是否为 0
+func (d Decimal) IsZero() bool { return d.coef == 0 }
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than o | Cmp 在 d 小于、等于、大于 o 时分别返回 -1、0、1
+func (d Decimal) Cmp(o Decimal) int {
+	scale := max(d.scale, o.scale)
+	return rescale(d.big(), d.scale, scale).Cmp(rescale(o.big(), o.scale, scale))
+}
+
+// Equal reports whether d and o are the same number, whatever their scale | Equal 判断 d 和 o 数值是否相等，与小数位数无关
+func (d Decimal) Equal(o Decimal) bool { return d.Cmp(o) == 0 }
+
+// LessThan reports whether d < o | LessThan 判断 d < o
+func (d Decimal) LessThan(o Decimal) bool { return d.Cmp(o) < 0 }
+
+// GreaterThan reports whether d > o | GreaterThan 判断 d > o
+func (d Decimal) GreaterThan(o Decimal) bool { return d.Cmp(o) > 0 }
+
+// Neg returns -d | Neg 返回 -d
+func (d Decimal) Neg() Decimal {
+	return fromBig(new(big.Int).Neg(d.big()), d.scale)
+}
+
+// Abs returns |d| | Abs 返回 |d|
+func (d Decimal) Abs() Decimal {
+	if d.coef < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Add returns d + o at the larger scale | Add 返回 d + o，小数位数取较大者
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return fromBig(new(big.Int).Add(rescale(d.big(), d.scale, scale), rescale(o.big(), o.scale, scale)), scale)
+}
+
+// Sub returns d - o at the larger scale | Sub 返回 d - o，小数位数取较大者
+func (d Decimal) Sub(o Decimal) Decimal {
+	return d.Add(o.Neg())
+}
+
+// Mul returns d × o, exact up to MaxScale decimal places | Mul 返回 d × o，在 MaxScale 位小数内是精确的
+func (d Decimal) Mul(o Decimal) Decimal {
+	v, scale := new(big.Int).Mul(d.big(), o.big()), d.scale+o.scale
+	if scale > MaxScale {
+		v, scale = rescale(v, scale, MaxScale), MaxScale
+	}
+	return fromBig(v, scale)
+}
+
+// Div returns d ÷ o rounded to scale decimal places | Div 返回 d ÷ o，舍入到 scale 位小数
+func (d Decimal) Div(o Decimal, scale int32) Decimal {
+	if o.coef == 0 {
+		panic(ErrDivisionByZero)
+	}
+	scale = clampScale(scale)
+	// d/o = d.coef × 10^(o.scale+scale) / (o.coef × 10^d.scale) at scale | 在 scale 位小数下的商
+	num := new(big.Int).Mul(d.big(), pow10(o.scale+scale))
+	den := new(big.Int).Mul(o.big(), pow10(d.scale))
+	return fromBig(quoHalfEven(num, den), scale)
+}
+
+// Round returns d rounded half to even to scale decimal places, e.g. 2.345 → 2.34, 2.355 → 2.36.
+// A larger scale pads with zeros.
+// Round 返回 d 按四舍六入五成双舍入到 scale 位小数的结果，例如 2.345 → 2.34，2.355 → 2.36
+// scale 更大时补零。
+func (d Decimal) Round(scale int32) Decimal {
+	scale = clampScale(scale)
+	return fromBig(rescale(d.big(), d.scale, scale), scale)
+}
+
+// Truncate returns d with the digits beyond scale dropped | Truncate 返回舍弃 scale 位之后数字的 d
+func (d Decimal) Truncate(scale int32) Decimal {
+	scale = clampScale(scale)
+	if scale >= d.scale {
+		return d
+	}
+	return fromBig(new(big.Int).Quo(d.big(), pow10(d.scale-scale)), scale)
+}
+
+// Float64 returns the nearest float64, for display or statistics only | Float64 返回最接近的 float64，仅用于展示或统计
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns d with its scale, e.g. "12.50" | String 返回保留小数位数的 d，例如 "12.50"
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.coef, 10)
+	if d.scale == 0 {
+		return s
+	}
+	sign := ""
+	if d.coef < 0 {
+		sign, s = "-", s[1:]
+	}
+	if pad := int(d.scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	i := len(s) - int(d.scale)
+	return sign + s[:i] + "." + s[i:]
+}
+
+// StringFixed returns d rounded to scale decimal places, e.g. "12.50" | StringFixed 返回舍入到 scale 位小数的 d，例如 "12.50"
+func (d Decimal) StringFixed(scale int32) string {
+	return d.Round(scale).String()
+}
+
+// MarshalJSON encodes d as a string, which JavaScript cannot round | MarshalJSON 将 d 编码为字符串，避免 JavaScript 舍入
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a string or a number; null leaves d unchanged | UnmarshalJSON 接受字符串或数字；null 时 d 保持不变
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalText encodes d for query strings and forms | MarshalText 为查询字符串和表单编码 d
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses d from query strings and forms | UnmarshalText 从查询字符串和表单解析 d
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// FromDB parses a NUMERIC value (called by XORM when reading from database)
+// FromDB 解析 NUMERIC 值（XORM 从数据库读取时调用）
+func (d *Decimal) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*d = Zero
+		return nil
+	}
+	v, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// ToDB converts d to a NUMERIC literal (called by XORM when writing to database)
+// ToDB 将 d 转换为 NUMERIC 字面量（XORM 写入数据库时调用）
+func (d Decimal) ToDB() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d Decimal) big() *big.Int { return big.NewInt(d.coef) }
+
+// fromBig returns v at scale, panicking with ErrOverflow when it does not fit
+// fromBig 返回 scale 位小数的 v，超出范围时以 ErrOverflow panic
+func fromBig(v *big.Int, scale int32) Decimal {
+	if !v.IsInt64() {
+		panic(ErrOverflow)
+	}
+	return Decimal{coef: v.Int64(), scale: scale}
+}
+
+// rescale converts the coefficient v from scale from to scale to, rounding half to even
+// rescale 将系数 v 从 from 位小数转换为 to 位小数，四舍六入五成双
+func rescale(v *big.Int, from, to int32) *big.Int {
+	if to >= from {
+		return new(big.Int).Mul(v, pow10(to-from))
+	}
+	return quoHalfEven(v, pow10(from-to))
+}
+
+// quoHalfEven returns num / den rounded half to even | quoHalfEven 返回 num / den 四舍六入五成双的结果
+func quoHalfEven(num, den *big.Int) *big.Int {
+	if den.Sign() < 0 {
+		num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+	}
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	// Compare the remainder to half the divisor | 将余数与除数的一半比较
+	switch c := new(big.Int).Lsh(r.Abs(r), 1).Cmp(den); {
+	case c > 0, c == 0 && q.Bit(0) == 1:
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func clampScale(scale int32) int32 {
+	return min(max(scale, 0), MaxScale)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
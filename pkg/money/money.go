@@ -0,0 +1,131 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts of different currencies
+// ErrCurrencyMismatch 表示对不同货币的金额进行运算
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// minorUnits holds the decimal places of currencies that do not use 2 (ISO 4217)
+// minorUnits 保存小数位数不是 2 的货币（ISO 4217）
+var minorUnits = map[string]int32{
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0, "UGX": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyScale returns the decimal places of an ISO 4217 currency code, 2 by default
+// CurrencyScale 返回 ISO 4217 货币代码的小数位数，默认为 2
+func CurrencyScale(currency string) int32 {
+	if scale, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return scale
+	}
+	return 2
+}
+
+// Money is an amount in a currency, encoded in JSON as {"amount": "12.50", "currency": "CNY"}.
+// Store it as a decimal amount column and a currency column.
+// Money 表示某种货币的金额，JSON 编码为 {"amount": "12.50", "currency": "CNY"}
+// 存储时使用十进制金额列和货币列。
+//
+// Example | 示例:
+//
+//	price := money.NewMoney(product.Price, "CNY")
+//	total := price.Mul(money.NewFromInt(qty))      // rounded to fen | 舍入到分
+//	req.TotalFee = total.Minor()                   // 1250 fen for payment APIs | 支付接口使用的 1250 分
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// NewMoney returns amount in currency, rounded to the currency scale | NewMoney 返回 currency 货币的 amount，舍入到该货币的小数位数
+func NewMoney(amount Decimal, currency string) Money {
+	currency = strings.ToUpper(currency)
+	return Money{Amount: amount.Round(CurrencyScale(currency)), Currency: currency}
+}
+
+// FromMinor returns the amount of units in the minor unit, e.g. FromMinor(1250, "CNY") is 12.50 CNY
+// FromMinor 返回以最小单位计的金额，例如 FromMinor(1250, "CNY") 为 12.50 CNY
+func FromMinor(units int64, currency string) Money {
+	currency = strings.ToUpper(currency)
+	return Money{Amount: New(units, CurrencyScale(currency)), Currency: currency}
+}
+
+// Minor returns the amount in the minor unit (fen, cents), as payment APIs expect
+// Minor 返回以最小单位（分）计的金额，供支付接口使用
+func (m Money) Minor() int64 {
+	return m.Amount.Round(CurrencyScale(m.Currency)).Coefficient()
+}
+
+// IsZero reports whether the amount is 0 | IsZero 判断金额是否为 0
+func (m Money) IsZero() bool { return m.Amount.IsZero() }
+
+// IsNegative reports whether the amount is below 0 | IsNegative 判断金额是否小于 0
+func (m Money) IsNegative() bool { return m.Amount.Sign() < 0 }
+
+// Add returns m + o, failing when the currencies differ | Add 返回 m + o，货币不同时失败
+func (m Money) Add(o Money) (Money, error) {
+	if !m.sameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return NewMoney(m.Amount.Add(o.Amount), m.Currency), nil
+}
+
+// Sub returns m - o, failing when the currencies differ | Sub 返回 m - o，货币不同时失败
+func (m Money) Sub(o Money) (Money, error) {
+	if !m.sameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return NewMoney(m.Amount.Sub(o.Amount), m.Currency), nil
+}
+
+// Mul returns m × factor (a quantity, a discount rate) rounded to the currency scale
+// Mul 返回 m × factor（数量、折扣率），舍入到货币的小数位数
+func (m Money) Mul(factor Decimal) Money {
+	return NewMoney(m.Amount.Mul(factor), m.Currency)
+}
+
+// Cmp compares m and o, failing when the currencies differ | Cmp 比较 m 和 o，货币不同时失败
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.sameCurrency(o) {
+		return 0, fmt.Errorf("%w: %s, %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// Split divides m into n parts summing exactly to m; the first parts get the leftover minor units.
+// For example 10.00 split in 3 is 3.34, 3.33, 3.33.
+// Split 将 m 分成 n 份且总和恰好等于 m；余下的最小单位分给前几份。
+// 例如 10.00 分成 3 份为 3.34、3.33、3.33。
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	total := m.Minor()
+	part, rest := total/int64(n), total%int64(n)
+	step := int64(1)
+	if rest < 0 {
+		rest, step = -rest, -1
+	}
+	parts := make([]Money, n)
+	for i := range parts {
+		units := part
+		if int64(i) < rest {
+			units += step
+		}
+		parts[i] = FromMinor(units, m.Currency)
+	}
+	return parts
+}
+
+// String returns the amount and currency, e.g. "12.50 CNY" | String 返回金额和货币，例如 "12.50 CNY"
+func (m Money) String() string {
+	return m.Amount.StringFixed(CurrencyScale(m.Currency)) + " " + m.Currency
+}
+
+func (m Money) sameCurrency(o Money) bool {
+	return strings.EqualFold(m.Currency, o.Currency)
+}
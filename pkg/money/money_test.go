@@ -0,0 +1,172 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{"+3", "3"},
+		{".5", "0.5"},
+		{" 7 ", "7"},
+		{"0.1234567890123456785", "0.123456789012345678"},
+		{"0.1234567890123456775", "0.123456789012345678"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.in)
+		if err != nil || d.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.in, d, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", ".", "-", "1.2.3", "1e3", "--1", "abc", "99999999999999999999"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+	if d, err := NewFromFloat(0.1); err != nil || d.String() != "0.1" {
+		t.Errorf("NewFromFloat(0.1) = %s, %v", d, err)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("0.1"), MustParse("0.2")
+	if sum := a.Add(b); !sum.Equal(MustParse("0.3")) || sum.String() != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if diff := MustParse("5").Sub(MustParse("7.25")); diff.String() != "-2.25" {
+		t.Errorf("5 - 7.25 = %s", diff)
+	}
+	if p := MustParse("19.99").Mul(MustParse("3")); p.String() != "59.97" {
+		t.Errorf("19.99 × 3 = %s", p)
+	}
+	if q := MustParse("10").Div(MustParse("3"), 4); q.String() != "3.3333" {
+		t.Errorf("10 ÷ 3 = %s", q)
+	}
+	if q := MustParse("-1").Div(MustParse("8"), 2); q.String() != "-0.12" {
+		t.Errorf("-1 ÷ 8 = %s, want half to even -0.12", q)
+	}
+	if !MustParse("12.5").Equal(MustParse("12.500")) || !MustParse("-1").LessThan(Zero) || !New(1, 18).GreaterThan(Zero) {
+		t.Error("Comparisons should ignore the scale")
+	}
+	if tr := MustParse("-2.789").Truncate(1); tr.String() != "-2.7" {
+		t.Errorf("Truncate = %s", tr)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrDivisionByZero {
+			t.Errorf("Division by zero should panic with ErrDivisionByZero, got %v", r)
+		}
+	}()
+	MustParse("1").Div(Zero, 2)
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	tests := map[string]string{
+		"2.345": "2.34", "2.355": "2.36", "2.3451": "2.35",
+		"-2.345": "-2.34", "-2.355": "-2.36", "0.005": "0.00", "0.015": "0.02",
+		"1.5": "1.50",
+	}
+	for in, want := range tests {
+		if got := MustParse(in).Round(2).String(); got != want {
+			t.Errorf("Round(%s) = %s, want %s", in, got, want)
+		}
+	}
+	if got := MustParse("2.5").Round(0).String(); got != "2" {
+		t.Errorf("Round(2.5, 0) = %s, want 2", got)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrOverflow {
+			t.Errorf("Overflow should panic with ErrOverflow, got %v", r)
+		}
+	}()
+	New(1<<62, 0).Add(New(1<<62, 0))
+}
+
+func TestEncoding(t *testing.T) {
+	var v struct {
+		Price Decimal  `json:"price"`
+		Fee   Decimal  `json:"fee"`
+		Tip   *Decimal `json:"tip"`
+	}
+	if err := json.Unmarshal([]byte(`{"price":"12.50","fee":0.3,"tip":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price.String() != "12.50" || v.Fee.String() != "0.3" || v.Tip != nil {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+	data, _ := json.Marshal(NewMoney(MustParse("12.5"), "cny"))
+	if string(data) != `{"amount":"12.50","currency":"CNY"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"price":"1,5"}`), &v); err == nil {
+		t.Error("Invalid amounts should be rejected")
+	}
+
+	var d Decimal
+	if err := d.FromDB([]byte("123.4500")); err != nil || d.String() != "123.4500" {
+		t.Errorf("FromDB = %s, %v", d, err)
+	}
+	if err := d.FromDB(nil); err != nil || !d.IsZero() {
+		t.Errorf("FromDB(nil) = %s, %v", d, err)
+	}
+	if v, _ := MustParse("-0.07").ToDB(); v != "-0.07" {
+		t.Errorf("ToDB = %v", v)
+	}
+	if err := d.UnmarshalText([]byte("8.8")); err != nil || d.String() != "8.8" {
+		t.Errorf("UnmarshalText = %s, %v", d, err)
+	}
+}
+
+func TestMoney(t *testing.T) {
+	price := FromMinor(1999, "CNY")
+	if price.String() != "19.99 CNY" || price.Minor() != 1999 {
+		t.Errorf("FromMinor = %s", price)
+	}
+	if yen := NewMoney(MustParse("1234.5"), "JPY"); yen.String() != "1234 JPY" || yen.Minor() != 1234 {
+		t.Errorf("JPY has no minor unit, got %s", yen)
+	}
+	// 15% off 19.99 is 16.9915, rounded to fen | 19.99 打八五折为 16.9915，舍入到分
+	if got := price.Mul(MustParse("0.85")); got.String() != "16.99 CNY" {
+		t.Errorf("Discount = %s", got)
+	}
+	total, err := price.Add(FromMinor(1, "cny"))
+	if err != nil || total.Minor() != 2000 {
+		t.Errorf("Add = %s, %v", total, err)
+	}
+	if _, err := price.Sub(FromMinor(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub across currencies error = %v", err)
+	}
+	if c, err := price.Cmp(total); err != nil || c != -1 {
+		t.Errorf("Cmp = %d, %v", c, err)
+	}
+
+	for _, tt := range []struct {
+		amount string
+		want   []string
+	}{
+		{"10.00", []string{"3.34", "3.33", "3.33"}},
+		{"-0.05", []string{"-0.02", "-0.02", "-0.01"}},
+	} {
+		parts := NewMoney(MustParse(tt.amount), "CNY").Split(3)
+		sum := FromMinor(0, "CNY")
+		for i, p := range parts {
+			if p.Amount.String() != tt.want[i] {
+				t.Errorf("Split(%s)[%d] = %s, want %s", tt.amount, i, p.Amount, tt.want[i])
+			}
+			sum, _ = sum.Add(p)
+		}
+		if !sum.Amount.Equal(MustParse(tt.amount)) {
+			t.Errorf("Split(%s) sums to %s", tt.amount, sum)
+		}
+	}
+}
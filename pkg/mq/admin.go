@@ -0,0 +1,122 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nuohe369/crab/pkg/mq/internal"
+)
+
+// ErrNotSupported is returned by admin operations the driver cannot perform
+// ErrNotSupported 在驱动不支持的管理操作时返回
+var ErrNotSupported = internal.ErrNotSupported
+
+// TopicInfo describes a topic and its consumer groups
+// TopicInfo 描述一个主题及其消费者组
+type TopicInfo struct {
+	Name    string      `json:"name"`
+	Length  int64       `json:"length"`  // Messages in the stream or ready in the queue | 流中或队列中就绪的消息数
+	Delayed int64       `json:"delayed"` // Delayed messages not yet due, -1 when unknown | 尚未到期的延迟消息数，未知时为 -1
+	Groups  []GroupInfo `json:"groups"`
+}
+
+// GroupInfo describes a consumer group of a topic
+// GroupInfo 描述主题的一个消费者组
+type GroupInfo struct {
+	Name      string `json:"name"`
+	Consumers int64  `json:"consumers"`
+	Pending   int64  `json:"pending"` // Delivered but not acknowledged | 已投递但未确认
+	Lag       int64  `json:"lag"`     // Not yet delivered, -1 when unknown | 尚未投递，未知时为 -1
+}
+
+// Admin is implemented by clients that support topic management (the built-in drivers do)
+// RabbitMQ cannot list topics or delete groups over AMQP and returns ErrNotSupported.
+// Admin 由支持主题管理的客户端实现（内置驱动均已实现）
+// RabbitMQ 无法通过 AMQP 列出主题或删除消费者组，返回 ErrNotSupported。
+type Admin interface {
+	// Topics lists the topic names | Topics 列出主题名称
+	Topics(ctx context.Context) ([]string, error)
+	// TopicInfo returns the depth and consumer groups of topic | TopicInfo 返回主题的深度和消费者组
+	TopicInfo(ctx context.Context, topic string) (*TopicInfo, error)
+	// Purge removes the messages of topic | Purge 删除主题中的消息
+	Purge(ctx context.Context, topic string) error
+	// DeleteGroup deletes a consumer group of topic | DeleteGroup 删除主题的消费者组
+	DeleteGroup(ctx context.Context, topic, group string) error
+}
+
+func (w *mqWrapper) Topics(ctx context.Context) ([]string, error) {
+	return w.impl.Topics(ctx)
+}
+
+func (w *mqWrapper) TopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	info, err := w.impl.TopicInfo(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	t := &TopicInfo{Name: info.Name, Length: info.Length, Delayed: info.Delayed, Groups: []GroupInfo{}}
+	for _, g := range info.Groups {
+		t.Groups = append(t.Groups, GroupInfo(g))
+	}
+	return t, nil
+}
+
+func (w *mqWrapper) Purge(ctx context.Context, topic string) error {
+	return w.impl.Purge(ctx, topic)
+}
+
+func (w *mqWrapper) DeleteGroup(ctx context.Context, topic, group string) error {
+	return w.impl.DeleteGroup(ctx, topic, group)
+}
+
+// admin returns the default client as Admin (internal function)
+// admin 以 Admin 返回默认客户端（内部函数）
+func admin() (Admin, error) {
+	if defaultMQ == nil {
+		return nil, fmt.Errorf("mq: not initialized")
+	}
+	a, ok := defaultMQ.(Admin)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return a, nil
+}
+
+// Topics lists the topic names (using default client)
+// Topics 列出主题名称（使用默认客户端）
+func Topics(ctx context.Context) ([]string, error) {
+	a, err := admin()
+	if err != nil {
+		return nil, err
+	}
+	return a.Topics(ctx)
+}
+
+// Inspect returns the depth and consumer groups of topic (using default client)
+// Inspect 返回主题的深度和消费者组（使用默认客户端）
+func Inspect(ctx context.Context, topic string) (*TopicInfo, error) {
+	a, err := admin()
+	if err != nil {
+		return nil, err
+	}
+	return a.TopicInfo(ctx, topic)
+}
+
+// Purge removes the messages of topic, consumer groups are kept (using default client)
+// Purge 删除主题中的消息，保留消费者组（使用默认客户端）
+func Purge(ctx context.Context, topic string) error {
+	a, err := admin()
+	if err != nil {
+		return err
+	}
+	return a.Purge(ctx, topic)
+}
+
+// DeleteGroup deletes a consumer group of topic with its pending messages (using default client)
+// DeleteGroup 删除主题的消费者组及其待确认消息（使用默认客户端）
+func DeleteGroup(ctx context.Context, topic, group string) error {
+	a, err := admin()
+	if err != nil {
+		return err
+	}
+	return a.DeleteGroup(ctx, topic, group)
+}
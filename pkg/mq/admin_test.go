@@ -0,0 +1,67 @@
+package mq
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestAdminRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := New(Config{Driver: "redis", Redis: RedisConfig{Addr: mr.Addr()}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+	a := client.(Admin)
+
+	rdb := client.GetRaw().(redis.UniversalClient)
+	if err := rdb.XGroupCreateMkStream(ctx, "order:paid", "billing", "0").Err(); err != nil {
+		t.Fatalf("XGroupCreate failed: %v", err)
+	}
+	client.Publish(ctx, "order:paid", []byte("1"))
+	client.Publish(ctx, "order:paid", []byte("2"))
+	client.PublishDelay(ctx, "order:paid", []byte("3"), time.Hour)
+	rdb.Set(ctx, "not-a-stream", "x", 0)
+
+	topics, err := a.Topics(ctx)
+	if err != nil || !slices.Equal(topics, []string{"order:paid"}) {
+		t.Fatalf("Topics = %v, %v, want [order:paid]", topics, err)
+	}
+
+	info, err := a.TopicInfo(ctx, "order:paid")
+	if err != nil {
+		t.Fatalf("TopicInfo failed: %v", err)
+	}
+	if info.Length != 2 || info.Delayed != 1 || len(info.Groups) != 1 || info.Groups[0].Name != "billing" {
+		t.Errorf("Unexpected topic info: %+v", info)
+	}
+
+	if err := a.Purge(ctx, "order:paid"); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	info, _ = a.TopicInfo(ctx, "order:paid")
+	if info.Length != 0 || info.Delayed != 0 || len(info.Groups) != 1 {
+		t.Errorf("Expected empty topic with its group after purge, got %+v", info)
+	}
+
+	if err := a.DeleteGroup(ctx, "order:paid", "billing"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	if info, _ = a.TopicInfo(ctx, "order:paid"); len(info.Groups) != 0 {
+		t.Errorf("Expected no groups after delete, got %+v", info.Groups)
+	}
+}
+
+func TestAdminNotSupported(t *testing.T) {
+	defaultMQ = &blockingMQ{}
+	t.Cleanup(func() { defaultMQ = nil })
+	if _, err := Topics(context.Background()); err != ErrNotSupported {
+		t.Errorf("Topics error = %v, want ErrNotSupported", err)
+	}
+}
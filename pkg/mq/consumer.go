@@ -0,0 +1,122 @@
+package mq
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// ConsumerOption configures a registered consumer
+// ConsumerOption 配置已注册的消费者
+type ConsumerOption func(*consumer)
+
+// WithWorkers sets how many Consume loops run for the consumer (default 1)
+// WithWorkers 设置消费者运行的 Consume 循环数（默认 1）
+func WithWorkers(n int) ConsumerOption {
+	return func(c *consumer) {
+		c.workers = max(n, 1)
+	}
+}
+
+// consumer is a registered topic/group handler
+// consumer 是已注册的 topic/group 处理器
+type consumer struct {
+	topic   string
+	group   string
+	handler Handler
+	workers int
+}
+
+var (
+	consumersMu     sync.Mutex
+	consumers       []*consumer        // Registered consumers | 已注册的消费者
+	consumersCtx    context.Context    // Set while started | 启动期间设置
+	consumersCancel context.CancelFunc // Cancels the Consume loops | 取消 Consume 循环
+	consumersWG     sync.WaitGroup     // Running Consume loops | 运行中的 Consume 循环
+)
+
+// RegisterConsumer registers a handler of topic for group, run by the default client
+// Boot starts registered consumers after the modules and stops them during graceful
+// shutdown, letting in-flight messages finish. Register in a module's Init or Start;
+// a consumer registered after the start runs immediately.
+// RegisterConsumer 为 group 注册 topic 的处理器，由默认客户端运行
+// boot 在模块启动后启动已注册的消费者，并在优雅关闭时停止它们，等待正在处理的消息完成。
+// 请在模块的 Init 或 Start 中注册；启动后注册的消费者会立即运行。
+//
+// Example | 示例:
+//
+//	mq.RegisterConsumer("order:paid", "notify", handleOrderPaid, mq.WithWorkers(4))
+func RegisterConsumer(topic, group string, handler Handler, opts ...ConsumerOption) {
+	c := &consumer{topic: topic, group: group, handler: handler, workers: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	consumersMu.Lock()
+	defer consumersMu.Unlock()
+	consumers = append(consumers, c)
+	if consumersCtx != nil {
+		run(consumersCtx, c)
+	}
+}
+
+// StartConsumers starts the registered consumers, a no-op when MQ is not enabled
+// StartConsumers 启动已注册的消费者，未启用 MQ 时不执行任何操作
+func StartConsumers() {
+	if !Enabled() {
+		return
+	}
+	consumersMu.Lock()
+	defer consumersMu.Unlock()
+	if consumersCtx != nil {
+		return
+	}
+	consumersCtx, consumersCancel = context.WithCancel(context.Background())
+	for _, c := range consumers {
+		run(consumersCtx, c)
+	}
+}
+
+// StopConsumers stops the consumers and waits for in-flight messages until ctx is done
+// StopConsumers 停止消费者，并在 ctx 结束前等待正在处理的消息
+func StopConsumers(ctx context.Context) {
+	consumersMu.Lock()
+	cancel := consumersCancel
+	consumersCtx, consumersCancel = nil, nil
+	consumersMu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		consumersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("mq: consumers stopped")
+	case <-ctx.Done():
+		log.Printf("mq: consumers still running after shutdown timeout: %v", ctx.Err())
+	}
+}
+
+// run starts the Consume loops of c, consumersMu must be held
+// run 启动 c 的 Consume 循环，调用方须持有 consumersMu
+func run(ctx context.Context, c *consumer) {
+	// In-flight messages finish after stop instead of seeing a canceled context | 停止后正在处理的消息继续完成，而不是收到已取消的上下文
+	handler := func(ctx context.Context, msg *Message) error {
+		return c.handler(context.WithoutCancel(ctx), msg)
+	}
+	for range c.workers {
+		consumersWG.Add(1)
+		go func() {
+			defer consumersWG.Done()
+			if err := Consume(ctx, c.topic, c.group, handler); err != nil && ctx.Err() == nil {
+				log.Printf("mq: consumer of %s/%s stopped: %v", c.topic, c.group, err)
+			}
+		}()
+	}
+	log.Printf("mq: consumer of %s/%s started with %d worker(s)", c.topic, c.group, c.workers)
+}
@@ -0,0 +1,77 @@
+package mq
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingMQ delivers one message per Consume call once ctx is canceled
+type blockingMQ struct {
+	consumed atomic.Int32
+}
+
+func (q *blockingMQ) Publish(context.Context, string, []byte) error { return nil }
+func (q *blockingMQ) PublishDelay(context.Context, string, []byte, time.Duration) error {
+	return nil
+}
+func (q *blockingMQ) Ack(context.Context, string, string, string) error { return nil }
+func (q *blockingMQ) Close() error                                      { return nil }
+func (q *blockingMQ) GetRaw() any                                       { return nil }
+
+func (q *blockingMQ) Consume(ctx context.Context, topic, _ string, handler Handler) error {
+	q.consumed.Add(1)
+	<-ctx.Done()
+	// A message fetched just before the stop is still handled | 停止前刚取到的消息仍会被处理
+	return handler(ctx, &Message{ID: "1", Topic: topic})
+}
+
+func TestConsumerLifecycle(t *testing.T) {
+	q := &blockingMQ{}
+	defaultMQ = q
+	t.Cleanup(func() { defaultMQ, consumers = nil, nil })
+
+	var handled, canceled atomic.Int32
+	RegisterConsumer("orders", "billing", func(ctx context.Context, msg *Message) error {
+		time.Sleep(20 * time.Millisecond)
+		if ctx.Err() != nil {
+			canceled.Add(1)
+		}
+		handled.Add(1)
+		return nil
+	}, WithWorkers(2))
+
+	StartConsumers()
+	StartConsumers() // No-op while started | 已启动时不执行任何操作
+	deadline := time.Now().Add(time.Second)
+	for q.consumed.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := q.consumed.Load(); n != 2 {
+		t.Fatalf("Consume loops = %d, want 2", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	StopConsumers(ctx)
+
+	if n := handled.Load(); n != 2 {
+		t.Errorf("handled = %d after stop, want 2 (drained)", n)
+	}
+	if n := canceled.Load(); n != 0 {
+		t.Errorf("%d handler(s) saw a canceled context", n)
+	}
+}
+
+func TestStartConsumersWithoutMQ(t *testing.T) {
+	defaultMQ = nil
+	RegisterConsumer("orders", "billing", func(context.Context, *Message) error { return nil })
+	t.Cleanup(func() { consumers = nil })
+
+	StartConsumers()
+	StopConsumers(context.Background()) // Nothing started | 未启动任何消费者
+	if consumersCtx != nil {
+		t.Error("consumers started without mq")
+	}
+}
@@ -0,0 +1,46 @@
+package mq_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/testing/containers"
+)
+
+// TestRabbitMQPublishConsume publishes through a real RabbitMQ broker and consumes the message
+// TestRabbitMQPublishConsume 通过真实 RabbitMQ broker 发布并消费消息
+func TestRabbitMQPublishConsume(t *testing.T) {
+	client, err := mq.New(containers.RabbitMQ(t))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	received := make(chan *mq.Message, 1)
+	go client.Consume(ctx, "orders", "billing", func(ctx context.Context, msg *mq.Message) error {
+		received <- msg
+		return nil
+	})
+	// Declaring the group queue races with the first publish, so publish until it arrives
+	// 声明分组队列与首次发布存在竞争，因此持续发布直到收到消息
+	for {
+		if err := client.Publish(ctx, "orders", []byte("paid")); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		select {
+		case msg := <-received:
+			if string(msg.Payload) != "paid" {
+				t.Errorf("Payload = %q, want paid", msg.Payload)
+			}
+			return
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the message")
+		}
+	}
+}
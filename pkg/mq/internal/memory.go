@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// errMemoryClosed is returned after the memory broker is closed
+var errMemoryClosed = errors.New("mq: memory broker closed")
+
+// Memory is an in-process broker for tests and local development with the semantics of
+// Redis Streams: a new group starts at the beginning of the topic, every group receives each
+// message once, and a message whose handler fails stays pending without redelivery.
+// Messages are lost on exit.
+type Memory struct {
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+	seq    int64
+	wake   chan struct{} // Closed and replaced whenever messages arrive
+	closed chan struct{}
+	once   sync.Once
+}
+
+// memoryTopic is the log of a topic
+type memoryTopic struct {
+	messages []*Message
+	offset   int // Messages removed by Purge, the log index of messages[0]
+	delayed  map[*time.Timer]struct{}
+	groups   map[string]*memoryGroup
+}
+
+// memoryGroup is the position of a consumer group in a topic
+type memoryGroup struct {
+	next      int // Log index of the next message to deliver
+	pending   map[string]struct{}
+	consumers int64
+}
+
+// NewMemory creates an in-process broker
+func NewMemory() *Memory {
+	return &Memory{
+		topics: make(map[string]*memoryTopic),
+		wake:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// topic returns the log of name, creating it on first use; the caller holds m.mu
+func (m *Memory) topic(name string) *memoryTopic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &memoryTopic{delayed: make(map[*time.Timer]struct{}), groups: make(map[string]*memoryGroup)}
+		m.topics[name] = t
+	}
+	return t
+}
+
+// undelivered returns the messages g has not received yet
+func (t *memoryTopic) undelivered(g *memoryGroup) int {
+	return t.offset + len(t.messages) - max(g.next, t.offset)
+}
+
+// append adds a message to topic and wakes the consumers; the caller holds m.mu
+func (m *Memory) append(topic string, payload []byte, headers map[string]string) {
+	m.seq++
+	t := m.topic(topic)
+	t.messages = append(t.messages, &Message{
+		ID:      fmt.Sprintf("%d-%d", time.Now().UnixMilli(), m.seq),
+		Topic:   topic,
+		Payload: slices.Clone(payload),
+		Headers: maps.Clone(headers),
+	})
+	close(m.wake)
+	m.wake = make(chan struct{})
+}
+
+// Publish appends a message to the topic, it is confirmed on return whatever the options
+func (m *Memory) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, opts PublishOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.closed:
+		return errMemoryClosed
+	default:
+	}
+	m.append(topic, payload, headers)
+	return nil
+}
+
+// PublishDelay appends a message to the topic once the delay has passed
+func (m *Memory) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration, opts PublishOptions) error {
+	if delay <= 0 {
+		return m.Publish(ctx, topic, payload, headers, opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.closed:
+		return errMemoryClosed
+	default:
+	}
+	payload, headers = slices.Clone(payload), maps.Clone(headers)
+	t := m.topic(topic)
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Purge and Close drop the timers of messages that must not arrive
+		if _, ok := t.delayed[timer]; ok {
+			delete(t.delayed, timer)
+			m.append(topic, payload, headers)
+		}
+	})
+	t.delayed[timer] = struct{}{}
+	return nil
+}
+
+// Consume delivers the messages of topic to handler until ctx is done, acknowledging handled ones
+func (m *Memory) Consume(ctx context.Context, topic, group string, handler func(ctx context.Context, msg *Message) error) error {
+	m.mu.Lock()
+	t := m.topic(topic)
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memoryGroup{next: t.offset, pending: make(map[string]struct{})}
+		t.groups[group] = g
+	}
+	g.consumers++
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		g.consumers--
+		m.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.closed:
+			return errMemoryClosed
+		default:
+		}
+
+		var msg *Message
+		m.mu.Lock()
+		g.next = max(g.next, t.offset)
+		if i := g.next - t.offset; i < len(t.messages) {
+			copied := *t.messages[i]
+			msg = &copied
+			g.next++
+			g.pending[msg.ID] = struct{}{}
+		}
+		wake := m.wake
+		m.mu.Unlock()
+
+		if msg == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-m.closed:
+				return errMemoryClosed
+			case <-wake:
+			}
+			continue
+		}
+
+		if err := handler(ctx, msg); err != nil {
+			log.Printf("mq: failed to process message: %v", err)
+			// Don't Ack, the message stays pending like on Redis Streams
+			continue
+		}
+		m.Ack(ctx, topic, group, msg.ID)
+	}
+}
+
+// Lag returns the messages of topic not yet delivered to group plus the unacknowledged ones
+func (m *Memory) Lag(ctx context.Context, topic, group string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.topics[topic]
+	if !ok {
+		return 0, nil
+	}
+	g, ok := t.groups[group]
+	if !ok {
+		return int64(t.offset + len(t.messages)), nil
+	}
+	return int64(t.undelivered(g) + len(g.pending)), nil
+}
+
+// Topics lists the topics that received a message or a consumer
+func (m *Memory) Topics(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Sorted(maps.Keys(m.topics)), nil
+}
+
+// TopicInfo returns the length, delayed messages and groups of topic
+func (m *Memory) TopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info := &TopicInfo{Name: topic}
+	t, ok := m.topics[topic]
+	if !ok {
+		return info, nil
+	}
+	info.Length, info.Delayed = int64(len(t.messages)), int64(len(t.delayed))
+	for _, name := range slices.Sorted(maps.Keys(t.groups)) {
+		g := t.groups[name]
+		info.Groups = append(info.Groups, GroupInfo{
+			Name:      name,
+			Consumers: g.consumers,
+			Pending:   int64(len(g.pending)),
+			Lag:       int64(t.undelivered(g)),
+		})
+	}
+	return info, nil
+}
+
+// Purge removes the messages of topic, including delayed ones, and keeps its groups
+func (m *Memory) Purge(ctx context.Context, topic string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.topics[topic]
+	if !ok {
+		return nil
+	}
+	t.offset += len(t.messages)
+	t.messages = nil
+	for timer := range t.delayed {
+		timer.Stop()
+	}
+	clear(t.delayed)
+	return nil
+}
+
+// DeleteGroup removes a consumer group of topic with its pending entries
+func (m *Memory) DeleteGroup(ctx context.Context, topic, group string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.topics[topic]; ok {
+		delete(t.groups, group)
+	}
+	return nil
+}
+
+// Ack acknowledges a message
+func (m *Memory) Ack(ctx context.Context, topic, group, msgID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.topics[topic]; ok {
+		if g, ok := t.groups[group]; ok {
+			delete(g.pending, msgID)
+		}
+	}
+	return nil
+}
+
+// Close stops the consumers and drops the delayed messages
+func (m *Memory) Close() error {
+	m.once.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		close(m.closed)
+		for _, t := range m.topics {
+			for timer := range t.delayed {
+				timer.Stop()
+			}
+			clear(t.delayed)
+		}
+	})
+	return nil
+}
+
+// GetRaw returns the broker itself
+func (m *Memory) GetRaw() any {
+	return m
+}
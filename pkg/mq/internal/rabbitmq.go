@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -20,6 +21,9 @@ type RabbitMQ struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	mu      sync.Mutex
+
+	confirm *amqp.Channel    // Channel in confirm mode, opened on first confirmed publish
+	returns chan amqp.Return // Unroutable mandatory messages returned on confirm
 }
 
 // NewRabbitMQ creates a RabbitMQ client
@@ -60,26 +64,100 @@ func (r *RabbitMQ) ensureQueue(topic string) error {
 	return err
 }
 
+// headerTable converts message headers to an AMQP table
+func headerTable(headers map[string]string) amqp.Table {
+	if len(headers) == 0 {
+		return nil
+	}
+	table := make(amqp.Table, len(headers))
+	for k, v := range headers {
+		table[k] = v
+	}
+	return table
+}
+
 // Publish publishes a message
-func (r *RabbitMQ) Publish(ctx context.Context, topic string, payload []byte) error {
+func (r *RabbitMQ) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, opts PublishOptions) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if err := r.ensureQueue(topic); err != nil {
 		return fmt.Errorf("mq: failed to create queue: %w", err)
 	}
+	return r.publish(ctx, topic, payload, headers, opts)
+}
 
-	return r.channel.PublishWithContext(ctx,
-		"",    // exchange
-		topic, // routing key
-		false, // mandatory
-		false, // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/octet-stream",
-			Body:         payload,
-		},
-	)
+// publish sends a message to queue through the default exchange, r.mu must be held
+// With opts.Confirm (implied by opts.Mandatory) it returns after the broker acknowledges the message.
+func (r *RabbitMQ) publish(ctx context.Context, queue string, payload []byte, headers map[string]string, opts PublishOptions) error {
+	msg := amqp.Publishing{
+		MessageId:    uuid.New().String(),
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/octet-stream",
+		Headers:      headerTable(headers),
+		Body:         payload,
+	}
+	if !opts.Confirm && !opts.Mandatory {
+		return r.channel.PublishWithContext(ctx, "", queue, false, false, msg)
+	}
+
+	ch, err := r.confirmChannel()
+	if err != nil {
+		return fmt.Errorf("mq: failed to open confirm channel: %w", err)
+	}
+	r.drainReturns("")
+
+	dc, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", queue, opts.Mandatory, false, msg)
+	if err != nil {
+		return err
+	}
+	acked, err := dc.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("mq: publish to %s not confirmed: %w", queue, err)
+	}
+	// The broker returns an unroutable message before acking it
+	if ret, ok := r.drainReturns(msg.MessageId); ok {
+		return fmt.Errorf("mq: message to %s returned: %d %s", queue, ret.ReplyCode, ret.ReplyText)
+	}
+	if !acked {
+		return fmt.Errorf("mq: message to %s nacked by broker", queue)
+	}
+	return nil
+}
+
+// confirmChannel returns the channel in confirm mode, reopening it after a channel error; r.mu must be held
+func (r *RabbitMQ) confirmChannel() (*amqp.Channel, error) {
+	if r.confirm != nil && !r.confirm.IsClosed() {
+		return r.confirm, nil
+	}
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	// Buffered so a return left by a canceled publish never blocks the connection reader
+	r.returns = ch.NotifyReturn(make(chan amqp.Return, 64))
+	r.confirm = ch
+	return ch, nil
+}
+
+// drainReturns empties the pending returns, reporting the one of messageID if present
+func (r *RabbitMQ) drainReturns(messageID string) (amqp.Return, bool) {
+	var found amqp.Return
+	var ok bool
+	for {
+		select {
+		case ret := <-r.returns:
+			if messageID != "" && ret.MessageId == messageID {
+				found, ok = ret, true
+			}
+		default:
+			return found, ok
+		}
+	}
 }
 
 // delayQueue returns the delay queue name (one queue per delay duration)
@@ -113,7 +191,8 @@ func (r *RabbitMQ) ensureDelayQueue(topic string, delay time.Duration) (string,
 }
 
 // PublishDelay publishes a delayed message
-func (r *RabbitMQ) PublishDelay(ctx context.Context, topic string, payload []byte, delay time.Duration) error {
+// Headers survive the dead-letter hop to the target queue
+func (r *RabbitMQ) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration, opts PublishOptions) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -123,17 +202,7 @@ func (r *RabbitMQ) PublishDelay(ctx context.Context, topic string, payload []byt
 	}
 
 	// Send to delay queue
-	return r.channel.PublishWithContext(ctx,
-		"",     // exchange
-		delayQ, // routing key (延迟队列)
-		false,  // mandatory
-		false,  // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/octet-stream",
-			Body:         payload,
-		},
-	)
+	return r.publish(ctx, delayQ, payload, headers, opts)
 }
 
 // Consume consumes messages
@@ -190,6 +259,14 @@ func (r *RabbitMQ) Consume(ctx context.Context, topic, group string, handler fun
 				Topic:   topic,
 				Payload: d.Body,
 			}
+			for k, v := range d.Headers {
+				if s, ok := v.(string); ok {
+					if m.Headers == nil {
+						m.Headers = make(map[string]string)
+					}
+					m.Headers[k] = s
+				}
+			}
 
 			if err := handler(ctx, m); err != nil {
 				log.Printf("mq: failed to process message: %v", err)
@@ -204,6 +281,65 @@ func (r *RabbitMQ) Consume(ctx context.Context, topic, group string, handler fun
 	}
 }
 
+// Lag returns the messages ready in the topic queue
+// Uses a separate channel because a failed passive declare closes the channel.
+func (r *RabbitMQ) Lag(ctx context.Context, topic, group string) (int64, error) {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(topic, true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int64(q.Messages), nil
+}
+
+// Topics is not supported, listing queues requires the management plugin's HTTP API
+func (r *RabbitMQ) Topics(ctx context.Context) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+// TopicInfo returns the ready messages and consumers of the topic queue
+// Groups share the queue in RabbitMQ, so they are reported as one entry named after the queue.
+func (r *RabbitMQ) TopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(topic, true, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicInfo{
+		Name:    topic,
+		Length:  int64(q.Messages),
+		Delayed: -1,
+		Groups:  []GroupInfo{{Name: topic, Consumers: int64(q.Consumers), Lag: -1}},
+	}, nil
+}
+
+// Purge removes the ready messages of the topic queue, delay queues are left untouched
+func (r *RabbitMQ) Purge(ctx context.Context, topic string) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	_, err = ch.QueuePurge(topic, false)
+	return err
+}
+
+// DeleteGroup is not supported, RabbitMQ consumers share the topic queue
+func (r *RabbitMQ) DeleteGroup(ctx context.Context, topic, group string) error {
+	return ErrNotSupported
+}
+
 // Ack acknowledges a message (RabbitMQ handles this in Consume)
 func (r *RabbitMQ) Ack(ctx context.Context, topic, group, msgID string) error {
 	// RabbitMQ Ack is handled via delivery.Ack() in Consume
@@ -218,6 +354,9 @@ func (r *RabbitMQ) Close() error {
 	if r.channel != nil {
 		r.channel.Close()
 	}
+	if r.confirm != nil {
+		r.confirm.Close()
+	}
 	if r.conn != nil {
 		return r.conn.Close()
 	}
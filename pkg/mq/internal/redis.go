@@ -2,9 +2,13 @@ package internal
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,12 +22,18 @@ type RedisStreamsConfig struct {
 	DB       int
 	Cluster  string
 	MaxLen   int64
+
+	// Replicas that must acknowledge a confirmed publish (WAIT), 0 only checks the primary write
+	Replicas    int
+	WaitTimeout time.Duration
 }
 
 // RedisStreams Redis Streams implementation
 type RedisStreams struct {
-	client redis.UniversalClient
-	maxLen int64
+	client      redis.UniversalClient
+	maxLen      int64
+	replicas    int
+	waitTimeout time.Duration
 }
 
 // NewRedisStreams creates a Redis Streams client
@@ -59,17 +69,34 @@ func NewRedisStreams(cfg RedisStreamsConfig) (*RedisStreams, error) {
 		return nil, fmt.Errorf("mq: redis connection failed: %w", err)
 	}
 
+	if cfg.WaitTimeout <= 0 {
+		cfg.WaitTimeout = time.Second
+	}
+
 	return &RedisStreams{
-		client: client,
-		maxLen: cfg.MaxLen,
+		client:      client,
+		maxLen:      cfg.MaxLen,
+		replicas:    cfg.Replicas,
+		waitTimeout: cfg.WaitTimeout,
 	}, nil
 }
 
 // Publish publishes a message to Stream (immediately consumable)
-func (r *RedisStreams) Publish(ctx context.Context, topic string, payload []byte) error {
+// Headers are stored as JSON in the "headers" field.
+// XADD returns only after the primary wrote the entry; with opts.Confirm and replicas
+// configured, Publish also waits until enough replicas acknowledged it.
+func (r *RedisStreams) Publish(ctx context.Context, topic string, payload []byte, headers map[string]string, opts PublishOptions) error {
+	values := map[string]any{"payload": payload}
+	if len(headers) > 0 {
+		data, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+		values["headers"] = data
+	}
 	args := &redis.XAddArgs{
 		Stream: topic,
-		Values: map[string]any{"payload": payload},
+		Values: values,
 	}
 
 	// Set max length (approximate trimming)
@@ -78,10 +105,39 @@ func (r *RedisStreams) Publish(ctx context.Context, topic string, payload []byte
 		args.Approx = true
 	}
 
+	if opts.Confirm && r.replicas > 0 {
+		return r.writeAndWait(ctx, topic, func(pipe redis.Pipeliner) { pipe.XAdd(ctx, args) })
+	}
 	_, err := r.client.XAdd(ctx, args).Result()
 	return err
 }
 
+// writeAndWait runs write and waits for the replicas on the same connection, as WAIT requires
+// In cluster mode the pipeline runs on the primary owning key.
+func (r *RedisStreams) writeAndWait(ctx context.Context, key string, write func(pipe redis.Pipeliner)) error {
+	var c redis.Cmdable = r.client
+	if cc, ok := r.client.(*redis.ClusterClient); ok {
+		node, err := cc.MasterForKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		c = node
+	}
+
+	var wait *redis.Cmd
+	if _, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		write(pipe)
+		wait = pipe.Do(ctx, "wait", r.replicas, r.waitTimeout.Milliseconds())
+		return nil
+	}); err != nil {
+		return err
+	}
+	if n, _ := wait.Int64(); n < int64(r.replicas) {
+		return fmt.Errorf("mq: write to %s acknowledged by %d of %d replicas", key, n, r.replicas)
+	}
+	return nil
+}
+
 // delayKey returns the Sorted Set key for delay queue
 func delayKey(topic string) string {
 	return topic + ":delay"
@@ -89,14 +145,28 @@ func delayKey(topic string) string {
 
 // PublishDelay publishes a delayed message
 // Uses Sorted Set storage, score is the expiration timestamp
-func (r *RedisStreams) PublishDelay(ctx context.Context, topic string, payload []byte, delay time.Duration) error {
+// With opts.Confirm the sorted set write waits for the replicas like Publish.
+func (r *RedisStreams) PublishDelay(ctx context.Context, topic string, payload []byte, headers map[string]string, delay time.Duration, opts PublishOptions) error {
 	executeAt := time.Now().Add(delay).UnixMilli()
-	member := fmt.Sprintf("%s:%s", uuid.New().String(), string(payload))
+	id := uuid.New().String()
+	if len(headers) > 0 {
+		// Format: uuid;base64(headers):payload, base64 keeps ":" out of the prefix
+		data, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+		id += ";" + base64.RawURLEncoding.EncodeToString(data)
+	}
+	member := fmt.Sprintf("%s:%s", id, string(payload))
 
-	return r.client.ZAdd(ctx, delayKey(topic), redis.Z{
+	z := redis.Z{
 		Score:  float64(executeAt),
 		Member: member,
-	}).Err()
+	}
+	if opts.Confirm && r.replicas > 0 {
+		return r.writeAndWait(ctx, delayKey(topic), func(pipe redis.Pipeliner) { pipe.ZAdd(ctx, delayKey(topic), z) })
+	}
+	return r.client.ZAdd(ctx, delayKey(topic), z).Err()
 }
 
 // Message represents a message structure
@@ -104,6 +174,32 @@ type Message struct {
 	ID      string
 	Topic   string
 	Payload []byte
+	Headers map[string]string
+}
+
+// ErrNotSupported is returned by admin operations the driver cannot perform
+var ErrNotSupported = errors.New("mq: operation not supported by the driver")
+
+// TopicInfo describes a topic and its consumer groups
+type TopicInfo struct {
+	Name    string
+	Length  int64 // Messages in the stream or ready in the queue
+	Delayed int64 // Delayed messages not yet due, -1 when unknown
+	Groups  []GroupInfo
+}
+
+// GroupInfo describes a consumer group of a topic
+type GroupInfo struct {
+	Name      string
+	Consumers int64
+	Pending   int64 // Delivered but not acknowledged
+	Lag       int64 // Not yet delivered, -1 when unknown
+}
+
+// PublishOptions controls the delivery guarantee of a publish
+type PublishOptions struct {
+	Confirm   bool // Return only after the broker acknowledged the message
+	Mandatory bool // RabbitMQ: fail when the message cannot be routed to a queue, implies Confirm
 }
 
 // Consume consumes messages (both immediate and expired delayed messages)
@@ -157,6 +253,11 @@ func (r *RedisStreams) Consume(ctx context.Context, topic, group string, handler
 					Topic:   topic,
 					Payload: []byte(payload),
 				}
+				if raw, ok := msg.Values["headers"].(string); ok {
+					if err := json.Unmarshal([]byte(raw), &m.Headers); err != nil {
+						log.Printf("mq: invalid message headers: %v", err)
+					}
+				}
 
 				if err := handler(ctx, m); err != nil {
 					log.Printf("mq: failed to process message: %v", err)
@@ -164,8 +265,8 @@ func (r *RedisStreams) Consume(ctx context.Context, topic, group string, handler
 					continue
 				}
 
-				// Process success, auto Ack
-				r.client.XAck(ctx, topic, group, msg.ID)
+				// Process success, auto Ack (also while draining after ctx is canceled)
+				r.client.XAck(context.WithoutCancel(ctx), topic, group, msg.ID)
 			}
 		}
 	}
@@ -205,25 +306,130 @@ func (r *RedisStreams) doTransfer(ctx context.Context, topic string) {
 	for _, z := range results {
 		member := z.Member.(string)
 
-		// Parse payload (format: uuid:payload)
+		// Claim the message first so concurrent consumers transfer it only once
+		if n, err := r.client.ZRem(ctx, key, member).Result(); err != nil || n == 0 {
+			continue
+		}
+
+		// Parse payload (format: uuid[;headers]:payload)
 		parts := strings.SplitN(member, ":", 2)
 		if len(parts) != 2 {
 			log.Printf("mq: invalid delayed message format: %s", member)
-			r.client.ZRem(ctx, key, member)
 			continue
 		}
 		payload := parts[1]
 
+		var headers map[string]string
+		if _, encoded, ok := strings.Cut(parts[0], ";"); ok {
+			data, err := base64.RawURLEncoding.DecodeString(encoded)
+			if err == nil {
+				err = json.Unmarshal(data, &headers)
+			}
+			if err != nil {
+				log.Printf("mq: invalid delayed message headers: %v", err)
+			}
+		}
+
 		// Transfer to Stream
-		err := r.Publish(ctx, topic, []byte(payload))
+		err := r.Publish(ctx, topic, []byte(payload), headers, PublishOptions{})
 		if err != nil {
 			log.Printf("mq: failed to transfer delayed message: %v", err)
+			// Put it back for the next round
+			r.client.ZAdd(ctx, key, z)
+		}
+	}
+}
+
+// Lag returns the entries of topic not yet processed by group
+// Counts entries not delivered to the group (Redis 7+) plus delivered but unacknowledged ones.
+func (r *RedisStreams) Lag(ctx context.Context, topic, group string) (int64, error) {
+	groups, err := r.client.XInfoGroups(ctx, topic).Result()
+	if err != nil {
+		return 0, err
+	}
+	for _, g := range groups {
+		if g.Name != group {
 			continue
 		}
+		lag := g.Pending
+		if g.Lag > 0 {
+			lag += g.Lag
+		}
+		return lag, nil
+	}
+	return 0, fmt.Errorf("mq: consumer group %s not found on %s", group, topic)
+}
+
+// Topics lists the streams, scanning every primary in cluster mode
+func (r *RedisStreams) Topics(ctx context.Context) ([]string, error) {
+	cc, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return scanStreams(ctx, r.client)
+	}
+
+	var mu sync.Mutex
+	var topics []string
+	err := cc.ForEachMaster(ctx, func(ctx context.Context, c *redis.Client) error {
+		found, err := scanStreams(ctx, c)
+		mu.Lock()
+		topics = append(topics, found...)
+		mu.Unlock()
+		return err
+	})
+	return topics, err
+}
+
+// scanStreams returns the stream keys of one node
+func scanStreams(ctx context.Context, c redis.Cmdable) ([]string, error) {
+	var keys []string
+	iter := c.ScanType(ctx, 0, "*", 100, "stream").Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// TopicInfo returns the length, delayed messages and groups of topic
+func (r *RedisStreams) TopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	length, err := r.client.XLen(ctx, topic).Result()
+	if err != nil {
+		return nil, err
+	}
+	delayed, err := r.client.ZCard(ctx, delayKey(topic)).Result()
+	if err != nil {
+		return nil, err
+	}
+	info := &TopicInfo{Name: topic, Length: length, Delayed: delayed}
+	if length == 0 && r.client.Exists(ctx, topic).Val() == 0 {
+		return info, nil
+	}
 
-		// Delete transferred message
-		r.client.ZRem(ctx, key, member)
+	groups, err := r.client.XInfoGroups(ctx, topic).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		info.Groups = append(info.Groups, GroupInfo{
+			Name:      g.Name,
+			Consumers: g.Consumers,
+			Pending:   g.Pending,
+			Lag:       g.Lag,
+		})
+	}
+	return info, nil
+}
+
+// Purge removes the messages of topic, including delayed ones, and keeps its groups
+func (r *RedisStreams) Purge(ctx context.Context, topic string) error {
+	if err := r.client.XTrimMaxLen(ctx, topic, 0).Err(); err != nil {
+		return err
 	}
+	return r.client.Del(ctx, delayKey(topic)).Err()
+}
+
+// DeleteGroup destroys a consumer group of topic with its pending entries
+func (r *RedisStreams) DeleteGroup(ctx context.Context, topic, group string) error {
+	return r.client.XGroupDestroy(ctx, topic, group).Err()
 }
 
 // Ack acknowledges a message
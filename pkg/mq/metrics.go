@@ -0,0 +1,57 @@
+package mq
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/metrics"
+)
+
+// lagInterval is how often consumer lag is sampled
+// lagInterval 是采样消费者积压的间隔
+const lagInterval = 15 * time.Second
+
+// observeConsume records the duration and outcome of one handled message
+// observeConsume 记录一条消息的处理耗时和结果
+func observeConsume(topic, group string, d time.Duration, err error) {
+	h := metrics.Histogram("mq_consume_duration_seconds", "MQ message processing duration in seconds",
+		nil, "topic", "group", "status")
+	if h == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	h.WithLabelValues(topic, group, status).Observe(d.Seconds())
+}
+
+// reportLag samples the lag of group on topic until ctx is done
+// reportLag 采样 group 在 topic 上的积压，直到 ctx 结束
+func reportLag(ctx context.Context, impl interface {
+	Lag(ctx context.Context, topic, group string) (int64, error)
+}, topic, group string) {
+	g := metrics.Gauge("mq_consumer_lag", "Messages waiting to be processed by the consumer group", "topic", "group")
+	if g == nil {
+		return
+	}
+	ticker := time.NewTicker(lagInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lag, err := impl.Lag(ctx, topic, group)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("mq: failed to read lag of %s/%s: %v", topic, group, err)
+				}
+				continue
+			}
+			g.WithLabelValues(topic, group).Set(float64(lag))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/mq/internal"
+)
+
+func TestDeliveryOptions(t *testing.T) {
+	w, err := newWrapper(Config{
+		Delivery: AtMostOnce,
+		Topics: map[string]TopicConfig{
+			"payment:paid":  {Delivery: AtLeastOnce},
+			"order:created": {Mandatory: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("newWrapper failed: %v", err)
+	}
+
+	tests := []struct {
+		topic string
+		want  internal.PublishOptions
+	}{
+		{"user:login", internal.PublishOptions{}},
+		{"payment:paid", internal.PublishOptions{Confirm: true}},
+		{"order:created", internal.PublishOptions{Mandatory: true}},
+	}
+	for _, tt := range tests {
+		if got := w.options(tt.topic); got != tt.want {
+			t.Errorf("options(%s) = %+v, want %+v", tt.topic, got, tt.want)
+		}
+	}
+
+	// Topics inherit the default mode | 主题继承默认模式
+	w, _ = newWrapper(Config{Delivery: AtLeastOnce, Topics: map[string]TopicConfig{"a": {Mandatory: true}}})
+	if got := w.options("a"); !got.Confirm || !got.Mandatory {
+		t.Errorf("options(a) = %+v, want confirm and mandatory", got)
+	}
+
+	if _, err := newWrapper(Config{Topics: map[string]TopicConfig{"a": {Delivery: "exactly_once"}}}); err == nil {
+		t.Error("Expected error for an unsupported delivery mode")
+	}
+}
+
+func TestPublishAndWait(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := New(Config{Driver: "redis", Redis: RedisConfig{Addr: mr.Addr()}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.(Confirmer).PublishAndWait(context.Background(), "payment:paid", []byte("42")); err != nil {
+		t.Fatalf("PublishAndWait failed: %v", err)
+	}
+	if entries, _ := mr.Stream("payment:paid"); len(entries) != 1 {
+		t.Errorf("Expected 1 stream entry, got %d", len(entries))
+	}
+}
+
+func TestPublishAndWaitReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := New(Config{Driver: "redis", Redis: RedisConfig{Addr: mr.Addr(), Replicas: 1}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// The server cannot confirm replication, a confirmed publish fails | 服务器无法确认复制，确认发布失败
+	if err := client.(Confirmer).PublishAndWait(ctx, "payment:paid", []byte("42")); err == nil {
+		t.Error("Expected error when replicas cannot be confirmed")
+	}
+	// At-most-once publishes do not wait | 至多一次的发布不等待
+	if err := client.Publish(ctx, "payment:paid", []byte("43")); err != nil {
+		t.Errorf("Publish failed: %v", err)
+	}
+}
+
+func TestPublishAndWaitNotInitialized(t *testing.T) {
+	defaultMQ = nil
+	if err := PublishAndWait(context.Background(), "a", nil); err == nil {
+		t.Error("Expected error without mq")
+	}
+	defaultMQ = &blockingMQ{}
+	t.Cleanup(func() { defaultMQ = nil })
+	if err := PublishAndWait(context.Background(), "a", nil); err == nil {
+		t.Error("Expected error for a client without confirms")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	client := NewMemory()
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Publish(ctx, "order:created", []byte("1")); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := client.PublishDelay(ctx, "order:created", []byte("2"), 20*time.Millisecond); err != nil {
+		t.Fatalf("PublishDelay failed: %v", err)
+	}
+
+	// Both groups receive every message, a failed one stays pending | 两个组都收到每条消息，失败的消息保持待处理
+	got := make(chan string, 4)
+	for _, group := range []string{"mail", "stock"} {
+		go client.Consume(ctx, "order:created", group, func(ctx context.Context, msg *Message) error {
+			got <- group + ":" + string(msg.Payload)
+			if group == "stock" && string(msg.Payload) == "2" {
+				return errors.New("out of stock")
+			}
+			return nil
+		})
+	}
+	seen := make(map[string]bool)
+	for range 4 {
+		select {
+		case s := <-got:
+			seen[s] = true
+		case <-ctx.Done():
+			t.Fatalf("Timed out, received %v", seen)
+		}
+	}
+	for _, want := range []string{"mail:1", "mail:2", "stock:1", "stock:2"} {
+		if !seen[want] {
+			t.Errorf("Missing delivery %s in %v", want, seen)
+		}
+	}
+
+	// The handler's ack lands right after delivery | 处理器的确认紧随投递之后
+	deadline := time.Now().Add(time.Second)
+	for {
+		lag, _ := client.(Lagger).Lag(ctx, "order:created", "stock")
+		mailLag, _ := client.(Lagger).Lag(ctx, "order:created", "mail")
+		if lag == 1 && mailLag == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Lag stock = %d, mail = %d, want 1 and 0", lag, mailLag)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	info, err := client.(Admin).TopicInfo(ctx, "order:created")
+	if err != nil || info.Length != 2 || len(info.Groups) != 2 || info.Groups[1].Pending != 1 {
+		t.Errorf("TopicInfo = %+v, %v", info, err)
+	}
+	client.(Admin).Purge(ctx, "order:created")
+	if info, _ := client.(Admin).TopicInfo(ctx, "order:created"); info.Length != 0 {
+		t.Errorf("Length after Purge = %d", info.Length)
+	}
+}
@@ -0,0 +1,396 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"xorm.io/xorm"
+)
+
+const (
+	scheduleInterval  = 10 * time.Second // How often due schedules are checked | 检查到期计划的间隔
+	scheduleLookahead = time.Minute      // Occurrences within this window are handed to PublishAt | 该窗口内的触发点交给 PublishAt
+)
+
+// ErrScheduleNotFound is returned when a schedule does not exist
+// ErrScheduleNotFound 在计划不存在时返回
+var ErrScheduleNotFound = errors.New("mq: schedule not found")
+
+// PublishAt publishes a message consumable at t, rounded to the second (using default client)
+// A time in the past publishes immediately. Built on PublishDelay, so the message survives restarts.
+// PublishAt 发布在 t 时刻可被消费的消息，精确到秒（使用默认客户端）
+// 过去的时间会立即发布。基于 PublishDelay 实现，消息在重启后不会丢失。
+//
+// Example | 示例:
+//
+//	loc, _ := time.LoadLocation("Asia/Shanghai")
+//	at := time.Date(2026, 3, 1, 9, 0, 0, 0, loc)
+//	mq.PublishAt(ctx, "reminder:send", payload, at)
+func PublishAt(ctx context.Context, topic string, payload []byte, t time.Time) error {
+	return publishAfter(ctx, topic, payload, time.Until(t))
+}
+
+// publishAfter publishes a message consumable after delay, rounded to the second (internal function)
+// publishAfter 发布在 delay 之后可被消费的消息，精确到秒（内部函数）
+func publishAfter(ctx context.Context, topic string, payload []byte, delay time.Duration) error {
+	// Whole seconds keep RabbitMQ at one delay queue per second | 取整到秒使 RabbitMQ 每秒只有一个延迟队列
+	delay = delay.Round(time.Second)
+	if delay <= 0 {
+		return Publish(ctx, topic, payload)
+	}
+	return PublishDelay(ctx, topic, payload, delay)
+}
+
+// Schedule is a recurring publication of a fixed payload
+// Schedule 是固定载荷的周期性发布计划
+type Schedule struct {
+	ID        int64     `json:"id" xorm:"pk autoincr 'id'"`
+	Name      string    `json:"name" xorm:"varchar(100) notnull unique 'name'"` // Unique name | 唯一名称
+	Spec      string    `json:"spec" xorm:"varchar(100) notnull 'spec'"`        // Cron expression with 5 fields, e.g. "0 9 * * *" | 5 字段 cron 表达式，如 "0 9 * * *"
+	Timezone  string    `json:"timezone" xorm:"varchar(64) 'timezone'"`         // IANA time zone of Spec, empty is UTC | Spec 的 IANA 时区，为空时为 UTC
+	Topic     string    `json:"topic" xorm:"varchar(200) notnull 'topic'"`      // Topic to publish to | 发布的主题
+	Payload   []byte    `json:"payload" xorm:"blob 'payload'"`                  // Message body | 消息体
+	NextAt    time.Time `json:"next_at" xorm:"notnull index 'next_at'"`         // Next occurrence not yet published | 下一次尚未发布的触发时间
+	CreatedAt time.Time `json:"created_at" xorm:"created 'created_at'"`         // Creation time | 创建时间
+	UpdatedAt time.Time `json:"updated_at" xorm:"updated 'updated_at'"`         // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (s *Schedule) TableName() string {
+	return "mq_schedule"
+}
+
+// next returns the first occurrence of s after t (internal method)
+// next 返回 s 在 t 之后的第一次触发时间（内部方法）
+func (s *Schedule) next(t time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(s.Spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mq: invalid schedule spec %q: %w", s.Spec, err)
+	}
+	loc := time.UTC
+	if s.Timezone != "" {
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return time.Time{}, fmt.Errorf("mq: invalid schedule timezone %q: %w", s.Timezone, err)
+		}
+	}
+	return sched.Next(t.In(loc)).UTC(), nil
+}
+
+// ScheduleStore persists recurring schedules
+// ScheduleStore 持久化周期性发布计划
+type ScheduleStore interface {
+	Save(ctx context.Context, s *Schedule) error // Insert, or replace the schedule of the same name | 插入，或替换同名计划
+	Delete(ctx context.Context, name string) error
+	List(ctx context.Context) ([]Schedule, error)
+	// Due returns the schedules whose next occurrence is before t | Due 返回下一次触发时间早于 t 的计划
+	Due(ctx context.Context, t time.Time) ([]Schedule, error)
+	// Advance moves NextAt from from to to, false when another instance already did | Advance 将 NextAt 从 from 改为 to，其他实例已修改时返回 false
+	Advance(ctx context.Context, id int64, from, to time.Time) (bool, error)
+}
+
+var (
+	schedulesMu   sync.Mutex
+	schedules     ScheduleStore      = NewMemoryScheduleStore()
+	schedulesStop context.CancelFunc // Stops the schedule loop | 停止计划循环
+	schedulesDone chan struct{}      // Closed when the loop exits | 循环退出时关闭
+)
+
+// SetScheduleStore replaces the schedule store (default in memory, which only suits a single instance)
+// SetScheduleStore 替换计划存储（默认在内存中，仅适用于单实例）
+func SetScheduleStore(store ScheduleStore) {
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+	schedules = store
+}
+
+// getScheduleStore returns the current schedule store | getScheduleStore 返回当前计划存储
+func getScheduleStore() ScheduleStore {
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+	return schedules
+}
+
+// AddSchedule creates or replaces the schedule of s.Name, starting from the next occurrence
+// Every occurrence is published once across instances sharing a database store.
+// AddSchedule 创建或替换 s.Name 的计划，从下一次触发时间开始
+// 共享数据库存储的多个实例中，每次触发只发布一次。
+//
+// Example | 示例:
+//
+//	mq.AddSchedule(ctx, &mq.Schedule{
+//	    Name:     "daily-reminder",
+//	    Spec:     "0 9 * * *",
+//	    Timezone: "Asia/Shanghai",
+//	    Topic:    "reminder:send",
+//	})
+func AddSchedule(ctx context.Context, s *Schedule) error {
+	if s.Name == "" || s.Topic == "" {
+		return fmt.Errorf("mq: schedule name and topic are required")
+	}
+	next, err := s.next(time.Now())
+	if err != nil {
+		return err
+	}
+	s.NextAt = next
+	return getScheduleStore().Save(ctx, s)
+}
+
+// RemoveSchedule deletes the schedule of name
+// RemoveSchedule 删除 name 的计划
+func RemoveSchedule(ctx context.Context, name string) error {
+	return getScheduleStore().Delete(ctx, name)
+}
+
+// ListSchedules returns all schedules ordered by name
+// ListSchedules 返回按名称排序的所有计划
+func ListSchedules(ctx context.Context) ([]Schedule, error) {
+	return getScheduleStore().List(ctx)
+}
+
+// StartScheduler starts publishing due schedules, a no-op when MQ is not enabled
+// StartScheduler 开始发布到期的计划，未启用 MQ 时不执行任何操作
+func StartScheduler() {
+	if !Enabled() {
+		return
+	}
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+	if schedulesStop != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	schedulesStop, schedulesDone = cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(scheduleInterval)
+		defer ticker.Stop()
+		for {
+			runDueSchedules(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}(schedulesDone)
+}
+
+// StopScheduler stops the schedule loop and waits for the current round
+// StopScheduler 停止计划循环并等待当前轮次结束
+func StopScheduler() {
+	schedulesMu.Lock()
+	stop, done := schedulesStop, schedulesDone
+	schedulesStop, schedulesDone = nil, nil
+	schedulesMu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	<-done
+}
+
+// runDueSchedules hands the occurrences due within the lookahead to PublishAt (internal function)
+// Occurrences missed while no instance ran are collapsed into one late publication.
+// runDueSchedules 将预读窗口内到期的触发交给 PublishAt（内部函数）
+// 没有实例运行期间错过的触发会合并为一次延后发布。
+func runDueSchedules(ctx context.Context, now time.Time) {
+	store := getScheduleStore()
+	due, err := store.Due(ctx, now.Add(scheduleLookahead))
+	if err != nil {
+		log.Printf("mq: failed to load due schedules: %v", err)
+		return
+	}
+	for _, s := range due {
+		at := s.NextAt
+		next, err := s.next(maxTime(at, now))
+		if err != nil {
+			log.Printf("mq: schedule %s: %v", s.Name, err)
+			continue
+		}
+		// Claim the occurrence so only one instance publishes it | 占用该触发点，确保只有一个实例发布
+		ok, err := store.Advance(ctx, s.ID, at, next)
+		if err != nil || !ok {
+			if err != nil {
+				log.Printf("mq: failed to advance schedule %s: %v", s.Name, err)
+			}
+			continue
+		}
+		if err := publishAfter(ctx, s.Topic, s.Payload, at.Sub(now)); err != nil {
+			log.Printf("mq: failed to publish schedule %s: %v", s.Name, err)
+			// Give the occurrence back for the next round | 归还触发点，下一轮重试
+			if _, err := store.Advance(context.WithoutCancel(ctx), s.ID, next, at); err != nil {
+				log.Printf("mq: failed to restore schedule %s: %v", s.Name, err)
+			}
+		}
+	}
+}
+
+// maxTime returns the later of a and b | maxTime 返回 a 和 b 中较晚的时间
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// MemoryScheduleStore keeps schedules in memory, they are lost on restart
+// MemoryScheduleStore 在内存中保存计划，重启后丢失
+type MemoryScheduleStore struct {
+	mu     sync.RWMutex
+	nextID int64
+	items  map[string]Schedule
+}
+
+// NewMemoryScheduleStore creates an in-memory store
+// NewMemoryScheduleStore 创建内存存储
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{items: make(map[string]Schedule)}
+}
+
+// Save inserts or replaces the schedule of the same name
+// Save 插入或替换同名计划
+func (m *MemoryScheduleStore) Save(ctx context.Context, s *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if old, ok := m.items[s.Name]; ok {
+		s.ID, s.CreatedAt = old.ID, old.CreatedAt
+	} else {
+		m.nextID++
+		s.ID, s.CreatedAt = m.nextID, now
+	}
+	s.UpdatedAt = now
+	m.items[s.Name] = *s
+	return nil
+}
+
+// Delete removes the schedule of name
+// Delete 删除 name 的计划
+func (m *MemoryScheduleStore) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[name]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(m.items, name)
+	return nil
+}
+
+// List returns all schedules ordered by name
+// List 返回按名称排序的所有计划
+func (m *MemoryScheduleStore) List(ctx context.Context) ([]Schedule, error) {
+	return m.filter(func(Schedule) bool { return true }), nil
+}
+
+// Due returns the schedules whose next occurrence is before t
+// Due 返回下一次触发时间早于 t 的计划
+func (m *MemoryScheduleStore) Due(ctx context.Context, t time.Time) ([]Schedule, error) {
+	return m.filter(func(s Schedule) bool { return s.NextAt.Before(t) }), nil
+}
+
+// Advance moves NextAt from from to to
+// Advance 将 NextAt 从 from 改为 to
+func (m *MemoryScheduleStore) Advance(ctx context.Context, id int64, from, to time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, s := range m.items {
+		if s.ID != id {
+			continue
+		}
+		if !s.NextAt.Equal(from) {
+			return false, nil
+		}
+		s.NextAt, s.UpdatedAt = to, time.Now()
+		m.items[name] = s
+		return true, nil
+	}
+	return false, ErrScheduleNotFound
+}
+
+// filter returns the schedules matching keep ordered by name (internal method)
+// filter 返回满足 keep 的计划，按名称排序（内部方法）
+func (m *MemoryScheduleStore) filter(keep func(Schedule) bool) []Schedule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]Schedule, 0, len(m.items))
+	for _, s := range m.items {
+		if keep(s) {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// DBScheduleStore keeps schedules in the mq_schedule table
+// DBScheduleStore 在 mq_schedule 表中保存计划
+type DBScheduleStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBScheduleStore creates a database store; migrate Schedule with the other models
+// NewDBScheduleStore 创建数据库存储，Schedule 需随其他模型一起迁移
+func NewDBScheduleStore(engine *xorm.Engine) *DBScheduleStore {
+	return &DBScheduleStore{engine: engine}
+}
+
+// Save inserts or replaces the schedule of the same name
+// Save 插入或替换同名计划
+func (s *DBScheduleStore) Save(ctx context.Context, sc *Schedule) error {
+	old := &Schedule{}
+	has, err := s.engine.Context(ctx).Where("name = ?", sc.Name).Get(old)
+	if err != nil {
+		return err
+	}
+	if !has {
+		_, err = s.engine.Context(ctx).Insert(sc)
+		return err
+	}
+	sc.ID, sc.CreatedAt = old.ID, old.CreatedAt
+	_, err = s.engine.Context(ctx).ID(sc.ID).Cols("spec", "timezone", "topic", "payload", "next_at").Update(sc)
+	return err
+}
+
+// Delete removes the schedule of name
+// Delete 删除 name 的计划
+func (s *DBScheduleStore) Delete(ctx context.Context, name string) error {
+	n, err := s.engine.Context(ctx).Where("name = ?", name).Delete(&Schedule{})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// List returns all schedules ordered by name
+// List 返回按名称排序的所有计划
+func (s *DBScheduleStore) List(ctx context.Context) ([]Schedule, error) {
+	var list []Schedule
+	err := s.engine.Context(ctx).Asc("name").Find(&list)
+	return list, err
+}
+
+// Due returns the schedules whose next occurrence is before t
+// Due 返回下一次触发时间早于 t 的计划
+func (s *DBScheduleStore) Due(ctx context.Context, t time.Time) ([]Schedule, error) {
+	var list []Schedule
+	err := s.engine.Context(ctx).Where("next_at < ?", t).Asc("next_at").Find(&list)
+	return list, err
+}
+
+// Advance moves NextAt from from to to with a conditional update
+// Advance 通过条件更新将 NextAt 从 from 改为 to
+func (s *DBScheduleStore) Advance(ctx context.Context, id int64, from, to time.Time) (bool, error) {
+	n, err := s.engine.Context(ctx).ID(id).Where("next_at = ?", from).
+		Cols("next_at").Update(&Schedule{NextAt: to})
+	return n == 1, err
+}
@@ -0,0 +1,112 @@
+package mq
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMQ records the delay of every publish
+type recordingMQ struct {
+	blockingMQ
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (q *recordingMQ) Publish(context.Context, string, []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.delays = append(q.delays, 0)
+	return nil
+}
+
+func (q *recordingMQ) PublishDelay(_ context.Context, _ string, _ []byte, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.delays = append(q.delays, delay)
+	return nil
+}
+
+// useSchedules installs a recording client and an empty memory store
+func useSchedules(t *testing.T) *recordingMQ {
+	t.Helper()
+	q := &recordingMQ{}
+	defaultMQ = q
+	SetScheduleStore(NewMemoryScheduleStore())
+	t.Cleanup(func() {
+		defaultMQ = nil
+		SetScheduleStore(NewMemoryScheduleStore())
+	})
+	return q
+}
+
+func TestPublishAt(t *testing.T) {
+	q := useSchedules(t)
+	ctx := context.Background()
+
+	PublishAt(ctx, "a", nil, time.Now().Add(-time.Minute))
+	PublishAt(ctx, "a", nil, time.Now().Add(90*time.Second+200*time.Millisecond))
+	if len(q.delays) != 2 || q.delays[0] != 0 || q.delays[1] != 90*time.Second {
+		t.Errorf("delays = %v, want [0 1m30s]", q.delays)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	s := &Schedule{Spec: "0 9 * * *", Timezone: "Asia/Shanghai"}
+	// 00:30 UTC is 08:30 in Shanghai | UTC 00:30 是上海 08:30
+	next, err := s.next(time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("next failed: %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+
+	if err := AddSchedule(context.Background(), &Schedule{Name: "x", Topic: "a", Spec: "bad"}); err == nil {
+		t.Error("Expected error for an invalid spec")
+	}
+	if err := AddSchedule(context.Background(), &Schedule{Name: "x", Topic: "a", Spec: "@daily", Timezone: "Mars/Base"}); err == nil {
+		t.Error("Expected error for an invalid timezone")
+	}
+}
+
+func TestRunDueSchedules(t *testing.T) {
+	q := useSchedules(t)
+	ctx := context.Background()
+
+	if err := AddSchedule(ctx, &Schedule{Name: "minutely", Spec: "* * * * *", Topic: "tick"}); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	list, _ := ListSchedules(ctx)
+	at := list[0].NextAt
+
+	// Within the lookahead the occurrence is handed to the delay queue once | 预读窗口内的触发只交给延迟队列一次
+	runDueSchedules(ctx, at.Add(-30*time.Second))
+	runDueSchedules(ctx, at.Add(-30*time.Second))
+	if len(q.delays) != 1 || q.delays[0] != 30*time.Second {
+		t.Fatalf("delays = %v, want [30s]", q.delays)
+	}
+	list, _ = ListSchedules(ctx)
+	if want := at.Add(time.Minute); !list[0].NextAt.Equal(want) {
+		t.Errorf("NextAt = %v, want %v", list[0].NextAt, want)
+	}
+
+	// Missed occurrences collapse into one publication | 错过的触发合并为一次发布
+	now := at.Add(time.Hour + 10*time.Second)
+	runDueSchedules(ctx, now)
+	list, _ = ListSchedules(ctx)
+	if len(q.delays) != 2 || q.delays[1] != 0 {
+		t.Errorf("delays = %v, want a second immediate publish", q.delays)
+	}
+	if want := at.Add(time.Hour + time.Minute); !list[0].NextAt.Equal(want) {
+		t.Errorf("NextAt = %v, want %v", list[0].NextAt, want)
+	}
+
+	if err := RemoveSchedule(ctx, "minutely"); err != nil {
+		t.Errorf("RemoveSchedule failed: %v", err)
+	}
+	if err := RemoveSchedule(ctx, "minutely"); err != ErrScheduleNotFound {
+		t.Errorf("RemoveSchedule error = %v, want ErrScheduleNotFound", err)
+	}
+}
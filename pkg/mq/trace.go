@@ -0,0 +1,54 @@
+package mq
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "mq"
+
+// startPublish starts a producer span and returns the headers carrying it to consumers
+// startPublish 创建生产者 span，并返回将其传递给消费者的消息头
+func startPublish(ctx context.Context, topic string) (context.Context, trace.Span, map[string]string) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.operation.type", "send"),
+			attribute.String("messaging.destination.name", topic),
+		))
+
+	headers := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+	return ctx, span, headers
+}
+
+// startConsume continues the publisher's trace from the message headers with a consumer span
+// startConsume 根据消息头以消费者 span 延续发布方的链路
+func startConsume(ctx context.Context, group string, msg *Message) (context.Context, trace.Span) {
+	if len(msg.Headers) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+	}
+	return otel.Tracer(tracerName).Start(ctx, "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.operation.type", "process"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.String("messaging.message.id", msg.ID),
+		))
+}
+
+// endSpan records err on span and ends it
+// endSpan 在 span 上记录 err 并结束它
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
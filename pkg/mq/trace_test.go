@@ -0,0 +1,46 @@
+package mq
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, span, headers := startPublish(context.Background(), "orders")
+	span.End()
+	if headers["traceparent"] == "" {
+		t.Fatalf("headers = %v, want traceparent", headers)
+	}
+
+	// Consumers start from a fresh context, only the headers link them | 消费者从新的 context 开始，仅通过消息头关联
+	msg := &Message{ID: "1-0", Topic: "orders", Headers: headers}
+	consumeCtx, consumeSpan := startConsume(context.Background(), "billing", msg)
+	endSpan(consumeSpan, nil)
+
+	publish := trace.SpanContextFromContext(ctx)
+	consume := trace.SpanContextFromContext(consumeCtx)
+	if consume.TraceID() != publish.TraceID() {
+		t.Errorf("consumer trace %s, want %s", consume.TraceID(), publish.TraceID())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if got := spans[1].Parent().SpanID(); got != publish.SpanID() {
+		t.Errorf("consumer parent %s, want publish span %s", got, publish.SpanID())
+	}
+	if spans[1].SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("consumer kind = %v", spans[1].SpanKind())
+	}
+}
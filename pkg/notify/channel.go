@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/sms"
+)
+
+// emailChannel sends through pkg/email, queued when the email dispatcher is enabled
+// emailChannel 通过 pkg/email 发送，启用邮件调度器时走队列
+type emailChannel struct {
+	client *email.Client
+}
+
+// NewEmailChannel creates the email channel
+// NewEmailChannel 创建邮件渠道
+func NewEmailChannel(client *email.Client) Channel {
+	return &emailChannel{client: client}
+}
+
+func (c *emailChannel) Name() string { return ChannelEmail }
+
+func (c *emailChannel) Send(ctx context.Context, r *Recipient, n *Notification) error {
+	if r.Email == "" {
+		return nil
+	}
+	msg := &email.Message{To: []string{r.Email}, Subject: n.Title, Body: n.Body}
+	if n.EmailTemplate != "" {
+		subject, body, err := email.DefaultRegistry().RenderMessage(n.EmailTemplate, n.Data)
+		if err != nil {
+			return err
+		}
+		if subject != "" {
+			msg.Subject = subject
+		}
+		msg.Body, msg.IsHTML = body, true
+	}
+
+	if email.GetDispatcher() != nil {
+		_, err := email.Enqueue(ctx, msg)
+		return err
+	}
+	return c.client.SendContext(ctx, msg)
+}
+
+// smsChannel sends through pkg/sms
+// smsChannel 通过 pkg/sms 发送
+type smsChannel struct {
+	client *sms.Client
+}
+
+// NewSMSChannel creates the SMS channel
+// NewSMSChannel 创建短信渠道
+func NewSMSChannel(client *sms.Client) Channel {
+	return &smsChannel{client: client}
+}
+
+func (c *smsChannel) Name() string { return ChannelSMS }
+
+func (c *smsChannel) Send(ctx context.Context, r *Recipient, n *Notification) error {
+	if r.Phone == "" {
+		return nil
+	}
+	msg := &sms.Message{To: []string{r.Phone}, Template: n.SMSTemplate, Params: n.SMSParams}
+	if n.SMSTemplate == "" {
+		msg.Content = n.Body
+	}
+	return c.client.Send(ctx, msg)
+}
+
+// PushFunc pushes a WebSocket message to a user, e.g. service.PublishToUser
+// PushFunc 向用户推送 WebSocket 消息，例如 service.PublishToUser
+type PushFunc func(ctx context.Context, userID int64, msgType string, payload any) error
+
+// PushPayload is the WebSocket payload of a notification
+// PushPayload 是通知的 WebSocket 负载
+type PushPayload struct {
+	Category string         `json:"category"`
+	Title    string         `json:"title"`
+	Body     string         `json:"body"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// wsChannel pushes to the user's WebSocket connections
+// wsChannel 推送到用户的 WebSocket 连接
+type wsChannel struct {
+	push PushFunc
+}
+
+// NewWSChannel creates the WebSocket channel; messages use type "notify"
+// NewWSChannel 创建 WebSocket 渠道；消息类型为 "notify"
+func NewWSChannel(push PushFunc) Channel {
+	return &wsChannel{push: push}
+}
+
+func (c *wsChannel) Name() string { return ChannelWS }
+
+func (c *wsChannel) Send(ctx context.Context, r *Recipient, n *Notification) error {
+	return c.push(ctx, r.UserID, "notify", PushPayload{
+		Category: n.Category,
+		Title:    n.Title,
+		Body:     n.Body,
+		Data:     n.Data,
+	})
+}
@@ -0,0 +1,269 @@
+// Package notify fans a notification out to email, SMS and WebSocket push
+// Channels are chosen per category from config defaults and overridden by user preferences.
+// Package notify 将通知分发到邮件、短信和 WebSocket 推送
+// 按类别从配置默认值选择渠道，并由用户偏好覆盖。
+//
+// Usage | 用法:
+//
+//	notify.SetResolver(func(ctx context.Context, userID int64) (*notify.Recipient, error) {
+//		u, err := userService.Get(ctx, userID)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &notify.Recipient{UserID: userID, Email: u.Email, Phone: u.Phone}, nil
+//	})
+//
+//	notify.Send(ctx, &notify.Notification{
+//		UserID:   123,
+//		Category: "order",
+//		Title:    "Order shipped",
+//		Body:     "Your order 42 has been shipped",
+//	})
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Built-in channel names | 内置渠道名称
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelWS    = "ws"
+)
+
+// AllCategories is the preference category that applies to every category
+// AllCategories 是适用于所有类别的偏好类别
+const AllCategories = "*"
+
+// Config represents notification configuration
+// Config 表示通知配置
+type Config struct {
+	// Channels per category when the user has no preference, "*" for all other categories
+	// 用户没有偏好时各类别使用的渠道，"*" 表示其他所有类别
+	Defaults map[string][]string `toml:"defaults"`
+}
+
+// Recipient holds the addresses of a user
+// Recipient 保存用户的联系地址
+type Recipient struct {
+	UserID int64
+	Email  string // Empty skips the email channel | 为空时跳过邮件渠道
+	Phone  string // Empty skips the SMS channel | 为空时跳过短信渠道
+}
+
+// Notification is a message to one user
+// Notification 表示发给一个用户的消息
+type Notification struct {
+	UserID   int64          // Target user | 目标用户
+	Category string         // Preference category, e.g. "order", "security" | 偏好类别，例如 "order"、"security"
+	Title    string         // Title (email subject, push title) | 标题（邮件主题、推送标题）
+	Body     string         // Plain text body | 纯文本内容
+	Data     map[string]any // Extra data for templates and push payloads | 模板和推送负载的附加数据
+
+	EmailTemplate string            // Email template rendered with Data, overrides Title/Body | 使用 Data 渲染的邮件模板，优先于 Title/Body
+	SMSTemplate   string            // SMS template name, Body is sent when empty | 短信模板名，为空时发送 Body
+	SMSParams     map[string]string // SMS template parameters | 短信模板参数
+
+	Channels []string // Explicit channels, bypassing defaults and preferences | 显式指定渠道，忽略默认值和偏好
+}
+
+// Channel delivers notifications through one medium
+// Channel 通过一种媒介发送通知
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, r *Recipient, n *Notification) error
+}
+
+// ResolveFunc looks up the addresses of a user
+// ResolveFunc 查询用户的联系地址
+type ResolveFunc func(ctx context.Context, userID int64) (*Recipient, error)
+
+// Notifier routes notifications to channels
+// Notifier 将通知路由到各渠道
+type Notifier struct {
+	mu       sync.RWMutex
+	cfg      Config
+	channels map[string]Channel
+	resolve  ResolveFunc
+	prefs    PreferenceStore
+}
+
+// New creates a notifier with an in-memory preference store
+// New 创建使用内存偏好存储的通知器
+func New(cfg Config) *Notifier {
+	if len(cfg.Defaults) == 0 {
+		cfg.Defaults = map[string][]string{AllCategories: {ChannelWS}}
+	}
+	return &Notifier{
+		cfg:      cfg,
+		channels: make(map[string]Channel),
+		prefs:    NewMemoryPreferenceStore(),
+	}
+}
+
+// Register adds or replaces a channel
+// Register 添加或替换渠道
+func (n *Notifier) Register(ch Channel) *Notifier {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.channels[ch.Name()] = ch
+	return n
+}
+
+// SetResolver sets the recipient lookup; without it only UserID is known
+// SetResolver 设置收件人查询函数；未设置时仅知道 UserID
+func (n *Notifier) SetResolver(fn ResolveFunc) *Notifier {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.resolve = fn
+	return n
+}
+
+// SetPreferenceStore replaces the preference store
+// SetPreferenceStore 替换偏好存储
+func (n *Notifier) SetPreferenceStore(store PreferenceStore) *Notifier {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.prefs = store
+	return n
+}
+
+// Preferences returns the preference store
+// Preferences 返回偏好存储
+func (n *Notifier) Preferences() PreferenceStore {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.prefs
+}
+
+// Send delivers a notification on every selected channel
+// Channel failures are joined; the other channels are still attempted.
+// Send 在所有选中的渠道上发送通知
+// 渠道失败会被合并返回，其他渠道仍会继续发送。
+func (n *Notifier) Send(ctx context.Context, note *Notification) error {
+	names, err := n.ChannelsFor(ctx, note)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	n.mu.RLock()
+	resolve := n.resolve
+	n.mu.RUnlock()
+
+	recipient := &Recipient{UserID: note.UserID}
+	if resolve != nil {
+		if recipient, err = resolve(ctx, note.UserID); err != nil {
+			return fmt.Errorf("notify: resolve user %d: %w", note.UserID, err)
+		}
+	}
+
+	var errs []error
+	for _, name := range names {
+		n.mu.RLock()
+		ch, ok := n.channels[name]
+		n.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := ch.Send(ctx, recipient, note); err != nil {
+			errs = append(errs, fmt.Errorf("notify [%s]: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ChannelsFor returns the channels a notification will be sent on
+// ChannelsFor 返回通知将要发送的渠道
+func (n *Notifier) ChannelsFor(ctx context.Context, note *Notification) ([]string, error) {
+	if len(note.Channels) > 0 {
+		return note.Channels, nil
+	}
+
+	n.mu.RLock()
+	defaults, ok := n.cfg.Defaults[note.Category]
+	if !ok {
+		defaults = n.cfg.Defaults[AllCategories]
+	}
+	prefs := n.prefs
+	n.mu.RUnlock()
+
+	enabled := make(map[string]bool, len(defaults))
+	for _, name := range defaults {
+		enabled[name] = true
+	}
+
+	// "*" preferences first, then the category's own | 先应用 "*" 偏好，再应用类别自身的偏好
+	list, err := prefs.Get(ctx, note.UserID, note.Category)
+	if err != nil {
+		return nil, fmt.Errorf("notify: load preferences: %w", err)
+	}
+	for _, scope := range []string{AllCategories, note.Category} {
+		for _, p := range list {
+			if p.Category == scope {
+				enabled[p.Channel] = p.Enabled
+			}
+		}
+	}
+
+	var names []string
+	for _, name := range []string{ChannelWS, ChannelEmail, ChannelSMS} {
+		if enabled[name] {
+			names = append(names, name)
+			delete(enabled, name)
+		}
+	}
+	for name, on := range enabled {
+		if on {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+var defaultNotifier = New(Config{}) // Default notifier | 默认通知器
+
+// Init replaces the default notifier configuration, keeping registered channels
+// Init 替换默认通知器配置，保留已注册的渠道
+func Init(cfg Config) {
+	fresh := New(cfg)
+	defaultNotifier.mu.Lock()
+	defaultNotifier.cfg = fresh.cfg
+	defaultNotifier.mu.Unlock()
+}
+
+// Get returns the default notifier
+// Get 返回默认通知器
+func Get() *Notifier {
+	return defaultNotifier
+}
+
+// Register adds a channel to the default notifier
+// Register 向默认通知器添加渠道
+func Register(ch Channel) {
+	defaultNotifier.Register(ch)
+}
+
+// SetResolver sets the recipient lookup of the default notifier
+// SetResolver 设置默认通知器的收件人查询函数
+func SetResolver(fn ResolveFunc) {
+	defaultNotifier.SetResolver(fn)
+}
+
+// SetPreferenceStore replaces the preference store of the default notifier
+// SetPreferenceStore 替换默认通知器的偏好存储
+func SetPreferenceStore(store PreferenceStore) {
+	defaultNotifier.SetPreferenceStore(store)
+}
+
+// Send delivers a notification through the default notifier
+// Send 通过默认通知器发送通知
+func Send(ctx context.Context, note *Notification) error {
+	return defaultNotifier.Send(ctx, note)
+}
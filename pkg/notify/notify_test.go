@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type recordChannel struct {
+	name string
+	err  error
+	sent []*Recipient
+}
+
+func (c *recordChannel) Name() string { return c.name }
+
+func (c *recordChannel) Send(_ context.Context, r *Recipient, _ *Notification) error {
+	c.sent = append(c.sent, r)
+	return c.err
+}
+
+func TestChannelsFor_DefaultsAndPreferences(t *testing.T) {
+	ctx := context.Background()
+	n := New(Config{Defaults: map[string][]string{
+		"security":    {ChannelEmail, ChannelSMS},
+		AllCategories: {ChannelWS},
+	}})
+
+	got, _ := n.ChannelsFor(ctx, &Notification{UserID: 1, Category: "order"})
+	if strings.Join(got, ",") != "ws" {
+		t.Errorf("order channels = %v, want [ws]", got)
+	}
+	got, _ = n.ChannelsFor(ctx, &Notification{UserID: 1, Category: "security"})
+	if strings.Join(got, ",") != "email,sms" {
+		t.Errorf("security channels = %v, want [email sms]", got)
+	}
+
+	// Turn SMS off everywhere but back on for security, and email on for orders
+	prefs := n.Preferences()
+	prefs.Set(ctx, 1, AllCategories, ChannelSMS, false)
+	prefs.Set(ctx, 1, "security", ChannelSMS, true)
+	prefs.Set(ctx, 1, "order", ChannelEmail, true)
+	prefs.Set(ctx, 1, "order", ChannelWS, false)
+
+	got, _ = n.ChannelsFor(ctx, &Notification{UserID: 1, Category: "order"})
+	if strings.Join(got, ",") != "email" {
+		t.Errorf("order channels = %v, want [email]", got)
+	}
+	got, _ = n.ChannelsFor(ctx, &Notification{UserID: 1, Category: "security"})
+	if strings.Join(got, ",") != "email,sms" {
+		t.Errorf("security channels = %v, want [email sms]", got)
+	}
+
+	// Other users keep the defaults
+	got, _ = n.ChannelsFor(ctx, &Notification{UserID: 2, Category: "order"})
+	if strings.Join(got, ",") != "ws" {
+		t.Errorf("user 2 channels = %v, want [ws]", got)
+	}
+
+	// Explicit channels bypass everything
+	got, _ = n.ChannelsFor(ctx, &Notification{UserID: 1, Category: "order", Channels: []string{ChannelSMS}})
+	if strings.Join(got, ",") != "sms" {
+		t.Errorf("explicit channels = %v, want [sms]", got)
+	}
+}
+
+func TestSend_FanOut(t *testing.T) {
+	ctx := context.Background()
+	ws := &recordChannel{name: ChannelWS}
+	mail := &recordChannel{name: ChannelEmail, err: errors.New("smtp down")}
+	n := New(Config{Defaults: map[string][]string{AllCategories: {ChannelWS, ChannelEmail, ChannelSMS}}})
+	n.Register(ws).Register(mail) // no SMS channel registered
+	n.SetResolver(func(_ context.Context, userID int64) (*Recipient, error) {
+		return &Recipient{UserID: userID, Email: "u@example.com"}, nil
+	})
+
+	err := n.Send(ctx, &Notification{UserID: 7, Category: "order", Title: "Shipped"})
+	if err == nil || !strings.Contains(err.Error(), "notify [email]: smtp down") {
+		t.Errorf("expected joined email error, got %v", err)
+	}
+	if len(ws.sent) != 1 || ws.sent[0].UserID != 7 {
+		t.Errorf("ws not sent despite email failure: %+v", ws.sent)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].Email != "u@example.com" {
+		t.Errorf("email recipient not resolved: %+v", mail.sent)
+	}
+}
+
+func TestSend_ResolverError(t *testing.T) {
+	n := New(Config{})
+	n.Register(&recordChannel{name: ChannelWS})
+	n.SetResolver(func(context.Context, int64) (*Recipient, error) {
+		return nil, errors.New("no such user")
+	})
+	if err := n.Send(context.Background(), &Notification{UserID: 1}); err == nil {
+		t.Error("expected resolver error")
+	}
+}
+
+func TestWSChannel(t *testing.T) {
+	var gotType string
+	var gotPayload any
+	ch := NewWSChannel(func(_ context.Context, userID int64, msgType string, payload any) error {
+		gotType, gotPayload = msgType, payload
+		return nil
+	})
+	ch.Send(context.Background(), &Recipient{UserID: 1}, &Notification{Category: "order", Title: "T", Body: "B"})
+
+	p, ok := gotPayload.(PushPayload)
+	if gotType != "notify" || !ok || p.Title != "T" || p.Category != "order" {
+		t.Errorf("unexpected push %s %+v", gotType, gotPayload)
+	}
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Preference turns one channel on or off for a user and category ("*" for all categories)
+// Preference 为用户和类别（"*" 表示所有类别）开启或关闭一个渠道
+type Preference struct {
+	ID        int64     `json:"id" xorm:"pk autoincr 'id'"`
+	UserID    int64     `json:"user_id" xorm:"notnull unique(user_category_channel) 'user_id'"`               // User ID | 用户 ID
+	Category  string    `json:"category" xorm:"varchar(50) notnull unique(user_category_channel) 'category'"` // Category or "*" | 类别或 "*"
+	Channel   string    `json:"channel" xorm:"varchar(20) notnull unique(user_category_channel) 'channel'"`   // Channel name | 渠道名称
+	Enabled   bool      `json:"enabled" xorm:"notnull 'enabled'"`                                             // Whether the channel is on | 渠道是否开启
+	UpdatedAt time.Time `json:"updated_at" xorm:"updated 'updated_at'"`                                       // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (p *Preference) TableName() string {
+	return "notify_preference"
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{new(Preference)}
+}
+
+// PreferenceStore persists user notification preferences
+// PreferenceStore 持久化用户通知偏好
+type PreferenceStore interface {
+	Get(ctx context.Context, userID int64, category string) ([]Preference, error) // Preferences of category and "*" | 类别和 "*" 的偏好
+	Set(ctx context.Context, userID int64, category, channel string, enabled bool) error
+	List(ctx context.Context, userID int64) ([]Preference, error)
+}
+
+// memoryPreferenceStore keeps preferences in process
+// memoryPreferenceStore 在进程内保存偏好
+type memoryPreferenceStore struct {
+	mu    sync.RWMutex
+	prefs map[int64]map[[2]string]Preference
+}
+
+// NewMemoryPreferenceStore creates an in-process preference store
+// NewMemoryPreferenceStore 创建进程内偏好存储
+func NewMemoryPreferenceStore() PreferenceStore {
+	return &memoryPreferenceStore{prefs: make(map[int64]map[[2]string]Preference)}
+}
+
+func (s *memoryPreferenceStore) Get(_ context.Context, userID int64, category string) ([]Preference, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Preference
+	for key, p := range s.prefs[userID] {
+		if key[0] == category || key[0] == AllCategories {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryPreferenceStore) Set(_ context.Context, userID int64, category, channel string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.prefs[userID] == nil {
+		s.prefs[userID] = make(map[[2]string]Preference)
+	}
+	s.prefs[userID][[2]string{category, channel}] = Preference{
+		UserID:    userID,
+		Category:  category,
+		Channel:   channel,
+		Enabled:   enabled,
+		UpdatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (s *memoryPreferenceStore) List(_ context.Context, userID int64) ([]Preference, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Preference, 0, len(s.prefs[userID]))
+	for _, p := range s.prefs[userID] {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].Channel < out[j].Channel
+	})
+	return out, nil
+}
+
+// dbPreferenceStore keeps preferences in the notify_preference table
+// dbPreferenceStore 将偏好保存在 notify_preference 表中
+type dbPreferenceStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBPreferenceStore creates a preference store backed by the database
+// NewDBPreferenceStore 创建基于数据库的偏好存储
+func NewDBPreferenceStore(engine *xorm.Engine) PreferenceStore {
+	return &dbPreferenceStore{engine: engine}
+}
+
+func (s *dbPreferenceStore) Get(ctx context.Context, userID int64, category string) ([]Preference, error) {
+	var prefs []Preference
+	err := s.engine.Context(ctx).
+		Where("user_id = ?", userID).
+		In("category", category, AllCategories).
+		Find(&prefs)
+	return prefs, err
+}
+
+func (s *dbPreferenceStore) Set(ctx context.Context, userID int64, category, channel string, enabled bool) error {
+	var existing Preference
+	has, err := s.engine.Context(ctx).
+		Where("user_id = ? AND category = ? AND channel = ?", userID, category, channel).
+		Get(&existing)
+	if err != nil {
+		return err
+	}
+	if has {
+		existing.Enabled = enabled
+		_, err = s.engine.Context(ctx).ID(existing.ID).Cols("enabled", "updated_at").Update(&existing)
+		return err
+	}
+	_, err = s.engine.Context(ctx).Insert(&Preference{
+		UserID:   userID,
+		Category: category,
+		Channel:  channel,
+		Enabled:  enabled,
+	})
+	return err
+}
+
+func (s *dbPreferenceStore) List(ctx context.Context, userID int64) ([]Preference, error) {
+	var prefs []Preference
+	err := s.engine.Context(ctx).
+		Where("user_id = ?", userID).
+		Asc("category", "channel").
+		Find(&prefs)
+	return prefs, err
+}
@@ -0,0 +1,99 @@
+package otp
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// HeaderCode carries the one-time code, checked before the otp_code body field
+// HeaderCode 携带一次性验证码，优先于请求体字段 otp_code 检查
+const HeaderCode = "X-OTP-Code"
+
+// sendReq is the body of SendHandler | sendReq 是 SendHandler 的请求体
+type sendReq struct {
+	Channel string `json:"channel" form:"channel"` // email or sms | email 或 sms
+	Target  string `json:"target" form:"target"`   // Email address or phone number | 邮箱地址或手机号
+}
+
+// SendHandler returns a handler that sends a code for purpose to the target of the request body
+// SendHandler 返回向请求体中的目标发送 purpose 用途验证码的处理器
+func (m *Manager) SendHandler(purpose string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req sendReq
+		if err := c.BodyParser(&req); err != nil || req.Channel == "" || req.Target == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "channel and target are required")
+		}
+		if err := m.Send(c.UserContext(), purpose, req.Channel, req.Target); err != nil {
+			if errors.Is(err, ErrTooFrequent) {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(m.config.ResendInterval.Seconds())))
+			}
+			return toFiberError(err)
+		}
+		return c.JSON(fiber.Map{"expires_in": int(m.config.TTL.Seconds())})
+	}
+}
+
+// Require returns a middleware that verifies the code for purpose of the target in body field targetField
+// The code comes from the X-OTP-Code header or the otp_code body field.
+// Require 返回校验请求体字段 targetField 所指目标的 purpose 用途验证码的中间件
+// 验证码来自 X-OTP-Code 请求头或请求体字段 otp_code。
+func (m *Manager) Require(purpose, targetField string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		target := bodyField(c, targetField)
+		code := c.Get(HeaderCode)
+		if code == "" {
+			code = bodyField(c, "otp_code")
+		}
+		if target == "" || code == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "verification code is required")
+		}
+		if err := m.Verify(c.UserContext(), purpose, target, code); err != nil {
+			return toFiberError(err)
+		}
+		return c.Next()
+	}
+}
+
+// bodyField returns a string field of a JSON or form body | bodyField 返回 JSON 或表单请求体中的字符串字段
+func bodyField(c *fiber.Ctx, name string) string {
+	if c.Is("json") {
+		var body map[string]any
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return ""
+		}
+		s, _ := body[name].(string)
+		return s
+	}
+	return c.FormValue(name)
+}
+
+// toFiberError maps errors to HTTP errors, others are returned as is
+// toFiberError 将错误映射为 HTTP 错误，其他错误原样返回
+func toFiberError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrUnknownChannel):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrTooManyAttempts), errors.Is(err, ErrTooFrequent):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	}
+	return err
+}
+
+// SendHandler returns the send handler of the default manager
+// SendHandler 返回默认管理器的发送处理器
+func SendHandler(purpose string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return defaultManager.SendHandler(purpose)(c)
+	}
+}
+
+// Require returns the verification middleware of the default manager
+// Require 返回默认管理器的校验中间件
+func Require(purpose, targetField string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return defaultManager.Require(purpose, targetField)(c)
+	}
+}
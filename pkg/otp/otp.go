@@ -0,0 +1,246 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/sms"
+)
+
+// Delivery channels | 发送渠道
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+var (
+	ErrInvalidCode     = errors.New("otp: invalid or expired code")       // Wrong, expired or already used code | 验证码错误、过期或已使用
+	ErrTooManyAttempts = errors.New("otp: too many attempts")             // Attempt limit reached, the code is revoked | 达到尝试上限，验证码已作废
+	ErrTooFrequent     = errors.New("otp: code requested too frequently") // Requested again within ResendInterval | 在 ResendInterval 内重复请求
+	ErrUnknownChannel  = errors.New("otp: unknown channel")               // No sender registered for the channel | 渠道未注册发送器
+)
+
+// Config represents one-time code configuration
+// Config 表示一次性验证码配置
+type Config struct {
+	Length         int           `toml:"length"`          // Code digits, default 6 | 验证码位数，默认 6
+	TTL            time.Duration `toml:"ttl"`             // Code lifetime, default 5m | 验证码有效期，默认 5m
+	MaxAttempts    int           `toml:"max_attempts"`    // Wrong attempts before the code is revoked, default 5 | 验证码作废前允许的错误次数，默认 5
+	ResendInterval time.Duration `toml:"resend_interval"` // Minimum interval between codes to a target, default 60s | 同一目标两次发送的最小间隔，默认 60s
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Length <= 0 {
+		c.Length = 6
+	}
+	if c.TTL <= 0 {
+		c.TTL = 5 * time.Minute
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.ResendInterval <= 0 {
+		c.ResendInterval = time.Minute
+	}
+	return c
+}
+
+// Sender delivers a code to a target such as an email address or phone number
+// Sender 将验证码发送到邮箱地址或手机号等目标
+type Sender interface {
+	Send(ctx context.Context, target, code string, ttl time.Duration) error
+}
+
+// SenderFunc adapts a function to Sender
+// SenderFunc 将函数适配为 Sender
+type SenderFunc func(ctx context.Context, target, code string, ttl time.Duration) error
+
+// Send calls f | Send 调用 f
+func (f SenderFunc) Send(ctx context.Context, target, code string, ttl time.Duration) error {
+	return f(ctx, target, code, ttl)
+}
+
+// EmailSender sends codes with the default email client and the verify_code template
+// EmailSender 使用默认邮件客户端和 verify_code 模板发送验证码
+type EmailSender struct {
+	Template string // Template name, default verify_code | 模板名称，默认 verify_code
+}
+
+// Send sends the code by email | Send 通过邮件发送验证码
+func (s EmailSender) Send(ctx context.Context, target, code string, ttl time.Duration) error {
+	client := email.Get()
+	if client == nil {
+		return errors.New("otp: email not initialized")
+	}
+	name := s.Template
+	if name == "" {
+		name = "verify_code"
+	}
+	subject, body, err := email.DefaultRegistry().RenderMessage(name, email.VerifyCodeData{Code: code, ExpireMinutes: int(ttl.Minutes())})
+	if err != nil {
+		return err
+	}
+	return client.SendContext(ctx, &email.Message{To: []string{target}, Subject: subject, Body: body, IsHTML: true})
+}
+
+// SMSSender sends codes with the default SMS client
+// SMSSender 使用默认短信客户端发送验证码
+type SMSSender struct {
+	Template string // Template name or code | 模板名称或编号
+	Param    string // Template parameter holding the code, default "code" ("1" for Tencent) | 存放验证码的模板参数，默认 "code"（腾讯云为 "1"）
+}
+
+// Send sends the code by SMS | Send 通过短信发送验证码
+func (s SMSSender) Send(ctx context.Context, target, code string, _ time.Duration) error {
+	param := s.Param
+	if param == "" {
+		param = "code"
+	}
+	return sms.Send(ctx, &sms.Message{To: []string{target}, Template: s.Template, Params: map[string]string{param: code}})
+}
+
+// Manager issues and verifies one-time codes
+// Codes are scoped by purpose, so a login code cannot be used to register.
+// Manager 签发并校验一次性验证码
+// 验证码按用途隔离，登录验证码不能用于注册。
+type Manager struct {
+	config  Config
+	store   Store
+	mu      sync.RWMutex
+	senders map[string]Sender
+}
+
+// New creates a manager, a nil store keeps codes in memory
+// New 创建管理器，store 为 nil 时验证码保存在内存中
+func New(cfg Config, store Store) *Manager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Manager{config: cfg.withDefaults(), store: store, senders: make(map[string]Sender)}
+}
+
+// Register sets the sender of a channel
+// Register 设置渠道的发送器
+func (m *Manager) Register(channel string, s Sender) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.senders[channel] = s
+	return m
+}
+
+// Config returns the effective configuration
+// Config 返回生效的配置
+func (m *Manager) Config() Config {
+	return m.config
+}
+
+// codeKey returns the store key of a code | codeKey 返回验证码的存储键
+func codeKey(purpose, target string) string {
+	return "code:" + purpose + ":" + target
+}
+
+// Send generates a code for target and delivers it through channel
+// Send 为 target 生成验证码并通过 channel 发送
+func (m *Manager) Send(ctx context.Context, purpose, channel, target string) error {
+	m.mu.RLock()
+	sender, ok := m.senders[channel]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrUnknownChannel
+	}
+
+	key := codeKey(purpose, target)
+	ok, err := m.store.SetNX(ctx, key+":cooldown", "1", m.config.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTooFrequent
+	}
+
+	code := randomDigits(m.config.Length)
+	if err := m.store.Set(ctx, key, code, m.config.TTL); err != nil {
+		return err
+	}
+	// A new code gets a fresh attempt budget | 新验证码重置尝试次数
+	if err := m.store.Del(ctx, key+":attempts"); err != nil {
+		return err
+	}
+	if err := sender.Send(ctx, target, code, m.config.TTL); err != nil {
+		// Let the user retry at once | 允许用户立即重试
+		_ = m.store.Del(ctx, key, key+":cooldown")
+		return fmt.Errorf("otp: send %s code: %w", channel, err)
+	}
+	return nil
+}
+
+// Verify checks a code and consumes it on success
+// After MaxAttempts wrong codes the code is revoked and ErrTooManyAttempts is returned.
+// Verify 校验验证码，成功后将其消费
+// 错误次数达到 MaxAttempts 后验证码作废，并返回 ErrTooManyAttempts。
+func (m *Manager) Verify(ctx context.Context, purpose, target, code string) error {
+	key := codeKey(purpose, target)
+	// Count first, so concurrent guesses cannot exceed the limit | 先计数，避免并发猜测超过上限
+	n, err := m.store.Incr(ctx, key+":attempts", m.config.TTL)
+	if err != nil {
+		return err
+	}
+	if n > int64(m.config.MaxAttempts) {
+		_ = m.store.Del(ctx, key)
+		return ErrTooManyAttempts
+	}
+
+	expected, ok, err := m.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	code = strings.TrimSpace(code)
+	if !ok || code == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+		return ErrInvalidCode
+	}
+	return m.store.Del(ctx, key, key+":attempts")
+}
+
+// ValidateTOTP validates a TOTP code of account and rejects a code that was already used
+// ValidateTOTP 校验账号的 TOTP 验证码，并拒绝已使用过的验证码
+func (m *Manager) ValidateTOTP(ctx context.Context, account, secret, code string) (bool, error) {
+	s, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	ttl := time.Duration(2*TOTPSkew+1) * TOTPPeriod
+	return m.store.SetNX(ctx, fmt.Sprintf("totp:%s:%d", account, s), "1", ttl)
+}
+
+// randomDigits returns n random decimal digits | randomDigits 返回 n 位随机数字
+func randomDigits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		v, _ := rand.Int(rand.Reader, big.NewInt(10))
+		b[i] = byte('0' + v.Int64())
+	}
+	return string(b)
+}
+
+var defaultManager = New(Config{}, nil) // Default manager, codes in memory until Init | 默认管理器，Init 前验证码保存在内存中
+
+// Init initializes the default manager
+// Init 初始化默认管理器
+func Init(cfg Config, store Store) {
+	defaultManager = New(cfg, store)
+}
+
+// Get returns the default manager
+// Get 返回默认管理器
+func Get() *Manager {
+	return defaultManager
+}
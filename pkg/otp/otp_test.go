@@ -0,0 +1,206 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// rfcSecret is the RFC 6238 SHA1 test key "12345678901234567890" | rfcSecret 是 RFC 6238 SHA1 测试密钥
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+	}
+	for _, tt := range tests {
+		code, err := TOTP(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil || code != tt.code {
+			t.Errorf("TOTP at %d: expected %s, got %s %v", tt.unix, tt.code, code, err)
+		}
+	}
+
+	now := time.Unix(1111111109, 0)
+	if !ValidateTOTP(rfcSecret, "081804", now.Add(TOTPPeriod)) {
+		t.Error("Code of the previous step should validate")
+	}
+	if ValidateTOTP(rfcSecret, "081804", now.Add(3*TOTPPeriod)) {
+		t.Error("Code outside the skew should not validate")
+	}
+}
+
+func TestEnroll(t *testing.T) {
+	key, err := Enroll("crab", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Enroll failed: %v", err)
+	}
+	if len(key.Secret) != 32 {
+		t.Errorf("Expected 32 character secret, got %q", key.Secret)
+	}
+	u := key.URL()
+	if !strings.HasPrefix(u, "otpauth://totp/crab:alice@example.com?") || !strings.Contains(u, "secret="+key.Secret) {
+		t.Errorf("Unexpected URL %s", u)
+	}
+	code, _ := TOTP(key.Secret, time.Now())
+	if !ValidateTOTP(key.Secret, code, time.Now()) {
+		t.Error("Generated code should validate")
+	}
+}
+
+func TestValidateTOTPReplay(t *testing.T) {
+	m := New(Config{}, nil)
+	ctx := context.Background()
+	code, _ := TOTP(rfcSecret, time.Now())
+
+	if ok, err := m.ValidateTOTP(ctx, "alice", rfcSecret, code); !ok || err != nil {
+		t.Fatalf("First use should validate, got %v %v", ok, err)
+	}
+	if ok, _ := m.ValidateTOTP(ctx, "alice", rfcSecret, code); ok {
+		t.Error("Replayed code should be rejected")
+	}
+}
+
+// captureSender records the last code sent | captureSender 记录最后发送的验证码
+type captureSender struct{ code string }
+
+func (s *captureSender) Send(_ context.Context, _, code string, _ time.Duration) error {
+	s.code = code
+	return nil
+}
+
+func TestSendAndVerify(t *testing.T) {
+	sender := &captureSender{}
+	m := New(Config{MaxAttempts: 3}, nil).Register(ChannelSMS, sender)
+	ctx := context.Background()
+
+	if err := m.Send(ctx, "login", "email", "x"); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("Expected ErrUnknownChannel, got %v", err)
+	}
+	if err := m.Send(ctx, "login", ChannelSMS, "+8613800000000"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(sender.code) != 6 {
+		t.Fatalf("Expected 6 digit code, got %q", sender.code)
+	}
+	if err := m.Send(ctx, "login", ChannelSMS, "+8613800000000"); !errors.Is(err, ErrTooFrequent) {
+		t.Errorf("Expected ErrTooFrequent, got %v", err)
+	}
+
+	if err := m.Verify(ctx, "register", "+8613800000000", sender.code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Code should be scoped by purpose, got %v", err)
+	}
+	if err := m.Verify(ctx, "login", "+8613800000000", sender.code); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if err := m.Verify(ctx, "login", "+8613800000000", sender.code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Code should be single-use, got %v", err)
+	}
+}
+
+func TestAttemptLimit(t *testing.T) {
+	sender := &captureSender{}
+	m := New(Config{MaxAttempts: 2}, nil).Register(ChannelEmail, sender)
+	ctx := context.Background()
+	_ = m.Send(ctx, "reset", ChannelEmail, "a@example.com")
+
+	for i := 0; i < 2; i++ {
+		if err := m.Verify(ctx, "reset", "a@example.com", "wrong"); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("Attempt %d: expected ErrInvalidCode, got %v", i+1, err)
+		}
+	}
+	if err := m.Verify(ctx, "reset", "a@example.com", sender.code); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Expected ErrTooManyAttempts, got %v", err)
+	}
+}
+
+func TestSendFailureAllowsRetry(t *testing.T) {
+	fail := true
+	m := New(Config{}, nil).Register(ChannelSMS, SenderFunc(func(context.Context, string, string, time.Duration) error {
+		if fail {
+			return errors.New("provider down")
+		}
+		return nil
+	}))
+	ctx := context.Background()
+	if err := m.Send(ctx, "login", ChannelSMS, "1"); err == nil {
+		t.Fatal("Expected send error")
+	}
+	fail = false
+	if err := m.Send(ctx, "login", ChannelSMS, "1"); err != nil {
+		t.Errorf("Failed send should not start the cooldown, got %v", err)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	ctx := context.Background()
+
+	if n, err := store.Incr(ctx, "n", time.Minute); err != nil || n != 1 {
+		t.Fatalf("Expected 1, got %d %v", n, err)
+	}
+	if n, _ := store.Incr(ctx, "n", time.Minute); n != 2 {
+		t.Errorf("Expected 2, got %d", n)
+	}
+	if ttl := mr.TTL("otp:n"); ttl <= 0 {
+		t.Errorf("Expected counter TTL, got %v", ttl)
+	}
+	if ok, _ := store.SetNX(ctx, "k", "v", time.Minute); !ok {
+		t.Error("First SetNX should succeed")
+	}
+	if ok, _ := store.SetNX(ctx, "k", "v", time.Minute); ok {
+		t.Error("Second SetNX should fail")
+	}
+	if v, ok, err := store.Get(ctx, "k"); !ok || v != "v" || err != nil {
+		t.Errorf("Expected v, got %q %v %v", v, ok, err)
+	}
+	_ = store.Del(ctx, "k")
+	if _, ok, _ := store.Get(ctx, "k"); ok {
+		t.Error("Deleted key should be missing")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	sender := &captureSender{}
+	m := New(Config{}, nil).Register(ChannelEmail, sender)
+	_ = m.Send(context.Background(), "register", ChannelEmail, "a@example.com")
+
+	app := fiber.New()
+	app.Post("/register", m.Require("register", "email"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	tests := []struct {
+		name   string
+		code   string
+		status int
+	}{
+		{"wrong", "000000x", fiber.StatusBadRequest},
+		{"correct", sender.code, fiber.StatusOK},
+		{"reused", sender.code, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		body := `{"email":"a@example.com","otp_code":"` + tt.code + `"}`
+		req := httptest.NewRequest(fiber.MethodPost, "/register", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, resp.StatusCode)
+		}
+	}
+}
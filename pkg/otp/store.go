@@ -0,0 +1,151 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps codes, attempt counters and cooldowns
+// Store 保存验证码、尝试计数和冷却标记
+type Store interface {
+	// Set stores value for ttl | Set 保存 value，有效期为 ttl
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX stores value only if key is absent | SetNX 仅在 key 不存在时保存 value
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Get returns the value of key, false when missing | Get 返回 key 的值，不存在时返回 false
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Incr increments key, setting ttl when it is created | Incr 递增 key，创建时设置 ttl
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Del deletes keys | Del 删除键
+	Del(ctx context.Context, keys ...string) error
+}
+
+// memoryStore keeps entries in process memory, for single instance deployments and tests
+// memoryStore 将数据保存在进程内存中，适用于单实例部署和测试
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     string
+	count     int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// live returns the unexpired entry of key, callers hold mu | live 返回 key 未过期的条目，调用方需持有 mu
+func (s *memoryStore) live(key string) (memoryEntry, bool) {
+	e, ok := s.entries[key]
+	if ok && time.Now().After(e.expiresAt) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, ok
+}
+
+func (s *memoryStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.live(key); ok {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.live(key)
+	return e.value, ok, nil
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.live(key)
+	if !ok {
+		e = memoryEntry{expiresAt: time.Now().Add(ttl)}
+	}
+	e.count++
+	s.entries[key] = e
+	return e.count, nil
+}
+
+func (s *memoryStore) Del(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.entries, k)
+	}
+	return nil
+}
+
+// redisStore keeps entries in Redis so codes can be verified by any instance
+// redisStore 将数据保存在 Redis 中，任意实例都可以校验验证码
+type redisStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis store, prefix defaults to "otp:"
+// NewRedisStore 创建 Redis 存储，prefix 默认为 "otp:"
+func NewRedisStore(client redis.UniversalClient, prefix string) Store {
+	if prefix == "" {
+		prefix = "otp:"
+	}
+	return &redisStore{rdb: client, prefix: prefix}
+}
+
+func (s *redisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+func (s *redisStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, s.prefix+key, value, ttl).Result()
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	v, err := s.rdb.Get(ctx, s.prefix+key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	return v, err == nil, err
+}
+
+// incrScript increments a counter and sets its expiry on creation | incrScript 递增计数器并在创建时设置过期时间
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.rdb, []string{s.prefix + key}, ttl.Milliseconds()).Int64()
+}
+
+func (s *redisStore) Del(ctx context.Context, keys ...string) error {
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = s.prefix + k
+	}
+	return s.rdb.Del(ctx, full...).Err()
+}
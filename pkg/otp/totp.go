@@ -0,0 +1,143 @@
+// Package otp provides TOTP authenticator codes and one-time codes delivered by SMS or email
+// Package otp 提供 TOTP 身份验证器验证码，以及通过短信或邮件发送的一次性验证码
+//
+// Usage | 用法:
+//
+//	// TOTP enrollment | TOTP 绑定
+//	key, _ := otp.Enroll("crab", user.Email)   // show key.URL() as a QR code, store key.Secret
+//	ok := otp.ValidateTOTP(secret, code, time.Now())
+//
+//	// One-time codes | 一次性验证码
+//	otp.Init(otp.Config{}, otp.NewRedisStore(rdb, ""))
+//	otp.Get().Register(otp.ChannelEmail, otp.EmailSender{})
+//	router.Post("/code", otp.SendHandler("register"))
+//	router.Post("/register", otp.Require("register", "email"), Register)
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters, the defaults of authenticator apps (RFC 6238)
+// TOTP 参数，与身份验证器应用的默认值一致（RFC 6238）
+const (
+	TOTPDigits = 6                // Code length | 验证码长度
+	TOTPPeriod = 30 * time.Second // Time step | 时间步长
+	TOTPSkew   = 1                // Steps accepted before and after the current one | 当前步长前后允许的步数
+)
+
+// b32 encodes secrets as authenticator apps expect | b32 按身份验证器应用要求的格式编码密钥
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Key is a TOTP secret enrolled for an account
+// Key 是为账号绑定的 TOTP 密钥
+type Key struct {
+	Secret  string // Base32 secret, store it encrypted | Base32 密钥，应加密存储
+	Issuer  string // Service name shown in the app | 应用中显示的服务名称
+	Account string // Account name shown in the app | 应用中显示的账号名称
+}
+
+// Enroll generates a new TOTP key for an account
+// Enroll 为账号生成新的 TOTP 密钥
+func Enroll(issuer, account string) (*Key, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Secret: secret, Issuer: issuer, Account: account}, nil
+}
+
+// GenerateSecret returns a random 160-bit base32 secret
+// GenerateSecret 返回随机的 160 位 base32 密钥
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// URL returns the otpauth:// URL to render as a QR code
+// URL 返回用于生成二维码的 otpauth:// 地址
+func (k *Key) URL() string {
+	q := url.Values{}
+	q.Set("secret", k.Secret)
+	q.Set("issuer", k.Issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(TOTPDigits))
+	q.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	label := url.PathEscape(k.Issuer + ":" + k.Account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTP returns the code of secret at t
+// TOTP 返回 secret 在 t 时刻的验证码
+func TOTP(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, step(t)), nil
+}
+
+// ValidateTOTP checks code against secret at t, allowing TOTPSkew steps of clock drift
+// A code stays valid for its whole window: use Manager.ValidateTOTP to reject replays.
+// ValidateTOTP 校验 secret 在 t 时刻的验证码，允许 TOTPSkew 个步长的时钟偏差
+// 验证码在整个窗口内都有效：使用 Manager.ValidateTOTP 拒绝重放。
+func ValidateTOTP(secret, code string, t time.Time) bool {
+	_, ok := matchTOTP(secret, code, t)
+	return ok
+}
+
+// matchTOTP returns the time step code matches | matchTOTP 返回验证码匹配的时间步
+func matchTOTP(secret, code string, t time.Time) (uint64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	current := step(t)
+	for i := -TOTPSkew; i <= TOTPSkew; i++ {
+		s := current + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, s)), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// step returns the TOTP time step of t | step 返回 t 所在的 TOTP 时间步
+func step(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(TOTPPeriod.Seconds())
+}
+
+// decodeSecret decodes a base32 secret, tolerating spaces, lowercase and padding
+// decodeSecret 解码 base32 密钥，容忍空格、小写和填充
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return b32.DecodeString(strings.TrimRight(s, "="))
+}
+
+// hotp computes an RFC 4226 code | hotp 计算 RFC 4226 验证码
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000)
+}
@@ -0,0 +1,352 @@
+package payment
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+)
+
+// AlipayConfig configures the Alipay open platform gateway (RSA2 keys)
+// AlipayConfig 配置支付宝开放平台网关（RSA2 密钥）
+type AlipayConfig struct {
+	AppID      string `toml:"app_id"`      // Application ID, empty disables Alipay | 应用 ID，为空时禁用支付宝
+	PrivateKey string `toml:"private_key"` // Application private key, inline (PEM or base64) or a file path | 应用私钥，内联（PEM 或 base64）或文件路径
+	PublicKey  string `toml:"public_key"`  // Alipay public key, inline (PEM or base64) or a file path | 支付宝公钥，内联（PEM 或 base64）或文件路径
+	Sandbox    bool   `toml:"sandbox"`     // Use the sandbox gateway | 使用沙箱网关
+	Endpoint   string `toml:"endpoint"`    // Gateway URL override | 网关地址覆盖
+}
+
+// alipayZone is the time zone of Alipay timestamps | alipayZone 为支付宝时间戳使用的时区
+var alipayZone = time.FixedZone("CST", 8*3600)
+
+// alipayTimeLayout is the layout of Alipay timestamps | alipayTimeLayout 为支付宝时间戳格式
+const alipayTimeLayout = "2006-01-02 15:04:05"
+
+// alipayProvider calls the Alipay gateway, requests are signed with the application key and
+// responses and notifications verified with the Alipay public key
+// alipayProvider 调用支付宝网关，请求使用应用私钥签名，响应和通知使用支付宝公钥验签
+type alipayProvider struct {
+	cfg  AlipayConfig
+	http *http.Client
+	key  *rsa.PrivateKey
+	pub  *rsa.PublicKey
+}
+
+// NewAlipay creates the Alipay provider
+// NewAlipay 创建支付宝服务商
+func NewAlipay(cfg AlipayConfig, client *http.Client) (Provider, error) {
+	key, err := parsePrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("payment alipay: private_key: %w", err)
+	}
+	pub, err := parsePublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("payment alipay: public_key: %w", err)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://openapi.alipay.com/gateway.do"
+		if cfg.Sandbox {
+			cfg.Endpoint = "https://openapi-sandbox.dl.alipaydev.com/gateway.do"
+		}
+	}
+	return &alipayProvider{cfg: cfg, http: client, key: key, pub: pub}, nil
+}
+
+func (p *alipayProvider) Name() string { return Alipay }
+
+func (p *alipayProvider) Create(ctx context.Context, order *Order) (*Checkout, error) {
+	if order.Amount.Currency != "CNY" {
+		return nil, fmt.Errorf("%w: alipay takes CNY only", ErrInvalidOrder)
+	}
+	biz := map[string]any{
+		"out_trade_no": order.OutTradeNo,
+		"total_amount": order.Amount.Amount.StringFixed(2),
+		"subject":      order.Subject,
+	}
+	if !order.ExpireAt.IsZero() {
+		biz["time_expire"] = order.ExpireAt.In(alipayZone).Format(alipayTimeLayout)
+	}
+	extra := map[string]string{"notify_url": order.NotifyURL, "return_url": order.ReturnURL}
+
+	checkout := &Checkout{Provider: Alipay, OutTradeNo: order.OutTradeNo}
+	switch defaultString(order.Scene, ScenePage) {
+	case ScenePage:
+		biz["product_code"] = "FAST_INSTANT_TRADE_PAY"
+		query, err := p.signedQuery("alipay.trade.page.pay", biz, extra)
+		if err != nil {
+			return nil, err
+		}
+		checkout.URL = p.cfg.Endpoint + "?" + query
+	case SceneH5:
+		biz["product_code"] = "QUICK_WAP_WAY"
+		query, err := p.signedQuery("alipay.trade.wap.pay", biz, extra)
+		if err != nil {
+			return nil, err
+		}
+		checkout.URL = p.cfg.Endpoint + "?" + query
+	case SceneApp:
+		biz["product_code"] = "QUICK_MSECURITY_PAY"
+		query, err := p.signedQuery("alipay.trade.app.pay", biz, map[string]string{"notify_url": order.NotifyURL})
+		if err != nil {
+			return nil, err
+		}
+		checkout.Params = map[string]string{"order_string": query}
+	case SceneNative:
+		var resp struct {
+			QRCode string `json:"qr_code"`
+		}
+		if err := p.do(ctx, "alipay.trade.precreate", biz, map[string]string{"notify_url": order.NotifyURL}, &resp); err != nil {
+			return nil, err
+		}
+		checkout.URL = resp.QRCode
+	default:
+		return nil, ErrUnsupportedScene
+	}
+	return checkout, nil
+}
+
+// alipayTrade is a payment as returned by query and notifications | alipayTrade 为查询和通知返回的支付
+type alipayTrade struct {
+	OutTradeNo  string `json:"out_trade_no"`
+	TradeNo     string `json:"trade_no"`
+	TradeStatus string `json:"trade_status"`
+	TotalAmount string `json:"total_amount"`
+	PaidAt      string `json:"send_pay_date"` // gmt_payment in notifications | 通知中为 gmt_payment
+}
+
+func (t *alipayTrade) transaction() (*Transaction, error) {
+	amount, err := money.Parse(t.TotalAmount)
+	if err != nil {
+		return nil, fmt.Errorf("payment alipay: total_amount: %w", err)
+	}
+	tx := &Transaction{
+		Provider:   Alipay,
+		OutTradeNo: t.OutTradeNo,
+		TradeNo:    t.TradeNo,
+		Amount:     money.NewMoney(amount, "CNY"),
+	}
+	switch t.TradeStatus {
+	case "TRADE_SUCCESS", "TRADE_FINISHED":
+		tx.Status = StatusPaid
+	case "TRADE_CLOSED":
+		tx.Status = StatusClosed
+	default: // WAIT_BUYER_PAY | 等待付款
+		tx.Status = StatusPending
+	}
+	if at, err := time.ParseInLocation(alipayTimeLayout, t.PaidAt, alipayZone); err == nil {
+		tx.PaidAt = &at
+	}
+	return tx, nil
+}
+
+func (p *alipayProvider) Query(ctx context.Context, outTradeNo string) (*Transaction, error) {
+	var resp alipayTrade
+	if err := p.do(ctx, "alipay.trade.query", map[string]any{"out_trade_no": outTradeNo}, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.transaction()
+}
+
+func (p *alipayProvider) Close(ctx context.Context, outTradeNo string) error {
+	err := p.do(ctx, "alipay.trade.close", map[string]any{"out_trade_no": outTradeNo}, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Refund is synchronous at Alipay, a successful call means the money is returned
+// Refund 在支付宝是同步的，调用成功即表示已退回
+func (p *alipayProvider) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	biz := map[string]any{
+		"out_trade_no":   req.OutTradeNo,
+		"out_request_no": req.OutRefundNo,
+		"refund_amount":  req.Amount.Amount.StringFixed(2),
+		"refund_reason":  req.Reason,
+	}
+	var resp struct {
+		TradeNo string `json:"trade_no"`
+	}
+	if err := p.do(ctx, "alipay.trade.refund", biz, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &Refund{
+		Provider:    Alipay,
+		OutTradeNo:  req.OutTradeNo,
+		OutRefundNo: req.OutRefundNo,
+		RefundNo:    resp.TradeNo,
+		Status:      RefundSucceeded,
+		Amount:      req.Amount,
+	}, nil
+}
+
+// ParseNotify verifies a trade notification; refunds are settled synchronously, their notifications
+// carry the trade state only
+// ParseNotify 校验交易通知；退款同步完成，退款通知只携带交易状态
+func (p *alipayProvider) ParseNotify(_ context.Context, _ http.Header, body []byte) (*Notification, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("payment alipay: decode notification: %w", err)
+	}
+	params := make(map[string]string, len(form))
+	for k := range form {
+		if k != "sign" && k != "sign_type" {
+			params[k] = form.Get(k)
+		}
+	}
+	if verifySHA256(p.pub, alipayContent(params), form.Get("sign")) != nil || params["app_id"] != p.cfg.AppID {
+		return nil, ErrInvalidSignature
+	}
+	trade := alipayTrade{
+		OutTradeNo:  params["out_trade_no"],
+		TradeNo:     params["trade_no"],
+		TradeStatus: params["trade_status"],
+		TotalAmount: params["total_amount"],
+		PaidAt:      params["gmt_payment"],
+	}
+	tx, err := trade.transaction()
+	if err != nil {
+		return nil, err
+	}
+	return &Notification{Provider: Alipay, Transaction: tx}, nil
+}
+
+func (p *alipayProvider) Ack(err error) Reply {
+	if err == nil {
+		return Reply{Status: http.StatusOK, ContentType: "text/plain", Body: "success"}
+	}
+	return Reply{Status: http.StatusOK, ContentType: "text/plain", Body: "failure"}
+}
+
+// params returns the signed common and business parameters of a gateway call
+// params 返回网关调用的已签名公共参数和业务参数
+func (p *alipayProvider) params(method string, biz map[string]any, extra map[string]string) (map[string]string, error) {
+	content, err := json.Marshal(biz)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"app_id":      p.cfg.AppID,
+		"method":      method,
+		"format":      "JSON",
+		"charset":     "utf-8",
+		"sign_type":   "RSA2",
+		"timestamp":   time.Now().In(alipayZone).Format(alipayTimeLayout),
+		"version":     "1.0",
+		"biz_content": string(content),
+	}
+	for k, v := range extra {
+		if v != "" {
+			params[k] = v
+		}
+	}
+	sign, err := signSHA256(p.key, alipayContent(params))
+	if err != nil {
+		return nil, err
+	}
+	params["sign"] = sign
+	return params, nil
+}
+
+// signedQuery returns the signed parameters as a query string, for redirects and the app SDK
+// signedQuery 以查询字符串形式返回签名后的参数，用于跳转和 App SDK
+func (p *alipayProvider) signedQuery(method string, biz map[string]any, extra map[string]string) (string, error) {
+	params, err := p.params(method, biz, extra)
+	if err != nil {
+		return "", err
+	}
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return values.Encode(), nil
+}
+
+// do calls a gateway method and decodes the verified response node into out
+// do 调用网关方法，并将验签后的响应节点解码到 out
+func (p *alipayProvider) do(ctx context.Context, method string, biz map[string]any, extra map[string]string, out any) error {
+	query, err := p.signedQuery(method, biz, extra)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint, strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment alipay: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("payment alipay: read response: %w", err)
+	}
+
+	// The signature covers the raw bytes of the response node | 签名覆盖响应节点的原始字节
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return &Error{Provider: Alipay, Code: resp.Status, Message: err.Error(), Temporary: resp.StatusCode >= 500}
+	}
+	node := envelope[strings.ReplaceAll(method, ".", "_")+"_response"]
+	var result struct {
+		Code    string `json:"code"`
+		Msg     string `json:"msg"`
+		SubCode string `json:"sub_code"`
+		SubMsg  string `json:"sub_msg"`
+	}
+	if err := json.Unmarshal(node, &result); err != nil {
+		return &Error{Provider: Alipay, Code: resp.Status, Message: "missing response node", Temporary: resp.StatusCode >= 500}
+	}
+	if result.Code != "10000" {
+		if result.SubCode == "ACQ.TRADE_NOT_EXIST" {
+			return fmt.Errorf("%w: %s", ErrNotFound, result.SubMsg)
+		}
+		return &Error{
+			Provider:  Alipay,
+			Code:      defaultString(result.SubCode, result.Code),
+			Message:   defaultString(result.SubMsg, result.Msg),
+			Temporary: result.Code == "20000" || result.SubCode == "ACQ.SYSTEM_ERROR",
+		}
+	}
+	var sign string
+	_ = json.Unmarshal(envelope["sign"], &sign)
+	if verifySHA256(p.pub, string(node), sign) != nil {
+		return fmt.Errorf("payment alipay: %w on response", ErrInvalidSignature)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(node, out)
+}
+
+// alipayContent returns the string to sign: sorted non-empty k=v pairs joined with &
+// alipayContent 返回待签名字符串：按键排序的非空 k=v 以 & 连接
+func alipayContent(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if v != "" && k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(k + "=" + params[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package payment
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/webhook"
+)
+
+// keyBytes returns a key given inline as PEM, as a file path, or as bare base64 DER (the Alipay key tool format)
+// keyBytes 返回以内联 PEM、文件路径或裸 base64 DER（支付宝密钥工具格式）给出的密钥
+func keyBytes(s string) (data []byte, isPEM bool, err error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "-----BEGIN") {
+		return []byte(s), true, nil
+	}
+	if data, err := os.ReadFile(s); err == nil {
+		return data, strings.Contains(string(data), "-----BEGIN"), nil
+	}
+	der, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false, errors.New("key is neither PEM, a readable file nor base64")
+	}
+	return der, false, nil
+}
+
+// parsePrivateKey parses a PKCS#1 or PKCS#8 RSA private key, see keyBytes
+// parsePrivateKey 解析 PKCS#1 或 PKCS#8 格式的 RSA 私钥，见 keyBytes
+func parsePrivateKey(s string) (*rsa.PrivateKey, error) {
+	data, isPEM, err := keyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no PEM block found")
+		}
+		data = block.Bytes
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return key, nil
+}
+
+// parsePublicKey parses an RSA public key or certificate, see keyBytes
+// parsePublicKey 解析 RSA 公钥或证书，见 keyBytes
+func parsePublicKey(s string) (*rsa.PublicKey, error) {
+	data, isPEM, err := keyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM {
+		return webhook.ParseRSAPublicKey(data)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return key, nil
+}
+
+// signSHA256 returns the base64 RSA-SHA256 (PKCS#1 v1.5) signature of message
+// signSHA256 返回 message 的 base64 RSA-SHA256（PKCS#1 v1.5）签名
+func signSHA256(key *rsa.PrivateKey, message string) (string, error) {
+	digest := sha256.Sum256([]byte(message))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifySHA256 checks a base64 RSA-SHA256 signature of message
+// verifySHA256 校验 message 的 base64 RSA-SHA256 签名
+func verifySHA256(key *rsa.PublicKey, message, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256([]byte(message))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
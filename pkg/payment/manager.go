@@ -0,0 +1,662 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/httpclient"
+	"github.com/nuohe369/crab/pkg/money"
+	"github.com/nuohe369/crab/pkg/transaction"
+)
+
+// PaidHandler brings the business state along when an order is paid
+// It runs again after a failure, from later notifications and reconciliation, until it succeeds.
+// PaidHandler 在订单付款后同步业务状态
+// 失败后会在后续通知和对账中再次运行，直到成功。
+type PaidHandler func(ctx context.Context, r *Record) error
+
+// RefundHandler is called once when a refund succeeds, errors are logged
+// RefundHandler 在退款成功时调用一次，错误仅记录日志
+type RefundHandler func(ctx context.Context, r *Record, refund *RefundRecord) error
+
+// unpaidTTL is how long an order without an expiry waits for payment before reconciliation closes it
+// unpaidTTL 为未设置过期时间的订单等待付款的时长，超过后由对账关闭
+const unpaidTTL = 24 * time.Hour
+
+// reconcileBatch is how many orders one run of ReconcileJob looks at | reconcileBatch 为 ReconcileJob 每次运行处理的订单数量
+const reconcileBatch = 500
+
+// Manager creates orders through the providers and keeps their state in a Store
+// Manager 通过服务商创建订单并在 Store 中维护订单状态
+type Manager struct {
+	cfg       Config
+	store     Store
+	mu        sync.RWMutex
+	providers map[string]Provider
+	onPaid    []PaidHandler
+	onRefund  []RefundHandler
+}
+
+// New creates a manager with a provider for each configured section, nil store keeps orders in memory
+// New 为每个已配置的服务商创建驱动并返回管理器，store 为 nil 时订单保存在内存中
+func New(cfg Config, store Store) (*Manager, error) {
+	cfg = cfg.withDefaults()
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	m := &Manager{cfg: cfg, store: store, providers: make(map[string]Provider)}
+	// Payment calls are not retried blindly, the Manager retries by order number | 支付调用不盲目重试，由 Manager 按订单号重试
+	client := httpclient.New(httpclient.Config{Timeout: cfg.Timeout, RetryMax: -1})
+	if cfg.WeChat.MchID != "" {
+		p, err := NewWeChat(cfg.WeChat, client)
+		if err != nil {
+			return nil, err
+		}
+		m.Register(p)
+	}
+	if cfg.Alipay.AppID != "" {
+		p, err := NewAlipay(cfg.Alipay, client)
+		if err != nil {
+			return nil, err
+		}
+		m.Register(p)
+	}
+	if cfg.Stripe.SecretKey != "" {
+		p, err := NewStripe(cfg.Stripe, client)
+		if err != nil {
+			return nil, err
+		}
+		m.Register(p)
+	}
+	return m, nil
+}
+
+// Register adds or replaces a provider
+// Register 添加或替换服务商
+func (m *Manager) Register(p Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.providers[p.Name()] = p
+}
+
+// Provider returns a registered provider
+// Provider 返回已注册的服务商
+func (m *Manager) Provider(name string) (Provider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// Store returns the order store
+// Store 返回订单存储
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+// Config returns the configuration the manager was created with
+// Config 返回创建管理器时使用的配置
+func (m *Manager) Config() Config {
+	return m.cfg
+}
+
+// OnPaid adds a handler run when an order is paid, it must be idempotent
+// OnPaid 添加订单付款后运行的处理器，处理器必须幂等
+func (m *Manager) OnPaid(fn PaidHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onPaid = append(m.onPaid, fn)
+}
+
+// OnRefund adds a handler run when a refund succeeds
+// OnRefund 添加退款成功后运行的处理器
+func (m *Manager) OnRefund(fn RefundHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRefund = append(m.onRefund, fn)
+}
+
+// Create records an order and creates it at the provider
+// Creating a pending order again returns a fresh checkout for it; a paid order returns ErrAlreadyPaid.
+// Create 记录订单并在服务商处创建
+// 再次创建待付款订单会返回新的支付参数；已付款订单返回 ErrAlreadyPaid。
+func (m *Manager) Create(ctx context.Context, provider string, order *Order) (*Checkout, error) {
+	p, err := m.Provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	if order.OutTradeNo == "" || order.Subject == "" || order.Amount.Currency == "" || order.Amount.Minor() <= 0 {
+		return nil, ErrInvalidOrder
+	}
+
+	rec, err := m.store.GetOrder(ctx, order.OutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rec == nil:
+		rec = &Record{
+			Provider:   provider,
+			OutTradeNo: order.OutTradeNo,
+			Subject:    order.Subject,
+			Amount:     order.Amount.Minor(),
+			Currency:   order.Amount.Currency,
+			Status:     StatusPending,
+		}
+		if !order.ExpireAt.IsZero() {
+			expireAt := order.ExpireAt
+			rec.ExpireAt = &expireAt
+		}
+		if err := m.store.CreateOrder(ctx, rec); err != nil {
+			return nil, err
+		}
+	case rec.Status == StatusPaid || rec.Status == StatusRefunded:
+		return nil, ErrAlreadyPaid
+	case rec.Status == StatusClosed:
+		return nil, fmt.Errorf("%w: order %s is closed", ErrInvalidOrder, rec.OutTradeNo)
+	case rec.Provider != provider || rec.Amount != order.Amount.Minor() || rec.Currency != order.Amount.Currency:
+		return nil, fmt.Errorf("%w: order %s was created with another provider or amount", ErrInvalidOrder, rec.OutTradeNo)
+	}
+
+	if order.NotifyURL == "" && m.cfg.NotifyURL != "" {
+		o := *order
+		o.NotifyURL = strings.TrimRight(m.cfg.NotifyURL, "/") + "/" + provider
+		order = &o
+	}
+	checkout, err := p.Create(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if checkout.TradeNo != "" && checkout.TradeNo != rec.TradeNo {
+		updated := *rec
+		updated.TradeNo = checkout.TradeNo
+		if _, err := m.store.UpdateOrder(ctx, &updated, StatusPending); err != nil {
+			return nil, err
+		}
+	}
+	return checkout, nil
+}
+
+// Query returns an order, asking the provider first while it is pending
+// Query 返回订单，待付款时先向服务商查询
+func (m *Manager) Query(ctx context.Context, outTradeNo string) (*Record, error) {
+	rec, err := m.store.GetOrder(ctx, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, ErrNotFound
+	}
+	if rec.Status != StatusPending {
+		return rec, nil
+	}
+	p, err := m.Provider(rec.Provider)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := p.Query(ctx, outTradeNo)
+	if errors.Is(err, ErrNotFound) {
+		return rec, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := m.apply(ctx, rec, tx); err != nil {
+		return nil, err
+	}
+	return m.store.GetOrder(ctx, outTradeNo)
+}
+
+// Close closes an unpaid order at the provider; closing a closed order succeeds
+// Close 在服务商处关闭未付款订单；关闭已关闭的订单视为成功
+func (m *Manager) Close(ctx context.Context, outTradeNo string) error {
+	rec, err := m.store.GetOrder(ctx, outTradeNo)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		return ErrNotFound
+	}
+	switch rec.Status {
+	case StatusClosed:
+		return nil
+	case StatusPaid, StatusRefunded:
+		return ErrAlreadyPaid
+	}
+	p, err := m.Provider(rec.Provider)
+	if err != nil {
+		return err
+	}
+	if err := p.Close(ctx, outTradeNo); err != nil {
+		return err
+	}
+	closed := *rec
+	closed.Status = StatusClosed
+	_, err = m.store.UpdateOrder(ctx, &closed, StatusPending)
+	return err
+}
+
+// Refund returns money of a paid order
+// Retrying with the same OutRefundNo does not refund twice. The refund may still be pending when
+// this returns, the provider notification settles it.
+// Refund 对已付款订单退款
+// 使用同一 OutRefundNo 重试不会重复退款。返回时退款可能仍在处理中，由服务商通知完成。
+func (m *Manager) Refund(ctx context.Context, req *RefundRequest) (*RefundRecord, error) {
+	if req.OutTradeNo == "" || req.OutRefundNo == "" || req.Amount.Minor() <= 0 {
+		return nil, ErrInvalidOrder
+	}
+	existing, err := m.store.GetRefund(ctx, req.OutRefundNo)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status == RefundSucceeded {
+		return existing, nil
+	}
+
+	rec, err := m.store.GetOrder(ctx, req.OutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, ErrNotFound
+	}
+	switch {
+	case rec.Status == StatusRefunded:
+		return nil, ErrRefundExceedsTotal
+	case rec.Status != StatusPaid:
+		return nil, ErrNotPaid
+	case req.Amount.Currency != rec.Currency:
+		return nil, ErrAmountMismatch
+	}
+	p, err := m.Provider(rec.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending refunds count against the total until they fail | 处理中的退款在失败前计入总额
+	refunds, err := m.store.Refunds(ctx, rec.OutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	outstanding := rec.Refunded + req.Amount.Minor()
+	for _, r := range refunds {
+		if r.Status == RefundPending && r.OutRefundNo != req.OutRefundNo {
+			outstanding += r.Amount
+		}
+	}
+	if outstanding > rec.Amount {
+		return nil, ErrRefundExceedsTotal
+	}
+
+	if existing == nil {
+		existing = &RefundRecord{
+			OutTradeNo:  rec.OutTradeNo,
+			OutRefundNo: req.OutRefundNo,
+			Amount:      req.Amount.Minor(),
+			Currency:    rec.Currency,
+			Reason:      req.Reason,
+			Status:      RefundPending,
+		}
+		if err := m.store.CreateRefund(ctx, existing); err != nil {
+			return nil, err
+		}
+	} else if existing.Status == RefundFailed {
+		retry := *existing
+		retry.Status = RefundPending
+		if _, err := m.store.UpdateRefund(ctx, &retry, RefundFailed); err != nil {
+			return nil, err
+		}
+	}
+
+	r := *req
+	r.TradeNo = rec.TradeNo
+	r.Total = rec.Total()
+	if r.NotifyURL == "" && m.cfg.NotifyURL != "" {
+		r.NotifyURL = strings.TrimRight(m.cfg.NotifyURL, "/") + "/" + rec.Provider
+	}
+	res, err := p.Refund(ctx, &r)
+	if err != nil {
+		if !IsTemporary(err) {
+			failed := *existing
+			failed.Status = RefundFailed
+			if _, serr := m.store.UpdateRefund(ctx, &failed, RefundPending); serr != nil {
+				log.Printf("payment: failed to mark refund %s failed: %v", req.OutRefundNo, serr)
+			}
+		}
+		return nil, err
+	}
+	res.OutTradeNo, res.OutRefundNo = rec.OutTradeNo, req.OutRefundNo
+	if err := m.applyRefund(ctx, res); err != nil {
+		return nil, err
+	}
+	return m.store.GetRefund(ctx, req.OutRefundNo)
+}
+
+// Cancel undoes an order for a failed business flow: an unpaid order is closed, a paid one refunded in full
+// The refund uses OutRefundNo "<out_trade_no>-cancel", so cancelling again does not refund twice.
+// Cancel 为失败的业务流程撤销订单：未付款订单被关闭，已付款订单全额退款
+// 退款使用 OutRefundNo "<out_trade_no>-cancel"，因此重复撤销不会重复退款。
+func (m *Manager) Cancel(ctx context.Context, outTradeNo string) error {
+	rec, err := m.Query(ctx, outTradeNo)
+	if errors.Is(err, ErrNotFound) {
+		return nil // never created | 从未创建
+	}
+	if err != nil {
+		return err
+	}
+	switch rec.Status {
+	case StatusPending:
+		return m.Close(ctx, outTradeNo)
+	case StatusPaid:
+		if rec.Refunded >= rec.Amount {
+			return nil
+		}
+		_, err := m.Refund(ctx, &RefundRequest{
+			OutTradeNo:  outTradeNo,
+			OutRefundNo: outTradeNo + "-cancel",
+			Amount:      money.FromMinor(rec.Amount-rec.Refunded, rec.Currency),
+			Reason:      "cancelled",
+		})
+		return err
+	}
+	return nil
+}
+
+// HandleNotify verifies and applies a provider notification, returning the reply the provider expects
+// A failed OnPaid handler fails the reply so the provider notifies again.
+// HandleNotify 校验并应用服务商通知，返回服务商期望的应答
+// OnPaid 处理器失败时应答失败，使服务商再次通知。
+func (m *Manager) HandleNotify(ctx context.Context, provider string, header http.Header, body []byte) Reply {
+	p, err := m.Provider(provider)
+	if err != nil {
+		return Reply{Status: http.StatusNotFound, ContentType: "text/plain", Body: "unknown provider"}
+	}
+	n, err := p.ParseNotify(ctx, header, body)
+	if err != nil {
+		log.Printf("payment: rejected %s notification: %v", provider, err)
+		return p.Ack(err)
+	}
+	switch {
+	case n.Transaction != nil:
+		err = m.applyNotified(ctx, n.Transaction)
+	case n.Refund != nil:
+		err = m.applyRefund(ctx, n.Refund)
+	}
+	if err != nil {
+		log.Printf("payment: failed to apply %s notification: %v", provider, err)
+	}
+	return p.Ack(err)
+}
+
+// applyNotified applies a notified transaction to its order | applyNotified 将通知的交易应用到订单
+func (m *Manager) applyNotified(ctx context.Context, tx *Transaction) error {
+	rec, err := m.store.GetOrder(ctx, tx.OutTradeNo)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		// Not ours, acknowledge so the provider stops | 非本系统订单，确认接收使服务商停止通知
+		log.Printf("payment: notification for unknown order %s", tx.OutTradeNo)
+		return nil
+	}
+	return m.apply(ctx, rec, tx)
+}
+
+// apply moves an order to the state of its provider transaction and runs OnPaid handlers when paid
+// A payment after a local close still counts, the money was taken.
+// apply 将订单推进到服务商交易的状态，并在付款后运行 OnPaid 处理器
+// 本地关闭后到达的付款仍然有效，因为款项已收取。
+func (m *Manager) apply(ctx context.Context, rec *Record, tx *Transaction) error {
+	switch tx.Status {
+	case StatusPaid:
+		if tx.Amount.Minor() != rec.Amount || tx.Amount.Currency != rec.Currency {
+			return fmt.Errorf("%w: order %s is %s, provider reports %s", ErrAmountMismatch, rec.OutTradeNo, rec.Total(), tx.Amount)
+		}
+		paid := *rec
+		paid.Status = StatusPaid
+		if tx.TradeNo != "" {
+			paid.TradeNo = tx.TradeNo
+		}
+		paidAt := time.Now()
+		if tx.PaidAt != nil {
+			paidAt = *tx.PaidAt
+		}
+		paid.PaidAt = &paidAt
+		if _, err := m.store.UpdateOrder(ctx, &paid, StatusPending, StatusClosed); err != nil {
+			return err
+		}
+		// Reload, handlers run until one call marks the order fulfilled | 重新读取，处理器持续运行直到订单被标记为已履约
+		cur, err := m.store.GetOrder(ctx, rec.OutTradeNo)
+		if err != nil {
+			return err
+		}
+		if cur != nil && cur.Status == StatusPaid && !cur.Fulfilled {
+			return m.fulfill(ctx, cur)
+		}
+	case StatusClosed:
+		closed := *rec
+		closed.Status = StatusClosed
+		_, err := m.store.UpdateOrder(ctx, &closed, StatusPending)
+		return err
+	}
+	return nil
+}
+
+// fulfill runs the OnPaid handlers and marks the order fulfilled once all succeed
+// fulfill 运行 OnPaid 处理器，全部成功后将订单标记为已履约
+func (m *Manager) fulfill(ctx context.Context, rec *Record) error {
+	m.mu.RLock()
+	handlers := m.onPaid
+	m.mu.RUnlock()
+	for _, fn := range handlers {
+		if err := fn(ctx, rec); err != nil {
+			return fmt.Errorf("payment: paid handler of order %s: %w", rec.OutTradeNo, err)
+		}
+	}
+	done := *rec
+	done.Fulfilled = true
+	_, err := m.store.UpdateOrder(ctx, &done, StatusPaid)
+	return err
+}
+
+// applyRefund settles a refund reported by the provider
+// Refunds made outside the Manager, e.g. in the provider dashboard, are recorded when their order is known.
+// applyRefund 完成服务商报告的退款
+// 在 Manager 之外发起的退款（例如服务商后台）在订单已知时会被记录。
+func (m *Manager) applyRefund(ctx context.Context, res *Refund) error {
+	outRefundNo := res.OutRefundNo
+	if outRefundNo == "" {
+		outRefundNo = res.RefundNo // made in the dashboard | 在服务商后台发起
+	}
+	rr, err := m.store.GetRefund(ctx, outRefundNo)
+	if err != nil {
+		return err
+	}
+	if rr == nil {
+		if res.OutTradeNo == "" || outRefundNo == "" {
+			return nil
+		}
+		rec, err := m.store.GetOrder(ctx, res.OutTradeNo)
+		if err != nil || rec == nil {
+			return err
+		}
+		rr = &RefundRecord{
+			OutTradeNo:  rec.OutTradeNo,
+			OutRefundNo: outRefundNo,
+			RefundNo:    res.RefundNo,
+			Amount:      res.Amount.Minor(),
+			Currency:    rec.Currency,
+			Status:      RefundPending,
+		}
+		if err := m.store.CreateRefund(ctx, rr); err != nil {
+			return err
+		}
+	}
+
+	settled := *rr
+	if res.RefundNo != "" {
+		settled.RefundNo = res.RefundNo
+	}
+	switch res.Status {
+	case RefundFailed:
+		settled.Status = RefundFailed
+		_, err := m.store.UpdateRefund(ctx, &settled, RefundPending)
+		return err
+	case RefundSucceeded:
+		settled.Status = RefundSucceeded
+		ok, err := m.store.UpdateRefund(ctx, &settled, RefundPending, RefundFailed)
+		if err != nil || !ok {
+			return err
+		}
+	default:
+		if settled.RefundNo != rr.RefundNo {
+			_, err := m.store.UpdateRefund(ctx, &settled, RefundPending)
+			return err
+		}
+		return nil
+	}
+
+	// The refund succeeded just now, count it once | 退款刚刚成功，只计入一次
+	if err := m.store.AddRefunded(ctx, rr.OutTradeNo, rr.Amount); err != nil {
+		return err
+	}
+	rec, err := m.store.GetOrder(ctx, rr.OutTradeNo)
+	if err != nil || rec == nil {
+		return err
+	}
+	if rec.Refunded >= rec.Amount {
+		refunded := *rec
+		refunded.Status = StatusRefunded
+		if _, err := m.store.UpdateOrder(ctx, &refunded, StatusPaid); err != nil {
+			return err
+		}
+		rec.Status = StatusRefunded
+	}
+	m.mu.RLock()
+	handlers := m.onRefund
+	m.mu.RUnlock()
+	for _, fn := range handlers {
+		if err := fn(ctx, rec, &settled); err != nil {
+			log.Printf("payment: refund handler of %s failed: %v", settled.OutRefundNo, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile settles orders last updated before the given time: pending orders are queried at the
+// provider and closed once expired, paid orders whose OnPaid handlers failed run them again.
+// It returns how many orders it looked at; errors of single orders are joined.
+// Reconcile 处理最后更新早于指定时间的订单：待付款订单向服务商查询，过期后关闭；OnPaid 处理器失败的已付款订单再次运行处理器。
+// 返回处理的订单数量；单个订单的错误会被合并返回。
+func (m *Manager) Reconcile(ctx context.Context, before time.Time, limit int) (int, error) {
+	list, err := m.store.Unsettled(ctx, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for i := range list {
+		if err := m.reconcile(ctx, &list[i]); err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", list[i].OutTradeNo, err))
+		}
+	}
+	return len(list), errors.Join(errs...)
+}
+
+// ReconcileJob returns a cron job reconciling orders older than [payment] reconcile_after on the [payment] reconcile spec
+// ReconcileJob 返回按 [payment] reconcile 表达式执行的定时任务，对早于 [payment] reconcile_after 的订单对账
+//
+// Usage | 用法:
+//
+//	cron.Register(payment.Get().ReconcileJob())
+func (m *Manager) ReconcileJob() cron.Job {
+	return cron.Job{
+		Name:    "payment_reconcile",
+		Spec:    m.cfg.Reconcile,
+		Timeout: 10 * time.Minute,
+		Func: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			n, err := m.Reconcile(ctx, time.Now().Add(-m.cfg.ReconcileAfter), reconcileBatch)
+			if err != nil {
+				log.Printf("payment: reconciled %d orders with errors: %v", n, err)
+			}
+		},
+	}
+}
+
+// reconcile settles one order | reconcile 处理单个订单
+func (m *Manager) reconcile(ctx context.Context, rec *Record) error {
+	if rec.Status == StatusPaid {
+		return m.fulfill(ctx, rec)
+	}
+	p, err := m.Provider(rec.Provider)
+	if err != nil {
+		return err
+	}
+	tx, err := p.Query(ctx, rec.OutTradeNo)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		tx = &Transaction{Status: StatusPending}
+	case err != nil:
+		return err
+	}
+	if tx.Status != StatusPending {
+		return m.apply(ctx, rec, tx)
+	}
+
+	expireAt := rec.CreatedAt.Add(unpaidTTL)
+	if rec.ExpireAt != nil {
+		expireAt = *rec.ExpireAt
+	}
+	if time.Now().Before(expireAt) {
+		return nil
+	}
+	if err := p.Close(ctx, rec.OutTradeNo); err != nil {
+		return err
+	}
+	closed := *rec
+	closed.Status = StatusClosed
+	_, err = m.store.UpdateOrder(ctx, &closed, StatusPending)
+	return err
+}
+
+var defaultManager *Manager // Default manager, nil when not configured | 默认管理器，未配置时为 nil
+
+// Init initializes the default manager and registers the compensator of StepCreate
+// If no provider is configured, skip initialization
+// Init 初始化默认管理器并注册 StepCreate 的补偿器
+// 如果未配置任何服务商，跳过初始化
+func Init(cfg Config, store Store) error {
+	if !cfg.Enabled() {
+		log.Println("payment: no provider configured, skip initialization")
+		return nil
+	}
+	m, err := New(cfg, store)
+	if err != nil {
+		return err
+	}
+	defaultManager = m
+	transaction.RegisterCompensator(StepCreate, func(ctx context.Context, data string) error {
+		return m.Cancel(ctx, data)
+	})
+	log.Printf("payment: initialized, providers: %s", strings.Join(slices.Sorted(maps.Keys(m.providers)), ", "))
+	return nil
+}
+
+// Get returns the default manager, nil when not configured
+// Get 返回默认管理器，未配置时为 nil
+func Get() *Manager {
+	return defaultManager
+}
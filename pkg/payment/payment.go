@@ -0,0 +1,233 @@
+// Package payment takes payments through WeChat Pay, Alipay and Stripe behind one interface
+// Orders are created, queried, closed and refunded through a Manager, which records them in a
+// Store so signed provider notifications and periodic reconciliation move every order to its
+// final state exactly once. OnPaid handlers bring the business state along and run again until
+// they succeed, so they must be idempotent.
+// Package payment 通过统一接口使用微信支付、支付宝和 Stripe 收款
+// 订单通过 Manager 创建、查询、关闭和退款，Manager 将订单记录在 Store 中，使服务商的签名通知和定期对账
+// 将每个订单恰好一次地推进到最终状态。OnPaid 处理器负责同步业务状态，失败后会再次运行直到成功，因此必须幂等。
+//
+// Usage | 用法:
+//
+//	payment.Get().OnPaid(func(ctx context.Context, r *payment.Record) error {
+//		return orderService.MarkPaid(ctx, r.OutTradeNo) // idempotent | 幂等
+//	})
+//
+//	checkout, err := payment.Get().Create(ctx, payment.WeChat, &payment.Order{
+//		OutTradeNo: order.No,
+//		Amount:     money.FromMinor(order.TotalFen, "CNY"),
+//		Subject:    "Crab Pro (1 year)",
+//		Scene:      payment.SceneNative,
+//	})
+//	// render checkout.URL as a QR code | 将 checkout.URL 渲染为二维码
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+)
+
+// Built-in providers | 内置服务商
+const (
+	WeChat = "wechat"
+	Alipay = "alipay"
+	Stripe = "stripe"
+)
+
+// Payment scenes, each provider supports a subset | 支付场景，各服务商支持其中一部分
+const (
+	SceneNative = "native" // QR code in Checkout.URL (WeChat, Alipay) | 二维码内容在 Checkout.URL 中（微信、支付宝）
+	SceneJSAPI  = "jsapi"  // WeChat browser and mini programs, needs Order.OpenID | 微信内浏览器和小程序，需要 Order.OpenID
+	SceneApp    = "app"    // Mobile SDK parameters in Checkout.Params (WeChat, Alipay) | 移动端 SDK 参数在 Checkout.Params 中（微信、支付宝）
+	SceneH5     = "h5"     // Mobile browser redirect to Checkout.URL (WeChat, Alipay) | 手机浏览器跳转 Checkout.URL（微信、支付宝）
+	ScenePage   = "page"   // Desktop redirect to Checkout.URL (Alipay, Stripe Checkout) | 电脑网页跳转 Checkout.URL（支付宝、Stripe Checkout）
+)
+
+// Status is the state of an order | Status 为订单状态
+type Status string
+
+// Order states | 订单状态
+const (
+	StatusPending  Status = "pending"  // Waiting for the payer | 等待付款
+	StatusPaid     Status = "paid"     // Paid, possibly partly refunded | 已付款，可能已部分退款
+	StatusClosed   Status = "closed"   // Closed or expired unpaid | 未付款关闭或过期
+	StatusRefunded Status = "refunded" // Fully refunded | 已全额退款
+)
+
+// RefundStatus is the state of a refund | RefundStatus 为退款状态
+type RefundStatus string
+
+// Refund states | 退款状态
+const (
+	RefundPending   RefundStatus = "pending"   // Submitted, not settled yet | 已提交，尚未完成
+	RefundSucceeded RefundStatus = "succeeded" // Money returned | 已退回
+	RefundFailed    RefundStatus = "failed"    // Rejected or closed by the provider | 被服务商拒绝或关闭
+)
+
+var (
+	ErrUnknownProvider    = errors.New("payment: unknown provider")                    // Provider not configured | 服务商未配置
+	ErrUnsupportedScene   = errors.New("payment: scene not supported by the provider") // Scene the provider cannot serve | 服务商不支持该场景
+	ErrNotFound           = errors.New("payment: order not found")                     // Unknown to the store or the provider | 存储或服务商中不存在
+	ErrInvalidOrder       = errors.New("payment: invalid order")                       // Missing number, subject or amount | 缺少订单号、标题或金额
+	ErrInvalidSignature   = errors.New("payment: invalid signature")                   // Notification or response signature check failed | 通知或响应签名校验失败
+	ErrAmountMismatch     = errors.New("payment: amount mismatch")                     // Provider amount differs from the order | 服务商金额与订单不一致
+	ErrAlreadyPaid        = errors.New("payment: order already paid")                  // Creating an order that was paid | 创建已付款的订单
+	ErrNotPaid            = errors.New("payment: order not paid")                      // Refunding an unpaid order | 对未付款订单退款
+	ErrRefundExceedsTotal = errors.New("payment: refund exceeds the amount paid")      // Refunds would pass the paid amount | 退款总额将超过已付金额
+)
+
+// Error is a provider error response
+// Error 表示服务商错误响应
+type Error struct {
+	Provider  string // Provider name | 服务商名称
+	Code      string // Provider error code | 服务商错误码
+	Message   string // Provider error message | 服务商错误信息
+	Temporary bool   // Whether retrying may succeed | 重试是否可能成功
+}
+
+// Error implements error interface
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("payment %s: %s: %s", e.Provider, e.Code, e.Message)
+}
+
+// IsTemporary reports whether a provider call failed transiently: network errors and provider system errors
+// IsTemporary 判断服务商调用是否为暂时性失败：网络错误以及服务商系统错误
+func IsTemporary(err error) bool {
+	var payErr *Error
+	if errors.As(err, &payErr) {
+		return payErr.Temporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Order is a payment to create
+// Order 表示待创建的支付
+type Order struct {
+	OutTradeNo string      // Merchant order number, unique per payment | 商户订单号，每笔支付唯一
+	Amount     money.Money // Amount due | 应付金额
+	Subject    string      // Title shown to the payer | 向付款人展示的标题
+	Scene      string      // See Scene constants, empty uses the provider default | 见 Scene 常量，为空时使用服务商默认场景
+	NotifyURL  string      // Overrides <notify_url>/<provider> | 覆盖 <notify_url>/<provider>
+	ReturnURL  string      // Where page and h5 payments return | 网页和 H5 支付完成后的返回地址
+	OpenID     string      // Payer of jsapi payments | jsapi 支付的付款人
+	ClientIP   string      // Payer IP, required by WeChat h5 | 付款人 IP，微信 H5 支付必填
+	ExpireAt   time.Time   // Closes unpaid at this time, zero uses the provider default | 到期未付款则关闭，零值使用服务商默认值
+}
+
+// Checkout tells the client how to pay
+// Checkout 告诉客户端如何付款
+type Checkout struct {
+	Provider   string            `json:"provider"`
+	OutTradeNo string            `json:"out_trade_no"`
+	TradeNo    string            `json:"trade_no,omitempty"` // Provider payment ID when known at creation | 创建时已知的服务商支付 ID
+	URL        string            `json:"url,omitempty"`      // QR code content or redirect URL | 二维码内容或跳转地址
+	Params     map[string]string `json:"params,omitempty"`   // Client SDK parameters | 客户端 SDK 参数
+}
+
+// Transaction is the state of a payment at the provider
+// Transaction 表示服务商处的支付状态
+type Transaction struct {
+	Provider   string
+	OutTradeNo string
+	TradeNo    string      // Provider payment ID | 服务商支付 ID
+	Status     Status      // pending, paid or closed | pending、paid 或 closed
+	Amount     money.Money // Order amount | 订单金额
+	PaidAt     *time.Time  // Nil when unknown | 未知时为 nil
+}
+
+// RefundRequest asks for money back
+// RefundRequest 表示退款请求
+type RefundRequest struct {
+	OutTradeNo  string      // Order to refund | 要退款的订单
+	OutRefundNo string      // Merchant refund number, retrying with it does not refund twice | 商户退款单号，使用同一单号重试不会重复退款
+	Amount      money.Money // Amount to return | 退款金额
+	Reason      string      // Shown to the payer where supported | 在支持时向付款人展示
+
+	// Filled by the Manager from the order | 由 Manager 根据订单填写
+	TradeNo   string      // Provider payment ID | 服务商支付 ID
+	Total     money.Money // Order amount | 订单金额
+	NotifyURL string      // Refund notification URL | 退款通知地址
+}
+
+// Refund is the state of a refund at the provider
+// Refund 表示服务商处的退款状态
+type Refund struct {
+	Provider    string
+	OutTradeNo  string
+	OutRefundNo string
+	RefundNo    string // Provider refund ID | 服务商退款 ID
+	Status      RefundStatus
+	Amount      money.Money
+}
+
+// Notification is a verified provider notification, with a payment or a refund
+// Other events leave both nil and are acknowledged without effect.
+// Notification 表示已验签的服务商通知，包含支付或退款
+// 其他事件两者均为 nil，确认接收后不做处理。
+type Notification struct {
+	Provider    string
+	Transaction *Transaction
+	Refund      *Refund
+}
+
+// Reply is the answer a provider expects to a notification
+// Reply 是服务商期望的通知应答
+type Reply struct {
+	Status      int
+	ContentType string
+	Body        string
+}
+
+// Provider is one payment provider
+// Provider 表示一个支付服务商
+type Provider interface {
+	Name() string
+	Create(ctx context.Context, order *Order) (*Checkout, error)
+	Query(ctx context.Context, outTradeNo string) (*Transaction, error) // ErrNotFound when the provider does not know it | 服务商不存在该订单时返回 ErrNotFound
+	Close(ctx context.Context, outTradeNo string) error                 // Closing an unknown order succeeds | 关闭不存在的订单视为成功
+	Refund(ctx context.Context, req *RefundRequest) (*Refund, error)
+	// ParseNotify verifies the signature of a notification and decodes it
+	// ParseNotify 校验通知签名并解码
+	ParseNotify(ctx context.Context, header http.Header, body []byte) (*Notification, error)
+	// Ack returns the reply to a notification, err is nil when it was handled
+	// Ack 返回通知的应答，处理成功时 err 为 nil
+	Ack(err error) Reply
+}
+
+// Config represents the [payment] configuration section
+// Config 表示 [payment] 配置段
+type Config struct {
+	NotifyURL      string        `toml:"notify_url"`      // Public URL of the notify endpoint, providers call <notify_url>/<provider> | 通知接口的公网地址，服务商回调 <notify_url>/<provider>
+	NotifyPath     string        `toml:"notify_path"`     // Route of POST {notify_path}/:provider, empty disables | POST {notify_path}/:provider 的路由，为空时禁用
+	Timeout        time.Duration `toml:"timeout"`         // Provider API timeout, default 10s | 服务商接口超时，默认 10 秒
+	Reconcile      string        `toml:"reconcile"`       // Cron spec of the reconciliation job, empty disables | 对账任务的 cron 表达式，为空时禁用
+	ReconcileAfter time.Duration `toml:"reconcile_after"` // Age of orders the job settles, default 10m | 对账任务处理的订单最小时长，默认 10 分钟
+	WeChat         WeChatConfig  `toml:"wechat"`
+	Alipay         AlipayConfig  `toml:"alipay"`
+	Stripe         StripeConfig  `toml:"stripe"`
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.ReconcileAfter <= 0 {
+		c.ReconcileAfter = 10 * time.Minute
+	}
+	return c
+}
+
+// Enabled reports whether any provider is configured
+// Enabled 判断是否配置了任一服务商
+func (c Config) Enabled() bool {
+	return c.WeChat.MchID != "" || c.Alipay.AppID != "" || c.Stripe.SecretKey != ""
+}
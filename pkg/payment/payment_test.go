@@ -0,0 +1,343 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+	"github.com/nuohe369/crab/pkg/transaction"
+)
+
+// fakeProvider keeps transactions in memory and returns f.notify for notifications signed with X-Sign: ok
+type fakeProvider struct {
+	mu      sync.Mutex
+	txs     map[string]*Transaction
+	closed  []string
+	refunds []*RefundRequest
+	notify  *Notification
+	refund  RefundStatus
+}
+
+func newFake() *fakeProvider {
+	return &fakeProvider{txs: make(map[string]*Transaction), refund: RefundPending}
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) Create(_ context.Context, o *Order) (*Checkout, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txs[o.OutTradeNo] = &Transaction{Provider: "fake", OutTradeNo: o.OutTradeNo, TradeNo: "T" + o.OutTradeNo, Status: StatusPending, Amount: o.Amount}
+	return &Checkout{Provider: "fake", OutTradeNo: o.OutTradeNo, URL: "fake://" + o.OutTradeNo + "?notify=" + o.NotifyURL}, nil
+}
+
+func (f *fakeProvider) Query(_ context.Context, no string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, ok := f.txs[no]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *tx
+	return &c, nil
+}
+
+func (f *fakeProvider) Close(_ context.Context, no string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = append(f.closed, no)
+	if tx, ok := f.txs[no]; ok {
+		tx.Status = StatusClosed
+	}
+	return nil
+}
+
+func (f *fakeProvider) Refund(_ context.Context, req *RefundRequest) (*Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds = append(f.refunds, req)
+	return &Refund{Provider: "fake", OutTradeNo: req.OutTradeNo, OutRefundNo: req.OutRefundNo, RefundNo: "R" + req.OutRefundNo, Status: f.refund, Amount: req.Amount}, nil
+}
+
+func (f *fakeProvider) ParseNotify(_ context.Context, header http.Header, _ []byte) (*Notification, error) {
+	if header.Get("X-Sign") != "ok" {
+		return nil, ErrInvalidSignature
+	}
+	return f.notify, nil
+}
+
+func (f *fakeProvider) Ack(err error) Reply {
+	if err != nil {
+		return Reply{Status: http.StatusInternalServerError, Body: "FAIL"}
+	}
+	return Reply{Status: http.StatusOK, Body: "OK"}
+}
+
+// pay marks a transaction paid at the fake provider
+func (f *fakeProvider) pay(no string) *Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx := f.txs[no]
+	tx.Status = StatusPaid
+	c := *tx
+	return &c
+}
+
+var signed = http.Header{"X-Sign": {"ok"}}
+
+func newTestManager(t *testing.T) (*Manager, *fakeProvider) {
+	t.Helper()
+	m, err := New(Config{NotifyURL: "https://api.example.com/payment/notify/"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFake()
+	m.Register(f)
+	return m, f
+}
+
+func testOrder(no string) *Order {
+	return &Order{OutTradeNo: no, Amount: money.FromMinor(1000, "CNY"), Subject: "Pro"}
+}
+
+func TestCreate(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	if _, err := m.Create(ctx, "nope", testOrder("A1")); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unknown provider: %v", err)
+	}
+	if _, err := m.Create(ctx, "fake", &Order{OutTradeNo: "A1", Subject: "x"}); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("zero amount: %v", err)
+	}
+
+	c, err := m.Create(ctx, "fake", testOrder("A1"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if c.URL != "fake://A1?notify=https://api.example.com/payment/notify/fake" {
+		t.Errorf("URL = %s", c.URL)
+	}
+	if _, err := m.Create(ctx, "fake", testOrder("A1")); err != nil {
+		t.Errorf("creating a pending order again: %v", err)
+	}
+	other := testOrder("A1")
+	other.Amount = money.FromMinor(1, "CNY")
+	if _, err := m.Create(ctx, "fake", other); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("changed amount: %v", err)
+	}
+
+	rec, _ := m.Store().GetOrder(ctx, "A1")
+	if rec == nil || rec.Status != StatusPending || rec.Amount != 1000 || rec.Currency != "CNY" {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestHandleNotify(t *testing.T) {
+	m, f := newTestManager(t)
+	ctx := context.Background()
+	var paid []string
+	m.OnPaid(func(_ context.Context, r *Record) error {
+		paid = append(paid, r.OutTradeNo)
+		return nil
+	})
+	m.Create(ctx, "fake", testOrder("A1"))
+
+	if r := m.HandleNotify(ctx, "fake", http.Header{}, nil); r.Status != http.StatusInternalServerError {
+		t.Errorf("unsigned notification: %+v", r)
+	}
+	if r := m.HandleNotify(ctx, "nope", signed, nil); r.Status != http.StatusNotFound {
+		t.Errorf("unknown provider: %+v", r)
+	}
+
+	f.notify = &Notification{Provider: "fake", Transaction: f.pay("A1")}
+	for range 2 {
+		if r := m.HandleNotify(ctx, "fake", signed, nil); r.Status != http.StatusOK {
+			t.Fatalf("reply = %+v", r)
+		}
+	}
+	if len(paid) != 1 {
+		t.Errorf("OnPaid ran %d times, want 1", len(paid))
+	}
+	rec, _ := m.Store().GetOrder(ctx, "A1")
+	if rec.Status != StatusPaid || !rec.Fulfilled || rec.TradeNo != "TA1" || rec.PaidAt == nil {
+		t.Errorf("record = %+v", rec)
+	}
+	if _, err := m.Create(ctx, "fake", testOrder("A1")); !errors.Is(err, ErrAlreadyPaid) {
+		t.Errorf("creating a paid order: %v", err)
+	}
+
+	// Amount mismatch is rejected | 金额不一致被拒绝
+	m.Create(ctx, "fake", testOrder("A2"))
+	tx := f.pay("A2")
+	tx.Amount = money.FromMinor(1, "CNY")
+	f.notify = &Notification{Provider: "fake", Transaction: tx}
+	if r := m.HandleNotify(ctx, "fake", signed, nil); r.Status != http.StatusInternalServerError {
+		t.Errorf("amount mismatch acknowledged: %+v", r)
+	}
+	if rec, _ := m.Store().GetOrder(ctx, "A2"); rec.Status != StatusPending {
+		t.Errorf("status = %s, want pending", rec.Status)
+	}
+}
+
+func TestPaidHandlerRetry(t *testing.T) {
+	m, f := newTestManager(t)
+	ctx := context.Background()
+	calls := 0
+	m.OnPaid(func(context.Context, *Record) error {
+		calls++
+		if calls == 1 {
+			return errors.New("inventory down")
+		}
+		return nil
+	})
+	m.Create(ctx, "fake", testOrder("A1"))
+	f.notify = &Notification{Provider: "fake", Transaction: f.pay("A1")}
+
+	if r := m.HandleNotify(ctx, "fake", signed, nil); r.Status != http.StatusInternalServerError {
+		t.Errorf("failed handler acknowledged: %+v", r)
+	}
+	rec, _ := m.Store().GetOrder(ctx, "A1")
+	if rec.Status != StatusPaid || rec.Fulfilled {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	n, err := m.Reconcile(ctx, time.Now().Add(time.Second), 10)
+	if err != nil || n != 1 {
+		t.Fatalf("Reconcile = %d, %v", n, err)
+	}
+	if rec, _ := m.Store().GetOrder(ctx, "A1"); !rec.Fulfilled || calls != 2 {
+		t.Errorf("fulfilled = %v after %d calls", rec.Fulfilled, calls)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	m, f := newTestManager(t)
+	ctx := context.Background()
+
+	m.Create(ctx, "fake", testOrder("PAID"))
+	f.pay("PAID")
+	expired := testOrder("EXPIRED")
+	expired.ExpireAt = time.Now().Add(-time.Minute)
+	m.Create(ctx, "fake", expired)
+	m.Create(ctx, "fake", testOrder("WAITING"))
+
+	n, err := m.Reconcile(ctx, time.Now().Add(time.Second), 0)
+	if err != nil || n != 3 {
+		t.Fatalf("Reconcile = %d, %v", n, err)
+	}
+	for no, want := range map[string]Status{"PAID": StatusPaid, "EXPIRED": StatusClosed, "WAITING": StatusPending} {
+		if rec, _ := m.Store().GetOrder(ctx, no); rec.Status != want {
+			t.Errorf("%s status = %s, want %s", no, rec.Status, want)
+		}
+	}
+	if len(f.closed) != 1 || f.closed[0] != "EXPIRED" {
+		t.Errorf("closed = %v", f.closed)
+	}
+	if n, _ := m.Reconcile(ctx, time.Now().Add(-time.Hour), 0); n != 0 {
+		t.Errorf("recent orders reconciled: %d", n)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	m, f := newTestManager(t)
+	ctx := context.Background()
+	var refunded []string
+	m.OnRefund(func(_ context.Context, _ *Record, r *RefundRecord) error {
+		refunded = append(refunded, r.OutRefundNo)
+		return nil
+	})
+	m.Create(ctx, "fake", testOrder("A1"))
+
+	req := &RefundRequest{OutTradeNo: "A1", OutRefundNo: "R1", Amount: money.FromMinor(600, "CNY")}
+	if _, err := m.Refund(ctx, req); !errors.Is(err, ErrNotPaid) {
+		t.Errorf("refunding unpaid order: %v", err)
+	}
+	if _, err := m.Query(ctx, "A1"); err != nil {
+		t.Fatal(err)
+	}
+	f.pay("A1")
+	if rec, _ := m.Query(ctx, "A1"); rec.Status != StatusPaid {
+		t.Fatalf("Query status = %s", rec.Status)
+	}
+
+	rr, err := m.Refund(ctx, req)
+	if err != nil || rr.Status != RefundPending {
+		t.Fatalf("Refund = %+v, %v", rr, err)
+	}
+	if f.refunds[0].TradeNo != "TA1" || f.refunds[0].Total.Minor() != 1000 {
+		t.Errorf("request = %+v", f.refunds[0])
+	}
+	// The pending refund counts against the total | 处理中的退款计入总额
+	if _, err := m.Refund(ctx, &RefundRequest{OutTradeNo: "A1", OutRefundNo: "R2", Amount: money.FromMinor(500, "CNY")}); !errors.Is(err, ErrRefundExceedsTotal) {
+		t.Errorf("over-refund: %v", err)
+	}
+
+	f.notify = &Notification{Provider: "fake", Refund: &Refund{OutTradeNo: "A1", OutRefundNo: "R1", RefundNo: "RR1", Status: RefundSucceeded, Amount: req.Amount}}
+	for range 2 {
+		if r := m.HandleNotify(ctx, "fake", signed, nil); r.Status != http.StatusOK {
+			t.Fatalf("reply = %+v", r)
+		}
+	}
+	rec, _ := m.Store().GetOrder(ctx, "A1")
+	if rec.Refunded != 600 || rec.Status != StatusPaid || len(refunded) != 1 {
+		t.Errorf("after refund: %+v, handlers %v", rec, refunded)
+	}
+
+	// A synchronous refund of the rest settles the order | 同步退还剩余金额后订单完成退款
+	f.refund = RefundSucceeded
+	rr, err = m.Refund(ctx, &RefundRequest{OutTradeNo: "A1", OutRefundNo: "R2", Amount: money.FromMinor(400, "CNY")})
+	if err != nil || rr.Status != RefundSucceeded || rr.RefundNo != "RR2" {
+		t.Fatalf("Refund = %+v, %v", rr, err)
+	}
+	if rec, _ := m.Store().GetOrder(ctx, "A1"); rec.Refunded != 1000 || rec.Status != StatusRefunded {
+		t.Errorf("after full refund: %+v", rec)
+	}
+	if rr, err := m.Refund(ctx, &RefundRequest{OutTradeNo: "A1", OutRefundNo: "R2", Amount: money.FromMinor(400, "CNY")}); err != nil || rr.Status != RefundSucceeded {
+		t.Errorf("retrying a refund: %+v, %v", rr, err)
+	}
+	if len(f.refunds) != 2 {
+		t.Errorf("provider refunds = %d, want 2", len(f.refunds))
+	}
+}
+
+func TestCreateStep(t *testing.T) {
+	m, f := newTestManager(t)
+	ctx := context.Background()
+
+	// Unpaid orders are closed | 未付款订单被关闭
+	var checkout Checkout
+	err := transaction.NewSaga().
+		AddStep(m.CreateStep("fake", testOrder("S1"), &checkout)).
+		AddStep(transaction.SagaStep{Name: "reserve", Execute: func(context.Context) error { return errors.New("out of stock") }}).
+		Execute(ctx)
+	if err == nil {
+		t.Fatal("expected saga to fail")
+	}
+	if checkout.OutTradeNo != "S1" {
+		t.Errorf("checkout = %+v", checkout)
+	}
+	if rec, _ := m.Store().GetOrder(ctx, "S1"); rec.Status != StatusClosed {
+		t.Errorf("status = %s, want closed", rec.Status)
+	}
+
+	// Orders paid in the meantime are refunded | 期间已付款的订单被退款
+	step := m.CreateStep("fake", testOrder("S2"), nil)
+	if err := step.Execute(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.pay("S2")
+	if err := step.Compensate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.refunds) != 1 || f.refunds[0].OutRefundNo != "S2-cancel" || f.refunds[0].Amount.Minor() != 1000 {
+		t.Errorf("refunds = %+v", f.refunds)
+	}
+	if err := m.Cancel(ctx, "NEVER"); err != nil {
+		t.Errorf("cancelling an unknown order: %v", err)
+	}
+}
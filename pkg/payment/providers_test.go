@@ -0,0 +1,307 @@
+package payment
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+)
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func privatePEM(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+// publicBase64 returns the bare base64 DER form the Alipay key tool prints
+func publicBase64(key *rsa.PrivateKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func publicPEM(key *rsa.PrivateKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestKeys(t *testing.T) {
+	key := generateKey(t)
+	if _, err := parsePrivateKey(privatePEM(key)); err != nil {
+		t.Errorf("PKCS#1 PEM: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	if _, err := parsePrivateKey(base64.StdEncoding.EncodeToString(der)); err != nil {
+		t.Errorf("PKCS#8 base64: %v", err)
+	}
+	pub, err := parsePublicKey(publicBase64(key))
+	if err != nil {
+		t.Fatalf("public base64: %v", err)
+	}
+	sig, _ := signSHA256(key, "hello")
+	if err := verifySHA256(pub, "hello", sig); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	if err := verifySHA256(pub, "hellO", sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered message: %v", err)
+	}
+	if _, err := parsePrivateKey("not a key!"); err == nil {
+		t.Error("expected error for garbage")
+	}
+}
+
+func TestAlipay(t *testing.T) {
+	appKey, alipayKey := generateKey(t), generateKey(t)
+	appPub, _ := parsePublicKey(publicPEM(appKey))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		params := map[string]string{}
+		for k := range r.PostForm {
+			if k != "sign" {
+				params[k] = r.PostForm.Get(k)
+			}
+		}
+		if err := verifySHA256(appPub, alipayContent(params), r.PostForm.Get("sign")); err != nil {
+			t.Errorf("request signature: %v", err)
+		}
+		var biz map[string]string
+		json.Unmarshal([]byte(params["biz_content"]), &biz)
+
+		node := `{"code":"10000","msg":"Success","out_trade_no":"A1","trade_no":"2024050122001","trade_status":"TRADE_SUCCESS","total_amount":"10.00","send_pay_date":"2024-05-01 12:00:00"}`
+		if biz["out_trade_no"] != "A1" {
+			node = `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_NOT_EXIST","sub_msg":"交易不存在"}`
+		}
+		sign, _ := signSHA256(alipayKey, node)
+		name := strings.ReplaceAll(params["method"], ".", "_") + "_response"
+		w.Write([]byte(`{"` + name + `":` + node + `,"sign":"` + sign + `"}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewAlipay(AlipayConfig{AppID: "2021", PrivateKey: privatePEM(appKey), PublicKey: publicBase64(alipayKey), Endpoint: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c, err := p.Create(ctx, &Order{OutTradeNo: "A1", Amount: money.FromMinor(1000, "CNY"), Subject: "Pro", NotifyURL: "https://example.com/n"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	u, _ := url.Parse(c.URL)
+	if q := u.Query(); q.Get("method") != "alipay.trade.page.pay" || q.Get("notify_url") != "https://example.com/n" || q.Get("sign") == "" {
+		t.Errorf("URL = %s", c.URL)
+	}
+	if _, err := p.Create(ctx, &Order{OutTradeNo: "A1", Amount: money.FromMinor(1000, "USD"), Subject: "Pro"}); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("USD order: %v", err)
+	}
+
+	tx, err := p.Query(ctx, "A1")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if tx.Status != StatusPaid || tx.Amount.Minor() != 1000 || tx.TradeNo != "2024050122001" || tx.PaidAt == nil {
+		t.Errorf("transaction = %+v", tx)
+	}
+	if _, err := p.Query(ctx, "A2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: %v", err)
+	}
+
+	// Notifications are signed forms | 通知为签名的表单
+	params := map[string]string{
+		"app_id": "2021", "out_trade_no": "A1", "trade_no": "2024050122001", "trade_status": "TRADE_SUCCESS",
+		"total_amount": "10.00", "gmt_payment": "2024-05-01 12:00:00", "notify_id": "n1",
+	}
+	sign, _ := signSHA256(alipayKey, alipayContent(params))
+	form := url.Values{"sign": {sign}, "sign_type": {"RSA2"}}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	n, err := p.ParseNotify(ctx, nil, []byte(form.Encode()))
+	if err != nil {
+		t.Fatalf("ParseNotify failed: %v", err)
+	}
+	if n.Transaction == nil || n.Transaction.Status != StatusPaid || n.Transaction.OutTradeNo != "A1" {
+		t.Errorf("notification = %+v", n.Transaction)
+	}
+	form.Set("total_amount", "0.01")
+	if _, err := p.ParseNotify(ctx, nil, []byte(form.Encode())); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered notification: %v", err)
+	}
+	if r := p.Ack(nil); r.Body != "success" {
+		t.Errorf("Ack = %+v", r)
+	}
+}
+
+// wechatSign sets the platform signature headers of a response or notification
+func wechatSign(t *testing.T, key *rsa.PrivateKey, h http.Header, body []byte) {
+	t.Helper()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig, err := signSHA256(key, ts+"\nnonce\n"+string(body)+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Set("Wechatpay-Serial", "PUB_KEY_ID_1")
+	h.Set("Wechatpay-Timestamp", ts)
+	h.Set("Wechatpay-Nonce", "nonce")
+	h.Set("Wechatpay-Signature", sig)
+}
+
+func TestWeChat(t *testing.T) {
+	merchantKey, platformKey := generateKey(t), generateKey(t)
+	const apiV3Key = "0123456789abcdef0123456789abcdef"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), `WECHATPAY2-SHA256-RSA2048 mchid="1900"`) {
+			t.Errorf("Authorization = %s", r.Header.Get("Authorization"))
+		}
+		var body []byte
+		switch r.URL.Path {
+		case "/v3/pay/transactions/native":
+			body = []byte(`{"code_url":"weixin://wxpay/bizpayurl?pr=abc"}`)
+		case "/v3/pay/transactions/out-trade-no/A1":
+			body = []byte(`{"out_trade_no":"A1","transaction_id":"4200","trade_state":"SUCCESS","success_time":"2024-05-01T12:00:00+08:00","amount":{"total":1000,"currency":"CNY"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"ORDER_NOT_EXIST","message":"订单不存在"}`))
+			return
+		}
+		wechatSign(t, platformKey, w.Header(), body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	p, err := NewWeChat(WeChatConfig{
+		AppID: "wx1", MchID: "1900", SerialNo: "S1", PrivateKey: privatePEM(merchantKey), APIv3Key: apiV3Key,
+		PublicKeyID: "PUB_KEY_ID_1", PublicKey: publicPEM(platformKey), Endpoint: srv.URL,
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c, err := p.Create(ctx, &Order{OutTradeNo: "A1", Amount: money.FromMinor(1000, "CNY"), Subject: "Pro"})
+	if err != nil || c.URL != "weixin://wxpay/bizpayurl?pr=abc" {
+		t.Fatalf("Create = %+v, %v", c, err)
+	}
+	tx, err := p.Query(ctx, "A1")
+	if err != nil || tx.Status != StatusPaid || tx.Amount.Minor() != 1000 || tx.TradeNo != "4200" {
+		t.Fatalf("Query = %+v, %v", tx, err)
+	}
+	if _, err := p.Query(ctx, "A2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: %v", err)
+	}
+	if err := p.Close(ctx, "A2"); err != nil {
+		t.Errorf("closing an unknown order: %v", err)
+	}
+
+	// Notifications carry an AES-GCM encrypted resource | 通知携带 AES-GCM 加密的资源
+	block, _ := aes.NewCipher([]byte(apiV3Key))
+	gcm, _ := cipher.NewGCM(block)
+	plain := `{"out_trade_no":"A1","out_refund_no":"R1","refund_id":"5000","refund_status":"SUCCESS","amount":{"total":1000,"refund":600}}`
+	sealed := gcm.Seal(nil, []byte("0123456789ab"), []byte(plain), []byte("refund"))
+	body, _ := json.Marshal(map[string]any{
+		"event_type": "REFUND.SUCCESS",
+		"resource": map[string]string{
+			"algorithm": "AEAD_AES_256_GCM", "ciphertext": base64.StdEncoding.EncodeToString(sealed),
+			"associated_data": "refund", "nonce": "0123456789ab",
+		},
+	})
+	header := http.Header{}
+	wechatSign(t, platformKey, header, body)
+	n, err := p.ParseNotify(ctx, header, body)
+	if err != nil {
+		t.Fatalf("ParseNotify failed: %v", err)
+	}
+	if n.Refund == nil || n.Refund.Status != RefundSucceeded || n.Refund.OutRefundNo != "R1" || n.Refund.Amount.Minor() != 600 {
+		t.Errorf("refund = %+v", n.Refund)
+	}
+	header.Set("Wechatpay-Nonce", "other")
+	if _, err := p.ParseNotify(ctx, header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered notification: %v", err)
+	}
+	if r := p.Ack(errors.New("x")); r.Status != http.StatusInternalServerError {
+		t.Errorf("Ack = %+v", r)
+	}
+}
+
+func TestStripe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test" {
+			t.Errorf("Authorization = %s", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v1/payment_intents":
+			r.ParseForm()
+			if r.Header.Get("Idempotency-Key") != "create-A1" || r.PostForm.Get("amount") != "1250" || r.PostForm.Get("currency") != "usd" {
+				t.Errorf("create request %v %v", r.Header, r.PostForm)
+			}
+			w.Write([]byte(`{"id":"pi_1","status":"requires_payment_method","amount":1250,"currency":"usd","client_secret":"pi_1_secret","metadata":{"out_trade_no":"A1"}}`))
+		case "/v1/payment_intents/search":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"resource_missing","message":"No such object"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewStripe(StripeConfig{SecretKey: "sk_test", WebhookSecret: "whsec_test", Endpoint: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c, err := p.Create(ctx, &Order{OutTradeNo: "A1", Amount: money.FromMinor(1250, "USD"), Subject: "Pro"})
+	if err != nil || c.TradeNo != "pi_1" || c.Params["client_secret"] != "pi_1_secret" {
+		t.Fatalf("Create = %+v, %v", c, err)
+	}
+	if _, err := p.Create(ctx, &Order{OutTradeNo: "A1", Amount: money.FromMinor(1250, "USD"), Subject: "Pro", Scene: SceneNative}); !errors.Is(err, ErrUnsupportedScene) {
+		t.Errorf("native scene: %v", err)
+	}
+	if _, err := p.Query(ctx, "A2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: %v", err)
+	}
+
+	body := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","status":"succeeded","amount":1250,"currency":"usd","metadata":{"out_trade_no":"A1"}}}}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(ts + "." + string(body)))
+	header := http.Header{"Stripe-Signature": {"t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))}}
+	n, err := p.ParseNotify(ctx, header, body)
+	if err != nil {
+		t.Fatalf("ParseNotify failed: %v", err)
+	}
+	if n.Transaction == nil || n.Transaction.Status != StatusPaid || n.Transaction.Amount.Minor() != 1250 || n.Transaction.Amount.Currency != "USD" {
+		t.Errorf("transaction = %+v", n.Transaction)
+	}
+	if _, err := p.ParseNotify(ctx, http.Header{"Stripe-Signature": {"t=" + ts + ",v1=00"}}, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("bad signature: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/nuohe369/crab/pkg/transaction"
+)
+
+// Saga step names, StepCreate has a compensator registered by Init
+// Saga 步骤名称，StepCreate 的补偿器由 Init 注册
+const (
+	StepCreate = "payment.create"
+	StepRefund = "payment.refund"
+)
+
+// CreateStep returns a saga step that creates the order, storing the result in checkout when not nil
+// Its compensation cancels the order: closed when unpaid, refunded in full when the payer was faster.
+// CreateStep 返回创建订单的 saga 步骤，checkout 不为 nil 时保存创建结果
+// 其补偿操作撤销订单：未付款时关闭，付款人已付款时全额退款。
+func (m *Manager) CreateStep(provider string, order *Order, checkout *Checkout) transaction.SagaStep {
+	return transaction.SagaStep{
+		Name: StepCreate,
+		Data: order.OutTradeNo,
+		Execute: func(ctx context.Context) error {
+			c, err := m.Create(ctx, provider, order)
+			if err != nil {
+				return err
+			}
+			if checkout != nil {
+				*checkout = *c
+			}
+			return nil
+		},
+		Compensate: func(ctx context.Context) error {
+			return m.Cancel(ctx, order.OutTradeNo)
+		},
+	}
+}
+
+// RefundStep returns a saga step that refunds an order; money returned cannot be taken back, so
+// it has no compensation and belongs last in a saga
+// RefundStep 返回对订单退款的 saga 步骤；退回的款项无法收回，因此没有补偿操作，应放在 saga 的最后
+func (m *Manager) RefundStep(req *RefundRequest) transaction.SagaStep {
+	return transaction.SagaStep{
+		Name: StepRefund,
+		Data: req.OutRefundNo,
+		Execute: func(ctx context.Context) error {
+			_, err := m.Refund(ctx, req)
+			return err
+		},
+	}
+}
@@ -0,0 +1,294 @@
+package payment
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+	"xorm.io/xorm"
+)
+
+// Record is an order as the Manager tracks it
+// Record 表示 Manager 跟踪的订单
+type Record struct {
+	ID         int64      `json:"id" xorm:"pk autoincr 'id'"`
+	Provider   string     `json:"provider" xorm:"varchar(16) notnull 'provider'"`                // Provider name | 服务商名称
+	OutTradeNo string     `json:"out_trade_no" xorm:"varchar(64) notnull unique 'out_trade_no'"` // Merchant order number | 商户订单号
+	TradeNo    string     `json:"trade_no" xorm:"varchar(64) index 'trade_no'"`                  // Provider payment ID | 服务商支付 ID
+	Subject    string     `json:"subject" xorm:"varchar(255) 'subject'"`                         // Title shown to the payer | 向付款人展示的标题
+	Amount     int64      `json:"amount" xorm:"notnull 'amount'"`                                // Amount in the minor unit | 以最小单位计的金额
+	Refunded   int64      `json:"refunded" xorm:"notnull default 0 'refunded'"`                  // Refunded amount in the minor unit | 以最小单位计的已退款金额
+	Currency   string     `json:"currency" xorm:"varchar(3) notnull 'currency'"`                 // ISO 4217 code | ISO 4217 货币代码
+	Status     Status     `json:"status" xorm:"varchar(16) notnull index 'status'"`              // pending, paid, closed or refunded | 订单状态
+	Fulfilled  bool       `json:"fulfilled" xorm:"notnull default false 'fulfilled'"`            // OnPaid handlers succeeded | OnPaid 处理器已成功
+	ExpireAt   *time.Time `json:"expire_at,omitempty" xorm:"'expire_at'"`                        // Unpaid orders close at this time | 未付款订单在此时间关闭
+	PaidAt     *time.Time `json:"paid_at,omitempty" xorm:"'paid_at'"`                            // Payment time | 付款时间
+	CreatedAt  time.Time  `json:"created_at" xorm:"created 'created_at'"`                        // Creation time | 创建时间
+	UpdatedAt  time.Time  `json:"updated_at" xorm:"updated index 'updated_at'"`                  // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (r *Record) TableName() string {
+	return "payment_order"
+}
+
+// Total returns the order amount | Total 返回订单金额
+func (r *Record) Total() money.Money {
+	return money.FromMinor(r.Amount, r.Currency)
+}
+
+// RefundRecord is a refund as the Manager tracks it
+// RefundRecord 表示 Manager 跟踪的退款
+type RefundRecord struct {
+	ID          int64        `json:"id" xorm:"pk autoincr 'id'"`
+	OutTradeNo  string       `json:"out_trade_no" xorm:"varchar(64) notnull index 'out_trade_no'"`    // Refunded order | 被退款的订单
+	OutRefundNo string       `json:"out_refund_no" xorm:"varchar(64) notnull unique 'out_refund_no'"` // Merchant refund number | 商户退款单号
+	RefundNo    string       `json:"refund_no" xorm:"varchar(64) 'refund_no'"`                        // Provider refund ID | 服务商退款 ID
+	Amount      int64        `json:"amount" xorm:"notnull 'amount'"`                                  // Amount in the minor unit | 以最小单位计的金额
+	Currency    string       `json:"currency" xorm:"varchar(3) notnull 'currency'"`                   // ISO 4217 code | ISO 4217 货币代码
+	Reason      string       `json:"reason" xorm:"varchar(255) 'reason'"`                             // Refund reason | 退款原因
+	Status      RefundStatus `json:"status" xorm:"varchar(16) notnull index 'status'"`                // pending, succeeded or failed | 退款状态
+	CreatedAt   time.Time    `json:"created_at" xorm:"created 'created_at'"`                          // Creation time | 创建时间
+	UpdatedAt   time.Time    `json:"updated_at" xorm:"updated 'updated_at'"`                          // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (r *RefundRecord) TableName() string {
+	return "payment_refund"
+}
+
+// Models returns the tables of the database store, for migration
+// Models 返回数据库存储的表，用于迁移
+func Models() []any {
+	return []any{&Record{}, &RefundRecord{}}
+}
+
+// Store keeps orders and refunds
+// Updates are conditional on the current status so concurrent notifications, queries and
+// reconciliation apply each transition once.
+// Store 保存订单和退款
+// 更新以当前状态为条件，使并发的通知、查询和对账只应用每个状态变更一次。
+type Store interface {
+	CreateOrder(ctx context.Context, r *Record) error
+	GetOrder(ctx context.Context, outTradeNo string) (*Record, error) // nil if missing | 不存在时返回 nil
+	// UpdateOrder writes the status, trade number, fulfilled flag and payment time of r when the
+	// stored status is one of from, reporting whether it did
+	// UpdateOrder 在存储的状态属于 from 时写入 r 的状态、支付 ID、已履约标志和付款时间，并报告是否已写入
+	UpdateOrder(ctx context.Context, r *Record, from ...Status) (bool, error)
+	AddRefunded(ctx context.Context, outTradeNo string, amount int64) error
+	// Unsettled returns pending orders and paid orders not fulfilled, last updated before the time
+	// Unsettled 返回最后更新早于指定时间的待付款订单和未履约的已付款订单
+	Unsettled(ctx context.Context, before time.Time, limit int) ([]Record, error)
+
+	CreateRefund(ctx context.Context, r *RefundRecord) error
+	GetRefund(ctx context.Context, outRefundNo string) (*RefundRecord, error) // nil if missing | 不存在时返回 nil
+	// UpdateRefund writes the status and refund number of r when the stored status is one of from
+	// UpdateRefund 在存储的状态属于 from 时写入 r 的状态和退款 ID
+	UpdateRefund(ctx context.Context, r *RefundRecord, from ...RefundStatus) (bool, error)
+	Refunds(ctx context.Context, outTradeNo string) ([]RefundRecord, error)
+}
+
+// memoryStore keeps orders in process, for tests and single instances
+// memoryStore 在进程内保存订单，用于测试和单实例部署
+type memoryStore struct {
+	mu      sync.Mutex
+	orders  map[string]*Record
+	refunds map[string]*RefundRecord
+	nextID  int64
+}
+
+// NewMemoryStore creates an in-process store
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() Store {
+	return &memoryStore{orders: make(map[string]*Record), refunds: make(map[string]*RefundRecord)}
+}
+
+func (s *memoryStore) CreateOrder(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	r.ID = s.nextID
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	c := *r
+	s.orders[r.OutTradeNo] = &c
+	return nil
+}
+
+func (s *memoryStore) GetOrder(_ context.Context, outTradeNo string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.orders[outTradeNo]
+	if !ok {
+		return nil, nil
+	}
+	c := *r
+	return &c, nil
+}
+
+func (s *memoryStore) UpdateOrder(_ context.Context, r *Record, from ...Status) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.orders[r.OutTradeNo]
+	if !ok || !slices.Contains(from, cur.Status) {
+		return false, nil
+	}
+	cur.Status, cur.TradeNo, cur.Fulfilled, cur.PaidAt = r.Status, r.TradeNo, r.Fulfilled, r.PaidAt
+	cur.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *memoryStore) AddRefunded(_ context.Context, outTradeNo string, amount int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.orders[outTradeNo]; ok {
+		cur.Refunded += amount
+		cur.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *memoryStore) Unsettled(_ context.Context, before time.Time, limit int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Record
+	for _, r := range s.orders {
+		if r.UpdatedAt.Before(before) && (r.Status == StatusPending || (r.Status == StatusPaid && !r.Fulfilled)) {
+			out = append(out, *r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) CreateRefund(_ context.Context, r *RefundRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	r.ID = s.nextID
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	c := *r
+	s.refunds[r.OutRefundNo] = &c
+	return nil
+}
+
+func (s *memoryStore) GetRefund(_ context.Context, outRefundNo string) (*RefundRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.refunds[outRefundNo]
+	if !ok {
+		return nil, nil
+	}
+	c := *r
+	return &c, nil
+}
+
+func (s *memoryStore) UpdateRefund(_ context.Context, r *RefundRecord, from ...RefundStatus) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.refunds[r.OutRefundNo]
+	if !ok || !slices.Contains(from, cur.Status) {
+		return false, nil
+	}
+	cur.Status, cur.RefundNo = r.Status, r.RefundNo
+	cur.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (s *memoryStore) Refunds(_ context.Context, outTradeNo string) ([]RefundRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []RefundRecord
+	for _, r := range s.refunds {
+		if r.OutTradeNo == outTradeNo {
+			out = append(out, *r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// dbStore keeps orders and refunds in the payment_order and payment_refund tables
+// dbStore 将订单和退款保存在 payment_order 和 payment_refund 表中
+type dbStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBStore creates a store backed by the database
+// NewDBStore 创建基于数据库的存储
+func NewDBStore(engine *xorm.Engine) Store {
+	return &dbStore{engine: engine}
+}
+
+func (s *dbStore) CreateOrder(ctx context.Context, r *Record) error {
+	_, err := s.engine.Context(ctx).Insert(r)
+	return err
+}
+
+func (s *dbStore) GetOrder(ctx context.Context, outTradeNo string) (*Record, error) {
+	var r Record
+	has, err := s.engine.Context(ctx).Where("out_trade_no = ?", outTradeNo).Get(&r)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *dbStore) UpdateOrder(ctx context.Context, r *Record, from ...Status) (bool, error) {
+	n, err := s.engine.Context(ctx).Where("out_trade_no = ?", r.OutTradeNo).In("status", from).
+		Cols("status", "trade_no", "fulfilled", "paid_at").Update(r)
+	return n > 0, err
+}
+
+func (s *dbStore) AddRefunded(ctx context.Context, outTradeNo string, amount int64) error {
+	_, err := s.engine.Context(ctx).Where("out_trade_no = ?", outTradeNo).Incr("refunded", amount).Update(&Record{})
+	return err
+}
+
+func (s *dbStore) Unsettled(ctx context.Context, before time.Time, limit int) ([]Record, error) {
+	var list []Record
+	q := s.engine.Context(ctx).
+		Where("updated_at < ?", before).
+		And("(status = ? OR (status = ? AND fulfilled = ?))", StatusPending, StatusPaid, false).
+		Asc("id")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&list)
+	return list, err
+}
+
+func (s *dbStore) CreateRefund(ctx context.Context, r *RefundRecord) error {
+	_, err := s.engine.Context(ctx).Insert(r)
+	return err
+}
+
+func (s *dbStore) GetRefund(ctx context.Context, outRefundNo string) (*RefundRecord, error) {
+	var r RefundRecord
+	has, err := s.engine.Context(ctx).Where("out_refund_no = ?", outRefundNo).Get(&r)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *dbStore) UpdateRefund(ctx context.Context, r *RefundRecord, from ...RefundStatus) (bool, error) {
+	n, err := s.engine.Context(ctx).Where("out_refund_no = ?", r.OutRefundNo).In("status", from).
+		Cols("status", "refund_no").Update(r)
+	return n > 0, err
+}
+
+func (s *dbStore) Refunds(ctx context.Context, outTradeNo string) ([]RefundRecord, error) {
+	var list []RefundRecord
+	err := s.engine.Context(ctx).Where("out_trade_no = ?", outTradeNo).Asc("id").Find(&list)
+	return list, err
+}
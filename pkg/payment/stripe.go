@@ -0,0 +1,320 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/money"
+	"github.com/nuohe369/crab/pkg/webhook"
+)
+
+// StripeConfig configures Stripe PaymentIntents and Checkout
+// StripeConfig 配置 Stripe PaymentIntents 和 Checkout
+type StripeConfig struct {
+	SecretKey     string `toml:"secret_key"`     // sk_live_... or sk_test_..., empty disables Stripe | 为空时禁用 Stripe
+	WebhookSecret string `toml:"webhook_secret"` // whsec_... of the endpoint <notify_url>/stripe | 端点 <notify_url>/stripe 的签名密钥
+	Endpoint      string `toml:"endpoint"`       // API base URL override | API 基础地址覆盖
+}
+
+// stripeProvider calls the Stripe API. Orders are PaymentIntents tagged with metadata[out_trade_no],
+// page orders go through a Checkout Session whose PaymentIntent carries the same tag.
+// stripeProvider 调用 Stripe API。订单为带 metadata[out_trade_no] 标记的 PaymentIntent，
+// page 场景的订单通过 Checkout Session 创建，其 PaymentIntent 带有相同标记。
+type stripeProvider struct {
+	cfg      StripeConfig
+	http     *http.Client
+	verifier webhook.Verifier
+}
+
+// NewStripe creates the Stripe provider
+// NewStripe 创建 Stripe 服务商
+func NewStripe(cfg StripeConfig, client *http.Client) (Provider, error) {
+	if cfg.WebhookSecret == "" {
+		return nil, errors.New("payment stripe: webhook_secret is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.stripe.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &stripeProvider{cfg: cfg, http: client, verifier: webhook.Stripe(cfg.WebhookSecret)}, nil
+}
+
+func (p *stripeProvider) Name() string { return Stripe }
+
+// stripeIntent is a PaymentIntent | stripeIntent 表示 PaymentIntent
+type stripeIntent struct {
+	ID           string            `json:"id"`
+	Status       string            `json:"status"`
+	Amount       int64             `json:"amount"`
+	Currency     string            `json:"currency"`
+	ClientSecret string            `json:"client_secret"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+func (i *stripeIntent) transaction() *Transaction {
+	tx := &Transaction{
+		Provider:   Stripe,
+		OutTradeNo: i.Metadata["out_trade_no"],
+		TradeNo:    i.ID,
+		Amount:     money.FromMinor(i.Amount, i.Currency),
+	}
+	switch i.Status {
+	case "succeeded":
+		tx.Status = StatusPaid
+	case "canceled":
+		tx.Status = StatusClosed
+	default: // requires_payment_method, requires_action, processing... | 等待付款方式、需要验证、处理中等
+		tx.Status = StatusPending
+	}
+	return tx
+}
+
+func (p *stripeProvider) Create(ctx context.Context, order *Order) (*Checkout, error) {
+	currency := strings.ToLower(order.Amount.Currency)
+	amount := strconv.FormatInt(order.Amount.Minor(), 10)
+	checkout := &Checkout{Provider: Stripe, OutTradeNo: order.OutTradeNo}
+
+	switch order.Scene {
+	case ScenePage:
+		form := url.Values{
+			"mode":                                   {"payment"},
+			"success_url":                            {order.ReturnURL},
+			"cancel_url":                             {order.ReturnURL},
+			"client_reference_id":                    {order.OutTradeNo},
+			"line_items[0][quantity]":                {"1"},
+			"line_items[0][price_data][currency]":    {currency},
+			"line_items[0][price_data][unit_amount]": {amount},
+			"line_items[0][price_data][product_data][name]": {order.Subject},
+			"metadata[out_trade_no]":                        {order.OutTradeNo},
+			"payment_intent_data[metadata][out_trade_no]":   {order.OutTradeNo},
+		}
+		if !order.ExpireAt.IsZero() {
+			form.Set("expires_at", strconv.FormatInt(stripeExpiresAt(order.ExpireAt), 10))
+		}
+		var session struct {
+			URL string `json:"url"`
+		}
+		if err := p.do(ctx, http.MethodPost, "/v1/checkout/sessions", form, "create-"+order.OutTradeNo, &session); err != nil {
+			return nil, err
+		}
+		checkout.URL = session.URL
+	case "":
+		form := url.Values{
+			"amount":                             {amount},
+			"currency":                           {currency},
+			"description":                        {order.Subject},
+			"metadata[out_trade_no]":             {order.OutTradeNo},
+			"automatic_payment_methods[enabled]": {"true"},
+		}
+		var intent stripeIntent
+		if err := p.do(ctx, http.MethodPost, "/v1/payment_intents", form, "create-"+order.OutTradeNo, &intent); err != nil {
+			return nil, err
+		}
+		checkout.TradeNo = intent.ID
+		checkout.Params = map[string]string{"client_secret": intent.ClientSecret}
+	default:
+		return nil, ErrUnsupportedScene
+	}
+	return checkout, nil
+}
+
+// find returns the PaymentIntent tagged with outTradeNo | find 返回带有 outTradeNo 标记的 PaymentIntent
+func (p *stripeProvider) find(ctx context.Context, outTradeNo string) (*stripeIntent, error) {
+	query := url.Values{"query": {fmt.Sprintf("metadata['out_trade_no']:'%s'", strings.ReplaceAll(outTradeNo, "'", `\'`))}}
+	var result struct {
+		Data []stripeIntent `json:"data"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/v1/payment_intents/search?"+query.Encode(), nil, "", &result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 {
+		return nil, ErrNotFound
+	}
+	return &result.Data[0], nil
+}
+
+func (p *stripeProvider) Query(ctx context.Context, outTradeNo string) (*Transaction, error) {
+	intent, err := p.find(ctx, outTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	return intent.transaction(), nil
+}
+
+// Close cancels the PaymentIntent; Checkout Sessions without one expire on their own
+// Close 取消 PaymentIntent；尚无 PaymentIntent 的 Checkout Session 会自行过期
+func (p *stripeProvider) Close(ctx context.Context, outTradeNo string) error {
+	intent, err := p.find(ctx, outTradeNo)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if intent.Status == "canceled" || intent.Status == "succeeded" {
+		return nil
+	}
+	return p.do(ctx, http.MethodPost, "/v1/payment_intents/"+intent.ID+"/cancel", url.Values{}, "", nil)
+}
+
+// stripeRefund is a Refund object | stripeRefund 表示 Refund 对象
+type stripeRefund struct {
+	ID       string            `json:"id"`
+	Status   string            `json:"status"`
+	Amount   int64             `json:"amount"`
+	Currency string            `json:"currency"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+func (r *stripeRefund) refund() *Refund {
+	out := &Refund{
+		Provider:    Stripe,
+		OutTradeNo:  r.Metadata["out_trade_no"],
+		OutRefundNo: r.Metadata["out_refund_no"],
+		RefundNo:    r.ID,
+		Amount:      money.FromMinor(r.Amount, r.Currency),
+	}
+	switch r.Status {
+	case "succeeded":
+		out.Status = RefundSucceeded
+	case "failed", "canceled":
+		out.Status = RefundFailed
+	default: // pending, requires_action | 处理中、需要操作
+		out.Status = RefundPending
+	}
+	return out
+}
+
+func (p *stripeProvider) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	intentID := req.TradeNo
+	if intentID == "" {
+		intent, err := p.find(ctx, req.OutTradeNo)
+		if err != nil {
+			return nil, err
+		}
+		intentID = intent.ID
+	}
+	form := url.Values{
+		"payment_intent":          {intentID},
+		"amount":                  {strconv.FormatInt(req.Amount.Minor(), 10)},
+		"metadata[out_trade_no]":  {req.OutTradeNo},
+		"metadata[out_refund_no]": {req.OutRefundNo},
+	}
+	if req.Reason != "" {
+		form.Set("metadata[reason]", req.Reason)
+	}
+	var refund stripeRefund
+	if err := p.do(ctx, http.MethodPost, "/v1/refunds", form, "refund-"+req.OutRefundNo, &refund); err != nil {
+		return nil, err
+	}
+	return refund.refund(), nil
+}
+
+func (p *stripeProvider) ParseNotify(ctx context.Context, header http.Header, body []byte) (*Notification, error) {
+	if err := webhook.VerifyInbound(ctx, p.verifier, header, body, webhook.InboundConfig{ReplayTTL: -1}); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("payment stripe: decode event: %w", err)
+	}
+
+	n := &Notification{Provider: Stripe}
+	switch {
+	case strings.HasPrefix(event.Type, "payment_intent."):
+		var intent stripeIntent
+		if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+			return nil, fmt.Errorf("payment stripe: decode payment intent: %w", err)
+		}
+		n.Transaction = intent.transaction()
+	case strings.HasPrefix(event.Type, "refund.") || event.Type == "charge.refund.updated":
+		var refund stripeRefund
+		if err := json.Unmarshal(event.Data.Object, &refund); err != nil {
+			return nil, fmt.Errorf("payment stripe: decode refund: %w", err)
+		}
+		n.Refund = refund.refund()
+	}
+	return n, nil
+}
+
+func (p *stripeProvider) Ack(err error) Reply {
+	if err == nil {
+		return Reply{Status: http.StatusOK, ContentType: "application/json", Body: `{"received":true}`}
+	}
+	return Reply{Status: http.StatusBadRequest, ContentType: "application/json", Body: `{"received":false}`}
+}
+
+// do sends an API request, idempotencyKey makes retried POSTs safe
+// do 发送 API 请求，idempotencyKey 使重试的 POST 请求安全
+func (p *stripeProvider) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.cfg.Endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.cfg.SecretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment stripe: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error struct {
+				Type    string `json:"type"`
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if e.Error.Code == "resource_missing" {
+			return fmt.Errorf("%w: %s", ErrNotFound, e.Error.Message)
+		}
+		return &Error{
+			Provider:  Stripe,
+			Code:      defaultString(e.Error.Code, defaultString(e.Error.Type, resp.Status)),
+			Message:   e.Error.Message,
+			Temporary: resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests,
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// stripeExpiresAt clamps the expiry of a Checkout Session to the 30 minutes to 24 hours Stripe accepts
+// stripeExpiresAt 将 Checkout Session 的过期时间限制在 Stripe 接受的 30 分钟到 24 小时之间
+func stripeExpiresAt(at time.Time) int64 {
+	now := time.Now()
+	if earliest := now.Add(30*time.Minute + time.Minute); at.Before(earliest) {
+		at = earliest
+	}
+	if latest := now.Add(24 * time.Hour); at.After(latest) {
+		at = latest
+	}
+	return at.Unix()
+}
@@ -0,0 +1,383 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/pkg/money"
+	"github.com/nuohe369/crab/pkg/webhook"
+)
+
+// WeChatConfig configures WeChat Pay API v3
+// WeChatConfig 配置微信支付 API v3
+type WeChatConfig struct {
+	AppID       string `toml:"app_id"`        // AppID of the official account, mini program or app | 公众号、小程序或应用的 AppID
+	MchID       string `toml:"mch_id"`        // Merchant ID, empty disables WeChat Pay | 商户号，为空时禁用微信支付
+	SerialNo    string `toml:"serial_no"`     // Serial number of the merchant API certificate | 商户 API 证书序列号
+	PrivateKey  string `toml:"private_key"`   // apiclient_key.pem, inline or a file path | apiclient_key.pem，内联或文件路径
+	APIv3Key    string `toml:"api_v3_key"`    // Decrypts notifications | 用于解密通知
+	PublicKeyID string `toml:"public_key_id"` // Wechatpay-Serial of the key below (PUB_KEY_ID_... or the platform certificate serial) | 下方公钥的 Wechatpay-Serial
+	PublicKey   string `toml:"public_key"`    // WeChat Pay public key or platform certificate, inline or a file path | 微信支付公钥或平台证书，内联或文件路径
+	Endpoint    string `toml:"endpoint"`      // API base URL override | API 基础地址覆盖
+}
+
+// wechatProvider calls WeChat Pay API v3, requests are signed with the merchant key and
+// responses and notifications verified with the WeChat Pay public key
+// wechatProvider 调用微信支付 API v3，请求使用商户私钥签名，响应和通知使用微信支付公钥验签
+type wechatProvider struct {
+	cfg      WeChatConfig
+	http     *http.Client
+	key      *rsa.PrivateKey
+	verifier webhook.Verifier
+}
+
+// NewWeChat creates the WeChat Pay provider
+// NewWeChat 创建微信支付服务商
+func NewWeChat(cfg WeChatConfig, client *http.Client) (Provider, error) {
+	key, err := parsePrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("payment wechat: private_key: %w", err)
+	}
+	pub, err := parsePublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("payment wechat: public_key: %w", err)
+	}
+	if len(cfg.APIv3Key) != 32 {
+		return nil, errors.New("payment wechat: api_v3_key must be 32 bytes")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.mch.weixin.qq.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &wechatProvider{
+		cfg:      cfg,
+		http:     client,
+		key:      key,
+		verifier: webhook.WeChatPay(map[string]*rsa.PublicKey{cfg.PublicKeyID: pub}),
+	}, nil
+}
+
+func (p *wechatProvider) Name() string { return WeChat }
+
+// wechatAmount is the amount object of WeChat Pay | wechatAmount 为微信支付的金额对象
+type wechatAmount struct {
+	Total    int64  `json:"total,omitempty"`
+	Refund   int64  `json:"refund,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// wechatTransaction is a payment as returned by query and notifications | wechatTransaction 为查询和通知返回的支付
+type wechatTransaction struct {
+	OutTradeNo    string       `json:"out_trade_no"`
+	TransactionID string       `json:"transaction_id"`
+	TradeState    string       `json:"trade_state"`
+	SuccessTime   string       `json:"success_time"`
+	Amount        wechatAmount `json:"amount"`
+}
+
+func (t *wechatTransaction) transaction() *Transaction {
+	tx := &Transaction{
+		Provider:   WeChat,
+		OutTradeNo: t.OutTradeNo,
+		TradeNo:    t.TransactionID,
+		Amount:     money.FromMinor(t.Amount.Total, defaultString(t.Amount.Currency, "CNY")),
+	}
+	switch t.TradeState {
+	case "SUCCESS", "REFUND":
+		tx.Status = StatusPaid
+	case "CLOSED", "REVOKED":
+		tx.Status = StatusClosed
+	default: // NOTPAY, USERPAYING, PAYERROR | 未支付、支付中、支付失败（可重新支付）
+		tx.Status = StatusPending
+	}
+	if at, err := time.Parse(time.RFC3339, t.SuccessTime); err == nil {
+		tx.PaidAt = &at
+	}
+	return tx
+}
+
+func (p *wechatProvider) Create(ctx context.Context, order *Order) (*Checkout, error) {
+	scene := defaultString(order.Scene, SceneNative)
+	body := map[string]any{
+		"appid":        p.cfg.AppID,
+		"mchid":        p.cfg.MchID,
+		"description":  order.Subject,
+		"out_trade_no": order.OutTradeNo,
+		"notify_url":   order.NotifyURL,
+		"amount":       wechatAmount{Total: order.Amount.Minor(), Currency: order.Amount.Currency},
+	}
+	if !order.ExpireAt.IsZero() {
+		body["time_expire"] = order.ExpireAt.Format(time.RFC3339)
+	}
+	switch scene {
+	case SceneJSAPI:
+		body["payer"] = map[string]string{"openid": order.OpenID}
+	case SceneH5:
+		body["scene_info"] = map[string]any{"payer_client_ip": order.ClientIP, "h5_info": map[string]string{"type": "Wap"}}
+	case SceneNative, SceneApp:
+	default:
+		return nil, ErrUnsupportedScene
+	}
+
+	var resp struct {
+		CodeURL  string `json:"code_url"`
+		H5URL    string `json:"h5_url"`
+		PrepayID string `json:"prepay_id"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/v3/pay/transactions/"+scene, body, &resp); err != nil {
+		return nil, err
+	}
+
+	checkout := &Checkout{Provider: WeChat, OutTradeNo: order.OutTradeNo}
+	ts, nonce := strconv.FormatInt(time.Now().Unix(), 10), wechatNonce()
+	switch scene {
+	case SceneNative:
+		checkout.URL = resp.CodeURL
+	case SceneH5:
+		checkout.URL = resp.H5URL
+		if order.ReturnURL != "" {
+			checkout.URL += "&redirect_url=" + url.QueryEscape(order.ReturnURL)
+		}
+	case SceneJSAPI:
+		pkg := "prepay_id=" + resp.PrepayID
+		sign, err := signSHA256(p.key, p.cfg.AppID+"\n"+ts+"\n"+nonce+"\n"+pkg+"\n")
+		if err != nil {
+			return nil, err
+		}
+		checkout.Params = map[string]string{
+			"appId": p.cfg.AppID, "timeStamp": ts, "nonceStr": nonce, "package": pkg, "signType": "RSA", "paySign": sign,
+		}
+	case SceneApp:
+		sign, err := signSHA256(p.key, p.cfg.AppID+"\n"+ts+"\n"+nonce+"\n"+resp.PrepayID+"\n")
+		if err != nil {
+			return nil, err
+		}
+		checkout.Params = map[string]string{
+			"appid": p.cfg.AppID, "partnerid": p.cfg.MchID, "prepayid": resp.PrepayID, "package": "Sign=WXPay",
+			"noncestr": nonce, "timestamp": ts, "sign": sign,
+		}
+	}
+	return checkout, nil
+}
+
+func (p *wechatProvider) Query(ctx context.Context, outTradeNo string) (*Transaction, error) {
+	var resp wechatTransaction
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "?mchid=" + url.QueryEscape(p.cfg.MchID)
+	if err := p.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.transaction(), nil
+}
+
+func (p *wechatProvider) Close(ctx context.Context, outTradeNo string) error {
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "/close"
+	err := p.do(ctx, http.MethodPost, path, map[string]string{"mchid": p.cfg.MchID}, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// wechatRefund is a refund as returned by the refund API and notifications | wechatRefund 为退款接口和通知返回的退款
+type wechatRefund struct {
+	OutTradeNo   string       `json:"out_trade_no"`
+	OutRefundNo  string       `json:"out_refund_no"`
+	RefundID     string       `json:"refund_id"`
+	Status       string       `json:"status"`
+	RefundStatus string       `json:"refund_status"` // Notifications name it refund_status | 通知中字段名为 refund_status
+	Amount       wechatAmount `json:"amount"`
+}
+
+func (r *wechatRefund) refund() *Refund {
+	out := &Refund{
+		Provider:    WeChat,
+		OutTradeNo:  r.OutTradeNo,
+		OutRefundNo: r.OutRefundNo,
+		RefundNo:    r.RefundID,
+		Amount:      money.FromMinor(r.Amount.Refund, defaultString(r.Amount.Currency, "CNY")),
+	}
+	switch defaultString(r.Status, r.RefundStatus) {
+	case "SUCCESS":
+		out.Status = RefundSucceeded
+	case "CLOSED", "ABNORMAL":
+		out.Status = RefundFailed
+	default: // PROCESSING | 处理中
+		out.Status = RefundPending
+	}
+	return out
+}
+
+func (p *wechatProvider) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	body := map[string]any{
+		"out_trade_no":  req.OutTradeNo,
+		"out_refund_no": req.OutRefundNo,
+		"reason":        req.Reason,
+		"notify_url":    req.NotifyURL,
+		"amount": map[string]any{
+			"refund":   req.Amount.Minor(),
+			"total":    req.Total.Minor(),
+			"currency": req.Amount.Currency,
+		},
+	}
+	var resp wechatRefund
+	if err := p.do(ctx, http.MethodPost, "/v3/refund/domestic/refunds", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.refund(), nil
+}
+
+func (p *wechatProvider) ParseNotify(ctx context.Context, header http.Header, body []byte) (*Notification, error) {
+	// Providers resend a notification until it is acknowledged, state changes are idempotent instead of replays rejected
+	// 服务商会重发通知直到被确认，因此依靠状态变更的幂等性，而不是拒绝重放
+	if err := webhook.VerifyInbound(ctx, p.verifier, header, body, webhook.InboundConfig{ReplayTTL: -1}); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	var event struct {
+		EventType string `json:"event_type"`
+		Resource  struct {
+			Ciphertext     string `json:"ciphertext"`
+			AssociatedData string `json:"associated_data"`
+			Nonce          string `json:"nonce"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("payment wechat: decode notification: %w", err)
+	}
+	plain, err := p.decrypt(event.Resource.Ciphertext, event.Resource.Nonce, event.Resource.AssociatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notification{Provider: WeChat}
+	switch {
+	case strings.HasPrefix(event.EventType, "TRANSACTION."):
+		var t wechatTransaction
+		if err := json.Unmarshal(plain, &t); err != nil {
+			return nil, fmt.Errorf("payment wechat: decode transaction: %w", err)
+		}
+		n.Transaction = t.transaction()
+	case strings.HasPrefix(event.EventType, "REFUND."):
+		var r wechatRefund
+		if err := json.Unmarshal(plain, &r); err != nil {
+			return nil, fmt.Errorf("payment wechat: decode refund: %w", err)
+		}
+		n.Refund = r.refund()
+	}
+	return n, nil
+}
+
+func (p *wechatProvider) Ack(err error) Reply {
+	if err == nil {
+		return Reply{Status: http.StatusNoContent}
+	}
+	return Reply{Status: http.StatusInternalServerError, ContentType: "application/json", Body: `{"code":"FAIL","message":"失败"}`}
+}
+
+// decrypt opens the AEAD_AES_256_GCM resource of a notification | decrypt 解密通知中的 AEAD_AES_256_GCM 资源
+func (p *wechatProvider) decrypt(ciphertext, nonce, associatedData string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("payment wechat: decode ciphertext: %w", err)
+	}
+	block, err := aes.NewCipher([]byte(p.cfg.APIv3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+	if err != nil {
+		return nil, fmt.Errorf("payment wechat: decrypt notification: %w", err)
+	}
+	return plain, nil
+}
+
+// do sends a signed API request and decodes the verified response into out
+// do 发送签名的 API 请求，并将验签后的响应解码到 out
+func (p *wechatProvider) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	ts, nonce := strconv.FormatInt(time.Now().Unix(), 10), wechatNonce()
+	sig, err := signSHA256(p.key, method+"\n"+path+"\n"+ts+"\n"+nonce+"\n"+string(payload)+"\n")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.cfg.Endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		p.cfg.MchID, nonce, sig, ts, p.cfg.SerialNo))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment wechat: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("payment wechat: read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &e)
+		if e.Code == "ORDER_NOT_EXIST" || e.Code == "RESOURCE_NOT_EXISTS" {
+			return fmt.Errorf("%w: %s", ErrNotFound, e.Message)
+		}
+		return &Error{
+			Provider:  WeChat,
+			Code:      defaultString(e.Code, resp.Status),
+			Message:   e.Message,
+			Temporary: resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || e.Code == "SYSTEM_ERROR",
+		}
+	}
+	if _, _, err := p.verifier.Verify(resp.Header, data); err != nil {
+		return fmt.Errorf("payment wechat: %w on response", ErrInvalidSignature)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// wechatNonce returns a random 32 character nonce | wechatNonce 返回 32 位随机串
+func wechatNonce() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// defaultString returns s, or def when s is empty | defaultString 返回 s，为空时返回 def
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
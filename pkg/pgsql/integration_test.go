@@ -0,0 +1,42 @@
+package pgsql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/testing/containers"
+)
+
+// integrationItem is the table of TestSyncInsert | integrationItem 为 TestSyncInsert 使用的表
+type integrationItem struct {
+	ID   int64  `xorm:"pk autoincr"`
+	Name string `xorm:"varchar(64) notnull"`
+}
+
+// TestSyncInsert creates a table on a real PostgreSQL server and reads a row back
+// TestSyncInsert 在真实 PostgreSQL 服务器上建表并读回数据
+func TestSyncInsert(t *testing.T) {
+	client, err := pgsql.New(containers.Postgres(t))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Sync(new(integrationItem)); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := client.Session(ctx).Insert(&integrationItem{Name: "crab"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var got integrationItem
+	has, err := client.Session(ctx).Where("name = ?", "crab").Get(&got)
+	if err != nil || !has {
+		t.Fatalf("Get = %v, %v, want the inserted row", has, err)
+	}
+	if got.ID == 0 {
+		t.Error("Expected an autoincrement id")
+	}
+}
@@ -1,6 +1,8 @@
 package pgsql
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"sync"
@@ -14,20 +16,41 @@ import (
 // Config represents PostgreSQL configuration
 // Config 表示 PostgreSQL 配置
 type Config struct {
-	Host        string `toml:"host"`
-	Port        int    `toml:"port"`
-	User        string `toml:"user"`
-	Password    string `toml:"password"`
-	DBName      string `toml:"db_name"`
-	AutoMigrate bool   `toml:"auto_migrate"` // Auto migrate database schema | 自动迁移数据库架构
-	ShowSQL     bool   `toml:"show_sql"`     // Show SQL logs | 显示 SQL 日志
+	Host             string        `toml:"host"`
+	Port             int           `toml:"port"`
+	User             string        `toml:"user"`
+	Password         string        `toml:"password"`
+	DBName           string        `toml:"db_name"`
+	AutoMigrate      bool          `toml:"auto_migrate"`      // Auto migrate database schema | 自动迁移数据库架构
+	ShowSQL          bool          `toml:"show_sql"`          // Show SQL logs | 显示 SQL 日志
+	StatementTimeout time.Duration `toml:"statement_timeout"` // Server-side limit per statement, 0 for none | 服务端单条语句时限，0 表示不限制
+	Driver           string        `toml:"driver"`            // database/sql driver, default postgres; e.g. sqlite3 in tests | database/sql 驱动，默认 postgres；测试中可为 sqlite3 等
+	Source           string        `toml:"source"`            // Data source passed to the driver as is, replaces the fields above | 原样传给驱动的数据源，取代上面的连接字段
 }
 
 // DSN generates connection string
 // DSN 生成连接字符串
 func (c Config) DSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		c.Host, c.Port, c.User, c.Password, c.DBName)
+	if c.StatementTimeout > 0 {
+		// Sent as a startup parameter, PostgreSQL cancels longer statements itself | 作为启动参数发送，由 PostgreSQL 自行取消超时语句
+		dsn += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
+	return dsn
+}
+
+// Connection returns the driver name and data source to open
+// Connection 返回要打开的驱动名称和数据源
+func (c Config) Connection() (driver, source string) {
+	driver, source = c.Driver, c.Source
+	if driver == "" {
+		driver = "postgres"
+	}
+	if source == "" {
+		source = c.DSN()
+	}
+	return driver, source
 }
 
 // Client wraps PostgreSQL client
@@ -137,6 +160,31 @@ func SetLogger(logger Logger) {
 	mu.RUnlock()
 }
 
+// Stats represents connection pool statistics of a named client
+// Stats 表示命名客户端的连接池统计信息
+type Stats struct {
+	Name string
+	sql.DBStats
+}
+
+// AllStats returns pool statistics of the default client ("default") and all named clients
+// AllStats 返回默认客户端（"default"）和所有命名客户端的连接池统计信息
+func AllStats() []Stats {
+	var stats []Stats
+	if defaultClient != nil {
+		stats = append(stats, Stats{Name: "default", DBStats: defaultClient.engine.DB().Stats()})
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for name, client := range clients {
+		if client == nil || (name == "default" && defaultClient != nil) {
+			continue
+		}
+		stats = append(stats, Stats{Name: name, DBStats: client.engine.DB().Stats()})
+	}
+	return stats
+}
+
 // MustInit initializes and panics on error
 // MustInit 初始化，出错时 panic
 func MustInit(cfg Config) {
@@ -148,7 +196,7 @@ func MustInit(cfg Config) {
 // New creates PostgreSQL client
 // New 创建 PostgreSQL 客户端
 func New(cfg Config) (*Client, error) {
-	engine, err := xorm.NewEngine("postgres", cfg.DSN())
+	engine, err := xorm.NewEngine(cfg.Connection())
 	if err != nil {
 		return nil, err
 	}
@@ -181,6 +229,12 @@ func (c *Client) Engine() *xorm.Engine {
 	return c.engine
 }
 
+// Session returns an auto-closing session bound to ctx, canceling the running query when ctx is done
+// Session 返回绑定 ctx 的自动关闭会话，ctx 结束时取消正在执行的查询
+func (c *Client) Session(ctx context.Context) *xorm.Session {
+	return c.engine.Context(ctx)
+}
+
 // Sync synchronizes table structure
 // Sync 同步表结构
 func (c *Client) Sync(beans ...any) error {
@@ -0,0 +1,29 @@
+package pgsql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	cfg := Config{Host: "db", Port: 5432, User: "crab", Password: "secret", DBName: "crab"}
+	if dsn := cfg.DSN(); strings.Contains(dsn, "statement_timeout") {
+		t.Errorf("No timeout should be sent by default: %s", dsn)
+	}
+	cfg.StatementTimeout = 1500 * time.Millisecond
+	if dsn := cfg.DSN(); !strings.HasSuffix(dsn, " statement_timeout=1500") {
+		t.Errorf("DSN = %s", dsn)
+	}
+}
+
+func TestConnection(t *testing.T) {
+	cfg := Config{Host: "db", Port: 5432, User: "crab", DBName: "crab"}
+	if driver, source := cfg.Connection(); driver != "postgres" || source != cfg.DSN() {
+		t.Errorf("Connection() = %s, %s", driver, source)
+	}
+	cfg.Driver, cfg.Source = "sqlite3", "file::memory:"
+	if driver, source := cfg.Connection(); driver != "sqlite3" || source != "file::memory:" {
+		t.Errorf("Connection() = %s, %s", driver, source)
+	}
+}
@@ -4,16 +4,27 @@ import (
 	"context"
 	"log"
 
+	"github.com/nuohe369/crab/pkg/breaker"
+	"github.com/nuohe369/crab/pkg/bulkhead"
 	"github.com/nuohe369/crab/pkg/cache"
+	"github.com/nuohe369/crab/pkg/cdc"
 	"github.com/nuohe369/crab/pkg/cron"
+	"github.com/nuohe369/crab/pkg/dbcache"
+	"github.com/nuohe369/crab/pkg/discovery"
+	"github.com/nuohe369/crab/pkg/email"
+	"github.com/nuohe369/crab/pkg/httpclient"
+	"github.com/nuohe369/crab/pkg/jobs"
 	"github.com/nuohe369/crab/pkg/jwt"
 	"github.com/nuohe369/crab/pkg/logger"
 	"github.com/nuohe369/crab/pkg/metrics"
 	"github.com/nuohe369/crab/pkg/mq"
 	"github.com/nuohe369/crab/pkg/pgsql"
 	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/search"
+	"github.com/nuohe369/crab/pkg/sms"
 	"github.com/nuohe369/crab/pkg/snowflake"
 	"github.com/nuohe369/crab/pkg/storage"
+	"github.com/nuohe369/crab/pkg/timex"
 	"github.com/nuohe369/crab/pkg/trace"
 )
 
@@ -24,10 +35,21 @@ type Config struct {
 	SnowflakeMachineID int64
 	Databases          map[string]pgsql.Config
 	Redis              map[string]redis.Config
+	DBCache            dbcache.Config
 	MQ                 mq.Config
+	Jobs               jobs.Config
+	CDC                cdc.Config
 	JWT                jwt.Config
 	Metrics            metrics.Config
+	Breaker            map[string]breaker.Config
+	Bulkhead           map[string]bulkhead.Config
+	HTTPClient         httpclient.Config
+	Discovery          discovery.Config
+	Email              email.Config
+	SMS                sms.Config
 	Storage            storage.Config
+	Search             search.Config
+	Time               timex.Config
 	Trace              trace.Config
 }
 
@@ -35,6 +57,12 @@ type Config struct {
 func Init(cfg Config) {
 	log.Println("Initializing pkg infrastructure...")
 
+	// Initialize time zone and business calendar
+	if err := timex.Init(cfg.Time); err != nil {
+		log.Fatalf("Time initialization failed: %v", err)
+	}
+	log.Printf("  ✓ Time zone %s initialized", timex.Location())
+
 	// Initialize Snowflake ID generator
 	machineID := cfg.SnowflakeMachineID
 	if machineID == 0 {
@@ -116,6 +144,20 @@ func Init(cfg Config) {
 	cache.Init(redis.Get())
 	log.Println("  ✓ Cache initialized")
 
+	// Initialize query result cache, invalidated by a write hook on every database
+	dbcache.Init(cache.Get(), cfg.DBCache)
+	cacheHooked := make(map[*pgsql.Client]bool)
+	for name := range cfg.Databases {
+		if db := pgsql.Get(name); db != nil && !cacheHooked[db] {
+			db.AddHook(dbcache.Hook{})
+			cacheHooked[db] = true
+		}
+	}
+	if db := pgsql.Get(); db != nil && !cacheHooked[db] {
+		db.AddHook(dbcache.Hook{})
+	}
+	log.Println("  ✓ Query cache initialized")
+
 	// Initialize cron scheduler (optional)
 	cron.Init(redis.Get())
 	log.Println("  ✓ Cron initialized")
@@ -131,14 +173,82 @@ func Init(cfg Config) {
 		log.Println("  - Message queue not configured, skipping")
 	}
 
+	// Initialize entity change events (optional, published to MQ)
+	cdc.Init(cfg.CDC)
+	if cfg.CDC.Enabled {
+		if mq.Get() == nil {
+			log.Println("  ⚠ Change events enabled but MQ is not configured, events will fail to publish")
+		} else {
+			log.Println("  ✓ Change events initialized")
+		}
+	}
+
+	// Initialize background jobs (optional, depends on MQ)
+	if mq.Get() != nil {
+		if err := jobs.Init(cfg.Jobs); err != nil {
+			log.Printf("  ⚠ Background jobs initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ Background jobs initialized")
+		}
+	} else {
+		log.Println("  - Background jobs require MQ, skipping")
+	}
+
 	// Initialize JWT (optional)
-	if cfg.JWT.Secret != "" {
-		jwt.Init(cfg.JWT)
-		log.Println("  ✓ JWT initialized")
+	if cfg.JWT.Enabled() {
+		if err := jwt.Init(cfg.JWT); err != nil {
+			log.Printf("  ⚠ JWT initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ JWT initialized")
+		}
 	} else {
 		log.Println("  - JWT not configured, skipping")
 	}
 
+	// Initialize circuit breakers, [breaker.default] plus per-breaker overrides
+	breaker.Setup(cfg.Breaker)
+	log.Println("  ✓ Circuit breakers initialized")
+
+	// Initialize bulkheads, [bulkhead.default] plus per-dependency overrides
+	bulkhead.Setup(cfg.Bulkhead)
+	log.Println("  ✓ Bulkheads initialized")
+
+	// Configure the shared outbound HTTP client
+	httpclient.Init(cfg.HTTPClient)
+
+	// Initialize service discovery (optional)
+	if cfg.Discovery.Enabled() {
+		if err := discovery.Init(cfg.Discovery); err != nil {
+			log.Printf("  ⚠ Service discovery initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ Service discovery initialized")
+		}
+	} else {
+		log.Println("  - Service discovery not configured, skipping")
+	}
+
+	// Initialize email (optional, the send queue depends on MQ)
+	if cfg.Email.Enabled() {
+		if err := email.Init(cfg.Email); err != nil {
+			log.Printf("  ⚠ Email initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ Email initialized")
+		}
+	} else {
+		log.Println("  - Email not configured, skipping")
+	}
+
+	// Initialize SMS (optional)
+	if cfg.SMS.Driver != "" {
+		if err := sms.Init(cfg.SMS); err != nil {
+			log.Printf("  ⚠ SMS initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ SMS initialized")
+		}
+	} else {
+		log.Println("  - SMS not configured, skipping")
+	}
+
 	// Initialize metrics (optional)
 	if cfg.Metrics.Enabled {
 		metrics.Init(cfg.Metrics)
@@ -158,6 +268,17 @@ func Init(cfg Config) {
 		log.Println("  - Storage not configured, skipping")
 	}
 
+	// Initialize full-text search (optional, the postgres driver uses the databases above)
+	if cfg.Search.Driver != "" {
+		if err := search.Init(cfg.Search); err != nil {
+			log.Printf("  ⚠ Search initialization failed: %v", err)
+		} else {
+			log.Println("  ✓ Search initialized")
+		}
+	} else {
+		log.Println("  - Search not configured, skipping")
+	}
+
 	// Initialize distributed tracing (optional)
 	if cfg.Trace.Endpoint != "" {
 		shutdown, err := trace.Init(cfg.Trace)
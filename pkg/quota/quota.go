@@ -0,0 +1,359 @@
+// Package quota tracks per-user and per-org usage against the limits of their plan
+// Period resources (daily and monthly API calls) reset at the start of each UTC day or month;
+// gauge resources (storage bytes, seats) go up and down with Consume and Release and never reset.
+// A subject's limits come from its plan, or the default plan, with per-subject overrides on top;
+// resources a plan does not list are unlimited.
+// Package quota 按用户和组织统计用量，并与其套餐限额比较
+// 周期资源（每日和每月 API 调用）在每个 UTC 日或月开始时重置；
+// 计量资源（存储字节数、席位）通过 Consume 和 Release 增减，从不重置。
+// 主体的限额来自其套餐或默认套餐，并可按主体覆盖；套餐未列出的资源不受限制。
+//
+// Usage | 用法:
+//
+//	if _, err := quota.Consume(ctx, quota.Org(orgID), quota.Seats, 1); errors.Is(err, quota.ErrExceeded) {
+//		return errors.FromCode(response.CodeQuotaExceeded)
+//	}
+//	defer quota.Release(ctx, quota.Org(orgID), quota.Seats, 1) // on failure | 失败时
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
+)
+
+// Built-in resources | 内置资源
+const (
+	APICallsDaily   = "api_calls_daily"   // Resets daily | 每日重置
+	APICallsMonthly = "api_calls_monthly" // Resets monthly | 每月重置
+	StorageBytes    = "storage_bytes"     // Gauge | 计量值
+	Seats           = "seats"             // Gauge | 计量值
+)
+
+// Period is how often the usage of a resource resets
+// Period 表示资源用量的重置周期
+type Period string
+
+// Reset periods | 重置周期
+const (
+	PeriodNone  Period = "none"  // Gauge, never reset | 计量值，从不重置
+	PeriodDay   Period = "day"   // UTC day | UTC 日
+	PeriodMonth Period = "month" // UTC month | UTC 月
+)
+
+// Unlimited is the limit of resources a plan does not list
+// Unlimited 为套餐未列出的资源的限额
+const Unlimited int64 = -1
+
+var (
+	// ErrExceeded is returned when consuming would go over the limit
+	// ErrExceeded 在消耗会超过限额时返回
+	ErrExceeded = errors.New("quota: limit exceeded")
+	// ErrUnknownPlan is returned when saving settings with a plan that is not configured
+	// ErrUnknownPlan 在保存的设置使用未配置的套餐时返回
+	ErrUnknownPlan = errors.New("quota: unknown plan")
+)
+
+// Config represents the [quota] configuration section
+// Config 表示 [quota] 配置段
+type Config struct {
+	DefaultPlan string                      `toml:"default_plan"` // Plan of subjects without one (default "free") | 未指定套餐的主体使用的套餐（默认 "free"）
+	Plans       map[string]map[string]int64 `toml:"plans"`        // Limits per plan, resource to limit | 每个套餐的限额，资源到限额
+	Periods     map[string]Period           `toml:"periods"`      // Reset period of custom resources, built-ins have theirs | 自定义资源的重置周期，内置资源已有默认值
+	KeyPrefix   string                      `toml:"key_prefix"`   // Redis key prefix (default "quota:") | Redis 键前缀（默认 "quota:"）
+	AdminPath   string                      `toml:"admin_path"`   // Usage and limit endpoints, empty disables | 用量和限额管理接口路径，为空时禁用
+	Permission  string                      `toml:"permission"`   // Permission required by the endpoints (default "quota:write") | 管理接口要求的权限（默认 "quota:write"）
+}
+
+// builtinPeriods are the periods of the built-in resources | builtinPeriods 为内置资源的周期
+var builtinPeriods = map[string]Period{
+	APICallsDaily:   PeriodDay,
+	APICallsMonthly: PeriodMonth,
+	StorageBytes:    PeriodNone,
+	Seats:           PeriodNone,
+}
+
+// Settings are the plan and limit overrides of one subject
+// Settings 为单个主体的套餐和限额覆盖
+type Settings struct {
+	Plan   string           `json:"plan,omitempty"`   // Empty means the default plan | 为空表示默认套餐
+	Limits map[string]int64 `json:"limits,omitempty"` // Overrides of plan limits, Unlimited lifts one | 覆盖套餐限额，Unlimited 表示取消限制
+}
+
+// Usage is the usage of one resource by a subject
+// Usage 为主体对某个资源的用量
+type Usage struct {
+	Resource string     `json:"resource"`
+	Used     int64      `json:"used"`
+	Limit    int64      `json:"limit"`              // Unlimited (-1) without a limit | 无限额时为 Unlimited (-1)
+	ResetAt  *time.Time `json:"reset_at,omitempty"` // Start of the next period, nil for gauges | 下个周期的开始时间，计量资源为 nil
+}
+
+// Remaining returns how much is left, Unlimited without a limit
+// Remaining 返回剩余额度，无限额时返回 Unlimited
+func (u Usage) Remaining() int64 {
+	if u.Limit < 0 {
+		return Unlimited
+	}
+	return max(u.Limit-u.Used, 0)
+}
+
+// User returns the subject of a user | User 返回用户的主体
+func User(id int64) string {
+	return "user:" + strconv.FormatInt(id, 10)
+}
+
+// Org returns the subject of an organization | Org 返回组织的主体
+func Org(id int64) string {
+	return "org:" + strconv.FormatInt(id, 10)
+}
+
+// Manager checks and records usage in a Store
+// Manager 在 Store 中检查并记录用量
+type Manager struct {
+	store Store
+	cfg   Config
+	clock clock.Clock
+}
+
+// Option configures a Manager | Option 配置 Manager
+type Option func(*Manager)
+
+// WithClock sets the clock deciding the current period, for tests
+// WithClock 设置决定当前周期的时钟，用于测试
+func WithClock(c clock.Clock) Option {
+	return func(m *Manager) { m.clock = c }
+}
+
+// NewManager creates a manager recording usage in store
+// NewManager 创建在 store 中记录用量的管理器
+func NewManager(cfg Config, store Store, opts ...Option) *Manager {
+	m := &Manager{store: store, cfg: withDefaults(cfg), clock: clock.Real()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// withDefaults fills the unset fields of c | withDefaults 填充 c 中未设置的字段
+func withDefaults(c Config) Config {
+	if c.DefaultPlan == "" {
+		c.DefaultPlan = "free"
+	}
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = "quota:"
+	}
+	if c.Permission == "" {
+		c.Permission = "quota:write"
+	}
+	return c
+}
+
+// Period returns the reset period of a resource, PeriodNone when unknown
+// Period 返回资源的重置周期，未知资源返回 PeriodNone
+func (m *Manager) Period(resource string) Period {
+	if p, ok := m.cfg.Periods[resource]; ok {
+		return p
+	}
+	if p, ok := builtinPeriods[resource]; ok {
+		return p
+	}
+	return PeriodNone
+}
+
+// Plans returns the configured plans | Plans 返回配置的套餐
+func (m *Manager) Plans() map[string]map[string]int64 {
+	return m.cfg.Plans
+}
+
+// Settings returns the plan and overrides of subject | Settings 返回主体的套餐和覆盖
+func (m *Manager) Settings(ctx context.Context, subject string) (Settings, error) {
+	return m.store.GetSettings(ctx, subject)
+}
+
+// SaveSettings replaces the plan and overrides of subject, rejecting unknown plans
+// SaveSettings 替换主体的套餐和覆盖，拒绝未知套餐
+func (m *Manager) SaveSettings(ctx context.Context, subject string, s Settings) error {
+	if s.Plan != "" {
+		if _, ok := m.cfg.Plans[s.Plan]; !ok {
+			return fmt.Errorf("%w %q", ErrUnknownPlan, s.Plan)
+		}
+	}
+	return m.store.SaveSettings(ctx, subject, s)
+}
+
+// Limit returns the limit of resource for subject, Unlimited when there is none
+// Limit 返回主体对资源的限额，没有限额时返回 Unlimited
+func (m *Manager) Limit(ctx context.Context, subject, resource string) (int64, error) {
+	s, err := m.store.GetSettings(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	return m.limit(s, resource), nil
+}
+
+// limit resolves a limit from settings | limit 根据设置解析限额
+func (m *Manager) limit(s Settings, resource string) int64 {
+	if l, ok := s.Limits[resource]; ok {
+		return l
+	}
+	plan := s.Plan
+	if plan == "" {
+		plan = m.cfg.DefaultPlan
+	}
+	if l, ok := m.cfg.Plans[plan][resource]; ok {
+		return l
+	}
+	return Unlimited
+}
+
+// Consume records n units of resource for subject, or returns ErrExceeded with the
+// current usage when that would go over the limit
+// Consume 为主体记录 n 个单位的资源用量，超过限额时返回 ErrExceeded 和当前用量
+func (m *Manager) Consume(ctx context.Context, subject, resource string, n int64) (Usage, error) {
+	if n < 0 {
+		return Usage{}, fmt.Errorf("quota: negative amount %d, use Release", n)
+	}
+	limit, err := m.Limit(ctx, subject, resource)
+	if err != nil {
+		return Usage{}, err
+	}
+	key, resetAt := m.key(subject, resource)
+	used, ok, err := m.store.Add(ctx, key, n, limit, resetAt)
+	if err != nil {
+		return Usage{}, err
+	}
+	u := m.usage(resource, used, limit, resetAt)
+	if !ok {
+		return u, ErrExceeded
+	}
+	return u, nil
+}
+
+// Release gives back n units of resource, usage does not go below 0
+// Release 归还 n 个单位的资源用量，用量不会低于 0
+func (m *Manager) Release(ctx context.Context, subject, resource string, n int64) error {
+	key, resetAt := m.key(subject, resource)
+	_, _, err := m.store.Add(ctx, key, -n, Unlimited, resetAt)
+	return err
+}
+
+// SetUsage overwrites the current usage of resource, for corrections
+// SetUsage 覆盖资源的当前用量，用于修正
+func (m *Manager) SetUsage(ctx context.Context, subject, resource string, used int64) error {
+	key, resetAt := m.key(subject, resource)
+	return m.store.Set(ctx, key, max(used, 0), resetAt)
+}
+
+// Usage returns the current usage of resource by subject
+// Usage 返回主体对资源的当前用量
+func (m *Manager) Usage(ctx context.Context, subject, resource string) (Usage, error) {
+	s, err := m.store.GetSettings(ctx, subject)
+	if err != nil {
+		return Usage{}, err
+	}
+	key, resetAt := m.key(subject, resource)
+	used, err := m.store.Get(ctx, key)
+	if err != nil {
+		return Usage{}, err
+	}
+	return m.usage(resource, used, m.limit(s, resource), resetAt), nil
+}
+
+// Report returns the usage of every resource limited for subject, by its plan or overrides, sorted by resource
+// Report 返回主体在套餐或覆盖中受限的每个资源的用量，按资源排序
+func (m *Manager) Report(ctx context.Context, subject string) ([]Usage, error) {
+	s, err := m.store.GetSettings(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	plan := s.Plan
+	if plan == "" {
+		plan = m.cfg.DefaultPlan
+	}
+	resources := slices.Sorted(maps.Keys(m.cfg.Plans[plan]))
+	for r := range s.Limits {
+		if !slices.Contains(resources, r) {
+			resources = append(resources, r)
+		}
+	}
+	slices.Sort(resources)
+
+	out := make([]Usage, 0, len(resources))
+	for _, r := range resources {
+		key, resetAt := m.key(subject, r)
+		used, err := m.store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m.usage(r, used, m.limit(s, r), resetAt))
+	}
+	return out, nil
+}
+
+// usage builds a Usage | usage 构建 Usage
+func (m *Manager) usage(resource string, used, limit int64, resetAt time.Time) Usage {
+	u := Usage{Resource: resource, Used: used, Limit: limit}
+	if !resetAt.IsZero() {
+		u.ResetAt = &resetAt
+	}
+	return u
+}
+
+// key returns the counter key of the current period and when that period ends, zero for gauges
+// key 返回当前周期的计数键及周期结束时间，计量资源的结束时间为零值
+func (m *Manager) key(subject, resource string) (string, time.Time) {
+	key := m.cfg.KeyPrefix + "usage:" + subject + ":" + resource
+	now := m.clock.Now().UTC()
+	switch m.Period(resource) {
+	case PeriodDay:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return key + ":" + start.Format("20060102"), start.AddDate(0, 0, 1)
+	case PeriodMonth:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return key + ":" + start.Format("200601"), start.AddDate(0, 1, 0)
+	}
+	return key, time.Time{}
+}
+
+var (
+	cfg            = withDefaults(Config{})
+	defaultManager = NewManager(cfg, NewMemoryStore())
+)
+
+// Init replaces the default manager with one recording usage in store
+// Init 使用在 store 中记录用量的管理器替换默认管理器
+func Init(c Config, store Store) {
+	cfg = withDefaults(c)
+	defaultManager = NewManager(cfg, store)
+}
+
+// GetConfig returns the current configuration
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	return cfg
+}
+
+// Get returns the default manager
+// Get 返回默认管理器
+func Get() *Manager {
+	return defaultManager
+}
+
+// Consume records usage with the default manager, see Manager.Consume
+// Consume 使用默认管理器记录用量，见 Manager.Consume
+func Consume(ctx context.Context, subject, resource string, n int64) (Usage, error) {
+	return defaultManager.Consume(ctx, subject, resource, n)
+}
+
+// Release gives back usage with the default manager, see Manager.Release
+// Release 使用默认管理器归还用量，见 Manager.Release
+func Release(ctx context.Context, subject, resource string, n int64) error {
+	return defaultManager.Release(ctx, subject, resource, n)
+}
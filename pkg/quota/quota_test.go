@@ -0,0 +1,115 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nuohe369/crab/pkg/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+var testConfig = Config{
+	Plans: map[string]map[string]int64{
+		"free": {APICallsDaily: 2, Seats: 3},
+		"pro":  {APICallsDaily: 100, Seats: 20, StorageBytes: 1 << 30},
+	},
+}
+
+// stores returns each store implementation, Redis on a fresh miniredis server
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return map[string]Store{"memory": NewMemoryStore(), "redis": NewRedisStore(rdb, "")}
+}
+
+func TestConsume(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := NewManager(testConfig, store)
+			sub := User(1)
+
+			for i := range 2 {
+				if _, err := m.Consume(ctx, sub, APICallsDaily, 1); err != nil {
+					t.Fatalf("call %d: Consume = %v, want nil", i+1, err)
+				}
+			}
+			u, err := m.Consume(ctx, sub, APICallsDaily, 1)
+			if !errors.Is(err, ErrExceeded) || u.Used != 2 || u.Remaining() != 0 || u.ResetAt == nil {
+				t.Errorf("third call: usage %+v, err %v; want 2 used, reset time and ErrExceeded", u, err)
+			}
+
+			// Resources the plan does not list are unlimited | 套餐未列出的资源不受限制
+			if u, err := m.Consume(ctx, sub, StorageBytes, 1<<40); err != nil || u.Limit != Unlimited {
+				t.Errorf("storage: usage %+v, err %v; want unlimited", u, err)
+			}
+		})
+	}
+}
+
+func TestReleaseAndSettings(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := NewManager(testConfig, store)
+			sub := Org(7)
+
+			m.Consume(ctx, sub, Seats, 3)
+			if err := m.Release(ctx, sub, Seats, 5); err != nil {
+				t.Fatalf("Release failed: %v", err)
+			}
+			if u, _ := m.Usage(ctx, sub, Seats); u.Used != 0 || u.Limit != 3 || u.ResetAt != nil {
+				t.Errorf("after release: %+v, want 0 of 3 without reset", u)
+			}
+
+			if err := m.SaveSettings(ctx, sub, Settings{Plan: "gold"}); !errors.Is(err, ErrUnknownPlan) {
+				t.Errorf("SaveSettings(gold) = %v, want ErrUnknownPlan", err)
+			}
+			if err := m.SaveSettings(ctx, sub, Settings{Plan: "pro", Limits: map[string]int64{Seats: 50, "exports": 5}}); err != nil {
+				t.Fatalf("SaveSettings failed: %v", err)
+			}
+			m.SetUsage(ctx, sub, APICallsDaily, 40)
+
+			report, err := m.Report(ctx, sub)
+			if err != nil {
+				t.Fatalf("Report failed: %v", err)
+			}
+			want := map[string][2]int64{APICallsDaily: {40, 100}, "exports": {0, 5}, Seats: {0, 50}, StorageBytes: {0, 1 << 30}}
+			if len(report) != len(want) {
+				t.Fatalf("Report = %+v, want %d resources", report, len(want))
+			}
+			for _, u := range report {
+				if w := want[u.Resource]; u.Used != w[0] || u.Limit != w[1] {
+					t.Errorf("%s: used %d of %d, want %d of %d", u.Resource, u.Used, u.Limit, w[0], w[1])
+				}
+			}
+		})
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC))
+	m := NewManager(testConfig, NewMemoryStore(), WithClock(fake))
+	sub := User(1)
+
+	m.Consume(ctx, sub, APICallsDaily, 2)
+	u, _ := m.Consume(ctx, sub, APICallsMonthly, 5)
+	if want := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC); !u.ResetAt.Equal(want) {
+		t.Errorf("monthly ResetAt = %v, want %v", u.ResetAt, want)
+	}
+
+	// A new day starts a new counter | 新的一天使用新的计数
+	fake.Advance(2 * time.Hour)
+	if u, err := m.Consume(ctx, sub, APICallsDaily, 1); err != nil || u.Used != 1 {
+		t.Errorf("next day: usage %+v, err %v; want 1 used", u, err)
+	}
+	if u, _ := m.Usage(ctx, sub, APICallsMonthly); u.Used != 0 {
+		t.Errorf("next month: used %d, want 0", u.Used)
+	}
+}
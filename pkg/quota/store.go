@@ -0,0 +1,194 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps usage counters and subject settings
+// Store 保存用量计数和主体设置
+type Store interface {
+	// Add adds n to the counter at key unless the result would exceed limit (negative means none),
+	// returning the counter after the call and whether n was added. Negative n always succeeds
+	// and stops at 0. A non-zero expireAt drops the counter at that time.
+	// Add 在结果不超过 limit（负数表示不限）时将 n 加到 key 的计数上，返回调用后的计数及是否已添加。
+	// 负的 n 总是成功且不低于 0。expireAt 非零时计数在该时间删除。
+	Add(ctx context.Context, key string, n, limit int64, expireAt time.Time) (int64, bool, error)
+	Get(ctx context.Context, key string) (int64, error)                         // 0 if missing | 不存在时返回 0
+	Set(ctx context.Context, key string, value int64, expireAt time.Time) error // Overwrite | 覆盖
+	GetSettings(ctx context.Context, subject string) (Settings, error)          // Zero if missing | 不存在时返回零值
+	SaveSettings(ctx context.Context, subject string, s Settings) error         // Replace | 替换
+}
+
+// memoryStore keeps counters in process, for tests and single instances
+// memoryStore 在进程内保存计数，用于测试和单实例部署
+type memoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+	settings map[string]Settings
+}
+
+type memoryCounter struct {
+	value    int64
+	expireAt time.Time
+}
+
+// NewMemoryStore creates an in-process store
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() Store {
+	return &memoryStore{counters: make(map[string]memoryCounter), settings: make(map[string]Settings)}
+}
+
+// get returns the live counter at key, caller holds mu | get 返回 key 处未过期的计数，调用方需持有 mu
+func (s *memoryStore) get(key string) int64 {
+	c, ok := s.counters[key]
+	if !ok {
+		return 0
+	}
+	if !c.expireAt.IsZero() && !time.Now().Before(c.expireAt) {
+		delete(s.counters, key)
+		return 0
+	}
+	return c.value
+}
+
+func (s *memoryStore) Add(_ context.Context, key string, n, limit int64, expireAt time.Time) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.get(key)
+	if n > 0 && limit >= 0 && cur+n > limit {
+		return cur, false, nil
+	}
+	cur = max(cur+n, 0)
+	s.counters[key] = memoryCounter{value: cur, expireAt: expireAt}
+	return cur, true, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key), nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value int64, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key] = memoryCounter{value: value, expireAt: expireAt}
+	return nil
+}
+
+func (s *memoryStore) GetSettings(_ context.Context, subject string) (Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[subject], nil
+}
+
+func (s *memoryStore) SaveSettings(_ context.Context, subject string, st Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings[subject] = st
+	return nil
+}
+
+// redisStore keeps counters as Redis strings and settings in the <prefix>settings hash
+// redisStore 将计数保存为 Redis 字符串，设置保存在 <prefix>settings 哈希中
+type redisStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a store backed by Redis, prefix namespaces the settings hash (default "quota:")
+// NewRedisStore 创建基于 Redis 的存储，prefix 为设置哈希的命名空间（默认 "quota:"）
+func NewRedisStore(client redis.UniversalClient, prefix string) Store {
+	if prefix == "" {
+		prefix = "quota:"
+	}
+	return &redisStore{rdb: client, prefix: prefix}
+}
+
+// Lua script: check and add in one step so concurrent requests cannot overshoot the limit
+var addScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local n = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local expire_at = tonumber(ARGV[3])
+
+if n > 0 and limit >= 0 and cur + n > limit then
+    return {cur, 0}
+end
+
+cur = cur + n
+if cur < 0 then
+    cur = 0
+end
+redis.call('SET', KEYS[1], cur)
+if expire_at > 0 then
+    redis.call('PEXPIREAT', KEYS[1], expire_at)
+end
+return {cur, 1}
+`)
+
+// unixMilli returns t in Unix milliseconds, 0 for the zero time | unixMilli 返回 t 的 Unix 毫秒数，零值返回 0
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func (s *redisStore) Add(ctx context.Context, key string, n, limit int64, expireAt time.Time) (int64, bool, error) {
+	res, err := addScript.Run(ctx, s.rdb, []string{key}, n, limit, unixMilli(expireAt)).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	return res[0], res[1] == 1, nil
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (int64, error) {
+	v, err := s.rdb.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value int64, expireAt time.Time) error {
+	if expireAt.IsZero() {
+		return s.rdb.Set(ctx, key, value, 0).Err()
+	}
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, key, value, 0)
+		p.PExpireAt(ctx, key, expireAt)
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) GetSettings(ctx context.Context, subject string) (Settings, error) {
+	var st Settings
+	raw, err := s.rdb.HGet(ctx, s.prefix+"settings", subject).Result()
+	if errors.Is(err, redis.Nil) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		return st, fmt.Errorf("quota: decode settings of %s: %w", subject, err)
+	}
+	return st, nil
+}
+
+func (s *redisStore) SaveSettings(ctx context.Context, subject string, st Settings) error {
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.rdb.HSet(ctx, s.prefix+"settings", subject, raw).Err()
+}
@@ -48,9 +48,11 @@ if count < limit then
     -- Add current request
     redis.call('ZADD', key, now, now .. '-' .. math.random())
     redis.call('PEXPIRE', key, window)
-    return {1, limit - count - 1}
+    return {1, limit - count - 1, 0}
 else
-    return {0, 0}
+    -- The window frees a slot when the oldest request expires
+    local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+    return {0, 0, tonumber(oldest[2])}
 end
 `)
 
@@ -70,5 +72,10 @@ func (r *redisLimiter) Allow(ctx context.Context, key string, limit int, window
 	remaining := int(result[1].(int64))
 	resetAt := now.Add(window)
 
+	// When denied, reset is when the oldest request leaves the window
+	if oldest := result[2].(int64); !allowed && oldest > 0 {
+		resetAt = time.UnixMilli(oldest).Add(window)
+	}
+
 	return allowed, remaining, resetAt
 }
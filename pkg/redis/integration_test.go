@@ -0,0 +1,125 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuohe369/crab/pkg/redis"
+	"github.com/nuohe369/crab/pkg/testing/containers"
+)
+
+// withDB returns cfg using another database of the same server | withDB 返回使用同一服务器另一数据库的 cfg
+func withDB(cfg redis.Config, db int) redis.Config {
+	cfg.DB = db
+	return cfg
+}
+
+// TestMultipleRedisConfig tests multiple Redis configuration
+// TestMultipleRedisConfig 测试多 Redis 配置
+func TestMultipleRedisConfig(t *testing.T) {
+	cfg := containers.Redis(t)
+	t.Cleanup(redis.Close)
+
+	err := redis.InitMultiple(map[string]redis.Config{
+		"default": cfg,
+		"cache":   withDB(cfg, 1),
+		"session": withDB(cfg, 2),
+	})
+	if err != nil {
+		t.Fatalf("InitMultiple failed: %v", err)
+	}
+
+	defaultClient := redis.Get()
+	if defaultClient == nil {
+		t.Fatal("Expected default client to be non-nil")
+	}
+	if defaultClient != redis.Get("default") {
+		t.Error("Expected Get() and Get('default') to return same instance")
+	}
+	if redis.Get("cache") == nil || redis.Get("session") == nil {
+		t.Error("Expected named clients to be non-nil")
+	}
+	if redis.Get("nonexistent") != nil {
+		t.Error("Expected non-existent client to be nil")
+	}
+
+	// Each name talks to its own database | 每个名称使用各自的数据库
+	ctx := context.Background()
+	if err := redis.Get("cache").Set(ctx, "key", "cache", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if ok, _ := redis.Get("session").Exists(ctx, "key"); ok {
+		t.Error("Expected session database not to see the cache key")
+	}
+	if v, err := redis.Get("cache").Get(ctx, "key"); err != nil || v != "cache" {
+		t.Errorf("Get = %q, %v, want cache", v, err)
+	}
+}
+
+// TestSingleRedisBackwardCompatibility tests backward compatibility with single Redis
+// TestSingleRedisBackwardCompatibility 测试单 Redis 向后兼容性
+func TestSingleRedisBackwardCompatibility(t *testing.T) {
+	cfg := containers.Redis(t)
+	t.Cleanup(redis.Close)
+
+	if err := redis.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	client := redis.Get()
+	if client == nil {
+		t.Fatal("Expected client to be non-nil")
+	}
+	if client != redis.Get("default") {
+		t.Error("Expected Get() and Get('default') to return same instance")
+	}
+}
+
+// TestInitNamed tests InitNamed function
+// TestInitNamed 测试 InitNamed 函数
+func TestInitNamed(t *testing.T) {
+	cfg := containers.Redis(t)
+	redis.Close()
+	t.Cleanup(redis.Close)
+
+	if err := redis.InitNamed("default", cfg); err != nil {
+		t.Fatalf("InitNamed failed: %v", err)
+	}
+
+	// "default" also becomes the client of Get() | "default" 同时成为 Get() 的客户端
+	client := redis.Get()
+	if client == nil {
+		t.Fatal("Expected Get() to return client after InitNamed('default')")
+	}
+	if client != redis.Get("default") {
+		t.Error("Expected Get() and Get('default') to return same instance")
+	}
+}
+
+// TestCloseAllClients tests that Close closes all clients
+// TestCloseAllClients 测试 Close 关闭所有客户端
+func TestCloseAllClients(t *testing.T) {
+	cfg := containers.Redis(t)
+
+	err := redis.InitMultiple(map[string]redis.Config{
+		"default": cfg,
+		"cache":   withDB(cfg, 1),
+	})
+	if err != nil {
+		t.Fatalf("InitMultiple failed: %v", err)
+	}
+	if redis.Get() == nil || redis.Get("cache") == nil {
+		t.Fatal("Expected clients to exist")
+	}
+
+	redis.Close()
+
+	if redis.Get() != nil {
+		t.Error("Expected default client to be nil after Close()")
+	}
+	if redis.Get("cache") != nil {
+		t.Error("Expected cache client to be nil after Close()")
+	}
+	if redis.Get("default") != nil {
+		t.Error("Expected default client by name to be nil after Close()")
+	}
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// memoryTick is how often the memory server advances key TTLs | memoryTick 为内存服务器推进键 TTL 的间隔
+const memoryTick = 100 * time.Millisecond
+
+// memoryServer is the in-process Redis of the memory driver
+// miniredis only expires keys when told to, so a ticker advances its clock with the wall clock.
+// memoryServer 为 memory 驱动的进程内 Redis
+// miniredis 仅在被通知时才使键过期，因此由定时器按实际时间推进其时钟。
+type memoryServer struct {
+	*miniredis.Miniredis
+	stop chan struct{}
+	once sync.Once
+}
+
+// startMemory starts an in-process server on a loopback port
+// startMemory 在回环端口上启动进程内服务器
+func startMemory() (*memoryServer, error) {
+	mr := miniredis.NewMiniRedis()
+	if err := mr.Start(); err != nil {
+		return nil, err
+	}
+	s := &memoryServer{Miniredis: mr, stop: make(chan struct{})}
+	go s.tick()
+	return s, nil
+}
+
+// tick advances key TTLs until the server is closed | tick 推进键 TTL，直到服务器关闭
+func (s *memoryServer) tick() {
+	ticker := time.NewTicker(memoryTick)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// Close stops the server, the data is lost | Close 停止服务器，数据随之丢失
+func (s *memoryServer) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+		s.Miniredis.Close()
+	})
+}
@@ -16,12 +16,14 @@ import (
 type UniversalClient interface {
 	redis.Cmdable
 	Close() error
+	PoolStats() *redis.PoolStats
 }
 
 // Client wraps Redis client
 // Client 封装 Redis 客户端
 type Client struct {
 	client UniversalClient
+	server *memoryServer // In-process server of the memory driver | memory 驱动的进程内服务器
 }
 
 // Config represents Redis configuration
@@ -35,6 +37,11 @@ type Config struct {
 	// Cluster mode (comma-separated address list, e.g. "host1:6379,host2:6379,host3:6379")
 	// 集群模式（逗号分隔的地址列表，例如 "host1:6379,host2:6379,host3:6379"）
 	Cluster string `toml:"cluster"`
+
+	// Driver is redis (default) or memory, an in-process server for tests and local development
+	// whose data is lost on exit; the connection settings above are then ignored
+	// Driver 为 redis（默认）或 memory，即用于测试和本地开发的进程内服务器，退出时数据丢失；此时忽略上面的连接设置
+	Driver string `toml:"driver"`
 }
 
 var (
@@ -139,12 +146,49 @@ func Close() {
 	mu.Unlock()
 }
 
+// Stats represents connection pool statistics of a named client
+// Stats 表示命名客户端的连接池统计信息
+type Stats struct {
+	Name string
+	redis.PoolStats
+}
+
+// AllStats returns pool statistics of all initialized clients
+// AllStats 返回所有已初始化客户端的连接池统计信息
+func AllStats() []Stats {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	stats := make([]Stats, 0, len(clients))
+	for name, client := range clients {
+		if name == "" || client == nil {
+			continue // Same client as "default" | 与 "default" 为同一客户端
+		}
+		stats = append(stats, Stats{Name: name, PoolStats: *client.client.PoolStats()})
+	}
+	return stats
+}
+
 // New creates Redis client (auto detect standalone/cluster mode)
 // New 创建 Redis 客户端（自动检测单机/集群模式）
 func New(cfg Config) (*Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	var server *memoryServer
+	switch cfg.Driver {
+	case "", "redis":
+	case "memory":
+		var err error
+		if server, err = startMemory(); err != nil {
+			return nil, fmt.Errorf("redis: failed to start memory server: %w", err)
+		}
+		cfg = Config{Addr: server.Addr(), DB: cfg.DB}
+		log.Printf("redis: memory server started, address: %s", cfg.Addr)
+	default:
+		return nil, fmt.Errorf("redis: unsupported driver: %s", cfg.Driver)
+	}
+
 	var client UniversalClient
 
 	if cfg.Cluster != "" {
@@ -172,6 +216,8 @@ func New(cfg Config) (*Client, error) {
 			MaxRetryBackoff:    512 * time.Millisecond, // Max retry backoff | 最大重试间隔
 		})
 
+		clusterClient.AddHook(tracingHook{})
+
 		if err := clusterClient.Ping(ctx).Err(); err != nil {
 			return nil, err
 		}
@@ -199,6 +245,8 @@ func New(cfg Config) (*Client, error) {
 			MaxRetryBackoff:    512 * time.Millisecond, // Max retry backoff | 最大重试间隔
 		})
 
+		standaloneClient.AddHook(tracingHook{})
+
 		if err := standaloneClient.Ping(ctx).Err(); err != nil {
 			return nil, err
 		}
@@ -207,7 +255,7 @@ func New(cfg Config) (*Client, error) {
 		log.Printf("redis: standalone mode connected, address: %s", cfg.Addr)
 	}
 
-	return &Client{client: client}, nil
+	return &Client{client: client, server: server}, nil
 }
 
 // GetRaw returns underlying Redis client
@@ -285,7 +333,11 @@ func (c *Client) HExists(ctx context.Context, key, field string) (bool, error) {
 // Close closes connection
 // Close 关闭连接
 func (c *Client) Close() error {
-	return c.client.Close()
+	err := c.client.Close()
+	if c.server != nil {
+		c.server.Close()
+	}
+	return err
 }
 
 // Keys finds matching keys (supports wildcards)
@@ -295,3 +347,33 @@ func (c *Client) Close() error {
 func (c *Client) Keys(ctx context.Context, pattern string) ([]string, error) {
 	return c.client.Keys(ctx, pattern).Result()
 }
+
+// Expire sets key expiration
+// Expire 设置键过期时间
+func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return c.client.Expire(ctx, key, expiration).Err()
+}
+
+// SAdd adds members to a Set
+// SAdd 向 Set 添加成员
+func (c *Client) SAdd(ctx context.Context, key string, members ...any) error {
+	return c.client.SAdd(ctx, key, members...).Err()
+}
+
+// SRem removes members from a Set
+// SRem 从 Set 移除成员
+func (c *Client) SRem(ctx context.Context, key string, members ...any) error {
+	return c.client.SRem(ctx, key, members...).Err()
+}
+
+// SMembers gets all members of a Set
+// SMembers 获取 Set 的所有成员
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, key).Result()
+}
+
+// IsNil checks if the error indicates a missing key
+// IsNil 检查错误是否表示键不存在
+func IsNil(err error) bool {
+	return err == redis.Nil
+}
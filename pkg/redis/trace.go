@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook wraps commands in client spans when the context is part of a trace
+// Commands without a parent span, e.g. background polling, are not traced.
+// tracingHook 在 context 属于某条链路时将命令包装为客户端 span
+// 没有父 span 的命令（例如后台轮询）不会被追踪。
+type tracingHook struct{}
+
+var _ redis.Hook = tracingHook{}
+
+// startSpan starts a child span of the span in ctx, or returns nil when there is none
+// startSpan 创建 ctx 中 span 的子 span，若不存在则返回 nil
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, nil
+	}
+	attrs = append(attrs, attribute.String("db.system.name", "redis"))
+	return parent.TracerProvider().Tracer("redis").Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// endSpan records a command error other than redis.Nil and ends the span
+// endSpan 记录 redis.Nil 以外的命令错误并结束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// DialHook implements redis.Hook
+// DialHook 实现 redis.Hook
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+// ProcessHook 实现 redis.Hook
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := strings.ToUpper(cmd.Name())
+		ctx, span := startSpan(ctx, name, attribute.String("db.operation.name", name))
+		if span == nil {
+			return next(ctx, cmd)
+		}
+		err := next(ctx, cmd)
+		endSpan(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+// ProcessPipelineHook 实现 redis.Hook
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !trace.SpanFromContext(ctx).IsRecording() {
+			return next(ctx, cmds)
+		}
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = strings.ToUpper(cmd.Name())
+		}
+		ctx, span := startSpan(ctx, "PIPELINE",
+			attribute.String("db.operation.name", "PIPELINE"),
+			attribute.StringSlice("db.redis.commands", names),
+			attribute.Int("db.operation.batch.size", len(cmds)))
+		err := next(ctx, cmds)
+		endSpan(span, err)
+		return err
+	}
+}
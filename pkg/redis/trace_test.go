@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	process := tracingHook{}.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		cmd.SetErr(redis.Nil)
+		return redis.Nil
+	})
+
+	// Untraced contexts such as background polling create no spans | 未追踪的 context（例如后台轮询）不创建 span
+	process(context.Background(), redis.NewStringCmd(context.Background(), "get", "k"))
+	if n := len(recorder.Ended()); n != 0 {
+		t.Fatalf("recorded %d spans without a parent", n)
+	}
+
+	ctx, parent := tracer.Start(context.Background(), "request")
+	process(ctx, redis.NewStringCmd(ctx, "get", "k"))
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	cmd := spans[0]
+	if cmd.Name() != "GET" || cmd.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span = %s %v, want GET client", cmd.Name(), cmd.SpanKind())
+	}
+	if cmd.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("command span is not a child of the request span")
+	}
+	if len(cmd.Events()) != 0 {
+		t.Error("redis.Nil should not be recorded as an error")
+	}
+}
@@ -0,0 +1,155 @@
+// Package scaffold generates the source skeleton of a new module
+// The skeleton follows the module/testapi layout: module.go registers the module
+// with boot, internal/model holds its tables and internal/handler its routes.
+// Package scaffold 生成新模块的源码骨架
+// 骨架遵循 module/testapi 的布局：module.go 向 boot 注册模块，
+// internal/model 存放数据表，internal/handler 存放路由。
+//
+// Usage | 用法:
+//
+//	path, _ := scaffold.ModulePath("go.mod")
+//	files, err := scaffold.NewModule(scaffold.Options{Name: "order", ModulePath: path, Dir: "."})
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// FrameworkPath is the import path of the framework packages used by generated code
+// FrameworkPath 是生成代码所使用的框架包导入路径
+const FrameworkPath = "github.com/nuohe369/crab"
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Options configures a generated module
+// Options 配置生成的模块
+type Options struct {
+	Name       string // Module name, also its package, route prefix and table prefix | 模块名称，同时用作包名、路由前缀和表名前缀
+	ModulePath string // Go module path of the project (see ModulePath) | 项目的 Go 模块路径（参见 ModulePath）
+	Dir        string // Project root, the module is written to {Dir}/module/{Name} | 项目根目录，模块写入 {Dir}/module/{Name}
+}
+
+// data is passed to the templates
+// data 传递给模板
+type data struct {
+	Name       string // order_item
+	Type       string // OrderItem
+	Route      string // order-item
+	ModulePath string
+	Framework  string
+}
+
+// ValidName checks that name can be used as a package name
+// ValidName 检查 name 是否可以用作包名
+func ValidName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("scaffold: invalid module name %q, use lowercase letters, digits and underscores", name)
+	}
+	if token.IsKeyword(name) || name == "internal" {
+		return fmt.Errorf("scaffold: module name %q is reserved", name)
+	}
+	return nil
+}
+
+// ModulePath reads the module path from a go.mod file
+// ModulePath 从 go.mod 文件中读取模块路径
+func ModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("scaffold: no module line in %s", goMod)
+}
+
+// NewModule writes the skeleton and returns the created files; an existing module directory is never overwritten
+// NewModule 写入骨架并返回创建的文件；已存在的模块目录不会被覆盖
+func NewModule(opts Options) ([]string, error) {
+	if err := ValidName(opts.Name); err != nil {
+		return nil, err
+	}
+	if opts.ModulePath == "" {
+		return nil, fmt.Errorf("scaffold: module path is required")
+	}
+	root := filepath.Join(opts.Dir, "module", opts.Name)
+	if _, err := os.Stat(root); err == nil {
+		return nil, fmt.Errorf("scaffold: %s already exists", root)
+	}
+
+	d := data{
+		Name:       opts.Name,
+		Type:       typeName(opts.Name),
+		Route:      strings.ReplaceAll(opts.Name, "_", "-"),
+		ModulePath: opts.ModulePath,
+		Framework:  FrameworkPath,
+	}
+	// Render everything first so a template error leaves nothing behind | 先渲染全部文件，模板出错时不会留下残缺文件
+	rendered := make([][]byte, len(files))
+	for i, f := range files {
+		src, err := render(f.body, d)
+		if err != nil {
+			return nil, fmt.Errorf("scaffold: render %s: %w", f.path, err)
+		}
+		rendered[i] = src
+	}
+
+	created := make([]string, 0, len(files))
+	for i, f := range files {
+		path := filepath.Join(root, strings.ReplaceAll(f.path, "{name}", opts.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(path, rendered[i], 0644); err != nil {
+			return created, err
+		}
+		created = append(created, path)
+	}
+	return created, nil
+}
+
+// render executes a template and gofmts the result
+// render 执行模板并对结果执行 gofmt
+func render(body string, d data) ([]byte, error) {
+	t, err := template.New("").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// typeName converts snake_case to CamelCase
+// typeName 将 snake_case 转换为 CamelCase
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewModule(t *testing.T) {
+	dir := t.TempDir()
+	files, err := NewModule(Options{Name: "order_item", ModulePath: "example.com/shop", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 6 {
+		t.Fatalf("files = %v", files)
+	}
+
+	read := func(rel string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, "module", "order_item", rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	mod := read("module.go")
+	for _, want := range []string{
+		"package order_item",
+		`"example.com/shop/module/order_item/internal/handler"`,
+		`"github.com/nuohe369/crab/boot"`,
+		"new(model.OrderItem)",
+	} {
+		if !strings.Contains(mod, want) {
+			t.Errorf("module.go misses %s", want)
+		}
+	}
+	if !strings.Contains(read("internal/handler/order_item.go"), `router.Group("/order-item")`) {
+		t.Error("routes are not grouped under /order-item")
+	}
+	if !strings.Contains(read("internal/model/order_item.go"), `return "order_item"`) {
+		t.Error("table name is not order_item")
+	}
+
+	if _, err := NewModule(Options{Name: "order_item", ModulePath: "example.com/shop", Dir: dir}); err == nil {
+		t.Error("existing module should not be overwritten")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, ok := range map[string]bool{"order": true, "order_item2": true, "Order": false, "2fa": false, "go-pay": false, "type": false, "internal": false} {
+		if err := ValidName(name); (err == nil) != ok {
+			t.Errorf("ValidName(%q) = %v", name, err)
+		}
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	os.WriteFile(path, []byte("// comment\nmodule example.com/shop\n\ngo 1.24\n"), 0644)
+	got, err := ModulePath(path)
+	if err != nil || got != "example.com/shop" {
+		t.Errorf("ModulePath = %q, %v", got, err)
+	}
+}
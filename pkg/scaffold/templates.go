@@ -0,0 +1,249 @@
+package scaffold
+
+// files lists the generated files relative to module/{name}
+// files 列出相对于 module/{name} 生成的文件
+var files = []struct {
+	path string
+	body string
+}{
+	{"module.go", moduleTmpl},
+	{"module_test.go", moduleTestTmpl},
+	{"internal/model/{name}.go", modelTmpl},
+	{"internal/handler/handler.go", handlerTmpl},
+	{"internal/handler/{name}.go", resourceTmpl},
+	{"internal/handler/handler_test.go", handlerTestTmpl},
+}
+
+const moduleTmpl = `package {{.Name}}
+
+import (
+	"{{.Framework}}/boot"
+	"{{.ModulePath}}/module/{{.Name}}/internal/handler"
+	"{{.ModulePath}}/module/{{.Name}}/internal/model"
+)
+
+func init() {
+	boot.Register(&Module{})
+}
+
+// Module is the {{.Name}} module
+// Module 是 {{.Name}} 模块
+type Module struct{}
+
+func (m *Module) Name() string {
+	return "{{.Name}}"
+}
+
+func (m *Module) Models() []any {
+	return []any{
+		new(model.{{.Type}}),
+	}
+}
+
+func (m *Module) Init(ctx *boot.ModuleContext) error {
+	handler.Setup(ctx.Router)
+	return nil
+}
+
+func (m *Module) Start() error {
+	return nil
+}
+
+func (m *Module) Stop() error {
+	return nil
+}
+`
+
+const moduleTestTmpl = `package {{.Name}}
+
+import "testing"
+
+func TestModule(t *testing.T) {
+	m := &Module{}
+	if m.Name() != "{{.Name}}" {
+		t.Errorf("Name() = %q", m.Name())
+	}
+	if len(m.Models()) == 0 {
+		t.Error("Models() is empty")
+	}
+}
+`
+
+const modelTmpl = `package model
+
+import (
+	"time"
+
+	"{{.Framework}}/pkg/snowflake"
+)
+
+// {{.Type}} represents a {{.Name}} record
+// {{.Type}} 表示一条 {{.Name}} 记录
+type {{.Type}} struct {
+	ID        snowflake.SnowflakeID ` + "`" + `json:"id" xorm:"pk 'id' bigint"` + "`" + `
+	Name      string                ` + "`" + `json:"name" xorm:"varchar(100) notnull 'name'"` + "`" + `
+	Status    int                   ` + "`" + `json:"status" xorm:"default(1) 'status'"` + "`" + `
+	CreatedAt time.Time             ` + "`" + `json:"created_at" xorm:"created 'created_at'"` + "`" + `
+	UpdatedAt time.Time             ` + "`" + `json:"updated_at" xorm:"updated 'updated_at'"` + "`" + `
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (m *{{.Type}}) TableName() string {
+	return "{{.Name}}"
+}
+
+// BeforeInsert generates snowflake ID before insertion
+// BeforeInsert 插入前生成雪花 ID
+func (m *{{.Type}}) BeforeInsert() {
+	if m.ID.IsZero() {
+		m.ID = snowflake.SnowflakeID(snowflake.Generate())
+	}
+}
+`
+
+const handlerTmpl = `package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Setup registers all routes
+// Setup 注册所有路由
+func Setup(router fiber.Router) {
+	Setup{{.Type}}(router)
+}
+`
+
+const resourceTmpl = `package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"{{.Framework}}/common/errors"
+	basemodel "{{.Framework}}/common/model"
+	"{{.Framework}}/common/request"
+	"{{.Framework}}/common/response"
+	"{{.Framework}}/pkg/util"
+	"{{.ModulePath}}/module/{{.Name}}/internal/model"
+)
+
+// create{{.Type}}Req is the body of POST /{{.Route}}
+// create{{.Type}}Req 是 POST /{{.Route}} 的请求体
+type create{{.Type}}Req struct {
+	Name string ` + "`" + `json:"name"` + "`" + `
+}
+
+// Setup{{.Type}} registers {{.Name}} routes
+// Setup{{.Type}} 注册 {{.Name}} 路由
+func Setup{{.Type}}(router fiber.Router) {
+	g := router.Group("/{{.Route}}")
+	g.Post("/", Create{{.Type}})
+	g.Get("/:id", Get{{.Type}})
+	g.Delete("/:id", Delete{{.Type}})
+	g.Get("/", List{{.Type}})
+}
+
+// Create{{.Type}} creates a {{.Name}}
+// Create{{.Type}} 创建 {{.Name}}
+func Create{{.Type}}(c *fiber.Ctx) error {
+	var req create{{.Type}}Req
+	if err := c.BodyParser(&req); err != nil {
+		return errors.ErrParamInvalid()
+	}
+	if req.Name == "" {
+		return errors.New(response.CodeParamMissing, "name required")
+	}
+
+	item := &model.{{.Type}}{Name: req.Name}
+	if _, err := basemodel.GetDB(item).Context(c.UserContext()).Insert(item); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return response.OK(c, item)
+}
+
+// Get{{.Type}} gets a {{.Name}}
+// Get{{.Type}} 获取 {{.Name}}
+func Get{{.Type}}(c *fiber.Ctx) error {
+	id := util.MustStringToInt64(c.Params("id"))
+	if id == 0 {
+		return errors.ErrParamInvalid()
+	}
+
+	item := &model.{{.Type}}{}
+	has, err := basemodel.GetDB(item).Context(c.UserContext()).ID(id).Get(item)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	if !has {
+		return errors.ErrNotFound()
+	}
+	return response.OK(c, item)
+}
+
+// Delete{{.Type}} deletes a {{.Name}}
+// Delete{{.Type}} 删除 {{.Name}}
+func Delete{{.Type}}(c *fiber.Ctx) error {
+	id := util.MustStringToInt64(c.Params("id"))
+	if id == 0 {
+		return errors.ErrParamInvalid()
+	}
+
+	item := &model.{{.Type}}{}
+	if _, err := basemodel.GetDB(item).Context(c.UserContext()).ID(id).Delete(item); err != nil {
+		return errors.ErrDBError(err)
+	}
+	return response.OK(c, nil)
+}
+
+// List{{.Type}} lists {{.Name}} records
+// List{{.Type}} {{.Name}} 列表
+func List{{.Type}}(c *fiber.Ctx) error {
+	var req request.PageReq
+	if err := c.QueryParser(&req); err != nil {
+		return errors.ErrParamInvalid()
+	}
+
+	item := &model.{{.Type}}{}
+	var list []model.{{.Type}}
+	total, err := basemodel.GetDB(item).Context(c.UserContext()).
+		Limit(req.GetSize(), req.GetOffset()).
+		Desc("created_at").
+		FindAndCount(&list)
+	if err != nil {
+		return errors.ErrDBError(err)
+	}
+	return response.OKList(c, list, total, req.GetPage(), req.GetSize())
+}
+`
+
+const handlerTestTmpl = `package handler
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSetupRoutes(t *testing.T) {
+	app := fiber.New()
+	Setup(app)
+
+	want := map[string]bool{
+		"POST /{{.Route}}/":      false,
+		"GET /{{.Route}}/:id":    false,
+		"DELETE /{{.Route}}/:id": false,
+		"GET /{{.Route}}/":       false,
+	}
+	for _, r := range app.GetRoutes(true) {
+		key := r.Method + " " + r.Path
+		if _, ok := want[key]; ok {
+			want[key] = true
+		}
+	}
+	for route, found := range want {
+		if !found {
+			t.Errorf("route %s is not registered", route)
+		}
+	}
+}
+`
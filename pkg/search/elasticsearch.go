@@ -0,0 +1,172 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// elasticEngine uses the Elasticsearch REST API (7.x and 8.x)
+// elasticEngine 使用 Elasticsearch REST API（7.x 和 8.x）
+type elasticEngine struct{}
+
+// boosts maps field weights to multi_match boosts | boosts 将字段权重映射为 multi_match 的提升系数
+var boosts = map[string]int{"A": 4, "B": 3, "C": 2, "D": 1}
+
+func (elasticEngine) ensure(ctx context.Context, c *Client, idx *Index) error {
+	// Full-text fields are analyzed, other strings are keywords usable in filters and sorts
+	// 全文字段会被分词，其他字符串为可用于过滤和排序的 keyword
+	properties := make(map[string]any, len(idx.Fields))
+	for _, f := range idx.Fields {
+		properties[f.Name] = map[string]string{"type": "text"}
+	}
+	body, _ := json.Marshal(map[string]any{
+		"mappings": map[string]any{
+			"dynamic_templates": []any{
+				map[string]any{"strings": map[string]any{
+					"match_mapping_type": "string",
+					"mapping":            map[string]string{"type": "keyword"},
+				}},
+			},
+			"properties": properties,
+		},
+	})
+	err := c.do(ctx, http.MethodPut, "/"+url.PathEscape(c.name(idx)), body, "", nil)
+	var e *Error
+	if errors.As(err, &e) && e.Code == "resource_already_exists_exception" {
+		return nil
+	}
+	return err
+}
+
+func (elasticEngine) index(ctx context.Context, c *Client, idx *Index, docs []Document) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, d := range docs {
+		// Partial documents are merged, missing documents created | 部分文档会被合并，不存在的文档会被创建
+		_ = enc.Encode(map[string]any{"update": map[string]string{"_index": c.name(idx), "_id": d.ID}})
+		if err := enc.Encode(map[string]any{"doc": d.Fields, "doc_as_upsert": true}); err != nil {
+			return err
+		}
+	}
+	return elasticBulk(ctx, c, buf.Bytes())
+}
+
+func (elasticEngine) remove(ctx context.Context, c *Client, idx *Index, ids []string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		_ = enc.Encode(map[string]any{"delete": map[string]string{"_index": c.name(idx), "_id": id}})
+	}
+	return elasticBulk(ctx, c, buf.Bytes())
+}
+
+// elasticBulk sends a bulk request, returning the first failed item as an error
+// elasticBulk 发送批量请求，将第一个失败的条目作为错误返回
+func elasticBulk(ctx context.Context, c *Client, body []byte) error {
+	var resp struct {
+		Errors bool                                 `json:"errors"`
+		Items  []map[string]elasticBulkItemResponse `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/_bulk", body, "application/x-ndjson", &resp); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for _, r := range item {
+			if r.Error != nil {
+				return &Error{Driver: DriverElasticsearch, Status: r.Status, Code: r.Error.Type, Message: r.Error.Reason}
+			}
+		}
+	}
+	return nil
+}
+
+// elasticBulkItemResponse is the result of one bulk action | elasticBulkItemResponse 表示一个批量操作的结果
+type elasticBulkItemResponse struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func (elasticEngine) search(ctx context.Context, c *Client, idx *Index, q *Query) (*Result, error) {
+	limit, offset := q.page()
+	body, err := json.Marshal(elasticQuery(idx, q, limit, offset))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Score  *float64        `json:"_score"` // null when sorted by fields | 按字段排序时为 null
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(c.name(idx))+"/_search", body, "", &resp); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Hits: make([]Hit, len(resp.Hits.Hits)), Total: resp.Hits.Total.Value}
+	for i, h := range resp.Hits.Hits {
+		res.Hits[i] = Hit{ID: h.ID, Source: h.Source}
+		if h.Score != nil {
+			res.Hits[i].Score = *h.Score
+		}
+	}
+	return res, nil
+}
+
+// elasticQuery returns the search request body of q | elasticQuery 返回 q 的搜索请求体
+func elasticQuery(idx *Index, q *Query, limit, offset int) map[string]any {
+	must := map[string]any{"match_all": map[string]any{}}
+	if q.Text != "" {
+		fields := make([]string, len(idx.Fields))
+		for i, f := range idx.Fields {
+			fields[i] = fmt.Sprintf("%s^%d", f.Name, boosts[f.weight()])
+		}
+		must = map[string]any{"multi_match": map[string]any{"query": q.Text, "fields": fields}}
+	}
+
+	filter := make([]any, 0, len(q.Filters))
+	for _, field := range q.filterFields() {
+		if vs := values(q.Filters[field]); len(vs) == 1 {
+			filter = append(filter, map[string]any{"term": map[string]any{field: vs[0]}})
+		} else {
+			filter = append(filter, map[string]any{"terms": map[string]any{field: vs}})
+		}
+	}
+
+	body := map[string]any{
+		"from":             offset,
+		"size":             limit,
+		"track_total_hits": true,
+		"query":            map[string]any{"bool": map[string]any{"must": must, "filter": filter}},
+	}
+	if len(q.Sort) > 0 {
+		sorts := make([]any, 0, len(q.Sort))
+		for _, s := range q.Sort {
+			field, desc := parseSort(s)
+			order := "asc"
+			if desc {
+				order = "desc"
+			}
+			sorts = append(sorts, map[string]any{field: map[string]string{"order": order}})
+		}
+		body["sort"] = sorts
+	}
+	return body
+}
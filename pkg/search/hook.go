@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"xorm.io/xorm"
+)
+
+// ErrNotConfigured is returned by Find when no search driver is configured
+// ErrNotConfigured 表示未配置搜索驱动，由 Find 返回
+var ErrNotConfigured = errors.New("search: not configured")
+
+// Indexable is a model kept in sync with a search index.
+// Indexable 表示与搜索索引保持同步的模型
+//
+// SearchDocument reloads the record rather than reading the bean, since updates often set
+// only some columns; it returns false once the record is gone, including soft deletes.
+// SearchDocument 会重新加载记录而不是读取 bean，因为更新经常只设置部分列；
+// 记录不存在（包括软删除）时返回 false。
+//
+// Example | 示例:
+//
+//	func (a *Article) AfterInsert() { search.Sync(a) }
+//	func (a *Article) AfterUpdate() { search.Sync(a) }
+//	func (a *Article) AfterDelete() { search.Sync(a) }
+type Indexable interface {
+	SearchIndex() string                                              // Registered index name | 已注册的索引名
+	SearchID() string                                                 // Document ID, empty skips syncing | 文档 ID，为空时跳过同步
+	SearchDocument(ctx context.Context) (map[string]any, bool, error) // Current fields, false when gone | 当前字段，不存在时返回 false
+}
+
+// Sync refreshes the document of v in the background with the default client, for xorm hooks
+// With the postgres driver it does nothing, the trigger already updated the row.
+// Sync 使用默认客户端在后台刷新 v 的文档，用于 xorm 钩子
+// 使用 postgres 驱动时不做任何操作，触发器已经更新了该行。
+func Sync(v Indexable) {
+	c := defaultClient
+	if c == nil || c.config.Driver == DriverPostgres || v.SearchID() == "" {
+		return
+	}
+	// Hooks run inside the write, which must not wait for the engine | 钩子在写入过程中运行，不能等待搜索引擎
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+		defer cancel()
+		if err := c.Sync(ctx, v); err != nil {
+			log.Printf("search: sync %s %s failed: %v", v.SearchIndex(), v.SearchID(), err)
+		}
+	}()
+}
+
+// Sync indexes the current document of v, or deletes it when the record is gone
+// Sync 索引 v 的当前文档，记录不存在时删除该文档
+func (c *Client) Sync(ctx context.Context, v Indexable) error {
+	fields, ok, err := v.SearchDocument(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.Delete(ctx, v.SearchIndex(), v.SearchID())
+	}
+	return c.Index(ctx, v.SearchIndex(), Document{ID: v.SearchID(), Fields: fields})
+}
+
+// Find runs q on the index of T with the default client and loads the matching rows in rank order.
+// Find 使用默认客户端在 T 的索引上执行 q，并按排名顺序加载匹配的行
+//
+// Rows are read through session, so its conditions (tenant, soft delete) still apply;
+// the total is the one of the search.
+// 行通过 session 读取，因此其条件（租户、软删除）仍然生效；总数为搜索的总数。
+//
+// Usage | 用法:
+//
+//	list, total, err := search.Find[model.Article](ctx, db.NewSession(), &search.Query{Text: kw, Page: page, Size: size})
+func Find[T any, PT interface {
+	*T
+	Indexable
+}](ctx context.Context, session *xorm.Session, q *Query) ([]T, int64, error) {
+	c := defaultClient
+	if c == nil {
+		return nil, 0, ErrNotConfigured
+	}
+	var zero T
+	name := PT(&zero).SearchIndex()
+	res, err := c.Search(ctx, name, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(res.Hits) == 0 {
+		return []T{}, res.Total, nil
+	}
+	idx, err := lookup(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []T
+	if err := session.Context(ctx).In(idx.Key, res.IDs()).Find(&rows); err != nil {
+		return nil, 0, err
+	}
+	return rankOrder[T, PT](rows, res), res.Total, nil
+}
+
+// rankOrder sorts rows like the hits, dropping rows without a hit
+// rankOrder 按结果顺序排列行，丢弃没有对应结果的行
+func rankOrder[T any, PT interface {
+	*T
+	Indexable
+}](rows []T, res *Result) []T {
+	byID := make(map[string]int, len(rows))
+	for i := range rows {
+		byID[PT(&rows[i]).SearchID()] = i
+	}
+	out := make([]T, 0, len(rows))
+	for _, h := range res.Hits {
+		if i, ok := byID[h.ID]; ok {
+			out = append(out, rows[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// meiliEngine uses the Meilisearch REST API; index and settings changes are asynchronous tasks
+// meiliEngine 使用 Meilisearch REST API；索引和设置的变更为异步任务
+type meiliEngine struct{}
+
+func (meiliEngine) ensure(ctx context.Context, c *Client, idx *Index) error {
+	uid := c.name(idx)
+	body, _ := json.Marshal(map[string]string{"uid": uid, "primaryKey": idx.Key})
+	// An existing index only fails the task, not the request | 索引已存在只会导致任务失败，请求本身不会失败
+	if err := c.do(ctx, http.MethodPost, "/indexes", body, "", nil); err != nil {
+		return err
+	}
+
+	// Searchable attributes are ranked by order, highest weight first | 可搜索属性按顺序排名，权重最高的在前
+	fields := append([]Field(nil), idx.Fields...)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].weight() < fields[j].weight() })
+	searchable := make([]string, len(fields))
+	for i, f := range fields {
+		searchable[i] = f.Name
+	}
+	body, _ = json.Marshal(map[string][]string{
+		"searchableAttributes": searchable,
+		"filterableAttributes": nonNil(idx.Filterable),
+		"sortableAttributes":   nonNil(idx.Sortable),
+	})
+	return c.do(ctx, http.MethodPatch, "/indexes/"+url.PathEscape(uid)+"/settings", body, "", nil)
+}
+
+func (meiliEngine) index(ctx context.Context, c *Client, idx *Index, docs []Document) error {
+	batch := make([]map[string]any, len(docs))
+	for i, d := range docs {
+		doc := make(map[string]any, len(d.Fields)+1)
+		for k, v := range d.Fields {
+			doc[k] = v
+		}
+		doc[idx.Key] = d.ID
+		batch[i] = doc
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	// PUT merges the fields into existing documents | PUT 会将字段合并到已有文档中
+	path := "/indexes/" + url.PathEscape(c.name(idx)) + "/documents?primaryKey=" + url.QueryEscape(idx.Key)
+	return c.do(ctx, http.MethodPut, path, body, "", nil)
+}
+
+func (meiliEngine) remove(ctx context.Context, c *Client, idx *Index, ids []string) error {
+	body, _ := json.Marshal(ids)
+	return c.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(c.name(idx))+"/documents/delete-batch", body, "", nil)
+}
+
+func (meiliEngine) search(ctx context.Context, c *Client, idx *Index, q *Query) (*Result, error) {
+	limit, offset := q.page()
+	req := map[string]any{
+		"q":                q.Text,
+		"limit":            limit,
+		"offset":           offset,
+		"showRankingScore": true,
+	}
+	if filter := meiliFilter(q); len(filter) > 0 {
+		req["filter"] = filter
+	}
+	if len(q.Sort) > 0 {
+		sorts := make([]string, len(q.Sort))
+		for i, s := range q.Sort {
+			field, desc := parseSort(s)
+			if desc {
+				sorts[i] = field + ":desc"
+			} else {
+				sorts[i] = field + ":asc"
+			}
+		}
+		req["sort"] = sorts
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Hits               []map[string]json.RawMessage `json:"hits"`
+		EstimatedTotalHits int64                        `json:"estimatedTotalHits"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(c.name(idx))+"/search", body, "", &resp); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Hits: make([]Hit, len(resp.Hits)), Total: resp.EstimatedTotalHits}
+	for i, h := range resp.Hits {
+		var score float64
+		_ = json.Unmarshal(h["_rankingScore"], &score)
+		delete(h, "_rankingScore")
+		source, _ := json.Marshal(h)
+		res.Hits[i] = Hit{ID: rawID(h[idx.Key]), Score: score, Source: source}
+	}
+	return res, nil
+}
+
+// meiliFilter returns the filter expressions of q, ANDed by Meilisearch
+// meiliFilter 返回 q 的过滤表达式，Meilisearch 以 AND 组合
+func meiliFilter(q *Query) []string {
+	out := make([]string, 0, len(q.Filters))
+	for _, field := range q.filterFields() {
+		vs := values(q.Filters[field])
+		// JSON quoting matches the Meilisearch string syntax | JSON 引号与 Meilisearch 字符串语法一致
+		literals := make([]string, len(vs))
+		for i, v := range vs {
+			data, _ := json.Marshal(v)
+			literals[i] = string(data)
+		}
+		if len(literals) == 1 {
+			out = append(out, fmt.Sprintf("%s = %s", field, literals[0]))
+		} else {
+			out = append(out, fmt.Sprintf("%s IN [%s]", field, strings.Join(literals, ", ")))
+		}
+	}
+	return out
+}
+
+// rawID returns a JSON string or number as an ID | rawID 将 JSON 字符串或数字作为 ID 返回
+func rawID(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// nonNil returns an empty list for nil, which Meilisearch rejects | nonNil 将 nil 转为空列表，Meilisearch 不接受 null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"xorm.io/xorm"
+)
+
+// vectorColumn holds the tsvector of each row | vectorColumn 保存每行的 tsvector
+const vectorColumn = "search_vector"
+
+// postgresEngine searches tsvector columns of the indexed tables.
+// postgresEngine 搜索被索引表的 tsvector 列
+//
+// ensure adds the column, a GIN index and a BEFORE INSERT OR UPDATE trigger computing the
+// vector from the weighted fields, so rows stay in sync inside the writing transaction
+// whatever code writes them; existing rows are backfilled.
+// ensure 添加该列、GIN 索引以及根据加权字段计算向量的 BEFORE INSERT OR UPDATE 触发器，
+// 因此无论哪段代码写入，行都会在写入事务内保持同步；已有行会被回填。
+type postgresEngine struct{}
+
+// db returns the engine of the database holding idx | db 返回 idx 所在数据库的引擎
+func (postgresEngine) db(c *Client, idx *Index) (*xorm.Engine, error) {
+	name := idx.Database
+	if name == "" {
+		name = c.config.Database
+	}
+	var client *pgsql.Client
+	if name == "" {
+		client = pgsql.Get()
+	} else {
+		client = pgsql.Get(name)
+	}
+	if client == nil {
+		return nil, fmt.Errorf("search: pgsql database %q not initialized", name)
+	}
+	return client.Engine(), nil
+}
+
+func (e postgresEngine) ensure(ctx context.Context, c *Client, idx *Index) error {
+	db, err := e.db(c, idx)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range postgresSchema(idx, c.config.Language) {
+		if _, err := db.Context(ctx).Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e postgresEngine) index(ctx context.Context, c *Client, idx *Index, docs []Document) error {
+	db, err := e.db(c, idx)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	sql, args := postgresRefresh(idx, c.config.Language, ids)
+	_, err = db.Context(ctx).Exec(append([]any{sql}, args...)...)
+	return err
+}
+
+// remove does nothing: rows are the documents, deleted rows leave the results with them
+// remove 不做任何操作：行即文档，删除的行随之离开搜索结果
+func (postgresEngine) remove(context.Context, *Client, *Index, []string) error {
+	return nil
+}
+
+func (e postgresEngine) search(ctx context.Context, c *Client, idx *Index, q *Query) (*Result, error) {
+	db, err := e.db(c, idx)
+	if err != nil {
+		return nil, err
+	}
+	sql, countSQL, args := postgresSearch(idx, c.config.Language, q)
+
+	var total int64
+	if _, err := db.Context(ctx).SQL(countSQL, args...).Get(&total); err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID    string  `xorm:"id"`
+		Score float64 `xorm:"score"`
+	}
+	if err := db.Context(ctx).SQL(sql, args...).Find(&rows); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Hits: make([]Hit, len(rows)), Total: total}
+	for i, r := range rows {
+		res.Hits[i] = Hit{ID: r.ID, Score: r.Score}
+	}
+	return res, nil
+}
+
+// postgresSchema returns the statements creating the column, index and trigger of idx
+// postgresSchema 返回创建 idx 的列、索引和触发器的语句
+func postgresSchema(idx *Index, lang string) []string {
+	table := quoteIdent(idx.Table)
+	fn := quoteIdent(idx.Table + "_" + vectorColumn)
+	// Updates of other columns keep the vector | 更新其他列时保留原向量
+	columns := make([]string, len(idx.Fields))
+	for i, f := range idx.Fields {
+		columns[i] = quoteIdent(f.Name)
+	}
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s tsvector", table, vectorColumn),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s)",
+			quoteIdent("idx_"+idx.Table+"_"+vectorColumn), table, vectorColumn),
+		fmt.Sprintf("CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$ BEGIN NEW.%s := %s; RETURN NEW; END $$ LANGUAGE plpgsql",
+			fn, vectorColumn, vectorExpr(idx, lang, "NEW.")),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", fn, table),
+		fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT OR UPDATE OF %s ON %s FOR EACH ROW EXECUTE FUNCTION %s()",
+			fn, strings.Join(columns, ", "), table, fn),
+		fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL", table, vectorColumn, vectorExpr(idx, lang, ""), vectorColumn),
+	}
+}
+
+// postgresRefresh returns the statement recomputing the vectors of ids, e.g. after changing weights
+// postgresRefresh 返回重新计算 ids 向量的语句，例如修改权重之后
+func postgresRefresh(idx *Index, lang string, ids []string) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IN (%s)",
+		quoteIdent(idx.Table), vectorColumn, vectorExpr(idx, lang, ""),
+		quoteIdent(idx.Key), placeholders(len(ids))), args
+}
+
+// postgresSearch returns the page and count statements of q and their shared arguments
+// postgresSearch 返回 q 的分页和计数语句及其共用参数
+func postgresSearch(idx *Index, lang string, q *Query) (sql, countSQL string, args []any) {
+	from := " FROM " + quoteIdent(idx.Table)
+	var where []string
+	score := "0"
+	if q.Text != "" {
+		// The query is computed once and joined to every row | 查询只计算一次并与每行连接
+		from += fmt.Sprintf(", websearch_to_tsquery(%s, ?) AS query", quoteLiteral(lang))
+		where = append(where, vectorColumn+" @@ query")
+		score = fmt.Sprintf("ts_rank(%s, query)", vectorColumn)
+		args = append(args, q.Text)
+	}
+	if idx.Where != "" {
+		where = append(where, "("+idx.Where+")")
+	}
+	for _, field := range q.filterFields() {
+		vs := values(q.Filters[field])
+		if len(vs) == 1 {
+			where = append(where, quoteIdent(field)+" = ?")
+		} else {
+			where = append(where, fmt.Sprintf("%s IN (%s)", quoteIdent(field), placeholders(len(vs))))
+		}
+		args = append(args, vs...)
+	}
+	if len(where) > 0 {
+		from += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	order := make([]string, 0, len(q.Sort)+1)
+	for _, s := range q.Sort {
+		field, desc := parseSort(s)
+		if desc {
+			order = append(order, quoteIdent(field)+" DESC")
+		} else {
+			order = append(order, quoteIdent(field)+" ASC")
+		}
+	}
+	if len(order) == 0 {
+		order = append(order, "score DESC")
+	}
+	// The key keeps pages stable between equal scores | 主键保证相同得分时分页稳定
+	order = append(order, quoteIdent(idx.Key))
+
+	limit, offset := q.page()
+	sql = fmt.Sprintf("SELECT %s::text AS id, %s AS score%s ORDER BY %s LIMIT %d OFFSET %d",
+		quoteIdent(idx.Key), score, from, strings.Join(order, ", "), limit, offset)
+	return sql, "SELECT count(*)" + from, args
+}
+
+// vectorExpr returns the weighted tsvector expression of idx, columns prefixed by row
+// vectorExpr 返回 idx 的加权 tsvector 表达式，列名带 row 前缀
+func vectorExpr(idx *Index, lang string, row string) string {
+	parts := make([]string, len(idx.Fields))
+	for i, f := range idx.Fields {
+		parts[i] = fmt.Sprintf("setweight(to_tsvector(%s, coalesce(%s%s::text, '')), '%s')",
+			quoteLiteral(lang), row, quoteIdent(f.Name), f.weight())
+	}
+	return strings.Join(parts, " || ")
+}
+
+// quoteIdent quotes a PostgreSQL identifier | quoteIdent 为 PostgreSQL 标识符加引号
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a PostgreSQL string literal | quoteLiteral 为 PostgreSQL 字符串字面量加引号
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// placeholders returns n comma separated placeholders | placeholders 返回 n 个逗号分隔的占位符
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,466 @@
+// Package search provides full-text search through PostgreSQL tsvector, Meilisearch or Elasticsearch
+// Package search 提供基于 PostgreSQL tsvector、Meilisearch 或 Elasticsearch 的全文搜索
+//
+// Indexes are declared once and used through the same API whatever the driver:
+// 索引只需声明一次，无论使用哪种驱动都通过同一套 API 访问：
+//
+//	search.Register(search.Index{
+//		Name:       "article",
+//		Fields:     []search.Field{{Name: "title", Weight: "A"}, {Name: "content", Weight: "B"}},
+//		Filterable: []string{"status", "category_id"},
+//		Sortable:   []string{"created_at"},
+//	})
+//
+//	res, err := search.Get().Search(ctx, "article", &search.Query{Text: "golang", Size: 10})
+//
+// Models implementing Indexable stay in sync through xorm hooks calling Sync,
+// and Find loads the rows of a search page in rank order.
+// 实现 Indexable 的模型通过调用 Sync 的 xorm 钩子保持同步，
+// Find 按排名顺序加载一页搜索结果对应的行。
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported drivers | 支持的驱动
+const (
+	DriverPostgres      = "postgres"
+	DriverMeilisearch   = "meilisearch"
+	DriverElasticsearch = "elasticsearch"
+)
+
+// Config represents search configuration
+// Config 表示搜索配置
+type Config struct {
+	Driver   string        `toml:"driver"`   // postgres, meilisearch or elasticsearch | postgres、meilisearch 或 elasticsearch
+	Endpoint string        `toml:"endpoint"` // Meilisearch / Elasticsearch base URL | Meilisearch / Elasticsearch 基础地址
+	APIKey   string        `toml:"api_key"`  // Meilisearch key / Elasticsearch API key | Meilisearch 密钥 / Elasticsearch API 密钥
+	Username string        `toml:"username"` // Elasticsearch basic auth, when api_key is empty | Elasticsearch 基本认证，api_key 为空时使用
+	Password string        `toml:"password"` // Elasticsearch basic auth password | Elasticsearch 基本认证密码
+	Prefix   string        `toml:"prefix"`   // Index name prefix, e.g. "prod_" | 索引名前缀，例如 "prod_"
+	Database string        `toml:"database"` // pgsql client name for postgres, default database if empty | postgres 使用的 pgsql 客户端名，为空时使用默认数据库
+	Language string        `toml:"language"` // PostgreSQL text search configuration, default "simple" | PostgreSQL 文本搜索配置，默认 "simple"
+	Timeout  time.Duration `toml:"timeout"`  // Request timeout, default 10s | 请求超时，默认 10s
+}
+
+// Index declares a searchable collection: a table for postgres, an index for the search engines
+// Index 声明一个可搜索的集合：postgres 中为表，搜索引擎中为索引
+type Index struct {
+	Name       string   // Index name, also the table name unless Table is set | 索引名，未设置 Table 时也作为表名
+	Table      string   // PostgreSQL table | PostgreSQL 表名
+	Database   string   // pgsql client name, overrides Config.Database | pgsql 客户端名，优先于 Config.Database
+	Key        string   // Primary key column / document field, default "id" | 主键列 / 文档字段，默认 "id"
+	Where      string   // Extra PostgreSQL condition, e.g. "deleted_at IS NULL" | 额外的 PostgreSQL 条件，例如 "deleted_at IS NULL"
+	Fields     []Field  // Full-text fields | 全文字段
+	Filterable []string // Fields usable in Query.Filters | 可用于 Query.Filters 的字段
+	Sortable   []string // Fields usable in Query.Sort | 可用于 Query.Sort 的字段
+}
+
+// Field is a full-text field of an index
+// Field 表示索引的全文字段
+type Field struct {
+	Name   string // Column / document field | 列 / 文档字段
+	Weight string // "A" (highest) to "D", default "D" | "A"（最高）到 "D"，默认 "D"
+}
+
+// Document is one indexed record; Fields may be partial, engines merge them into the stored document
+// Document 表示一条被索引的记录；Fields 可以是部分字段，搜索引擎会合并到已存储的文档中
+type Document struct {
+	ID     string
+	Fields map[string]any
+}
+
+// Query is a search request
+// Query 表示搜索请求
+type Query struct {
+	Text    string         // User input, empty matches everything | 用户输入，为空时匹配全部
+	Filters map[string]any // Equality filters, a slice value matches any element | 等值过滤，切片值匹配任一元素
+	Sort    []string       // "field:asc" or "field:desc", rank order if empty | "field:asc" 或 "field:desc"，为空时按相关度排序
+	Page    int            // Page number, default 1 | 页码，默认 1
+	Size    int            // Page size, default 10 | 每页数量，默认 10
+}
+
+// Hit is one search result
+// Hit 表示一条搜索结果
+type Hit struct {
+	ID     string          `json:"id"`
+	Score  float64         `json:"score"`
+	Source json.RawMessage `json:"source,omitempty"` // Stored document (search engines only) | 已存储的文档（仅搜索引擎）
+}
+
+// Result is a page of search results
+// Result 表示一页搜索结果
+type Result struct {
+	Hits  []Hit `json:"hits"`
+	Total int64 `json:"total"` // Estimated by Meilisearch | Meilisearch 为估算值
+}
+
+// IDs returns the IDs of the hits in rank order
+// IDs 按排名顺序返回结果 ID
+func (r *Result) IDs() []string {
+	ids := make([]string, len(r.Hits))
+	for i, h := range r.Hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+var (
+	// ErrIndexNotRegistered is returned for indexes not declared with Register
+	// ErrIndexNotRegistered 表示索引未通过 Register 声明
+	ErrIndexNotRegistered = errors.New("search: index not registered")
+
+	// ErrInvalidQuery is returned for filters or sorts on fields not allowed by the index
+	// ErrInvalidQuery 表示过滤或排序使用了索引不允许的字段
+	ErrInvalidQuery = errors.New("search: invalid query")
+)
+
+// engine implements one driver
+// engine 实现一种驱动
+type engine interface {
+	ensure(ctx context.Context, c *Client, idx *Index) error
+	index(ctx context.Context, c *Client, idx *Index, docs []Document) error
+	remove(ctx context.Context, c *Client, idx *Index, ids []string) error
+	search(ctx context.Context, c *Client, idx *Index, q *Query) (*Result, error)
+}
+
+// Client represents a search client
+// Client 表示搜索客户端
+type Client struct {
+	config  Config
+	http    *http.Client
+	engine  engine
+	mu      sync.Mutex
+	ensured map[string]bool // Indexes created on the backend | 已在后端创建的索引
+}
+
+// New creates a search client for the configured driver
+// New 根据配置的驱动创建搜索客户端
+func New(cfg Config) (*Client, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Language == "" {
+		cfg.Language = "simple"
+	}
+	c := &Client{config: cfg, http: &http.Client{Timeout: cfg.Timeout}, ensured: make(map[string]bool)}
+	switch cfg.Driver {
+	case DriverPostgres:
+		c.engine = postgresEngine{}
+	case DriverMeilisearch:
+		c.engine = meiliEngine{}
+	case DriverElasticsearch:
+		c.engine = elasticEngine{}
+	default:
+		return nil, fmt.Errorf("search: unsupported driver: %s", cfg.Driver)
+	}
+	if cfg.Driver != DriverPostgres && cfg.Endpoint == "" {
+		return nil, fmt.Errorf("search: endpoint is required for %s", cfg.Driver)
+	}
+	return c, nil
+}
+
+var (
+	defaultClient *Client // Default search client | 默认搜索客户端
+
+	indexesMu sync.RWMutex
+	indexes   = make(map[string]*Index) // Registered indexes by name | 按名称注册的索引
+)
+
+// Init initializes the default client
+// If driver is empty, skip initialization
+// Init 初始化默认客户端
+// 如果 driver 为空，跳过初始化
+func Init(cfg Config) error {
+	if cfg.Driver == "" {
+		log.Println("search: driver not configured, skip initialization")
+		return nil
+	}
+	client, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	defaultClient = client
+	log.Printf("search: initialized, driver: %s", cfg.Driver)
+	return nil
+}
+
+// Get returns the default search client, nil when not configured
+// Get 返回默认搜索客户端，未配置时为 nil
+func Get() *Client {
+	return defaultClient
+}
+
+// Register declares an index, usually from the init of the package defining the model
+// Register 声明索引，通常在定义模型的包的 init 中调用
+func Register(idx Index) {
+	if idx.Name == "" || len(idx.Fields) == 0 {
+		panic("search: index name and fields are required")
+	}
+	if idx.Table == "" {
+		idx.Table = idx.Name
+	}
+	if idx.Key == "" {
+		idx.Key = "id"
+	}
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+	indexes[idx.Name] = &idx
+}
+
+// lookup returns the registered index | lookup 返回已注册的索引
+func lookup(name string) (*Index, error) {
+	indexesMu.RLock()
+	defer indexesMu.RUnlock()
+	idx, ok := indexes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrIndexNotRegistered, name)
+	}
+	return idx, nil
+}
+
+// prepare returns the index, creating it on the backend on first use
+// prepare 返回索引，首次使用时在后端创建
+func (c *Client) prepare(ctx context.Context, name string) (*Index, error) {
+	idx, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	ok := c.ensured[name]
+	c.mu.Unlock()
+	if ok {
+		return idx, nil
+	}
+	if err := c.engine.ensure(ctx, c, idx); err != nil {
+		return nil, fmt.Errorf("search: prepare index %s: %w", name, err)
+	}
+	c.mu.Lock()
+	c.ensured[name] = true
+	c.mu.Unlock()
+	return idx, nil
+}
+
+// Index adds or updates documents
+// Index 添加或更新文档
+//
+// With the postgres driver the rows are the documents, only their IDs are used to refresh the tsvector.
+// 使用 postgres 驱动时行即文档，只使用 ID 刷新 tsvector。
+func (c *Client) Index(ctx context.Context, index string, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	idx, err := c.prepare(ctx, index)
+	if err != nil {
+		return err
+	}
+	return c.engine.index(ctx, c, idx, docs)
+}
+
+// Delete removes documents
+// Delete 删除文档
+func (c *Client) Delete(ctx context.Context, index string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	idx, err := c.prepare(ctx, index)
+	if err != nil {
+		return err
+	}
+	return c.engine.remove(ctx, c, idx, ids)
+}
+
+// Search runs a query
+// Search 执行查询
+func (c *Client) Search(ctx context.Context, index string, q *Query) (*Result, error) {
+	idx, err := c.prepare(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	if err := idx.validate(q); err != nil {
+		return nil, err
+	}
+	return c.engine.search(ctx, c, idx, q)
+}
+
+// name returns the backend name of idx | name 返回 idx 在后端的名称
+func (c *Client) name(idx *Index) string {
+	return c.config.Prefix + idx.Name
+}
+
+// validate checks that filters and sorts only use allowed fields, they are interpolated into queries
+// validate 检查过滤和排序只使用允许的字段，这些字段会被拼接到查询中
+func (idx *Index) validate(q *Query) error {
+	for field := range q.Filters {
+		if !contains(idx.Filterable, field) {
+			return fmt.Errorf("%w: field %s is not filterable", ErrInvalidQuery, field)
+		}
+	}
+	for _, s := range q.Sort {
+		field, _ := parseSort(s)
+		if !contains(idx.Sortable, field) {
+			return fmt.Errorf("%w: field %s is not sortable", ErrInvalidQuery, field)
+		}
+	}
+	return nil
+}
+
+// parseSort splits "field:desc" into field and descending | parseSort 将 "field:desc" 拆分为字段和是否降序
+func parseSort(s string) (field string, desc bool) {
+	field, dir, _ := strings.Cut(s, ":")
+	return field, strings.EqualFold(dir, "desc")
+}
+
+// filterFields returns the filtered fields of q in a stable order | filterFields 以稳定顺序返回 q 的过滤字段
+func (q *Query) filterFields() []string {
+	fields := make([]string, 0, len(q.Filters))
+	for field := range q.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// page returns the limit and offset of q | page 返回 q 的 limit 和 offset
+func (q *Query) page() (limit, offset int) {
+	limit, page := q.Size, q.Page
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	return limit, (page - 1) * limit
+}
+
+// weight returns the normalized weight letter of f | weight 返回 f 规范化后的权重字母
+func (f Field) weight() string {
+	switch w := strings.ToUpper(f.Weight); w {
+	case "A", "B", "C":
+		return w
+	default:
+		return "D"
+	}
+}
+
+// values returns the elements of a filter value, a slice matches any of them
+// values 返回过滤值的元素，切片匹配其中任一元素
+func values(v any) []any {
+	switch vs := v.(type) {
+	case []any:
+		return vs
+	case []string:
+		out := make([]any, len(vs))
+		for i, s := range vs {
+			out[i] = s
+		}
+		return out
+	case []int:
+		out := make([]any, len(vs))
+		for i, n := range vs {
+			out[i] = n
+		}
+		return out
+	case []int64:
+		out := make([]any, len(vs))
+		for i, n := range vs {
+			out[i] = n
+		}
+		return out
+	default:
+		return []any{v}
+	}
+}
+
+// Error is an error response of Meilisearch or Elasticsearch
+// Error 表示 Meilisearch 或 Elasticsearch 的错误响应
+type Error struct {
+	Driver  string // Driver name | 驱动名称
+	Status  int    // HTTP status | HTTP 状态码
+	Code    string // Engine error code / type | 引擎错误码 / 类型
+	Message string // Engine error message | 引擎错误信息
+}
+
+// Error implements error interface
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("search %s: %d %s: %s", e.Driver, e.Status, e.Code, e.Message)
+}
+
+// do sends a JSON request to the engine and decodes the response into out
+// do 向搜索引擎发送 JSON 请求并将响应解码到 out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, contentType string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case c.config.Driver == DriverMeilisearch && c.config.APIKey != "":
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	case c.config.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.config.APIKey)
+	case c.config.Username != "":
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("search %s: request failed: %w", c.config.Driver, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		e := &Error{Driver: c.config.Driver, Status: resp.StatusCode, Message: string(data)}
+		// Meilisearch: {"code", "message"}, Elasticsearch: {"error": {"type", "reason"}}
+		var result struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Error   struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &result) == nil {
+			switch {
+			case result.Code != "":
+				e.Code, e.Message = result.Code, result.Message
+			case result.Error.Type != "":
+				e.Code, e.Message = result.Error.Type, result.Error.Reason
+			}
+		}
+		return e
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func init() {
+	Register(Index{
+		Name:       "article",
+		Fields:     []Field{{Name: "content", Weight: "B"}, {Name: "title", Weight: "A"}},
+		Filterable: []string{"status", "category_id"},
+		Sortable:   []string{"created_at"},
+		Where:      "deleted_at IS NULL",
+	})
+}
+
+// fakeEngine records the requests sent to a Meilisearch / Elasticsearch server and replies with reply
+type fakeEngine struct {
+	mu       sync.Mutex
+	requests []string // "METHOD /path body"
+	reply    func(r *http.Request) (int, string)
+}
+
+func (f *fakeEngine) start(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		f.mu.Unlock()
+		status, resp := http.StatusAccepted, `{}`
+		if f.reply != nil {
+			status, resp = f.reply(r)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (f *fakeEngine) last() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
+
+func TestPostgresSearchSQL(t *testing.T) {
+	idx, _ := lookup("article")
+	sql, countSQL, args := postgresSearch(idx, "simple", &Query{
+		Text:    "go web",
+		Filters: map[string]any{"status": 1, "category_id": []int64{3, 4}},
+		Page:    2,
+		Size:    5,
+	})
+
+	from := ` FROM "article", websearch_to_tsquery('simple', ?) AS query WHERE search_vector @@ query AND (deleted_at IS NULL) AND "category_id" IN (?, ?) AND "status" = ?`
+	if want := `SELECT "id"::text AS id, ts_rank(search_vector, query) AS score` + from + ` ORDER BY score DESC, "id" LIMIT 5 OFFSET 5`; sql != want {
+		t.Errorf("sql =\n%s\nwant\n%s", sql, want)
+	}
+	if want := "SELECT count(*)" + from; countSQL != want {
+		t.Errorf("countSQL = %s", countSQL)
+	}
+	if want := []any{"go web", int64(3), int64(4), 1}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	sql, _, args = postgresSearch(idx, "simple", &Query{Sort: []string{"created_at:desc"}})
+	if !strings.Contains(sql, `AS score FROM "article" WHERE (deleted_at IS NULL) ORDER BY "created_at" DESC, "id" LIMIT 10 OFFSET 0`) || len(args) != 0 {
+		t.Errorf("Unexpected browse query %s %v", sql, args)
+	}
+}
+
+func TestPostgresSchema(t *testing.T) {
+	idx, _ := lookup("article")
+	stmts := postgresSchema(idx, "simple")
+	vector := `setweight(to_tsvector('simple', coalesce(NEW."content"::text, '')), 'B') || setweight(to_tsvector('simple', coalesce(NEW."title"::text, '')), 'A')`
+	if !strings.Contains(stmts[2], "NEW.search_vector := "+vector) {
+		t.Errorf("Trigger function should compute the weighted vector, got %s", stmts[2])
+	}
+	if want := `CREATE TRIGGER "article_search_vector" BEFORE INSERT OR UPDATE OF "content", "title" ON "article"`; !strings.HasPrefix(stmts[4], want) {
+		t.Errorf("Unexpected trigger %s", stmts[4])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c, err := New(Config{Driver: DriverMeilisearch, Endpoint: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ensured["article"] = true
+	for _, q := range []*Query{
+		{Filters: map[string]any{"user_id": 1}},
+		{Sort: []string{"title:asc"}},
+	} {
+		if _, err := c.Search(context.Background(), "article", q); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("Search(%+v) error = %v, want ErrInvalidQuery", q, err)
+		}
+	}
+	if _, err := c.Search(context.Background(), "missing", &Query{}); !errors.Is(err, ErrIndexNotRegistered) {
+		t.Errorf("Unknown index error = %v", err)
+	}
+	if _, err := New(Config{Driver: DriverElasticsearch}); err == nil {
+		t.Error("Engines without endpoint should be rejected")
+	}
+}
+
+func TestMeilisearch(t *testing.T) {
+	fake := &fakeEngine{reply: func(r *http.Request) (int, string) {
+		if strings.HasSuffix(r.URL.Path, "/search") {
+			return http.StatusOK, `{"hits":[{"id":"7","title":"Go","_rankingScore":0.9},{"id":"3","title":"Web","_rankingScore":0.5}],"estimatedTotalHits":2}`
+		}
+		return http.StatusAccepted, `{"taskUid":1}`
+	}}
+	srv := fake.start(t)
+	c, _ := New(Config{Driver: DriverMeilisearch, Endpoint: srv.URL, APIKey: "key", Prefix: "test_"})
+	ctx := context.Background()
+
+	res, err := c.Search(ctx, "article", &Query{Text: "go", Filters: map[string]any{"status": 1, "category_id": []string{"a", "b"}}, Sort: []string{"created_at:DESC"}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if res.Total != 2 || !reflect.DeepEqual(res.IDs(), []string{"7", "3"}) || res.Hits[0].Score != 0.9 || string(res.Hits[0].Source) != `{"id":"7","title":"Go"}` {
+		t.Errorf("Unexpected result %+v", res)
+	}
+	if want := `POST /indexes/test_article/search {"filter":["category_id IN [\"a\", \"b\"]","status = 1"],"limit":10,"offset":0,"q":"go","showRankingScore":true,"sort":["created_at:desc"]}`; fake.last() != want {
+		t.Errorf("request =\n%s\nwant\n%s", fake.last(), want)
+	}
+	if want := `PATCH /indexes/test_article/settings {"filterableAttributes":["status","category_id"],"searchableAttributes":["title","content"],"sortableAttributes":["created_at"]}`; fake.requests[1] != want {
+		t.Errorf("settings =\n%s\nwant\n%s", fake.requests[1], want)
+	}
+
+	if err := c.Index(ctx, "article", Document{ID: "7", Fields: map[string]any{"title": "Go"}}); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if want := `PUT /indexes/test_article/documents?primaryKey=id [{"id":"7","title":"Go"}]`; fake.last() != want {
+		t.Errorf("request = %s", fake.last())
+	}
+	if len(fake.requests) != 4 {
+		t.Errorf("The index should be prepared once, got requests %v", fake.requests)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	fake := &fakeEngine{reply: func(r *http.Request) (int, string) {
+		switch {
+		case r.Method == http.MethodPut:
+			return http.StatusBadRequest, `{"error":{"type":"resource_already_exists_exception","reason":"exists"}}`
+		case r.URL.Path == "/_bulk":
+			return http.StatusOK, `{"errors":true,"items":[{"update":{"status":200}},{"update":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`
+		default:
+			return http.StatusOK, `{"hits":{"total":{"value":1},"hits":[{"_id":"7","_score":null,"_source":{"title":"Go"}}]}}`
+		}
+	}}
+	srv := fake.start(t)
+	c, _ := New(Config{Driver: DriverElasticsearch, Endpoint: srv.URL, Username: "elastic", Password: "secret"})
+	ctx := context.Background()
+
+	res, err := c.Search(ctx, "article", &Query{Text: "go", Filters: map[string]any{"status": 1}, Sort: []string{"created_at:desc"}})
+	if err != nil {
+		t.Fatalf("Search failed (existing index should be accepted): %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "7" || string(res.Hits[0].Source) != `{"title":"Go"}` {
+		t.Errorf("Unexpected result %+v", res)
+	}
+	var body map[string]any
+	_ = json.Unmarshal([]byte(strings.SplitN(fake.last(), " ", 3)[2]), &body)
+	query, _ := json.Marshal(body["query"])
+	if want := `{"bool":{"filter":[{"term":{"status":1}}],"must":{"multi_match":{"fields":["content^3","title^4"],"query":"go"}}}}`; string(query) != want {
+		t.Errorf("query = %s", query)
+	}
+
+	err = c.Index(ctx, "article", Document{ID: "1", Fields: map[string]any{"title": "a"}}, Document{ID: "2"})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "mapper_parsing_exception" {
+		t.Errorf("Expected the failed bulk item, got %v", err)
+	}
+	if want := "POST /_bulk {\"update\":{\"_id\":\"1\",\"_index\":\"article\"}}\n{\"doc\":{\"title\":\"a\"},\"doc_as_upsert\":true}\n"; !strings.HasPrefix(fake.last(), want) {
+		t.Errorf("bulk = %q", fake.last())
+	}
+}
+
+// article is an Indexable whose document is read from a map
+type article struct {
+	ID    string
+	store map[string]string
+}
+
+func (a *article) SearchIndex() string { return "article" }
+func (a *article) SearchID() string    { return a.ID }
+func (a *article) SearchDocument(context.Context) (map[string]any, bool, error) {
+	title, ok := a.store[a.ID]
+	return map[string]any{"title": title}, ok, nil
+}
+
+func TestSyncAndRankOrder(t *testing.T) {
+	fake := &fakeEngine{}
+	srv := fake.start(t)
+	c, _ := New(Config{Driver: DriverMeilisearch, Endpoint: srv.URL})
+	ctx := context.Background()
+	store := map[string]string{"1": "Go"}
+
+	if err := c.Sync(ctx, &article{ID: "1", store: store}); err != nil || !strings.HasPrefix(fake.last(), "PUT ") {
+		t.Errorf("Existing record should be indexed, got %v %s", err, fake.last())
+	}
+	if err := c.Sync(ctx, &article{ID: "2", store: store}); err != nil || fake.last() != `POST /indexes/article/documents/delete-batch ["2"]` {
+		t.Errorf("Missing record should be deleted, got %v %s", err, fake.last())
+	}
+
+	rows := []article{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	got := rankOrder[article](rows, &Result{Hits: []Hit{{ID: "3"}, {ID: "9"}, {ID: "1"}}})
+	if len(got) != 2 || got[0].ID != "3" || got[1].ID != "1" {
+		t.Errorf("rankOrder = %+v", got)
+	}
+}
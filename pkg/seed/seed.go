@@ -0,0 +1,228 @@
+// Package seed runs registered database seeds, giving local and demo environments consistent baseline data.
+// Package seed 执行已注册的数据库种子，使本地和演示环境拥有一致的基础数据。
+//
+// Seeds are applied once per version: each run is recorded in the seed_history table of its
+// database, in the same transaction as the seed. Bump Version to apply a seed again, so seed
+// functions must stay idempotent (insert what is missing, update what changed).
+// 每个种子的每个版本只应用一次：每次执行都与种子在同一事务中记录到所在数据库的 seed_history 表。
+// 增加 Version 可再次应用种子，因此种子函数必须保持幂等（插入缺失的数据，更新变更的数据）。
+//
+// Example | 示例:
+//
+//	func init() {
+//		seed.Register(seed.Seed{
+//			Name:   "shop_categories",
+//			Module: "shop",
+//			Envs:   []string{"dev", "demo"},
+//			Run: func(ctx context.Context, s *xorm.Session) error {
+//				for i, name := range []string{"Books", "Games"} {
+//					if has, err := s.Where("name = ?", name).Exist(new(Category)); err != nil || has {
+//						return err
+//					}
+//					if _, err := s.Insert(&Category{Name: name, Sort: i}); err != nil {
+//						return err
+//					}
+//				}
+//				return nil
+//			},
+//		})
+//	}
+package seed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"xorm.io/xorm"
+)
+
+// Seed is a named function filling baseline data | Seed 表示填充基础数据的具名函数
+type Seed struct {
+	Name     string   // Unique name, recorded once applied | 唯一名称，应用后会被记录
+	Module   string   // Owning module, empty for shared seeds which always run | 所属模块，为空表示共享种子，总是执行
+	Version  int      // Applied again when raised | 提高后会再次应用
+	Order    int      // Lower runs first, then by name | 越小越先执行，其次按名称
+	Envs     []string // Environments (app.env), empty for all | 环境（app.env），为空表示全部
+	Database string   // pgsql database, empty for the default | pgsql 数据库，为空表示默认数据库
+	// Run fills the data inside a transaction committed with the history record
+	// Run 在事务中填充数据，该事务与历史记录一起提交
+	Run func(ctx context.Context, s *xorm.Session) error
+}
+
+// History records an applied seed | History 记录已应用的种子
+type History struct {
+	Name      string    `json:"name" xorm:"pk varchar(128) 'name'"`
+	Version   int       `json:"version" xorm:"notnull default(0) 'version'"`
+	AppliedAt time.Time `json:"applied_at" xorm:"'applied_at'"`
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (h *History) TableName() string {
+	return "seed_history"
+}
+
+// Options selects the seeds of a run | Options 选择一次执行的种子
+type Options struct {
+	Env     string   // Current environment, seeds limited to other environments are skipped | 当前环境，仅限其他环境的种子会被跳过
+	Modules []string // Started modules, empty for all | 启动的模块，为空表示全部
+	Names   []string // Only these seeds, empty for all | 仅执行这些种子，为空表示全部
+	Force   bool     // Apply again seeds already recorded | 再次应用已记录的种子
+}
+
+// Result is the outcome of a run | Result 为一次执行的结果
+type Result struct {
+	Applied []string // Seeds applied by this run | 本次应用的种子
+	Skipped []string // Seeds already applied at their version | 当前版本已应用的种子
+}
+
+var (
+	mu    sync.Mutex
+	seeds = make(map[string]Seed)
+)
+
+// Register adds a seed; it panics on a missing name or function and on duplicate names
+// Register 添加种子；名称或函数缺失以及名称重复时 panic
+func Register(s Seed) {
+	if s.Name == "" || s.Run == nil {
+		panic("seed: Register requires a name and a function")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := seeds[s.Name]; ok {
+		panic(fmt.Sprintf("seed: %q registered twice", s.Name))
+	}
+	seeds[s.Name] = s
+}
+
+// Registered returns the registered seeds in run order | Registered 按执行顺序返回已注册的种子
+func Registered() []Seed {
+	return plan(Options{})
+}
+
+// plan returns the seeds selected by opts in run order | plan 按执行顺序返回 opts 选择的种子
+func plan(opts Options) []Seed {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]Seed, 0, len(seeds))
+	for _, s := range seeds {
+		switch {
+		case len(opts.Names) > 0 && !slices.Contains(opts.Names, s.Name):
+		case opts.Env != "" && len(s.Envs) > 0 && !slices.Contains(s.Envs, opts.Env):
+		case s.Module != "" && len(opts.Modules) > 0 && !slices.Contains(opts.Modules, s.Module):
+		default:
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Order != out[j].Order {
+			return out[i].Order < out[j].Order
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Run applies the seeds selected by opts in order, stopping at the first failure
+// Run 按顺序应用 opts 选择的种子，遇到第一个失败时停止
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if len(opts.Names) > 0 {
+		if unknown := unknownNames(opts.Names); len(unknown) > 0 {
+			return nil, fmt.Errorf("seed: unknown seeds %v", unknown)
+		}
+	}
+	res := &Result{}
+	synced := make(map[*xorm.Engine]bool)
+	for _, s := range plan(opts) {
+		db, err := engine(s.Database)
+		if err != nil {
+			return res, err
+		}
+		if !synced[db] {
+			if err := db.Sync(new(History)); err != nil {
+				return res, fmt.Errorf("seed: create history table: %w", err)
+			}
+			synced[db] = true
+		}
+		applied, err := apply(ctx, db, s, opts.Force)
+		if err != nil {
+			return res, fmt.Errorf("seed %s: %w", s.Name, err)
+		}
+		if applied {
+			res.Applied = append(res.Applied, s.Name)
+			log.Printf("  ✓ Seed %s applied (version %d)", s.Name, s.Version)
+		} else {
+			res.Skipped = append(res.Skipped, s.Name)
+		}
+	}
+	return res, nil
+}
+
+// apply runs s in a transaction unless its version is recorded, and records it
+// apply 在事务中执行 s（其版本已记录时除外）并记录
+func apply(ctx context.Context, db *xorm.Engine, s Seed, force bool) (bool, error) {
+	session := db.NewSession().Context(ctx)
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return false, err
+	}
+	// The row lock and primary key keep two instances from applying a seed twice | 行锁和主键防止两个实例重复应用同一种子
+	h := new(History)
+	has, err := session.ID(s.Name).ForUpdate().Get(h)
+	if err != nil {
+		_ = session.Rollback()
+		return false, err
+	}
+	if has && h.Version >= s.Version && !force {
+		return false, session.Rollback()
+	}
+
+	if err := s.Run(ctx, session); err != nil {
+		_ = session.Rollback()
+		return false, err
+	}
+	record := &History{Name: s.Name, Version: s.Version, AppliedAt: time.Now()}
+	if has {
+		_, err = session.ID(s.Name).Cols("version", "applied_at").Update(record)
+	} else {
+		_, err = session.Insert(record)
+	}
+	if err != nil {
+		_ = session.Rollback()
+		return false, err
+	}
+	return true, session.Commit()
+}
+
+// engine returns the engine of a pgsql database, the default one for "" | engine 返回 pgsql 数据库的引擎，"" 为默认数据库
+func engine(name string) (*xorm.Engine, error) {
+	var client *pgsql.Client
+	if name == "" {
+		client = pgsql.Get()
+	} else {
+		client = pgsql.Get(name)
+	}
+	if client == nil {
+		return nil, fmt.Errorf("seed: pgsql database %q not initialized", name)
+	}
+	return client.Engine(), nil
+}
+
+// unknownNames returns the names without a registered seed | unknownNames 返回没有对应已注册种子的名称
+func unknownNames(names []string) []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var unknown []string
+	for _, n := range names {
+		if _, ok := seeds[n]; !ok {
+			unknown = append(unknown, n)
+		}
+	}
+	return unknown
+}
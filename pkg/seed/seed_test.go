@@ -0,0 +1,76 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"xorm.io/xorm"
+)
+
+// reset empties the registry for one test | reset 为单个测试清空注册表
+func reset(t *testing.T) {
+	mu.Lock()
+	prev := seeds
+	seeds = make(map[string]Seed)
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		seeds = prev
+		mu.Unlock()
+	})
+}
+
+func noop(context.Context, *xorm.Session) error { return nil }
+
+func names(list []Seed) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = s.Name
+	}
+	return out
+}
+
+func TestPlan(t *testing.T) {
+	reset(t)
+	Register(Seed{Name: "roles", Order: -1, Run: noop})
+	Register(Seed{Name: "demo_orders", Module: "shop", Order: 10, Envs: []string{"demo"}, Run: noop})
+	Register(Seed{Name: "categories", Module: "shop", Envs: []string{"dev", "demo"}, Run: noop})
+	Register(Seed{Name: "articles", Module: "cms", Run: noop})
+
+	tests := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{}, []string{"roles", "articles", "categories", "demo_orders"}},
+		{Options{Env: "dev"}, []string{"roles", "articles", "categories"}},
+		{Options{Env: "prod"}, []string{"roles", "articles"}},
+		{Options{Env: "demo", Modules: []string{"shop"}}, []string{"roles", "categories", "demo_orders"}},
+		{Options{Env: "demo", Names: []string{"demo_orders", "articles"}}, []string{"articles", "demo_orders"}},
+	}
+	for _, tt := range tests {
+		if got := names(plan(tt.opts)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("plan(%+v) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	reset(t)
+	Register(Seed{Name: "roles", Run: noop})
+	for _, s := range []Seed{{Name: "roles", Run: noop}, {Name: "", Run: noop}, {Name: "users"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) should panic", s.Name)
+				}
+			}()
+			Register(s)
+		}()
+	}
+
+	if _, err := Run(context.Background(), Options{Names: []string{"roles", "missing"}}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Unknown names should be rejected before running, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newAutoCert returns a TLS config backed by Let's Encrypt and the http-01 challenge wrapper
+// newAutoCert 返回由 Let's Encrypt 提供证书的 TLS 配置以及 http-01 验证包装器
+func newAutoCert(cfg TLSConfig) (*tls.Config, func(http.Handler) http.Handler) {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	// TLSConfig also answers tls-alpn-01 challenges on the HTTPS listener | TLSConfig 同时在 HTTPS 监听上响应 tls-alpn-01 验证
+	return m.TLSConfig(), m.HTTPHandler
+}
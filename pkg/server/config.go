@@ -0,0 +1,78 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultBodyLimit is the request body limit used when body_limit is not set
+// DefaultBodyLimit 是未设置 body_limit 时使用的请求体限制
+const DefaultBodyLimit = 4 * 1024 * 1024
+
+// Config is the [server] section: listen address, Fiber/fasthttp tuning and TLS
+// Zero values fall back to the framework defaults.
+// Config 是 [server] 配置段：监听地址、Fiber/fasthttp 调优参数和 TLS
+// 零值使用框架默认值。
+type Config struct {
+	Addr            string         `toml:"addr"`              // Listen address | 监听地址
+	Prefork         bool           `toml:"prefork"`           // One process per CPU sharing the port, not used with TLS | 每个 CPU 一个进程共享端口，TLS 下不生效
+	Concurrency     int            `toml:"concurrency"`       // Max concurrent connections (default 262144) | 最大并发连接数（默认 262144）
+	BodyLimit       int            `toml:"body_limit"`        // Max request body in bytes (default 4MB) | 最大请求体字节数（默认 4MB）
+	BodyLimits      map[string]int `toml:"body_limits"`       // Body limit per path prefix (module or route), may exceed body_limit but the largest one is buffered on every route | 按路径前缀（模块或路由）的请求体限制，可超过 body_limit，但最大值对所有路由生效为缓冲上限
+	ReadBufferSize  int            `toml:"read_buffer_size"`  // Per-connection read buffer, also the max header size (default 8192) | 每连接读缓冲区，同时是最大请求头大小（默认 8192）
+	WriteBufferSize int            `toml:"write_buffer_size"` // Per-connection write buffer (default 8192) | 每连接写缓冲区（默认 8192）
+	ReadTimeout     time.Duration  `toml:"read_timeout"`      // Default 10s | 默认 10s
+	WriteTimeout    time.Duration  `toml:"write_timeout"`     // Default 10s; SSE streams get it per event | 默认 10s；SSE 流按事件计算
+	IdleTimeout     time.Duration  `toml:"idle_timeout"`      // Keep-alive idle timeout (default 120s) | 长连接空闲超时（默认 120s）
+	RequestTimeout  time.Duration  `toml:"request_timeout"`   // Cancels c.UserContext() of slow handlers, 0 for none | 取消慢处理器的 c.UserContext()，0 表示不限制
+	ShutdownTimeout time.Duration  `toml:"shutdown_timeout"`  // Wait for in-flight requests and background work on shutdown (default 30s) | 关闭时等待进行中请求和后台任务的时长（默认 30s）
+	TrustedProxies  []string       `toml:"trusted_proxies"`   // IPs/CIDRs whose proxy headers are trusted | 信任其代理头的 IP/CIDR
+	ProxyHeader     string         `toml:"proxy_header"`      // Client IP header set by the proxy, e.g. X-Forwarded-For | 代理设置的客户端 IP 头，如 X-Forwarded-For
+	HTTP2Addr       string         `toml:"http2_addr"`        // Extra HTTP/2 listener (h2 with TLS, h2c without), responses are buffered | 额外的 HTTP/2 监听（启用 TLS 时为 h2，否则为 h2c），响应会被缓冲
+	TLS             TLSConfig      `toml:"tls"`               // HTTPS, redirect and mTLS settings | HTTPS、重定向和 mTLS 设置
+}
+
+// Fiber applies the configuration to base, keeping base values for unset fields
+// Fiber 将配置应用到 base，未设置的字段保留 base 中的值
+func (c Config) Fiber(base fiber.Config) fiber.Config {
+	base.Prefork = c.Prefork
+	if c.Concurrency > 0 {
+		base.Concurrency = c.Concurrency
+	}
+	if c.BodyLimit > 0 {
+		base.BodyLimit = c.BodyLimit
+	}
+	// The server accepts the largest limit, middleware enforces the others. fasthttp reads the whole
+	// body before routing, so every route may buffer up to that size; keep large limits rare.
+	// 服务器接受最大限制，其余由中间件执行。fasthttp 在路由前读取完整请求体，
+	// 因此每个路由都可能缓冲到该大小，应避免配置过大的限制。
+	for _, limit := range c.BodyLimits {
+		if limit > base.BodyLimit {
+			base.BodyLimit = limit
+		}
+	}
+	if c.ReadBufferSize > 0 {
+		base.ReadBufferSize = c.ReadBufferSize
+	}
+	if c.WriteBufferSize > 0 {
+		base.WriteBufferSize = c.WriteBufferSize
+	}
+	if c.ReadTimeout > 0 {
+		base.ReadTimeout = c.ReadTimeout
+	}
+	if c.WriteTimeout > 0 {
+		base.WriteTimeout = c.WriteTimeout
+	}
+	if c.IdleTimeout > 0 {
+		base.IdleTimeout = c.IdleTimeout
+	}
+	if len(c.TrustedProxies) > 0 {
+		base.EnableTrustedProxyCheck = true
+		base.TrustedProxies = c.TrustedProxies
+	}
+	if c.ProxyHeader != "" {
+		base.ProxyHeader = c.ProxyHeader
+	}
+	return base
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestConfigFiberBodyLimit(t *testing.T) {
+	base := fiber.Config{BodyLimit: DefaultBodyLimit, ReadBufferSize: 4096}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want int
+	}{
+		{"unset keeps base", Config{}, DefaultBodyLimit},
+		{"body_limit", Config{BodyLimit: 1 << 20}, 1 << 20},
+		// The server accepts the largest per-path limit | 服务器接受最大的按路径限制
+		{"larger path limit", Config{BodyLimit: 1 << 20, BodyLimits: map[string]int{"/upload": 32 << 20, "/avatar": 8 << 20}}, 32 << 20},
+		{"smaller path limit", Config{BodyLimit: 1 << 20, BodyLimits: map[string]int{"/comments": 1 << 10}}, 1 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.Fiber(base)
+			if got.BodyLimit != tt.want {
+				t.Errorf("Expected BodyLimit %d, got %d", tt.want, got.BodyLimit)
+			}
+			if got.ReadBufferSize != 4096 {
+				t.Errorf("Expected unset ReadBufferSize to keep base, got %d", got.ReadBufferSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTP2Server returns a net/http server for handler on addr that speaks HTTP/2 next to HTTP/1.1:
+// h2 over TLS when t is set, cleartext h2c otherwise. Start it with ListenAndServe(srv).
+// HTTP2Server 返回在 addr 上为 handler 提供服务的 net/http 服务器，同时支持 HTTP/2 和 HTTP/1.1：
+// 设置 t 时为基于 TLS 的 h2，否则为明文 h2c。使用 ListenAndServe(srv) 启动。
+func HTTP2Server(addr string, handler http.Handler, t *TLS) *http.Server {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if t != nil {
+		protocols.SetHTTP2(true)
+		srv.TLSConfig = t.config.Clone()
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	srv.Protocols = &protocols
+	return srv
+}
+
+// ListenAndServe starts srv, over TLS when it has a TLS config
+// ListenAndServe 启动 srv，有 TLS 配置时使用 TLS
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
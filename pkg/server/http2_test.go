@@ -0,0 +1,62 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestHTTP2Server(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+
+	dir := t.TempDir()
+	ca, _, certFile, keyFile := writeCert(t, dir, "server", true, nil, nil)
+	tl, err := NewTLS(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	cases := []struct {
+		name   string
+		tls    *TLS
+		scheme string
+		client *http.Transport
+	}{
+		{"h2c", nil, "http", func() *http.Transport {
+			var p http.Protocols
+			p.SetUnencryptedHTTP2(true)
+			return &http.Transport{Protocols: &p}
+		}()},
+		{"h2", tl, "https", &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}, ForceAttemptHTTP2: true}},
+	}
+	for _, tc := range cases {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := HTTP2Server(ln.Addr().String(), handler, tc.tls)
+		if srv.TLSConfig != nil {
+			go srv.ServeTLS(ln, "", "")
+		} else {
+			go srv.Serve(ln)
+		}
+
+		resp, err := (&http.Client{Transport: tc.client}).Get(tc.scheme + "://" + ln.Addr().String() + "/")
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "HTTP/2.0" {
+			t.Errorf("%s: server saw %s", tc.name, body)
+		}
+		srv.Close()
+	}
+}
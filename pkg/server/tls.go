@@ -0,0 +1,170 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLSConfig configures HTTPS serving
+// Certificates come from CertFile/KeyFile, or from Let's Encrypt when AutoCert is set.
+// TLSConfig 配置 HTTPS 服务
+// 证书来自 CertFile/KeyFile，设置 AutoCert 时来自 Let's Encrypt。
+type TLSConfig struct {
+	Enabled      bool     `toml:"enabled"`        // Serve HTTPS | 提供 HTTPS 服务
+	CertFile     string   `toml:"cert_file"`      // PEM certificate (chain) file | PEM 证书（链）文件
+	KeyFile      string   `toml:"key_file"`       // PEM private key file | PEM 私钥文件
+	AutoCert     bool     `toml:"autocert"`       // Obtain certificates from Let's Encrypt | 从 Let's Encrypt 获取证书
+	Domains      []string `toml:"domains"`        // Hosts autocert may issue for (required with autocert) | autocert 允许签发的域名（使用 autocert 时必填）
+	Email        string   `toml:"email"`          // ACME account contact | ACME 账户联系邮箱
+	CacheDir     string   `toml:"cache_dir"`      // Autocert certificate cache, default certs | autocert 证书缓存目录，默认 certs
+	RedirectAddr string   `toml:"redirect_addr"`  // Plain HTTP listener redirecting to HTTPS, e.g. :80 | 重定向到 HTTPS 的 HTTP 监听地址，如 :80
+	ClientCAFile string   `toml:"client_ca_file"` // CA bundle for client certificates (mTLS) | 客户端证书 CA（mTLS）
+	ClientAuth   string   `toml:"client_auth"`    // request, require, verify_if_given or verify (default with client_ca_file) | 客户端证书校验模式（设置 client_ca_file 时默认 verify）
+	MinVersion   string   `toml:"min_version"`    // 1.2 (default) or 1.3 | 最低 TLS 版本，1.2（默认）或 1.3
+}
+
+// TLS holds the resolved TLS settings of a server
+// TLS 保存服务器解析后的 TLS 设置
+type TLS struct {
+	config   *tls.Config
+	redirect string
+	// challenge wraps the redirect handler to answer ACME http-01 challenges | 包装重定向处理器以响应 ACME http-01 验证
+	challenge func(http.Handler) http.Handler
+}
+
+// NewTLS validates cfg and loads the certificates; it returns nil when TLS is disabled
+// NewTLS 校验 cfg 并加载证书；未启用 TLS 时返回 nil
+func NewTLS(cfg TLSConfig) (*TLS, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	t := &TLS{redirect: cfg.RedirectAddr}
+
+	switch {
+	case cfg.AutoCert:
+		if len(cfg.Domains) == 0 {
+			return nil, errors.New("server: tls autocert requires domains")
+		}
+		if cfg.CacheDir == "" {
+			cfg.CacheDir = "certs"
+		}
+		t.config, t.challenge = newAutoCert(cfg)
+	case cfg.CertFile != "" && cfg.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("server: load tls certificate: %w", err)
+		}
+		t.config = &tls.Config{Certificates: []tls.Certificate{cert}}
+	default:
+		return nil, errors.New("server: tls requires cert_file and key_file, or autocert")
+	}
+
+	switch cfg.MinVersion {
+	case "", "1.2":
+		t.config.MinVersion = tls.VersionTLS12
+	case "1.3":
+		t.config.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("server: unsupported tls min_version %q", cfg.MinVersion)
+	}
+
+	if err := setClientAuth(t.config, cfg); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// setClientAuth configures client certificate verification (mTLS)
+// setClientAuth 配置客户端证书校验（mTLS）
+func setClientAuth(c *tls.Config, cfg TLSConfig) error {
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("server: read client ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: no certificates in %s", cfg.ClientCAFile)
+		}
+		c.ClientCAs = pool
+	}
+
+	mode := cfg.ClientAuth
+	if mode == "" && cfg.ClientCAFile != "" {
+		mode = "verify"
+	}
+	switch mode {
+	case "":
+		c.ClientAuth = tls.NoClientCert
+	case "request":
+		c.ClientAuth = tls.RequestClientCert
+	case "require":
+		c.ClientAuth = tls.RequireAnyClientCert
+	case "verify_if_given":
+		c.ClientAuth = tls.VerifyClientCertIfGiven
+	case "verify":
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return fmt.Errorf("server: unsupported tls client_auth %q", cfg.ClientAuth)
+	}
+	if (mode == "verify" || mode == "verify_if_given") && c.ClientCAs == nil {
+		return fmt.Errorf("server: tls client_auth %q requires client_ca_file", mode)
+	}
+	return nil
+}
+
+// Config returns the crypto/tls configuration
+// Config 返回 crypto/tls 配置
+func (t *TLS) Config() *tls.Config {
+	return t.config
+}
+
+// Listen opens a TLS listener on addr
+// Listen 在 addr 上打开 TLS 监听
+func (t *TLS) Listen(addr string) (net.Listener, error) {
+	return tls.Listen("tcp", addr, t.config)
+}
+
+// RedirectServer returns the plain HTTP server that redirects to the HTTPS listener on httpsAddr,
+// or nil when no redirect_addr is configured. With autocert it also answers http-01 challenges.
+// RedirectServer 返回重定向到 httpsAddr 上 HTTPS 监听的 HTTP 服务器，未配置 redirect_addr 时返回 nil。
+// 使用 autocert 时它同时响应 http-01 验证。
+func (t *TLS) RedirectServer(httpsAddr string) *http.Server {
+	if t.redirect == "" {
+		return nil
+	}
+	var handler http.Handler = RedirectHandler(httpsAddr)
+	if t.challenge != nil {
+		handler = t.challenge(handler)
+	}
+	return &http.Server{
+		Addr:              t.redirect,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// RedirectHandler permanently redirects every request to the same host and path over HTTPS on httpsAddr's port
+// RedirectHandler 将所有请求永久重定向到 httpsAddr 端口上相同主机和路径的 HTTPS 地址
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.Trim(r.Host, "[]")
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert creates a certificate signed by parent (self-signed when parent is nil) and writes it as PEM files
+func writeCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return cert, key, certFile, keyFile
+}
+
+func TestNewTLSValidation(t *testing.T) {
+	if tl, err := NewTLS(TLSConfig{}); tl != nil || err != nil {
+		t.Errorf("disabled: got %v, %v", tl, err)
+	}
+
+	dir := t.TempDir()
+	_, _, certFile, keyFile := writeCert(t, dir, "server", false, nil, nil)
+	cases := map[string]TLSConfig{
+		"no certificate":    {Enabled: true},
+		"autocert domains":  {Enabled: true, AutoCert: true},
+		"missing file":      {Enabled: true, CertFile: filepath.Join(dir, "none.crt"), KeyFile: keyFile},
+		"min version":       {Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"},
+		"client auth mode":  {Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientAuth: "always"},
+		"verify without ca": {Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientAuth: "verify"},
+	}
+	for name, cfg := range cases {
+		if _, err := NewTLS(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	tl, err := NewTLS(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+	if err != nil {
+		t.Fatalf("NewTLS failed: %v", err)
+	}
+	if tl.Config().MinVersion != tls.VersionTLS13 || tl.Config().ClientAuth != tls.NoClientCert {
+		t.Errorf("unexpected config: min %x, client auth %v", tl.Config().MinVersion, tl.Config().ClientAuth)
+	}
+	if tl.RedirectServer(":443") != nil {
+		t.Error("redirect server without redirect_addr")
+	}
+}
+
+func TestTLSMutualAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caFile, _ := writeCert(t, dir, "ca", true, nil, nil)
+	_, _, certFile, keyFile := writeCert(t, dir, "server", false, ca, caKey)
+	_, _, clientCert, clientKey := writeCert(t, dir, "client", false, ca, caKey)
+
+	tl, err := NewTLS(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("NewTLS failed: %v", err)
+	}
+	if tl.Config().ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("client auth = %v, want verify by default with client_ca_file", tl.Config().ClientAuth)
+	}
+
+	ln, err := tl.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Write([]byte("ok"))
+			conn.Close()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	dial := func(certs []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{RootCAs: roots, Certificates: certs})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// TLS 1.3 reports a rejected client certificate on the first read | TLS 1.3 在首次读取时报告客户端证书被拒绝
+		_, err = conn.Read(make([]byte, 2))
+		return err
+	}
+
+	if err := dial(nil); err == nil {
+		t.Error("handshake without client certificate succeeded")
+	}
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dial([]tls.Certificate{cert}); err != nil {
+		t.Errorf("handshake with client certificate failed: %v", err)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	cases := []struct {
+		httpsAddr, host, path, want string
+	}{
+		{":443", "example.com", "/a?b=1", "https://example.com/a?b=1"},
+		{":443", "example.com:80", "/", "https://example.com/"},
+		{":8443", "example.com:8080", "/x", "https://example.com:8443/x"},
+		{":443", "[::1]:80", "/", "https://[::1]/"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		rec := httptest.NewRecorder()
+		RedirectHandler(tc.httpsAddr).ServeHTTP(rec, req)
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tc.want {
+			t.Errorf("%s%s: got %d %q, want %q", tc.host, tc.path, rec.Code, rec.Header().Get("Location"), tc.want)
+		}
+	}
+
+	tl := &TLS{redirect: ":80", config: &tls.Config{}}
+	if srv := tl.RedirectServer(":443"); srv == nil || srv.Addr != ":80" {
+		t.Errorf("RedirectServer = %+v", srv)
+	}
+}
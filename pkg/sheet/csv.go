@@ -0,0 +1,71 @@
+package sheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter writes RFC 4180 CSV
+// csvWriter 写入 RFC 4180 CSV
+type csvWriter struct {
+	w   *csv.Writer
+	row []string
+}
+
+// NewCSVWriter creates a CSV writer, optionally starting with a UTF-8 BOM
+// NewCSVWriter 创建 CSV 写入器，可选以 UTF-8 BOM 开头
+func NewCSVWriter(w io.Writer, bom bool) (Writer, error) {
+	if bom {
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) WriteRow(cells []any) error {
+	c.row = c.row[:0]
+	for _, v := range cells {
+		c.row = append(c.row, formatCell(v))
+	}
+	return c.w.Write(c.row)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvReader reads CSV, tolerating a leading BOM and ragged rows
+// csvReader 读取 CSV，容忍开头的 BOM 和列数不一致的行
+type csvReader struct {
+	r    *csv.Reader
+	line int
+}
+
+// NewCSVReader creates a CSV reader
+// NewCSVReader 创建 CSV 读取器
+func NewCSVReader(r io.Reader) Reader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xEF\xBB\xBF" {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return &csvReader{r: cr}
+}
+
+func (c *csvReader) Read() ([]string, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	c.line, _ = c.r.FieldPos(0)
+	return row, nil
+}
+
+func (c *csvReader) Line() int {
+	return c.line
+}
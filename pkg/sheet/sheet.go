@@ -0,0 +1,143 @@
+// Package sheet streams tabular data as CSV or XLSX
+// Rows are written and read one at a time, so large files never sit in memory as a whole.
+// Package sheet 以 CSV 或 XLSX 格式流式读写表格数据
+// 按行写入和读取，因此大文件不会整体保存在内存中。
+//
+// Usage | 用法:
+//
+//	w, err := sheet.NewWriter(sheet.FormatXLSX, file)
+//	w.WriteRow([]any{"ID", "Name", "Created"})
+//	w.WriteRow([]any{42, "Alice", time.Now()})
+//	err = w.Close()
+//
+//	r, err := sheet.NewReader(sheet.FormatOf("users.xlsx"), file, size)
+//	for {
+//		row, err := r.Read()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+package sheet
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported formats | 支持的格式
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// TimeLayout formats time.Time cells
+// TimeLayout 用于格式化 time.Time 单元格
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Writer writes rows of one sheet
+// Writer 写入一个工作表的行
+type Writer interface {
+	// WriteRow appends one row | WriteRow 追加一行
+	WriteRow(cells []any) error
+	// Close flushes buffered data; it does not close the underlying writer
+	// Close 刷新缓冲数据；不会关闭底层 writer
+	Close() error
+}
+
+// NewWriter creates a writer for format; CSV output starts with a UTF-8 BOM so Excel detects the encoding
+// NewWriter 创建指定格式的写入器；CSV 输出以 UTF-8 BOM 开头，便于 Excel 识别编码
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w, true)
+	case FormatXLSX:
+		return NewXLSXWriter(w, "Sheet1")
+	default:
+		return nil, fmt.Errorf("sheet: unsupported format %q", format)
+	}
+}
+
+// Reader reads rows of one sheet
+// Reader 读取一个工作表的行
+type Reader interface {
+	// Read returns the next row, io.EOF after the last one | Read 返回下一行，最后一行之后返回 io.EOF
+	Read() ([]string, error)
+	// Line returns the 1-based row number of the last row read | Line 返回最近读取行的行号（从 1 开始）
+	Line() int
+}
+
+// NewReader creates a reader for format; XLSX needs random access, hence io.ReaderAt
+// NewReader 创建指定格式的读取器；XLSX 需要随机访问，因此使用 io.ReaderAt
+func NewReader(format string, r io.ReaderAt, size int64) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(io.NewSectionReader(r, 0, size)), nil
+	case FormatXLSX:
+		return NewXLSXReader(r, size)
+	default:
+		return nil, fmt.Errorf("sheet: unsupported format %q", format)
+	}
+}
+
+// FormatOf returns the format of a file name by its extension, empty if unsupported
+// FormatOf 根据扩展名返回文件格式，不支持时返回空
+func FormatOf(name string) string {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if !Supported(format) {
+		return ""
+	}
+	return format
+}
+
+// ContentType returns the MIME type of format
+// ContentType 返回格式的 MIME 类型
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Supported reports whether format can be written and read
+// Supported 判断格式是否支持读写
+func Supported(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+// formatCell renders a cell as text
+// formatCell 将单元格渲染为文本
+func formatCell(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(TimeLayout)
+	case *time.Time:
+		if x == nil {
+			return ""
+		}
+		return formatCell(*x)
+	case bool:
+		return strconv.FormatBool(x)
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,185 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteRow([]any{"id", "name", "created"})
+	w.WriteRow([]any{int64(42), "Smith, \"J\"", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\xEF\xBB\xBFid,name,created\n42,\"Smith, \"\"J\"\"\",2024-05-01 08:30:00\n"
+	if buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteRow([]any{"id", "note"})
+	w.WriteRow([]any{7, "a < b & c"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	var doc struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(sheet), &doc); err != nil {
+		t.Fatalf("sheet is not valid XML: %v\n%s", err, sheet)
+	}
+	if len(doc.Rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(doc.Rows))
+	}
+	c := doc.Rows[1].Cells
+	if c[0].Ref != "A2" || c[0].Value != "7" || c[0].Type != "" {
+		t.Errorf("numeric cell = %+v", c[0])
+	}
+	if c[1].Ref != "B2" || c[1].Type != "inlineStr" || c[1].Inline != "a < b & c" {
+		t.Errorf("string cell = %+v", c[1])
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	got := strings.Join([]string{columnName(0), columnName(25), columnName(26), columnName(701), columnName(702)}, ",")
+	if got != "A,Z,AA,ZZ,AAA" {
+		t.Errorf("column names = %s", got)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	r := NewCSVReader(strings.NewReader("\xEF\xBB\xBFname,age\n\"Smith, J\",42\nBob\n"))
+	var rows [][]string
+	var lines []int
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+		lines = append(lines, r.Line())
+	}
+	if len(rows) != 3 || rows[0][0] != "name" || rows[1][0] != "Smith, J" || len(rows[2]) != 1 {
+		t.Fatalf("rows = %q", rows)
+	}
+	if lines[2] != 3 {
+		t.Errorf("lines = %v, want last 3", lines)
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatXLSX, &buf)
+	w.WriteRow([]any{"id", "name", "active"})
+	w.WriteRow([]any{7, "a < b", true})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(FormatXLSX, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	row, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(row, "|") != "7|a < b|true" || r.Line() != 2 {
+		t.Errorf("row = %q line %d", row, r.Line())
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("err = %v, want EOF", err)
+	}
+}
+
+func TestXLSXReaderSharedStrings(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Data" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>name</t></si><si><r><t>Al</t></r><r><t>ice</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+			`<row r="4"><c r="A4" t="s"><v>1</v></c><c r="C4"><v>3.5</v></c><c r="D4" t="b"><v>0</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, body := range parts {
+		f, _ := zw.Create(name)
+		io.WriteString(f, body)
+	}
+	zw.Close()
+
+	r, err := NewXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Read()
+	row, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(row, "|") != "Alice||3.5|false" || r.Line() != 4 {
+		t.Errorf("row = %q line %d", row, r.Line())
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for name, want := range map[string]string{"a.CSV": FormatCSV, "b.xlsx": FormatXLSX, "c.xls": "", "d": ""} {
+		if got := FormatOf(name); got != want {
+			t.Errorf("FormatOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Static parts of a single-sheet workbook | 单工作表工作簿的静态部分
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// xlsxWriter streams rows into xl/worksheets/sheet1.xml using inline strings
+// xlsxWriter 使用内联字符串将行流式写入 xl/worksheets/sheet1.xml
+type xlsxWriter struct {
+	zw   *zip.Writer
+	buf  *bufio.Writer
+	rows int
+}
+
+// NewXLSXWriter creates an XLSX writer with one sheet
+// NewXLSXWriter 创建只有一个工作表的 XLSX 写入器
+func NewXLSXWriter(w io.Writer, sheetName string) (Writer, error) {
+	zw := zip.NewWriter(w)
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escapeXML(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet is the last entry, so rows can be streamed into it | 工作表是最后一个条目，因此可以流式写入行
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(f)
+	if _, err := buf.WriteString(xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, buf: buf}, nil
+}
+
+func (x *xlsxWriter) WriteRow(cells []any) error {
+	x.rows++
+	row := strconv.Itoa(x.rows)
+	x.buf.WriteString(`<row r="` + row + `">`)
+	for i, v := range cells {
+		ref := columnName(i) + row
+		switch n := v.(type) {
+		case int, int8, int16, int32, uint, uint8, uint16, uint32, float32, float64:
+			x.buf.WriteString(`<c r="` + ref + `"><v>` + formatCell(n) + `</v></c>`)
+		default:
+			// int64/uint64 are usually IDs beyond float precision, keep them as text | int64/uint64 通常是超出浮点精度的 ID，保留为文本
+			text := formatCell(v)
+			if text == "" {
+				continue
+			}
+			x.buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + escapeXML(text) + `</t></is></c>`)
+		}
+	}
+	_, err := x.buf.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.buf.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := x.buf.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// xlsxText is rich or plain text of a shared or inline string
+// xlsxText 是共享字符串或内联字符串的富文本或纯文本
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+// xlsxRow is one <row> of a worksheet
+// xlsxRow 是工作表中的一个 <row>
+type xlsxRow struct {
+	R     int `xml:"r,attr"`
+	Cells []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		V      string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// xlsxReader streams the rows of the first worksheet
+// xlsxReader 流式读取第一个工作表的行
+type xlsxReader struct {
+	rc     io.ReadCloser
+	dec    *xml.Decoder
+	shared []string
+	line   int
+}
+
+// NewXLSXReader creates a reader over the first worksheet of a workbook
+// Cells are returned as text: numbers as written, booleans as true/false, dates as serial numbers.
+// NewXLSXReader 创建读取工作簿第一个工作表的读取器
+// 单元格以文本返回：数字保持原样，布尔值为 true/false，日期为序列号。
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("sheet: open xlsx: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+	f := files[firstSheetPath(files)]
+	if f == nil {
+		return nil, fmt.Errorf("sheet: xlsx has no worksheet")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxReader{rc: rc, dec: xml.NewDecoder(rc), shared: shared}, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			x.rc.Close()
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if err := x.dec.DecodeElement(&row, &start); err != nil {
+			x.rc.Close()
+			return nil, fmt.Errorf("sheet: decode row: %w", err)
+		}
+		// Empty rows are not stored, follow r so line numbers match the sheet | 空行不会被存储，跟随 r 使行号与工作表一致
+		x.line++
+		if row.R > 0 {
+			x.line = row.R
+		}
+		return x.cells(row), nil
+	}
+}
+
+func (x *xlsxReader) Line() int {
+	return x.line
+}
+
+// cells converts a row into text, placing cells by their reference
+// cells 将行转换为文本，按单元格引用定位
+func (x *xlsxReader) cells(row xlsxRow) []string {
+	var out []string
+	for _, c := range row.Cells {
+		i := len(out)
+		if c.Ref != "" {
+			i = columnIndex(c.Ref)
+		}
+		for len(out) <= i {
+			out = append(out, "")
+		}
+		switch c.Type {
+		case "s":
+			if n, err := strconv.Atoi(c.V); err == nil && n >= 0 && n < len(x.shared) {
+				out[i] = x.shared[n]
+			}
+		case "inlineStr":
+			out[i] = c.Inline.String()
+		case "b":
+			out[i] = strconv.FormatBool(c.V == "1")
+		default:
+			out[i] = c.V
+		}
+	}
+	return out
+}
+
+// readSharedStrings loads xl/sharedStrings.xml, which may be absent
+// readSharedStrings 加载 xl/sharedStrings.xml，该文件可能不存在
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodeZipXML(f, &sst); err != nil {
+		return nil, fmt.Errorf("sheet: read shared strings: %w", err)
+	}
+	out := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		out[i] = item.String()
+	}
+	return out, nil
+}
+
+// firstSheetPath resolves the first worksheet through the workbook relationships
+// firstSheetPath 通过工作簿关系解析第一个工作表的路径
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if decodeZipXML(files["xl/workbook.xml"], &wb) != nil || len(wb.Sheets) == 0 ||
+		decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels) != nil {
+		return fallback
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+// decodeZipXML decodes one XML entry of the archive
+// decodeZipXML 解码压缩包中的一个 XML 条目
+func decodeZipXML(f *zip.File, v any) error {
+	if f == nil {
+		return fmt.Errorf("missing entry")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column index
+// columnIndex 将 "AB12" 等单元格引用转换为从零开始的列索引
+func columnIndex(ref string) int {
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+	}
+	return n - 1
+}
+
+// columnName converts a zero-based index to A, B, ..., Z, AA, ...
+// columnName 将从零开始的索引转换为 A、B、...、Z、AA、...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// escapeXML escapes text and drops characters XML cannot carry
+// escapeXML 转义文本并替换 XML 无法承载的字符
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
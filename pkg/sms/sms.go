@@ -0,0 +1,197 @@
+// Package sms provides SMS sending through Aliyun, Tencent Cloud and Twilio
+// Package sms 提供通过阿里云、腾讯云和 Twilio 发送短信的功能
+//
+// Usage | 用法:
+//
+//	sms.Get().Send(ctx, &sms.Message{
+//		To:       []string{"+8613800000000"},
+//		Template: "verify_code",
+//		Params:   map[string]string{"code": "123456"},
+//	})
+package sms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Supported drivers | 支持的驱动
+const (
+	DriverAliyun  = "aliyun"
+	DriverTencent = "tencent"
+	DriverTwilio  = "twilio"
+)
+
+// Config represents SMS configuration
+// Config 表示短信配置
+type Config struct {
+	Driver    string            `toml:"driver"`     // aliyun, tencent or twilio | aliyun、tencent 或 twilio
+	AccessKey string            `toml:"access_key"` // Aliyun AccessKey ID / Tencent SecretId / Twilio Account SID
+	SecretKey string            `toml:"secret_key"` // Aliyun AccessKey secret / Tencent SecretKey / Twilio auth token
+	SignName  string            `toml:"sign_name"`  // Signature name (Aliyun, Tencent) | 短信签名（阿里云、腾讯云）
+	AppID     string            `toml:"app_id"`     // Tencent SmsSdkAppId | 腾讯云 SmsSdkAppId
+	Region    string            `toml:"region"`     // Aliyun cn-hangzhou / Tencent ap-guangzhou by default | 默认阿里云 cn-hangzhou / 腾讯云 ap-guangzhou
+	From      string            `toml:"from"`       // Twilio sender number or messaging service SID | Twilio 发送号码或消息服务 SID
+	Endpoint  string            `toml:"endpoint"`   // API base URL override | API 基础地址覆盖
+	Timeout   time.Duration     `toml:"timeout"`    // Request timeout, default 10s | 请求超时，默认 10s
+	Templates map[string]string `toml:"templates"`  // Template name to provider template code (Twilio: message text) | 模板名到服务商模板编号（Twilio 为消息文本）
+}
+
+// Message represents an SMS message
+// Message 表示短信消息
+type Message struct {
+	To       []string          // Phone numbers, E.164 recommended | 手机号，建议 E.164 格式
+	Template string            // Template name from Config.Templates or a raw template code | Config.Templates 中的模板名或原始模板编号
+	Params   map[string]string // Template parameters; Tencent uses keys "1", "2", ... in order | 模板参数；腾讯云按 "1"、"2"… 顺序使用
+	Content  string            // Plain text body (Twilio), overrides Template | 纯文本内容（Twilio），优先于 Template
+}
+
+// Error is a provider error response
+// Error 表示服务商错误响应
+type Error struct {
+	Provider  string // Driver name | 驱动名称
+	Code      string // Provider error code | 服务商错误码
+	Message   string // Provider error message | 服务商错误信息
+	Temporary bool   // Whether retrying may succeed | 重试是否可能成功
+}
+
+// Error implements error interface
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("sms %s: %s: %s", e.Provider, e.Code, e.Message)
+}
+
+// IsTemporary reports whether a send failure is transient: network errors and provider throttling/system errors
+// IsTemporary 判断发送失败是否为暂时性错误：网络错误以及服务商限流/系统错误
+func IsTemporary(err error) bool {
+	var smsErr *Error
+	if errors.As(err, &smsErr) {
+		return smsErr.Temporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// transport sends a message through one provider
+// transport 通过一个服务商发送消息
+type transport interface {
+	send(ctx context.Context, c *Client, msg *Message) error
+}
+
+// Client represents an SMS client
+// Client 表示短信客户端
+type Client struct {
+	config    Config
+	http      *http.Client
+	transport transport
+}
+
+// New creates an SMS client for the configured driver
+// New 根据配置的驱动创建短信客户端
+func New(cfg Config) (*Client, error) {
+	c := &Client{config: cfg, http: &http.Client{Timeout: cfg.Timeout}}
+	if c.http.Timeout <= 0 {
+		c.http.Timeout = 10 * time.Second
+	}
+	switch cfg.Driver {
+	case DriverAliyun:
+		c.transport = aliyunTransport{}
+	case DriverTencent:
+		c.transport = tencentTransport{}
+	case DriverTwilio:
+		c.transport = twilioTransport{}
+	default:
+		return nil, fmt.Errorf("sms: unsupported driver: %s", cfg.Driver)
+	}
+	return c, nil
+}
+
+var defaultClient *Client // Default SMS client | 默认短信客户端
+
+// Init initializes the default client
+// If driver is empty, skip initialization
+// Init 初始化默认客户端
+// 如果 driver 为空，跳过初始化
+func Init(cfg Config) error {
+	if cfg.Driver == "" {
+		log.Println("sms: driver not configured, skip initialization")
+		return nil
+	}
+	client, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	defaultClient = client
+	log.Printf("sms: initialized, driver: %s", cfg.Driver)
+	return nil
+}
+
+// Get returns the default SMS client, nil when not configured
+// Get 返回默认短信客户端，未配置时为 nil
+func Get() *Client {
+	return defaultClient
+}
+
+// Send sends a message with the default client
+// Send 使用默认客户端发送消息
+func Send(ctx context.Context, msg *Message) error {
+	if defaultClient == nil {
+		return errors.New("sms: not initialized")
+	}
+	return defaultClient.Send(ctx, msg)
+}
+
+// Send sends a message
+// Send 发送消息
+func (c *Client) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("recipient cannot be empty")
+	}
+	if msg.Template == "" && msg.Content == "" {
+		return fmt.Errorf("template or content is required")
+	}
+	return c.transport.send(ctx, c, msg)
+}
+
+// templateCode resolves a template name to the provider template code
+// templateCode 将模板名解析为服务商模板编号
+func (c *Client) templateCode(name string) string {
+	if code, ok := c.config.Templates[name]; ok {
+		return code
+	}
+	return name
+}
+
+// text renders the plain text body for providers without server-side templates
+// text 为不支持服务端模板的服务商渲染纯文本内容
+func (c *Client) text(msg *Message) (string, error) {
+	if msg.Content != "" {
+		return msg.Content, nil
+	}
+	tpl, err := template.New(msg.Template).Option("missingkey=error").Parse(c.templateCode(msg.Template))
+	if err != nil {
+		return "", fmt.Errorf("sms: invalid template %s: %w", msg.Template, err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, msg.Params); err != nil {
+		return "", fmt.Errorf("sms: render template %s: %w", msg.Template, err)
+	}
+	return buf.String(), nil
+}
+
+// apiBase returns the configured endpoint or the provider default
+// apiBase 返回配置的地址或服务商默认地址
+func (c *Client) apiBase(def string) string {
+	if c.config.Endpoint != "" {
+		return strings.TrimSuffix(c.config.Endpoint, "/")
+	}
+	return def
+}
@@ -0,0 +1,151 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew_UnsupportedDriver(t *testing.T) {
+	if _, err := New(Config{Driver: "pigeon"}); err == nil {
+		t.Error("expected error for unsupported driver")
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	c, _ := New(Config{Driver: DriverTwilio})
+	if err := c.Send(context.Background(), &Message{Content: "x"}); err == nil {
+		t.Error("expected error without recipients")
+	}
+	if err := c.Send(context.Background(), &Message{To: []string{"+1"}}); err == nil {
+		t.Error("expected error without template or content")
+	}
+}
+
+func TestAliyun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("TemplateCode") != "SMS_123" || q.Get("PhoneNumbers") != "13800000000" || q.Get("SignName") != "Crab" {
+			t.Errorf("unexpected query %v", q)
+		}
+		if q.Get("TemplateParam") != `{"code":"1234"}` {
+			t.Errorf("TemplateParam = %s", q.Get("TemplateParam"))
+		}
+
+		// Recompute the signature over everything but Signature
+		params := map[string]string{}
+		for k := range q {
+			if k != "Signature" {
+				params[k] = q.Get(k)
+			}
+		}
+		if want := aliyunSign("secret", aliyunCanonicalQuery(params)); q.Get("Signature") != want {
+			t.Errorf("Signature = %s, want %s", q.Get("Signature"), want)
+		}
+		w.Write([]byte(`{"Code":"OK","Message":"OK"}`))
+	}))
+	defer srv.Close()
+
+	c, _ := New(Config{
+		Driver: DriverAliyun, AccessKey: "id", SecretKey: "secret", SignName: "Crab", Endpoint: srv.URL,
+		Templates: map[string]string{"verify_code": "SMS_123"},
+	})
+	err := c.Send(context.Background(), &Message{To: []string{"13800000000"}, Template: "verify_code", Params: map[string]string{"code": "1234"}})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+}
+
+func TestAliyun_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Code":"isp.SYSTEM_ERROR","Message":"system error"}`))
+	}))
+	defer srv.Close()
+
+	c, _ := New(Config{Driver: DriverAliyun, Endpoint: srv.URL})
+	err := c.Send(context.Background(), &Message{To: []string{"1"}, Template: "SMS_1"})
+	if !IsTemporary(err) {
+		t.Errorf("expected temporary error, got %v", err)
+	}
+}
+
+func TestTencent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TC-Action") != "SendSms" {
+			t.Errorf("X-TC-Action = %s", r.Header.Get("X-TC-Action"))
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "TC3-HMAC-SHA256 Credential=sid/") {
+			t.Errorf("Authorization = %s", auth)
+		}
+		var body struct {
+			TemplateID       string   `json:"TemplateId"`
+			TemplateParamSet []string `json:"TemplateParamSet"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.TemplateID != "1001" || strings.Join(body.TemplateParamSet, ",") != "a,b,c" {
+			t.Errorf("unexpected body %+v", body)
+		}
+		w.Write([]byte(`{"Response":{"SendStatusSet":[{"PhoneNumber":"+8613800000000","Code":"LimitExceeded.PhoneNumberDailyLimit","Message":"limit"}]}}`))
+	}))
+	defer srv.Close()
+
+	c, _ := New(Config{Driver: DriverTencent, AccessKey: "sid", SecretKey: "key", Endpoint: srv.URL})
+	err := c.Send(context.Background(), &Message{
+		To:       []string{"+8613800000000"},
+		Template: "1001",
+		Params:   map[string]string{"10": "c", "2": "b", "1": "a"},
+	})
+	if err == nil || IsTemporary(err) {
+		t.Errorf("expected permanent error, got %v", err)
+	}
+}
+
+func TestTwilio(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "AC1" || pass != "token" {
+			t.Errorf("unexpected auth %s:%s", user, pass)
+		}
+		r.ParseForm()
+		if r.Form.Get("From") != "+15550000000" {
+			t.Errorf("From = %s", r.Form.Get("From"))
+		}
+		bodies = append(bodies, r.Form.Get("To")+":"+r.Form.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c, _ := New(Config{
+		Driver: DriverTwilio, AccessKey: "AC1", SecretKey: "token", From: "+15550000000", Endpoint: srv.URL,
+		Templates: map[string]string{"verify_code": "Your code is {{.code}}"},
+	})
+	err := c.Send(context.Background(), &Message{To: []string{"+1", "+2"}, Template: "verify_code", Params: map[string]string{"code": "42"}})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if strings.Join(bodies, "|") != "+1:Your code is 42|+2:Your code is 42" {
+		t.Errorf("bodies = %v", bodies)
+	}
+}
+
+func TestTwilio_Throttled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":20429,"message":"Too Many Requests"}`))
+	}))
+	defer srv.Close()
+
+	c, _ := New(Config{Driver: DriverTwilio, Endpoint: srv.URL})
+	err := c.Send(context.Background(), &Message{To: []string{"+1"}, Content: "hi"})
+	if !IsTemporary(err) || !strings.Contains(err.Error(), "20429") {
+		t.Errorf("expected temporary 20429 error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tencentTransport sends through the Tencent Cloud SMS API 2021-01-11 (TC3-HMAC-SHA256)
+// tencentTransport 通过腾讯云短信 API 2021-01-11 发送（TC3-HMAC-SHA256 签名）
+type tencentTransport struct{}
+
+func (tencentTransport) send(ctx context.Context, c *Client, msg *Message) error {
+	region := c.config.Region
+	if region == "" {
+		region = "ap-guangzhou"
+	}
+	payload, err := json.Marshal(map[string]any{
+		"PhoneNumberSet":   msg.To,
+		"SmsSdkAppId":      c.config.AppID,
+		"SignName":         c.config.SignName,
+		"TemplateId":       c.templateCode(msg.Template),
+		"TemplateParamSet": orderedParams(msg.Params),
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.apiBase("https://sms.tencentcloudapi.com")
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-TC-Action", "SendSms")
+	req.Header.Set("X-TC-Version", "2021-01-11")
+	req.Header.Set("X-TC-Region", region)
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("Authorization", tencentAuthorization(c.config.AccessKey, c.config.SecretKey, u.Host, payload, now))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms tencent: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Response struct {
+			Error *struct {
+				Code    string `json:"Code"`
+				Message string `json:"Message"`
+			} `json:"Error"`
+			SendStatusSet []struct {
+				PhoneNumber string `json:"PhoneNumber"`
+				Code        string `json:"Code"`
+				Message     string `json:"Message"`
+			} `json:"SendStatusSet"`
+		} `json:"Response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &Error{Provider: DriverTencent, Code: resp.Status, Message: err.Error(), Temporary: resp.StatusCode >= 500}
+	}
+	if e := result.Response.Error; e != nil {
+		return &Error{
+			Provider:  DriverTencent,
+			Code:      e.Code,
+			Message:   e.Message,
+			Temporary: strings.HasPrefix(e.Code, "InternalError") || e.Code == "RequestLimitExceeded",
+		}
+	}
+	for _, st := range result.Response.SendStatusSet {
+		if st.Code != "Ok" {
+			return &Error{Provider: DriverTencent, Code: st.Code, Message: st.PhoneNumber + ": " + st.Message}
+		}
+	}
+	return nil
+}
+
+// orderedParams returns parameter values ordered by key, numerically when keys are numbers
+// orderedParams 按键排序返回参数值，键为数字时按数值排序
+func orderedParams(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, params[k])
+	}
+	return values
+}
+
+// tencentAuthorization builds the TC3-HMAC-SHA256 Authorization header
+// tencentAuthorization 构建 TC3-HMAC-SHA256 Authorization 请求头
+func tencentAuthorization(secretID, secretKey, host string, payload []byte, now time.Time) string {
+	date := now.UTC().Format("2006-01-02")
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := "POST\n/\n\ncontent-type:application/json; charset=utf-8\nhost:" + host + "\n\ncontent-type;host\n" + hex.EncodeToString(payloadHash[:])
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/sms/tc3_request"
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(now.Unix(), 10) + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("TC3"+secretKey), date)
+	key = hmacSHA256(key, "sms")
+	key = hmacSHA256(key, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return "TC3-HMAC-SHA256 Credential=" + secretID + "/" + scope + ", SignedHeaders=content-type;host, Signature=" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
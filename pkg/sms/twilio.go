@@ -0,0 +1,74 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// twilioTransport sends through the Twilio Messages API, one request per recipient
+// twilioTransport 通过 Twilio Messages API 发送，每个收件人一个请求
+type twilioTransport struct{}
+
+func (twilioTransport) send(ctx context.Context, c *Client, msg *Message) error {
+	body, err := c.text(msg)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", c.apiBase("https://api.twilio.com"), c.config.AccessKey)
+
+	for _, to := range msg.To {
+		form := url.Values{"To": {to}, "Body": {body}}
+		// Messaging service SIDs start with MG | 消息服务 SID 以 MG 开头
+		if strings.HasPrefix(c.config.From, "MG") {
+			form.Set("MessagingServiceSid", c.config.From)
+		} else {
+			form.Set("From", c.config.From)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(c.config.AccessKey, c.config.SecretKey)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if err := twilioDo(c.http, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// twilioDo sends one request and converts error responses
+// twilioDo 发送一个请求并转换错误响应
+func twilioDo(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms twilio: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	code := strconv.Itoa(result.Code)
+	if result.Code == 0 {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	return &Error{
+		Provider:  DriverTwilio,
+		Code:      code,
+		Message:   result.Message,
+		Temporary: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 const (
@@ -21,13 +25,25 @@ const (
 	timestampShift = machineBits + sequenceBits // Timestamp shift | 时间戳左移位数
 )
 
+// DefaultMaxSkewWait is how long Generate sleeps out a backward clock jump before borrowing
+// DefaultMaxSkewWait 是 Generate 在借用序列号之前等待时钟回拨恢复的最长时间
+const DefaultMaxSkewWait = 10 * time.Millisecond
+
+// ErrClockMovedBackwards is returned by NextID when the clock moved back further than the skew wait
+// ErrClockMovedBackwards 在时钟回拨超过等待时间时由 NextID 返回
+var ErrClockMovedBackwards = errors.New("snowflake: clock moved backwards")
+
 // Node represents a Snowflake ID generator
 // Node 表示雪花 ID 生成器
 type Node struct {
-	mu        sync.Mutex // Mutex for concurrent access | 并发访问互斥锁
-	machineID int64      // Machine ID | 机器 ID
-	sequence  int64      // Sequence number | 序列号
-	lastTime  int64      // Last timestamp | 上次时间戳
+	mu          sync.Mutex    // Mutex for concurrent access | 并发访问互斥锁
+	machineID   int64         // Machine ID | 机器 ID
+	sequence    int64         // Sequence number | 序列号
+	lastTime    int64         // Last timestamp | 上次时间戳
+	maxSkewWait time.Duration // Longest backward jump waited out | 等待恢复的最大回拨时长
+	clock       clock.Clock   // Time source | 时间来源
+	skewEvents  atomic.Uint64 // Backward clock jumps seen | 检测到的时钟回拨次数
+	behind      bool          // Clock is behind the last timestamp | 时钟落后于上次时间戳
 }
 
 var defaultNode *Node // Default node instance | 默认节点实例
@@ -38,7 +54,7 @@ func Init(machineID int64) error {
 	if machineID < 0 || machineID > machineMax {
 		machineID = machineID & machineMax
 	}
-	defaultNode = &Node{machineID: machineID}
+	defaultNode = NewNode(machineID)
 	return nil
 }
 
@@ -52,25 +68,51 @@ func Generate() int64 {
 }
 
 // Generate generates a unique ID
+// When the clock moves backwards, small jumps are waited out; for larger ones the node
+// keeps counting from the last timestamp (borrowing sequence numbers, then future
+// milliseconds) until the clock catches up, so IDs stay unique and increasing.
 // Generate 生成唯一 ID
+// 时钟回拨时，较小的回拨会等待恢复；较大的回拨则从上次时间戳继续计数（先借用序列号，再借用未来毫秒），
+// 直到时钟追上，从而保证 ID 唯一且递增。
 func (n *Node) Generate() int64 {
+	id, _ := n.next(false)
+	return id
+}
+
+// NextID generates a unique ID like Generate, but returns ErrClockMovedBackwards
+// instead of borrowing when the clock moved back further than the skew wait
+// NextID 与 Generate 一样生成唯一 ID，但在时钟回拨超过等待时间时返回 ErrClockMovedBackwards 而不是借用
+func (n *Node) NextID() (int64, error) {
+	return n.next(true)
+}
+
+// next generates an ID; strict refuses to borrow on large backward jumps
+// next 生成 ID；strict 为 true 时在较大回拨下拒绝借用
+func (n *Node) next(strict bool) (int64, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	now := time.Now().UnixMilli()
+	now := n.now()
+	if now < n.lastTime {
+		now = n.waitSkew(now)
+		if now < n.lastTime && strict {
+			return 0, fmt.Errorf("%w by %dms", ErrClockMovedBackwards, n.lastTime-now)
+		}
+	}
 
-	if now == n.lastTime {
-		// Same millisecond, increment sequence | 同一毫秒内，递增序列号
+	if now <= n.lastTime {
+		// Same millisecond, or clock still behind: continue from the last timestamp
+		// 同一毫秒内，或时钟仍落后：从上次时间戳继续
+		now = n.lastTime
 		n.sequence = (n.sequence + 1) & sequenceMax
 		if n.sequence == 0 {
-			// Sequence overflow, wait for next millisecond | 序列号溢出，等待下一毫秒
-			for now <= n.lastTime {
-				now = time.Now().UnixMilli()
-			}
+			// Sequence overflow, move to the next millisecond | 序列号溢出，进入下一毫秒
+			now = n.nextMillis()
 		}
 	} else {
 		// New millisecond, reset sequence | 新的毫秒，重置序列号
 		n.sequence = 0
+		n.behind = false
 	}
 
 	n.lastTime = now
@@ -78,7 +120,80 @@ func (n *Node) Generate() int64 {
 	// Combine timestamp, machine ID, and sequence | 组合时间戳、机器 ID 和序列号
 	return ((now - epoch) << timestampShift) |
 		(n.machineID << machineShift) |
-		n.sequence
+		n.sequence, nil
+}
+
+// now returns the current time in milliseconds | now 返回当前毫秒时间
+func (n *Node) now() int64 {
+	return n.clock.Now().UnixMilli()
+}
+
+// waitSkew records a backward jump and sleeps it out when within maxSkewWait
+// A jump is counted once, not on every call until the clock catches up. The lock is
+// released while sleeping so other callers are not stalled behind this one.
+// Called with n.mu held.
+// waitSkew 记录一次时钟回拨，在 maxSkewWait 内时等待其恢复
+// 每次回拨只计数一次，而不是在时钟追上之前每次调用都计数。等待期间释放锁，避免其他调用方排队等待。
+// 调用时需持有 n.mu。
+func (n *Node) waitSkew(now int64) int64 {
+	if !n.behind {
+		n.behind = true
+		n.skewEvents.Add(1)
+	}
+	skew := time.Duration(n.lastTime-now) * time.Millisecond
+	if skew <= n.maxSkewWait {
+		n.mu.Unlock()
+		n.clock.Sleep(skew)
+		n.mu.Lock()
+		now = n.now()
+	}
+	return now
+}
+
+// nextMillis waits for the clock to pass the last timestamp
+// When the clock is behind it borrows the next millisecond instead of spinning.
+// nextMillis 等待时钟越过上次时间戳；时钟落后时借用下一毫秒而不是空转。
+func (n *Node) nextMillis() int64 {
+	for {
+		now := n.now()
+		if now > n.lastTime {
+			return now
+		}
+		if now < n.lastTime {
+			return n.lastTime + 1
+		}
+	}
+}
+
+// SetMaxSkewWait sets how long a backward clock jump is waited out before borrowing
+// SetMaxSkewWait 设置借用之前等待时钟回拨恢复的最长时间
+func (n *Node) SetMaxSkewWait(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.maxSkewWait = d
+}
+
+// SetClock replaces the time source of the node, for tests
+// SetClock 替换节点的时间来源，用于测试
+func (n *Node) SetClock(c clock.Clock) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.clock = c
+}
+
+// SkewEvents returns how many backward clock jumps the node has seen
+// SkewEvents 返回节点检测到的时钟回拨次数
+func (n *Node) SkewEvents() uint64 {
+	return n.skewEvents.Load()
+}
+
+// SkewEvents returns the backward clock jumps seen by the default node
+// SkewEvents 返回默认节点检测到的时钟回拨次数
+func SkewEvents() uint64 {
+	if defaultNode == nil {
+		return 0
+	}
+	return defaultNode.SkewEvents()
 }
 
 // Parse parses a Snowflake ID into its components
@@ -97,7 +212,7 @@ func NewNode(machineID int64) *Node {
 	if machineID < 0 || machineID > machineMax {
 		machineID = machineID & machineMax
 	}
-	return &Node{machineID: machineID}
+	return &Node{machineID: machineID, maxSkewWait: DefaultMaxSkewWait, clock: clock.Real()}
 }
 
 // SnowflakeID is a custom type for Snowflake IDs with automatic conversion support
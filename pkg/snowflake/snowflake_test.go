@@ -1,9 +1,12 @@
 package snowflake
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 func TestGenerate(t *testing.T) {
@@ -139,3 +142,120 @@ func BenchmarkGenerateParallel(b *testing.B) {
 		}
 	})
 }
+
+// fakeNode returns a node on a fake clock | fakeNode 返回使用模拟时钟的节点
+func fakeNode() (*Node, *clock.Fake) {
+	fake := clock.NewFake(time.Now())
+	node := NewNode(1)
+	node.SetClock(fake)
+	return node, fake
+}
+
+func TestGenerate_SmallClockSkewWaits(t *testing.T) {
+	node, fake := fakeNode()
+
+	first := node.Generate()
+	fake.Advance(-2 * time.Millisecond) // within DefaultMaxSkewWait
+	ids := make(chan int64)
+	go func() { ids <- node.Generate() }()
+
+	// Generate sleeps out the jump on the clock | Generate 在时钟上等待回拨恢复
+	fake.BlockUntil(1)
+	fake.Advance(2 * time.Millisecond)
+	second := <-ids
+
+	if second <= first {
+		t.Errorf("IDs not increasing after skew: %d <= %d", second, first)
+	}
+	if node.SkewEvents() != 1 {
+		t.Errorf("SkewEvents = %d, want 1", node.SkewEvents())
+	}
+}
+
+func TestGenerate_LargeClockSkewBorrows(t *testing.T) {
+	node, fake := fakeNode()
+
+	last := node.Generate()
+	fake.Advance(-time.Minute)
+
+	seen := map[int64]bool{last: true}
+	// Exhaust more than one millisecond of sequence numbers while behind
+	for i := 0; i < int(sequenceMax)*2; i++ {
+		id := node.Generate()
+		if id <= last {
+			t.Fatalf("ID %d not greater than %d", id, last)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %d", id)
+		}
+		seen[id] = true
+		last = id
+	}
+}
+
+func TestGenerate_SkewCountedOnce(t *testing.T) {
+	node, fake := fakeNode()
+
+	node.Generate()
+	fake.Advance(-time.Minute)
+	for range 100 {
+		node.Generate()
+	}
+	if node.SkewEvents() != 1 {
+		t.Errorf("SkewEvents = %d, want 1 while the clock stays behind", node.SkewEvents())
+	}
+
+	// Once the clock catches up, a new jump is counted again | 时钟追上后，新的回拨会再次计数
+	fake.Advance(2 * time.Minute)
+	node.Generate()
+	fake.Advance(-time.Minute)
+	node.Generate()
+	if node.SkewEvents() != 2 {
+		t.Errorf("SkewEvents = %d, want 2", node.SkewEvents())
+	}
+}
+
+func TestGenerate_SkewWaitReleasesLock(t *testing.T) {
+	node, fake := fakeNode()
+	node.SetMaxSkewWait(time.Second)
+
+	node.Generate()
+	fake.Advance(-300 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		node.Generate() // Sleeps out the 300ms jump | 等待 300ms 的回拨恢复
+		close(done)
+	}()
+
+	// Wait until the generator is sleeping | 等待生成器进入睡眠
+	fake.BlockUntil(1)
+	set := make(chan struct{})
+	go func() {
+		node.SetMaxSkewWait(time.Second)
+		close(set)
+	}()
+	select {
+	case <-set:
+	case <-time.After(time.Second):
+		t.Fatal("SetMaxSkewWait blocked while Generate slept")
+	}
+	fake.Advance(300 * time.Millisecond)
+	<-done
+}
+
+func TestNextID_LargeClockSkewErrors(t *testing.T) {
+	node, fake := fakeNode()
+
+	if _, err := node.NextID(); err != nil {
+		t.Fatalf("NextID failed: %v", err)
+	}
+	fake.Advance(-time.Second)
+	if _, err := node.NextID(); !errors.Is(err, ErrClockMovedBackwards) {
+		t.Errorf("expected ErrClockMovedBackwards, got %v", err)
+	}
+
+	fake.Advance(time.Second)
+	if _, err := node.NextID(); err != nil {
+		t.Errorf("NextID after recovery failed: %v", err)
+	}
+}
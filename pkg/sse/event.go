@@ -0,0 +1,92 @@
+// Package sse provides a Server-Sent Events hub, the EventSource counterpart of pkg/ws
+// Package sse 提供 Server-Sent Events Hub，是 pkg/ws 的 EventSource 版本
+package sse
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// Event represents an SSE event.
+// Event 表示 SSE 事件
+//
+// The same structure travels through Redis Pub/Sub in cluster mode, so every node
+// keeps the same ID for Last-Event-ID resume.
+// 集群模式下同一结构通过 Redis Pub/Sub 传输，因此各节点使用相同 ID 支持 Last-Event-ID 续传
+type Event struct {
+	ID     string `json:"id,omitempty"`      // Event ID, assigned by Publish when empty | 事件 ID，为空时由 Publish 分配
+	UserID int64  `json:"user_id,omitempty"` // Target user ID (0 means broadcast) | 目标用户 ID（0 表示广播）
+	Type   string `json:"type,omitempty"`    // SSE event name, empty means "message" | SSE 事件名，为空表示 "message"
+	Data   any    `json:"data,omitempty"`    // Event data, sent as JSON | 事件数据，以 JSON 发送
+
+	// Trace carries the publisher's trace context between nodes, cleared before delivery
+	// Trace 在节点间携带发布方的链路上下文，投递前清除
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// NewEvent creates an event for specific user
+// NewEvent 创建发送给特定用户的事件
+func NewEvent(userID int64, eventType string, data any) *Event {
+	return &Event{
+		UserID: userID,
+		Type:   eventType,
+		Data:   data,
+	}
+}
+
+// NewBroadcast creates a broadcast event for all users
+// NewBroadcast 创建广播给所有用户的事件
+func NewBroadcast(eventType string, data any) *Event {
+	return &Event{
+		Type: eventType,
+		Data: data,
+	}
+}
+
+// Bytes serializes event to JSON bytes
+// Bytes 将事件序列化为 JSON 字节
+func (e *Event) Bytes() []byte {
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// ParseEvent parses event from JSON bytes
+// ParseEvent 从 JSON 字节解析事件
+func ParseEvent(data []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Encode returns the event in the text/event-stream format
+// String data is sent as is, other data as JSON.
+// Encode 返回 text/event-stream 格式的事件
+// 字符串数据原样发送，其他数据以 JSON 发送。
+func (e *Event) Encode() []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Type != "" {
+		buf.WriteString("event: " + e.Type + "\n")
+	}
+	var data string
+	switch v := e.Data.(type) {
+	case string:
+		data = v
+	case nil:
+	default:
+		raw, _ := json.Marshal(v)
+		data = string(raw)
+	}
+	// Each line needs its own data field | 每一行都需要单独的 data 字段
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
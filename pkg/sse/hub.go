@@ -0,0 +1,285 @@
+package sse
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Hub is the SSE stream pool.
+// Hub 是 SSE 流连接池
+//
+// Main features | 主要功能:
+// 1. Per-user streams, a user may have several tabs or devices | 按用户的流，一个用户可以有多个标签页或设备
+// 2. Local broadcast and targeted events | 本地广播和定向事件
+// 3. Redis Pub/Sub cluster support (optional), same as ws.EnableCluster | Redis Pub/Sub 集群支持（可选），与 ws.EnableCluster 相同
+// 4. Last-Event-ID resume from a bounded history | 基于有限历史的 Last-Event-ID 续传
+//
+// Usage | 使用方法:
+//
+//	hub := sse.NewHub()
+//	router.Get("/events", middleware.RequireAuth(), hub.Handler(userIDFromCtx))
+//	hub.Publish(ctx, sse.NewEvent(123, "order.paid", payload))
+//
+// Cluster mode | 集群模式:
+//
+//	hub.EnableCluster(ctx, redisClient, "sse:user")
+type Hub struct {
+	streams     map[*Stream]bool           // All connected streams | 所有已连接的流
+	userStreams map[int64]map[*Stream]bool // Maps user ID to streams | 用户 ID 到流的映射
+	history     []*Event                   // Recent events, oldest first | 最近的事件，最旧的在前
+	opts        *Options                   // Configuration options | 配置选项
+	mu          sync.RWMutex               // Protects streams, userStreams and history | 保护 streams、userStreams 和 history
+	redis       RedisClient                // Redis client (cluster mode) | Redis 客户端（集群模式）
+	channel     string                     // Redis channel name (cluster mode) | Redis 频道名称（集群模式）
+	node        string                     // Random prefix of generated IDs | 生成 ID 的随机前缀
+	seq         atomic.Uint64              // Counter of generated IDs | 生成 ID 的计数器
+	dropped     atomic.Uint64              // Events dropped on full send buffers | 因发送缓冲区已满而丢弃的事件数
+}
+
+var (
+	hubsMu sync.Mutex
+	hubs   []*Hub // Hubs created by NewHub, for AllStats | NewHub 创建的 Hub，供 AllStats 使用
+)
+
+// NewHub creates a Hub.
+// NewHub 创建 Hub
+//
+// Example | 示例:
+//
+//	hub := sse.NewHub()
+//	hub := sse.NewHub(sse.WithHeartbeat(30 * time.Second), sse.WithHistory(1000))
+func NewHub(opts ...Option) *Hub {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	node := make([]byte, 4)
+	_, _ = rand.Read(node)
+	h := &Hub{
+		streams:     make(map[*Stream]bool),
+		userStreams: make(map[int64]map[*Stream]bool),
+		opts:        options,
+		node:        hex.EncodeToString(node),
+	}
+
+	hubsMu.Lock()
+	hubs = append(hubs, h)
+	hubsMu.Unlock()
+	return h
+}
+
+// nextID returns a new event ID, unique across nodes | nextID 返回跨节点唯一的新事件 ID
+func (h *Hub) nextID() string {
+	return h.node + "-" + strconv.FormatUint(h.seq.Add(1), 10)
+}
+
+// subscribe registers a stream of userID and returns the events after lastID it missed
+// Both happen under the lock, so no event is lost or sent twice between them.
+// subscribe 注册 userID 的流并返回 lastID 之后错过的事件
+// 两者在同一把锁内完成，因此之间不会丢失或重复事件。
+func (h *Hub) subscribe(userID int64, lastID string) (*Stream, []*Event) {
+	s := newStream(userID, h.opts.SendBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.streams[s] = true
+	if userID > 0 {
+		if h.userStreams[userID] == nil {
+			h.userStreams[userID] = make(map[*Stream]bool)
+		}
+		h.userStreams[userID][s] = true
+	}
+	log.Printf("sse: stream %d connected, total: %d", userID, len(h.streams))
+
+	if lastID == "" {
+		return s, nil
+	}
+	for i := len(h.history) - 1; i >= 0; i-- {
+		if h.history[i].ID != lastID {
+			continue
+		}
+		var missed []*Event
+		for _, e := range h.history[i+1:] {
+			if e.UserID == 0 || e.UserID == userID {
+				missed = append(missed, e)
+			}
+		}
+		return s, missed
+	}
+	// Older than the history, the client starts from now | 早于历史记录，客户端从当前开始
+	return s, nil
+}
+
+// unsubscribe removes a stream | unsubscribe 移除流
+func (h *Hub) unsubscribe(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.streams[s]; !ok {
+		return
+	}
+	delete(h.streams, s)
+	s.Close()
+	if s.UserID > 0 {
+		if streams, ok := h.userStreams[s.UserID]; ok {
+			delete(streams, s)
+			if len(streams) == 0 {
+				delete(h.userStreams, s.UserID)
+			}
+		}
+	}
+	log.Printf("sse: stream %d disconnected, total: %d", s.UserID, len(h.streams))
+}
+
+// DeliverLocal delivers event to local streams.
+// DeliverLocal 将事件投递到本地流
+//
+// Broadcasts when UserID is 0, otherwise sends to the streams of the user, and keeps the
+// event for resume. This is called when receiving events from Redis Pub/Sub; in cluster
+// mode use Publish instead.
+// UserID 为 0 时广播，否则发送到该用户的流，并保留事件用于续传。
+// 这在从 Redis Pub/Sub 接收事件时调用；集群模式下请使用 Publish。
+//
+// Returns | 返回:
+//   - int: number of streams the event was queued on | 事件进入队列的流数量
+func (h *Hub) DeliverLocal(e *Event) int {
+	if e.ID == "" {
+		e.ID = h.nextID()
+	}
+	data := e.Encode()
+
+	h.mu.Lock()
+	if h.opts.History > 0 {
+		h.history = append(h.history, e)
+		if over := len(h.history) - h.opts.History; over > 0 {
+			h.history = append(h.history[:0:0], h.history[over:]...)
+		}
+	}
+	var targets []*Stream
+	if e.UserID == 0 {
+		targets = make([]*Stream, 0, len(h.streams))
+		for s := range h.streams {
+			targets = append(targets, s)
+		}
+	} else {
+		for s := range h.userStreams[e.UserID] {
+			targets = append(targets, s)
+		}
+	}
+	h.mu.Unlock()
+
+	// Release lock before sending | 释放锁后再发送
+	sent := 0
+	for _, s := range targets {
+		if s.send(data) {
+			sent++
+		}
+	}
+	if dropped := len(targets) - sent; dropped > 0 {
+		h.dropped.Add(uint64(dropped))
+		log.Printf("sse: dropped %d events (send buffer full)", dropped)
+	}
+	return sent
+}
+
+// Broadcast sends event to all local streams
+// Broadcast 向所有本地流发送事件
+func (h *Hub) Broadcast(e *Event) {
+	e.UserID = 0
+	h.DeliverLocal(e)
+}
+
+// SendToUser sends event to the local streams of a user
+// SendToUser 向用户的本地流发送事件
+//
+// Returns | 返回:
+//   - bool: whether user was found (sent to at least one stream) | 是否找到用户（至少发送到一个流）
+func (h *Hub) SendToUser(userID int64, e *Event) bool {
+	e.UserID = userID
+	return h.DeliverLocal(e) > 0
+}
+
+// Close ends every stream, called on shutdown
+// Close 结束所有流，在关闭时调用
+func (h *Hub) Close() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.streams {
+		s.Close()
+	}
+}
+
+// ClientCount returns current stream count
+// ClientCount 返回当前流数量
+func (h *Hub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.streams)
+}
+
+// UserCount returns current online user count
+// UserCount 返回当前在线用户数
+func (h *Hub) UserCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.userStreams)
+}
+
+// IsUserOnline checks if user has a stream on this node
+// IsUserOnline 检查用户在本节点是否有流
+func (h *Hub) IsUserOnline(userID int64) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.userStreams[userID]) > 0
+}
+
+// Stats represents hub statistics
+// Stats 表示 Hub 统计信息
+type Stats struct {
+	Name    string // Hub name (Options.Name) | Hub 名称（Options.Name）
+	Clients int    // Current stream count | 当前流数量
+	Users   int    // Current online user count | 当前在线用户数
+	Dropped uint64 // Events dropped on full send buffers | 因发送缓冲区已满而丢弃的事件数
+}
+
+// Stats returns current statistics of the hub
+// Stats 返回 Hub 的当前统计信息
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return Stats{
+		Name:    h.opts.Name,
+		Clients: len(h.streams),
+		Users:   len(h.userStreams),
+		Dropped: h.dropped.Load(),
+	}
+}
+
+// AllStats returns statistics of all hubs, summed per name
+// AllStats 返回所有 Hub 的统计信息，按名称汇总
+func AllStats() []Stats {
+	hubsMu.Lock()
+	all := append([]*Hub(nil), hubs...)
+	hubsMu.Unlock()
+
+	var stats []Stats
+	index := make(map[string]int)
+	for _, h := range all {
+		s := h.Stats()
+		i, ok := index[s.Name]
+		if !ok {
+			index[s.Name] = len(stats)
+			stats = append(stats, s)
+			continue
+		}
+		stats[i].Clients += s.Clients
+		stats[i].Users += s.Users
+		stats[i].Dropped += s.Dropped
+	}
+	return stats
+}
@@ -0,0 +1,86 @@
+package sse
+
+import "time"
+
+// Default configuration values
+const (
+	defaultHeartbeat  = 15 * time.Second // Comment line interval keeping proxies from closing idle streams
+	defaultRetry      = 3 * time.Second  // Reconnect delay suggested to EventSource
+	defaultSendBuffer = 64               // Send buffer size
+	defaultHistory    = 256              // Events kept for Last-Event-ID resume
+	defaultName       = "default"        // Hub name in metrics
+)
+
+// Options represents Hub configuration options
+type Options struct {
+	// Heartbeat is the interval of ": ping" comments sent on idle streams.
+	// Default: 15 seconds
+	Heartbeat time.Duration
+
+	// Retry is the reconnect delay sent to clients in the retry field.
+	// Default: 3 seconds
+	Retry time.Duration
+
+	// SendBuffer is the capacity of each stream's send channel.
+	// Events are dropped for a stream whose buffer is full.
+	// Default: 64
+	SendBuffer int
+
+	// History is the number of recent events replayed to clients reconnecting with Last-Event-ID.
+	// 0 disables resume.
+	// Default: 256
+	History int
+
+	// Name identifies the hub in metrics.
+	// Default: "default"
+	Name string
+}
+
+// Option is a function type for configuring Options
+type Option func(*Options)
+
+// defaultOptions returns default configuration
+func defaultOptions() *Options {
+	return &Options{
+		Heartbeat:  defaultHeartbeat,
+		Retry:      defaultRetry,
+		SendBuffer: defaultSendBuffer,
+		History:    defaultHistory,
+		Name:       defaultName,
+	}
+}
+
+// WithHeartbeat sets heartbeat interval
+func WithHeartbeat(d time.Duration) Option {
+	return func(o *Options) {
+		o.Heartbeat = d
+	}
+}
+
+// WithRetry sets the reconnect delay suggested to clients
+func WithRetry(d time.Duration) Option {
+	return func(o *Options) {
+		o.Retry = d
+	}
+}
+
+// WithSendBuffer sets send buffer size
+func WithSendBuffer(size int) Option {
+	return func(o *Options) {
+		o.SendBuffer = size
+	}
+}
+
+// WithHistory sets the number of events kept for resume
+func WithHistory(size int) Option {
+	return func(o *Options) {
+		o.History = size
+	}
+}
+
+// WithName sets the hub name reported in metrics
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
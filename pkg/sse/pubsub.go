@@ -0,0 +1,153 @@
+package sse
+
+import (
+	"context"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "sse"
+
+// RedisClient defines the Redis client interface, the same as ws.RedisClient.
+type RedisClient interface {
+	Publish(ctx context.Context, channel string, message any) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// EnableCluster enables cluster mode.
+//
+// Hub subscribes to the channel and delivers received events to local streams, so
+// Publish on any node reaches the streams of every node. Event IDs are assigned by the
+// publishing node, so a client can resume with Last-Event-ID on any node.
+//
+// Usage:
+//
+//	hub := sse.NewHub()
+//	hub.EnableCluster(ctx, redisClient, "sse:user")
+//
+// Note:
+//   - Subscription will be closed when ctx is canceled
+func (h *Hub) EnableCluster(ctx context.Context, rdb RedisClient, channel string) {
+	if rdb == nil {
+		log.Println("sse: redis client is nil, cluster mode disabled")
+		return
+	}
+
+	h.redis = rdb
+	h.channel = channel
+
+	go h.subscribeLoop(ctx, channel)
+
+	log.Printf("sse: cluster mode enabled, channel: %s", channel)
+}
+
+// subscribeLoop is the subscription loop
+func (h *Hub) subscribeLoop(ctx context.Context, channel string) {
+	sub := h.redis.Subscribe(ctx, channel)
+	if sub == nil {
+		log.Printf("sse: failed to subscribe channel: %s", channel)
+		return
+	}
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("sse: unsubscribed from channel: %s", channel)
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				log.Printf("sse: channel closed: %s", channel)
+				return
+			}
+			if msg == nil {
+				continue
+			}
+
+			e, err := ParseEvent([]byte(msg.Payload))
+			if err != nil {
+				log.Printf("sse: invalid event on %s: %v", channel, err)
+				continue
+			}
+			h.deliver(ctx, e)
+		}
+	}
+}
+
+// Publish publishes event to all nodes.
+//
+// In cluster mode, events are broadcast to all nodes via Redis Pub/Sub.
+// In standalone mode (EnableCluster not called), events are delivered locally.
+//
+// Usage:
+//
+//	// Send to specific user
+//	hub.Publish(ctx, sse.NewEvent(123, "notify", payload))
+//
+//	// Broadcast to all users
+//	hub.Publish(ctx, sse.NewBroadcast("system", payload))
+func (h *Hub) Publish(ctx context.Context, e *Event) error {
+	if e.ID == "" {
+		e.ID = h.nextID()
+	}
+	if h.redis == nil {
+		h.deliver(ctx, e)
+		return nil
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "sse publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(append(eventAttrs(e), attribute.String("messaging.destination.name", h.channel))...))
+	out := *e
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		out.Trace = carrier
+	}
+	err := h.redis.Publish(ctx, h.channel, out.Bytes()).Err()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
+
+// PublishToUser publishes event to specific user (0 means broadcast).
+func (h *Hub) PublishToUser(ctx context.Context, userID int64, e *Event) error {
+	e.UserID = userID
+	return h.Publish(ctx, e)
+}
+
+// deliver delivers e to local streams inside a span, continuing e.Trace when it came from another node
+// deliver 在 span 中将 e 投递到本地流，事件来自其他节点时延续 e.Trace 中的链路
+func (h *Hub) deliver(ctx context.Context, e *Event) {
+	kind := trace.SpanKindInternal
+	if e.Trace != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(e.Trace))
+		e.Trace = nil
+		kind = trace.SpanKindConsumer
+	}
+	_, span := otel.Tracer(tracerName).Start(ctx, "sse deliver",
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(eventAttrs(e)...))
+	defer span.End()
+	span.SetAttributes(attribute.Int("sse.recipients", h.DeliverLocal(e)))
+}
+
+// eventAttrs returns the span attributes describing e
+// eventAttrs 返回描述 e 的 span 属性
+func eventAttrs(e *Event) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("sse.event.type", e.Type),
+		attribute.Int64("sse.user_id", e.UserID),
+		attribute.Bool("sse.broadcast", e.UserID == 0),
+	}
+}
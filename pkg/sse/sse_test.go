@@ -0,0 +1,170 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		event *Event
+		want  string
+	}{
+		{&Event{ID: "1", Type: "order", Data: map[string]int{"id": 7}}, "id: 1\nevent: order\ndata: {\"id\":7}\n\n"},
+		{&Event{Data: "line1\nline2"}, "data: line1\ndata: line2\n\n"},
+		{&Event{Type: "ping"}, "event: ping\ndata: \n\n"},
+	}
+	for _, tt := range tests {
+		if got := string(tt.event.Encode()); got != tt.want {
+			t.Errorf("Encode() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDeliverLocal(t *testing.T) {
+	hub := NewHub()
+	alice, _ := hub.subscribe(1, "")
+	bob, _ := hub.subscribe(2, "")
+	anon, _ := hub.subscribe(0, "")
+
+	if !hub.SendToUser(1, NewEvent(0, "private", nil)) || hub.SendToUser(3, NewEvent(0, "private", nil)) {
+		t.Error("SendToUser should only reach online users")
+	}
+	hub.Broadcast(NewBroadcast("news", nil))
+
+	counts := map[*Stream]int{alice: 2, bob: 1, anon: 1}
+	for s, want := range counts {
+		if got := len(s.events); got != want {
+			t.Errorf("Stream of user %d got %d events, want %d", s.UserID, got, want)
+		}
+	}
+	if hub.ClientCount() != 3 || hub.UserCount() != 2 || !hub.IsUserOnline(2) {
+		t.Errorf("Unexpected stats %+v", hub.Stats())
+	}
+
+	hub.unsubscribe(bob)
+	if hub.IsUserOnline(2) || hub.ClientCount() != 2 {
+		t.Error("Unsubscribed stream should be removed")
+	}
+	if bob.send([]byte("x")) {
+		t.Error("Closed stream should not accept events")
+	}
+}
+
+func TestResume(t *testing.T) {
+	hub := NewHub(WithHistory(3))
+	var ids []string
+	for i, uid := range []int64{0, 1, 2, 0, 1} {
+		e := NewEvent(uid, "e", i)
+		hub.DeliverLocal(e)
+		ids = append(ids, e.ID)
+	}
+
+	// History keeps the last 3 events: ids[2] (user 2), ids[3] (broadcast), ids[4] (user 1)
+	// 历史保留最后 3 个事件：ids[2]（用户 2）、ids[3]（广播）、ids[4]（用户 1）
+	_, missed := hub.subscribe(1, ids[2])
+	if len(missed) != 2 || missed[0].ID != ids[3] || missed[1].ID != ids[4] {
+		t.Errorf("Expected the broadcast and the event of user 1, got %+v", missed)
+	}
+	if _, missed := hub.subscribe(1, ids[0]); missed != nil {
+		t.Errorf("IDs older than the history should resume from now, got %+v", missed)
+	}
+	if _, missed := hub.subscribe(1, ids[4]); len(missed) != 0 {
+		t.Errorf("Up to date client should miss nothing, got %+v", missed)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	hub := NewHub(WithRetry(time.Second))
+	first := NewBroadcast("first", "a")
+	hub.DeliverLocal(first)
+	hub.DeliverLocal(NewBroadcast("second", "b"))
+
+	app := fiber.New()
+	app.Get("/events", hub.Handler(func(c *fiber.Ctx) int64 { return 5 }))
+
+	go func() {
+		// Wait for the stream, send one event, then end the stream | 等待流建立，发送一个事件后结束流
+		for !hub.IsUserOnline(5) {
+			time.Sleep(time.Millisecond)
+		}
+		hub.SendToUser(5, NewEvent(0, "live", "c"))
+		time.Sleep(20 * time.Millisecond)
+		hub.Close()
+	}()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", first.ID)
+	resp, err := app.Test(req, 5000)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	got := string(body)
+
+	if ct := resp.Header.Get(fiber.HeaderContentType); ct != "text/event-stream" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.HasPrefix(got, "retry: 1000\n\n") {
+		t.Errorf("Expected retry field first, got %q", got)
+	}
+	if strings.Contains(got, "event: first") || !strings.Contains(got, "event: second\ndata: b") || !strings.Contains(got, "event: live\ndata: c") {
+		t.Errorf("Expected the missed and the live event only, got %q", got)
+	}
+	for hub.ClientCount() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node1, node2 := NewHub(), NewHub()
+	node1.EnableCluster(ctx, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "sse:test")
+	node2.EnableCluster(ctx, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "sse:test")
+	s, _ := node2.subscribe(9, "")
+
+	// Wait for both subscriptions | 等待两个订阅建立
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mr.PubSubChannels("sse:*")) == 0 || mr.PubSubNumSub("sse:test")["sse:test"] < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriptions not ready")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	e := NewEvent(9, "cross", "hi")
+	if err := node1.Publish(ctx, e); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	select {
+	case data := <-s.events:
+		if !strings.HasPrefix(string(data), "id: "+e.ID+"\n") {
+			t.Errorf("Expected the publisher's ID, got %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Event not delivered across nodes")
+	}
+
+	// Both nodes keep the event for resume | 两个节点都保留事件用于续传
+	time.Sleep(20 * time.Millisecond)
+	if _, missed := node1.subscribe(9, ""); missed != nil {
+		t.Errorf("Unexpected missed events %+v", missed)
+	}
+	node1.mu.RLock()
+	n := len(node1.history)
+	node1.mu.RUnlock()
+	if n != 1 {
+		t.Errorf("Publisher node should keep the event once, got %d", n)
+	}
+}
@@ -0,0 +1,121 @@
+package sse
+
+import (
+	"bufio"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Stream is one open EventSource connection
+// Stream 表示一个打开的 EventSource 连接
+type Stream struct {
+	UserID int64 // User ID (0 means anonymous) | 用户 ID（0 表示匿名）
+
+	events chan []byte   // Encoded events waiting to be written | 等待写出的已编码事件
+	done   chan struct{} // Closed when the stream ends | 流结束时关闭
+	once   sync.Once
+}
+
+// newStream creates a stream | newStream 创建流
+func newStream(userID int64, buffer int) *Stream {
+	return &Stream{
+		UserID: userID,
+		events: make(chan []byte, buffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// send queues data without blocking, false when the buffer is full or the stream ended
+// send 非阻塞地将 data 加入队列，缓冲区已满或流已结束时返回 false
+func (s *Stream) send(data []byte) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	select {
+	case s.events <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close ends the stream, the client reconnects after the retry delay
+// Close 结束流，客户端在重连延迟后重新连接
+func (s *Stream) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Handler returns the EventSource endpoint of the hub.
+// Handler 返回 Hub 的 EventSource 接口
+//
+// userID returns the user of the request, 0 for anonymous streams which only receive
+// broadcasts; nil treats every stream as anonymous. Resume uses the Last-Event-ID header
+// sent by EventSource on reconnect, or the lastEventId query parameter.
+// userID 返回请求的用户，0 表示只接收广播的匿名流；为 nil 时所有流均为匿名。
+// 续传使用 EventSource 重连时发送的 Last-Event-ID 请求头，或 lastEventId 查询参数。
+//
+// Usage | 用法:
+//
+//	router.Get("/events", middleware.RequireAuth(), hub.Handler(func(c *fiber.Ctx) int64 {
+//		uid, _ := c.Locals(ctxutil.LocalsUserID).(int64)
+//		return uid
+//	}))
+func (h *Hub) Handler(userID func(c *fiber.Ctx) int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var uid int64
+		if userID != nil {
+			uid = userID(c)
+		}
+		lastID := c.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("lastEventId")
+		}
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no") // Disable nginx buffering | 禁用 nginx 缓冲
+
+		s, missed := h.subscribe(uid, lastID)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer h.unsubscribe(s)
+			h.serve(s, w, missed)
+		})
+		return nil
+	}
+}
+
+// serve writes events and heartbeats until the stream ends or the client goes away
+// serve 写出事件和心跳，直到流结束或客户端断开
+func (h *Hub) serve(s *Stream, w *bufio.Writer, missed []*Event) {
+	_, _ = w.WriteString("retry: " + strconv.FormatInt(h.opts.Retry.Milliseconds(), 10) + "\n\n")
+	for _, e := range missed {
+		_, _ = w.Write(e.Encode())
+	}
+	if w.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.opts.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case data := <-s.events:
+			_, _ = w.Write(data)
+		case <-heartbeat.C:
+			// Comment lines are ignored by EventSource | EventSource 会忽略注释行
+			_, _ = w.WriteString(": ping\n\n")
+		}
+		// A failed flush means the client went away | 刷新失败表示客户端已断开
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
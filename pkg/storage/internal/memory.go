@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// memoryFile is a file kept by Memory
+type memoryFile struct {
+	data         []byte
+	contentType  string
+	lastModified int64
+}
+
+// Memory in-memory storage for tests and local development, files are lost on exit
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+// NewMemory creates in-memory storage
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string]memoryFile)}
+}
+
+// Put uploads a file
+func (m *Memory) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("storage: failed to read file: %w", err)
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	m.mu.Lock()
+	m.files[key] = memoryFile{data: data, contentType: contentType, lastModified: time.Now().Unix()}
+	m.mu.Unlock()
+	return nil
+}
+
+// Get downloads a file
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	f, ok := m.files[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage: file does not exist: %s", key)
+	}
+	// Files are replaced, never modified, so readers can share the data
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// Delete deletes a file
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.files, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// Exists checks if a file exists
+func (m *Memory) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.RLock()
+	_, ok := m.files[key]
+	m.mu.RUnlock()
+	return ok, nil
+}
+
+// Info returns file information
+func (m *Memory) Info(ctx context.Context, key string) (*FileInfo, error) {
+	m.mu.RLock()
+	f, ok := m.files[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage: file does not exist: %s", key)
+	}
+	return &FileInfo{
+		Key:          key,
+		Size:         int64(len(f.data)),
+		ContentType:  f.contentType,
+		LastModified: f.lastModified,
+	}, nil
+}
+
+// URL returns the file access URL, memory files cannot be served so it only identifies the key
+func (m *Memory) URL(key string) string {
+	return "memory://" + key
+}
+
+// GetRaw returns the underlying (memory storage returns itself)
+func (m *Memory) GetRaw() any {
+	return m
+}
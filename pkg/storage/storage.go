@@ -1,5 +1,5 @@
-// Package storage provides unified object storage interface supporting local, OSS, and S3
-// Package storage 提供统一的对象存储接口，支持本地、OSS 和 S3
+// Package storage provides unified object storage interface supporting local, OSS, S3 and memory
+// Package storage 提供统一的对象存储接口，支持本地、OSS、S3 和内存
 package storage
 
 import (
@@ -52,7 +52,7 @@ type Storage interface {
 // Config represents storage configuration
 // Config 表示存储配置
 type Config struct {
-	Driver string      `toml:"driver"` // local, oss, s3 | 本地、OSS、S3
+	Driver string      `toml:"driver"` // local, oss, s3, memory | 本地、OSS、S3、内存
 	Local  LocalConfig `toml:"local"`  // Local storage configuration | 本地存储配置
 	OSS    OSSConfig   `toml:"oss"`    // Alibaba Cloud OSS configuration | 阿里云 OSS 配置
 	S3     S3Config    `toml:"s3"`     // AWS S3 configuration | AWS S3 配置
@@ -159,11 +159,20 @@ func New(cfg Config) (Storage, error) {
 		}
 		return &storageWrapper{impl: impl}, nil
 
+	case "memory":
+		return NewMemory(), nil
+
 	default:
 		return nil, fmt.Errorf("storage: unsupported driver: %s", cfg.Driver)
 	}
 }
 
+// NewMemory creates in-memory storage for tests and local development, files are lost on exit
+// NewMemory 创建用于测试和本地开发的内存存储，退出时文件丢失
+func NewMemory() Storage {
+	return &storageWrapper{impl: internal.NewMemory()}
+}
+
 // storageWrapper wraps internal implementation
 type storageWrapper struct {
 	impl interface {
@@ -0,0 +1,236 @@
+// Package containers starts throwaway service containers for integration tests through the docker CLI
+// Package containers 通过 docker 命令行为集成测试启动一次性服务容器
+//
+// Tests skip when no container runtime is available; set CRAB_REQUIRE_CONTAINERS=1 in CI to fail instead,
+// so integration tests cannot pass by skipping. CRAB_CONTAINER_RUNTIME selects another docker-compatible
+// CLI such as podman, and DOCKER_HOST is honored for remote daemons.
+// 没有可用的容器运行时时测试会跳过；在 CI 中设置 CRAB_REQUIRE_CONTAINERS=1 使其失败，避免集成测试因跳过而通过。
+// CRAB_CONTAINER_RUNTIME 可选择其他兼容 docker 的命令行（例如 podman），远程守护进程使用 DOCKER_HOST。
+//
+// Usage | 用法:
+//
+//	func TestOrders(t *testing.T) {
+//	    db, err := pgsql.New(containers.Postgres(t))
+//	    ...
+//	}
+package containers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Environment variables | 环境变量
+const (
+	RequireEnv = "CRAB_REQUIRE_CONTAINERS" // Non-empty fails tests instead of skipping | 非空时测试失败而不是跳过
+	RuntimeEnv = "CRAB_CONTAINER_RUNTIME"  // CLI to run, default docker | 使用的命令行，默认 docker
+)
+
+// DefaultTimeout bounds the start of a container until it is ready
+// DefaultTimeout 限制容器从启动到就绪的时长
+const DefaultTimeout = time.Minute
+
+// Request describes a container to start
+// Request 描述要启动的容器
+type Request struct {
+	Image string            // Image reference | 镜像引用
+	Ports []string          // Container ports to publish, e.g. "5432/tcp" | 要发布的容器端口，例如 "5432/tcp"
+	Env   map[string]string // Environment variables | 环境变量
+	Cmd   []string          // Arguments after the image | 镜像之后的参数
+
+	// Ready is polled until it returns nil; nil waits for the first port to accept connections
+	// Ready 被轮询直到返回 nil；为 nil 时等待第一个端口接受连接
+	Ready   func(ctx context.Context, c *Container) error
+	Timeout time.Duration // Startup limit, default DefaultTimeout | 启动时限，默认 DefaultTimeout
+}
+
+// Container is a running container, removed when the test ends
+// Container 表示运行中的容器，测试结束时删除
+type Container struct {
+	ID    string
+	Image string
+	Host  string // Host of the published ports | 发布端口所在的主机
+
+	ports map[string]string
+}
+
+// Port returns the host port published for a container port such as "5432/tcp"
+// Port 返回容器端口（例如 "5432/tcp"）发布到的主机端口
+func (c *Container) Port(port string) string {
+	return c.ports[port]
+}
+
+// Addr returns host:port of a published container port
+// Addr 返回已发布容器端口的 host:port
+func (c *Container) Addr(port string) string {
+	return net.JoinHostPort(c.Host, c.Port(port))
+}
+
+var (
+	runtimeOnce sync.Once
+	runtimeErr  error
+)
+
+// runtime returns the container CLI | runtime 返回容器命令行
+func runtime() string {
+	if r := os.Getenv(RuntimeEnv); r != "" {
+		return r
+	}
+	return "docker"
+}
+
+// available reports whether the container runtime answers, checked once per test binary
+// available 判断容器运行时是否可用，每个测试程序只检查一次
+func available() error {
+	runtimeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, runtimeErr = run(ctx, "info", "--format", "{{.ServerVersion}}")
+	})
+	return runtimeErr
+}
+
+// run executes the container CLI and returns its trimmed output
+// run 执行容器命令行并返回去除首尾空白的输出
+func run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, runtime(), args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s %s: %w: %s", runtime(), args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s %s: %w", runtime(), args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Run starts a container and waits until it is ready; the test skips when no runtime is available
+// unless CRAB_REQUIRE_CONTAINERS is set
+// Run 启动容器并等待就绪；没有可用运行时时测试跳过，除非设置了 CRAB_REQUIRE_CONTAINERS
+func Run(t testing.TB, req Request) *Container {
+	t.Helper()
+	if err := available(); err != nil {
+		if os.Getenv(RequireEnv) != "" {
+			t.Fatalf("containers: %s is required but unavailable: %v", RuntimeEnv, err)
+		}
+		t.Skipf("containers: no container runtime, skipping: %v", err)
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := []string{"run", "-d", "--label", "crab.testing=" + t.Name()}
+	for _, p := range req.Ports {
+		args = append(args, "-p", p)
+	}
+	for _, k := range sortedKeys(req.Env) {
+		args = append(args, "-e", k+"="+req.Env[k])
+	}
+	args = append(args, req.Image)
+	args = append(args, req.Cmd...)
+	id, err := run(ctx, args...)
+	if err != nil {
+		t.Fatalf("containers: start %s: %v", req.Image, err)
+	}
+	c := &Container{ID: id, Image: req.Image, Host: dockerHost(os.Getenv("DOCKER_HOST")), ports: make(map[string]string)}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := run(ctx, "rm", "-f", "-v", c.ID); err != nil {
+			t.Logf("containers: remove %s: %v", c.Image, err)
+		}
+	})
+
+	for _, p := range req.Ports {
+		out, err := run(ctx, "port", id, p)
+		if err != nil {
+			t.Fatalf("containers: port %s of %s: %v", p, req.Image, err)
+		}
+		if c.ports[p] = hostPort(out); c.ports[p] == "" {
+			t.Fatalf("containers: port %s of %s not published: %q", p, req.Image, out)
+		}
+	}
+
+	ready := req.Ready
+	if ready == nil && len(req.Ports) > 0 {
+		ready = func(ctx context.Context, c *Container) error {
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.Addr(req.Ports[0]))
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}
+	}
+	if ready != nil {
+		if err := wait(ctx, c, ready); err != nil {
+			logs, _ := run(context.Background(), "logs", "--tail", "30", id)
+			t.Fatalf("containers: %s not ready within %s: %v\n%s", req.Image, timeout, err, logs)
+		}
+	}
+	return c
+}
+
+// wait polls ready until it succeeds or ctx is done | wait 轮询 ready 直到成功或 ctx 结束
+func wait(ctx context.Context, c *Container, ready func(context.Context, *Container) error) error {
+	for {
+		attempt, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := ready(attempt, c)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// hostPort extracts the port of the first binding printed by "docker port", e.g. "0.0.0.0:49153"
+// hostPort 从 "docker port" 输出的第一个绑定中提取端口，例如 "0.0.0.0:49153"
+func hostPort(out string) string {
+	line, _, _ := strings.Cut(out, "\n")
+	_, port, err := net.SplitHostPort(strings.TrimSpace(line))
+	if err != nil {
+		return ""
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return ""
+	}
+	return port
+}
+
+// dockerHost returns the host of published ports for a DOCKER_HOST value, loopback for local daemons
+// dockerHost 根据 DOCKER_HOST 返回发布端口所在的主机，本地守护进程为回环地址
+func dockerHost(env string) string {
+	if u, err := url.Parse(env); err == nil && u.Scheme == "tcp" && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "127.0.0.1"
+}
+
+// sortedKeys returns the keys of m in order | sortedKeys 按顺序返回 m 的键
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
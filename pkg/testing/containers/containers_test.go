@@ -0,0 +1,47 @@
+package containers
+
+import "testing"
+
+func TestHostPort(t *testing.T) {
+	tests := map[string]string{
+		"0.0.0.0:49153":                "49153",
+		"0.0.0.0:49153\n[::]:49153":    "49153",
+		"[::]:32768":                   "32768",
+		"":                             "",
+		"Error: no public port '5432'": "",
+	}
+	for out, want := range tests {
+		if got := hostPort(out); got != want {
+			t.Errorf("hostPort(%q) = %q, want %q", out, got, want)
+		}
+	}
+}
+
+func TestDockerHost(t *testing.T) {
+	tests := map[string]string{
+		"":                            "127.0.0.1",
+		"unix:///var/run/docker.sock": "127.0.0.1",
+		"tcp://10.0.0.5:2375":         "10.0.0.5",
+		"ssh://user@build":            "127.0.0.1",
+	}
+	for env, want := range tests {
+		if got := dockerHost(env); got != want {
+			t.Errorf("dockerHost(%q) = %q, want %q", env, got, want)
+		}
+	}
+}
+
+func TestRunSkipsWithoutRuntime(t *testing.T) {
+	t.Setenv(RuntimeEnv, "crab-no-such-runtime")
+	t.Setenv(RequireEnv, "")
+	if available() == nil {
+		t.Skip("a container runtime answered before the override")
+	}
+	ok := t.Run("run", func(t *testing.T) {
+		Run(t, Request{Image: RedisImage})
+		t.Error("Expected Run to skip")
+	})
+	if !ok {
+		t.Error("Expected the subtest to skip, not fail")
+	}
+}
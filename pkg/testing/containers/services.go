@@ -0,0 +1,91 @@
+package containers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/pgsql"
+	"github.com/nuohe369/crab/pkg/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Images of the service helpers, override them in TestMain to pin other versions
+// 服务辅助函数使用的镜像，可在 TestMain 中覆盖以固定其他版本
+var (
+	PostgresImage = "postgres:16-alpine"
+	RedisImage    = "redis:7-alpine"
+	RabbitMQImage = "rabbitmq:3-alpine"
+)
+
+// Postgres starts PostgreSQL and returns the configuration of its crab database
+// Postgres 启动 PostgreSQL 并返回其 crab 数据库的配置
+func Postgres(t testing.TB) pgsql.Config {
+	t.Helper()
+	c := Run(t, Request{
+		Image: PostgresImage,
+		Ports: []string{"5432/tcp"},
+		Env:   map[string]string{"POSTGRES_USER": "crab", "POSTGRES_PASSWORD": "crab", "POSTGRES_DB": "crab"},
+		// The image restarts the server after initdb, so wait for queries rather than the port
+		// 镜像在 initdb 之后会重启服务器，因此等待查询可用而不是端口可用
+		Ready: func(ctx context.Context, c *Container) error {
+			db, err := sql.Open("postgres", postgresConfig(c).DSN())
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return db.PingContext(ctx)
+		},
+	})
+	return postgresConfig(c)
+}
+
+// postgresConfig returns the configuration of a PostgreSQL container | postgresConfig 返回 PostgreSQL 容器的配置
+func postgresConfig(c *Container) pgsql.Config {
+	port, _ := strconv.Atoi(c.Port("5432/tcp"))
+	return pgsql.Config{Host: c.Host, Port: port, User: "crab", Password: "crab", DBName: "crab"}
+}
+
+// Redis starts Redis and returns its configuration
+// Redis 启动 Redis 并返回其配置
+func Redis(t testing.TB) redis.Config {
+	t.Helper()
+	c := Run(t, Request{
+		Image: RedisImage,
+		Ports: []string{"6379/tcp"},
+		Ready: func(ctx context.Context, c *Container) error {
+			client := goredis.NewClient(&goredis.Options{Addr: c.Addr("6379/tcp")})
+			defer client.Close()
+			return client.Ping(ctx).Err()
+		},
+	})
+	return redis.Config{Addr: c.Addr("6379/tcp")}
+}
+
+// RabbitMQ starts RabbitMQ and returns an mq configuration using it
+// RabbitMQ 启动 RabbitMQ 并返回使用它的 mq 配置
+func RabbitMQ(t testing.TB) mq.Config {
+	t.Helper()
+	var cfg mq.Config
+	Run(t, Request{
+		Image: RabbitMQImage,
+		Ports: []string{"5672/tcp"},
+		// The port opens before the broker accepts logins | 端口在 broker 接受登录之前就已开放
+		Ready: func(ctx context.Context, c *Container) error {
+			cfg = mq.Config{
+				Driver:   "rabbitmq",
+				RabbitMQ: mq.RabbitMQConfig{URL: fmt.Sprintf("amqp://guest:guest@%s/", c.Addr("5672/tcp"))},
+			}
+			client, err := mq.New(cfg)
+			if err != nil {
+				return err
+			}
+			return client.Close()
+		},
+		Timeout: 2 * DefaultTimeout,
+	})
+	return cfg
+}
@@ -0,0 +1,110 @@
+package timex
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// JSONTime is a time encoded in the configured zone and layout (RFC 3339 by default), for response structs.
+// The zero time encodes as null.
+// JSONTime 表示以配置的时区和格式（默认 RFC 3339）编码的时间，用于响应结构体
+// 零值时间编码为 null。
+//
+// Example | 示例:
+//
+//	type UserVO struct {
+//		CreatedAt   timex.JSONTime  `json:"created_at"`
+//		LastLoginAt *timex.JSONTime `json:"last_login_at,omitempty"`
+//	}
+//	vo := UserVO{CreatedAt: timex.JSON(u.CreatedAt), LastLoginAt: timex.JSONPtr(u.LastLoginAt)}
+type JSONTime time.Time
+
+// JSON converts t | JSON 转换 t
+func JSON(t time.Time) JSONTime {
+	return JSONTime(t)
+}
+
+// JSONPtr converts t, nil and the zero time give nil | JSONPtr 转换 t，nil 和零值时间返回 nil
+func JSONPtr(t *time.Time) *JSONTime {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	jt := JSONTime(*t)
+	return &jt
+}
+
+// Time returns the time.Time | Time 返回 time.Time
+func (t JSONTime) Time() time.Time {
+	return time.Time(t)
+}
+
+// IsZero reports whether t is the zero time | IsZero 判断 t 是否为零值时间
+func (t JSONTime) IsZero() bool {
+	return time.Time(t).IsZero()
+}
+
+// String formats t like JSON, without quotes | String 与 JSON 格式相同，不带引号
+func (t JSONTime) String() string {
+	return Format(time.Time(t))
+}
+
+// MarshalJSON implements json.Marshaler | MarshalJSON 实现 json.Marshaler
+func (t JSONTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(Format(time.Time(t)))), nil
+}
+
+// UnmarshalJSON accepts the formats of Parse and Unix numbers; null and "" give the zero time
+// UnmarshalJSON 接受 Parse 支持的格式和 Unix 数字；null 和 "" 为零值时间
+func (t *JSONTime) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if s == "" || s == "null" {
+		*t = JSONTime{}
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*t = JSONTime(v)
+	return nil
+}
+
+// UnmarshalText parses query strings and forms | UnmarshalText 解析查询字符串和表单
+func (t *JSONTime) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*t = JSONTime{}
+		return nil
+	}
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*t = JSONTime(v)
+	return nil
+}
+
+// Value implements driver.Valuer (for database write) | Value 实现 driver.Valuer（用于数据库写入）
+func (t JSONTime) Value() (driver.Value, error) {
+	return time.Time(t), nil
+}
+
+// Scan implements sql.Scanner (for database read) | Scan 实现 sql.Scanner（用于数据库读取）
+func (t *JSONTime) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*t = JSONTime{}
+	case time.Time:
+		*t = JSONTime(v)
+	default:
+		return fmt.Errorf("timex: cannot scan %T into JSONTime", value)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package timex
+
+import (
+	"fmt"
+	"time"
+)
+
+// Unit is the length of a Period | Unit 表示 Period 的长度
+type Unit int
+
+const (
+	Day Unit = iota
+	Week
+	Month
+	Quarter
+	Year
+)
+
+// Period is the half-open interval [Start, End), e.g. a day or a month of a report
+// Period 表示左闭右开区间 [Start, End)，例如报表中的一天或一个月
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls in the period | Contains 判断 t 是否在该周期内
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Next returns the following period of the same unit | Next 返回相同单位的下一个周期
+func (p Period) Next(unit Unit) Period {
+	return PeriodOf(p.End, unit)
+}
+
+// Prev returns the preceding period of the same unit | Prev 返回相同单位的上一个周期
+func (p Period) Prev(unit Unit) Period {
+	return PeriodOf(p.Start.Add(-time.Nanosecond), unit)
+}
+
+// PeriodOf returns the period of unit containing t in the configured zone; weeks start on Monday.
+// Bounds are local midnights, so a day lasts 23 or 25 hours across a DST change.
+// PeriodOf 返回在配置时区中包含 t 的 unit 周期；每周从周一开始。
+// 边界为当地零点，因此夏令时切换的那一天为 23 或 25 小时。
+//
+// Example | 示例:
+//
+//	month := timex.PeriodOf(time.Now(), timex.Month)
+//	db.Where("created_at >= ? AND created_at < ?", month.Start, month.End)
+func PeriodOf(t time.Time, unit Unit) Period {
+	start := StartOfDay(t)
+	switch unit {
+	case Week:
+		// Monday is 0 | 周一为 0
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		return Period{Start: start, End: start.AddDate(0, 0, 7)}
+	case Month:
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+		return Period{Start: start, End: start.AddDate(0, 1, 0)}
+	case Quarter:
+		month := (start.Month()-1)/3*3 + 1
+		start = time.Date(start.Year(), month, 1, 0, 0, 0, 0, start.Location())
+		return Period{Start: start, End: start.AddDate(0, 3, 0)}
+	case Year:
+		start = time.Date(start.Year(), 1, 1, 0, 0, 0, 0, start.Location())
+		return Period{Start: start, End: start.AddDate(1, 0, 0)}
+	}
+	return Period{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// StartOfDay returns midnight of the day of t in the configured zone | StartOfDay 返回 t 在配置时区中当天的零点
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Calendar tells business days: Monday to Friday, minus holidays, plus make-up workdays.
+// Calendar 判断工作日：周一至周五，去掉节假日，加上调休上班日。
+//
+// Example | 示例:
+//
+//	// Ship within 3 business days | 3 个工作日内发货
+//	deadline := timex.Default().AddBusinessDays(order.PaidAt, 3)
+type Calendar struct {
+	holidays map[string]bool
+	workdays map[string]bool
+}
+
+// NewCalendar builds a calendar from "2006-01-02" dates | NewCalendar 根据 "2006-01-02" 日期构建日历
+func NewCalendar(holidays, workdays []string) (*Calendar, error) {
+	c := &Calendar{holidays: make(map[string]bool, len(holidays)), workdays: make(map[string]bool, len(workdays))}
+	for _, list := range []struct {
+		dates []string
+		set   map[string]bool
+	}{{holidays, c.holidays}, {workdays, c.workdays}} {
+		for _, d := range list.dates {
+			if _, err := time.Parse(DateLayout, d); err != nil {
+				return nil, fmt.Errorf("timex: invalid calendar date %q", d)
+			}
+			list.set[d] = true
+		}
+	}
+	return c, nil
+}
+
+// IsBusinessDay reports whether the day of t in the configured zone is worked
+// IsBusinessDay 判断 t 在配置时区中的当天是否为工作日
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(Location())
+	day := t.Format(DateLayout)
+	if c.workdays[day] {
+		return true
+	}
+	if c.holidays[day] {
+		return false
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// AddBusinessDays moves t by n business days (backwards when negative), keeping the time of day.
+// From a non-business day, the first step lands on the next business day.
+// AddBusinessDays 将 t 移动 n 个工作日（负数向前），保留一天中的时刻。
+// 从非工作日出发时，第一步落在下一个工作日。
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	t = t.In(Location())
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// BusinessDaysBetween counts the business days in [from, to), by calendar day
+// BusinessDaysBetween 按日历日统计 [from, to) 中的工作日数
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	n := 0
+	for day, end := StartOfDay(from), StartOfDay(to); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(day) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,158 @@
+// Package timex provides time zone aware parsing and formatting, the JSONTime type of API
+// responses and business day / period calculations.
+// Package timex 提供时区感知的解析和格式化、API 响应使用的 JSONTime 类型以及工作日/周期计算。
+//
+// Times are stored as instants (timestamptz, UTC) and shown in the configured zone,
+// so servers and clients in other zones agree on the calendar day.
+// 时间以时刻存储（timestamptz，UTC），并以配置的时区展示，
+// 因此不同时区的服务器和客户端对日历日期的理解一致。
+package timex
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Common layouts | 常用格式
+const (
+	DateLayout     = "2006-01-02"
+	DateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// Config holds the time settings | Config 保存时间设置
+type Config struct {
+	Timezone string   `toml:"timezone"` // IANA zone, e.g. "Asia/Shanghai"; empty is the server zone | IANA 时区，例如 "Asia/Shanghai"；为空时使用服务器时区
+	Layout   string   `toml:"layout"`   // JSONTime layout, default RFC 3339 | JSONTime 格式，默认 RFC 3339
+	Holidays []string `toml:"holidays"` // Non-working weekdays, "2006-01-02" | 非工作的工作日，"2006-01-02"
+	Workdays []string `toml:"workdays"` // Working weekend days (make-up days) | 需要上班的周末（调休）
+}
+
+// settings is the active configuration | settings 为当前生效的配置
+type settings struct {
+	loc      *time.Location
+	layout   string
+	calendar *Calendar
+}
+
+var current atomic.Pointer[settings]
+
+func init() {
+	current.Store(&settings{loc: time.Local, layout: time.RFC3339, calendar: &Calendar{}})
+}
+
+// Init applies cfg; the zone name and dates are validated | Init 应用 cfg，会校验时区名称和日期
+func Init(cfg Config) error {
+	loc := time.Local
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return fmt.Errorf("timex: invalid timezone %q: %w", cfg.Timezone, err)
+		}
+	}
+	layout := cfg.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	calendar, err := NewCalendar(cfg.Holidays, cfg.Workdays)
+	if err != nil {
+		return err
+	}
+	current.Store(&settings{loc: loc, layout: layout, calendar: calendar})
+	return nil
+}
+
+// Location returns the configured zone | Location 返回配置的时区
+func Location() *time.Location {
+	return current.Load().loc
+}
+
+// Layout returns the configured JSONTime layout | Layout 返回配置的 JSONTime 格式
+func Layout() string {
+	return current.Load().layout
+}
+
+// Default returns the calendar built from the configured holidays | Default 返回由配置的节假日构建的日历
+func Default() *Calendar {
+	return current.Load().calendar
+}
+
+// Now returns the current time in the configured zone | Now 返回配置时区的当前时间
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// In returns t in the configured zone | In 返回配置时区中的 t
+func In(t time.Time) time.Time {
+	return t.In(Location())
+}
+
+// Format formats t in the configured zone and layout; the zero time is "" | Format 以配置的时区和格式格式化 t；零值时间为 ""
+func Format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location()).Format(Layout())
+}
+
+// FormatDate returns the calendar day of t in the configured zone, e.g. "2024-05-01"
+// FormatDate 返回 t 在配置时区中的日历日期，例如 "2024-05-01"
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location()).Format(DateLayout)
+}
+
+// parseLayouts are tried in order by Parse | parseLayouts 为 Parse 依次尝试的格式
+var parseLayouts = []string{time.RFC3339Nano, DateTimeLayout, "2006-01-02T15:04:05", "2006-01-02 15:04", DateLayout}
+
+// Parse parses the configured layout, RFC 3339, "2006-01-02 15:04:05" or "2006-01-02".
+// Values without an offset are read in the configured zone; digits only are Unix seconds
+// or milliseconds.
+// Parse 解析配置的格式、RFC 3339、"2006-01-02 15:04:05" 或 "2006-01-02"
+// 不带时区偏移的值按配置的时区读取；纯数字为 Unix 秒或毫秒。
+func Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if ts, ok := parseUnix(s); ok {
+		return ts.In(Location()), nil
+	}
+	loc := Location()
+	if t, err := time.ParseInLocation(Layout(), s, loc); err == nil {
+		return t, nil
+	}
+	for _, layout := range parseLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timex: cannot parse %q", s)
+}
+
+// ParseDate parses a "2006-01-02" day at midnight in the configured zone | ParseDate 将 "2006-01-02" 解析为配置时区中当天的零点
+func ParseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation(DateLayout, strings.TrimSpace(s), Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timex: cannot parse date %q", s)
+	}
+	return t, nil
+}
+
+// parseUnix parses Unix seconds, or milliseconds past 13 digits | parseUnix 解析 Unix 秒，13 位及以上为毫秒
+func parseUnix(s string) (time.Time, bool) {
+	if s == "" || len(s) > 16 {
+		return time.Time{}, false
+	}
+	var n int64
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return time.Time{}, false
+		}
+		n = n*10 + int64(s[i]-'0')
+	}
+	if len(s) >= 13 {
+		return time.UnixMilli(n), true
+	}
+	return time.Unix(n, 0), true
+}
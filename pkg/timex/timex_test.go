@@ -0,0 +1,127 @@
+package timex
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// withConfig applies cfg for one test | withConfig 为单个测试应用 cfg
+func withConfig(t *testing.T, cfg Config) {
+	t.Helper()
+	prev := current.Load()
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { current.Store(prev) })
+}
+
+func TestFormatAndParse(t *testing.T) {
+	withConfig(t, Config{Timezone: "Asia/Shanghai"})
+	instant := time.Date(2024, 4, 30, 17, 30, 0, 0, time.UTC)
+
+	if s := Format(instant); s != "2024-05-01T01:30:00+08:00" {
+		t.Errorf("Format = %s", s)
+	}
+	if s := FormatDate(instant); s != "2024-05-01" {
+		t.Errorf("FormatDate = %s, the day should be the one of the zone", s)
+	}
+	for _, s := range []string{"2024-05-01T01:30:00+08:00", "2024-04-30T17:30:00Z", "2024-05-01 01:30:00", "1714498200", "1714498200000"} {
+		got, err := Parse(s)
+		if err != nil || !got.Equal(instant) {
+			t.Errorf("Parse(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := Parse("01/05/2024"); err == nil {
+		t.Error("Unknown layouts should fail")
+	}
+	if err := Init(Config{Timezone: "Mars/Olympus"}); err == nil {
+		t.Error("Unknown zones should be rejected")
+	}
+}
+
+func TestJSONTime(t *testing.T) {
+	withConfig(t, Config{Timezone: "Asia/Shanghai", Layout: DateTimeLayout})
+	var v struct {
+		At    JSONTime  `json:"at"`
+		Never *JSONTime `json:"never,omitempty"`
+		Zero  JSONTime  `json:"zero"`
+	}
+	v.At = JSON(time.Date(2024, 4, 30, 17, 30, 0, 0, time.UTC))
+	v.Never = JSONPtr(nil)
+	data, _ := json.Marshal(v)
+	if string(data) != `{"at":"2024-05-01 01:30:00","zero":null}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"at":"2024-05-01T01:30:00+08:00","zero":""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.At.Time().Equal(time.Date(2024, 4, 30, 17, 30, 0, 0, time.UTC)) || !v.Zero.IsZero() {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"at":1714498200}`), &v); err != nil || v.At.Time().Unix() != 1714498200 {
+		t.Errorf("Unix numbers should be accepted, got %v %v", v.At, err)
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	withConfig(t, Config{Timezone: "Asia/Shanghai"})
+	loc := Location()
+	at := time.Date(2024, 5, 15, 23, 0, 0, 0, time.UTC) // Thursday 16th 07:00 in Shanghai | 上海时间 16 日周四 07:00
+
+	tests := []struct {
+		unit       Unit
+		start, end string
+	}{
+		{Day, "2024-05-16", "2024-05-17"},
+		{Week, "2024-05-13", "2024-05-20"},
+		{Month, "2024-05-01", "2024-06-01"},
+		{Quarter, "2024-04-01", "2024-07-01"},
+		{Year, "2024-01-01", "2025-01-01"},
+	}
+	for _, tt := range tests {
+		p := PeriodOf(at, tt.unit)
+		if FormatDate(p.Start) != tt.start || FormatDate(p.End) != tt.end || p.Start.Location() != loc || !p.Contains(at) {
+			t.Errorf("PeriodOf(%d) = %v - %v", tt.unit, p.Start, p.End)
+		}
+	}
+	month := PeriodOf(at, Month)
+	if FormatDate(month.Next(Month).Start) != "2024-06-01" || FormatDate(month.Prev(Month).Start) != "2024-04-01" {
+		t.Errorf("Next/Prev of %v are wrong", month)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	withConfig(t, Config{Timezone: "Asia/Shanghai"})
+	// Labour Day 2024: May 1-5 off, Sunday April 28 and Saturday May 11 worked | 2024 年劳动节：5 月 1-5 日放假，4 月 28 日（周日）和 5 月 11 日（周六）上班
+	c, err := NewCalendar([]string{"2024-05-01", "2024-05-02", "2024-05-03"}, []string{"2024-04-28", "2024-05-11"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(s string) time.Time {
+		d, _ := ParseDate(s)
+		return d.Add(10 * time.Hour)
+	}
+
+	for s, want := range map[string]bool{
+		"2024-04-28": true, "2024-04-29": true, "2024-05-01": false,
+		"2024-05-04": false, "2024-05-06": true, "2024-05-11": true,
+	} {
+		if got := c.IsBusinessDay(day(s)); got != want {
+			t.Errorf("IsBusinessDay(%s) = %v", s, got)
+		}
+	}
+	if got := c.AddBusinessDays(day("2024-04-30"), 1); FormatDate(got) != "2024-05-06" || got.Hour() != 10 {
+		t.Errorf("AddBusinessDays(+1) = %v", got)
+	}
+	if got := c.AddBusinessDays(day("2024-05-06"), -2); FormatDate(got) != "2024-04-29" {
+		t.Errorf("AddBusinessDays(-2) = %v", got)
+	}
+	if n := c.BusinessDaysBetween(day("2024-04-27"), day("2024-05-12")); n != 9 {
+		t.Errorf("BusinessDaysBetween = %d, want 9", n)
+	}
+	if _, err := NewCalendar([]string{"2024-13-01"}, nil); err == nil {
+		t.Error("Invalid dates should be rejected")
+	}
+}
@@ -1,11 +1,96 @@
 package trace
 
 import (
-	"github.com/gofiber/contrib/otelfiber"
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// HeaderTraceID is the response header carrying the trace ID
+// HeaderTraceID 是携带追踪 ID 的响应头
+const HeaderTraceID = "X-Trace-ID"
+
+// fiberCarrier adapts Fiber request headers to propagation.TextMapCarrier
+// fiberCarrier 将 Fiber 请求头适配为 propagation.TextMapCarrier
+type fiberCarrier struct {
+	c *fiber.Ctx
+}
+
+func (f fiberCarrier) Get(key string) string {
+	return f.c.Get(key)
+}
+
+func (f fiberCarrier) Set(key, value string) {
+	f.c.Request().Header.Set(key, value)
+}
+
+func (f fiberCarrier) Keys() []string {
+	headers := f.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
 // FiberMiddleware returns Fiber tracing middleware
+// It starts a server span per request, continuing the caller's trace from the
+// traceparent and baggage headers, stores it in c.UserContext() and returns the
+// trace ID in X-Trace-ID. Paths under [trace] skip_paths are not traced.
+// FiberMiddleware 返回 Fiber 追踪中间件
+// 为每个请求创建服务端 span，根据 traceparent 和 baggage 请求头延续调用方的链路，
+// 将其存入 c.UserContext() 并在 X-Trace-ID 中返回追踪 ID。[trace] skip_paths 下的路径不追踪。
 func FiberMiddleware() fiber.Handler {
-	return otelfiber.Middleware()
+	tracer := otel.Tracer("fiber")
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, prefix := range current.SkipPaths {
+			if strings.HasPrefix(path, prefix) {
+				return c.Next()
+			}
+		}
+
+		// Fiber strings point into reused buffers, copy what the exporter keeps | Fiber 字符串指向复用缓冲区，复制导出器会保留的值
+		method, path := strings.Clone(c.Method()), strings.Clone(path)
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), fiberCarrier{c})
+		ctx, span := tracer.Start(ctx, method+" "+path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", path),
+				attribute.String("client.address", strings.Clone(c.IP())),
+				attribute.String("user_agent.original", strings.Clone(c.Get(fiber.HeaderUserAgent))),
+			))
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			c.Set(HeaderTraceID, sc.TraceID().String())
+		}
+
+		// Let the error handler write the response so the final status is known | 由错误处理器写入响应，以获得最终状态码
+		if err := c.Next(); err != nil {
+			span.RecordError(err)
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "HTTP "+strconv.Itoa(status))
+		}
+		return nil
+	}
 }
@@ -4,9 +4,11 @@ package trace
 
 import (
 	"context"
+	"net/http"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -17,17 +19,63 @@ import (
 
 var tracer trace.Tracer // Global tracer instance | 全局追踪器实例
 
+// Sampler names | 采样器名称
+const (
+	SamplerParentBased = "parentbased" // Follow the caller's decision, sample_ratio for root spans (default) | 遵循调用方的决定，根 span 使用 sample_ratio（默认）
+	SamplerRatio       = "ratio"       // sample_ratio for every trace, ignoring the caller | 所有链路使用 sample_ratio，忽略调用方
+	SamplerAlwaysOn    = "always_on"   // Sample everything | 全部采样
+	SamplerAlwaysOff   = "always_off"  // Sample nothing | 不采样
+)
+
 // Config represents tracing configuration
 // Config 表示追踪配置
 type Config struct {
-	ServiceName string `toml:"service_name"` // Service name | 服务名称
-	Endpoint    string `toml:"endpoint"`     // OTLP endpoint, e.g. localhost:4318 | OTLP 端点，例如 localhost:4318
-	Insecure    bool   `toml:"insecure"`     // Use insecure connection | 使用不安全连接
+	ServiceName string   `toml:"service_name"` // Service name | 服务名称
+	Endpoint    string   `toml:"endpoint"`     // OTLP endpoint, e.g. localhost:4318 | OTLP 端点，例如 localhost:4318
+	Insecure    bool     `toml:"insecure"`     // Use insecure connection | 使用不安全连接
+	Sampler     string   `toml:"sampler"`      // parentbased, ratio, always_on or always_off | 采样器
+	SampleRatio float64  `toml:"sample_ratio"` // Fraction of traces sampled, 0 means 1 | 采样比例，0 表示 1
+	SkipPaths   []string `toml:"skip_paths"`   // Path prefixes not traced by the middleware, default /health and /metrics | 中间件不追踪的路径前缀，默认 /health 和 /metrics
+}
+
+var current = Config{SkipPaths: []string{"/health", "/metrics"}} // Config of the last Init | 最近一次 Init 的配置
+
+// W3C traceparent and baggage are propagated even when no exporter is configured
+// 即使未配置导出器也会传播 W3C traceparent 和 baggage
+func init() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// newSampler builds the sampler of cfg
+// newSampler 根据配置构建采样器
+func newSampler(cfg Config) sdktrace.Sampler {
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	switch cfg.Sampler {
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample()
+	case SamplerAlwaysOff:
+		return sdktrace.NeverSample()
+	case SamplerRatio:
+		return sdktrace.TraceIDRatioBased(ratio)
+	default:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	}
 }
 
 // Init initializes tracing and returns a shutdown function
 // Init 初始化追踪并返回关闭函数
 func Init(cfg Config) (func(context.Context) error, error) {
+	if cfg.SkipPaths == nil {
+		cfg.SkipPaths = current.SkipPaths
+	}
+	current = cfg
+
 	opts := []otlptracehttp.Option{
 		otlptracehttp.WithEndpoint(cfg.Endpoint),
 	}
@@ -52,13 +100,10 @@ func Init(cfg Config) (func(context.Context) error, error) {
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
 	)
 
 	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
 
 	tracer = tp.Tracer(cfg.ServiceName)
 	return tp.Shutdown, nil
@@ -67,9 +112,20 @@ func Init(cfg Config) (func(context.Context) error, error) {
 // Start creates a child span
 // Start 创建子 span
 func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if tracer == nil {
+		return otel.Tracer("crab").Start(ctx, name, opts...)
+	}
 	return tracer.Start(ctx, name, opts...)
 }
 
+// Inject writes traceparent and baggage of ctx into outbound HTTP headers
+// pkg/httpclient does this automatically; use it for other clients.
+// Inject 将 ctx 的 traceparent 和 baggage 写入出站 HTTP 请求头
+// pkg/httpclient 会自动完成；其他客户端请使用此函数。
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
 // SpanFromContext gets current span from context
 // SpanFromContext 从上下文获取当前 span
 func SpanFromContext(ctx context.Context) trace.Span {
@@ -88,6 +144,14 @@ func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
 	trace.SpanFromContext(ctx).SetAttributes(attrs...)
 }
 
+// RecordError records an error on the current span and marks it as failed
+// RecordError 在当前 span 上记录错误并将其标记为失败
+func RecordError(ctx context.Context, err error, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
+}
+
 // TraceID gets current trace ID
 // TraceID 获取当前追踪 ID
 func TraceID(ctx context.Context) string {
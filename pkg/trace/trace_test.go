@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, "ParentBased{root:AlwaysOnSampler"},
+		{Config{SampleRatio: 0.25}, "ParentBased{root:TraceIDRatioBased{0.25}"},
+		{Config{Sampler: SamplerRatio, SampleRatio: 0.1}, "TraceIDRatioBased{0.1}"},
+		{Config{Sampler: SamplerAlwaysOff}, "AlwaysOffSampler"},
+		{Config{Sampler: SamplerAlwaysOn}, "AlwaysOnSampler"},
+	}
+	for _, tt := range tests {
+		if got := newSampler(tt.cfg).Description(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("newSampler(%+v) = %s, want prefix %s", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	h := http.Header{}
+	Inject(ctx, h)
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if got := h.Get("traceparent"); got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+	if trace.SpanContextFromContext(ctx).TraceID() != span.SpanContext().TraceID() {
+		t.Error("span not in context")
+	}
+}
@@ -0,0 +1,443 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Dead saga status constants | 死信 saga 状态常量
+const (
+	DeadSagaPending  = "pending"  // Waiting for retry or manual resolution | 等待重试或人工处理
+	DeadSagaRetrying = "retrying" // Claimed by a running retry | 已被正在执行的重试占用
+	DeadSagaResolved = "resolved" // Compensated by retry or resolved manually | 已通过重试补偿或人工处理
+)
+
+const (
+	// retryClaimTimeout after which a retrying claim is considered abandoned, e.g. the process died
+	// 重试占用的超时时间，超过后视为已放弃，例如进程已退出
+	retryClaimTimeout = 10 * time.Minute
+
+	maxPendingClosures = 1000           // In-process closures kept at most | 最多保留的进程内闭包数
+	pendingClosureTTL  = 24 * time.Hour // In-process closures are dropped after this | 进程内闭包在此时间后丢弃
+)
+
+var (
+	// ErrDeadSagaNotFound is returned when a dead saga does not exist
+	// ErrDeadSagaNotFound 在死信 saga 不存在时返回
+	ErrDeadSagaNotFound = errors.New("dead saga not found")
+
+	// ErrDeadSagaResolved is returned when retrying or resolving a resolved dead saga
+	// ErrDeadSagaResolved 在重试或处理已解决的死信 saga 时返回
+	ErrDeadSagaResolved = errors.New("dead saga already resolved")
+
+	// ErrDeadSagaRetrying is returned when another retry of the dead saga is running
+	// ErrDeadSagaRetrying 在该死信 saga 的另一次重试正在执行时返回
+	ErrDeadSagaRetrying = errors.New("dead saga retry in progress")
+)
+
+// DeadSaga records compensations that kept failing
+// Steps are listed in the order they must be compensated.
+// DeadSaga 记录持续失败的补偿
+// Steps 按需要补偿的顺序排列。
+type DeadSaga struct {
+	ID        int64             `json:"id" xorm:"pk autoincr 'id'"`
+	SagaName  string            `json:"saga_name" xorm:"varchar(100) index 'saga_name'"`  // Saga name set by WithName | 由 WithName 设置的 saga 名称
+	Steps     []string          `json:"steps" xorm:"json 'steps'"`                        // Steps still to compensate | 仍需补偿的步骤
+	Data      map[string]string `json:"data,omitempty" xorm:"json 'data'"`                // SagaStep.Data by step name | 按步骤名称保存的 SagaStep.Data
+	Error     string            `json:"error" xorm:"text 'error'"`                        // Last compensation error | 最近一次补偿错误
+	Status    string            `json:"status" xorm:"varchar(20) notnull index 'status'"` // pending, retrying or resolved | pending、retrying 或 resolved
+	Attempts  int               `json:"attempts" xorm:"default(1) 'attempts'"`            // Compensation rounds so far | 已进行的补偿轮次
+	Note      string            `json:"note,omitempty" xorm:"varchar(500) 'note'"`        // Resolution note | 处理备注
+	CreatedAt time.Time         `json:"created_at" xorm:"created 'created_at'"`           // Creation time | 创建时间
+	UpdatedAt time.Time         `json:"updated_at" xorm:"updated 'updated_at'"`           // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (d *DeadSaga) TableName() string {
+	return "saga_dead_letter"
+}
+
+// DeadLetterStore persists dead sagas
+// DeadLetterStore 持久化死信 saga
+type DeadLetterStore interface {
+	Save(ctx context.Context, d *DeadSaga) error // Insert when ID is 0, update otherwise | ID 为 0 时插入，否则更新
+	Get(ctx context.Context, id int64) (*DeadSaga, error)
+	List(ctx context.Context, status string, limit, offset int) ([]DeadSaga, int64, error)
+	// Claim atomically moves a pending dead saga, or one retrying since before staleBefore, to retrying
+	// Claim 原子地将 pending 状态或在 staleBefore 之前开始重试的死信 saga 置为 retrying
+	Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error)
+}
+
+var (
+	storeMu     sync.RWMutex
+	deadLetters DeadLetterStore = NewMemoryDeadLetterStore()
+
+	// In-process compensations of dead sagas, keyed by dead saga ID | 进程内死信 saga 的补偿函数，按死信 saga ID 索引
+	pendingMu    sync.Mutex
+	pendingSteps = map[int64]pendingClosures{}
+
+	compensatorMu sync.RWMutex
+	compensators  = map[string]func(ctx context.Context, data string) error{}
+)
+
+// pendingClosures holds the original steps of a dead saga
+// pendingClosures 保存死信 saga 的原始步骤
+type pendingClosures struct {
+	steps []SagaStep
+	at    time.Time
+}
+
+// storePending keeps the closures of a dead saga, dropping expired and then the oldest entries
+// Closures only help retries in this process; registered compensators cover the rest.
+// storePending 保存死信 saga 的闭包，先丢弃过期条目，再丢弃最旧的条目
+// 闭包只对本进程内的重试有效，其余情况由已注册的补偿器处理。
+func storePending(id int64, steps []SagaStep) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	now := time.Now()
+	for k, v := range pendingSteps {
+		if now.Sub(v.at) > pendingClosureTTL {
+			delete(pendingSteps, k)
+		}
+	}
+	for len(pendingSteps) >= maxPendingClosures {
+		var oldest int64
+		var oldestAt time.Time
+		for k, v := range pendingSteps {
+			if oldestAt.IsZero() || v.at.Before(oldestAt) || v.at.Equal(oldestAt) && k < oldest {
+				oldest, oldestAt = k, v.at
+			}
+		}
+		delete(pendingSteps, oldest)
+	}
+	pendingSteps[id] = pendingClosures{steps: steps, at: now}
+}
+
+// loadPending returns the closures of a dead saga by step name, nil when gone
+// loadPending 按步骤名称返回死信 saga 的闭包，不存在时返回 nil
+func loadPending(id int64) map[string]SagaStep {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	v, ok := pendingSteps[id]
+	if !ok {
+		return nil
+	}
+	closures := make(map[string]SagaStep, len(v.steps))
+	for _, step := range v.steps {
+		closures[step.Name] = step
+	}
+	return closures
+}
+
+// deletePending drops the closures of a resolved dead saga | deletePending 删除已解决死信 saga 的闭包
+func deletePending(id int64) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	delete(pendingSteps, id)
+}
+
+// SetDeadLetterStore replaces the dead saga store (default in memory)
+// SetDeadLetterStore 替换死信 saga 存储（默认在内存中）
+func SetDeadLetterStore(store DeadLetterStore) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	deadLetters = store
+}
+
+// getStore returns the current dead saga store | getStore 返回当前死信 saga 存储
+func getStore() DeadLetterStore {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return deadLetters
+}
+
+// RegisterCompensator registers a compensation by step name for dead sagas
+// It is used by RetryDeadSaga when the original closure is gone, e.g. after a
+// restart; it receives the SagaStep.Data saved with the dead saga.
+// RegisterCompensator 按步骤名称为死信 saga 注册补偿函数
+// 当原始闭包不存在时（例如重启后）由 RetryDeadSaga 使用，参数为随死信 saga 保存的 SagaStep.Data。
+func RegisterCompensator(stepName string, fn func(ctx context.Context, data string) error) {
+	compensatorMu.Lock()
+	defer compensatorMu.Unlock()
+	compensators[stepName] = fn
+}
+
+// recordDeadSaga stores the steps whose compensation failed
+// recordDeadSaga 保存补偿失败的步骤
+func recordDeadSaga(ctx context.Context, name string, steps []SagaStep, cause error) (int64, error) {
+	d := &DeadSaga{
+		SagaName: name,
+		Steps:    make([]string, 0, len(steps)),
+		Data:     make(map[string]string),
+		Error:    cause.Error(),
+		Status:   DeadSagaPending,
+		Attempts: 1,
+	}
+	for _, step := range steps {
+		d.Steps = append(d.Steps, step.Name)
+		if step.Data != "" {
+			d.Data[step.Name] = step.Data
+		}
+	}
+	if err := getStore().Save(ctx, d); err != nil {
+		return 0, err
+	}
+	storePending(d.ID, steps)
+	return d.ID, nil
+}
+
+// ListDeadSagas lists dead sagas, all statuses when status is empty
+// ListDeadSagas 列出死信 saga，status 为空时列出所有状态
+func ListDeadSagas(ctx context.Context, status string, limit, offset int) ([]DeadSaga, int64, error) {
+	return getStore().List(ctx, status, limit, offset)
+}
+
+// GetDeadSaga returns a dead saga by ID
+// GetDeadSaga 按 ID 返回死信 saga
+func GetDeadSaga(ctx context.Context, id int64) (*DeadSaga, error) {
+	return getStore().Get(ctx, id)
+}
+
+// RetryDeadSaga runs the pending compensations of a dead saga again
+// Steps that succeed are removed; the saga is resolved once none remain.
+// The dead saga is claimed first, so concurrent retries return ErrDeadSagaRetrying.
+// RetryDeadSaga 重新执行死信 saga 中待处理的补偿
+// 成功的步骤会被移除，全部完成后标记为已解决。
+// 执行前会先占用该死信 saga，并发重试返回 ErrDeadSagaRetrying。
+func RetryDeadSaga(ctx context.Context, id int64) error {
+	store := getStore()
+	claimed, err := store.Claim(ctx, id, time.Now().Add(-retryClaimTimeout))
+	if err != nil {
+		return err
+	}
+	d, err := store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		if d.Status == DeadSagaResolved {
+			return ErrDeadSagaResolved
+		}
+		return ErrDeadSagaRetrying
+	}
+
+	closures := loadPending(id)
+	d.Attempts++
+	for len(d.Steps) > 0 {
+		name := d.Steps[0]
+		if err := runCompensation(ctx, name, closures, d.Data[name]); err != nil {
+			d.Error = fmt.Sprintf("compensation for step '%s' failed: %v", name, err)
+			d.Status = DeadSagaPending
+			if saveErr := store.Save(ctx, d); saveErr != nil {
+				return saveErr
+			}
+			return errors.New(d.Error)
+		}
+		d.Steps = d.Steps[1:]
+	}
+
+	d.Status = DeadSagaResolved
+	d.Error = ""
+	d.Note = "compensated by retry"
+	deletePending(id)
+	return store.Save(ctx, d)
+}
+
+// ResolveDeadSaga marks a dead saga as resolved without compensating, e.g. after a manual fix
+// ResolveDeadSaga 将死信 saga 标记为已解决而不执行补偿，例如人工修复之后
+func ResolveDeadSaga(ctx context.Context, id int64, note string) error {
+	store := getStore()
+	d, err := store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch d.Status {
+	case DeadSagaResolved:
+		return ErrDeadSagaResolved
+	case DeadSagaRetrying:
+		return ErrDeadSagaRetrying
+	}
+	d.Status = DeadSagaResolved
+	d.Note = note
+	deletePending(id)
+	return store.Save(ctx, d)
+}
+
+// runCompensation compensates one step using its closure or a registered compensator
+// runCompensation 使用闭包或已注册的补偿器补偿单个步骤
+func runCompensation(ctx context.Context, name string, closures map[string]SagaStep, data string) error {
+	if step, ok := closures[name]; ok && step.Compensate != nil {
+		return step.Compensate(ctx)
+	}
+	compensatorMu.RLock()
+	fn, ok := compensators[name]
+	compensatorMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no compensator registered for step '%s'", name)
+	}
+	return fn(ctx, data)
+}
+
+// MemoryDeadLetterStore keeps dead sagas in memory, they are lost on restart
+// MemoryDeadLetterStore 在内存中保存死信 saga，重启后丢失
+type MemoryDeadLetterStore struct {
+	mu     sync.RWMutex
+	nextID int64
+	items  map[int64]DeadSaga
+}
+
+// NewMemoryDeadLetterStore creates an in-memory store
+// NewMemoryDeadLetterStore 创建内存存储
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{items: make(map[int64]DeadSaga)}
+}
+
+// Save inserts or updates a dead saga
+// Save 插入或更新死信 saga
+func (m *MemoryDeadLetterStore) Save(ctx context.Context, d *DeadSaga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if d.ID == 0 {
+		m.nextID++
+		d.ID = m.nextID
+		d.CreatedAt = now
+	}
+	d.UpdatedAt = now
+	m.items[d.ID] = *d
+	return nil
+}
+
+// Get returns a dead saga by ID
+// Get 按 ID 返回死信 saga
+func (m *MemoryDeadLetterStore) Get(ctx context.Context, id int64) (*DeadSaga, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	d, ok := m.items[id]
+	if !ok {
+		return nil, ErrDeadSagaNotFound
+	}
+	d.Steps = append([]string(nil), d.Steps...)
+	return &d, nil
+}
+
+// Claim moves a pending or stale retrying dead saga to retrying
+// Claim 将 pending 或重试已超时的死信 saga 置为 retrying
+func (m *MemoryDeadLetterStore) Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.items[id]
+	if !ok {
+		return false, ErrDeadSagaNotFound
+	}
+	if d.Status != DeadSagaPending && (d.Status != DeadSagaRetrying || !d.UpdatedAt.Before(staleBefore)) {
+		return false, nil
+	}
+	d.Status = DeadSagaRetrying
+	d.UpdatedAt = time.Now()
+	m.items[id] = d
+	return true, nil
+}
+
+// List returns dead sagas, newest first
+// List 返回死信 saga，最新的在前
+func (m *MemoryDeadLetterStore) List(ctx context.Context, status string, limit, offset int) ([]DeadSaga, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]DeadSaga, 0, len(m.items))
+	for _, d := range m.items {
+		if status == "" || d.Status == status {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+
+	total := int64(len(list))
+	if offset >= len(list) {
+		return []DeadSaga{}, total, nil
+	}
+	list = list[offset:]
+	if limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	return list, total, nil
+}
+
+// DBDeadLetterStore keeps dead sagas in the saga_dead_letter table
+// DBDeadLetterStore 在 saga_dead_letter 表中保存死信 saga
+type DBDeadLetterStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBDeadLetterStore creates a database store; migrate DeadSaga with the other models
+// NewDBDeadLetterStore 创建数据库存储，DeadSaga 需随其他模型一起迁移
+func NewDBDeadLetterStore(engine *xorm.Engine) *DBDeadLetterStore {
+	return &DBDeadLetterStore{engine: engine}
+}
+
+// Save inserts or updates a dead saga
+// Save 插入或更新死信 saga
+func (s *DBDeadLetterStore) Save(ctx context.Context, d *DeadSaga) error {
+	if d.ID == 0 {
+		_, err := s.engine.Context(ctx).Insert(d)
+		return err
+	}
+	_, err := s.engine.Context(ctx).ID(d.ID).AllCols().Update(d)
+	return err
+}
+
+// Get returns a dead saga by ID
+// Get 按 ID 返回死信 saga
+func (s *DBDeadLetterStore) Get(ctx context.Context, id int64) (*DeadSaga, error) {
+	d := &DeadSaga{}
+	has, err := s.engine.Context(ctx).ID(id).Get(d)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrDeadSagaNotFound
+	}
+	return d, nil
+}
+
+// Claim moves a pending or stale retrying dead saga to retrying with a conditional update
+// Claim 通过条件更新将 pending 或重试已超时的死信 saga 置为 retrying
+func (s *DBDeadLetterStore) Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	n, err := s.engine.Context(ctx).ID(id).
+		Where("status = ? OR (status = ? AND updated_at < ?)", DeadSagaPending, DeadSagaRetrying, staleBefore).
+		Cols("status").Update(&DeadSaga{Status: DeadSagaRetrying})
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		// Distinguish a missing record from one claimed elsewhere | 区分记录不存在与已被其他调用方占用
+		if has, err := s.engine.Context(ctx).ID(id).Exist(&DeadSaga{}); err != nil {
+			return false, err
+		} else if !has {
+			return false, ErrDeadSagaNotFound
+		}
+	}
+	return n == 1, nil
+}
+
+// List returns dead sagas, newest first
+// List 返回死信 saga，最新的在前
+func (s *DBDeadLetterStore) List(ctx context.Context, status string, limit, offset int) ([]DeadSaga, int64, error) {
+	session := s.engine.Context(ctx).Desc("id")
+	if status != "" {
+		session = session.Where("status = ?", status)
+	}
+	if limit > 0 {
+		session = session.Limit(limit, offset)
+	}
+	var list []DeadSaga
+	total, err := session.FindAndCount(&list)
+	return list, total, err
+}
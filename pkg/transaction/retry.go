@@ -7,6 +7,8 @@ import (
 	"errors"
 	"math/rand"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 // RetryConfig represents retry configuration
@@ -17,6 +19,7 @@ type RetryConfig struct {
 	MaxInterval     time.Duration // Max interval (default 10s) | 最大间隔（默认 10s）
 	Multiplier      float64       // Backoff multiplier (default 2.0) | 退避乘数（默认 2.0）
 	Jitter          float64       // Jitter factor 0-1 (default 0.1) | 抖动因子 0-1（默认 0.1）
+	Clock           clock.Clock   // Clock of the waits (default real time) | 等待使用的时钟（默认真实时间）
 }
 
 // DefaultRetryConfig returns default retry configuration
@@ -60,6 +63,10 @@ func (s *RetryableSagaStep) WithRetryableErrors(errs ...error) *RetryableSagaSte
 func (s *RetryableSagaStep) ExecuteWithRetry(ctx context.Context) error {
 	var lastErr error
 	interval := s.RetryConfig.InitialInterval
+	clk := s.RetryConfig.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
 
 	for attempt := 1; attempt <= s.RetryConfig.MaxAttempts; attempt++ {
 		// Check if context is cancelled | 检查上下文是否已取消
@@ -68,6 +75,7 @@ func (s *RetryableSagaStep) ExecuteWithRetry(ctx context.Context) error {
 		}
 
 		lastErr = s.Execute(ctx)
+		recordAttempt(ctx, attempt, lastErr)
 		if lastErr == nil {
 			return nil
 		}
@@ -88,7 +96,7 @@ func (s *RetryableSagaStep) ExecuteWithRetry(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(waitTime):
+		case <-clk.After(waitTime):
 		}
 
 		// Update interval | 更新间隔
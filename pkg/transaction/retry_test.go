@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	"github.com/nuohe369/crab/pkg/clock"
 )
 
 func TestRetryableSagaStep_Success(t *testing.T) {
@@ -56,6 +58,47 @@ func TestRetryableSagaStep_RetryOnFailure(t *testing.T) {
 	}
 }
 
+func TestRetryableSagaStep_BackoffOnClock(t *testing.T) {
+	start := time.Now()
+	fake := clock.NewFake(start)
+	var attempts []time.Duration
+
+	step := NewRetryableSagaStep(SagaStep{
+		Name: "test_step",
+		Execute: func(ctx context.Context) error {
+			attempts = append(attempts, fake.Since(start))
+			return errors.New("temporary error")
+		},
+	}, RetryConfig{
+		MaxAttempts:     4,
+		InitialInterval: time.Second,
+		MaxInterval:     3 * time.Second,
+		Multiplier:      2.0,
+		Clock:           fake,
+	})
+
+	done := make(chan error)
+	go func() { done <- step.ExecuteWithRetry(context.Background()) }()
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		fake.BlockUntil(1)
+		fake.Advance(wait)
+	}
+	if err := <-done; err == nil {
+		t.Error("Expected the last error after all attempts")
+	}
+
+	// Exponential backoff capped at MaxInterval | 指数退避，上限为 MaxInterval
+	want := []time.Duration{0, time.Second, 3 * time.Second, 6 * time.Second}
+	if len(attempts) != len(want) {
+		t.Fatalf("Expected %d attempts, got %v", len(want), attempts)
+	}
+	for i := range want {
+		if attempts[i] != want[i] {
+			t.Errorf("attempt %d at %v, want %v", i+1, attempts[i], want[i])
+		}
+	}
+}
+
 func TestRetryableSagaStep_MaxAttemptsExceeded(t *testing.T) {
 	attempts := 0
 	testErr := errors.New("persistent error")
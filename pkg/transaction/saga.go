@@ -4,8 +4,11 @@ package transaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SagaStep represents a single step in a Saga transaction.
@@ -16,6 +19,20 @@ type SagaStep struct {
 	Name       string                          // Step name for logging and debugging | 步骤名称，用于日志和调试
 	Execute    func(ctx context.Context) error // Forward operation | 正向操作
 	Compensate func(ctx context.Context) error // Compensating operation (rollback) | 补偿操作（回滚）
+
+	// Timeout bounds the step's Execute; 0 means no limit beyond the saga deadline.
+	// Timeout 限制步骤 Execute 的执行时间；0 表示除 saga 截止时间外不做限制。
+	Timeout time.Duration
+
+	// Data is persisted with a dead saga so a compensator registered with
+	// RegisterCompensator can retry the compensation after a restart.
+	// Data 随死信 saga 持久化，使通过 RegisterCompensator 注册的补偿器在重启后仍可重试补偿。
+	Data string
+
+	// DependsOn lists the names of steps that must succeed first. nil means the step
+	// follows the previously added step (or parallel group); an empty slice makes it a root.
+	// DependsOn 列出必须先成功的步骤名称。nil 表示跟随前一个添加的步骤（或并行组），空切片表示根步骤。
+	DependsOn []string
 }
 
 // Saga represents a distributed transaction using the Saga pattern.
@@ -24,9 +41,14 @@ type SagaStep struct {
 // 它管理一系列步骤，失败时自动补偿
 type Saga struct {
 	steps     []SagaStep                                 // All steps to execute | 要执行的所有步骤
-	executed  []SagaStep                                 // Successfully executed steps | 已成功执行的步骤
+	after     [][]int                                    // Implicit dependencies of each step (indexes) | 每个步骤的隐式依赖（索引）
+	stage     []int                                      // Indexes of the last added step or parallel group | 最后添加的步骤或并行组的索引
+	executed  []SagaStep                                 // Successfully executed steps, in completion order | 已成功执行的步骤，按完成顺序
 	onSuccess func(ctx context.Context) error            // Success callback | 成功回调
 	onFailure func(ctx context.Context, err error) error // Failure callback | 失败回调
+	name      string                                     // Saga name recorded in dead sagas | 记录在死信 saga 中的名称
+	compRetry *RetryConfig                               // Compensation retry policy, nil means no retry | 补偿重试策略，nil 表示不重试
+	timeout   time.Duration                              // Deadline for the forward phase, 0 means none | 正向阶段的截止时间，0 表示不限制
 }
 
 // NewSaga creates a new Saga transaction coordinator.
@@ -39,11 +61,81 @@ func NewSaga() *Saga {
 }
 
 // AddStep adds a step to the saga.
-// Steps are executed in the order they are added.
+// Steps are executed in the order they are added, unless step.DependsOn declares otherwise.
 // AddStep 向 saga 添加步骤
-// 步骤按添加顺序执行
+// 步骤按添加顺序执行，除非 step.DependsOn 另有声明
 func (s *Saga) AddStep(step SagaStep) *Saga {
+	s.stage = []int{s.add(step)}
+	return s
+}
+
+// AddParallelSteps adds a group of steps that run concurrently.
+// The group starts after the previously added step (or group) succeeds, and the
+// next added step waits for the whole group. On failure, steps that already
+// succeeded are compensated in reverse completion order.
+// AddParallelSteps 添加一组并发执行的步骤
+// 该组在前一个添加的步骤（或组）成功后开始，下一个添加的步骤会等待整组完成。
+// 失败时，已成功的步骤按完成顺序的逆序补偿。
+//
+// Usage | 用法:
+//
+//	saga.AddStep(createOrder).
+//	    AddParallelSteps([]SagaStep{reserveStock, createShipmentDraft}).
+//	    AddStep(chargePayment)
+func (s *Saga) AddParallelSteps(steps []SagaStep) *Saga {
+	group := make([]int, 0, len(steps))
+	for _, step := range steps {
+		group = append(group, s.add(step))
+	}
+	if len(group) > 0 {
+		s.stage = group
+	}
+	return s
+}
+
+// add appends a step depending on the current stage and returns its index
+// add 追加一个依赖当前阶段的步骤并返回其索引
+func (s *Saga) add(step SagaStep) int {
 	s.steps = append(s.steps, step)
+	s.after = append(s.after, append([]int(nil), s.stage...))
+	return len(s.steps) - 1
+}
+
+// WithName sets the saga name recorded with dead sagas
+// WithName 设置记录在死信 saga 中的名称
+func (s *Saga) WithName(name string) *Saga {
+	s.name = name
+	return s
+}
+
+// WithCompensationRetry retries failing compensations with backoff.
+// Compensations that still fail are recorded as a dead saga, see RetryDeadSaga.
+// WithCompensationRetry 使用退避策略重试失败的补偿
+// 仍然失败的补偿会被记录为死信 saga，参见 RetryDeadSaga。
+func (s *Saga) WithCompensationRetry(config RetryConfig) *Saga {
+	def := DefaultRetryConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = def.MaxAttempts
+	}
+	if config.InitialInterval <= 0 {
+		config.InitialInterval = def.InitialInterval
+	}
+	if config.MaxInterval <= 0 {
+		config.MaxInterval = def.MaxInterval
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = def.Multiplier
+	}
+	s.compRetry = &config
+	return s
+}
+
+// WithTimeout sets a deadline for the forward phase of the saga.
+// Steps still running at the deadline time out and the saga is compensated.
+// WithTimeout 设置 saga 正向阶段的截止时间
+// 截止时仍在运行的步骤超时，saga 随之补偿。
+func (s *Saga) WithTimeout(d time.Duration) *Saga {
+	s.timeout = d
 	return s
 }
 
@@ -68,9 +160,12 @@ func (s *Saga) OnFailure(fn func(ctx context.Context, err error) error) *Saga {
 }
 
 // Execute runs the saga transaction.
-// It executes steps sequentially. If any step fails, it compensates all previously executed steps in reverse order.
+// It executes steps in dependency order, running independent steps concurrently.
+// If any step fails, no new steps are started, running steps are awaited, and all
+// executed steps are compensated in reverse completion order.
 // Execute 运行 saga 事务
-// 它按顺序执行步骤。如果任何步骤失败，它会按相反顺序补偿所有先前执行的步骤
+// 它按依赖顺序执行步骤，相互独立的步骤并发执行。如果任何步骤失败，不再启动新步骤，
+// 等待运行中的步骤结束，并按完成顺序的逆序补偿所有已执行的步骤
 //
 // Execution flow:
 //   - Success: Execute Step1 → Execute Step2 → Execute Step3 → OnSuccess
@@ -80,29 +175,52 @@ func (s *Saga) OnFailure(fn func(ctx context.Context, err error) error) *Saga {
 //   - nil if all steps succeed
 //   - error if any step fails (compensation is automatically triggered)
 func (s *Saga) Execute(ctx context.Context) error {
-	// Forward phase: execute all steps | 正向阶段：执行所有步骤
-	for i, step := range s.steps {
-		if err := s.executeStep(ctx, step, i+1, len(s.steps)); err != nil {
-			// Step failed, trigger compensation | 步骤失败，触发补偿
-			compensateErr := s.compensate(ctx)
+	name := s.name
+	if name == "" {
+		name = "saga"
+	}
+	ctx, span := tracer().Start(ctx, "saga "+name, trace.WithAttributes(
+		attrSagaName.String(name),
+		attrSagaSteps.Int(len(s.steps)),
+	))
+	outcome, err := s.execute(ctx)
+	endSpan(span, outcome, err)
+	return err
+}
 
-			// Call failure callback if set | 如果设置了失败回调，则调用
-			if s.onFailure != nil {
-				if cbErr := s.onFailure(ctx, err); cbErr != nil {
-					// Log callback error but don't override original error | 记录回调错误但不覆盖原始错误
-					return fmt.Errorf("step %d failed: %w (failure callback error: %v)", i+1, err, cbErr)
-				}
-			}
+// execute runs the saga and returns its outcome for tracing
+// execute 运行 saga 并返回用于追踪的结果
+func (s *Saga) execute(ctx context.Context) (string, error) {
+	deps, err := s.resolve()
+	if err != nil {
+		return outcomeFailed, err
+	}
 
-			// Return original error with compensation info | 返回带补偿信息的原始错误
-			if compensateErr != nil {
-				return fmt.Errorf("step %d failed: %w (compensation also failed: %v)", i+1, err, compensateErr)
+	// Forward phase: execute all steps | 正向阶段：执行所有步骤
+	runCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	if failed, err := s.run(runCtx, deps); err != nil {
+		i := failed
+		// Step failed, trigger compensation | 步骤失败，触发补偿
+		compensateErr := s.compensate(ctx)
+
+		// Call failure callback if set | 如果设置了失败回调，则调用
+		if s.onFailure != nil {
+			if cbErr := s.onFailure(ctx, err); cbErr != nil {
+				// Log callback error but don't override original error | 记录回调错误但不覆盖原始错误
+				return compensationOutcome(compensateErr), fmt.Errorf("step %d failed: %w (failure callback error: %v)", i+1, err, cbErr)
 			}
-			return fmt.Errorf("step %d failed: %w (compensated successfully)", i+1, err)
 		}
 
-		// Track successfully executed step | 跟踪成功执行的步骤
-		s.executed = append(s.executed, step)
+		// Return original error with compensation info | 返回带补偿信息的原始错误
+		if compensateErr != nil {
+			return outcomeCompensationFailed, fmt.Errorf("step %d failed: %w (compensation also failed: %v)", i+1, err, compensateErr)
+		}
+		return outcomeCompensated, fmt.Errorf("step %d failed: %w (compensated successfully)", i+1, err)
 	}
 
 	// All steps succeeded, call success callback | 所有步骤成功，调用成功回调
@@ -112,28 +230,213 @@ func (s *Saga) Execute(ctx context.Context) error {
 			compensateErr := s.compensate(ctx)
 
 			if compensateErr != nil {
-				return fmt.Errorf("success callback failed: %w (compensation also failed: %v)", err, compensateErr)
+				return outcomeCompensationFailed, fmt.Errorf("success callback failed: %w (compensation also failed: %v)", err, compensateErr)
 			}
-			return fmt.Errorf("success callback failed: %w (compensated successfully)", err)
+			return outcomeCompensated, fmt.Errorf("success callback failed: %w (compensated successfully)", err)
 		}
 	}
 
-	return nil
+	return outcomeSuccess, nil
+}
+
+// compensationOutcome maps a compensation error to a saga outcome
+// compensationOutcome 将补偿错误映射为 saga 结果
+func compensationOutcome(compensateErr error) string {
+	if compensateErr != nil {
+		return outcomeCompensationFailed
+	}
+	return outcomeCompensated
+}
+
+// resolve returns the dependencies of every step, validating names and cycles
+// resolve 返回每个步骤的依赖，并校验名称和环
+func (s *Saga) resolve() ([][]int, error) {
+	index := make(map[string]int, len(s.steps))
+	for i, step := range s.steps {
+		if step.Name == "" {
+			continue
+		}
+		if _, dup := index[step.Name]; dup {
+			index[step.Name] = -1 // ambiguous, only an error if referenced | 有歧义，仅在被引用时报错
+			continue
+		}
+		index[step.Name] = i
+	}
+
+	deps := make([][]int, len(s.steps))
+	for i, step := range s.steps {
+		if step.DependsOn == nil {
+			deps[i] = s.after[i]
+			continue
+		}
+		deps[i] = make([]int, 0, len(step.DependsOn))
+		for _, name := range step.DependsOn {
+			j, ok := index[name]
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("step '%s' depends on unknown step '%s'", step.Name, name)
+			case j < 0:
+				return nil, fmt.Errorf("step '%s' depends on ambiguous step name '%s'", step.Name, name)
+			case j == i:
+				return nil, fmt.Errorf("step '%s' depends on itself", step.Name)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+
+	// Cycle detection (Kahn) | 环检测（Kahn 算法）
+	pending := make([]int, len(deps))
+	dependents := make([][]int, len(deps))
+	for i, d := range deps {
+		pending[i] = len(d)
+		for _, j := range d {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	queue := make([]int, 0, len(deps))
+	for i, n := range pending {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for k := 0; k < len(queue); k++ {
+		for _, j := range dependents[queue[k]] {
+			if pending[j]--; pending[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+	if len(queue) != len(deps) {
+		return nil, fmt.Errorf("saga steps have a dependency cycle")
+	}
+	return deps, nil
+}
+
+// errStepNotStarted marks a step skipped because the context was already done
+// errStepNotStarted 标记因上下文已结束而未执行的步骤
+var errStepNotStarted = errors.New("not started, context done")
+
+// stepResult is the outcome of one step execution
+// stepResult 是一次步骤执行的结果
+type stepResult struct {
+	index    int
+	err      error
+	timedOut bool // The step may have taken effect | 步骤可能已经生效
+}
+
+// run executes steps as their dependencies complete and returns the first failure
+// Only this goroutine touches s.executed, step goroutines report through a channel.
+// run 在依赖完成后执行步骤，并返回第一个失败
+// 只有当前 goroutine 修改 s.executed，步骤 goroutine 通过 channel 汇报结果。
+func (s *Saga) run(ctx context.Context, deps [][]int) (int, error) {
+	total := len(s.steps)
+	pending := make([]int, total)
+	dependents := make([][]int, total)
+	for i, d := range deps {
+		pending[i] = len(d)
+		for _, j := range d {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	results := make(chan stepResult)
+	running := 0
+	start := func(i int) {
+		running++
+		go func() {
+			err := s.executeStep(ctx, s.steps[i], i+1, total)
+			// A step skipped by the pre-check never ran and has nothing to compensate
+			// 被执行前检查跳过的步骤从未运行，无需补偿
+			timedOut := errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, errStepNotStarted)
+			results <- stepResult{index: i, err: err, timedOut: timedOut}
+		}()
+	}
+
+	for i := range total {
+		if pending[i] == 0 {
+			start(i)
+		}
+	}
+
+	failed, firstErr := -1, error(nil)
+	for running > 0 {
+		r := <-results
+		running--
+		if r.err != nil {
+			if firstErr == nil {
+				failed, firstErr = r.index, r.err
+			}
+			// A timed-out step may have taken effect, so it is compensated too;
+			// compensations must therefore be idempotent.
+			// 超时的步骤可能已经生效，因此也会被补偿，补偿操作必须幂等。
+			if r.timedOut {
+				s.executed = append(s.executed, s.steps[r.index])
+			}
+			continue
+		}
+
+		// Track successfully executed step | 跟踪成功执行的步骤
+		s.executed = append(s.executed, s.steps[r.index])
+		if firstErr != nil {
+			continue // Stop scheduling after a failure | 失败后不再调度新步骤
+		}
+		for _, j := range dependents[r.index] {
+			if pending[j]--; pending[j] == 0 {
+				start(j)
+			}
+		}
+	}
+	return failed, firstErr
 }
 
 // executeStep executes a single step with logging.
+// The step runs with its own deadline when Timeout is set. If it does not return
+// once its context is done, it is abandoned and the error wraps ctx.Err()
+// (context.DeadlineExceeded on timeout), so a hung call cannot block the saga.
 // executeStep 执行单个步骤并记录日志
+// 设置了 Timeout 时步骤使用独立的截止时间。上下文结束后步骤仍未返回则放弃等待，
+// 错误包装 ctx.Err()（超时为 context.DeadlineExceeded），避免挂起的调用阻塞整个 saga
 func (s *Saga) executeStep(ctx context.Context, step SagaStep, current, total int) error {
 	start := time.Now()
 
 	// Check context cancellation before execution | 执行前检查上下文取消
 	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("context cancelled before step '%s': %w", step.Name, err)
+		return fmt.Errorf("step '%s' %w: %w", step.Name, errStepNotStarted, err)
+	}
+
+	ctx, span := startStepSpan(ctx, "execute", step.Name, current)
+	outcome, err := outcomeSuccess, error(nil)
+	defer func() { endSpan(span, outcome, err) }()
+
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
 	}
 
 	// Execute the step | 执行步骤
-	if err := step.Execute(ctx); err != nil {
-		return fmt.Errorf("step '%s' execution failed: %w", step.Name, err)
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("step '%s' panicked: %v", step.Name, r)
+			}
+		}()
+		done <- step.Execute(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			outcome = outcomeFailed
+			if errors.Is(err, context.DeadlineExceeded) {
+				outcome = outcomeTimeout
+			}
+			return fmt.Errorf("step '%s' execution failed: %w", step.Name, err)
+		}
+	case <-ctx.Done():
+		outcome, err = outcomeTimeout, ctx.Err()
+		return fmt.Errorf("step '%s' interrupted after %s: %w", step.Name, time.Since(start).Round(time.Millisecond), err)
 	}
 
 	// Log execution time (can be replaced with actual logger) | 记录执行时间（可替换为实际日志器）
@@ -142,9 +445,17 @@ func (s *Saga) executeStep(ctx context.Context, step SagaStep, current, total in
 	return nil
 }
 
-// compensate runs compensation operations in reverse order.
-// compensate 按相反顺序运行补偿操作
+// compensate runs compensation operations in reverse completion order.
+// A step always completes after its dependencies, so dependents are compensated first.
+// Compensation runs even if ctx is canceled or past its deadline. When a
+// compensation fails (after retries), it and the remaining steps are recorded
+// as a dead saga for manual intervention.
+// compensate 按完成顺序的逆序运行补偿操作
+// 步骤总在其依赖之后完成，因此依赖它的步骤会先被补偿。
+// 即使 ctx 已取消或超时，补偿仍会执行。补偿（重试后）失败时，该步骤及剩余步骤会被记录为死信 saga，等待人工处理
 func (s *Saga) compensate(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+
 	// Compensate in reverse order | 按相反顺序补偿
 	for i := len(s.executed) - 1; i >= 0; i-- {
 		step := s.executed[i]
@@ -155,15 +466,44 @@ func (s *Saga) compensate(ctx context.Context) error {
 		}
 
 		// Execute compensation | 执行补偿
-		if err := step.Compensate(ctx); err != nil {
+		if err := s.compensateStep(ctx, step, i+1); err != nil {
 			// Compensation failed - this is a critical error | 补偿失败 - 这是一个严重错误
-			return fmt.Errorf("compensation for step '%s' failed: %w", step.Name, err)
+			err = fmt.Errorf("compensation for step '%s' failed: %w", step.Name, err)
+			remaining := make([]SagaStep, 0, i+1)
+			for j := i; j >= 0; j-- {
+				if s.executed[j].Compensate != nil {
+					remaining = append(remaining, s.executed[j])
+				}
+			}
+			if id, recErr := recordDeadSaga(ctx, s.name, remaining, err); recErr == nil {
+				trace.SpanFromContext(ctx).SetAttributes(attrDeadSagaID.Int64(id))
+				return fmt.Errorf("%w (recorded as dead saga %d)", err, id)
+			}
+			return err
 		}
 	}
 
 	return nil
 }
 
+// compensateStep runs one compensation, with retries when configured
+// compensateStep 运行单个补偿，配置了重试时进行重试
+func (s *Saga) compensateStep(ctx context.Context, step SagaStep, index int) (err error) {
+	ctx, span := startStepSpan(ctx, "compensate", step.Name, index)
+	defer func() {
+		if err != nil {
+			endSpan(span, outcomeFailed, err)
+		} else {
+			endSpan(span, outcomeSuccess, nil)
+		}
+	}()
+
+	if s.compRetry == nil {
+		return step.Compensate(ctx)
+	}
+	return Retry(ctx, step.Compensate, *s.compRetry)
+}
+
 // GetExecutedSteps returns the list of successfully executed steps.
 // Useful for debugging and monitoring.
 // GetExecutedSteps 返回成功执行的步骤列表
@@ -193,13 +533,9 @@ func (s *Saga) ExecutedCount() int {
 func (s *Saga) AddRetryableStep(step SagaStep, config RetryConfig) *Saga {
 	retryable := NewRetryableSagaStep(step, config)
 	// Wrap Execute function | 包装 Execute 函数
-	wrappedStep := SagaStep{
-		Name:       step.Name,
-		Execute:    retryable.ExecuteWithRetry,
-		Compensate: step.Compensate,
-	}
-	s.steps = append(s.steps, wrappedStep)
-	return s
+	wrappedStep := step
+	wrappedStep.Execute = retryable.ExecuteWithRetry
+	return s.AddStep(wrappedStep)
 }
 
 // WithDefaultRetry adds a step with default retry configuration
@@ -212,11 +548,7 @@ func (s *Saga) WithDefaultRetry(step SagaStep) *Saga {
 // AddRetryableStepWithErrors 添加可重试的步骤，指定可重试的错误类型
 func (s *Saga) AddRetryableStepWithErrors(step SagaStep, config RetryConfig, retryableErrs ...error) *Saga {
 	retryable := NewRetryableSagaStep(step, config).WithRetryableErrors(retryableErrs...)
-	wrappedStep := SagaStep{
-		Name:       step.Name,
-		Execute:    retryable.ExecuteWithRetry,
-		Compensate: step.Compensate,
-	}
-	s.steps = append(s.steps, wrappedStep)
-	return s
+	wrappedStep := step
+	wrappedStep.Execute = retryable.ExecuteWithRetry
+	return s.AddStep(wrappedStep)
 }
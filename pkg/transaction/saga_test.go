@@ -3,7 +3,9 @@ package transaction
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 )
 
 // TestNewSaga tests Saga initialization
@@ -377,3 +379,368 @@ func TestSaga_EmptySaga(t *testing.T) {
 		t.Error("success callback should be called even for empty saga")
 	}
 }
+
+// TestSaga_AddParallelSteps tests concurrent execution of a parallel group
+func TestSaga_AddParallelSteps(t *testing.T) {
+	var mu sync.Mutex
+	events := make([]string, 0)
+	record := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+
+	// Both parallel steps must be running at the same time to pass the barrier
+	barrier := make(chan struct{})
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	go func() { arrived.Wait(); close(barrier) }()
+
+	parallel := func(name string) SagaStep {
+		return SagaStep{
+			Name: name,
+			Execute: func(ctx context.Context) error {
+				arrived.Done()
+				select {
+				case <-barrier:
+				case <-time.After(time.Second):
+					return errors.New("steps did not run concurrently")
+				}
+				record(name)
+				return nil
+			},
+		}
+	}
+
+	err := NewSaga().
+		AddStep(SagaStep{Name: "order", Execute: func(ctx context.Context) error { record("order"); return nil }}).
+		AddParallelSteps([]SagaStep{parallel("stock"), parallel("shipment")}).
+		AddStep(SagaStep{Name: "payment", Execute: func(ctx context.Context) error { record("payment"); return nil }}).
+		Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if len(events) != 4 || events[0] != "order" || events[3] != "payment" {
+		t.Errorf("unexpected order: %v", events)
+	}
+}
+
+// TestSaga_ParallelFailureCompensation tests compensation after a parallel step fails
+func TestSaga_ParallelFailureCompensation(t *testing.T) {
+	var mu sync.Mutex
+	compensated := make([]string, 0)
+	step := func(name string, fail bool) SagaStep {
+		return SagaStep{
+			Name: name,
+			Execute: func(ctx context.Context) error {
+				if fail {
+					return errors.New(name + " failed")
+				}
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				mu.Lock()
+				compensated = append(compensated, name)
+				mu.Unlock()
+				return nil
+			},
+		}
+	}
+
+	paymentRan := false
+	saga := NewSaga().
+		AddStep(step("order", false)).
+		AddParallelSteps([]SagaStep{step("stock", false), step("shipment", true)}).
+		AddStep(SagaStep{Name: "payment", Execute: func(ctx context.Context) error { paymentRan = true; return nil }})
+
+	if err := saga.Execute(context.Background()); err == nil {
+		t.Fatal("Execute() should return error")
+	}
+	if paymentRan {
+		t.Error("payment must not run after a failed dependency")
+	}
+	if len(compensated) != 2 || compensated[0] != "stock" || compensated[1] != "order" {
+		t.Errorf("compensated = %v, want [stock order]", compensated)
+	}
+}
+
+// TestSaga_DependsOn tests explicit dependency declarations and validation
+func TestSaga_DependsOn(t *testing.T) {
+	var mu sync.Mutex
+	done := map[string]bool{}
+	step := func(name string, deps ...string) SagaStep {
+		return SagaStep{
+			Name:      name,
+			DependsOn: deps,
+			Execute: func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, d := range deps {
+					if !done[d] {
+						return errors.New(name + " ran before " + d)
+					}
+				}
+				done[name] = true
+				return nil
+			},
+		}
+	}
+
+	err := NewSaga().
+		AddStep(step("c", "a", "b")).
+		AddStep(step("a", []string{}...)).
+		AddStep(step("b", "a")).
+		Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if err := NewSaga().AddStep(step("a", "missing")).Execute(context.Background()); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+	if err := NewSaga().AddStep(step("a", "b")).AddStep(step("b", "a")).Execute(context.Background()); err == nil {
+		t.Error("expected error for dependency cycle")
+	}
+}
+
+// TestSaga_CompensationRetry tests compensation retries and dead saga handling
+func TestSaga_CompensationRetry(t *testing.T) {
+	SetDeadLetterStore(NewMemoryDeadLetterStore())
+
+	attempts := 0
+	broken := true
+	saga := NewSaga().
+		WithName("checkout").
+		WithCompensationRetry(RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond}).
+		AddStep(SagaStep{
+			Name:    "reserve",
+			Execute: func(ctx context.Context) error { return nil },
+			Compensate: func(ctx context.Context) error {
+				attempts++
+				if broken {
+					return errors.New("stock service down")
+				}
+				return nil
+			},
+		}).
+		AddStep(SagaStep{
+			Name:    "charge",
+			Execute: func(ctx context.Context) error { return errors.New("card declined") },
+		})
+
+	if err := saga.Execute(context.Background()); err == nil {
+		t.Fatal("Execute() should return error")
+	}
+	if attempts != 2 {
+		t.Errorf("compensation attempts = %d, want 2", attempts)
+	}
+
+	list, total, err := ListDeadSagas(context.Background(), DeadSagaPending, 10, 0)
+	if err != nil || total != 1 {
+		t.Fatalf("ListDeadSagas() = %d, %v", total, err)
+	}
+	dead := list[0]
+	if dead.SagaName != "checkout" || len(dead.Steps) != 1 || dead.Steps[0] != "reserve" {
+		t.Errorf("unexpected dead saga: %+v", dead)
+	}
+
+	if err := RetryDeadSaga(context.Background(), dead.ID); err == nil {
+		t.Error("RetryDeadSaga() should fail while the service is down")
+	}
+	broken = false
+	if err := RetryDeadSaga(context.Background(), dead.ID); err != nil {
+		t.Fatalf("RetryDeadSaga() error = %v", err)
+	}
+	if d, _ := GetDeadSaga(context.Background(), dead.ID); d.Status != DeadSagaResolved || d.Attempts != 3 {
+		t.Errorf("unexpected dead saga after retry: %+v", d)
+	}
+	if err := ResolveDeadSaga(context.Background(), dead.ID, "done"); err != ErrDeadSagaResolved {
+		t.Errorf("ResolveDeadSaga() error = %v, want ErrDeadSagaResolved", err)
+	}
+}
+
+// TestRetryDeadSaga_RegisteredCompensator tests retry through a registered compensator
+func TestRetryDeadSaga_RegisteredCompensator(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	SetDeadLetterStore(store)
+
+	d := &DeadSaga{Steps: []string{"refund"}, Data: map[string]string{"refund": "order-1"}, Status: DeadSagaPending}
+	store.Save(context.Background(), d)
+
+	var got string
+	RegisterCompensator("refund", func(ctx context.Context, data string) error {
+		got = data
+		return nil
+	})
+	if err := RetryDeadSaga(context.Background(), d.ID); err != nil {
+		t.Fatalf("RetryDeadSaga() error = %v", err)
+	}
+	if got != "order-1" {
+		t.Errorf("compensator data = %q, want order-1", got)
+	}
+}
+
+// TestRetryDeadSaga_Concurrent tests that only one concurrent retry compensates
+func TestRetryDeadSaga_Concurrent(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	SetDeadLetterStore(store)
+
+	d := &DeadSaga{Steps: []string{"release"}, Status: DeadSagaPending}
+	store.Save(context.Background(), d)
+
+	var calls int
+	started, release := make(chan struct{}), make(chan struct{})
+	RegisterCompensator("release", func(ctx context.Context, data string) error {
+		calls++
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- RetryDeadSaga(context.Background(), d.ID) }()
+	<-started
+
+	if err := RetryDeadSaga(context.Background(), d.ID); err != ErrDeadSagaRetrying {
+		t.Errorf("concurrent RetryDeadSaga() error = %v, want ErrDeadSagaRetrying", err)
+	}
+	if err := ResolveDeadSaga(context.Background(), d.ID, "manual"); err != ErrDeadSagaRetrying {
+		t.Errorf("ResolveDeadSaga() during retry error = %v, want ErrDeadSagaRetrying", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("RetryDeadSaga() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("compensation calls = %d, want 1", calls)
+	}
+	if err := RetryDeadSaga(context.Background(), d.ID); err != ErrDeadSagaResolved {
+		t.Errorf("RetryDeadSaga() after resolve error = %v, want ErrDeadSagaResolved", err)
+	}
+}
+
+// TestRetryDeadSaga_StaleClaim tests that an abandoned retry can be claimed again
+func TestRetryDeadSaga_StaleClaim(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	SetDeadLetterStore(store)
+
+	d := &DeadSaga{Steps: []string{"unlock"}, Status: DeadSagaRetrying}
+	store.Save(context.Background(), d)
+	RegisterCompensator("unlock", func(ctx context.Context, data string) error { return nil })
+
+	if ok, err := store.Claim(context.Background(), d.ID, time.Now().Add(-time.Minute)); ok || err != nil {
+		t.Errorf("Claim() of a fresh retry = %v, %v, want false", ok, err)
+	}
+	if ok, err := store.Claim(context.Background(), d.ID, time.Now().Add(time.Minute)); !ok || err != nil {
+		t.Errorf("Claim() of a stale retry = %v, %v, want true", ok, err)
+	}
+	if _, err := store.Claim(context.Background(), 999, time.Now()); err != ErrDeadSagaNotFound {
+		t.Errorf("Claim() of a missing saga error = %v, want ErrDeadSagaNotFound", err)
+	}
+}
+
+// TestPendingClosuresBounded tests that in-process closures do not grow without bound
+func TestPendingClosuresBounded(t *testing.T) {
+	pendingMu.Lock()
+	pendingSteps = map[int64]pendingClosures{-1: {at: time.Now().Add(-2 * pendingClosureTTL)}}
+	pendingMu.Unlock()
+
+	for i := int64(1); i <= maxPendingClosures+10; i++ {
+		storePending(i, []SagaStep{{Name: "step"}})
+	}
+	if loadPending(-1) != nil {
+		t.Error("expired closures should be dropped")
+	}
+	if loadPending(1) != nil {
+		t.Error("oldest closures should be evicted")
+	}
+	if loadPending(maxPendingClosures+10) == nil {
+		t.Error("newest closures should be kept")
+	}
+	pendingMu.Lock()
+	n := len(pendingSteps)
+	pendingMu.Unlock()
+	if n != maxPendingClosures {
+		t.Errorf("pending closures = %d, want %d", n, maxPendingClosures)
+	}
+}
+
+// TestSaga_StepTimeout tests that a hung step times out and is compensated
+func TestSaga_StepTimeout(t *testing.T) {
+	compensated := make([]string, 0)
+	hang := make(chan struct{})
+	defer close(hang)
+
+	saga := NewSaga().
+		AddStep(SagaStep{
+			Name:       "order",
+			Execute:    func(ctx context.Context) error { return nil },
+			Compensate: func(ctx context.Context) error { compensated = append(compensated, "order"); return nil },
+		}).
+		AddStep(SagaStep{
+			Name:    "payment",
+			Timeout: 20 * time.Millisecond,
+			Execute: func(ctx context.Context) error {
+				<-hang // Ignores ctx, like a hung external call
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				if ctx.Err() != nil {
+					t.Error("compensation context must not be expired")
+				}
+				compensated = append(compensated, "payment")
+				return nil
+			},
+		})
+
+	start := time.Now()
+	err := saga.Execute(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("hung step blocked the saga")
+	}
+	if len(compensated) != 2 || compensated[0] != "payment" || compensated[1] != "order" {
+		t.Errorf("compensated = %v, want [payment order]", compensated)
+	}
+}
+
+// TestSaga_DeadlineBeforeStep tests that a step skipped after the deadline is not compensated
+func TestSaga_DeadlineBeforeStep(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	executed, compensated := false, false
+	err := NewSaga().
+		AddStep(SagaStep{
+			Name:       "reserve",
+			Execute:    func(ctx context.Context) error { executed = true; return nil },
+			Compensate: func(ctx context.Context) error { compensated = true; return nil },
+		}).
+		Execute(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+	if executed || compensated {
+		t.Errorf("executed = %v, compensated = %v, want neither", executed, compensated)
+	}
+}
+
+// TestSaga_WithTimeout tests the saga-level deadline
+func TestSaga_WithTimeout(t *testing.T) {
+	err := NewSaga().
+		WithTimeout(20 * time.Millisecond).
+		AddStep(SagaStep{
+			Name: "slow",
+			Execute: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}).
+		Execute(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,73 @@
+package transaction
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys for saga tracing
+// saga 追踪使用的 span 属性键
+const (
+	attrSagaName   = attribute.Key("saga.name")
+	attrSagaSteps  = attribute.Key("saga.steps")
+	attrStepName   = attribute.Key("saga.step.name")
+	attrStepIndex  = attribute.Key("saga.step.index")
+	attrAttempts   = attribute.Key("saga.step.attempts")
+	attrOutcome    = attribute.Key("saga.outcome")
+	attrPhase      = attribute.Key("saga.phase")
+	attrDeadSagaID = attribute.Key("saga.dead_saga_id")
+)
+
+// Outcome values recorded on saga spans | 记录在 saga span 上的结果值
+const (
+	outcomeSuccess            = "success"
+	outcomeFailed             = "failed"
+	outcomeTimeout            = "timeout"
+	outcomeCompensated        = "compensated"
+	outcomeCompensationFailed = "compensation_failed"
+)
+
+// tracer returns the saga tracer; without a tracer provider spans are no-ops
+// tracer 返回 saga 追踪器，未设置 TracerProvider 时 span 为空操作
+func tracer() trace.Tracer {
+	return otel.Tracer("transaction")
+}
+
+// startStepSpan starts a child span for one step execution or compensation
+// startStepSpan 为单个步骤的执行或补偿创建子 span
+func startStepSpan(ctx context.Context, phase, name string, index int) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "saga."+phase+" "+name, trace.WithAttributes(
+		attrPhase.String(phase),
+		attrStepName.String(name),
+		attrStepIndex.Int(index),
+		attrAttempts.Int(1),
+	))
+}
+
+// endSpan records the outcome and error, then ends the span
+// endSpan 记录结果和错误后结束 span
+func endSpan(span trace.Span, outcome string, err error) {
+	span.SetAttributes(attrOutcome.String(outcome))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordAttempt updates the attempt count on the current step span
+// recordAttempt 更新当前步骤 span 上的尝试次数
+func recordAttempt(ctx context.Context, attempt int, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attrAttempts.Int(attempt))
+	if err != nil {
+		span.AddEvent("attempt failed", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+	}
+}
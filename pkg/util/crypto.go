@@ -5,7 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/nuohe369/crab/pkg/crypto"
 )
 
 // MD5 calculates MD5 hash
@@ -20,14 +20,17 @@ func SHA256(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
-// HashPassword encrypts password with bcrypt
+// HashPassword hashes password with crypto.HashPassword
+//
+// Deprecated: use crypto.HashPassword.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return crypto.HashPassword(password)
 }
 
-// CheckPassword verifies password with bcrypt
+// CheckPassword verifies password against an argon2id or bcrypt hash
+//
+// Deprecated: use crypto.VerifyPassword, which also reports outdated hashes.
 func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _, _ := crypto.VerifyPassword(password, hash)
+	return ok
 }
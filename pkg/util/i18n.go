@@ -4,7 +4,7 @@ import (
 	"database/sql/driver"
 	"errors"
 
-	"github.com/bytedance/sonic"
+	"github.com/nuohe369/crab/pkg/json"
 )
 
 // Default language
@@ -77,7 +77,7 @@ func (t I18nText) Value() (driver.Value, error) {
 	if t == nil || len(t) == 0 {
 		return "{}", nil
 	}
-	return sonic.Marshal(t)
+	return json.Marshal(t)
 }
 
 // Scan implements sql.Scanner interface for database read
@@ -102,24 +102,24 @@ func (t *I18nText) Scan(value interface{}) error {
 		return nil
 	}
 
-	return sonic.Unmarshal(bytes, t)
+	return json.Unmarshal(bytes, t)
 }
 
-// MarshalJSON implements sonic.Marshaler interface
+// MarshalJSON implements json.Marshaler interface
 func (t I18nText) MarshalJSON() ([]byte, error) {
 	if t == nil {
 		return []byte("{}"), nil
 	}
-	return sonic.Marshal(map[string]string(t))
+	return json.Marshal(map[string]string(t))
 }
 
-// UnmarshalJSON implements sonic.Unmarshaler interface
+// UnmarshalJSON implements json.Unmarshaler interface
 func (t *I18nText) UnmarshalJSON(data []byte) error {
 	if t == nil {
 		return errors.New("I18nText: UnmarshalJSON on nil pointer")
 	}
 	var m map[string]string
-	if err := sonic.Unmarshal(data, &m); err != nil {
+	if err := json.Unmarshal(data, &m); err != nil {
 		return err
 	}
 	*t = m
@@ -136,5 +136,5 @@ func (t I18nText) ToDB() ([]byte, error) {
 	if t == nil || len(t) == 0 {
 		return []byte("{}"), nil
 	}
-	return sonic.Marshal(t)
+	return json.Marshal(t)
 }
@@ -5,7 +5,8 @@ import (
 	"time"
 )
 
-// DateTime custom time type, JSON serializes to "2006-01-02 15:04:05"
+// DateTime custom time type, JSON serializes to "2006-01-02 15:04:05" in the server zone
+// New response structs should use timex.JSONTime, which follows the configured zone and layout
 type DateTime time.Time
 
 // MarshalJSON implements json.Marshaler interface
@@ -0,0 +1,312 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nuohe369/crab/pkg/httpclient"
+	"github.com/nuohe369/crab/pkg/jobs"
+)
+
+// JobType is the jobs type of deliveries
+// JobType 是投递使用的任务类型
+const JobType = "webhook.deliver"
+
+// Request headers of a delivery | 投递的请求头
+const (
+	HeaderID        = "X-Webhook-ID"        // Delivery ID, stable across retries | 投递 ID，重试时不变
+	HeaderEvent     = "X-Webhook-Event"     // Event name | 事件名称
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds of the attempt | 本次尝试的 Unix 秒数
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC of "timestamp.body"> | sha256=<"timestamp.body" 的十六进制 HMAC>
+)
+
+// Event is the JSON body of a delivery
+// Event 是投递的 JSON 请求体
+type Event struct {
+	ID        string    `json:"id"`         // Event ID, shared by all subscriptions | 事件 ID，所有订阅共享
+	Event     string    `json:"event"`      // Event name | 事件名称
+	CreatedAt time.Time `json:"created_at"` // Publish time | 发布时间
+	Data      any       `json:"data"`       // Payload | 负载
+}
+
+// deliverPayload is the jobs payload of a delivery | deliverPayload 是投递任务的负载
+type deliverPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Dispatcher manages subscriptions and delivers events
+// Dispatcher 管理订阅并投递事件
+type Dispatcher struct {
+	config  Config
+	store   Store
+	client  *http.Client
+	enqueue func(ctx context.Context, id string) error // Schedules a delivery | 安排投递
+}
+
+// New creates a dispatcher, a nil store keeps data in memory
+// Deliveries are queued on the default jobs runner, or attempted once in the background when jobs are not initialized.
+// New 创建分发器，store 为 nil 时数据保存在内存中
+// 投递加入默认 jobs 运行器的队列，未初始化 jobs 时在后台尝试一次。
+func New(cfg Config, store Store) *Dispatcher {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	cfg = cfg.withDefaults()
+	d := &Dispatcher{
+		config: cfg,
+		store:  store,
+		client: httpclient.New(httpclient.Config{Timeout: cfg.Timeout, RetryMax: -1}),
+	}
+	d.enqueue = func(ctx context.Context, id string) error {
+		if jobs.Get() == nil {
+			go d.attempt(context.WithoutCancel(ctx), id, true)
+			return nil
+		}
+		_, err := jobs.Enqueue(ctx, JobType, deliverPayload{DeliveryID: id}, jobs.Queue(d.config.Queue), jobs.MaxRetries(d.config.MaxRetries))
+		return err
+	}
+	return d
+}
+
+// Config returns the effective configuration
+// Config 返回生效的配置
+func (d *Dispatcher) Config() Config {
+	return d.config
+}
+
+// Store returns the store, for listing subscriptions and deliveries
+// Store 返回存储，用于列出订阅和投递
+func (d *Dispatcher) Store() Store {
+	return d.store
+}
+
+// Subscribe validates and saves a new subscription, generating its secret when empty
+// Subscribe 校验并保存新订阅，密钥为空时自动生成
+func (d *Dispatcher) Subscribe(ctx context.Context, s *Subscription) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.Secret == "" {
+		s.Secret = NewSecret()
+	}
+	s.ID = 0
+	return d.store.SaveSubscription(ctx, s)
+}
+
+// Publish delivers an event to every active subscription matching it and returns the delivery IDs
+// Publish 将事件投递到所有匹配的已启用订阅，并返回投递 ID
+func (d *Dispatcher) Publish(ctx context.Context, event string, data any) ([]string, error) {
+	subs, err := d.store.Subscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	eventID := uuid.NewString()
+	body, err := json.Marshal(Event{ID: eventID, Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("webhook: encode %s: %w", event, err)
+	}
+
+	var ids []string
+	for _, s := range subs {
+		if !s.Active || !s.Matches(event) {
+			continue
+		}
+		dl := &Delivery{ID: uuid.NewString(), SubscriptionID: s.ID, EventID: eventID, Event: event, Payload: string(body), Status: StatusPending}
+		if err := d.store.SaveDelivery(ctx, dl); err != nil {
+			return ids, err
+		}
+		if err := d.enqueue(ctx, dl.ID); err != nil {
+			return ids, fmt.Errorf("webhook: enqueue delivery %s: %w", dl.ID, err)
+		}
+		ids = append(ids, dl.ID)
+	}
+	return ids, nil
+}
+
+// Redeliver sends a delivery again with a fresh retry budget, ErrNotFound when it does not exist
+// Redeliver 以新的重试次数重新发送投递，不存在时返回 ErrNotFound
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) error {
+	dl, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	if dl == nil {
+		return ErrNotFound
+	}
+	dl.Status = StatusPending
+	if err := d.store.SaveDelivery(ctx, dl); err != nil {
+		return err
+	}
+	return d.enqueue(ctx, id)
+}
+
+// handle runs one delivery job, the last allowed attempt marks the delivery failed
+// handle 运行一个投递任务，最后一次允许的尝试会将投递标记为失败
+func (d *Dispatcher) handle(ctx context.Context, job *jobs.Job) error {
+	var p deliverPayload
+	if err := job.Bind(&p); err != nil {
+		return jobs.Permanent(err)
+	}
+	final := job.MaxRetries < 0 || job.Attempt > job.MaxRetries
+	return d.attempt(ctx, p.DeliveryID, final)
+}
+
+// attempt sends a delivery once and records the attempt
+// attempt 发送一次投递并记录本次尝试
+func (d *Dispatcher) attempt(ctx context.Context, id string, final bool) error {
+	dl, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	if dl == nil {
+		return jobs.Permanent(fmt.Errorf("delivery %s: %w", id, ErrNotFound))
+	}
+	if dl.Status == StatusSucceeded {
+		return nil
+	}
+	sub, err := d.store.GetSubscription(ctx, dl.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if sub == nil || !sub.Active {
+		dl.Status, dl.LastError = StatusFailed, "subscription deleted or inactive"
+		return jobs.Permanent(errors.Join(errors.New(dl.LastError), d.store.SaveDelivery(ctx, dl)))
+	}
+
+	a := d.send(ctx, sub, dl)
+	if err := d.store.AddAttempt(ctx, a); err != nil {
+		log.Printf("webhook: failed to record attempt of delivery %s: %v", dl.ID, err)
+	}
+
+	dl.Attempts = a.Number
+	dl.LastStatus, dl.LastError = a.StatusCode, a.Error
+	switch {
+	case a.Error == "":
+		now := time.Now()
+		dl.Status, dl.DeliveredAt = StatusSucceeded, &now
+	case final:
+		dl.Status = StatusFailed
+	default:
+		dl.Status = StatusRetrying
+	}
+	if err := d.store.SaveDelivery(ctx, dl); err != nil {
+		return err
+	}
+	if a.Error != "" {
+		return errors.New(a.Error)
+	}
+	return nil
+}
+
+// send posts the payload of a delivery and returns the attempt, Error is empty on a 2xx answer
+// send 发送投递的负载并返回尝试记录，响应为 2xx 时 Error 为空
+func (d *Dispatcher) send(ctx context.Context, sub *Subscription, dl *Delivery) *Attempt {
+	a := &Attempt{DeliveryID: dl.ID, Number: dl.Attempts + 1}
+	start := time.Now()
+	defer func() { a.Duration = time.Since(start).Milliseconds() }()
+
+	body := []byte(dl.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		a.Error = truncate(err.Error(), 500)
+		return a
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crab-webhook")
+	req.Header.Set(HeaderID, dl.ID)
+	req.Header.Set(HeaderEvent, dl.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		a.Error = truncate(err.Error(), 500)
+		return a
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	a.StatusCode, a.Response = resp.StatusCode, truncate(string(snippet), 1024)
+	if resp.StatusCode/100 != 2 {
+		a.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+	}
+	return a
+}
+
+// Sign returns the signature header value of body sent at timestamp
+// Sign 返回在 timestamp 发送的 body 的签名请求头值
+func Sign(secret string, timestamp int64, body []byte) string {
+	return "sha256=" + hmacHex(secret, []byte(strconv.FormatInt(timestamp, 10)), []byte("."), body)
+}
+
+// Verify checks the signature headers of a received delivery
+// Requests older than tolerance are rejected to prevent replays, 0 disables the check.
+// Verify 校验收到的投递的签名请求头
+// 早于 tolerance 的请求会被拒绝以防止重放，0 表示不检查。
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+			return ErrInvalidSignature
+		}
+	}
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewSecret returns a random signing secret
+// NewSecret 返回随机签名密钥
+func NewSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// truncate limits s to n bytes of valid UTF-8 | truncate 将 s 限制为 n 字节的合法 UTF-8
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.ToValidUTF8(s, "")
+}
+
+var defaultDispatcher = New(Config{}, nil) // Default dispatcher, data in memory until Init | 默认分发器，Init 前数据保存在内存中
+
+// Init initializes the default dispatcher and registers the delivery job
+// Init 初始化默认分发器并注册投递任务
+func Init(cfg Config, store Store) {
+	defaultDispatcher = New(cfg, store)
+	jobs.Register(JobType, func(ctx context.Context, job *jobs.Job) error {
+		return defaultDispatcher.handle(ctx, job)
+	})
+}
+
+// Get returns the default dispatcher
+// Get 返回默认分发器
+func Get() *Dispatcher {
+	return defaultDispatcher
+}
+
+// Publish publishes an event with the default dispatcher
+// Publish 使用默认分发器发布事件
+func Publish(ctx context.Context, event string, data any) ([]string, error) {
+	return defaultDispatcher.Publish(ctx, event, data)
+}
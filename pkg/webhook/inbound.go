@@ -0,0 +1,329 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrReplayed is returned for a signed request that was already accepted
+// ErrReplayed 表示已被接受过的签名请求再次到达
+var ErrReplayed = errors.New("webhook: request replayed")
+
+// Verifier checks the signature of an inbound webhook
+// It returns a value unique to the request (delivery ID, nonce or signature) used for replay
+// protection, and the signed timestamp, zero when the scheme has none.
+// Verifier 校验接收到的 Webhook 的签名
+// 返回请求唯一的值（投递 ID、随机串或签名）用于防重放，以及签名中的时间戳，方案不含时间戳时为零值。
+type Verifier interface {
+	Scheme() string // Scheme name, namespaces replay keys | 方案名称，用于区分防重放键
+	Verify(header http.Header, body []byte) (id string, timestamp time.Time, err error)
+}
+
+// InboundConfig configures inbound verification
+// InboundConfig 配置接收验证
+type InboundConfig struct {
+	Tolerance time.Duration // Max age of a signed timestamp, default 5m, -1 disables | 签名时间戳的最大时长，默认 5 分钟，-1 表示不检查
+	ReplayTTL time.Duration // How long request IDs are remembered, default 24h, -1 disables | 请求 ID 的记忆时长，默认 24 小时，-1 表示不防重放
+	Store     ReplayStore   // Replay cache, default the package store | 防重放缓存，默认使用包级存储
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c InboundConfig) withDefaults() InboundConfig {
+	if c.Tolerance == 0 {
+		c.Tolerance = 5 * time.Minute
+	}
+	if c.ReplayTTL == 0 {
+		c.ReplayTTL = 24 * time.Hour
+	}
+	if c.Store == nil {
+		c.Store = defaultReplayStore
+	}
+	return c
+}
+
+// VerifyInbound checks the signature, timestamp and uniqueness of an inbound webhook
+// VerifyInbound 校验接收到的 Webhook 的签名、时间戳和唯一性
+func VerifyInbound(ctx context.Context, v Verifier, header http.Header, body []byte, cfg InboundConfig) error {
+	cfg = cfg.withDefaults()
+	id, ts, err := v.Verify(header, body)
+	if err != nil {
+		return err
+	}
+	if cfg.Tolerance > 0 && !ts.IsZero() {
+		if age := time.Since(ts); age > cfg.Tolerance || age < -cfg.Tolerance {
+			return ErrInvalidSignature
+		}
+	}
+	if cfg.ReplayTTL < 0 || id == "" {
+		return nil
+	}
+	ok, err := cfg.Store.Claim(ctx, v.Scheme()+":"+id, cfg.ReplayTTL)
+	if err != nil {
+		return fmt.Errorf("webhook: replay check: %w", err)
+	}
+	if !ok {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// RequireSignature returns a middleware rejecting inbound webhooks that fail VerifyInbound with 401
+// RequireSignature 返回中间件，对未通过 VerifyInbound 的 Webhook 返回 401
+//
+// Usage | 用法:
+//
+//	router.Post("/hooks/stripe", webhook.RequireSignature(webhook.Stripe(secret)), h.Stripe)
+func RequireSignature(v Verifier, config ...InboundConfig) fiber.Handler {
+	var cfg InboundConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return func(c *fiber.Ctx) error {
+		header := http.Header{}
+		for k, values := range c.GetReqHeaders() {
+			for _, value := range values {
+				header.Add(k, value)
+			}
+		}
+		err := VerifyInbound(c.UserContext(), v, header, c.Body(), cfg)
+		if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrReplayed) {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// hmacVerifier checks the headers sent by Dispatcher | hmacVerifier 校验 Dispatcher 发送的请求头
+type hmacVerifier struct{ secret string }
+
+// HMAC verifies webhooks signed by Dispatcher, replay key X-Webhook-ID
+// HMAC 校验 Dispatcher 签名的 Webhook，防重放键为 X-Webhook-ID
+func HMAC(secret string) Verifier {
+	return hmacVerifier{secret: secret}
+}
+
+func (v hmacVerifier) Scheme() string { return "hmac" }
+
+func (v hmacVerifier) Verify(header http.Header, body []byte) (string, time.Time, error) {
+	if err := Verify(v.secret, header, body, 0); err != nil {
+		return "", time.Time{}, err
+	}
+	ts, _ := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	// Retries of one delivery are signed again, the signature identifies the request | 同一投递的重试会重新签名，以签名标识请求
+	return header.Get(HeaderSignature), time.Unix(ts, 0), nil
+}
+
+// stripeVerifier checks Stripe-Signature | stripeVerifier 校验 Stripe-Signature
+type stripeVerifier struct{ secrets []string }
+
+// Stripe verifies the Stripe-Signature header, "t=<unix>,v1=<hex HMAC-SHA256 of "t.body">"
+// Several secrets may be given while rolling one.
+// Stripe 校验 Stripe-Signature 请求头，格式为 "t=<unix>,v1=<"t.body" 的十六进制 HMAC-SHA256>"
+// 轮换密钥期间可以传入多个密钥。
+func Stripe(secrets ...string) Verifier {
+	return stripeVerifier{secrets: secrets}
+}
+
+func (v stripeVerifier) Scheme() string { return "stripe" }
+
+func (v stripeVerifier) Verify(header http.Header, body []byte) (string, time.Time, error) {
+	var t string
+	var sigs []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			t = val
+		case "v1":
+			sigs = append(sigs, val)
+		}
+	}
+	ts, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+	for _, secret := range v.secrets {
+		expected := hmacHex(secret, []byte(t), []byte("."), body)
+		for _, sig := range sigs {
+			if hmac.Equal([]byte(sig), []byte(expected)) {
+				return sig, time.Unix(ts, 0), nil
+			}
+		}
+	}
+	return "", time.Time{}, ErrInvalidSignature
+}
+
+// githubVerifier checks X-Hub-Signature-256 | githubVerifier 校验 X-Hub-Signature-256
+type githubVerifier struct{ secret string }
+
+// GitHub verifies the X-Hub-Signature-256 header, "sha256=<hex HMAC-SHA256 of body>"
+// The scheme has no timestamp, replays are caught by X-GitHub-Delivery, so manual redeliveries
+// within InboundConfig.ReplayTTL are rejected too.
+// GitHub 校验 X-Hub-Signature-256 请求头，格式为 "sha256=<body 的十六进制 HMAC-SHA256>"
+// 该方案没有时间戳，通过 X-GitHub-Delivery 防重放，因此 InboundConfig.ReplayTTL 内的手动重新投递也会被拒绝。
+func GitHub(secret string) Verifier {
+	return githubVerifier{secret: secret}
+}
+
+func (v githubVerifier) Scheme() string { return "github" }
+
+func (v githubVerifier) Verify(header http.Header, body []byte) (string, time.Time, error) {
+	sig, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok || !hmac.Equal([]byte(sig), []byte(hmacHex(v.secret, body))) {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+	return header.Get("X-GitHub-Delivery"), time.Time{}, nil
+}
+
+// wechatPayVerifier checks Wechatpay-Signature | wechatPayVerifier 校验 Wechatpay-Signature
+type wechatPayVerifier struct{ keys map[string]*rsa.PublicKey }
+
+// WeChatPay verifies WeChat Pay v3 notifications, an RSA-SHA256 signature of
+// "<Wechatpay-Timestamp>\n<Wechatpay-Nonce>\n<body>\n" by the platform key of Wechatpay-Serial
+// WeChatPay 校验微信支付 v3 通知，即 Wechatpay-Serial 对应的平台公钥对
+// "<Wechatpay-Timestamp>\n<Wechatpay-Nonce>\n<body>\n" 的 RSA-SHA256 签名
+func WeChatPay(keys map[string]*rsa.PublicKey) Verifier {
+	return wechatPayVerifier{keys: keys}
+}
+
+func (v wechatPayVerifier) Scheme() string { return "wechatpay" }
+
+func (v wechatPayVerifier) Verify(header http.Header, body []byte) (string, time.Time, error) {
+	key := v.keys[header.Get("Wechatpay-Serial")]
+	t, nonce := header.Get("Wechatpay-Timestamp"), header.Get("Wechatpay-Nonce")
+	ts, err := strconv.ParseInt(t, 10, 64)
+	if key == nil || err != nil || nonce == "" {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(header.Get("Wechatpay-Signature"))
+	if err != nil {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+	digest := sha256.Sum256([]byte(t + "\n" + nonce + "\n" + string(body) + "\n"))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+	return nonce, time.Unix(ts, 0), nil
+}
+
+// ParseRSAPublicKey parses a PEM public key or certificate, as downloaded for WeChat Pay
+// ParseRSAPublicKey 解析 PEM 格式的公钥或证书，例如下载的微信支付平台证书
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("webhook: no PEM block found")
+	}
+	var pub any
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pub = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pub = key
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("webhook: not an RSA public key")
+	}
+	return key, nil
+}
+
+// hmacHex returns the hex HMAC-SHA256 of the concatenated parts | hmacHex 返回各部分拼接后的十六进制 HMAC-SHA256
+func hmacHex(secret string, parts ...[]byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReplayStore remembers the IDs of accepted requests
+// ReplayStore 记录已接受请求的 ID
+type ReplayStore interface {
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) // false if already claimed | 已被记录时返回 false
+}
+
+// memoryReplayStore keeps IDs in process | memoryReplayStore 在进程内保存 ID
+type memoryReplayStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewMemoryReplayStore creates an in-process replay cache, for single instance deployments and tests
+// NewMemoryReplayStore 创建进程内防重放缓存，用于单实例部署和测试
+func NewMemoryReplayStore() ReplayStore {
+	return &memoryReplayStore{seen: make(map[string]time.Time)}
+}
+
+func (s *memoryReplayStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, exp := range s.seen {
+		if now.After(exp) {
+			delete(s.seen, k)
+		}
+	}
+	if _, ok := s.seen[key]; ok {
+		return false, nil
+	}
+	s.seen[key] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisClient defines the Redis client interface used by the Redis replay store
+// RedisClient 定义 Redis 防重放存储使用的 Redis 客户端接口
+type RedisClient interface {
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
+}
+
+// redisReplayStore keeps IDs under webhook:replay:{key} | redisReplayStore 将 ID 保存在 webhook:replay:{key} 下
+type redisReplayStore struct {
+	client RedisClient
+}
+
+// NewRedisReplayStore creates a replay cache shared by all instances
+// NewRedisReplayStore 创建所有实例共享的防重放缓存
+func NewRedisReplayStore(client RedisClient) ReplayStore {
+	return &redisReplayStore{client: client}
+}
+
+func (s *redisReplayStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, "webhook:replay:"+key, 1, ttl)
+}
+
+var defaultReplayStore = NewMemoryReplayStore() // Replay cache of InboundConfig without Store | 未指定 Store 时使用的防重放缓存
+
+// SetReplayStore sets the default replay cache
+// SetReplayStore 设置默认防重放缓存
+func SetReplayStore(s ReplayStore) {
+	defaultReplayStore = s
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStripe(t *testing.T) {
+	body := []byte(`{"type":"charge.succeeded"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := hmacHex("new", []byte(ts), []byte("."), body)
+	h := http.Header{}
+	h.Set("Stripe-Signature", "t="+ts+",v1=deadbeef,v1="+sig+",v0=old")
+
+	id, got, err := Stripe("old", "new").Verify(h, body)
+	if err != nil || id != sig || strconv.FormatInt(got.Unix(), 10) != ts {
+		t.Errorf("Expected valid signature, got %q %v %v", id, got, err)
+	}
+	if _, _, err := Stripe("other").Verify(h, body); err != ErrInvalidSignature {
+		t.Errorf("Wrong secret should be rejected, got %v", err)
+	}
+	h.Set("Stripe-Signature", "v1="+sig)
+	if _, _, err := Stripe("new").Verify(h, body); err != ErrInvalidSignature {
+		t.Errorf("Missing timestamp should be rejected, got %v", err)
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	h := http.Header{}
+	h.Set("X-Hub-Signature-256", "sha256="+hmacHex("s", body))
+	h.Set("X-GitHub-Delivery", "d-1")
+
+	id, ts, err := GitHub("s").Verify(h, body)
+	if err != nil || id != "d-1" || !ts.IsZero() {
+		t.Errorf("Expected valid signature, got %q %v %v", id, ts, err)
+	}
+	if _, _, err := GitHub("s").Verify(h, []byte(`{}`)); err != ErrInvalidSignature {
+		t.Errorf("Modified body should be rejected, got %v", err)
+	}
+}
+
+func TestWeChatPay(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pub, err := ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParseRSAPublicKey failed: %v", err)
+	}
+
+	body := []byte(`{"event_type":"TRANSACTION.SUCCESS"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256([]byte(ts + "\nn-1\n" + string(body) + "\n"))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	h := http.Header{}
+	h.Set("Wechatpay-Serial", "serial-1")
+	h.Set("Wechatpay-Timestamp", ts)
+	h.Set("Wechatpay-Nonce", "n-1")
+	h.Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(sig))
+
+	v := WeChatPay(map[string]*rsa.PublicKey{"serial-1": pub})
+	if id, _, err := v.Verify(h, body); err != nil || id != "n-1" {
+		t.Errorf("Expected valid signature, got %q %v", id, err)
+	}
+	if _, _, err := v.Verify(h, []byte(`{}`)); err != ErrInvalidSignature {
+		t.Errorf("Modified body should be rejected, got %v", err)
+	}
+	h.Set("Wechatpay-Serial", "unknown")
+	if _, _, err := v.Verify(h, body); err != ErrInvalidSignature {
+		t.Errorf("Unknown serial should be rejected, got %v", err)
+	}
+}
+
+func TestVerifyInbound(t *testing.T) {
+	ctx := context.Background()
+	body := []byte(`{}`)
+	cfg := InboundConfig{Tolerance: time.Minute, Store: NewMemoryReplayStore()}
+	signed := func(ts int64) http.Header {
+		h := http.Header{}
+		h.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		h.Set(HeaderSignature, Sign("s", ts, body))
+		return h
+	}
+
+	h := signed(time.Now().Unix())
+	if err := VerifyInbound(ctx, HMAC("s"), h, body, cfg); err != nil {
+		t.Fatalf("VerifyInbound failed: %v", err)
+	}
+	if err := VerifyInbound(ctx, HMAC("s"), h, body, cfg); err != ErrReplayed {
+		t.Errorf("Expected ErrReplayed, got %v", err)
+	}
+	if err := VerifyInbound(ctx, HMAC("s"), signed(time.Now().Add(-time.Hour).Unix()), body, cfg); err != ErrInvalidSignature {
+		t.Errorf("Stale timestamp should be rejected, got %v", err)
+	}
+
+	cfg.ReplayTTL = -1
+	h = signed(time.Now().Unix())
+	for range 2 {
+		if err := VerifyInbound(ctx, HMAC("s"), h, body, cfg); err != nil {
+			t.Errorf("Replay check disabled, got %v", err)
+		}
+	}
+}
+
+func TestRequireSignature(t *testing.T) {
+	app := fiber.New()
+	app.Post("/hooks", RequireSignature(GitHub("s"), InboundConfig{Store: NewMemoryReplayStore()}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	body := `{"zen":"hi"}`
+	send := func(sig, delivery string) int {
+		req := httptest.NewRequest(fiber.MethodPost, "/hooks", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", sig)
+		req.Header.Set("X-GitHub-Delivery", delivery)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	valid := "sha256=" + hmacHex("s", []byte(body))
+	tests := []struct {
+		name, sig, delivery string
+		want                int
+	}{
+		{"valid", valid, "d-1", fiber.StatusNoContent},
+		{"replayed", valid, "d-1", fiber.StatusUnauthorized},
+		{"new delivery", valid, "d-2", fiber.StatusNoContent},
+		{"wrong signature", "sha256=00", "d-3", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := send(tt.sig, tt.delivery); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMemoryReplayStoreExpiry(t *testing.T) {
+	s := NewMemoryReplayStore()
+	ctx := context.Background()
+	if ok, _ := s.Claim(ctx, "k", time.Millisecond); !ok {
+		t.Fatal("First claim should succeed")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, _ := s.Claim(ctx, "k", time.Minute); !ok {
+		t.Error("Expired key should be claimable again")
+	}
+}
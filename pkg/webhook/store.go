@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// Store persists subscriptions, deliveries and attempts
+// Store 持久化订阅、投递和尝试记录
+type Store interface {
+	Subscriptions(ctx context.Context) ([]Subscription, error)                           // Sorted by ID | 按 ID 排序
+	GetSubscription(ctx context.Context, id int64) (*Subscription, error)                // nil if missing | 不存在时返回 nil
+	SaveSubscription(ctx context.Context, s *Subscription) error                         // Creates when ID is 0, otherwise replaces | ID 为 0 时创建，否则替换
+	DeleteSubscription(ctx context.Context, id int64) error                              // Missing IDs are ignored | 忽略不存在的 ID
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)                       // nil if missing | 不存在时返回 nil
+	SaveDelivery(ctx context.Context, d *Delivery) error                                 // Create or replace | 创建或替换
+	Deliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error) // Newest first | 按时间倒序
+	AddAttempt(ctx context.Context, a *Attempt) error                                    // Appends an attempt | 追加尝试记录
+	Attempts(ctx context.Context, deliveryID string) ([]Attempt, error)                  // Oldest first | 按时间正序
+}
+
+// memoryStore keeps everything in process, for tests and single instance development
+// memoryStore 在进程内保存所有数据，用于测试和单实例开发
+type memoryStore struct {
+	mu            sync.RWMutex
+	nextID        int64
+	subscriptions map[int64]Subscription
+	deliveries    map[string]Delivery
+	attempts      map[string][]Attempt
+}
+
+// NewMemoryStore creates an in-process store
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() Store {
+	return &memoryStore{
+		subscriptions: make(map[int64]Subscription),
+		deliveries:    make(map[string]Delivery),
+		attempts:      make(map[string][]Attempt),
+	}
+}
+
+func (s *memoryStore) Subscriptions(_ context.Context) ([]Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *memoryStore) GetSubscription(_ context.Context, id int64) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+func (s *memoryStore) SaveSubscription(_ context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if sub.ID == 0 {
+		s.nextID++
+		sub.ID = s.nextID
+		sub.CreatedAt = now
+	}
+	sub.UpdatedAt = now
+	s.subscriptions[sub.ID] = *sub
+	return nil
+}
+
+func (s *memoryStore) DeleteSubscription(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscriptions, id)
+	return nil
+}
+
+func (s *memoryStore) GetDelivery(_ context.Context, id string) (*Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+func (s *memoryStore) SaveDelivery(_ context.Context, d *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = now
+	}
+	d.UpdatedAt = now
+	s.deliveries[d.ID] = *d
+	return nil
+}
+
+func (s *memoryStore) Deliveries(_ context.Context, subscriptionID int64, limit int) ([]Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Delivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) AddAttempt(_ context.Context, a *Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	a.ID = s.nextID
+	a.CreatedAt = time.Now()
+	s.attempts[a.DeliveryID] = append(s.attempts[a.DeliveryID], *a)
+	return nil
+}
+
+func (s *memoryStore) Attempts(_ context.Context, deliveryID string) ([]Attempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Attempt(nil), s.attempts[deliveryID]...), nil
+}
+
+// dbStore keeps data in the webhook_subscription, webhook_delivery and webhook_attempt tables
+// dbStore 将数据保存在 webhook_subscription、webhook_delivery 和 webhook_attempt 表中
+type dbStore struct {
+	engine *xorm.Engine
+}
+
+// NewDBStore creates a store backed by the database
+// NewDBStore 创建基于数据库的存储
+func NewDBStore(engine *xorm.Engine) Store {
+	return &dbStore{engine: engine}
+}
+
+func (s *dbStore) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := s.engine.Context(ctx).Asc("id").Find(&subs)
+	return subs, err
+}
+
+func (s *dbStore) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	var sub Subscription
+	has, err := s.engine.Context(ctx).ID(id).Get(&sub)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *dbStore) SaveSubscription(ctx context.Context, sub *Subscription) error {
+	if sub.ID == 0 {
+		_, err := s.engine.Context(ctx).Insert(sub)
+		return err
+	}
+	_, err := s.engine.Context(ctx).ID(sub.ID).AllCols().Update(sub)
+	return err
+}
+
+func (s *dbStore) DeleteSubscription(ctx context.Context, id int64) error {
+	_, err := s.engine.Context(ctx).ID(id).Delete(&Subscription{})
+	return err
+}
+
+func (s *dbStore) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	var d Delivery
+	has, err := s.engine.Context(ctx).ID(id).Get(&d)
+	if err != nil || !has {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *dbStore) SaveDelivery(ctx context.Context, d *Delivery) error {
+	has, err := s.engine.Context(ctx).Exist(&Delivery{ID: d.ID})
+	if err != nil {
+		return err
+	}
+	if has {
+		_, err = s.engine.Context(ctx).ID(d.ID).AllCols().Update(d)
+		return err
+	}
+	_, err = s.engine.Context(ctx).Insert(d)
+	return err
+}
+
+func (s *dbStore) Deliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error) {
+	var list []Delivery
+	q := s.engine.Context(ctx).Where("subscription_id = ?", subscriptionID).Desc("created_at")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&list)
+	return list, err
+}
+
+func (s *dbStore) AddAttempt(ctx context.Context, a *Attempt) error {
+	_, err := s.engine.Context(ctx).Insert(a)
+	return err
+}
+
+func (s *dbStore) Attempts(ctx context.Context, deliveryID string) ([]Attempt, error) {
+	var list []Attempt
+	err := s.engine.Context(ctx).Where("delivery_id = ?", deliveryID).Asc("id").Find(&list)
+	return list, err
+}
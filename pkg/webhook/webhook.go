@@ -0,0 +1,171 @@
+// Package webhook delivers events to HTTP endpoints subscribed per event type
+// Each delivery is a JSON POST signed with the subscription secret. Deliveries run on
+// pkg/jobs, so failed attempts are retried with the exponential backoff of [jobs], and
+// every attempt is recorded so deliveries can be inspected and redelivered.
+// Package webhook 将事件投递到按事件类型订阅的 HTTP 端点
+// 每次投递为使用订阅密钥签名的 JSON POST 请求。投递在 pkg/jobs 上运行，失败后按 [jobs] 的指数退避重试，
+// 每次尝试都会被记录，便于查看和重新投递。
+//
+// Receivers check the X-Webhook-Signature header, "sha256=" followed by the hex HMAC-SHA256
+// of "<X-Webhook-Timestamp>.<body>" keyed with the secret, or call Verify.
+// 接收方校验 X-Webhook-Signature 请求头，其值为 "sha256=" 加上以密钥计算的 "<X-Webhook-Timestamp>.<body>"
+// 的十六进制 HMAC-SHA256，也可以直接调用 Verify。
+//
+// Inbound webhooks from other services are checked with RequireSignature and a Verifier
+// (HMAC, Stripe, GitHub or WeChatPay), which also rejects stale timestamps and replays.
+// 其他服务发来的 Webhook 使用 RequireSignature 和 Verifier（HMAC、Stripe、GitHub 或 WeChatPay）校验，
+// 同时拒绝过期时间戳和重放请求。
+//
+// Usage | 用法:
+//
+//	webhook.Get().Subscribe(ctx, &webhook.Subscription{URL: "https://example.com/hooks", Events: []string{"order.*"}})
+//
+//	ids, err := webhook.Publish(ctx, "order.paid", order)
+//
+//	router.Post("/hooks/github", webhook.RequireSignature(webhook.GitHub(secret)), h.GitHub)
+package webhook
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Delivery status | 投递状态
+const (
+	StatusPending   = "pending"   // Waiting for its first attempt | 等待首次尝试
+	StatusRetrying  = "retrying"  // Failed, another attempt is scheduled | 已失败，已安排下一次尝试
+	StatusSucceeded = "succeeded" // The endpoint answered 2xx | 端点返回 2xx
+	StatusFailed    = "failed"    // Retries exhausted | 重试次数已用尽
+)
+
+var (
+	ErrNotFound         = errors.New("webhook: not found")                                // Subscription or delivery does not exist | 订阅或投递不存在
+	ErrInvalidSignature = errors.New("webhook: invalid signature")                        // Signature missing, wrong or expired | 签名缺失、错误或已过期
+	ErrEndpoint         = errors.New("webhook: endpoint must be an absolute http(s) URL") // Invalid subscription URL | 订阅地址无效
+	ErrNoEvents         = errors.New("webhook: at least one event is required")           // Subscription without events | 订阅没有事件
+)
+
+// Config represents webhook configuration
+// Config 表示 Webhook 配置
+type Config struct {
+	Timeout    time.Duration `toml:"timeout"`     // Per attempt timeout, default 10s | 每次尝试的超时，默认 10 秒
+	MaxRetries int           `toml:"max_retries"` // Retries after the first attempt, default 8, -1 disables | 首次尝试后的重试次数，默认 8，-1 表示不重试
+	Queue      string        `toml:"queue"`       // jobs queue of deliveries, default "default" | 投递使用的 jobs 队列，默认 "default"
+	AdminPath  string        `toml:"admin_path"`  // Subscription and redelivery endpoints, empty disables | 订阅和重新投递接口路径，为空时禁用
+	Permission string        `toml:"permission"`  // Permission required by the admin endpoints (default "webhook:admin") | 管理接口要求的权限（默认 "webhook:admin"）
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 8
+	}
+	if c.Queue == "" {
+		c.Queue = "default"
+	}
+	if c.Permission == "" {
+		c.Permission = "webhook:admin"
+	}
+	return c
+}
+
+// Subscription sends the events matching Events to URL
+// An event matches "*", its exact name, or a prefix pattern such as "order.*".
+// Subscription 将匹配 Events 的事件发送到 URL
+// 事件匹配 "*"、完整名称或 "order.*" 这样的前缀模式。
+type Subscription struct {
+	ID          int64     `json:"id" xorm:"pk autoincr 'id'"`
+	URL         string    `json:"url" xorm:"varchar(500) notnull 'url'"`         // Endpoint URL | 端点地址
+	Events      []string  `json:"events" xorm:"json 'events'"`                   // Event patterns | 事件模式
+	Secret      string    `json:"secret" xorm:"varchar(100) notnull 'secret'"`   // Signing secret, generated when empty | 签名密钥，为空时自动生成
+	Description string    `json:"description" xorm:"varchar(255) 'description'"` // Description | 描述
+	Active      bool      `json:"active" xorm:"notnull 'active'"`                // Inactive subscriptions receive nothing | 未启用的订阅不接收事件
+	CreatedAt   time.Time `json:"created_at" xorm:"created 'created_at'"`        // Creation time | 创建时间
+	UpdatedAt   time.Time `json:"updated_at" xorm:"updated 'updated_at'"`        // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (s *Subscription) TableName() string {
+	return "webhook_subscription"
+}
+
+// Validate checks the URL and events
+// Validate 检查地址和事件
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrEndpoint
+	}
+	if len(s.Events) == 0 {
+		return ErrNoEvents
+	}
+	return nil
+}
+
+// Matches checks if the subscription receives event
+// Matches 检查订阅是否接收 event
+func (s *Subscription) Matches(event string) bool {
+	for _, p := range s.Events {
+		if p == "*" || p == event {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event sent to one subscription
+// Delivery 表示发送到一个订阅的一个事件
+type Delivery struct {
+	ID             string     `json:"id" xorm:"pk varchar(36) 'id'"`
+	SubscriptionID int64      `json:"subscription_id" xorm:"notnull index 'subscription_id'"` // Subscription ID | 订阅 ID
+	EventID        string     `json:"event_id" xorm:"varchar(36) notnull index 'event_id'"`   // Shared by the deliveries of one event | 同一事件的投递共享
+	Event          string     `json:"event" xorm:"varchar(100) notnull 'event'"`              // Event name | 事件名称
+	Payload        string     `json:"payload" xorm:"text 'payload'"`                          // Request body | 请求体
+	Status         string     `json:"status" xorm:"varchar(16) notnull index 'status'"`       // pending, retrying, succeeded or failed | 投递状态
+	Attempts       int        `json:"attempts" xorm:"notnull default 0 'attempts'"`           // Attempts made | 已尝试次数
+	LastStatus     int        `json:"last_status" xorm:"notnull default 0 'last_status'"`     // HTTP status of the last attempt, 0 when unanswered | 最近一次尝试的 HTTP 状态码，无响应时为 0
+	LastError      string     `json:"last_error" xorm:"varchar(500) 'last_error'"`            // Error of the last attempt | 最近一次尝试的错误
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" xorm:"'delivered_at'"`           // Time of the successful attempt | 成功投递的时间
+	CreatedAt      time.Time  `json:"created_at" xorm:"created index 'created_at'"`           // Creation time | 创建时间
+	UpdatedAt      time.Time  `json:"updated_at" xorm:"updated 'updated_at'"`                 // Update time | 更新时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (d *Delivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// Attempt records one HTTP request of a delivery
+// Attempt 记录投递的一次 HTTP 请求
+type Attempt struct {
+	ID         int64     `json:"id" xorm:"pk autoincr 'id'"`
+	DeliveryID string    `json:"delivery_id" xorm:"varchar(36) notnull index 'delivery_id'"` // Delivery ID | 投递 ID
+	Number     int       `json:"number" xorm:"notnull 'number'"`                             // 1 for the first attempt | 首次尝试为 1
+	StatusCode int       `json:"status_code" xorm:"notnull default 0 'status_code'"`         // HTTP status, 0 when unanswered | HTTP 状态码，无响应时为 0
+	Response   string    `json:"response" xorm:"varchar(1024) 'response'"`                   // Start of the response body | 响应体开头部分
+	Error      string    `json:"error" xorm:"varchar(500) 'error'"`                          // Transport or status error | 传输或状态错误
+	Duration   int64     `json:"duration_ms" xorm:"notnull default 0 'duration_ms'"`         // Milliseconds | 毫秒
+	CreatedAt  time.Time `json:"created_at" xorm:"created 'created_at'"`                     // Attempt time | 尝试时间
+}
+
+// TableName returns the table name
+// TableName 返回表名
+func (a *Attempt) TableName() string {
+	return "webhook_attempt"
+}
+
+// Models returns the models to be auto-migrated
+// Models 返回需要自动迁移的模型
+func Models() []any {
+	return []any{new(Subscription), new(Delivery), new(Attempt)}
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nuohe369/crab/pkg/jobs"
+)
+
+// newDispatcher returns a dispatcher that records enqueued deliveries instead of running them
+// newDispatcher 返回一个只记录入队投递而不执行的分发器
+func newDispatcher(t *testing.T) (*Dispatcher, *[]string) {
+	t.Helper()
+	d := New(Config{MaxRetries: 2}, nil)
+	var queued []string
+	d.enqueue = func(_ context.Context, id string) error {
+		queued = append(queued, id)
+		return nil
+	}
+	return d, &queued
+}
+
+func TestMatches(t *testing.T) {
+	s := &Subscription{Events: []string{"order.*", "user.created"}}
+	tests := map[string]bool{
+		"order.paid":   true,
+		"order.":       true,
+		"user.created": true,
+		"user.deleted": false,
+		"orders":       false,
+	}
+	for event, want := range tests {
+		if got := s.Matches(event); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", event, got, want)
+		}
+	}
+	if !(&Subscription{Events: []string{"*"}}).Matches("anything") {
+		t.Error("* should match every event")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		sub  Subscription
+		want error
+	}{
+		{Subscription{URL: "https://example.com/hook", Events: []string{"*"}}, nil},
+		{Subscription{URL: "ftp://example.com", Events: []string{"*"}}, ErrEndpoint},
+		{Subscription{URL: "/relative", Events: []string{"*"}}, ErrEndpoint},
+		{Subscription{URL: "http://example.com"}, ErrNoEvents},
+	}
+	for _, tt := range tests {
+		if err := tt.sub.Validate(); err != tt.want {
+			t.Errorf("Validate(%s) = %v, want %v", tt.sub.URL, err, tt.want)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	ts := time.Now().Unix()
+	h := http.Header{}
+	h.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	h.Set(HeaderSignature, Sign("secret", ts, body))
+
+	if err := Verify("secret", h, body, time.Minute); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if err := Verify("other", h, body, time.Minute); err != ErrInvalidSignature {
+		t.Errorf("Wrong secret should be rejected, got %v", err)
+	}
+	if err := Verify("secret", h, []byte(`{"id":"2"}`), time.Minute); err != ErrInvalidSignature {
+		t.Errorf("Modified body should be rejected, got %v", err)
+	}
+
+	old := ts - 3600
+	h.Set(HeaderTimestamp, strconv.FormatInt(old, 10))
+	h.Set(HeaderSignature, Sign("secret", old, body))
+	if err := Verify("secret", h, body, time.Minute); err != ErrInvalidSignature {
+		t.Errorf("Expired timestamp should be rejected, got %v", err)
+	}
+	if err := Verify("secret", h, body, 0); err != nil {
+		t.Errorf("Zero tolerance should skip the age check, got %v", err)
+	}
+}
+
+func TestPublishAndDeliver(t *testing.T) {
+	var received atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("s1", r.Header, body, time.Minute); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		received.Store(r.Header.Get(HeaderEvent))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	d, queued := newDispatcher(t)
+	ctx := context.Background()
+	_ = d.Subscribe(ctx, &Subscription{URL: srv.URL, Events: []string{"order.*"}, Secret: "s1", Active: true})
+	_ = d.Subscribe(ctx, &Subscription{URL: srv.URL, Events: []string{"user.*"}, Active: true})
+	_ = d.Subscribe(ctx, &Subscription{URL: srv.URL, Events: []string{"*"}})
+
+	ids, err := d.Publish(ctx, "order.paid", map[string]int{"amount": 100})
+	if err != nil || len(ids) != 1 || len(*queued) != 1 {
+		t.Fatalf("Expected one delivery, got %v %v", ids, err)
+	}
+	if err := d.attempt(ctx, ids[0], false); err != nil {
+		t.Fatalf("attempt failed: %v", err)
+	}
+	if received.Load() != "order.paid" {
+		t.Errorf("Receiver got %v", received.Load())
+	}
+
+	dl, _ := d.store.GetDelivery(ctx, ids[0])
+	if dl.Status != StatusSucceeded || dl.Attempts != 1 || dl.LastStatus != http.StatusOK || dl.DeliveredAt == nil {
+		t.Errorf("Unexpected delivery %+v", dl)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(dl.Payload), &ev); err != nil || ev.Event != "order.paid" || ev.ID != dl.EventID {
+		t.Errorf("Unexpected payload %s", dl.Payload)
+	}
+	attempts, _ := d.store.Attempts(ctx, ids[0])
+	if len(attempts) != 1 || attempts[0].Response != "ok" {
+		t.Errorf("Unexpected attempts %+v", attempts)
+	}
+}
+
+func TestRetryAndRedeliver(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	d, queued := newDispatcher(t)
+	ctx := context.Background()
+	_ = d.Subscribe(ctx, &Subscription{URL: srv.URL, Events: []string{"*"}, Active: true})
+	ids, _ := d.Publish(ctx, "ping", nil)
+
+	// Failed attempts return an error so jobs retries them | 失败的尝试返回错误，由 jobs 重试
+	if err := d.handle(ctx, &jobs.Job{Payload: mustJSON(deliverPayload{ids[0]}), Attempt: 1, MaxRetries: 2}); err == nil {
+		t.Fatal("Expected an error for a 502 answer")
+	}
+	dl, _ := d.store.GetDelivery(ctx, ids[0])
+	if dl.Status != StatusRetrying || dl.LastStatus != http.StatusBadGateway {
+		t.Errorf("Expected retrying, got %+v", dl)
+	}
+	_ = d.handle(ctx, &jobs.Job{Payload: mustJSON(deliverPayload{ids[0]}), Attempt: 3, MaxRetries: 2})
+	dl, _ = d.store.GetDelivery(ctx, ids[0])
+	if dl.Status != StatusFailed || dl.Attempts != 2 {
+		t.Errorf("Expected failed after the last attempt, got %+v", dl)
+	}
+
+	if err := d.Redeliver(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	fail.Store(false)
+	if err := d.Redeliver(ctx, ids[0]); err != nil || len(*queued) != 2 {
+		t.Fatalf("Redeliver failed: %v", err)
+	}
+	_ = d.attempt(ctx, ids[0], false)
+	dl, _ = d.store.GetDelivery(ctx, ids[0])
+	if dl.Status != StatusSucceeded || dl.Attempts != 3 {
+		t.Errorf("Expected succeeded on the third attempt, got %+v", dl)
+	}
+}
+
+func TestInactiveSubscription(t *testing.T) {
+	d, _ := newDispatcher(t)
+	ctx := context.Background()
+	sub := &Subscription{URL: "http://127.0.0.1:1", Events: []string{"*"}, Active: true}
+	_ = d.Subscribe(ctx, sub)
+	ids, _ := d.Publish(ctx, "ping", nil)
+	_ = d.store.DeleteSubscription(ctx, sub.ID)
+
+	if err := d.attempt(ctx, ids[0], false); !jobs.IsPermanent(err) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+	if dl, _ := d.store.GetDelivery(ctx, ids[0]); dl.Status != StatusFailed {
+		t.Errorf("Expected failed, got %s", dl.Status)
+	}
+}
+
+func mustJSON(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
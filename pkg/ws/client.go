@@ -30,7 +30,7 @@ import (
 type Client struct {
 	hub    *Hub            // The connection pool this client belongs to | 此客户端所属的连接池
 	UserID int64           // User ID (0 means unauthenticated) | 用户 ID（0 表示未认证）
-	Conn   *websocket.Conn // WebSocket connection | WebSocket 连接
+	Conn   *websocket.Conn // WebSocket connection, nil for long-poll clients | WebSocket 连接，长轮询客户端为 nil
 	send   chan []byte     // Channel for sending messages | 发送消息的通道
 }
 
@@ -68,6 +68,7 @@ func (c *Client) Send(msg *Message) {
 	case c.send <- msg.Bytes():
 	default:
 		// Send queue full, drop message | 发送队列已满，丢弃消息
+		c.hub.dropped.Add(1)
 		log.Printf("ws: client %d send buffer full, message dropped", c.UserID)
 	}
 }
@@ -78,6 +79,7 @@ func (c *Client) SendBytes(data []byte) {
 	select {
 	case c.send <- data:
 	default:
+		c.hub.dropped.Add(1)
 		log.Printf("ws: client %d send buffer full, message dropped", c.UserID)
 	}
 }
@@ -139,10 +141,8 @@ func (c *Client) ReadPump() {
 			continue
 		}
 
-		// Call message handler | 调用消息处理器
-		if c.hub.OnMessage != nil {
-			c.hub.OnMessage(c, msg)
-		}
+		// Route to the handler of msg.Type or OnMessage | 路由到 msg.Type 的处理器或 OnMessage
+		c.hub.dispatch(c, msg, data)
 	}
 }
 
@@ -198,5 +198,10 @@ func (c *Client) WritePump() {
 // Close closes the connection
 // Close 关闭连接
 func (c *Client) Close() {
+	if c.Conn == nil {
+		// Long-poll client, the session ends with the hub registration | 长轮询客户端，会话随 Hub 注销结束
+		c.hub.Unregister(c)
+		return
+	}
 	c.Conn.Close()
 }
@@ -0,0 +1,191 @@
+// Package ws provides WebSocket connection management and message broadcasting
+// Package ws 提供 WebSocket 连接管理和消息广播
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// TypeError is the Type of the reply sent for unknown message types and handler errors
+// TypeError 是未知消息类型和处理器错误的回复消息类型
+const TypeError = "error"
+
+// internalErrorText is the reply text for handler errors that are not safe to show to clients
+// internalErrorText 是不宜展示给客户端的处理器错误的回复文本
+const internalErrorText = "internal error"
+
+// clientError is an error whose message can be sent to clients, such as common/errors.BizError
+// clientError 是消息可以发送给客户端的错误，例如 common/errors.BizError
+type clientError interface {
+	error
+	Localize(lang string) string
+}
+
+// publicError is a fixed message safe to send to clients
+// publicError 是可以发送给客户端的固定消息
+type publicError string
+
+func (e publicError) Error() string            { return string(e) }
+func (e publicError) Localize(_ string) string { return string(e) }
+
+// errInvalidPayload is returned by typed handlers when the payload cannot be decoded
+// errInvalidPayload 在类型化处理器无法解码载荷时返回
+const errInvalidPayload = publicError("invalid payload")
+
+// HandlerFunc handles client messages of one type
+// A returned business error (common/errors.BizError) is sent back to the client as a TypeError
+// message; other errors are logged and answered with a generic message. Panics count as failures.
+// HandlerFunc 处理一种类型的客户端消息
+// 返回的业务错误（common/errors.BizError）会以 TypeError 消息回复给客户端；
+// 其他错误会记录日志并以通用消息回复。panic 计为失败。
+type HandlerFunc func(client *Client, msg *Message) error
+
+// handler is a registered message handler with its counters
+// handler 是已注册的消息处理器及其计数
+type handler struct {
+	fn      func(client *Client, msg *Message, data []byte) error
+	handled atomic.Uint64 // Messages handled without error | 处理成功的消息数
+	failed  atomic.Uint64 // Messages whose handler returned an error | 处理器返回错误的消息数
+}
+
+// MessageStats represents the handler statistics of one message type
+// MessageStats 表示一种消息类型的处理统计
+type MessageStats struct {
+	Handled uint64 // Messages handled without error | 处理成功的消息数
+	Failed  uint64 // Messages whose handler returned an error | 处理器返回错误的消息数
+}
+
+// HandleFunc registers the handler of msgType, replacing any previous one.
+// HandleFunc 注册 msgType 的处理器，覆盖已有的处理器
+//
+// Once a handler is registered, messages of other types go to OnMessage if set,
+// otherwise they are answered with a TypeError message.
+// 注册处理器后，其他类型的消息交给 OnMessage（如已设置），否则以 TypeError 消息回复
+//
+// Example | 示例:
+//
+//	hub.HandleFunc("ping", func(client *ws.Client, msg *ws.Message) error {
+//	    client.Send(&ws.Message{Type: "pong"})
+//	    return nil
+//	})
+func (h *Hub) HandleFunc(msgType string, fn HandlerFunc) {
+	h.handle(msgType, func(client *Client, msg *Message, _ []byte) error {
+		return fn(client, msg)
+	})
+}
+
+// Handle registers a typed handler of msgType on hub, the payload is decoded from JSON into T.
+// Handle 在 hub 上注册 msgType 的类型化处理器，载荷从 JSON 解码为 T
+//
+// A payload that cannot be decoded is answered with a TypeError message without calling fn.
+// 无法解码的载荷会以 TypeError 消息回复，不调用 fn
+//
+// Example | 示例:
+//
+//	type ChatSend struct {
+//	    To   int64  `json:"to"`
+//	    Text string `json:"text"`
+//	}
+//
+//	ws.Handle(hub, "chat.send", func(client *ws.Client, p ChatSend) error {
+//	    return hub.PublishToUser(ctx, p.To, ws.NewMessage(p.To, "chat.message", p.Text))
+//	})
+func Handle[T any](hub *Hub, msgType string, fn func(client *Client, payload T) error) {
+	hub.handle(msgType, func(client *Client, _ *Message, data []byte) error {
+		var msg struct {
+			Payload T `json:"payload"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidPayload, err)
+		}
+		return fn(client, msg.Payload)
+	})
+}
+
+// handle stores the handler of msgType (internal method)
+// handle 保存 msgType 的处理器（内部方法）
+func (h *Hub) handle(msgType string, fn func(client *Client, msg *Message, data []byte) error) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	h.handlers[msgType] = &handler{fn: fn}
+}
+
+// dispatch routes a client message to its handler, falling back to OnMessage (internal method)
+// dispatch 将客户端消息路由到其处理器，未注册时回退到 OnMessage（内部方法）
+func (h *Hub) dispatch(client *Client, msg *Message, data []byte) {
+	h.handlersMu.RLock()
+	hd, ok := h.handlers[msg.Type]
+	registered := len(h.handlers) > 0
+	h.handlersMu.RUnlock()
+
+	if !ok {
+		switch {
+		case h.OnMessage != nil:
+			h.OnMessage(client, msg)
+		case registered:
+			h.unknown.Add(1)
+			client.Send(errorMessage(msg.Type, "unknown message type"))
+		}
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			hd.failed.Add(1)
+			log.Printf("ws: client %d handler %q panic: %v\n%s", client.UserID, msg.Type, r, debug.Stack())
+			client.Send(errorMessage(msg.Type, internalErrorText))
+		}
+	}()
+
+	if err := hd.fn(client, msg, data); err != nil {
+		hd.failed.Add(1)
+		client.Send(errorMessage(msg.Type, replyText(client, msg.Type, err)))
+		return
+	}
+	hd.handled.Add(1)
+}
+
+// replyText returns the text sent to the client for a handler error, logging errors not meant for clients
+// replyText 返回处理器错误回复给客户端的文本，不宜展示的错误会记录日志
+func replyText(client *Client, msgType string, err error) string {
+	var ce clientError
+	if errors.As(err, &ce) {
+		return ce.Localize("")
+	}
+	log.Printf("ws: client %d handler %q error: %v", client.UserID, msgType, err)
+	return internalErrorText
+}
+
+// messageStats returns the statistics of registered handlers (internal method)
+// messageStats 返回已注册处理器的统计（内部方法）
+func (h *Hub) messageStats() map[string]MessageStats {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+
+	if len(h.handlers) == 0 {
+		return nil
+	}
+	stats := make(map[string]MessageStats, len(h.handlers))
+	for msgType, hd := range h.handlers {
+		stats[msgType] = MessageStats{Handled: hd.handled.Load(), Failed: hd.failed.Load()}
+	}
+	return stats
+}
+
+// errorMessage builds the TypeError reply to a message of msgType
+// errorMessage 构造针对 msgType 消息的 TypeError 回复
+func errorMessage(msgType, text string) *Message {
+	return &Message{
+		Type: TypeError,
+		Payload: map[string]any{
+			"type":    msgType,
+			"message": text,
+		},
+	}
+}
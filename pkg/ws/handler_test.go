@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// bizError mimics common/errors.BizError, whose message is meant for clients
+type bizError string
+
+func (e bizError) Error() string               { return "[1001] " + string(e) }
+func (e bizError) Localize(lang string) string { return string(e) }
+
+// testClient returns a client without connection whose sent messages can be read back
+func testClient(hub *Hub) *Client {
+	return &Client{hub: hub, UserID: 1, send: make(chan []byte, 8)}
+}
+
+// received parses the next sent message, nil if none
+func received(t *testing.T, c *Client) *Message {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		msg, err := ParseMessage(data)
+		if err != nil {
+			t.Fatalf("invalid sent message: %v", err)
+		}
+		return msg
+	default:
+		return nil
+	}
+}
+
+func TestHandleTyped(t *testing.T) {
+	hub := NewHub()
+	type chat struct {
+		Text string `json:"text"`
+	}
+	var got string
+	Handle(hub, "chat.send", func(client *Client, p chat) error {
+		got = p.Text
+		return nil
+	})
+
+	client := testClient(hub)
+	data := []byte(`{"type":"chat.send","payload":{"text":"hello"}}`)
+	msg, _ := ParseMessage(data)
+	hub.dispatch(client, msg, data)
+
+	if got != "hello" {
+		t.Errorf("payload text = %q, want hello", got)
+	}
+	if reply := received(t, client); reply != nil {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+	if s := hub.Stats().Messages["chat.send"]; s.Handled != 1 || s.Failed != 0 {
+		t.Errorf("stats = %+v, want 1 handled", s)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	hub := NewHub()
+	Handle(hub, "typed", func(client *Client, p struct{ N int }) error { return nil })
+	hub.HandleFunc("fail", func(client *Client, msg *Message) error { return errors.New("boom") })
+	hub.HandleFunc("biz", func(client *Client, msg *Message) error {
+		return fmt.Errorf("wrapped: %w", bizError("balance too low"))
+	})
+	client := testClient(hub)
+
+	tests := []struct {
+		data string
+		want string
+	}{
+		// Raw errors are not sent to clients | 原始错误不会发送给客户端
+		{`{"type":"fail"}`, internalErrorText},
+		{`{"type":"biz"}`, "balance too low"},
+		{`{"type":"typed","payload":"not an object"}`, "invalid payload"},
+		{`{"type":"missing"}`, "unknown message type"},
+	}
+	for _, tt := range tests {
+		msg, _ := ParseMessage([]byte(tt.data))
+		hub.dispatch(client, msg, []byte(tt.data))
+
+		reply := received(t, client)
+		if reply == nil || reply.Type != TypeError {
+			t.Fatalf("%s: reply = %+v, want %s", tt.data, reply, TypeError)
+		}
+		payload, _ := reply.Payload.(map[string]any)
+		if payload["type"] != msg.Type {
+			t.Errorf("%s: error type = %v, want %s", tt.data, payload["type"], msg.Type)
+		}
+		if payload["message"] != tt.want {
+			t.Errorf("%s: error message = %v, want %s", tt.data, payload["message"], tt.want)
+		}
+	}
+
+	s := hub.Stats()
+	if s.Messages["fail"].Failed != 1 || s.Messages["typed"].Failed != 1 || s.Unknown != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestHandleFallsBackToOnMessage(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("ping", func(client *Client, msg *Message) error { return nil })
+	var got string
+	hub.OnMessage = func(client *Client, msg *Message) { got = msg.Type }
+	client := testClient(hub)
+
+	data := []byte(`{"type":"other"}`)
+	msg, _ := ParseMessage(data)
+	hub.dispatch(client, msg, data)
+
+	if got != "other" {
+		t.Errorf("OnMessage got %q, want other", got)
+	}
+	if reply := received(t, client); reply != nil {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+}
+
+func TestHandlePanic(t *testing.T) {
+	hub := NewHub()
+	hub.HandleFunc("crash", func(client *Client, msg *Message) error { panic("nil map") })
+	client := testClient(hub)
+
+	data := []byte(`{"type":"crash"}`)
+	msg, _ := ParseMessage(data)
+	hub.dispatch(client, msg, data)
+
+	reply := received(t, client)
+	if reply == nil || reply.Type != TypeError {
+		t.Fatalf("reply = %+v, want %s", reply, TypeError)
+	}
+	if payload, _ := reply.Payload.(map[string]any); payload["message"] != internalErrorText {
+		t.Errorf("error message = %v, want %s", payload["message"], internalErrorText)
+	}
+	if s := hub.Stats().Messages["crash"]; s.Failed != 1 || s.Handled != 0 {
+		t.Errorf("stats = %+v, want 1 failed", s)
+	}
+}
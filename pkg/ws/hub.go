@@ -5,6 +5,7 @@ package ws
 import (
 	"log"
 	"sync"
+	"sync/atomic"
 )
 
 // Hub is the WebSocket connection pool.
@@ -18,6 +19,7 @@ import (
 // 2. Maintain userID → Client mapping | 维护 userID → Client 映射
 // 3. Local message broadcasting | 本地消息广播
 // 4. Redis Pub/Sub cluster support (optional) | Redis Pub/Sub 集群支持（可选）
+// 5. Routing client messages by type (HandleFunc / Handle) | 按类型路由客户端消息（HandleFunc / Handle）
 //
 // Usage | 使用方法:
 //
@@ -36,13 +38,22 @@ type Hub struct {
 	broadcast    chan []byte                        // Broadcast channel | 广播通道
 	opts         *Options                           // Configuration options | 配置选项
 	mu           sync.RWMutex                       // Protects clients and userClients | 保护 clients 和 userClients
-	OnMessage    func(client *Client, msg *Message) // Message handler | 消息处理器
+	OnMessage    func(client *Client, msg *Message) // Handler of types without HandleFunc/Handle | 未通过 HandleFunc/Handle 注册的类型的处理器
 	OnConnect    func(client *Client)               // Connection established callback | 连接建立回调
 	OnDisconnect func(client *Client)               // Connection closed callback | 连接关闭回调
 	redis        RedisClient                        // Redis client (cluster mode) | Redis 客户端（集群模式）
 	channel      string                             // Redis channel name (cluster mode) | Redis 频道名称（集群模式）
+	dropped      atomic.Uint64                      // Messages dropped on full send buffers | 因发送缓冲区已满而丢弃的消息数
+	handlers     map[string]*handler                // Handlers by message type | 按消息类型的处理器
+	handlersMu   sync.RWMutex                       // Protects handlers | 保护 handlers
+	unknown      atomic.Uint64                      // Messages of unregistered types | 未注册类型的消息数
 }
 
+var (
+	hubsMu sync.Mutex
+	hubs   []*Hub // Hubs created by NewHub, for AllStats | NewHub 创建的 Hub，供 AllStats 使用
+)
+
 // NewHub creates a Hub.
 // NewHub 创建 Hub
 //
@@ -59,14 +70,20 @@ func NewHub(opts ...Option) *Hub {
 		opt(options)
 	}
 
-	return &Hub{
+	h := &Hub{
 		clients:     make(map[*Client]bool),
 		userClients: make(map[int64]map[*Client]bool),
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
 		broadcast:   make(chan []byte, 256),
 		opts:        options,
+		handlers:    make(map[string]*handler),
 	}
+
+	hubsMu.Lock()
+	hubs = append(hubs, h)
+	hubsMu.Unlock()
+	return h
 }
 
 // Run starts the Hub event loop.
@@ -175,6 +192,7 @@ func (h *Hub) broadcastLocal(message []byte) {
 
 	// Log dropped message count | 记录丢弃的消息数量
 	if dropped > 0 {
+		h.dropped.Add(uint64(dropped))
 		log.Printf("ws: broadcast dropped %d messages (send buffer full)", dropped)
 	}
 }
@@ -315,3 +333,65 @@ func (h *Hub) GetUserClients(userID int64) []*Client {
 	}
 	return result
 }
+
+// Stats represents hub statistics
+// Stats 表示 Hub 统计信息
+type Stats struct {
+	Name    string // Hub name (Options.Name) | Hub 名称（Options.Name）
+	Clients int    // Current connection count | 当前连接数
+	Users   int    // Current online user count | 当前在线用户数
+	Dropped uint64 // Messages dropped on full send buffers | 因发送缓冲区已满而丢弃的消息数
+	Unknown uint64 // Messages of unregistered types answered with an error | 以错误回复的未注册类型消息数
+
+	Messages map[string]MessageStats // Handler statistics by message type | 按消息类型的处理统计
+}
+
+// Stats returns current statistics of the hub
+// Stats 返回 Hub 的当前统计信息
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return Stats{
+		Name:    h.opts.Name,
+		Clients: len(h.clients),
+		Users:   len(h.userClients),
+		Dropped: h.dropped.Load(),
+		Unknown: h.unknown.Load(),
+
+		Messages: h.messageStats(),
+	}
+}
+
+// AllStats returns statistics of all hubs, summed per name
+// AllStats 返回所有 Hub 的统计信息，按名称汇总
+func AllStats() []Stats {
+	hubsMu.Lock()
+	all := append([]*Hub(nil), hubs...)
+	hubsMu.Unlock()
+
+	var stats []Stats
+	index := make(map[string]int)
+	for _, h := range all {
+		s := h.Stats()
+		i, ok := index[s.Name]
+		if !ok {
+			index[s.Name] = len(stats)
+			stats = append(stats, s)
+			continue
+		}
+		stats[i].Clients += s.Clients
+		stats[i].Users += s.Users
+		stats[i].Dropped += s.Dropped
+		stats[i].Unknown += s.Unknown
+		for msgType, m := range s.Messages {
+			if stats[i].Messages == nil {
+				stats[i].Messages = make(map[string]MessageStats)
+			}
+			sum := stats[i].Messages[msgType]
+			sum.Handled += m.Handled
+			sum.Failed += m.Failed
+			stats[i].Messages[msgType] = sum
+		}
+	}
+	return stats
+}
@@ -0,0 +1,252 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LongPoll is the HTTP long-polling transport of a Hub.
+// LongPoll 是 Hub 的 HTTP 长轮询传输
+//
+// Each session registers a virtual Client (Conn is nil) with the hub, so Broadcast,
+// SendToUser, Publish and message handlers treat it like a WebSocket connection.
+// It serves environments where WebSocket upgrades are blocked, e.g. corporate proxies.
+// 每个会话向 Hub 注册一个虚拟 Client（Conn 为 nil），因此 Broadcast、SendToUser、
+// Publish 和消息处理器对其与 WebSocket 连接一视同仁。
+// 用于 WebSocket 升级被拦截的环境，例如企业代理。
+//
+// Protocol (one endpoint) | 协议（单个接口）:
+//
+//	POST   /poll          -> {"sid": "..."}         open a session | 打开会话
+//	GET    /poll?sid=...  -> [message, ...]         wait up to PollTimeout | 最多等待 PollTimeout
+//	POST   /poll?sid=...  <- message                send a client message | 发送客户端消息
+//	DELETE /poll?sid=...                            close the session | 关闭会话
+//
+// Unknown or expired sessions answer 410 Gone, the client then opens a new session.
+// Sessions not polled within ReadTimeout are closed, messages queued in between
+// (up to SendBuffer) are returned by the next poll.
+// 未知或已过期的会话返回 410 Gone，客户端随后打开新会话。
+// 超过 ReadTimeout 未轮询的会话会被关闭，期间排队的消息（最多 SendBuffer 条）由下次轮询返回。
+//
+// Usage | 使用方法:
+//
+//	poll := ws.NewLongPoll(hub)
+//	router.All("/poll", middleware.RequireAuth(), poll.Handler(userIDFromCtx, nil))
+type LongPoll struct {
+	hub      *Hub
+	sessions map[string]*pollSession // Open sessions by ID | 按 ID 索引的打开会话
+	mu       sync.Mutex              // Protects sessions | 保护 sessions
+}
+
+// pollSession is one long-poll session | pollSession 表示一个长轮询会话
+type pollSession struct {
+	id     string
+	client *Client
+	idle   *time.Timer // Closes the session when not polled | 未轮询时关闭会话
+	active int         // Polls in progress, guarded by LongPoll.mu | 进行中的轮询数，由 LongPoll.mu 保护
+}
+
+// NewLongPoll creates the long-poll transport of hub, whose Run loop must be running
+// NewLongPoll 创建 hub 的长轮询传输，hub 的 Run 循环必须已启动
+func NewLongPoll(hub *Hub) *LongPoll {
+	return &LongPoll{
+		hub:      hub,
+		sessions: make(map[string]*pollSession),
+	}
+}
+
+// SessionCount returns current session count
+// SessionCount 返回当前会话数
+func (p *LongPoll) SessionCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// Handler returns the long-poll endpoint.
+// Handler 返回长轮询接口
+//
+// userID returns the user of the request, 0 for anonymous sessions; a session only
+// accepts requests of the user who opened it. onOpen, if not nil, runs after a session
+// is registered, e.g. to send a welcome message.
+// userID 返回请求的用户，0 表示匿名会话；会话只接受打开它的用户的请求。
+// onOpen 不为 nil 时在会话注册后调用，例如发送欢迎消息。
+func (p *LongPoll) Handler(userID func(c *fiber.Ctx) int64, onOpen func(client *Client)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var uid int64
+		if userID != nil {
+			uid = userID(c)
+		}
+
+		sid := c.Query("sid")
+		if sid == "" {
+			if c.Method() != fiber.MethodPost {
+				return fiber.ErrMethodNotAllowed
+			}
+			s := p.open(uid)
+			if onOpen != nil {
+				onOpen(s.client)
+			}
+			return c.JSON(fiber.Map{"sid": s.id})
+		}
+
+		s := p.lookup(sid, uid)
+		if s == nil {
+			return fiber.ErrGone
+		}
+		switch c.Method() {
+		case fiber.MethodGet:
+			return p.poll(c, s)
+		case fiber.MethodPost:
+			return p.receive(c, s)
+		case fiber.MethodDelete:
+			p.close(s)
+			return c.SendStatus(fiber.StatusNoContent)
+		default:
+			return fiber.ErrMethodNotAllowed
+		}
+	}
+}
+
+// open registers a new session of userID | open 注册 userID 的新会话
+func (p *LongPoll) open(userID int64) *pollSession {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	s := &pollSession{
+		id:     hex.EncodeToString(id),
+		client: NewClient(p.hub, userID, nil),
+	}
+	s.idle = time.AfterFunc(p.hub.opts.ReadTimeout, func() { p.expire(s) })
+
+	p.hub.Register(s.client)
+	p.mu.Lock()
+	p.sessions[s.id] = s
+	p.mu.Unlock()
+	return s
+}
+
+// lookup returns the session sid of userID, nil if unknown | lookup 返回 userID 的 sid 会话，不存在时返回 nil
+func (p *LongPoll) lookup(sid string, userID int64) *pollSession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.sessions[sid]
+	if !ok || s.client.UserID != userID {
+		return nil
+	}
+	return s
+}
+
+// poll waits for queued messages and returns them as a JSON array
+// poll 等待排队的消息并以 JSON 数组返回
+func (p *LongPoll) poll(c *fiber.Ctx, s *pollSession) error {
+	p.mu.Lock()
+	s.active++
+	s.idle.Stop()
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		if s.active--; s.active == 0 {
+			s.idle.Reset(p.hub.opts.ReadTimeout)
+		}
+		p.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(p.hub.opts.PollTimeout)
+	defer timer.Stop()
+
+	var messages [][]byte
+	select {
+	case data, ok := <-s.client.send:
+		if !ok {
+			// Unregistered, e.g. by Client.Close | 已被注销，例如通过 Client.Close
+			p.close(s)
+			return fiber.ErrGone
+		}
+		messages = append(messages, data)
+	case <-timer.C:
+	case <-c.Context().Done():
+		return nil
+	}
+
+	// Drain what else is queued without waiting | 不等待地取出其余排队消息
+drain:
+	for {
+		select {
+		case data, ok := <-s.client.send:
+			if !ok {
+				break drain
+			}
+			messages = append(messages, data)
+		default:
+			break drain
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(encodeBatch(messages))
+}
+
+// receive dispatches the client message in the request body | receive 分发请求体中的客户端消息
+func (p *LongPoll) receive(c *fiber.Ctx, s *pollSession) error {
+	data := c.Body()
+	if int64(len(data)) > p.hub.opts.MaxMessageSize {
+		return fiber.ErrRequestEntityTooLarge
+	}
+	msg, err := ParseMessage(data)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid message")
+	}
+	// The body buffer is reused after the handler returns | 处理器返回后请求体缓冲区会被复用
+	p.hub.dispatch(s.client, msg, append([]byte(nil), data...))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// expire closes a session not polled within ReadTimeout | expire 关闭超过 ReadTimeout 未轮询的会话
+func (p *LongPoll) expire(s *pollSession) {
+	p.mu.Lock()
+	_, open := p.sessions[s.id]
+	active := s.active
+	p.mu.Unlock()
+	if !open || active > 0 {
+		return
+	}
+	log.Printf("ws: long-poll session of client %d expired", s.client.UserID)
+	p.close(s)
+}
+
+// close removes the session and unregisters its client | close 移除会话并注销其客户端
+func (p *LongPoll) close(s *pollSession) {
+	p.mu.Lock()
+	_, ok := p.sessions[s.id]
+	delete(p.sessions, s.id)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+	s.idle.Stop()
+	p.hub.Unregister(s.client)
+}
+
+// encodeBatch joins encoded messages into a JSON array, sized up front so it allocates once
+// encodeBatch 将已编码的消息拼接为 JSON 数组，预先计算大小以只分配一次
+func encodeBatch(messages [][]byte) []byte {
+	size := len(messages) + 2 // Brackets and commas, at most one spare | 方括号与逗号，最多多出一个字节
+	for _, data := range messages {
+		size += len(data)
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, '[')
+	for i, data := range messages {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, data...)
+	}
+	return append(buf, ']')
+}
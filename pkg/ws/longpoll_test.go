@@ -0,0 +1,154 @@
+package ws
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nuohe369/crab/pkg/json"
+)
+
+// pollApp returns an app serving the long-poll endpoint of hub for user 7
+func pollApp(poll *LongPoll) *fiber.App {
+	app := fiber.New()
+	app.All("/poll", poll.Handler(func(c *fiber.Ctx) int64 { return 7 }, func(client *Client) {
+		client.Send(&Message{Type: "connected"})
+	}))
+	return app
+}
+
+// do sends a request and returns status and body
+func do(t *testing.T, app *fiber.App, method, target, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	resp, err := app.Test(req, 5000)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+// openSession opens a session and returns its ID
+func openSession(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	status, body := do(t, app, fiber.MethodPost, "/poll", "")
+	var res struct {
+		SID string `json:"sid"`
+	}
+	if status != fiber.StatusOK || json.UnmarshalString(body, &res) != nil || res.SID == "" {
+		t.Fatalf("open failed: %d %s", status, body)
+	}
+	return res.SID
+}
+
+func TestLongPoll(t *testing.T) {
+	hub := NewHub(WithPollTimeout(50 * time.Millisecond))
+	go hub.Run()
+	poll := NewLongPoll(hub)
+	app := pollApp(poll)
+
+	sid := openSession(t, app)
+	waitFor(t, func() bool { return hub.IsUserOnline(7) })
+
+	hub.SendToUser(7, &Message{Type: "notify", Payload: "a"})
+	status, body := do(t, app, fiber.MethodGet, "/poll?sid="+sid, "")
+	var msgs []Message
+	if status != fiber.StatusOK || json.UnmarshalString(body, &msgs) != nil {
+		t.Fatalf("poll failed: %d %s", status, body)
+	}
+	if len(msgs) != 2 || msgs[0].Type != "connected" || msgs[1].Type != "notify" {
+		t.Errorf("Expected the queued messages in order, got %s", body)
+	}
+
+	// Nothing queued: empty list after PollTimeout | 无排队消息：PollTimeout 后返回空列表
+	if _, body := do(t, app, fiber.MethodGet, "/poll?sid="+sid, ""); body != "[]" {
+		t.Errorf("Expected empty list, got %s", body)
+	}
+
+	// A message arriving while polling ends the wait | 轮询期间到达的消息结束等待
+	hub2 := NewHub(WithPollTimeout(5 * time.Second))
+	go hub2.Run()
+	app2 := pollApp(NewLongPoll(hub2))
+	sid2 := openSession(t, app2)
+	waitFor(t, func() bool { return hub2.IsUserOnline(7) })
+	do(t, app2, fiber.MethodGet, "/poll?sid="+sid2, "")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		hub2.SendToUser(7, &Message{Type: "live"})
+	}()
+	start := time.Now()
+	if _, body := do(t, app2, fiber.MethodGet, "/poll?sid="+sid2, ""); !strings.Contains(body, `"live"`) || time.Since(start) > time.Second {
+		t.Errorf("Expected the live message without waiting PollTimeout, got %s", body)
+	}
+
+	if status, _ := do(t, app, fiber.MethodDelete, "/poll?sid="+sid, ""); status != fiber.StatusNoContent {
+		t.Errorf("close status = %d", status)
+	}
+	if status, _ := do(t, app, fiber.MethodGet, "/poll?sid="+sid, ""); status != fiber.StatusGone {
+		t.Errorf("Closed session should answer 410, got %d", status)
+	}
+	waitFor(t, func() bool { return !hub.IsUserOnline(7) })
+}
+
+func TestLongPollReceive(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	got := make(chan string, 1)
+	Handle(hub, "chat.send", func(client *Client, p struct {
+		Text string `json:"text"`
+	}) error {
+		got <- p.Text
+		client.Send(&Message{Type: "chat.ack"})
+		return nil
+	})
+	app := pollApp(NewLongPoll(hub))
+	sid := openSession(t, app)
+
+	status, _ := do(t, app, fiber.MethodPost, "/poll?sid="+sid, `{"type":"chat.send","payload":{"text":"hi"}}`)
+	if status != fiber.StatusNoContent || <-got != "hi" {
+		t.Fatalf("Message not dispatched, status %d", status)
+	}
+	if _, body := do(t, app, fiber.MethodGet, "/poll?sid="+sid, ""); !strings.Contains(body, `"chat.ack"`) {
+		t.Errorf("Expected the handler reply, got %s", body)
+	}
+	if status, _ := do(t, app, fiber.MethodPost, "/poll?sid="+sid, "not json"); status != fiber.StatusBadRequest {
+		t.Errorf("Invalid message status = %d", status)
+	}
+}
+
+func TestLongPollExpire(t *testing.T) {
+	hub := NewHub(WithReadTimeout(30 * time.Millisecond))
+	go hub.Run()
+	poll := NewLongPoll(hub)
+	app := pollApp(poll)
+	sid := openSession(t, app)
+
+	waitFor(t, func() bool { return poll.SessionCount() == 0 && !hub.IsUserOnline(7) })
+	if status, _ := do(t, app, fiber.MethodGet, "/poll?sid="+sid, ""); status != fiber.StatusGone {
+		t.Errorf("Expired session should answer 410, got %d", status)
+	}
+
+	// Another user cannot use the session | 其他用户不能使用该会话
+	sid = openSession(t, app)
+	other := fiber.New()
+	other.All("/poll", poll.Handler(func(c *fiber.Ctx) int64 { return 8 }, nil))
+	if status, _ := do(t, other, fiber.MethodGet, "/poll?sid="+sid, ""); status != fiber.StatusGone {
+		t.Errorf("Session of another user should answer 410, got %d", status)
+	}
+}
+
+// waitFor waits until cond holds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
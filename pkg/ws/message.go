@@ -2,7 +2,7 @@
 // Package ws 提供 WebSocket 连接管理和消息广播
 package ws
 
-import "github.com/bytedance/sonic"
+import "github.com/nuohe369/crab/pkg/json"
 
 // Message represents WebSocket message structure.
 // Message 表示 WebSocket 消息结构
@@ -16,6 +16,10 @@ type Message struct {
 	UserID  int64  `json:"user_id,omitempty"` // Target user ID (0 means broadcast) | 目标用户 ID（0 表示广播）
 	Type    string `json:"type"`              // Message type | 消息类型
 	Payload any    `json:"payload,omitempty"` // Message content | 消息内容
+
+	// Trace carries the publisher's trace context between nodes, cleared before delivery
+	// Trace 在节点间携带发布方的链路上下文，投递前清除
+	Trace map[string]string `json:"trace,omitempty"`
 }
 
 // NewMessage creates a message for specific user
@@ -40,16 +44,28 @@ func NewBroadcast(msgType string, payload any) *Message {
 
 // Bytes serializes message to JSON bytes
 // Bytes 将消息序列化为 JSON 字节
+//
+// The result is owned by the caller, send queues keep it after Bytes returns; use
+// AppendBytes with a pooled buffer when the bytes are only needed briefly.
+// 结果归调用方所有，发送队列会在 Bytes 返回后继续持有；仅短暂使用字节时，请配合池化缓冲区使用 AppendBytes。
 func (m *Message) Bytes() []byte {
-	data, _ := sonic.Marshal(m)
+	data, _ := json.Marshal(m)
 	return data
 }
 
+// AppendBytes appends the JSON encoding of the message to dst, for callers that
+// encode into reusable buffers
+// AppendBytes 将消息的 JSON 编码追加到 dst，供编码到可复用缓冲区的调用方使用
+func (m *Message) AppendBytes(dst []byte) []byte {
+	dst, _ = json.MarshalAppend(dst, m)
+	return dst
+}
+
 // ParseMessage parses message from JSON bytes
 // ParseMessage 从 JSON 字节解析消息
 func ParseMessage(data []byte) (*Message, error) {
 	var msg Message
-	if err := sonic.Unmarshal(data, &msg); err != nil {
+	if err := json.Unmarshal(data, &msg); err != nil {
 		return nil, err
 	}
 	return &msg, nil
@@ -130,3 +130,63 @@ func TestMessageRoundTrip(t *testing.T) {
 		t.Errorf("Payload title mismatch: got %v", payload["title"])
 	}
 }
+
+func TestMessageBytesOwned(t *testing.T) {
+	first := NewMessage(1, "first", nil).Bytes()
+	want := string(first)
+	// Later encodings must not reuse the returned slice | 之后的编码不得复用返回的切片
+	for range 10 {
+		NewMessage(2, "second", "payload").Bytes()
+	}
+	if string(first) != want {
+		t.Errorf("Bytes result changed to %s, want %s", first, want)
+	}
+
+	if got := string(NewMessage(1, "first", nil).AppendBytes([]byte("x"))); got != "x"+want {
+		t.Errorf("AppendBytes = %s, want x%s", got, want)
+	}
+}
+
+func TestEncodeBatch(t *testing.T) {
+	tests := []struct {
+		in   [][]byte
+		want string
+	}{
+		{nil, "[]"},
+		{[][]byte{[]byte(`{"a":1}`)}, `[{"a":1}]`},
+		{[][]byte{[]byte(`1`), []byte(`2`), []byte(`3`)}, `[1,2,3]`},
+	}
+	for _, tt := range tests {
+		if got := string(encodeBatch(tt.in)); got != tt.want {
+			t.Errorf("encodeBatch = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func BenchmarkMessageBytes(b *testing.B) {
+	msg := NewMessage(1, "notify", map[string]any{"title": "hello", "count": 3})
+	b.ReportAllocs()
+	for b.Loop() {
+		msg.Bytes()
+	}
+}
+
+func BenchmarkMessageAppendBytes(b *testing.B) {
+	msg := NewMessage(1, "notify", map[string]any{"title": "hello", "count": 3})
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for b.Loop() {
+		buf = msg.AppendBytes(buf[:0])
+	}
+}
+
+func BenchmarkEncodeBatch(b *testing.B) {
+	messages := make([][]byte, 20)
+	for i := range messages {
+		messages[i] = NewMessage(int64(i), "notify", "payload").Bytes()
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		encodeBatch(messages)
+	}
+}
@@ -9,6 +9,8 @@ const (
 	defaultPingInterval   = 30 * time.Second // Ping interval
 	defaultMaxMessageSize = 512 * 1024       // Max message size 512KB
 	defaultSendBuffer     = 256              // Send buffer size
+	defaultPollTimeout    = 25 * time.Second // Long-poll wait before an empty response
+	defaultName           = "default"        // Hub name in metrics
 )
 
 // Options represents Hub configuration options
@@ -34,6 +36,17 @@ type Options struct {
 	// SendBuffer is the capacity of Client.Send channel.
 	// Default: 256
 	SendBuffer int
+
+	// PollTimeout is how long a long-poll request waits for messages before
+	// returning an empty list. Keep it below proxy idle timeouts.
+	// Long-poll sessions not polled within ReadTimeout are closed.
+	// Default: 25 seconds
+	PollTimeout time.Duration
+
+	// Name identifies the hub in metrics.
+	// Hubs sharing a name are reported together.
+	// Default: "default"
+	Name string
 }
 
 // Option is a function type for configuring Options
@@ -47,6 +60,8 @@ func defaultOptions() *Options {
 		PingInterval:   defaultPingInterval,
 		MaxMessageSize: defaultMaxMessageSize,
 		SendBuffer:     defaultSendBuffer,
+		PollTimeout:    defaultPollTimeout,
+		Name:           defaultName,
 	}
 }
 
@@ -84,3 +99,17 @@ func WithSendBuffer(size int) Option {
 		o.SendBuffer = size
 	}
 }
+
+// WithPollTimeout sets long-poll wait duration
+func WithPollTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollTimeout = d
+	}
+}
+
+// WithName sets the hub name reported in metrics
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
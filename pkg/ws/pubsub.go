@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 
+	"github.com/nuohe369/crab/pkg/json"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -80,7 +81,7 @@ func (h *Hub) subscribeLoop(ctx context.Context, channel string) {
 				log.Printf("ws: invalid message on %s: %v", channel, err)
 				continue
 			}
-			h.DeliverLocal(wsMsg)
+			h.deliver(ctx, wsMsg)
 		}
 	}
 }
@@ -106,11 +107,21 @@ func (h *Hub) subscribeLoop(ctx context.Context, channel string) {
 //	hub.Publish(ctx, ws.NewBroadcast("system", payload))
 func (h *Hub) Publish(ctx context.Context, msg *Message) error {
 	if h.redis == nil {
-		h.DeliverLocal(msg)
+		h.deliver(ctx, msg)
 		return nil
 	}
 
-	return h.redis.Publish(ctx, h.channel, msg.Bytes()).Err()
+	ctx, span := startPublish(ctx, h.channel, msg)
+	out := *msg
+	out.Trace = injectTrace(ctx)
+	// Publish returns once Redis replied, so the payload can live in a pooled buffer
+	// Publish 在 Redis 回复后才返回，因此载荷可以使用池化缓冲区
+	buf := json.GetBuffer()
+	defer json.PutBuffer(buf)
+	*buf = out.AppendBytes(*buf)
+	err := h.redis.Publish(ctx, h.channel, *buf).Err()
+	endSpan(span, err)
+	return err
 }
 
 // PublishToUser publishes message to specific user.
@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ws"
+
+// messageAttrs returns the span attributes describing msg
+// messageAttrs 返回描述 msg 的 span 属性
+func messageAttrs(msg *Message) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("ws.message.type", msg.Type),
+		attribute.Int64("ws.user_id", msg.UserID),
+		attribute.Bool("ws.broadcast", msg.UserID == 0),
+	}
+}
+
+// startPublish starts a producer span for a cluster publish
+// startPublish 为集群发布创建生产者 span
+func startPublish(ctx context.Context, channel string, msg *Message) (context.Context, trace.Span) {
+	attrs := append(messageAttrs(msg), attribute.String("messaging.destination.name", channel))
+	return otel.Tracer(tracerName).Start(ctx, "ws publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...))
+}
+
+// injectTrace returns the trace context of ctx to embed in a published message
+// injectTrace 返回 ctx 的链路上下文，用于嵌入发布的消息
+func injectTrace(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// deliver delivers msg to local connections inside a span
+// The span continues msg.Trace when the message came from another node.
+// deliver 在 span 中将 msg 投递到本地连接
+// 消息来自其他节点时，span 延续 msg.Trace 中的链路。
+func (h *Hub) deliver(ctx context.Context, msg *Message) {
+	kind := trace.SpanKindInternal
+	if msg.Trace != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Trace))
+		msg.Trace = nil
+		kind = trace.SpanKindConsumer
+	}
+	_, span := otel.Tracer(tracerName).Start(ctx, "ws deliver",
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(messageAttrs(msg)...))
+	defer span.End()
+
+	if msg.UserID == 0 {
+		h.Broadcast(msg)
+		span.SetAttributes(attribute.Int("ws.recipients", h.ClientCount()))
+		return
+	}
+	span.SetAttributes(attribute.Bool("ws.user.online", h.SendToUser(msg.UserID, msg)))
+}
+
+// endSpan records err on span and ends it
+// endSpan 在 span 上记录 err 并结束它
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
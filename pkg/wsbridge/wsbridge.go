@@ -0,0 +1,212 @@
+// Package wsbridge forwards mq messages to WebSocket clients.
+// Package wsbridge 将 mq 消息转发给 WebSocket 客户端。
+//
+// A bridge consumes a topic and maps each message to ws messages, delivered with Hub.Publish,
+// so backend events (order status changes, finished exports) reach connected users without a
+// hand-written consumer per module. The topic is consumed once per hub in a shared group; in
+// cluster mode Hub.Publish fans the messages out to every node over Redis.
+// 桥接器消费主题并将每条消息映射为 ws 消息，通过 Hub.Publish 投递，
+// 使后端事件（订单状态变更、导出完成）无需每个模块手写消费者即可到达已连接的用户。
+// 每个 Hub 在共享的消费组中消费一次主题；集群模式下 Hub.Publish 通过 Redis 将消息分发到所有节点。
+//
+// Example | 示例:
+//
+//	// Configured: [[ws_bridge.routes]] topic = "order:status", hub = "user", user_field = "user_id"
+//	// 配置方式：[[ws_bridge.routes]] topic = "order:status", hub = "user", user_field = "user_id"
+//
+//	// In code | 代码方式
+//	wsbridge.Register(hub, "order:status", func(ctx context.Context, msg *mq.Message) ([]*ws.Message, error) {
+//	    var e OrderEvent
+//	    if err := json.Unmarshal(msg.Payload, &e); err != nil {
+//	        return nil, nil // Drop, retrying cannot fix it | 丢弃，重试无法修复
+//	    }
+//	    return []*ws.Message{ws.NewMessage(e.UserID, "order.status", e)}, nil
+//	})
+package wsbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/ws"
+)
+
+// Mapper maps an mq message to ws messages, UserID 0 broadcasts and an empty result drops it.
+// An error is returned to mq, which retries the message.
+// Mapper 将 mq 消息映射为 ws 消息，UserID 为 0 表示广播，结果为空表示丢弃。
+// 返回的错误交给 mq，由其重试消息。
+type Mapper func(ctx context.Context, msg *mq.Message) ([]*ws.Message, error)
+
+// Config represents bridge configuration
+// Config 表示桥接配置
+type Config struct {
+	Group  string  `toml:"group"`  // Consumer group prefix, the hub name is appended, default "ws-bridge" | 消费组前缀，会追加 Hub 名称，默认 "ws-bridge"
+	Routes []Route `toml:"routes"` // Configured topic routes | 已配置的主题路由
+}
+
+// Route forwards a topic to a hub, mapping JSON payloads with JSON
+// Route 将主题转发到 Hub，使用 JSON 映射 JSON 消息体
+type Route struct {
+	Topic     string `toml:"topic"`      // mq topic | mq 主题
+	Hub       string `toml:"hub"`        // Hub name, e.g. "user" or "admin" | Hub 名称，例如 "user" 或 "admin"
+	Type      string `toml:"type"`       // ws message type, default the topic | ws 消息类型，默认为主题
+	UserField string `toml:"user_field"` // Dotted path of the target user ID(s), empty broadcasts | 目标用户 ID 的点分路径，为空表示广播
+	Workers   int    `toml:"workers"`    // Consume loops, default 1 | Consume 循环数，默认 1
+}
+
+// withDefaults fills unset fields | withDefaults 填充未设置的字段
+func (c Config) withDefaults() Config {
+	if c.Group == "" {
+		c.Group = "ws-bridge"
+	}
+	return c
+}
+
+// options configures a bridge | options 配置桥接器
+type options struct {
+	group   string
+	workers int
+}
+
+// Option configures a bridge registered with Register
+// Option 配置通过 Register 注册的桥接器
+type Option func(*options)
+
+// WithGroup sets the consumer group, default "ws-bridge:<hub name>"
+// WithGroup 设置消费组，默认 "ws-bridge:<hub 名称>"
+func WithGroup(group string) Option {
+	return func(o *options) { o.group = group }
+}
+
+// WithWorkers sets how many Consume loops run for the bridge (default 1)
+// WithWorkers 设置桥接器运行的 Consume 循环数（默认 1）
+func WithWorkers(n int) Option {
+	return func(o *options) { o.workers = n }
+}
+
+// Init registers the configured routes on the named hubs, routes without a topic or naming an unknown hub are
+// skipped and reported in the error
+// Init 在指定名称的 Hub 上注册已配置的路由，缺少主题或引用未知 Hub 的路由会被跳过并在错误中报告
+func Init(cfg Config, hubs map[string]*ws.Hub) error {
+	cfg = cfg.withDefaults()
+	var errs []error
+	for _, r := range cfg.Routes {
+		hub := hubs[r.Hub]
+		if hub == nil || r.Topic == "" {
+			errs = append(errs, fmt.Errorf("wsbridge: route %q: missing topic or unknown hub %q", r.Topic, r.Hub))
+			continue
+		}
+		msgType := r.Type
+		if msgType == "" {
+			msgType = r.Topic
+		}
+		Register(hub, r.Topic, JSON(msgType, r.UserField),
+			WithGroup(cfg.Group+":"+hub.Stats().Name), WithWorkers(r.Workers))
+	}
+	return errors.Join(errs...)
+}
+
+// Register forwards topic to hub through fn, consumers start with the other mq consumers
+// Register 通过 fn 将主题转发到 hub，消费者与其他 mq 消费者一同启动
+func Register(hub *ws.Hub, topic string, fn Mapper, opts ...Option) {
+	o := &options{group: "ws-bridge:" + hub.Stats().Name, workers: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	mq.RegisterConsumer(topic, o.group, handler(hub, fn), mq.WithWorkers(o.workers))
+}
+
+// handler publishes the mapped messages of each mq message | handler 发布每条 mq 消息映射出的消息
+func handler(hub *ws.Hub, fn Mapper) mq.Handler {
+	return func(ctx context.Context, msg *mq.Message) error {
+		out, err := fn(ctx, msg)
+		if err != nil {
+			return err
+		}
+		for _, m := range out {
+			if err := hub.Publish(ctx, m); err != nil {
+				return fmt.Errorf("wsbridge: publish %s: %w", m.Type, err)
+			}
+		}
+		return nil
+	}
+}
+
+// JSON maps a JSON payload to a msgType message carrying it unchanged. userField is the dotted
+// path of the target user, a number, numeric string or an array of them; empty broadcasts.
+// Payloads that are not JSON or miss the field are dropped.
+// JSON 将 JSON 消息体映射为原样携带它的 msgType 消息。userField 为目标用户的点分路径，
+// 值为数字、数字字符串或其数组；为空表示广播。非 JSON 或缺少该字段的消息体会被丢弃。
+func JSON(msgType, userField string) Mapper {
+	return func(_ context.Context, msg *mq.Message) ([]*ws.Message, error) {
+		payload := json.RawMessage(msg.Payload)
+		if userField == "" {
+			if !json.Valid(payload) {
+				log.Printf("wsbridge: drop %s message %s: payload is not JSON", msg.Topic, msg.ID)
+				return nil, nil
+			}
+			return []*ws.Message{ws.NewBroadcast(msgType, payload)}, nil
+		}
+
+		users, err := userIDs(payload, userField)
+		if err != nil {
+			log.Printf("wsbridge: drop %s message %s: %v", msg.Topic, msg.ID, err)
+			return nil, nil
+		}
+		out := make([]*ws.Message, len(users))
+		for i, id := range users {
+			out[i] = ws.NewMessage(id, msgType, payload)
+		}
+		return out, nil
+	}
+}
+
+// userIDs reads the user IDs at the dotted path of a JSON object
+// userIDs 读取 JSON 对象中点分路径处的用户 ID
+func userIDs(payload []byte, path string) ([]int64, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber() // Snowflake IDs exceed float64 precision | 雪花 ID 超出 float64 精度
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("field %s not found", path)
+		}
+		if v, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("field %s not found", path)
+		}
+	}
+
+	values, ok := v.([]any)
+	if !ok {
+		values = []any{v}
+	}
+	ids := make([]int64, 0, len(values))
+	for _, value := range values {
+		var s string
+		switch x := value.(type) {
+		case json.Number:
+			s = x.String()
+		case string:
+			s = x
+		default:
+			return nil, fmt.Errorf("field %s is not a user ID", path)
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("field %s is not a user ID", path)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
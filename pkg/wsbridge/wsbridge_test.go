@@ -0,0 +1,65 @@
+package wsbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nuohe369/crab/pkg/mq"
+	"github.com/nuohe369/crab/pkg/ws"
+)
+
+func TestJSON(t *testing.T) {
+	ctx := context.Background()
+	msg := func(payload string) *mq.Message {
+		return &mq.Message{ID: "1", Topic: "order:status", Payload: []byte(payload)}
+	}
+
+	out, err := JSON("order.status", "order.user_id")(ctx, msg(`{"order":{"user_id":"1900000000000000001"},"status":"paid"}`))
+	if err != nil || len(out) != 1 || out[0].UserID != 1900000000000000001 || out[0].Type != "order.status" {
+		t.Fatalf("JSON = %+v, %v", out, err)
+	}
+	if raw, _ := out[0].Payload.(json.RawMessage); string(raw) != `{"order":{"user_id":"1900000000000000001"},"status":"paid"}` {
+		t.Errorf("Payload = %s, should be forwarded unchanged", raw)
+	}
+
+	out, _ = JSON("chat", "members")(ctx, msg(`{"members":[1,2,3]}`))
+	if len(out) != 3 || out[2].UserID != 3 {
+		t.Errorf("An array should fan out to each user, got %+v", out)
+	}
+
+	out, _ = JSON("notice", "")(ctx, msg(`{"text":"hi"}`))
+	if len(out) != 1 || out[0].UserID != 0 {
+		t.Errorf("An empty field should broadcast, got %+v", out)
+	}
+
+	for _, payload := range []string{`not json`, `{"user":1}`, `{"user_id":true}`, `{"user_id":-1}`} {
+		if out, err := JSON("x", "user_id")(ctx, msg(payload)); out != nil || err != nil {
+			t.Errorf("%s should be dropped, got %+v, %v", payload, out, err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	hub := ws.NewHub()
+	go hub.Run()
+
+	fail := errors.New("mapping failed")
+	h := handler(hub, func(context.Context, *mq.Message) ([]*ws.Message, error) { return nil, fail })
+	if err := h(context.Background(), &mq.Message{}); !errors.Is(err, fail) {
+		t.Errorf("Mapper errors should reach mq for a retry, got %v", err)
+	}
+
+	h = handler(hub, JSON("notice", ""))
+	if err := h(context.Background(), &mq.Message{Payload: []byte(`{}`)}); err != nil {
+		t.Errorf("Standalone hubs deliver locally, got %v", err)
+	}
+}
+
+func TestInitUnknownHub(t *testing.T) {
+	err := Init(Config{Routes: []Route{{Topic: "order:status", Hub: "shop"}}}, map[string]*ws.Hub{})
+	if err == nil {
+		t.Error("A route naming an unknown hub should fail")
+	}
+}